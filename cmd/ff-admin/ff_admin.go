@@ -0,0 +1,136 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/wsclient"
+	"github.com/spf13/cobra"
+)
+
+// options are the connection settings shared by all commands
+type options struct {
+	url        string
+	adminURL   string
+	namespace  string
+	username   string
+	password   string
+	token      string
+	jsonOutput bool
+	verbose    bool
+}
+
+func newRootCommand() *cobra.Command {
+	o := &options{}
+	rootCmd := &cobra.Command{
+		Use:   "ff-admin",
+		Short: "ff-admin manages the subscriptions and offsets of a FireFly node, and tails its events",
+		Long: `ff-admin is a command line tool for operators of a FireFly node. It calls the API and admin API
+of the node to manage subscriptions, inspect and rewind the aggregator offsets, and tail events
+and change events over websockets.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if o.verbose {
+				log.SetLevel("debug")
+			} else {
+				log.SetLevel("warn")
+			}
+		},
+	}
+	flags := rootCmd.PersistentFlags()
+	flags.StringVarP(&o.url, "url", "u", "http://localhost:5000", "URL of the FireFly API")
+	flags.StringVar(&o.adminURL, "admin-url", "http://localhost:5001", "URL of the FireFly admin API")
+	flags.StringVarP(&o.namespace, "namespace", "n", "default", "namespace")
+	flags.StringVar(&o.username, "username", "", "username for basic auth")
+	flags.StringVar(&o.password, "password", "", "password for basic auth")
+	flags.StringVar(&o.token, "token", "", "bearer token for auth")
+	flags.BoolVar(&o.jsonOutput, "json", false, "print the full JSON returned by FireFly, instead of a table")
+	flags.BoolVarP(&o.verbose, "verbose", "v", false, "log the requests made to FireFly")
+
+	rootCmd.AddCommand(newSubscriptionsCommand(o))
+	rootCmd.AddCommand(newOffsetsCommand(o))
+	rootCmd.AddCommand(newEventsCommand(o))
+	rootCmd.AddCommand(newChangesCommand(o))
+	return rootCmd
+}
+
+func (o *options) newClient(baseURL string) *resty.Client {
+	client := resty.New().SetHostURL(strings.TrimSuffix(baseURL, "/"))
+	if o.username != "" {
+		client.SetBasicAuth(o.username, o.password)
+	}
+	if o.token != "" {
+		client.SetAuthToken(o.token)
+	}
+	return client
+}
+
+// call makes a request to the API, or the admin API, unmarshalling the response into result
+func (o *options) call(ctx context.Context, admin bool, method, path string, body, result interface{}) error {
+	baseURL := o.url + "/api/v1"
+	if admin {
+		baseURL = o.adminURL + "/admin/api/v1"
+	}
+	req := o.newClient(baseURL).R().SetContext(ctx)
+	if body != nil {
+		req.SetBody(body)
+	}
+	if result != nil {
+		req.SetResult(result)
+	}
+	log.L(ctx).Debugf("--> %s %s%s", method, baseURL, path)
+	res, err := req.Execute(method, path)
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgAdminCLIRequestFailed)
+	}
+	log.L(ctx).Debugf("<-- %s %s%s [%d]", method, baseURL, path, res.StatusCode())
+	return nil
+}
+
+func (o *options) nsPath(format string, args ...interface{}) string {
+	return fmt.Sprintf("/namespaces/%s", o.namespace) + fmt.Sprintf(format, args...)
+}
+
+func (o *options) wsConfig(baseURL, path, query string) *wsclient.WSConfig {
+	config := &wsclient.WSConfig{
+		HTTPURL:      strings.TrimSuffix(baseURL, "/") + "?" + query,
+		WSKeyPath:    path,
+		AuthUsername: o.username,
+		AuthPassword: o.password,
+	}
+	if o.token != "" {
+		config.HTTPHeaders = fftypes.JSONObject{"Authorization": "Bearer " + o.token}
+	}
+	return config
+}
+
+func printJSON(out io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
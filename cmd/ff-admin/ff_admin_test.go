@@ -0,0 +1,80 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, func(args ...string) (string, error)) {
+	svr := httptest.NewServer(handler)
+	t.Cleanup(svr.Close)
+	run := func(args ...string) (string, error) {
+		cmd := newRootCommand()
+		out := &bytes.Buffer{}
+		cmd.SetOut(out)
+		cmd.SetArgs(append([]string{"--url", svr.URL, "--admin-url", svr.URL}, args...))
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+	return svr, run
+}
+
+func TestBasicAuth(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		username, password, ok := req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user1", username)
+		assert.Equal(t, "pass1", password)
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`[]`))
+	})
+	_, err := run("subscriptions", "list", "--username", "user1", "--password", "pass1")
+	assert.NoError(t, err)
+}
+
+func TestTokenAuth(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "Bearer token1", req.Header.Get("Authorization"))
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`[]`))
+	})
+	_, err := run("subscriptions", "list", "--token", "token1", "-v")
+	assert.NoError(t, err)
+}
+
+func TestRequestFailed(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(403)
+		res.Write([]byte(`{"error":"FF10493: Forbidden"}`))
+	})
+	_, err := run("subscriptions", "list")
+	assert.Regexp(t, "FF10499.*FF10493", err)
+}
+
+func TestRequestConnectFailed(t *testing.T) {
+	svr, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {})
+	svr.Close()
+	_, err := run("offsets", "get")
+	assert.Regexp(t, "FF10499", err)
+}
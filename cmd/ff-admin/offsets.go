@@ -0,0 +1,85 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/spf13/cobra"
+)
+
+func newOffsetsCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "offsets",
+		Aliases: []string{"offset"},
+		Short:   "Inspect and rewind the offset of the aggregator",
+		Long: `Inspect and rewind the checkpoint of the aggregator, which is the local sequence of the last
+pin that was aggregated for a ledger. Rewinding causes all pins from the sequence onwards to be re-aggregated.`,
+	}
+	cmd.AddCommand(newOffsetsGetCommand(o))
+	cmd.AddCommand(newOffsetsRewindCommand(o))
+	return cmd
+}
+
+func newOffsetsGetCommand(o *options) *cobra.Command {
+	var ledger string
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Show the checkpoint of the aggregator",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "/aggregator/checkpoint"
+			if ledger != "" {
+				path += "?ledger=" + url.QueryEscape(ledger)
+			}
+			var offset fftypes.Offset
+			if err := o.call(cmd.Context(), true, http.MethodGet, path, nil, &offset); err != nil {
+				return err
+			}
+			if o.jsonOutput {
+				return printJSON(cmd.OutOrStdout(), &offset)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %d\n", offset.Name, offset.Current)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&ledger, "ledger", "", "name of the ledger, if not the default ledger")
+	return cmd
+}
+
+func newOffsetsRewindCommand(o *options) *cobra.Command {
+	rewind := &fftypes.AggregatorRewind{}
+	cmd := &cobra.Command{
+		Use:   "rewind",
+		Short: "Rewind the checkpoint of the aggregator to a sequence",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.call(cmd.Context(), true, http.MethodPost, "/aggregator/rewind", rewind, nil); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Rewound aggregator to sequence %d\n", rewind.Sequence)
+			return nil
+		},
+	}
+	cmd.Flags().Int64Var(&rewind.Sequence, "sequence", 0, "local sequence of the first pin to re-aggregate")
+	cmd.Flags().StringVar(&rewind.Ledger, "ledger", "", "name of the ledger, if not the default ledger")
+	_ = cmd.MarkFlagRequired("sequence")
+	return cmd
+}
@@ -0,0 +1,73 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetsGet(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/admin/api/v1/aggregator/checkpoint", req.URL.Path)
+		assert.Equal(t, "ledger2", req.URL.Query().Get("ledger"))
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(&fftypes.Offset{Type: fftypes.OffsetTypeAggregator, Name: "ff_aggregator_batchpin_ledger2", Current: 12345})
+	})
+
+	out, err := run("offsets", "get", "--ledger", "ledger2")
+	assert.NoError(t, err)
+	assert.Equal(t, "ff_aggregator_batchpin_ledger2 12345\n", out)
+
+	out, err = run("offsets", "get", "--ledger", "ledger2", "--json")
+	assert.NoError(t, err)
+	assert.Regexp(t, `"current": 12345`, out)
+}
+
+func TestOffsetsRewind(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/admin/api/v1/aggregator/rewind", req.URL.Path)
+		var rewind fftypes.AggregatorRewind
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&rewind))
+		assert.Equal(t, int64(100), rewind.Sequence)
+		assert.Equal(t, "", rewind.Ledger)
+		res.WriteHeader(204)
+	})
+
+	out, err := run("offsets", "rewind", "--sequence", "100")
+	assert.NoError(t, err)
+	assert.Equal(t, "Rewound aggregator to sequence 100\n", out)
+}
+
+func TestOffsetsRewindFail(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(500)
+	})
+	_, err := run("offsets", "rewind", "--sequence", "100")
+	assert.Regexp(t, "FF10499", err)
+}
+
+func TestOffsetsRewindMissingSequence(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {})
+	_, err := run("offsets", "rewind")
+	assert.Regexp(t, "sequence", err)
+}
@@ -0,0 +1,145 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"text/tabwriter"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/spf13/cobra"
+)
+
+func newSubscriptionsCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "subscriptions",
+		Aliases: []string{"subscription", "subs", "sub"},
+		Short:   "List, create and delete the subscriptions of a namespace",
+	}
+	cmd.AddCommand(newSubscriptionsListCommand(o))
+	cmd.AddCommand(newSubscriptionsCreateCommand(o))
+	cmd.AddCommand(newSubscriptionsDeleteCommand(o))
+	return cmd
+}
+
+func newSubscriptionsListCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the subscriptions of the namespace",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var subs []*fftypes.Subscription
+			if err := o.call(cmd.Context(), false, http.MethodGet, o.nsPath("/subscriptions"), nil, &subs); err != nil {
+				return err
+			}
+			if o.jsonOutput {
+				return printJSON(cmd.OutOrStdout(), subs)
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tNAME\tTRANSPORT\tEVENTS\tTOPICS\tCREATED")
+			for _, sub := range subs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", sub.ID, sub.Name, sub.Transport, sub.Filter.Events, sub.Filter.Topics, sub.Created)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func newSubscriptionsCreateCommand(o *options) *cobra.Command {
+	var file, firstEvent string
+	var withData bool
+	sub := &fftypes.Subscription{}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a subscription, from flags or from a JSON file",
+		Long: `Create a subscription. The subscription can be described with flags, or in full as a JSON
+document in the same format as the API, with --file. Flags that are set override the fields of the file.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input := &fftypes.Subscription{}
+			if file != "" {
+				b, err := ioutil.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				if err := json.Unmarshal(b, input); err != nil {
+					return err
+				}
+			}
+			flags := cmd.Flags()
+			overrides := map[string]struct{ from, to *string }{
+				"name":      {&sub.Name, &input.Name},
+				"transport": {&sub.Transport, &input.Transport},
+				"events":    {&sub.Filter.Events, &input.Filter.Events},
+				"topics":    {&sub.Filter.Topics, &input.Filter.Topics},
+				"tag":       {&sub.Filter.Tag, &input.Filter.Tag},
+				"group":     {&sub.Filter.Group, &input.Filter.Group},
+				"author":    {&sub.Filter.Author, &input.Filter.Author},
+			}
+			for name, field := range overrides {
+				if flags.Changed(name) || *field.to == "" {
+					*field.to = *field.from
+				}
+			}
+			if flags.Changed("first-event") {
+				fe := fftypes.SubOptsFirstEvent(firstEvent)
+				input.Options.FirstEvent = &fe
+			}
+			if flags.Changed("with-data") {
+				input.Options.WithData = &withData
+			}
+
+			var created fftypes.Subscription
+			if err := o.call(cmd.Context(), false, http.MethodPost, o.nsPath("/subscriptions"), input, &created); err != nil {
+				return err
+			}
+			return printJSON(cmd.OutOrStdout(), &created)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&file, "file", "f", "", "JSON file describing the subscription")
+	flags.StringVar(&sub.Name, "name", "", "name of the subscription")
+	flags.StringVar(&sub.Transport, "transport", "websockets", "transport the events are delivered over")
+	flags.StringVar(&sub.Filter.Events, "events", "", "regular expression matching the event types to deliver")
+	flags.StringVar(&sub.Filter.Topics, "topics", "", "regular expression matching the topics to deliver")
+	flags.StringVar(&sub.Filter.Tag, "tag", "", "regular expression matching the message tags to deliver")
+	flags.StringVar(&sub.Filter.Group, "group", "", "regular expression matching the message groups to deliver")
+	flags.StringVar(&sub.Filter.Author, "author", "", "regular expression matching the message authors to deliver")
+	flags.StringVar(&firstEvent, "first-event", "", "first event to deliver: oldest, newest, or a sequence number")
+	flags.BoolVar(&withData, "with-data", false, "include the data of messages in the events delivered")
+	return cmd
+}
+
+func newSubscriptionsDeleteCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <id>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a subscription",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.call(cmd.Context(), false, http.MethodDelete, o.nsPath("/subscriptions/%s", args[0]), nil, nil); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted subscription %s\n", args[0])
+			return nil
+		},
+	}
+}
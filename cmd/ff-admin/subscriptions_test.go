@@ -0,0 +1,137 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionsList(t *testing.T) {
+	subID := fftypes.NewUUID()
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, "/api/v1/namespaces/ns1/subscriptions", req.URL.Path)
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode([]*fftypes.Subscription{{
+			SubscriptionRef: fftypes.SubscriptionRef{ID: subID, Namespace: "ns1", Name: "sub1"},
+			Transport:       "websockets",
+			Filter:          fftypes.SubscriptionFilter{Events: "message_confirmed"},
+		}})
+	})
+
+	out, err := run("subscriptions", "list", "-n", "ns1")
+	assert.NoError(t, err)
+	assert.Regexp(t, "^ID +NAME +TRANSPORT +EVENTS", out)
+	assert.Regexp(t, subID.String()+" +sub1 +websockets +message_confirmed", out)
+
+	out, err = run("subs", "ls", "-n", "ns1", "--json")
+	assert.NoError(t, err)
+	var subs []*fftypes.Subscription
+	assert.NoError(t, json.Unmarshal([]byte(out), &subs))
+	assert.Equal(t, "sub1", subs[0].Name)
+}
+
+func TestSubscriptionsCreateFromFlags(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/api/v1/namespaces/default/subscriptions", req.URL.Path)
+		var sub fftypes.Subscription
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&sub))
+		assert.Equal(t, "sub1", sub.Name)
+		assert.Equal(t, "websockets", sub.Transport)
+		assert.Equal(t, "topic1", sub.Filter.Topics)
+		assert.Equal(t, fftypes.SubOptsFirstEventOldest, *sub.Options.FirstEvent)
+		assert.True(t, *sub.Options.WithData)
+		sub.ID = fftypes.NewUUID()
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(201)
+		json.NewEncoder(res).Encode(&sub)
+	})
+
+	out, err := run("subscriptions", "create", "--name", "sub1", "--topics", "topic1", "--first-event", "oldest", "--with-data")
+	assert.NoError(t, err)
+	assert.Regexp(t, `"name": "sub1"`, out)
+}
+
+func TestSubscriptionsCreateFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sub.json")
+	err := ioutil.WriteFile(file, []byte(`{"name":"sub1","transport":"webhooks","filter":{"events":"message_confirmed"},"options":{"url":"http://example.com"}}`), 0644)
+	assert.NoError(t, err)
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		var sub fftypes.JSONObject
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&sub))
+		assert.Equal(t, "sub2", sub.GetString("name"))
+		assert.Equal(t, "webhooks", sub.GetString("transport"))
+		assert.Equal(t, "message_confirmed", sub.GetObject("filter").GetString("events"))
+		assert.Equal(t, "http://example.com", sub.GetObject("options").GetString("url"))
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`{}`))
+	})
+
+	_, err = run("subscriptions", "create", "-f", file, "--name", "sub2")
+	assert.NoError(t, err)
+}
+
+func TestSubscriptionsCreateBadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sub.json")
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {})
+
+	_, err := run("subscriptions", "create", "-f", file)
+	assert.True(t, os.IsNotExist(err))
+
+	err = ioutil.WriteFile(file, []byte(`!json`), 0644)
+	assert.NoError(t, err)
+	_, err = run("subscriptions", "create", "-f", file)
+	assert.Error(t, err)
+}
+
+func TestSubscriptionsDelete(t *testing.T) {
+	subID := fftypes.NewUUID()
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodDelete, req.Method)
+		assert.Equal(t, "/api/v1/namespaces/default/subscriptions/"+subID.String(), req.URL.Path)
+		res.WriteHeader(204)
+	})
+
+	out, err := run("subscriptions", "delete", subID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Deleted subscription "+subID.String()+"\n", out)
+}
+
+func TestSubscriptionsCreateFail(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(500)
+	})
+	_, err := run("subscriptions", "create", "--name", "sub1")
+	assert.Regexp(t, "FF10499", err)
+}
+
+func TestSubscriptionsDeleteFail(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(404)
+	})
+	_, err := run("subscriptions", "delete", fftypes.NewUUID().String())
+	assert.Regexp(t, "FF10499", err)
+}
@@ -0,0 +1,157 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/wsclient"
+	"github.com/spf13/cobra"
+)
+
+func newEventsCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "events",
+		Aliases: []string{"event"},
+		Short:   "Tail the events of a namespace",
+	}
+	cmd.AddCommand(newEventsTailCommand(o))
+	return cmd
+}
+
+func newEventsTailCommand(o *options) *cobra.Command {
+	var subscription string
+	var count int
+	filter := &fftypes.SubscriptionFilter{}
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Print the events of the namespace as they are delivered over a websocket",
+		Long: `Print the events of the namespace as they are delivered, one JSON document per line. By default an
+ephemeral subscription is started, which delivers only new events. Use --subscription to receive the events of
+an existing subscription with the websockets transport, which acknowledges each event as it is printed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			query.Set("namespace", o.namespace)
+			query.Set("autoack", "true")
+			if subscription != "" {
+				query.Set("name", subscription)
+			} else {
+				query.Set("ephemeral", "true")
+				for name, value := range map[string]string{
+					"filter.events": filter.Events,
+					"filter.topics": filter.Topics,
+					"filter.tag":    filter.Tag,
+				} {
+					if value != "" {
+						query.Set(name, value)
+					}
+				}
+			}
+			return o.tail(cmd, o.wsConfig(o.url, "/ws", query.Encode()), count)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&subscription, "subscription", "s", "", "name of an existing subscription to receive the events of")
+	flags.StringVar(&filter.Events, "events", "", "regular expression matching the event types to print (ephemeral only)")
+	flags.StringVar(&filter.Topics, "topics", "", "regular expression matching the topics to print (ephemeral only)")
+	flags.StringVar(&filter.Tag, "tag", "", "regular expression matching the message tags to print (ephemeral only)")
+	flags.IntVar(&count, "count", 0, "exit after printing this many events (0 to run until interrupted)")
+	return cmd
+}
+
+func newChangesCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "changes",
+		Aliases: []string{"change"},
+		Short:   "Tail the change events of the node",
+	}
+	cmd.AddCommand(newChangesTailCommand(o))
+	return cmd
+}
+
+func newChangesTailCommand(o *options) *cobra.Command {
+	var collections, types []string
+	var count int
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Print the change events of the node as they happen, from the admin websocket",
+		Long: `Print the change events of the node as they happen, one JSON document per line, from the admin
+websocket. Changes in all namespaces are printed, unless --namespace is set.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if len(collections) > 0 {
+				query.Set("collections", strings.Join(collections, ","))
+			}
+			if len(types) > 0 {
+				query.Set("types", strings.Join(types, ","))
+			}
+			if cmd.Flag("namespace").Changed {
+				query.Set("namespaces", o.namespace)
+			}
+			return o.tail(cmd, o.wsConfig(o.adminURL, "/admin/ws", query.Encode()), count)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringSliceVar(&collections, "collections", nil, "collections to print the changes of, such as subscriptions,namespaces,operations")
+	flags.StringSliceVar(&types, "types", nil, "types of change to print: created, updated or deleted")
+	flags.IntVar(&count, "count", 0, "exit after printing this many changes (0 to run until interrupted)")
+	return cmd
+}
+
+// tail prints each message received on the websocket on its own line, reconnecting if the connection
+// is lost, until count messages have been printed or the command is interrupted
+func (o *options) tail(cmd *cobra.Command, config *wsclient.WSConfig, count int) error {
+	ctx, cancelCtx := context.WithCancel(cmd.Context())
+	defer cancelCtx()
+	config.InitialDelay = 250 * time.Millisecond
+	config.MaximumDelay = 10 * time.Second
+	wsc, err := wsclient.New(ctx, config, nil, nil)
+	if err == nil {
+		err = wsc.Connect()
+	}
+	if err != nil {
+		return err
+	}
+	// The client closes its receive channel once it has been closed, so the loop below ends when interrupted
+	go func() {
+		<-ctx.Done()
+		wsc.Close()
+	}()
+
+	printed := 0
+	for msg := range wsc.Receive() {
+		var protocolErr fftypes.WSProtocolErrorPayload
+		if json.Unmarshal(msg, &protocolErr) == nil && protocolErr.Type == fftypes.WSProtocolErrorEventType {
+			return i18n.NewError(ctx, i18n.MsgAdminCLIProtocolError, protocolErr.Error)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(string(msg)))
+		printed++
+		if count > 0 && printed >= count {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWebSocketServer(t *testing.T, check func(req *http.Request), messages ...string) (*httptest.Server, func(args ...string) (string, error)) {
+	upgrader := &websocket.Upgrader{}
+	return newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		check(req)
+		wsConn, err := upgrader.Upgrade(res, req, nil)
+		assert.NoError(t, err)
+		defer wsConn.Close()
+		for _, msg := range messages {
+			err = wsConn.WriteMessage(websocket.TextMessage, []byte(msg))
+			assert.NoError(t, err)
+		}
+		// Wait for the client to close
+		_, _, _ = wsConn.ReadMessage()
+	})
+}
+
+func TestEventsTailEphemeral(t *testing.T) {
+	_, run := newTestWebSocketServer(t, func(req *http.Request) {
+		assert.Equal(t, "/ws", req.URL.Path)
+		query := req.URL.Query()
+		assert.Equal(t, "ns1", query.Get("namespace"))
+		assert.Equal(t, "true", query.Get("ephemeral"))
+		assert.Equal(t, "true", query.Get("autoack"))
+		assert.Equal(t, "message_.*", query.Get("filter.events"))
+		assert.Equal(t, "topic1", query.Get("filter.topics"))
+		assert.NotContains(t, query, "filter.tag")
+	}, `{"id":"event1"}`, `{"id":"event2"}`, `{"id":"event3"}`)
+
+	out, err := run("events", "tail", "-n", "ns1", "--events", "message_.*", "--topics", "topic1", "--count", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"id\":\"event1\"}\n{\"id\":\"event2\"}\n", out)
+}
+
+func TestEventsTailSubscription(t *testing.T) {
+	_, run := newTestWebSocketServer(t, func(req *http.Request) {
+		query := req.URL.Query()
+		assert.Equal(t, "sub1", query.Get("name"))
+		assert.NotContains(t, query, "ephemeral")
+	}, `{"type":"protocol_error","error":"FF10178: bad start"}`)
+
+	_, err := run("events", "tail", "-s", "sub1")
+	assert.Regexp(t, "FF10500.*FF10178", err)
+}
+
+func TestEventsTailTokenAuth(t *testing.T) {
+	_, run := newTestWebSocketServer(t, func(req *http.Request) {
+		assert.Equal(t, "Bearer token1", req.Header.Get("Authorization"))
+	}, `{"id":"event1"}`)
+
+	out, err := run("events", "tail", "--token", "token1", "--count", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"id\":\"event1\"}\n", out)
+}
+
+func TestEventsTailConnectFail(t *testing.T) {
+	_, run := newTestServer(t, func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(401)
+	})
+	_, err := run("events", "tail")
+	assert.Regexp(t, "FF10161", err)
+}
+
+func TestChangesTail(t *testing.T) {
+	_, run := newTestWebSocketServer(t, func(req *http.Request) {
+		assert.Equal(t, "/admin/ws", req.URL.Path)
+		query := req.URL.Query()
+		assert.Equal(t, "subscriptions,operations", query.Get("collections"))
+		assert.Equal(t, "created", query.Get("types"))
+		assert.NotContains(t, query, "namespaces")
+	}, `{"type":"change_notification","change":{"collection":"operations"}}`)
+
+	out, err := run("changes", "tail", "--collections", "subscriptions", "--collections", "operations", "--types", "created", "--count", "1")
+	assert.NoError(t, err)
+	assert.Regexp(t, `"collection":"operations"`, out)
+}
+
+func TestChangesTailCancelled(t *testing.T) {
+	svr, _ := newTestWebSocketServer(t, func(req *http.Request) {
+		assert.Equal(t, "ns1", req.URL.Query().Get("namespaces"))
+	})
+
+	// The tail runs until the context is cancelled, as happens when the command is interrupted
+	cmd := newRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--admin-url", svr.URL, "changes", "tail", "-n", "ns1"})
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelCtx()
+	err := cmd.ExecuteContext(ctx)
+	assert.NoError(t, err)
+}
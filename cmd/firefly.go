@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
@@ -31,6 +32,9 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/internal/secrets"
+	"github.com/hyperledger/firefly/internal/tracing"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -56,6 +60,7 @@ var showConfigCommand = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		// Initialize config of all plugins
 		getOrchestrator()
+		secrets.InitConfig()
 		_ = config.ReadConfig(cfgFile)
 
 		// Print it all out
@@ -67,10 +72,16 @@ var showConfigCommand = &cobra.Command{
 
 var cfgFile string
 
+var migrateDryRun bool
+
+var validateConfig bool
+
 var _utOrchestrator orchestrator.Orchestrator
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "f", "", "config file")
+	rootCmd.Flags().BoolVar(&migrateDryRun, "migrate-dry-run", false, "print the database migrations that are pending, then exit without applying them")
+	rootCmd.Flags().BoolVar(&validateConfig, "validate-config", false, "initialize each plugin and check the connectivity of its runtime, then print a report and exit with an error if the config is invalid")
 	rootCmd.AddCommand(showConfigCommand)
 }
 
@@ -91,6 +102,7 @@ func run() error {
 
 	// Read the configuration
 	config.Reset()
+	secrets.InitConfig()
 	err := config.ReadConfig(cfgFile)
 
 	// Setup logging after reading config (even if failed), to output header correctly
@@ -98,15 +110,40 @@ func run() error {
 	ctx = log.WithLogger(ctx, logrus.WithField("pid", os.Getpid()))
 
 	config.SetupLogging(ctx)
+	config.OnReload(ctx, config.ApplyLogSettings, config.LogLevel, config.LogModules, config.LogJSONEnabled,
+		config.LogNoColor, config.LogForceColor, config.LogTimeFormat, config.LogUTC)
 	log.L(ctx).Infof("Project Firefly")
 	log.L(ctx).Infof("© Copyright 2021 Kaleido, Inc.")
 
+	if validateConfig {
+		defer cancelCtx()
+		return printConfigValidation(ctx, err)
+	}
+
 	// Deferred error return from reading config
 	if err != nil {
 		cancelCtx()
 		return i18n.WrapError(ctx, err, i18n.MsgConfigFailed)
 	}
 
+	// Fetch the secrets the config refers to, which are then refreshed until shutdown
+	if err := secrets.Init(ctx); err != nil {
+		cancelCtx()
+		return err
+	}
+
+	if migrateDryRun {
+		defer cancelCtx()
+		return printPendingMigrations(ctx, getOrchestrator())
+	}
+
+	if err := tracing.Init(ctx); err != nil {
+		cancelCtx()
+		return err
+	}
+	// Flush spans on exit, with a fresh context as ctx has been cancelled by then
+	defer tracing.Shutdown(context.Background())
+
 	// Setup signal handling to cancel the context, which shuts down the API Server
 	errChan := make(chan error)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -132,6 +169,52 @@ func run() error {
 	}
 }
 
+func printPendingMigrations(ctx context.Context, o orchestrator.Orchestrator) error {
+	pending, err := o.PendingDatabaseMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Database has %d pending migrations", len(pending))
+	for _, migration := range pending {
+		fmt.Println(migration)
+	}
+	return nil
+}
+
+// printConfigValidation prints the report from validating the config as JSON on stdout, so it can be checked
+// by CI pipelines. A failure to read the config, or to fetch the secrets it refers to, is reported on its own.
+func printConfigValidation(ctx context.Context, readErr error) error {
+	if readErr != nil {
+		readErr = i18n.WrapError(ctx, readErr, i18n.MsgConfigFailed)
+	} else {
+		readErr = secrets.Init(ctx)
+	}
+	var report *fftypes.ConfigValidationReport
+	if readErr != nil {
+		report = &fftypes.ConfigValidationReport{
+			ConfigFile: config.ConfigFileUsed(),
+			Error:      readErr.Error(),
+		}
+	} else {
+		report = getOrchestrator().ValidateConfig(ctx)
+	}
+	b, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(b))
+	if readErr != nil {
+		return readErr
+	}
+	if !report.Valid {
+		failed := 0
+		for _, pv := range report.Plugins {
+			if pv.Error != "" {
+				failed++
+			}
+		}
+		return i18n.NewError(ctx, i18n.MsgConfigValidationFailed, failed)
+	}
+	return nil
+}
+
 func startFirefly(ctx context.Context, cancelCtx context.CancelFunc, o orchestrator.Orchestrator, as apiserver.Server, errChan chan error) {
 	var err error
 	// Start debug listener
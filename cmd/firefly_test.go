@@ -25,6 +25,7 @@ import (
 
 	"github.com/hyperledger/firefly/mocks/apiservermocks"
 	"github.com/hyperledger/firefly/mocks/orchestratormocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -120,6 +121,80 @@ func TestExecOkRestartThenExit(t *testing.T) {
 	assert.EqualError(t, err, "second run")
 }
 
+func TestExecMigrateDryRun(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("PendingDatabaseMigrations", mock.Anything).Return([]string{"000001_create_messages_table"}, nil)
+	_utOrchestrator = o
+	defer func() { _utOrchestrator = nil }()
+	migrateDryRun = true
+	defer func() { migrateDryRun = false }()
+
+	os.Chdir(configDir)
+	err := Execute()
+	assert.NoError(t, err)
+	o.AssertExpectations(t)
+}
+
+func TestExecMigrateDryRunFail(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("PendingDatabaseMigrations", mock.Anything).Return(nil, fmt.Errorf("FF10416 newer"))
+	_utOrchestrator = o
+	defer func() { _utOrchestrator = nil }()
+	migrateDryRun = true
+	defer func() { migrateDryRun = false }()
+
+	os.Chdir(configDir)
+	err := Execute()
+	assert.Regexp(t, "FF10416", err)
+}
+
+func TestExecValidateConfig(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("ValidateConfig", mock.Anything).Return(&fftypes.ConfigValidationReport{Valid: true})
+	_utOrchestrator = o
+	defer func() { _utOrchestrator = nil }()
+	validateConfig = true
+	defer func() { validateConfig = false }()
+
+	os.Chdir(configDir)
+	err := Execute()
+	assert.NoError(t, err)
+	o.AssertExpectations(t)
+}
+
+func TestExecValidateConfigInvalid(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("ValidateConfig", mock.Anything).Return(&fftypes.ConfigValidationReport{
+		Valid: false,
+		Plugins: []*fftypes.PluginValidation{
+			{Type: "database", Name: "postgres", Plugin: "postgres", Initialized: true},
+			{Type: "dataexchange", Name: "ffdx", Plugin: "ffdx", Error: "pop"},
+		},
+	})
+	_utOrchestrator = o
+	defer func() { _utOrchestrator = nil }()
+	validateConfig = true
+	defer func() { validateConfig = false }()
+
+	os.Chdir(configDir)
+	err := Execute()
+	assert.Regexp(t, "FF10508.*1", err)
+}
+
+func TestExecValidateConfigReadFail(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	_utOrchestrator = o
+	defer func() { _utOrchestrator = nil }()
+	validateConfig = true
+	defer func() { validateConfig = false }()
+	cfgFile = "missing.yaml"
+	defer func() { cfgFile = "" }()
+
+	err := Execute()
+	assert.Regexp(t, "FF10101", err)
+	o.AssertExpectations(t)
+}
+
 func TestAPIServerError(t *testing.T) {
 	o := &orchestratormocks.Orchestrator{}
 	o.On("Init", mock.Anything, mock.Anything).Return(nil)
@@ -0,0 +1,20 @@
+BEGIN;
+CREATE TABLE quarantinedbatches (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  batch_id         UUID,
+  tx_id            UUID,
+  hash             CHAR(64),
+  payload_ref      VARCHAR(1024),
+  key              VARCHAR(1024),
+  contexts         TEXT,
+  reason           TEXT            NOT NULL,
+  payload          TEXT,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX quarantinedbatches_id ON quarantinedbatches(id);
+CREATE INDEX quarantinedbatches_batch ON quarantinedbatches(namespace, batch_id);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE IF EXISTS groupmembershipchanges;
+ALTER TABLE messages DROP COLUMN group_version;
+ALTER TABLE groups DROP COLUMN version;
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+ALTER TABLE groups ADD COLUMN version BIGINT DEFAULT 0;
+ALTER TABLE messages ADD COLUMN group_version BIGINT DEFAULT 0;
+
+CREATE TABLE groupmembershipchanges (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  group_hash       CHAR(64)        NOT NULL,
+  effective_from   BIGINT          NOT NULL,
+  author           VARCHAR(1024)   NOT NULL,
+  added            TEXT,
+  removed          TEXT,
+  members          TEXT            NOT NULL,
+  message_id       UUID,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX groupmembershipchanges_id ON groupmembershipchanges(id);
+CREATE UNIQUE INDEX groupmembershipchanges_version ON groupmembershipchanges(group_hash, effective_from);
+
+COMMIT;
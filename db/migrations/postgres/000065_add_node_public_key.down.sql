@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE nodes DROP COLUMN public_key;
+COMMIT;
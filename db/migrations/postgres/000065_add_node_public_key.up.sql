@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE nodes ADD COLUMN public_key VARCHAR(128);
+UPDATE nodes SET public_key = '';
+COMMIT;
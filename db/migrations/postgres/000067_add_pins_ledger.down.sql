@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX pins_ledger;
+ALTER TABLE pins DROP COLUMN ledger;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE pins ADD COLUMN ledger VARCHAR(64) DEFAULT '';
+CREATE INDEX pins_ledger ON pins(ledger);
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS archive_entries;
+DROP TABLE IF EXISTS archives;
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+CREATE TABLE archives (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  location         VARCHAR(1024)   NOT NULL,
+  hash             CHAR(64)        NOT NULL,
+  size             BIGINT          NOT NULL,
+  event_count      BIGINT          NOT NULL,
+  data_count       BIGINT          NOT NULL,
+  first_sequence   BIGINT          NOT NULL,
+  last_sequence    BIGINT          NOT NULL,
+  oldest           BIGINT,
+  newest           BIGINT,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX archives_id ON archives(id);
+
+CREATE TABLE archive_entries (
+  seq              SERIAL          PRIMARY KEY,
+  entry_type       VARCHAR(64)     NOT NULL,
+  entry_id         UUID            NOT NULL,
+  archive_id       UUID            NOT NULL
+);
+
+CREATE UNIQUE INDEX archive_entries_entry ON archive_entries(entry_type, entry_id);
+
+COMMIT;
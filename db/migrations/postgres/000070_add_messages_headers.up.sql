@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE messages ADD COLUMN headers TEXT;
+COMMIT;
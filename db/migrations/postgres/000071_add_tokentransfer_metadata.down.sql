@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE tokentransfer DROP COLUMN metadata_id;
+COMMIT;
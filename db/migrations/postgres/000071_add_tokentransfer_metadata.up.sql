@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE tokentransfer ADD COLUMN metadata_id UUID;
+COMMIT;
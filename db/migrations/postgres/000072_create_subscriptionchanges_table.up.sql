@@ -0,0 +1,16 @@
+BEGIN;
+CREATE TABLE subscriptionchanges (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  subscription_id  UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  name             VARCHAR(64)     NOT NULL,
+  change_type      VARCHAR(64)     NOT NULL,
+  def_before       TEXT,
+  def_after        TEXT,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX subscriptionchanges_id ON subscriptionchanges(id);
+CREATE INDEX subscriptionchanges_subscription ON subscriptionchanges(subscription_id);
+COMMIT;
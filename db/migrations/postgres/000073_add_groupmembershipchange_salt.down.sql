@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE groupmembershipchanges DROP COLUMN salt;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE groupmembershipchanges ADD COLUMN salt CHAR(64);
+COMMIT;
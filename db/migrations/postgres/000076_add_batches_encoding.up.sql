@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE batches ADD COLUMN encoding VARCHAR(64) DEFAULT '';
+COMMIT;
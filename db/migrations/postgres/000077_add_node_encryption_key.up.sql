@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE nodes ADD COLUMN encryption_key VARCHAR(128);
+UPDATE nodes SET encryption_key = '';
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE data DROP COLUMN group_hash;
+COMMIT;
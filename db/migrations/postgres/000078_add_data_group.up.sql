@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE data ADD COLUMN group_hash CHAR(64);
+COMMIT;
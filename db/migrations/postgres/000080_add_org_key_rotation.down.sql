@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX orgs_previous_identity;
+ALTER TABLE orgs DROP COLUMN identity_rotated;
+ALTER TABLE orgs DROP COLUMN previous_identity;
+COMMIT;
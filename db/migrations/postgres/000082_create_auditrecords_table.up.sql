@@ -0,0 +1,21 @@
+BEGIN;
+CREATE TABLE auditrecords (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64),
+  identity         VARCHAR(1024),
+  remote_addr      VARCHAR(256),
+  method           VARCHAR(16)     NOT NULL,
+  path             VARCHAR(1024)   NOT NULL,
+  operation        VARCHAR(64)     NOT NULL,
+  request_hash     CHAR(64),
+  status           INTEGER         NOT NULL,
+  error            TEXT,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX auditrecords_id ON auditrecords(id);
+CREATE INDEX auditrecords_created ON auditrecords(created);
+CREATE INDEX auditrecords_identity ON auditrecords(identity);
+
+COMMIT;
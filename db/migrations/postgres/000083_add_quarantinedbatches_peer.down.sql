@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE quarantinedbatches DROP COLUMN peer;
+COMMIT;
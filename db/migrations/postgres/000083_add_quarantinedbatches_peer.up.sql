@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE quarantinedbatches ADD COLUMN peer VARCHAR(256);
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE nodes DROP COLUMN privacy_key;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE nodes ADD COLUMN privacy_key VARCHAR(128);
+UPDATE nodes SET privacy_key = '';
+COMMIT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS groupmembershipchanges;
+ALTER TABLE messages DROP COLUMN group_version;
+ALTER TABLE groups DROP COLUMN version;
@@ -0,0 +1,2 @@
+ALTER TABLE nodes ADD COLUMN public_key VARCHAR(128);
+UPDATE nodes SET public_key = '';
@@ -0,0 +1,2 @@
+DROP INDEX pins_ledger;
+ALTER TABLE pins DROP COLUMN ledger;
@@ -0,0 +1 @@
+ALTER TABLE tokentransfer ADD COLUMN metadata_id UUID;
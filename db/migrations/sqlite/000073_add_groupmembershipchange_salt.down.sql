@@ -0,0 +1 @@
+ALTER TABLE groupmembershipchanges DROP COLUMN salt;
@@ -0,0 +1 @@
+ALTER TABLE groupmembershipchanges ADD COLUMN salt CHAR(64);
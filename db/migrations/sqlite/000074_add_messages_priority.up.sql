@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN priority VARCHAR(64) DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE batches ADD COLUMN encoding VARCHAR(64) DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE nodes ADD COLUMN encryption_key VARCHAR(128);
+UPDATE nodes SET encryption_key = '';
@@ -0,0 +1 @@
+ALTER TABLE data ADD COLUMN group_hash CHAR(64);
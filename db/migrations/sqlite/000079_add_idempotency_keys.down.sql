@@ -0,0 +1,4 @@
+DROP INDEX messages_idempotency_key;
+ALTER TABLE messages DROP COLUMN idempotency_key;
+DROP INDEX transactions_idempotency_key;
+ALTER TABLE transactions DROP COLUMN idempotency_key;
@@ -0,0 +1,3 @@
+DROP INDEX orgs_previous_identity;
+ALTER TABLE orgs DROP COLUMN identity_rotated;
+ALTER TABLE orgs DROP COLUMN previous_identity;
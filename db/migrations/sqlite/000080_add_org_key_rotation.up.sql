@@ -0,0 +1,3 @@
+ALTER TABLE orgs ADD COLUMN previous_identity VARCHAR(1024) DEFAULT '';
+ALTER TABLE orgs ADD COLUMN identity_rotated BIGINT;
+CREATE INDEX orgs_previous_identity ON orgs(previous_identity);
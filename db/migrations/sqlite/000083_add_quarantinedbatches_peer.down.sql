@@ -0,0 +1 @@
+ALTER TABLE quarantinedbatches DROP COLUMN peer;
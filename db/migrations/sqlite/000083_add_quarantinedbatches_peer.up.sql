@@ -0,0 +1 @@
+ALTER TABLE quarantinedbatches ADD COLUMN peer VARCHAR(256);
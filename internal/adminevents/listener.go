@@ -0,0 +1,169 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// changeEventFilter selects the change events delivered to a listener. Each field is a set of allowed
+// values, and an empty set allows every value.
+type changeEventFilter struct {
+	collections map[string]bool
+	types       map[string]bool
+	namespaces  map[string]bool
+}
+
+// parseFilter builds the filter from the "collections", "types" and "namespaces" query parameters,
+// each of which can be repeated or contain a comma separated list
+func parseFilter(query url.Values) *changeEventFilter {
+	return &changeEventFilter{
+		collections: querySet(query, "collections"),
+		types:       querySet(query, "types"),
+		namespaces:  querySet(query, "namespaces"),
+	}
+}
+
+func querySet(query url.Values, name string) map[string]bool {
+	set := make(map[string]bool)
+	for _, value := range query[name] {
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				set[entry] = true
+			}
+		}
+	}
+	return set
+}
+
+func (f *changeEventFilter) matches(ce *fftypes.ChangeEvent) bool {
+	return (len(f.collections) == 0 || f.collections[ce.Collection]) &&
+		(len(f.types) == 0 || f.types[string(ce.Type)]) &&
+		(len(f.namespaces) == 0 || f.namespaces[ce.Namespace])
+}
+
+type listener struct {
+	ctx          context.Context
+	cancelCtx    func()
+	am           *adminEventManager
+	id           string
+	wsConn       *websocket.Conn
+	filter       *changeEventFilter
+	events       chan *fftypes.ChangeEvent
+	senderDone   chan struct{}
+	receiverDone chan struct{}
+	closeOnce    sync.Once
+}
+
+func newListener(am *adminEventManager, wsConn *websocket.Conn, filter *changeEventFilter) *listener {
+	id := fftypes.NewUUID().String()
+	ctx := log.WithLogField(am.ctx, "websocket", id)
+	ctx, cancelCtx := context.WithCancel(ctx)
+	l := &listener{
+		ctx:          ctx,
+		cancelCtx:    cancelCtx,
+		am:           am,
+		id:           id,
+		wsConn:       wsConn,
+		filter:       filter,
+		events:       make(chan *fftypes.ChangeEvent, am.queueLength),
+		senderDone:   make(chan struct{}),
+		receiverDone: make(chan struct{}),
+	}
+	go l.sendLoop()
+	go l.receiveLoop()
+	return l
+}
+
+// queue hands the event to the sender without blocking. A listener that has fallen a full queue behind
+// is disconnected, rather than silently missing events, so the UI knows to reload its view and reconnect.
+func (l *listener) queue(ce *fftypes.ChangeEvent) {
+	select {
+	case l.events <- ce:
+	default:
+		log.L(l.ctx).Warnf("Admin change event listener is not keeping up with %d queued events - disconnecting", cap(l.events))
+		l.close()
+	}
+}
+
+func (l *listener) sendLoop() {
+	defer close(l.senderDone)
+	defer l.close()
+	for {
+		select {
+		case ce := <-l.events:
+			writer, err := l.wsConn.NextWriter(websocket.TextMessage)
+			if err == nil {
+				err = json.NewEncoder(writer).Encode(&fftypes.WSChangeNotification{
+					WSClientActionBase: fftypes.WSClientActionBase{
+						Type: fftypes.WSClientActionChangeNotifcation,
+					},
+					ChangeEvent: ce,
+				})
+				if err == nil {
+					// The message is only written to the socket when the writer is closed
+					err = writer.Close()
+				}
+			}
+			if err != nil {
+				log.L(l.ctx).Errorf("Write failed on socket: %s", err)
+				return
+			}
+		case <-l.receiverDone:
+			log.L(l.ctx).Debugf("Sender closing - receiver completed")
+			return
+		case <-l.ctx.Done():
+			log.L(l.ctx).Debugf("Sender closing - context cancelled")
+			return
+		}
+	}
+}
+
+// receiveLoop reads until the socket closes. Listeners do not send any actions, but reading is
+// required to process the control messages of the websocket protocol, and to detect the close.
+func (l *listener) receiveLoop() {
+	defer close(l.receiverDone)
+	for {
+		if _, _, err := l.wsConn.NextReader(); err != nil {
+			log.L(l.ctx).Debugf("Receiver closing: %s", err)
+			return
+		}
+	}
+}
+
+func (l *listener) close() {
+	l.closeOnce.Do(func() {
+		l.cancelCtx()
+		_ = l.wsConn.Close()
+		l.am.listenerClosed(l.id)
+		log.L(l.ctx).Infof("Admin change event listener disconnected")
+	})
+}
+
+func (l *listener) waitClose() {
+	l.close()
+	<-l.senderDone
+	<-l.receiverDone
+}
@@ -0,0 +1,113 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminevents
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Manager streams the change events of the local node to listeners on the admin websocket, so management
+// UIs can update as subscriptions, namespaces, operations and other resources change, without polling the API
+type Manager interface {
+	// ServeHTTPWebSocketListener upgrades the request to a websocket, and streams change events to it until it is closed
+	ServeHTTPWebSocketListener(res http.ResponseWriter, req *http.Request)
+	// Dispatch queues a change event for each listener whose filter it matches. It never blocks the caller.
+	Dispatch(ce *fftypes.ChangeEvent)
+	// WaitStop waits for all listeners to close, which happens when the context of the manager is cancelled
+	WaitStop()
+}
+
+type adminEventManager struct {
+	ctx         context.Context
+	queueLength int
+	upgrader    websocket.Upgrader
+
+	mux       sync.Mutex
+	listeners map[string]*listener
+}
+
+func NewAdminEventManager(ctx context.Context) Manager {
+	return &adminEventManager{
+		ctx:         log.WithLogField(ctx, "role", "admin-events"),
+		queueLength: config.GetInt(config.AdminWebSocketEventQueueLength),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  int(config.GetByteSize(config.AdminWebSocketReadBufferSize)),
+			WriteBufferSize: int(config.GetByteSize(config.AdminWebSocketWriteBufferSize)),
+			CheckOrigin: func(r *http.Request) bool {
+				// Cors is handled by the API server that wraps this handler
+				return true
+			},
+		},
+		listeners: make(map[string]*listener),
+	}
+}
+
+func (am *adminEventManager) ServeHTTPWebSocketListener(res http.ResponseWriter, req *http.Request) {
+	filter := parseFilter(req.URL.Query())
+	wsConn, err := am.upgrader.Upgrade(res, req, nil)
+	if err != nil {
+		log.L(am.ctx).Errorf("Admin WebSocket upgrade failed: %s", err)
+		return
+	}
+
+	am.mux.Lock()
+	l := newListener(am, wsConn, filter)
+	am.listeners[l.id] = l
+	am.mux.Unlock()
+	log.L(l.ctx).Infof("Admin change event listener connected")
+}
+
+func (am *adminEventManager) Dispatch(ce *fftypes.ChangeEvent) {
+	// Take a copy of the listener list, so we don't hold a lock while queuing to a listener
+	am.mux.Lock()
+	listeners := make([]*listener, 0, len(am.listeners))
+	for _, l := range am.listeners {
+		listeners = append(listeners, l)
+	}
+	am.mux.Unlock()
+
+	for _, l := range listeners {
+		if l.filter.matches(ce) {
+			l.queue(ce)
+		}
+	}
+}
+
+func (am *adminEventManager) listenerClosed(id string) {
+	am.mux.Lock()
+	delete(am.listeners, id)
+	am.mux.Unlock()
+}
+
+func (am *adminEventManager) WaitStop() {
+	am.mux.Lock()
+	listeners := make([]*listener, 0, len(am.listeners))
+	for _, l := range am.listeners {
+		listeners = append(listeners, l)
+	}
+	am.mux.Unlock()
+	for _, l := range listeners {
+		l.waitClose()
+	}
+}
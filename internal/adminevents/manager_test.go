@@ -0,0 +1,203 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/wsconfig"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/wsclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAdminEventManager(t *testing.T) (*adminEventManager, func()) {
+	config.Reset()
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	am := NewAdminEventManager(ctx).(*adminEventManager)
+	return am, func() {
+		cancelCtx()
+		am.WaitStop()
+	}
+}
+
+func connectTestListener(t *testing.T, am *adminEventManager, query string) (wsclient.WSClient, func()) {
+	svr := httptest.NewServer(http.HandlerFunc(am.ServeHTTPWebSocketListener))
+
+	clientPrefix := config.NewPluginConfig("ut.wsclient")
+	wsconfig.InitPrefix(clientPrefix)
+	clientPrefix.Set(restclient.HTTPConfigURL, fmt.Sprintf("http://%s?%s", svr.Listener.Addr(), query))
+	wsc, err := wsclient.New(context.Background(), wsconfig.GenerateConfigFromPrefix(clientPrefix), nil, nil)
+	assert.NoError(t, err)
+	err = wsc.Connect()
+	assert.NoError(t, err)
+
+	// The listener is registered just after the upgrade response is returned to the client
+	for listenerCount(am) == 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+	return wsc, func() {
+		wsc.Close()
+		svr.Close()
+	}
+}
+
+func listenerCount(am *adminEventManager) int {
+	am.mux.Lock()
+	defer am.mux.Unlock()
+	return len(am.listeners)
+}
+
+func TestDispatchFiltered(t *testing.T) {
+	am, cancel := newTestAdminEventManager(t)
+	defer cancel()
+	wsc, closeClient := connectTestListener(t, am, "collections=operations,namespaces&types=updated&types=created")
+	defer closeClient()
+
+	opID := fftypes.NewUUID()
+	am.Dispatch(&fftypes.ChangeEvent{Collection: "subscriptions", Type: fftypes.ChangeEventTypeCreated, Namespace: "ns1"})
+	am.Dispatch(&fftypes.ChangeEvent{Collection: "operations", Type: fftypes.ChangeEventTypeDeleted, Namespace: "ns1"})
+	am.Dispatch(&fftypes.ChangeEvent{Collection: "operations", Type: fftypes.ChangeEventTypeUpdated, Namespace: "ns1", ID: opID})
+
+	var notification fftypes.WSChangeNotification
+	err := json.Unmarshal(<-wsc.Receive(), &notification)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.WSClientActionChangeNotifcation, notification.Type)
+	assert.Equal(t, "operations", notification.ChangeEvent.Collection)
+	assert.Equal(t, fftypes.ChangeEventTypeUpdated, notification.ChangeEvent.Type)
+	assert.Equal(t, *opID, *notification.ChangeEvent.ID)
+}
+
+func TestFilterNamespaces(t *testing.T) {
+	f := &changeEventFilter{
+		collections: map[string]bool{},
+		types:       map[string]bool{},
+		namespaces:  map[string]bool{"ns1": true},
+	}
+	assert.True(t, f.matches(&fftypes.ChangeEvent{Collection: "namespaces", Namespace: "ns1"}))
+	assert.False(t, f.matches(&fftypes.ChangeEvent{Collection: "namespaces", Namespace: "ns2"}))
+}
+
+func TestListenerClosedByClient(t *testing.T) {
+	am, cancel := newTestAdminEventManager(t)
+	defer cancel()
+	_, closeClient := connectTestListener(t, am, "")
+	closeClient()
+
+	for listenerCount(am) > 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+}
+
+func TestListenerDisconnectedWhenQueueFull(t *testing.T) {
+	am, cancel := newTestAdminEventManager(t)
+	defer cancel()
+
+	conns := make(chan *websocket.Conn, 1)
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		wsConn, err := am.upgrader.Upgrade(res, req, nil)
+		assert.NoError(t, err)
+		conns <- wsConn
+	}))
+	defer svr.Close()
+	clientConn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s", svr.Listener.Addr()), nil)
+	assert.NoError(t, err)
+	defer clientConn.Close()
+
+	// Build the listener without its loops, so nothing drains the queue
+	ctx, cancelCtx := context.WithCancel(am.ctx)
+	l := &listener{
+		ctx:       ctx,
+		cancelCtx: cancelCtx,
+		am:        am,
+		id:        "listener1",
+		wsConn:    <-conns,
+		events:    make(chan *fftypes.ChangeEvent, 1),
+	}
+	am.listeners[l.id] = l
+
+	l.queue(&fftypes.ChangeEvent{Collection: "operations"})
+	assert.Equal(t, 1, listenerCount(am))
+	l.queue(&fftypes.ChangeEvent{Collection: "operations"})
+	assert.Equal(t, 0, listenerCount(am))
+	assert.Error(t, l.ctx.Err())
+}
+
+func TestListenerWriteFail(t *testing.T) {
+	am, cancel := newTestAdminEventManager(t)
+	defer cancel()
+
+	conns := make(chan *websocket.Conn, 1)
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		wsConn, err := am.upgrader.Upgrade(res, req, nil)
+		assert.NoError(t, err)
+		conns <- wsConn
+	}))
+	defer svr.Close()
+	clientConn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s", svr.Listener.Addr()), nil)
+	assert.NoError(t, err)
+	defer clientConn.Close()
+
+	// Build the listener on a socket that is already closed, and run the sender without the receiver
+	ctx, cancelCtx := context.WithCancel(am.ctx)
+	l := &listener{
+		ctx:        ctx,
+		cancelCtx:  cancelCtx,
+		am:         am,
+		id:         "listener1",
+		wsConn:     <-conns,
+		events:     make(chan *fftypes.ChangeEvent, 1),
+		senderDone: make(chan struct{}),
+	}
+	am.listeners[l.id] = l
+	_ = l.wsConn.UnderlyingConn().Close()
+
+	l.events <- &fftypes.ChangeEvent{Collection: "operations"}
+	l.sendLoop()
+	assert.Equal(t, 0, listenerCount(am))
+	<-l.senderDone
+}
+
+func TestUpgradeFail(t *testing.T) {
+	am, cancel := newTestAdminEventManager(t)
+	defer cancel()
+	svr := httptest.NewServer(http.HandlerFunc(am.ServeHTTPWebSocketListener))
+	defer svr.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s", svr.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+	assert.Equal(t, 0, listenerCount(am))
+}
+
+func TestWaitStopClosesListeners(t *testing.T) {
+	am, cancel := newTestAdminEventManager(t)
+	_, closeClient := connectTestListener(t, am, "")
+	defer closeClient()
+
+	cancel()
+	assert.Equal(t, 0, listenerCount(am))
+}
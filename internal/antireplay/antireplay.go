@@ -0,0 +1,127 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package antireplay
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+var sequencer struct {
+	mux  sync.Mutex
+	last int64
+}
+
+// NextSequence returns a strictly increasing sequence number for a transmission sent by this node.
+// The sequence is derived from the current time in nanoseconds, so it continues to increase across restarts.
+func NextSequence() int64 {
+	sequencer.mux.Lock()
+	defer sequencer.mux.Unlock()
+	seq := time.Now().UnixNano()
+	if seq <= sequencer.last {
+		seq = sequencer.last + 1
+	}
+	sequencer.last = seq
+	return seq
+}
+
+// Window tracks the sequences of transmissions received from each peer, so that replays can be rejected.
+// Check is called before a transmission is processed, and Record once it has been processed successfully,
+// so that transmissions that fail with a retryable error can be re-delivered.
+type Window interface {
+	Check(peerID string, sequence int64) error
+	Record(peerID string, sequence int64)
+}
+
+type peerWindow struct {
+	latest int64
+	seen   map[int64]bool
+}
+
+type sequenceWindow struct {
+	ctx    context.Context
+	mux    sync.Mutex
+	window time.Duration
+	peers  map[string]*peerWindow
+}
+
+// NewWindow returns a window that rejects transmissions with a sequence that has already been received from
+// the peer, or that is more than the window duration behind the latest sequence from that peer (or ahead of
+// the local clock). A zero duration disables checking. Transmissions without a sequence, from nodes that
+// pre-date replay protection, are always accepted.
+func NewWindow(ctx context.Context, window time.Duration) Window {
+	if window <= 0 {
+		return &disabledWindow{}
+	}
+	return &sequenceWindow{
+		ctx:    ctx,
+		window: window,
+		peers:  make(map[string]*peerWindow),
+	}
+}
+
+func (sw *sequenceWindow) Check(peerID string, sequence int64) error {
+	if sequence == 0 {
+		return nil
+	}
+	sw.mux.Lock()
+	defer sw.mux.Unlock()
+	var latest int64
+	pw := sw.peers[peerID]
+	if pw != nil {
+		latest = pw.latest
+		if pw.seen[sequence] {
+			return i18n.NewError(sw.ctx, i18n.MsgTransmissionReplayed, sequence, peerID)
+		}
+	}
+	if (pw != nil && sequence <= latest-int64(sw.window)) || sequence > time.Now().UnixNano()+int64(sw.window) {
+		return i18n.NewError(sw.ctx, i18n.MsgTransmissionOutsideWindow, sequence, peerID, latest, sw.window)
+	}
+	return nil
+}
+
+func (sw *sequenceWindow) Record(peerID string, sequence int64) {
+	if sequence == 0 {
+		return
+	}
+	sw.mux.Lock()
+	defer sw.mux.Unlock()
+	pw := sw.peers[peerID]
+	if pw == nil {
+		pw = &peerWindow{seen: make(map[int64]bool)}
+		sw.peers[peerID] = pw
+	}
+	pw.seen[sequence] = true
+	if sequence > pw.latest {
+		pw.latest = sequence
+		// Anything that is now behind the window will be rejected without needing to be remembered
+		for seq := range pw.seen {
+			if seq <= pw.latest-int64(sw.window) {
+				delete(pw.seen, seq)
+			}
+		}
+	}
+}
+
+type disabledWindow struct{}
+
+func (dw *disabledWindow) Check(peerID string, sequence int64) error { return nil }
+
+func (dw *disabledWindow) Record(peerID string, sequence int64) {}
@@ -0,0 +1,84 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package antireplay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextSequenceIncreasing(t *testing.T) {
+	sequencer.last = time.Now().Add(1 * time.Hour).UnixNano()
+	defer func() { sequencer.last = 0 }()
+	s1 := NextSequence()
+	s2 := NextSequence()
+	assert.Greater(t, s2, s1)
+}
+
+func TestWindowReplay(t *testing.T) {
+	w := NewWindow(context.Background(), 1*time.Minute)
+	seq := NextSequence()
+	assert.NoError(t, w.Check("peer1", seq))
+	w.Record("peer1", seq)
+	assert.Regexp(t, "FF10400", w.Check("peer1", seq))
+	assert.NoError(t, w.Check("peer2", seq))
+}
+
+func TestWindowOutOfOrderWithinWindow(t *testing.T) {
+	w := NewWindow(context.Background(), 1*time.Minute)
+	now := time.Now()
+	seq1 := now.Add(-30 * time.Second).UnixNano()
+	seq2 := now.UnixNano()
+	w.Record("peer1", seq2)
+	assert.NoError(t, w.Check("peer1", seq1))
+	w.Record("peer1", seq1)
+	assert.Regexp(t, "FF10400", w.Check("peer1", seq1))
+}
+
+func TestWindowBehind(t *testing.T) {
+	w := NewWindow(context.Background(), 1*time.Minute)
+	now := time.Now()
+	old := now.Add(-2 * time.Minute).UnixNano()
+	assert.NoError(t, w.Check("peer1", old))
+	w.Record("peer1", old)
+	w.Record("peer1", now.UnixNano())
+	assert.Empty(t, w.(*sequenceWindow).peers["peer1"].seen[old])
+	assert.Regexp(t, "FF10401", w.Check("peer1", old))
+	assert.Regexp(t, "FF10401", w.Check("peer1", old+1))
+}
+
+func TestWindowAhead(t *testing.T) {
+	w := NewWindow(context.Background(), 1*time.Minute)
+	assert.Regexp(t, "FF10401", w.Check("peer1", time.Now().Add(1*time.Hour).UnixNano()))
+}
+
+func TestWindowNoSequence(t *testing.T) {
+	w := NewWindow(context.Background(), 1*time.Minute)
+	w.Record("peer1", 0)
+	assert.NoError(t, w.Check("peer1", 0))
+	assert.Empty(t, w.(*sequenceWindow).peers)
+}
+
+func TestWindowDisabled(t *testing.T) {
+	w := NewWindow(context.Background(), 0)
+	seq := NextSequence()
+	w.Record("peer1", seq)
+	assert.NoError(t, w.Check("peer1", seq))
+}
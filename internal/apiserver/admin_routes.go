@@ -23,6 +23,18 @@ var adminRoutes = []*oapispec.Route{
 	getConfigRecord,
 	getConfigRecords,
 	postResetConfig,
+	postReloadConfig,
 	putConfigRecord,
 	deleteConfigRecord,
+	getQuarantinedBatches,
+	getQuarantinedBatchByID,
+	postReprocessQuarantinedBatch,
+	getAggregatorCheckpoint,
+	postAggregatorRewind,
+	postRawTransaction,
+	postCatchup,
+	getAuditRecords,
+	getLogLevels,
+	putLogLevels,
+	getPluginHealth,
 }
@@ -0,0 +1,515 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/internal/rbac"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// graphqlFilterParams are the arguments accepted on a collection, in addition to the fields of its query factory
+var graphqlFilterParams = []string{"skip", "limit", "sort", "descending", "ascending"}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data fftypes.JSONObject `json:"data"`
+}
+
+type graphqlResolver func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, filter database.AndFilter) (interface{}, error)
+
+// graphqlRelation is a field that is resolved by a call to the orchestrator, rather than read from the parent object.
+// Relations with a query factory are collections, that accept the same filters as the equivalent REST collection.
+// Relations that are lists without a query factory cannot be filtered, such as the data of a message.
+type graphqlRelation struct {
+	typeName     string
+	queryFactory database.QueryFactory
+	list         bool
+	resolve      graphqlResolver
+}
+
+// graphqlType maps a GraphQL object type to a Go type. Every JSON field of the Go type can be selected,
+// and a relation takes precedence over the JSON field of the same name when it has a selection of sub-fields.
+type graphqlType struct {
+	name      string
+	fields    map[string]bool
+	relations map[string]*graphqlRelation
+}
+
+var graphqlRoots = map[string]*graphqlRelation{
+	"batches": {typeName: "Batch", queryFactory: database.BatchQueryFactory, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, _ interface{}, filter database.AndFilter) (interface{}, error) {
+		batches, _, err := o.GetBatches(ctx, ns, filter)
+		return batches, err
+	}},
+	"data": {typeName: "Data", queryFactory: database.DataQueryFactory, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, _ interface{}, filter database.AndFilter) (interface{}, error) {
+		data, _, err := o.GetData(ctx, ns, filter)
+		return data, err
+	}},
+	"events": {typeName: "Event", queryFactory: database.EventQueryFactory, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, _ interface{}, filter database.AndFilter) (interface{}, error) {
+		events, _, err := o.GetEvents(ctx, ns, filter)
+		return events, err
+	}},
+	"messages": {typeName: "Message", queryFactory: database.MessageQueryFactory, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, _ interface{}, filter database.AndFilter) (interface{}, error) {
+		msgs, _, err := o.GetMessages(ctx, ns, filter)
+		return msgs, err
+	}},
+	"operations": {typeName: "Operation", queryFactory: database.OperationQueryFactory, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, _ interface{}, filter database.AndFilter) (interface{}, error) {
+		ops, _, err := o.GetOperations(ctx, ns, filter)
+		return ops, err
+	}},
+	"transactions": {typeName: "Transaction", queryFactory: database.TransactionQueryFactory, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, _ interface{}, filter database.AndFilter) (interface{}, error) {
+		txs, _, err := o.GetTransactions(ctx, ns, filter)
+		return txs, err
+	}},
+}
+
+func graphqlTransactionByID(ctx context.Context, o orchestrator.Orchestrator, ns string, id *fftypes.UUID) (*fftypes.Transaction, error) {
+	if id == nil {
+		return nil, nil
+	}
+	return o.GetTransactionByID(ctx, ns, id.String())
+}
+
+var graphqlTypes = map[string]*graphqlType{
+	"Batch": newGraphQLType("Batch", fftypes.Batch{}, map[string]*graphqlRelation{
+		"transaction": {typeName: "Transaction", resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, _ database.AndFilter) (interface{}, error) {
+			return graphqlTransactionByID(ctx, o, ns, parent.(*fftypes.Batch).Payload.TX.ID)
+		}},
+	}),
+	"Data": newGraphQLType("Data", fftypes.Data{}, map[string]*graphqlRelation{
+		"messages": {typeName: "Message", queryFactory: database.MessageQueryFactory, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, filter database.AndFilter) (interface{}, error) {
+			msgs, _, err := o.GetMessagesForData(ctx, ns, parent.(*fftypes.Data).ID.String(), filter)
+			return msgs, err
+		}},
+	}),
+	"Event": newGraphQLType("Event", fftypes.Event{}, map[string]*graphqlRelation{
+		"message": {typeName: "Message", resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, _ database.AndFilter) (interface{}, error) {
+			event := parent.(*fftypes.Event)
+			switch event.Type {
+			case fftypes.EventTypeMessageConfirmed, fftypes.EventTypeMessageRejected:
+				return o.GetMessageByID(ctx, ns, event.Reference.String())
+			default:
+				return nil, nil
+			}
+		}},
+		"tx": {typeName: "Transaction", resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, _ database.AndFilter) (interface{}, error) {
+			return graphqlTransactionByID(ctx, o, ns, parent.(*fftypes.Event).Transaction)
+		}},
+	}),
+	"Message": newGraphQLType("Message", fftypes.Message{}, map[string]*graphqlRelation{
+		"batch": {typeName: "Batch", resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, _ database.AndFilter) (interface{}, error) {
+			msg := parent.(*fftypes.Message)
+			if msg.BatchID == nil {
+				return nil, nil
+			}
+			return o.GetBatchByID(ctx, ns, msg.BatchID.String())
+		}},
+		"data": {typeName: "Data", list: true, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, _ database.AndFilter) (interface{}, error) {
+			return o.GetMessageData(ctx, ns, parent.(*fftypes.Message).Header.ID.String())
+		}},
+		"events": {typeName: "Event", queryFactory: database.EventQueryFactory, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, filter database.AndFilter) (interface{}, error) {
+			events, _, err := o.GetMessageEvents(ctx, ns, parent.(*fftypes.Message).Header.ID.String(), filter)
+			return events, err
+		}},
+		"transaction": {typeName: "Transaction", resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, _ database.AndFilter) (interface{}, error) {
+			return o.GetMessageTransaction(ctx, ns, parent.(*fftypes.Message).Header.ID.String())
+		}},
+	}),
+	"Operation": newGraphQLType("Operation", fftypes.Operation{}, map[string]*graphqlRelation{
+		"tx": {typeName: "Transaction", resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, _ database.AndFilter) (interface{}, error) {
+			return graphqlTransactionByID(ctx, o, ns, parent.(*fftypes.Operation).Transaction)
+		}},
+	}),
+	"Transaction": newGraphQLType("Transaction", fftypes.Transaction{}, map[string]*graphqlRelation{
+		"operations": {typeName: "Operation", list: true, resolve: func(ctx context.Context, o orchestrator.Orchestrator, ns string, parent interface{}, _ database.AndFilter) (interface{}, error) {
+			ops, _, err := o.GetTransactionOperations(ctx, ns, parent.(*fftypes.Transaction).ID.String())
+			return ops, err
+		}},
+	}),
+}
+
+func newGraphQLType(name string, prototype interface{}, relations map[string]*graphqlRelation) *graphqlType {
+	t := &graphqlType{
+		name:      name,
+		fields:    make(map[string]bool),
+		relations: relations,
+	}
+	addJSONFields(t.fields, reflect.TypeOf(prototype))
+	return t
+}
+
+// addJSONFields adds the names each field of a struct will have when serialized to JSON, including promoted fields
+func addJSONFields(fields map[string]bool, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case name == "-" || f.PkgPath != "":
+		case f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct:
+			addJSONFields(fields, f.Type)
+		case name == "":
+			fields[f.Name] = true
+		default:
+			fields[name] = true
+		}
+	}
+}
+
+type graphqlExecutor struct {
+	as        *apiServer
+	o         orchestrator.Orchestrator
+	ns        string
+	variables map[string]interface{}
+	declared  map[string]*gqlValue
+}
+
+func (as *apiServer) graphqlHandler(o orchestrator.Orchestrator) func(res http.ResponseWriter, req *http.Request) (status int, err error) {
+	return func(res http.ResponseWriter, req *http.Request) (status int, err error) {
+		if err := rbac.Authorize(req.Context(), mux.Vars(req)["ns"], rbac.RoleReader); err != nil {
+			return 403, err
+		}
+		var gqlReq graphqlRequest
+		decoder := json.NewDecoder(req.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&gqlReq); err != nil {
+			return 400, i18n.WrapError(req.Context(), err, i18n.MsgJSONDecodeFailed)
+		}
+		data, err := as.executeGraphQL(req.Context(), o, mux.Vars(req)["ns"], &gqlReq)
+		if err != nil {
+			return 500, err
+		}
+		return as.handleOutput(req.Context(), res, 200, &graphqlResponse{Data: data})
+	}
+}
+
+func selectGraphQLOperation(ctx context.Context, ops []*gqlOperation, name string) (*gqlOperation, error) {
+	if name == "" && len(ops) == 1 {
+		return ops[0], nil
+	}
+	for _, op := range ops {
+		if name != "" && op.name == name {
+			return op, nil
+		}
+	}
+	return nil, i18n.NewError(ctx, i18n.MsgGraphQLOperationNotFound, name)
+}
+
+// executeGraphQL runs a GraphQL query, where each top level field lists a collection of the namespace,
+// and nested relations are resolved for each object returned. Errors fail the whole query.
+// The cost of the query is checked before anything is resolved, as the relations between types are cyclic.
+func (as *apiServer) executeGraphQL(ctx context.Context, o orchestrator.Orchestrator, ns string, req *graphqlRequest) (fftypes.JSONObject, error) {
+	ops, err := parseGraphQL(ctx, req.Query, as.graphqlMaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	op, err := selectGraphQLOperation(ctx, ops, req.OperationName)
+	if err != nil {
+		return nil, err
+	}
+	e := &graphqlExecutor{
+		as:        as,
+		o:         o,
+		ns:        ns,
+		variables: req.Variables,
+		declared:  make(map[string]*gqlValue),
+	}
+	for _, v := range op.variables {
+		e.declared[v.name] = v.defaultValue
+	}
+	if as.graphqlMaxCost > 0 {
+		if _, err := e.cost(ctx, nil, op.selections, 1); err != nil {
+			return nil, err
+		}
+	}
+	result := fftypes.JSONObject{}
+	for _, field := range op.selections {
+		if field.name == "__typename" {
+			result[field.alias] = "Query"
+			continue
+		}
+		root, ok := graphqlRoots[field.name]
+		if !ok {
+			return nil, i18n.NewError(ctx, i18n.MsgGraphQLUnknownField, field.name, "Query")
+		}
+		if result[field.alias], err = e.resolveRelation(ctx, "Query", root, nil, field); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (e *graphqlExecutor) resolveRelation(ctx context.Context, parentType string, rel *graphqlRelation, parent interface{}, field *gqlField) (interface{}, error) {
+	if len(field.selections) == 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgGraphQLSelectionRequired, field.name, parentType)
+	}
+	var filter database.AndFilter
+	if rel.queryFactory != nil {
+		values, err := e.filterValues(ctx, rel.queryFactory, field)
+		if err != nil {
+			return nil, err
+		}
+		if parent != nil && e.as.graphqlNestedLimit > 0 && len(values["limit"]) == 0 {
+			values.Set("limit", strconv.FormatUint(e.as.graphqlNestedLimit, 10))
+		}
+		if filter, err = e.as.buildFilterFromValues(ctx, values, rel.queryFactory); err != nil {
+			return nil, err
+		}
+	} else if len(field.args) > 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgGraphQLUnknownArgument, field.args[0].name, field.name)
+	}
+	obj, err := rel.resolve(ctx, e.o, e.ns, parent, filter)
+	if err != nil {
+		return nil, err
+	}
+	return e.resolveValue(ctx, graphqlTypes[rel.typeName], obj, field.selections)
+}
+
+func (e *graphqlExecutor) resolveValue(ctx context.Context, t *graphqlType, obj interface{}, selections []*gqlField) (interface{}, error) {
+	v := reflect.ValueOf(obj)
+	switch {
+	case !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()):
+		return nil, nil
+	case v.Kind() == reflect.Slice:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			item, err := e.resolveObject(ctx, t, v.Index(i).Interface(), selections)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return e.resolveObject(ctx, t, obj, selections)
+	}
+}
+
+func (e *graphqlExecutor) resolveObject(ctx context.Context, t *graphqlType, obj interface{}, selections []*gqlField) (interface{}, error) {
+	// Our types always serialize, and project the same JSON as the REST API returns
+	b, _ := json.Marshal(obj)
+	var jsonObj map[string]interface{}
+	_ = json.Unmarshal(b, &jsonObj)
+
+	result := fftypes.JSONObject{}
+	for _, field := range selections {
+		rel := t.relationFor(field)
+		switch {
+		case field.name == "__typename":
+			result[field.alias] = t.name
+		case rel != nil:
+			value, err := e.resolveRelation(ctx, t.name, rel, obj, field)
+			if err != nil {
+				return nil, err
+			}
+			result[field.alias] = value
+		case t.fields[field.name]:
+			if len(field.args) > 0 {
+				return nil, i18n.NewError(ctx, i18n.MsgGraphQLUnknownArgument, field.args[0].name, field.name)
+			}
+			result[field.alias] = projectJSON(jsonObj[field.name], field.selections)
+		default:
+			return nil, i18n.NewError(ctx, i18n.MsgGraphQLUnknownField, field.name, t.name)
+		}
+	}
+	return result, nil
+}
+
+// relationFor returns the relation a field selects, or nil if the field is read from the JSON of the object
+func (t *graphqlType) relationFor(field *gqlField) *graphqlRelation {
+	if t == nil {
+		return graphqlRoots[field.name]
+	}
+	rel := t.relations[field.name]
+	if rel != nil && (len(field.selections) > 0 || !t.fields[field.name]) {
+		return rel
+	}
+	return nil
+}
+
+// cost estimates the number of objects a selection set resolves, assuming every collection returns a full page.
+// Each object returned multiplies the cost of the relations nested beneath it, so the estimate fails as soon
+// as it exceeds the maximum - before it can overflow. The Query type is passed as nil.
+func (e *graphqlExecutor) cost(ctx context.Context, t *graphqlType, selections []*gqlField, multiplier uint64) (total uint64, err error) {
+	for _, field := range selections {
+		rel := t.relationFor(field)
+		if rel == nil {
+			continue // read from the object, or an unknown field that fails on execution
+		}
+		count := multiplier * e.pageSize(ctx, rel, field, t != nil)
+		if total += count; total > e.as.graphqlMaxCost {
+			return 0, i18n.NewError(ctx, i18n.MsgGraphQLMaxCost, e.as.graphqlMaxCost)
+		}
+		nested, err := e.cost(ctx, graphqlTypes[rel.typeName], field.selections, count)
+		if err != nil {
+			return 0, err
+		}
+		if total += nested; total > e.as.graphqlMaxCost {
+			return 0, i18n.NewError(ctx, i18n.MsgGraphQLMaxCost, e.as.graphqlMaxCost)
+		}
+	}
+	return total, nil
+}
+
+// pageSize returns the maximum number of objects a relation can return, which is capped just above the
+// maximum cost of a query. Collections without a limit are assumed to return the maximum filter limit.
+func (e *graphqlExecutor) pageSize(ctx context.Context, rel *graphqlRelation, field *gqlField, nested bool) uint64 {
+	var size uint64
+	switch {
+	case rel.queryFactory != nil:
+		size = e.as.defaultFilterLimit
+		if nested && e.as.graphqlNestedLimit > 0 {
+			size = e.as.graphqlNestedLimit
+		}
+		for _, arg := range field.args {
+			if arg.name != "limit" {
+				continue
+			}
+			if strs, _ := e.argumentStrings(ctx, arg.name, arg.value); len(strs) > 0 {
+				size, _ = strconv.ParseUint(strs[0], 10, 64)
+			}
+		}
+		if size == 0 {
+			size = e.as.maxFilterLimit
+		}
+	case rel.list:
+		size = e.as.graphqlNestedLimit
+	}
+	switch {
+	case size == 0:
+		return 1
+	case size > e.as.graphqlMaxCost:
+		return e.as.graphqlMaxCost + 1
+	default:
+		return size
+	}
+}
+
+// projectJSON selects sub-fields from a JSON value. Nested objects are free-form (such as the value of data),
+// so selections are not validated below the top level of each type.
+func projectJSON(v interface{}, selections []*gqlField) interface{} {
+	if len(selections) == 0 {
+		return v
+	}
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(selections))
+		for _, field := range selections {
+			result[field.alias] = projectJSON(vt[field.name], field.selections)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(vt))
+		for i, item := range vt {
+			result[i] = projectJSON(item, selections)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// filterValues converts the arguments of a collection field to the same values as the query string of
+// the equivalent REST API, so they are processed with identical syntax. A list is the same as a repeated
+// query parameter, so the conditions are combined with OR.
+func (e *graphqlExecutor) filterValues(ctx context.Context, qf database.QueryFactory, field *gqlField) (url.Values, error) {
+	known := append(qf.NewFilter(ctx).Fields(), graphqlFilterParams...)
+	values := url.Values{}
+	for _, arg := range field.args {
+		if !isKnownParam(known, arg.name) {
+			return nil, i18n.NewError(ctx, i18n.MsgGraphQLUnknownArgument, arg.name, field.name)
+		}
+		strs, err := e.argumentStrings(ctx, arg.name, arg.value)
+		if err != nil {
+			return nil, err
+		}
+		values[arg.name] = append(values[arg.name], strs...)
+	}
+	return values, nil
+}
+
+func (e *graphqlExecutor) argumentStrings(ctx context.Context, name string, v *gqlValue) ([]string, error) {
+	switch v.kind {
+	case gqlVariable:
+		if value, ok := e.variables[v.raw]; ok {
+			return jsonArgumentStrings(ctx, name, value)
+		}
+		defaultValue, ok := e.declared[v.raw]
+		switch {
+		case !ok:
+			return nil, i18n.NewError(ctx, i18n.MsgGraphQLUnknownVariable, v.raw)
+		case defaultValue == nil:
+			return nil, nil
+		default:
+			return e.argumentStrings(ctx, name, defaultValue)
+		}
+	case gqlNull:
+		return nil, nil
+	case gqlList:
+		var strs []string
+		for _, item := range v.list {
+			itemStrs, err := e.argumentStrings(ctx, name, item)
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, itemStrs...)
+		}
+		return strs, nil
+	default:
+		return []string{v.raw}, nil
+	}
+}
+
+func jsonArgumentStrings(ctx context.Context, name string, value interface{}) ([]string, error) {
+	switch vt := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{vt}, nil
+	case bool:
+		return []string{strconv.FormatBool(vt)}, nil
+	case json.Number:
+		return []string{vt.String()}, nil
+	case []interface{}:
+		var strs []string
+		for _, item := range vt {
+			itemStrs, err := jsonArgumentStrings(ctx, name, item)
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, itemStrs...)
+		}
+		return strs, nil
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgGraphQLInvalidArgument, name)
+	}
+}
@@ -0,0 +1,442 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+type gqlValueKind int
+
+const (
+	gqlString gqlValueKind = iota
+	gqlInt
+	gqlFloat
+	gqlBoolean
+	gqlNull
+	gqlEnum
+	gqlVariable
+	gqlList
+)
+
+// gqlValue is an argument value. Scalars hold their literal text in raw, and variables hold their name
+type gqlValue struct {
+	kind gqlValueKind
+	raw  string
+	list []*gqlValue
+}
+
+type gqlArgument struct {
+	name  string
+	value *gqlValue
+}
+
+type gqlField struct {
+	alias      string
+	name       string
+	args       []*gqlArgument
+	selections []*gqlField
+}
+
+type gqlVariableDef struct {
+	name         string
+	defaultValue *gqlValue
+}
+
+type gqlOperation struct {
+	name       string
+	variables  []*gqlVariableDef
+	selections []*gqlField
+}
+
+// gqlEscapes are the single character escape sequences allowed in a GraphQL string
+var gqlEscapes = map[byte]byte{'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t'}
+
+// gqlParser is a recursive descent parser for the subset of the GraphQL query language we execute.
+// That is query operations with variables, aliases and arguments - but not fragments or directives.
+// The nesting of selection sets and list values is limited to maxDepth (when non-zero), which bounds
+// both the recursion of the parser and the number of levels of relations a query can resolve.
+type gqlParser struct {
+	ctx      context.Context
+	query    string
+	pos      int
+	depth    int
+	maxDepth int
+}
+
+func parseGraphQL(ctx context.Context, query string, maxDepth int) ([]*gqlOperation, error) {
+	p := &gqlParser{ctx: ctx, query: query, maxDepth: maxDepth}
+	var ops []*gqlOperation
+	for p.peek() != 0 {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, p.syntaxError("no operations")
+	}
+	return ops, nil
+}
+
+func (p *gqlParser) syntaxError(msg string) error {
+	return i18n.NewError(p.ctx, i18n.MsgGraphQLSyntaxError, p.pos, msg)
+}
+
+func (p *gqlParser) skipIgnored() {
+	for p.pos < len(p.query) {
+		switch p.query[p.pos] {
+		case '#':
+			for p.pos < len(p.query) && p.query[p.pos] != '\n' && p.query[p.pos] != '\r' {
+				p.pos++
+			}
+		case ' ', '\t', '\n', '\r', ',':
+			// Commas are insignificant in GraphQL, in the same way as whitespace
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next significant character, or zero at the end of the query
+func (p *gqlParser) peek() byte {
+	p.skipIgnored()
+	if p.pos >= len(p.query) {
+		return 0
+	}
+	return p.query[p.pos]
+}
+
+// enter increments the nesting depth, failing if it exceeds the maximum. The caller must call leave
+func (p *gqlParser) enter() error {
+	p.depth++
+	if p.maxDepth > 0 && p.depth > p.maxDepth {
+		return i18n.NewError(p.ctx, i18n.MsgGraphQLMaxDepth, p.maxDepth)
+	}
+	return nil
+}
+
+func (p *gqlParser) leave() {
+	p.depth--
+}
+
+func (p *gqlParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.syntaxError(fmt.Sprintf("expected '%c'", c))
+	}
+	p.pos++
+	return nil
+}
+
+func isGQLNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isGQLNameChar(c byte) bool {
+	return isGQLNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func (p *gqlParser) parseName() (string, error) {
+	if !isGQLNameStart(p.peek()) {
+		return "", p.syntaxError("expected name")
+	}
+	start := p.pos
+	for p.pos < len(p.query) && isGQLNameChar(p.query[p.pos]) {
+		p.pos++
+	}
+	return p.query[start:p.pos], nil
+}
+
+func (p *gqlParser) parseOperation() (op *gqlOperation, err error) {
+	op = &gqlOperation{}
+	if p.peek() != '{' {
+		opType, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		switch opType {
+		case "query":
+		case "mutation", "subscription":
+			return nil, i18n.NewError(p.ctx, i18n.MsgGraphQLUnsupported, opType+"s")
+		case "fragment":
+			return nil, i18n.NewError(p.ctx, i18n.MsgGraphQLUnsupported, "fragments")
+		default:
+			return nil, p.syntaxError(fmt.Sprintf("unexpected '%s'", opType))
+		}
+		if isGQLNameStart(p.peek()) {
+			op.name, _ = p.parseName()
+		}
+		if p.peek() == '(' {
+			if op.variables, err = p.parseVariableDefs(); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek() == '@' {
+			return nil, i18n.NewError(p.ctx, i18n.MsgGraphQLUnsupported, "directives")
+		}
+	}
+	op.selections, err = p.parseSelectionSet()
+	return op, err
+}
+
+func (p *gqlParser) parseVariableDefs() (defs []*gqlVariableDef, err error) {
+	p.pos++ // '('
+	for p.peek() != ')' {
+		if err := p.expect('$'); err != nil {
+			return nil, err
+		}
+		def := &gqlVariableDef{}
+		if def.name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		// The type is checked for syntax only, as values are converted to filter strings regardless of type
+		if err := p.parseType(); err != nil {
+			return nil, err
+		}
+		if p.peek() == '=' {
+			p.pos++
+			if def.defaultValue, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+		defs = append(defs, def)
+	}
+	p.pos++
+	return defs, nil
+}
+
+func (p *gqlParser) parseType() error {
+	if p.peek() == '[' {
+		p.pos++
+		if err := p.parseType(); err != nil {
+			return err
+		}
+		if err := p.expect(']'); err != nil {
+			return err
+		}
+	} else if _, err := p.parseName(); err != nil {
+		return err
+	}
+	if p.peek() == '!' {
+		p.pos++
+	}
+	return nil
+}
+
+func (p *gqlParser) parseSelectionSet() (fields []*gqlField, err error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	for p.peek() != '}' {
+		if p.peek() == '.' {
+			return nil, i18n.NewError(p.ctx, i18n.MsgGraphQLUnsupported, "fragments")
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, p.syntaxError("empty selection set")
+	}
+	p.pos++
+	return fields, nil
+}
+
+func (p *gqlParser) parseField() (field *gqlField, err error) {
+	field = &gqlField{}
+	if field.name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	field.alias = field.name
+	if p.peek() == ':' {
+		p.pos++
+		if field.name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek() == '(' {
+		if field.args, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek() == '@' {
+		return nil, i18n.NewError(p.ctx, i18n.MsgGraphQLUnsupported, "directives")
+	}
+	if p.peek() == '{' {
+		if field.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *gqlParser) parseArguments() (args []*gqlArgument, err error) {
+	p.pos++ // '('
+	for len(args) == 0 || p.peek() != ')' {
+		arg := &gqlArgument{}
+		if arg.name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		if arg.value, err = p.parseValue(false); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.pos++
+	return args, nil
+}
+
+func (p *gqlParser) parseValue(isConst bool) (*gqlValue, error) {
+	switch c := p.peek(); {
+	case c == '$':
+		if isConst {
+			return nil, p.syntaxError("variables are not allowed in default values")
+		}
+		p.pos++
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		return &gqlValue{kind: gqlVariable, raw: name}, nil
+	case c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &gqlValue{kind: gqlString, raw: s}, nil
+	case c == '[':
+		p.pos++
+		defer p.leave()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		v := &gqlValue{kind: gqlList}
+		for p.peek() != ']' {
+			item, err := p.parseValue(isConst)
+			if err != nil {
+				return nil, err
+			}
+			v.list = append(v.list, item)
+		}
+		p.pos++
+		return v, nil
+	case c == '{':
+		return nil, i18n.NewError(p.ctx, i18n.MsgGraphQLUnsupported, "object values")
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case isGQLNameStart(c):
+		name, _ := p.parseName()
+		switch name {
+		case "true", "false":
+			return &gqlValue{kind: gqlBoolean, raw: name}, nil
+		case "null":
+			return &gqlValue{kind: gqlNull, raw: name}, nil
+		default:
+			return &gqlValue{kind: gqlEnum, raw: name}, nil
+		}
+	default:
+		return nil, p.syntaxError("expected value")
+	}
+}
+
+func (p *gqlParser) parseNumber() (*gqlValue, error) {
+	start := p.pos
+	kind := gqlInt
+	p.pos++ // '-' or first digit
+	for ; p.pos < len(p.query); p.pos++ {
+		c := p.query[p.pos]
+		switch {
+		case c == '.' || c == 'e' || c == 'E':
+			kind = gqlFloat
+		case (c == '+' || c == '-') && (p.query[p.pos-1] == 'e' || p.query[p.pos-1] == 'E'):
+		case c < '0' || c > '9':
+			return p.checkNumber(kind, p.query[start:p.pos])
+		}
+	}
+	return p.checkNumber(kind, p.query[start:])
+}
+
+func (p *gqlParser) checkNumber(kind gqlValueKind, raw string) (*gqlValue, error) {
+	if _, err := strconv.ParseFloat(raw, 64); err != nil {
+		return nil, p.syntaxError(fmt.Sprintf("invalid number '%s'", raw))
+	}
+	return &gqlValue{kind: kind, raw: raw}, nil
+}
+
+func (p *gqlParser) parseString() (string, error) {
+	if strings.HasPrefix(p.query[p.pos:], `"""`) {
+		// Block strings are taken verbatim, without the indentation handling of the GraphQL spec
+		end := strings.Index(p.query[p.pos+3:], `"""`)
+		if end < 0 {
+			return "", p.syntaxError("unterminated string")
+		}
+		s := p.query[p.pos+3 : p.pos+3+end]
+		p.pos += end + 6
+		return s, nil
+	}
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.query) {
+		c := p.query[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return sb.String(), nil
+		case c == '\n' || c == '\r':
+			return "", p.syntaxError("unterminated string")
+		case c == '\\' && p.pos+1 < len(p.query):
+			e := p.query[p.pos+1]
+			if unescaped, ok := gqlEscapes[e]; ok {
+				sb.WriteByte(unescaped)
+				p.pos += 2
+				continue
+			}
+			if e != 'u' || p.pos+6 > len(p.query) {
+				return "", p.syntaxError("invalid escape sequence")
+			}
+			r, err := strconv.ParseUint(p.query[p.pos+2:p.pos+6], 16, 32)
+			if err != nil {
+				return "", p.syntaxError("invalid escape sequence")
+			}
+			sb.WriteRune(rune(r))
+			p.pos += 6
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.syntaxError("unterminated string")
+}
@@ -0,0 +1,141 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGraphQLFull(t *testing.T) {
+	ops, err := parseGraphQL(context.Background(), `
+		# A named query, with variables
+		query Msgs($topic: String! = "t1", $tags: [String!], $n: Int) {
+			msgs: messages(topics: $topic, tag: ["a", "b\"\\\/\b\f\n\r\tc\u0041"], limit: 10, confirmed: null, state: confirmed, x: -1.5e+3, y: true) {
+				header { id }
+				data { value }
+			}
+		}
+		query Other { events(sequence: """>10""") { id } }
+	`, 0)
+	assert.NoError(t, err)
+	assert.Len(t, ops, 2)
+
+	op := ops[0]
+	assert.Equal(t, "Msgs", op.name)
+	assert.Len(t, op.variables, 3)
+	assert.Equal(t, "t1", op.variables[0].defaultValue.raw)
+	assert.Nil(t, op.variables[1].defaultValue)
+
+	f := op.selections[0]
+	assert.Equal(t, "msgs", f.alias)
+	assert.Equal(t, "messages", f.name)
+	assert.Len(t, f.args, 7)
+	assert.Equal(t, gqlVariable, f.args[0].value.kind)
+	assert.Equal(t, "topic", f.args[0].value.raw)
+	assert.Equal(t, gqlList, f.args[1].value.kind)
+	assert.Equal(t, "b\"\\/\b\f\n\r\tcA", f.args[1].value.list[1].raw)
+	assert.Equal(t, gqlInt, f.args[2].value.kind)
+	assert.Equal(t, gqlNull, f.args[3].value.kind)
+	assert.Equal(t, gqlEnum, f.args[4].value.kind)
+	assert.Equal(t, gqlFloat, f.args[5].value.kind)
+	assert.Equal(t, "-1.5e+3", f.args[5].value.raw)
+	assert.Equal(t, gqlBoolean, f.args[6].value.kind)
+	assert.Len(t, f.selections, 2)
+	assert.Equal(t, "id", f.selections[0].selections[0].name)
+
+	assert.Equal(t, "Other", ops[1].name)
+	assert.Equal(t, ">10", ops[1].selections[0].args[0].value.raw)
+}
+
+func TestParseGraphQLAnonymous(t *testing.T) {
+	ops, err := parseGraphQL(context.Background(), `{ messages(limit:1) { id } }`, 0)
+	assert.NoError(t, err)
+	assert.Len(t, ops, 1)
+	assert.Equal(t, "", ops[0].name)
+	assert.Equal(t, "1", ops[0].selections[0].args[0].value.raw)
+
+	ops, err = parseGraphQL(context.Background(), `query { data { id } }`, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "data", ops[0].selections[0].name)
+}
+
+func TestParseGraphQLErrors(t *testing.T) {
+	for query, errMsg := range map[string]string{
+		``:                                 "FF10465.*no operations",
+		`# comment only`:                   "FF10465.*no operations",
+		`mutation { a }`:                   "FF10466.*mutations",
+		`subscription { a }`:               "FF10466.*subscriptions",
+		`fragment f on Message { a }`:      "FF10466.*fragments",
+		`{ ...f }`:                         "FF10466.*fragments",
+		`query @dir { a }`:                 "FF10466.*directives",
+		`{ a @skip(if: true) }`:            "FF10466.*directives",
+		`{ a(b: {c: 1}) }`:                 "FF10466.*object values",
+		`other { a }`:                      "FF10465.*unexpected 'other'",
+		`1`:                                "FF10465.*expected name",
+		`{ }`:                              "FF10465.*empty selection set",
+		`{ a`:                              "FF10465.*expected name",
+		`{ a: }`:                           "FF10465.*expected name",
+		`{ a() }`:                          "FF10465.*expected name",
+		`{ a(b) }`:                         "FF10465.*expected ':'",
+		`{ a(b: ) }`:                       "FF10465.*expected value",
+		`{ a(b: [1) }`:                     "FF10465.*expected value",
+		`{ a(b: $) }`:                      "FF10465.*expected name",
+		`{ a(b: 1.2.3) }`:                  "FF10465.*invalid number '1.2.3'",
+		`{ a(b: -) }`:                      "FF10465.*invalid number '-'",
+		`{ a(b: "abc) }`:                   "FF10465.*unterminated string",
+		`{ a(b: "abc`:                      "FF10465.*unterminated string",
+		`{ a(b: "abc\`:                     "FF10465.*unterminated string",
+		"{ a(b: \"abc\n\") }":              "FF10465.*unterminated string",
+		`{ a(b: """abc) }`:                 "FF10465.*unterminated string",
+		`{ a(b: "\x") }`:                   "FF10465.*invalid escape sequence",
+		`{ a(b: "\u12") }`:                 "FF10465.*invalid escape sequence",
+		`{ a(b: "\uXXXX") }`:               "FF10465.*invalid escape sequence",
+		`{ a { } }`:                        "FF10465.*empty selection set",
+		`{ a(b: 1) { c(d: ) } }`:           "FF10465.*expected value",
+		`query Q(a: Int) { a }`:            "FF10465.*expected '\\$'",
+		`query Q($: Int) { a }`:            "FF10465.*expected name",
+		`query Q($a Int) { a }`:            "FF10465.*expected ':'",
+		`query Q($a: ) { a }`:              "FF10465.*expected name",
+		`query Q($a: [Int) { a }`:          "FF10465.*expected ']'",
+		`query Q($a: [) { a }`:             "FF10465.*expected name",
+		`query Q($a: Int = $b) { a }`:      "FF10465.*variables are not allowed",
+		`query Q($a: Int = [1, $b]) { a }`: "FF10465.*variables are not allowed",
+		`query Q($a: Int) a`:               "FF10465.*expected '{'",
+		`{ a(b: 1`:                         "FF10465.*expected name",
+		`{ a } b`:                          "FF10465.*unexpected 'b'",
+	} {
+		_, err := parseGraphQL(context.Background(), query, 0)
+		assert.Regexp(t, errMsg, err, query)
+	}
+}
+
+func TestParseGraphQLMaxDepth(t *testing.T) {
+	_, err := parseGraphQL(context.Background(), `{ messages { header { id } } }`, 3)
+	assert.NoError(t, err)
+
+	for _, query := range []string{
+		`{ messages { header { tag { x } } } }`,
+		`{ messages(topics: [[[["a"]]]]) { id } }`,
+		`query Q($a: [Int] = [[[[1]]]]) { a }`,
+	} {
+		_, err := parseGraphQL(context.Background(), query, 3)
+		assert.Regexp(t, "FF10510.*3", err, query)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/orchestratormocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testGraphQL(t *testing.T, o *orchestratormocks.Orchestrator, req *graphqlRequest) (int, fftypes.JSONObject) {
+	_, as := newTestServer()
+	r := as.createMuxRouter(context.Background(), o)
+	o.On("LoadShedding").Return(nil).Maybe()
+	var b bytes.Buffer
+	_ = json.NewEncoder(&b).Encode(req)
+	httpReq := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/graphql", &b)
+	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httpReq)
+	var body fftypes.JSONObject
+	_ = json.NewDecoder(res.Body).Decode(&body)
+	return res.Result().StatusCode, body
+}
+
+func filterString(t *testing.T, filter database.AndFilter) string {
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+	return fi.String()
+}
+
+func TestGraphQLMessagesWithRelations(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	msgID := fftypes.NewUUID()
+	batchID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	o.On("GetMessages", mock.Anything, "ns1", mock.MatchedBy(func(filter database.AndFilter) bool {
+		return filterString(t, filter) == "( tag == 'a' ) && ( ( topics == 't1' ) || ( topics == 't2' ) ) sort=-sequence limit=5"
+	})).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: msgID, Tag: "a", Topics: fftypes.FFStringArray{"t1"}}, BatchID: batchID},
+	}, nil, nil)
+	o.On("GetMessageData", mock.Anything, "ns1", msgID.String()).Return([]*fftypes.Data{
+		{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr(`{"some":"value","other":"ignored"}`)},
+	}, nil)
+	o.On("GetMessageTransaction", mock.Anything, "ns1", msgID.String()).Return(&fftypes.Transaction{ID: txID}, nil)
+	o.On("GetMessageEvents", mock.Anything, "ns1", msgID.String(), mock.MatchedBy(func(filter database.AndFilter) bool {
+		return filterString(t, filter) == "( type == 'message_confirmed' )"
+	})).Return([]*fftypes.Event{
+		{Type: fftypes.EventTypeMessageConfirmed, Reference: msgID},
+	}, nil, nil)
+	o.On("GetMessageByID", mock.Anything, "ns1", msgID.String()).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+	}, nil)
+	o.On("GetBatchByID", mock.Anything, "ns1", batchID.String()).Return(&fftypes.Batch{
+		ID:      batchID,
+		Payload: fftypes.BatchPayload{TX: fftypes.TransactionRef{ID: txID}},
+	}, nil)
+	o.On("GetTransactionByID", mock.Anything, "ns1", txID.String()).Return(&fftypes.Transaction{ID: txID}, nil)
+	o.On("GetTransactionOperations", mock.Anything, "ns1", txID.String()).Return([]*fftypes.Operation{
+		{Type: fftypes.OpTypeBlockchainBatchPin},
+	}, nil, nil)
+
+	status, body := testGraphQL(t, o, &graphqlRequest{
+		Query: `query Msgs($topics: [String!], $limit: Int, $tag: String = "a", $unset: String) {
+			__typename
+			msgs: messages(topics: $topics, tag: $tag, limit: $limit, sort: "sequence", descending: true, confirmed: $unset) {
+				__typename
+				header { id tag }
+				batchID: batch
+				data { value { some } }
+				dataRefs: data
+				transaction { id operations { type } }
+				events(type: message_confirmed) { type message { header { id } } }
+				batch { id transaction { id } }
+			}
+		}`,
+		Variables: map[string]interface{}{
+			"topics": []string{"t1", "t2"},
+			"limit":  5,
+		},
+	})
+	assert.Equal(t, 200, status)
+
+	expected := fmt.Sprintf(`{"data":{"__typename":"Query","msgs":[{
+		"__typename":"Message",
+		"header":{"id":"%[1]s","tag":"a"},
+		"batchID":"%[2]s",
+		"data":[{"value":{"some":"value"}}],
+		"dataRefs":null,
+		"transaction":{"id":"%[3]s","operations":[{"type":"blockchain_batch_pin"}]},
+		"events":[{"type":"message_confirmed","message":{"header":{"id":"%[1]s"}}}],
+		"batch":{"id":"%[2]s","transaction":{"id":"%[3]s"}}
+	}]}}`, msgID, batchID, txID)
+	actual, _ := json.Marshal(body)
+	assert.JSONEq(t, expected, string(actual))
+	o.AssertExpectations(t)
+}
+
+func TestGraphQLCollections(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	dataID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	o.On("GetData", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Data{{ID: dataID}}, nil, nil)
+	o.On("GetMessagesForData", mock.Anything, "ns1", dataID.String(), mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	o.On("GetEvents", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Event{
+		{Type: fftypes.EventTypeTransactionSubmitted, Reference: txID, Transaction: txID},
+		{Type: fftypes.EventTypeTransactionSubmitted, Reference: txID},
+	}, nil, nil)
+	o.On("GetTransactionByID", mock.Anything, "ns1", txID.String()).Return(&fftypes.Transaction{ID: txID}, nil)
+	o.On("GetTransactions", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Transaction{}, nil, nil)
+	o.On("GetBatches", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Batch{{}}, nil, nil)
+	o.On("GetOperations", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Operation{{}}, nil, nil)
+
+	status, body := testGraphQL(t, o, &graphqlRequest{
+		Query: `query A { data { id messages(limit: 1) { id } } }
+		query B {
+			data(skip: 1) { id }
+			events { message { id } tx { id } }
+			transactions { id }
+			batches { transaction { id } }
+			operations { tx { id } }
+		}`,
+		OperationName: "B",
+	})
+	assert.Equal(t, 200, status)
+	expected := fmt.Sprintf(`{"data":{
+		"data":[{"id":"%[1]s"}],
+		"events":[{"message":null,"tx":{"id":"%[2]s"}},{"message":null,"tx":null}],
+		"transactions":[],
+		"batches":[{"transaction":null}],
+		"operations":[{"tx":null}]
+	}}`, dataID, txID)
+	actual, _ := json.Marshal(body)
+	assert.JSONEq(t, expected, string(actual))
+
+	status, _ = testGraphQL(t, o, &graphqlRequest{
+		Query:         `query A { data { id messages(limit: 1) { id } } } query B { data { id } }`,
+		OperationName: "A",
+	})
+	assert.Equal(t, 200, status)
+	o.AssertExpectations(t)
+}
+
+func TestGraphQLMessageNoBatch(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("GetMessages", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Message{{}}, nil, nil)
+	status, body := testGraphQL(t, o, &graphqlRequest{
+		Query: `{ messages { batch { id } } }`,
+	})
+	assert.Equal(t, 200, status)
+	actual, _ := json.Marshal(body)
+	assert.JSONEq(t, `{"data":{"messages":[{"batch":null}]}}`, string(actual))
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	for query, errMsg := range map[string]string{
+		`{ messages { id }`:                                    "FF10465",
+		`query A { messages { id } } query B { data }`:         "FF10467",
+		`{ unknown { id } }`:                                   "FF10468.*unknown.*Query",
+		`{ messages { unknown } }`:                             "FF10468.*unknown.*Message",
+		`{ messages }`:                                         "FF10469.*messages.*Query",
+		`{ messages { transaction } }`:                         "FF10469.*transaction.*Message",
+		`{ messages(unknown: 1) { id } }`:                      "FF10470.*unknown.*messages",
+		`{ messages(count: true) { id } }`:                     "FF10470.*count.*messages",
+		`{ messages { header(a: 1) } }`:                        "FF10470.*a.*header",
+		`{ messages { transaction(a: 1) { id } } }`:            "FF10470.*a.*transaction",
+		`{ messages(tag: $tag) { id } }`:                       "FF10471.*tag",
+		`query Q($tag: String) { messages(tag: [$x]) { id } }`: "FF10471.*x",
+		`query Q($obj: JSON) { messages(tag: $obj) { id } }`:   "FF10472.*tag",
+		`query Q($obj: JSON) { messages(tag: [$obj]) { id } }`: "FF10472.*tag",
+		`query Q($objs: JSON) { messages(tag: $objs) { id } }`: "FF10472.*tag",
+	} {
+		o := &orchestratormocks.Orchestrator{}
+		o.On("GetMessages", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Message{{}}, nil, nil).Maybe()
+		status, body := testGraphQL(t, o, &graphqlRequest{
+			Query: query,
+			Variables: map[string]interface{}{
+				"obj":  map[string]interface{}{"a": "b"},
+				"objs": []interface{}{map[string]interface{}{"a": "b"}},
+			},
+		})
+		assert.Equal(t, 400, status, query)
+		assert.Regexp(t, errMsg, body["error"], query)
+	}
+}
+
+func TestGraphQLVariableTypes(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("GetMessages", mock.Anything, "ns1", mock.MatchedBy(func(filter database.AndFilter) bool {
+		return filterString(t, filter) == "( ( tag == '1' ) || ( tag == 'true' ) || ( tag == 'x' ) ) && ( ( topics == 'a' ) || ( topics == 'b' ) )"
+	})).Return([]*fftypes.Message{}, nil, nil)
+	status, _ := testGraphQL(t, o, &graphqlRequest{
+		Query: `query Q($tags: [String]) { messages(tag: $tags, topics: ["a", "b"], cid: null) { id } }`,
+		Variables: map[string]interface{}{
+			"tags": []interface{}{1, true, "x", nil},
+		},
+	})
+	assert.Equal(t, 200, status)
+	o.AssertExpectations(t)
+}
+
+func TestGraphQLBadJSON(t *testing.T) {
+	_, r := newTestAPIServer()
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/graphql", bytes.NewReader([]byte(`!json`)))
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 400, res.Result().StatusCode)
+}
+
+func TestGraphQLRootFail(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("GetMessages", mock.Anything, "ns1", mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	status, body := testGraphQL(t, o, &graphqlRequest{Query: `{ messages { id } }`})
+	assert.Equal(t, 500, status)
+	assert.Regexp(t, "pop", body["error"])
+}
+
+func TestGraphQLRelationFail(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("GetMessages", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Message{{}}, nil, nil)
+	o.On("GetMessageData", mock.Anything, "ns1", mock.Anything).Return(nil, fmt.Errorf("pop"))
+	status, body := testGraphQL(t, o, &graphqlRequest{Query: `{ messages { data { id } } }`})
+	assert.Equal(t, 500, status)
+	assert.Regexp(t, "pop", body["error"])
+}
+
+func TestGraphQLRelationFilterFail(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	o.On("GetData", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Data{{ID: fftypes.NewUUID()}}, nil, nil)
+	status, body := testGraphQL(t, o, &graphqlRequest{Query: `{ data { messages(bad: 1) { id } } }`})
+	assert.Equal(t, 400, status)
+	assert.Regexp(t, "FF10470", body["error"])
+}
+
+func TestGraphQLMaxFilterSkip(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	as := &apiServer{maxFilterSkip: 10}
+	_, err := as.executeGraphQL(context.Background(), o, "ns1", &graphqlRequest{
+		Query: `{ messages(skip: 11) { id } }`,
+	})
+	assert.Regexp(t, "FF10183", err)
+}
+
+func TestProjectJSON(t *testing.T) {
+	ops, err := parseGraphQL(context.Background(), `{ items { a { b } c { d } } }`, 0)
+	assert.NoError(t, err)
+	var v interface{}
+	err = json.Unmarshal([]byte(`[{"a":[{"b":1,"x":2},{"b":3}],"c":"scalar","y":4}]`), &v)
+	assert.NoError(t, err)
+	result, _ := json.Marshal(projectJSON(v, ops[0].selections[0].selections))
+	assert.JSONEq(t, `[{"a":[{"b":1},{"b":3}],"c":"scalar"}]`, string(result))
+}
+
+func TestGraphQLNestedDefaultLimit(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	dataID := fftypes.NewUUID()
+	o.On("GetData", mock.Anything, "ns1", mock.MatchedBy(func(filter database.AndFilter) bool {
+		return filterString(t, filter) == " limit=25"
+	})).Return([]*fftypes.Data{{ID: dataID}}, nil, nil)
+	o.On("GetMessagesForData", mock.Anything, "ns1", dataID.String(), mock.MatchedBy(func(filter database.AndFilter) bool {
+		return filterString(t, filter) == " limit=3"
+	})).Return([]*fftypes.Message{}, nil, nil).Once()
+	o.On("GetMessagesForData", mock.Anything, "ns1", dataID.String(), mock.MatchedBy(func(filter database.AndFilter) bool {
+		return filterString(t, filter) == " limit=7"
+	})).Return([]*fftypes.Message{}, nil, nil).Once()
+
+	as := &apiServer{defaultFilterLimit: 25, graphqlNestedLimit: 3}
+	_, err := as.executeGraphQL(context.Background(), o, "ns1", &graphqlRequest{
+		Query: `{ data { defaulted: messages { id } explicit: messages(limit: 7) { id } } }`,
+	})
+	assert.NoError(t, err)
+
+	o.AssertExpectations(t)
+}
+
+func TestGraphQLMaxDepth(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	as := &apiServer{graphqlMaxDepth: 3}
+	_, err := as.executeGraphQL(context.Background(), o, "ns1", &graphqlRequest{
+		Query: `{ messages { batch { transaction { operations { id } } } } }`,
+	})
+	assert.Regexp(t, "FF10510", err)
+	o.AssertExpectations(t)
+}
+
+func TestGraphQLMaxCost(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	as := &apiServer{defaultFilterLimit: 25, maxFilterLimit: 250, graphqlNestedLimit: 10, graphqlMaxCost: 100}
+	for _, query := range []string{
+		// 25 messages, each with 10 events
+		`{ messages(tag: "a") { events { id } } }`,
+		// The cyclic relations multiply the cost at each level
+		`{ messages(limit: 2) { events(limit: 2) { message { events(limit: 2) { message { data { id } } } } } } }`,
+		// A limit of zero is unlimited
+		`query Q($limit: Int = 0) { messages(limit: $limit) { id } }`,
+		// Limits are capped before they are multiplied
+		`{ messages(limit: 100000000000) { events(limit: 100000000000) { id } } }`,
+		// Each top level field adds to the cost
+		`{ a: messages(limit: 60) { id } b: events(limit: 60) { id } }`,
+	} {
+		_, err := as.executeGraphQL(context.Background(), o, "ns1", &graphqlRequest{Query: query})
+		assert.Regexp(t, "FF10511.*100", err, query)
+	}
+	o.AssertExpectations(t)
+}
+
+func TestGraphQLWithinMaxCost(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	msgID := fftypes.NewUUID()
+	o.On("GetMessages", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: msgID}},
+	}, nil, nil)
+	o.On("GetMessageEvents", mock.Anything, "ns1", msgID.String(), mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+	o.On("GetMessageData", mock.Anything, "ns1", msgID.String()).Return([]*fftypes.Data{}, nil)
+
+	// 5 messages, each with 10 events and 10 data, and the header read from the message
+	as := &apiServer{defaultFilterLimit: 25, maxFilterLimit: 250, graphqlNestedLimit: 10, graphqlMaxCost: 105}
+	_, err := as.executeGraphQL(context.Background(), o, "ns1", &graphqlRequest{
+		Query:     `query Q($limit: Int) { messages(limit: $limit) { header { id } events { id } data { id } } }`,
+		Variables: map[string]interface{}{"limit": json.Number("5")},
+	})
+	assert.NoError(t, err)
+
+	o.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// getSparseFields returns the JSON paths requested with the fields query parameter, which can
+// be repeated, or contain a comma separated list
+func (as *apiServer) getSparseFields(form url.Values) (fields []string) {
+	for _, fv := range as.getValues(form, "fields") {
+		for _, field := range strings.Split(fv, ",") {
+			field = strings.TrimSpace(field)
+			if field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// sparseFieldSelections builds the selections to project each item of the output of a route onto,
+// from a set of dot separated JSON paths. The paths are checked against the output type of the
+// route, with a warning for those that are unknown.
+func sparseFieldSelections(ctx context.Context, route *oapispec.Route, fields []string) []*gqlField {
+	var outputType reflect.Type
+	if route.JSONOutputValue != nil {
+		outputType = reflect.TypeOf(route.JSONOutputValue())
+	}
+	var selections []*gqlField
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		if outputType != nil && !isJSONFieldPath(outputType, path) {
+			apiwarnings.Add(ctx, i18n.MsgWarnUnknownSparseField, field)
+		}
+		selections = addSparseFieldSelection(selections, path)
+	}
+	return selections
+}
+
+func addSparseFieldSelection(selections []*gqlField, path []string) []*gqlField {
+	for _, s := range selections {
+		if s.name == path[0] {
+			if len(path) == 1 || (len(s.selections) == 0) {
+				// Selecting the whole object, takes precedence over its sub-fields
+				s.selections = nil
+			} else {
+				s.selections = addSparseFieldSelection(s.selections, path[1:])
+			}
+			return selections
+		}
+	}
+	s := &gqlField{name: path[0], alias: path[0]}
+	if len(path) > 1 {
+		s.selections = addSparseFieldSelection(nil, path[1:])
+	}
+	return append(selections, s)
+}
+
+// isJSONFieldPath checks a path exists in the JSON serialization of a type. Paths within maps,
+// interfaces, and types with their own JSON serialization, cannot be checked.
+func isJSONFieldPath(t reflect.Type, path []string) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	switch {
+	case len(path) == 0:
+		return true
+	case t.Kind() == reflect.Map || t.Kind() == reflect.Interface ||
+		t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return true
+	case t.Kind() != reflect.Struct:
+		return false
+	}
+	f, ok := jsonStructField(t, path[0])
+	return ok && isJSONFieldPath(f.Type, path[1:])
+}
+
+// jsonStructField finds the field of a struct with a JSON name, using the same rules as addJSONFields
+func jsonStructField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case jsonName == "-" || f.PkgPath != "":
+		case f.Anonymous && jsonName == "" && f.Type.Kind() == reflect.Struct:
+			if ef, ok := jsonStructField(f.Type, name); ok {
+				return ef, true
+			}
+		case jsonName == "" && f.Name == name, jsonName == name:
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// projectSparseFields reduces each item in the output of a collection route, to the selected fields
+func projectSparseFields(output interface{}, selections []*gqlField) interface{} {
+	if fr, ok := output.(*filterResultsWithCount); ok {
+		projected := *fr
+		projected.Items = projectSparseFields(fr.Items, selections)
+		return &projected
+	}
+	b, err := json.Marshal(output)
+	if err != nil {
+		// Left for the error to be reported when the output is serialized
+		return output
+	}
+	var generic interface{}
+	_ = json.Unmarshal(b, &generic)
+	return projectJSON(generic, selections)
+}
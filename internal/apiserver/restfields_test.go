@@ -0,0 +1,110 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetMessagesSparseFields(t *testing.T) {
+	o, r := newTestAPIServer()
+	msgID := fftypes.NewUUID()
+	o.On("GetMessages", mock.Anything, "ns1", mock.MatchedBy(func(filter database.AndFilter) bool {
+		fi, _ := filter.Finalize()
+		return reflect.DeepEqual(fi.RequiredFields, []string{"header.id", "header.tag", "state", "unknown"})
+	})).Return([]*fftypes.Message{
+		{
+			Header: fftypes.MessageHeader{ID: msgID, Tag: "tag1", Topics: fftypes.FFStringArray{"topic1"}},
+			State:  fftypes.MessageStateConfirmed,
+		},
+	}, nil, nil)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/ns1/messages?fields=header.id,header.tag&fields=state,,unknown", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	assert.Regexp(t, "FF10480.*unknown", res.Result().Header.Get(fftypes.HTTPHeadersWarning))
+	var resJSON []map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"header":  map[string]interface{}{"id": msgID.String(), "tag": "tag1"},
+			"state":   "confirmed",
+			"unknown": nil,
+		},
+	}, resJSON)
+}
+
+func TestGetMessagesSparseFieldsWithCount(t *testing.T) {
+	o, r := newTestAPIServer()
+	var total int64 = 10
+	o.On("GetMessages", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{Tag: "tag1"}},
+	}, &database.FilterResult{TotalCount: &total}, nil)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/ns1/messages?fields=header.tag&count", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Equal(t, float64(10), resJSON["total"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"header": map[string]interface{}{"tag": "tag1"}},
+	}, resJSON["items"])
+}
+
+func TestSparseFieldSelectionsWholeObject(t *testing.T) {
+	selections := sparseFieldSelections(context.Background(), getMsgs, []string{"header.id", "header", "header.tag", "data.id"})
+	assert.Len(t, selections, 2)
+	assert.Equal(t, "header", selections[0].name)
+	assert.Nil(t, selections[0].selections)
+	assert.Equal(t, "data", selections[1].name)
+	assert.Equal(t, "id", selections[1].selections[0].name)
+}
+
+func TestSparseFieldSelectionsNoOutput(t *testing.T) {
+	selections := sparseFieldSelections(context.Background(), &oapispec.Route{}, []string{"anything"})
+	assert.Len(t, selections, 1)
+}
+
+func TestIsJSONFieldPath(t *testing.T) {
+	msgType := reflect.TypeOf([]*fftypes.Message{})
+	assert.True(t, isJSONFieldPath(msgType, []string{"header", "author"}))
+	assert.True(t, isJSONFieldPath(msgType, []string{"header", "headers", "anything"}))
+	assert.True(t, isJSONFieldPath(msgType, []string{"data", "hash"}))
+	assert.True(t, isJSONFieldPath(msgType, []string{"confirmed", "anything"}))
+	assert.False(t, isJSONFieldPath(msgType, []string{"sequence"}))
+	assert.False(t, isJSONFieldPath(msgType, []string{"header", "tag", "anything"}))
+	assert.False(t, isJSONFieldPath(msgType, []string{"header", "missing"}))
+	assert.True(t, isJSONFieldPath(reflect.TypeOf(struct{ NoTag string }{}), []string{"NoTag"}))
+}
+
+func TestProjectSparseFieldsMarshalFail(t *testing.T) {
+	output := map[bool]bool{true: false}
+	assert.Equal(t, output, projectSparseFields(output, []*gqlField{{name: "a", alias: "a"}}))
+}
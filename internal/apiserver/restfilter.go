@@ -26,15 +26,24 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/oapispec"
 	"github.com/hyperledger/firefly/pkg/database"
 )
 
+// filterLimitWarnPercent is the percentage of the maximum filter limit, above which we warn the caller
+const filterLimitWarnPercent = 90
+
+// filterReservedParams are the query parameters processed by buildFilter itself, rather than as field filters
+var filterReservedParams = []string{"skip", "limit", "sort", "descending", "ascending", "count", "fields"}
+
 type filterResultsWithCount struct {
-	Count int64       `json:"count"`
-	Total int64       `json:"total"`
-	Items interface{} `json:"items"`
+	Count    int64       `json:"count"`
+	Total    int64       `json:"total"`
+	Items    interface{} `json:"items"`
+	Warnings []string    `json:"warnings,omitempty"`
 }
 
 type filterModifiers struct {
@@ -73,15 +82,25 @@ func (as *apiServer) getValues(values url.Values, key string) (results []string)
 }
 
 func (as *apiServer) buildFilter(req *http.Request, ff database.QueryFactory) (database.AndFilter, error) {
-	ctx := req.Context()
-	log.L(ctx).Debugf("Query: %s", req.URL.RawQuery)
+	log.L(req.Context()).Debugf("Query: %s", req.URL.RawQuery)
+	_ = req.ParseForm()
+	filter, err := as.buildFilterFromValues(req.Context(), req.Form, ff)
+	if err == nil {
+		// Allows the database to skip reading fields that will not be returned
+		filter.RequiredFields(as.getSparseFields(req.Form)...)
+	}
+	return filter, err
+}
+
+// buildFilterFromValues builds a filter from a set of named values, with the same syntax for each value as
+// the query parameters of the REST API. It is shared by the REST collection routes, and by GraphQL arguments.
+func (as *apiServer) buildFilterFromValues(ctx context.Context, form url.Values, ff database.QueryFactory) (database.AndFilter, error) {
 	fb := ff.NewFilterLimit(ctx, as.defaultFilterLimit)
 	possibleFields := fb.Fields()
 	sort.Strings(possibleFields)
 	filter := fb.And()
-	_ = req.ParseForm()
 	for _, field := range possibleFields {
-		values := as.getValues(req.Form, field)
+		values := as.getValues(form, field)
 		if len(values) == 1 {
 			cond, err := as.getCondition(ctx, fb, field, values[0])
 			if err != nil {
@@ -101,44 +120,101 @@ func (as *apiServer) buildFilter(req *http.Request, ff database.QueryFactory) (d
 			filter.Condition(fb.Or(fs...))
 		}
 	}
-	skipVals := as.getValues(req.Form, "skip")
+	skipVals := as.getValues(form, "skip")
 	if len(skipVals) > 0 {
 		s, _ := strconv.ParseUint(skipVals[0], 10, 64)
 		if as.maxFilterSkip != 0 && s > as.maxFilterSkip {
-			return nil, i18n.NewError(req.Context(), i18n.MsgMaxFilterSkip, as.maxFilterSkip)
+			return nil, i18n.NewError(ctx, i18n.MsgMaxFilterSkip, as.maxFilterSkip)
 		}
 		filter.Skip(s)
 	}
-	limitVals := as.getValues(req.Form, "limit")
+	limitVals := as.getValues(form, "limit")
 	if len(limitVals) > 0 {
 		l, _ := strconv.ParseUint(limitVals[0], 10, 64)
 		if as.maxFilterLimit != 0 && l > as.maxFilterLimit {
-			return nil, i18n.NewError(req.Context(), i18n.MsgMaxFilterLimit, as.maxFilterLimit)
+			return nil, i18n.NewError(ctx, i18n.MsgMaxFilterLimit, as.maxFilterLimit)
+		}
+		if as.maxFilterLimit != 0 && l*100 >= as.maxFilterLimit*filterLimitWarnPercent {
+			apiwarnings.Add(ctx, i18n.MsgWarnFilterLimitNearMax, l, as.maxFilterLimit)
 		}
 		filter.Limit(l)
 	}
-	sortVals := as.getValues(req.Form, "sort")
+	sortVals := as.getValues(form, "sort")
 	for _, sv := range sortVals {
 		subSortVals := strings.Split(sv, ",")
 		for _, ssv := range subSortVals {
 			ssv = strings.TrimSpace(ssv)
 			if ssv != "" {
+				if !isFilterField(possibleFields, strings.TrimPrefix(ssv, "-")) {
+					apiwarnings.Add(ctx, i18n.MsgWarnUnknownSortField, ssv)
+				}
 				filter.Sort(ssv)
 			}
 		}
 	}
-	descendingVals := as.getValues(req.Form, "descending")
-	ascendingVals := as.getValues(req.Form, "ascending")
+	descendingVals := as.getValues(form, "descending")
+	ascendingVals := as.getValues(form, "ascending")
 	if len(descendingVals) > 0 && (descendingVals[0] == "" || strings.EqualFold(descendingVals[0], "true")) {
 		filter.Descending()
 	} else if len(ascendingVals) > 0 && (ascendingVals[0] == "" || strings.EqualFold(ascendingVals[0], "true")) {
 		filter.Ascending()
 	}
-	countVals := as.getValues(req.Form, "count")
+	countVals := as.getValues(form, "count")
 	filter.Count(len(countVals) > 0 && (countVals[0] == "" || strings.EqualFold(countVals[0], "true")))
 	return filter, nil
 }
 
+func isFilterField(fields []string, name string) bool {
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownParam(known []string, name string) bool {
+	for _, k := range known {
+		// Consistent with getValues, query parameter names are case insensitive
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkQueryParams warns the caller about query parameters that are deprecated, or that will
+// be silently ignored, before they become a problem for the application
+func (as *apiServer) checkQueryParams(ctx context.Context, req *http.Request, route *oapispec.Route) {
+	if route.Deprecated {
+		apiwarnings.Add(ctx, i18n.MsgWarnDeprecatedRoute, req.Method, req.URL.Path)
+	}
+	query := req.URL.Query()
+	known := make([]string, 0, len(route.QueryParams))
+	for _, qp := range route.QueryParams {
+		known = append(known, qp.Name)
+		if _, supplied := query[qp.Name]; supplied && qp.Deprecated {
+			apiwarnings.Add(ctx, i18n.MsgWarnDeprecatedQueryParam, qp.Name)
+		}
+	}
+	if route.FilterFactory == nil {
+		// Without a filter, unrecognized parameters are not in conflict with anything
+		return
+	}
+	known = append(known, filterReservedParams...)
+	known = append(known, route.FilterFactory.NewFilter(ctx).Fields()...)
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !isKnownParam(known, name) {
+			apiwarnings.Add(ctx, i18n.MsgWarnUnknownQueryParam, name)
+		}
+	}
+}
+
 func (as *apiServer) checkNoMods(ctx context.Context, mods filterModifiers, field, op string, filter database.Filter) (database.Filter, error) {
 	emptyModifiers := filterModifiers{}
 	if mods != emptyModifiers {
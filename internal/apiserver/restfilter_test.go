@@ -21,6 +21,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/internal/oapispec"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/stretchr/testify/assert"
 )
@@ -147,3 +149,68 @@ func TestBuildFilterLimitLimit(t *testing.T) {
 	_, err := as.buildFilter(req, database.MessageQueryFactory)
 	assert.Regexp(t, "FF10184.*500", err)
 }
+
+func TestBuildFilterWarnUnknownSortField(t *testing.T) {
+	as := &apiServer{
+		maxFilterLimit: 250,
+	}
+	req := httptest.NewRequest("GET", "/things?sort=-tag,-wrong", nil)
+	ctx := apiwarnings.WithWarnings(req.Context())
+	req = req.WithContext(ctx)
+	filter, err := as.buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, " sort=-tag", fi.String())
+	warnings := apiwarnings.Get(ctx)
+	assert.Len(t, warnings, 1)
+	assert.Regexp(t, "FF10363.*-wrong", warnings[0])
+}
+
+func TestBuildFilterWarnLimitNearMax(t *testing.T) {
+	as := &apiServer{
+		maxFilterLimit: 250,
+	}
+	req := httptest.NewRequest("GET", "/things?limit=225", nil)
+	ctx := apiwarnings.WithWarnings(req.Context())
+	req = req.WithContext(ctx)
+	_, err := as.buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	warnings := apiwarnings.Get(ctx)
+	assert.Len(t, warnings, 1)
+	assert.Regexp(t, "FF10368.*225.*250", warnings[0])
+}
+
+func TestCheckQueryParams(t *testing.T) {
+	as := &apiServer{}
+	route := &oapispec.Route{
+		Name: "testRoute",
+		QueryParams: []*oapispec.QueryParam{
+			{Name: "fetchdata", IsBool: true},
+			{Name: "oldparam", Deprecated: true},
+			{Name: "unused", Deprecated: true},
+		},
+		FilterFactory: database.MessageQueryFactory,
+		Deprecated:    true,
+	}
+	req := httptest.NewRequest("GET", "/things?TAG=abc&fetchdata&oldparam=x&zzz=1&aaa=2&limit=1&Sort=tag", nil)
+	ctx := apiwarnings.WithWarnings(req.Context())
+	as.checkQueryParams(ctx, req, route)
+	warnings := apiwarnings.Get(ctx)
+	assert.Len(t, warnings, 4)
+	assert.Regexp(t, "FF10365.*GET /things", warnings[0])
+	assert.Regexp(t, "FF10366.*oldparam", warnings[1])
+	assert.Regexp(t, "FF10364.*aaa", warnings[2])
+	assert.Regexp(t, "FF10364.*zzz", warnings[3])
+}
+
+func TestCheckQueryParamsNoFilter(t *testing.T) {
+	as := &apiServer{}
+	route := &oapispec.Route{
+		Name: "testRoute",
+	}
+	req := httptest.NewRequest("GET", "/things?zzz=1", nil)
+	ctx := apiwarnings.WithWarnings(req.Context())
+	as.checkQueryParams(ctx, req, route)
+	assert.Empty(t, apiwarnings.Get(ctx))
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/rbac"
 )
 
 var deleteConfigRecord = &oapispec.Route{
@@ -36,6 +37,7 @@ var deleteConfigRecord = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: nil,
 	JSONOutputCodes: []int{http.StatusNoContent},
+	RequiredRole:    rbac.RoleAdmin,
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		err = getOr(r.Ctx).DeleteConfigRecord(r.Ctx, r.PP["key"])
 		return nil, err
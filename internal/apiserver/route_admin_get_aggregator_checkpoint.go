@@ -0,0 +1,46 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/rbac"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var getAggregatorCheckpoint = &oapispec.Route{
+	Name:       "getAggregatorCheckpoint",
+	Path:       "aggregator/checkpoint",
+	Method:     http.MethodGet,
+	PathParams: nil,
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "ledger", Description: i18n.MsgLedgerNameDesc},
+	},
+	FilterFactory:   nil,
+	Description:     i18n.MsgTBD,
+	JSONInputValue:  nil,
+	JSONOutputValue: func() interface{} { return &fftypes.Offset{} },
+	JSONOutputCodes: []int{http.StatusOK},
+	RequiredRole:    rbac.RoleAdmin,
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		output, err = getOr(r.Ctx).GetAggregatorCheckpoint(r.Ctx, r.QP["ledger"])
+		return output, err
+	},
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/rbac"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -35,6 +36,7 @@ var getConfig = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.JSONObject{} },
 	JSONOutputCodes: []int{http.StatusOK},
+	RequiredRole:    rbac.RoleAdmin,
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output = getOr(r.Ctx).GetConfig(r.Ctx)
 		return output, nil
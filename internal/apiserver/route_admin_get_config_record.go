@@ -21,6 +21,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/rbac"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -38,6 +39,7 @@ var getConfigRecord = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return fftypes.JSONAnyPtr("{}") },
 	JSONOutputCodes: []int{http.StatusOK},
+	RequiredRole:    rbac.RoleAdmin,
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		configRecord, err := getOr(r.Ctx).GetConfigRecord(r.Ctx, r.PP["key"])
 		return configRecord.Value, err
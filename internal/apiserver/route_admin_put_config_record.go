@@ -22,6 +22,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/rbac"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -60,6 +61,7 @@ var putConfigRecord = &oapispec.Route{
 	JSONInputValue:  func() interface{} { return fftypes.JSONAnyPtr("{}") },
 	JSONOutputValue: nil,
 	JSONOutputCodes: []int{http.StatusOK},
+	RequiredRole:    rbac.RoleAdmin,
 	JSONInputSchema: func(ctx context.Context) string { return anyJSONSchema },
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = getOr(r.Ctx).PutConfigRecord(r.Ctx, r.PP["key"], r.Input.(*fftypes.JSONAny))
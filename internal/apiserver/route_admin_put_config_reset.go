@@ -22,6 +22,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/rbac"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -36,6 +37,7 @@ var postResetConfig = &oapispec.Route{
 	JSONInputValue:  func() interface{} { return fftypes.JSONAnyPtr("{}") },
 	JSONOutputValue: nil,
 	JSONOutputCodes: []int{http.StatusNoContent},
+	RequiredRole:    rbac.RoleAdmin,
 	JSONInputSchema: func(ctx context.Context) string { return emptyObjectSchema },
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		getOr(r.Ctx).ResetConfig(r.Ctx)
@@ -36,6 +36,8 @@ var getMsgByID = &oapispec.Route{
 	},
 	QueryParams: []*oapispec.QueryParam{
 		{Name: "fetchdata", IsBool: true, Description: i18n.MsgFetchDataDesc},
+		{Name: "waitForState", Description: i18n.MsgWaitForStateDesc},
+		{Name: "timeout", Description: i18n.MsgLongPollTimeoutDesc},
 	},
 	FilterFactory:   nil,
 	Description:     i18n.MsgTBD,
@@ -43,7 +45,18 @@ var getMsgByID = &oapispec.Route{
 	JSONOutputValue: func() interface{} { return &fftypes.MessageInOut{} }, // can include full values
 	JSONOutputCodes: []int{http.StatusOK},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
-		if strings.EqualFold(r.QP["data"], "true") || strings.EqualFold(r.QP["fetchdata"], "true") {
+		fetchData := strings.EqualFold(r.QP["data"], "true") || strings.EqualFold(r.QP["fetchdata"], "true")
+		if waitForState := r.QP["waitForState"]; waitForState != "" {
+			timeout, err := getLongPollTimeout(r)
+			if err != nil {
+				return nil, err
+			}
+			msg, err := getOr(r.Ctx).WaitForMessageState(r.Ctx, r.PP["ns"], r.PP["msgid"], fftypes.MessageState(waitForState), timeout)
+			if err != nil || !fetchData {
+				return msg, err
+			}
+		}
+		if fetchData {
 			return getOr(r.Ctx).GetMessageByIDWithData(r.Ctx, r.PP["ns"], r.PP["msgid"])
 		}
 		return getOr(r.Ctx).GetMessageByID(r.Ctx, r.PP["ns"], r.PP["msgid"])
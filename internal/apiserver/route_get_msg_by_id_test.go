@@ -17,9 +17,12 @@
 package apiserver
 
 import (
+	"context"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -50,3 +53,58 @@ func TestGetMessageByIDWithData(t *testing.T) {
 
 	assert.Equal(t, 200, res.Result().StatusCode)
 }
+
+func TestGetMessageByIDWaitForState(t *testing.T) {
+	o, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/messages/abcd12345?waitForState=confirmed&timeout=5s", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	o.On("WaitForMessageState", mock.Anything, "mynamespace", "abcd12345", fftypes.MessageStateConfirmed, 5*time.Second).
+		Return(&fftypes.Message{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	o.AssertExpectations(t)
+}
+
+func TestGetMessageByIDWaitForStateWithData(t *testing.T) {
+	o, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/messages/abcd12345?waitForState=confirmed&fetchdata", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	o.On("WaitForMessageState", mock.Anything, "mynamespace", "abcd12345", fftypes.MessageStateConfirmed, 30*time.Second).
+		Return(&fftypes.Message{}, nil)
+	o.On("GetMessageByIDWithData", mock.Anything, "mynamespace", "abcd12345").
+		Return(&fftypes.MessageInOut{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	o.AssertExpectations(t)
+}
+
+func TestGetMessageByIDWaitForStateBadTimeout(t *testing.T) {
+	_, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/messages/abcd12345?waitForState=confirmed&timeout=bad", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 400, res.Result().StatusCode)
+	assert.Regexp(t, "FF10375", res.Body.String())
+}
+
+func TestGetMessageByIDWaitForStateFail(t *testing.T) {
+	o, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/messages/abcd12345?waitForState=wrong", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	o.On("WaitForMessageState", mock.Anything, "mynamespace", "abcd12345", fftypes.MessageState("wrong"), 30*time.Second).
+		Return(nil, i18n.NewError(context.Background(), i18n.MsgInvalidWaitForState, "wrong", ""))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 400, res.Result().StatusCode)
+}
@@ -33,13 +33,23 @@ var getOpByID = &oapispec.Route{
 		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
 		{Name: "opid", Description: i18n.MsgTBD},
 	},
-	QueryParams:     nil,
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "waitForState", Description: i18n.MsgWaitForStateDesc},
+		{Name: "timeout", Description: i18n.MsgLongPollTimeoutDesc},
+	},
 	FilterFactory:   nil,
 	Description:     i18n.MsgTBD,
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return &fftypes.Operation{} },
 	JSONOutputCodes: []int{http.StatusOK},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		if waitForState := r.QP["waitForState"]; waitForState != "" {
+			timeout, err := getLongPollTimeout(r)
+			if err != nil {
+				return nil, err
+			}
+			return getOr(r.Ctx).WaitForOperationState(r.Ctx, r.PP["ns"], r.PP["opid"], fftypes.OpStatus(waitForState), timeout)
+		}
 		output, err = getOr(r.Ctx).GetOperationByID(r.Ctx, r.PP["ns"], r.PP["opid"])
 		return output, err
 	},
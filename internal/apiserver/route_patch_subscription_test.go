@@ -0,0 +1,43 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPatchSubscription(t *testing.T) {
+	o, r := newTestAPIServer()
+	u := fftypes.NewUUID()
+	req := httptest.NewRequest("PATCH", fmt.Sprintf("/api/v1/namespaces/ns1/subscriptions/%s", u), bytes.NewReader([]byte(`{"options":{"readAhead":50}}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	res := httptest.NewRecorder()
+
+	o.On("PatchSubscription", mock.Anything, "ns1", u.String(), fftypes.JSONObject{
+		"options": map[string]interface{}{"readAhead": float64(50)},
+	}).Return(&fftypes.Subscription{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
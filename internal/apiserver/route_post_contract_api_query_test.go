@@ -41,7 +41,7 @@ func TestPostContractAPIQuery(t *testing.T) {
 
 	mcm.On("InvokeContractAPI", mock.Anything, "ns1", "banana", "peel", mock.MatchedBy(func(req *fftypes.ContractCallRequest) bool {
 		return req.Type == fftypes.CallTypeQuery
-	})).Return("banana", nil)
+	}), false).Return("banana", nil)
 	r.ServeHTTP(res, req)
 
 	assert.Equal(t, 200, res.Result().StatusCode)
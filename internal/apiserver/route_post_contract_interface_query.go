@@ -48,6 +48,6 @@ var postContractInterfaceQuery = &oapispec.Route{
 			return nil, err
 		}
 		req.Method = &fftypes.FFIMethod{Pathname: r.PP["methodPath"]}
-		return getOr(r.Ctx).Contracts().InvokeContract(r.Ctx, r.PP["ns"], req)
+		return getOr(r.Ctx).Contracts().InvokeContract(r.Ctx, r.PP["ns"], req, false)
 	},
 }
@@ -43,7 +43,7 @@ func TestPostContractInterfaceQuery(t *testing.T) {
 
 	mcm.On("InvokeContract", mock.Anything, "ns1", mock.MatchedBy(func(req *fftypes.ContractCallRequest) bool {
 		return req.Type == fftypes.CallTypeQuery && *req.Interface == *interfaceID
-	})).Return("banana", nil)
+	}), false).Return("banana", nil)
 	r.ServeHTTP(res, req)
 
 	assert.Equal(t, 200, res.Result().StatusCode)
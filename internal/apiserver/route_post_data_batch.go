@@ -0,0 +1,53 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var postDataBatch = &oapispec.Route{
+	Name:   "postDataBatch",
+	Path:   "namespaces/{ns}/data/batch",
+	Method: http.MethodPost,
+	PathParams: []*oapispec.PathParam{
+		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
+	},
+	QueryParams:     nil,
+	FilterFactory:   nil,
+	Description:     i18n.MsgPostDataBatchDescription,
+	JSONInputValue:  func() interface{} { return &[]*fftypes.DataRefOrValue{} },
+	JSONInputMask:   nil,
+	JSONOutputValue: func() interface{} { return []*fftypes.Data{} },
+	JSONOutputCodes: []int{http.StatusCreated},
+	Transactional:   true,
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		inputs := *r.Input.(*[]*fftypes.DataRefOrValue)
+		data := make([]*fftypes.Data, len(inputs))
+		for i, input := range inputs {
+			if data[i], err = getOr(r.Ctx).Data().UploadJSON(r.Ctx, r.PP["ns"], input); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	},
+}
@@ -0,0 +1,94 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/mocks/orchestratormocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockRunAsGroup(o *orchestratormocks.Orchestrator) *mock.Call {
+	rag := o.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	return rag
+}
+
+func TestPostDataBatch(t *testing.T) {
+	o, r := newTestAPIServer()
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+	mockRunAsGroup(o)
+	input := []*fftypes.DataRefOrValue{
+		{Value: fftypes.JSONAnyPtr(`"one"`)},
+		{Value: fftypes.JSONAnyPtr(`"two"`)},
+	}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(&input)
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/data/batch", &buf)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	mdm.On("UploadJSON", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.DataRefOrValue")).
+		Return(&fftypes.Data{}, nil).Twice()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 201, res.Result().StatusCode)
+	var output []*fftypes.Data
+	json.NewDecoder(res.Body).Decode(&output)
+	assert.Len(t, output, 2)
+	o.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestPostDataBatchFail(t *testing.T) {
+	o, r := newTestAPIServer()
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+	mockRunAsGroup(o)
+	input := []*fftypes.DataRefOrValue{
+		{Value: fftypes.JSONAnyPtr(`"one"`)},
+		{Value: fftypes.JSONAnyPtr(`"two"`)},
+	}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(&input)
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/data/batch", &buf)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	mdm.On("UploadJSON", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.DataRefOrValue")).
+		Return(&fftypes.Data{}, nil).Once()
+	mdm.On("UploadJSON", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.DataRefOrValue")).
+		Return(nil, fmt.Errorf("pop")).Once()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 500, res.Result().StatusCode)
+	mdm.AssertExpectations(t)
+}
@@ -0,0 +1,69 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var postDataBlob = &oapispec.Route{
+	Name:   "postDataBlob",
+	Path:   "namespaces/{ns}/data/blob",
+	Method: http.MethodPost,
+	PathParams: []*oapispec.PathParam{
+		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
+	},
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "filename", Description: i18n.MsgTBD},
+		{Name: "autometa", IsBool: true, Description: i18n.MsgTBD},
+		{Name: "public", IsBool: true, Description: i18n.MsgTBD},
+		{Name: "validator", Description: i18n.MsgTBD},
+		{Name: "datatype.name", Description: i18n.MsgTBD},
+		{Name: "datatype.version", Description: i18n.MsgTBD},
+	},
+	FilterFactory:   nil,
+	Description:     i18n.MsgTBD,
+	JSONInputValue:  nil,
+	JSONOutputValue: func() interface{} { return &fftypes.Data{} },
+	JSONOutputCodes: []int{http.StatusCreated},
+	StreamUploadHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		data := &fftypes.DataRefOrValue{}
+		if validator := r.QP["validator"]; len(validator) > 0 {
+			data.Validator = fftypes.ValidatorType(validator)
+		}
+		if r.QP["datatype.name"] != "" {
+			data.Datatype = &fftypes.DatatypeRef{
+				Name:    r.QP["datatype.name"],
+				Version: r.QP["datatype.version"],
+			}
+		}
+		r.Part.Filename = r.QP["filename"]
+		dm := getOr(r.Ctx).Data()
+		// The request body is streamed straight through to the data exchange, hashing as we go
+		uploaded, err := dm.UploadBLOB(r.Ctx, r.PP["ns"], data, r.Part, strings.EqualFold(r.QP["autometa"], "true"))
+		if err == nil && strings.EqualFold(r.QP["public"], "true") {
+			err = dm.PublishBLOB(r.Ctx, uploaded)
+		}
+		return uploaded, err
+	},
+}
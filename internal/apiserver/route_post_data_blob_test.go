@@ -0,0 +1,81 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostDataBlobStream(t *testing.T) {
+	o, r := newTestAPIServer()
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/data/blob?filename=file.bin&autometa&validator=json&datatype.name=dt1&datatype.version=1", bytes.NewReader([]byte(`some data`)))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res := httptest.NewRecorder()
+
+	mdm.On("UploadBLOB", mock.Anything, "ns1", mock.MatchedBy(func(d *fftypes.DataRefOrValue) bool {
+		return d.Validator == fftypes.ValidatorTypeJSON && d.Datatype.Name == "dt1" && d.Datatype.Version == "1"
+	}), mock.MatchedBy(func(mp *fftypes.Multipart) bool {
+		b, _ := ioutil.ReadAll(mp.Data)
+		return string(b) == "some data" && mp.Filename == "file.bin" && mp.Mimetype == "application/octet-stream"
+	}), true).Return(&fftypes.Data{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 201, res.Result().StatusCode)
+	mdm.AssertExpectations(t)
+}
+
+func TestPostDataBlobStreamPublic(t *testing.T) {
+	o, r := newTestAPIServer()
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/data/blob?public", bytes.NewReader([]byte(`some data`)))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res := httptest.NewRecorder()
+
+	data := &fftypes.Data{ID: fftypes.NewUUID()}
+	mdm.On("UploadBLOB", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.DataRefOrValue"), mock.AnythingOfType("*fftypes.Multipart"), false).
+		Return(data, nil)
+	mdm.On("PublishBLOB", mock.Anything, data).Return(fmt.Errorf("pop"))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 500, res.Result().StatusCode)
+	mdm.AssertExpectations(t)
+}
+
+func TestPostDataBlobJSONNotSupported(t *testing.T) {
+	_, r := newTestAPIServer()
+
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/data/blob", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 415, res.Result().StatusCode)
+}
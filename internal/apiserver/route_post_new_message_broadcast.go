@@ -98,6 +98,20 @@ var postNewMessageBroadcast = &oapispec.Route{
 	Description:     i18n.MsgTBD,
 	JSONInputValue:  func() interface{} { return &fftypes.MessageInOut{} },
 	JSONInputSchema: func(ctx context.Context) string { return broadcastSchema },
+	JSONInputExample: func() interface{} {
+		return &fftypes.MessageInOut{
+			Message: fftypes.Message{
+				Header: fftypes.MessageHeader{
+					Type:   fftypes.MessageTypeBroadcast,
+					Topics: fftypes.FFStringArray{"topic1"},
+					Tag:    "tag1",
+				},
+			},
+			InlineData: fftypes.InlineData{
+				{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+			},
+		}
+	},
 	JSONOutputValue: func() interface{} { return &fftypes.Message{} },
 	JSONOutputCodes: []int{http.StatusAccepted, http.StatusOK},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
@@ -120,6 +120,26 @@ var postNewMessagePrivate = &oapispec.Route{
 	Description:     i18n.MsgTBD,
 	JSONInputValue:  func() interface{} { return &fftypes.MessageInOut{} },
 	JSONInputSchema: func(ctx context.Context) string { return privateSendSchema },
+	JSONInputExample: func() interface{} {
+		return &fftypes.MessageInOut{
+			Message: fftypes.Message{
+				Header: fftypes.MessageHeader{
+					Type:   fftypes.MessageTypePrivate,
+					Topics: fftypes.FFStringArray{"topic1"},
+					Tag:    "tag1",
+				},
+			},
+			InlineData: fftypes.InlineData{
+				{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+			},
+			Group: &fftypes.InputGroup{
+				Members: []fftypes.MemberInput{
+					{Identity: "did:firefly:org/org1"},
+					{Identity: "did:firefly:org/org2"},
+				},
+			},
+		}
+	},
 	JSONOutputValue: func() interface{} { return &fftypes.Message{} },
 	JSONOutputCodes: []int{http.StatusAccepted, http.StatusOK},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
@@ -0,0 +1,52 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var postNewMessagesBulk = &oapispec.Route{
+	Name:   "postNewMessagesBulk",
+	Path:   "namespaces/{ns}/messages/bulk",
+	Method: http.MethodPost,
+	PathParams: []*oapispec.PathParam{
+		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
+	},
+	QueryParams:     nil,
+	FilterFactory:   nil,
+	Description:     i18n.MsgPostBulkMessagesDescription,
+	JSONInputValue:  func() interface{} { return &[]*fftypes.MessageInOut{} },
+	JSONInputMask:   nil,
+	JSONOutputValue: func() interface{} { return []*fftypes.BulkMessageResult{} },
+	JSONOutputCodes: []int{http.StatusAccepted, http.StatusBadRequest},
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		results, err := getOr(r.Ctx).SubmitMessages(r.Ctx, r.PP["ns"], *r.Input.(*[]*fftypes.MessageInOut))
+		for _, result := range results {
+			if result.Status != fftypes.BulkMessageStatusAccepted {
+				// The status of every message is returned, so the caller can correct the rejected ones
+				r.SuccessStatus = http.StatusBadRequest
+			}
+		}
+		return results, err
+	},
+}
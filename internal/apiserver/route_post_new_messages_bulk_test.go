@@ -0,0 +1,73 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostNewMessagesBulk(t *testing.T) {
+	o, r := newTestAPIServer()
+	input := []*fftypes.MessageInOut{{}, {}}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(&input)
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/messages/bulk", &buf)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	o.On("SubmitMessages", mock.Anything, "ns1", mock.MatchedBy(func(msgs []*fftypes.MessageInOut) bool {
+		return len(msgs) == 2
+	})).Return([]*fftypes.BulkMessageResult{
+		{Index: 0, Status: fftypes.BulkMessageStatusAccepted},
+		{Index: 1, Status: fftypes.BulkMessageStatusAccepted},
+	}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 202, res.Result().StatusCode)
+	var output []*fftypes.BulkMessageResult
+	json.NewDecoder(res.Body).Decode(&output)
+	assert.Len(t, output, 2)
+	o.AssertExpectations(t)
+}
+
+func TestPostNewMessagesBulkRejected(t *testing.T) {
+	o, r := newTestAPIServer()
+	input := []*fftypes.MessageInOut{{}, {}}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(&input)
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/messages/bulk", &buf)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	o.On("SubmitMessages", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.BulkMessageResult{
+		{Index: 0, Status: fftypes.BulkMessageStatusAborted},
+		{Index: 1, Status: fftypes.BulkMessageStatusRejected, Error: "pop"},
+	}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 400, res.Result().StatusCode)
+	var output []*fftypes.BulkMessageResult
+	json.NewDecoder(res.Body).Decode(&output)
+	assert.Equal(t, "pop", output[1].Error)
+}
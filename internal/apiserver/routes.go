@@ -25,16 +25,29 @@ var routes = []*oapispec.Route{
 	postNewNamespace,
 	postNewMessageBroadcast,
 	postNewMessagePrivate,
+	postNewMessagesBulk,
 	postNewMessageRequestReply,
 	postNodesSelf,
 	postNewOrganization,
 	postNewOrganizationSelf,
+	postRotateOrganizationKey,
 
 	postData,
+	postDataBlob,
+	postDataBatch,
+	postFlushBatches,
+	postGroupMembers,
+	postNewGroup,
+	postOpRetry,
 	postNewSubscription,
+	postRetireNamespace,
 
+	putNamespace,
 	putSubscription,
 
+	patchSubscription,
+
+	deleteNamespace,
 	deleteSubscription,
 
 	getBatchByID,
@@ -45,10 +58,12 @@ var routes = []*oapispec.Route{
 	getDatatypeByName,
 	getDatatypes,
 	getDataMsgs,
+	getEventsExport,
 	getEventByID,
 	getEvents,
 	getGroups,
 	getGroupByHash,
+	getGroupHistory,
 	getMsgByID,
 	getMsgData,
 	getMsgEvents,
@@ -61,11 +76,14 @@ var routes = []*oapispec.Route{
 	getNetworkNodes,
 	getNamespace,
 	getNamespaces,
+	getNamespaceUsage,
 	getOpByID,
 	getOps,
 	getStatus,
 	getStatusBatchManager,
+	getStatusPlugins,
 	getSubscriptionByID,
+	getSubscriptionHistory,
 	getSubscriptions,
 	getTxnByID,
 	getTxnOps,
@@ -78,8 +96,12 @@ var routes = []*oapispec.Route{
 	postTokenPool,
 	getTokenPools,
 	getTokenPoolByNameOrID,
+	postTokenPoolPause,
+	postTokenPoolResume,
+	postTokenPoolArchive,
 	getTokenBalances,
 	getTokenApprovals,
+	getTokenApprovalByID,
 	getTokenAccounts,
 	getTokenAccountPools,
 	getTokenTransfers,
@@ -101,6 +123,7 @@ var routes = []*oapispec.Route{
 	postContractInterfaceQuery,
 	postContractInterfaceSubscribe,
 	postContractInterfaceGenerate,
+	postContractInterfaceImport,
 
 	postNewContractAPI,
 	getContractAPIByName,
@@ -109,6 +132,8 @@ var routes = []*oapispec.Route{
 	postContractAPIInvoke,
 	postContractAPIQuery,
 	postContractAPISubscribe,
+	getContractAPISubscriptions,
+	deleteContractAPISubscription,
 
 	postNewContractSubscription,
 	getContractSubscriptionByNameOrID,
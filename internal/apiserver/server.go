@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,13 +38,19 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/gorilla/mux"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/internal/auth/authfactory"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/events/eifactory"
 	"github.com/hyperledger/firefly/internal/events/websockets"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/loadshed"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/oapispec"
 	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/internal/ratelimit"
+	"github.com/hyperledger/firefly/internal/rbac"
+	"github.com/hyperledger/firefly/pkg/auth"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -52,10 +59,14 @@ type orchestratorContextKey struct{}
 
 var ffcodeExtractor = regexp.MustCompile(`^(FF\d+):`)
 
+// requestIDValidator limits the request IDs accepted from callers to short strings that are safe to log and forward
+var requestIDValidator = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
 var (
 	adminConfigPrefix   = config.NewPluginConfig("admin")
 	apiConfigPrefix     = config.NewPluginConfig("http")
 	metricsConfigPrefix = config.NewPluginConfig("metrics")
+	authConfigPrefix    = config.NewPluginConfig("auth")
 )
 
 // Server is the external interface for the API Server
@@ -68,10 +79,18 @@ type apiServer struct {
 	defaultFilterLimit uint64
 	maxFilterLimit     uint64
 	maxFilterSkip      uint64
+	graphqlMaxDepth    int
+	graphqlMaxCost     uint64
+	graphqlNestedLimit uint64
 	apiTimeout         time.Duration
 	apiMaxTimeout      time.Duration
 	metricsEnabled     bool
+	auditEnabled       bool
+	tracingEnabled     bool
 	ffiSwaggerGen      oapiffi.FFISwaggerGen
+	rateLimiter        ratelimit.Limiter
+	authPlugin         auth.Plugin
+	authorizer         rbac.Authorizer
 }
 
 func InitConfig() {
@@ -79,6 +98,7 @@ func InitConfig() {
 	initHTTPConfPrefx(adminConfigPrefix, 5001)
 	initHTTPConfPrefx(metricsConfigPrefix, 6000)
 	initMetricsConfPrefix(metricsConfigPrefix)
+	authfactory.InitPrefix(authConfigPrefix)
 }
 
 func NewAPIServer() Server {
@@ -86,10 +106,16 @@ func NewAPIServer() Server {
 		defaultFilterLimit: uint64(config.GetUint(config.APIDefaultFilterLimit)),
 		maxFilterLimit:     uint64(config.GetUint(config.APIMaxFilterLimit)),
 		maxFilterSkip:      uint64(config.GetUint(config.APIMaxFilterSkip)),
+		graphqlMaxDepth:    config.GetInt(config.APIGraphQLMaxDepth),
+		graphqlMaxCost:     uint64(config.GetUint(config.APIGraphQLMaxCost)),
+		graphqlNestedLimit: uint64(config.GetUint(config.APIGraphQLNestedDefaultLimit)),
 		apiTimeout:         config.GetDuration(config.APIRequestTimeout),
 		apiMaxTimeout:      config.GetDuration(config.APIRequestMaxTimeout),
 		metricsEnabled:     config.GetBool(config.MetricsEnabled),
+		auditEnabled:       config.GetBool(config.APIAuditEnabled),
+		tracingEnabled:     config.GetBool(config.TracingEnabled),
 		ffiSwaggerGen:      oapiffi.NewFFISwaggerGen(),
+		rateLimiter:        ratelimit.NewLimiter(),
 	}
 }
 
@@ -103,6 +129,10 @@ func (as *apiServer) Serve(ctx context.Context, o orchestrator.Orchestrator) (er
 	adminErrChan := make(chan error)
 	metricsErrChan := make(chan error)
 
+	if err := as.initAuth(ctx); err != nil {
+		return err
+	}
+
 	if !o.IsPreInit() {
 		apiHTTPServer, err := newHTTPServer(ctx, "api", as.createMuxRouter(ctx, o), httpErrChan, apiConfigPrefix)
 		if err != nil {
@@ -207,9 +237,39 @@ func (as *apiServer) getParams(req *http.Request, route *oapispec.Route) (queryP
 	return queryParams, pathParams
 }
 
+// decodeJSONInput decodes the request body into the input of the route. If the namespace of the
+// request is configured for strict decoding, fields that are not part of the input are rejected.
+func (as *apiServer) decodeJSONInput(req *http.Request, jsonInput *interface{}) error {
+	if !config.GetPredefinedNamespace(mux.Vars(req)["ns"]).GetBool("strictDecode") {
+		return json.NewDecoder(req.Body).Decode(jsonInput)
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err == nil {
+		err = json.Unmarshal(b, jsonInput)
+	}
+	if err == nil {
+		err = fftypes.CheckKnownFields(req.Context(), "", b, *jsonInput)
+	}
+	return err
+}
+
+// isJSONContentType accepts plain JSON, and the JSON merge patch (RFC 7396) content type used by PATCH routes
+func isJSONContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "application/merge-patch+json")
+}
+
 func (as *apiServer) routeHandler(o orchestrator.Orchestrator, apiBaseURL string, route *oapispec.Route) http.HandlerFunc {
 	// Check the mandatory parts are ok at startup time
-	return as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+	handler := as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+
+		if err := checkRole(req, route); err != nil {
+			return 403, err
+		}
+
+		if err := as.checkLoadShedding(req.Context(), res, o, route); err != nil {
+			return 503, err
+		}
 
 		var jsonInput interface{}
 		if route.JSONInputValue != nil {
@@ -217,6 +277,7 @@ func (as *apiServer) routeHandler(o orchestrator.Orchestrator, apiBaseURL string
 		}
 		var queryParams, pathParams map[string]string
 		var multipart *multipartState
+		var stream *fftypes.Multipart
 		contentType := req.Header.Get("Content-Type")
 		var err error
 		if req.Method != http.MethodGet && req.Method != http.MethodDelete {
@@ -227,9 +288,14 @@ func (as *apiServer) routeHandler(o orchestrator.Orchestrator, apiBaseURL string
 					return 400, err
 				}
 				defer multipart.close()
-			case strings.HasPrefix(strings.ToLower(contentType), "application/json"):
+			case strings.HasPrefix(strings.ToLower(contentType), "application/octet-stream") && route.StreamUploadHandler != nil:
+				stream = &fftypes.Multipart{
+					Mimetype: contentType,
+					Data:     req.Body,
+				}
+			case isJSONContentType(contentType) && route.JSONHandler != nil:
 				if jsonInput != nil {
-					err = json.NewDecoder(req.Body).Decode(&jsonInput)
+					err = as.decodeJSONInput(req, &jsonInput)
 				}
 			default:
 				return 415, i18n.NewError(req.Context(), i18n.MsgInvalidContentType)
@@ -241,6 +307,7 @@ func (as *apiServer) routeHandler(o orchestrator.Orchestrator, apiBaseURL string
 		var output interface{}
 		if err == nil {
 			queryParams, pathParams = as.getParams(req, route)
+			as.checkQueryParams(req.Context(), req, route)
 			if route.FilterFactory != nil {
 				filter, err = as.buildFilter(req, route.FilterFactory)
 			}
@@ -263,12 +330,24 @@ func (as *apiServer) routeHandler(o orchestrator.Orchestrator, apiBaseURL string
 			if len(route.JSONOutputCodes) > 0 {
 				r.SuccessStatus = route.JSONOutputCodes[0]
 			}
+			handler := route.JSONHandler
 			if multipart != nil {
 				r.FP = multipart.formParams
 				r.Part = multipart.part
-				output, err = route.FormUploadHandler(r)
+				handler = route.FormUploadHandler
+			}
+			if stream != nil {
+				r.Part = stream
+				handler = route.StreamUploadHandler
+			}
+			if route.Transactional {
+				err = o.RunAsGroup(rCtx, func(ctx context.Context) (err error) {
+					r.Ctx = ctx
+					output, err = handler(r)
+					return err
+				})
 			} else {
-				output, err = route.JSONHandler(r)
+				output, err = handler(r)
 			}
 			status = r.SuccessStatus // Can be updated by the route
 		}
@@ -281,11 +360,53 @@ func (as *apiServer) routeHandler(o orchestrator.Orchestrator, apiBaseURL string
 				err = i18n.NewError(req.Context(), i18n.MsgFieldsAfterFile, trailing.FormName())
 			}
 		}
+		if err == nil && route.FilterFactory != nil {
+			if fields := as.getSparseFields(req.Form); len(fields) > 0 {
+				output = projectSparseFields(output, sparseFieldSelections(req.Context(), route, fields))
+			}
+		}
 		if err == nil {
+			as.addWarnings(req.Context(), res, output)
 			status, err = as.handleOutput(req.Context(), res, status, output)
 		}
 		return status, err
 	})
+	if as.auditEnabled && route.Method != http.MethodGet {
+		return as.auditHandler(o, route, handler)
+	}
+	return handler
+}
+
+// checkLoadShedding rejects the request if the node is shedding the class of load it belongs to. Collection
+// queries are the first to be rejected, and requests that submit new work are rejected only under the most
+// severe database pressure. Queries for individual resources are always allowed through.
+func (as *apiServer) checkLoadShedding(ctx context.Context, res http.ResponseWriter, o orchestrator.Orchestrator, route *oapispec.Route) error {
+	ls := o.LoadShedding()
+	if ls == nil {
+		return nil
+	}
+	level := ls.Level()
+	shed := level >= loadshed.LevelRejectSends
+	if route.Method == http.MethodGet {
+		shed = route.FilterFactory != nil && level >= loadshed.LevelPauseBulkQueries
+	}
+	if !shed {
+		return nil
+	}
+	res.Header().Set("Retry-After", strconv.FormatInt(int64(ls.RetryAfter().Seconds()), 10))
+	return i18n.NewError(ctx, i18n.MsgLoadShedding, level)
+}
+
+// addWarnings returns any non-fatal warnings raised while processing the request to the caller.
+// They are always set as response headers, and are also included in the body of collection results.
+func (as *apiServer) addWarnings(ctx context.Context, res http.ResponseWriter, output interface{}) {
+	warnings := apiwarnings.Get(ctx)
+	for _, w := range warnings {
+		res.Header().Add(fftypes.HTTPHeadersWarning, w)
+	}
+	if fr, ok := output.(*filterResultsWithCount); ok && len(warnings) > 0 {
+		fr.Warnings = warnings
+	}
 }
 
 func (as *apiServer) handleOutput(ctx context.Context, res http.ResponseWriter, status int, output interface{}) (int, error) {
@@ -335,7 +456,7 @@ func (as *apiServer) getTimeout(req *http.Request) time.Duration {
 	if reqTimeoutHeader != "" {
 		customTimeout, err := fftypes.ParseDurationString(reqTimeoutHeader, time.Second /* default is seconds */)
 		if err != nil {
-			log.L(req.Context()).Warnf("Invalid Request-Timeout header '%s': %s", reqTimeoutHeader, err)
+			apiwarnings.Add(req.Context(), i18n.MsgWarnInvalidRequestTimeout, reqTimeoutHeader, err)
 		} else {
 			reqTimeout = time.Duration(customTimeout)
 			if reqTimeout > as.apiMaxTimeout {
@@ -346,13 +467,41 @@ func (as *apiServer) getTimeout(req *http.Request) time.Duration {
 	return reqTimeout
 }
 
+// getLongPollTimeout returns how long to hold a request that uses waitForState, which is
+// still bounded by the overall request timeout
+func getLongPollTimeout(r *oapispec.APIRequest) (time.Duration, error) {
+	timeoutParam := r.QP["timeout"]
+	if timeoutParam == "" {
+		return config.GetDuration(config.APIDefaultLongPollTimeout), nil
+	}
+	timeout, err := fftypes.ParseDurationString(timeoutParam, time.Second /* default is seconds */)
+	if err != nil {
+		return 0, i18n.NewError(r.Ctx, i18n.MsgInvalidLongPollTimeout, timeoutParam, err)
+	}
+	if timeout <= 0 {
+		return 0, i18n.NewError(r.Ctx, i18n.MsgInvalidLongPollTimeout, timeoutParam, "must be greater than zero")
+	}
+	return time.Duration(timeout), nil
+}
+
 func (as *apiServer) apiWrapper(handler func(res http.ResponseWriter, req *http.Request) (status int, err error)) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 
+		// Use the request ID of the caller if it supplied one, so the request can be followed from the application
+		// through to the connectors, which are passed the same ID on the calls made for the request
+		httpReqID := req.Header.Get(fftypes.HTTPHeadersRequestID)
+		if !requestIDValidator.MatchString(httpReqID) {
+			httpReqID = fftypes.ShortID()
+		}
+		res.Header().Set(fftypes.HTTPHeadersRequestID, httpReqID)
+		ctx := log.WithLogField(req.Context(), log.RequestIDField, httpReqID)
+		if ns := mux.Vars(req)["ns"]; ns != "" {
+			ctx = log.WithLogField(ctx, log.NamespaceField, ns)
+		}
+		ctx = apiwarnings.WithWarnings(ctx)
+		req = req.WithContext(ctx)
 		reqTimeout := as.getTimeout(req)
-		ctx, cancel := context.WithTimeout(req.Context(), reqTimeout)
-		httpReqID := fftypes.ShortID()
-		ctx = log.WithLogField(ctx, "httpreq", httpReqID)
+		ctx, cancel := context.WithTimeout(ctx, reqTimeout)
 		req = req.WithContext(ctx)
 		defer cancel()
 
@@ -392,7 +541,8 @@ func (as *apiServer) apiWrapper(handler func(res http.ResponseWriter, req *http.
 			res.Header().Add("Content-Type", "application/json")
 			res.WriteHeader(status)
 			_ = json.NewEncoder(res).Encode(&fftypes.RESTError{
-				Error: err.Error(),
+				Error:    err.Error(),
+				Warnings: apiwarnings.Get(ctx),
 			})
 		} else {
 			l.Infof("<-- %s %s [%d] (%.2fms)", req.Method, req.URL.Path, status, durationMS)
@@ -464,8 +614,11 @@ func (as *apiServer) swaggerGenerator(routes []*oapispec.Route, apiBaseURL strin
 
 func (as *apiServer) contractSwaggerGenerator(o orchestrator.Orchestrator, apiBaseURL string) func(req *http.Request) (*openapi3.T, error) {
 	return func(req *http.Request) (*openapi3.T, error) {
-		cm := o.Contracts()
 		vars := mux.Vars(req)
+		if err := rbac.Authorize(req.Context(), vars["ns"], rbac.RoleReader); err != nil {
+			return nil, err
+		}
+		cm := o.Contracts()
 		api, err := cm.GetContractAPI(req.Context(), apiBaseURL, vars["ns"], vars["apiName"])
 		if err != nil {
 			return nil, err
@@ -486,19 +639,30 @@ func (as *apiServer) contractSwaggerGenerator(o orchestrator.Orchestrator, apiBa
 func (as *apiServer) createMuxRouter(ctx context.Context, o orchestrator.Orchestrator) *mux.Router {
 	r := mux.NewRouter()
 
+	if as.tracingEnabled {
+		r.Use(tracingMiddleware)
+	}
 	if as.metricsEnabled {
 		r.Use(metrics.GetRestServerInstrumentation().Middleware)
 	}
+	if as.rateLimiter != nil {
+		// Before auth, so that failed authentication attempts are rate limited too
+		r.Use(as.rateLimitMiddleware)
+	}
+	if as.authPlugin != nil {
+		r.Use(as.authMiddleware)
+	}
 
 	publicURL := as.getPublicURL(apiConfigPrefix, "")
 	apiBaseURL := fmt.Sprintf("%s/api/v1", publicURL)
 	for _, route := range routes {
-		if route.JSONHandler != nil {
+		if route.JSONHandler != nil || route.StreamUploadHandler != nil {
 			r.HandleFunc(fmt.Sprintf("/api/v1/%s", route.Path), as.routeHandler(o, apiBaseURL, route)).
 				Methods(route.Method)
 		}
 	}
 
+	r.HandleFunc(`/api/v1/namespaces/{ns}/graphql`, as.apiWrapper(as.graphqlHandler(o))).Methods(http.MethodPost)
 	r.HandleFunc(`/api/v1/namespaces/{ns}/apis/{apiName}/api/swagger{ext:\.yaml|\.json|}`, as.apiWrapper(as.swaggerHandler(as.contractSwaggerGenerator(o, apiBaseURL))))
 	r.HandleFunc(`/api/v1/namespaces/{ns}/apis/{apiName}/api`, func(rw http.ResponseWriter, req *http.Request) {
 		url := req.URL.String() + "/swagger.yaml"
@@ -524,9 +688,15 @@ func (as *apiServer) createMuxRouter(ctx context.Context, o orchestrator.Orchest
 
 func (as *apiServer) createAdminMuxRouter(o orchestrator.Orchestrator) *mux.Router {
 	r := mux.NewRouter()
+	if as.tracingEnabled {
+		r.Use(tracingMiddleware)
+	}
 	if as.metricsEnabled {
 		r.Use(metrics.GetAdminServerInstrumentation().Middleware)
 	}
+	if as.authPlugin != nil {
+		r.Use(as.authMiddleware)
+	}
 
 	publicURL := as.getPublicURL(adminConfigPrefix, "admin")
 	apiBaseURL := fmt.Sprintf("%s/admin/api/v1", publicURL)
@@ -538,6 +708,7 @@ func (as *apiServer) createAdminMuxRouter(o orchestrator.Orchestrator) *mux.Rout
 	}
 	r.HandleFunc(`/admin/api/swagger{ext:\.yaml|\.json|}`, as.apiWrapper(as.swaggerHandler(as.swaggerGenerator(adminRoutes, apiBaseURL))))
 	r.HandleFunc(`/admin/api`, as.apiWrapper(as.swaggerUIHandler(publicURL+"/api/swagger.yaml")))
+	r.HandleFunc(`/admin/ws`, as.apiWrapper(as.adminEventsHandler(o)))
 	r.HandleFunc(`/favicon{any:.*}.png`, favIcons)
 
 	return r
@@ -0,0 +1,42 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/internal/rbac"
+)
+
+// adminEventsHandler upgrades the request to a websocket that streams the change events of the node, so management
+// UIs can update without polling. The events cover every namespace, so listening requires the admin role for all namespaces.
+// The collections, types and namespaces query parameters restrict the events that are delivered.
+func (as *apiServer) adminEventsHandler(o orchestrator.Orchestrator) func(res http.ResponseWriter, req *http.Request) (status int, err error) {
+	return func(res http.ResponseWriter, req *http.Request) (status int, err error) {
+		if err := rbac.Authorize(req.Context(), rbac.AllNamespaces, rbac.RoleAdmin); err != nil {
+			return 403, err
+		}
+		ae := o.AdminEvents()
+		if ae == nil {
+			return 503, i18n.NewError(req.Context(), i18n.MsgAdminEventsNotAvailable)
+		}
+		ae.ServeHTTPWebSocketListener(res, req)
+		return http.StatusSwitchingProtocols, nil
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/rbac"
+	"github.com/hyperledger/firefly/mocks/admineventsmocks"
+	"github.com/hyperledger/firefly/mocks/authmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminEvents(t *testing.T) {
+	mor, r := newTestAdminServer()
+	mae := &admineventsmocks.Manager{}
+	mor.On("AdminEvents").Return(mae)
+	mae.On("ServeHTTPWebSocketListener", mock.Anything, mock.Anything).Return()
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/admin/ws?collections=operations", nil))
+
+	mae.AssertExpectations(t)
+}
+
+func TestAdminEventsNotInitialized(t *testing.T) {
+	mor, r := newTestAdminServer()
+	mor.On("AdminEvents").Return(nil)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/admin/ws", nil))
+	assert.Equal(t, 503, res.Result().StatusCode)
+	assert.Regexp(t, "FF10498", res.Body.String())
+}
+
+func TestAdminEventsForbidden(t *testing.T) {
+	mor, as := newTestServer()
+	config.Set(config.AuthRBACEnabled, true)
+	config.Set(config.AuthRBACGrants, fftypes.JSONObjectArray{
+		{"identity": "app1", "namespace": "ns1", "role": "admin"},
+	})
+	var err error
+	as.authorizer, err = rbac.NewAuthorizer(context.Background())
+	assert.NoError(t, err)
+	mauth := &authmocks.Plugin{}
+	as.authPlugin = mauth
+	r := as.createAdminMuxRouter(mor)
+	mauth.On("Authenticate", mock.Anything).Return("app1", nil)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/admin/ws", nil))
+	assert.Equal(t, 403, res.Result().StatusCode)
+	mor.AssertNotCalled(t, "AdminEvents")
+}
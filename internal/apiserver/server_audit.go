@@ -0,0 +1,98 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// auditResponseWriter captures the status of the response, and the body of error responses, for the audit record
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	errBody bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status >= 300 {
+		w.errBody.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// hashingBody calculates the hash of the request body as it is read by the handler
+type hashingBody struct {
+	io.Reader
+	io.Closer
+}
+
+// auditHandler records who called the route, with a hash of what they sent and the result, once the handler completes.
+// Calls are recorded whether they succeed or fail, and failure to write the audit record is logged
+// rather than returned, as the response has already been sent.
+func (as *apiServer) auditHandler(o orchestrator.Orchestrator, route *oapispec.Route, handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		hash := sha256.New()
+		req.Body = &hashingBody{Reader: io.TeeReader(req.Body, hash), Closer: req.Body}
+		aw := &auditResponseWriter{ResponseWriter: res}
+
+		handler(aw, req)
+
+		// Include any of the body the handler did not read, so the hash is always of the whole request
+		_, _ = io.Copy(ioutil.Discard, req.Body)
+		var requestHash fftypes.Bytes32
+		copy(requestHash[:], hash.Sum(nil))
+
+		record := &fftypes.AuditRecord{
+			Namespace:   mux.Vars(req)["ns"],
+			Identity:    callerIdentity(req),
+			RemoteAddr:  remoteHost(req),
+			Method:      req.Method,
+			Path:        req.URL.Path,
+			Operation:   route.Name,
+			RequestHash: &requestHash,
+			Status:      aw.status,
+		}
+		if aw.status >= 300 {
+			var restErr fftypes.RESTError
+			if err := json.Unmarshal(aw.errBody.Bytes(), &restErr); err == nil {
+				record.Error = restErr.Error
+			}
+		}
+		if err := o.RecordAudit(req.Context(), record); err != nil {
+			log.L(req.Context()).Errorf("Failed to record audit record for %s %s (identity='%s' status=%d): %s", req.Method, req.URL.Path, record.Identity, record.Status, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditSuccess(t *testing.T) {
+	mor, as := newTestServer()
+	as.auditEnabled = true
+	r := as.createAdminMuxRouter(mor)
+
+	body := `{"foo":"bar"}`
+	req := httptest.NewRequest("PUT", "/admin/api/v1/config/records/foo", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.SetBasicAuth("admin1", "pass")
+	req.RemoteAddr = "10.0.0.1:12345"
+	res := httptest.NewRecorder()
+
+	mor.On("PutConfigRecord", mock.Anything, "foo", mock.Anything).Return(fftypes.JSONAnyPtr(body), nil)
+	var record *fftypes.AuditRecord
+	mor.On("RecordAudit", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		record = args[1].(*fftypes.AuditRecord)
+	}).Return(nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	expectedHash := fftypes.Bytes32(sha256.Sum256([]byte(body)))
+	assert.Equal(t, &fftypes.AuditRecord{
+		Identity:    "admin1",
+		RemoteAddr:  "10.0.0.1",
+		Method:      "PUT",
+		Path:        "/admin/api/v1/config/records/foo",
+		Operation:   "putConfigRecord",
+		RequestHash: &expectedHash,
+		Status:      200,
+	}, record)
+}
+
+func TestAuditFailure(t *testing.T) {
+	mor, as := newTestServer()
+	as.auditEnabled = true
+	r := as.createMuxRouter(context.Background(), mor)
+
+	body := `{"header": bad json` + strings.Repeat(" ", 1024)
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/messages/broadcast", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	mor.On("RecordAudit", mock.Anything, mock.MatchedBy(func(record *fftypes.AuditRecord) bool {
+		expectedHash := fftypes.Bytes32(sha256.Sum256([]byte(body)))
+		return record.Namespace == "ns1" &&
+			record.Operation == "postNewMessageBroadcast" &&
+			record.Status == 400 &&
+			*record.RequestHash == expectedHash &&
+			strings.Contains(record.Error, "invalid character")
+	})).Return(fmt.Errorf("pop"))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 400, res.Result().StatusCode)
+	mor.AssertExpectations(t)
+}
+
+func TestAuditNotForQueries(t *testing.T) {
+	mor, as := newTestServer()
+	as.auditEnabled = true
+	r := as.createMuxRouter(context.Background(), mor)
+
+	req := httptest.NewRequest("GET", "/api/v1/status", nil)
+	res := httptest.NewRecorder()
+
+	mor.On("GetStatus", mock.Anything).Return(&fftypes.NodeStatus{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	mor.AssertNotCalled(t, "RecordAudit", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,121 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/firefly/internal/auth/authfactory"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/rbac"
+	"github.com/hyperledger/firefly/pkg/auth"
+)
+
+// initAuth loads the configured auth plugin, if API requests are to be authenticated, and the
+// authorizer that checks the roles of the authenticated identities if role based access control is enabled
+func (as *apiServer) initAuth(ctx context.Context) (err error) {
+	if as.authorizer, err = rbac.NewAuthorizer(ctx); err != nil {
+		return err
+	}
+	authType := config.GetString(config.AuthType)
+	if authType == "" {
+		if as.authorizer != nil {
+			return i18n.NewError(ctx, i18n.MsgRBACRequiresAuth)
+		}
+		return nil
+	}
+	if as.authPlugin, err = authfactory.GetPlugin(ctx, authType); err != nil {
+		return err
+	}
+	return as.authPlugin.Init(ctx, authConfigPrefix.SubPrefix(as.authPlugin.Name()))
+}
+
+// callerIdentity is the identity resolved by the auth plugin, or the user supplied with basic auth
+// when no auth plugin is configured, or empty for unauthenticated requests
+func callerIdentity(req *http.Request) string {
+	if identity := auth.GetIdentity(req.Context()); identity != "" {
+		return identity
+	}
+	if username, _, ok := req.BasicAuth(); ok {
+		return username
+	}
+	return ""
+}
+
+// remoteHost is the address of the caller, without the port
+func remoteHost(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// checkRole checks the caller holds the role required by the route, for the namespace in the path of the request.
+// Allowed for all callers if role based access control is disabled.
+func checkRole(req *http.Request, route *oapispec.Route) error {
+	role := route.RequiredRole
+	if role == rbac.RoleNone {
+		role = rbac.RoleSender
+		if route.Method == http.MethodGet {
+			role = rbac.RoleReader
+		}
+	}
+	return rbac.Authorize(req.Context(), mux.Vars(req)["ns"], role)
+}
+
+type authResultKey struct{}
+
+type authResult struct {
+	identity string
+	err      error
+}
+
+// authenticate authenticates the request with the auth plugin once, keeping the result on the context of the
+// request, as the rate limiter needs the authenticated identity before the auth middleware runs
+func (as *apiServer) authenticate(req *http.Request) (*http.Request, *authResult) {
+	if result, ok := req.Context().Value(authResultKey{}).(*authResult); ok {
+		return req, result
+	}
+	result := &authResult{}
+	result.identity, result.err = as.authPlugin.Authenticate(req)
+	return req.WithContext(context.WithValue(req.Context(), authResultKey{}, result)), result
+}
+
+// authMiddleware rejects requests that the auth plugin cannot authenticate with a 401, and attaches
+// the identity of the caller (and the authorizer for their roles) to the context of the requests that it does authenticate
+func (as *apiServer) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		req, result := as.authenticate(req)
+		if result.err == nil {
+			ctx := auth.WithIdentity(req.Context(), result.identity)
+			if as.authorizer != nil {
+				ctx = rbac.WithAuthorizer(ctx, as.authorizer)
+			}
+			next.ServeHTTP(res, req.WithContext(ctx))
+			return
+		}
+		as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+			return http.StatusUnauthorized, i18n.WrapError(req.Context(), result.err, i18n.MsgAuthenticationFailed)
+		})(res, req)
+	})
+}
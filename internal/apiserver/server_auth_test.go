@@ -0,0 +1,157 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/rbac"
+	"github.com/hyperledger/firefly/mocks/authmocks"
+	"github.com/hyperledger/firefly/pkg/auth"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInitAuthDisabled(t *testing.T) {
+	config.Reset()
+	as := &apiServer{}
+	err := as.initAuth(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, as.authPlugin)
+}
+
+func TestInitAuthUnknown(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthType, "wrong")
+	as := &apiServer{}
+	err := as.initAuth(context.Background())
+	assert.Regexp(t, "FF10483.*wrong", err)
+}
+
+func TestInitAuthRBACWithoutAuth(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthRBACEnabled, true)
+	as := &apiServer{}
+	err := as.initAuth(context.Background())
+	assert.Regexp(t, "FF10494", err)
+}
+
+func TestInitAuthRBACBadGrant(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthRBACEnabled, true)
+	config.Set(config.AuthRBACGrants, fftypes.JSONObjectArray{{"identity": "app1"}})
+	as := &apiServer{}
+	err := as.initAuth(context.Background())
+	assert.Regexp(t, "FF10493", err)
+}
+
+func TestInitAuthOk(t *testing.T) {
+	config.Reset()
+	InitConfig()
+	config.Set(config.AuthType, "apikey")
+	as := &apiServer{}
+	err := as.initAuth(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey", as.authPlugin.Name())
+}
+
+func TestCallerIdentity(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	assert.Equal(t, "", callerIdentity(req))
+
+	req.SetBasicAuth("user1", "pass")
+	assert.Equal(t, "user1", callerIdentity(req))
+
+	req = req.WithContext(auth.WithIdentity(req.Context(), "app1"))
+	assert.Equal(t, "app1", callerIdentity(req))
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	mor, as := newTestServer()
+	mauth := &authmocks.Plugin{}
+	as.authPlugin = mauth
+	r := as.createMuxRouter(context.Background(), mor)
+	mor.On("GetStatus", mock.MatchedBy(func(ctx context.Context) bool {
+		return auth.GetIdentity(ctx) == "app1"
+	})).Return(&fftypes.NodeStatus{}, nil)
+
+	mauth.On("Authenticate", mock.Anything).Return("app1", nil).Once()
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	assert.Equal(t, 200, res.Result().StatusCode)
+
+	mauth.On("Authenticate", mock.Anything).Return("", i18n.NewError(context.Background(), i18n.MsgInvalidAPIKey)).Once()
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	assert.Equal(t, 401, res.Result().StatusCode)
+	assert.Regexp(t, "FF10484.*FF10486", res.Body.String())
+
+	mauth.On("Authenticate", mock.Anything).Return("", fmt.Errorf("pop")).Once()
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	assert.Equal(t, 401, res.Result().StatusCode)
+
+	mauth.AssertExpectations(t)
+}
+
+func TestRBAC(t *testing.T) {
+	mor, as := newTestServer()
+	config.Set(config.AuthRBACEnabled, true)
+	config.Set(config.AuthRBACGrants, fftypes.JSONObjectArray{
+		{"identity": "app1", "namespace": "ns1", "role": "sender"},
+		{"identity": "ops", "role": "admin"},
+	})
+	var err error
+	as.authorizer, err = rbac.NewAuthorizer(context.Background())
+	assert.NoError(t, err)
+	mauth := &authmocks.Plugin{}
+	as.authPlugin = mauth
+	r := as.createMuxRouter(context.Background(), mor)
+	ar := as.createAdminMuxRouter(mor)
+	mor.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{}, nil)
+	mor.On("GetStatus", mock.Anything).Return(&fftypes.NodeStatus{}, nil)
+	mor.On("GetConfig", mock.Anything).Return(fftypes.JSONObject{})
+
+	request := func(router http.Handler, identity, method, path string) int {
+		mauth.On("Authenticate", mock.Anything).Return(identity, nil).Once()
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(method, path, nil))
+		return res.Result().StatusCode
+	}
+
+	// Namespaced routes require the role for the namespace
+	assert.Equal(t, 200, request(r, "app1", http.MethodGet, "/api/v1/namespaces/ns1"))
+	assert.Equal(t, 403, request(r, "app1", http.MethodGet, "/api/v1/namespaces/ns2"))
+	assert.Equal(t, 403, request(r, "app1", http.MethodDelete, "/api/v1/namespaces/ns1"))
+	assert.Equal(t, 403, request(r, "app1", http.MethodPost, "/api/v1/namespaces/ns2/graphql"))
+	assert.Equal(t, 403, request(r, "app1", http.MethodGet, "/api/v1/namespaces/ns2/apis/api1/api/swagger.json"))
+
+	// Routes outside of namespaces, and the admin API, require a grant for all namespaces
+	assert.Equal(t, 403, request(r, "app1", http.MethodGet, "/api/v1/status"))
+	assert.Equal(t, 200, request(r, "ops", http.MethodGet, "/api/v1/status"))
+	assert.Equal(t, 403, request(ar, "app1", http.MethodGet, "/admin/api/v1/config"))
+	assert.Equal(t, 200, request(ar, "ops", http.MethodGet, "/admin/api/v1/config"))
+
+	mauth.AssertExpectations(t)
+}
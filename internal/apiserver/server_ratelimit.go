@@ -0,0 +1,56 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// rateLimitIdentity is the identity a request is counted against for rate limiting. This is the
+// identity authenticated by the auth plugin, or the address of the caller when there is no auth plugin
+// or the request fails authentication. Unauthenticated credentials, such as a basic auth username
+// with no auth plugin configured, are never used, as the caller could change them on every request.
+func (as *apiServer) rateLimitIdentity(req *http.Request) (*http.Request, string) {
+	if as.authPlugin != nil {
+		var result *authResult
+		if req, result = as.authenticate(req); result.err == nil {
+			return req, result.identity
+		}
+	}
+	return req, remoteHost(req)
+}
+
+// rateLimitMiddleware rejects requests over the rate limit with a 429, and a Retry-After header
+// giving the number of whole seconds until the caller will be allowed to make another request
+func (as *apiServer) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		req, identity := as.rateLimitIdentity(req)
+		ok, retryAfter := as.rateLimiter.Allow(identity)
+		if ok {
+			next.ServeHTTP(res, req)
+			return
+		}
+		as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+			res.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+			return http.StatusTooManyRequests, i18n.NewError(req.Context(), i18n.MsgRateLimited, identity)
+		})(res, req)
+	})
+}
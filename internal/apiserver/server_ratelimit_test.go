@@ -0,0 +1,130 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/mocks/authmocks"
+	"github.com/hyperledger/firefly/mocks/ratelimitmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRateLimitIdentity(t *testing.T) {
+	_, as := newTestServer()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	_, identity := as.rateLimitIdentity(req)
+	assert.Equal(t, "10.0.0.1", identity)
+
+	req.RemoteAddr = "pipe"
+	_, identity = as.rateLimitIdentity(req)
+	assert.Equal(t, "pipe", identity)
+
+	// The basic auth username is not authenticated without an auth plugin, so is not trusted
+	req.SetBasicAuth("user1", "pass")
+	_, identity = as.rateLimitIdentity(req)
+	assert.Equal(t, "pipe", identity)
+}
+
+func TestRateLimitIdentityAuthenticated(t *testing.T) {
+	_, as := newTestServer()
+	mauth := &authmocks.Plugin{}
+	as.authPlugin = mauth
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+
+	mauth.On("Authenticate", req).Return("app1", nil).Once()
+	authReq, identity := as.rateLimitIdentity(req)
+	assert.Equal(t, "app1", identity)
+
+	// The result is reused by the auth middleware, rather than authenticating again
+	_, result := as.authenticate(authReq)
+	assert.Equal(t, "app1", result.identity)
+
+	mauth.On("Authenticate", req).Return("", fmt.Errorf("pop")).Once()
+	_, identity = as.rateLimitIdentity(req)
+	assert.Equal(t, "10.0.0.1", identity)
+
+	mauth.AssertExpectations(t)
+}
+
+func TestRateLimited(t *testing.T) {
+	mor, as := newTestServer()
+	mrl := &ratelimitmocks.Limiter{}
+	as.rateLimiter = mrl
+	r := as.createMuxRouter(context.Background(), mor)
+	mor.On("GetStatus", mock.Anything).Return(&fftypes.NodeStatus{}, nil)
+
+	mrl.On("Allow", "10.0.0.1").Return(true, time.Duration(0)).Once()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 200, res.Result().StatusCode)
+
+	mrl.On("Allow", "10.0.0.1").Return(false, 1500*time.Millisecond).Once()
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 429, res.Result().StatusCode)
+	assert.Equal(t, "2", res.Result().Header.Get("Retry-After"))
+	assert.Regexp(t, "FF10482.*10.0.0.1", res.Body.String())
+
+	mrl.AssertExpectations(t)
+}
+
+func TestRateLimitedBeforeAuth(t *testing.T) {
+	mor, as := newTestServer()
+	mrl := &ratelimitmocks.Limiter{}
+	as.rateLimiter = mrl
+	mauth := &authmocks.Plugin{}
+	as.authPlugin = mauth
+	r := as.createMuxRouter(context.Background(), mor)
+	mor.On("GetStatus", mock.Anything).Return(&fftypes.NodeStatus{}, nil)
+
+	// Authenticated once, and counted against the authenticated identity
+	mauth.On("Authenticate", mock.Anything).Return("app1", nil).Once()
+	mrl.On("Allow", "app1").Return(true, time.Duration(0)).Once()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 200, res.Result().StatusCode)
+
+	// Failed authentication attempts are counted against the address of the caller
+	mauth.On("Authenticate", mock.Anything).Return("", fmt.Errorf("pop")).Once()
+	mrl.On("Allow", "10.0.0.1").Return(true, time.Duration(0)).Once()
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 401, res.Result().StatusCode)
+
+	mauth.On("Authenticate", mock.Anything).Return("", fmt.Errorf("pop")).Once()
+	mrl.On("Allow", "10.0.0.1").Return(false, time.Second).Once()
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 429, res.Result().StatusCode)
+
+	mauth.AssertExpectations(t)
+	mrl.AssertExpectations(t)
+}
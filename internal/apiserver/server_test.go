@@ -24,18 +24,26 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gorilla/mux"
+	"github.com/hyperledger/firefly/internal/apiwarnings"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/loadshed"
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/mocks/broadcastmocks"
 	"github.com/hyperledger/firefly/mocks/contractmocks"
+	"github.com/hyperledger/firefly/mocks/loadshedmocks"
 	"github.com/hyperledger/firefly/mocks/oapiffimocks"
 	"github.com/hyperledger/firefly/mocks/orchestratormocks"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -46,6 +54,7 @@ const configDir = "../../test/data/config"
 func newTestServer() (*orchestratormocks.Orchestrator, *apiServer) {
 	InitConfig()
 	mor := &orchestratormocks.Orchestrator{}
+	mor.On("LoadShedding").Return(nil).Maybe()
 	as := &apiServer{
 		apiTimeout:    5 * time.Second,
 		ffiSwaggerGen: &oapiffimocks.FFISwaggerGen{},
@@ -126,6 +135,66 @@ func TestStartMetricsFail(t *testing.T) {
 	assert.Regexp(t, "FF10104", err)
 }
 
+func TestLoadShedding(t *testing.T) {
+	_, as := newTestServer()
+	mo := &orchestratormocks.Orchestrator{}
+	mls := &loadshedmocks.Monitor{}
+	mo.On("LoadShedding").Return(mls)
+	mls.On("RetryAfter").Return(30 * time.Second)
+	handlerFn := func(r *oapispec.APIRequest) (output interface{}, err error) {
+		return map[string]interface{}{}, nil
+	}
+	newServer := func(method string, ff database.QueryFactory) *httptest.Server {
+		return httptest.NewServer(as.routeHandler(mo, "http://localhost:5000/api/v1", &oapispec.Route{
+			Name:            "testRoute",
+			Path:            "/test",
+			Method:          method,
+			FilterFactory:   ff,
+			JSONInputValue:  func() interface{} { return map[string]interface{}{} },
+			JSONOutputValue: func() interface{} { return map[string]interface{}{} },
+			JSONOutputCodes: []int{200},
+			JSONHandler:     handlerFn,
+		}))
+	}
+	sQuery := newServer(http.MethodGet, database.MessageQueryFactory)
+	defer sQuery.Close()
+	sGet := newServer(http.MethodGet, nil)
+	defer sGet.Close()
+	sPost := newServer(http.MethodPost, nil)
+	defer sPost.Close()
+	post := func() *http.Response {
+		res, err := http.Post(sPost.URL, "application/json", bytes.NewReader([]byte(`{}`)))
+		assert.NoError(t, err)
+		return res
+	}
+
+	// Collection queries are shed first
+	mls.On("Level").Return(loadshed.LevelPauseBulkQueries).Once()
+	res, err := http.Get(sQuery.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 503, res.StatusCode)
+	assert.Equal(t, "30", res.Header.Get("Retry-After"))
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Regexp(t, "FF10430.*pause_bulk_queries", resJSON["error"])
+
+	mls.On("Level").Return(loadshed.LevelSlowDispatch).Once()
+	res, err = http.Get(sGet.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+
+	mls.On("Level").Return(loadshed.LevelSlowDispatch).Once()
+	assert.Equal(t, 200, post().StatusCode)
+
+	// Sends are shed last
+	mls.On("Level").Return(loadshed.LevelRejectSends).Once()
+	res = post()
+	assert.Equal(t, 503, res.StatusCode)
+	assert.Equal(t, "30", res.Header.Get("Retry-After"))
+
+	mls.AssertExpectations(t)
+}
+
 func TestJSONHTTPServePOST201(t *testing.T) {
 	mo, as := newTestServer()
 	handler := as.routeHandler(mo, "http://localhost:5000/api/v1", &oapispec.Route{
@@ -290,6 +359,45 @@ func TestNotFound(t *testing.T) {
 	assert.Regexp(t, "FF10109", resJSON["error"])
 }
 
+func TestRequestID(t *testing.T) {
+	_, as := newTestServer()
+	var reqIDs []string
+	handler := as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+		reqIDs = append(reqIDs, log.GetField(req.Context(), log.RequestIDField))
+		return 204, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(fftypes.HTTPHeadersRequestID, "app-req:1234")
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assert.Equal(t, "app-req:1234", res.Result().Header.Get(fftypes.HTTPHeadersRequestID))
+
+	req.Header.Set(fftypes.HTTPHeadersRequestID, "bad request id")
+	res = httptest.NewRecorder()
+	handler(res, req)
+	generated := res.Result().Header.Get(fftypes.HTTPHeadersRequestID)
+	assert.NotEmpty(t, generated)
+	assert.NotEqual(t, "bad request id", generated)
+
+	assert.Equal(t, []string{"app-req:1234", generated}, reqIDs)
+}
+
+func TestRequestNamespace(t *testing.T) {
+	_, as := newTestServer()
+	var namespaces []string
+	handler := as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+		namespaces = append(namespaces, log.GetField(req.Context(), log.NamespaceField))
+		return 204, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/namespaces/ns1/messages", nil)
+	handler(httptest.NewRecorder(), mux.SetURLVars(req, map[string]string{"ns": "ns1"}))
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	assert.Equal(t, []string{"ns1", ""}, namespaces)
+}
+
 func TestTimeout(t *testing.T) {
 	mo, as := newTestServer()
 	handler := as.routeHandler(mo, "http://localhost:5000/api/v1", &oapispec.Route{
@@ -338,6 +446,63 @@ func TestBadTimeout(t *testing.T) {
 	res, err := http.DefaultClient.Do(req)
 	assert.NoError(t, err)
 	assert.Equal(t, 204, res.StatusCode)
+	assert.Regexp(t, "FF10367.*bad timeout", res.Header.Get(fftypes.HTTPHeadersWarning))
+}
+
+func TestJSONHTTPWarningsFilterResult(t *testing.T) {
+	mo, as := newTestServer()
+	handler := as.routeHandler(mo, "http://localhost:5000/api/v1", &oapispec.Route{
+		Name:            "testRoute",
+		Path:            "/test",
+		Method:          "GET",
+		FilterFactory:   database.MessageQueryFactory,
+		JSONOutputValue: func() interface{} { return []*fftypes.Message{} },
+		JSONOutputCodes: []int{200},
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+			apiwarnings.Add(r.Ctx, i18n.MsgWarnMessageNearBatchLimit, 95.0, 100.0)
+			total := int64(0)
+			return filterResult([]*fftypes.Message{}, &database.FilterResult{TotalCount: &total}, nil)
+		},
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/test?count&badfield=abc", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	warnings := res.Header.Values(fftypes.HTTPHeadersWarning)
+	assert.Len(t, warnings, 2)
+	assert.Regexp(t, "FF10364.*badfield", warnings[0])
+	assert.Regexp(t, "FF10369", warnings[1])
+	var resJSON filterResultsWithCount
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Equal(t, warnings, resJSON.Warnings)
+}
+
+func TestJSONHTTPWarningsOnError(t *testing.T) {
+	mo, as := newTestServer()
+	handler := as.routeHandler(mo, "http://localhost:5000/api/v1", &oapispec.Route{
+		Name:            "testRoute",
+		Path:            "/test",
+		Method:          "GET",
+		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
+		JSONOutputCodes: []int{200},
+		Deprecated:      true,
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+			return nil, fmt.Errorf("pop")
+		},
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/test", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 500, res.StatusCode)
+	var resJSON fftypes.RESTError
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Equal(t, "pop", resJSON.Error)
+	assert.Len(t, resJSON.Warnings, 1)
+	assert.Regexp(t, "FF10365.*GET /test", resJSON.Warnings[0])
 }
 
 func TestSwaggerUI(t *testing.T) {
@@ -513,3 +678,44 @@ func TestContractAPISwaggerUI(t *testing.T) {
 	b, _ := ioutil.ReadAll(res.Body)
 	assert.Regexp(t, "html", string(b))
 }
+
+func TestJSONHTTPStrictDecode(t *testing.T) {
+	config.Reset()
+	defer config.Reset()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{{"name": "ns1", "strictDecode": true}})
+
+	o, r := newTestAPIServer()
+	mbm := &broadcastmocks.Manager{}
+	o.On("Broadcast").Return(mbm)
+	mbm.On("BroadcastMessage", mock.Anything, mock.Anything, mock.AnythingOfType("*fftypes.MessageInOut"), false).
+		Return(&fftypes.Message{}, nil)
+
+	for _, tc := range []struct {
+		ns     string
+		body   string
+		status int
+		err    string
+	}{
+		{ns: "ns1", body: `{"header":{"tag":"a"},"data":[{"value":{"any":"thing"}}]}`, status: 202},
+		{ns: "ns1", body: `{"header":{"tag":"a","unknown":"b"}}`, status: 400, err: "FF10429.*header.unknown"},
+		{ns: "ns1", body: `{"data":[{"value":"a"},{"valeu":"b"}]}`, status: 400, err: "FF10429.*data\\[1\\].valeu"},
+		{ns: "ns1", body: `{!`, status: 400, err: "invalid character"},
+		{ns: "ns2", body: `{"header":{"tag":"a","unknown":"b"}}`, status: 202},
+	} {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/namespaces/%s/messages/broadcast", tc.ns), strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+		assert.Equal(t, tc.status, res.Result().StatusCode, tc.body)
+		if tc.err != "" {
+			assert.Regexp(t, tc.err, res.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/messages/broadcast", iotest.ErrReader(fmt.Errorf("pop")))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 400, res.Result().StatusCode)
+	assert.Regexp(t, "pop", res.Body.String())
+}
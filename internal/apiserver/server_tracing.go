@@ -0,0 +1,77 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// tracingResponseWriter captures the status of the response for the span
+type tracingResponseWriter struct {
+	http.ResponseWriter
+	ctx    context.Context
+	status int
+}
+
+func (w *tracingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Hijack passes through to the underlying writer, so websocket upgrades work under the middleware
+func (w *tracingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, i18n.NewError(w.ctx, i18n.MsgHijackUnsupported)
+	}
+	return hijacker.Hijack()
+}
+
+// tracingMiddleware starts a server span for each request, continuing the trace of the caller if the request
+// carries a traceparent header. The span is named by the route template rather than the path, so that
+// requests for different resources on the same route are grouped together.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		name := req.URL.Path
+		if route := mux.CurrentRoute(req); route != nil {
+			if tmpl, err := route.GetPathTemplate(); err == nil {
+				name = tmpl
+			}
+		}
+		ctx := tracing.Extract(req.Context(), req.Header)
+		ctx, span := tracing.StartSpan(ctx, req.Method+" "+name,
+			attribute.String("http.method", req.Method),
+			attribute.String("http.target", req.URL.Path),
+		)
+		tw := &tracingResponseWriter{ResponseWriter: res, ctx: ctx, status: http.StatusOK}
+		next.ServeHTTP(tw, req.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.status_code", tw.status))
+		if tw.status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(tw.status))
+		}
+		span.End()
+	})
+}
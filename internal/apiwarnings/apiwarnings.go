@@ -0,0 +1,62 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiwarnings
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+)
+
+type warningsContextKey struct{}
+
+type warnings struct {
+	mux  sync.Mutex
+	list []string
+}
+
+// WithWarnings returns a context that collects non-fatal warnings raised while processing an API request
+func WithWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warningsContextKey{}, &warnings{})
+}
+
+// Add records a translated warning against the API request associated with the context.
+// The warning is always logged, and is silently dropped if the context is not collecting warnings
+// (such as for processing that is not driven by an API call).
+func Add(ctx context.Context, msg i18n.MessageKey, inserts ...interface{}) {
+	warning := i18n.ExpandWithCode(ctx, msg, inserts...)
+	log.L(ctx).Warnf("%s", warning)
+	w, ok := ctx.Value(warningsContextKey{}).(*warnings)
+	if ok {
+		w.mux.Lock()
+		defer w.mux.Unlock()
+		w.list = append(w.list, warning)
+	}
+}
+
+// Get returns the warnings collected for the API request associated with the context, in the order they were added
+func Get(ctx context.Context) []string {
+	w, ok := ctx.Value(warningsContextKey{}).(*warnings)
+	if !ok {
+		return nil
+	}
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return append([]string{}, w.list...)
+}
@@ -0,0 +1,48 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiwarnings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarningsCollected(t *testing.T) {
+	ctx := WithWarnings(context.Background())
+	assert.Empty(t, Get(ctx))
+
+	Add(ctx, i18n.MsgWarnUnknownSortField, "field1")
+	Add(ctx, i18n.MsgWarnUnknownQueryParam, "param1")
+
+	warnings := Get(ctx)
+	assert.Len(t, warnings, 2)
+	assert.Regexp(t, "FF10363.*field1", warnings[0])
+	assert.Regexp(t, "FF10364.*param1", warnings[1])
+
+	// Returned slice is a copy
+	warnings[0] = "changed"
+	assert.Regexp(t, "FF10363", Get(ctx)[0])
+}
+
+func TestWarningsNotCollected(t *testing.T) {
+	ctx := context.Background()
+	Add(ctx, i18n.MsgWarnUnknownSortField, "field1")
+	assert.Nil(t, Get(ctx))
+}
@@ -0,0 +1,36 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/publicstorage/s3"
+)
+
+const (
+	// ArchiveTypeFilesystem writes archives as files to the configured directory
+	ArchiveTypeFilesystem = "filesystem"
+	// ArchiveTypeS3 writes archives as objects to an S3-compatible bucket, configured under archive.s3
+	ArchiveTypeS3 = "s3"
+)
+
+var s3Config = config.NewPluginConfig("archive.s3")
+
+// InitConfig registers the config of the S3-compatible object store
+func InitConfig() {
+	(&s3.S3{}).InitPrefix(s3Config)
+}
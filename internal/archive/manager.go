@@ -0,0 +1,345 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/karlseguin/ccache"
+)
+
+// Manager runs the background tiering of old events, and the data of the messages they confirm, into
+// compressed archives in object storage. It provides the read path to fetch archived records on demand.
+//
+// Archived records are not returned by list queries, so ArchivedBefore lets callers indicate that results might be incomplete.
+type Manager interface {
+	Start() error
+	WaitStop()
+
+	// GetEventByID returns an event that has been moved into an archive, or nil if it has not been archived
+	GetEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Event, error)
+	// GetDataByID returns data that has been moved into an archive, or nil if it has not been archived
+	GetDataByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Data, error)
+	// ArchivedBefore returns the creation time of the newest archived event, or nil if nothing has been archived
+	ArchivedBefore(ctx context.Context) (*fftypes.FFTime, error)
+}
+
+// archiveContents is the compressed JSON payload of an archive
+type archiveContents struct {
+	Events []*fftypes.Event `json:"events"`
+	Data   []*fftypes.Data  `json:"data"`
+}
+
+type archiveManager struct {
+	ctx       context.Context
+	cancelCtx context.CancelFunc
+	database  database.Plugin
+	store     ObjectStore
+	threshold time.Duration
+	interval  time.Duration
+	batchSize int
+	cache     *ccache.Cache
+	cacheTTL  time.Duration
+	done      chan struct{}
+}
+
+func NewArchiveManager(ctx context.Context, di database.Plugin) (Manager, error) {
+	if di == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
+	}
+	store, err := newObjectStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	am := &archiveManager{
+		database:  di,
+		store:     store,
+		threshold: config.GetDuration(config.ArchiveThreshold),
+		interval:  config.GetDuration(config.ArchiveInterval),
+		batchSize: config.GetInt(config.ArchiveBatchSize),
+		cache: ccache.New(
+			ccache.Configure().MaxSize(config.GetInt64(config.ArchiveCacheSize)),
+		),
+		cacheTTL: config.GetDuration(config.ArchiveCacheTTL),
+		done:     make(chan struct{}),
+	}
+	am.ctx, am.cancelCtx = context.WithCancel(log.WithLogField(log.WithModule(ctx, "archive"), "role", "archive"))
+	return am, nil
+}
+
+func (am *archiveManager) Start() error {
+	go am.tieringLoop()
+	return nil
+}
+
+func (am *archiveManager) WaitStop() {
+	am.cancelCtx()
+	<-am.done
+}
+
+func (am *archiveManager) tieringLoop() {
+	defer close(am.done)
+	for {
+		// Keep archiving full batches until we are up to date
+		for {
+			archive, err := am.archiveBatch(am.ctx)
+			if err != nil {
+				log.L(am.ctx).Errorf("Archiving failed: %s", err)
+				break
+			}
+			if archive == nil || archive.EventCount < int64(am.batchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-am.ctx.Done():
+			log.L(am.ctx).Debugf("Archive tiering loop exiting")
+			return
+		case <-time.After(am.interval):
+		}
+	}
+}
+
+// deliveredSequence returns the highest event sequence that has been delivered to every durable subscription
+func (am *archiveManager) deliveredSequence(ctx context.Context) (sequence int64, limited bool, err error) {
+	fb := database.OffsetQueryFactory.NewFilter(ctx)
+	offsets, _, err := am.database.GetOffsets(ctx, fb.Eq("type", fftypes.OffsetTypeSubscription))
+	if err != nil {
+		return -1, false, err
+	}
+	for _, offset := range offsets {
+		if !limited || offset.Current < sequence {
+			sequence = offset.Current
+			limited = true
+		}
+	}
+	return sequence, limited, nil
+}
+
+// deliveredData returns the data of the messages confirmed (or rejected) by the events being archived.
+// Data can be referenced by multiple messages, so is only archived along with the last message that uses it.
+func (am *archiveManager) deliveredData(ctx context.Context, events []*fftypes.Event) ([]*fftypes.Data, error) {
+	msgIDs := make(map[fftypes.UUID]bool)
+	for _, event := range events {
+		if (event.Type == fftypes.EventTypeMessageConfirmed || event.Type == fftypes.EventTypeMessageRejected) && event.Reference != nil {
+			msgIDs[*event.Reference] = true
+		}
+	}
+
+	data := []*fftypes.Data{}
+	dataIDs := make(map[fftypes.UUID]bool)
+	for _, event := range events {
+		if event.Reference == nil || !msgIDs[*event.Reference] {
+			continue
+		}
+		msg, err := am.database.GetMessageByID(ctx, event.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			continue
+		}
+		for _, dataRef := range msg.Data {
+			if dataRef.ID == nil || dataIDs[*dataRef.ID] {
+				continue
+			}
+			msgs, _, err := am.database.GetMessagesForData(ctx, dataRef.ID, database.MessageQueryFactory.NewFilter(ctx).And())
+			if err != nil {
+				return nil, err
+			}
+			shared := false
+			for _, m := range msgs {
+				shared = shared || !msgIDs[*m.Header.ID]
+			}
+			if shared {
+				continue
+			}
+			d, err := am.database.GetDataByID(ctx, dataRef.ID, true)
+			if err != nil {
+				return nil, err
+			}
+			if d != nil {
+				dataIDs[*d.ID] = true
+				data = append(data, d)
+			}
+		}
+	}
+	return data, nil
+}
+
+// archiveBatch moves the next batch of events older than the threshold, along with the delivered data
+// of the messages they confirm, into an archive. Returns nil if there was nothing to archive.
+func (am *archiveManager) archiveBatch(ctx context.Context) (*fftypes.Archive, error) {
+	fb := database.EventQueryFactory.NewFilter(ctx)
+	conditions := []database.Filter{
+		fb.Lt("created", fftypes.FFTime(time.Now().Add(-am.threshold))),
+	}
+	// Events are only archived once they have been delivered to every durable subscription
+	sequence, limited, err := am.deliveredSequence(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if limited {
+		conditions = append(conditions, fb.Lte("sequence", sequence))
+	}
+	events, _, err := am.database.GetEvents(ctx, fb.And(conditions...).Sort("sequence").Limit(uint64(am.batchSize)))
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+
+	data, err := am.deliveredData(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &fftypes.Archive{
+		ID:            fftypes.NewUUID(),
+		EventCount:    int64(len(events)),
+		DataCount:     int64(len(data)),
+		FirstSequence: events[0].Sequence,
+		LastSequence:  events[len(events)-1].Sequence,
+		Oldest:        events[0].Created,
+		Newest:        events[len(events)-1].Created,
+	}
+	archive.Location = archive.ID.String() + ".json.gz"
+	eventIDs := make([]*fftypes.UUID, len(events))
+	for i, event := range events {
+		eventIDs[i] = event.ID
+		archive.Entries = append(archive.Entries, &fftypes.ArchiveEntry{Type: fftypes.ArchiveEntryTypeEvent, ID: event.ID})
+	}
+	dataIDs := make([]*fftypes.UUID, len(data))
+	for i, d := range data {
+		dataIDs[i] = d.ID
+		archive.Entries = append(archive.Entries, &fftypes.ArchiveEntry{Type: fftypes.ArchiveEntryTypeData, ID: d.ID})
+	}
+
+	// Write the compressed archive, and then its manifest, to the object store
+	var buff bytes.Buffer
+	gz := gzip.NewWriter(&buff)
+	_ = json.NewEncoder(gz).Encode(&archiveContents{Events: events, Data: data})
+	_ = gz.Close()
+	b := buff.Bytes()
+	hash := fftypes.Bytes32(sha256.Sum256(b))
+	archive.Hash = &hash
+	archive.Size = int64(len(b))
+	if err = am.store.Put(ctx, archive.Location, b); err != nil {
+		return nil, err
+	}
+	manifest, _ := json.Marshal(archive)
+	if err = am.store.Put(ctx, archive.ID.String()+".manifest.json", manifest); err != nil {
+		return nil, err
+	}
+
+	// Record the manifest, and remove the archived records from the database
+	err = am.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := am.database.InsertArchive(ctx, archive); err != nil {
+			return err
+		}
+		if err := am.database.DeleteEvents(ctx, eventIDs); err != nil {
+			return err
+		}
+		if len(dataIDs) > 0 {
+			return am.database.DeleteData(ctx, dataIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Archived %d events (sequence %d-%d) and %d data to '%s'", archive.EventCount, archive.FirstSequence, archive.LastSequence, archive.DataCount, archive.Location)
+	return archive, nil
+}
+
+func (am *archiveManager) fetchArchive(ctx context.Context, archive *fftypes.Archive) (*archiveContents, error) {
+	if cached := am.cache.Get(archive.ID.String()); cached != nil {
+		cached.Extend(am.cacheTTL)
+		return cached.Value().(*archiveContents), nil
+	}
+
+	b, err := am.store.Get(ctx, archive.Location)
+	if err != nil {
+		return nil, err
+	}
+	hash := fftypes.Bytes32(sha256.Sum256(b))
+	if !hash.Equals(archive.Hash) {
+		return nil, i18n.NewError(ctx, i18n.MsgArchiveHashMismatch, archive.ID, archive.Hash, &hash)
+	}
+	var contents archiveContents
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	if err == nil {
+		err = json.NewDecoder(gz).Decode(&contents)
+	}
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgArchiveReadFailed, archive.ID)
+	}
+	am.cache.Set(archive.ID.String(), &contents, am.cacheTTL)
+	return &contents, nil
+}
+
+func (am *archiveManager) fetchArchiveForEntry(ctx context.Context, entryType fftypes.ArchiveEntryType, id *fftypes.UUID) (*archiveContents, error) {
+	archive, err := am.database.GetArchiveForEntry(ctx, entryType, id)
+	if err != nil || archive == nil {
+		return nil, err
+	}
+	return am.fetchArchive(ctx, archive)
+}
+
+func (am *archiveManager) GetEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Event, error) {
+	contents, err := am.fetchArchiveForEntry(ctx, fftypes.ArchiveEntryTypeEvent, id)
+	if err != nil || contents == nil {
+		return nil, err
+	}
+	for _, event := range contents.Events {
+		if event.ID.Equals(id) {
+			return event, nil
+		}
+	}
+	return nil, nil
+}
+
+func (am *archiveManager) GetDataByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Data, error) {
+	contents, err := am.fetchArchiveForEntry(ctx, fftypes.ArchiveEntryTypeData, id)
+	if err != nil || contents == nil {
+		return nil, err
+	}
+	for _, d := range contents.Data {
+		if d.ID.Equals(id) {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (am *archiveManager) ArchivedBefore(ctx context.Context) (*fftypes.FFTime, error) {
+	fb := database.ArchiveQueryFactory.NewFilter(ctx)
+	archives, _, err := am.database.GetArchives(ctx, fb.And().Sort("lastsequence").Descending().Limit(1))
+	if err != nil || len(archives) == 0 {
+		return nil, err
+	}
+	return archives[0].Newest, nil
+}
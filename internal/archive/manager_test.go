@@ -0,0 +1,483 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testStore struct {
+	objects map[string][]byte
+	putErrs []error
+	getErr  error
+}
+
+func (ts *testStore) Put(ctx context.Context, key string, data []byte) error {
+	if len(ts.putErrs) > 0 {
+		err := ts.putErrs[0]
+		ts.putErrs = ts.putErrs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	ts.objects[key] = data
+	return nil
+}
+
+func (ts *testStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if ts.getErr != nil {
+		return nil, ts.getErr
+	}
+	return ts.objects[key], nil
+}
+
+func newTestArchiveManager(t *testing.T) (*archiveManager, *databasemocks.Plugin, *testStore, func()) {
+	config.Reset()
+	config.Set(config.ArchiveDirectory, filepath.Join(t.TempDir(), "archives"))
+	mdi := &databasemocks.Plugin{}
+	am, err := NewArchiveManager(context.Background(), mdi)
+	assert.NoError(t, err)
+	ts := &testStore{objects: make(map[string][]byte)}
+	am.(*archiveManager).store = ts
+	return am.(*archiveManager), mdi, ts, func() {
+		am.(*archiveManager).cancelCtx()
+		mdi.AssertExpectations(t)
+	}
+}
+
+func writeTestArchive(t *testing.T, ts *testStore, contents *archiveContents) *fftypes.Archive {
+	var buff bytes.Buffer
+	gz := gzip.NewWriter(&buff)
+	err := json.NewEncoder(gz).Encode(contents)
+	assert.NoError(t, err)
+	gz.Close()
+	hash := fftypes.Bytes32(sha256.Sum256(buff.Bytes()))
+	archive := &fftypes.Archive{
+		ID:       fftypes.NewUUID(),
+		Location: "archive1.json.gz",
+		Hash:     &hash,
+	}
+	ts.objects[archive.Location] = buff.Bytes()
+	return archive
+}
+
+func TestNewArchiveManagerNilDeps(t *testing.T) {
+	_, err := NewArchiveManager(context.Background(), nil)
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestNewArchiveManagerMissingDirectory(t *testing.T) {
+	config.Reset()
+	_, err := NewArchiveManager(context.Background(), &databasemocks.Plugin{})
+	assert.Regexp(t, "FF10138", err)
+}
+
+func TestStartWaitStop(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+	am.interval = 0
+	am.batchSize = 1
+
+	calls := 0
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return(func(ctx context.Context, filter database.Filter) []*fftypes.Event {
+		calls++
+		if calls == 1 {
+			return []*fftypes.Event{{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransactionSubmitted, Sequence: 1}}
+		}
+		if calls == 3 {
+			am.cancelCtx()
+		}
+		return []*fftypes.Event{}
+	}, nil, nil)
+	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	mdi.On("InsertArchive", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("DeleteEvents", mock.Anything, mock.Anything).Return(nil)
+
+	err := am.Start()
+	assert.NoError(t, err)
+	<-am.done
+	am.WaitStop()
+}
+
+func TestArchiveBatchSuccess(t *testing.T) {
+	am, mdi, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	msgID1 := fftypes.NewUUID()
+	msgID2 := fftypes.NewUUID()
+	dataID1 := fftypes.NewUUID()
+	dataID2 := fftypes.NewUUID()
+	dataID3 := fftypes.NewUUID()
+	events := []*fftypes.Event{
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: msgID1, Sequence: 10, Created: fftypes.Now()},
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageRejected, Reference: msgID2, Sequence: 11, Created: fftypes.Now()},
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransactionSubmitted, Reference: fftypes.NewUUID(), Sequence: 12, Created: fftypes.Now()},
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: fftypes.NewUUID(), Sequence: 13, Created: fftypes.Now()},
+	}
+	msg1 := &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID1},
+		Data:   fftypes.DataRefs{{ID: dataID1}, {ID: dataID2}, {}},
+	}
+	msg2 := &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID2},
+		Data:   fftypes.DataRefs{{ID: dataID1}, {ID: dataID3}},
+	}
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{
+		{Current: 20}, {Current: 15}, {Current: 30},
+	}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.MatchedBy(func(filter database.Filter) bool {
+		f, _ := filter.Finalize()
+		return strings.HasSuffix(f.String(), "&& ( sequence <= 15 ) sort=sequence limit=1000")
+	})).Return(events, nil, nil)
+	mdi.On("GetMessageByID", mock.Anything, msgID1).Return(msg1, nil)
+	mdi.On("GetMessageByID", mock.Anything, msgID2).Return(msg2, nil)
+	mdi.On("GetMessageByID", mock.Anything, events[3].Reference).Return(nil, nil)
+	mdi.On("GetMessagesForData", mock.Anything, dataID1, mock.Anything).Return([]*fftypes.Message{msg1, msg2}, nil, nil)
+	mdi.On("GetMessagesForData", mock.Anything, dataID2, mock.Anything).Return([]*fftypes.Message{msg1, {Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}}, nil, nil)
+	mdi.On("GetMessagesForData", mock.Anything, dataID3, mock.Anything).Return([]*fftypes.Message{msg2}, nil, nil)
+	mdi.On("GetDataByID", mock.Anything, dataID1, true).Return(&fftypes.Data{ID: dataID1}, nil)
+	mdi.On("GetDataByID", mock.Anything, dataID3, true).Return(nil, nil)
+	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		err := args[1].(func(context.Context) error)(context.Background())
+		assert.NoError(t, err)
+	}).Return(nil)
+	mdi.On("InsertArchive", mock.Anything, mock.MatchedBy(func(a *fftypes.Archive) bool {
+		return a.EventCount == 4 && a.DataCount == 1 && a.FirstSequence == 10 && a.LastSequence == 13 && len(a.Entries) == 5
+	})).Return(nil)
+	mdi.On("DeleteEvents", mock.Anything, []*fftypes.UUID{events[0].ID, events[1].ID, events[2].ID, events[3].ID}).Return(nil)
+	mdi.On("DeleteData", mock.Anything, []*fftypes.UUID{dataID1}).Return(nil)
+
+	archive, err := am.archiveBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, archive.ID.String()+".json.gz", archive.Location)
+	assert.Equal(t, int64(len(ts.objects[archive.Location])), archive.Size)
+	assert.NotNil(t, ts.objects[archive.ID.String()+".manifest.json"])
+
+	// Check the archived contents can be read back
+	contents, err := am.fetchArchive(context.Background(), archive)
+	assert.NoError(t, err)
+	assert.Len(t, contents.Events, 4)
+	assert.Len(t, contents.Data, 1)
+}
+
+func TestArchiveBatchNoEvents(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+
+	archive, err := am.archiveBatch(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, archive)
+}
+
+func TestArchiveBatchGetMessageFail(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: fftypes.NewUUID()},
+	}, nil, nil)
+	mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.archiveBatch(context.Background())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestArchiveBatchGetMessagesForDataFail(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: fftypes.NewUUID()},
+	}, nil, nil)
+	mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(&fftypes.Message{
+		Data: fftypes.DataRefs{{ID: fftypes.NewUUID()}},
+	}, nil)
+	mdi.On("GetMessagesForData", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := am.archiveBatch(context.Background())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestArchiveBatchGetDataFail(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: fftypes.NewUUID()},
+	}, nil, nil)
+	mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(&fftypes.Message{
+		Data: fftypes.DataRefs{{ID: fftypes.NewUUID()}},
+	}, nil)
+	mdi.On("GetMessagesForData", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetDataByID", mock.Anything, mock.Anything, true).Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.archiveBatch(context.Background())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestArchiveBatchPutArchiveFail(t *testing.T) {
+	am, mdi, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+	ts.putErrs = []error{fmt.Errorf("pop")}
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransactionSubmitted},
+	}, nil, nil)
+
+	_, err := am.archiveBatch(context.Background())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestArchiveBatchPutManifestFail(t *testing.T) {
+	am, mdi, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+	ts.putErrs = []error{nil, fmt.Errorf("pop")}
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransactionSubmitted},
+	}, nil, nil)
+
+	_, err := am.archiveBatch(context.Background())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestArchiveBatchInsertArchiveFail(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransactionSubmitted},
+	}, nil, nil)
+	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	mdi.On("InsertArchive", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.archiveBatch(context.Background())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestArchiveBatchDeleteEventsFail(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetOffsets", mock.Anything, mock.Anything).Return([]*fftypes.Offset{}, nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransactionSubmitted},
+	}, nil, nil)
+	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	mdi.On("InsertArchive", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("DeleteEvents", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.archiveBatch(context.Background())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestFetchArchiveCached(t *testing.T) {
+	am, _, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	archive := writeTestArchive(t, ts, &archiveContents{Events: []*fftypes.Event{{ID: fftypes.NewUUID()}}})
+	contents, err := am.fetchArchive(context.Background(), archive)
+	assert.NoError(t, err)
+	assert.Len(t, contents.Events, 1)
+
+	ts.getErr = fmt.Errorf("pop")
+	contents, err = am.fetchArchive(context.Background(), archive)
+	assert.NoError(t, err)
+	assert.Len(t, contents.Events, 1)
+}
+
+func TestFetchArchiveGetFail(t *testing.T) {
+	am, _, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	archive := writeTestArchive(t, ts, &archiveContents{})
+	ts.getErr = fmt.Errorf("pop")
+	_, err := am.fetchArchive(context.Background(), archive)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestFetchArchiveHashMismatch(t *testing.T) {
+	am, _, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	archive := writeTestArchive(t, ts, &archiveContents{})
+	archive.Hash = fftypes.NewRandB32()
+	_, err := am.fetchArchive(context.Background(), archive)
+	assert.Regexp(t, "FF10409", err)
+}
+
+func TestFetchArchiveBadContent(t *testing.T) {
+	am, _, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	archive := writeTestArchive(t, ts, &archiveContents{})
+	ts.objects[archive.Location] = []byte("not gzip")
+	hash := fftypes.Bytes32(sha256.Sum256(ts.objects[archive.Location]))
+	archive.Hash = &hash
+	_, err := am.fetchArchive(context.Background(), archive)
+	assert.Regexp(t, "FF10410", err)
+}
+
+func TestGetEventByID(t *testing.T) {
+	am, mdi, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	event := &fftypes.Event{ID: fftypes.NewUUID()}
+	archive := writeTestArchive(t, ts, &archiveContents{Events: []*fftypes.Event{{ID: fftypes.NewUUID()}, event}})
+	mdi.On("GetArchiveForEntry", mock.Anything, fftypes.ArchiveEntryTypeEvent, event.ID).Return(archive, nil)
+
+	result, err := am.GetEventByID(context.Background(), event.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, event.ID, result.ID)
+}
+
+func TestGetEventByIDNotInArchive(t *testing.T) {
+	am, mdi, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	archive := writeTestArchive(t, ts, &archiveContents{})
+	mdi.On("GetArchiveForEntry", mock.Anything, fftypes.ArchiveEntryTypeEvent, mock.Anything).Return(archive, nil)
+
+	result, err := am.GetEventByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestGetEventByIDNotArchived(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetArchiveForEntry", mock.Anything, fftypes.ArchiveEntryTypeEvent, mock.Anything).Return(nil, nil)
+
+	result, err := am.GetEventByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestGetEventByIDFail(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetArchiveForEntry", mock.Anything, fftypes.ArchiveEntryTypeEvent, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.GetEventByID(context.Background(), fftypes.NewUUID())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetDataByID(t *testing.T) {
+	am, mdi, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	data := &fftypes.Data{ID: fftypes.NewUUID()}
+	archive := writeTestArchive(t, ts, &archiveContents{Data: []*fftypes.Data{{ID: fftypes.NewUUID()}, data}})
+	mdi.On("GetArchiveForEntry", mock.Anything, fftypes.ArchiveEntryTypeData, data.ID).Return(archive, nil)
+
+	result, err := am.GetDataByID(context.Background(), data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, data.ID, result.ID)
+}
+
+func TestGetDataByIDNotInArchive(t *testing.T) {
+	am, mdi, ts, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	archive := writeTestArchive(t, ts, &archiveContents{Data: []*fftypes.Data{{ID: fftypes.NewUUID()}}})
+	mdi.On("GetArchiveForEntry", mock.Anything, fftypes.ArchiveEntryTypeData, mock.Anything).Return(archive, nil)
+
+	result, err := am.GetDataByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestGetDataByIDFail(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetArchiveForEntry", mock.Anything, fftypes.ArchiveEntryTypeData, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.GetDataByID(context.Background(), fftypes.NewUUID())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestArchivedBefore(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	newest := fftypes.Now()
+	mdi.On("GetArchives", mock.Anything, mock.MatchedBy(func(filter database.Filter) bool {
+		f, _ := filter.Finalize()
+		return strings.HasSuffix(f.String(), "sort=-lastsequence limit=1")
+	})).Return([]*fftypes.Archive{{Newest: newest}}, nil, nil)
+
+	result, err := am.ArchivedBefore(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, newest, result)
+}
+
+func TestArchivedBeforeNone(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetArchives", mock.Anything, mock.Anything).Return([]*fftypes.Archive{}, nil, nil)
+
+	result, err := am.ArchivedBefore(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestArchivedBeforeFail(t *testing.T) {
+	am, mdi, _, cancel := newTestArchiveManager(t)
+	defer cancel()
+
+	mdi.On("GetArchives", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := am.ArchivedBefore(context.Background())
+	assert.EqualError(t, err, "pop")
+}
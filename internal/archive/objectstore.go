@@ -0,0 +1,105 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/publicstorage/s3"
+)
+
+// ObjectStore is the object storage that archives are written to, and fetched from on demand
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// newObjectStore creates the object store of the configured type
+func newObjectStore(ctx context.Context) (ObjectStore, error) {
+	storeType := config.GetString(config.ArchiveType)
+	switch storeType {
+	case ArchiveTypeFilesystem:
+		return newFSObjectStore(ctx, config.GetString(config.ArchiveDirectory))
+	case ArchiveTypeS3:
+		return newS3ObjectStore(ctx, s3Config)
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownArchiveType, storeType)
+	}
+}
+
+// fsObjectStore stores objects as files in a directory, which can be a mounted object storage bucket
+type fsObjectStore struct {
+	directory string
+}
+
+func newFSObjectStore(ctx context.Context, directory string) (ObjectStore, error) {
+	if directory == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "directory", "archive")
+	}
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgArchiveStoreFailed, directory)
+	}
+	return &fsObjectStore{directory: directory}, nil
+}
+
+func (fs *fsObjectStore) Put(ctx context.Context, key string, data []byte) error {
+	// Write to a temporary file first, so that a partially written object is never visible
+	path := filepath.Join(fs.directory, key)
+	tmpPath := path + ".tmp"
+	err := ioutil.WriteFile(tmpPath, data, 0644)
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgArchiveStoreFailed, key)
+	}
+	return nil
+}
+
+func (fs *fsObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(fs.directory, key))
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgArchiveStoreFailed, key)
+	}
+	return data, nil
+}
+
+// s3ObjectStore stores objects in an S3-compatible bucket, using the same client as the S3 public storage plugin
+type s3ObjectStore struct {
+	client *s3.S3
+}
+
+func newS3ObjectStore(ctx context.Context, prefix config.Prefix) (ObjectStore, error) {
+	client := &s3.S3{}
+	if err := client.Init(ctx, prefix, nil); err != nil {
+		return nil, err
+	}
+	return &s3ObjectStore{client: client}, nil
+}
+
+func (s *s3ObjectStore) Put(ctx context.Context, key string, data []byte) error {
+	return s.client.PutObject(ctx, key, data)
+}
+
+func (s *s3ObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.client.GetObject(ctx, key)
+}
@@ -0,0 +1,153 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/publicstorage/s3"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFSObjectStoreMissingDirectory(t *testing.T) {
+	_, err := newFSObjectStore(context.Background(), "")
+	assert.Regexp(t, "FF10138", err)
+}
+
+func TestNewFSObjectStoreBadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	err := ioutil.WriteFile(file, []byte{}, 0644)
+	assert.NoError(t, err)
+	_, err = newFSObjectStore(context.Background(), filepath.Join(file, "sub"))
+	assert.Regexp(t, "FF10408", err)
+}
+
+func TestFSObjectStorePutGet(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archives")
+	store, err := newFSObjectStore(context.Background(), dir)
+	assert.NoError(t, err)
+
+	err = store.Put(context.Background(), "obj1", []byte("hello"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "obj1.tmp"))
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := store.Get(context.Background(), "obj1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestFSObjectStorePutFail(t *testing.T) {
+	dir := t.TempDir()
+	store, err := newFSObjectStore(context.Background(), dir)
+	assert.NoError(t, err)
+	err = store.Put(context.Background(), "missing/obj1", []byte("hello"))
+	assert.Regexp(t, "FF10408.*missing/obj1", err)
+}
+
+func TestFSObjectStoreGetFail(t *testing.T) {
+	store, err := newFSObjectStore(context.Background(), t.TempDir())
+	assert.NoError(t, err)
+	_, err = store.Get(context.Background(), "obj1")
+	assert.Regexp(t, "FF10408.*obj1", err)
+}
+
+func TestNewObjectStoreFilesystem(t *testing.T) {
+	config.Reset()
+	config.Set(config.ArchiveDirectory, t.TempDir())
+	store, err := newObjectStore(context.Background())
+	assert.NoError(t, err)
+	assert.IsType(t, &fsObjectStore{}, store)
+}
+
+func TestNewObjectStoreUnknownType(t *testing.T) {
+	config.Reset()
+	config.Set(config.ArchiveType, "wrong")
+	_, err := newObjectStore(context.Background())
+	assert.Regexp(t, "FF10512.*wrong", err)
+}
+
+func TestNewObjectStoreS3MissingURL(t *testing.T) {
+	config.Reset()
+	InitConfig()
+	config.Set(config.ArchiveType, ArchiveTypeS3)
+	_, err := newObjectStore(context.Background())
+	assert.Regexp(t, "FF10138.*archive.s3.url", err)
+}
+
+func TestS3ObjectStorePutGet(t *testing.T) {
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	config.Reset()
+	InitConfig()
+	config.Set(config.ArchiveType, ArchiveTypeS3)
+	s3Config.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	s3Config.Set(restclient.HTTPCustomClient, mockedClient)
+	s3Config.Set(s3.S3ConfBucket, "bucket1")
+	s3Config.Set(s3.S3ConfKeyPrefix, "archives/")
+
+	objects := make(map[string][]byte)
+	httpmock.RegisterResponder("PUT", "http://localhost:12345/bucket1/archives/obj1",
+		func(req *http.Request) (*http.Response, error) {
+			objects[req.URL.Path], _ = ioutil.ReadAll(req.Body)
+			return httpmock.NewBytesResponse(200, []byte{}), nil
+		})
+	httpmock.RegisterResponder("GET", "http://localhost:12345/bucket1/archives/obj1",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewBytesResponse(200, objects[req.URL.Path]), nil
+		})
+
+	store, err := newObjectStore(context.Background())
+	assert.NoError(t, err)
+
+	err = store.Put(context.Background(), "obj1", []byte("hello"))
+	assert.NoError(t, err)
+	data, err := store.Get(context.Background(), "obj1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestS3ObjectStoreGetFail(t *testing.T) {
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	config.Reset()
+	InitConfig()
+	s3Config.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	s3Config.Set(restclient.HTTPCustomClient, mockedClient)
+	s3Config.Set(s3.S3ConfBucket, "bucket1")
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/bucket1/obj1",
+		httpmock.NewStringResponder(404, "not found"))
+
+	store, err := newS3ObjectStore(context.Background(), s3Config)
+	assert.NoError(t, err)
+	_, err = store.Get(context.Background(), "obj1")
+	assert.Regexp(t, "FF10379", err)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/hyperledger/firefly/pkg/tokens"
 )
 
@@ -41,6 +42,7 @@ type Manager interface {
 	GetTokenPools(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenPool, *database.FilterResult, error)
 	GetTokenPool(ctx context.Context, ns, connector, poolName string) (*fftypes.TokenPool, error)
 	GetTokenPoolByNameOrID(ctx context.Context, ns string, poolNameOrID string) (*fftypes.TokenPool, error)
+	UpdateTokenPoolState(ctx context.Context, ns, poolNameOrID string, state fftypes.TokenPoolState) (*fftypes.TokenPool, error)
 
 	GetTokenBalances(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenBalance, *database.FilterResult, error)
 	GetTokenAccounts(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenAccount, *database.FilterResult, error)
@@ -59,49 +61,65 @@ type Manager interface {
 	NewApproval(ns string, approve *fftypes.TokenApprovalInput) sysmessaging.MessageSender
 	TokenApproval(ctx context.Context, ns string, approval *fftypes.TokenApprovalInput, waitConfirm bool) (*fftypes.TokenApproval, error)
 	GetTokenApprovals(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenApproval, *database.FilterResult, error)
+	GetTokenApprovalByID(ctx context.Context, ns, id string) (*fftypes.TokenApproval, error)
+
+	RetryOperation(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error)
 
 	Start() error
 	WaitStop()
 }
 
 type assetManager struct {
-	ctx       context.Context
-	database  database.Plugin
-	txHelper  txcommon.Helper
-	identity  identity.Manager
-	data      data.Manager
-	syncasync syncasync.Bridge
-	broadcast broadcast.Manager
-	messaging privatemessaging.Manager
-	tokens    map[string]tokens.Plugin
-	retry     retry.Retry
-	metrics   metrics.Manager
+	ctx           context.Context
+	database      database.Plugin
+	txHelper      txcommon.Helper
+	identity      identity.Manager
+	data          data.Manager
+	syncasync     syncasync.Bridge
+	broadcast     broadcast.Manager
+	messaging     privatemessaging.Manager
+	publicstorage publicstorage.Plugin
+	tokens        map[string]tokens.Plugin
+	retry         retry.Retry
+	metrics       metrics.Manager
+	readOnly      bool
 }
 
-func NewAssetManager(ctx context.Context, di database.Plugin, im identity.Manager, dm data.Manager, sa syncasync.Bridge, bm broadcast.Manager, pm privatemessaging.Manager, ti map[string]tokens.Plugin, mm metrics.Manager) (Manager, error) {
-	if di == nil || im == nil || sa == nil || bm == nil || pm == nil || ti == nil {
+func NewAssetManager(ctx context.Context, di database.Plugin, im identity.Manager, dm data.Manager, sa syncasync.Bridge, bm broadcast.Manager, pm privatemessaging.Manager, pi publicstorage.Plugin, ti map[string]tokens.Plugin, mm metrics.Manager) (Manager, error) {
+	if di == nil || im == nil || sa == nil || bm == nil || pm == nil || pi == nil || ti == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 	am := &assetManager{
-		ctx:       ctx,
-		database:  di,
-		txHelper:  txcommon.NewTransactionHelper(di),
-		identity:  im,
-		data:      dm,
-		syncasync: sa,
-		broadcast: bm,
-		messaging: pm,
-		tokens:    ti,
+		ctx:           ctx,
+		database:      di,
+		txHelper:      txcommon.NewTransactionHelper(di),
+		identity:      im,
+		data:          dm,
+		syncasync:     sa,
+		broadcast:     bm,
+		messaging:     pm,
+		publicstorage: pi,
+		tokens:        ti,
 		retry: retry.Retry{
 			InitialDelay: config.GetDuration(config.AssetManagerRetryInitialDelay),
 			MaximumDelay: config.GetDuration(config.AssetManagerRetryMaxDelay),
 			Factor:       config.GetFloat64(config.AssetManagerRetryFactor),
 		},
-		metrics: mm,
+		metrics:  mm,
+		readOnly: config.GetBool(config.NodeReadOnly),
 	}
+	am.retry.ReloadFromConfig(ctx, config.AssetManagerRetryInitialDelay, config.AssetManagerRetryMaxDelay, config.AssetManagerRetryFactor)
 	return am, nil
 }
 
+// checkWritable refuses to submit a request to a token connector when the node is in read-only mode
+func (am *assetManager) checkWritable(ctx context.Context) error {
+	if am.readOnly {
+		return i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+	}
+	return nil
+}
+
 func (am *assetManager) selectTokenPlugin(ctx context.Context, name string) (tokens.Plugin, error) {
 	for pluginName, plugin := range am.tokens {
 		if pluginName == name {
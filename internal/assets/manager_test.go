@@ -25,6 +25,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/metricsmocks"
 	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
 	"github.com/hyperledger/firefly/mocks/txcommonmocks"
@@ -43,12 +44,13 @@ func newTestAssets(t *testing.T) (*assetManager, func()) {
 	msa := &syncasyncmocks.Bridge{}
 	mbm := &broadcastmocks.Manager{}
 	mpm := &privatemessagingmocks.Manager{}
+	mps := &publicstoragemocks.Plugin{}
 	mti := &tokenmocks.Plugin{}
 	mm := &metricsmocks.Manager{}
 	mti.On("Name").Return("ut_tokens").Maybe()
 	mm.On("IsMetricsEnabled").Return(false)
 	ctx, cancel := context.WithCancel(context.Background())
-	a, err := NewAssetManager(ctx, mdi, mim, mdm, msa, mbm, mpm, map[string]tokens.Plugin{"magic-tokens": mti}, mm)
+	a, err := NewAssetManager(ctx, mdi, mim, mdm, msa, mbm, mpm, mps, map[string]tokens.Plugin{"magic-tokens": mti}, mm)
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
 	rag.RunFn = func(a mock.Arguments) {
 		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
@@ -67,13 +69,14 @@ func newTestAssetsWithMetrics(t *testing.T) (*assetManager, func()) {
 	msa := &syncasyncmocks.Bridge{}
 	mbm := &broadcastmocks.Manager{}
 	mpm := &privatemessagingmocks.Manager{}
+	mps := &publicstoragemocks.Plugin{}
 	mti := &tokenmocks.Plugin{}
 	mm := &metricsmocks.Manager{}
 	mti.On("Name").Return("ut_tokens").Maybe()
 	mm.On("IsMetricsEnabled").Return(true)
 	mm.On("TransferSubmitted", mock.Anything)
 	ctx, cancel := context.WithCancel(context.Background())
-	a, err := NewAssetManager(ctx, mdi, mim, mdm, msa, mbm, mpm, map[string]tokens.Plugin{"magic-tokens": mti}, mm)
+	a, err := NewAssetManager(ctx, mdi, mim, mdm, msa, mbm, mpm, mps, map[string]tokens.Plugin{"magic-tokens": mti}, mm)
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
 	rag.RunFn = func(a mock.Arguments) {
 		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
@@ -85,7 +88,7 @@ func newTestAssetsWithMetrics(t *testing.T) (*assetManager, func()) {
 }
 
 func TestInitFail(t *testing.T) {
-	_, err := NewAssetManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil)
+	_, err := NewAssetManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
 
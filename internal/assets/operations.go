@@ -0,0 +1,111 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/tokens"
+)
+
+// RetryOperation resubmits a failed token transfer or approval to the connector, under a new operation
+// in the same transaction. The operation inputs hold the full transfer or approval, including the pool.
+func (am *assetManager) RetryOperation(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error) {
+	switch op.Type {
+	case fftypes.OpTypeTokenTransfer:
+		var transfer fftypes.TokenTransfer
+		if err := retrieveRetryInputs(ctx, op, &transfer); err != nil {
+			return nil, err
+		}
+		pool, plugin, err := am.resolveRetryPool(ctx, op, transfer.Connector, transfer.Pool)
+		if err != nil {
+			return nil, err
+		}
+		return am.submitRetry(ctx, op, func(opID *fftypes.UUID) error {
+			switch transfer.Type {
+			case fftypes.TokenTransferTypeMint:
+				return plugin.MintTokens(ctx, opID, pool.ProtocolID, &transfer)
+			case fftypes.TokenTransferTypeTransfer:
+				return plugin.TransferTokens(ctx, opID, pool.ProtocolID, &transfer)
+			case fftypes.TokenTransferTypeBurn:
+				return plugin.BurnTokens(ctx, opID, pool.ProtocolID, &transfer)
+			default:
+				panic(fmt.Sprintf("unknown transfer type: %v", transfer.Type))
+			}
+		})
+	case fftypes.OpTypeTokenApproval:
+		var approval fftypes.TokenApproval
+		if err := retrieveRetryInputs(ctx, op, &approval); err != nil {
+			return nil, err
+		}
+		pool, plugin, err := am.resolveRetryPool(ctx, op, approval.Connector, approval.Pool)
+		if err != nil {
+			return nil, err
+		}
+		return am.submitRetry(ctx, op, func(opID *fftypes.UUID) error {
+			return plugin.TokensApproval(ctx, opID, pool.ProtocolID, &approval)
+		})
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgOperationNotRetryable, op.ID, op.Type)
+	}
+}
+
+func retrieveRetryInputs(ctx context.Context, op *fftypes.Operation, inputs interface{}) error {
+	s := op.Input.String()
+	if err := json.Unmarshal([]byte(s), inputs); err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgJSONObjectParseFailed, s)
+	}
+	return nil
+}
+
+func (am *assetManager) resolveRetryPool(ctx context.Context, op *fftypes.Operation, connector string, poolID *fftypes.UUID) (*fftypes.TokenPool, tokens.Plugin, error) {
+	if poolID == nil {
+		// Operations submitted before the pool was recorded in the inputs cannot be retried
+		return nil, nil, i18n.NewError(ctx, i18n.MsgOperationNotRetryable, op.ID, op.Type)
+	}
+	plugin, err := am.selectTokenPlugin(ctx, connector)
+	if err != nil {
+		return nil, nil, err
+	}
+	pool, err := am.database.GetTokenPoolByID(ctx, poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pool == nil {
+		return nil, nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return pool, plugin, nil
+}
+
+func (am *assetManager) submitRetry(ctx context.Context, op *fftypes.Operation, submit func(opID *fftypes.UUID) error) (*fftypes.Operation, error) {
+	if err := am.checkWritable(ctx); err != nil {
+		return nil, err
+	}
+	retry, err := am.txHelper.PrepareOperationRetry(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	if err = submit(retry.ID); err != nil {
+		am.txHelper.WriteOperationFailure(ctx, retry, err)
+		return nil, err
+	}
+	return retry, nil
+}
@@ -0,0 +1,307 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newRetryTransferOp(t *testing.T, transfer *fftypes.TokenTransfer) *fftypes.Operation {
+	op := &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Type:   fftypes.OpTypeTokenTransfer,
+		Status: fftypes.OpStatusFailed,
+	}
+	assert.NoError(t, txcommon.AddTokenTransferInputs(op, transfer))
+	return op
+}
+
+func newRetryApprovalOp(t *testing.T, approval *fftypes.TokenApproval) *fftypes.Operation {
+	op := &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Type:   fftypes.OpTypeTokenApproval,
+		Status: fftypes.OpStatusFailed,
+	}
+	assert.NoError(t, txcommon.AddTokenApprovalInputs(op, approval))
+	return op
+}
+
+func TestRetryTransfers(t *testing.T) {
+	pool := &fftypes.TokenPool{ID: fftypes.NewUUID(), ProtocolID: "F1"}
+	for _, transferType := range []fftypes.TokenTransferType{
+		fftypes.TokenTransferTypeMint,
+		fftypes.TokenTransferTypeTransfer,
+		fftypes.TokenTransferTypeBurn,
+	} {
+		am, cancel := newTestAssets(t)
+		mdi := am.database.(*databasemocks.Plugin)
+		mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+		mth := am.txHelper.(*txcommonmocks.Helper)
+
+		op := newRetryTransferOp(t, &fftypes.TokenTransfer{
+			LocalID:   fftypes.NewUUID(),
+			Type:      transferType,
+			Pool:      pool.ID,
+			Connector: "magic-tokens",
+		})
+		retry := &fftypes.Operation{ID: fftypes.NewUUID()}
+		mdi.On("GetTokenPoolByID", context.Background(), pool.ID).Return(pool, nil)
+		mth.On("PrepareOperationRetry", context.Background(), op).Return(retry, nil)
+		matchTransfer := mock.MatchedBy(func(transfer *fftypes.TokenTransfer) bool {
+			return transfer.Type == transferType && *transfer.Pool == *pool.ID
+		})
+		mti.On("MintTokens", context.Background(), retry.ID, "F1", matchTransfer).Return(nil).Maybe()
+		mti.On("TransferTokens", context.Background(), retry.ID, "F1", matchTransfer).Return(nil).Maybe()
+		mti.On("BurnTokens", context.Background(), retry.ID, "F1", matchTransfer).Return(nil).Maybe()
+
+		res, err := am.RetryOperation(context.Background(), op)
+		assert.NoError(t, err)
+		assert.Equal(t, retry, res)
+
+		mdi.AssertExpectations(t)
+		mti.AssertExpectations(t)
+		mth.AssertExpectations(t)
+		cancel()
+	}
+}
+
+func TestRetryTransferBadType(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+	mdi := am.database.(*databasemocks.Plugin)
+	mth := am.txHelper.(*txcommonmocks.Helper)
+
+	pool := &fftypes.TokenPool{ID: fftypes.NewUUID()}
+	op := newRetryTransferOp(t, &fftypes.TokenTransfer{
+		Type:      "wrong",
+		Pool:      pool.ID,
+		Connector: "magic-tokens",
+	})
+	mdi.On("GetTokenPoolByID", context.Background(), pool.ID).Return(pool, nil)
+	mth.On("PrepareOperationRetry", context.Background(), op).Return(&fftypes.Operation{ID: fftypes.NewUUID()}, nil)
+
+	assert.Panics(t, func() {
+		_, _ = am.RetryOperation(context.Background(), op)
+	})
+}
+
+func TestRetryTransferBadInputs(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op := &fftypes.Operation{
+		ID:    fftypes.NewUUID(),
+		Type:  fftypes.OpTypeTokenTransfer,
+		Input: fftypes.JSONObject{"localId": "!uuid"},
+	}
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10151", err)
+}
+
+func TestRetryTransferNoPool(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op := newRetryTransferOp(t, &fftypes.TokenTransfer{
+		Type:      fftypes.TokenTransferTypeMint,
+		Connector: "magic-tokens",
+	})
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10395", err)
+}
+
+func TestRetryTransferBadConnector(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op := newRetryTransferOp(t, &fftypes.TokenTransfer{
+		Type:      fftypes.TokenTransferTypeMint,
+		Pool:      fftypes.NewUUID(),
+		Connector: "bad",
+	})
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10272", err)
+}
+
+func TestRetryTransferPoolLookupFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+	mdi := am.database.(*databasemocks.Plugin)
+
+	poolID := fftypes.NewUUID()
+	op := newRetryTransferOp(t, &fftypes.TokenTransfer{
+		Type:      fftypes.TokenTransferTypeMint,
+		Pool:      poolID,
+		Connector: "magic-tokens",
+	})
+	mdi.On("GetTokenPoolByID", context.Background(), poolID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryTransferPoolNotFound(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+	mdi := am.database.(*databasemocks.Plugin)
+
+	poolID := fftypes.NewUUID()
+	op := newRetryTransferOp(t, &fftypes.TokenTransfer{
+		Type:      fftypes.TokenTransferTypeMint,
+		Pool:      poolID,
+		Connector: "magic-tokens",
+	})
+	mdi.On("GetTokenPoolByID", context.Background(), poolID).Return(nil, nil)
+
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryApproval(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mth := am.txHelper.(*txcommonmocks.Helper)
+
+	pool := &fftypes.TokenPool{ID: fftypes.NewUUID(), ProtocolID: "F1"}
+	op := newRetryApprovalOp(t, &fftypes.TokenApproval{
+		LocalID:   fftypes.NewUUID(),
+		Pool:      pool.ID,
+		Connector: "magic-tokens",
+		Operator:  "0x12345",
+		Approved:  true,
+	})
+	retry := &fftypes.Operation{ID: fftypes.NewUUID()}
+	mdi.On("GetTokenPoolByID", context.Background(), pool.ID).Return(pool, nil)
+	mth.On("PrepareOperationRetry", context.Background(), op).Return(retry, nil)
+	mti.On("TokensApproval", context.Background(), retry.ID, "F1", mock.MatchedBy(func(approval *fftypes.TokenApproval) bool {
+		return approval.Operator == "0x12345" && approval.Approved
+	})).Return(nil)
+
+	res, err := am.RetryOperation(context.Background(), op)
+	assert.NoError(t, err)
+	assert.Equal(t, retry, res)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+	mth.AssertExpectations(t)
+}
+
+func TestRetryApprovalBadInputs(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op := &fftypes.Operation{
+		ID:    fftypes.NewUUID(),
+		Type:  fftypes.OpTypeTokenApproval,
+		Input: fftypes.JSONObject{"localId": "!uuid"},
+	}
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10151", err)
+}
+
+func TestRetryApprovalNoPool(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op := newRetryApprovalOp(t, &fftypes.TokenApproval{
+		Connector: "magic-tokens",
+	})
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10395", err)
+}
+
+func TestRetryApprovalSubmitFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mth := am.txHelper.(*txcommonmocks.Helper)
+
+	pool := &fftypes.TokenPool{ID: fftypes.NewUUID(), ProtocolID: "F1"}
+	op := newRetryApprovalOp(t, &fftypes.TokenApproval{
+		Pool:      pool.ID,
+		Connector: "magic-tokens",
+	})
+	retry := &fftypes.Operation{ID: fftypes.NewUUID()}
+	mdi.On("GetTokenPoolByID", context.Background(), pool.ID).Return(pool, nil)
+	mth.On("PrepareOperationRetry", context.Background(), op).Return(retry, nil)
+	mti.On("TokensApproval", context.Background(), retry.ID, "F1", mock.Anything).Return(fmt.Errorf("pop"))
+	mth.On("WriteOperationFailure", context.Background(), retry, fmt.Errorf("pop")).Return()
+
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.EqualError(t, err, "pop")
+
+	mth.AssertExpectations(t)
+}
+
+func TestRetryPrepareFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+	mdi := am.database.(*databasemocks.Plugin)
+	mth := am.txHelper.(*txcommonmocks.Helper)
+
+	pool := &fftypes.TokenPool{ID: fftypes.NewUUID(), ProtocolID: "F1"}
+	op := newRetryApprovalOp(t, &fftypes.TokenApproval{
+		Pool:      pool.ID,
+		Connector: "magic-tokens",
+	})
+	mdi.On("GetTokenPoolByID", context.Background(), pool.ID).Return(pool, nil)
+	mth.On("PrepareOperationRetry", context.Background(), op).Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	_, err := am.RetryOperation(context.Background(), &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeTokenCreatePool,
+	})
+	assert.Regexp(t, "FF10395", err)
+}
+
+func TestRetryReadOnly(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+	am.readOnly = true
+	mdi := am.database.(*databasemocks.Plugin)
+
+	pool := &fftypes.TokenPool{ID: fftypes.NewUUID(), ProtocolID: "F1"}
+	op := newRetryApprovalOp(t, &fftypes.TokenApproval{
+		Pool:      pool.ID,
+		Connector: "magic-tokens",
+	})
+	mdi.On("GetTokenPoolByID", context.Background(), pool.ID).Return(pool, nil)
+
+	_, err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10358", err)
+}
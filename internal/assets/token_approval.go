@@ -19,7 +19,6 @@ package assets
 import (
 	"context"
 
-	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
 	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
@@ -30,6 +29,15 @@ func (am *assetManager) GetTokenApprovals(ctx context.Context, ns string, filter
 	return am.database.GetTokenApprovals(ctx, am.scopeNS(ns, filter))
 }
 
+func (am *assetManager) GetTokenApprovalByID(ctx context.Context, ns, id string) (*fftypes.TokenApproval, error) {
+	approvalID, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return am.database.GetTokenApproval(ctx, approvalID)
+}
+
 type approveSender struct {
 	mgr       *assetManager
 	namespace string
@@ -77,6 +85,9 @@ func (am *assetManager) TokenApproval(ctx context.Context, ns string, approval *
 }
 
 func (s *approveSender) sendInternal(ctx context.Context, method sendMethod) error {
+	if err := s.mgr.checkWritable(ctx); err != nil {
+		return err
+	}
 	if method == methodSendAndWait {
 		out, err := s.mgr.syncasync.WaitForTokenApproval(ctx, s.namespace, s.approval.LocalID, s.Send)
 		if out != nil {
@@ -101,11 +112,13 @@ func (s *approveSender) sendInternal(ctx context.Context, method sendMethod) err
 		if err != nil {
 			return err
 		}
-		if pool.State != fftypes.TokenPoolStateConfirmed {
-			return i18n.NewError(ctx, i18n.MsgTokenPoolNotConfirmed)
+		if err = validatePoolState(ctx, pool, true); err != nil {
+			return err
 		}
+		// Record the resolved pool in the operation inputs, so the operation can be retried
+		s.approval.TokenApproval.Pool = pool.ID
 
-		txid, err := s.mgr.txHelper.SubmitNewTransaction(ctx, s.namespace, fftypes.TransactionTypeTokenApproval)
+		txid, err := s.mgr.txHelper.SubmitNewTransaction(ctx, s.namespace, fftypes.TransactionTypeTokenApproval, "")
 		if err != nil {
 			return err
 		}
@@ -130,7 +143,7 @@ func (s *approveSender) sendInternal(ctx context.Context, method sendMethod) err
 	err = plugin.TokensApproval(ctx, op.ID, pool.ProtocolID, &s.approval.TokenApproval)
 	// if transaction fails,  mark op as failed in DB
 	if err != nil {
-		s.mgr.txHelper.WriteOperationFailure(ctx, op.ID, err)
+		s.mgr.txHelper.WriteOperationFailure(ctx, op, err)
 	}
 
 	return err
@@ -54,6 +54,9 @@ func (am *assetManager) CreateTokenPool(ctx context.Context, ns string, pool *ff
 }
 
 func (am *assetManager) createTokenPoolInternal(ctx context.Context, pool *fftypes.TokenPool, waitConfirm bool) (*fftypes.TokenPool, error) {
+	if err := am.checkWritable(ctx); err != nil {
+		return nil, err
+	}
 	plugin, err := am.selectTokenPlugin(ctx, pool.Connector)
 	if err != nil {
 		return nil, err
@@ -68,7 +71,7 @@ func (am *assetManager) createTokenPoolInternal(ctx context.Context, pool *fftyp
 
 	var op *fftypes.Operation
 	err = am.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
-		txid, err := am.txHelper.SubmitNewTransaction(ctx, pool.Namespace, fftypes.TransactionTypeTokenPool)
+		txid, err := am.txHelper.SubmitNewTransaction(ctx, pool.Namespace, fftypes.TransactionTypeTokenPool, "")
 		if err != nil {
 			return err
 		}
@@ -90,7 +93,7 @@ func (am *assetManager) createTokenPoolInternal(ctx context.Context, pool *fftyp
 	}
 
 	if complete, err := plugin.CreateTokenPool(ctx, op.ID, pool); err != nil {
-		am.txHelper.WriteOperationFailure(ctx, op.ID, err)
+		am.txHelper.WriteOperationFailure(ctx, op, err)
 		return nil, err
 	} else if complete {
 		am.txHelper.WriteOperationSuccess(ctx, op.ID, nil)
@@ -114,7 +117,7 @@ func (am *assetManager) ActivateTokenPool(ctx context.Context, pool *fftypes.Tok
 	}
 
 	if complete, err := plugin.ActivateTokenPool(ctx, op.ID, pool, event); err != nil {
-		am.txHelper.WriteOperationFailure(ctx, op.ID, err)
+		am.txHelper.WriteOperationFailure(ctx, op, err)
 		return err
 	} else if complete {
 		am.txHelper.WriteOperationSuccess(ctx, op.ID, nil)
@@ -172,3 +175,45 @@ func (am *assetManager) GetTokenPoolByNameOrID(ctx context.Context, ns, poolName
 	}
 	return pool, nil
 }
+
+// UpdateTokenPoolState moves a confirmed pool between the active, paused and archived states. The state
+// is local to this node, and controls which new transfers and approvals this node will submit against the pool.
+func (am *assetManager) UpdateTokenPoolState(ctx context.Context, ns, poolNameOrID string, state fftypes.TokenPoolState) (*fftypes.TokenPool, error) {
+	var pool *fftypes.TokenPool
+	err := am.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		if pool, err = am.GetTokenPoolByNameOrID(ctx, ns, poolNameOrID); err != nil {
+			return err
+		}
+		if err = pool.ValidateStateTransition(ctx, state); err != nil {
+			return err
+		}
+		pool.State = state
+		if err = am.database.UpsertTokenPool(ctx, pool); err != nil {
+			return err
+		}
+		event := fftypes.NewEvent(fftypes.EventTypePoolStateChanged, pool.Namespace, pool.ID, pool.TX.ID)
+		return am.database.InsertEvent(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// validatePoolState checks new work can be submitted against the pool. Pausing a pool stops new
+// transfers (including mints and burns), while archiving a pool also stops new approvals.
+func validatePoolState(ctx context.Context, pool *fftypes.TokenPool, allowPaused bool) error {
+	switch pool.State {
+	case fftypes.TokenPoolStateConfirmed:
+		return nil
+	case fftypes.TokenPoolStatePaused:
+		if allowPaused {
+			return nil
+		}
+		return i18n.NewError(ctx, i18n.MsgTokenPoolPaused)
+	case fftypes.TokenPoolStateArchived:
+		return i18n.NewError(ctx, i18n.MsgTokenPoolArchived)
+	default:
+		return i18n.NewError(ctx, i18n.MsgTokenPoolNotConfirmed)
+	}
+}
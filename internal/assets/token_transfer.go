@@ -17,10 +17,14 @@
 package assets
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"math/big"
 
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
 	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
@@ -114,6 +118,29 @@ func (am *assetManager) validateTransfer(ctx context.Context, ns string, transfe
 	if transfer.To == "" {
 		transfer.To = transfer.Key
 	}
+	if transfer.Metadata != nil {
+		if transfer.Type != fftypes.TokenTransferTypeMint {
+			return i18n.NewError(ctx, i18n.MsgTokenMetadataMintOnly)
+		}
+		if transfer.URI != "" {
+			return i18n.NewError(ctx, i18n.MsgTokenMetadataWithURI)
+		}
+	}
+	return nil
+}
+
+// validateNonFungibleTransfer checks transfers and burns in a non-fungible pool (such as ERC-721) refer to a single token.
+// Mints are not checked, as the connector may assign the token index, and some standards can mint multiple tokens at once.
+func validateNonFungibleTransfer(ctx context.Context, pool *fftypes.TokenPool, transfer *fftypes.TokenTransfer) error {
+	if pool.Type != fftypes.TokenTypeNonFungible || transfer.Type == fftypes.TokenTransferTypeMint {
+		return nil
+	}
+	if transfer.TokenIndex == "" {
+		return i18n.NewError(ctx, i18n.MsgTokenIndexRequired)
+	}
+	if transfer.Amount.Int().Cmp(big.NewInt(1)) != 0 {
+		return i18n.NewError(ctx, i18n.MsgNonFungibleAmount)
+	}
 	return nil
 }
 
@@ -211,6 +238,9 @@ func (s *transferSender) resolve(ctx context.Context) error {
 }
 
 func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) error {
+	if err := s.mgr.checkWritable(ctx); err != nil {
+		return err
+	}
 	if method == methodSendAndWait {
 		out, err := s.mgr.syncasync.WaitForTokenTransfer(ctx, s.namespace, s.transfer.LocalID, s.Send)
 		if out != nil {
@@ -228,6 +258,12 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 		return nil
 	}
 
+	if s.transfer.Metadata != nil && s.transfer.TokenTransfer.Metadata == nil {
+		if err = s.publishMetadata(ctx); err != nil {
+			return err
+		}
+	}
+
 	var pool *fftypes.TokenPool
 	var op *fftypes.Operation
 	err = s.mgr.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
@@ -235,11 +271,16 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 		if err != nil {
 			return err
 		}
-		if pool.State != fftypes.TokenPoolStateConfirmed {
-			return i18n.NewError(ctx, i18n.MsgTokenPoolNotConfirmed)
+		if err = validatePoolState(ctx, pool, false); err != nil {
+			return err
+		}
+		if err = validateNonFungibleTransfer(ctx, pool, &s.transfer.TokenTransfer); err != nil {
+			return err
 		}
+		// Record the resolved pool in the operation inputs, so the operation can be retried
+		s.transfer.TokenTransfer.Pool = pool.ID
 
-		txid, err := s.mgr.txHelper.SubmitNewTransaction(ctx, s.namespace, fftypes.TransactionTypeTokenTransfer)
+		txid, err := s.mgr.txHelper.SubmitNewTransaction(ctx, s.namespace, fftypes.TransactionTypeTokenTransfer, s.transfer.IdempotencyKey)
 		if err != nil {
 			return err
 		}
@@ -281,11 +322,54 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 	}
 
 	if err != nil {
-		s.mgr.txHelper.WriteOperationFailure(ctx, op.ID, err)
+		s.mgr.txHelper.WriteOperationFailure(ctx, op, err)
 	}
 	return err
 }
 
+// publishMetadata stores the metadata supplied for a mint, and uploads it (or its blob) to public storage.
+// The resulting URI is set on the mint so the connector records it on-chain, and the stored data is linked to the mint.
+func (s *transferSender) publishMetadata(ctx context.Context) (err error) {
+	var data *fftypes.Data
+	if s.transfer.Metadata.ID != nil {
+		if data, err = s.mgr.database.GetDataByID(ctx, s.transfer.Metadata.ID, true); err != nil {
+			return err
+		}
+		if data == nil || data.Namespace != s.namespace {
+			return i18n.NewError(ctx, i18n.MsgTokenMetadataNotFound, s.transfer.Metadata.ID)
+		}
+	} else if data, err = s.mgr.data.UploadJSON(ctx, s.namespace, s.transfer.Metadata); err != nil {
+		return err
+	}
+
+	var reader io.ReadCloser
+	if data.Blob != nil && data.Blob.Hash != nil {
+		if _, reader, err = s.mgr.data.DownloadBLOB(ctx, s.namespace, data.ID.String()); err != nil {
+			return err
+		}
+	} else {
+		reader = io.NopCloser(bytes.NewReader(data.Value.Bytes()))
+	}
+	defer reader.Close()
+
+	publicRef, err := s.mgr.publicstorage.PublishData(ctx, reader)
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Published metadata '%s' for token mint '%s' to public storage: '%s'", data.ID, s.transfer.LocalID, publicRef)
+
+	if data.Blob != nil && data.Blob.Hash != nil {
+		update := database.DataQueryFactory.NewUpdate(ctx).Set("blob.public", publicRef)
+		if err = s.mgr.database.UpdateData(ctx, data.ID, update); err != nil {
+			return err
+		}
+	}
+
+	s.transfer.URI = fmt.Sprintf("%s://%s", s.mgr.publicstorage.Name(), publicRef)
+	s.transfer.TokenTransfer.Metadata = data.ID
+	return nil
+}
+
 func (s *transferSender) buildTransferMessage(ctx context.Context, ns string, in *fftypes.MessageInOut) (sysmessaging.MessageSender, error) {
 	allowedTypes := []fftypes.FFEnum{
 		fftypes.MessageTypeTransferBroadcast,
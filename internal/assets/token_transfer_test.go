@@ -17,14 +17,18 @@ package assets
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/mocks/broadcastmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
@@ -89,13 +93,42 @@ func TestMintTokensSuccess(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
 	assert.NoError(t, err)
 }
 
+func TestMintTokensIdempotencyKeyDuplicate(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewFFBigInt(5),
+		},
+		Pool:           "pool1",
+		IdempotencyKey: "retry1",
+	}
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mth := am.txHelper.(*txcommonmocks.Helper)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("retry1")).Return(nil, i18n.NewError(context.Background(), i18n.MsgDuplicateIdempotencyKeyTX, "retry1", fftypes.NewUUID()))
+
+	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.Regexp(t, "FF10452", err)
+
+	mth.AssertExpectations(t)
+}
+
 func TestMintTokenUnknownConnectorSuccess(t *testing.T) {
 	am, cancel := newTestAssets(t)
 	defer cancel()
@@ -118,7 +151,7 @@ func TestMintTokenUnknownConnectorSuccess(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
@@ -238,7 +271,7 @@ func TestMintTokenUnknownPoolSuccess(t *testing.T) {
 	}))).Return(tokenPools, filterResult, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(tokenPools[0], nil)
 	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
@@ -409,7 +442,7 @@ func TestMintTokensFail(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(fmt.Errorf("pop"))
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 	mth.On("WriteOperationFailure", context.Background(), mock.Anything, fmt.Errorf("pop"))
 
@@ -440,7 +473,7 @@ func TestMintTokensFailAndDbFail(t *testing.T) {
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(fmt.Errorf("pop"))
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mth.On("WriteOperationFailure", context.Background(), mock.Anything, fmt.Errorf("pop"))
 
 	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
@@ -467,7 +500,7 @@ func TestMintTokensOperationFail(t *testing.T) {
 	mth := am.txHelper.(*txcommonmocks.Helper)
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(fmt.Errorf("pop"))
 
 	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
@@ -498,7 +531,7 @@ func TestMintTokensConfirm(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForTokenTransfer", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
@@ -516,6 +549,185 @@ func TestMintTokensConfirm(t *testing.T) {
 	mti.AssertExpectations(t)
 }
 
+func TestMintTokensWithMetadata(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	value := fftypes.JSONAnyPtr(`{"name":"token1"}`)
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewFFBigInt(1),
+		},
+		Pool:     "pool1",
+		Metadata: &fftypes.DataRefOrValue{Value: value},
+	}
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+	data := &fftypes.Data{
+		ID:    fftypes.NewUUID(),
+		Value: value,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdm := am.data.(*datamocks.Manager)
+	mps := am.publicstorage.(*publicstoragemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mth := am.txHelper.(*txcommonmocks.Helper)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdm.On("UploadJSON", context.Background(), "ns1", mint.Metadata).Return(data, nil)
+	mps.On("PublishData", context.Background(), mock.Anything).Return("Qm12345", nil)
+	mps.On("Name").Return("ipfs")
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+
+	out, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "ipfs://Qm12345", out.URI)
+	assert.Equal(t, data.ID, out.Metadata)
+
+	mdm.AssertExpectations(t)
+	mps.AssertExpectations(t)
+}
+
+func TestMintTokensWithMetadataBlob(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Blob:      &fftypes.BlobRef{Hash: fftypes.NewRandB32()},
+	}
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewFFBigInt(1),
+		},
+		Pool: "pool1",
+		Metadata: &fftypes.DataRefOrValue{
+			DataRef: fftypes.DataRef{ID: data.ID},
+		},
+	}
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdm := am.data.(*datamocks.Manager)
+	mps := am.publicstorage.(*publicstoragemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mth := am.txHelper.(*txcommonmocks.Helper)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetDataByID", context.Background(), data.ID, true).Return(data, nil)
+	mdm.On("DownloadBLOB", context.Background(), "ns1", data.ID.String()).Return(&fftypes.Blob{}, io.NopCloser(strings.NewReader("image")), nil)
+	mps.On("PublishData", context.Background(), mock.Anything).Return("Qm12345", nil)
+	mps.On("Name").Return("ipfs")
+	mdi.On("UpdateData", context.Background(), data.ID, mock.Anything).Return(nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+
+	out, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "ipfs://Qm12345", out.URI)
+	assert.Equal(t, data.ID, out.Metadata)
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestMintTokensWithMetadataNotFound(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewFFBigInt(1),
+		},
+		Pool: "pool1",
+		Metadata: &fftypes.DataRefOrValue{
+			DataRef: fftypes.DataRef{ID: fftypes.NewUUID()},
+		},
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetDataByID", context.Background(), mint.Metadata.ID, true).Return(nil, nil)
+
+	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.Regexp(t, "FF10438", err)
+}
+
+func TestMintTokensWithMetadataPublishFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewFFBigInt(1),
+		},
+		Pool:     "pool1",
+		Metadata: &fftypes.DataRefOrValue{Value: fftypes.JSONAnyPtr(`{}`)},
+	}
+
+	mdm := am.data.(*datamocks.Manager)
+	mps := am.publicstorage.(*publicstoragemocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdm.On("UploadJSON", context.Background(), "ns1", mint.Metadata).Return(&fftypes.Data{ID: fftypes.NewUUID()}, nil)
+	mps.On("PublishData", context.Background(), mock.Anything).Return("", fmt.Errorf("pop"))
+
+	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestMintTokensWithMetadataAndURI(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewFFBigInt(1),
+			URI:    "ipfs://Qm12345",
+		},
+		Pool:     "pool1",
+		Metadata: &fftypes.DataRefOrValue{Value: fftypes.JSONAnyPtr(`{}`)},
+	}
+
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+
+	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.Regexp(t, "FF10437", err)
+}
+
+func TestBurnTokensWithMetadata(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	burn := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewFFBigInt(1),
+		},
+		Pool:     "pool1",
+		Metadata: &fftypes.DataRefOrValue{Value: fftypes.JSONAnyPtr(`{}`)},
+	}
+
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+
+	_, err := am.BurnTokens(context.Background(), "ns1", burn, false)
+	assert.Regexp(t, "FF10436", err)
+}
+
 func TestBurnTokensSuccess(t *testing.T) {
 	am, cancel := newTestAssetsWithMetrics(t)
 	defer cancel()
@@ -538,7 +750,7 @@ func TestBurnTokensSuccess(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("BurnTokens", context.Background(), mock.Anything, "F1", &burn.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.BurnTokens(context.Background(), "ns1", burn, false)
@@ -591,7 +803,7 @@ func TestBurnTokensConfirm(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("BurnTokens", context.Background(), mock.Anything, "F1", &burn.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForTokenTransfer", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
@@ -633,7 +845,7 @@ func TestTransferTokensSuccess(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", &transfer.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.TransferTokens(context.Background(), "ns1", transfer, false)
@@ -644,6 +856,35 @@ func TestTransferTokensSuccess(t *testing.T) {
 	mti.AssertExpectations(t)
 }
 
+func TestTransferTokensPausedPool(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	transfer := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			From:   "A",
+			To:     "B",
+			Amount: *fftypes.NewFFBigInt(5),
+		},
+		Pool: "pool1",
+	}
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStatePaused,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+
+	_, err := am.TransferTokens(context.Background(), "ns1", transfer, false)
+	assert.Regexp(t, "FF10432", err)
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
 func TestTransferTokensUnconfirmedPool(t *testing.T) {
 	am, cancel := newTestAssets(t)
 	defer cancel()
@@ -673,6 +914,102 @@ func TestTransferTokensUnconfirmedPool(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestTransferTokensNonFungible(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	transfer := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			From:       "A",
+			To:         "B",
+			TokenIndex: "42",
+			Amount:     *fftypes.NewFFBigInt(1),
+		},
+		Pool: "pool1",
+	}
+	pool := &fftypes.TokenPool{
+		Type:       fftypes.TokenTypeNonFungible,
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mth := am.txHelper.(*txcommonmocks.Helper)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", &transfer.TokenTransfer).Return(nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+
+	_, err := am.TransferTokens(context.Background(), "ns1", transfer, false)
+	assert.NoError(t, err)
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTransferTokensNonFungibleNoIndex(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	transfer := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			From:   "A",
+			To:     "B",
+			Amount: *fftypes.NewFFBigInt(1),
+		},
+		Pool: "pool1",
+	}
+	pool := &fftypes.TokenPool{
+		Type:       fftypes.TokenTypeNonFungible,
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+
+	_, err := am.TransferTokens(context.Background(), "ns1", transfer, false)
+	assert.Regexp(t, "FF10426", err)
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestBurnTokensNonFungibleBadAmount(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	burn := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			TokenIndex: "42",
+			Amount:     *fftypes.NewFFBigInt(2),
+		},
+		Pool: "pool1",
+	}
+	pool := &fftypes.TokenPool{
+		Type:       fftypes.TokenTypeNonFungible,
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+
+	_, err := am.BurnTokens(context.Background(), "ns1", burn, false)
+	assert.Regexp(t, "FF10427", err)
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
 func TestTransferTokensIdentityFail(t *testing.T) {
 	am, cancel := newTestAssets(t)
 	defer cancel()
@@ -731,7 +1068,7 @@ func TestTransferTokensInvalidType(t *testing.T) {
 	mdi := am.database.(*databasemocks.Plugin)
 	mth := am.txHelper.(*txcommonmocks.Helper)
 	mdi.On("GetTokenPool", am.ctx, "ns1", "pool1").Return(pool, nil)
-	mth.On("SubmitNewTransaction", am.ctx, "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", am.ctx, "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", am.ctx, mock.Anything).Return(nil)
 
 	sender := &transferSender{
@@ -766,7 +1103,7 @@ func TestTransferTokensTransactionFail(t *testing.T) {
 	mth := am.txHelper.(*txcommonmocks.Helper)
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(nil, fmt.Errorf("pop"))
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(nil, fmt.Errorf("pop"))
 
 	_, err := am.TransferTokens(context.Background(), "ns1", transfer, false)
 	assert.EqualError(t, err, "pop")
@@ -816,7 +1153,7 @@ func TestTransferTokensWithBroadcastMessage(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", &transfer.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 	mbm.On("NewBroadcast", "ns1", transfer.Message).Return(mms)
 	mms.On("Prepare", context.Background()).Return(nil)
@@ -913,7 +1250,7 @@ func TestTransferTokensWithPrivateMessage(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", &transfer.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 	mpm.On("NewMessage", "ns1", transfer.Message).Return(mms)
 	mms.On("Prepare", context.Background()).Return(nil)
@@ -993,7 +1330,7 @@ func TestTransferTokensConfirm(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", &transfer.TokenTransfer).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForTokenTransfer", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
@@ -1054,7 +1391,7 @@ func TestTransferTokensWithBroadcastConfirm(t *testing.T) {
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", &transfer.TokenTransfer).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
-	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", context.Background(), "ns1", fftypes.TransactionTypeTokenTransfer, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mbm.On("NewBroadcast", "ns1", transfer.Message).Return(mms)
 	mms.On("Prepare", context.Background()).Return(nil)
 	mdi.On("UpsertMessage", context.Background(), mock.MatchedBy(func(msg *fftypes.Message) bool {
@@ -1130,3 +1467,21 @@ func TestTransferPrepare(t *testing.T) {
 	err := sender.Prepare(context.Background())
 	assert.NoError(t, err)
 }
+
+func TestTransferTokensReadOnly(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+	am.readOnly = true
+
+	transfer := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			From:   "A",
+			To:     "B",
+			Amount: *fftypes.NewFFBigInt(5),
+		},
+		Pool: "pool1",
+	}
+
+	err := am.NewTransfer("ns1", transfer).Send(context.Background())
+	assert.Regexp(t, "FF10358", err)
+}
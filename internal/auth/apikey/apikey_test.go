@@ -0,0 +1,89 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("apikey_unit_tests")
+
+func newTestAPIKey(t *testing.T) *APIKey {
+	config.Reset()
+	a := &APIKey{}
+	a.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(APIKeyConfKeys, fftypes.JSONObjectArray{
+		{"key": "key1", "identity": "app1"},
+		{"key": "key2", "identity": "app2"},
+	})
+	err := a.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey", a.Name())
+	return a
+}
+
+func TestInitBadKeys(t *testing.T) {
+	config.Reset()
+	a := &APIKey{}
+	a.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(APIKeyConfKeys, fftypes.JSONObjectArray{
+		{"key": "key1"},
+	})
+	err := a.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10490.*0", err)
+}
+
+func TestAuthenticateHeader(t *testing.T) {
+	a := newTestAPIKey(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.Header.Set("X-API-Key", "key2")
+	identity, err := a.Authenticate(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "app2", identity)
+}
+
+func TestAuthenticateBearer(t *testing.T) {
+	a := newTestAPIKey(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.Header.Set("Authorization", "Bearer key1")
+	identity, err := a.Authenticate(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "app1", identity)
+}
+
+func TestAuthenticateMissing(t *testing.T) {
+	a := newTestAPIKey(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	_, err := a.Authenticate(req)
+	assert.Regexp(t, "FF10485", err)
+}
+
+func TestAuthenticateInvalid(t *testing.T) {
+	a := newTestAPIKey(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.Header.Set("X-API-Key", "key3")
+	_, err := a.Authenticate(req)
+	assert.Regexp(t, "FF10486", err)
+}
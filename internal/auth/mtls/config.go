@@ -0,0 +1,31 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+)
+
+const (
+	// MTLSConfIdentities maps client certificates to identities, as an array of objects each containing the subject common name "cn" of
+	// a certificate and the "identity" it authenticates. When not set, the common name of the certificate is used as the identity
+	MTLSConfIdentities = "identities"
+)
+
+func (m *MTLS) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(MTLSConfIdentities)
+}
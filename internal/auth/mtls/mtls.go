@@ -0,0 +1,66 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// MTLS authenticates API requests using the client certificate of a mutual TLS connection.
+// The HTTP server must be configured with tls.clientAuth, so the certificate is verified during the handshake.
+type MTLS struct {
+	identities map[string]string
+}
+
+func (m *MTLS) Name() string {
+	return "mtls"
+}
+
+func (m *MTLS) Init(ctx context.Context, prefix config.Prefix) error {
+	m.identities = nil
+	for i, entry := range prefix.GetObjectArray(MTLSConfIdentities) {
+		cn := entry.GetString("cn")
+		identity := entry.GetString("identity")
+		if cn == "" || identity == "" {
+			return i18n.NewError(ctx, i18n.MsgInvalidClientCertConfig, i)
+		}
+		if m.identities == nil {
+			m.identities = make(map[string]string)
+		}
+		m.identities[cn] = identity
+	}
+	return nil
+}
+
+func (m *MTLS) Authenticate(req *http.Request) (string, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return "", i18n.NewError(req.Context(), i18n.MsgMissingCredentials)
+	}
+	cn := req.TLS.VerifiedChains[0][0].Subject.CommonName
+	if m.identities == nil && cn != "" {
+		return cn, nil
+	}
+	identity, ok := m.identities[cn]
+	if !ok {
+		return "", i18n.NewError(req.Context(), i18n.MsgUnmappedClientCert, cn)
+	}
+	return identity, nil
+}
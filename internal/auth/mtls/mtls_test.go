@@ -0,0 +1,95 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("mtls_unit_tests")
+
+func newTestMTLS(t *testing.T, identities fftypes.JSONObjectArray) *MTLS {
+	config.Reset()
+	m := &MTLS{}
+	m.InitPrefix(utConfPrefix)
+	if identities != nil {
+		utConfPrefix.Set(MTLSConfIdentities, identities)
+	}
+	err := m.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, "mtls", m.Name())
+	return m
+}
+
+func newTestRequest(cn string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{
+			{{Subject: pkix.Name{CommonName: cn}}},
+		},
+	}
+	return req
+}
+
+func TestInitBadIdentities(t *testing.T) {
+	config.Reset()
+	m := &MTLS{}
+	m.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(MTLSConfIdentities, fftypes.JSONObjectArray{
+		{"identity": "app1"},
+	})
+	err := m.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10491.*0", err)
+}
+
+func TestAuthenticateCommonName(t *testing.T) {
+	m := newTestMTLS(t, nil)
+	identity, err := m.Authenticate(newTestRequest("client1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "client1", identity)
+
+	_, err = m.Authenticate(newTestRequest(""))
+	assert.Regexp(t, "FF10489", err)
+}
+
+func TestAuthenticateMapped(t *testing.T) {
+	m := newTestMTLS(t, fftypes.JSONObjectArray{
+		{"cn": "client1", "identity": "app1"},
+	})
+	identity, err := m.Authenticate(newTestRequest("client1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "app1", identity)
+
+	_, err = m.Authenticate(newTestRequest("client2"))
+	assert.Regexp(t, "FF10489.*client2", err)
+}
+
+func TestAuthenticateNoCert(t *testing.T) {
+	m := newTestMTLS(t, nil)
+	_, err := m.Authenticate(httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	assert.Regexp(t, "FF10485", err)
+}
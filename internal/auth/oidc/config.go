@@ -0,0 +1,41 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+)
+
+const (
+	// OIDCConfAudience the audience tokens must be issued for. When not set, the audience of tokens is not checked
+	OIDCConfAudience = "audience"
+	// OIDCConfIdentityClaim the claim in the token that holds the identity of the caller
+	OIDCConfIdentityClaim = "identityClaim"
+	// OIDCConfClockSkew the tolerance allowed for differences between the clocks of the OIDC provider and this node, when checking token expiry
+	OIDCConfClockSkew = "clockSkew"
+	// OIDCConfKeysRefreshInterval the minimum time between fetching the signing keys of the OIDC provider, when a token is signed with a key that is not known
+	OIDCConfKeysRefreshInterval = "keysRefreshInterval"
+)
+
+func (o *OIDC) InitPrefix(prefix config.Prefix) {
+	restclient.InitPrefix(prefix)
+	prefix.AddKnownKey(OIDCConfAudience)
+	prefix.AddKnownKey(OIDCConfIdentityClaim, "sub")
+	prefix.AddKnownKey(OIDCConfClockSkew, "30s")
+	prefix.AddKnownKey(OIDCConfKeysRefreshInterval, "1m")
+}
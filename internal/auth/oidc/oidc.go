@@ -0,0 +1,215 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/restclient"
+)
+
+// OIDC authenticates API requests carrying a bearer token issued by an OpenID Connect provider,
+// by verifying the signature of the token against the published keys of the provider, and checking its claims.
+// The url of the plugin config is the issuer URL of the provider.
+type OIDC struct {
+	client          *resty.Client
+	issuer          string
+	audience        string
+	identityClaim   string
+	clockSkew       time.Duration
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	keysMux   sync.Mutex
+	keys      map[string]crypto.PublicKey
+	lastFetch time.Time
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type discoveryDocument struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+func (o *OIDC) Name() string {
+	return "oidc"
+}
+
+func (o *OIDC) Init(ctx context.Context, prefix config.Prefix) error {
+	o.issuer = strings.TrimSuffix(prefix.GetString(restclient.HTTPConfigURL), "/")
+	if o.issuer == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "auth.oidc")
+	}
+	o.client = restclient.New(log.WithLogField(ctx, "auth", "oidc"), prefix)
+	o.audience = prefix.GetString(OIDCConfAudience)
+	o.identityClaim = prefix.GetString(OIDCConfIdentityClaim)
+	o.clockSkew = prefix.GetDuration(OIDCConfClockSkew)
+	o.refreshInterval = prefix.GetDuration(OIDCConfKeysRefreshInterval)
+	o.now = time.Now
+	o.keys = make(map[string]crypto.PublicKey)
+	return nil
+}
+
+func (o *OIDC) Authenticate(req *http.Request) (string, error) {
+	ctx := req.Context()
+	authHeader := req.Header.Get("Authorization")
+	if len(authHeader) <= 7 || !strings.EqualFold(authHeader[0:7], "bearer ") {
+		return "", i18n.NewError(ctx, i18n.MsgMissingCredentials)
+	}
+	parts := strings.Split(authHeader[7:], ".")
+	if len(parts) != 3 {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidAuthToken, "malformed")
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidAuthToken, "malformed header")
+	}
+	key, err := o.getKey(ctx, header.KeyID)
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidAuthToken, "unknown signing key")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidAuthToken, "malformed signature")
+	}
+	if err := verifySignature(header.Algorithm, key, parts[0]+"."+parts[1], signature); err != nil {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidAuthToken, err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidAuthToken, "malformed claims")
+	}
+	if err := o.checkClaims(claims); err != nil {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidAuthToken, err)
+	}
+	identity, _ := claims[o.identityClaim].(string)
+	if identity == "" {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidAuthToken, "missing claim "+o.identityClaim)
+	}
+	return identity, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	return err
+}
+
+// checkClaims checks the token was issued by the configured provider, for the configured audience, and is currently valid
+func (o *OIDC) checkClaims(claims map[string]interface{}) error {
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != o.issuer {
+		return errors.New("issuer mismatch")
+	}
+	if o.audience != "" && !hasAudience(claims["aud"], o.audience) {
+		return errors.New("audience mismatch")
+	}
+	now := o.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(o.clockSkew)) {
+		return errors.New("expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(o.clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("not yet valid")
+	}
+	return nil
+}
+
+// hasAudience checks the aud claim, which can be either a single string or an array of strings
+func hasAudience(aud interface{}, audience string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == audience
+	case []interface{}:
+		for _, entry := range a {
+			if entry == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getKey returns the signing key with the given ID. The keys of the provider are fetched again when a key
+// is not known, as providers rotate their keys, but no more often than the refresh interval so that tokens
+// signed with made up key IDs cannot be used to drive load onto the provider.
+func (o *OIDC) getKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	o.keysMux.Lock()
+	defer o.keysMux.Unlock()
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	if !o.lastFetch.IsZero() && o.now().Sub(o.lastFetch) < o.refreshInterval {
+		return nil, nil
+	}
+	keys, err := o.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o.keys = keys
+	o.lastFetch = o.now()
+	return o.keys[kid], nil
+}
+
+func (o *OIDC) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var discovery discoveryDocument
+	res, err := o.client.R().SetContext(ctx).
+		SetResult(&discovery).
+		Get("/.well-known/openid-configuration")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgOIDCProviderRESTErr)
+	}
+	var jwks jsonWebKeySet
+	res, err = o.client.R().SetContext(ctx).
+		SetResult(&jwks).
+		Get(discovery.JWKSURI)
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgOIDCProviderRESTErr)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.L(ctx).Warnf("Ignoring key '%s' from OIDC provider: %s", jwk.KeyID, err)
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	log.L(ctx).Infof("Fetched %d signing keys from OIDC provider %s", len(keys), o.issuer)
+	return keys, nil
+}
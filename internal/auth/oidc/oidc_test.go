@@ -0,0 +1,298 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("oidc_unit_tests")
+
+var testRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+var testECKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newTestOIDC(t *testing.T) (*OIDC, func()) {
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+
+	config.Reset()
+	o := &OIDC{}
+	o.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(restclient.HTTPConfigURL, "https://idp.example.com")
+	utConfPrefix.Set(restclient.HTTPCustomClient, mockedClient)
+	utConfPrefix.Set(OIDCConfAudience, "firefly")
+
+	err := o.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, "oidc", o.Name())
+	o.now = func() time.Time { return time.Unix(1000000, 0) }
+	return o, httpmock.DeactivateAndReset
+}
+
+func mockKeys() {
+	httpmock.RegisterResponder("GET", "https://idp.example.com/.well-known/openid-configuration",
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
+			"jwks_uri": "https://idp.example.com/keys",
+		}))
+	httpmock.RegisterResponder("GET", "https://idp.example.com/keys",
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
+			"keys": []fftypes.JSONObject{
+				{"kty": "RSA", "kid": "rsa1", "n": b64(testRSAKey.N.Bytes()), "e": b64(big.NewInt(int64(testRSAKey.E)).Bytes())},
+				{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(testECKey.X.Bytes()), "y": b64(testECKey.Y.Bytes())},
+				{"kty": "oct", "kid": "sym1"},
+			},
+		}))
+}
+
+func signToken(alg, kid string, claims fftypes.JSONObject) string {
+	header, _ := json.Marshal(fftypes.JSONObject{"alg": alg, "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64(header) + "." + b64(payload)
+	h := crypto.SHA256.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+	var sig []byte
+	if strings.HasPrefix(alg, "RS") {
+		sig, _ = rsa.SignPKCS1v15(rand.Reader, testRSAKey, crypto.SHA256, digest)
+	} else {
+		r, s, _ := ecdsa.Sign(rand.Reader, testECKey, digest)
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signingInput + "." + b64(sig)
+}
+
+func validClaims() fftypes.JSONObject {
+	return fftypes.JSONObject{
+		"iss": "https://idp.example.com/",
+		"aud": []string{"other", "firefly"},
+		"sub": "user1",
+		"exp": 1000060,
+		"nbf": 999990,
+	}
+}
+
+func authenticate(o *OIDC, token string) (string, error) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return o.Authenticate(req)
+}
+
+func TestInitMissingURL(t *testing.T) {
+	config.Reset()
+	o := &OIDC{}
+	o.InitPrefix(utConfPrefix)
+	err := o.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestAuthenticateRSAOk(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	mockKeys()
+
+	identity, err := authenticate(o, signToken("RS256", "rsa1", validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", identity)
+
+	// Keys are cached
+	identity, err = authenticate(o, signToken("RS256", "rsa1", validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", identity)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestAuthenticateECOk(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	mockKeys()
+
+	claims := validClaims()
+	claims["aud"] = "firefly"
+	identity, err := authenticate(o, signToken("ES256", "ec1", claims))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", identity)
+}
+
+func TestAuthenticateUnknownKeyRefreshLimited(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	mockKeys()
+
+	_, err := authenticate(o, signToken("RS256", "rsa2", validClaims()))
+	assert.Regexp(t, "FF10487.*unknown signing key", err)
+	_, err = authenticate(o, signToken("RS256", "rsa3", validClaims()))
+	assert.Regexp(t, "FF10487.*unknown signing key", err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestAuthenticateProviderFail(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	httpmock.RegisterResponder("GET", "https://idp.example.com/.well-known/openid-configuration",
+		httpmock.NewJsonResponderOrPanic(500, fftypes.JSONObject{}))
+
+	_, err := authenticate(o, signToken("RS256", "rsa1", validClaims()))
+	assert.Regexp(t, "FF10488", err)
+}
+
+func TestAuthenticateKeysFail(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	httpmock.RegisterResponder("GET", "https://idp.example.com/.well-known/openid-configuration",
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
+			"jwks_uri": "https://idp.example.com/keys",
+		}))
+	httpmock.RegisterResponder("GET", "https://idp.example.com/keys",
+		httpmock.NewJsonResponderOrPanic(500, fftypes.JSONObject{}))
+
+	_, err := authenticate(o, signToken("RS256", "rsa1", validClaims()))
+	assert.Regexp(t, "FF10488", err)
+}
+
+func TestAuthenticateBadSignature(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	mockKeys()
+
+	token := signToken("RS256", "rsa1", validClaims())
+	_, err := authenticate(o, token[0:strings.LastIndex(token, ".")]+".AAAA")
+	assert.Regexp(t, "FF10487.*signature verification failed", err)
+
+	_, err = authenticate(o, token[0:strings.LastIndex(token, ".")]+".!")
+	assert.Regexp(t, "FF10487.*malformed signature", err)
+
+	token = signToken("ES256", "ec1", validClaims())
+	_, err = authenticate(o, token[0:strings.LastIndex(token, ".")]+".AAAA")
+	assert.Regexp(t, "FF10487.*signature verification failed", err)
+}
+
+func TestAuthenticateAlgorithmMismatch(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	mockKeys()
+
+	_, err := authenticate(o, signToken("ES256", "rsa1", validClaims()))
+	assert.Regexp(t, "FF10487.*does not match key", err)
+	_, err = authenticate(o, signToken("RS256", "ec1", validClaims()))
+	assert.Regexp(t, "FF10487.*does not match key", err)
+	_, err = authenticate(o, signToken("HS256", "rsa1", validClaims()))
+	assert.Regexp(t, "FF10487.*does not match key", err)
+	_, err = authenticate(o, signToken("none", "rsa1", validClaims()))
+	assert.Regexp(t, "FF10487.*unsupported algorithm", err)
+	_, err = authenticate(o, signToken("RS999", "rsa1", validClaims()))
+	assert.Regexp(t, "FF10487.*unsupported algorithm", err)
+}
+
+func TestAuthenticateBadClaims(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	mockKeys()
+
+	claims := validClaims()
+	claims["iss"] = "https://other.example.com"
+	_, err := authenticate(o, signToken("RS256", "rsa1", claims))
+	assert.Regexp(t, "FF10487.*issuer mismatch", err)
+
+	claims = validClaims()
+	claims["aud"] = "other"
+	_, err = authenticate(o, signToken("RS256", "rsa1", claims))
+	assert.Regexp(t, "FF10487.*audience mismatch", err)
+
+	claims = validClaims()
+	claims["exp"] = 999960
+	_, err = authenticate(o, signToken("RS256", "rsa1", claims))
+	assert.Regexp(t, "FF10487.*expired", err)
+
+	claims = validClaims()
+	claims["nbf"] = 1000060
+	_, err = authenticate(o, signToken("RS256", "rsa1", claims))
+	assert.Regexp(t, "FF10487.*not yet valid", err)
+
+	claims = validClaims()
+	delete(claims, "sub")
+	_, err = authenticate(o, signToken("RS256", "rsa1", claims))
+	assert.Regexp(t, "FF10487.*missing claim sub", err)
+}
+
+func TestAuthenticateMalformed(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	_, err := o.Authenticate(req)
+	assert.Regexp(t, "FF10485", err)
+
+	_, err = authenticate(o, "not-a-jwt")
+	assert.Regexp(t, "FF10487.*malformed", err)
+
+	_, err = authenticate(o, "!.e30.e30")
+	assert.Regexp(t, "FF10487.*malformed header", err)
+}
+
+func TestAuthenticateMalformedClaims(t *testing.T) {
+	o, done := newTestOIDC(t)
+	defer done()
+	mockKeys()
+
+	header, _ := json.Marshal(fftypes.JSONObject{"alg": "RS256", "kid": "rsa1"})
+	signingInput := b64(header) + ".!"
+	h := crypto.SHA256.New()
+	h.Write([]byte(signingInput))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, testRSAKey, crypto.SHA256, h.Sum(nil))
+	_, err := authenticate(o, signingInput+"."+b64(sig))
+	assert.Regexp(t, "FF10487.*malformed claims", err)
+}
+
+func TestPublicKeyErrors(t *testing.T) {
+	_, err := (&jsonWebKey{KeyType: "RSA", N: "!"}).publicKey()
+	assert.Regexp(t, "invalid key parameter", err)
+	_, err = (&jsonWebKey{KeyType: "RSA", N: "AQAB", E: ""}).publicKey()
+	assert.Regexp(t, "invalid key parameter", err)
+	_, err = (&jsonWebKey{KeyType: "EC", Curve: "P-1"}).publicKey()
+	assert.Regexp(t, "unsupported curve", err)
+	_, err = (&jsonWebKey{KeyType: "EC", Curve: "P-256", X: "!"}).publicKey()
+	assert.Regexp(t, "invalid key parameter", err)
+	_, err = (&jsonWebKey{KeyType: "EC", Curve: "P-256", X: "AQAB", Y: "!"}).publicKey()
+	assert.Regexp(t, "invalid key parameter", err)
+	_, err = (&jsonWebKey{KeyType: "EC", Curve: "P-256", X: "AQAB", Y: "AQAB"}).publicKey()
+	assert.Regexp(t, "invalid point", err)
+}
@@ -24,19 +24,24 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
-func NewBatchManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, di database.Plugin, dm data.Manager) (Manager, error) {
-	if di == nil || dm == nil {
+func NewBatchManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, di database.Plugin, dm data.Manager, eb eventbus.Bus, mm metrics.Manager) (Manager, error) {
+	if di == nil || dm == nil || eb == nil || mm == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
-	pCtx, cancelCtx := context.WithCancel(log.WithLogField(ctx, "role", "batchmgr"))
+	if err := validateNamespaceOverrides(ctx); err != nil {
+		return nil, err
+	}
+	pCtx, cancelCtx := context.WithCancel(log.WithLogField(log.WithModule(ctx, "batch"), "role", "batchmgr"))
 	readPageSize := config.GetUint(config.BatchManagerReadPageSize)
 	bm := &batchManager{
 		ctx:                        pCtx,
@@ -44,6 +49,8 @@ func NewBatchManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, di data
 		ni:                         ni,
 		database:                   di,
 		data:                       dm,
+		eventBus:                   eb,
+		metrics:                    mm,
 		readOffset:                 -1, // On restart we trawl for all ready messages
 		readPageSize:               uint64(readPageSize),
 		messagePollTimeout:         config.GetDuration(config.BatchManagerReadPollTimeout),
@@ -57,22 +64,43 @@ func NewBatchManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, di data
 			Factor:       config.GetFloat64(config.BatchRetryFactor),
 		},
 	}
+	if config.GetBool(config.BatchAdaptiveEnabled) {
+		bm.adaptiveMinSize = config.GetUint(config.BatchAdaptiveMinSize)
+		if bm.adaptiveMinSize == 0 {
+			bm.adaptiveMinSize = 1
+		}
+	}
+	config.OnReload(pCtx, bm.reloadRetry, config.BatchRetryInitDelay, config.BatchRetryMaxDelay, config.BatchRetryFactor)
+	eb.Subscribe(eventbus.TopicMessageCreated, func(payload interface{}) {
+		bm.newMessages <- payload.(int64)
+	})
 	return bm, nil
 }
 
 type Manager interface {
 	RegisterDispatcher(name string, txType fftypes.TransactionType, msgTypes []fftypes.MessageType, handler DispatchHandler, batchOptions DispatcherOptions)
-	NewMessages() chan<- int64
 	Start() error
 	Close()
 	WaitStop()
 	Status() *ManagerStatus
+	Flush(ctx context.Context, ns, author string) (*FlushResult, error)
+	SetBatchTimeout(dispatcherName string, timeout time.Duration)
 }
 
 type ManagerStatus struct {
 	Processors []*ProcessorStatus `json:"processors"`
 }
 
+// FlushRequest selects the batch processors to flush within a namespace. All processors are flushed if no author is set.
+type FlushRequest struct {
+	Author string `json:"author,omitempty"`
+}
+
+// FlushResult lists the batches that were sealed and dispatched by a flush
+type FlushResult struct {
+	Batches []*fftypes.UUID `json:"batches"`
+}
+
 type ProcessorStatus struct {
 	Dispatcher string      `json:"dispatcher"`
 	Name       string      `json:"name"`
@@ -85,6 +113,8 @@ type batchManager struct {
 	ni                         sysmessaging.LocalNodeInfo
 	database                   database.Plugin
 	data                       data.Manager
+	eventBus                   eventbus.Bus
+	metrics                    metrics.Manager
 	dispatcherMux              sync.Mutex
 	dispatchers                map[string]*dispatcher
 	newMessages                chan int64
@@ -94,6 +124,7 @@ type batchManager struct {
 	readPageSize               uint64
 	messagePollTimeout         time.Duration
 	startupOffsetRetryAttempts int
+	adaptiveMinSize            uint
 }
 
 type DispatchHandler func(context.Context, *fftypes.Batch, []*fftypes.Bytes32) error
@@ -137,10 +168,6 @@ func (bm *batchManager) Start() error {
 	return nil
 }
 
-func (bm *batchManager) NewMessages() chan<- int64 {
-	return bm.newMessages
-}
-
 func (bm *batchManager) getProcessor(txType fftypes.TransactionType, msgType fftypes.MessageType, group *fftypes.Bytes32, namespace string, identity *fftypes.Identity) (*batchProcessor, error) {
 	bm.dispatcherMux.Lock()
 	defer bm.dispatcherMux.Unlock()
@@ -157,8 +184,10 @@ func (bm *batchManager) getProcessor(txType fftypes.TransactionType, msgType fft
 			bm.ctx, // Background context, not the call context
 			bm.ni,
 			bm.database,
+			bm.eventBus,
+			bm.metrics,
 			&batchProcessorConf{
-				DispatcherOptions: dispatcher.options,
+				DispatcherOptions: NamespaceOptions(namespace, dispatcher.options),
 				name:              name,
 				txType:            txType,
 				dispatcherName:    dispatcher.name,
@@ -166,6 +195,7 @@ func (bm *batchManager) getProcessor(txType fftypes.TransactionType, msgType fft
 				identity:          *identity,
 				group:             group,
 				dispatch:          dispatcher.handler,
+				adaptiveMinSize:   bm.adaptiveMinSize,
 			},
 			bm.retry,
 		)
@@ -188,9 +218,42 @@ func (bm *batchManager) assembleMessageData(msg *fftypes.Message) (data []*fftyp
 	if !foundAll {
 		return nil, i18n.NewError(bm.ctx, i18n.MsgDataNotFound, msg.Header.ID)
 	}
+	if msg.Header.Group == nil {
+		// The values of data restricted to a private group are never included in a broadcast batch.
+		// They are distributed to the members of the group separately, and verified against the hash.
+		for i, d := range data {
+			if d.Group != nil {
+				withheld := *d
+				withheld.Value = nil
+				data[i] = &withheld
+			}
+		}
+	}
 	return data, nil
 }
 
+// expireMessage moves a message that reached its expiry before being sent into expired state, so it
+// is excluded from batch assembly, and emits an event to notify the submitter.
+func (bm *batchManager) expireMessage(msg *fftypes.Message) error {
+	log.L(bm.ctx).Infof("Message %s expired at %s before it was sent", msg.Header.ID, msg.Expiry)
+	return bm.retry.Do(bm.ctx, fmt.Sprintf("expire message %s", msg.Header.ID), func(attempt int) (retry bool, err error) {
+		err = bm.database.RunAsGroup(bm.ctx, func(ctx context.Context) error {
+			fb := database.MessageQueryFactory.NewFilter(ctx)
+			filter := fb.And(
+				fb.Eq("id", msg.Header.ID),
+				fb.Eq("state", fftypes.MessageStateReady),
+			)
+			update := database.MessageQueryFactory.NewUpdate(ctx).Set("state", fftypes.MessageStateExpired)
+			if err := bm.database.UpdateMessages(ctx, filter, update); err != nil {
+				return err
+			}
+			event := fftypes.NewEvent(fftypes.EventTypeMessageExpired, msg.Header.Namespace, msg.Header.ID, nil)
+			return bm.database.InsertEvent(ctx, event)
+		})
+		return true, err
+	})
+}
+
 func (bm *batchManager) readPage() ([]*fftypes.Message, error) {
 
 	var msgs []*fftypes.Message
@@ -205,6 +268,36 @@ func (bm *batchManager) readPage() ([]*fftypes.Message, error) {
 	return msgs, err
 }
 
+// prioritizeMessages returns a copy of a page of messages in the order they should be dispatched,
+// where higher priority messages move ahead of lower priority messages. A message never moves ahead
+// of an earlier message on the same topic, so per-topic ordering is preserved.
+func prioritizeMessages(msgs []*fftypes.Message) []*fftypes.Message {
+	remaining := append([]*fftypes.Message{}, msgs...)
+	ordered := make([]*fftypes.Message, 0, len(msgs))
+	for len(remaining) > 0 {
+		// Pick the first message with the highest weight, that is not blocked by an earlier message on the same topic
+		next := -1
+		for i, msg := range remaining {
+			if next >= 0 && msg.Header.PriorityWeight() <= remaining[next].Header.PriorityWeight() {
+				continue
+			}
+			blocked := false
+			for _, earlier := range remaining[0:i] {
+				if earlier.Header.SharesTopic(&msg.Header) {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				next = i
+			}
+		}
+		ordered = append(ordered, remaining[next])
+		remaining = append(remaining[0:next], remaining[next+1:]...)
+	}
+	return ordered
+}
+
 func (bm *batchManager) messageSequencer() {
 	l := log.L(bm.ctx)
 	l.Debugf("Started batch assembly message sequencer")
@@ -223,7 +316,15 @@ func (bm *batchManager) messageSequencer() {
 		batchWasFull := (uint64(len(msgs)) == bm.readPageSize)
 
 		if len(msgs) > 0 {
-			for _, msg := range msgs {
+			for _, msg := range prioritizeMessages(msgs) {
+				if msg.Expiry != nil && time.Now().After(*msg.Expiry.Time()) {
+					if err := bm.expireMessage(msg); err != nil {
+						l.Debugf("Exiting: %s", err)
+						return
+					}
+					continue
+				}
+
 				data, err := bm.assembleMessageData(msg)
 				if err != nil {
 					l.Errorf("Failed to retrieve message data for %s: %s", msg.Header.ID, err)
@@ -349,6 +450,33 @@ func (bm *batchManager) getProcessors() []*batchProcessor {
 	return processors
 }
 
+// reloadRetry applies the retry delays from the config to the manager, and to the running processors
+func (bm *batchManager) reloadRetry(ctx context.Context) {
+	initialDelay := config.GetDuration(config.BatchRetryInitDelay)
+	maximumDelay := config.GetDuration(config.BatchRetryMaxDelay)
+	factor := config.GetFloat64(config.BatchRetryFactor)
+	bm.retry.SetDelays(initialDelay, maximumDelay, factor)
+	for _, p := range bm.getProcessors() {
+		p.retry.SetDelays(initialDelay, maximumDelay, factor)
+	}
+}
+
+// SetBatchTimeout changes the batch timeout of a dispatcher, including its running processors,
+// which continue to use the timeout configured for their namespace if there is one
+func (bm *batchManager) SetBatchTimeout(dispatcherName string, timeout time.Duration) {
+	bm.dispatcherMux.Lock()
+	defer bm.dispatcherMux.Unlock()
+	for _, d := range bm.dispatchers {
+		if d.name != dispatcherName {
+			continue
+		}
+		d.options.BatchTimeout = timeout
+		for _, p := range d.processors {
+			p.setBatchTimeout(NamespaceOptions(p.conf.namespace, d.options).BatchTimeout)
+		}
+	}
+}
+
 func (bm *batchManager) Status() *ManagerStatus {
 	processors := bm.getProcessors()
 	pStatus := make([]*ProcessorStatus, len(processors))
@@ -360,6 +488,25 @@ func (bm *batchManager) Status() *ManagerStatus {
 	}
 }
 
+// Flush asks each batch processor for the namespace and author to seal and dispatch its open batch immediately,
+// rather than waiting for the batch timeout, and waits for the dispatch to complete.
+func (bm *batchManager) Flush(ctx context.Context, ns, author string) (*FlushResult, error) {
+	result := &FlushResult{Batches: []*fftypes.UUID{}}
+	for _, p := range bm.getProcessors() {
+		if p.conf.namespace != ns || (author != "" && p.conf.identity.Author != author) {
+			continue
+		}
+		batchID, err := p.requestFlush(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if batchID != nil {
+			result.Batches = append(result.Batches, batchID)
+		}
+	}
+	return result, nil
+}
+
 func (bm *batchManager) Close() {
 	bm.cancelCtx() // all processor contexts are child contexts
 }
@@ -23,9 +23,12 @@ import (
 	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/mocks/metricsmocks"
 	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -33,6 +36,12 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+func newTestMetrics() *metricsmocks.Manager {
+	mmi := &metricsmocks.Manager{}
+	mmi.On("IsMetricsEnabled").Return(false).Maybe()
+	return mmi
+}
+
 func TestE2EDispatchBroadcast(t *testing.T) {
 	log.SetLevel("debug")
 	config.Reset()
@@ -41,6 +50,7 @@ func TestE2EDispatchBroadcast(t *testing.T) {
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mni.On("GetNodeUUID", mock.Anything).Return(fftypes.NewUUID())
+	mni.On("SignBatchHash", mock.Anything, mock.Anything).Return("")
 	readyForDispatch := make(chan bool)
 	waitForDispatch := make(chan *fftypes.Batch)
 	handler := func(ctx context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
@@ -69,7 +79,8 @@ func TestE2EDispatchBroadcast(t *testing.T) {
 		return nil
 	}
 	ctx, cancel := context.WithCancel(context.Background())
-	bmi, _ := NewBatchManager(ctx, mni, mdi, mdm)
+	eb := eventbus.NewBus()
+	bmi, _ := NewBatchManager(ctx, mni, mdi, mdm, eb, newTestMetrics())
 	bm := bmi.(*batchManager)
 
 	bm.RegisterDispatcher("utdispatcher", fftypes.TransactionTypeBatchPin, []fftypes.MessageType{fftypes.MessageTypeBroadcast}, handler, DispatcherOptions{
@@ -120,7 +131,7 @@ func TestE2EDispatchBroadcast(t *testing.T) {
 	err := bm.Start()
 	assert.NoError(t, err)
 
-	bm.NewMessages() <- msg.Sequence
+	eb.Publish(eventbus.TopicMessageCreated, msg.Sequence)
 
 	readyForDispatch <- true
 
@@ -137,7 +148,7 @@ func TestE2EDispatchBroadcast(t *testing.T) {
 	// Wait for the reaping
 	for len(bm.getProcessors()) > 0 {
 		time.Sleep(1 * time.Millisecond)
-		bm.NewMessages() <- msg.Sequence
+		eb.Publish(eventbus.TopicMessageCreated, msg.Sequence)
 	}
 
 	cancel()
@@ -153,6 +164,7 @@ func TestE2EDispatchPrivateUnpinned(t *testing.T) {
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mni.On("GetNodeUUID", mock.Anything).Return(fftypes.NewUUID())
+	mni.On("SignBatchHash", mock.Anything, mock.Anything).Return("")
 	readyForDispatch := make(chan bool)
 	waitForDispatch := make(chan *fftypes.Batch)
 	var groupID fftypes.Bytes32
@@ -184,7 +196,8 @@ func TestE2EDispatchPrivateUnpinned(t *testing.T) {
 		return nil
 	}
 	ctx, cancel := context.WithCancel(context.Background())
-	bmi, _ := NewBatchManager(ctx, mni, mdi, mdm)
+	eb := eventbus.NewBus()
+	bmi, _ := NewBatchManager(ctx, mni, mdi, mdm, eb, newTestMetrics())
 	bm := bmi.(*batchManager)
 
 	bm.RegisterDispatcher("utdispatcher", fftypes.TransactionTypeBatchPin, []fftypes.MessageType{fftypes.MessageTypePrivate}, handler, DispatcherOptions{
@@ -242,7 +255,7 @@ func TestE2EDispatchPrivateUnpinned(t *testing.T) {
 	err := bm.Start()
 	assert.NoError(t, err)
 
-	bm.NewMessages() <- msg.Sequence
+	eb.Publish(eventbus.TopicMessageCreated, msg.Sequence)
 
 	readyForDispatch <- true
 	b := <-waitForDispatch
@@ -264,7 +277,7 @@ func TestDispatchUnknownType(t *testing.T) {
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	ctx, cancel := context.WithCancel(context.Background())
-	bmi, _ := NewBatchManager(ctx, mni, mdi, mdm)
+	bmi, _ := NewBatchManager(ctx, mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	bm := bmi.(*batchManager)
 
 	msg := &fftypes.Message{}
@@ -280,16 +293,30 @@ func TestDispatchUnknownType(t *testing.T) {
 }
 
 func TestInitFailNoPersistence(t *testing.T) {
-	_, err := NewBatchManager(context.Background(), nil, nil, nil)
+	_, err := NewBatchManager(context.Background(), nil, nil, nil, nil, nil)
 	assert.Error(t, err)
 }
 
+func TestInitAdaptiveSizing(t *testing.T) {
+	config.Reset()
+	config.Set(config.BatchAdaptiveEnabled, true)
+	config.Set(config.BatchAdaptiveMinSize, 5)
+	bm, err := NewBatchManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &databasemocks.Plugin{}, &datamocks.Manager{}, eventbus.NewBus(), newTestMetrics())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), bm.(*batchManager).adaptiveMinSize)
+
+	config.Set(config.BatchAdaptiveMinSize, 0)
+	bm, err = NewBatchManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &databasemocks.Plugin{}, &datamocks.Manager{}, eventbus.NewBus(), newTestMetrics())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), bm.(*batchManager).adaptiveMinSize)
+}
+
 func TestGetInvalidBatchTypeMsg(t *testing.T) {
 
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
-	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	defer bm.Close()
 	msg := &fftypes.Message{Header: fftypes.MessageHeader{}}
 	err := bm.(*batchManager).dispatchMessage(msg)
@@ -301,7 +328,7 @@ func TestMessageSequencerCancelledContext(t *testing.T) {
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mdi.On("GetMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
-	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	defer bm.Close()
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -314,7 +341,7 @@ func TestMessageSequencerMissingMessageData(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
-	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 
 	dataID := fftypes.NewUUID()
 	mdi.On("GetMessages", mock.Anything, mock.Anything, mock.Anything).
@@ -343,13 +370,89 @@ func TestMessageSequencerMissingMessageData(t *testing.T) {
 	mdm.AssertExpectations(t)
 }
 
+func TestMessageSequencerExpiredMessage(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mdm := &datamocks.Manager{}
+	mni := &sysmessagingmocks.LocalNodeInfo{}
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
+
+	msgID := fftypes.NewUUID()
+	mdi.On("GetMessages", mock.Anything, mock.Anything, mock.Anything).
+		Return([]*fftypes.Message{
+			{
+				Header: fftypes.MessageHeader{
+					ID:        msgID,
+					Namespace: "ns1",
+				},
+				Expiry: fftypes.UnixTime(0),
+			},
+		}, nil, nil).
+		Run(func(args mock.Arguments) {
+			bm.Close()
+		}).
+		Once()
+	mdi.On("GetMessages", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+	mdi.On("UpdateMessages", mock.Anything, mock.MatchedBy(func(f database.Filter) bool {
+		fi, err := f.Finalize()
+		assert.NoError(t, err)
+		return fi.String() == fmt.Sprintf("( id == '%s' ) && ( state == 'ready' )", msgID)
+	}), mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeMessageExpired && *e.Reference == *msgID
+	})).Return(nil)
+
+	bm.(*batchManager).messageSequencer()
+
+	bm.WaitStop()
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestMessageSequencerExpireMessageFail(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mdm := &datamocks.Manager{}
+	mni := &sysmessagingmocks.LocalNodeInfo{}
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
+
+	mdi.On("GetMessages", mock.Anything, mock.Anything, mock.Anything).
+		Return([]*fftypes.Message{
+			{
+				Header: fftypes.MessageHeader{
+					ID:        fftypes.NewUUID(),
+					Namespace: "ns1",
+				},
+				Expiry: fftypes.UnixTime(0),
+			},
+		}, nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		bm.Close()
+	})
+
+	bm.(*batchManager).messageSequencer()
+
+	bm.WaitStop()
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
 func TestMessageSequencerUpdateMessagesFail(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mni.On("GetNodeUUID", mock.Anything).Return(fftypes.NewUUID())
+	mni.On("SignBatchHash", mock.Anything, mock.Anything).Return("")
 	ctx, cancelCtx := context.WithCancel(context.Background())
-	bm, _ := NewBatchManager(ctx, mni, mdi, mdm)
+	bm, _ := NewBatchManager(ctx, mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	bm.RegisterDispatcher("utdispatcher", fftypes.TransactionTypeBatchPin, []fftypes.MessageType{fftypes.MessageTypeBroadcast}, func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
 		return nil
 	}, DispatcherOptions{BatchMaxSize: 1, DisposeTimeout: 0})
@@ -398,8 +501,9 @@ func TestMessageSequencerDispatchFail(t *testing.T) {
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mni.On("GetNodeUUID", mock.Anything).Return(fftypes.NewUUID())
+	mni.On("SignBatchHash", mock.Anything, mock.Anything).Return("")
 	ctx, cancelCtx := context.WithCancel(context.Background())
-	bm, _ := NewBatchManager(ctx, mni, mdi, mdm)
+	bm, _ := NewBatchManager(ctx, mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	bm.RegisterDispatcher("utdispatcher", fftypes.TransactionTypeBatchPin, []fftypes.MessageType{fftypes.MessageTypeBroadcast}, func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
 		cancelCtx()
 		return fmt.Errorf("fizzle")
@@ -434,8 +538,9 @@ func TestMessageSequencerUpdateBatchFail(t *testing.T) {
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mni.On("GetNodeUUID", mock.Anything).Return(fftypes.NewUUID())
+	mni.On("SignBatchHash", mock.Anything, mock.Anything).Return("")
 	ctx, cancelCtx := context.WithCancel(context.Background())
-	bm, _ := NewBatchManager(ctx, mni, mdi, mdm)
+	bm, _ := NewBatchManager(ctx, mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	bm.RegisterDispatcher("utdispatcher", fftypes.TransactionTypeBatchPin, []fftypes.MessageType{fftypes.MessageTypeBroadcast}, func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
 		return nil
 	}, DispatcherOptions{BatchMaxSize: 1, DisposeTimeout: 0})
@@ -482,7 +587,7 @@ func TestWaitForPollTimeout(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
-	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	bm.(*batchManager).messagePollTimeout = 1 * time.Microsecond
 	bm.(*batchManager).waitForNewMessages()
 }
@@ -491,10 +596,11 @@ func TestWaitForNewMessage(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
-	bmi, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	eb := eventbus.NewBus()
+	bmi, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eb, newTestMetrics())
 	bm := bmi.(*batchManager)
 	bm.readOffset = 22222
-	bm.NewMessages() <- 12345
+	eb.Publish(eventbus.TopicMessageCreated, int64(12345))
 	bm.waitForNewMessages()
 	assert.Equal(t, int64(12344), bm.readOffset)
 }
@@ -503,7 +609,7 @@ func TestAssembleMessageDataNilData(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
-	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	bm.Close()
 	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return(nil, false, nil)
 	_, err := bm.(*batchManager).assembleMessageData(&fftypes.Message{
@@ -519,7 +625,7 @@ func TestGetMessageDataFail(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
-	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return(nil, false, fmt.Errorf("pop"))
 	bm.Close()
 	_, _ = bm.(*batchManager).assembleMessageData(&fftypes.Message{
@@ -537,7 +643,7 @@ func TestGetMessageNotFound(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
-	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
 	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return(nil, false, nil)
 	bm.Close()
 	_, err := bm.(*batchManager).assembleMessageData(&fftypes.Message{
@@ -550,3 +656,168 @@ func TestGetMessageNotFound(t *testing.T) {
 	})
 	assert.Regexp(t, "FF10133", err)
 }
+
+func TestAssembleMessageDataWithholdsPrivateValues(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mdm := &datamocks.Manager{}
+	mni := &sysmessagingmocks.LocalNodeInfo{}
+	bm, _ := NewBatchManager(context.Background(), mni, mdi, mdm, eventbus.NewBus(), newTestMetrics())
+	bm.Close()
+	public := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr(`"public"`)}
+	private := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr(`"private"`), Group: fftypes.NewRandB32()}
+	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return(func(ctx context.Context, msg *fftypes.Message, withValue bool) []*fftypes.Data {
+		return []*fftypes.Data{public, private}
+	}, true, nil)
+
+	data, err := bm.(*batchManager).assembleMessageData(&fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID: fftypes.NewUUID(),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `"public"`, data[0].Value.String())
+	assert.Nil(t, data[1].Value)
+	assert.Equal(t, private.ID, data[1].ID)
+	assert.Equal(t, `"private"`, private.Value.String())
+
+	// The whole of a private message is sent to the group, so nothing is withheld
+	data, err = bm.(*batchManager).assembleMessageData(&fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:    fftypes.NewUUID(),
+			Group: fftypes.NewRandB32(),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `"private"`, data[1].Value.String())
+}
+
+func TestPrioritizeMessages(t *testing.T) {
+	newMsg := func(seq int64, priority fftypes.MessagePriority, topics ...string) *fftypes.Message {
+		return &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Namespace: "ns1",
+				Topics:    topics,
+				Priority:  priority,
+			},
+			Sequence: seq,
+		}
+	}
+	msgs := []*fftypes.Message{
+		newMsg(1, fftypes.MessagePriorityLow, "bulk"),
+		newMsg(2, "", "t1"),
+		newMsg(3, fftypes.MessagePriorityLow, "control"),
+		newMsg(4, fftypes.MessagePriorityHigh, "control"),
+		newMsg(5, fftypes.MessagePriorityHigh, "other"),
+	}
+	ordered := prioritizeMessages(msgs)
+	sequences := make([]int64, len(ordered))
+	for i, msg := range ordered {
+		sequences[i] = msg.Sequence
+	}
+	// Message 4 cannot move ahead of message 3, as they share a topic
+	assert.Equal(t, []int64{5, 2, 1, 3, 4}, sequences)
+	assert.Equal(t, int64(5), msgs[4].Sequence)
+}
+
+func newFakeFlushProcessor(ns, author string, batchID *fftypes.UUID) *batchProcessor {
+	bp := &batchProcessor{
+		conf: &batchProcessorConf{
+			namespace: ns,
+			identity:  fftypes.Identity{Author: author},
+		},
+		flushRequests: make(chan chan *fftypes.UUID),
+		done:          make(chan struct{}),
+	}
+	go func() {
+		for flushed := range bp.flushRequests {
+			flushed <- batchID
+		}
+	}()
+	return bp
+}
+
+func TestFlush(t *testing.T) {
+	bm, _ := NewBatchManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &databasemocks.Plugin{}, &datamocks.Manager{}, eventbus.NewBus(), newTestMetrics())
+	batch1 := fftypes.NewUUID()
+	batch2 := fftypes.NewUUID()
+	bm.(*batchManager).dispatchers["utdispatcher"] = &dispatcher{
+		processors: map[string]*batchProcessor{
+			"p1": newFakeFlushProcessor("ns1", "org1", batch1),
+			"p2": newFakeFlushProcessor("ns1", "org1", nil),
+			"p3": newFakeFlushProcessor("ns1", "org2", batch2),
+			"p4": newFakeFlushProcessor("ns2", "org1", fftypes.NewUUID()),
+		},
+	}
+
+	result, err := bm.Flush(context.Background(), "ns1", "org1")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*fftypes.UUID{batch1}, result.Batches)
+
+	result, err = bm.Flush(context.Background(), "ns1", "")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*fftypes.UUID{batch1, batch2}, result.Batches)
+}
+
+func TestFlushCancelled(t *testing.T) {
+	bm, _ := NewBatchManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &databasemocks.Plugin{}, &datamocks.Manager{}, eventbus.NewBus(), newTestMetrics())
+	bm.(*batchManager).dispatchers["utdispatcher"] = &dispatcher{
+		processors: map[string]*batchProcessor{
+			"p1": {
+				conf:          &batchProcessorConf{namespace: "ns1"},
+				flushRequests: make(chan chan *fftypes.UUID),
+				done:          make(chan struct{}),
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := bm.Flush(ctx, "ns1", "")
+	assert.Regexp(t, "FF10158", err)
+}
+
+func TestSetBatchTimeout(t *testing.T) {
+	config.Reset()
+	bm, _ := NewBatchManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &databasemocks.Plugin{}, &datamocks.Manager{}, eventbus.NewBus(), newTestMetrics())
+	d1 := &dispatcher{
+		name:    "utdispatcher1",
+		options: DispatcherOptions{BatchTimeout: 1 * time.Second},
+		processors: map[string]*batchProcessor{
+			"p1": {conf: &batchProcessorConf{namespace: "ns1", DispatcherOptions: DispatcherOptions{BatchTimeout: 1 * time.Second}}},
+		},
+	}
+	d2 := &dispatcher{
+		name:       "utdispatcher2",
+		options:    DispatcherOptions{BatchTimeout: 1 * time.Second},
+		processors: map[string]*batchProcessor{},
+	}
+	bm.(*batchManager).dispatchers["tx:1"] = d1
+	bm.(*batchManager).dispatchers["tx:2"] = d1
+	bm.(*batchManager).dispatchers["tx:3"] = d2
+
+	bm.SetBatchTimeout("utdispatcher1", 5*time.Second)
+	assert.Equal(t, 5*time.Second, d1.options.BatchTimeout)
+	assert.Equal(t, 5*time.Second, d1.processors["p1"].getBatchTimeout())
+	assert.Equal(t, 1*time.Second, d2.options.BatchTimeout)
+}
+
+func TestReloadRetry(t *testing.T) {
+	config.Reset()
+	bm, _ := NewBatchManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &databasemocks.Plugin{}, &datamocks.Manager{}, eventbus.NewBus(), newTestMetrics())
+	bp := &batchProcessor{retry: &retry.Retry{}}
+	bm.(*batchManager).dispatchers["utdispatcher"] = &dispatcher{
+		processors: map[string]*batchProcessor{"p1": bp},
+	}
+
+	config.Set(config.BatchRetryInitDelay, "10ms")
+	config.Set(config.BatchRetryMaxDelay, "1s")
+	config.Set(config.BatchRetryFactor, 3.0)
+	bm.(*batchManager).reloadRetry(context.Background())
+
+	initialDelay, maximumDelay, factor := bp.retry.Delays()
+	assert.Equal(t, 10*time.Millisecond, initialDelay)
+	assert.Equal(t, 1*time.Second, maximumDelay)
+	assert.Equal(t, 3.0, factor)
+	initialDelay, _, _ = bm.(*batchManager).retry.Delays()
+	assert.Equal(t, 10*time.Millisecond, initialDelay)
+}
@@ -21,11 +21,15 @@ import (
 	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/binary"
+	"encoding/json"
 	"math"
 	"sync"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/eventbus"
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
 	"github.com/hyperledger/firefly/internal/txcommon"
@@ -47,6 +51,8 @@ type batchProcessorConf struct {
 	identity       fftypes.Identity
 	group          *fftypes.Bytes32
 	dispatch       DispatchHandler
+	// adaptiveMinSize enables adaptive batch sizing when non-zero, and is the smallest target size
+	adaptiveMinSize uint
 }
 
 // FlushStatus is an object that can be returned on REST queries to understand the status
@@ -61,6 +67,7 @@ type FlushStatus struct {
 	AverageBatchMessages float64         `json:"averageBatchMessages"`
 	AverageBatchData     float64         `json:"averageBatchData"`
 	AverageFlushTimeMS   int64           `json:"averageFlushTimeMS"`
+	TargetBatchSize      uint            `json:"targetBatchSize,omitempty"`
 	TotalBatches         int64           `json:"totalBatches"`
 	TotalErrors          int64           `json:"totalErrors"`
 
@@ -74,10 +81,13 @@ type batchProcessor struct {
 	ctx                context.Context
 	ni                 sysmessaging.LocalNodeInfo
 	database           database.Plugin
+	eventBus           eventbus.Bus
+	metrics            metrics.Manager
 	txHelper           txcommon.Helper
 	cancelCtx          func()
 	done               chan struct{}
 	quescing           chan bool
+	flushRequests      chan chan *fftypes.UUID
 	newWork            chan *batchWork
 	assemblyID         *fftypes.UUID
 	assemblyQueue      []*batchWork
@@ -91,22 +101,26 @@ type batchProcessor struct {
 
 const batchSizeEstimateBase = int64(512)
 
-func newBatchProcessor(ctx context.Context, ni sysmessaging.LocalNodeInfo, di database.Plugin, conf *batchProcessorConf, baseRetryConf *retry.Retry) *batchProcessor {
+func newBatchProcessor(ctx context.Context, ni sysmessaging.LocalNodeInfo, di database.Plugin, eb eventbus.Bus, mm metrics.Manager, conf *batchProcessorConf, baseRetryConf *retry.Retry) *batchProcessor {
 	pCtx := log.WithLogField(log.WithLogField(ctx, "d", conf.dispatcherName), "p", conf.name)
 	pCtx, cancelCtx := context.WithCancel(pCtx)
+	initialDelay, maximumDelay, factor := baseRetryConf.Delays()
 	bp := &batchProcessor{
-		ctx:       pCtx,
-		cancelCtx: cancelCtx,
-		ni:        ni,
-		database:  di,
-		txHelper:  txcommon.NewTransactionHelper(di),
-		newWork:   make(chan *batchWork, conf.BatchMaxSize),
-		quescing:  make(chan bool, 1),
-		done:      make(chan struct{}),
+		ctx:           pCtx,
+		cancelCtx:     cancelCtx,
+		ni:            ni,
+		database:      di,
+		eventBus:      eb,
+		metrics:       mm,
+		txHelper:      txcommon.NewTransactionHelper(di),
+		newWork:       make(chan *batchWork, conf.BatchMaxSize),
+		quescing:      make(chan bool, 1),
+		flushRequests: make(chan chan *fftypes.UUID),
+		done:          make(chan struct{}),
 		retry: &retry.Retry{
-			InitialDelay: baseRetryConf.InitialDelay,
-			MaximumDelay: baseRetryConf.MaximumDelay,
-			Factor:       baseRetryConf.Factor,
+			InitialDelay: initialDelay,
+			MaximumDelay: maximumDelay,
+			Factor:       factor,
 		},
 		conf:             conf,
 		flushedSequences: []int64{},
@@ -114,6 +128,13 @@ func newBatchProcessor(ctx context.Context, ni sysmessaging.LocalNodeInfo, di da
 			LastFlushTime: fftypes.Now(),
 		},
 	}
+	// With adaptive sizing we start small, for the lowest latency, and grow if the load requires it
+	if conf.adaptiveMinSize > conf.BatchMaxSize {
+		conf.adaptiveMinSize = conf.BatchMaxSize
+	}
+	if conf.adaptiveMinSize > 0 {
+		bp.flushStatus.TargetBatchSize = conf.adaptiveMinSize
+	}
 	// Capture flush errors for our status
 	bp.retry.ErrCallback = bp.captureFlushError
 	bp.newAssembly()
@@ -140,6 +161,28 @@ func (bp *batchProcessor) status() *ProcessorStatus {
 	}
 }
 
+func (bp *batchProcessor) getBatchTimeout() time.Duration {
+	bp.statusMux.Lock()
+	defer bp.statusMux.Unlock()
+	return bp.conf.BatchTimeout
+}
+
+// setBatchTimeout changes the batch timeout, from the next batch that is started
+func (bp *batchProcessor) setBatchTimeout(timeout time.Duration) {
+	bp.statusMux.Lock()
+	defer bp.statusMux.Unlock()
+	bp.conf.BatchTimeout = timeout
+}
+
+// batchSizeTarget is the number of messages that fills a batch. Only the assembly loop updates the target,
+// so it can read it without taking the status lock.
+func (bp *batchProcessor) batchSizeTarget() uint {
+	if bp.conf.adaptiveMinSize > 0 {
+		return bp.flushStatus.TargetBatchSize
+	}
+	return bp.conf.BatchMaxSize
+}
+
 func (bp *batchProcessor) newAssembly(initalWork ...*batchWork) {
 	bp.assemblyID = fftypes.NewUUID()
 	bp.assemblyQueue = append([]*batchWork{}, initalWork...)
@@ -179,7 +222,9 @@ func (bp *batchProcessor) addWork(newWork *batchWork) (full, overflow bool) {
 	log.L(bp.ctx).Debugf("Added message %s sequence=%d to in-flight batch assembly %s", newWork.msg.Header.ID, newWork.msg.Sequence, bp.assemblyID)
 	bp.assemblyQueueBytes += newWork.estimateSize()
 	bp.assemblyQueue = newQueue
-	full = len(bp.assemblyQueue) >= int(bp.conf.BatchMaxSize) || (bp.assemblyQueueBytes >= bp.conf.BatchMaxBytes)
+	// High priority messages flush the batch immediately, along with anything already queued ahead of them
+	full = len(bp.assemblyQueue) >= int(bp.batchSizeTarget()) || (bp.assemblyQueueBytes >= bp.conf.BatchMaxBytes) ||
+		newWork.msg.Header.Priority.Equals(fftypes.MessagePriorityHigh)
 	overflow = len(bp.assemblyQueue) > 1 && (bp.assemblyQueueBytes > bp.conf.BatchMaxBytes)
 	return full, overflow
 }
@@ -210,7 +255,26 @@ func (bp *batchProcessor) addFlushedSequences(flushAssembly []*batchWork) {
 	bp.flushedSequences = newFlushedSequences
 }
 
-func (bp *batchProcessor) startFlush(overflow bool) (id *fftypes.UUID, flushAssembly []*batchWork, byteSize int64) {
+func isMembershipChange(work *batchWork) bool {
+	return work.msg != nil && work.msg.Header.Tag == string(fftypes.SystemTagChangeGroupMembers)
+}
+
+// isolateMembershipChanges splits the work so that any membership change is flushed in a batch of its own.
+// The batch containing a membership change is not sent to the nodes of departing members, so it must not
+// contain any other messages they are entitled to receive.
+func isolateMembershipChanges(work []*batchWork) (flushWork, heldWork []*batchWork) {
+	for i, w := range work {
+		if isMembershipChange(w) {
+			if i == 0 {
+				return work[:1], work[1:]
+			}
+			return work[:i], work[i:]
+		}
+	}
+	return work, nil
+}
+
+func (bp *batchProcessor) startFlush(overflow bool) (id *fftypes.UUID, flushAssembly []*batchWork, byteSize int64, held bool) {
 	bp.statusMux.Lock()
 	defer bp.statusMux.Unlock()
 	// Start the clock
@@ -225,16 +289,19 @@ func (bp *batchProcessor) startFlush(overflow bool) (id *fftypes.UUID, flushAsse
 	} else {
 		flushAssembly = bp.assemblyQueue
 	}
+	// Hold back any work either side of a membership change for the next flush
+	flushAssembly, heldWork := isolateMembershipChanges(flushAssembly)
+	held = len(heldWork) > 0
 	bp.addFlushedSequences(flushAssembly)
 	// Cycle to the next assembly
 	id = bp.assemblyID
 	byteSize = bp.assemblyQueueBytes
 	bp.flushStatus.Flushing = id
-	bp.newAssembly(overflowWork...)
-	return id, flushAssembly, byteSize
+	bp.newAssembly(append(heldWork, overflowWork...)...)
+	return id, flushAssembly, byteSize, held
 }
 
-func (bp *batchProcessor) endFlush(batch *fftypes.Batch, byteSize int64) {
+func (bp *batchProcessor) endFlush(batch *fftypes.Batch, byteSize int64, timedout bool) {
 	bp.statusMux.Lock()
 	defer bp.statusMux.Unlock()
 	fs := &bp.flushStatus
@@ -255,6 +322,37 @@ func (bp *batchProcessor) endFlush(batch *fftypes.Batch, byteSize int64) {
 
 	fs.totalDataFlushed += int64(len(batch.Payload.Data))
 	fs.AverageBatchData = math.Round((float64(fs.totalDataFlushed)/float64(fs.TotalBatches))*100) / 100
+
+	if bp.conf.adaptiveMinSize > 0 {
+		bp.adaptBatchSize(len(batch.Payload.Messages), timedout)
+	}
+	if bp.metrics.IsMetricsEnabled() {
+		bp.metrics.BatchFlushed(bp.conf.namespace, bp.conf.dispatcherName, len(batch.Payload.Messages), bp.batchSizeTarget())
+	}
+}
+
+// adaptBatchSize doubles the target size when a batch fills up, as the load is keeping up with us and
+// larger batches are more efficient. It halves the target when a batch times out before filling up, so under
+// light load we flush sooner rather than waiting for the timeout on every batch.
+// Must be called with the status lock held.
+func (bp *batchProcessor) adaptBatchSize(messages int, timedout bool) {
+	fs := &bp.flushStatus
+	previous := fs.TargetBatchSize
+	switch {
+	case messages >= int(fs.TargetBatchSize) && fs.TargetBatchSize < bp.conf.BatchMaxSize:
+		fs.TargetBatchSize *= 2
+		if fs.TargetBatchSize > bp.conf.BatchMaxSize {
+			fs.TargetBatchSize = bp.conf.BatchMaxSize
+		}
+	case timedout && fs.TargetBatchSize > bp.conf.adaptiveMinSize:
+		fs.TargetBatchSize /= 2
+		if fs.TargetBatchSize < bp.conf.adaptiveMinSize {
+			fs.TargetBatchSize = bp.conf.adaptiveMinSize
+		}
+	}
+	if fs.TargetBatchSize != previous {
+		log.L(bp.ctx).Debugf("Adjusted target batch size from %d to %d", previous, fs.TargetBatchSize)
+	}
 }
 
 func (bp *batchProcessor) captureFlushError(err error) {
@@ -268,6 +366,27 @@ func (bp *batchProcessor) captureFlushError(err error) {
 	fs.LastFlushError = err.Error()
 }
 
+// requestFlush asks the assembly loop to flush the open batch, and returns the ID of the batch once it has been
+// dispatched. The ID is nil if there was no work waiting, or if the processor shut down.
+func (bp *batchProcessor) requestFlush(ctx context.Context) (*fftypes.UUID, error) {
+	flushed := make(chan *fftypes.UUID, 1)
+	select {
+	case bp.flushRequests <- flushed:
+	case <-bp.done:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, i18n.NewError(ctx, i18n.MsgContextCanceled)
+	}
+	select {
+	case batchID := <-flushed:
+		return batchID, nil
+	case <-bp.done:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, i18n.NewError(ctx, i18n.MsgContextCanceled)
+	}
+}
+
 func (bp *batchProcessor) startQuiesce() {
 	// We are ready to quiesce, but we can't safely close our input channel.
 	// We just do a non-blocking pass (queue length is 1) to the manager to
@@ -292,6 +411,7 @@ func (bp *batchProcessor) assemblyLoop() {
 	for !quescing {
 
 		var timedout, full, overflow bool
+		var flushed chan *fftypes.UUID
 		select {
 		case <-bp.ctx.Done():
 			l.Tracef("Batch processor shutting down")
@@ -305,6 +425,8 @@ func (bp *batchProcessor) assemblyLoop() {
 				// We need to flush
 				timedout = true
 			}
+		case flushed = <-bp.flushRequests:
+			l.Debugf("Flush requested")
 		case work, ok := <-bp.newWork:
 			if !ok {
 				quescing = true
@@ -313,26 +435,36 @@ func (bp *batchProcessor) assemblyLoop() {
 				if idle {
 					// We've hit a message while we were idle - we now need to wait for the batch to time out.
 					_ = batchTimeout.Stop()
-					batchTimeout = time.NewTimer(bp.conf.BatchTimeout)
+					batchTimeout = time.NewTimer(bp.getBatchTimeout())
 					idle = false
 				}
 			}
 		}
-		if (full || timedout || quescing) && len(bp.assemblyQueue) > 0 {
+		if (full || timedout || quescing || flushed != nil) && len(bp.assemblyQueue) > 0 {
 			// Let Go GC the old timer
 			_ = batchTimeout.Stop()
 
 			// If we are in overflow, start the clock for the next batch to start before we do the flush
 			// (even though we won't check it until after).
 			if overflow {
-				batchTimeout = time.NewTimer(bp.conf.BatchTimeout)
+				batchTimeout = time.NewTimer(bp.getBatchTimeout())
 			}
 
-			err := bp.flush(overflow)
-			if err != nil {
-				l.Warnf("Batch processor shutting down: %s", err)
-				_ = batchTimeout.Stop()
-				return
+			// Work held back from the flush, because it was batched with a membership change, is flushed straight after
+			var batchID *fftypes.UUID
+			held := true
+			for held {
+				var err error
+				batchID, held, err = bp.flush(overflow, timedout)
+				if err != nil {
+					l.Warnf("Batch processor shutting down: %s", err)
+					_ = batchTimeout.Stop()
+					return
+				}
+			}
+			if flushed != nil {
+				flushed <- batchID
+				flushed = nil
 			}
 
 			// If we didn't overflow, then just go back to idle - we don't know if we have more work to come, so
@@ -342,30 +474,33 @@ func (bp *batchProcessor) assemblyLoop() {
 				idle = true
 			}
 		}
+		if flushed != nil {
+			flushed <- nil // there was no work to flush
+		}
 	}
 }
 
-func (bp *batchProcessor) flush(overflow bool) error {
-	id, flushWork, byteSize := bp.startFlush(overflow)
+func (bp *batchProcessor) flush(overflow, timedout bool) (*fftypes.UUID, bool, error) {
+	id, flushWork, byteSize, held := bp.startFlush(overflow)
 	batch := bp.buildFlushBatch(id, flushWork)
 
 	pins, err := bp.persistBatch(batch)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 
 	err = bp.dispatchBatch(batch, pins)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 
 	err = bp.markMessagesDispatched(batch)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 
-	bp.endFlush(batch, byteSize)
-	return nil
+	bp.endFlush(batch, byteSize, timedout)
+	return id, held, nil
 }
 
 func (bp *batchProcessor) buildFlushBatch(id *fftypes.UUID, newWork []*batchWork) *fftypes.Batch {
@@ -382,7 +517,9 @@ func (bp *batchProcessor) buildFlushBatch(id *fftypes.UUID, newWork []*batchWork
 	for _, w := range newWork {
 		if w.msg != nil {
 			w.msg.BatchID = batch.ID
-			w.msg.State = "" // state should always be set by receivers when loading the batch
+			w.msg.State = ""          // state should always be set by receivers when loading the batch
+			w.msg.Expiry = nil        // expiry only applies to the sender, before the message is sent
+			w.msg.IdempotencyKey = "" // idempotency keys are only meaningful to the submitting node
 			batch.Payload.Messages = append(batch.Payload.Messages, w.msg)
 		}
 		batch.Payload.Data = append(batch.Payload.Data, w.data...)
@@ -390,7 +527,7 @@ func (bp *batchProcessor) buildFlushBatch(id *fftypes.UUID, newWork []*batchWork
 	return batch
 }
 
-func (bp *batchProcessor) maskContext(ctx context.Context, msg *fftypes.Message, topic string) (contextOrPin *fftypes.Bytes32, err error) {
+func (bp *batchProcessor) maskContext(ctx context.Context, msg *fftypes.Message, topic string, salt *fftypes.Bytes32) (contextOrPin *fftypes.Bytes32, err error) {
 
 	hashBuilder := sha256.New()
 	hashBuilder.Write([]byte(topic))
@@ -406,6 +543,14 @@ func (bp *batchProcessor) maskContext(ctx context.Context, msg *fftypes.Message,
 	// a salt for the hash as it is not on chain)
 	hashBuilder.Write((*msg.Header.Group)[:])
 
+	// Once the membership of a group has changed, the version of the group is also part of the
+	// context - so each version of the group has a fresh set of nonces for its members
+	if msg.Header.GroupVersion > 0 {
+		versionBytes := make([]byte, 8)
+		binary.BigEndian.PutUint64(versionBytes, uint64(msg.Header.GroupVersion))
+		hashBuilder.Write(versionBytes)
+	}
+
 	// The combination of the topic and group is the context
 	contextHash := fftypes.HashResult(hashBuilder)
 
@@ -421,6 +566,12 @@ func (bp *batchProcessor) maskContext(ctx context.Context, msg *fftypes.Message,
 		return nil, err
 	}
 
+	// The salt from the membership change that created this version of the group is only known to its
+	// members, so members that have been removed cannot calculate the pins
+	if salt != nil {
+		hashBuilder.Write(salt[:])
+	}
+
 	// Now combine our sending identity, and this nonce, to produce the hash that should
 	// be expected by all members of the group as the next nonce from us on this topic.
 	// Note we use our identity DID (not signing key) for this.
@@ -432,12 +583,49 @@ func (bp *batchProcessor) maskContext(ctx context.Context, msg *fftypes.Message,
 	return fftypes.HashResult(hashBuilder), err
 }
 
+// groupVersionSalt returns the salt for the version of the group a private message is sent to, if that
+// version was created by a membership change. The membership change itself is the first message on the
+// new version, and has not been applied yet - so the salt is read from the change in the batch.
+func (bp *batchProcessor) groupVersionSalt(ctx context.Context, batch *fftypes.Batch, msg *fftypes.Message) (*fftypes.Bytes32, error) {
+	if msg.Header.Group == nil || msg.Header.GroupVersion == 0 {
+		return nil, nil
+	}
+	if msg.Header.Tag == string(fftypes.SystemTagChangeGroupMembers) && len(msg.Data) > 0 {
+		for _, d := range batch.Payload.Data {
+			if d.ID.Equals(msg.Data[0].ID) {
+				var def fftypes.GroupMembershipChangeDefinition
+				if err := json.Unmarshal(d.Value.Bytes(), &def); err != nil {
+					return nil, i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
+				}
+				return def.Salt, nil
+			}
+		}
+	}
+	fb := database.GroupMembershipChangeQueryFactory.NewFilterLimit(ctx, 1)
+	changes, _, err := bp.database.GetGroupMembershipChanges(ctx, fb.And(
+		fb.Eq("group", msg.Header.Group),
+		fb.Eq("effectivefrom", msg.Header.GroupVersion),
+	))
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		log.L(ctx).Warnf("Membership change for group %s version %d not found", msg.Header.Group, msg.Header.GroupVersion)
+		return nil, nil
+	}
+	return changes[0].Salt, nil
+}
+
 func (bp *batchProcessor) maskContexts(ctx context.Context, batch *fftypes.Batch) ([]*fftypes.Bytes32, error) {
 	// Calculate the sequence hashes
 	contextsOrPins := make([]*fftypes.Bytes32, 0, len(batch.Payload.Messages))
 	for _, msg := range batch.Payload.Messages {
+		salt, err := bp.groupVersionSalt(ctx, batch, msg)
+		if err != nil {
+			return nil, err
+		}
 		for _, topic := range msg.Header.Topics {
-			contextOrPin, err := bp.maskContext(ctx, msg, topic)
+			contextOrPin, err := bp.maskContext(ctx, msg, topic, salt)
 			if err != nil {
 				return nil, err
 			}
@@ -462,11 +650,12 @@ func (bp *batchProcessor) persistBatch(batch *fftypes.Batch) (contexts []*fftype
 			}
 
 			batch.Payload.TX.Type = bp.conf.txType
-			if batch.Payload.TX.ID, err = bp.txHelper.SubmitNewTransaction(ctx, batch.Namespace, bp.conf.txType); err != nil {
+			if batch.Payload.TX.ID, err = bp.txHelper.SubmitNewTransaction(ctx, batch.Namespace, bp.conf.txType, ""); err != nil {
 				return err
 			}
 
 			batch.Hash = batch.Payload.Hash()
+			batch.Payload.NodeSignature = bp.ni.SignBatchHash(ctx, batch.Hash)
 			log.L(ctx).Debugf("Batch %s sealed. Hash=%s", batch.ID, batch.Hash)
 			return bp.database.UpsertBatch(ctx, batch)
 		})
@@ -482,13 +671,15 @@ func (bp *batchProcessor) dispatchBatch(batch *fftypes.Batch, pins []*fftypes.By
 }
 
 func (bp *batchProcessor) markMessagesDispatched(batch *fftypes.Batch) error {
-	return bp.retry.Do(bp.ctx, "mark dispatched messages", func(attempt int) (retry bool, err error) {
+	// Update all the messages in the batch with the batch ID
+	msgIDs := make([]driver.Value, len(batch.Payload.Messages))
+	changed := make([]*fftypes.UUID, len(batch.Payload.Messages))
+	for i, msg := range batch.Payload.Messages {
+		msgIDs[i] = msg.Header.ID
+		changed[i] = msg.Header.ID
+	}
+	err := bp.retry.Do(bp.ctx, "mark dispatched messages", func(attempt int) (retry bool, err error) {
 		return true, bp.database.RunAsGroup(bp.ctx, func(ctx context.Context) (err error) {
-			// Update all the messages in the batch with the batch ID
-			msgIDs := make([]driver.Value, len(batch.Payload.Messages))
-			for i, msg := range batch.Payload.Messages {
-				msgIDs[i] = msg.Header.ID
-			}
 			fb := database.MessageQueryFactory.NewFilter(ctx)
 			filter := fb.And(
 				fb.In("id", msgIDs),
@@ -526,4 +717,13 @@ func (bp *batchProcessor) markMessagesDispatched(batch *fftypes.Batch) error {
 			return nil
 		})
 	})
+	if err == nil && bp.conf.txType == fftypes.TransactionTypeBatchPin {
+		// The move to sent state has no event of its own, so notify anyone waiting on it once committed
+		bp.eventBus.Publish(eventbus.TopicMessageStateChanged, &eventbus.MessageStateChange{
+			Namespace: batch.Namespace,
+			Messages:  changed,
+			State:     fftypes.MessageStateSent,
+		})
+	}
+	return err
 }
@@ -16,14 +16,18 @@ package batch
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/metricsmocks"
 	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
 	"github.com/hyperledger/firefly/mocks/txcommonmocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -35,7 +39,8 @@ func newTestBatchProcessor(dispatch DispatchHandler) (*databasemocks.Plugin, *ba
 	mdi := &databasemocks.Plugin{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mni.On("GetNodeUUID", mock.Anything).Return(fftypes.NewUUID()).Maybe()
-	bp := newBatchProcessor(context.Background(), mni, mdi, &batchProcessorConf{
+	mni.On("SignBatchHash", mock.Anything, mock.Anything).Return("").Maybe()
+	bp := newBatchProcessor(context.Background(), mni, mdi, eventbus.NewBus(), newTestMetrics(), &batchProcessorConf{
 		namespace: "ns1",
 		txType:    fftypes.TransactionTypeBatchPin,
 		identity:  fftypes.Identity{Author: "did:firefly:org/abcd", Key: "0x12345"},
@@ -78,7 +83,7 @@ func TestUnfilledBatch(t *testing.T) {
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
 
 	mth := bp.txHelper.(*txcommonmocks.Helper)
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 
 	// Dispatch the work
 	go func() {
@@ -100,6 +105,72 @@ func TestUnfilledBatch(t *testing.T) {
 	<-bp.done
 }
 
+func TestMarkMessagesDispatchedPublishesSent(t *testing.T) {
+	mdi, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+	<-bp.done
+
+	changes := make(chan *eventbus.MessageStateChange, 1)
+	bp.eventBus.Subscribe(eventbus.TopicMessageStateChanged, func(payload interface{}) {
+		changes <- payload.(*eventbus.MessageStateChange)
+	})
+
+	mockRunAsGroupPassthrough(mdi)
+	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	msgID := fftypes.NewUUID()
+	err := bp.markMessagesDispatched(&fftypes.Batch{
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{{Header: fftypes.MessageHeader{ID: msgID}}},
+		},
+	})
+	assert.NoError(t, err)
+
+	change := <-changes
+	assert.Equal(t, "ns1", change.Namespace)
+	assert.Equal(t, []*fftypes.UUID{msgID}, change.Messages)
+	assert.Equal(t, fftypes.MessageStateSent, change.State)
+}
+
+func TestBatchSignedByNode(t *testing.T) {
+	log.SetLevel("debug")
+	config.Reset()
+
+	dispatched := make(chan *fftypes.Batch)
+	mdi, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		dispatched <- b
+		return nil
+	})
+	mni := &sysmessagingmocks.LocalNodeInfo{}
+	mni.On("GetNodeUUID", mock.Anything).Return(fftypes.NewUUID())
+	mni.On("SignBatchHash", mock.Anything, mock.Anything).Return("sig1")
+	bp.ni = mni
+
+	mockRunAsGroupPassthrough(mdi)
+	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mth := bp.txHelper.(*txcommonmocks.Helper)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+
+	go func() {
+		bp.newWork <- &batchWork{
+			msg: &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Sequence: 1000},
+		}
+	}()
+
+	batch := <-dispatched
+	assert.Equal(t, "sig1", batch.Payload.NodeSignature)
+	assert.Equal(t, batch.Hash, batch.Payload.Hash())
+	mni.AssertCalled(t, "SignBatchHash", mock.Anything, batch.Hash)
+
+	bp.cancelCtx()
+	<-bp.done
+}
+
 func TestBatchSizeOverflow(t *testing.T) {
 	log.SetLevel("debug")
 	config.Reset()
@@ -116,7 +187,7 @@ func TestBatchSizeOverflow(t *testing.T) {
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
 
 	mth := bp.txHelper.(*txcommonmocks.Helper)
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 
 	// Dispatch the work
 	go func() {
@@ -162,7 +233,7 @@ func TestCloseToUnblockUpsertBatch(t *testing.T) {
 	mockRunAsGroupPassthrough(mdi)
 	waitForCall := make(chan bool)
 	mth := bp.txHelper.(*txcommonmocks.Helper)
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin).
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin, fftypes.IdempotencyKey("")).
 		Run(func(a mock.Arguments) {
 			waitForCall <- true
 			<-waitForCall
@@ -218,6 +289,8 @@ func TestAddWorkInRecentlyFlushed(t *testing.T) {
 	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
 		return nil
 	})
+	bp.cancelCtx()
+	<-bp.done
 	bp.flushedSequences = []int64{100, 500, 400, 900, 200, 700}
 	_, _ = bp.addWork(&batchWork{
 		msg: &fftypes.Message{
@@ -232,6 +305,8 @@ func TestAddWorkInSortDeDup(t *testing.T) {
 	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
 		return nil
 	})
+	bp.cancelCtx()
+	<-bp.done
 	bp.assemblyQueue = []*batchWork{
 		{msg: &fftypes.Message{Sequence: 200}},
 		{msg: &fftypes.Message{Sequence: 201}},
@@ -253,10 +328,31 @@ func TestAddWorkInSortDeDup(t *testing.T) {
 	}, bp.assemblyQueue)
 }
 
+func TestAddWorkHighPriorityFull(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+	<-bp.done
+	full, overflow := bp.addWork(&batchWork{
+		msg: &fftypes.Message{Sequence: 200},
+	})
+	assert.False(t, full)
+	assert.False(t, overflow)
+	full, overflow = bp.addWork(&batchWork{
+		msg: &fftypes.Message{Sequence: 201, Header: fftypes.MessageHeader{Priority: fftypes.MessagePriorityHigh}},
+	})
+	assert.True(t, full)
+	assert.False(t, overflow)
+	assert.Len(t, bp.assemblyQueue, 2)
+}
+
 func TestStartFlushOverflow(t *testing.T) {
 	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
 		return nil
 	})
+	bp.cancelCtx()
+	<-bp.done
 	batchID := fftypes.NewUUID()
 	bp.assemblyID = batchID
 	bp.flushedSequences = []int64{100, 101, 102, 103, 104}
@@ -268,7 +364,8 @@ func TestStartFlushOverflow(t *testing.T) {
 	}
 	bp.conf.BatchMaxSize = 3
 
-	flushBatchID, flushAssembly, _ := bp.startFlush(true)
+	flushBatchID, flushAssembly, _, held := bp.startFlush(true)
+	assert.False(t, held)
 	assert.Equal(t, batchID, flushBatchID)
 	assert.Equal(t, []int64{102, 103, 104, 200, 201, 202}, bp.flushedSequences)
 	assert.Equal(t, []*batchWork{
@@ -282,6 +379,79 @@ func TestStartFlushOverflow(t *testing.T) {
 	assert.NotEqual(t, batchID, bp.assemblyID)
 }
 
+func TestStartFlushIsolatesMembershipChange(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+	<-bp.done
+	changeWork := &batchWork{msg: &fftypes.Message{Sequence: 201, Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagChangeGroupMembers)}}}
+	bp.assemblyQueue = []*batchWork{
+		{msg: &fftypes.Message{Sequence: 200}},
+		changeWork,
+		{msg: &fftypes.Message{Sequence: 202}},
+		{msg: &fftypes.Message{Sequence: 203}},
+	}
+
+	// Work before the change is flushed on its own, with the overflow still held back
+	_, flushAssembly, _, held := bp.startFlush(true)
+	assert.True(t, held)
+	assert.Equal(t, []*batchWork{{msg: &fftypes.Message{Sequence: 200}}}, flushAssembly)
+	assert.Len(t, bp.assemblyQueue, 3)
+
+	// Then the change on its own
+	_, flushAssembly, _, held = bp.startFlush(true)
+	assert.True(t, held)
+	assert.Equal(t, []*batchWork{changeWork}, flushAssembly)
+	assert.Equal(t, []*batchWork{
+		{msg: &fftypes.Message{Sequence: 202}},
+		{msg: &fftypes.Message{Sequence: 203}},
+	}, bp.assemblyQueue)
+
+	// Then the work after the change
+	_, flushAssembly, _, held = bp.startFlush(true)
+	assert.False(t, held)
+	assert.Equal(t, []*batchWork{{msg: &fftypes.Message{Sequence: 202}}}, flushAssembly)
+	assert.Equal(t, []*batchWork{{msg: &fftypes.Message{Sequence: 203}}}, bp.assemblyQueue)
+}
+
+func TestMembershipChangeBatchedAlone(t *testing.T) {
+	log.SetLevel("debug")
+	config.Reset()
+
+	dispatched := make(chan *fftypes.Batch, 3)
+	mdi, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		dispatched <- b
+		return nil
+	})
+
+	mockRunAsGroupPassthrough(mdi)
+	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
+
+	mth := bp.txHelper.(*txcommonmocks.Helper)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+
+	// Queue a membership change between other messages, which are all flushed when the batch times out
+	for i := 0; i < 5; i++ {
+		msg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Sequence: int64(1000 + i)}
+		if i == 2 {
+			msg.Header.Tag = string(fftypes.SystemTagChangeGroupMembers)
+		}
+		bp.newWork <- &batchWork{msg: msg}
+	}
+
+	batch1, batch2, batch3 := <-dispatched, <-dispatched, <-dispatched
+	assert.Len(t, batch1.Payload.Messages, 2)
+	assert.Len(t, batch2.Payload.Messages, 1)
+	assert.Equal(t, string(fftypes.SystemTagChangeGroupMembers), batch2.Payload.Messages[0].Header.Tag)
+	assert.Len(t, batch3.Payload.Messages, 2)
+
+	bp.cancelCtx()
+	<-bp.done
+}
+
 func TestStartQuiesceNonBlocking(t *testing.T) {
 	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
 		return nil
@@ -308,7 +478,7 @@ func TestMarkMessageDispatchedUnpinnedOK(t *testing.T) {
 	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
 
 	mth := bp.txHelper.(*txcommonmocks.Helper)
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeUnpinned).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeUnpinned, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 
 	// Dispatch the work
 	go func() {
@@ -331,3 +501,337 @@ func TestMarkMessageDispatchedUnpinnedOK(t *testing.T) {
 
 	mdi.AssertExpectations(t)
 }
+
+func TestMaskContextGroupVersion(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+	mdi := bp.database.(*databasemocks.Plugin)
+	contexts := []*fftypes.Bytes32{}
+	ugcn := mdi.On("UpsertNonceNext", mock.Anything, mock.Anything).Return(nil)
+	ugcn.RunFn = func(a mock.Arguments) {
+		contexts = append(contexts, a[1].(*fftypes.Nonce).Context)
+	}
+
+	gid := fftypes.NewRandB32()
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Group:    gid,
+			Identity: fftypes.Identity{Author: "did:firefly:org/abcd"},
+		},
+	}
+	pin0, err := bp.maskContext(bp.ctx, msg, "topic1", nil)
+	assert.NoError(t, err)
+
+	msg.Header.GroupVersion = 1
+	pin1, err := bp.maskContext(bp.ctx, msg, "topic1", nil)
+	assert.NoError(t, err)
+
+	// Each version of the group is a separate context, with its own nonces
+	assert.Len(t, contexts, 2)
+	assert.NotEqual(t, *contexts[0], *contexts[1])
+	assert.NotEqual(t, *pin0, *pin1)
+
+	h := sha256.New()
+	h.Write([]byte("topic1"))
+	h.Write((*gid)[:])
+	h.Write([]byte{0, 0, 0, 0, 0, 0, 0, 1})
+	assert.Equal(t, *fftypes.HashResult(h), *contexts[1])
+
+	<-bp.done
+	mdi.AssertExpectations(t)
+}
+
+func TestMaskContextsGroupVersionSalt(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+	mdi := bp.database.(*databasemocks.Plugin)
+	mdi.On("UpsertNonceNext", mock.Anything, mock.Anything).Return(nil)
+
+	gid := fftypes.NewRandB32()
+	salt1 := fftypes.NewRandB32()
+	salt2 := fftypes.NewRandB32()
+	mdi.On("GetGroupMembershipChanges", mock.Anything, mock.Anything).Return([]*fftypes.GroupMembershipChange{
+		{Group: gid, EffectiveFrom: 1, Salt: salt1},
+	}, nil, nil)
+
+	change := &fftypes.GroupMembershipChange{Group: gid, EffectiveFrom: 2, Salt: salt2}
+	b, _ := json.Marshal(change.Definition())
+	changeData := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtrBytes(b)}
+	newMsg := func(version int64) *fftypes.Message {
+		return &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Group:        gid,
+				GroupVersion: version,
+				Identity:     fftypes.Identity{Author: "did:firefly:org/abcd"},
+				Topics:       fftypes.FFStringArray{"topic1"},
+			},
+		}
+	}
+	changeMsg := newMsg(2)
+	changeMsg.Header.Tag = string(fftypes.SystemTagChangeGroupMembers)
+	changeMsg.Data = fftypes.DataRefs{{ID: changeData.ID}}
+	batch := &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{newMsg(1), changeMsg},
+			Data:     []*fftypes.Data{{ID: fftypes.NewUUID()}, changeData},
+		},
+	}
+
+	pins, err := bp.maskContexts(bp.ctx, batch)
+	assert.NoError(t, err)
+	assert.Len(t, pins, 2)
+
+	// The salt is mixed in after the context, and before the author and nonce
+	unsalted, err := bp.maskContext(bp.ctx, newMsg(1), "topic1", nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, *unsalted, *pins[0])
+	salted, err := bp.maskContext(bp.ctx, newMsg(1), "topic1", salt1)
+	assert.NoError(t, err)
+	assert.Equal(t, *salted, *pins[0])
+	salted, err = bp.maskContext(bp.ctx, newMsg(2), "topic1", salt2)
+	assert.NoError(t, err)
+	assert.Equal(t, *salted, *pins[1])
+
+	<-bp.done
+	mdi.AssertExpectations(t)
+}
+
+func TestMaskContextsGroupVersionSaltBadChange(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+
+	changeData := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr(`!json`)}
+	batch := &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{{
+				Header: fftypes.MessageHeader{
+					Group:        fftypes.NewRandB32(),
+					GroupVersion: 1,
+					Tag:          string(fftypes.SystemTagChangeGroupMembers),
+					Topics:       fftypes.FFStringArray{"topic1"},
+				},
+				Data: fftypes.DataRefs{{ID: changeData.ID}},
+			}},
+			Data: []*fftypes.Data{changeData},
+		},
+	}
+
+	_, err := bp.maskContexts(bp.ctx, batch)
+	assert.Regexp(t, "FF10137", err)
+	<-bp.done
+}
+
+func TestMaskContextsGroupVersionSaltLookupFail(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+	mdi := bp.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupMembershipChanges", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	batch := &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{{
+				Header: fftypes.MessageHeader{
+					Group:        fftypes.NewRandB32(),
+					GroupVersion: 1,
+					Topics:       fftypes.FFStringArray{"topic1"},
+				},
+			}},
+		},
+	}
+
+	_, err := bp.maskContexts(bp.ctx, batch)
+	assert.EqualError(t, err, "pop")
+	<-bp.done
+}
+
+func TestMaskContextsGroupVersionSaltNotFound(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+	mdi := bp.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupMembershipChanges", mock.Anything, mock.Anything).Return([]*fftypes.GroupMembershipChange{}, nil, nil)
+	mdi.On("UpsertNonceNext", mock.Anything, mock.Anything).Return(nil)
+
+	batch := &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{{
+				Header: fftypes.MessageHeader{
+					Group:        fftypes.NewRandB32(),
+					GroupVersion: 1,
+					Topics:       fftypes.FFStringArray{"topic1"},
+				},
+			}},
+		},
+	}
+
+	pins, err := bp.maskContexts(bp.ctx, batch)
+	assert.NoError(t, err)
+	assert.Len(t, pins, 1)
+	<-bp.done
+}
+
+func TestAdaptiveBatchFlushesAtTarget(t *testing.T) {
+	log.SetLevel("debug")
+	config.Reset()
+
+	dispatched := make(chan *fftypes.Batch)
+	mdi, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		dispatched <- b
+		return nil
+	})
+	bp.conf.adaptiveMinSize = 2
+	bp.flushStatus.TargetBatchSize = 2
+	bp.conf.BatchTimeout = 1 * time.Minute
+	mmi := &metricsmocks.Manager{}
+	mmi.On("IsMetricsEnabled").Return(true)
+	mmi.On("BatchFlushed", "ns1", "", 2, uint(4)).Return()
+	bp.metrics = mmi
+
+	mockRunAsGroupPassthrough(mdi)
+	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mth := bp.txHelper.(*txcommonmocks.Helper)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			bp.newWork <- &batchWork{
+				msg: &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Sequence: int64(1000 + i)},
+			}
+		}
+	}()
+
+	// The batch is flushed when it reaches the target, without waiting for the timeout
+	batch := <-dispatched
+	assert.Equal(t, 2, len(batch.Payload.Messages))
+
+	bp.cancelCtx()
+	<-bp.done
+
+	assert.Equal(t, uint(4), bp.status().Status.TargetBatchSize)
+	mmi.AssertExpectations(t)
+}
+
+func TestAdaptBatchSize(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.cancelCtx()
+	<-bp.done
+	bp.conf.adaptiveMinSize = 2
+	bp.flushStatus.TargetBatchSize = 2
+
+	bp.adaptBatchSize(2, false)
+	assert.Equal(t, uint(4), bp.batchSizeTarget())
+	bp.adaptBatchSize(4, false)
+	assert.Equal(t, uint(8), bp.batchSizeTarget())
+	bp.adaptBatchSize(8, false)
+	assert.Equal(t, uint(10), bp.batchSizeTarget())
+	bp.adaptBatchSize(10, false)
+	assert.Equal(t, uint(10), bp.batchSizeTarget())
+	bp.adaptBatchSize(3, false)
+	assert.Equal(t, uint(10), bp.batchSizeTarget())
+	bp.adaptBatchSize(3, true)
+	assert.Equal(t, uint(5), bp.batchSizeTarget())
+	bp.adaptBatchSize(1, true)
+	assert.Equal(t, uint(2), bp.batchSizeTarget())
+	bp.adaptBatchSize(1, true)
+	assert.Equal(t, uint(2), bp.batchSizeTarget())
+
+	bp.conf.adaptiveMinSize = 3
+	bp.flushStatus.TargetBatchSize = 4
+	bp.adaptBatchSize(1, true)
+	assert.Equal(t, uint(3), bp.batchSizeTarget())
+}
+
+func TestAdaptiveMinSizeLimitedToMaxSize(t *testing.T) {
+	bp := newBatchProcessor(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &databasemocks.Plugin{}, eventbus.NewBus(), newTestMetrics(), &batchProcessorConf{
+		DispatcherOptions: DispatcherOptions{
+			BatchMaxSize:   10,
+			DisposeTimeout: 1 * time.Minute,
+		},
+		adaptiveMinSize: 20,
+	}, &retry.Retry{})
+	bp.cancelCtx()
+	<-bp.done
+	assert.Equal(t, uint(10), bp.batchSizeTarget())
+}
+
+func TestRequestFlush(t *testing.T) {
+	log.SetLevel("debug")
+	config.Reset()
+
+	dispatched := make(chan *fftypes.Batch, 1)
+	mdi, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		dispatched <- b
+		return nil
+	})
+	bp.conf.BatchTimeout = 1 * time.Minute
+
+	mockRunAsGroupPassthrough(mdi)
+	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mth := bp.txHelper.(*txcommonmocks.Helper)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeBatchPin, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+
+	// Nothing to flush
+	batchID, err := bp.requestFlush(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, batchID)
+
+	bp.newWork <- &batchWork{
+		msg: &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Sequence: 1000},
+	}
+	// The work might not have been added to the assembly yet when we first ask
+	for batchID == nil {
+		batchID, err = bp.requestFlush(context.Background())
+		assert.NoError(t, err)
+	}
+	batch := <-dispatched
+	assert.Equal(t, batch.ID, batchID)
+
+	bp.cancelCtx()
+	<-bp.done
+
+	batchID, err = bp.requestFlush(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, batchID)
+}
+
+func TestRequestFlushCancelled(t *testing.T) {
+	bp := &batchProcessor{
+		flushRequests: make(chan chan *fftypes.UUID),
+		done:          make(chan struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := bp.requestFlush(ctx)
+	assert.Regexp(t, "FF10158", err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		<-bp.flushRequests
+		cancel()
+	}()
+	_, err = bp.requestFlush(ctx)
+	assert.Regexp(t, "FF10158", err)
+
+	go func() {
+		<-bp.flushRequests
+		close(bp.done)
+	}()
+	batchID, err := bp.requestFlush(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, batchID)
+}
@@ -0,0 +1,160 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// namespaceOverrides are read from the "batch" section of a predefined namespace, and override the options
+// of every dispatcher for the messages in that namespace. Zero values are not overridden.
+// The "message" section provides defaults for the messages sent in the namespace, that are applied as the
+// messages are submitted for batching.
+type namespaceOverrides struct {
+	size          uint
+	timeout       time.Duration
+	payloadLimit  int64
+	messageExpiry time.Duration
+	messageTxType fftypes.TransactionType
+}
+
+// normalizeSection round trips a config section through JSON, so numbers are handled consistently whatever
+// the format of the config file
+func normalizeSection(section fftypes.JSONObject) fftypes.JSONObject {
+	var conf fftypes.JSONObject
+	b, _ := json.Marshal(section)
+	_ = json.Unmarshal(b, &conf)
+	return conf
+}
+
+func parseNamespaceOverrides(ctx context.Context, nsObject fftypes.JSONObject) (*namespaceOverrides, error) {
+	overrides := &namespaceOverrides{}
+	name := nsObject.GetString("name")
+	if batchObject, ok := nsObject.GetObjectOk("batch"); ok {
+		if err := overrides.parseBatch(ctx, name, normalizeSection(batchObject)); err != nil {
+			return nil, err
+		}
+	}
+	if messageObject, ok := nsObject.GetObjectOk("message"); ok {
+		if err := overrides.parseMessage(ctx, name, normalizeSection(messageObject)); err != nil {
+			return nil, err
+		}
+	}
+	return overrides, nil
+}
+
+func (overrides *namespaceOverrides) parseBatch(ctx context.Context, name string, conf fftypes.JSONObject) error {
+	if s, ok := conf.GetStringOk("size"); ok {
+		size, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || size == 0 {
+			return i18n.NewError(ctx, i18n.MsgInvalidNamespaceBatchConfig, name, "size")
+		}
+		overrides.size = uint(size)
+	}
+	if s, ok := conf.GetStringOk("timeout"); ok {
+		timeout, err := fftypes.ParseDurationString(s, time.Millisecond)
+		if err != nil || timeout <= 0 {
+			return i18n.NewError(ctx, i18n.MsgInvalidNamespaceBatchConfig, name, "timeout")
+		}
+		overrides.timeout = time.Duration(timeout)
+	}
+	if s, ok := conf.GetStringOk("payloadLimit"); ok {
+		overrides.payloadLimit = fftypes.ParseToByteSize(s)
+		if overrides.payloadLimit <= 0 {
+			return i18n.NewError(ctx, i18n.MsgInvalidNamespaceBatchConfig, name, "payloadLimit")
+		}
+	}
+	return nil
+}
+
+func (overrides *namespaceOverrides) parseMessage(ctx context.Context, name string, conf fftypes.JSONObject) error {
+	if s, ok := conf.GetStringOk("expiry"); ok {
+		expiry, err := fftypes.ParseDurationString(s, time.Millisecond)
+		if err != nil || expiry <= 0 {
+			return i18n.NewError(ctx, i18n.MsgInvalidNamespaceMsgConfig, name, "expiry")
+		}
+		overrides.messageExpiry = time.Duration(expiry)
+	}
+	if s, ok := conf.GetStringOk("txType"); ok {
+		switch txType := fftypes.TransactionType(s).Lower(); txType {
+		case fftypes.TransactionTypeBatchPin, fftypes.TransactionTypeUnpinned:
+			overrides.messageTxType = txType
+		default:
+			return i18n.NewError(ctx, i18n.MsgInvalidNamespaceMsgConfig, name, "txType")
+		}
+	}
+	return nil
+}
+
+// validateNamespaceOverrides checks the batch configuration of all the predefined namespaces at startup,
+// so the overrides can be applied later without any error handling
+func validateNamespaceOverrides(ctx context.Context) error {
+	for _, nsObject := range config.GetObjectArray(config.NamespacesPredefined) {
+		if _, err := parseNamespaceOverrides(ctx, nsObject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NamespaceOptions applies any batch configuration overrides of a predefined namespace to the options of a dispatcher
+func NamespaceOptions(ns string, options DispatcherOptions) DispatcherOptions {
+	overrides, err := parseNamespaceOverrides(context.Background(), config.GetPredefinedNamespace(ns))
+	if err != nil {
+		return options
+	}
+	if overrides.size > 0 {
+		options.BatchMaxSize = overrides.size
+	}
+	if overrides.timeout > 0 {
+		options.BatchTimeout = overrides.timeout
+	}
+	if overrides.payloadLimit > 0 {
+		options.BatchMaxBytes = overrides.payloadLimit
+	}
+	return options
+}
+
+// NamespacePayloadLimit returns the maximum payload size of a batch in the namespace, for checking the size
+// of messages before they are sent
+func NamespacePayloadLimit(ns string, defaultLimit int64) int64 {
+	return NamespaceOptions(ns, DispatcherOptions{BatchMaxBytes: defaultLimit}).BatchMaxBytes
+}
+
+// NamespaceMessageDefaults applies the message defaults of a predefined namespace to a message being sent,
+// for any fields not set by the application. The transaction type default is only used for private messages,
+// as broadcasts are always pinned.
+func NamespaceMessageDefaults(ns string, msg *fftypes.Message) {
+	overrides, err := parseNamespaceOverrides(context.Background(), config.GetPredefinedNamespace(ns))
+	if err != nil {
+		return
+	}
+	if msg.Expiry == nil && overrides.messageExpiry > 0 {
+		expiry := fftypes.FFTime(time.Now().Add(overrides.messageExpiry))
+		msg.Expiry = &expiry
+	}
+	if msg.Header.TxType == "" && overrides.messageTxType != "" {
+		msg.Header.TxType = overrides.messageTxType
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+var testDispatcherOptions = DispatcherOptions{
+	BatchMaxSize:   200,
+	BatchMaxBytes:  1024 * 1024,
+	BatchTimeout:   500 * time.Millisecond,
+	DisposeTimeout: 2 * time.Minute,
+}
+
+func TestNamespaceOptions(t *testing.T) {
+	config.Reset()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "telemetry", "batch": map[string]interface{}{"size": 1000, "timeout": "100ms", "payloadLimit": "2mb"}},
+		{"name": "contracts", "batch": map[string]interface{}{"timeout": 5000}},
+		{"name": "default"},
+	})
+	assert.NoError(t, validateNamespaceOverrides(context.Background()))
+
+	assert.Equal(t, DispatcherOptions{
+		BatchMaxSize:   1000,
+		BatchMaxBytes:  2 * 1024 * 1024,
+		BatchTimeout:   100 * time.Millisecond,
+		DisposeTimeout: 2 * time.Minute,
+	}, NamespaceOptions("telemetry", testDispatcherOptions))
+
+	contractsOptions := testDispatcherOptions
+	contractsOptions.BatchTimeout = 5 * time.Second
+	assert.Equal(t, contractsOptions, NamespaceOptions("contracts", testDispatcherOptions))
+
+	assert.Equal(t, testDispatcherOptions, NamespaceOptions("default", testDispatcherOptions))
+	assert.Equal(t, testDispatcherOptions, NamespaceOptions("unknown", testDispatcherOptions))
+
+	assert.Equal(t, int64(2*1024*1024), NamespacePayloadLimit("telemetry", 1000))
+	assert.Equal(t, int64(1000), NamespacePayloadLimit("contracts", 1000))
+}
+
+func TestNamespaceOptionsInvalid(t *testing.T) {
+	for _, batchConf := range []map[string]interface{}{
+		{"size": 0},
+		{"size": "lots"},
+		{"timeout": "soon"},
+		{"timeout": "0s"},
+		{"payloadLimit": "huge"},
+	} {
+		config.Reset()
+		config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+			{"name": "ns1", "batch": batchConf},
+		})
+		err := validateNamespaceOverrides(context.Background())
+		assert.Regexp(t, "FF10450.*ns1", err)
+		assert.Equal(t, testDispatcherOptions, NamespaceOptions("ns1", testDispatcherOptions))
+	}
+}
+
+func TestNamespaceMessageDefaults(t *testing.T) {
+	config.Reset()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "telemetry", "message": map[string]interface{}{"expiry": "1h", "txType": "Unpinned"}},
+		{"name": "default"},
+	})
+	assert.NoError(t, validateNamespaceOverrides(context.Background()))
+
+	msg := &fftypes.Message{}
+	NamespaceMessageDefaults("telemetry", msg)
+	assert.Equal(t, fftypes.TransactionTypeUnpinned, msg.Header.TxType)
+	assert.True(t, msg.Expiry.Time().After(time.Now().Add(59*time.Minute)))
+
+	// Values set by the application are not overridden
+	expiry := fftypes.Now()
+	msg = &fftypes.Message{Header: fftypes.MessageHeader{TxType: fftypes.TransactionTypeBatchPin}, Expiry: expiry}
+	NamespaceMessageDefaults("telemetry", msg)
+	assert.Equal(t, fftypes.TransactionTypeBatchPin, msg.Header.TxType)
+	assert.Equal(t, expiry, msg.Expiry)
+
+	msg = &fftypes.Message{}
+	NamespaceMessageDefaults("default", msg)
+	assert.Empty(t, msg.Header.TxType)
+	assert.Nil(t, msg.Expiry)
+}
+
+func TestNamespaceMessageDefaultsInvalid(t *testing.T) {
+	for _, messageConf := range []map[string]interface{}{
+		{"expiry": "never"},
+		{"expiry": "0s"},
+		{"txType": "token_pool"},
+	} {
+		config.Reset()
+		config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+			{"name": "ns1", "message": messageConf},
+		})
+		err := validateNamespaceOverrides(context.Background())
+		assert.Regexp(t, "FF10464.*ns1", err)
+		msg := &fftypes.Message{}
+		NamespaceMessageDefaults("ns1", msg)
+		assert.Nil(t, msg.Expiry)
+	}
+}
+
+func TestInitFailBadNamespaceBatchConfig(t *testing.T) {
+	config.Reset()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "ns1", "batch": map[string]interface{}{"size": -1}},
+	})
+	_, err := NewBatchManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &databasemocks.Plugin{}, &datamocks.Manager{}, eventbus.NewBus(), newTestMetrics())
+	assert.Regexp(t, "FF10450", err)
+}
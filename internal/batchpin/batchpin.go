@@ -18,56 +18,139 @@ package batchpin
 
 import (
 	"context"
+	"time"
 
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/tracing"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Submitter interface {
 	SubmitPinnedBatch(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error
+
+	// EnsurePrivacyGroup establishes the privacy group of the member nodes of a group on the ledger of the namespace, when
+	// the pins of private batches are submitted as private transactions. Returns false if the pins are submitted publicly
+	EnsurePrivacyGroup(ctx context.Context, ns string, group *fftypes.Bytes32) (bool, error)
 }
 
 type batchPinSubmitter struct {
 	database   database.Plugin
 	identity   identity.Manager
 	blockchain blockchain.Plugin
+	nsLedgers  map[string]blockchain.Plugin
 	metrics    metrics.Manager
+	readOnly   bool
 }
 
-func NewBatchPinSubmitter(di database.Plugin, im identity.Manager, bi blockchain.Plugin, mm metrics.Manager) Submitter {
+// NewBatchPinSubmitter creates a submitter that pins batches to the default ledger, unless the namespace of the
+// batch is routed to the plugin of another ledger in nsLedgers
+func NewBatchPinSubmitter(di database.Plugin, im identity.Manager, bi blockchain.Plugin, nsLedgers map[string]blockchain.Plugin, mm metrics.Manager) Submitter {
 	return &batchPinSubmitter{
 		database:   di,
 		identity:   im,
 		blockchain: bi,
+		nsLedgers:  nsLedgers,
 		metrics:    mm,
+		readOnly:   config.GetBool(config.NodeReadOnly),
 	}
 }
 
-func (bp *batchPinSubmitter) SubmitPinnedBatch(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
+func (bp *batchPinSubmitter) ledger(ns string) blockchain.Plugin {
+	if nsLedger, ok := bp.nsLedgers[ns]; ok {
+		return nsLedger
+	}
+	return bp.blockchain
+}
+
+func (bp *batchPinSubmitter) EnsurePrivacyGroup(ctx context.Context, ns string, groupHash *fftypes.Bytes32) (bool, error) {
+	bi := bp.ledger(ns)
+	if bi.PrivacyKey() == "" {
+		return false, nil
+	}
+
+	group, err := bp.database.GetGroupByHash(ctx, groupHash)
+	if err != nil {
+		return false, err
+	}
+	if group == nil {
+		return false, i18n.NewError(ctx, i18n.MsgGroupNotFound, groupHash)
+	}
+	privacyKeys := make([]string, 0, len(group.Members))
+	for _, member := range group.Members {
+		node, err := bp.database.GetNodeByID(ctx, member.Node)
+		if err != nil {
+			return false, err
+		}
+		if node == nil {
+			return false, i18n.NewError(ctx, i18n.MsgNodeNotFound, member.Node)
+		}
+		if node.PrivacyKey == "" {
+			return false, i18n.NewError(ctx, i18n.MsgNodeNoPrivacyKey, node.Name)
+		}
+		privacyKeys = append(privacyKeys, node.PrivacyKey)
+	}
+	return true, bi.EnsurePrivacyGroup(ctx, groupHash, privacyKeys)
+}
+
+func (bp *batchPinSubmitter) SubmitPinnedBatch(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) (err error) {
+	// Every broadcast and pinned private batch is submitted here, so this is where read-only mode is enforced
+	if bp.readOnly {
+		return i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+	}
+
+	bi := bp.ledger(batch.Namespace)
+
+	// The batch is linked to the traces of each of the messages it pins
+	msgIDs := make([]*fftypes.UUID, len(batch.Payload.Messages))
+	for i, msg := range batch.Payload.Messages {
+		msgIDs[i] = msg.Header.ID
+	}
+	ctx, span := tracing.StartLinkedSpan(ctx, "batchpin.SubmitPinnedBatch", tracing.MessageLinks(msgIDs...),
+		attribute.String("batch.id", batch.ID.String()),
+		attribute.Int("batch.messages", len(msgIDs)),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
 
 	// The pending blockchain transaction
 	op := fftypes.NewOperation(
-		bp.blockchain,
+		bi,
 		batch.Namespace,
 		batch.Payload.TX.ID,
 		fftypes.OpTypeBlockchainBatchPin)
+	fee, err := bi.ResolveTransactionFee(ctx, batch.Namespace)
+	if err != nil {
+		return err
+	}
+	txcommon.AddTransactionFeeInput(op, fee)
 	if err := bp.database.InsertOperation(ctx, op); err != nil {
 		return err
 	}
 
 	if bp.metrics.IsMetricsEnabled() {
-		bp.metrics.CountBatchPin()
+		bp.metrics.CountBatchPin(batch.Namespace)
 	}
-	// Write the batch pin to the blockchain
-	return bp.blockchain.SubmitBatchPin(ctx, op.ID, nil /* TODO: ledger selection */, batch.Key, &blockchain.BatchPin{
+	// Write the batch pin to the blockchain. The ledger is selected by the plugin instance for the namespace,
+	// so no ledger ID is passed.
+	startTime := time.Now()
+	err = bi.SubmitBatchPin(ctx, op.ID, nil, batch.Key, &blockchain.BatchPin{
 		Namespace:       batch.Namespace,
 		TransactionID:   batch.Payload.TX.ID,
 		BatchID:         batch.ID,
 		BatchHash:       batch.Hash,
 		BatchPayloadRef: batch.PayloadRef,
+		Group:           batch.Group,
+		Fee:             fee,
 		Contexts:        contexts,
 	})
+	if bp.metrics.IsMetricsEnabled() {
+		bp.metrics.BlockchainSubmitted(bi.Name(), batch.Namespace, time.Since(startTime), err)
+	}
+	return err
 }
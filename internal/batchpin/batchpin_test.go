@@ -26,6 +26,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/metricsmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -40,22 +41,37 @@ func newTestBatchPinSubmitter(t *testing.T, enableMetrics bool) *batchPinSubmitt
 	mmi := &metricsmocks.Manager{}
 	mmi.On("IsMetricsEnabled").Return(enableMetrics)
 	if enableMetrics {
-		mmi.On("CountBatchPin").Return()
+		mmi.On("CountBatchPin", mock.Anything).Return()
 	}
 	mbi.On("Name").Return("ut").Maybe()
-	bps := NewBatchPinSubmitter(mdi, mim, mbi, mmi).(*batchPinSubmitter)
+	bps := NewBatchPinSubmitter(mdi, mim, mbi, nil, mmi).(*batchPinSubmitter)
 	return bps
 }
 
+type testContextKey struct{}
+
+// newTestContext returns a context that can be told apart from the contexts of other tests
+func newTestContext() context.Context {
+	return context.WithValue(context.Background(), testContextKey{}, fftypes.NewUUID())
+}
+
+// spanOf matches the context of the span the submitter starts for the batch, which is derived from the context of the call
+func spanOf(ctx context.Context) interface{} {
+	return mock.MatchedBy(func(spanCtx context.Context) bool {
+		return spanCtx != ctx && spanCtx.Value(testContextKey{}) == ctx.Value(testContextKey{})
+	})
+}
+
 func TestSubmitPinnedBatchOk(t *testing.T) {
 	bp := newTestBatchPinSubmitter(t, false)
-	ctx := context.Background()
+	ctx := newTestContext()
 
 	mbi := bp.blockchain.(*blockchainmocks.Plugin)
 	mdi := bp.database.(*databasemocks.Plugin)
 
 	batch := &fftypes.Batch{
-		ID: fftypes.NewUUID(),
+		ID:    fftypes.NewUUID(),
+		Group: fftypes.NewRandB32(),
 		Identity: fftypes.Identity{
 			Author: "id1",
 			Key:    "0x12345",
@@ -67,29 +83,78 @@ func TestSubmitPinnedBatchOk(t *testing.T) {
 		},
 	}
 	contexts := []*fftypes.Bytes32{}
+	fee := &fftypes.TransactionFee{
+		Policy:   fftypes.FeePolicyTypeFixed,
+		GasPrice: fftypes.NewFFBigInt(100),
+	}
 
-	mdi.On("InsertOperation", ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+	mbi.On("ResolveTransactionFee", spanOf(ctx), "").Return(fee, nil)
+	mdi.On("InsertOperation", spanOf(ctx), mock.MatchedBy(func(op *fftypes.Operation) bool {
 		assert.Equal(t, fftypes.OpTypeBlockchainBatchPin, op.Type)
 		assert.Equal(t, "ut", op.Plugin)
 		assert.Equal(t, *batch.Payload.TX.ID, *op.Transaction)
+		assert.Equal(t, fee, op.Input["fee"])
 		return true
 	})).Return(nil)
-	mbi.On("SubmitBatchPin", ctx, mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.Anything).Return(nil)
+	mbi.On("SubmitBatchPin", spanOf(ctx), mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.MatchedBy(func(pin *blockchain.BatchPin) bool {
+		return pin.Group == batch.Group && pin.Fee == fee
+	})).Return(nil)
 	mmi := bp.metrics.(*metricsmocks.Manager)
 	mmi.On("IsMetricsEnabled").Return(false)
 	err := bp.SubmitPinnedBatch(ctx, batch, contexts)
 	assert.NoError(t, err)
 }
 
+func TestSubmitPinnedBatchNamespaceLedger(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	ctx := newTestContext()
+
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mbi2 := &blockchainmocks.Plugin{}
+	mbi2.On("Name").Return("ut2")
+	bp.nsLedgers = map[string]blockchain.Plugin{"ns2": mbi2}
+	mdi := bp.database.(*databasemocks.Plugin)
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns2",
+		Identity: fftypes.Identity{
+			Author: "id1",
+			Key:    "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+	}
+	contexts := []*fftypes.Bytes32{}
+
+	mbi2.On("ResolveTransactionFee", spanOf(ctx), "ns2").Return(nil, nil)
+	mdi.On("InsertOperation", spanOf(ctx), mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Plugin == "ut2" && op.Input == nil
+	})).Return(nil)
+	mbi2.On("SubmitBatchPin", spanOf(ctx), mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.MatchedBy(func(pin *blockchain.BatchPin) bool {
+		return pin.Namespace == "ns2"
+	})).Return(nil)
+	err := bp.SubmitPinnedBatch(ctx, batch, contexts)
+	assert.NoError(t, err)
+
+	mbi.AssertNotCalled(t, "SubmitBatchPin", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mbi2.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
 func TestSubmitPinnedBatchWithMetricsOk(t *testing.T) {
 	bp := newTestBatchPinSubmitter(t, true)
-	ctx := context.Background()
+	ctx := newTestContext()
 
 	mbi := bp.blockchain.(*blockchainmocks.Plugin)
 	mdi := bp.database.(*databasemocks.Plugin)
 	mmi := bp.metrics.(*metricsmocks.Manager)
 	batch := &fftypes.Batch{
-		ID: fftypes.NewUUID(),
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
 		Identity: fftypes.Identity{
 			Author: "id1",
 			Key:    "0x12345",
@@ -102,15 +167,17 @@ func TestSubmitPinnedBatchWithMetricsOk(t *testing.T) {
 	}
 	contexts := []*fftypes.Bytes32{}
 
-	mdi.On("InsertOperation", ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+	mbi.On("ResolveTransactionFee", spanOf(ctx), "ns1").Return(nil, nil)
+	mdi.On("InsertOperation", spanOf(ctx), mock.MatchedBy(func(op *fftypes.Operation) bool {
 		assert.Equal(t, fftypes.OpTypeBlockchainBatchPin, op.Type)
 		assert.Equal(t, "ut", op.Plugin)
 		assert.Equal(t, *batch.Payload.TX.ID, *op.Transaction)
 		return true
 	})).Return(nil)
-	mbi.On("SubmitBatchPin", ctx, mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.Anything).Return(nil)
+	mbi.On("SubmitBatchPin", spanOf(ctx), mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.Anything).Return(nil)
 	mmi.On("IsMetricsEnabled").Return(true)
 	mmi.On("BatchPinCounter").Return()
+	mmi.On("BlockchainSubmitted", "ut", "ns1", mock.Anything, nil).Return()
 
 	err := bp.SubmitPinnedBatch(ctx, batch, contexts)
 	assert.NoError(t, err)
@@ -118,7 +185,7 @@ func TestSubmitPinnedBatchWithMetricsOk(t *testing.T) {
 
 func TestSubmitPinnedBatchOpFail(t *testing.T) {
 	bp := newTestBatchPinSubmitter(t, false)
-	ctx := context.Background()
+	ctx := newTestContext()
 
 	mdi := bp.database.(*databasemocks.Plugin)
 	mmi := bp.metrics.(*metricsmocks.Manager)
@@ -137,9 +204,169 @@ func TestSubmitPinnedBatchOpFail(t *testing.T) {
 	}
 	contexts := []*fftypes.Bytes32{}
 
-	mdi.On("InsertOperation", ctx, mock.Anything).Return(fmt.Errorf("pop"))
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveTransactionFee", spanOf(ctx), "").Return(nil, nil)
+	mdi.On("InsertOperation", spanOf(ctx), mock.Anything).Return(fmt.Errorf("pop"))
 	mmi.On("IsMetricsEnabled").Return(false)
 	err := bp.SubmitPinnedBatch(ctx, batch, contexts)
 	assert.Regexp(t, "pop", err)
 
 }
+
+func TestSubmitPinnedBatchFeeFail(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	ctx := newTestContext()
+
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+	}
+
+	mbi.On("ResolveTransactionFee", spanOf(ctx), "ns1").Return(nil, fmt.Errorf("pop"))
+	err := bp.SubmitPinnedBatch(ctx, batch, []*fftypes.Bytes32{})
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestEnsurePrivacyGroupNotPrivate(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("PrivacyKey").Return("")
+
+	private, err := bp.EnsurePrivacyGroup(context.Background(), "ns1", fftypes.NewRandB32())
+	assert.NoError(t, err)
+	assert.False(t, private)
+}
+
+func TestEnsurePrivacyGroupOk(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mdi := bp.database.(*databasemocks.Plugin)
+
+	groupHash := fftypes.NewRandB32()
+	node1 := &fftypes.Node{ID: fftypes.NewUUID(), Name: "node1", PrivacyKey: "key1"}
+	node2 := &fftypes.Node{ID: fftypes.NewUUID(), Name: "node2", PrivacyKey: "key2"}
+	mbi.On("PrivacyKey").Return("key1")
+	mdi.On("GetGroupByHash", mock.Anything, groupHash).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: fftypes.Members{{Node: node1.ID}, {Node: node2.ID}},
+		},
+	}, nil)
+	mdi.On("GetNodeByID", mock.Anything, node1.ID).Return(node1, nil)
+	mdi.On("GetNodeByID", mock.Anything, node2.ID).Return(node2, nil)
+	mbi.On("EnsurePrivacyGroup", mock.Anything, groupHash, []string{"key1", "key2"}).Return(nil)
+
+	private, err := bp.EnsurePrivacyGroup(context.Background(), "ns1", groupHash)
+	assert.NoError(t, err)
+	assert.True(t, private)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestEnsurePrivacyGroupNamespaceLedger(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mbi2 := &blockchainmocks.Plugin{}
+	mbi2.On("PrivacyKey").Return("")
+	bp.nsLedgers = map[string]blockchain.Plugin{"ns2": mbi2}
+
+	private, err := bp.EnsurePrivacyGroup(context.Background(), "ns2", fftypes.NewRandB32())
+	assert.NoError(t, err)
+	assert.False(t, private)
+
+	mbi.AssertNotCalled(t, "PrivacyKey")
+	mbi2.AssertExpectations(t)
+}
+
+func TestEnsurePrivacyGroupGetGroupFail(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mdi := bp.database.(*databasemocks.Plugin)
+	mbi.On("PrivacyKey").Return("key1")
+	mdi.On("GetGroupByHash", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := bp.EnsurePrivacyGroup(context.Background(), "ns1", fftypes.NewRandB32())
+	assert.Regexp(t, "pop", err)
+}
+
+func TestEnsurePrivacyGroupGroupNotFound(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mdi := bp.database.(*databasemocks.Plugin)
+	mbi.On("PrivacyKey").Return("key1")
+	mdi.On("GetGroupByHash", mock.Anything, mock.Anything).Return(nil, nil)
+
+	_, err := bp.EnsurePrivacyGroup(context.Background(), "ns1", fftypes.NewRandB32())
+	assert.Regexp(t, "FF10226", err)
+}
+
+func TestEnsurePrivacyGroupGetNodeFail(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mdi := bp.database.(*databasemocks.Plugin)
+	mbi.On("PrivacyKey").Return("key1")
+	mdi.On("GetGroupByHash", mock.Anything, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: fftypes.Members{{Node: fftypes.NewUUID()}},
+		},
+	}, nil)
+	mdi.On("GetNodeByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := bp.EnsurePrivacyGroup(context.Background(), "ns1", fftypes.NewRandB32())
+	assert.Regexp(t, "pop", err)
+}
+
+func TestEnsurePrivacyGroupNodeNotFound(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mdi := bp.database.(*databasemocks.Plugin)
+	mbi.On("PrivacyKey").Return("key1")
+	mdi.On("GetGroupByHash", mock.Anything, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: fftypes.Members{{Node: fftypes.NewUUID()}},
+		},
+	}, nil)
+	mdi.On("GetNodeByID", mock.Anything, mock.Anything).Return(nil, nil)
+
+	_, err := bp.EnsurePrivacyGroup(context.Background(), "ns1", fftypes.NewRandB32())
+	assert.Regexp(t, "FF10224", err)
+}
+
+func TestEnsurePrivacyGroupNodeNoPrivacyKey(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t, false)
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mdi := bp.database.(*databasemocks.Plugin)
+	mbi.On("PrivacyKey").Return("key1")
+	mdi.On("GetGroupByHash", mock.Anything, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: fftypes.Members{{Node: fftypes.NewUUID()}},
+		},
+	}, nil)
+	mdi.On("GetNodeByID", mock.Anything, mock.Anything).Return(&fftypes.Node{Name: "node1"}, nil)
+
+	_, err := bp.EnsurePrivacyGroup(context.Background(), "ns1", fftypes.NewRandB32())
+	assert.Regexp(t, "FF10517", err)
+}
+
+func TestSubmitPinnedBatchReadOnly(t *testing.T) {
+	config.Reset()
+	config.Set(config.NodeReadOnly, true)
+	defer config.Reset()
+	bp := newTestBatchPinSubmitter(t, false)
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+	}
+
+	err := bp.SubmitPinnedBatch(context.Background(), batch, []*fftypes.Bytes32{})
+	assert.Regexp(t, "FF10358", err)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorallowlist
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// AuthorAllowlist is a batch validator that restricts the authors that can submit batches
+// to each configured namespace. Namespaces without an entry are not restricted.
+type AuthorAllowlist struct {
+	allowed map[string]map[string]bool
+}
+
+func (a *AuthorAllowlist) Name() string {
+	return "authorallowlist"
+}
+
+func (a *AuthorAllowlist) Init(ctx context.Context, prefix config.Prefix) error {
+	a.allowed = make(map[string]map[string]bool)
+	for i, entry := range prefix.GetObjectArray(AuthorAllowlistConfNamespaces) {
+		ns := entry.GetString(AuthorAllowlistConfNamespaceName)
+		if ns == "" {
+			key := fmt.Sprintf("%s[%d].%s", prefix.Resolve(AuthorAllowlistConfNamespaces), i, AuthorAllowlistConfNamespaceName)
+			return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, key, a.Name())
+		}
+		authors := a.allowed[ns]
+		if authors == nil {
+			authors = make(map[string]bool)
+			a.allowed[ns] = authors
+		}
+		for _, author := range entry.GetStringArray(AuthorAllowlistConfNamespaceAuthors) {
+			authors[author] = true
+		}
+		log.L(ctx).Infof("Author allowlist for namespace '%s': %d authors", ns, len(authors))
+	}
+	return nil
+}
+
+func (a *AuthorAllowlist) ValidateBatch(ctx context.Context, batch *fftypes.Batch) (valid bool, reason string, err error) {
+	authors, restricted := a.allowed[batch.Namespace]
+	if restricted && !authors[batch.Author] {
+		return false, fmt.Sprintf("Author '%s' is not in the allowlist for namespace '%s'", batch.Author, batch.Namespace), nil
+	}
+	return true, "", nil
+}
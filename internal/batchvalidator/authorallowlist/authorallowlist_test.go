@@ -0,0 +1,70 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorallowlist
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/batchvalidator"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("authorallowlist_unit_tests")
+
+func newTestAuthorAllowlist(namespaces fftypes.JSONObjectArray) (batchvalidator.Plugin, error) {
+	config.Reset()
+	a := &AuthorAllowlist{}
+	a.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(AuthorAllowlistConfNamespaces, namespaces)
+	return a, a.Init(context.Background(), utConfPrefix)
+}
+
+func TestValidateBatch(t *testing.T) {
+	a, err := newTestAuthorAllowlist(fftypes.JSONObjectArray{
+		{"name": "ns1", "authors": []string{"did:firefly:org/org1"}},
+		{"name": "ns1", "authors": []string{"did:firefly:org/org2"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "authorallowlist", a.Name())
+
+	valid, _, err := a.ValidateBatch(context.Background(), &fftypes.Batch{Namespace: "ns1", Identity: fftypes.Identity{Author: "did:firefly:org/org1"}})
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, _, err = a.ValidateBatch(context.Background(), &fftypes.Batch{Namespace: "ns1", Identity: fftypes.Identity{Author: "did:firefly:org/org2"}})
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, reason, err := a.ValidateBatch(context.Background(), &fftypes.Batch{Namespace: "ns1", Identity: fftypes.Identity{Author: "did:firefly:org/org3"}})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Regexp(t, "did:firefly:org/org3.*ns1", reason)
+
+	valid, _, err = a.ValidateBatch(context.Background(), &fftypes.Batch{Namespace: "ns2", Identity: fftypes.Identity{Author: "did:firefly:org/org3"}})
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestInitMissingNamespaceName(t *testing.T) {
+	_, err := newTestAuthorAllowlist(fftypes.JSONObjectArray{
+		{"authors": []string{"did:firefly:org/org1"}},
+	})
+	assert.Regexp(t, "FF10138.*namespaces\\[0\\].name", err)
+}
@@ -0,0 +1,34 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorallowlist
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+)
+
+const (
+	// AuthorAllowlistConfNamespaces is an array of namespace entries, each with a "name" and a list of "authors"
+	AuthorAllowlistConfNamespaces = "namespaces"
+	// AuthorAllowlistConfNamespaceName is the name of the namespace the allowlist applies to
+	AuthorAllowlistConfNamespaceName = "name"
+	// AuthorAllowlistConfNamespaceAuthors is the list of author DIDs allowed to submit batches to the namespace
+	AuthorAllowlistConfNamespaceAuthors = "authors"
+)
+
+func (a *AuthorAllowlist) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(AuthorAllowlistConfNamespaces)
+}
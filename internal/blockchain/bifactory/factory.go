@@ -37,6 +37,13 @@ func InitPrefix(prefix config.Prefix) {
 	}
 }
 
+// InitLedgersPrefix registers the keys common to all entries in the list of additional ledgers. The config
+// of the plugin for each ledger is registered as it is loaded, as it depends on the type of the entry
+func InitLedgersPrefix(prefix config.PrefixArray) {
+	prefix.AddKnownKey(blockchain.LedgerConfigName)
+	prefix.AddKnownKey(blockchain.LedgerConfigType)
+}
+
 func GetPlugin(ctx context.Context, pluginType string) (blockchain.Plugin, error) {
 	plugin, ok := pluginsByName[pluginType]
 	if !ok {
@@ -0,0 +1,178 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectorhealth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/internal/retry"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/wsclient"
+)
+
+const (
+	defaultHealthCheckEnabled        = true
+	defaultHealthCheckPath           = "/status"
+	defaultHealthCheckInterval       = "30s"
+	defaultHealthCheckRetryInitDelay = "1s"
+	defaultHealthCheckRetryMaxDelay  = "30s"
+)
+
+const (
+	// HealthCheckEnabled enables liveness probing of the connector
+	HealthCheckEnabled = "healthCheck.enabled"
+	// HealthCheckPath is the path of the REST API of the connector, that returns a success status code when it is healthy
+	HealthCheckPath = "healthCheck.path"
+	// HealthCheckInterval is the frequency of the health checks, while the connector is healthy
+	HealthCheckInterval = "healthCheck.interval"
+	// HealthCheckRetryInitDelay is the initial delay before re-checking a connector that is unhealthy
+	HealthCheckRetryInitDelay = "healthCheck.retry.initDelay"
+	// HealthCheckRetryMaxDelay is the maximum delay between re-checking a connector that is unhealthy
+	HealthCheckRetryMaxDelay = "healthCheck.retry.maxDelay"
+)
+
+// RecoveryHandler is called when a connector becomes healthy again after a failed health check, to
+// re-establish any state in the connector that might have been lost if it was restarted (such as event streams).
+// Returning an error means the connector is still considered unhealthy, and recovery is retried on the next check.
+type RecoveryHandler func(ctx context.Context) error
+
+// Monitor performs liveness probing of the REST API and the websocket of a connector. While the connector is
+// unhealthy the checks are retried with exponential backoff, and once it recovers the recovery handler is called.
+type Monitor struct {
+	ctx           context.Context
+	client        *resty.Client
+	wsconn        wsclient.WSClient
+	path          string
+	interval      time.Duration
+	retry         retry.Retry
+	errKey        i18n.MessageKey
+	onRecover     RecoveryHandler
+	healthMux     sync.Mutex
+	health        fftypes.ConnectorHealth
+	needsRecovery bool
+	done          chan struct{}
+}
+
+// InitPrefix adds the health check configuration to the config of a connector
+func InitPrefix(prefix config.KeySet) {
+	prefix.AddKnownKey(HealthCheckEnabled, defaultHealthCheckEnabled)
+	prefix.AddKnownKey(HealthCheckPath, defaultHealthCheckPath)
+	prefix.AddKnownKey(HealthCheckInterval, defaultHealthCheckInterval)
+	prefix.AddKnownKey(HealthCheckRetryInitDelay, defaultHealthCheckRetryInitDelay)
+	prefix.AddKnownKey(HealthCheckRetryMaxDelay, defaultHealthCheckRetryMaxDelay)
+}
+
+// NewMonitor returns a health monitor for a connector, or nil if health checks are disabled in the config.
+// The errKey is used to wrap errors from the REST API of the connector.
+func NewMonitor(ctx context.Context, prefix config.Prefix, client *resty.Client, wsconn wsclient.WSClient, errKey i18n.MessageKey, onRecover RecoveryHandler) *Monitor {
+	if !prefix.GetBool(HealthCheckEnabled) {
+		return nil
+	}
+	return &Monitor{
+		ctx:      log.WithLogField(ctx, "role", "connector-health"),
+		client:   client,
+		wsconn:   wsconn,
+		path:     prefix.GetString(HealthCheckPath),
+		interval: prefix.GetDuration(HealthCheckInterval),
+		retry: retry.Retry{
+			InitialDelay: prefix.GetDuration(HealthCheckRetryInitDelay),
+			MaximumDelay: prefix.GetDuration(HealthCheckRetryMaxDelay),
+		},
+		errKey:    errKey,
+		onRecover: onRecover,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start starts the background health checks, which run until the context is cancelled
+func (m *Monitor) Start() {
+	go m.monitorLoop()
+}
+
+// Health returns the result of the latest health check
+func (m *Monitor) Health() *fftypes.ConnectorHealth {
+	m.healthMux.Lock()
+	defer m.healthMux.Unlock()
+	health := m.health
+	return &health
+}
+
+func (m *Monitor) monitorLoop() {
+	defer close(m.done)
+	for {
+		// Retry indefinitely (until the context closes) with backoff while the connector is unhealthy
+		_ = m.retry.DoCustomLog(m.ctx, func(attempt int) (retry bool, err error) {
+			return true, m.check()
+		})
+
+		select {
+		case <-m.ctx.Done():
+			log.L(m.ctx).Debugf("Connector health monitor exiting")
+			return
+		case <-time.After(m.interval):
+		}
+	}
+}
+
+func (m *Monitor) probe() error {
+	res, err := m.client.R().SetContext(m.ctx).Get(m.path)
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(m.ctx, res, err, m.errKey)
+	}
+	if !m.wsconn.Connected() {
+		return i18n.NewError(m.ctx, i18n.MsgConnectorWSDisconnected)
+	}
+	return nil
+}
+
+func (m *Monitor) check() error {
+	err := m.probe()
+	if err == nil && m.needsRecovery && m.onRecover != nil {
+		err = m.onRecover(m.ctx)
+	}
+
+	m.healthMux.Lock()
+	defer m.healthMux.Unlock()
+	now := fftypes.Now()
+	m.health.LastChecked = now
+	m.health.Connected = m.wsconn.Connected()
+	if err != nil {
+		if m.health.Healthy || !m.needsRecovery {
+			log.L(m.ctx).Warnf("Connector health check failed: %s", err)
+		}
+		m.health.Healthy = false
+		m.health.Error = err.Error()
+		m.needsRecovery = true
+		return err
+	}
+	if m.needsRecovery {
+		log.L(m.ctx).Infof("Connector recovered")
+		m.health.Recoveries++
+		m.needsRecovery = false
+	}
+	m.health.Healthy = true
+	m.health.LastHealthy = now
+	m.health.Error = ""
+	return nil
+}
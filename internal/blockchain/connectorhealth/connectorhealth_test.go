@@ -0,0 +1,130 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectorhealth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/mocks/wsmocks"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("health_unit_tests")
+
+func newTestMonitor(t *testing.T, handler http.HandlerFunc, onRecover RecoveryHandler) (*Monitor, *wsmocks.WSClient, func()) {
+	config.Reset()
+	InitPrefix(utConfPrefix)
+	utConfPrefix.Set(HealthCheckInterval, "1ms")
+	utConfPrefix.Set(HealthCheckRetryInitDelay, "1ms")
+	utConfPrefix.Set(HealthCheckRetryMaxDelay, "1ms")
+	server := httptest.NewServer(handler)
+	ctx, cancel := context.WithCancel(context.Background())
+	wsm := &wsmocks.WSClient{}
+	m := NewMonitor(ctx, utConfPrefix, resty.New().SetBaseURL(server.URL), wsm, i18n.MsgEthconnectRESTErr, onRecover)
+	return m, wsm, func() {
+		cancel()
+		server.Close()
+	}
+}
+
+func TestMonitorDisabled(t *testing.T) {
+	config.Reset()
+	InitPrefix(utConfPrefix)
+	utConfPrefix.Set(HealthCheckEnabled, false)
+	assert.Nil(t, NewMonitor(context.Background(), utConfPrefix, resty.New(), &wsmocks.WSClient{}, i18n.MsgEthconnectRESTErr, nil))
+}
+
+func TestMonitorRecovery(t *testing.T) {
+	var calls, recoveries int32
+	m, wsm, done := newTestMonitor(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/status", r.URL.Path)
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(500)
+			return
+		}
+		w.WriteHeader(200)
+	}, func(ctx context.Context) error {
+		atomic.AddInt32(&recoveries, 1)
+		return nil
+	})
+	defer done()
+	wsm.On("Connected").Return(true)
+
+	m.Start()
+	health := m.Health()
+	for health.Recoveries == 0 {
+		time.Sleep(1 * time.Millisecond)
+		health = m.Health()
+	}
+	done()
+	<-m.done
+
+	assert.True(t, health.Healthy)
+	assert.True(t, health.Connected)
+	assert.Empty(t, health.Error)
+	assert.NotNil(t, health.LastHealthy)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&recoveries))
+}
+
+func TestMonitorCheckWSDisconnected(t *testing.T) {
+	m, wsm, done := newTestMonitor(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}, nil)
+	defer done()
+	wsm.On("Connected").Return(false)
+
+	err := m.check()
+	assert.Regexp(t, "FF10407", err)
+	health := m.Health()
+	assert.False(t, health.Healthy)
+	assert.False(t, health.Connected)
+	assert.Regexp(t, "FF10407", health.Error)
+
+	// Still failing, with recovery pending
+	err = m.check()
+	assert.Regexp(t, "FF10407", err)
+	assert.True(t, m.needsRecovery)
+}
+
+func TestMonitorCheckRecoveryFails(t *testing.T) {
+	m, wsm, done := newTestMonitor(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}, func(ctx context.Context) error {
+		return fmt.Errorf("pop")
+	})
+	defer done()
+	wsm.On("Connected").Return(true)
+
+	err := m.check()
+	assert.NoError(t, err)
+	assert.True(t, m.Health().Healthy)
+
+	m.needsRecovery = true
+	err = m.check()
+	assert.EqualError(t, err, "pop")
+	assert.False(t, m.Health().Healthy)
+	assert.Equal(t, int64(0), m.Health().Recoveries)
+}
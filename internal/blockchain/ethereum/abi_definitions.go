@@ -48,6 +48,26 @@ var batchPinMethodABI = ABIElementMarshaling{
 	},
 }
 
+var isRegisteredMethodABI = ABIElementMarshaling{
+	Name:            "isRegistered",
+	Type:            "function",
+	StateMutability: "view",
+	Inputs: []ABIArgumentMarshaling{
+		{
+			InternalType: "address",
+			Name:         "key",
+			Type:         "address",
+		},
+	},
+	Outputs: []ABIArgumentMarshaling{
+		{
+			InternalType: "bool",
+			Name:         "",
+			Type:         "bool",
+		},
+	},
+}
+
 var batchPinEventABI = ABIElementMarshaling{
 	Name: "BatchPin",
 	Type: "event",
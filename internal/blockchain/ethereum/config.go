@@ -17,6 +17,7 @@
 package ethereum
 
 import (
+	"github.com/hyperledger/firefly/internal/blockchain/connectorhealth"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/config/wsconfig"
 	"github.com/hyperledger/firefly/internal/restclient"
@@ -32,6 +33,8 @@ const (
 	defaultAddressResolverResponseField = "address"
 	defaultAddressResolverCacheSize     = 1000
 	defaultAddressResolverCacheTTL      = "24h"
+
+	defaultFeesOracleMethod = "GET"
 )
 
 const (
@@ -50,6 +53,9 @@ const (
 	EthconnectPrefixShort = "prefixShort"
 	// EthconnectPrefixLong is used in HTTP headers in requests to ethconnect
 	EthconnectPrefixLong = "prefixLong"
+	// EthconnectConfigIdentityRegistry is the address of an on-chain registry contract with an isRegistered(address) method,
+	// that the authors of batches can be verified against
+	EthconnectConfigIdentityRegistry = "identityRegistry"
 
 	// AddressResolverConfigKey is a sub-key in the config to contain an address resolver config.
 	AddressResolverConfigKey = "addressResolver"
@@ -67,17 +73,48 @@ const (
 	AddressResolverCacheSize = "cache.size"
 	// AddressResolverCacheTTL the TTL on cache entries
 	AddressResolverCacheTTL = "cache.ttl"
+
+	// PrivateTransactionsConfigKey is a sub-key in the config to contain the Besu/Tessera private transaction config
+	PrivateTransactionsConfigKey = "privateTransactions"
+	// PrivateTransactionsEnabled when true the pins of private batches are submitted as private transactions, in the privacy group of the FireFly group
+	PrivateTransactionsEnabled = "enabled"
+	// PrivateTransactionsPrivateFrom the Tessera public key of this node, that private transactions are sent from
+	PrivateTransactionsPrivateFrom = "privateFrom"
+	// PrivateTransactionsBesuConfigKey is a sub-key of the private transaction config, with the REST client config of the
+	// Besu JSON-RPC endpoint used to look up and create privacy groups
+	PrivateTransactionsBesuConfigKey = "besu"
+
+	// FeesConfigKey is a sub-key in the config to contain the default fee policy for batch pins and contract invokes
+	FeesConfigKey = "fees"
+	// FeesPolicy is the fee policy - fixed, eip1559 or estimate. When not set, the fee is left for ethconnect to choose
+	FeesPolicy = "policy"
+	// FeesGasPrice is the gas price in wei for the fixed policy
+	FeesGasPrice = "gasPrice"
+	// FeesMaxFeePerGas is the max fee per gas in wei for the eip1559 policy
+	FeesMaxFeePerGas = "maxFeePerGas"
+	// FeesMaxPriorityFeePerGas is the max priority fee per gas in wei for the eip1559 policy
+	FeesMaxPriorityFeePerGas = "maxPriorityFeePerGas"
+	// FeesOracleConfigKey is a sub-key of the fees config, with the REST client config of the gas oracle queried by the estimate policy
+	FeesOracleConfigKey = "oracle"
+	// FeesOracleMethod the HTTP method to use to call the gas oracle (default GET)
+	FeesOracleMethod = "method"
+	// FeesNamespacesConfigKey is an array of fee policies that override the default policy for individual namespaces
+	FeesNamespacesConfigKey = "namespaces"
+	// FeesNamespaceName is the name of the namespace a fee policy in the namespaces array applies to
+	FeesNamespaceName = "name"
 )
 
 func (e *Ethereum) InitPrefix(prefix config.Prefix) {
 	ethconnectConf := prefix.SubPrefix(EthconnectConfigKey)
 	wsconfig.InitPrefix(ethconnectConf)
+	connectorhealth.InitPrefix(ethconnectConf)
 	ethconnectConf.AddKnownKey(EthconnectConfigInstancePath)
 	ethconnectConf.AddKnownKey(EthconnectConfigTopic)
 	ethconnectConf.AddKnownKey(EthconnectConfigBatchSize, defaultBatchSize)
 	ethconnectConf.AddKnownKey(EthconnectConfigBatchTimeout, defaultBatchTimeout)
 	ethconnectConf.AddKnownKey(EthconnectPrefixShort, defaultPrefixShort)
 	ethconnectConf.AddKnownKey(EthconnectPrefixLong, defaultPrefixLong)
+	ethconnectConf.AddKnownKey(EthconnectConfigIdentityRegistry)
 
 	addressResolverConf := prefix.SubPrefix(AddressResolverConfigKey)
 	restclient.InitPrefix(addressResolverConf)
@@ -88,4 +125,30 @@ func (e *Ethereum) InitPrefix(prefix config.Prefix) {
 	addressResolverConf.AddKnownKey(AddressResolverResponseField, defaultAddressResolverResponseField)
 	addressResolverConf.AddKnownKey(AddressResolverCacheSize, defaultAddressResolverCacheSize)
 	addressResolverConf.AddKnownKey(AddressResolverCacheTTL, defaultAddressResolverCacheTTL)
+
+	privateTxConf := prefix.SubPrefix(PrivateTransactionsConfigKey)
+	privateTxConf.AddKnownKey(PrivateTransactionsEnabled, false)
+	privateTxConf.AddKnownKey(PrivateTransactionsPrivateFrom)
+	restclient.InitPrefix(privateTxConf.SubPrefix(PrivateTransactionsBesuConfigKey))
+
+	feesConf := prefix.SubPrefix(FeesConfigKey)
+	initFeePolicyPrefix(feesConf)
+	oracleConf := feesConf.SubPrefix(FeesOracleConfigKey)
+	restclient.InitPrefix(oracleConf)
+	oracleConf.AddKnownKey(FeesOracleMethod, defaultFeesOracleMethod)
+	feeNamespacesConfig(feesConf)
+}
+
+func initFeePolicyPrefix(ks config.KeySet) {
+	ks.AddKnownKey(FeesPolicy)
+	ks.AddKnownKey(FeesGasPrice)
+	ks.AddKnownKey(FeesMaxFeePerGas)
+	ks.AddKnownKey(FeesMaxPriorityFeePerGas)
+}
+
+func feeNamespacesConfig(feesConf config.Prefix) config.PrefixArray {
+	nsArray := feesConf.SubPrefix(FeesNamespacesConfigKey).Array()
+	nsArray.AddKnownKey(FeesNamespaceName)
+	initFeePolicyPrefix(nsArray)
+	return nsArray
 }
@@ -23,8 +23,10 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/blockchain/connectorhealth"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/config/wsconfig"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -59,9 +61,20 @@ type Ethereum struct {
 		stream *eventStream
 		sub    *subscription
 	}
+	subMux          sync.Mutex
 	wsconn          wsclient.WSClient
 	closed          chan struct{}
 	addressResolver *addressResolver
+	fees            *feePolicies
+	privateFrom     string
+	besu            *resty.Client
+	privacyMux      sync.Mutex
+	privacyGroups   map[fftypes.Bytes32]*privacyGroup
+	privateSubs     map[string]bool
+	registryAddress string
+	batchSize       uint
+	batchTimeout    uint
+	health          *connectorhealth.Monitor
 }
 
 type eventStreamWebsocket struct {
@@ -89,10 +102,11 @@ type paramDetails struct {
 }
 
 type Schema struct {
-	Type       string             `json:"type"`
-	Details    *paramDetails      `json:"details,omitempty"`
-	Properties map[string]*Schema `json:"properties,omitempty"`
-	Items      *Schema            `json:"items,omitempty"`
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Details     *paramDetails      `json:"details,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
 }
 
 func (s *Schema) ToJSON() string {
@@ -121,12 +135,34 @@ type ABIElementMarshaling struct {
 	Outputs         []ABIArgumentMarshaling `json:"outputs"`
 }
 
+// DevDocs is the developer documentation output by the Solidity compiler for a contract (solc --devdoc),
+// where methods and events are keyed by their canonical signature
+type DevDocs struct {
+	Title   string                    `json:"title,omitempty"`
+	Details string                    `json:"details,omitempty"`
+	Methods map[string]DevDocsElement `json:"methods,omitempty"`
+	Events  map[string]DevDocsElement `json:"events,omitempty"`
+}
+
+// DevDocsElement is the developer documentation for a single method or event
+type DevDocsElement struct {
+	Details string            `json:"details,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+	Returns map[string]string `json:"returns,omitempty"`
+}
+
 type EthconnectMessageRequest struct {
-	Headers EthconnectMessageHeaders `json:"headers,omitempty"`
-	To      string                   `json:"to"`
-	From    string                   `json:"from,omitempty"`
-	Method  ABIElementMarshaling     `json:"method"`
-	Params  []interface{}            `json:"params"`
+	Headers              EthconnectMessageHeaders `json:"headers,omitempty"`
+	To                   string                   `json:"to"`
+	From                 string                   `json:"from,omitempty"`
+	PrivateFrom          string                   `json:"privateFrom,omitempty"`
+	PrivacyGroupID       string                   `json:"privacyGroupId,omitempty"`
+	GasPrice             *fftypes.FFBigInt        `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *fftypes.FFBigInt        `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *fftypes.FFBigInt        `json:"maxPriorityFeePerGas,omitempty"`
+	Method               ABIElementMarshaling     `json:"method"`
+	Params               []interface{}            `json:"params"`
+	BlockNumber          *fftypes.FFBigInt        `json:"blockNumber,omitempty"`
 }
 
 type EthconnectMessageHeaders struct {
@@ -145,8 +181,9 @@ func (e *Ethereum) Init(ctx context.Context, prefix config.Prefix, callbacks blo
 
 	ethconnectConf := prefix.SubPrefix(EthconnectConfigKey)
 	addressResolverConf := prefix.SubPrefix(AddressResolverConfigKey)
+	privateTxConf := prefix.SubPrefix(PrivateTransactionsConfigKey)
 
-	e.ctx = log.WithLogField(ctx, "proto", "ethereum")
+	e.ctx = log.WithLogField(log.WithModule(ctx, "blockchain"), "proto", "ethereum")
 	e.callbacks = callbacks
 
 	if addressResolverConf.GetString(AddressResolverURLTemplate) != "" {
@@ -155,13 +192,32 @@ func (e *Ethereum) Init(ctx context.Context, prefix config.Prefix, callbacks blo
 		}
 	}
 
+	if e.fees, err = newFeePolicies(e.ctx, prefix.SubPrefix(FeesConfigKey)); err != nil {
+		return err
+	}
+
 	if ethconnectConf.GetString(restclient.HTTPConfigURL) == "" {
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "blockchain.ethconnect")
 	}
 
+	if privateTxConf.GetBool(PrivateTransactionsEnabled) {
+		if e.privateFrom = privateTxConf.GetString(PrivateTransactionsPrivateFrom); e.privateFrom == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "privateFrom", "blockchain.ethereum.privateTransactions")
+		}
+		besuConf := privateTxConf.SubPrefix(PrivateTransactionsBesuConfigKey)
+		if besuConf.GetString(restclient.HTTPConfigURL) == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "blockchain.ethereum.privateTransactions.besu")
+		}
+		e.besu = restclient.New(e.ctx, besuConf)
+	}
+	e.privacyGroups = make(map[fftypes.Bytes32]*privacyGroup)
+	e.privateSubs = make(map[string]bool)
+
 	e.client = restclient.New(e.ctx, ethconnectConf)
+	e.registryAddress = ethconnectConf.GetString(EthconnectConfigIdentityRegistry)
 	e.capabilities = &blockchain.Capabilities{
-		GlobalSequencer: true,
+		GlobalSequencer:  true,
+		IdentityRegistry: e.registryAddress != "",
 	}
 
 	e.instancePath = ethconnectConf.GetString(EthconnectConfigInstancePath)
@@ -207,15 +263,12 @@ func (e *Ethereum) Init(ctx context.Context, prefix config.Prefix, callbacks blo
 	e.streams = &streamManager{
 		client: e.client,
 	}
-	batchSize := ethconnectConf.GetUint(EthconnectConfigBatchSize)
-	batchTimeout := uint(ethconnectConf.GetDuration(EthconnectConfigBatchTimeout).Milliseconds())
-	if e.initInfo.stream, err = e.streams.ensureEventStream(e.ctx, e.topic, batchSize, batchTimeout); err != nil {
-		return err
-	}
-	log.L(e.ctx).Infof("Event stream: %s (topic=%s)", e.initInfo.stream.ID, e.topic)
-	if e.initInfo.sub, err = e.streams.ensureSubscription(e.ctx, e.instancePath, e.initInfo.stream.ID, batchPinEventABI); err != nil {
+	e.batchSize = ethconnectConf.GetUint(EthconnectConfigBatchSize)
+	e.batchTimeout = uint(ethconnectConf.GetDuration(EthconnectConfigBatchTimeout).Milliseconds())
+	if err = e.initEventStream(e.ctx); err != nil {
 		return err
 	}
+	e.health = connectorhealth.NewMonitor(e.ctx, ethconnectConf, e.client, e.wsconn, i18n.MsgEthconnectRESTErr, e.initEventStream)
 
 	e.closed = make(chan struct{})
 	go e.eventLoop()
@@ -223,14 +276,59 @@ func (e *Ethereum) Init(ctx context.Context, prefix config.Prefix, callbacks blo
 	return nil
 }
 
+// initEventStream ensures the event stream and batch pin subscription exist in ethconnect. This is called on
+// startup, and again each time ethconnect recovers from a failed health check, in case it was restarted without its state.
+func (e *Ethereum) initEventStream(ctx context.Context) error {
+	e.subMux.Lock()
+	defer e.subMux.Unlock()
+	stream, err := e.streams.ensureEventStream(ctx, e.topic, e.batchSize, e.batchTimeout)
+	if err != nil {
+		return err
+	}
+	e.initInfo.stream = stream
+	log.L(ctx).Infof("Event stream: %s (topic=%s)", stream.ID, e.topic)
+	sub, err := e.streams.ensureSubscription(ctx, e.instancePath, stream.ID, batchPinEventABI)
+	if err != nil {
+		return err
+	}
+	e.initInfo.sub = sub
+	if e.privateFrom != "" {
+		// The pins submitted in privacy groups are delivered on their own subscriptions, which must be
+		// recognized after a restart, before the privacy group of each FireFly group is next used
+		return e.loadPrivateSubscriptions(ctx, stream.ID)
+	}
+	return nil
+}
+
+func (e *Ethereum) eventStreamID() string {
+	e.subMux.Lock()
+	defer e.subMux.Unlock()
+	return e.initInfo.stream.ID
+}
+
 func (e *Ethereum) Start() error {
-	return e.wsconn.Connect()
+	err := e.wsconn.Connect()
+	if err == nil && e.health != nil {
+		e.health.Start()
+	}
+	return err
+}
+
+func (e *Ethereum) ConnectorHealth() *fftypes.ConnectorHealth {
+	if e.health == nil {
+		return nil
+	}
+	return e.health.Health()
 }
 
 func (e *Ethereum) Capabilities() *blockchain.Capabilities {
 	return e.capabilities
 }
 
+func (e *Ethereum) VerifierType() fftypes.VerifierType {
+	return fftypes.VerifierTypeEthAddress
+}
+
 func (e *Ethereum) afterConnect(ctx context.Context, w wsclient.WSClient) error {
 	// Send a subscribe to our topic after each connect/reconnect
 	b, _ := json.Marshal(&ethWSCommandPayload{
@@ -255,13 +353,24 @@ func ethHexFormatB32(b *fftypes.Bytes32) string {
 }
 
 func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONObject) (err error) {
+	batch, authorAddress := e.parseBatchPinEvent(ctx, msgJSON)
+	if batch == nil {
+		return nil // move on
+	}
+
+	// If there's an error dispatching the event, we must return the error and shutdown
+	return e.callbacks.BatchPinComplete(batch, authorAddress)
+}
+
+// parseBatchPinEvent returns the batch pin and the signing key of a BatchPin event, or nil if the event is not valid
+func (e *Ethereum) parseBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONObject) (batch *blockchain.BatchPin, authorAddress string) {
 	sBlockNumber := msgJSON.GetString("blockNumber")
 	sTransactionHash := msgJSON.GetString("transactionHash")
 	blockNumber := msgJSON.GetInt64("blockNumber")
 	txIndex := msgJSON.GetInt64("transactionIndex")
 	logIndex := msgJSON.GetInt64("logIndex")
 	dataJSON := msgJSON.GetObject("data")
-	authorAddress := dataJSON.GetString("author")
+	authorAddress = dataJSON.GetString("author")
 	ns := dataJSON.GetString("namespace")
 	sUUIDs := dataJSON.GetString("uuids")
 	sBatchHash := dataJSON.GetString("batchHash")
@@ -271,7 +380,7 @@ func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSON
 	timestamp, err := fftypes.ParseTimeString(timestampStr)
 	if err != nil {
 		log.L(ctx).Errorf("BatchPin event is not valid - missing timestamp: %+v", msgJSON)
-		return nil // move on
+		return nil, "" // move on
 	}
 
 	if sBlockNumber == "" ||
@@ -280,19 +389,19 @@ func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSON
 		sUUIDs == "" ||
 		sBatchHash == "" {
 		log.L(ctx).Errorf("BatchPin event is not valid - missing data: %+v", msgJSON)
-		return nil // move on
+		return nil, "" // move on
 	}
 
 	authorAddress, err = e.ResolveSigningKey(ctx, authorAddress)
 	if err != nil {
 		log.L(ctx).Errorf("BatchPin event is not valid - bad from address (%s): %+v", err, msgJSON)
-		return nil // move on
+		return nil, "" // move on
 	}
 
 	hexUUIDs, err := hex.DecodeString(strings.TrimPrefix(sUUIDs, "0x"))
 	if err != nil || len(hexUUIDs) != 32 {
 		log.L(ctx).Errorf("BatchPin event is not valid - bad uuids (%s): %+v", err, msgJSON)
-		return nil // move on
+		return nil, "" // move on
 	}
 	var txnID fftypes.UUID
 	copy(txnID[:], hexUUIDs[0:16])
@@ -303,7 +412,7 @@ func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSON
 	err = batchHash.UnmarshalText([]byte(sBatchHash))
 	if err != nil {
 		log.L(ctx).Errorf("BatchPin event is not valid - bad batchHash (%s): %+v", err, msgJSON)
-		return nil // move on
+		return nil, "" // move on
 	}
 
 	contexts := make([]*fftypes.Bytes32, len(sContexts))
@@ -312,13 +421,13 @@ func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSON
 		err = hash.UnmarshalText([]byte(sHash))
 		if err != nil {
 			log.L(ctx).Errorf("BatchPin event is not valid - bad pin %d (%s): %+v", i, err, msgJSON)
-			return nil // move on
+			return nil, "" // move on
 		}
 		contexts[i] = &hash
 	}
 
 	delete(msgJSON, "data")
-	batch := &blockchain.BatchPin{
+	batch = &blockchain.BatchPin{
 		Namespace:       ns,
 		TransactionID:   &txnID,
 		BatchID:         &batchID,
@@ -335,9 +444,7 @@ func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSON
 			Timestamp:      timestamp,
 		},
 	}
-
-	// If there's an error dispatching the event, we must return the error and shutdown
-	return e.callbacks.BatchPinComplete(batch, authorAddress)
+	return batch, authorAddress
 }
 
 func (e *Ethereum) handleContractEvent(ctx context.Context, msgJSON fftypes.JSONObject) (err error) {
@@ -395,7 +502,34 @@ func (e *Ethereum) handleReceipt(ctx context.Context, reply fftypes.JSONObject)
 		updateType = fftypes.OpStatusFailed
 	}
 	l.Infof("Ethconnect '%s' reply: request=%s tx=%s message=%s", replyType, requestID, txHash, message)
-	return e.callbacks.BlockchainOpUpdate(operationID, updateType, txHash, message, reply)
+	return e.callbacks.BlockchainOpUpdate(operationID, updateType, txHash, message, reply, buildReceipt(replyType, reply))
+}
+
+func receiptInteger(reply fftypes.JSONObject, key string) *fftypes.FFBigInt {
+	if _, ok := reply.GetStringOk(key); !ok {
+		return nil
+	}
+	return (*fftypes.FFBigInt)(reply.GetInteger(key))
+}
+
+// buildReceipt extracts the outcome of a mined transaction from an ethconnect reply.
+// Errors that occur before the transaction is mined (type "Error") do not have a receipt.
+func buildReceipt(replyType string, reply fftypes.JSONObject) *fftypes.TransactionReceipt {
+	switch replyType {
+	case "TransactionSuccess":
+		return &fftypes.TransactionReceipt{
+			BlockNumber: receiptInteger(reply, "blockNumber"),
+			GasUsed:     receiptInteger(reply, "gasUsed"),
+		}
+	case "TransactionFailure":
+		return &fftypes.TransactionReceipt{
+			BlockNumber:  receiptInteger(reply, "blockNumber"),
+			GasUsed:      receiptInteger(reply, "gasUsed"),
+			RevertReason: reply.GetString("errorMessage"),
+		}
+	default:
+		return nil
+	}
 }
 
 func (e *Ethereum) handleMessageBatch(ctx context.Context, messages []interface{}) error {
@@ -416,7 +550,7 @@ func (e *Ethereum) handleMessageBatch(ctx context.Context, messages []interface{
 		l1.Infof("Received '%s' message", signature)
 		l1.Tracef("Message: %+v", msgJSON)
 
-		if sub == e.initInfo.sub.ID {
+		if sub == e.batchPinSubscriptionID() || e.isPrivateBatchPinSubscription(sub) {
 			switch signature {
 			case broadcastBatchEventSignature:
 				if err := e.handleBatchPinEvent(ctx1, msgJSON); err != nil {
@@ -499,7 +633,7 @@ func (e *Ethereum) ResolveSigningKey(ctx context.Context, key string) (string, e
 	return resolved, err
 }
 
-func (e *Ethereum) invokeContractMethod(ctx context.Context, address, signingKey string, abi ABIElementMarshaling, requestID string, input []interface{}) (*resty.Response, error) {
+func (e *Ethereum) invokeContractMethod(ctx context.Context, address, signingKey string, abi ABIElementMarshaling, requestID string, input []interface{}, privacyGroupID string, fee *fftypes.TransactionFee) (*resty.Response, error) {
 	body := EthconnectMessageRequest{
 		Headers: EthconnectMessageHeaders{
 			Type: "SendTransaction",
@@ -510,20 +644,30 @@ func (e *Ethereum) invokeContractMethod(ctx context.Context, address, signingKey
 		Method: abi,
 		Params: input,
 	}
+	if privacyGroupID != "" {
+		body.PrivateFrom = e.privateFrom
+		body.PrivacyGroupID = privacyGroupID
+	}
+	if fee != nil {
+		body.GasPrice = fee.GasPrice
+		body.MaxFeePerGas = fee.MaxFeePerGas
+		body.MaxPriorityFeePerGas = fee.MaxPriorityFeePerGas
+	}
 	return e.client.R().
 		SetContext(ctx).
 		SetBody(body).
 		Post("/")
 }
 
-func (e *Ethereum) queryContractMethod(ctx context.Context, address string, abi ABIElementMarshaling, input []interface{}) (*resty.Response, error) {
+func (e *Ethereum) queryContractMethod(ctx context.Context, address string, abi ABIElementMarshaling, input []interface{}, blockNumber *fftypes.FFBigInt) (*resty.Response, error) {
 	body := EthconnectMessageRequest{
 		Headers: EthconnectMessageHeaders{
 			Type: "Query",
 		},
-		To:     address,
-		Method: abi,
-		Params: input,
+		To:          address,
+		Method:      abi,
+		Params:      input,
+		BlockNumber: blockNumber,
 	}
 	return e.client.R().
 		SetContext(ctx).
@@ -531,6 +675,10 @@ func (e *Ethereum) queryContractMethod(ctx context.Context, address string, abi
 		Post("/")
 }
 
+func (e *Ethereum) ResolveTransactionFee(ctx context.Context, ns string) (*fftypes.TransactionFee, error) {
+	return e.fees.resolve(ctx, ns)
+}
+
 func (e *Ethereum) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batch *blockchain.BatchPin) error {
 	ethHashes := make([]string, len(batch.Contexts))
 	for i, v := range batch.Contexts {
@@ -546,14 +694,22 @@ func (e *Ethereum) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID
 		batch.BatchPayloadRef,
 		ethHashes,
 	}
-	res, err := e.invokeContractMethod(ctx, e.instancePath, signingKey, batchPinMethodABI, operationID.String(), input)
+	var privacyGroupID string
+	if e.privateFrom != "" && batch.Group != nil {
+		// The pin of a private batch is only made visible to the member nodes of the group, in the
+		// privacy group established for them by EnsurePrivacyGroup
+		if privacyGroupID = e.privacyGroupID(batch.Group); privacyGroupID == "" {
+			return i18n.NewError(ctx, i18n.MsgNoPrivacyGroup, batch.Group)
+		}
+	}
+	res, err := e.invokeContractMethod(ctx, e.instancePath, signingKey, batchPinMethodABI, operationID.String(), input, privacyGroupID, batch.Fee)
 	if err != nil || !res.IsSuccess() {
 		return restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
 	}
 	return nil
 }
 
-func (e *Ethereum) InvokeContract(ctx context.Context, operationID *fftypes.UUID, signingKey string, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}) error {
+func (e *Ethereum) InvokeContract(ctx context.Context, operationID *fftypes.UUID, signingKey string, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}, fee *fftypes.TransactionFee) error {
 	ethereumLocation, err := parseContractLocation(ctx, location)
 	if err != nil {
 		return err
@@ -562,7 +718,33 @@ func (e *Ethereum) InvokeContract(ctx context.Context, operationID *fftypes.UUID
 	if err != nil {
 		return err
 	}
-	res, err := e.invokeContractMethod(ctx, ethereumLocation.Address, signingKey, abi, operationID.String(), orderedInput)
+	res, err := e.invokeContractMethod(ctx, ethereumLocation.Address, signingKey, abi, operationID.String(), orderedInput, "", fee)
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	return nil
+}
+
+func (e *Ethereum) SubmitRawTransaction(ctx context.Context, operationID *fftypes.UUID, signingKey string, tx fftypes.JSONObject) error {
+	// The caller supplies the full Ethconnect message, but we own the headers that
+	// correlate the receipt back to the operation, and the signing key
+	body := fftypes.JSONObject{}
+	for k, v := range tx {
+		body[k] = v
+	}
+	headers := fftypes.JSONObject{}
+	for k, v := range tx.GetObject("headers") {
+		headers[k] = v
+	}
+	headers["type"] = "SendTransaction"
+	headers["id"] = operationID.String()
+	body["headers"] = headers
+	body["from"] = signingKey
+
+	res, err := e.client.R().
+		SetContext(ctx).
+		SetBody(body).
+		Post("/")
 	if err != nil || !res.IsSuccess() {
 		return restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
 	}
@@ -578,7 +760,7 @@ func (e *Ethereum) QueryContract(ctx context.Context, location *fftypes.JSONAny,
 	if err != nil {
 		return nil, err
 	}
-	res, err := e.queryContractMethod(ctx, ethereumLocation.Address, abi, orderedInput)
+	res, err := e.queryContractMethod(ctx, ethereumLocation.Address, abi, orderedInput, nil)
 	if err != nil || !res.IsSuccess() {
 		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
 	}
@@ -589,6 +771,41 @@ func (e *Ethereum) QueryContract(ctx context.Context, location *fftypes.JSONAny,
 	return output, nil
 }
 
+func (e *Ethereum) VerifyIdentityRegistered(ctx context.Context, signingKey string, blockNumber *fftypes.FFBigInt) (bool, error) {
+	res, err := e.queryContractMethod(ctx, e.registryAddress, isRegisteredMethodABI, []interface{}{signingKey}, blockNumber)
+	if err != nil || !res.IsSuccess() {
+		return false, restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	output := &queryOutput{}
+	if err = json.Unmarshal(res.Body(), output); err != nil {
+		return false, err
+	}
+	registered, _ := output.Output.(bool)
+	return registered, nil
+}
+
+func (e *Ethereum) GetBatchPin(ctx context.Context, blockchainTXID string) (*blockchain.BatchPin, string, error) {
+	// The events emitted by the transaction are returned in the same form they are delivered on the event stream
+	res, err := e.client.R().
+		SetContext(ctx).
+		Get(fmt.Sprintf("/transactions/%s/events", blockchainTXID))
+	if err != nil || !res.IsSuccess() {
+		return nil, "", restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	var events []fftypes.JSONObject
+	if err = json.Unmarshal(res.Body(), &events); err != nil {
+		return nil, "", err
+	}
+	for _, event := range events {
+		if strings.EqualFold(event.GetString("address"), e.instancePath) && event.GetString("signature") == broadcastBatchEventSignature {
+			if batch, authorAddress := e.parseBatchPinEvent(ctx, event); batch != nil {
+				return batch, authorAddress, nil
+			}
+		}
+	}
+	return nil, "", nil
+}
+
 func (e *Ethereum) ValidateContractLocation(ctx context.Context, location *fftypes.JSONAny) (err error) {
 	_, err = parseContractLocation(ctx, location)
 	return
@@ -616,7 +833,7 @@ func (e *Ethereum) AddSubscription(ctx context.Context, subscription *fftypes.Co
 	}
 
 	subName := fmt.Sprintf("ff-sub-%s", subscription.ID)
-	result, err := e.streams.createSubscription(ctx, location, e.initInfo.stream.ID, subName, abi)
+	result, err := e.streams.createSubscription(ctx, location, e.eventStreamID(), subName, abi, "")
 	if err != nil {
 		return err
 	}
@@ -628,6 +845,32 @@ func (e *Ethereum) DeleteSubscription(ctx context.Context, subscription *fftypes
 	return e.streams.deleteSubscription(ctx, subscription.ProtocolID)
 }
 
+func (e *Ethereum) batchPinSubscriptionID() string {
+	e.subMux.Lock()
+	defer e.subMux.Unlock()
+	return e.initInfo.sub.ID
+}
+
+// ResetBatchPinSubscription creates a new batch pin subscription from block 0, before deleting the existing one.
+// The lock is held until the new subscription is recorded, so its first events are not mistaken for contract events.
+func (e *Ethereum) ResetBatchPinSubscription(ctx context.Context) error {
+	e.subMux.Lock()
+	old := e.initInfo.sub
+	location := &Location{
+		Address: e.instancePath,
+	}
+	sub, err := e.streams.createSubscription(ctx, location, e.initInfo.stream.ID, old.Name, batchPinEventABI, "")
+	if err == nil {
+		e.initInfo.sub = sub
+	}
+	e.subMux.Unlock()
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Reset %s subscription from block 0: %s (replaces %s)", old.Name, sub.ID, old.ID)
+	return e.streams.deleteSubscription(ctx, old.ID)
+}
+
 func (e *Ethereum) GetFFIParamValidator(ctx context.Context) (fftypes.FFIParamValidator, error) {
 	return &FFIParamValidator{}, nil
 }
@@ -758,11 +1001,24 @@ func (e *Ethereum) GenerateFFI(ctx context.Context, generationRequest *fftypes.F
 	if err != nil {
 		return nil, i18n.NewError(ctx, i18n.MsgFFIGenerationFailed, "unable to deserialize JSON as ABI")
 	}
-	ffi := e.convertABIToFFI(generationRequest.Namespace, generationRequest.Name, generationRequest.Version, generationRequest.Description, abi)
+	devDocs := &DevDocs{}
+	if generationRequest.DevDocs != nil {
+		if err = json.Unmarshal(generationRequest.DevDocs.Bytes(), devDocs); err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgFFIGenerationFailed, "unable to deserialize JSON as devdocs")
+		}
+	}
+	ffi := e.convertABIToFFI(generationRequest.Namespace, generationRequest.Name, generationRequest.Version, generationRequest.Description, abi, devDocs)
 	return ffi, nil
 }
 
-func (e *Ethereum) convertABIToFFI(ns, name, version, description string, abi []ABIElementMarshaling) *fftypes.FFI {
+func (e *Ethereum) convertABIToFFI(ns, name, version, description string, abi []ABIElementMarshaling, devDocs *DevDocs) *fftypes.FFI {
+	if description == "" {
+		// Fall back to the documentation of the contract itself
+		description = devDocs.Details
+		if description == "" {
+			description = devDocs.Title
+		}
+	}
 	ffi := &fftypes.FFI{
 		Namespace:   ns,
 		Name:        name,
@@ -775,18 +1031,22 @@ func (e *Ethereum) convertABIToFFI(ns, name, version, description string, abi []
 	for _, element := range abi {
 		switch element.Type {
 		case "event":
+			docs := devDocs.Events[abiElementSignature(element)]
 			event := &fftypes.FFIEvent{
 				FFIEventDefinition: fftypes.FFIEventDefinition{
-					Name:   element.Name,
-					Params: e.convertABIArgumentsToFFI(element.Inputs),
+					Name:        element.Name,
+					Description: docs.Details,
+					Params:      e.convertABIArgumentsToFFI(element.Inputs, docs.Params),
 				},
 			}
 			ffi.Events = append(ffi.Events, event)
 		case "function":
+			docs := devDocs.Methods[abiElementSignature(element)]
 			method := &fftypes.FFIMethod{
-				Name:    element.Name,
-				Params:  e.convertABIArgumentsToFFI(element.Inputs),
-				Returns: e.convertABIArgumentsToFFI(element.Outputs),
+				Name:        element.Name,
+				Description: docs.Details,
+				Params:      e.convertABIArgumentsToFFI(element.Inputs, docs.Params),
+				Returns:     e.convertABIArgumentsToFFI(element.Outputs, docs.Returns),
 			}
 			ffi.Methods = append(ffi.Methods, method)
 		}
@@ -794,19 +1054,40 @@ func (e *Ethereum) convertABIToFFI(ns, name, version, description string, abi []
 	return ffi
 }
 
-func (e *Ethereum) convertABIArgumentsToFFI(args []ABIArgumentMarshaling) fftypes.FFIParams {
+func (e *Ethereum) convertABIArgumentsToFFI(args []ABIArgumentMarshaling, docs map[string]string) fftypes.FFIParams {
 	ffiParams := fftypes.FFIParams{}
-	for _, arg := range args {
+	for i, arg := range args {
 		param := &fftypes.FFIParam{
 			Name: arg.Name,
 		}
 		s := e.getSchema(arg)
+		// Unnamed return values are documented by position in devdocs
+		if s.Description = docs[arg.Name]; s.Description == "" {
+			s.Description = docs[fmt.Sprintf("_%d", i)]
+		}
 		param.Schema = fftypes.JSONAnyPtr(s.ToJSON())
 		ffiParams = append(ffiParams, param)
 	}
 	return ffiParams
 }
 
+// abiElementSignature returns the canonical signature of a method or event, such as "transfer(address,uint256)",
+// which is how the Solidity compiler keys the documentation of each element
+func abiElementSignature(element ABIElementMarshaling) string {
+	return element.Name + abiArgumentsSignature(element.Inputs)
+}
+
+func abiArgumentsSignature(args []ABIArgumentMarshaling) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type
+		if strings.HasPrefix(arg.Type, "tuple") {
+			types[i] = abiArgumentsSignature(arg.Components) + strings.TrimPrefix(arg.Type, "tuple")
+		}
+	}
+	return "(" + strings.Join(types, ",") + ")"
+}
+
 func (e *Ethereum) getSchema(arg ABIArgumentMarshaling) *Schema {
 	s := &Schema{
 		Type: e.getFFIType(arg.Type),
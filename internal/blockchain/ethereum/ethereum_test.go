@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
@@ -41,6 +42,7 @@ import (
 var utConfPrefix = config.NewPluginConfig("eth_unit_tests")
 var utEthconnectConf = utConfPrefix.SubPrefix(EthconnectConfigKey)
 var utAddressResolverConf = utConfPrefix.SubPrefix(AddressResolverConfigKey)
+var utPrivateTxConf = utConfPrefix.SubPrefix(PrivateTransactionsConfigKey)
 
 func testFFIMethod() *fftypes.FFIMethod {
 	return &fftypes.FFIMethod{
@@ -110,6 +112,15 @@ func TestInitBadAddressResolver(t *testing.T) {
 	assert.Regexp(t, "FF10337.*urlTemplate", err)
 }
 
+func TestInitBadFeePolicy(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	resetConf()
+	utConfPrefix.SubPrefix(FeesConfigKey).Set(FeesPolicy, "cheap")
+	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10411", err)
+}
+
 func TestInitMissingInstance(t *testing.T) {
 	e, cancel := newTestEthereum()
 	defer cancel()
@@ -121,6 +132,29 @@ func TestInitMissingInstance(t *testing.T) {
 	assert.Regexp(t, "FF10138.*instance", err)
 }
 
+func TestInitPrivateTransactionsMissingPrivateFrom(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	resetConf()
+	utEthconnectConf.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utPrivateTxConf.Set(PrivateTransactionsEnabled, true)
+
+	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*privateFrom", err)
+}
+
+func TestInitPrivateTransactionsMissingBesuURL(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	resetConf()
+	utEthconnectConf.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utPrivateTxConf.Set(PrivateTransactionsEnabled, true)
+	utPrivateTxConf.Set(PrivateTransactionsPrivateFrom, "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=")
+
+	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*besu", err)
+}
+
 func TestInitMissingTopic(t *testing.T) {
 	e, cancel := newTestEthereum()
 	defer cancel()
@@ -149,6 +183,8 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	u.Scheme = "http"
 	httpURL := u.String()
 
+	httpmock.RegisterResponder("GET", fmt.Sprintf("%s/status", httpURL),
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{"ok": true}))
 	httpmock.RegisterResponder("GET", fmt.Sprintf("%s/eventstreams", httpURL),
 		httpmock.NewJsonResponderOrPanic(200, []eventStream{}))
 	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/eventstreams", httpURL),
@@ -168,6 +204,7 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	utEthconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
 	utEthconnectConf.Set(EthconnectConfigInstancePath, "/instances/0x12345")
 	utEthconnectConf.Set(EthconnectConfigTopic, "topic1")
+	utEthconnectConf.Set(EthconnectConfigIdentityRegistry, "0x23456")
 
 	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
 	assert.NoError(t, err)
@@ -177,6 +214,8 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	assert.Equal(t, "es12345", e.initInfo.stream.ID)
 	assert.Equal(t, "sub12345", e.initInfo.sub.ID)
 	assert.True(t, e.Capabilities().GlobalSequencer)
+	assert.True(t, e.Capabilities().IdentityRegistry)
+	assert.Equal(t, fftypes.VerifierTypeEthAddress, e.VerifierType())
 
 	err = e.Start()
 	assert.NoError(t, err)
@@ -194,6 +233,11 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	fromServer <- `{"not": "a reply"}`
 	fromServer <- `42`
 
+	// Health check of the REST API and websocket passes
+	for health := e.ConnectorHealth(); !health.Healthy; health = e.ConnectorHealth() {
+		time.Sleep(1 * time.Millisecond)
+	}
+
 }
 
 func TestWSInitFail(t *testing.T) {
@@ -222,6 +266,7 @@ func TestWSConnectFail(t *testing.T) {
 
 	err := e.Start()
 	assert.EqualError(t, err, "pop")
+	assert.Nil(t, e.ConnectorHealth())
 }
 
 func TestInitAllExistingStreams(t *testing.T) {
@@ -546,6 +591,44 @@ func TestSubmitBatchPinOK(t *testing.T) {
 			assert.Equal(t, "0x9ffc50ff6bfe4502adc793aea54cc059c5df767cfe444e038eb51c5523097db5", params[1])
 			assert.Equal(t, ethHexFormatB32(batch.BatchHash), params[2])
 			assert.Equal(t, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", params[3])
+			assert.Nil(t, body["privacyGroupId"])
+			return httpmock.NewJsonResponderOrPanic(200, "")(req)
+		})
+
+	err := e.SubmitBatchPin(context.Background(), nil, nil, addr, batch)
+
+	assert.NoError(t, err)
+
+}
+
+func TestSubmitBatchPinWithFee(t *testing.T) {
+
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	addr := ethHexFormatB32(fftypes.NewRandB32())
+	batch := &blockchain.BatchPin{
+		TransactionID:   fftypes.NewUUID(),
+		BatchID:         fftypes.NewUUID(),
+		BatchHash:       fftypes.NewRandB32(),
+		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:        []*fftypes.Bytes32{},
+		Fee: &fftypes.TransactionFee{
+			Policy:               fftypes.FeePolicyTypeEIP1559,
+			MaxFeePerGas:         fftypes.NewFFBigInt(2000000000),
+			MaxPriorityFeePerGas: fftypes.NewFFBigInt(1500000000),
+		},
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Nil(t, body["gasPrice"])
+			assert.Equal(t, "2000000000", body["maxFeePerGas"])
+			assert.Equal(t, "1500000000", body["maxPriorityFeePerGas"])
 			return httpmock.NewJsonResponderOrPanic(200, "")(req)
 		})
 
@@ -555,6 +638,61 @@ func TestSubmitBatchPinOK(t *testing.T) {
 
 }
 
+func TestSubmitBatchPinPrivate(t *testing.T) {
+
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.privateFrom = "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="
+
+	addr := ethHexFormatB32(fftypes.NewRandB32())
+	group := fftypes.MustParseBytes32("44dc0861e69d9bab17dd5e90a8898c2ea156ad04e5fabf83119cc010486e6c1b")
+	e.privacyGroups = map[fftypes.Bytes32]*privacyGroup{
+		*group: {id: "RNwIYeadm6sX3V6QqImMLqFWrQTl+r+DEZzAEEhubBs="},
+	}
+	batch := &blockchain.BatchPin{
+		TransactionID: fftypes.MustParseUUID("9ffc50ff-6bfe-4502-adc7-93aea54cc059"),
+		BatchID:       fftypes.MustParseUUID("c5df767c-fe44-4e03-8eb5-1c5523097db5"),
+		BatchHash:     fftypes.NewRandB32(),
+		Group:         group,
+		Contexts: []*fftypes.Bytes32{
+			fftypes.NewRandB32(),
+		},
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=", body["privateFrom"])
+			assert.Equal(t, "RNwIYeadm6sX3V6QqImMLqFWrQTl+r+DEZzAEEhubBs=", body["privacyGroupId"])
+			return httpmock.NewJsonResponderOrPanic(200, "")(req)
+		})
+
+	err := e.SubmitBatchPin(context.Background(), nil, nil, addr, batch)
+
+	assert.NoError(t, err)
+
+}
+
+func TestSubmitBatchPinPrivateNoPrivacyGroup(t *testing.T) {
+
+	e, cancel := newTestEthereum()
+	defer cancel()
+	e.privateFrom = "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="
+
+	batch := &blockchain.BatchPin{
+		TransactionID: fftypes.NewUUID(),
+		BatchID:       fftypes.NewUUID(),
+		BatchHash:     fftypes.NewRandB32(),
+		Group:         fftypes.NewRandB32(),
+	}
+	err := e.SubmitBatchPin(context.Background(), nil, nil, "0x12345", batch)
+	assert.Regexp(t, "FF10516", err)
+
+}
+
 func TestSubmitBatchEmptyPayloadRef(t *testing.T) {
 
 	e, cancel := newTestEthereum()
@@ -1112,7 +1250,12 @@ func TestHandleReceiptTXSuccess(t *testing.T) {
 		fftypes.OpStatusSucceeded,
 		"0x71a38acb7a5d4a970854f6d638ceb1fa10a4b59cbf4ed7674273a1a8dc8b36b8",
 		"",
-		mock.Anything).Return(nil)
+		mock.Anything,
+		mock.MatchedBy(func(receipt *fftypes.TransactionReceipt) bool {
+			return receipt.BlockNumber.Int().Int64() == 209696 &&
+				receipt.GasUsed.Int().Int64() == 24655 &&
+				receipt.RevertReason == ""
+		})).Return(nil)
 
 	err := json.Unmarshal(data.Bytes(), &reply)
 	assert.NoError(t, err)
@@ -1152,7 +1295,8 @@ func TestHandleBadPayloadsAndThenReceiptFailure(t *testing.T) {
 		fftypes.OpStatusFailed,
 		"",
 		"Packing arguments for method 'broadcastBatch': abi: cannot use [3]uint8 as type [32]uint8 as argument",
-		mock.Anything).Return(fmt.Errorf("Shutdown"))
+		mock.Anything,
+		(*fftypes.TransactionReceipt)(nil)).Return(fmt.Errorf("Shutdown"))
 	done := make(chan struct{})
 	txsu.RunFn = func(a mock.Arguments) {
 		close(done)
@@ -1165,6 +1309,59 @@ func TestHandleBadPayloadsAndThenReceiptFailure(t *testing.T) {
 	<-done
 }
 
+func TestHandleReceiptTXFailure(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	wsm := &wsmocks.WSClient{}
+	e := &Ethereum{
+		ctx:       context.Background(),
+		topic:     "topic1",
+		callbacks: em,
+		wsconn:    wsm,
+	}
+
+	var reply fftypes.JSONObject
+	operationID := fftypes.NewUUID()
+	data := fftypes.JSONAnyPtr(`{
+		"_id": "4373614c-e0f7-47b0-640e-7eacec417a9e",
+		"blockHash": "0xad269b2b43481e44500f583108e8d24bd841fb767c7f526772959d195b9c72d5",
+		"blockNumber": "209696",
+		"errorMessage": "execution reverted: insufficient balance",
+		"gasUsed": "23017",
+		"headers": {
+			"id": "4603a151-f212-446e-5c15-0f36b57cecc7",
+			"requestId": "` + operationID.String() + `",
+			"type": "TransactionFailure"
+		},
+		"status": "0",
+		"transactionHash": "0x71a38acb7a5d4a970854f6d638ceb1fa10a4b59cbf4ed7674273a1a8dc8b36b8"
+	}`)
+
+	em.On("BlockchainOpUpdate",
+		operationID,
+		fftypes.OpStatusFailed,
+		"0x71a38acb7a5d4a970854f6d638ceb1fa10a4b59cbf4ed7674273a1a8dc8b36b8",
+		"execution reverted: insufficient balance",
+		mock.Anything,
+		mock.MatchedBy(func(receipt *fftypes.TransactionReceipt) bool {
+			return receipt.BlockNumber.Int().Int64() == 209696 &&
+				receipt.GasUsed.Int().Int64() == 23017 &&
+				receipt.RevertReason == "execution reverted: insufficient balance"
+		})).Return(nil)
+
+	err := json.Unmarshal(data.Bytes(), &reply)
+	assert.NoError(t, err)
+	err = e.handleReceipt(context.Background(), reply)
+	assert.NoError(t, err)
+
+	em.AssertExpectations(t)
+}
+
+func TestBuildReceiptMissingFields(t *testing.T) {
+	receipt := buildReceipt("TransactionSuccess", fftypes.JSONObject{})
+	assert.Nil(t, receipt.BlockNumber)
+	assert.Nil(t, receipt.GasUsed)
+}
+
 func TestHandleReceiptNoRequestID(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
 	wsm := &wsmocks.WSClient{}
@@ -1394,6 +1591,66 @@ func TestDeleteSubscriptionFail(t *testing.T) {
 	assert.Regexp(t, "FF10111", err)
 }
 
+func TestResetBatchPinSubscription(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	e.initInfo.stream = &eventStream{
+		ID: "es-1",
+	}
+	e.initInfo.sub = &subscription{
+		ID:   "sb-1",
+		Name: "BatchPin",
+	}
+	e.streams = &streamManager{
+		client: e.client,
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "BatchPin", body["name"])
+			assert.Equal(t, "es-1", body["stream"])
+			assert.Equal(t, "0", body["fromBlock"])
+			return httpmock.NewJsonResponderOrPanic(200, &subscription{ID: "sb-2"})(req)
+		})
+	httpmock.RegisterResponder("DELETE", `http://localhost:12345/subscriptions/sb-1`,
+		httpmock.NewStringResponder(204, ""))
+
+	err := e.ResetBatchPinSubscription(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "sb-2", e.batchPinSubscriptionID())
+}
+
+func TestResetBatchPinSubscriptionFail(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	e.initInfo.stream = &eventStream{
+		ID: "es-1",
+	}
+	e.initInfo.sub = &subscription{
+		ID: "sb-1",
+	}
+	e.streams = &streamManager{
+		client: e.client,
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions`,
+		httpmock.NewStringResponder(500, ""))
+
+	err := e.ResetBatchPinSubscription(context.Background())
+
+	assert.Regexp(t, "FF10111", err)
+	assert.Equal(t, "sb-1", e.batchPinSubscriptionID())
+}
+
 func TestHandleMessageContractEvent(t *testing.T) {
 	data := fftypes.JSONAnyPtr(`
 [
@@ -1531,6 +1788,38 @@ func TestHandleMessageContractEventError(t *testing.T) {
 	em.AssertExpectations(t)
 }
 
+func TestInvokeContractWithFee(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	signingKey := ethHexFormatB32(fftypes.NewRandB32())
+	location := &Location{
+		Address: "0x12345",
+	}
+	method := testFFIMethod()
+	params := map[string]interface{}{
+		"x": float64(1),
+		"y": float64(2),
+	}
+	fee := &fftypes.TransactionFee{
+		Policy:   fftypes.FeePolicyTypeFixed,
+		GasPrice: fftypes.NewFFBigInt(1000000000),
+	}
+	locationBytes, err := json.Marshal(location)
+	assert.NoError(t, err)
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "1000000000", body["gasPrice"])
+			assert.Nil(t, body["maxFeePerGas"])
+			return httpmock.NewJsonResponderOrPanic(200, "")(req)
+		})
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, fee)
+	assert.NoError(t, err)
+}
+
 func TestInvokeContractOK(t *testing.T) {
 	e, cancel := newTestEthereum()
 	defer cancel()
@@ -1558,7 +1847,7 @@ func TestInvokeContractOK(t *testing.T) {
 			assert.Equal(t, float64(2), params[1])
 			return httpmock.NewJsonResponderOrPanic(200, "")(req)
 		})
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
 	assert.NoError(t, err)
 }
 
@@ -1574,7 +1863,7 @@ func TestInvokeContractAddressNotSet(t *testing.T) {
 	}
 	locationBytes, err := json.Marshal(location)
 	assert.NoError(t, err)
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
 	assert.Regexp(t, "'address' not set", err)
 }
 
@@ -1598,7 +1887,7 @@ func TestInvokeContractEthconnectError(t *testing.T) {
 		func(req *http.Request) (*http.Response, error) {
 			return httpmock.NewJsonResponderOrPanic(400, "")(req)
 		})
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
 	assert.Regexp(t, "FF10111", err)
 }
 
@@ -1625,10 +1914,60 @@ func TestInvokeContractPrepareFail(t *testing.T) {
 	}
 	locationBytes, err := json.Marshal(location)
 	assert.NoError(t, err)
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
 	assert.Regexp(t, "invalid json", err)
 }
 
+func TestSubmitRawTransactionOK(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	signingKey := ethHexFormatB32(fftypes.NewRandB32())
+	opID := fftypes.NewUUID()
+	tx := fftypes.JSONObject{
+		"headers": map[string]interface{}{
+			"type": "Query",
+			"id":   "override",
+			"ctx":  "keep",
+		},
+		"from": "0xoverride",
+		"to":   "0x12345",
+		"method": map[string]interface{}{
+			"name": "setAdmin",
+		},
+		"params": []interface{}{"0xabcde"},
+	}
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			headers := body["headers"].(map[string]interface{})
+			assert.Equal(t, "SendTransaction", headers["type"])
+			assert.Equal(t, opID.String(), headers["id"])
+			assert.Equal(t, "keep", headers["ctx"])
+			assert.Equal(t, signingKey, body["from"])
+			assert.Equal(t, "0x12345", body["to"])
+			assert.Equal(t, "0xabcde", body["params"].([]interface{})[0])
+			return httpmock.NewJsonResponderOrPanic(200, "")(req)
+		})
+	err := e.SubmitRawTransaction(context.Background(), opID, signingKey, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xoverride", tx["from"])
+}
+
+func TestSubmitRawTransactionEthconnectError(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	signingKey := ethHexFormatB32(fftypes.NewRandB32())
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		httpmock.NewJsonResponderOrPanic(400, ""))
+	err := e.SubmitRawTransaction(context.Background(), fftypes.NewUUID(), signingKey, fftypes.JSONObject{"to": "0x12345"})
+	assert.Regexp(t, "FF10111", err)
+}
+
 func TestQueryContractOK(t *testing.T) {
 	e, cancel := newTestEthereum()
 	defer cancel()
@@ -2085,7 +2424,7 @@ func TestConvertABIToFFI(t *testing.T) {
 		},
 	}
 
-	actualFFI := e.convertABIToFFI("default", "SimpleStorage", "v0.0.1", "desc", abi)
+	actualFFI := e.convertABIToFFI("default", "SimpleStorage", "v0.0.1", "desc", abi, &DevDocs{})
 	assert.NotNil(t, actualFFI)
 	assert.Equal(t, expectedFFI, actualFFI)
 }
@@ -2142,7 +2481,7 @@ func TestConvertABIToFFIWithObject(t *testing.T) {
 		Events: []*fftypes.FFIEvent{},
 	}
 
-	actualFFI := e.convertABIToFFI("default", "WidgetTest", "v0.0.1", "desc", abi)
+	actualFFI := e.convertABIToFFI("default", "WidgetTest", "v0.0.1", "desc", abi, &DevDocs{})
 	assert.NotNil(t, actualFFI)
 	assert.Equal(t, expectedFFI, actualFFI)
 }
@@ -2187,7 +2526,7 @@ func TestConvertABIToFFIWithArray(t *testing.T) {
 		Events: []*fftypes.FFIEvent{},
 	}
 
-	actualFFI := e.convertABIToFFI("default", "WidgetTest", "v0.0.1", "desc", abi)
+	actualFFI := e.convertABIToFFI("default", "WidgetTest", "v0.0.1", "desc", abi, &DevDocs{})
 	assert.NotNil(t, actualFFI)
 	assert.Equal(t, expectedFFI, actualFFI)
 }
@@ -2231,7 +2570,7 @@ func TestConvertABIToFFIWithNestedArray(t *testing.T) {
 		Events: []*fftypes.FFIEvent{},
 	}
 
-	actualFFI := e.convertABIToFFI("default", "WidgetTest", "v0.0.1", "desc", abi)
+	actualFFI := e.convertABIToFFI("default", "WidgetTest", "v0.0.1", "desc", abi, &DevDocs{})
 	assert.NotNil(t, actualFFI)
 	assert.Equal(t, expectedFFI, actualFFI)
 }
@@ -2292,7 +2631,7 @@ func TestConvertABIToFFIWithNestedArrayOfObjects(t *testing.T) {
 		Events: []*fftypes.FFIEvent{},
 	}
 
-	actualFFI := e.convertABIToFFI("default", "WidgetTest", "v0.0.1", "desc", abi)
+	actualFFI := e.convertABIToFFI("default", "WidgetTest", "v0.0.1", "desc", abi, &DevDocs{})
 	assert.NotNil(t, actualFFI)
 	assert.Equal(t, expectedFFI, actualFFI)
 }
@@ -2332,6 +2671,83 @@ func TestGenerateFFIFail(t *testing.T) {
 	assert.Regexp(t, "FF10346", err)
 }
 
+func TestGenerateFFIWithDevDocs(t *testing.T) {
+	e, _ := newTestEthereum()
+	ffi, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
+		Name:    "Token",
+		Version: "v0.0.1",
+		Input: fftypes.JSONAnyPtr(`[
+			{
+				"type": "function",
+				"name": "transfer",
+				"inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}],
+				"outputs": [{"name": "", "type": "bool"}]
+			},
+			{
+				"type": "function",
+				"name": "setOrder",
+				"inputs": [{"name": "order", "type": "tuple[]", "components": [{"name": "id", "type": "uint256"}, {"name": "owner", "type": "address"}]}],
+				"outputs": []
+			},
+			{
+				"type": "event",
+				"name": "Transfer",
+				"inputs": [{"name": "from", "type": "address", "indexed": true}, {"name": "to", "type": "address", "indexed": true}, {"name": "value", "type": "uint256"}]
+			}
+		]`),
+		DevDocs: fftypes.JSONAnyPtr(`{
+			"title": "A token",
+			"details": "A simple fungible token",
+			"methods": {
+				"transfer(address,uint256)": {
+					"details": "Moves tokens to another account",
+					"params": {"to": "The recipient", "amount": "The amount"},
+					"returns": {"_0": "Whether the transfer succeeded"}
+				},
+				"setOrder((uint256,address)[])": {
+					"details": "Sets the orders"
+				}
+			},
+			"events": {
+				"Transfer(address,address,uint256)": {
+					"details": "Emitted on every transfer"
+				}
+			}
+		}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "A simple fungible token", ffi.Description)
+	assert.Equal(t, "Moves tokens to another account", ffi.Methods[0].Description)
+	assert.Equal(t, `{"type":"string","description":"The recipient","details":{"type":"address"}}`, ffi.Methods[0].Params[0].Schema.String())
+	assert.Equal(t, `{"type":"boolean","description":"Whether the transfer succeeded","details":{"type":"bool"}}`, ffi.Methods[0].Returns[0].Schema.String())
+	assert.Equal(t, "Sets the orders", ffi.Methods[1].Description)
+	assert.Equal(t, "Emitted on every transfer", ffi.Events[0].Description)
+	assert.Equal(t, `{"type":"string","details":{"type":"address","indexed":true}}`, ffi.Events[0].Params[0].Schema.String())
+}
+
+func TestGenerateFFIDevDocsTitle(t *testing.T) {
+	e, _ := newTestEthereum()
+	ffi, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
+		Name:    "Simple",
+		Version: "v0.0.1",
+		Input:   fftypes.JSONAnyPtr(`[]`),
+		DevDocs: fftypes.JSONAnyPtr(`{"title": "Simple storage"}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Simple storage", ffi.Description)
+}
+
+func TestGenerateFFIBadDevDocs(t *testing.T) {
+	e, _ := newTestEthereum()
+	_, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
+		Name:    "Simple",
+		Version: "v0.0.1",
+		Input:   fftypes.JSONAnyPtr(`[]`),
+		DevDocs: fftypes.JSONAnyPtr(`[]`),
+	})
+	assert.Regexp(t, "FF10346.*devdocs", err)
+}
+
 func TestGetFFIType(t *testing.T) {
 	e, _ := newTestEthereum()
 	assert.Equal(t, e.getFFIType("string"), "string")
@@ -2343,3 +2759,135 @@ func TestGetFFIType(t *testing.T) {
 	assert.Equal(t, e.getFFIType("tuple"), "object")
 	assert.Equal(t, e.getFFIType("foobar"), "")
 }
+
+func TestVerifyIdentityRegisteredOK(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.registryAddress = "0x23456"
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			headers := body["headers"].(map[string]interface{})
+			assert.Equal(t, "Query", headers["type"])
+			assert.Equal(t, "0x23456", body["to"])
+			assert.Equal(t, "isRegistered", body["method"].(map[string]interface{})["name"])
+			assert.Equal(t, []interface{}{"0x12345"}, body["params"])
+			assert.Equal(t, "12345", body["blockNumber"])
+			return httpmock.NewJsonResponderOrPanic(200, queryOutput{Output: true})(req)
+		})
+	registered, err := e.VerifyIdentityRegistered(context.Background(), "0x12345", fftypes.NewFFBigInt(12345))
+	assert.NoError(t, err)
+	assert.True(t, registered)
+}
+
+func TestVerifyIdentityRegisteredEthconnectError(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		httpmock.NewJsonResponderOrPanic(500, queryOutput{}))
+	_, err := e.VerifyIdentityRegistered(context.Background(), "0x12345", nil)
+	assert.Regexp(t, "FF10111", err)
+}
+
+func TestVerifyIdentityRegisteredUnmarshalResponseError(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		httpmock.NewStringResponder(200, "[definitely not JSON}"))
+	_, err := e.VerifyIdentityRegistered(context.Background(), "0x12345", nil)
+	assert.Regexp(t, "invalid character", err)
+}
+
+func TestGetBatchPinOK(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.instancePath = "0x1c197604587f046fd40684a8f21f4609fb811a7b"
+	httpmock.RegisterResponder("GET", `http://localhost:12345/transactions/0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628/events`,
+		httpmock.NewStringResponder(200, `[
+			{
+				"address": "0x06d34B270F15a0d82913EFD0627B0F62Fd22ecd5",
+				"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])"
+			},
+			{
+				"address": "0x1C197604587F046FD40684A8f21f4609FB811A7b",
+				"signature": "Random(address,uint256,bytes32,bytes32,bytes32)"
+			},
+			{
+				"address": "0x1C197604587F046FD40684A8f21f4609FB811A7b",
+				"blockNumber": "38011",
+				"transactionIndex": "0x0",
+				"transactionHash": "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628",
+				"data": {
+					"author": "0X91D2B4381A4CD5C7C0F27565A7D4B829844C8635",
+					"namespace": "ns1",
+					"uuids": "0xe19af8b390604051812d7597d19adfb9847d3bfd074249efb65d3fed15f5b0a6",
+					"batchHash": "0xd71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be",
+					"payloadRef": "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+					"contexts": [
+						"0x68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a"
+					]
+				},
+				"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
+				"logIndex": "50",
+				"timestamp": "1620576488"
+			}
+		]`))
+	batch, signingKey, err := e.GetBatchPin(context.Background(), "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", signingKey)
+	assert.Equal(t, "ns1", batch.Namespace)
+	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", batch.BatchID.String())
+	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", batch.BatchHash.String())
+	assert.Equal(t, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", batch.BatchPayloadRef)
+	assert.Equal(t, "000000038011/000000/000050", batch.Event.ProtocolID)
+}
+
+func TestGetBatchPinNotPinned(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.instancePath = "0x1c197604587f046fd40684a8f21f4609fb811a7b"
+	httpmock.RegisterResponder("GET", `http://localhost:12345/transactions/0x12345/events`,
+		httpmock.NewStringResponder(200, `[
+			{
+				"address": "0x1C197604587F046FD40684A8f21f4609FB811A7b",
+				"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
+				"data": {}
+			}
+		]`))
+	batch, _, err := e.GetBatchPin(context.Background(), "0x12345")
+	assert.NoError(t, err)
+	assert.Nil(t, batch)
+}
+
+func TestGetBatchPinEthconnectError(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", `http://localhost:12345/transactions/0x12345/events`,
+		httpmock.NewStringResponder(500, `{"error":"pop"}`))
+	_, _, err := e.GetBatchPin(context.Background(), "0x12345")
+	assert.Regexp(t, "FF10111", err)
+}
+
+func TestGetBatchPinUnmarshalResponseError(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", `http://localhost:12345/transactions/0x12345/events`,
+		httpmock.NewStringResponder(200, "[definitely not JSON}"))
+	_, _, err := e.GetBatchPin(context.Background(), "0x12345")
+	assert.Regexp(t, "invalid character", err)
+}
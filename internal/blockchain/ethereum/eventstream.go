@@ -44,12 +44,13 @@ type eventStream struct {
 }
 
 type subscription struct {
-	ID        string               `json:"id"`
-	Name      string               `json:"name,omitempty"`
-	Stream    string               `json:"stream"`
-	FromBlock string               `json:"fromBlock"`
-	Address   string               `json:"address"`
-	Event     ABIElementMarshaling `json:"event"`
+	ID             string               `json:"id"`
+	Name           string               `json:"name,omitempty"`
+	Stream         string               `json:"stream"`
+	FromBlock      string               `json:"fromBlock"`
+	Address        string               `json:"address"`
+	PrivacyGroupID string               `json:"privacyGroupId,omitempty"`
+	Event          ABIElementMarshaling `json:"event"`
 }
 
 func (s *streamManager) getEventStreams(ctx context.Context) (streams []*eventStream, err error) {
@@ -133,13 +134,14 @@ func (s *streamManager) getSubscriptions(ctx context.Context) (subs []*subscript
 	return subs, nil
 }
 
-func (s *streamManager) createSubscription(ctx context.Context, location *Location, stream, subName string, abi ABIElementMarshaling) (*subscription, error) {
+func (s *streamManager) createSubscription(ctx context.Context, location *Location, stream, subName string, abi ABIElementMarshaling, privacyGroupID string) (*subscription, error) {
 	sub := subscription{
-		Name:      subName,
-		Stream:    stream,
-		FromBlock: "0",
-		Address:   location.Address,
-		Event:     abi,
+		Name:           subName,
+		Stream:         stream,
+		FromBlock:      "0",
+		Address:        location.Address,
+		PrivacyGroupID: privacyGroupID,
+		Event:          abi,
 	}
 	res, err := s.client.R().
 		SetContext(ctx).
@@ -190,7 +192,7 @@ func (s *streamManager) ensureSubscription(ctx context.Context, instancePath, st
 	}
 
 	if sub == nil {
-		if sub, err = s.createSubscription(ctx, location, stream, subName, abi); err != nil {
+		if sub, err = s.createSubscription(ctx, location, stream, subName, abi, ""); err != nil {
 			return nil, err
 		}
 	}
@@ -198,3 +200,32 @@ func (s *streamManager) ensureSubscription(ctx context.Context, instancePath, st
 	log.L(ctx).Infof("%s subscription: %s", abi.Name, sub.ID)
 	return sub, nil
 }
+
+// privateSubscriptionName qualifies the name of the subscription to the contract with the privacy group
+func privateSubscriptionName(instancePath string, abi ABIElementMarshaling, privacyGroupID string) string {
+	instanceUniqueHash := hex.EncodeToString(sha256.New().Sum([]byte(instancePath)))[0:16]
+	privacyGroupHash := sha256.Sum256([]byte(privacyGroupID))
+	return fmt.Sprintf("%s_%s_%s", abi.Name, instanceUniqueHash, hex.EncodeToString(privacyGroupHash[:])[0:16])
+}
+
+// ensurePrivateSubscription ensures a subscription to the events of the contract emitted by private transactions in the
+// privacy group, which are not delivered on the subscription to the public events of the contract
+func (s *streamManager) ensurePrivateSubscription(ctx context.Context, instancePath, stream string, abi ABIElementMarshaling, privacyGroupID string) (*subscription, error) {
+	subName := privateSubscriptionName(instancePath, abi, privacyGroupID)
+	existingSubs, err := s.getSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range existingSubs {
+		if sub.Name == subName {
+			return sub, nil
+		}
+	}
+
+	sub, err := s.createSubscription(ctx, &Location{Address: instancePath}, stream, subName, abi, privacyGroupID)
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("%s subscription for privacy group %s: %s", abi.Name, privacyGroupID, sub.ID)
+	return sub, nil
+}
@@ -0,0 +1,166 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// feePolicy is the configured policy for setting the fee of the transactions submitted for a namespace
+type feePolicy struct {
+	policy               fftypes.FeePolicyType
+	gasPrice             *fftypes.FFBigInt
+	maxFeePerGas         *fftypes.FFBigInt
+	maxPriorityFeePerGas *fftypes.FFBigInt
+}
+
+// feePolicies holds the default fee policy, any overrides for individual namespaces,
+// and the client for the gas oracle used by the estimate policy
+type feePolicies struct {
+	defaultPolicy *feePolicy
+	namespaces    map[string]*feePolicy
+	oracle        *resty.Client
+	oracleMethod  string
+}
+
+type feeOracleResponse struct {
+	GasPrice             *fftypes.FFBigInt `json:"gasPrice"`
+	MaxFeePerGas         *fftypes.FFBigInt `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *fftypes.FFBigInt `json:"maxPriorityFeePerGas"`
+}
+
+func newFeePolicies(ctx context.Context, feesConf config.Prefix) (fp *feePolicies, err error) {
+	fp = &feePolicies{
+		namespaces: make(map[string]*feePolicy),
+	}
+	oracleConf := feesConf.SubPrefix(FeesOracleConfigKey)
+	if oracleConf.GetString(restclient.HTTPConfigURL) != "" {
+		fp.oracle = restclient.New(ctx, oracleConf)
+		fp.oracleMethod = oracleConf.GetString(FeesOracleMethod)
+	}
+
+	if fp.defaultPolicy, err = fp.parseFeePolicy(ctx, feesConf); err != nil {
+		return nil, err
+	}
+	nsArray := feeNamespacesConfig(feesConf)
+	for i := 0; i < nsArray.ArraySize(); i++ {
+		entry := nsArray.ArrayEntry(i)
+		name := entry.GetString(FeesNamespaceName)
+		if name == "" {
+			return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, entry.Resolve(FeesNamespaceName), "ethereum")
+		}
+		if fp.namespaces[name], err = fp.parseFeePolicy(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+	return fp, nil
+}
+
+func parseWei(ctx context.Context, conf config.Prefix, key string) (*fftypes.FFBigInt, error) {
+	str := conf.GetString(key)
+	if str == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, conf.Resolve(key), "ethereum")
+	}
+	var i big.Int
+	if _, ok := i.SetString(str, 0); !ok || i.Sign() < 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidFeeValue, str, conf.Resolve(key))
+	}
+	return (*fftypes.FFBigInt)(&i), nil
+}
+
+func (fp *feePolicies) parseFeePolicy(ctx context.Context, conf config.Prefix) (p *feePolicy, err error) {
+	policy := fftypes.FeePolicyType(strings.ToLower(conf.GetString(FeesPolicy)))
+	p = &feePolicy{policy: policy}
+	switch policy {
+	case "":
+		// Leave the fee for ethconnect to choose
+		return nil, nil
+	case fftypes.FeePolicyTypeFixed:
+		p.gasPrice, err = parseWei(ctx, conf, FeesGasPrice)
+	case fftypes.FeePolicyTypeEIP1559:
+		if p.maxFeePerGas, err = parseWei(ctx, conf, FeesMaxFeePerGas); err == nil {
+			p.maxPriorityFeePerGas, err = parseWei(ctx, conf, FeesMaxPriorityFeePerGas)
+		}
+	case fftypes.FeePolicyTypeEstimate:
+		if fp.oracle == nil {
+			err = i18n.NewError(ctx, i18n.MsgMissingPluginConfig, conf.Resolve(FeesOracleConfigKey+"."+restclient.HTTPConfigURL), "ethereum")
+		}
+	default:
+		err = i18n.NewError(ctx, i18n.MsgInvalidFeePolicy, policy, conf.Resolve(FeesPolicy))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (fp *feePolicies) resolve(ctx context.Context, ns string) (*fftypes.TransactionFee, error) {
+	p, ok := fp.namespaces[ns]
+	if !ok {
+		p = fp.defaultPolicy
+	}
+	if p == nil {
+		return nil, nil
+	}
+	switch p.policy {
+	case fftypes.FeePolicyTypeFixed:
+		return &fftypes.TransactionFee{
+			Policy:   p.policy,
+			GasPrice: p.gasPrice,
+		}, nil
+	case fftypes.FeePolicyTypeEIP1559:
+		return &fftypes.TransactionFee{
+			Policy:               p.policy,
+			MaxFeePerGas:         p.maxFeePerGas,
+			MaxPriorityFeePerGas: p.maxPriorityFeePerGas,
+		}, nil
+	default:
+		return fp.estimate(ctx)
+	}
+}
+
+// estimate queries the gas oracle for the fee to use. An oracle that returns EIP-1559 fees is preferred
+// over the legacy gas price, if both are returned.
+func (fp *feePolicies) estimate(ctx context.Context) (*fftypes.TransactionFee, error) {
+	var estimate feeOracleResponse
+	res, err := fp.oracle.R().
+		SetContext(ctx).
+		SetResult(&estimate).
+		Execute(fp.oracleMethod, "")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgFeeOracleRESTErr)
+	}
+	fee := &fftypes.TransactionFee{Policy: fftypes.FeePolicyTypeEstimate}
+	switch {
+	case estimate.MaxFeePerGas != nil && estimate.MaxPriorityFeePerGas != nil:
+		fee.MaxFeePerGas = estimate.MaxFeePerGas
+		fee.MaxPriorityFeePerGas = estimate.MaxPriorityFeePerGas
+	case estimate.GasPrice != nil:
+		fee.GasPrice = estimate.GasPrice
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgFeeOracleNoEstimate)
+	}
+	return fee, nil
+}
@@ -0,0 +1,206 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethereum
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func utFeesConfigPrefix() config.Prefix {
+	config.Reset()
+	prefix := config.NewPluginConfig("utfees")
+	(&Ethereum{}).InitPrefix(prefix)
+	feesPrefix := prefix.SubPrefix(FeesConfigKey)
+	feesPrefix.AddKnownKey(FeesNamespacesConfigKey)
+	return feesPrefix
+}
+
+func TestFeePoliciesNone(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	fp, err := newFeePolicies(context.Background(), prefix)
+	assert.NoError(t, err)
+
+	e := &Ethereum{fees: fp}
+	fee, err := e.ResolveTransactionFee(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.Nil(t, fee)
+}
+
+func TestFeePoliciesFixedWithNamespaceOverride(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesPolicy, "Fixed")
+	prefix.Set(FeesGasPrice, "1000000000")
+	prefix.Set(FeesNamespacesConfigKey, map[string]interface{}{
+		"0": map[string]interface{}{
+			FeesNamespaceName:        "ns2",
+			FeesPolicy:               "eip1559",
+			FeesMaxFeePerGas:         "0x77359400",
+			FeesMaxPriorityFeePerGas: "1500000000",
+		},
+		"1": map[string]interface{}{
+			FeesNamespaceName: "ns3",
+		},
+	})
+	fp, err := newFeePolicies(context.Background(), prefix)
+	assert.NoError(t, err)
+
+	fee, err := fp.resolve(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.FeePolicyTypeFixed, fee.Policy)
+	assert.Equal(t, "1000000000", fee.GasPrice.Int().String())
+	assert.Nil(t, fee.MaxFeePerGas)
+
+	fee, err = fp.resolve(context.Background(), "ns2")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.FeePolicyTypeEIP1559, fee.Policy)
+	assert.Nil(t, fee.GasPrice)
+	assert.Equal(t, "2000000000", fee.MaxFeePerGas.Int().String())
+	assert.Equal(t, "1500000000", fee.MaxPriorityFeePerGas.Int().String())
+
+	// An override without a policy leaves the fee for ethconnect to choose
+	fee, err = fp.resolve(context.Background(), "ns3")
+	assert.NoError(t, err)
+	assert.Nil(t, fee)
+}
+
+func TestFeePoliciesBadPolicy(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesPolicy, "cheap")
+	_, err := newFeePolicies(context.Background(), prefix)
+	assert.Regexp(t, "FF10411.*cheap.*fees.policy", err)
+}
+
+func TestFeePoliciesFixedMissingGasPrice(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesPolicy, "fixed")
+	_, err := newFeePolicies(context.Background(), prefix)
+	assert.Regexp(t, "FF10138.*fees.gasPrice", err)
+}
+
+func TestFeePoliciesFixedBadGasPrice(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesPolicy, "fixed")
+	prefix.Set(FeesGasPrice, "-1")
+	_, err := newFeePolicies(context.Background(), prefix)
+	assert.Regexp(t, "FF10412.*-1.*fees.gasPrice", err)
+}
+
+func TestFeePoliciesEIP1559BadMaxFee(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesPolicy, "eip1559")
+	prefix.Set(FeesMaxFeePerGas, "lots")
+	_, err := newFeePolicies(context.Background(), prefix)
+	assert.Regexp(t, "FF10412.*lots.*fees.maxFeePerGas", err)
+}
+
+func TestFeePoliciesEstimateMissingOracle(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesPolicy, "estimate")
+	_, err := newFeePolicies(context.Background(), prefix)
+	assert.Regexp(t, "FF10138.*fees.oracle.url", err)
+}
+
+func TestFeePoliciesNamespaceMissingName(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesNamespacesConfigKey, map[string]interface{}{
+		"0": map[string]interface{}{
+			FeesPolicy: "estimate",
+		},
+	})
+	_, err := newFeePolicies(context.Background(), prefix)
+	assert.Regexp(t, "FF10138.*fees.namespaces.0.name", err)
+}
+
+func TestFeePoliciesNamespaceBadPolicy(t *testing.T) {
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesNamespacesConfigKey, map[string]interface{}{
+		"0": map[string]interface{}{
+			FeesNamespaceName: "ns1",
+			FeesPolicy:        "cheap",
+		},
+	})
+	_, err := newFeePolicies(context.Background(), prefix)
+	assert.Regexp(t, "FF10411.*fees.namespaces.0.policy", err)
+}
+
+func newTestFeeOracle(t *testing.T, status int, body string) (config.Prefix, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(status)
+		rw.Write([]byte(body))
+	}))
+	prefix := utFeesConfigPrefix()
+	prefix.Set(FeesPolicy, "estimate")
+	prefix.SubPrefix(FeesOracleConfigKey).Set(restclient.HTTPConfigURL, server.URL)
+	prefix.SubPrefix(FeesOracleConfigKey).Set(FeesOracleMethod, "POST")
+	return prefix, server.Close
+}
+
+func TestFeePoliciesEstimateEIP1559(t *testing.T) {
+	prefix, done := newTestFeeOracle(t, 200, `{"gasPrice": "100", "maxFeePerGas": 200, "maxPriorityFeePerGas": "50"}`)
+	defer done()
+	fp, err := newFeePolicies(context.Background(), prefix)
+	assert.NoError(t, err)
+
+	fee, err := fp.resolve(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.FeePolicyTypeEstimate, fee.Policy)
+	assert.Nil(t, fee.GasPrice)
+	assert.Equal(t, "200", fee.MaxFeePerGas.Int().String())
+	assert.Equal(t, "50", fee.MaxPriorityFeePerGas.Int().String())
+}
+
+func TestFeePoliciesEstimateGasPrice(t *testing.T) {
+	prefix, done := newTestFeeOracle(t, 200, `{"gasPrice": "100", "maxFeePerGas": "200"}`)
+	defer done()
+	fp, err := newFeePolicies(context.Background(), prefix)
+	assert.NoError(t, err)
+
+	fee, err := fp.resolve(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", fee.GasPrice.Int().String())
+	assert.Nil(t, fee.MaxFeePerGas)
+}
+
+func TestFeePoliciesEstimateNoValues(t *testing.T) {
+	prefix, done := newTestFeeOracle(t, 200, `{}`)
+	defer done()
+	fp, err := newFeePolicies(context.Background(), prefix)
+	assert.NoError(t, err)
+
+	_, err = fp.resolve(context.Background(), "ns1")
+	assert.Regexp(t, "FF10414", err)
+}
+
+func TestFeePoliciesEstimateError(t *testing.T) {
+	prefix, done := newTestFeeOracle(t, 500, `{"error": "pop"}`)
+	defer done()
+	fp, err := newFeePolicies(context.Background(), prefix)
+	assert.NoError(t, err)
+
+	_, err = fp.resolve(context.Background(), "ns1")
+	assert.Regexp(t, "FF10413.*pop", err)
+}
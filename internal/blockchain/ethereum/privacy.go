@@ -0,0 +1,193 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// privacyGroup is the Besu privacy group that the pins of a FireFly group are submitted in, for the current
+// set of member privacy keys, along with the subscription that delivers those pins
+type privacyGroup struct {
+	members string
+	id      string
+	subID   string
+}
+
+type besuPrivacyGroup struct {
+	PrivacyGroupID string   `json:"privacyGroupId"`
+	Name           string   `json:"name,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Members        []string `json:"members,omitempty"`
+}
+
+type besuCreatePrivacyGroup struct {
+	Addresses   []string `json:"addresses"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+}
+
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonRPCResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *jsonRPCError   `json:"error,omitempty"`
+}
+
+func (e *Ethereum) PrivacyKey() string {
+	return e.privateFrom
+}
+
+func (e *Ethereum) besuRPC(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	var rpcRes jsonRPCResponse
+	res, err := e.besu.R().
+		SetContext(ctx).
+		SetBody(&jsonRPCRequest{
+			JSONRPC: "2.0",
+			ID:      1,
+			Method:  method,
+			Params:  params,
+		}).
+		SetResult(&rpcRes).
+		Post("")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgBesuRPCErr)
+	}
+	if rpcRes.Error != nil {
+		return i18n.NewError(ctx, i18n.MsgBesuRPCErr, fmt.Sprintf("%s [%d] %s", method, rpcRes.Error.Code, rpcRes.Error.Message))
+	}
+	if err := json.Unmarshal(rpcRes.Result, result); err != nil {
+		return i18n.NewError(ctx, i18n.MsgBesuRPCErr, fmt.Sprintf("%s %s", method, err))
+	}
+	return nil
+}
+
+// findOrCreatePrivacyGroup returns the ID of the privacy group containing exactly the supplied members, creating it if
+// none exists. The sender of the first batch to the group creates it, before the batch is transmitted to the other
+// members, so the members find the same group when they receive the batch.
+func (e *Ethereum) findOrCreatePrivacyGroup(ctx context.Context, group *fftypes.Bytes32, members []string) (string, error) {
+	var existing []*besuPrivacyGroup
+	if err := e.besuRPC(ctx, "priv_findPrivacyGroup", &existing, members); err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		// Choose consistently between groups created concurrently, so all members submit and subscribe to the same one
+		sort.Slice(existing, func(i, j int) bool { return existing[i].PrivacyGroupID < existing[j].PrivacyGroupID })
+		return existing[0].PrivacyGroupID, nil
+	}
+
+	var id string
+	err := e.besuRPC(ctx, "priv_createPrivacyGroup", &id, &besuCreatePrivacyGroup{
+		Addresses:   members,
+		Name:        fmt.Sprintf("firefly-%s", group),
+		Description: fmt.Sprintf("FireFly group %s", group),
+	})
+	if err != nil {
+		return "", err
+	}
+	log.L(ctx).Infof("Created privacy group %s for group=%s members=%d", id, group, len(members))
+	return id, nil
+}
+
+func (e *Ethereum) EnsurePrivacyGroup(ctx context.Context, group *fftypes.Bytes32, privacyKeys []string) error {
+	// This node is always a member of the privacy groups it submits to
+	unique := map[string]bool{e.privateFrom: true}
+	members := []string{e.privateFrom}
+	for _, key := range privacyKeys {
+		if !unique[key] {
+			unique[key] = true
+			members = append(members, key)
+		}
+	}
+	sort.Strings(members)
+	memberList := strings.Join(members, ",")
+
+	e.privacyMux.Lock()
+	defer e.privacyMux.Unlock()
+	if pg, ok := e.privacyGroups[*group]; ok && pg.members == memberList && e.isPrivateBatchPinSubscription(pg.subID) {
+		return nil
+	}
+
+	id, err := e.findOrCreatePrivacyGroup(ctx, group, members)
+	if err != nil {
+		return err
+	}
+	sub, err := e.streams.ensurePrivateSubscription(ctx, e.instancePath, e.eventStreamID(), batchPinEventABI, id)
+	if err != nil {
+		return err
+	}
+	e.subMux.Lock()
+	e.privateSubs[sub.ID] = true
+	e.subMux.Unlock()
+
+	e.privacyGroups[*group] = &privacyGroup{
+		members: memberList,
+		id:      id,
+		subID:   sub.ID,
+	}
+	return nil
+}
+
+func (e *Ethereum) privacyGroupID(group *fftypes.Bytes32) string {
+	e.privacyMux.Lock()
+	defer e.privacyMux.Unlock()
+	if pg, ok := e.privacyGroups[*group]; ok {
+		return pg.id
+	}
+	return ""
+}
+
+// loadPrivateSubscriptions registers the existing subscriptions to the pins of privacy groups on the stream.
+// Must be called with subMux held.
+func (e *Ethereum) loadPrivateSubscriptions(ctx context.Context, streamID string) error {
+	subs, err := e.streams.getSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	e.privateSubs = make(map[string]bool)
+	for _, sub := range subs {
+		if sub.Stream == streamID && sub.PrivacyGroupID != "" && strings.HasPrefix(sub.Name, batchPinEventABI.Name+"_") {
+			e.privateSubs[sub.ID] = true
+		}
+	}
+	return nil
+}
+
+func (e *Ethereum) isPrivateBatchPinSubscription(subID string) bool {
+	e.subMux.Lock()
+	defer e.subMux.Unlock()
+	return e.privateSubs[subID]
+}
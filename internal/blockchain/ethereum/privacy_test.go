@@ -0,0 +1,329 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testPrivacyKey1 = "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="
+	testPrivacyKey2 = "QfeDAys9MPDs2XHExtc84jKGHxZg/aj52DTh0vtA3Xc="
+)
+
+const testPrivateBatchPinEvent = `[
+  {
+		"address": "0x1C197604587F046FD40684A8f21f4609FB811A7b",
+		"blockNumber": "38011",
+		"transactionIndex": "0x0",
+		"transactionHash": "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628",
+		"data": {
+			"author": "0X91D2B4381A4CD5C7C0F27565A7D4B829844C8635",
+			"namespace": "ns1",
+			"uuids": "0xe19af8b390604051812d7597d19adfb9847d3bfd074249efb65d3fed15f5b0a6",
+			"batchHash": "0xd71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be",
+			"payloadRef": "",
+			"contexts": [
+				"0x68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a"
+			]
+    },
+		"subId": "sb-private",
+		"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
+		"logIndex": "50",
+		"timestamp": "1620576488"
+  }
+]`
+
+func newTestPrivateEthereum() (*Ethereum, func()) {
+	e, cancel := newTestEthereum()
+	e.privateFrom = testPrivacyKey1
+	e.besu = resty.New().SetBaseURL("http://besu:8545")
+	e.privacyGroups = make(map[fftypes.Bytes32]*privacyGroup)
+	e.privateSubs = make(map[string]bool)
+	e.streams = &streamManager{client: e.client}
+	e.initInfo.stream = &eventStream{ID: "es12345"}
+	e.initInfo.sub = &subscription{ID: "sb-public"}
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	httpmock.ActivateNonDefault(e.besu.GetClient())
+	return e, func() {
+		httpmock.DeactivateAndReset()
+		cancel()
+	}
+}
+
+func besuResponder(t *testing.T, results map[string]interface{}) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		var rpcReq jsonRPCRequest
+		err := json.NewDecoder(req.Body).Decode(&rpcReq)
+		assert.NoError(t, err)
+		result, ok := results[rpcReq.Method]
+		if !ok {
+			return httpmock.NewJsonResponderOrPanic(200, &jsonRPCResponse{
+				Error: &jsonRPCError{Code: -32601, Message: "Method not found"},
+			})(req)
+		}
+		b, _ := json.Marshal(result)
+		return httpmock.NewJsonResponderOrPanic(200, &jsonRPCResponse{Result: b})(req)
+	}
+}
+
+func TestPrivacyKey(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+	assert.Equal(t, testPrivacyKey1, e.PrivacyKey())
+}
+
+func TestInitPrivateTransactionsLoadsSubscriptions(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []eventStream{{ID: "es12345", WebSocket: eventStreamWebsocket{Topic: "topic1"}}}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{
+			{ID: "sub12345", Stream: "es12345", Name: "BatchPin_30783132333435e3"},
+			{ID: "sb-private", Stream: "es12345", Name: privateSubscriptionName("0x12345", batchPinEventABI, "pg1"), PrivacyGroupID: "pg1"},
+			{ID: "sb-other-stream", Stream: "es67890", Name: privateSubscriptionName("0x12345", batchPinEventABI, "pg2"), PrivacyGroupID: "pg2"},
+			{ID: "sb-contract", Stream: "es12345", Name: "ff-sub-1234"},
+		}))
+	httpmock.RegisterResponder("PATCH", "http://localhost:12345/eventstreams/es12345",
+		httpmock.NewJsonResponderOrPanic(200, &eventStream{ID: "es12345", WebSocket: eventStreamWebsocket{Topic: "topic1"}}))
+
+	resetConf()
+	utEthconnectConf.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utEthconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+	utEthconnectConf.Set(EthconnectConfigInstancePath, "0x12345")
+	utEthconnectConf.Set(EthconnectConfigTopic, "topic1")
+	utPrivateTxConf.Set(PrivateTransactionsEnabled, true)
+	utPrivateTxConf.Set(PrivateTransactionsPrivateFrom, testPrivacyKey1)
+	utPrivateTxConf.SubPrefix(PrivateTransactionsBesuConfigKey).Set(restclient.HTTPConfigURL, "http://besu:8545")
+
+	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "sub12345", e.initInfo.sub.ID)
+	assert.Equal(t, map[string]bool{"sb-private": true}, e.privateSubs)
+	assert.NotNil(t, e.besu)
+}
+
+func TestInitPrivateTransactionsLoadSubscriptionsFail(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.loadPrivateSubscriptions(context.Background(), "es12345")
+	assert.Regexp(t, "FF10111", err)
+}
+
+func TestEnsurePrivacyGroupFindDeliversPins(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+	group := fftypes.NewRandB32()
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", besuResponder(t, map[string]interface{}{
+		"priv_findPrivacyGroup": []*besuPrivacyGroup{
+			{PrivacyGroupID: "pg2"},
+			{PrivacyGroupID: "pg1"},
+		},
+	}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/subscriptions",
+		func(req *http.Request) (*http.Response, error) {
+			var body subscription
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "pg1", body.PrivacyGroupID)
+			assert.Equal(t, "es12345", body.Stream)
+			assert.Equal(t, "0", body.FromBlock)
+			assert.Equal(t, privateSubscriptionName(e.instancePath, batchPinEventABI, "pg1"), body.Name)
+			body.ID = "sb-private"
+			return httpmock.NewJsonResponderOrPanic(200, body)(req)
+		})
+
+	// The receiving node finds the privacy group the sender created, whatever the order of the members
+	err := e.EnsurePrivacyGroup(context.Background(), group, []string{testPrivacyKey2, testPrivacyKey1})
+	assert.NoError(t, err)
+	assert.Equal(t, "pg1", e.privacyGroupID(group))
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+
+	// Cached while the members are unchanged
+	err = e.EnsurePrivacyGroup(context.Background(), group, []string{testPrivacyKey2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+
+	// The pins delivered on the subscription of the privacy group are passed to the aggregator
+	em := e.callbacks.(*blockchainmocks.Callbacks)
+	em.On("BatchPinComplete", mock.Anything, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635").Return(nil)
+	var events []interface{}
+	err = json.Unmarshal([]byte(testPrivateBatchPinEvent), &events)
+	assert.NoError(t, err)
+	err = e.handleMessageBatch(context.Background(), events)
+	assert.NoError(t, err)
+
+	em.AssertExpectations(t)
+	b := em.Calls[0].Arguments[0].(*blockchain.BatchPin)
+	assert.Equal(t, "ns1", b.Namespace)
+	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", b.BatchID.String())
+	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", b.Event.BlockchainTXID)
+	assert.Len(t, b.Contexts, 1)
+}
+
+func TestEnsurePrivacyGroupCreate(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+	group := fftypes.NewRandB32()
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", func(req *http.Request) (*http.Response, error) {
+		var rpcReq jsonRPCRequest
+		json.NewDecoder(req.Body).Decode(&rpcReq)
+		if rpcReq.Method == "priv_createPrivacyGroup" {
+			create := rpcReq.Params[0].(map[string]interface{})
+			assert.Equal(t, []interface{}{testPrivacyKey1, testPrivacyKey2}, create["addresses"])
+			assert.Equal(t, fmt.Sprintf("firefly-%s", group), create["name"])
+			return httpmock.NewJsonResponderOrPanic(200, &jsonRPCResponse{Result: json.RawMessage(`"pg1"`)})(req)
+		}
+		assert.Equal(t, "priv_findPrivacyGroup", rpcReq.Method)
+		assert.Equal(t, []interface{}{[]interface{}{testPrivacyKey1, testPrivacyKey2}}, rpcReq.Params)
+		return httpmock.NewJsonResponderOrPanic(200, &jsonRPCResponse{Result: json.RawMessage(`[]`)})(req)
+	})
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, &subscription{ID: "sb-private"}))
+
+	err := e.EnsurePrivacyGroup(context.Background(), group, []string{testPrivacyKey1, testPrivacyKey2, testPrivacyKey2})
+	assert.NoError(t, err)
+	assert.Equal(t, "pg1", e.privacyGroupID(group))
+	assert.True(t, e.isPrivateBatchPinSubscription("sb-private"))
+}
+
+func TestEnsurePrivacyGroupSubscriptionLost(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+	group := fftypes.NewRandB32()
+	e.privacyGroups[*group] = &privacyGroup{members: testPrivacyKey1, id: "pg1", subID: "sb-lost"}
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", besuResponder(t, map[string]interface{}{
+		"priv_findPrivacyGroup": []*besuPrivacyGroup{{PrivacyGroupID: "pg1"}},
+	}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{
+			{ID: "sb-private", Name: privateSubscriptionName(e.instancePath, batchPinEventABI, "pg1"), PrivacyGroupID: "pg1"},
+		}))
+
+	// The subscription is no longer known after ethconnect was reset, so is looked up again
+	err := e.EnsurePrivacyGroup(context.Background(), group, []string{})
+	assert.NoError(t, err)
+	assert.True(t, e.isPrivateBatchPinSubscription("sb-private"))
+	assert.Equal(t, "sb-private", e.privacyGroups[*group].subID)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestEnsurePrivacyGroupFindFail(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", httpmock.NewStringResponder(500, "pop"))
+
+	err := e.EnsurePrivacyGroup(context.Background(), fftypes.NewRandB32(), []string{testPrivacyKey2})
+	assert.Regexp(t, "FF10515.*pop", err)
+}
+
+func TestEnsurePrivacyGroupRPCError(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", besuResponder(t, map[string]interface{}{}))
+
+	err := e.EnsurePrivacyGroup(context.Background(), fftypes.NewRandB32(), []string{testPrivacyKey2})
+	assert.Regexp(t, "FF10515.*priv_findPrivacyGroup.*Method not found", err)
+}
+
+func TestEnsurePrivacyGroupBadResult(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", besuResponder(t, map[string]interface{}{
+		"priv_findPrivacyGroup": "not an array",
+	}))
+
+	err := e.EnsurePrivacyGroup(context.Background(), fftypes.NewRandB32(), []string{testPrivacyKey2})
+	assert.Regexp(t, "FF10515.*priv_findPrivacyGroup", err)
+}
+
+func TestEnsurePrivacyGroupCreateFail(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", besuResponder(t, map[string]interface{}{
+		"priv_findPrivacyGroup": []*besuPrivacyGroup{},
+	}))
+
+	err := e.EnsurePrivacyGroup(context.Background(), fftypes.NewRandB32(), []string{testPrivacyKey2})
+	assert.Regexp(t, "FF10515.*priv_createPrivacyGroup", err)
+}
+
+func TestEnsurePrivacyGroupSubscribeFail(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+	group := fftypes.NewRandB32()
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", besuResponder(t, map[string]interface{}{
+		"priv_findPrivacyGroup": []*besuPrivacyGroup{{PrivacyGroupID: "pg1"}},
+	}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/subscriptions",
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.EnsurePrivacyGroup(context.Background(), group, []string{testPrivacyKey2})
+	assert.Regexp(t, "FF10111", err)
+	assert.Empty(t, e.privacyGroupID(group))
+}
+
+func TestEnsurePrivacyGroupListSubscriptionsFail(t *testing.T) {
+	e, cancel := newTestPrivateEthereum()
+	defer cancel()
+
+	httpmock.RegisterResponder("POST", "http://besu:8545", besuResponder(t, map[string]interface{}{
+		"priv_findPrivacyGroup": []*besuPrivacyGroup{{PrivacyGroupID: "pg1"}},
+	}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.EnsurePrivacyGroup(context.Background(), fftypes.NewRandB32(), []string{testPrivacyKey2})
+	assert.Regexp(t, "FF10111", err)
+}
@@ -17,6 +17,7 @@
 package fabric
 
 import (
+	"github.com/hyperledger/firefly/internal/blockchain/connectorhealth"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/config/wsconfig"
 )
@@ -49,11 +50,15 @@ const (
 	FabconnectPrefixShort = "prefixShort"
 	// FabconnectPrefixLong is used in HTTP headers in requests to ethconnect
 	FabconnectPrefixLong = "prefixLong"
+	// FabconnectConfigIdentityRegistry is the name of an identity registry chaincode on the default channel, with an
+	// IsRegistered(key) function, that the authors of batches can be verified against
+	FabconnectConfigIdentityRegistry = "identityRegistry"
 )
 
 func (f *Fabric) InitPrefix(prefix config.Prefix) {
 	fabconnectConf := prefix.SubPrefix(FabconnectConfigKey)
 	wsconfig.InitPrefix(fabconnectConf)
+	connectorhealth.InitPrefix(fabconnectConf)
 	fabconnectConf.AddKnownKey(FabconnectConfigDefaultChannel)
 	fabconnectConf.AddKnownKey(FabconnectConfigChaincode)
 	fabconnectConf.AddKnownKey(FabconnectConfigSigner)
@@ -62,4 +67,5 @@ func (f *Fabric) InitPrefix(prefix config.Prefix) {
 	fabconnectConf.AddKnownKey(FabconnectConfigBatchTimeout, defaultBatchTimeout)
 	fabconnectConf.AddKnownKey(FabconnectPrefixShort, defaultPrefixShort)
 	fabconnectConf.AddKnownKey(FabconnectPrefixLong, defaultPrefixLong)
+	fabconnectConf.AddKnownKey(FabconnectConfigIdentityRegistry)
 }
@@ -25,8 +25,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/blockchain/connectorhealth"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/config/wsconfig"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -46,6 +48,7 @@ type Fabric struct {
 	topic          string
 	defaultChannel string
 	chaincode      string
+	registry       string
 	signer         string
 	prefixShort    string
 	prefixLong     string
@@ -57,9 +60,13 @@ type Fabric struct {
 		stream *eventStream
 		sub    *subscription
 	}
-	idCache map[string]*fabIdentity
-	wsconn  wsclient.WSClient
-	closed  chan struct{}
+	subMux       sync.Mutex
+	idCache      map[string]*fabIdentity
+	wsconn       wsclient.WSClient
+	closed       chan struct{}
+	batchSize    uint
+	batchTimeout uint
+	health       *connectorhealth.Monitor
 }
 
 type eventStreamWebsocket struct {
@@ -153,7 +160,7 @@ func (f *Fabric) Init(ctx context.Context, prefix config.Prefix, callbacks block
 
 	fabconnectConf := prefix.SubPrefix(FabconnectConfigKey)
 
-	f.ctx = log.WithLogField(ctx, "proto", "fabric")
+	f.ctx = log.WithLogField(log.WithModule(ctx, "blockchain"), "proto", "fabric")
 	f.callbacks = callbacks
 	f.idCache = make(map[string]*fabIdentity)
 
@@ -176,8 +183,10 @@ func (f *Fabric) Init(ctx context.Context, prefix config.Prefix, callbacks block
 	f.prefixLong = fabconnectConf.GetString(FabconnectPrefixLong)
 
 	f.client = restclient.New(f.ctx, fabconnectConf)
+	f.registry = fabconnectConf.GetString(FabconnectConfigIdentityRegistry)
 	f.capabilities = &blockchain.Capabilities{
-		GlobalSequencer: true,
+		GlobalSequencer:  true,
+		IdentityRegistry: f.registry != "",
 	}
 
 	wsConfig := wsconfig.GenerateConfigFromPrefix(fabconnectConf)
@@ -195,34 +204,71 @@ func (f *Fabric) Init(ctx context.Context, prefix config.Prefix, callbacks block
 		client: f.client,
 		signer: f.signer,
 	}
-	batchSize := fabconnectConf.GetUint(FabconnectConfigBatchSize)
-	batchTimeout := uint(fabconnectConf.GetDuration(FabconnectConfigBatchTimeout).Milliseconds())
-	if f.initInfo.stream, err = f.streams.ensureEventStream(f.ctx, f.topic, batchSize, batchTimeout); err != nil {
+	f.batchSize = fabconnectConf.GetUint(FabconnectConfigBatchSize)
+	f.batchTimeout = uint(fabconnectConf.GetDuration(FabconnectConfigBatchTimeout).Milliseconds())
+	if err = f.initEventStream(f.ctx); err != nil {
 		return err
 	}
-	log.L(f.ctx).Infof("Event stream: %s", f.initInfo.stream.ID)
+	f.health = connectorhealth.NewMonitor(f.ctx, fabconnectConf, f.client, f.wsconn, i18n.MsgFabconnectRESTErr, f.initEventStream)
+
+	f.closed = make(chan struct{})
+	go f.eventLoop()
+
+	return nil
+}
+
+// initEventStream ensures the event stream and batch pin subscription exist in fabconnect. This is called on
+// startup, and again each time fabconnect recovers from a failed health check, in case it was restarted without its state.
+func (f *Fabric) initEventStream(ctx context.Context) error {
+	f.subMux.Lock()
+	defer f.subMux.Unlock()
+	stream, err := f.streams.ensureEventStream(ctx, f.topic, f.batchSize, f.batchTimeout)
+	if err != nil {
+		return err
+	}
+	f.initInfo.stream = stream
+	log.L(ctx).Infof("Event stream: %s", stream.ID)
 	location := &Location{
 		Channel:   f.defaultChannel,
 		Chaincode: f.chaincode,
 	}
-	if f.initInfo.sub, err = f.streams.ensureSubscription(f.ctx, location, f.initInfo.stream.ID, batchPinEvent); err != nil {
+	sub, err := f.streams.ensureSubscription(ctx, location, stream.ID, batchPinEvent)
+	if err != nil {
 		return err
 	}
-
-	f.closed = make(chan struct{})
-	go f.eventLoop()
-
+	f.initInfo.sub = sub
 	return nil
 }
 
+func (f *Fabric) eventStreamID() string {
+	f.subMux.Lock()
+	defer f.subMux.Unlock()
+	return f.initInfo.stream.ID
+}
+
 func (f *Fabric) Start() error {
-	return f.wsconn.Connect()
+	err := f.wsconn.Connect()
+	if err == nil && f.health != nil {
+		f.health.Start()
+	}
+	return err
+}
+
+func (f *Fabric) ConnectorHealth() *fftypes.ConnectorHealth {
+	if f.health == nil {
+		return nil
+	}
+	return f.health.Health()
 }
 
 func (f *Fabric) Capabilities() *blockchain.Capabilities {
 	return f.capabilities
 }
 
+func (f *Fabric) VerifierType() fftypes.VerifierType {
+	return fftypes.VerifierTypeMSPIdentity
+}
+
 func (f *Fabric) afterConnect(ctx context.Context, w wsclient.WSClient) error {
 	// Send a subscribe to our topic after each connect/reconnect
 	b, _ := json.Marshal(&fabWSCommandPayload{
@@ -255,10 +301,21 @@ func decodeJSONPayload(ctx context.Context, payloadString string) *fftypes.JSONO
 }
 
 func (f *Fabric) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONObject) (err error) {
+	batch, signer := f.parseBatchPinEvent(ctx, msgJSON)
+	if batch == nil {
+		return nil // move on
+	}
+
+	// If there's an error dispatching the event, we must return the error and shutdown
+	return f.callbacks.BatchPinComplete(batch, signer)
+}
+
+// parseBatchPinEvent returns the batch pin and the signing identity of a BatchPin event, or nil if the event is not valid
+func (f *Fabric) parseBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONObject) (batch *blockchain.BatchPin, signer string) {
 	payloadString := msgJSON.GetString("payload")
 	payload := decodeJSONPayload(ctx, payloadString)
 	if payload == nil {
-		return nil // move on
+		return nil, "" // move on
 	}
 
 	sTransactionHash := msgJSON.GetString("transactionId")
@@ -266,7 +323,7 @@ func (f *Fabric) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONOb
 	transactionIndex := msgJSON.GetInt64("transactionIndex")
 	eventIndex := msgJSON.GetInt64("eventIndex")
 	timestamp := msgJSON.GetInt64("timestamp")
-	signer := payload.GetString("signer")
+	signer = payload.GetString("signer")
 	ns := payload.GetString("namespace")
 	sUUIDs := payload.GetString("uuids")
 	sBatchHash := payload.GetString("batchHash")
@@ -276,7 +333,7 @@ func (f *Fabric) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONOb
 	hexUUIDs, err := hex.DecodeString(strings.TrimPrefix(sUUIDs, "0x"))
 	if err != nil || len(hexUUIDs) != 32 {
 		log.L(ctx).Errorf("BatchPin event is not valid - bad uuids (%s): %s", sUUIDs, err)
-		return nil // move on
+		return nil, "" // move on
 	}
 	var txnID fftypes.UUID
 	copy(txnID[:], hexUUIDs[0:16])
@@ -287,7 +344,7 @@ func (f *Fabric) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONOb
 	err = batchHash.UnmarshalText([]byte(sBatchHash))
 	if err != nil {
 		log.L(ctx).Errorf("BatchPin event is not valid - bad batchHash (%s): %s", sBatchHash, err)
-		return nil // move on
+		return nil, "" // move on
 	}
 
 	contexts := make([]*fftypes.Bytes32, len(sContexts))
@@ -296,13 +353,13 @@ func (f *Fabric) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONOb
 		err = hash.UnmarshalText([]byte(sHash))
 		if err != nil {
 			log.L(ctx).Errorf("BatchPin event is not valid - bad pin %d (%s): %s", i, sHash, err)
-			return nil // move on
+			return nil, "" // move on
 		}
 		contexts[i] = &hash
 	}
 
 	delete(msgJSON, "payload")
-	batch := &blockchain.BatchPin{
+	batch = &blockchain.BatchPin{
 		Namespace:       ns,
 		TransactionID:   &txnID,
 		BatchID:         &batchID,
@@ -319,9 +376,7 @@ func (f *Fabric) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONOb
 			Timestamp:      fftypes.UnixTime(timestamp),
 		},
 	}
-
-	// If there's an error dispatching the event, we must return the error and shutdown
-	return f.callbacks.BatchPinComplete(batch, signer)
+	return batch, signer
 }
 
 func (f *Fabric) handleContractEvent(ctx context.Context, msgJSON fftypes.JSONObject) (err error) {
@@ -382,8 +437,15 @@ func (f *Fabric) handleReceipt(ctx context.Context, reply fftypes.JSONObject) er
 	if replyType != "TransactionSuccess" {
 		updateType = fftypes.OpStatusFailed
 	}
+	var receipt *fftypes.TransactionReceipt
+	if _, ok := reply.GetStringOk("blockNumber"); ok {
+		// Fabric has no concept of gas, or of a revert reason separate to the error message
+		receipt = &fftypes.TransactionReceipt{
+			BlockNumber: (*fftypes.FFBigInt)(reply.GetInteger("blockNumber")),
+		}
+	}
 	l.Infof("Fabconnect '%s' reply tx=%s (request=%s) %s", replyType, txHash, requestID, message)
-	return f.callbacks.BlockchainOpUpdate(operationID, updateType, txHash, message, reply)
+	return f.callbacks.BlockchainOpUpdate(operationID, updateType, txHash, message, reply, receipt)
 }
 
 func (f *Fabric) handleMessageBatch(ctx context.Context, messages []interface{}) error {
@@ -404,7 +466,7 @@ func (f *Fabric) handleMessageBatch(ctx context.Context, messages []interface{})
 		l1.Infof("Received '%s' message", eventName)
 		l1.Tracef("Message: %+v", msgJSON)
 
-		if sub == f.initInfo.sub.ID {
+		if sub == f.batchPinSubscriptionID() {
 			switch eventName {
 			case broadcastBatchEventName:
 				if err := f.handleBatchPinEvent(ctx1, msgJSON); err != nil {
@@ -537,6 +599,20 @@ func hexFormatB32(b *fftypes.Bytes32) string {
 	return "0x" + hex.EncodeToString(b[0:32])
 }
 
+func (f *Fabric) ResolveTransactionFee(ctx context.Context, ns string) (*fftypes.TransactionFee, error) {
+	// Fabric transactions do not have a fee
+	return nil, nil
+}
+
+func (f *Fabric) PrivacyKey() string {
+	// Fabric pins are visible to the members of the channel
+	return ""
+}
+
+func (f *Fabric) EnsurePrivacyGroup(ctx context.Context, group *fftypes.Bytes32, privacyKeys []string) error {
+	return nil
+}
+
 func (f *Fabric) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batch *blockchain.BatchPin) error {
 	hashes := make([]string, len(batch.Contexts))
 	for i, v := range batch.Contexts {
@@ -562,7 +638,7 @@ func (f *Fabric) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID,
 	return nil
 }
 
-func (f *Fabric) InvokeContract(ctx context.Context, operationID *fftypes.UUID, signingKey string, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}) error {
+func (f *Fabric) InvokeContract(ctx context.Context, operationID *fftypes.UUID, signingKey string, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}, fee *fftypes.TransactionFee) error {
 	// All arguments must be JSON serialized
 	args, err := jsonEncodeInput(input)
 	if err != nil {
@@ -596,6 +672,34 @@ func (f *Fabric) InvokeContract(ctx context.Context, operationID *fftypes.UUID,
 	return nil
 }
 
+func (f *Fabric) SubmitRawTransaction(ctx context.Context, operationID *fftypes.UUID, signingKey string, tx fftypes.JSONObject) error {
+	// The caller supplies the full Fabconnect transaction, but we own the headers that
+	// correlate the receipt back to the operation, and the signer
+	body := fftypes.JSONObject{}
+	for k, v := range tx {
+		body[k] = v
+	}
+	headers := fftypes.JSONObject{}
+	for k, v := range tx.GetObject("headers") {
+		headers[k] = v
+	}
+	headers["id"] = operationID.String()
+	headers["signer"] = getUserName(signingKey)
+	if headers.GetString("channel") == "" {
+		headers["channel"] = f.defaultChannel
+	}
+	body["headers"] = headers
+
+	res, err := f.client.R().
+		SetContext(ctx).
+		SetBody(body).
+		Post("/transactions")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+	return nil
+}
+
 func (f *Fabric) QueryContract(ctx context.Context, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}) (interface{}, error) {
 	// All arguments must be JSON serialized
 	args, err := jsonEncodeInput(input)
@@ -646,6 +750,64 @@ func (f *Fabric) QueryContract(ctx context.Context, location *fftypes.JSONAny, m
 	return output.Result, nil
 }
 
+func (f *Fabric) VerifyIdentityRegistered(ctx context.Context, signingKey string, blockNumber *fftypes.FFBigInt) (bool, error) {
+	// Chaincode queries can only be evaluated against the current world state of the channel,
+	// so the registration is checked as of the latest block regardless of the block requested
+	in := &fabTxNamedInput{
+		Headers: &fabTxInputHeaders{
+			PayloadSchema: &PayloadSchema{
+				Type: "array",
+				PrefixItems: []*PrefixItem{
+					{Name: "key", Type: "string"},
+				},
+			},
+			Channel:   f.defaultChannel,
+			Chaincode: f.registry,
+			Signer:    f.signer,
+		},
+		Func: "IsRegistered",
+		Args: map[string]string{"key": signingKey},
+	}
+
+	res, err := f.client.R().
+		SetContext(ctx).
+		SetBody(in).
+		Post("/query")
+	if err != nil || !res.IsSuccess() {
+		return false, restclient.WrapRestErr(ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+	output := &fabQueryNamedOutput{}
+	if err = json.Unmarshal(res.Body(), output); err != nil {
+		return false, err
+	}
+	registered, _ := output.Result.(bool)
+	return registered, nil
+}
+
+func (f *Fabric) GetBatchPin(ctx context.Context, blockchainTXID string) (*blockchain.BatchPin, string, error) {
+	// The events emitted by the transaction are returned in the same form they are delivered on the event stream
+	res, err := f.client.R().
+		SetContext(ctx).
+		SetQueryParam("channel", f.defaultChannel).
+		SetQueryParam("signer", f.signer).
+		Get(fmt.Sprintf("/transactions/%s/events", blockchainTXID))
+	if err != nil || !res.IsSuccess() {
+		return nil, "", restclient.WrapRestErr(ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+	var events []fftypes.JSONObject
+	if err = json.Unmarshal(res.Body(), &events); err != nil {
+		return nil, "", err
+	}
+	for _, event := range events {
+		if event.GetString("chaincodeId") == f.chaincode && event.GetString("eventName") == broadcastBatchEventName {
+			if batch, signer := f.parseBatchPinEvent(ctx, event); batch != nil {
+				return batch, signer, nil
+			}
+		}
+	}
+	return nil, "", nil
+}
+
 func jsonEncodeInput(params map[string]interface{}) (output map[string]string, err error) {
 	output = make(map[string]string, len(params))
 	for field, value := range params {
@@ -688,7 +850,7 @@ func (f *Fabric) AddSubscription(ctx context.Context, subscription *fftypes.Cont
 	if err != nil {
 		return err
 	}
-	result, err := f.streams.createSubscription(ctx, location, f.initInfo.stream.ID, "", subscription.Event.Name)
+	result, err := f.streams.createSubscription(ctx, location, f.eventStreamID(), "", subscription.Event.Name)
 	if err != nil {
 		return err
 	}
@@ -700,11 +862,34 @@ func (f *Fabric) DeleteSubscription(ctx context.Context, subscription *fftypes.C
 	return f.streams.deleteSubscription(ctx, subscription.ProtocolID)
 }
 
+func (f *Fabric) batchPinSubscriptionID() string {
+	f.subMux.Lock()
+	defer f.subMux.Unlock()
+	return f.initInfo.sub.ID
+}
+
+// ResetBatchPinSubscription creates a new batch pin subscription from block 0, before deleting the existing one.
+// The lock is held until the new subscription is recorded, so its first events are not mistaken for contract events.
+func (f *Fabric) ResetBatchPinSubscription(ctx context.Context) error {
+	f.subMux.Lock()
+	old := f.initInfo.sub
+	location := &Location{
+		Channel:   f.defaultChannel,
+		Chaincode: f.chaincode,
+	}
+	sub, err := f.streams.createSubscription(ctx, location, f.initInfo.stream.ID, old.Name, batchPinEvent)
+	if err == nil {
+		f.initInfo.sub = sub
+	}
+	f.subMux.Unlock()
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Reset %s subscription from block 0: %s (replaces %s)", old.Name, sub.ID, old.ID)
+	return f.streams.deleteSubscription(ctx, old.ID)
+}
+
 func (f *Fabric) GetFFIParamValidator(ctx context.Context) (fftypes.FFIParamValidator, error) {
 	// Fabconnect does not require any additional validation beyond "JSON Schema correctness" at this time
 	return nil, nil
 }
-
-func (f *Fabric) GenerateFFI(ctx context.Context, generationRequest *fftypes.FFIGenerationRequest) (*fftypes.FFI, error) {
-	return nil, i18n.NewError(ctx, i18n.MsgFFIGenerationUnsupported)
-}
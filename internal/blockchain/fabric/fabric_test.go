@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
@@ -147,6 +148,8 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	u.Scheme = "http"
 	httpURL := u.String()
 
+	httpmock.RegisterResponder("GET", fmt.Sprintf("%s/status", httpURL),
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{"ok": true}))
 	httpmock.RegisterResponder("GET", fmt.Sprintf("%s/eventstreams", httpURL),
 		httpmock.NewJsonResponderOrPanic(200, []eventStream{}))
 	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/eventstreams", httpURL),
@@ -168,6 +171,7 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	utFabconnectConf.Set(FabconnectConfigChaincode, "firefly")
 	utFabconnectConf.Set(FabconnectConfigSigner, "signer001")
 	utFabconnectConf.Set(FabconnectConfigTopic, "topic1")
+	utFabconnectConf.Set(FabconnectConfigIdentityRegistry, "registry")
 
 	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
 	assert.NoError(t, err)
@@ -177,6 +181,8 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	assert.Equal(t, "es12345", e.initInfo.stream.ID)
 	assert.Equal(t, "sub12345", e.initInfo.sub.ID)
 	assert.True(t, e.Capabilities().GlobalSequencer)
+	assert.True(t, e.Capabilities().IdentityRegistry)
+	assert.Equal(t, fftypes.VerifierTypeMSPIdentity, e.VerifierType())
 
 	err = e.Start()
 	assert.NoError(t, err)
@@ -194,6 +200,11 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	fromServer <- `{"not": "a reply"}`
 	fromServer <- `42`
 
+	// Health check of the REST API and websocket passes
+	for health := e.ConnectorHealth(); !health.Healthy; health = e.ConnectorHealth() {
+		time.Sleep(1 * time.Millisecond)
+	}
+
 }
 
 func TestWSInitFail(t *testing.T) {
@@ -223,6 +234,7 @@ func TestWSConnectFail(t *testing.T) {
 
 	err := e.Start()
 	assert.EqualError(t, err, "pop")
+	assert.Nil(t, e.ConnectorHealth())
 }
 
 func TestInitAllExistingStreams(t *testing.T) {
@@ -378,6 +390,22 @@ func TestSubQueryCreateError(t *testing.T) {
 
 }
 
+func TestResolveTransactionFee(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	fee, err := e.ResolveTransactionFee(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.Nil(t, fee)
+}
+
+func TestPrivacyGroupNotSupported(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	assert.Empty(t, e.PrivacyKey())
+	err := e.EnsurePrivacyGroup(context.Background(), fftypes.NewRandB32(), []string{"key1"})
+	assert.NoError(t, err)
+}
+
 func TestSubmitBatchPinOK(t *testing.T) {
 
 	e, cancel := newTestFabric()
@@ -912,7 +940,8 @@ func TestEventLoopUnexpectedMessage(t *testing.T) {
 		fftypes.OpStatusFailed,
 		"",
 		"Packing arguments for method 'broadcastBatch': abi: cannot use [3]uint8 as type [32]uint8 as argument",
-		mock.Anything).Return(fmt.Errorf("Shutdown"))
+		mock.Anything,
+		(*fftypes.TransactionReceipt)(nil)).Return(fmt.Errorf("Shutdown"))
 	done := make(chan struct{})
 	txsu.RunFn = func(a mock.Arguments) {
 		close(done)
@@ -948,6 +977,7 @@ func TestHandleReceiptTXSuccess(t *testing.T) {
 				"type": "TransactionSuccess"
 		},
 		"transactionId": "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2",
+		"blockNumber": 91,
 		"receivedAt": 1630033474675
   }`)
 
@@ -956,7 +986,10 @@ func TestHandleReceiptTXSuccess(t *testing.T) {
 		fftypes.OpStatusSucceeded,
 		"ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2",
 		"",
-		mock.Anything).Return(nil)
+		mock.Anything,
+		mock.MatchedBy(func(receipt *fftypes.TransactionReceipt) bool {
+			return receipt.BlockNumber.Int().Int64() == 91 && receipt.GasUsed == nil
+		})).Return(nil)
 
 	err := json.Unmarshal(data, &reply)
 	assert.NoError(t, err)
@@ -1044,7 +1077,8 @@ func TestHandleReceiptFailedTx(t *testing.T) {
 		fftypes.OpStatusFailed,
 		"ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2",
 		"",
-		mock.Anything).Return(nil)
+		mock.Anything,
+		(*fftypes.TransactionReceipt)(nil)).Return(nil)
 
 	err := json.Unmarshal(data, &reply)
 	assert.NoError(t, err)
@@ -1195,6 +1229,66 @@ func TestDeleteSubscriptionFail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 }
 
+func TestResetBatchPinSubscription(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	e.initInfo.stream = &eventStream{
+		ID: "es-1",
+	}
+	e.initInfo.sub = &subscription{
+		ID:   "sb-1",
+		Name: "BatchPin",
+	}
+	e.streams = &streamManager{
+		client: e.client,
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "BatchPin", body["name"])
+			assert.Equal(t, "es-1", body["stream"])
+			assert.Equal(t, "0", body["fromBlock"])
+			return httpmock.NewJsonResponderOrPanic(200, &subscription{ID: "sb-2"})(req)
+		})
+	httpmock.RegisterResponder("DELETE", `http://localhost:12345/subscriptions/sb-1`,
+		httpmock.NewStringResponder(204, ""))
+
+	err := e.ResetBatchPinSubscription(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "sb-2", e.batchPinSubscriptionID())
+}
+
+func TestResetBatchPinSubscriptionFail(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	e.initInfo.stream = &eventStream{
+		ID: "es-1",
+	}
+	e.initInfo.sub = &subscription{
+		ID: "sb-1",
+	}
+	e.streams = &streamManager{
+		client: e.client,
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions`,
+		httpmock.NewStringResponder(500, ""))
+
+	err := e.ResetBatchPinSubscription(context.Background())
+
+	assert.Regexp(t, "FF10284", err)
+	assert.Equal(t, "sb-1", e.batchPinSubscriptionID())
+}
+
 func TestHandleMessageContractEvent(t *testing.T) {
 	data := []byte(`
 [
@@ -1349,7 +1443,7 @@ func TestInvokeContractOK(t *testing.T) {
 			assert.Equal(t, "test", body["args"].(map[string]interface{})["description"])
 			return httpmock.NewJsonResponderOrPanic(200, "")(req)
 		})
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
 	assert.NoError(t, err)
 }
 
@@ -1380,7 +1474,7 @@ func TestInvokeContractBadSchema(t *testing.T) {
 	}
 	locationBytes, err := json.Marshal(location)
 	assert.NoError(t, err)
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
 	assert.Regexp(t, "FF10151", err)
 }
 
@@ -1396,7 +1490,7 @@ func TestInvokeContractChaincodeNotSet(t *testing.T) {
 	}
 	locationBytes, err := json.Marshal(location)
 	assert.NoError(t, err)
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
 	assert.Regexp(t, "FF10310", err)
 }
 
@@ -1421,7 +1515,76 @@ func TestInvokeContractFabconnectError(t *testing.T) {
 		func(req *http.Request) (*http.Response, error) {
 			return httpmock.NewJsonResponderOrPanic(400, "")(req)
 		})
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
+	assert.Regexp(t, "FF10284", err)
+}
+
+func TestSubmitRawTransactionOK(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	signingKey := fftypes.NewRandB32().String()
+	opID := fftypes.NewUUID()
+	tx := fftypes.JSONObject{
+		"headers": map[string]interface{}{
+			"id":        "override",
+			"signer":    "override",
+			"chaincode": "admin",
+		},
+		"func": "setAdmin",
+		"args": map[string]interface{}{"admin": "org1"},
+	}
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			headers := body["headers"].(map[string]interface{})
+			assert.Equal(t, opID.String(), headers["id"])
+			assert.Equal(t, signingKey, headers["signer"])
+			assert.Equal(t, "firefly", headers["channel"])
+			assert.Equal(t, "admin", headers["chaincode"])
+			assert.Equal(t, "setAdmin", body["func"])
+			assert.Equal(t, "org1", body["args"].(map[string]interface{})["admin"])
+			return httpmock.NewJsonResponderOrPanic(200, "")(req)
+		})
+	err := e.SubmitRawTransaction(context.Background(), opID, signingKey, tx)
+	assert.NoError(t, err)
+}
+
+func TestSubmitRawTransactionChannelSet(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	signingKey := fftypes.NewRandB32().String()
+	tx := fftypes.JSONObject{
+		"headers": map[string]interface{}{
+			"channel":   "other",
+			"chaincode": "admin",
+		},
+		"func": "setAdmin",
+	}
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "other", (body["headers"].(map[string]interface{}))["channel"])
+			return httpmock.NewJsonResponderOrPanic(200, "")(req)
+		})
+	err := e.SubmitRawTransaction(context.Background(), fftypes.NewUUID(), signingKey, tx)
+	assert.NoError(t, err)
+}
+
+func TestSubmitRawTransactionFabconnectError(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	signingKey := fftypes.NewRandB32().String()
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		httpmock.NewJsonResponderOrPanic(400, ""))
+	err := e.SubmitRawTransaction(context.Background(), fftypes.NewUUID(), signingKey, fftypes.JSONObject{"func": "setAdmin"})
 	assert.Regexp(t, "FF10284", err)
 }
 
@@ -1595,7 +1758,7 @@ func TestInvokeJSONEncodeParamsError(t *testing.T) {
 		func(req *http.Request) (*http.Response, error) {
 			return httpmock.NewJsonResponderOrPanic(400, "")(req)
 		})
-	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params)
+	err = e.InvokeContract(context.Background(), nil, signingKey, fftypes.JSONAnyPtrBytes(locationBytes), method, params, nil)
 	assert.Regexp(t, "FF10151", err)
 }
 
@@ -1606,13 +1769,123 @@ func TestGetFFIParamValidator(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func TestGenerateFFI(t *testing.T) {
-	e, _ := newTestFabric()
-	_, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
-		Name:        "Simple",
-		Version:     "v0.0.1",
-		Description: "desc",
-		Input:       fftypes.JSONAnyPtr(`[]`),
-	})
-	assert.Regexp(t, "FF10347", err)
+func TestVerifyIdentityRegisteredOK(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.defaultChannel = "firefly"
+	e.registry = "registry"
+	httpmock.RegisterResponder("POST", `http://localhost:12345/query`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "firefly", body["headers"].(map[string]interface{})["channel"])
+			assert.Equal(t, "registry", body["headers"].(map[string]interface{})["chaincode"])
+			assert.Equal(t, "IsRegistered", body["func"])
+			assert.Equal(t, "signer001", body["args"].(map[string]interface{})["key"])
+			return httpmock.NewJsonResponderOrPanic(200, &fabQueryNamedOutput{Result: true})(req)
+		})
+	registered, err := e.VerifyIdentityRegistered(context.Background(), "signer001", nil)
+	assert.NoError(t, err)
+	assert.True(t, registered)
+}
+
+func TestVerifyIdentityRegisteredFabconnectError(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", `http://localhost:12345/query`,
+		httpmock.NewJsonResponderOrPanic(500, &fabQueryNamedOutput{}))
+	_, err := e.VerifyIdentityRegistered(context.Background(), "signer001", nil)
+	assert.Regexp(t, "FF10284", err)
+}
+
+func TestVerifyIdentityRegisteredUnmarshalResponseError(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", `http://localhost:12345/query`,
+		httpmock.NewStringResponder(200, "[definitely not JSON}"))
+	_, err := e.VerifyIdentityRegistered(context.Background(), "signer001", nil)
+	assert.Regexp(t, "invalid character", err)
+}
+
+func TestGetBatchPinOK(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", `http://localhost:12345/transactions/ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2/events`,
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "firefly", req.URL.Query().Get("channel"))
+			return httpmock.NewStringResponder(200, `[
+				{
+					"chaincodeId": "other",
+					"eventName": "BatchPin"
+				},
+				{
+					"chaincodeId": "firefly",
+					"eventName": "Random"
+				},
+				{
+					"chaincodeId": "firefly",
+					"blockNumber": 91,
+					"transactionId": "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2",
+					"transactionIndex": 2,
+					"eventIndex": 50,
+					"eventName": "BatchPin",
+					"payload": "eyJzaWduZXIiOiJ1MHZnd3U5czAwLXg1MDk6OkNOPXVzZXIyLE9VPWNsaWVudDo6Q049ZmFicmljLWNhLXNlcnZlciIsInRpbWVzdGFtcCI6eyJzZWNvbmRzIjoxNjMwMDMxNjY3LCJuYW5vcyI6NzkxNDk5MDAwfSwibmFtZXNwYWNlIjoibnMxIiwidXVpZHMiOiIweGUxOWFmOGIzOTA2MDQwNTE4MTJkNzU5N2QxOWFkZmI5ODQ3ZDNiZmQwNzQyNDllZmI2NWQzZmVkMTVmNWIwYTYiLCJiYXRjaEhhc2giOiIweGQ3MWViMTM4ZDc0YzIyOWEzODhlYjBlMWFiYzAzZjRjN2NiYjIxZDRmYzRiODM5ZmJmMGVjNzNlNDI2M2Y2YmUiLCJwYXlsb2FkUmVmIjoiUW1mNDEyalFaaXVWVXRkZ25CMzZGWEZYN3hnNVY2S0ViU0o0ZHBRdWhrTHlmRCIsImNvbnRleHRzIjpbIjB4NjhlNGRhNzlmODA1YmNhNWI5MTJiY2RhOWM2M2QwM2U2ZTg2NzEwOGRhYmI5Yjk0NDEwOWFlYTU0MWVmNTIyYSIsIjB4MTliODIwOTNkZTVjZTkyYTAxZTMzMzA0OGU4NzdlMjM3NDM1NGJmODQ2ZGQwMzQ4NjRlZjZmZmJkNjQzODc3MSJdfQ=="
+				}
+			]`)(req)
+		})
+	batch, signer, err := e.GetBatchPin(context.Background(), "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2")
+	assert.NoError(t, err)
+	assert.Equal(t, "u0vgwu9s00-x509::CN=user2,OU=client::CN=fabric-ca-server", signer)
+	assert.Equal(t, "ns1", batch.Namespace)
+	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", batch.BatchID.String())
+	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", batch.BatchHash.String())
+	assert.Equal(t, "000000000091/000002/000050", batch.Event.ProtocolID)
+}
+
+func TestGetBatchPinNotPinned(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", `http://localhost:12345/transactions/tx1/events`,
+		httpmock.NewStringResponder(200, `[
+			{
+				"chaincodeId": "firefly",
+				"eventName": "BatchPin",
+				"payload": "bad"
+			}
+		]`))
+	batch, _, err := e.GetBatchPin(context.Background(), "tx1")
+	assert.NoError(t, err)
+	assert.Nil(t, batch)
+}
+
+func TestGetBatchPinFabconnectError(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", `http://localhost:12345/transactions/tx1/events`,
+		httpmock.NewStringResponder(500, `{"error":"pop"}`))
+	_, _, err := e.GetBatchPin(context.Background(), "tx1")
+	assert.Regexp(t, "FF10284", err)
+}
+
+func TestGetBatchPinUnmarshalResponseError(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", `http://localhost:12345/transactions/tx1/events`,
+		httpmock.NewStringResponder(200, "[definitely not JSON}"))
+	_, _, err := e.GetBatchPin(context.Background(), "tx1")
+	assert.Regexp(t, "invalid character", err)
 }
@@ -0,0 +1,226 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fabric
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// systemContractName is the contract the Fabric contract API adds to every chaincode, to serve the metadata itself
+const systemContractName = "org.hyperledger.fabric"
+
+const componentSchemaRefPrefix = "#/components/schemas/"
+
+// contractMetadata is the metadata returned by org.hyperledger.fabric:GetMetadata, for chaincode
+// built with the Fabric contract API (in Go, Node.js or Java)
+type contractMetadata struct {
+	Info       *metadataInfo                `json:"info,omitempty"`
+	Contracts  map[string]*metadataContract `json:"contracts"`
+	Components struct {
+		Schemas map[string]interface{} `json:"schemas,omitempty"`
+	} `json:"components"`
+}
+
+type metadataInfo struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type metadataContract struct {
+	Name         string                 `json:"name"`
+	Info         *metadataInfo          `json:"info,omitempty"`
+	Transactions []*metadataTransaction `json:"transactions"`
+}
+
+type metadataTransaction struct {
+	Name       string           `json:"name"`
+	Parameters []*metadataParam `json:"parameters,omitempty"`
+	Returns    *fftypes.JSONAny `json:"returns,omitempty"`
+}
+
+type metadataParam struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Schema      interface{} `json:"schema,omitempty"`
+}
+
+func (i *metadataInfo) description() string {
+	if i == nil {
+		return ""
+	}
+	if i.Description != "" {
+		return i.Description
+	}
+	return i.Title
+}
+
+func (f *Fabric) GenerateFFI(ctx context.Context, generationRequest *fftypes.FFIGenerationRequest) (*fftypes.FFI, error) {
+	var metadata contractMetadata
+	if err := json.Unmarshal(generationRequest.Input.Bytes(), &metadata); err != nil || metadata.Contracts == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgFFIGenerationFailed, "unable to deserialize JSON as contract metadata")
+	}
+	return convertMetadataToFFI(ctx, generationRequest, &metadata)
+}
+
+func convertMetadataToFFI(ctx context.Context, generationRequest *fftypes.FFIGenerationRequest, metadata *contractMetadata) (*fftypes.FFI, error) {
+	contractNames := make([]string, 0, len(metadata.Contracts))
+	for name := range metadata.Contracts {
+		if name != systemContractName {
+			contractNames = append(contractNames, name)
+		}
+	}
+	sort.Strings(contractNames)
+
+	ffi := &fftypes.FFI{
+		Namespace:   generationRequest.Namespace,
+		Name:        generationRequest.Name,
+		Version:     generationRequest.Version,
+		Description: generationRequest.Description,
+		Methods:     []*fftypes.FFIMethod{},
+		Events:      []*fftypes.FFIEvent{},
+	}
+	if ffi.Description == "" {
+		ffi.Description = metadata.Info.description()
+	}
+
+	for _, contractName := range contractNames {
+		contract := metadata.Contracts[contractName]
+		for _, tx := range contract.Transactions {
+			method := &fftypes.FFIMethod{
+				Name:        tx.Name,
+				Description: contract.Info.description(),
+				Params:      fftypes.FFIParams{},
+				Returns:     fftypes.FFIParams{},
+			}
+			// Chaincode with multiple contracts routes each transaction by its qualified name
+			if len(contractNames) > 1 {
+				method.Name = contractName + ":" + tx.Name
+			}
+			for _, param := range tx.Parameters {
+				ffiParam, err := metadataParamToFFI(ctx, metadata, param)
+				if err != nil {
+					return nil, err
+				}
+				method.Params = append(method.Params, ffiParam)
+			}
+			returns, err := metadataReturnsToFFI(ctx, metadata, tx.Returns)
+			if err != nil {
+				return nil, err
+			}
+			method.Returns = append(method.Returns, returns...)
+			ffi.Methods = append(ffi.Methods, method)
+		}
+	}
+	return ffi, nil
+}
+
+func metadataReturnsToFFI(ctx context.Context, metadata *contractMetadata, returns *fftypes.JSONAny) (fftypes.FFIParams, error) {
+	ffiParams := fftypes.FFIParams{}
+	if returns.IsNil() {
+		return ffiParams, nil
+	}
+	// The Node.js contract API describes returns as a list of named parameters, and the Go contract API as a single schema
+	if bytes.HasPrefix(bytes.TrimSpace(returns.Bytes()), []byte("[")) {
+		var params []*metadataParam
+		if err := json.Unmarshal(returns.Bytes(), &params); err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgFFIGenerationFailed, err)
+		}
+		for _, param := range params {
+			ffiParam, err := metadataParamToFFI(ctx, metadata, param)
+			if err != nil {
+				return nil, err
+			}
+			ffiParams = append(ffiParams, ffiParam)
+		}
+		return ffiParams, nil
+	}
+	ffiParam, err := metadataParamToFFI(ctx, metadata, &metadataParam{Schema: map[string]interface{}(returns.JSONObject())})
+	if err != nil {
+		return nil, err
+	}
+	return append(ffiParams, ffiParam), nil
+}
+
+func metadataParamToFFI(ctx context.Context, metadata *contractMetadata, param *metadataParam) (*fftypes.FFIParam, error) {
+	schema, err := resolveSchemaRefs(ctx, metadata, param.Schema, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	schemaObj, ok := schema.(map[string]interface{})
+	if !ok || len(schemaObj) == 0 {
+		// Every chaincode argument is passed as a string
+		schemaObj = map[string]interface{}{"type": "string"}
+	}
+	if param.Description != "" {
+		schemaObj["description"] = param.Description
+	}
+	b, _ := json.Marshal(schemaObj)
+	return &fftypes.FFIParam{
+		Name:   param.Name,
+		Schema: fftypes.JSONAnyPtr(string(b)),
+	}, nil
+}
+
+// resolveSchemaRefs returns a copy of the schema with every reference to a component schema replaced inline,
+// as FFI parameter schemas must be self-contained
+func resolveSchemaRefs(ctx context.Context, metadata *contractMetadata, schema interface{}, resolving map[string]bool) (interface{}, error) {
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		if ref, ok := s["$ref"].(string); ok {
+			name := strings.TrimPrefix(ref, componentSchemaRefPrefix)
+			component, ok := metadata.Components.Schemas[name]
+			if !ok || name == ref {
+				return nil, i18n.NewError(ctx, i18n.MsgFFIGenerationFailed, "unresolved schema reference '"+ref+"'")
+			}
+			if resolving[name] {
+				return nil, i18n.NewError(ctx, i18n.MsgFFIGenerationFailed, "recursive schema reference '"+ref+"'")
+			}
+			resolving[name] = true
+			defer delete(resolving, name)
+			return resolveSchemaRefs(ctx, metadata, component, resolving)
+		}
+		resolved := make(map[string]interface{}, len(s))
+		for k, v := range s {
+			if k == "$id" {
+				continue
+			}
+			var err error
+			if resolved[k], err = resolveSchemaRefs(ctx, metadata, v, resolving); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(s))
+		for i, v := range s {
+			var err error
+			if resolved[i], err = resolveSchemaRefs(ctx, metadata, v, resolving); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	default:
+		return schema, nil
+	}
+}
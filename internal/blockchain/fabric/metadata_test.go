@@ -0,0 +1,188 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fabric
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMetadata = `{
+	"info": {"title": "asset-transfer", "version": "1.0.0"},
+	"contracts": {
+		"AssetContract": {
+			"name": "AssetContract",
+			"info": {"title": "AssetContract", "description": "Manages assets"},
+			"transactions": [
+				{
+					"name": "CreateAsset",
+					"tag": ["submitTx"],
+					"parameters": [
+						{"name": "id", "description": "The asset ID", "schema": {"type": "string"}},
+						{"name": "asset", "schema": {"$ref": "#/components/schemas/Asset"}},
+						{"name": "untyped"}
+					]
+				},
+				{
+					"name": "ReadAsset",
+					"tag": ["evaluate"],
+					"parameters": [{"name": "id", "schema": {"type": "string"}}],
+					"returns": {"$ref": "#/components/schemas/Asset"}
+				},
+				{
+					"name": "AssetExists",
+					"parameters": [{"name": "id", "schema": {"type": "string"}}],
+					"returns": [{"name": "exists", "schema": {"type": "boolean"}}]
+				}
+			]
+		},
+		"org.hyperledger.fabric": {
+			"name": "org.hyperledger.fabric",
+			"transactions": [{"name": "GetMetadata"}]
+		}
+	},
+	"components": {
+		"schemas": {
+			"Asset": {
+				"$id": "Asset",
+				"type": "object",
+				"properties": {
+					"id": {"type": "string"},
+					"owner": {"$ref": "#/components/schemas/Owner"},
+					"tags": {"type": "array", "items": {"type": "string"}}
+				},
+				"required": ["id"]
+			},
+			"Owner": {
+				"$id": "Owner",
+				"type": "object",
+				"properties": {"name": {"type": "string"}}
+			}
+		}
+	}
+}`
+
+func TestGenerateFFI(t *testing.T) {
+	e, _ := newTestFabric()
+	ffi, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
+		Namespace: "ns1",
+		Name:      "assets",
+		Version:   "v1.0.0",
+		Input:     fftypes.JSONAnyPtr(testMetadata),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", ffi.Namespace)
+	assert.Equal(t, "asset-transfer", ffi.Description)
+	assert.Len(t, ffi.Methods, 3)
+
+	create := ffi.Methods[0]
+	assert.Equal(t, "CreateAsset", create.Name)
+	assert.Equal(t, "Manages assets", create.Description)
+	assert.Equal(t, `{"description":"The asset ID","type":"string"}`, create.Params[0].Schema.String())
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"owner": {"type": "object", "properties": {"name": {"type": "string"}}},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["id"]
+	}`, create.Params[1].Schema.String())
+	assert.Equal(t, `{"type":"string"}`, create.Params[2].Schema.String())
+	assert.Empty(t, create.Returns)
+
+	read := ffi.Methods[1]
+	assert.Equal(t, "ReadAsset", read.Name)
+	assert.Len(t, read.Returns, 1)
+	assert.Equal(t, "", read.Returns[0].Name)
+
+	exists := ffi.Methods[2]
+	assert.Equal(t, "exists", exists.Returns[0].Name)
+	assert.Equal(t, `{"type":"boolean"}`, exists.Returns[0].Schema.String())
+}
+
+func TestGenerateFFIMultipleContracts(t *testing.T) {
+	e, _ := newTestFabric()
+	ffi, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
+		Name:        "multi",
+		Version:     "v1.0.0",
+		Description: "desc",
+		Input: fftypes.JSONAnyPtr(`{
+			"contracts": {
+				"Zebra": {"name": "Zebra", "transactions": [{"name": "Stripe"}]},
+				"Apple": {"name": "Apple", "transactions": [{"name": "Peel"}]}
+			}
+		}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "desc", ffi.Description)
+	assert.Equal(t, "Apple:Peel", ffi.Methods[0].Name)
+	assert.Equal(t, "Zebra:Stripe", ffi.Methods[1].Name)
+}
+
+func TestGenerateFFIBadInput(t *testing.T) {
+	e, _ := newTestFabric()
+	_, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
+		Name:    "Simple",
+		Version: "v0.0.1",
+		Input:   fftypes.JSONAnyPtr(`[]`),
+	})
+	assert.Regexp(t, "FF10346", err)
+}
+
+func TestGenerateFFIBadRefs(t *testing.T) {
+	e, _ := newTestFabric()
+	for _, schema := range []string{
+		`{"$ref": "#/components/schemas/Missing"}`,
+		`{"$ref": "Asset"}`,
+		`{"type": "object", "properties": {"a": {"$ref": "#/components/schemas/Missing"}}}`,
+		`{"oneOf": [{"$ref": "#/components/schemas/Missing"}]}`,
+		`{"$ref": "#/components/schemas/Loop"}`,
+	} {
+		for _, tx := range []string{
+			`{"name": "Param", "parameters": [{"name": "a", "schema": ` + schema + `}]}`,
+			`{"name": "Returns", "returns": ` + schema + `}`,
+			`{"name": "ReturnsList", "returns": [{"name": "a", "schema": ` + schema + `}]}`,
+		} {
+			_, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
+				Name:    "Simple",
+				Version: "v0.0.1",
+				Input: fftypes.JSONAnyPtr(`{
+					"contracts": {"C": {"name": "C", "transactions": [` + tx + `]}},
+					"components": {"schemas": {
+						"Asset": {"type": "object"},
+						"Loop": {"type": "object", "properties": {"next": {"$ref": "#/components/schemas/Loop"}}}
+					}}
+				}`),
+			})
+			assert.Regexp(t, "FF10346", err, tx)
+		}
+	}
+}
+
+func TestGenerateFFIBadReturnsList(t *testing.T) {
+	e, _ := newTestFabric()
+	_, err := e.GenerateFFI(context.Background(), &fftypes.FFIGenerationRequest{
+		Name:    "Simple",
+		Version: "v0.0.1",
+		Input:   fftypes.JSONAnyPtr(`{"contracts": {"C": {"name": "C", "transactions": [{"name": "A", "returns": [false]}]}}}`),
+	})
+	assert.Regexp(t, "FF10346", err)
+}
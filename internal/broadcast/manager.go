@@ -18,6 +18,7 @@ package broadcast
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/privatemessaging"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
 	"github.com/hyperledger/firefly/pkg/blockchain"
@@ -40,6 +42,9 @@ import (
 
 const broadcastDispatcherName = "pinned_broadcast"
 
+// batchSizeWarnPercent is the percentage of the batch payload limit, above which a message sender receives a warning
+const batchSizeWarnPercent = 90
+
 type Manager interface {
 	NewBroadcast(ns string, in *fftypes.MessageInOut) sysmessaging.MessageSender
 	BroadcastDatatype(ctx context.Context, ns string, datatype *fftypes.Datatype, waitConfirm bool) (msg *fftypes.Message, err error)
@@ -61,17 +66,28 @@ type broadcastManager struct {
 	blockchain            blockchain.Plugin
 	exchange              dataexchange.Plugin
 	publicstorage         publicstorage.Plugin
+	nsPublicStorage       map[string]publicstorage.Plugin
 	batch                 batch.Manager
 	syncasync             syncasync.Bridge
 	batchpin              batchpin.Submitter
+	messaging             privatemessaging.Manager
 	maxBatchPayloadLength int64
+	batchCompression      fftypes.BatchEncoding
 	metrics               metrics.Manager
 }
 
-func NewBroadcastManager(ctx context.Context, di database.Plugin, im identity.Manager, dm data.Manager, bi blockchain.Plugin, dx dataexchange.Plugin, pi publicstorage.Plugin, ba batch.Manager, sa syncasync.Bridge, bp batchpin.Submitter, mm metrics.Manager) (Manager, error) {
-	if di == nil || im == nil || dm == nil || bi == nil || dx == nil || pi == nil || ba == nil {
+func NewBroadcastManager(ctx context.Context, di database.Plugin, im identity.Manager, dm data.Manager, bi blockchain.Plugin, dx dataexchange.Plugin, pi publicstorage.Plugin, nsPublicStorage map[string]publicstorage.Plugin, ba batch.Manager, sa syncasync.Bridge, bp batchpin.Submitter, pm privatemessaging.Manager, mm metrics.Manager) (Manager, error) {
+	if di == nil || im == nil || dm == nil || bi == nil || dx == nil || pi == nil || ba == nil || pm == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
+	var batchCompression fftypes.BatchEncoding
+	switch compression := fftypes.BatchEncoding(config.GetString(config.BroadcastBatchCompression)).Lower(); compression {
+	case "", "none":
+	case fftypes.BatchEncodingGzip:
+		batchCompression = compression
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgUnsupportedBatchCompression, compression)
+	}
 	bm := &broadcastManager{
 		ctx:                   ctx,
 		database:              di,
@@ -80,10 +96,13 @@ func NewBroadcastManager(ctx context.Context, di database.Plugin, im identity.Ma
 		blockchain:            bi,
 		exchange:              dx,
 		publicstorage:         pi,
+		nsPublicStorage:       nsPublicStorage,
 		batch:                 ba,
 		syncasync:             sa,
 		batchpin:              bp,
+		messaging:             pm,
 		maxBatchPayloadLength: config.GetByteSize(config.BroadcastBatchPayloadLimit),
+		batchCompression:      batchCompression,
 		metrics:               mm,
 	}
 	bo := batch.DispatcherOptions{
@@ -99,40 +118,131 @@ func NewBroadcastManager(ctx context.Context, di database.Plugin, im identity.Ma
 			fftypes.MessageTypeDefinition,
 			fftypes.MessageTypeTransferBroadcast,
 		}, bm.dispatchBatch, bo)
+	config.OnReload(ctx, func(ctx context.Context) {
+		ba.SetBatchTimeout(broadcastDispatcherName, config.GetDuration(config.BroadcastBatchTimeout))
+	}, config.BroadcastBatchTimeout)
 	return bm, nil
 }
 
+// publicStorageFor returns the public storage a namespace publishes to, which is the default public storage
+// of the node unless the namespace is configured with its own
+func (bm *broadcastManager) publicStorageFor(ns string) publicstorage.Plugin {
+	if pi, ok := bm.nsPublicStorage[ns]; ok {
+		return pi
+	}
+	return bm.publicstorage
+}
+
 func (bm *broadcastManager) dispatchBatch(ctx context.Context, batch *fftypes.Batch, pins []*fftypes.Bytes32) error {
 
-	// Serialize the full payload, which has already been sealed for us by the BatchManager
+	// The values of any data restricted to a private group are withheld from the batch, and sent to the members
+	// of the group. We do this before publishing, so the whole dispatch is retried if this fails.
+	if err := bm.messaging.SendPrivateData(ctx, batch); err != nil {
+		return err
+	}
+
+	// Serialize the full payload, which has already been sealed for us by the BatchManager.
+	// The encoding is recorded in the batch, but receivers detect compression from the payload itself.
+	batch.Encoding = bm.batchCompression
 	payload, err := json.Marshal(batch)
 	if err != nil {
 		return i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
 	}
+	if batch.Encoding == fftypes.BatchEncodingGzip {
+		payload = gzipPayload(payload)
+	}
 
 	// Write it to IPFS to get a payload reference
 	// The payload ref will be persisted back to the batch, as well as being used in the TX
-	batch.PayloadRef, err = bm.publicstorage.PublishData(ctx, bytes.NewReader(payload))
+	batch.PayloadRef, err = bm.publicStorageFor(batch.Namespace).PublishData(ctx, bytes.NewReader(payload))
 	if err != nil {
 		return err
 	}
 
-	return bm.database.RunAsGroup(ctx, func(ctx context.Context) error {
+	err = bm.database.RunAsGroup(ctx, func(ctx context.Context) error {
 		return bm.submitTXAndUpdateDB(ctx, batch, pins)
 	})
+	if err != nil {
+		return err
+	}
+
+	if bm.publicStorageFor(batch.Namespace).Capabilities().Pinning {
+		bm.pinBatch(ctx, batch)
+	}
+	return nil
+}
+
+func gzipPayload(payload []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(payload) // writing to an in-memory buffer cannot fail
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+// pinBatch requests the batch payload is pinned by the public storage, so it is retained after publishing.
+// The batch has already been submitted to the blockchain at this point, so pinning failures are recorded
+// against the operation rather than causing the dispatch of the batch to be retried.
+func (bm *broadcastManager) pinBatch(ctx context.Context, batch *fftypes.Batch) {
+	op := fftypes.NewOperation(
+		bm.publicStorageFor(batch.Namespace),
+		batch.Namespace,
+		batch.Payload.TX.ID,
+		fftypes.OpTypePublicStorageBatchPin)
+	op.Input = fftypes.JSONObject{
+		"batch":      batch.ID,
+		"payloadRef": batch.PayloadRef,
+	}
+	if err := bm.database.InsertOperation(ctx, op); err != nil {
+		log.L(ctx).Errorf("Failed to record pin operation for batch '%s': %s", batch.ID, err)
+		return
+	}
+	bm.requestPin(ctx, op)
+}
+
+// requestPin asks the public storage to pin the payload of a batch pin operation, and fails the operation if
+// the request cannot be made. Otherwise the public storage resolves the operation once pinning completes.
+func (bm *broadcastManager) requestPin(ctx context.Context, op *fftypes.Operation) {
+	payloadRef := op.Input.GetString("payloadRef")
+	if err := bm.publicStorageFor(op.Namespace).PinData(ctx, op.ID, payloadRef); err != nil {
+		log.L(ctx).Errorf("Failed to pin batch payload '%s' for operation '%s': %s", payloadRef, op.ID, err)
+		if err := bm.database.ResolveOperation(ctx, op.ID, fftypes.OpStatusFailed, err.Error(), nil); err != nil {
+			log.L(ctx).Errorf("Failed to update pin operation '%s': %s", op.ID, err)
+		}
+	}
+}
+
+// resumePins restarts tracking of the pin operations that were still pending when the node stopped, as the
+// public storage tracks the completion of each pin in memory
+func (bm *broadcastManager) resumePins() error {
+	fb := database.OperationQueryFactory.NewFilter(bm.ctx)
+	ops, _, err := bm.database.GetOperations(bm.ctx, fb.And(
+		fb.Eq("type", fftypes.OpTypePublicStorageBatchPin),
+		fb.Eq("status", fftypes.OpStatusPending),
+	))
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		log.L(bm.ctx).Infof("Resuming pin operation '%s' for payload '%s'", op.ID, op.Input.GetString("payloadRef"))
+		bm.requestPin(bm.ctx, op)
+	}
+	return nil
 }
 
 func (bm *broadcastManager) submitTXAndUpdateDB(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
 
 	// Update the batch to store the payloadRef
-	err := bm.database.UpdateBatch(ctx, batch.ID, database.BatchQueryFactory.NewUpdate(ctx).Set("payloadref", batch.PayloadRef))
+	err := bm.database.UpdateBatch(ctx, batch.ID, database.BatchQueryFactory.NewUpdate(ctx).
+		Set("payloadref", batch.PayloadRef).
+		Set("encoding", batch.Encoding))
 	if err != nil {
 		return err
 	}
 
 	// The completed PublicStorage upload
 	op := fftypes.NewOperation(
-		bm.publicstorage,
+		bm.publicStorageFor(batch.Namespace),
 		batch.Namespace,
 		batch.Payload.TX.ID,
 		fftypes.OpTypePublicStorageBatchBroadcast)
@@ -155,7 +265,7 @@ func (bm *broadcastManager) publishBlobs(ctx context.Context, dataToPublish []*f
 		defer reader.Close()
 
 		// ... to the public storage
-		publicRef, err := bm.publicstorage.PublishData(ctx, reader)
+		publicRef, err := bm.publicStorageFor(d.Data.Namespace).PublishData(ctx, reader)
 		if err != nil {
 			return err
 		}
@@ -174,7 +284,7 @@ func (bm *broadcastManager) publishBlobs(ctx context.Context, dataToPublish []*f
 }
 
 func (bm *broadcastManager) Start() error {
-	return nil
+	return bm.resumePins()
 }
 
 func (bm *broadcastManager) WaitStop() {
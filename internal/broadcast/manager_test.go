@@ -18,7 +18,9 @@ package broadcast
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -33,10 +35,12 @@ import (
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/metricsmocks"
+	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -45,6 +49,7 @@ func newTestBroadcastCommon(t *testing.T, metricsEnabled bool) (*broadcastManage
 	config.Reset()
 	mdi := &databasemocks.Plugin{}
 	mim := &identitymanagermocks.Manager{}
+	mim.On("VerifyCryptoPolicy", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe() // no namespace policies by default
 	mdm := &datamocks.Manager{}
 	mbi := &blockchainmocks.Plugin{}
 	mpi := &publicstoragemocks.Plugin{}
@@ -52,10 +57,13 @@ func newTestBroadcastCommon(t *testing.T, metricsEnabled bool) (*broadcastManage
 	mdx := &dataexchangemocks.Plugin{}
 	msa := &syncasyncmocks.Bridge{}
 	mbp := &batchpinmocks.Submitter{}
+	mpm := &privatemessagingmocks.Manager{}
+	mpm.On("SendPrivateData", mock.Anything, mock.Anything).Return(nil).Maybe() // no private data by default
 	mmi := &metricsmocks.Manager{}
 	mmi.On("IsMetricsEnabled").Return(metricsEnabled)
 	mbi.On("Name").Return("ut_blockchain").Maybe()
 	mpi.On("Name").Return("ut_publicstorage").Maybe()
+	mpi.On("Capabilities").Return(&publicstorage.Capabilities{}).Maybe()
 	mba.On("RegisterDispatcher",
 		broadcastDispatcherName,
 		fftypes.TransactionTypeBatchPin,
@@ -73,7 +81,7 @@ func newTestBroadcastCommon(t *testing.T, metricsEnabled bool) (*broadcastManage
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	b, err := NewBroadcastManager(ctx, mdi, mim, mdm, mbi, mdx, mpi, mba, msa, mbp, mmi)
+	b, err := NewBroadcastManager(ctx, mdi, mim, mdm, mbi, mdx, mpi, nil, mba, msa, mbp, mpm, mmi)
 	assert.NoError(t, err)
 	return b.(*broadcastManager), cancel
 }
@@ -90,10 +98,27 @@ func newTestBroadcastWithMetrics(t *testing.T) (*broadcastManager, func()) {
 }
 
 func TestInitFail(t *testing.T) {
-	_, err := NewBroadcastManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	_, err := NewBroadcastManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
 
+func TestInitBatchCompression(t *testing.T) {
+	config.Reset()
+	config.Set(config.BroadcastBatchCompression, "GZIP")
+	mba := &batchmocks.Manager{}
+	mba.On("RegisterDispatcher", broadcastDispatcherName, fftypes.TransactionTypeBatchPin, mock.Anything, mock.Anything, mock.Anything).Return()
+	bm, err := NewBroadcastManager(context.Background(), &databasemocks.Plugin{}, &identitymanagermocks.Manager{}, &datamocks.Manager{}, &blockchainmocks.Plugin{}, &dataexchangemocks.Plugin{}, &publicstoragemocks.Plugin{}, nil, mba, &syncasyncmocks.Bridge{}, &batchpinmocks.Submitter{}, &privatemessagingmocks.Manager{}, &metricsmocks.Manager{})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.BatchEncodingGzip, bm.(*broadcastManager).batchCompression)
+}
+
+func TestInitBadBatchCompression(t *testing.T) {
+	config.Reset()
+	config.Set(config.BroadcastBatchCompression, "lz4")
+	_, err := NewBroadcastManager(context.Background(), &databasemocks.Plugin{}, &identitymanagermocks.Manager{}, &datamocks.Manager{}, &blockchainmocks.Plugin{}, &dataexchangemocks.Plugin{}, &publicstoragemocks.Plugin{}, nil, &batchmocks.Manager{}, &syncasyncmocks.Bridge{}, &batchpinmocks.Submitter{}, &privatemessagingmocks.Manager{}, &metricsmocks.Manager{})
+	assert.Regexp(t, "FF10442.*lz4", err)
+}
+
 func TestBroadcastMessageGood(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -108,6 +133,7 @@ func TestBroadcastMessageGood(t *testing.T) {
 	err := broadcast.sendInternal(context.Background(), methodSend)
 	assert.NoError(t, err)
 
+	bm.database.(*databasemocks.Plugin).On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
 	bm.Start()
 	bm.WaitStop()
 }
@@ -158,6 +184,18 @@ func TestDispatchBatchUploadFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestDispatchBatchSendPrivateDataFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mpm := &privatemessagingmocks.Manager{}
+	bm.messaging = mpm
+	mpm.On("SendPrivateData", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bm.dispatchBatch(context.Background(), &fftypes.Batch{}, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.EqualError(t, err, "pop")
+	mpm.AssertExpectations(t)
+}
+
 func TestDispatchBatchSubmitBatchPinSucceed(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -178,6 +216,209 @@ func TestDispatchBatchSubmitBatchPinSucceed(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDispatchBatchNamespacePublicStorage(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	nsps := &publicstoragemocks.Plugin{}
+	bm.nsPublicStorage = map[string]publicstorage.Plugin{"ns2": nsps}
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns2",
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mbp := bm.batchpin.(*batchpinmocks.Submitter)
+	nsps.On("Name").Return("ut_publicstorage2")
+	nsps.On("Capabilities").Return(&publicstorage.Capabilities{})
+	nsps.On("PublishData", mock.Anything, mock.Anything).Return("id1", nil)
+	mdi.On("UpdateBatch", mock.Anything, batch.ID, mock.Anything).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Plugin == "ut_publicstorage2"
+	})).Return(nil)
+	mbp.On("SubmitPinnedBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := bm.dispatchBatch(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+	assert.Equal(t, "id1", batch.PayloadRef)
+
+	nsps.AssertExpectations(t)
+	bm.publicstorage.(*publicstoragemocks.Plugin).AssertNotCalled(t, "PublishData", mock.Anything, mock.Anything)
+}
+
+func TestDispatchBatchCompressed(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	bm.batchCompression = fftypes.BatchEncodingGzip
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mps := bm.publicstorage.(*publicstoragemocks.Plugin)
+	mbp := bm.batchpin.(*batchpinmocks.Submitter)
+	mps.On("PublishData", mock.Anything, mock.MatchedBy(func(r io.Reader) bool {
+		zr, err := gzip.NewReader(r)
+		assert.NoError(t, err)
+		var published fftypes.Batch
+		err = json.NewDecoder(zr).Decode(&published)
+		assert.NoError(t, err)
+		return published.ID.Equals(batch.ID) && published.Encoding == fftypes.BatchEncodingGzip
+	})).Return("id1", nil)
+	mdi.On("UpdateBatch", mock.Anything, batch.ID, mock.MatchedBy(func(u database.Update) bool {
+		info, _ := u.Finalize()
+		return len(info.SetOperations) == 2 && info.SetOperations[1].Field == "encoding"
+	})).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mbp.On("SubmitPinnedBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := bm.dispatchBatch(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.BatchEncodingGzip, batch.Encoding)
+	mdi.AssertExpectations(t)
+}
+
+func newTestPinningPublicStorage(bm *broadcastManager) *publicstoragemocks.Plugin {
+	mps := &publicstoragemocks.Plugin{}
+	mps.On("Name").Return("ut_publicstorage").Maybe()
+	mps.On("Capabilities").Return(&publicstorage.Capabilities{Pinning: true})
+	bm.publicstorage = mps
+	return mps
+}
+
+func TestDispatchBatchPinDataSucceed(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mps := newTestPinningPublicStorage(bm)
+	mbp := bm.batchpin.(*batchpinmocks.Submitter)
+	mps.On("PublishData", mock.Anything, mock.Anything).Return("id1", nil)
+	mdi.On("UpdateBatch", mock.Anything, batch.ID, mock.Anything).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypePublicStorageBatchBroadcast
+	})).Return(nil)
+	mbp.On("SubmitPinnedBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	var pinOp *fftypes.Operation
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		pinOp = op
+		return op.Type == fftypes.OpTypePublicStorageBatchPin &&
+			op.Input.GetString("payloadRef") == "id1" &&
+			op.Status == fftypes.OpStatusPending
+	})).Return(nil)
+	mps.On("PinData", mock.Anything, mock.Anything, "id1").Return(nil)
+
+	err := bm.dispatchBatch(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+	assert.Equal(t, pinOp.ID, mps.Calls[len(mps.Calls)-1].Arguments[1])
+
+	mdi.AssertExpectations(t)
+	mps.AssertExpectations(t)
+}
+
+func TestDispatchBatchPinDataInsertOpFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mps := newTestPinningPublicStorage(bm)
+	mbp := bm.batchpin.(*batchpinmocks.Submitter)
+	mps.On("PublishData", mock.Anything, mock.Anything).Return("id1", nil)
+	mdi.On("UpdateBatch", mock.Anything, batch.ID, mock.Anything).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypePublicStorageBatchBroadcast
+	})).Return(nil)
+	mbp.On("SubmitPinnedBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bm.dispatchBatch(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mps.AssertNotCalled(t, "PinData", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDispatchBatchPinDataFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mps := newTestPinningPublicStorage(bm)
+	mbp := bm.batchpin.(*batchpinmocks.Submitter)
+	mps.On("PublishData", mock.Anything, mock.Anything).Return("id1", nil)
+	mdi.On("UpdateBatch", mock.Anything, batch.ID, mock.Anything).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mbp.On("SubmitPinnedBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mps.On("PinData", mock.Anything, mock.Anything, "id1").Return(fmt.Errorf("pop"))
+	mdi.On("ResolveOperation", mock.Anything, mock.Anything, fftypes.OpStatusFailed, "pop", fftypes.JSONObject(nil)).Return(fmt.Errorf("pop"))
+
+	err := bm.dispatchBatch(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mps.AssertExpectations(t)
+}
+
+func TestStartResumesPendingPins(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	op1 := &fftypes.Operation{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Type:      fftypes.OpTypePublicStorageBatchPin,
+		Status:    fftypes.OpStatusPending,
+		Input:     fftypes.JSONObject{"payloadRef": "id1"},
+	}
+	op2 := &fftypes.Operation{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Type:      fftypes.OpTypePublicStorageBatchPin,
+		Status:    fftypes.OpStatusPending,
+		Input:     fftypes.JSONObject{"payloadRef": "id2"},
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mps := bm.publicstorage.(*publicstoragemocks.Plugin)
+	mdi.On("GetOperations", mock.Anything, mock.MatchedBy(func(filter database.AndFilter) bool {
+		info, _ := filter.Finalize()
+		return info.String() == "( type == 'publicstorage_batch_pin' ) && ( status == 'Pending' )"
+	})).Return([]*fftypes.Operation{op1, op2}, nil, nil)
+	mps.On("PinData", mock.Anything, op1.ID, "id1").Return(nil)
+	mps.On("PinData", mock.Anything, op2.ID, "id2").Return(fmt.Errorf("pop"))
+	mdi.On("ResolveOperation", mock.Anything, op2.ID, fftypes.OpStatusFailed, "pop", fftypes.JSONObject(nil)).Return(nil)
+
+	err := bm.Start()
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mps.AssertExpectations(t)
+}
+
+func TestStartResumePinsFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := bm.Start()
+	assert.Regexp(t, "pop", err)
+}
+
 func TestDispatchBatchSubmitBroadcastFail(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
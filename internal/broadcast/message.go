@@ -20,9 +20,12 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/internal/batch"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -89,6 +92,7 @@ func (s *broadcastSender) setDefaults() {
 	if s.msg.Header.Type == "" {
 		s.msg.Header.Type = fftypes.MessageTypeBroadcast
 	}
+	batch.NamespaceMessageDefaults(s.namespace, &s.msg.Message)
 	// We only have one transaction type for broadcast currently
 	s.msg.Header.TxType = fftypes.TransactionTypeBatchPin
 }
@@ -104,8 +108,13 @@ func (s *broadcastSender) resolveAndSend(ctx context.Context, method sendMethod)
 				return err
 			}
 			msgSizeEstimate := s.msg.EstimateSize(true)
-			if msgSizeEstimate > s.mgr.maxBatchPayloadLength {
-				return i18n.NewError(ctx, i18n.MsgTooLargeBroadcast, float64(msgSizeEstimate)/1024, float64(s.mgr.maxBatchPayloadLength)/1024)
+			maxBatchPayloadLength := batch.NamespacePayloadLimit(s.msg.Header.Namespace, s.mgr.maxBatchPayloadLength)
+			if msgSizeEstimate > maxBatchPayloadLength {
+				return i18n.NewError(ctx, i18n.MsgTooLargeBroadcast, float64(msgSizeEstimate)/1024, float64(maxBatchPayloadLength)/1024)
+			}
+			if msgSizeEstimate*100 >= maxBatchPayloadLength*batchSizeWarnPercent {
+				// The message only just fits in a batch, so warn the caller while there is still headroom
+				apiwarnings.Add(ctx, i18n.MsgWarnMessageNearBatchLimit, float64(msgSizeEstimate)/1024, float64(maxBatchPayloadLength)/1024)
 			}
 			s.resolved = true
 		}
@@ -121,7 +130,7 @@ func (s *broadcastSender) resolveAndSend(ctx context.Context, method sendMethod)
 	})
 
 	if err != nil || sent {
-		return err
+		return s.checkDuplicateKey(ctx, err)
 	}
 
 	// Perform deferred processing
@@ -130,16 +139,23 @@ func (s *broadcastSender) resolveAndSend(ctx context.Context, method sendMethod)
 			return err
 		}
 	}
-	return s.sendInternal(ctx, method)
+	return s.checkDuplicateKey(ctx, s.sendInternal(ctx, method))
 }
 
 func (s *broadcastSender) resolve(ctx context.Context) ([]*fftypes.DataAndBlob, error) {
+	if err := s.checkIdempotencyKey(ctx); err != nil {
+		return nil, err
+	}
+
 	// Resolve the sending identity
 	if !s.isRootOrgBroadcast(ctx) {
-		if err := s.mgr.identity.ResolveInputIdentity(ctx, &s.msg.Header.Identity); err != nil {
-			return nil, i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+		if err := s.resolveIdentity(ctx); err != nil {
+			return nil, err
 		}
 	}
+	if err := s.mgr.identity.VerifyCryptoPolicy(ctx, s.namespace, &s.msg.Header.Identity); err != nil {
+		return nil, err
+	}
 
 	// The data manager is responsible for the heavy lifting of storing/validating all our in-line data elements
 	dataRefs, dataToPublish, err := s.mgr.data.ResolveInlineDataBroadcast(ctx, s.namespace, s.msg.InlineData)
@@ -147,6 +163,48 @@ func (s *broadcastSender) resolve(ctx context.Context) ([]*fftypes.DataAndBlob,
 	return dataToPublish, err
 }
 
+// checkIdempotencyKey rejects a retry of a submission that has already been accepted, before any data is
+// written. The unique constraint on the messages table protects against two concurrent submissions.
+func (s *broadcastSender) checkIdempotencyKey(ctx context.Context) error {
+	if s.msg.IdempotencyKey == "" {
+		return nil
+	}
+	existing, err := s.existingMessage(ctx)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return i18n.NewError(ctx, i18n.MsgDuplicateIdempotencyKeyMsg, s.msg.IdempotencyKey, existing.Header.ID)
+	}
+	return nil
+}
+
+// checkDuplicateKey reports a message with the same idempotency key that was accepted concurrently, which the
+// unique constraint rejected after checkIdempotencyKey had passed. This is called once the database transaction
+// of the insert has been rolled back.
+func (s *broadcastSender) checkDuplicateKey(ctx context.Context, err error) error {
+	if err != database.DuplicateKey || s.msg.IdempotencyKey == "" {
+		return err
+	}
+	existing, lookupErr := s.existingMessage(ctx)
+	if lookupErr != nil || existing == nil {
+		return err
+	}
+	return i18n.NewError(ctx, i18n.MsgDuplicateIdempotencyKeyMsg, s.msg.IdempotencyKey, existing.Header.ID)
+}
+
+func (s *broadcastSender) existingMessage(ctx context.Context) (*fftypes.Message, error) {
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	existing, _, err := s.mgr.database.GetMessages(ctx, fb.And(
+		fb.Eq("namespace", s.namespace),
+		fb.Eq("idempotencykey", string(s.msg.IdempotencyKey)),
+	).Limit(1))
+	if err != nil || len(existing) == 0 {
+		return nil, err
+	}
+	return existing[0], nil
+}
+
 func (s *broadcastSender) sendInternal(ctx context.Context, method sendMethod) (err error) {
 	if method == methodSendAndWait {
 		out, err := s.mgr.syncasync.WaitForMessage(ctx, s.namespace, s.msg.Header.ID, s.Send)
@@ -168,6 +226,7 @@ func (s *broadcastSender) sendInternal(ctx context.Context, method sendMethod) (
 	if err := s.mgr.database.UpsertMessage(ctx, &s.msg.Message, database.UpsertOptimizationNew); err != nil {
 		return err
 	}
+	tracing.RecordMessage(ctx, s.msg.Header.ID)
 	log.L(ctx).Infof("Sent broadcast message %s:%s sequence=%d", s.msg.Header.Namespace, s.msg.Header.ID, s.msg.Sequence)
 
 	return err
@@ -197,3 +256,15 @@ func (s *broadcastSender) isRootOrgBroadcast(ctx context.Context) bool {
 	}
 	return false
 }
+
+// resolveIdentity resolves the sending identity, which might be a child identity that the submitter
+// is sending on behalf of
+func (s *broadcastSender) resolveIdentity(ctx context.Context) error {
+	if s.msg.OnBehalfOf != "" {
+		return s.mgr.identity.ResolveDelegatedIdentity(ctx, &s.msg.Header.Identity, s.msg.OnBehalfOf)
+	}
+	if err := s.mgr.identity.ResolveInputIdentity(ctx, &s.msg.Header.Identity); err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+	}
+	return nil
+}
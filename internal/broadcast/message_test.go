@@ -26,6 +26,8 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
@@ -344,6 +346,89 @@ func TestBroadcastMessageTooLarge(t *testing.T) {
 	mdm.AssertExpectations(t)
 }
 
+func TestBroadcastMessageTooLargeForNamespace(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	bm.maxBatchPayloadLength = 10000000
+	defer cancel()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "ns1", "batch": map[string]interface{}{"payloadLimit": "500kb"}},
+	})
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), ValueSize: 1000001},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Identity: fftypes.Identity{
+					Author: "did:firefly:org/abcd",
+					Key:    "0x12345",
+				},
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+	}, true)
+	assert.Regexp(t, "FF10327.*500.00kb", err)
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestBroadcastMessageNearLimitWarning(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	bm.maxBatchPayloadLength = 1000000
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	ctx := apiwarnings.WithWarnings(context.Background())
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), ValueSize: 950000},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
+	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Identity: fftypes.Identity{
+					Author: "did:firefly:org/abcd",
+					Key:    "0x12345",
+				},
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.EqualError(t, err, "pop")
+	warnings := apiwarnings.Get(ctx)
+	assert.Len(t, warnings, 1)
+	assert.Regexp(t, "FF10369", warnings[0])
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
 func TestBroadcastMessageBadInput(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -371,6 +456,186 @@ func TestBroadcastMessageBadInput(t *testing.T) {
 	mdm.AssertExpectations(t)
 }
 
+func TestBroadcastMessageIdempotencyKeyOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdi.On("GetMessages", ctx, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mdi.On("UpsertMessage", ctx, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.IdempotencyKey == "retry1"
+	}), database.UpsertOptimizationNew).Return(nil)
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestBroadcastMessageIdempotencyKeyDuplicate(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	existing := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mdi.On("GetMessages", ctx, mock.Anything).Return([]*fftypes.Message{existing}, nil, nil)
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.Regexp(t, "FF10451.*"+existing.Header.ID.String(), err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBroadcastMessageIdempotencyKeyConcurrent(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	// The other submission is accepted between the check, and the insert
+	existing := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mdi.On("GetMessages", ctx, mock.Anything).Return([]*fftypes.Message{}, nil, nil).Once()
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(database.DuplicateKey)
+	mdi.On("GetMessages", ctx, mock.Anything).Return([]*fftypes.Message{existing}, nil, nil).Once()
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.Regexp(t, "FF10451.*"+existing.Header.ID.String(), err)
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestBroadcastMessageIdempotencyKeyConcurrentQueryFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdi.On("GetMessages", ctx, mock.Anything).Return([]*fftypes.Message{}, nil, nil).Once()
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(database.DuplicateKey)
+	mdi.On("GetMessages", ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop")).Once()
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.Equal(t, database.DuplicateKey, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBroadcastMessageIdempotencyKeyQueryFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdi.On("GetMessages", ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBroadcastMessageCryptoPolicyRejected(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	ctx := context.Background()
+	mim := &identitymanagermocks.Manager{}
+	bm.identity = mim
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
+	mim.On("VerifyCryptoPolicy", ctx, "ns1", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mim.AssertExpectations(t)
+}
+
 func TestBroadcastMessageBadIdentity(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -389,6 +654,25 @@ func TestBroadcastMessageBadIdentity(t *testing.T) {
 	mim.AssertExpectations(t)
 }
 
+func TestBroadcastMessageOnBehalfOfFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	ctx := context.Background()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveDelegatedIdentity", ctx, mock.Anything, "org2").Return(fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"hello": "world"}`)},
+		},
+		OnBehalfOf: "org2",
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mim.AssertExpectations(t)
+}
+
 func TestPublishBlobsSendMessageFail(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"math"
 	"net/http"
-	"os"
 	"reflect"
 	"sort"
 	"strings"
@@ -48,12 +47,54 @@ var (
 	APIMaxFilterLimit = rootKey("api.maxFilterLimit")
 	// APIMaxFilterSkip is the maximum skip value that can be specified on the API
 	APIMaxFilterSkip = rootKey("api.maxFilterLimit")
+	// APIMaxBulkMessages is the maximum number of messages that can be submitted in a single bulk submission
+	APIMaxBulkMessages = rootKey("api.maxBulkMessages")
 	// APIRequestTimeout is the server side timeout for API calls (context timeout), to avoid the server continuing processing when the client gives up
 	APIRequestTimeout = rootKey("api.requestTimeout")
 	// APIRequestMaxTimeout is the maximum timeout an application can set using a Request-Timeout header
 	APIRequestMaxTimeout = rootKey("api.requestMaxTimeout")
+	// APIDefaultLongPollTimeout is the time to hold a request using waitForState, when the application does not specify a timeout
+	APIDefaultLongPollTimeout = rootKey("api.defaultLongPollTimeout")
+	// APIGraphQLMaxDepth is the maximum depth of nested selections in a GraphQL query
+	APIGraphQLMaxDepth = rootKey("api.graphql.maxDepth")
+	// APIGraphQLMaxCost is the maximum estimated number of objects a GraphQL query can resolve, counting each object of every nested page
+	APIGraphQLMaxCost = rootKey("api.graphql.maxCost")
+	// APIGraphQLNestedDefaultLimit is the default limit applied to collections nested within another object in a GraphQL query
+	APIGraphQLNestedDefaultLimit = rootKey("api.graphql.nestedDefaultLimit")
+	// APIAuditEnabled if true every API call that makes a change is recorded in the audit log, with the identity of the caller and the result
+	APIAuditEnabled = rootKey("api.audit.enabled")
+	// APIRateLimitEnabled if true API requests are rate limited, and requests over the limit are rejected with a 429 status
+	APIRateLimitEnabled = rootKey("api.rateLimit.enabled")
+	// APIRateLimitRequestsPerSecond is the rate at which API requests are allowed across all callers (0 for no limit)
+	APIRateLimitRequestsPerSecond = rootKey("api.rateLimit.requestsPerSecond")
+	// APIRateLimitBurst is the number of API requests across all callers that can be made in a burst above the rate
+	APIRateLimitBurst = rootKey("api.rateLimit.burst")
+	// APIRateLimitIdentityRequestsPerSecond is the rate at which API requests are allowed for each calling identity (0 for no limit)
+	APIRateLimitIdentityRequestsPerSecond = rootKey("api.rateLimit.identity.requestsPerSecond")
+	// APIRateLimitIdentityBurst is the number of API requests each calling identity can make in a burst above the rate
+	APIRateLimitIdentityBurst = rootKey("api.rateLimit.identity.burst")
 	// APIShutdownTimeout is the amount of time to wait for any in-flight requests to finish before killing the HTTP server
 	APIShutdownTimeout = rootKey("api.shutdownTimeout")
+	// ArchiveEnabled enables the background tiering of old events, and their delivered data, into compressed archives in object storage. Archived records can be fetched by ID, and list queries warn that they are not included
+	ArchiveEnabled = rootKey("archive.enabled")
+	// ArchiveType is the type of object storage that archives are written to - filesystem or s3. The S3 bucket is configured under archive.s3
+	ArchiveType = rootKey("archive.type")
+	// ArchiveDirectory is the directory of the filesystem object storage that archives are written to, such as a mounted storage bucket
+	ArchiveDirectory = rootKey("archive.directory")
+	// ArchiveThreshold is the age after which events, and the data of messages they confirm, are moved into an archive once delivered to every durable subscription
+	ArchiveThreshold = rootKey("archive.threshold")
+	// ArchiveInterval is how often the tiering job checks for records to archive
+	ArchiveInterval = rootKey("archive.interval")
+	// ArchiveBatchSize is the maximum number of events written into a single archive
+	ArchiveBatchSize = rootKey("archive.batchSize")
+	// ArchiveCacheSize is the number of archives held in memory after being fetched on demand
+	ArchiveCacheSize = rootKey("archive.cache.size")
+	// ArchiveCacheTTL is how long archives are held in memory after being fetched on demand
+	ArchiveCacheTTL = rootKey("archive.cache.ttl")
+	// BatchAdaptiveEnabled enables adaptive sizing, where each batch processor grows its batch size under sustained load, and shrinks it under light load
+	BatchAdaptiveEnabled = rootKey("batch.adaptive.enabled")
+	// BatchAdaptiveMinSize is the smallest batch size that adaptive sizing will shrink to
+	BatchAdaptiveMinSize = rootKey("batch.adaptive.minSize")
 	// BatchManagerReadPageSize is the size of each page of messages read from the database into memory when assembling batches
 	BatchManagerReadPageSize = rootKey("batch.manager.readPageSize")
 	// BatchManagerReadPollTimeout is how long without any notifications of new messages to wait, before doing a page query
@@ -66,6 +107,8 @@ var (
 	BatchRetryMaxDelay = rootKey("batch.retry.maxDelay")
 	// BlockchainType is the name of the blockchain interface plugin being used by this firefly node
 	BlockchainType = rootKey("blockchain.type")
+	// BroadcastBatchCompression is the compression applied to batch payloads before they are published to public storage (none, or gzip)
+	BroadcastBatchCompression = rootKey("broadcast.batch.compression")
 	// BroadcastBatchAgentTimeout how long to keep around a batching agent for a sending identity before disposal
 	BroadcastBatchAgentTimeout = rootKey("broadcast.batch.agentTimeout")
 	// BroadcastBatchSize is the maximum number of messages that can be packed into a batch
@@ -109,8 +152,12 @@ var (
 	DataexchangeType = rootKey("dataexchange.type")
 	// DatabaseType the type of the database interface plugin to use
 	DatabaseType = rootKey("database.type")
+	// DatabaseMigrationsDryRun prevents the database plugin applying any migrations on startup, so the pending migrations can be listed
+	DatabaseMigrationsDryRun = rootKey("database.migrations.dryRun")
 	// TokensList is the root key containing a list of supported token connectors
 	TokensList = rootKey("tokens")
+	// LedgersList is the root key containing a list of additional ledgers, each connected with its own blockchain plugin
+	LedgersList = rootKey("ledgers")
 	// DebugPort a HTTP port on which to enable the go debugger
 	DebugPort = rootKey("debug.port")
 	// EventTransportsDefault the default event transport for new subscriptions
@@ -119,8 +166,14 @@ var (
 	EventTransportsEnabled = rootKey("event.transports.enabled")
 	// EventAggregatorFirstEvent the first event the aggregator should process, if no previous offest is stored in the DB
 	EventAggregatorFirstEvent = rootKey("event.aggregator.firstEvent")
+	// EventAggregatorBatchCacheLimit the maximum number of recently confirmed batch IDs to keep, to short-circuit duplicate batch pin events
+	EventAggregatorBatchCacheLimit = rootKey("event.aggregator.batchCache.limit")
+	// EventAggregatorBatchCacheTTL the time-to-live for entries in the confirmed batch cache
+	EventAggregatorBatchCacheTTL = rootKey("event.aggregator.batchCache.ttl")
 	// EventAggregatorBatchSize the maximum number of records to read from the DB before performing an aggregation run
 	EventAggregatorBatchSize = rootKey("event.aggregator.batchSize")
+	// EventAggregatorBatchValidators the names of the batch validator plugins to run against each batch received, before it is persisted
+	EventAggregatorBatchValidators = rootKey("event.aggregator.batchValidators")
 	// EventAggregatorBatchTimeout how long to wait for new events to arrive before performing aggregation on a page of events
 	EventAggregatorBatchTimeout = rootKey("event.aggregator.batchTimeout")
 	// EventAggregatorOpCorrelationRetries how many times to correlate an event for an operation (such as tx submission) back to an operation.
@@ -128,12 +181,22 @@ var (
 	EventAggregatorOpCorrelationRetries = rootKey("event.aggregator.opCorrelationRetries")
 	// EventAggregatorPollTimeout the time to wait without a notification of new events, before trying a select on the table
 	EventAggregatorPollTimeout = rootKey("event.aggregator.pollTimeout")
+	// EventAggregatorRequireNodeSignature if true, batches must be signed by a registered node that has a public key, otherwise they are rejected
+	EventAggregatorRequireNodeSignature = rootKey("event.aggregator.requireNodeSignature")
+	// EventAggregatorVerifyIdentityRegistry if true, the signing keys of batches must be registered in the on-chain identity registry of the blockchain plugin, otherwise they are rejected
+	EventAggregatorVerifyIdentityRegistry = rootKey("event.aggregator.verifyIdentityRegistry")
 	// EventAggregatorRetryFactor the backoff factor to use for retry of database operations
 	EventAggregatorRetryFactor = rootKey("event.aggregator.retry.factor")
 	// EventAggregatorRetryInitDelay the initial delay to use for retry of data base operations
 	EventAggregatorRetryInitDelay = rootKey("event.aggregator.retry.initDelay")
 	// EventAggregatorRetryMaxDelay the maximum delay to use for retry of data base operations
 	EventAggregatorRetryMaxDelay = rootKey("event.aggregator.retry.maxDelay")
+	// EventAggregatorRetryJitter the fraction of each retry delay that is randomized, so aggregators do not retry in lockstep
+	EventAggregatorRetryJitter = rootKey("event.aggregator.retry.jitter")
+	// EventAggregatorRetryCircuitBreakerThreshold the number of consecutive failed database operations that trip the circuit breaker, or zero to disable it
+	EventAggregatorRetryCircuitBreakerThreshold = rootKey("event.aggregator.retry.circuitBreaker.threshold")
+	// EventAggregatorRetryCircuitBreakerResetDelay how long the circuit breaker stays open before a trial database operation is allowed
+	EventAggregatorRetryCircuitBreakerResetDelay = rootKey("event.aggregator.retry.circuitBreaker.resetDelay")
 	// EventDispatcherPollTimeout the time to wait without a notification of new events, before trying a select on the table
 	EventDispatcherPollTimeout = rootKey("event.dispatcher.pollTimeout")
 	// EventDispatcherBufferLength the number of events + attachments an individual dispatcher should hold in memory ready for delivery to the subscription
@@ -146,28 +209,74 @@ var (
 	EventDispatcherRetryInitDelay = rootKey("event.dispatcher.retry.initDelay")
 	// EventDispatcherRetryMaxDelay he maximum delay to use for retry of data base operations
 	EventDispatcherRetryMaxDelay = rootKey("event.dispatcher.retry.maxDelay")
+	// EventDispatcherRetryJitter the fraction of each retry delay that is randomized, so the event pollers of subscriptions do not retry in lockstep
+	EventDispatcherRetryJitter = rootKey("event.dispatcher.retry.jitter")
+	// EventDispatcherRetryCircuitBreakerThreshold the number of consecutive failed database operations that trip the circuit breaker of an event poller, or zero to disable it
+	EventDispatcherRetryCircuitBreakerThreshold = rootKey("event.dispatcher.retry.circuitBreaker.threshold")
+	// EventDispatcherRetryCircuitBreakerResetDelay how long the circuit breaker of an event poller stays open before a trial database operation is allowed
+	EventDispatcherRetryCircuitBreakerResetDelay = rootKey("event.dispatcher.retry.circuitBreaker.resetDelay")
+	// EventPollerAdaptive if true, the event pollers lengthen the poll timeout each time it expires with no events, up to the maximum
+	EventPollerAdaptive = rootKey("event.poller.adaptive.enabled")
+	// EventPollerAdaptiveFactor the factor by which the poll timeout is lengthened when no events arrive
+	EventPollerAdaptiveFactor = rootKey("event.poller.adaptive.factor")
+	// EventPollerAdaptiveMaxTimeout the maximum poll timeout in adaptive mode
+	EventPollerAdaptiveMaxTimeout = rootKey("event.poller.adaptive.maxTimeout")
+	// EventCatchupPageSize the number of broadcast batches to request, or return, in each page of a catch-up from another member
+	EventCatchupPageSize = rootKey("event.catchup.pageSize")
+	// EventDXAntiReplayWindow how far behind the latest transmission from a peer a data exchange transmission can be, before it is rejected. Zero disables replay checking
+	EventDXAntiReplayWindow = rootKey("event.dx.antiReplayWindow")
 	// EventDBEventsBufferSize the size of the buffer of change events
 	EventDBEventsBufferSize = rootKey("event.dbevents.bufferSize")
 	// GroupCacheSize cache size for private group addresses
 	GroupCacheSize = rootKey("group.cache.size")
 	// GroupCacheTTL cache time-to-live for private group addresses
 	GroupCacheTTL = rootKey("group.cache.ttl")
+	// AuthType the type of the auth plugin that authenticates API requests. When not set, API requests are not authenticated
+	AuthType = rootKey("auth.type")
+	// AuthRBACEnabled if true each API request is only allowed if the authenticated identity has been granted a sufficient role for the namespace
+	AuthRBACEnabled = rootKey("auth.rbac.enabled")
+	// AuthRBACGrants the roles granted to identities, as an array of objects each containing an "identity", a "role" (reader, sender or admin) and a "namespace" ("*" or omitted for all namespaces)
+	AuthRBACGrants = rootKey("auth.rbac.grants")
 	// AdminEnabled determines whether the admin interface will be enabled or not
 	AdminEnabled = rootKey("admin.enabled")
 	// AdminPreinit waits for at least one ConfigREcord to be posted to the server before it starts (the database must be available on startup)
 	AdminPreinit = rootKey("admin.preinit")
+	// AdminWebSocketEventQueueLength the number of change events that can be queued for each listener on the admin websocket, before a listener that is not keeping up is disconnected
+	AdminWebSocketEventQueueLength = rootKey("admin.ws.eventQueueLength")
+	// AdminWebSocketReadBufferSize the read buffer size for admin websocket connections
+	AdminWebSocketReadBufferSize = rootKey("admin.ws.readBufferSize")
+	// AdminWebSocketWriteBufferSize the write buffer size for admin websocket connections
+	AdminWebSocketWriteBufferSize = rootKey("admin.ws.writeBufferSize")
 	// IdentityType the type of the identity plugin in use
 	IdentityType = rootKey("identity.type")
 	// IdentityManagerCacheTTL the identity manager cache time to live
 	IdentityManagerCacheTTL = rootKey("identity.manager.cache.ttl")
 	// IdentityManagerCacheLimit the identity manager cache limit in count of items
 	IdentityManagerCacheLimit = rootKey("identity.manager.cache.limit")
+	// IdentityManagerKeyRotationGracePeriod how long the previous signing key of an organization is still accepted, after the key is rotated
+	IdentityManagerKeyRotationGracePeriod = rootKey("identity.manager.keyRotation.gracePeriod")
 	// Lang is the language to use for translation
 	Lang = rootKey("lang")
+	// LoadSheddingEnabled if true load is shed in stages when the database is under pressure, rather than letting all activity slow down at once
+	LoadSheddingEnabled = rootKey("loadShedding.enabled")
+	// LoadSheddingWindow the period over which database latency and errors are measured, before deciding whether to change the level of load shedding
+	LoadSheddingWindow = rootKey("loadShedding.window")
+	// LoadSheddingLatencyThreshold the average database latency at which load shedding begins. Each further multiple of this latency sheds the next class of load
+	LoadSheddingLatencyThreshold = rootKey("loadShedding.latencyThreshold")
+	// LoadSheddingErrorRateThreshold the proportion of database calls failing (0-1) at which load shedding begins. Each further multiple of this rate sheds the next class of load
+	LoadSheddingErrorRateThreshold = rootKey("loadShedding.errorRateThreshold")
+	// LoadSheddingDispatchDelay the delay added before each database call by event dispatchers, while dispatch is being slowed
+	LoadSheddingDispatchDelay = rootKey("loadShedding.dispatchDelay")
+	// LoadSheddingRetryAfter the time API clients are asked to wait in the Retry-After header, when their request is rejected to shed load
+	LoadSheddingRetryAfter = rootKey("loadShedding.retryAfter")
 	// LogForceColor forces color to be enabled, even if we do not detect a TTY
 	LogForceColor = rootKey("log.forceColor")
 	// LogLevel is the logging level
 	LogLevel = rootKey("log.level")
+	// LogJSONEnabled if true log entries are written as JSON objects, one per line, rather than as text
+	LogJSONEnabled = rootKey("log.json.enabled")
+	// LogModules a map of module name to log level, for modules that should log at a different level to the rest of the node
+	LogModules = rootKey("log.modules")
 	// LogNoColor forces color to be disabled, even if we detect a TTY
 	LogNoColor = rootKey("log.noColor")
 	// LogTimeFormat is a string format for timestamps
@@ -196,6 +305,24 @@ var (
 	NodeName = rootKey("node.name")
 	// NodeDescription is a description for the node
 	NodeDescription = rootKey("node.description")
+	// NodeStartupBackground if true, plugins that connect to external runtimes are started in the background, with retry, so the API is available while they start
+	NodeStartupBackground = rootKey("node.startup.background")
+	// NodeStartupRetryFactor the backoff factor to use for retry of background plugin startup
+	NodeStartupRetryFactor = rootKey("node.startup.retry.factor")
+	// NodeStartupRetryInitDelay the initial delay to use for retry of background plugin startup
+	NodeStartupRetryInitDelay = rootKey("node.startup.retry.initDelay")
+	// NodeStartupRetryMaxDelay the maximum delay to use for retry of background plugin startup
+	NodeStartupRetryMaxDelay = rootKey("node.startup.retry.maxDelay")
+	// NodeSigningKey the path to a PEM encoded Ed25519 private key, used to sign each batch this node seals. The public key is registered with the node
+	NodeSigningKey = rootKey("node.signingKey")
+	// NodeEncryptionKey the path to a PEM encoded X25519 private key, used to encrypt private transmissions end-to-end. The public key is registered with the node
+	NodeEncryptionKey = rootKey("node.encryptionKey")
+	// NodeEncryptionKeyType the type of key wrapper that holds the node encryption key. The "local" type reads the private key from node.encryptionKey
+	NodeEncryptionKeyType = rootKey("node.encryptionKeyType")
+	// NodeDXCertPin if true the fingerprint of the TLS certificate published by the local data exchange is pinned in the node registration, so other members reject any endpoint that does not present it
+	NodeDXCertPin = rootKey("node.dx.certPin")
+	// NodeReadOnly if true the node processes and serves data from the network, but refuses to send messages or submit blockchain transactions
+	NodeReadOnly = rootKey("node.readOnly")
 	// OrgName is the short name o the org
 	OrgName = rootKey("org.name")
 	// OrgIdentityDeprecated deprecated synonym to org.key
@@ -206,8 +333,30 @@ var (
 	OrgDescription = rootKey("org.description")
 	// OrchestratorStartupAttempts is how many time to attempt to connect to core infrastructure on startup
 	OrchestratorStartupAttempts = rootKey("orchestrator.startupAttempts")
+	// PublicStorageBatchPayloadLimit is the maximum size of a batch payload that will be retrieved from public storage
+	PublicStorageBatchPayloadLimit = rootKey("publicstorage.batchPayloadLimit")
+	// PublicStorageCacheDirectory is a local directory for caching retrieved payloads on disk. If empty, payloads are only cached in memory
+	PublicStorageCacheDirectory = rootKey("publicstorage.cache.directory")
+	// PublicStorageCacheSize is the total size of retrieved payloads to cache in memory. If zero, payloads are not cached in memory
+	PublicStorageCacheSize = rootKey("publicstorage.cache.size")
+	// PublicStorageCacheTTL is how long retrieved payloads are cached in memory
+	PublicStorageCacheTTL = rootKey("publicstorage.cache.ttl")
 	// PublicStorageType specifies which public storage interface plugin to use
 	PublicStorageType = rootKey("publicstorage.type")
+	// SubscriptionClassBulkBufferLength the number of events a bulk dispatcher reads in each page (0 to use event.dispatcher.bufferLength)
+	SubscriptionClassBulkBufferLength = rootKey("subscription.classes.bulk.bufferLength")
+	// SubscriptionClassBulkWorkers the maximum number of bulk dispatchers that can query the database concurrently (0 for unlimited)
+	SubscriptionClassBulkWorkers = rootKey("subscription.classes.bulk.workers")
+	// SubscriptionClassRealtimeBufferLength the number of events a realtime dispatcher reads in each page (0 to use event.dispatcher.bufferLength)
+	SubscriptionClassRealtimeBufferLength = rootKey("subscription.classes.realtime.bufferLength")
+	// SubscriptionClassRealtimeWorkers the maximum number of realtime dispatchers that can query the database concurrently (0 for unlimited)
+	SubscriptionClassRealtimeWorkers = rootKey("subscription.classes.realtime.workers")
+	// SubscriptionClassStandardBufferLength the number of events a standard dispatcher reads in each page (0 to use event.dispatcher.bufferLength)
+	SubscriptionClassStandardBufferLength = rootKey("subscription.classes.standard.bufferLength")
+	// SubscriptionClassStandardWorkers the maximum number of standard dispatchers that can query the database concurrently (0 for unlimited)
+	SubscriptionClassStandardWorkers = rootKey("subscription.classes.standard.workers")
+	// SubscriptionDefaultsDeliveryClass the delivery class for subscriptions that do not explicitly configure one
+	SubscriptionDefaultsDeliveryClass = rootKey("subscription.defaults.deliveryClass")
 	// SubscriptionDefaultsReadAhead default read ahead to enable for subscriptions that do not explicitly configure readahead
 	SubscriptionDefaultsReadAhead = rootKey("subscription.defaults.batchSize")
 	// SubscriptionMax maximum number of pre-defined subscriptions that can exist (note for high fan-out consider connecting a dedicated pub/sub broker to the dispatcher)
@@ -224,6 +373,20 @@ var (
 	AssetManagerRetryMaxDelay = rootKey("asset.manager.retry.maxDelay")
 	// AssetManagerRetryFactor the backoff factor to use for retry of database operations
 	AssetManagerRetryFactor = rootKey("asset.manager.retry.factor")
+	// TracingEnabled if true spans are emitted over OTLP for API requests, database groups, connector calls and event delivery
+	TracingEnabled = rootKey("tracing.enabled")
+	// TracingServiceName the service name reported on every span emitted by this node
+	TracingServiceName = rootKey("tracing.serviceName")
+	// TracingSampleRatio the fraction of new traces to sample, between 0 and 1. Traces started by a caller follow the sampling decision of the caller
+	TracingSampleRatio = rootKey("tracing.sampleRatio")
+	// TracingOTLPEndpoint the host and port of the OTLP HTTP collector to export spans to
+	TracingOTLPEndpoint = rootKey("tracing.otlp.endpoint")
+	// TracingOTLPInsecure if true spans are exported over plain HTTP rather than HTTPS
+	TracingOTLPInsecure = rootKey("tracing.otlp.insecure")
+	// TracingMessageCacheSize the number of messages to remember the trace context of, so their confirmation and delivery join the trace that submitted them
+	TracingMessageCacheSize = rootKey("tracing.messageCache.size")
+	// TracingMessageCacheTTL how long to remember the trace context of a submitted message
+	TracingMessageCacheTTL = rootKey("tracing.messageCache.ttl")
 	// UIEnabled set to false to disable the UI (default is true, so UI will be enabled if ui.path is valid)
 	UIEnabled = rootKey("ui.enabled")
 	// UIPath the path on which to serve the UI
@@ -281,6 +444,10 @@ func Reset() {
 	defer keysMutex.Unlock()
 
 	viper.Reset()
+	configFileUsed = ""
+	fileSettings = nil
+	arrayDefaults = make(map[string]bool)
+	resetReloadListeners()
 
 	// Set defaults
 	viper.SetDefault(string(APIDefaultFilterLimit), 25)
@@ -288,8 +455,28 @@ func Reset() {
 	viper.SetDefault(string(APIRequestMaxTimeout), "10m")
 	viper.SetDefault(string(APIMaxFilterLimit), 250)
 	viper.SetDefault(string(APIMaxFilterSkip), 1000) // protects database (skip+limit pagination is not for bulk operations)
+	viper.SetDefault(string(APIMaxBulkMessages), 1000)
 	viper.SetDefault(string(APIRequestTimeout), "120s")
 	viper.SetDefault(string(APIShutdownTimeout), "10s")
+	viper.SetDefault(string(APIAuditEnabled), false)
+	viper.SetDefault(string(APIGraphQLMaxDepth), 10)
+	viper.SetDefault(string(APIGraphQLMaxCost), 1000)
+	viper.SetDefault(string(APIGraphQLNestedDefaultLimit), 10)
+	viper.SetDefault(string(APIRateLimitEnabled), false)
+	viper.SetDefault(string(APIRateLimitRequestsPerSecond), 1000)
+	viper.SetDefault(string(APIRateLimitBurst), 1000)
+	viper.SetDefault(string(APIRateLimitIdentityRequestsPerSecond), 100)
+	viper.SetDefault(string(APIRateLimitIdentityBurst), 200)
+	viper.SetDefault(string(APIDefaultLongPollTimeout), "30s")
+	viper.SetDefault(string(ArchiveEnabled), false)
+	viper.SetDefault(string(ArchiveType), "filesystem")
+	viper.SetDefault(string(ArchiveThreshold), "720h")
+	viper.SetDefault(string(ArchiveInterval), "1h")
+	viper.SetDefault(string(ArchiveBatchSize), 1000)
+	viper.SetDefault(string(ArchiveCacheSize), 10)
+	viper.SetDefault(string(ArchiveCacheTTL), "5m")
+	viper.SetDefault(string(BatchAdaptiveEnabled), false)
+	viper.SetDefault(string(BatchAdaptiveMinSize), 1)
 	viper.SetDefault(string(BatchManagerReadPageSize), 100)
 	viper.SetDefault(string(BatchManagerReadPollTimeout), "30s")
 	viper.SetDefault(string(BatchRetryFactor), 2.0)
@@ -309,33 +496,71 @@ func Reset() {
 	viper.SetDefault(string(CorsEnabled), true)
 	viper.SetDefault(string(CorsMaxAge), 600)
 	viper.SetDefault(string(DataexchangeType), "https")
+	viper.SetDefault(string(DatabaseMigrationsDryRun), false)
 	viper.SetDefault(string(DebugPort), -1)
 	viper.SetDefault(string(EventAggregatorFirstEvent), fftypes.SubOptsFirstEventOldest)
+	viper.SetDefault(string(EventAggregatorBatchCacheLimit), 1000 /* items */)
+	viper.SetDefault(string(EventAggregatorBatchCacheTTL), "1h")
 	viper.SetDefault(string(EventAggregatorBatchSize), 50)
 	viper.SetDefault(string(EventAggregatorBatchTimeout), "250ms")
+	viper.SetDefault(string(EventAggregatorBatchValidators), []string{})
+	viper.SetDefault(string(EventAggregatorRequireNodeSignature), false)
+	viper.SetDefault(string(EventAggregatorVerifyIdentityRegistry), false)
 	viper.SetDefault(string(EventAggregatorPollTimeout), "30s")
 	viper.SetDefault(string(EventAggregatorRetryFactor), 2.0)
 	viper.SetDefault(string(EventAggregatorRetryInitDelay), "100ms")
 	viper.SetDefault(string(EventAggregatorRetryMaxDelay), "30s")
+	viper.SetDefault(string(EventAggregatorRetryJitter), 0.2)
+	viper.SetDefault(string(EventAggregatorRetryCircuitBreakerThreshold), 0)
+	viper.SetDefault(string(EventAggregatorRetryCircuitBreakerResetDelay), "30s")
 	viper.SetDefault(string(EventAggregatorOpCorrelationRetries), 3)
+	viper.SetDefault(string(EventCatchupPageSize), 25)
 	viper.SetDefault(string(EventDBEventsBufferSize), 100)
+	viper.SetDefault(string(EventDXAntiReplayWindow), "10m")
 	viper.SetDefault(string(EventDispatcherBufferLength), 5)
+	viper.SetDefault(string(EventPollerAdaptive), false)
+	viper.SetDefault(string(EventPollerAdaptiveFactor), 2.0)
+	viper.SetDefault(string(EventPollerAdaptiveMaxTimeout), "5m")
 	viper.SetDefault(string(EventDispatcherBatchTimeout), "0")
 	viper.SetDefault(string(EventDispatcherPollTimeout), "30s")
+	viper.SetDefault(string(EventDispatcherRetryFactor), 2.0)
+	viper.SetDefault(string(EventDispatcherRetryInitDelay), "100ms")
+	viper.SetDefault(string(EventDispatcherRetryMaxDelay), "30s")
+	viper.SetDefault(string(EventDispatcherRetryJitter), 0.2)
+	viper.SetDefault(string(EventDispatcherRetryCircuitBreakerThreshold), 0)
+	viper.SetDefault(string(EventDispatcherRetryCircuitBreakerResetDelay), "30s")
 	viper.SetDefault(string(EventTransportsEnabled), []string{"websockets", "webhooks"})
 	viper.SetDefault(string(EventTransportsDefault), "websockets")
 	viper.SetDefault(string(GroupCacheSize), "1Mb")
 	viper.SetDefault(string(GroupCacheTTL), "1h")
 	viper.SetDefault(string(AdminEnabled), false)
+	viper.SetDefault(string(AdminWebSocketEventQueueLength), 250)
+	viper.SetDefault(string(AdminWebSocketReadBufferSize), "16Kb")
+	viper.SetDefault(string(AdminWebSocketWriteBufferSize), "16Kb")
+	viper.SetDefault(string(AuthRBACEnabled), false)
 	viper.SetDefault(string(IdentityType), "onchain")
 	viper.SetDefault(string(Lang), "en")
+	viper.SetDefault(string(LoadSheddingEnabled), false)
+	viper.SetDefault(string(LoadSheddingWindow), "10s")
+	viper.SetDefault(string(LoadSheddingLatencyThreshold), "500ms")
+	viper.SetDefault(string(LoadSheddingErrorRateThreshold), 0.1)
+	viper.SetDefault(string(LoadSheddingDispatchDelay), "1s")
+	viper.SetDefault(string(LoadSheddingRetryAfter), "30s")
 	viper.SetDefault(string(LogLevel), "info")
+	viper.SetDefault(string(LogJSONEnabled), false)
 	viper.SetDefault(string(LogTimeFormat), "2006-01-02T15:04:05.000Z07:00")
 	viper.SetDefault(string(LogUTC), false)
 	viper.SetDefault(string(LogFilesize), "100m")
 	viper.SetDefault(string(LogMaxAge), "24h")
 	viper.SetDefault(string(LogMaxBackups), 2)
 	viper.SetDefault(string(NamespacesDefault), "default")
+	viper.SetDefault(string(NodeEncryptionKeyType), "local")
+	viper.SetDefault(string(NodeDXCertPin), false)
+	viper.SetDefault(string(NodeReadOnly), false)
+	viper.SetDefault(string(NodeStartupBackground), false)
+	viper.SetDefault(string(NodeStartupRetryFactor), 2.0)
+	viper.SetDefault(string(NodeStartupRetryInitDelay), "1s")
+	viper.SetDefault(string(NodeStartupRetryMaxDelay), "30s")
 	viper.SetDefault(string(NamespacesPredefined), fftypes.JSONObjectArray{{"name": "default", "description": "Default predefined namespace"}})
 	viper.SetDefault(string(OrchestratorStartupAttempts), 5)
 	viper.SetDefault(string(PrivateMessagingRetryFactor), 2.0)
@@ -346,6 +571,16 @@ func Reset() {
 	viper.SetDefault(string(PrivateMessagingBatchSize), 200)
 	viper.SetDefault(string(PrivateMessagingBatchTimeout), "1s")
 	viper.SetDefault(string(PrivateMessagingBatchPayloadLimit), "800Kb")
+	viper.SetDefault(string(PublicStorageBatchPayloadLimit), "100Mb")
+	viper.SetDefault(string(PublicStorageCacheSize), "10Mb")
+	viper.SetDefault(string(PublicStorageCacheTTL), "1h")
+	viper.SetDefault(string(SubscriptionClassBulkBufferLength), 50)
+	viper.SetDefault(string(SubscriptionClassBulkWorkers), 2)
+	viper.SetDefault(string(SubscriptionClassRealtimeBufferLength), 0)
+	viper.SetDefault(string(SubscriptionClassRealtimeWorkers), 0)
+	viper.SetDefault(string(SubscriptionClassStandardBufferLength), 0)
+	viper.SetDefault(string(SubscriptionClassStandardWorkers), 20)
+	viper.SetDefault(string(SubscriptionDefaultsDeliveryClass), "standard")
 	viper.SetDefault(string(SubscriptionDefaultsReadAhead), 0)
 	viper.SetDefault(string(SubscriptionMax), 500)
 	viper.SetDefault(string(SubscriptionsRetryInitialDelay), "250ms")
@@ -354,11 +589,19 @@ func Reset() {
 	viper.SetDefault(string(AssetManagerRetryInitialDelay), "250ms")
 	viper.SetDefault(string(AssetManagerRetryMaxDelay), "30s")
 	viper.SetDefault(string(AssetManagerRetryFactor), 2.0)
+	viper.SetDefault(string(TracingEnabled), false)
+	viper.SetDefault(string(TracingServiceName), "firefly")
+	viper.SetDefault(string(TracingSampleRatio), 1.0)
+	viper.SetDefault(string(TracingOTLPEndpoint), "localhost:4318")
+	viper.SetDefault(string(TracingOTLPInsecure), false)
+	viper.SetDefault(string(TracingMessageCacheSize), 1000)
+	viper.SetDefault(string(TracingMessageCacheTTL), "5m")
 	viper.SetDefault(string(UIEnabled), true)
 	viper.SetDefault(string(ValidatorCacheSize), "1Mb")
 	viper.SetDefault(string(ValidatorCacheTTL), "1h")
 	viper.SetDefault(string(IdentityManagerCacheLimit), 100 /* items */)
 	viper.SetDefault(string(IdentityManagerCacheTTL), "1h")
+	viper.SetDefault(string(IdentityManagerKeyRotationGracePeriod), "24h")
 
 	i18n.SetLang(viper.GetString(string(Lang)))
 }
@@ -374,18 +617,29 @@ func ReadConfig(cfgFile string) error {
 	viper.AutomaticEnv()
 	viper.SetConfigType("yaml")
 	if cfgFile != "" {
-		f, err := os.Open(cfgFile)
-		if err == nil {
-			defer f.Close()
-			err = viper.ReadConfig(f)
-		}
-		return err
+		return readConfigFileLocked(cfgFile)
 	}
 	viper.SetConfigName("firefly.core")
 	viper.AddConfigPath("/etc/firefly/")
 	viper.AddConfigPath("$HOME/.firefly")
 	viper.AddConfigPath(".")
-	return viper.ReadInConfig()
+	// Locate the file in the search path, then read it again with its includes and environment variables expanded
+	err := viper.ReadInConfig()
+	if err == nil {
+		err = readConfigFileLocked(viper.ConfigFileUsed())
+	}
+	return err
+}
+
+func readConfigFileLocked(filename string) error {
+	tree, settings, err := readConfigFile(filename)
+	if err != nil {
+		return err
+	}
+	applyConfigTreeLocked(tree)
+	configFileUsed = filename
+	fileSettings = settings
+	return nil
 }
 
 func MergeConfig(configRecords []*fftypes.ConfigRecord) error {
@@ -450,9 +704,81 @@ func GetKnownKeys() []string {
 	return keys
 }
 
+// ConfigFileUsed returns the name of the config file that was read, or an empty string if no file was read
+func ConfigFileUsed() string {
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+	return configFileUsed
+}
+
+// UnknownKeys returns the keys set in the config file that are not known to the core or to any plugin, which
+// are usually misspelled. A key is known if it, or a key it is nested beneath, is known - as some keys hold
+// objects such as HTTP headers. Keys within the entries of an array are checked against the keys known for
+// that entry, and against the keys known for every entry of the array.
+func UnknownKeys() []string {
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+
+	known := make(map[string]bool, len(knownKeys))
+	for k := range knownKeys {
+		// Viper reports all keys in lower case
+		known[strings.ToLower(k)] = true
+	}
+	unknown := make([]string, 0)
+	for k, v := range fileSettings {
+		unknown = appendUnknownKeys(unknown, known, k, k, v)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// appendUnknownKeys checks a key by its path, and by its generic path in which array indexes are replaced with []
+func appendUnknownKeys(unknown []string, known map[string]bool, key, generic string, value interface{}) []string {
+	if isKnownKey(known, key) || isKnownKey(known, generic) {
+		return unknown
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			k = strings.ToLower(k)
+			unknown = appendUnknownKeys(unknown, known, key+"."+k, generic+"."+k, child)
+		}
+		return unknown
+	case []interface{}:
+		hasEntries := false
+		for i, entry := range v {
+			if m, ok := entry.(map[string]interface{}); ok {
+				hasEntries = true
+				for k, child := range m {
+					k = strings.ToLower(k)
+					unknown = appendUnknownKeys(unknown, known, fmt.Sprintf("%s.%d.%s", key, i, k), generic+"[]."+k, child)
+				}
+			}
+		}
+		if hasEntries {
+			return unknown
+		}
+	}
+	return append(unknown, key)
+}
+
+func isKnownKey(known map[string]bool, key string) bool {
+	for {
+		if known[key] {
+			return true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
 // configPrefix is the main config structure passed to plugins, and used for root to wrap viper
 type configPrefix struct {
-	prefix string
+	prefix  string
+	inArray bool
 }
 
 // configPrefixArray is a point in the config that supports an array
@@ -482,7 +808,8 @@ func (c *configPrefix) prefixKey(k string) string {
 
 func (c *configPrefix) SubPrefix(suffix string) Prefix {
 	return &configPrefix{
-		prefix: c.prefix + suffix + ".",
+		prefix:  c.prefix + suffix + ".",
+		inArray: c.inArray,
 	}
 }
 
@@ -496,8 +823,20 @@ func (c *configPrefix) Array() PrefixArray {
 func (c *configPrefixArray) ArraySize() int {
 	val := viper.Get(c.base)
 	vt := reflect.TypeOf(val)
-	if vt != nil && (vt.Kind() == reflect.Slice || vt.Kind() == reflect.Map) {
+	if vt == nil {
+		return 0
+	}
+	switch vt.Kind() {
+	case reflect.Slice:
 		return reflect.ValueOf(val).Len()
+	case reflect.Map:
+		// The defaults set on the entries we have already returned hide an array read from the
+		// config file, so we check for further entries in the file by index
+		size := reflect.ValueOf(val).Len()
+		for viper.Get(fmt.Sprintf("%s.%d", c.base, size)) != nil {
+			size++
+		}
+		return size
 	}
 	return 0
 }
@@ -505,19 +844,17 @@ func (c *configPrefixArray) ArraySize() int {
 // ArrayEntry must only be called after the config has been loaded
 func (c *configPrefixArray) ArrayEntry(i int) Prefix {
 	cp := &configPrefix{
-		prefix: c.base + fmt.Sprintf(".%d.", i),
+		prefix:  c.base + fmt.Sprintf(".%d.", i),
+		inArray: true,
 	}
 	for knownKey, defValue := range c.defaults {
+		// Defaults are set directly on the entry, as Viper can't handle defaults inside the array
 		cp.AddKnownKey(knownKey, defValue...)
-		// Sadly Viper can't handle defaults inside the array, when
-		// a value is set. So here we check/set the defaults.
-		if defValue != nil && cp.Get(knownKey) == nil {
-			if len(defValue) == 1 {
-				cp.Set(knownKey, defValue[0])
-			} else if len(defValue) > 0 {
-				cp.Set(knownKey, defValue)
-			}
-		}
+	}
+	if len(c.defaults) > 0 {
+		keysMutex.Lock()
+		arrayDefaults[c.base] = true
+		keysMutex.Unlock()
 	}
 	return cp
 }
@@ -545,6 +882,14 @@ func (c *configPrefix) AddKnownKey(k string, defValue ...interface{}) {
 
 func (c *configPrefix) SetDefault(k string, defValue interface{}) {
 	key := c.prefix + k
+	if c.inArray {
+		// Sadly Viper can't handle defaults inside an array, when a value is set.
+		// So here we check/set the defaults (including for sub-prefixes of an entry).
+		if viper.Get(key) == nil {
+			viper.Set(key, defValue)
+		}
+		return
+	}
 	viper.SetDefault(key, defValue)
 }
 
@@ -679,6 +1024,16 @@ func (c *configPrefix) GetObjectArray(key string) fftypes.JSONObjectArray {
 	return v
 }
 
+// GetPredefinedNamespace gets the configuration of a predefined namespace, or nil if the namespace is not predefined
+func GetPredefinedNamespace(name string) fftypes.JSONObject {
+	for _, nsObject := range GetObjectArray(NamespacesPredefined) {
+		if nsObject.GetString("name") == name {
+			return nsObject
+		}
+	}
+	return nil
+}
+
 // Get gets a configuration in raw form
 func Get(key RootKey) interface{} {
 	return root.Get(string(key))
@@ -711,12 +1066,6 @@ func (c *configPrefix) Resolve(key string) string {
 
 // SetupLogging initializes logging
 func SetupLogging(ctx context.Context) {
-	log.SetFormatting(log.Formatting{
-		DisableColor:    GetBool(LogNoColor),
-		ForceColor:      GetBool(LogForceColor),
-		TimestampFormat: GetString(LogTimeFormat),
-		UTC:             GetBool(LogUTC),
-	})
 	logFilename := GetString(LogFilename)
 	if logFilename != "" {
 		lumberjack := &lumberjack.Logger{
@@ -728,6 +1077,25 @@ func SetupLogging(ctx context.Context) {
 		}
 		logrus.SetOutput(lumberjack)
 	}
+	ApplyLogSettings(ctx)
+}
+
+// ApplyLogSettings applies the formatting and levels of the log, which unlike the log output can be
+// changed while the node is running
+func ApplyLogSettings(ctx context.Context) {
+	log.SetFormatting(log.Formatting{
+		DisableColor:    GetBool(LogNoColor),
+		ForceColor:      GetBool(LogForceColor),
+		TimestampFormat: GetString(LogTimeFormat),
+		UTC:             GetBool(LogUTC),
+		JSON:            GetBool(LogJSONEnabled),
+	})
+	log.ResetModuleLevels()
+	for module, level := range GetObject(LogModules) {
+		if levelStr, ok := level.(string); ok {
+			log.SetModuleLevel(module, levelStr)
+		}
+	}
 	log.SetLevel(GetString(LogLevel))
-	log.L(ctx).Debugf("Log level: %s", logrus.GetLevel())
+	log.L(ctx).Debugf("Log level: %s modules: %v", log.GetLevel(), log.GetModuleLevels())
 }
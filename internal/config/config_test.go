@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -58,6 +59,8 @@ func TestDefaults(t *testing.T) {
 	assert.Equal(t, []string{"*"}, GetStringSlice(CorsAllowedOrigins))
 	assert.NotEmpty(t, GetObjectArray(NamespacesPredefined))
 	assert.Equal(t, int64(1024*1024), GetByteSize(ValidatorCacheSize))
+	assert.Equal(t, "default", GetPredefinedNamespace("default").GetString("name"))
+	assert.Nil(t, GetPredefinedNamespace("unknown"))
 }
 
 func TestSpecificConfigFileOk(t *testing.T) {
@@ -146,6 +149,27 @@ tokens:
 	assert.Equal(t, []string{"arr1", "arr2"}, sally.GetStringSlice("key2"))
 }
 
+func TestArrayEntrySubPrefixDefaults(t *testing.T) {
+	defer Reset()
+
+	ledgers := NewPluginConfig("ledgers").Array()
+	ledgers.AddKnownKey("name")
+	viper.SetConfigType("yaml")
+	err := viper.ReadConfig(strings.NewReader(`
+ledgers:
+- name: ledger1
+  ethereum:
+    ethconnect:
+      url: http://ethconnect
+`))
+	assert.NoError(t, err)
+	ethconnect := ledgers.ArrayEntry(0).SubPrefix("ethereum").SubPrefix("ethconnect")
+	ethconnect.AddKnownKey("url", "http://default")
+	ethconnect.AddKnownKey("batchSize", 50)
+	assert.Equal(t, "http://ethconnect", ethconnect.GetString("url"))
+	assert.Equal(t, 50, ethconnect.GetInt("batchSize"))
+}
+
 func TestMapOfAdminOverridePlugins(t *testing.T) {
 	defer Reset()
 
@@ -179,6 +203,38 @@ func TestGetKnownKeys(t *testing.T) {
 	}
 }
 
+func TestUnknownKeys(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "unknownkeys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	Reset()
+	defer Reset()
+	NewPluginConfig("ukplugin").AddKnownKey("headers")
+	NewPluginConfig("uktokens").Array().AddKnownKey("name")
+	writeTestConfig(t, fileName, `
+log:
+  level: info
+  levle: debug
+ukplugin:
+  headers:
+    X-Api-Key: abc
+uktokens:
+- name: tok1
+  Plugin: fftokens
+  extra:
+    a: 1
+Mystery:
+- a
+- b
+`)
+	err = ReadConfig(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, fileName, ConfigFileUsed())
+	assert.Equal(t, []string{"log.levle", "mystery", "uktokens.0.extra.a", "uktokens.0.plugin"}, UnknownKeys())
+}
+
 func TestSetupLoggingToFile(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "logtest")
 	assert.NoError(t, err)
@@ -203,6 +259,21 @@ func TestSetupLogging(t *testing.T) {
 	SetupLogging(context.Background())
 }
 
+func TestSetupLoggingModules(t *testing.T) {
+	Reset()
+	Set(LogJSONEnabled, true)
+	Set(LogModules, map[string]interface{}{
+		"aggregator": "trace",
+		"batch":      "error",
+	})
+	SetupLogging(context.Background())
+	assert.Equal(t, map[string]string{"aggregator": "trace", "batch": "error"}, log.GetModuleLevels())
+
+	Reset()
+	SetupLogging(context.Background())
+	assert.Empty(t, log.GetModuleLevels())
+}
+
 func TestMergeConfigOk(t *testing.T) {
 
 	conf1 := fftypes.JSONAnyPtr(`{
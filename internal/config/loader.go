@@ -0,0 +1,144 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/spf13/viper"
+)
+
+// includeKey is the top level key of a config file that lists further YAML files to read, either as a single
+// path or as an array of paths. Relative paths are resolved against the directory of the file that includes them.
+// Each included file is merged over the file that includes it, in order, so later files override earlier ones.
+const includeKey = "include"
+
+// envVarPattern matches ${VAR} and ${VAR:-default} references in config values, and the $${ escape
+var envVarPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// loadConfigFile reads a config file, and any files it includes, expanding references to environment
+// variables in the string values of each. The result is the nested tree of settings from all the files.
+func loadConfigFile(filename string) (map[string]interface{}, error) {
+	merged := viper.New()
+	if err := mergeConfigFile(merged, filename, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	tree := merged.AllSettings()
+	delete(tree, includeKey)
+	return tree, nil
+}
+
+func mergeConfigFile(merged *viper.Viper, filename string, including map[string]bool) error {
+	ctx := context.Background()
+	absFilename, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	if including[absFilename] {
+		return i18n.NewError(ctx, i18n.MsgConfigIncludeCycle, filename)
+	}
+	including[absFilename] = true
+	defer delete(including, absFilename)
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	file := viper.New()
+	file.SetConfigType("yaml")
+	if err := file.ReadConfig(bytes.NewReader(b)); err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgConfigFileParseFailed, filename)
+	}
+	settings, err := expandEnvVars(ctx, file.AllSettings())
+	if err != nil {
+		return err
+	}
+	includes := file.GetStringSlice(includeKey)
+	if err := merged.MergeConfigMap(settings.(map[string]interface{})); err != nil {
+		return err
+	}
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(filename), include)
+		}
+		if err := mergeConfigFile(merged, include, including); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandEnvVars replaces ${VAR} in the string values of the settings with the value of the environment variable.
+// ${VAR:-default} gives a default for when the variable is not set, and $${ is replaced with a literal ${.
+// A reference to a variable that is not set, and has no default, is an error, so that a missing secret
+// is reported at startup rather than being used as an empty value.
+func expandEnvVars(ctx context.Context, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		var err error
+		expanded := envVarPattern.ReplaceAllStringFunc(v, func(ref string) string {
+			if ref == "$${" {
+				return "${"
+			}
+			match := envVarPattern.FindStringSubmatch(ref)
+			if envValue, ok := os.LookupEnv(match[1]); ok {
+				return envValue
+			}
+			if match[2] != "" {
+				return match[3]
+			}
+			if err == nil {
+				err = i18n.NewError(ctx, i18n.MsgConfigEnvVarNotSet, match[1])
+			}
+			return ref
+		})
+		return expanded, err
+	case map[string]interface{}:
+		for k, entry := range v {
+			expanded, err := expandEnvVars(ctx, entry)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = expanded
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for k, entry := range v {
+			converted[fmt.Sprintf("%v", k)] = entry
+		}
+		return expandEnvVars(ctx, converted)
+	case []interface{}:
+		for i, entry := range v {
+			expanded, err := expandEnvVars(ctx, entry)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadConfigEnvVars(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "loadertest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+	os.Setenv("UT_FF_DB_URL", "postgres://user:p@ss#word@db:5432")
+	defer os.Unsetenv("UT_FF_DB_URL")
+	os.Setenv("UT_FF_LIMIT", "75")
+	defer os.Unsetenv("UT_FF_LIMIT")
+
+	Reset()
+	defer Reset()
+	writeTestConfig(t, fileName, `
+api:
+  defaultFilterLimit: ${UT_FF_LIMIT}
+  requestTimeout: ${UT_FF_UNSET:-45s}
+ui:
+  path: /ui/$${NOT_EXPANDED}
+tokens:
+- name: tok1
+  url: http://${UT_FF_UNSET:-localhost}:3000/${UT_FF_LIMIT}
+  password: ${secret:vault:tokens#password}
+database:
+  postgres:
+    url: ${UT_FF_DB_URL}
+`)
+	err = ReadConfig(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, 75, GetInt(APIDefaultFilterLimit))
+	assert.Equal(t, "45s", GetString(APIRequestTimeout))
+	assert.Equal(t, "/ui/${NOT_EXPANDED}", GetString(UIPath))
+	assert.Equal(t, "postgres://user:p@ss#word@db:5432", viper.GetString("database.postgres.url"))
+	tokens := NewPluginConfig("tokens").Array()
+	tokens.AddKnownKey("name")
+	tokens.AddKnownKey("url")
+	assert.Equal(t, 1, tokens.ArraySize())
+	assert.Equal(t, "http://localhost:3000/75", tokens.ArrayEntry(0).GetString("url"))
+	// References to secrets are left to be resolved by the secrets package
+	assert.Equal(t, "${secret:vault:tokens#password}", viper.GetString("tokens.0.password"))
+}
+
+func TestReadConfigEnvVarNotSet(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "loadertest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	Reset()
+	defer Reset()
+	writeTestConfig(t, fileName, "publicstorage:\n- password: ${UT_FF_UNSET}\n")
+	err = ReadConfig(fileName)
+	assert.Regexp(t, "FF10503.*UT_FF_UNSET", err)
+}
+
+func TestReadConfigIncludes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "loadertest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	err = os.Mkdir(path.Join(tmpDir, "env"), 0700)
+	assert.NoError(t, err)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+	os.Setenv("UT_FF_ENV", "prod")
+	defer os.Unsetenv("UT_FF_ENV")
+
+	Reset()
+	defer Reset()
+	writeTestConfig(t, fileName, "include:\n- secrets.yaml\n- env/${UT_FF_ENV}.yaml\napi:\n  defaultFilterLimit: 10\n  maxFilterLimit: 100\nlog:\n  level: info\n")
+	writeTestConfig(t, path.Join(tmpDir, "secrets.yaml"), "api:\n  maxFilterLimit: 200\n")
+	writeTestConfig(t, path.Join(tmpDir, "env", "prod.yaml"), "include: "+path.Join(tmpDir, "env", "common.yaml")+"\napi:\n  maxFilterLimit: 300\n")
+	writeTestConfig(t, path.Join(tmpDir, "env", "common.yaml"), "log:\n  level: warn\n")
+	err = ReadConfig(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, GetInt(APIDefaultFilterLimit))
+	assert.Equal(t, 300, GetInt(APIMaxFilterLimit))
+	assert.Equal(t, "warn", GetString(LogLevel))
+	assert.Nil(t, viper.Get(includeKey))
+
+	// Changes to included files are picked up on reload
+	OnReload(context.Background(), func(ctx context.Context) {}, LogLevel)
+	writeTestConfig(t, path.Join(tmpDir, "env", "common.yaml"), "log:\n  level: debug\n")
+	applied, restartRequired, err := ReloadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"log.level"}, applied)
+	assert.Empty(t, restartRequired)
+	assert.Equal(t, "debug", GetString(LogLevel))
+}
+
+func TestReadConfigIncludeCycle(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "loadertest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	Reset()
+	defer Reset()
+	writeTestConfig(t, fileName, "include: other.yaml\n")
+	writeTestConfig(t, path.Join(tmpDir, "other.yaml"), "include: firefly.core.yaml\n")
+	err = ReadConfig(fileName)
+	assert.Regexp(t, "FF10501.*firefly.core.yaml", err)
+}
+
+func TestReadConfigIncludeMissing(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "loadertest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	Reset()
+	defer Reset()
+	writeTestConfig(t, fileName, "include: missing.yaml\n")
+	err = ReadConfig(fileName)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestReadConfigIncludeBadYAML(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "loadertest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	Reset()
+	defer Reset()
+	writeTestConfig(t, fileName, "include: bad.yaml\n")
+	writeTestConfig(t, path.Join(tmpDir, "bad.yaml"), "api: [\n")
+	err = ReadConfig(fileName)
+	assert.Regexp(t, "FF10502.*bad.yaml", err)
+}
+
+func TestExpandEnvVarsNonStringKeys(t *testing.T) {
+	os.Setenv("UT_FF_VALUE", "value1")
+	defer os.Unsetenv("UT_FF_VALUE")
+	expanded, err := expandEnvVars(context.Background(), []interface{}{
+		map[interface{}]interface{}{1: "${UT_FF_VALUE}", "flag": true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"1": "value1", "flag": true}}, expanded)
+
+	_, err = expandEnvVars(context.Background(), map[interface{}]interface{}{"a": []interface{}{"${UT_FF_UNSET}"}})
+	assert.Regexp(t, "FF10503", err)
+	_, err = expandEnvVars(context.Background(), map[string]interface{}{"a": map[string]interface{}{"b": "${UT_FF_UNSET}"}})
+	assert.Regexp(t, "FF10503", err)
+}
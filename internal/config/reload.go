@@ -0,0 +1,222 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/spf13/viper"
+)
+
+// ReloadListener applies changes to the keys it was registered for, after the config file has been reloaded
+type ReloadListener func(ctx context.Context)
+
+type reloadRegistration struct {
+	ctx      context.Context
+	keys     []string
+	listener ReloadListener
+}
+
+var (
+	reloadMux       sync.Mutex
+	reloadListeners []*reloadRegistration
+	// protected by keysMutex
+	configFileUsed string
+	fileSettings   map[string]interface{}
+	arrayDefaults  = make(map[string]bool)
+)
+
+// OnReload registers a listener that is called when any of the keys, or any key nested beneath them,
+// are changed by reloading the config file. These keys are then reported as applied, rather than as
+// requiring a restart. The listener is removed once the context is done, so components that are
+// restarted along with the orchestrator register with their new context.
+func OnReload(ctx context.Context, listener ReloadListener, keys ...RootKey) {
+	strKeys := make([]string, len(keys))
+	for i, k := range keys {
+		strKeys[i] = string(k)
+	}
+	addReloadListener(ctx, listener, strKeys)
+}
+
+// OnPrefixReload registers a listener for changes to any key within the sections of the config
+func OnPrefixReload(ctx context.Context, listener ReloadListener, prefixes ...Prefix) {
+	strKeys := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		if cp, ok := p.(*configPrefix); ok {
+			strKeys = append(strKeys, strings.TrimSuffix(cp.prefix, "."))
+		}
+	}
+	addReloadListener(ctx, listener, strKeys)
+}
+
+func addReloadListener(ctx context.Context, listener ReloadListener, keys []string) {
+	for i, k := range keys {
+		// Viper reports all keys in lower case
+		keys[i] = strings.ToLower(k)
+	}
+	reloadMux.Lock()
+	defer reloadMux.Unlock()
+	reloadListeners = append(activeReloadListenersLocked(), &reloadRegistration{
+		ctx:      ctx,
+		keys:     keys,
+		listener: listener,
+	})
+}
+
+// activeReloadListenersLocked drops the listeners of components that have shut down
+func activeReloadListenersLocked() []*reloadRegistration {
+	active := make([]*reloadRegistration, 0, len(reloadListeners))
+	for _, r := range reloadListeners {
+		if r.ctx.Err() == nil {
+			active = append(active, r)
+		}
+	}
+	return active
+}
+
+func resetReloadListeners() {
+	reloadMux.Lock()
+	defer reloadMux.Unlock()
+	reloadListeners = nil
+}
+
+func (r *reloadRegistration) matches(key string) bool {
+	for _, k := range r.keys {
+		if key == k || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// readConfigFile returns the tree of settings from the config file and its includes, along with the flattened
+// settings alone, without any defaults or overrides, so that we can tell which keys were changed in the files
+func readConfigFile(filename string) (tree, settings map[string]interface{}, err error) {
+	if tree, err = loadConfigFile(filename); err != nil {
+		return nil, nil, err
+	}
+	v := viper.New()
+	_ = v.MergeConfigMap(tree)
+	settings = make(map[string]interface{})
+	for _, k := range v.AllKeys() {
+		settings[k] = v.Get(k)
+	}
+	return tree, settings, nil
+}
+
+// applyConfigTreeLocked replaces the settings previously read from config files with the tree
+func applyConfigTreeLocked(tree map[string]interface{}) {
+	_ = viper.ReadConfig(bytes.NewReader([]byte{}))
+	_ = viper.MergeConfigMap(tree)
+}
+
+func changedKeys(before, after map[string]interface{}) []string {
+	changed := make([]string, 0)
+	for k, v := range after {
+		if !reflect.DeepEqual(before[k], v) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// ReloadConfig reads the config file again, and calls the listeners for the keys that changed in the file.
+// Changes to keys with no listener are returned as requiring a restart, although the new values are visible
+// to components that read them later. The file is checked before it replaces the current config, so a file
+// that cannot be parsed leaves the current config in place.
+func ReloadConfig(ctx context.Context) (applied, restartRequired []string, err error) {
+	keysMutex.Lock()
+	filename := configFileUsed
+	if filename == "" {
+		keysMutex.Unlock()
+		return nil, nil, i18n.NewError(ctx, i18n.MsgConfigReloadNoFile)
+	}
+	tree, settings, err := readConfigFile(filename)
+	if err != nil {
+		keysMutex.Unlock()
+		return nil, nil, i18n.WrapError(ctx, err, i18n.MsgConfigFailed)
+	}
+	// The file has been parsed successfully, so it can now replace the current config
+	applyConfigTreeLocked(tree)
+	changed := changedKeys(fileSettings, settings)
+	fileSettings = settings
+	clearArrayDefaultsLocked(changed)
+	keysMutex.Unlock()
+
+	reloadMux.Lock()
+	reloadListeners = activeReloadListenersLocked()
+	listeners := make([]*reloadRegistration, 0)
+	applied = make([]string, 0)
+	restartRequired = make([]string, 0)
+	for _, k := range changed {
+		matched := false
+		for _, r := range reloadListeners {
+			if r.matches(k) {
+				matched = true
+				listeners = appendUnique(listeners, r)
+			}
+		}
+		if matched {
+			applied = append(applied, k)
+		} else {
+			restartRequired = append(restartRequired, k)
+		}
+	}
+	reloadMux.Unlock()
+
+	for _, r := range listeners {
+		r.listener(r.ctx)
+	}
+	log.L(ctx).Infof("Reloaded config from %s: applied=%v restartRequired=%v", filename, applied, restartRequired)
+	return applied, restartRequired, nil
+}
+
+// clearArrayDefaultsLocked removes the defaults set on the entries of arrays that have changed, as they would
+// otherwise hide the new entries. The defaults are set again on the new entries when they are next read.
+func clearArrayDefaultsLocked(changed []string) {
+	for base := range arrayDefaults {
+		for _, k := range changed {
+			if k == base || strings.HasPrefix(k, base+".") || strings.HasPrefix(base, k+".") {
+				viper.Set(base, nil)
+				delete(arrayDefaults, base)
+				break
+			}
+		}
+	}
+}
+
+func appendUnique(listeners []*reloadRegistration, r *reloadRegistration) []*reloadRegistration {
+	for _, existing := range listeners {
+		if existing == r {
+			return listeners
+		}
+	}
+	return append(listeners, r)
+}
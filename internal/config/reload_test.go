@@ -0,0 +1,132 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestConfig(t *testing.T, fileName, content string) {
+	err := ioutil.WriteFile(fileName, []byte(content), 0600)
+	assert.NoError(t, err)
+}
+
+func TestReloadConfigApplied(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "reloadtest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	Reset()
+	defer Reset()
+	writeTestConfig(t, fileName, "log:\n  level: info\napi:\n  defaultFilterLimit: 50\nbatch:\n  retry:\n    initDelay: 1s\n")
+	err = ReadConfig(fileName)
+	assert.NoError(t, err)
+
+	logReloads := 0
+	OnReload(context.Background(), func(ctx context.Context) {
+		logReloads++
+		assert.Equal(t, "debug", GetString(LogLevel))
+	}, LogLevel, LogModules)
+	retryReloads := 0
+	OnReload(context.Background(), func(ctx context.Context) { retryReloads++ }, BatchRetryInitDelay, BatchRetryMaxDelay)
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	OnReload(cancelledCtx, func(ctx context.Context) { assert.Fail(t, "should not be called") }, APIDefaultFilterLimit)
+
+	writeTestConfig(t, fileName, "log:\n  level: debug\n  modules:\n    batch: trace\napi:\n  defaultFilterLimit: 51\nbatch:\n  retry:\n    initDelay: 1s\n")
+	applied, restartRequired, err := ReloadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"log.level", "log.modules.batch"}, applied)
+	assert.Equal(t, []string{"api.defaultfilterlimit"}, restartRequired)
+	assert.Equal(t, 1, logReloads)
+	assert.Equal(t, 0, retryReloads)
+	assert.Equal(t, 51, GetInt(APIDefaultFilterLimit))
+}
+
+func TestReloadConfigBadFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "reloadtest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	Reset()
+	defer Reset()
+	writeTestConfig(t, fileName, "api:\n  defaultFilterLimit: 50\n")
+	err = ReadConfig(fileName)
+	assert.NoError(t, err)
+
+	writeTestConfig(t, fileName, "api: [\n")
+	_, _, err = ReloadConfig(context.Background())
+	assert.Regexp(t, "FF10101", err)
+	assert.Equal(t, 50, GetInt(APIDefaultFilterLimit))
+
+	os.Remove(fileName)
+	_, _, err = ReloadConfig(context.Background())
+	assert.Regexp(t, "FF10101", err)
+}
+
+func TestReloadConfigNoFile(t *testing.T) {
+	Reset()
+	_, _, err := ReloadConfig(context.Background())
+	assert.Regexp(t, "FF10497", err)
+}
+
+func TestReloadConfigArray(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "reloadtest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	Reset()
+	defer Reset()
+	gwPrefix := NewPluginConfig("plugin").SubPrefix("gateways")
+	gwArray := gwPrefix.Array()
+	gwArray.AddKnownKey("url")
+	gwArray.AddKnownKey("timeout", "5s")
+	writeTestConfig(t, fileName, "plugin:\n  gateways:\n  - url: http://gw1\n  - url: http://gw2\n    timeout: 10s\n")
+	err = ReadConfig(fileName)
+	assert.NoError(t, err)
+
+	readGateways := func() []string {
+		gateways := []string{}
+		for n := 0; n < gwArray.ArraySize(); n++ {
+			entry := gwArray.ArrayEntry(n)
+			gateways = append(gateways, entry.GetString("url")+"="+entry.GetString("timeout"))
+		}
+		return gateways
+	}
+	assert.Equal(t, []string{"http://gw1=5s", "http://gw2=10s"}, readGateways())
+
+	var reloaded []string
+	OnPrefixReload(context.Background(), func(ctx context.Context) {
+		reloaded = readGateways()
+	}, gwPrefix)
+
+	writeTestConfig(t, fileName, "plugin:\n  gateways:\n  - url: http://gw3\n")
+	applied, restartRequired, err := ReloadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"plugin.gateways"}, applied)
+	assert.Empty(t, restartRequired)
+	assert.Equal(t, []string{"http://gw3=5s"}, reloaded)
+}
@@ -17,8 +17,13 @@
 package wsconfig
 
 import (
+	"context"
+
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/internal/secrets"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/wsclient"
 )
 
@@ -63,9 +68,33 @@ func GenerateConfigFromPrefix(prefix config.Prefix) *wsclient.WSConfig {
 		InitialDelay:           prefix.GetDuration(restclient.HTTPConfigRetryInitDelay),
 		MaximumDelay:           prefix.GetDuration(restclient.HTTPConfigRetryMaxDelay),
 		InitialConnectAttempts: prefix.GetInt(WSConfigKeyInitialConnectAttempts),
-		HTTPHeaders:            prefix.GetObject(restclient.HTTPConfigHeaders),
-		AuthUsername:           prefix.GetString(restclient.HTTPConfigAuthUsername),
-		AuthPassword:           prefix.GetString(restclient.HTTPConfigAuthPassword),
+		HTTPHeaders:            resolveHeaders(prefix.GetObject(restclient.HTTPConfigHeaders)),
+		AuthUsername:           resolveSecret(prefix.GetString(restclient.HTTPConfigAuthUsername)),
+		AuthPassword:           resolveSecret(prefix.GetString(restclient.HTTPConfigAuthPassword)),
 		HeartbeatInterval:      prefix.GetDuration(WSConfigHeartbeatInterval),
 	}
 }
+
+// resolveSecret resolves the secrets a credential refers to. The secrets in the config are fetched at startup,
+// so a failure here is only logged. Rotated secrets are used by the websocket after a restart.
+func resolveSecret(value string) string {
+	ctx := context.Background()
+	resolved, err := secrets.Resolve(ctx, value)
+	if err != nil {
+		log.L(ctx).Errorf("Failed to resolve secret for websocket: %s", err)
+		return value
+	}
+	return resolved
+}
+
+// resolveHeaders returns a copy of the headers with their secrets resolved, leaving the config unchanged
+func resolveHeaders(headers fftypes.JSONObject) fftypes.JSONObject {
+	resolved := make(fftypes.JSONObject, len(headers))
+	for k, v := range headers {
+		if vs, ok := v.(string); ok {
+			v = resolveSecret(vs)
+		}
+		resolved[k] = v
+	}
+	return resolved
+}
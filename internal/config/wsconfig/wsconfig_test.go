@@ -1,6 +1,9 @@
 package wsconfig
 
 import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -45,3 +48,26 @@ func TestWSConfigGeneration(t *testing.T) {
 	assert.Equal(t, 1024, wsConfig.ReadBufferSize)
 	assert.Equal(t, 1024, wsConfig.WriteBufferSize)
 }
+
+func TestWSConfigGenerationSecrets(t *testing.T) {
+	resetConf()
+	filename := filepath.Join(t.TempDir(), "password")
+	err := ioutil.WriteFile(filename, []byte("pass"), 0600)
+	assert.NoError(t, err)
+
+	headers := map[string]interface{}{
+		"x-api-key": fmt.Sprintf("${secret:file:%s}", filename),
+		"count":     1,
+	}
+	utConfPrefix.Set(restclient.HTTPConfigHeaders, headers)
+	utConfPrefix.Set(restclient.HTTPConfigAuthUsername, "user")
+	utConfPrefix.Set(restclient.HTTPConfigAuthPassword, "${secret:vault:ws#password}")
+
+	wsConfig := GenerateConfigFromPrefix(utConfPrefix)
+	assert.Equal(t, "pass", wsConfig.HTTPHeaders.GetString("x-api-key"))
+	assert.Equal(t, "user", wsConfig.AuthUsername)
+	// The unresolved value is used when a secret cannot be fetched
+	assert.Equal(t, "${secret:vault:ws#password}", wsConfig.AuthPassword)
+	// The config is not modified
+	assert.Equal(t, fmt.Sprintf("${secret:file:%s}", filename), utConfPrefix.GetObject(restclient.HTTPConfigHeaders).GetString("x-api-key"))
+}
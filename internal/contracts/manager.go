@@ -22,8 +22,10 @@ import (
 	"strings"
 
 	"github.com/hyperledger/firefly/internal/broadcast"
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
+	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
@@ -39,13 +41,17 @@ type Manager interface {
 	GetFFIByIDWithChildren(ctx context.Context, id *fftypes.UUID) (*fftypes.FFI, error)
 	GetFFIs(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.FFI, *database.FilterResult, error)
 
-	InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest) (interface{}, error)
-	InvokeContractAPI(ctx context.Context, ns, apiName, methodPath string, req *fftypes.ContractCallRequest) (interface{}, error)
+	InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest, waitConfirm bool) (interface{}, error)
+	InvokeContractAPI(ctx context.Context, ns, apiName, methodPath string, req *fftypes.ContractCallRequest, waitConfirm bool) (interface{}, error)
+	SubmitRawTransaction(ctx context.Context, ns string, req *fftypes.RawTransactionRequest) (*fftypes.Operation, error)
+	RetryOperation(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error)
 	GetContractAPI(ctx context.Context, httpServerURL, ns, apiName string) (*fftypes.ContractAPI, error)
 	GetContractAPIs(ctx context.Context, httpServerURL, ns string, filter database.AndFilter) ([]*fftypes.ContractAPI, *database.FilterResult, error)
 	BroadcastContractAPI(ctx context.Context, httpServerURL, ns string, api *fftypes.ContractAPI, waitConfirm bool) (output *fftypes.ContractAPI, err error)
 	SubscribeContract(ctx context.Context, ns, eventPath string, req *fftypes.ContractSubscribeRequest) (*fftypes.ContractSubscription, error)
 	SubscribeContractAPI(ctx context.Context, ns, apiName, eventPath string, req *fftypes.ContractSubscribeRequest) (*fftypes.ContractSubscription, error)
+	GetContractAPISubscriptions(ctx context.Context, ns, apiName string, filter database.AndFilter) ([]*fftypes.ContractSubscription, *database.FilterResult, error)
+	DeleteContractAPISubscription(ctx context.Context, ns, apiName, nameOrID string) error
 
 	ValidateFFIAndSetPathnames(ctx context.Context, ffi *fftypes.FFI) error
 
@@ -54,6 +60,7 @@ type Manager interface {
 	GetContractSubscriptions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.ContractSubscription, *database.FilterResult, error)
 	DeleteContractSubscriptionByNameOrID(ctx context.Context, ns, nameOrID string) error
 	GenerateFFI(ctx context.Context, ns string, generationRequest *fftypes.FFIGenerationRequest) (*fftypes.FFI, error)
+	ImportFFI(ctx context.Context, ns string, generationRequest *fftypes.FFIGenerationRequest, waitConfirm bool) (*fftypes.FFI, error)
 }
 
 type contractManager struct {
@@ -63,11 +70,13 @@ type contractManager struct {
 	broadcast         broadcast.Manager
 	identity          identity.Manager
 	blockchain        blockchain.Plugin
+	syncasync         syncasync.Bridge
 	ffiParamValidator fftypes.FFIParamValidator
+	readOnly          bool
 }
 
-func NewContractManager(ctx context.Context, database database.Plugin, publicStorage publicstorage.Plugin, broadcast broadcast.Manager, identity identity.Manager, blockchain blockchain.Plugin) (Manager, error) {
-	if database == nil || publicStorage == nil || broadcast == nil || identity == nil || blockchain == nil {
+func NewContractManager(ctx context.Context, database database.Plugin, publicStorage publicstorage.Plugin, broadcast broadcast.Manager, identity identity.Manager, blockchain blockchain.Plugin, sa syncasync.Bridge) (Manager, error) {
+	if database == nil || publicStorage == nil || broadcast == nil || identity == nil || blockchain == nil || sa == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 	v, err := blockchain.GetFFIParamValidator(ctx)
@@ -81,10 +90,20 @@ func NewContractManager(ctx context.Context, database database.Plugin, publicSto
 		broadcast:         broadcast,
 		identity:          identity,
 		blockchain:        blockchain,
+		syncasync:         sa,
 		ffiParamValidator: v,
+		readOnly:          config.GetBool(config.NodeReadOnly),
 	}, nil
 }
 
+// checkWritable refuses to submit a blockchain transaction when the node is in read-only mode
+func (cm *contractManager) checkWritable(ctx context.Context) error {
+	if cm.readOnly {
+		return i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+	}
+	return nil
+}
+
 func (cm *contractManager) newFFISchemaCompiler() *jsonschema.Compiler {
 	c := fftypes.NewFFISchemaCompiler()
 	if cm.ffiParamValidator != nil {
@@ -161,8 +180,8 @@ func (cm *contractManager) GetFFIs(ctx context.Context, ns string, filter databa
 	return cm.database.GetFFIs(ctx, ns, filter)
 }
 
-func (cm *contractManager) writeInvokeTransaction(ctx context.Context, ns string, input fftypes.JSONObject) (*fftypes.Operation, error) {
-	txid, err := cm.txHelper.SubmitNewTransaction(ctx, ns, fftypes.TransactionTypeContractInvoke)
+func (cm *contractManager) writeInvokeTransaction(ctx context.Context, ns string, input fftypes.JSONObject, fee *fftypes.TransactionFee) (*fftypes.Operation, error) {
+	txid, err := cm.txHelper.SubmitNewTransaction(ctx, ns, fftypes.TransactionTypeContractInvoke, "")
 	if err != nil {
 		return nil, err
 	}
@@ -173,15 +192,29 @@ func (cm *contractManager) writeInvokeTransaction(ctx context.Context, ns string
 		txid,
 		fftypes.OpTypeBlockchainInvoke)
 	op.Input = input
+	txcommon.AddTransactionFeeInput(op, fee)
 	return op, cm.database.InsertOperation(ctx, op)
 }
 
-func (cm *contractManager) InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest) (res interface{}, err error) {
+func (cm *contractManager) InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest, waitConfirm bool) (res interface{}, err error) {
+	if req.Type == fftypes.CallTypeInvoke {
+		if err := cm.checkWritable(ctx); err != nil {
+			return nil, err
+		}
+	}
 	req.Key, err = cm.identity.ResolveSigningKey(ctx, req.Key)
 	if err != nil {
 		return nil, err
 	}
 
+	// The fee is resolved outside of the database transaction, as it might need to query a gas oracle
+	var fee *fftypes.TransactionFee
+	if req.Type == fftypes.CallTypeInvoke {
+		if fee, err = cm.blockchain.ResolveTransactionFee(ctx, ns); err != nil {
+			return nil, err
+		}
+	}
+
 	var op *fftypes.Operation
 	err = cm.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
 		if req.Method, err = cm.resolveInvokeContractRequest(ctx, ns, req); err != nil {
@@ -191,7 +224,7 @@ func (cm *contractManager) InvokeContract(ctx context.Context, ns string, req *f
 			return err
 		}
 		if req.Type == fftypes.CallTypeInvoke {
-			op, err = cm.writeInvokeTransaction(ctx, ns, req.Input)
+			op, err = cm.writeInvokeTransaction(ctx, ns, req.Input, fee)
 			if err != nil {
 				return err
 			}
@@ -204,21 +237,84 @@ func (cm *contractManager) InvokeContract(ctx context.Context, ns string, req *f
 
 	switch req.Type {
 	case fftypes.CallTypeInvoke:
-		err = cm.blockchain.InvokeContract(ctx, op.ID, req.Key, req.Location, req.Method, req.Input)
-		res = &fftypes.ContractCallResponse{ID: op.ID}
+		send := func(ctx context.Context) error {
+			err := cm.blockchain.InvokeContract(ctx, op.ID, req.Key, req.Location, req.Method, req.Input, fee)
+			if err != nil {
+				cm.txHelper.WriteOperationFailure(ctx, op, err)
+			}
+			return err
+		}
+		if waitConfirm {
+			// The completed operation is returned, including the receipt from the blockchain
+			return cm.syncasync.WaitForInvokeOpResult(ctx, ns, op.ID, send)
+		}
+		return &fftypes.ContractCallResponse{ID: op.ID}, send(ctx)
 	case fftypes.CallTypeQuery:
-		res, err = cm.blockchain.QueryContract(ctx, req.Location, req.Method, req.Input)
+		return cm.blockchain.QueryContract(ctx, req.Location, req.Method, req.Input)
 	default:
 		panic(fmt.Sprintf("unknown call type: %s", req.Type))
 	}
+}
+
+func (cm *contractManager) SubmitRawTransaction(ctx context.Context, ns string, req *fftypes.RawTransactionRequest) (op *fftypes.Operation, err error) {
+	if err := cm.checkWritable(ctx); err != nil {
+		return nil, err
+	}
+	if len(req.Transaction) == 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "transaction")
+	}
+	req.Key, err = cm.identity.ResolveSigningKey(ctx, req.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cm.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		txid, err := cm.txHelper.SubmitNewTransaction(ctx, ns, fftypes.TransactionTypeRawTransaction, "")
+		if err != nil {
+			return err
+		}
+		op = fftypes.NewOperation(
+			cm.blockchain,
+			ns,
+			txid,
+			fftypes.OpTypeBlockchainRawTransaction)
+		op.Input = fftypes.JSONObject{
+			"key":         req.Key,
+			"transaction": req.Transaction,
+		}
+		return cm.database.InsertOperation(ctx, op)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cm.blockchain.SubmitRawTransaction(ctx, op.ID, req.Key, req.Transaction); err != nil {
+		cm.txHelper.WriteOperationFailure(ctx, op, err)
+		return nil, err
+	}
+	return op, nil
+}
 
-	if op != nil && err != nil {
-		cm.txHelper.WriteOperationFailure(ctx, op.ID, err)
+func (cm *contractManager) RetryOperation(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error) {
+	// Only raw transactions record everything needed to resubmit them in the operation input
+	if op.Type != fftypes.OpTypeBlockchainRawTransaction {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationNotRetryable, op.ID, op.Type)
+	}
+	if err := cm.checkWritable(ctx); err != nil {
+		return nil, err
+	}
+	retry, err := cm.txHelper.PrepareOperationRetry(ctx, op)
+	if err != nil {
+		return nil, err
 	}
-	return res, err
+	if err = cm.blockchain.SubmitRawTransaction(ctx, retry.ID, retry.Input.GetString("key"), retry.Input.GetObject("transaction")); err != nil {
+		cm.txHelper.WriteOperationFailure(ctx, retry, err)
+		return nil, err
+	}
+	return retry, nil
 }
 
-func (cm *contractManager) InvokeContractAPI(ctx context.Context, ns, apiName, methodPath string, req *fftypes.ContractCallRequest) (interface{}, error) {
+func (cm *contractManager) InvokeContractAPI(ctx context.Context, ns, apiName, methodPath string, req *fftypes.ContractCallRequest, waitConfirm bool) (interface{}, error) {
 	api, err := cm.database.GetContractAPIByName(ctx, ns, apiName)
 	if err != nil {
 		return nil, err
@@ -232,7 +328,7 @@ func (cm *contractManager) InvokeContractAPI(ctx context.Context, ns, apiName, m
 	if api.Location != nil {
 		req.Location = api.Location
 	}
-	return cm.InvokeContract(ctx, ns, req)
+	return cm.InvokeContract(ctx, ns, req, waitConfirm)
 }
 
 func (cm *contractManager) resolveInvokeContractRequest(ctx context.Context, ns string, req *fftypes.ContractCallRequest) (method *fftypes.FFIMethod, err error) {
@@ -559,11 +655,9 @@ func (cm *contractManager) SubscribeContract(ctx context.Context, ns, eventPath
 }
 
 func (cm *contractManager) SubscribeContractAPI(ctx context.Context, ns, apiName, eventPath string, req *fftypes.ContractSubscribeRequest) (*fftypes.ContractSubscription, error) {
-	api, err := cm.database.GetContractAPIByName(ctx, ns, apiName)
+	api, err := cm.getContractAPIWithInterface(ctx, ns, apiName)
 	if err != nil {
 		return nil, err
-	} else if api == nil || api.Interface == nil {
-		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
 	}
 
 	req.Interface = api.Interface.ID
@@ -574,6 +668,57 @@ func (cm *contractManager) SubscribeContractAPI(ctx context.Context, ns, apiName
 	return cm.SubscribeContract(ctx, ns, eventPath, req)
 }
 
+func (cm *contractManager) getContractAPIWithInterface(ctx context.Context, ns, apiName string) (*fftypes.ContractAPI, error) {
+	api, err := cm.database.GetContractAPIByName(ctx, ns, apiName)
+	if err != nil {
+		return nil, err
+	} else if api == nil || api.Interface == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return api, nil
+}
+
+// subscriptionInAPI checks a subscription is for the interface of the API, and at the API's location (if the API has one)
+func subscriptionInAPI(sub *fftypes.ContractSubscription, api *fftypes.ContractAPI) bool {
+	if sub.Interface == nil || !sub.Interface.ID.Equals(api.Interface.ID) {
+		return false
+	}
+	return api.Location == nil || (sub.Location != nil && sub.Location.String() == api.Location.String())
+}
+
+func (cm *contractManager) GetContractAPISubscriptions(ctx context.Context, ns, apiName string, filter database.AndFilter) ([]*fftypes.ContractSubscription, *database.FilterResult, error) {
+	api, err := cm.getContractAPIWithInterface(ctx, ns, apiName)
+	if err != nil {
+		return nil, nil, err
+	}
+	fb := filter.Builder()
+	filter = filter.Condition(fb.Eq("interface", api.Interface.ID))
+	if api.Location != nil {
+		filter = filter.Condition(fb.Eq("location", api.Location.String()))
+	}
+	return cm.GetContractSubscriptions(ctx, ns, filter)
+}
+
+func (cm *contractManager) DeleteContractAPISubscription(ctx context.Context, ns, apiName, nameOrID string) error {
+	return cm.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		api, err := cm.getContractAPIWithInterface(ctx, ns, apiName)
+		if err != nil {
+			return err
+		}
+		sub, err := cm.GetContractSubscriptionByNameOrID(ctx, ns, nameOrID)
+		if err != nil {
+			return err
+		}
+		if sub.Namespace != ns || !subscriptionInAPI(sub, api) {
+			return i18n.NewError(ctx, i18n.Msg404NotFound)
+		}
+		if err = cm.blockchain.DeleteSubscription(ctx, sub); err != nil {
+			return err
+		}
+		return cm.database.DeleteContractSubscriptionByID(ctx, sub.ID)
+	})
+}
+
 func (cm *contractManager) checkParamSchema(ctx context.Context, input interface{}, param *fftypes.FFIParam) error {
 	// TODO: Cache the compiled schema?
 	c := jsonschema.NewCompiler()
@@ -595,3 +740,11 @@ func (cm *contractManager) GenerateFFI(ctx context.Context, ns string, generatio
 	generationRequest.Namespace = ns
 	return cm.blockchain.GenerateFFI(ctx, generationRequest)
 }
+
+func (cm *contractManager) ImportFFI(ctx context.Context, ns string, generationRequest *fftypes.FFIGenerationRequest, waitConfirm bool) (*fftypes.FFI, error) {
+	ffi, err := cm.GenerateFFI(ctx, ns, generationRequest)
+	if err != nil {
+		return nil, err
+	}
+	return cm.BroadcastFFI(ctx, ns, ffi, waitConfirm)
+}
@@ -19,14 +19,17 @@ package contracts
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/blockchain/ethereum"
+	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/broadcastmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/mocks/txcommonmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -41,9 +44,11 @@ func newTestContractManager() *contractManager {
 	mbm := &broadcastmocks.Manager{}
 	mim := &identitymanagermocks.Manager{}
 	mbi := &blockchainmocks.Plugin{}
+	msa := &syncasyncmocks.Bridge{}
 	mbi.On("GetFFIParamValidator", mock.Anything).Return(nil, nil)
 
 	mbi.On("Name").Return("mockblockchain").Maybe()
+	mbi.On("ResolveTransactionFee", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
 
 	rag := mdb.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
 	rag.RunFn = func(a mock.Arguments) {
@@ -51,13 +56,13 @@ func newTestContractManager() *contractManager {
 			a[1].(func(context.Context) error)(a[0].(context.Context)),
 		}
 	}
-	cm, _ := NewContractManager(context.Background(), mdb, mps, mbm, mim, mbi)
+	cm, _ := NewContractManager(context.Background(), mdb, mps, mbm, mim, mbi, msa)
 	cm.(*contractManager).txHelper = &txcommonmocks.Helper{}
 	return cm.(*contractManager)
 }
 
 func TestNewContractManagerFail(t *testing.T) {
-	_, err := NewContractManager(context.Background(), nil, nil, nil, nil, nil)
+	_, err := NewContractManager(context.Background(), nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
 
@@ -67,8 +72,9 @@ func TestNewContractManagerFFISchemaLoaderFail(t *testing.T) {
 	mbm := &broadcastmocks.Manager{}
 	mim := &identitymanagermocks.Manager{}
 	mbi := &blockchainmocks.Plugin{}
+	msa := &syncasyncmocks.Bridge{}
 	mbi.On("GetFFIParamValidator", mock.Anything).Return(nil, fmt.Errorf("pop"))
-	_, err := NewContractManager(context.Background(), mdb, mps, mbm, mim, mbi)
+	_, err := NewContractManager(context.Background(), mdb, mps, mbm, mim, mbi, msa)
 	assert.Regexp(t, "pop", err)
 }
 
@@ -78,8 +84,9 @@ func TestNewContractManagerFFISchemaLoader(t *testing.T) {
 	mbm := &broadcastmocks.Manager{}
 	mim := &identitymanagermocks.Manager{}
 	mbi := &blockchainmocks.Plugin{}
+	msa := &syncasyncmocks.Bridge{}
 	mbi.On("GetFFIParamValidator", mock.Anything).Return(&ethereum.FFIParamValidator{}, nil)
-	_, err := NewContractManager(context.Background(), mdb, mps, mbm, mim, mbi)
+	_, err := NewContractManager(context.Background(), mdb, mps, mbm, mim, mbi, msa)
 	assert.NoError(t, err)
 }
 
@@ -990,20 +997,84 @@ func TestInvokeContract(t *testing.T) {
 		},
 	}
 
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
 	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
 		return op.Namespace == "ns1" && op.Type == fftypes.OpTypeBlockchainInvoke && op.Plugin == "mockblockchain"
 	})).Return(nil)
-	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input, (*fftypes.TransactionFee)(nil)).Return(nil)
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 
 	assert.NoError(t, err)
 	mth.AssertExpectations(t)
 }
 
+func TestInvokeContractWithFee(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := &blockchainmocks.Plugin{}
+	cm.blockchain = mbi
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mdi := cm.database.(*databasemocks.Plugin)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+
+	req := &fftypes.ContractCallRequest{
+		Type:      fftypes.CallTypeInvoke,
+		Interface: fftypes.NewUUID(),
+		Ledger:    fftypes.JSONAnyPtr(""),
+		Location:  fftypes.JSONAnyPtr(""),
+		Input:     map[string]interface{}{"fee": "100"},
+		Method: &fftypes.FFIMethod{
+			Name:    "doStuff",
+			ID:      fftypes.NewUUID(),
+			Params:  fftypes.FFIParams{},
+			Returns: fftypes.FFIParams{},
+		},
+	}
+	fee := &fftypes.TransactionFee{
+		Policy:   fftypes.FeePolicyTypeFixed,
+		GasPrice: fftypes.NewFFBigInt(1000000000),
+	}
+
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
+	mbi.On("Name").Return("mockblockchain")
+	mbi.On("ResolveTransactionFee", mock.Anything, "ns1").Return(fee, nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Input["fee"] == fee
+	})).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input, fee).Return(nil)
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "100", req.Input["fee"])
+	mbi.AssertExpectations(t)
+}
+
+func TestInvokeContractFeeFail(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := &blockchainmocks.Plugin{}
+	cm.blockchain = mbi
+	mim := cm.identity.(*identitymanagermocks.Manager)
+
+	req := &fftypes.ContractCallRequest{
+		Type:      fftypes.CallTypeInvoke,
+		Interface: fftypes.NewUUID(),
+		Location:  fftypes.JSONAnyPtr(""),
+	}
+
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
+	mbi.On("ResolveTransactionFee", mock.Anything, "ns1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
+
+	assert.EqualError(t, err, "pop")
+	mbi.AssertExpectations(t)
+}
+
 func TestInvokeContractFail(t *testing.T) {
 	cm := newTestContractManager()
 	mbi := cm.blockchain.(*blockchainmocks.Plugin)
@@ -1024,21 +1095,104 @@ func TestInvokeContractFail(t *testing.T) {
 		},
 	}
 
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
 	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
 		return op.Namespace == "ns1" && op.Type == fftypes.OpTypeBlockchainInvoke && op.Plugin == "mockblockchain"
 	})).Return(nil)
-	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input).Return(fmt.Errorf("pop"))
+	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input, (*fftypes.TransactionFee)(nil)).Return(fmt.Errorf("pop"))
 	mth.On("WriteOperationFailure", mock.Anything, mock.Anything, fmt.Errorf("pop"))
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 
 	assert.EqualError(t, err, "pop")
 	mth.AssertExpectations(t)
 }
 
+func TestInvokeContractConfirm(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mdi := cm.database.(*databasemocks.Plugin)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+	msa := cm.syncasync.(*syncasyncmocks.Bridge)
+
+	req := &fftypes.ContractCallRequest{
+		Type:      fftypes.CallTypeInvoke,
+		Interface: fftypes.NewUUID(),
+		Ledger:    fftypes.JSONAnyPtr(""),
+		Location:  fftypes.JSONAnyPtr(""),
+		Method: &fftypes.FFIMethod{
+			Name:    "doStuff",
+			ID:      fftypes.NewUUID(),
+			Params:  fftypes.FFIParams{},
+			Returns: fftypes.FFIParams{},
+		},
+	}
+
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Namespace == "ns1" && op.Type == fftypes.OpTypeBlockchainInvoke && op.Plugin == "mockblockchain"
+	})).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input, (*fftypes.TransactionFee)(nil)).Return(nil)
+	completed := &fftypes.Operation{Status: fftypes.OpStatusSucceeded}
+	msa.On("WaitForInvokeOpResult", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.UUID"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			send := args[3].(syncasync.RequestSender)
+			send(context.Background())
+		}).
+		Return(completed, nil)
+
+	res, err := cm.InvokeContract(context.Background(), "ns1", req, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, completed, res)
+	mbi.AssertExpectations(t)
+	msa.AssertExpectations(t)
+}
+
+func TestInvokeContractConfirmSendFail(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mdi := cm.database.(*databasemocks.Plugin)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+	msa := cm.syncasync.(*syncasyncmocks.Bridge)
+
+	req := &fftypes.ContractCallRequest{
+		Type:      fftypes.CallTypeInvoke,
+		Interface: fftypes.NewUUID(),
+		Ledger:    fftypes.JSONAnyPtr(""),
+		Location:  fftypes.JSONAnyPtr(""),
+		Method: &fftypes.FFIMethod{
+			Name:    "doStuff",
+			ID:      fftypes.NewUUID(),
+			Params:  fftypes.FFIParams{},
+			Returns: fftypes.FFIParams{},
+		},
+	}
+
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input, (*fftypes.TransactionFee)(nil)).Return(fmt.Errorf("pop"))
+	mth.On("WriteOperationFailure", mock.Anything, mock.Anything, fmt.Errorf("pop"))
+	msa.On("WaitForInvokeOpResult", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.UUID"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			send := args[3].(syncasync.RequestSender)
+			send(context.Background())
+		}).
+		Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, true)
+
+	assert.EqualError(t, err, "pop")
+	mth.AssertExpectations(t)
+	msa.AssertExpectations(t)
+}
+
 func TestInvokeContractFailResolveSigningKey(t *testing.T) {
 	cm := newTestContractManager()
 	mim := cm.identity.(*identitymanagermocks.Manager)
@@ -1052,7 +1206,7 @@ func TestInvokeContractFailResolveSigningKey(t *testing.T) {
 
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("", fmt.Errorf("pop"))
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 
 	assert.Regexp(t, "pop", err)
 }
@@ -1070,9 +1224,9 @@ func TestInvokeContractFailResolve(t *testing.T) {
 	}
 
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
-	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, req.Method, req.Input, (*fftypes.TransactionFee)(nil)).Return(nil)
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 
 	assert.Regexp(t, "FF10313", err)
 }
@@ -1096,9 +1250,9 @@ func TestInvokeContractTXFail(t *testing.T) {
 	}
 
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke).Return(nil, fmt.Errorf("pop"))
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(nil, fmt.Errorf("pop"))
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 
 	assert.EqualError(t, err, "pop")
 }
@@ -1118,7 +1272,7 @@ func TestInvokeContractNoMethodSignature(t *testing.T) {
 
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 
 	assert.Regexp(t, "FF10314", err)
 }
@@ -1141,7 +1295,7 @@ func TestInvokeContractMethodNotFound(t *testing.T) {
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
 	mdb.On("GetFFIMethod", mock.Anything, "ns1", req.Interface, req.Method.Name).Return(nil, fmt.Errorf("pop"))
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 
 	assert.Regexp(t, "FF10315", err)
 }
@@ -1178,7 +1332,7 @@ func TestInvokeContractMethodBadInput(t *testing.T) {
 	}
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 	assert.Regexp(t, "FF10304", err)
 }
 
@@ -1203,13 +1357,13 @@ func TestQueryContract(t *testing.T) {
 	}
 
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
 		return op.Namespace == "ns1" && op.Type == fftypes.OpTypeBlockchainInvoke && op.Plugin == "mockblockchain"
 	})).Return(nil)
 	mbi.On("QueryContract", mock.Anything, req.Location, req.Method, req.Input).Return(struct{}{}, nil)
 
-	_, err := cm.InvokeContract(context.Background(), "ns1", req)
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
 
 	assert.NoError(t, err)
 }
@@ -1233,13 +1387,13 @@ func TestCallContractInvalidType(t *testing.T) {
 	}
 
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
 		return op.Namespace == "ns1" && op.Type == fftypes.OpTypeBlockchainInvoke && op.Plugin == "mockblockchain"
 	})).Return(nil)
 
 	assert.PanicsWithValue(t, "unknown call type: ", func() {
-		cm.InvokeContract(context.Background(), "ns1", req)
+		cm.InvokeContract(context.Background(), "ns1", req, false)
 	})
 }
 
@@ -1357,6 +1511,110 @@ func TestDeleteContractSubscriptionNotFound(t *testing.T) {
 	assert.Regexp(t, "FF10109", err)
 }
 
+func TestSubmitRawTransaction(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mdi := cm.database.(*databasemocks.Plugin)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+
+	req := &fftypes.RawTransactionRequest{
+		Transaction: fftypes.JSONObject{"to": "0x12345"},
+	}
+
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeRawTransaction, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Namespace == "ns1" && op.Type == fftypes.OpTypeBlockchainRawTransaction && op.Plugin == "mockblockchain" &&
+			op.Input.GetString("key") == "key-resolved"
+	})).Return(nil)
+	mbi.On("SubmitRawTransaction", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Transaction).Return(nil)
+
+	op, err := cm.SubmitRawTransaction(context.Background(), "ns1", req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpTypeBlockchainRawTransaction, op.Type)
+	mth.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestSubmitRawTransactionMissingTransaction(t *testing.T) {
+	cm := newTestContractManager()
+
+	_, err := cm.SubmitRawTransaction(context.Background(), "ns1", &fftypes.RawTransactionRequest{})
+	assert.Regexp(t, "FF10140", err)
+}
+
+func TestSubmitRawTransactionBadKey(t *testing.T) {
+	cm := newTestContractManager()
+	mim := cm.identity.(*identitymanagermocks.Manager)
+
+	req := &fftypes.RawTransactionRequest{
+		Transaction: fftypes.JSONObject{"to": "0x12345"},
+	}
+
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("", fmt.Errorf("pop"))
+
+	_, err := cm.SubmitRawTransaction(context.Background(), "ns1", req)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSubmitRawTransactionTXFail(t *testing.T) {
+	cm := newTestContractManager()
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+
+	req := &fftypes.RawTransactionRequest{
+		Transaction: fftypes.JSONObject{"to": "0x12345"},
+	}
+
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeRawTransaction, fftypes.IdempotencyKey("")).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.SubmitRawTransaction(context.Background(), "ns1", req)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSubmitRawTransactionInsertOpFail(t *testing.T) {
+	cm := newTestContractManager()
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mdi := cm.database.(*databasemocks.Plugin)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+
+	req := &fftypes.RawTransactionRequest{
+		Transaction: fftypes.JSONObject{"to": "0x12345"},
+	}
+
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeRawTransaction, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := cm.SubmitRawTransaction(context.Background(), "ns1", req)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSubmitRawTransactionSubmitFail(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mdi := cm.database.(*databasemocks.Plugin)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+
+	req := &fftypes.RawTransactionRequest{
+		Transaction: fftypes.JSONObject{"to": "0x12345"},
+	}
+
+	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeRawTransaction, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mbi.On("SubmitRawTransaction", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Transaction).Return(fmt.Errorf("pop"))
+	mth.On("WriteOperationFailure", mock.Anything, mock.AnythingOfType("*fftypes.Operation"), fmt.Errorf("pop")).Return()
+
+	_, err := cm.SubmitRawTransaction(context.Background(), "ns1", req)
+	assert.EqualError(t, err, "pop")
+	mth.AssertExpectations(t)
+}
+
 func TestInvokeContractAPI(t *testing.T) {
 	cm := newTestContractManager()
 	mdb := cm.database.(*databasemocks.Plugin)
@@ -1385,13 +1643,13 @@ func TestInvokeContractAPI(t *testing.T) {
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
 	mdb.On("GetContractAPIByName", mock.Anything, "ns1", "banana").Return(api, nil)
 	mdb.On("GetFFIMethod", mock.Anything, "ns1", mock.Anything, mock.Anything).Return(&fftypes.FFIMethod{Name: "peel"}, nil)
-	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke).Return(fftypes.NewUUID(), nil)
+	mth.On("SubmitNewTransaction", mock.Anything, "ns1", fftypes.TransactionTypeContractInvoke, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
 	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
 		return op.Namespace == "ns1" && op.Type == fftypes.OpTypeBlockchainInvoke && op.Plugin == "mockblockchain"
 	})).Return(nil)
-	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, mock.AnythingOfType("*fftypes.FFIMethod"), req.Input).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, mock.AnythingOfType("*fftypes.UUID"), "key-resolved", req.Location, mock.AnythingOfType("*fftypes.FFIMethod"), req.Input, (*fftypes.TransactionFee)(nil)).Return(nil)
 
-	_, err := cm.InvokeContractAPI(context.Background(), "ns1", "banana", "peel", req)
+	_, err := cm.InvokeContractAPI(context.Background(), "ns1", "banana", "peel", req, false)
 
 	assert.NoError(t, err)
 }
@@ -1413,7 +1671,7 @@ func TestInvokeContractAPIFailContractLookup(t *testing.T) {
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
 	mdb.On("GetContractAPIByName", mock.Anything, "ns1", "banana").Return(nil, fmt.Errorf("pop"))
 
-	_, err := cm.InvokeContractAPI(context.Background(), "ns1", "banana", "peel", req)
+	_, err := cm.InvokeContractAPI(context.Background(), "ns1", "banana", "peel", req, false)
 
 	assert.Regexp(t, "pop", err)
 }
@@ -1435,7 +1693,7 @@ func TestInvokeContractAPIContractNotFound(t *testing.T) {
 	mim.On("ResolveSigningKey", mock.Anything, "").Return("key-resolved", nil)
 	mdb.On("GetContractAPIByName", mock.Anything, "ns1", "banana").Return(nil, nil)
 
-	_, err := cm.InvokeContractAPI(context.Background(), "ns1", "banana", "peel", req)
+	_, err := cm.InvokeContractAPI(context.Background(), "ns1", "banana", "peel", req, false)
 
 	assert.Regexp(t, "FF10109", err)
 }
@@ -1913,6 +2171,44 @@ func TestGenerateFFI(t *testing.T) {
 	assert.Equal(t, "generated", ffi.Name)
 }
 
+func TestImportFFI(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mdb := cm.database.(*databasemocks.Plugin)
+	mbm := cm.broadcast.(*broadcastmocks.Manager)
+
+	mbi.On("GenerateFFI", mock.Anything, mock.MatchedBy(func(req *fftypes.FFIGenerationRequest) bool {
+		return req.Namespace == "ns1"
+	})).Return(&fftypes.FFI{
+		Name:    "generated",
+		Version: "1.0.0",
+		Methods: []*fftypes.FFIMethod{{Name: "sum"}},
+	}, nil)
+	mdb.On("GetFFI", mock.Anything, "ns1", "generated", "1.0.0").Return(nil, nil)
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID: fftypes.NewUUID(),
+		},
+	}
+	mbm.On("BroadcastDefinitionAsNode", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.FFI"), fftypes.SystemTagDefineFFI, true).Return(msg, nil)
+
+	ffi, err := cm.ImportFFI(context.Background(), "ns1", &fftypes.FFIGenerationRequest{}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "sum", ffi.Methods[0].Pathname)
+	assert.Equal(t, msg.Header.ID, ffi.Message)
+
+	mbi.AssertExpectations(t)
+	mbm.AssertExpectations(t)
+}
+
+func TestImportFFIGenerateFail(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("GenerateFFI", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := cm.ImportFFI(context.Background(), "ns1", &fftypes.FFIGenerationRequest{}, false)
+	assert.EqualError(t, err, "pop")
+}
+
 type MockFFIParamValidator struct{}
 
 func (v MockFFIParamValidator) Compile(ctx jsonschema.CompilerContext, m map[string]interface{}) (jsonschema.ExtSchema, error) {
@@ -1926,3 +2222,314 @@ func (v *MockFFIParamValidator) GetMetaSchema() *jsonschema.Schema {
 func (v *MockFFIParamValidator) GetExtensionName() string {
 	return "ffi"
 }
+
+func TestRetryOperationRawTransaction(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+
+	op := &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeBlockchainRawTransaction,
+		Input: fftypes.JSONObject{
+			"key":         "key1",
+			"transaction": map[string]interface{}{"to": "0x12345"},
+		},
+	}
+	retry := &fftypes.Operation{
+		ID:    fftypes.NewUUID(),
+		Type:  op.Type,
+		Input: op.Input,
+	}
+	mth.On("PrepareOperationRetry", context.Background(), op).Return(retry, nil)
+	mbi.On("SubmitRawTransaction", context.Background(), retry.ID, "key1", fftypes.JSONObject{"to": "0x12345"}).Return(nil)
+
+	res, err := cm.RetryOperation(context.Background(), op)
+	assert.NoError(t, err)
+	assert.Equal(t, retry, res)
+
+	mth.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestRetryOperationRawTransactionSubmitFail(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+
+	op := &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeBlockchainRawTransaction,
+	}
+	retry := &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: op.Type,
+	}
+	mth.On("PrepareOperationRetry", context.Background(), op).Return(retry, nil)
+	mbi.On("SubmitRawTransaction", context.Background(), retry.ID, "", fftypes.JSONObject{}).Return(fmt.Errorf("pop"))
+	mth.On("WriteOperationFailure", context.Background(), retry, fmt.Errorf("pop")).Return()
+
+	_, err := cm.RetryOperation(context.Background(), op)
+	assert.EqualError(t, err, "pop")
+
+	mth.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestRetryOperationPrepareFail(t *testing.T) {
+	cm := newTestContractManager()
+	mth := cm.txHelper.(*txcommonmocks.Helper)
+
+	op := &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeBlockchainRawTransaction,
+	}
+	mth.On("PrepareOperationRetry", context.Background(), op).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.RetryOperation(context.Background(), op)
+	assert.EqualError(t, err, "pop")
+
+	mth.AssertExpectations(t)
+}
+
+func TestRetryOperationNotRetryable(t *testing.T) {
+	cm := newTestContractManager()
+
+	_, err := cm.RetryOperation(context.Background(), &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeBlockchainInvoke,
+	})
+	assert.Regexp(t, "FF10395", err)
+}
+
+func TestGetContractAPISubscriptions(t *testing.T) {
+	cm := newTestContractManager()
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	api := &fftypes.ContractAPI{
+		Interface: &fftypes.FFIReference{
+			ID: fftypes.NewUUID(),
+		},
+		Location: fftypes.JSONAnyPtr(`{"address":"0x12345"}`),
+	}
+	subs := []*fftypes.ContractSubscription{{ID: fftypes.NewUUID()}}
+
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(api, nil)
+	mdb.On("GetContractSubscriptions", context.Background(), mock.MatchedBy(func(f database.AndFilter) bool {
+		fi, _ := f.Finalize()
+		s := fi.String()
+		return strings.Contains(s, api.Interface.ID.String()) &&
+			strings.Contains(s, `location == '{"address":"0x12345"}'`) &&
+			strings.Contains(s, "namespace == 'ns1'")
+	})).Return(subs, nil, nil)
+
+	f := database.ContractSubscriptionQueryFactory.NewFilter(context.Background()).And()
+	res, _, err := cm.GetContractAPISubscriptions(context.Background(), "ns1", "banana", f)
+	assert.NoError(t, err)
+	assert.Equal(t, subs, res)
+
+	mdb.AssertExpectations(t)
+}
+
+func TestGetContractAPISubscriptionsNoLocation(t *testing.T) {
+	cm := newTestContractManager()
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	api := &fftypes.ContractAPI{
+		Interface: &fftypes.FFIReference{
+			ID: fftypes.NewUUID(),
+		},
+	}
+
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(api, nil)
+	mdb.On("GetContractSubscriptions", context.Background(), mock.MatchedBy(func(f database.AndFilter) bool {
+		fi, _ := f.Finalize()
+		return !strings.Contains(fi.String(), "location")
+	})).Return([]*fftypes.ContractSubscription{}, nil, nil)
+
+	f := database.ContractSubscriptionQueryFactory.NewFilter(context.Background()).And()
+	_, _, err := cm.GetContractAPISubscriptions(context.Background(), "ns1", "banana", f)
+	assert.NoError(t, err)
+
+	mdb.AssertExpectations(t)
+}
+
+func TestGetContractAPISubscriptionsAPINotFound(t *testing.T) {
+	cm := newTestContractManager()
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(nil, nil)
+
+	f := database.ContractSubscriptionQueryFactory.NewFilter(context.Background()).And()
+	_, _, err := cm.GetContractAPISubscriptions(context.Background(), "ns1", "banana", f)
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetContractAPISubscriptionsAPIFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(nil, fmt.Errorf("pop"))
+
+	f := database.ContractSubscriptionQueryFactory.NewFilter(context.Background()).And()
+	_, _, err := cm.GetContractAPISubscriptions(context.Background(), "ns1", "banana", f)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDeleteContractAPISubscription(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	api := &fftypes.ContractAPI{
+		Interface: &fftypes.FFIReference{
+			ID: fftypes.NewUUID(),
+		},
+		Location: fftypes.JSONAnyPtr(`{"address":"0x12345"}`),
+	}
+	sub := &fftypes.ContractSubscription{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Interface: &fftypes.FFIReference{ID: api.Interface.ID},
+		Location:  fftypes.JSONAnyPtr(`{"address":"0x12345"}`),
+	}
+
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(api, nil)
+	mdb.On("GetContractSubscription", context.Background(), "ns1", "sub1").Return(sub, nil)
+	mbi.On("DeleteSubscription", context.Background(), sub).Return(nil)
+	mdb.On("DeleteContractSubscriptionByID", context.Background(), sub.ID).Return(nil)
+
+	err := cm.DeleteContractAPISubscription(context.Background(), "ns1", "banana", "sub1")
+	assert.NoError(t, err)
+
+	mbi.AssertExpectations(t)
+	mdb.AssertExpectations(t)
+}
+
+func TestDeleteContractAPISubscriptionAPINotFound(t *testing.T) {
+	cm := newTestContractManager()
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(&fftypes.ContractAPI{}, nil)
+
+	err := cm.DeleteContractAPISubscription(context.Background(), "ns1", "banana", "sub1")
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestDeleteContractAPISubscriptionSubNotFound(t *testing.T) {
+	cm := newTestContractManager()
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	api := &fftypes.ContractAPI{
+		Interface: &fftypes.FFIReference{
+			ID: fftypes.NewUUID(),
+		},
+	}
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(api, nil)
+	mdb.On("GetContractSubscription", context.Background(), "ns1", "sub1").Return(nil, nil)
+
+	err := cm.DeleteContractAPISubscription(context.Background(), "ns1", "banana", "sub1")
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestDeleteContractAPISubscriptionOtherInterface(t *testing.T) {
+	cm := newTestContractManager()
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	api := &fftypes.ContractAPI{
+		Interface: &fftypes.FFIReference{
+			ID: fftypes.NewUUID(),
+		},
+	}
+	sub := &fftypes.ContractSubscription{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Interface: &fftypes.FFIReference{ID: fftypes.NewUUID()},
+	}
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(api, nil)
+	mdb.On("GetContractSubscription", context.Background(), "ns1", "sub1").Return(sub, nil)
+
+	err := cm.DeleteContractAPISubscription(context.Background(), "ns1", "banana", "sub1")
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestDeleteContractAPISubscriptionOtherLocation(t *testing.T) {
+	cm := newTestContractManager()
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	api := &fftypes.ContractAPI{
+		Interface: &fftypes.FFIReference{
+			ID: fftypes.NewUUID(),
+		},
+		Location: fftypes.JSONAnyPtr(`{"address":"0x12345"}`),
+	}
+	sub := &fftypes.ContractSubscription{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Interface: &fftypes.FFIReference{ID: api.Interface.ID},
+		Location:  fftypes.JSONAnyPtr(`{"address":"0x67890"}`),
+	}
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(api, nil)
+	mdb.On("GetContractSubscription", context.Background(), "ns1", "sub1").Return(sub, nil)
+
+	err := cm.DeleteContractAPISubscription(context.Background(), "ns1", "banana", "sub1")
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestDeleteContractAPISubscriptionBlockchainFail(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mdb := cm.database.(*databasemocks.Plugin)
+
+	api := &fftypes.ContractAPI{
+		Interface: &fftypes.FFIReference{
+			ID: fftypes.NewUUID(),
+		},
+	}
+	sub := &fftypes.ContractSubscription{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Interface: &fftypes.FFIReference{ID: api.Interface.ID},
+	}
+	mdb.On("GetContractAPIByName", context.Background(), "ns1", "banana").Return(api, nil)
+	mdb.On("GetContractSubscription", context.Background(), "ns1", "sub1").Return(sub, nil)
+	mbi.On("DeleteSubscription", context.Background(), sub).Return(fmt.Errorf("pop"))
+
+	err := cm.DeleteContractAPISubscription(context.Background(), "ns1", "banana", "sub1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestInvokeContractReadOnly(t *testing.T) {
+	cm := newTestContractManager()
+	cm.readOnly = true
+
+	req := &fftypes.ContractCallRequest{
+		Type:      fftypes.CallTypeInvoke,
+		Interface: fftypes.NewUUID(),
+		Location:  fftypes.JSONAnyPtr(""),
+	}
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
+	assert.Regexp(t, "FF10358", err)
+}
+
+func TestSubmitRawTransactionReadOnly(t *testing.T) {
+	cm := newTestContractManager()
+	cm.readOnly = true
+
+	_, err := cm.SubmitRawTransaction(context.Background(), "ns1", &fftypes.RawTransactionRequest{
+		Transaction: fftypes.JSONObject{"to": "0x12345"},
+	})
+	assert.Regexp(t, "FF10358", err)
+}
+
+func TestRetryOperationRawTransactionReadOnly(t *testing.T) {
+	cm := newTestContractManager()
+	cm.readOnly = true
+
+	_, err := cm.RetryOperation(context.Background(), &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeBlockchainRawTransaction,
+	})
+	assert.Regexp(t, "FF10358", err)
+}
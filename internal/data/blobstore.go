@@ -32,10 +32,20 @@ import (
 )
 
 type blobStore struct {
-	dm            *dataManager
-	publicstorage publicstorage.Plugin
-	database      database.Plugin
-	exchange      dataexchange.Plugin
+	dm              *dataManager
+	publicstorage   publicstorage.Plugin
+	nsPublicStorage map[string]publicstorage.Plugin
+	database        database.Plugin
+	exchange        dataexchange.Plugin
+}
+
+// publicStorageFor returns the public storage the blobs of a namespace are published to, which is the
+// default public storage of the node unless the namespace is configured with its own
+func (bs *blobStore) publicStorageFor(ns string) publicstorage.Plugin {
+	if pi, ok := bs.nsPublicStorage[ns]; ok {
+		return pi
+	}
+	return bs.publicstorage
 }
 
 func (bs *blobStore) uploadVerifyBLOB(ctx context.Context, ns string, id *fftypes.UUID, expectedHash *fftypes.Bytes32, reader io.Reader) (hash *fftypes.Bytes32, written int64, payloadRef string, err error) {
@@ -145,7 +155,7 @@ func (bs *blobStore) UploadBLOB(ctx context.Context, ns string, inData *fftypes.
 
 func (bs *blobStore) CopyBlobPStoDX(ctx context.Context, data *fftypes.Data) (blob *fftypes.Blob, err error) {
 
-	reader, err := bs.publicstorage.RetrieveData(ctx, data.Blob.Public)
+	reader, err := bs.publicStorageFor(data.Namespace).RetrieveData(ctx, data.Blob.Public)
 	if err != nil {
 		return nil, err
 	}
@@ -184,7 +194,7 @@ func (bs *blobStore) DownloadBLOB(ctx context.Context, ns, dataID string) (*ffty
 		return nil, nil, err
 	}
 
-	data, err := bs.database.GetDataByID(ctx, id, false)
+	data, err := bs.dm.getDataByID(ctx, id, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -206,3 +216,37 @@ func (bs *blobStore) DownloadBLOB(ctx context.Context, ns, dataID string) (*ffty
 	reader, err := bs.exchange.DownloadBLOB(ctx, blob.PayloadRef)
 	return blob, reader, err
 }
+
+// PublishBLOB streams the blob attached to a piece of data from the local data exchange to public
+// storage, and records the public reference on the data.
+func (bs *blobStore) PublishBLOB(ctx context.Context, data *fftypes.Data) error {
+	if data.Blob == nil || data.Blob.Hash == nil {
+		return i18n.NewError(ctx, i18n.MsgDataDoesNotHaveBlob)
+	}
+	blob, err := bs.database.GetBlobMatchingHash(ctx, data.Blob.Hash)
+	if err != nil {
+		return err
+	}
+	if blob == nil {
+		return i18n.NewError(ctx, i18n.MsgBlobNotFound, data.Blob.Hash)
+	}
+
+	reader, err := bs.exchange.DownloadBLOB(ctx, blob.PayloadRef)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgDownloadBlobFailed, blob.PayloadRef)
+	}
+	defer reader.Close()
+
+	publicRef, err := bs.publicStorageFor(data.Namespace).PublishData(ctx, reader)
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Published blob with hash '%s' for data '%s' to public storage: '%s'", data.Blob.Hash, data.ID, publicRef)
+
+	update := database.DataQueryFactory.NewUpdate(ctx).Set("blob.public", publicRef)
+	if err = bs.database.UpdateData(ctx, data.ID, update); err != nil {
+		return err
+	}
+	data.Blob.Public = publicRef
+	return nil
+}
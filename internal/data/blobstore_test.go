@@ -32,6 +32,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -458,6 +459,32 @@ func TestCopyBlobPStoDownloadNotFound(t *testing.T) {
 
 }
 
+func TestCopyBlobPStoDXNamespacePublicStorage(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	nsps := &publicstoragemocks.Plugin{}
+	dm.nsPublicStorage = map[string]publicstorage.Plugin{"ns2": nsps}
+
+	payload := []byte(`some data`)
+	var hash fftypes.Bytes32 = sha256.Sum256(payload)
+
+	nsps.On("RetrieveData", ctx, "public-ref").Return(nil, nil)
+
+	blob, err := dm.CopyBlobPStoDX(ctx, &fftypes.Data{
+		Namespace: "ns2",
+		ID:        fftypes.NewUUID(),
+		Blob: &fftypes.BlobRef{
+			Hash:   &hash,
+			Public: "public-ref",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, blob)
+
+	nsps.AssertExpectations(t)
+}
+
 func TestDownloadBlobOk(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
@@ -613,3 +640,127 @@ func TestDownloadBlobBadID(t *testing.T) {
 	assert.Regexp(t, "FF10142", err)
 
 }
+
+func TestPublishBlobOk(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	blobHash := fftypes.NewRandB32()
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Blob:      &fftypes.BlobRef{Hash: blobHash},
+	}
+
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, blobHash).Return(&fftypes.Blob{
+		Hash:       blobHash,
+		PayloadRef: "ns1/blob1",
+	}, nil)
+	mdi.On("UpdateData", ctx, data.ID, mock.Anything).Return(nil)
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(
+		ioutil.NopCloser(bytes.NewReader([]byte("some blob"))),
+		nil)
+
+	mps := dm.publicstorage.(*publicstoragemocks.Plugin)
+	mps.On("PublishData", ctx, mock.MatchedBy(func(reader io.Reader) bool {
+		b, _ := ioutil.ReadAll(reader)
+		return string(b) == "some blob"
+	})).Return("public-ref", nil)
+
+	err := dm.PublishBLOB(ctx, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "public-ref", data.Blob.Public)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+	mps.AssertExpectations(t)
+}
+
+func TestPublishBlobNoBlob(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	err := dm.PublishBLOB(ctx, &fftypes.Data{})
+	assert.Regexp(t, "FF10241", err)
+}
+
+func TestPublishBlobLookupErr(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	blobHash := fftypes.NewRandB32()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, blobHash).Return(nil, fmt.Errorf("pop"))
+
+	err := dm.PublishBLOB(ctx, &fftypes.Data{Blob: &fftypes.BlobRef{Hash: blobHash}})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPublishBlobNotFound(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	blobHash := fftypes.NewRandB32()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, blobHash).Return(nil, nil)
+
+	err := dm.PublishBLOB(ctx, &fftypes.Data{Blob: &fftypes.BlobRef{Hash: blobHash}})
+	assert.Regexp(t, "FF10239", err)
+}
+
+func TestPublishBlobDownloadFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	blobHash := fftypes.NewRandB32()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, blobHash).Return(&fftypes.Blob{PayloadRef: "ns1/blob1"}, nil)
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(nil, fmt.Errorf("pop"))
+
+	err := dm.PublishBLOB(ctx, &fftypes.Data{Blob: &fftypes.BlobRef{Hash: blobHash}})
+	assert.Regexp(t, "FF10240", err)
+}
+
+func TestPublishBlobPublishFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	blobHash := fftypes.NewRandB32()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, blobHash).Return(&fftypes.Blob{PayloadRef: "ns1/blob1"}, nil)
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(ioutil.NopCloser(bytes.NewReader([]byte("some blob"))), nil)
+	mps := dm.publicstorage.(*publicstoragemocks.Plugin)
+	mps.On("PublishData", ctx, mock.Anything).Return("", fmt.Errorf("pop"))
+
+	err := dm.PublishBLOB(ctx, &fftypes.Data{Blob: &fftypes.BlobRef{Hash: blobHash}})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPublishBlobUpdateFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	blobHash := fftypes.NewRandB32()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, blobHash).Return(&fftypes.Blob{PayloadRef: "ns1/blob1"}, nil)
+	mdi.On("UpdateData", ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(ioutil.NopCloser(bytes.NewReader([]byte("some blob"))), nil)
+	mps := dm.publicstorage.(*publicstoragemocks.Plugin)
+	mps.On("PublishData", ctx, mock.Anything).Return("public-ref", nil)
+
+	err := dm.PublishBLOB(ctx, &fftypes.Data{Blob: &fftypes.BlobRef{Hash: blobHash}})
+	assert.EqualError(t, err, "pop")
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/archive"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
@@ -44,6 +45,7 @@ type Manager interface {
 	UploadBLOB(ctx context.Context, ns string, inData *fftypes.DataRefOrValue, blob *fftypes.Multipart, autoMeta bool) (*fftypes.Data, error)
 	CopyBlobPStoDX(ctx context.Context, data *fftypes.Data) (blob *fftypes.Blob, err error)
 	DownloadBLOB(ctx context.Context, ns, dataID string) (*fftypes.Blob, io.ReadCloser, error)
+	PublishBLOB(ctx context.Context, data *fftypes.Data) error
 }
 
 type dataManager struct {
@@ -52,11 +54,12 @@ type dataManager struct {
 	database          database.Plugin
 	publicstorage     publicstorage.Plugin
 	exchange          dataexchange.Plugin
+	archive           archive.Manager // nil unless archiving is enabled
 	validatorCache    *ccache.Cache
 	validatorCacheTTL time.Duration
 }
 
-func NewDataManager(ctx context.Context, di database.Plugin, pi publicstorage.Plugin, dx dataexchange.Plugin) (Manager, error) {
+func NewDataManager(ctx context.Context, di database.Plugin, pi publicstorage.Plugin, nsPublicStorage map[string]publicstorage.Plugin, dx dataexchange.Plugin, am archive.Manager) (Manager, error) {
 	if di == nil || pi == nil || dx == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
@@ -64,13 +67,15 @@ func NewDataManager(ctx context.Context, di database.Plugin, pi publicstorage.Pl
 		database:          di,
 		publicstorage:     pi,
 		exchange:          dx,
+		archive:           am,
 		validatorCacheTTL: config.GetDuration(config.ValidatorCacheTTL),
 	}
 	dm.blobStore = blobStore{
-		dm:            dm,
-		database:      di,
-		publicstorage: pi,
-		exchange:      dx,
+		dm:              dm,
+		database:        di,
+		publicstorage:   pi,
+		nsPublicStorage: nsPublicStorage,
+		exchange:        dx,
 	}
 	dm.validatorCache = ccache.New(
 		// We use a LRU cache with a size-aware max
@@ -97,6 +102,9 @@ func (dm *dataManager) VerifyNamespaceExists(ctx context.Context, ns string) err
 	if namespace == nil {
 		return i18n.NewError(ctx, i18n.MsgNamespaceNotExist)
 	}
+	if namespace.Retired != nil {
+		return i18n.NewError(ctx, i18n.MsgNamespaceRetired, ns)
+	}
 	return nil
 }
 
@@ -158,7 +166,8 @@ func (dm *dataManager) GetMessageData(ctx context.Context, msg *fftypes.Message,
 
 func (dm *dataManager) ValidateAll(ctx context.Context, data []*fftypes.Data) (valid bool, err error) {
 	for _, d := range data {
-		if d.Datatype != nil && d.Validator != fftypes.ValidatorTypeNone {
+		// Values restricted to a private group can only be validated by the members that receive them
+		if d.Datatype != nil && d.Validator != fftypes.ValidatorTypeNone && !d.ValueWithheld() {
 			v, err := dm.getValidatorForDatatype(ctx, d.Namespace, d.Validator, d.Datatype)
 			if err != nil {
 				return false, err
@@ -176,12 +185,21 @@ func (dm *dataManager) ValidateAll(ctx context.Context, data []*fftypes.Data) (v
 	return true, nil
 }
 
+// getDataByID falls back to the archives for data that has been moved out of the database
+func (dm *dataManager) getDataByID(ctx context.Context, id *fftypes.UUID, withValue bool) (*fftypes.Data, error) {
+	d, err := dm.database.GetDataByID(ctx, id, withValue)
+	if err != nil || d != nil || dm.archive == nil {
+		return d, err
+	}
+	return dm.archive.GetDataByID(ctx, id)
+}
+
 func (dm *dataManager) resolveRef(ctx context.Context, ns string, dataRef *fftypes.DataRef, withValue bool) (*fftypes.Data, error) {
 	if dataRef == nil || dataRef.ID == nil {
 		log.L(ctx).Warnf("data is nil")
 		return nil, nil
 	}
-	d, err := dm.database.GetDataByID(ctx, dataRef.ID, withValue)
+	d, err := dm.getDataByID(ctx, dataRef.ID, withValue)
 	if err != nil {
 		return nil, err
 	}
@@ -240,12 +258,33 @@ func (dm *dataManager) checkValidation(ctx context.Context, ns string, validator
 	return nil
 }
 
-func (dm *dataManager) validateAndStore(ctx context.Context, ns string, validator fftypes.ValidatorType, datatype *fftypes.DatatypeRef, value *fftypes.JSONAny, blobRef *fftypes.BlobRef) (data *fftypes.Data, blob *fftypes.Blob, err error) {
+func (dm *dataManager) checkPrivateGroup(ctx context.Context, ns string, group *fftypes.Bytes32, blobRef *fftypes.BlobRef) error {
+	if group == nil {
+		return nil
+	}
+	if blobRef != nil {
+		return i18n.NewError(ctx, i18n.MsgPrivateDataNotSupported)
+	}
+	g, err := dm.database.GetGroupByHash(ctx, group)
+	if err != nil {
+		return err
+	}
+	if g == nil || g.Namespace != ns {
+		return i18n.NewError(ctx, i18n.MsgPrivateDataGroupNotFound, group, ns)
+	}
+	return nil
+}
+
+func (dm *dataManager) validateAndStore(ctx context.Context, ns string, validator fftypes.ValidatorType, datatype *fftypes.DatatypeRef, value *fftypes.JSONAny, blobRef *fftypes.BlobRef, group *fftypes.Bytes32) (data *fftypes.Data, blob *fftypes.Blob, err error) {
 
 	if err := dm.checkValidation(ctx, ns, validator, datatype, value); err != nil {
 		return nil, nil, err
 	}
 
+	if err := dm.checkPrivateGroup(ctx, ns, group, blobRef); err != nil {
+		return nil, nil, err
+	}
+
 	if blob, err = dm.resolveBlob(ctx, blobRef); err != nil {
 		return nil, nil, err
 	}
@@ -257,6 +296,7 @@ func (dm *dataManager) validateAndStore(ctx context.Context, ns string, validato
 		Namespace: ns,
 		Value:     value,
 		Blob:      blobRef,
+		Group:     group,
 	}
 	err = data.Seal(ctx, blob)
 	if err == nil {
@@ -269,7 +309,7 @@ func (dm *dataManager) validateAndStore(ctx context.Context, ns string, validato
 }
 
 func (dm *dataManager) validateAndStoreInlined(ctx context.Context, ns string, value *fftypes.DataRefOrValue) (*fftypes.Data, *fftypes.Blob, *fftypes.DataRef, error) {
-	data, blob, err := dm.validateAndStore(ctx, ns, value.Validator, value.Datatype, value.Value, value.Blob)
+	data, blob, err := dm.validateAndStore(ctx, ns, value.Validator, value.Datatype, value.Value, value.Blob, value.Group)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -283,7 +323,7 @@ func (dm *dataManager) validateAndStoreInlined(ctx context.Context, ns string, v
 }
 
 func (dm *dataManager) UploadJSON(ctx context.Context, ns string, inData *fftypes.DataRefOrValue) (*fftypes.Data, error) {
-	data, _, err := dm.validateAndStore(ctx, ns, inData.Validator, inData.Datatype, inData.Value, inData.Blob)
+	data, _, err := dm.validateAndStore(ctx, ns, inData.Validator, inData.Datatype, inData.Value, inData.Blob, inData.Group)
 	return data, err
 }
 
@@ -337,6 +377,12 @@ func (dm *dataManager) resolveInlineData(ctx context.Context, ns string, inData
 			return nil, nil, i18n.NewError(ctx, i18n.MsgDataMissing, i)
 		}
 
+		// Data restricted to a group is only withheld from broadcasts. The whole of a private message is
+		// already restricted to the group it is sent to, which might not be the same group.
+		if !broadcast && data.Group != nil {
+			return nil, nil, i18n.NewError(ctx, i18n.MsgPrivateDataNotSupported)
+		}
+
 		// If the data is being resolved for public broadcast, and there is a blob attachment, that blob
 		// needs to be published by our calller
 		if broadcast && blob != nil && data.Blob.Public == "" {
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/archivemocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
@@ -36,7 +37,7 @@ func newTestDataManager(t *testing.T) (*dataManager, context.Context, func()) {
 	mdi := &databasemocks.Plugin{}
 	mdx := &dataexchangemocks.Plugin{}
 	mps := &publicstoragemocks.Plugin{}
-	dm, err := NewDataManager(ctx, mdi, mps, mdx)
+	dm, err := NewDataManager(ctx, mdi, mps, nil, mdx, nil)
 	assert.NoError(t, err)
 	return dm.(*dataManager), ctx, cancel
 }
@@ -93,7 +94,7 @@ func TestValidateE2E(t *testing.T) {
 }
 
 func TestInitBadDeps(t *testing.T) {
-	_, err := NewDataManager(context.Background(), nil, nil, nil)
+	_, err := NewDataManager(context.Background(), nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
 
@@ -244,6 +245,31 @@ func TestGetMessageDataOk(t *testing.T) {
 
 }
 
+func TestGetMessageDataArchived(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mar := &archivemocks.Manager{}
+	dm.archive = mar
+	dataID := fftypes.NewUUID()
+	hash := fftypes.NewRandB32()
+	mdi.On("GetDataByID", mock.Anything, dataID, true).Return(nil, nil)
+	mar.On("GetDataByID", mock.Anything, dataID).Return(&fftypes.Data{
+		ID:   dataID,
+		Hash: hash,
+	}, nil)
+	data, foundAll, err := dm.GetMessageData(ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID()},
+		Data:   fftypes.DataRefs{{ID: dataID, Hash: hash}},
+	}, true)
+	assert.NoError(t, err)
+	assert.True(t, foundAll)
+	assert.Equal(t, *dataID, *data[0].ID)
+	mar.AssertExpectations(t)
+
+}
+
 func TestCheckDatatypeVerifiesTheSchema(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
@@ -478,6 +504,86 @@ func TestResolveInlineDataValueWithValidation(t *testing.T) {
 	assert.Regexp(t, "FF10198", err)
 }
 
+func TestResolveInlineDataBroadcastPrivateGroup(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdi := dm.database.(*databasemocks.Plugin)
+
+	groupHash := fftypes.NewRandB32()
+	mdi.On("GetGroupByHash", ctx, groupHash).Return(&fftypes.Group{Hash: groupHash, GroupIdentity: fftypes.GroupIdentity{Namespace: "ns1"}}, nil)
+	mdi.On("UpsertData", ctx, mock.MatchedBy(func(d *fftypes.Data) bool {
+		return d.Group.Equals(groupHash)
+	}), database.UpsertOptimizationNew).Return(nil)
+
+	refs, dataToPublish, err := dm.ResolveInlineDataBroadcast(ctx, "ns1", fftypes.InlineData{
+		{Value: fftypes.JSONAnyPtr(`{"some":"json"}`), Group: groupHash},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, refs, 1)
+	assert.Empty(t, dataToPublish)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveInlineDataPrivateGroupNotSupported(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdi := dm.database.(*databasemocks.Plugin)
+
+	groupHash := fftypes.NewRandB32()
+	dataID := fftypes.NewUUID()
+	mdi.On("GetDataByID", ctx, dataID, false).Return(&fftypes.Data{
+		ID:        dataID,
+		Namespace: "ns1",
+		Hash:      fftypes.NewRandB32(),
+		Group:     groupHash,
+	}, nil)
+
+	_, err := dm.ResolveInlineDataPrivate(ctx, "ns1", fftypes.InlineData{
+		{DataRef: fftypes.DataRef{ID: dataID}},
+	})
+	assert.Regexp(t, "FF10448", err)
+}
+
+func TestResolveInlineDataPrivateGroupBlob(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	_, _, err := dm.ResolveInlineDataBroadcast(ctx, "ns1", fftypes.InlineData{
+		{Blob: &fftypes.BlobRef{Hash: fftypes.NewRandB32()}, Group: fftypes.NewRandB32()},
+	})
+	assert.Regexp(t, "FF10448", err)
+}
+
+func TestResolveInlineDataPrivateGroupNotFound(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdi := dm.database.(*databasemocks.Plugin)
+
+	groupHash := fftypes.NewRandB32()
+	mdi.On("GetGroupByHash", ctx, groupHash).Return(&fftypes.Group{Hash: groupHash, GroupIdentity: fftypes.GroupIdentity{Namespace: "ns2"}}, nil)
+
+	_, _, err := dm.ResolveInlineDataBroadcast(ctx, "ns1", fftypes.InlineData{
+		{Value: fftypes.JSONAnyPtr(`{"some":"json"}`), Group: groupHash},
+	})
+	assert.Regexp(t, "FF10449", err)
+}
+
+func TestResolveInlineDataPrivateGroupLookupFail(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdi := dm.database.(*databasemocks.Plugin)
+
+	groupHash := fftypes.NewRandB32()
+	mdi.On("GetGroupByHash", ctx, groupHash).Return(nil, fmt.Errorf("pop"))
+
+	_, err := dm.UploadJSON(ctx, "ns1", &fftypes.DataRefOrValue{
+		Value: fftypes.JSONAnyPtr(`{"some":"json"}`),
+		Group: groupHash,
+	})
+	assert.EqualError(t, err, "pop")
+}
+
 func TestResolveInlineDataNoRefOrValue(t *testing.T) {
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
@@ -611,6 +717,26 @@ func TestValidateAllLookupError(t *testing.T) {
 
 }
 
+func TestValidateAllValueWithheld(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	data := &fftypes.Data{
+		Namespace: "ns1",
+		Validator: fftypes.ValidatorTypeJSON,
+		Datatype: &fftypes.DatatypeRef{
+			Name:    "customer",
+			Version: "0.0.1",
+		},
+		Hash:  fftypes.NewRandB32(),
+		Group: fftypes.NewRandB32(),
+	}
+	valid, err := dm.ValidateAll(ctx, []*fftypes.Data{data})
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+}
+
 func TestGetValidatorForDatatypeNilRef(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
@@ -667,6 +793,15 @@ func TestVerifyNamespaceExistsNotFound(t *testing.T) {
 	assert.Regexp(t, "FF10187", err)
 }
 
+func TestVerifyNamespaceExistsRetired(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{Retired: fftypes.Now()}, nil)
+	err := dm.VerifyNamespaceExists(ctx, "ns1")
+	assert.Regexp(t, "FF10460", err)
+}
+
 func TestVerifyNamespaceExistsOk(t *testing.T) {
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
@@ -28,11 +28,12 @@ import (
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/database/sqlcommon"
 	"github.com/hyperledger/firefly/pkg/database"
-
-	// Import pq driver
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// pqUniqueViolation is the SQLSTATE of an insert that violates a unique index
+const pqUniqueViolation = "23505"
+
 type Postgres struct {
 	sqlcommon.SQLCommon
 }
@@ -57,6 +58,10 @@ func (psql *Postgres) Features() sqlcommon.SQLFeatures {
 	features.ExclusiveTableLockSQL = func(table string) string {
 		return fmt.Sprintf(`LOCK TABLE "%s" IN EXCLUSIVE MODE;`, table)
 	}
+	features.IsUniqueViolation = func(err error) bool {
+		pqErr, ok := err.(*pq.Error)
+		return ok && pqErr.Code == pqUniqueViolation
+	}
 	return features
 }
 
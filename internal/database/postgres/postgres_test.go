@@ -18,12 +18,14 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/database/sqlcommon"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,6 +43,9 @@ func TestPostgresProvider(t *testing.T) {
 	assert.Equal(t, "postgres", psql.Name())
 	assert.Equal(t, sq.Dollar, psql.Features().PlaceholderFormat)
 	assert.Equal(t, `LOCK TABLE "events" IN EXCLUSIVE MODE;`, psql.Features().ExclusiveTableLockSQL("events"))
+	assert.True(t, psql.Features().IsUniqueViolation(&pq.Error{Code: "23505"}))
+	assert.False(t, psql.Features().IsUniqueViolation(&pq.Error{Code: "23503"}))
+	assert.False(t, psql.Features().IsUniqueViolation(fmt.Errorf("pop")))
 
 	insert := sq.Insert("test").Columns("col1").Values("val1")
 	insert, query := psql.ApplyInsertQueryCustomizations(insert, true)
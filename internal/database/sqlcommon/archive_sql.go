@@ -0,0 +1,178 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	archiveColumns = []string{
+		"id",
+		"location",
+		"hash",
+		"size",
+		"event_count",
+		"data_count",
+		"first_sequence",
+		"last_sequence",
+		"oldest",
+		"newest",
+		"created",
+	}
+	archiveFilterFieldMap = map[string]string{
+		"eventcount":    "event_count",
+		"datacount":     "data_count",
+		"firstsequence": "first_sequence",
+		"lastsequence":  "last_sequence",
+	}
+)
+
+func (s *SQLCommon) InsertArchive(ctx context.Context, archive *fftypes.Archive) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	archive.Created = fftypes.Now()
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("archives").
+			Columns(archiveColumns...).
+			Values(
+				archive.ID,
+				archive.Location,
+				archive.Hash,
+				archive.Size,
+				archive.EventCount,
+				archive.DataCount,
+				archive.FirstSequence,
+				archive.LastSequence,
+				archive.Oldest,
+				archive.Newest,
+				archive.Created,
+			),
+		nil, // no change events for archives
+	); err != nil {
+		return err
+	}
+
+	for _, entry := range archive.Entries {
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("archive_entries").
+				Columns("entry_type", "entry_id", "archive_id").
+				Values(entry.Type, entry.ID, archive.ID),
+			nil,
+		); err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) archiveResult(ctx context.Context, row *sql.Rows) (*fftypes.Archive, error) {
+	var archive fftypes.Archive
+	err := row.Scan(
+		&archive.ID,
+		&archive.Location,
+		&archive.Hash,
+		&archive.Size,
+		&archive.EventCount,
+		&archive.DataCount,
+		&archive.FirstSequence,
+		&archive.LastSequence,
+		&archive.Oldest,
+		&archive.Newest,
+		&archive.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "archives")
+	}
+	return &archive, nil
+}
+
+func (s *SQLCommon) getArchive(ctx context.Context, query sq.SelectBuilder, desc interface{}) (*fftypes.Archive, error) {
+	rows, _, err := s.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Archive '%s' not found", desc)
+		return nil, nil
+	}
+
+	return s.archiveResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetArchiveByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Archive, error) {
+	return s.getArchive(ctx,
+		sq.Select(archiveColumns...).
+			From("archives").
+			Where(sq.Eq{"id": id}),
+		id,
+	)
+}
+
+func (s *SQLCommon) GetArchiveForEntry(ctx context.Context, entryType fftypes.ArchiveEntryType, id *fftypes.UUID) (*fftypes.Archive, error) {
+	cols := make([]string, len(archiveColumns))
+	for i, col := range archiveColumns {
+		cols[i] = "a." + col
+	}
+	return s.getArchive(ctx,
+		sq.Select(cols...).
+			From("archive_entries AS e").
+			Join("archives AS a ON a.id = e.archive_id").
+			Where(sq.Eq{"e.entry_type": entryType, "e.entry_id": id}),
+		id,
+	)
+}
+
+func (s *SQLCommon) GetArchives(ctx context.Context, filter database.Filter) ([]*fftypes.Archive, *database.FilterResult, error) {
+	query, fop, fi, err := s.filterSelect(ctx, "",
+		sq.Select(archiveColumns...).From("archives"),
+		filter, archiveFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	archives := []*fftypes.Archive{}
+	for rows.Next() {
+		archive, err := s.archiveResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		archives = append(archives, archive)
+	}
+
+	return archives, s.queryRes(ctx, tx, "archives", fop, fi), err
+}
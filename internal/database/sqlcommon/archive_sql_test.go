@@ -0,0 +1,181 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new archive manifest
+	eventID := fftypes.NewUUID()
+	dataID := fftypes.NewUUID()
+	archive := &fftypes.Archive{
+		ID:            fftypes.NewUUID(),
+		Location:      "archive1.json.gz",
+		Hash:          fftypes.NewRandB32(),
+		Size:          12345,
+		EventCount:    1,
+		DataCount:     1,
+		FirstSequence: 10,
+		LastSequence:  10,
+		Oldest:        fftypes.Now(),
+		Newest:        fftypes.Now(),
+		Entries: []*fftypes.ArchiveEntry{
+			{Type: fftypes.ArchiveEntryTypeEvent, ID: eventID},
+			{Type: fftypes.ArchiveEntryTypeData, ID: dataID},
+		},
+	}
+	err := s.InsertArchive(ctx, archive)
+	assert.NoError(t, err)
+	assert.NotNil(t, archive.Created)
+
+	// The entries are only held in the manifest stored with the archive
+	archive.Entries = nil
+	archiveJson, _ := json.Marshal(&archive)
+
+	// Query back the archive (by query filter)
+	fb := database.ArchiveQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("location", "archive1.json.gz"),
+		fb.Eq("eventcount", 1),
+	)
+	archives, res, err := s.GetArchives(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(archives))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	archiveReadJson, _ := json.Marshal(archives[0])
+	assert.Equal(t, string(archiveJson), string(archiveReadJson))
+
+	// Query back the archive (by ID)
+	archiveRead, err := s.GetArchiveByID(ctx, archive.ID)
+	assert.NoError(t, err)
+	archiveReadJson, _ = json.Marshal(archiveRead)
+	assert.Equal(t, string(archiveJson), string(archiveReadJson))
+
+	// Query back the archive (by entry)
+	archiveRead, err = s.GetArchiveForEntry(ctx, fftypes.ArchiveEntryTypeData, dataID)
+	assert.NoError(t, err)
+	archiveReadJson, _ = json.Marshal(archiveRead)
+	assert.Equal(t, string(archiveJson), string(archiveReadJson))
+	archiveRead, err = s.GetArchiveForEntry(ctx, fftypes.ArchiveEntryTypeData, eventID)
+	assert.NoError(t, err)
+	assert.Nil(t, archiveRead)
+}
+
+func TestInsertArchiveFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertArchive(context.Background(), &fftypes.Archive{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertArchiveFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertArchive(context.Background(), &fftypes.Archive{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertArchiveFailInsertEntry(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertArchive(context.Background(), &fftypes.Archive{
+		Entries: []*fftypes.ArchiveEntry{{Type: fftypes.ArchiveEntryTypeEvent, ID: fftypes.NewUUID()}},
+	})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertArchiveFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertArchive(context.Background(), &fftypes.Archive{})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchiveByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetArchiveByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchiveByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	archive, err := s.GetArchiveByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, archive)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchiveByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetArchiveByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchivesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.ArchiveQueryFactory.NewFilter(context.Background()).Eq("location", "")
+	_, _, err := s.GetArchives(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchivesBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.ArchiveQueryFactory.NewFilter(context.Background()).Eq("location", map[bool]bool{true: false})
+	_, _, err := s.GetArchives(context.Background(), f)
+	assert.Regexp(t, "FF10149.*location", err)
+}
+
+func TestGetArchivesScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.ArchiveQueryFactory.NewFilter(context.Background()).Eq("location", "")
+	_, _, err := s.GetArchives(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,126 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	auditRecordColumns = []string{
+		"id",
+		"namespace",
+		"identity",
+		"remote_addr",
+		"method",
+		"path",
+		"operation",
+		"request_hash",
+		"status",
+		"error",
+		"created",
+	}
+	auditRecordFilterFieldMap = map[string]string{
+		"remoteaddr":  "remote_addr",
+		"requesthash": "request_hash",
+	}
+)
+
+func (s *SQLCommon) InsertAuditRecord(ctx context.Context, record *fftypes.AuditRecord) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	record.Created = fftypes.Now()
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("auditrecords").
+			Columns(auditRecordColumns...).
+			Values(
+				record.ID,
+				record.Namespace,
+				record.Identity,
+				record.RemoteAddr,
+				record.Method,
+				record.Path,
+				record.Operation,
+				record.RequestHash,
+				record.Status,
+				record.Error,
+				record.Created,
+			),
+		nil, // no change events for audit records
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) auditRecordResult(ctx context.Context, row *sql.Rows) (*fftypes.AuditRecord, error) {
+	var record fftypes.AuditRecord
+	err := row.Scan(
+		&record.ID,
+		&record.Namespace,
+		&record.Identity,
+		&record.RemoteAddr,
+		&record.Method,
+		&record.Path,
+		&record.Operation,
+		&record.RequestHash,
+		&record.Status,
+		&record.Error,
+		&record.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "auditrecords")
+	}
+	return &record, nil
+}
+
+func (s *SQLCommon) GetAuditRecords(ctx context.Context, filter database.Filter) ([]*fftypes.AuditRecord, *database.FilterResult, error) {
+	query, fop, fi, err := s.filterSelect(ctx, "",
+		sq.Select(auditRecordColumns...).From("auditrecords"),
+		filter, auditRecordFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	records := []*fftypes.AuditRecord{}
+	for rows.Next() {
+		record, err := s.auditRecordResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, s.queryRes(ctx, tx, "auditrecords", fop, fi), err
+}
@@ -0,0 +1,125 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRecordE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new audit record
+	record := &fftypes.AuditRecord{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Identity:    "app1",
+		RemoteAddr:  "10.0.0.1",
+		Method:      "POST",
+		Path:        "/api/v1/namespaces/ns1/messages/broadcast",
+		Operation:   "postNewMessageBroadcast",
+		RequestHash: fftypes.NewRandB32(),
+		Status:      400,
+		Error:       "FF10123: bad input",
+	}
+	err := s.InsertAuditRecord(ctx, record)
+	assert.NoError(t, err)
+	assert.NotNil(t, record.Created)
+	recordJson, _ := json.Marshal(&record)
+
+	// Query back the audit record
+	fb := database.AuditRecordQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("identity", "app1"),
+		fb.Eq("requesthash", record.RequestHash),
+		fb.Gte("status", 400),
+	)
+	records, res, err := s.GetAuditRecords(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	recordReadJson, _ := json.Marshal(records[0])
+	assert.Equal(t, string(recordJson), string(recordReadJson))
+
+	// Check no match on a different identity
+	records, _, err = s.GetAuditRecords(ctx, fb.And(fb.Eq("identity", "app2")))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(records))
+}
+
+func TestInsertAuditRecordFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertAuditRecord(context.Background(), &fftypes.AuditRecord{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertAuditRecordFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertAuditRecord(context.Background(), &fftypes.AuditRecord{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertAuditRecordFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertAuditRecord(context.Background(), &fftypes.AuditRecord{})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAuditRecordsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.AuditRecordQueryFactory.NewFilter(context.Background()).Eq("identity", "")
+	_, _, err := s.GetAuditRecords(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAuditRecordsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.AuditRecordQueryFactory.NewFilter(context.Background()).Eq("identity", map[bool]bool{true: false})
+	_, _, err := s.GetAuditRecords(context.Background(), f)
+	assert.Regexp(t, "FF10149.*identity", err)
+}
+
+func TestGetAuditRecordsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.AuditRecordQueryFactory.NewFilter(context.Background()).Eq("identity", "")
+	_, _, err := s.GetAuditRecords(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
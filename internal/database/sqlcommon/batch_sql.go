@@ -43,6 +43,7 @@ var (
 		"tx_type",
 		"tx_id",
 		"node_id",
+		"encoding",
 	}
 	batchFilterFieldMap = map[string]string{
 		"type":       "btype",
@@ -101,6 +102,7 @@ func (s *SQLCommon) UpsertBatch(ctx context.Context, batch *fftypes.Batch) (err
 				Set("tx_type", batch.Payload.TX.Type).
 				Set("tx_id", batch.Payload.TX.ID).
 				Set("node_id", batch.Node).
+				Set("encoding", batch.Encoding).
 				Where(sq.Eq{"id": batch.ID}),
 			func() {
 				s.callbacks.UUIDCollectionNSEvent(database.CollectionBatches, fftypes.ChangeEventTypeUpdated, batch.Namespace, batch.ID)
@@ -128,6 +130,7 @@ func (s *SQLCommon) UpsertBatch(ctx context.Context, batch *fftypes.Batch) (err
 					batch.Payload.TX.Type,
 					batch.Payload.TX.ID,
 					batch.Node,
+					batch.Encoding,
 				),
 			func() {
 				s.callbacks.UUIDCollectionNSEvent(database.CollectionBatches, fftypes.ChangeEventTypeCreated, batch.Namespace, batch.ID)
@@ -157,6 +160,7 @@ func (s *SQLCommon) batchResult(ctx context.Context, row *sql.Rows) (*fftypes.Ba
 		&batch.Payload.TX.Type,
 		&batch.Payload.TX.ID,
 		&batch.Node,
+		&batch.Encoding,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "batches")
@@ -100,6 +100,7 @@ func TestBatch2EWithDB(t *testing.T) {
 			},
 		},
 		PayloadRef: payloadRef,
+		Encoding:   fftypes.BatchEncodingGzip,
 		Confirmed:  fftypes.Now(),
 	}
 
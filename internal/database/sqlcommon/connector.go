@@ -0,0 +1,48 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+)
+
+// dsnConnector opens connections with the current datasource URL. When a secret in the URL is rotated new
+// connections use the new credentials, while the existing connections in the pool are left to be recycled.
+type dsnConnector struct {
+	driver driver.Driver
+	mux    sync.Mutex
+	dsn    string
+}
+
+func (c *dsnConnector) setDSN(dsn string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.dsn = dsn
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mux.Lock()
+	dsn := c.dsn
+	c.mux.Unlock()
+	return c.driver.Open(dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
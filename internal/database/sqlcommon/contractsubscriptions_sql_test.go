@@ -65,6 +65,8 @@ func TestContractSubscriptionE2EWithDB(t *testing.T) {
 	fb := database.ContractSubscriptionQueryFactory.NewFilter(ctx)
 	filter := fb.And(
 		fb.Eq("protocolid", sub.ProtocolID),
+		fb.Eq("interface", sub.Interface.ID),
+		fb.Eq("location", sub.Location.String()),
 	)
 	subs, res, err := s.GetContractSubscriptions(ctx, filter.Count(true))
 	assert.NoError(t, err)
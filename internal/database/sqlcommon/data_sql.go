@@ -41,6 +41,7 @@ var (
 		"blob_name",
 		"blob_size",
 		"value_size",
+		"group_hash",
 	}
 	dataColumnsWithValue = append(append([]string{}, dataColumnsNoValue...), "value")
 	dataFilterFieldMap   = map[string]string{
@@ -51,6 +52,7 @@ var (
 		"blob.public":      "blob_public",
 		"blob.name":        "blob_name",
 		"blob.size":        "blob_size",
+		"group":            "group_hash",
 	}
 )
 
@@ -69,6 +71,7 @@ func (s *SQLCommon) attemptDataUpdate(ctx context.Context, tx *txWrapper, data *
 			Set("blob_name", blob.Name).
 			Set("blob_size", blob.Size).
 			Set("value_size", data.ValueSize).
+			Set("group_hash", data.Group).
 			Set("value", data.Value).
 			Where(sq.Eq{
 				"id":   data.ID,
@@ -97,6 +100,7 @@ func (s *SQLCommon) attemptDataInsert(ctx context.Context, tx *txWrapper, data *
 				blob.Name,
 				blob.Size,
 				data.ValueSize,
+				data.Group,
 				data.Value,
 			),
 		func() {
@@ -189,6 +193,7 @@ func (s *SQLCommon) dataResult(ctx context.Context, row *sql.Rows, withValue boo
 		&data.Blob.Name,
 		&data.Blob.Size,
 		&data.ValueSize,
+		&data.Group,
 	}
 	if withValue {
 		results = append(results, &data.Value)
@@ -314,3 +319,18 @@ func (s *SQLCommon) UpdateData(ctx context.Context, id *fftypes.UUID, update dat
 
 	return s.commitTx(ctx, tx, autoCommit)
 }
+
+func (s *SQLCommon) DeleteData(ctx context.Context, ids []*fftypes.UUID) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	// No change events are emitted, as the data has been moved into an archive
+	if err = s.deleteTx(ctx, tx, sq.Delete("data").Where(sq.Eq{"id": ids}), nil); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
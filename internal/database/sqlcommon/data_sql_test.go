@@ -100,6 +100,7 @@ func TestDataE2EWithDB(t *testing.T) {
 			Name:   "path/to/myfile.ext",
 			Size:   12345,
 		},
+		Group: fftypes.NewRandB32(),
 	}
 
 	// Check disallows hash update, regardless of optimization
@@ -133,6 +134,7 @@ func TestDataE2EWithDB(t *testing.T) {
 		fb.Eq("datatype.name", dataUpdated.Datatype.Name),
 		fb.Eq("datatype.version", dataUpdated.Datatype.Version),
 		fb.Eq("hash", dataUpdated.Hash),
+		fb.Eq("group", dataUpdated.Group),
 		fb.Gt("created", 0),
 	)
 	dataRes, _, err := s.GetData(ctx, filter)
@@ -163,6 +165,13 @@ func TestDataE2EWithDB(t *testing.T) {
 	assert.Equal(t, 1, len(dataRes))
 	assert.Equal(t, int64(1), *res.TotalCount)
 
+	// Delete the data, once it has been archived
+	err = s.DeleteData(ctx, []*fftypes.UUID{dataID})
+	assert.NoError(t, err)
+	dataRead, err = s.GetDataByID(ctx, dataID, true)
+	assert.NoError(t, err)
+	assert.Nil(t, dataRead)
+
 	s.callbacks.AssertExpectations(t)
 }
 
@@ -325,3 +334,19 @@ func TestDataUpdateFail(t *testing.T) {
 	err := s.UpdateData(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10117", err)
 }
+
+func TestDeleteDataBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.DeleteData(context.Background(), []*fftypes.UUID{fftypes.NewUUID()})
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestDeleteDataFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.DeleteData(context.Background(), []*fftypes.UUID{fftypes.NewUUID()})
+	assert.Regexp(t, "FF10118", err)
+}
@@ -187,3 +187,18 @@ func (s *SQLCommon) UpdateEvent(ctx context.Context, id *fftypes.UUID, update da
 
 	return s.commitTx(ctx, tx, autoCommit)
 }
+
+func (s *SQLCommon) DeleteEvents(ctx context.Context, ids []*fftypes.UUID) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	// No change events are emitted, as the events have been moved into an archive
+	if err = s.deleteTx(ctx, tx, sq.Delete("events").Where(sq.Eq{"id": ids}), nil); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
@@ -99,6 +99,13 @@ func TestEventE2EWithDB(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(events))
 
+	// Delete the event, once it has been archived
+	err = s.DeleteEvents(ctx, []*fftypes.UUID{eventRead.ID})
+	assert.NoError(t, err)
+	eventRead, err = s.GetEventByID(ctx, eventID)
+	assert.NoError(t, err)
+	assert.Nil(t, eventRead)
+
 	s.callbacks.AssertExpectations(t)
 }
 
@@ -223,3 +230,19 @@ func TestEventUpdateFail(t *testing.T) {
 	err := s.UpdateEvent(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10117", err)
 }
+
+func TestDeleteEventsBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.DeleteEvents(context.Background(), []*fftypes.UUID{fftypes.NewUUID()})
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestDeleteEventsFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.DeleteEvents(context.Background(), []*fftypes.UUID{fftypes.NewUUID()})
+	assert.Regexp(t, "FF10118", err)
+}
@@ -34,6 +34,7 @@ var (
 		"name",
 		"ledger",
 		"hash",
+		"version",
 		"created",
 	}
 	groupFilterFieldMap = map[string]string{
@@ -123,6 +124,7 @@ func (s *SQLCommon) attemptGroupInsert(ctx context.Context, tx *txWrapper, group
 				group.Name,
 				group.Ledger,
 				group.Hash,
+				group.Version,
 				group.Created,
 			),
 		func() {
@@ -235,6 +237,7 @@ func (s *SQLCommon) groupResult(ctx context.Context, row *sql.Rows) (*fftypes.Gr
 		&group.Name,
 		&group.Ledger,
 		&group.Hash,
+		&group.Version,
 		&group.Created,
 	)
 	if err != nil {
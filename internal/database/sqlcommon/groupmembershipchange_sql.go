@@ -0,0 +1,150 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	groupMembershipChangeColumns = []string{
+		"id",
+		"namespace",
+		"group_hash",
+		"effective_from",
+		"author",
+		"added",
+		"removed",
+		"members",
+		"salt",
+		"message_id",
+		"created",
+	}
+	groupMembershipChangeFilterFieldMap = map[string]string{
+		"group":         "group_hash",
+		"effectivefrom": "effective_from",
+		"message":       "message_id",
+	}
+)
+
+func (s *SQLCommon) InsertGroupMembershipChange(ctx context.Context, change *fftypes.GroupMembershipChange) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	change.Created = fftypes.Now()
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("groupmembershipchanges").
+			Columns(groupMembershipChangeColumns...).
+			Values(
+				change.ID,
+				change.Namespace,
+				change.Group,
+				change.EffectiveFrom,
+				change.Author,
+				change.Add,
+				change.Remove,
+				change.Members,
+				change.Salt,
+				change.Message,
+				change.Created,
+			),
+		func() {
+			s.callbacks.UUIDCollectionNSEvent(database.CollectionGroupMembershipChanges, fftypes.ChangeEventTypeCreated, change.Namespace, change.ID)
+		},
+	); err != nil {
+		return err
+	}
+
+	// Move the group to the new version, with the resulting member list
+	if _, err = s.updateTx(ctx, tx,
+		sq.Update("groups").
+			Set("version", change.EffectiveFrom).
+			Where(sq.Eq{"hash": change.Group}),
+		func() {
+			s.callbacks.HashCollectionNSEvent(database.CollectionGroups, fftypes.ChangeEventTypeUpdated, change.Namespace, change.Group)
+		},
+	); err != nil {
+		return err
+	}
+	group := &fftypes.Group{
+		Hash: change.Group,
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: change.Members,
+		},
+	}
+	if err = s.updateMembers(ctx, tx, group, true); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) groupMembershipChangeResult(ctx context.Context, row *sql.Rows) (*fftypes.GroupMembershipChange, error) {
+	var change fftypes.GroupMembershipChange
+	err := row.Scan(
+		&change.ID,
+		&change.Namespace,
+		&change.Group,
+		&change.EffectiveFrom,
+		&change.Author,
+		&change.Add,
+		&change.Remove,
+		&change.Members,
+		&change.Salt,
+		&change.Message,
+		&change.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "groupmembershipchanges")
+	}
+	return &change, nil
+}
+
+func (s *SQLCommon) GetGroupMembershipChanges(ctx context.Context, filter database.Filter) ([]*fftypes.GroupMembershipChange, *database.FilterResult, error) {
+	query, fop, fi, err := s.filterSelect(ctx, "",
+		sq.Select(groupMembershipChangeColumns...).From("groupmembershipchanges"),
+		filter, groupMembershipChangeFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	changes := []*fftypes.GroupMembershipChange{}
+	for rows.Next() {
+		change, err := s.groupMembershipChangeResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, s.queryRes(ctx, tx, "groupmembershipchanges", fop, fi), err
+}
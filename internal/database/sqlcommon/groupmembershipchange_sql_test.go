@@ -0,0 +1,186 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGroupMembershipChangeE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a group to change
+	m1 := &fftypes.Member{Identity: "0x12345", Node: fftypes.NewUUID()}
+	m2 := &fftypes.Member{Identity: "0x23456", Node: fftypes.NewUUID()}
+	m3 := &fftypes.Member{Identity: "0x34567", Node: fftypes.NewUUID()}
+	group := &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Name:      "group1",
+			Namespace: "ns1",
+			Members:   fftypes.Members{m1, m2},
+		},
+		Created: fftypes.Now(),
+	}
+	group.Seal()
+
+	change := &fftypes.GroupMembershipChange{
+		ID:            fftypes.NewUUID(),
+		Namespace:     "ns1",
+		Group:         group.Hash,
+		EffectiveFrom: 1,
+		Author:        "0x12345",
+		Add:           fftypes.Members{m3},
+		Remove:        fftypes.Members{m2},
+		Members:       fftypes.Members{m1, m3},
+		Salt:          fftypes.NewRandB32(),
+		Message:       fftypes.NewUUID(),
+	}
+
+	s.callbacks.On("HashCollectionNSEvent", database.CollectionGroups, fftypes.ChangeEventTypeCreated, "ns1", group.Hash, mock.Anything).Return()
+	s.callbacks.On("HashCollectionNSEvent", database.CollectionGroups, fftypes.ChangeEventTypeUpdated, "ns1", group.Hash, mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionGroupMembershipChanges, fftypes.ChangeEventTypeCreated, "ns1", change.ID).Return()
+
+	err := s.UpsertGroup(ctx, group, database.UpsertOptimizationNew)
+	assert.NoError(t, err)
+
+	err = s.InsertGroupMembershipChange(ctx, change)
+	assert.NoError(t, err)
+	assert.NotNil(t, change.Created)
+	changeJson, _ := json.Marshal(&change)
+
+	// Check the group has moved to the new version
+	groupRead, err := s.GetGroupByHash(ctx, group.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), groupRead.Version)
+	assert.Equal(t, fftypes.Members{m1, m3}, groupRead.Members)
+
+	// Re-receiving the group definition must not revert the membership
+	err = s.UpsertGroup(ctx, group, database.UpsertOptimizationExisting)
+	assert.NoError(t, err)
+	groupRead, err = s.GetGroupByHash(ctx, group.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), groupRead.Version)
+	assert.Equal(t, fftypes.Members{m1, m3}, groupRead.Members)
+
+	// Query back the change
+	fb := database.GroupMembershipChangeQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("group", group.Hash),
+		fb.Eq("effectivefrom", 1),
+		fb.Eq("message", change.Message),
+	)
+	changes, res, err := s.GetGroupMembershipChanges(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	changeReadJson, _ := json.Marshal(changes[0])
+	assert.Equal(t, string(changeJson), string(changeReadJson))
+
+	// A second change for the same version is rejected
+	change.ID = fftypes.NewUUID()
+	err = s.InsertGroupMembershipChange(ctx, change)
+	assert.Equal(t, database.DuplicateKey, err)
+}
+
+func TestInsertGroupMembershipChangeFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertGroupMembershipChange(context.Background(), &fftypes.GroupMembershipChange{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertGroupMembershipChangeFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertGroupMembershipChange(context.Background(), &fftypes.GroupMembershipChange{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertGroupMembershipChangeFailUpdateGroup(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertGroupMembershipChange(context.Background(), &fftypes.GroupMembershipChange{})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertGroupMembershipChangeFailMembers(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertGroupMembershipChange(context.Background(), &fftypes.GroupMembershipChange{})
+	assert.Regexp(t, "FF10118", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertGroupMembershipChangeFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertGroupMembershipChange(context.Background(), &fftypes.GroupMembershipChange{})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGroupMembershipChangesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.GroupMembershipChangeQueryFactory.NewFilter(context.Background()).Eq("author", "")
+	_, _, err := s.GetGroupMembershipChanges(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGroupMembershipChangesBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.GroupMembershipChangeQueryFactory.NewFilter(context.Background()).Eq("author", map[bool]bool{true: false})
+	_, _, err := s.GetGroupMembershipChanges(context.Background(), f)
+	assert.Regexp(t, "FF10149.*author", err)
+}
+
+func TestGetGroupMembershipChangesScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.GroupMembershipChangeQueryFactory.NewFilter(context.Background()).Eq("author", "")
+	_, _, err := s.GetGroupMembershipChanges(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -47,15 +48,81 @@ var (
 		"confirmed",
 		"tx_type",
 		"batch_id",
+		"group_version",
+		"headers",
+		"priority",
+		"expiry",
+		"idempotency_key",
 	}
 	msgFilterFieldMap = map[string]string{
-		"type":   "mtype",
-		"txtype": "tx_type",
-		"batch":  "batch_id",
-		"group":  "group_hash",
+		"type":           "mtype",
+		"txtype":         "tx_type",
+		"batch":          "batch_id",
+		"group":          "group_hash",
+		"groupversion":   "group_version",
+		"idempotencykey": "idempotency_key",
+	}
+	// msgColumnJSONPaths are the JSON paths of the message fields held in each of the msgColumns
+	msgColumnJSONPaths = map[string]string{
+		"id":              "header.id",
+		"cid":             "header.cid",
+		"mtype":           "header.type",
+		"author":          "header.author",
+		"key":             "header.key",
+		"created":         "header.created",
+		"namespace":       "header.namespace",
+		"topics":          "header.topics",
+		"tag":             "header.tag",
+		"group_hash":      "header.group",
+		"datahash":        "header.datahash",
+		"hash":            "hash",
+		"pins":            "pins",
+		"state":           "state",
+		"confirmed":       "confirmed",
+		"tx_type":         "header.txtype",
+		"batch_id":        "batch",
+		"group_version":   "header.groupVersion",
+		"headers":         "header.headers",
+		"priority":        "header.priority",
+		"expiry":          "expiry",
+		"idempotency_key": "idempotencyKey",
 	}
 )
 
+// msgRequiredColumns works out the columns to read for the fields required by a filter, and whether the
+// data references are needed. All columns are read if no fields are required, or if any field is unknown.
+func msgRequiredColumns(fi *database.FilterInfo) (cols []string, loadDataRefs bool) {
+	if len(fi.RequiredFields) == 0 {
+		return msgColumns, true
+	}
+	required := make(map[string]bool)
+	for _, field := range fi.RequiredFields {
+		matched := false
+		if field == "data" || strings.HasPrefix(field, "data.") {
+			// The data references are loaded using the ID of the message
+			loadDataRefs = true
+			required["id"] = true
+			matched = true
+		}
+		for _, col := range msgColumns {
+			path := msgColumnJSONPaths[col]
+			if path == field || strings.HasPrefix(path, field+".") || strings.HasPrefix(field, path+".") {
+				required[col] = true
+				matched = true
+			}
+		}
+		if !matched {
+			return msgColumns, true
+		}
+	}
+	for _, col := range msgColumns {
+		if required[col] {
+			cols = append(cols, col)
+		}
+	}
+	return cols, loadDataRefs
+}
+
 func (s *SQLCommon) attemptMessageUpdate(ctx context.Context, tx *txWrapper, message *fftypes.Message) (int64, error) {
 	return s.updateTx(ctx, tx,
 		sq.Update("messages").
@@ -75,6 +142,10 @@ func (s *SQLCommon) attemptMessageUpdate(ctx context.Context, tx *txWrapper, mes
 			Set("confirmed", message.Confirmed).
 			Set("tx_type", message.Header.TxType).
 			Set("batch_id", message.BatchID).
+			Set("group_version", message.Header.GroupVersion).
+			Set("headers", message.Header.Headers).
+			Set("priority", message.Header.Priority).
+			Set("expiry", message.Expiry).
 			Where(sq.Eq{
 				"id":   message.Header.ID,
 				"hash": message.Hash,
@@ -106,6 +177,11 @@ func (s *SQLCommon) attemptMessageInsert(ctx context.Context, tx *txWrapper, mes
 				message.Confirmed,
 				message.Header.TxType,
 				message.BatchID,
+				message.Header.GroupVersion,
+				message.Header.Headers,
+				message.Header.Priority,
+				message.Expiry,
+				message.IdempotencyKey,
 			),
 		func() {
 			s.callbacks.OrderedUUIDCollectionNSEvent(database.CollectionMessages, fftypes.ChangeEventTypeCreated, message.Header.Namespace, message.Header.ID, message.Sequence)
@@ -315,9 +391,11 @@ func (s *SQLCommon) loadDataRefs(ctx context.Context, msgs []*fftypes.Message) e
 	return nil
 }
 
-func (s *SQLCommon) msgResult(ctx context.Context, row *sql.Rows) (*fftypes.Message, error) {
+// msgResult reads a row containing the supplied message columns, followed by the sequence
+func (s *SQLCommon) msgResult(ctx context.Context, row *sql.Rows, cols []string) (*fftypes.Message, error) {
 	var msg fftypes.Message
-	err := row.Scan(
+	// In the same order as msgColumns
+	fieldPointers := []interface{}{
 		&msg.Header.ID,
 		&msg.Header.CID,
 		&msg.Header.Type,
@@ -335,9 +413,25 @@ func (s *SQLCommon) msgResult(ctx context.Context, row *sql.Rows) (*fftypes.Mess
 		&msg.Confirmed,
 		&msg.Header.TxType,
 		&msg.BatchID,
-		// Must be added to the list of columns in all selects
-		&msg.Sequence,
-	)
+		&msg.Header.GroupVersion,
+		&msg.Header.Headers,
+		&msg.Header.Priority,
+		&msg.Expiry,
+		&msg.IdempotencyKey,
+	}
+	if len(cols) < len(msgColumns) {
+		// A subset of the columns, in the same relative order
+		sparse := make([]interface{}, 0, len(cols))
+		for i, col := range msgColumns {
+			if len(sparse) < len(cols) && cols[len(sparse)] == col {
+				sparse = append(sparse, fieldPointers[i])
+			}
+		}
+		fieldPointers = sparse
+	}
+	// Must be added to the list of columns in all selects
+	fieldPointers = append(fieldPointers, &msg.Sequence)
+	err := row.Scan(fieldPointers...)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "messages")
 	}
@@ -363,7 +457,7 @@ func (s *SQLCommon) GetMessageByID(ctx context.Context, id *fftypes.UUID) (messa
 		return nil, nil
 	}
 
-	msg, err := s.msgResult(ctx, rows)
+	msg, err := s.msgResult(ctx, rows, msgColumns)
 	if err != nil {
 		return nil, err
 	}
@@ -376,7 +470,7 @@ func (s *SQLCommon) GetMessageByID(ctx context.Context, id *fftypes.UUID) (messa
 	return msg, nil
 }
 
-func (s *SQLCommon) getMessagesQuery(ctx context.Context, query sq.SelectBuilder, fop sq.Sqlizer, fi *database.FilterInfo, allowCount bool) (message []*fftypes.Message, fr *database.FilterResult, err error) {
+func (s *SQLCommon) getMessagesQuery(ctx context.Context, query sq.SelectBuilder, fop sq.Sqlizer, fi *database.FilterInfo, cols []string, loadDataRefs, allowCount bool) (message []*fftypes.Message, fr *database.FilterResult, err error) {
 	if fi.Count && !allowCount {
 		return nil, nil, i18n.NewError(ctx, i18n.MsgFilterCountNotSupported)
 	}
@@ -389,7 +483,7 @@ func (s *SQLCommon) getMessagesQuery(ctx context.Context, query sq.SelectBuilder
 
 	msgs := []*fftypes.Message{}
 	for rows.Next() {
-		msg, err := s.msgResult(ctx, rows)
+		msg, err := s.msgResult(ctx, rows, cols)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -397,7 +491,7 @@ func (s *SQLCommon) getMessagesQuery(ctx context.Context, query sq.SelectBuilder
 	}
 
 	rows.Close()
-	if len(msgs) > 0 {
+	if len(msgs) > 0 && loadDataRefs {
 		if err = s.loadDataRefs(ctx, msgs); err != nil {
 			return nil, nil, err
 		}
@@ -406,9 +500,8 @@ func (s *SQLCommon) getMessagesQuery(ctx context.Context, query sq.SelectBuilder
 }
 
 func (s *SQLCommon) GetMessages(ctx context.Context, filter database.Filter) (message []*fftypes.Message, fr *database.FilterResult, err error) {
-	cols := append([]string{}, msgColumns...)
-	cols = append(cols, sequenceColumn)
-	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(cols...).From("messages"), filter, msgFilterFieldMap,
+	// The columns are added once the filter is finalized, as they depend on the fields it requires
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select().From("messages"), filter, msgFilterFieldMap,
 		[]interface{}{
 			&database.SortField{Field: "confirmed", Descending: true, Nulls: database.NullsFirst},
 			"created",
@@ -416,7 +509,9 @@ func (s *SQLCommon) GetMessages(ctx context.Context, filter database.Filter) (me
 	if err != nil {
 		return nil, nil, err
 	}
-	return s.getMessagesQuery(ctx, query, fop, fi, true)
+	cols, loadDataRefs := msgRequiredColumns(fi)
+	query = query.Columns(append(append([]string{}, cols...), sequenceColumn)...)
+	return s.getMessagesQuery(ctx, query, fop, fi, cols, loadDataRefs, true)
 }
 
 func (s *SQLCommon) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter database.Filter) (message []*fftypes.Message, fr *database.FilterResult, err error) {
@@ -432,7 +527,7 @@ func (s *SQLCommon) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID
 	}
 
 	query = query.LeftJoin("messages AS m ON m.id = md.message_id")
-	return s.getMessagesQuery(ctx, query, fop, fi, false)
+	return s.getMessagesQuery(ctx, query, fop, fi, msgColumns, true, false)
 }
 
 func (s *SQLCommon) UpdateMessage(ctx context.Context, msgid *fftypes.UUID, update database.Update) (err error) {
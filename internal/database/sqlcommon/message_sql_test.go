@@ -60,9 +60,10 @@ func TestUpsertE2EWithDB(t *testing.T) {
 			DataHash:  fftypes.NewRandB32(),
 			TxType:    fftypes.TransactionTypeUnpinned,
 		},
-		Hash:      fftypes.NewRandB32(),
-		State:     fftypes.MessageStateStaged,
-		Confirmed: nil,
+		Hash:           fftypes.NewRandB32(),
+		State:          fftypes.MessageStateStaged,
+		Confirmed:      nil,
+		IdempotencyKey: "retry1",
 		Data: []*fftypes.DataRef{
 			{ID: dataID1, Hash: rand1},
 			{ID: dataID2, Hash: rand2},
@@ -99,19 +100,24 @@ func TestUpsertE2EWithDB(t *testing.T) {
 				Key:    "0x12345",
 				Author: "did:firefly:org/abcd",
 			},
-			Created:   fftypes.Now(),
-			Namespace: "ns12345",
-			Topics:    []string{"topic1", "topic2"},
-			Tag:       "tag1",
-			Group:     gid,
-			DataHash:  fftypes.NewRandB32(),
-			TxType:    fftypes.TransactionTypeBatchPin,
+			Created:      fftypes.Now(),
+			Namespace:    "ns12345",
+			Topics:       []string{"topic1", "topic2"},
+			Tag:          "tag1",
+			Group:        gid,
+			GroupVersion: 1,
+			Headers:      fftypes.JSONObject{"x-route": "east"},
+			Priority:     fftypes.MessagePriorityHigh,
+			DataHash:     fftypes.NewRandB32(),
+			TxType:       fftypes.TransactionTypeBatchPin,
 		},
-		Hash:      fftypes.NewRandB32(),
-		Pins:      []string{fftypes.NewRandB32().String(), fftypes.NewRandB32().String()},
-		State:     fftypes.MessageStateRejected,
-		Confirmed: fftypes.Now(),
-		BatchID:   bid,
+		Hash:           fftypes.NewRandB32(),
+		Pins:           []string{fftypes.NewRandB32().String(), fftypes.NewRandB32().String()},
+		State:          fftypes.MessageStateRejected,
+		Confirmed:      fftypes.Now(),
+		Expiry:         fftypes.Now(),
+		IdempotencyKey: "retry1",
+		BatchID:        bid,
 		Data: []*fftypes.DataRef{
 			{ID: dataID1, Hash: rand1},
 			{ID: dataID2, Hash: rand2}, // Note the data refs cannot change, as it would affect the hash, and the hash is immutable
@@ -148,7 +154,9 @@ func TestUpsertE2EWithDB(t *testing.T) {
 		fb.Eq("author", msgUpdated.Header.Author),
 		fb.Eq("topics", msgUpdated.Header.Topics),
 		fb.Eq("group", msgUpdated.Header.Group),
+		fb.Eq("groupversion", msgUpdated.Header.GroupVersion),
 		fb.Eq("cid", msgUpdated.Header.CID),
+		fb.Eq("idempotencykey", "retry1"),
 		fb.Gt("created", "0"),
 		fb.Gt("confirmed", "0"),
 	)
@@ -205,9 +213,87 @@ func TestUpsertE2EWithDB(t *testing.T) {
 	msgReadJson, _ = json.Marshal(msgRead)
 	assert.Equal(t, string(msgJson), string(msgReadJson))
 
+	// A different message cannot re-use the idempotency key
+	msgDup := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Type:      fftypes.MessageTypeBroadcast,
+			Namespace: "ns12345",
+			Created:   fftypes.Now(),
+			DataHash:  fftypes.NewRandB32(),
+		},
+		Hash:           fftypes.NewRandB32(),
+		IdempotencyKey: "retry1",
+	}
+	err = s.UpsertMessage(ctx, msgDup, database.UpsertOptimizationNew)
+	assert.Equal(t, database.DuplicateKey, err)
+
 	s.callbacks.AssertExpectations(t)
 }
 
+func TestGetMessagesRequiredFieldsWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Type:      fftypes.MessageTypeBroadcast,
+			Namespace: "ns1",
+			Topics:    []string{"topic1"},
+			Tag:       "tag1",
+			Created:   fftypes.Now(),
+			DataHash:  fftypes.NewRandB32(),
+		},
+		Hash:  fftypes.NewRandB32(),
+		State: fftypes.MessageStateConfirmed,
+		Data: []*fftypes.DataRef{
+			{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+		},
+	}
+	s.callbacks.On("OrderedUUIDCollectionNSEvent", database.CollectionMessages, fftypes.ChangeEventTypeCreated, "ns1", msg.Header.ID, mock.Anything).Return()
+	err := s.UpsertMessage(ctx, msg, database.UpsertOptimizationNew)
+	assert.NoError(t, err)
+
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	newFilter := func() database.AndFilter {
+		return database.MessageQueryFactory.NewFilter(ctx).And()
+	}
+
+	// Only the columns of the required fields are read
+	msgs, _, err := s.GetMessages(ctx, fb.And(fb.Eq("tag", "tag1")).RequiredFields("header.tag", "state"))
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, "tag1", msgs[0].Header.Tag)
+	assert.Equal(t, fftypes.MessageStateConfirmed, msgs[0].State)
+	assert.Nil(t, msgs[0].Header.ID)
+	assert.Empty(t, msgs[0].Header.Topics)
+	assert.Nil(t, msgs[0].Data)
+
+	// The data references need the ID, and a whole object selects all of its columns
+	msgs, _, err = s.GetMessages(ctx, newFilter().RequiredFields("header", "data.id"))
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, msg.Header.ID, msgs[0].Header.ID)
+	assert.Equal(t, "topic1", msgs[0].Header.Topics.String())
+	assert.Empty(t, msgs[0].State)
+	assert.Len(t, msgs[0].Data, 1)
+
+	// Nested fields of a column, select that column
+	msgs, _, err = s.GetMessages(ctx, newFilter().RequiredFields("header.headers.custom"))
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+	assert.Empty(t, msgs[0].Header.Tag)
+
+	// An unknown field means all the columns are read
+	msgs, _, err = s.GetMessages(ctx, newFilter().RequiredFields("unknown"))
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, "tag1", msgs[0].Header.Tag)
+	assert.Len(t, msgs[0].Data, 1)
+}
+
 func TestUpsertMessageFailBegin(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
@@ -435,7 +521,7 @@ func TestGetMessageByIDLoadRefsFail(t *testing.T) {
 	cols := append([]string{}, msgColumns...)
 	cols = append(cols, "id()")
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(cols).
-		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "author1", "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), "confirmed", 0, "pin", nil, 0))
+		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "author1", "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), "confirmed", 0, "pin", nil, 0, nil, "", nil, nil, 0))
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	_, err := s.GetMessageByID(context.Background(), msgID)
 	assert.Regexp(t, "FF10115", err)
@@ -482,7 +568,7 @@ func TestGetMessagesLoadRefsFail(t *testing.T) {
 	cols := append([]string{}, msgColumns...)
 	cols = append(cols, "id()")
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(cols).
-		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "author1", "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), "confirmed", 0, "pin", nil, 0))
+		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "author1", "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), "confirmed", 0, "pin", nil, 0, nil, "", nil, nil, 0))
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.MessageQueryFactory.NewFilter(context.Background()).Gt("confirmed", "0")
 	_, _, err := s.GetMessages(context.Background(), f)
@@ -19,6 +19,7 @@ package sqlcommon
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -35,6 +36,7 @@ var (
 		"name",
 		"description",
 		"created",
+		"retired",
 	}
 	namespaceFilterFieldMap = map[string]string{
 		"message": "message_id",
@@ -86,6 +88,7 @@ func (s *SQLCommon) UpsertNamespace(ctx context.Context, namespace *fftypes.Name
 				Set("name", namespace.Name).
 				Set("description", namespace.Description).
 				Set("created", namespace.Created).
+				Set("retired", namespace.Retired).
 				Where(sq.Eq{"name": namespace.Name}),
 			func() {
 				s.callbacks.UUIDCollectionEvent(database.CollectionNamespaces, fftypes.ChangeEventTypeUpdated, namespace.ID)
@@ -108,6 +111,7 @@ func (s *SQLCommon) UpsertNamespace(ctx context.Context, namespace *fftypes.Name
 					namespace.Name,
 					namespace.Description,
 					namespace.Created,
+					namespace.Retired,
 				),
 			func() {
 				s.callbacks.UUIDCollectionEvent(database.CollectionNamespaces, fftypes.ChangeEventTypeCreated, namespace.ID)
@@ -129,6 +133,7 @@ func (s *SQLCommon) namespaceResult(ctx context.Context, row *sql.Rows) (*fftype
 		&namespace.Name,
 		&namespace.Description,
 		&namespace.Created,
+		&namespace.Retired,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "namespaces")
@@ -207,3 +212,73 @@ func (s *SQLCommon) DeleteNamespace(ctx context.Context, id *fftypes.UUID) (err
 
 	return s.commitTx(ctx, tx, autoCommit)
 }
+
+var (
+	// namespaceChildTables are tables without their own namespace column, that hang off a namespace-scoped parent.
+	// They must be cleaned up before the parent rows are removed.
+	namespaceChildTables = []struct {
+		table, column, parent, parentColumn string
+	}{
+		{table: "messages_data", column: "message_id", parent: "messages", parentColumn: "id"},
+		{table: "pins", column: "batch_id", parent: "batches", parentColumn: "id"},
+		{table: "members", column: "group_hash", parent: "groups", parentColumn: "hash"},
+		{table: "nonces", column: "group_hash", parent: "groups", parentColumn: "hash"},
+	}
+	// namespaceScopedTables are all the tables with a namespace column, in the order they are cleaned up.
+	// Dependants are listed before the rows they reference.
+	namespaceScopedTables = []string{
+		"ffimethods",
+		"ffievents",
+		"contractapis",
+		"contractsubscriptions",
+		"ffi",
+		"tokenapproval",
+		"tokentransfer",
+		"tokenbalance",
+		"tokenpool",
+		"blockchainevents",
+		"events",
+		"operations",
+		"quarantinedbatches",
+		"groupmembershipchanges",
+		"subscriptionchanges",
+		"groups",
+		"data",
+		"messages",
+		"batches",
+		"datatypes",
+		"subscriptions",
+		"transactions",
+	}
+)
+
+// DeleteNamespaceData removes every namespace-scoped row for the namespace, in a single transaction.
+// No per-row change events are emitted, as the namespace itself is going away.
+func (s *SQLCommon) DeleteNamespaceData(ctx context.Context, ns string) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	for _, child := range namespaceChildTables {
+		err = s.deleteTx(ctx, tx, sq.Delete(child.table).Where(
+			sq.Expr(fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE namespace = ?)", child.column, child.parentColumn, child.parent), ns),
+		), nil)
+		if err != nil && err != database.DeleteRecordNotFound {
+			return err
+		}
+	}
+
+	for _, table := range namespaceScopedTables {
+		err = s.deleteTx(ctx, tx, sq.Delete(table).Where(sq.Eq{
+			"namespace": ns,
+		}), nil)
+		if err != nil && err != database.DeleteRecordNotFound {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
@@ -18,6 +18,7 @@ package sqlcommon
 
 import (
 	"context"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -75,6 +76,7 @@ func TestNamespacesE2EWithDB(t *testing.T) {
 		Name:        "namespace1",
 		Description: "description1",
 		Created:     fftypes.Now(),
+		Retired:     fftypes.Now(),
 	}
 	s.callbacks.On("UUIDCollectionEvent", database.CollectionNamespaces, fftypes.ChangeEventTypeUpdated, namespace.ID, mock.Anything).Return()
 	err = s.UpsertNamespace(context.Background(), namespaceUpdated, true)
@@ -92,6 +94,7 @@ func TestNamespacesE2EWithDB(t *testing.T) {
 	filter := fb.And(
 		fb.Eq("type", string(namespaceUpdated.Type)),
 		fb.Eq("name", namespaceUpdated.Name),
+		fb.Gt("retired", 0),
 	)
 	namespaceRes, res, err := s.GetNamespaces(ctx, filter.Count(true))
 	assert.NoError(t, err)
@@ -228,3 +231,91 @@ func TestNamespaceDeleteFail(t *testing.T) {
 	err := s.DeleteNamespace(context.Background(), fftypes.NewUUID())
 	assert.Regexp(t, "FF10118", err)
 }
+
+func TestDeleteNamespaceDataWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	s.callbacks.On("UUIDCollectionNSEvent", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	s.callbacks.On("OrderedUUIDCollectionNSEvent", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	msgIDs := map[string]*fftypes.UUID{}
+	batchIDs := map[string]*fftypes.UUID{}
+	for _, ns := range []string{"ns1", "ns2"} {
+		msgIDs[ns] = fftypes.NewUUID()
+		batchIDs[ns] = fftypes.NewUUID()
+		err := s.UpsertMessage(ctx, &fftypes.Message{
+			Header: fftypes.MessageHeader{ID: msgIDs[ns], Namespace: ns, Created: fftypes.Now(), DataHash: fftypes.NewRandB32()},
+			Hash:   fftypes.NewRandB32(),
+			Data:   fftypes.DataRefs{{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()}},
+		}, database.UpsertOptimizationSkip)
+		assert.NoError(t, err)
+		err = s.UpsertBatch(ctx, &fftypes.Batch{ID: batchIDs[ns], Namespace: ns, Created: fftypes.Now()})
+		assert.NoError(t, err)
+	}
+
+	err := s.DeleteNamespaceData(ctx, "ns1")
+	assert.NoError(t, err)
+
+	msg, err := s.GetMessageByID(ctx, msgIDs["ns1"])
+	assert.NoError(t, err)
+	assert.Nil(t, msg)
+	batch, err := s.GetBatchByID(ctx, batchIDs["ns1"])
+	assert.NoError(t, err)
+	assert.Nil(t, batch)
+
+	msg, err = s.GetMessageByID(ctx, msgIDs["ns2"])
+	assert.NoError(t, err)
+	assert.Len(t, msg.Data, 1)
+	batch, err = s.GetBatchByID(ctx, batchIDs["ns2"])
+	assert.NoError(t, err)
+	assert.NotNil(t, batch)
+
+	// Repeating is fine, as there is nothing left to delete
+	err = s.DeleteNamespaceData(ctx, "ns1")
+	assert.NoError(t, err)
+}
+
+func TestDeleteNamespaceDataFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.DeleteNamespaceData(context.Background(), "ns1")
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteNamespaceDataFailChildDelete(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.DeleteNamespaceData(context.Background(), "ns1")
+	assert.Regexp(t, "FF10118", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteNamespaceDataFailDelete(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	for range namespaceChildTables {
+		mock.ExpectExec("DELETE .*").WillReturnResult(driver.ResultNoRows)
+	}
+	mock.ExpectExec("DELETE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.DeleteNamespaceData(context.Background(), "ns1")
+	assert.Regexp(t, "FF10118", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteNamespaceDataFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	for i := 0; i < len(namespaceChildTables)+len(namespaceScopedTables); i++ {
+		mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.DeleteNamespaceData(context.Background(), "ns1")
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -38,11 +38,17 @@ var (
 		"dx_peer",
 		"dx_endpoint",
 		"created",
+		"public_key",
+		"encryption_key",
+		"privacy_key",
 	}
 	nodeFilterFieldMap = map[string]string{
-		"message":     "message_id",
-		"dx.peer":     "dx_peer",
-		"dx.endpoint": "dx_endpoint",
+		"message":       "message_id",
+		"dx.peer":       "dx_peer",
+		"dx.endpoint":   "dx_endpoint",
+		"publickey":     "public_key",
+		"encryptionkey": "encryption_key",
+		"privacykey":    "privacy_key",
 	}
 )
 
@@ -95,6 +101,9 @@ func (s *SQLCommon) UpsertNode(ctx context.Context, node *fftypes.Node, allowExi
 				Set("dx_peer", node.DX.Peer).
 				Set("dx_endpoint", node.DX.Endpoint).
 				Set("created", node.Created).
+				Set("public_key", node.PublicKey).
+				Set("encryption_key", node.EncryptionKey).
+				Set("privacy_key", node.PrivacyKey).
 				Where(sq.Eq{"id": node.ID}),
 			func() {
 				s.callbacks.UUIDCollectionEvent(database.CollectionNodes, fftypes.ChangeEventTypeUpdated, node.ID)
@@ -115,6 +124,9 @@ func (s *SQLCommon) UpsertNode(ctx context.Context, node *fftypes.Node, allowExi
 					node.DX.Peer,
 					node.DX.Endpoint,
 					node.Created,
+					node.PublicKey,
+					node.EncryptionKey,
+					node.PrivacyKey,
 				),
 			func() {
 				s.callbacks.UUIDCollectionEvent(database.CollectionNodes, fftypes.ChangeEventTypeCreated, node.ID)
@@ -138,6 +150,9 @@ func (s *SQLCommon) nodeResult(ctx context.Context, row *sql.Rows) (*fftypes.Nod
 		&node.DX.Peer,
 		&node.DX.Endpoint,
 		&node.Created,
+		&node.PublicKey,
+		&node.EncryptionKey,
+		&node.PrivacyKey,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "nodes")
@@ -81,7 +81,10 @@ func TestNodesE2EWithDB(t *testing.T) {
 			Peer:     "peer1",
 			Endpoint: fftypes.JSONObject{"some": "info"},
 		},
-		Created: fftypes.Now(),
+		Created:       fftypes.Now(),
+		PublicKey:     "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+		EncryptionKey: "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a",
+		PrivacyKey:    "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=",
 	}
 	err = s.UpsertNode(context.Background(), nodeUpdated, true)
 	assert.NoError(t, err)
@@ -98,6 +101,9 @@ func TestNodesE2EWithDB(t *testing.T) {
 	filter := fb.And(
 		fb.Eq("description", string(nodeUpdated.Description)),
 		fb.Eq("name", nodeUpdated.Name),
+		fb.Eq("publickey", nodeUpdated.PublicKey),
+		fb.Eq("encryptionkey", nodeUpdated.EncryptionKey),
+		fb.Eq("privacykey", nodeUpdated.PrivacyKey),
 	)
 	nodeRes, res, err := s.GetNodes(ctx, filter.Count(true))
 	assert.NoError(t, err)
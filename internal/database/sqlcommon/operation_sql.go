@@ -40,11 +40,14 @@ var (
 		"error",
 		"input",
 		"output",
+		"retry_id",
+		"receipt",
 	}
 	opFilterFieldMap = map[string]string{
 		"tx":     "tx_id",
 		"type":   "optype",
 		"status": "opstatus",
+		"retry":  "retry_id",
 	}
 )
 
@@ -70,6 +73,8 @@ func (s *SQLCommon) InsertOperation(ctx context.Context, operation *fftypes.Oper
 				operation.Error,
 				operation.Input,
 				operation.Output,
+				operation.Retry,
+				operation.Receipt,
 			),
 		func() {
 			s.callbacks.UUIDCollectionNSEvent(database.CollectionOperations, fftypes.ChangeEventTypeCreated, operation.Namespace, operation.ID)
@@ -95,6 +100,8 @@ func (s *SQLCommon) opResult(ctx context.Context, row *sql.Rows) (*fftypes.Opera
 		&op.Error,
 		&op.Input,
 		&op.Output,
+		&op.Retry,
+		&op.Receipt,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "operations")
@@ -152,7 +159,7 @@ func (s *SQLCommon) GetOperations(ctx context.Context, filter database.Filter) (
 	return ops, s.queryRes(ctx, tx, "operations", fop, fi), err
 }
 
-func (s *SQLCommon) updateOperation(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+func (s *SQLCommon) UpdateOperation(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
 
 	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
 	if err != nil {
@@ -182,5 +189,5 @@ func (s *SQLCommon) ResolveOperation(ctx context.Context, id *fftypes.UUID, stat
 	if output != nil {
 		update.Set("output", output)
 	}
-	return s.updateOperation(ctx, id, update)
+	return s.UpdateOperation(ctx, id, update)
 }
@@ -103,6 +103,27 @@ func TestOperationE2EWithDB(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(operations))
 
+	// Link a retry
+	retryID := fftypes.NewUUID()
+	err = s.UpdateOperation(ctx, operation.ID, database.OperationQueryFactory.NewUpdate(ctx).Set("retry", retryID))
+	assert.NoError(t, err)
+	operations, _, err = s.GetOperations(ctx, fb.And(fb.Eq("retry", retryID)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(operations))
+	assert.Equal(t, *retryID, *operations[0].Retry)
+
+	// Record a receipt
+	receipt := &fftypes.TransactionReceipt{
+		BlockNumber:  fftypes.NewFFBigInt(12345),
+		GasUsed:      fftypes.NewFFBigInt(21000),
+		RevertReason: "pop",
+	}
+	err = s.UpdateOperation(ctx, operation.ID, database.OperationQueryFactory.NewUpdate(ctx).Set("receipt", receipt))
+	assert.NoError(t, err)
+	operationRead, err = s.GetOperationByID(ctx, operationID)
+	assert.NoError(t, err)
+	assert.Equal(t, receipt, operationRead.Receipt)
+
 	s.callbacks.AssertExpectations(t)
 }
 
@@ -193,7 +214,7 @@ func TestOperationUpdateBeginFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
 	u := database.OperationQueryFactory.NewUpdate(context.Background()).Set("id", fftypes.NewUUID())
-	err := s.updateOperation(context.Background(), fftypes.NewUUID(), u)
+	err := s.UpdateOperation(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10114", err)
 }
 
@@ -201,7 +222,7 @@ func TestOperationUpdateBuildQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin()
 	u := database.OperationQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
-	err := s.updateOperation(context.Background(), fftypes.NewUUID(), u)
+	err := s.UpdateOperation(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10149.*id", err)
 }
 
@@ -211,6 +232,6 @@ func TestOperationUpdateFail(t *testing.T) {
 	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
 	mock.ExpectRollback()
 	u := database.OperationQueryFactory.NewUpdate(context.Background()).Set("id", fftypes.NewUUID())
-	err := s.updateOperation(context.Background(), fftypes.NewUUID(), u)
+	err := s.UpdateOperation(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10117", err)
 }
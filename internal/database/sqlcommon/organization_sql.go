@@ -37,9 +37,13 @@ var (
 		"description",
 		"profile",
 		"created",
+		"previous_identity",
+		"identity_rotated",
 	}
 	organizationFilterFieldMap = map[string]string{
-		"message": "message_id",
+		"message":          "message_id",
+		"previousidentity": "previous_identity",
+		"identityrotated":  "identity_rotated",
 	}
 )
 
@@ -88,6 +92,8 @@ func (s *SQLCommon) UpsertOrganization(ctx context.Context, organization *fftype
 				Set("description", organization.Description).
 				Set("profile", organization.Profile).
 				Set("created", organization.Created).
+				Set("previous_identity", organization.PreviousIdentity).
+				Set("identity_rotated", organization.IdentityRotated).
 				Where(sq.Eq{"identity": organization.Identity}),
 			func() {
 				s.callbacks.UUIDCollectionEvent(database.CollectionOrganizations, fftypes.ChangeEventTypeUpdated, organization.ID)
@@ -108,6 +114,8 @@ func (s *SQLCommon) UpsertOrganization(ctx context.Context, organization *fftype
 					organization.Description,
 					organization.Profile,
 					organization.Created,
+					organization.PreviousIdentity,
+					organization.IdentityRotated,
 				),
 			func() {
 				s.callbacks.UUIDCollectionEvent(database.CollectionOrganizations, fftypes.ChangeEventTypeCreated, organization.ID)
@@ -131,6 +139,8 @@ func (s *SQLCommon) organizationResult(ctx context.Context, row *sql.Rows) (*fft
 		&organization.Description,
 		&organization.Profile,
 		&organization.Created,
+		&organization.PreviousIdentity,
+		&organization.IdentityRotated,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "orgs")
@@ -117,6 +117,25 @@ func TestOrganizationsE2EWithDB(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(organizations))
 
+	// Rotate the key
+	rotatedTime := fftypes.Now()
+	up = database.OrganizationQueryFactory.NewUpdate(ctx).
+		Set("identity", "0x34567").
+		Set("previousidentity", organizationUpdated.Identity).
+		Set("identityrotated", rotatedTime)
+	err = s.UpdateOrganization(ctx, organizationUpdated.ID, up)
+	assert.NoError(t, err)
+
+	// Test find by previous identity
+	filter = fb.And(
+		fb.Eq("previousidentity", "0x12345"),
+	)
+	organizations, _, err = s.GetOrganizations(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(organizations))
+	assert.Equal(t, "0x34567", organizations[0].Identity)
+	assert.Equal(t, rotatedTime.UnixNano(), organizations[0].IdentityRotated.UnixNano())
+
 	s.callbacks.AssertExpectations(t)
 }
 
@@ -35,6 +35,7 @@ var (
 		"idx",
 		"dispatched",
 		"created",
+		"ledger",
 	}
 	pinFilterFieldMap = map[string]string{
 		"batch": "batch_id",
@@ -83,6 +84,7 @@ func (s *SQLCommon) UpsertPin(ctx context.Context, pin *fftypes.Pin) (err error)
 					pin.Index,
 					pin.Dispatched,
 					pin.Created,
+					pin.Ledger,
 				),
 			func() {
 				s.callbacks.OrderedCollectionEvent(database.CollectionPins, fftypes.ChangeEventTypeCreated, pin.Sequence)
@@ -105,6 +107,7 @@ func (s *SQLCommon) pinResult(ctx context.Context, row *sql.Rows) (*fftypes.Pin,
 		&pin.Index,
 		&pin.Dispatched,
 		&pin.Created,
+		&pin.Ledger,
 		&pin.Sequence,
 	)
 	if err != nil {
@@ -44,6 +44,7 @@ func TestPinsE2EWithDB(t *testing.T) {
 		Index:      10,
 		Created:    fftypes.Now(),
 		Dispatched: false,
+		Ledger:     "ledger1",
 	}
 
 	s.callbacks.On("OrderedCollectionEvent", database.CollectionPins, fftypes.ChangeEventTypeCreated, mock.Anything).Return()
@@ -59,10 +60,12 @@ func TestPinsE2EWithDB(t *testing.T) {
 		fb.Eq("hash", pin.Hash),
 		fb.Eq("batch", pin.Batch),
 		fb.Gt("created", 0),
+		fb.Eq("ledger", "ledger1"),
 	)
 	pinRes, res, err := s.GetPins(ctx, filter.Count(true))
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(pinRes))
+	assert.Equal(t, "ledger1", pinRes[0].Ledger)
 	assert.Equal(t, int64(1), *res.TotalCount)
 
 	// Set it dispatched
@@ -31,6 +31,7 @@ type SQLFeatures struct {
 	UseILIKE              bool
 	PlaceholderFormat     sq.PlaceholderFormat
 	ExclusiveTableLockSQL func(table string) string
+	IsUniqueViolation     func(err error) bool
 }
 
 func DefaultSQLProviderFeatures() SQLFeatures {
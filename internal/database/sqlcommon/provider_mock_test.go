@@ -27,6 +27,7 @@ import (
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/stretchr/testify/mock"
 )
 
 // testProvider uses the datadog mocking framework
@@ -47,8 +48,10 @@ type mockProvider struct {
 
 func newMockProvider() *mockProvider {
 	mp := &mockProvider{
-		prefix: config.NewPluginConfig("unittest.mockdb"),
+		prefix:    config.NewPluginConfig("unittest.mockdb"),
+		callbacks: &databasemocks.Callbacks{},
 	}
+	mp.callbacks.On("ObserveLatency", mock.Anything, mock.Anything, mock.Anything).Maybe()
 	mp.SQLCommon.InitPrefix(mp, mp.prefix)
 	mp.mockDB, mp.mdb, _ = sqlmock.New()
 	return mp
@@ -25,14 +25,13 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	migratedb "github.com/golang-migrate/migrate/v4/database"
-	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	migratesqlite3 "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
-
-	// Import SQLite driver
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/mock"
 )
 
 // sqliteGoTestProvider uses QL in-memory database
@@ -53,6 +52,7 @@ func newSQLiteTestProvider(t *testing.T) (*sqliteGoTestProvider, func()) {
 		capabilities: &database.Capabilities{},
 		prefix:       config.NewPluginConfig("unittest.db"),
 	}
+	tp.callbacks.On("ObserveLatency", mock.Anything, mock.Anything, mock.Anything).Maybe()
 	tp.SQLCommon.InitPrefix(tp, tp.prefix)
 	dir, err := ioutil.TempDir("", "")
 	assert.NoError(t, err)
@@ -82,6 +82,10 @@ func (psql *sqliteGoTestProvider) Features() SQLFeatures {
 	features := DefaultSQLProviderFeatures()
 	features.PlaceholderFormat = sq.Dollar
 	features.UseILIKE = false // Not supported
+	features.IsUniqueViolation = func(err error) bool {
+		sqliteErr, ok := err.(sqlite3.Error)
+		return ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
 	return features
 }
 
@@ -95,5 +99,5 @@ func (tp *sqliteGoTestProvider) Open(url string) (*sql.DB, error) {
 }
 
 func (tp *sqliteGoTestProvider) GetMigrationDriver(db *sql.DB) (migratedb.Driver, error) {
-	return sqlite3.WithInstance(db, &sqlite3.Config{})
+	return migratesqlite3.WithInstance(db, &migratesqlite3.Config{})
 }
@@ -0,0 +1,174 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	quarantinedBatchColumns = []string{
+		"id",
+		"namespace",
+		"batch_id",
+		"tx_id",
+		"hash",
+		"payload_ref",
+		"key",
+		"peer",
+		"contexts",
+		"reason",
+		"payload",
+		"created",
+	}
+	quarantinedBatchFilterFieldMap = map[string]string{
+		"batch":      "batch_id",
+		"tx":         "tx_id",
+		"payloadref": "payload_ref",
+	}
+)
+
+func (s *SQLCommon) InsertQuarantinedBatch(ctx context.Context, qb *fftypes.QuarantinedBatch) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	qb.Created = fftypes.Now()
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("quarantinedbatches").
+			Columns(quarantinedBatchColumns...).
+			Values(
+				qb.ID,
+				qb.Namespace,
+				qb.Batch,
+				qb.TX,
+				qb.Hash,
+				qb.PayloadRef,
+				qb.Key,
+				qb.Peer,
+				qb.Contexts,
+				qb.Reason,
+				qb.Payload,
+				qb.Created,
+			),
+		func() {
+			s.callbacks.UUIDCollectionNSEvent(database.CollectionQuarantinedBatches, fftypes.ChangeEventTypeCreated, qb.Namespace, qb.ID)
+		},
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) quarantinedBatchResult(ctx context.Context, row *sql.Rows) (*fftypes.QuarantinedBatch, error) {
+	var qb fftypes.QuarantinedBatch
+	err := row.Scan(
+		&qb.ID,
+		&qb.Namespace,
+		&qb.Batch,
+		&qb.TX,
+		&qb.Hash,
+		&qb.PayloadRef,
+		&qb.Key,
+		&qb.Peer,
+		&qb.Contexts,
+		&qb.Reason,
+		&qb.Payload,
+		&qb.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "quarantinedbatches")
+	}
+	return &qb, nil
+}
+
+func (s *SQLCommon) GetQuarantinedBatchByID(ctx context.Context, id *fftypes.UUID) (*fftypes.QuarantinedBatch, error) {
+	rows, _, err := s.query(ctx,
+		sq.Select(quarantinedBatchColumns...).
+			From("quarantinedbatches").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Quarantined batch '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.quarantinedBatchResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetQuarantinedBatches(ctx context.Context, filter database.Filter) ([]*fftypes.QuarantinedBatch, *database.FilterResult, error) {
+	query, fop, fi, err := s.filterSelect(ctx, "",
+		sq.Select(quarantinedBatchColumns...).From("quarantinedbatches"),
+		filter, quarantinedBatchFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	qbs := []*fftypes.QuarantinedBatch{}
+	for rows.Next() {
+		qb, err := s.quarantinedBatchResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		qbs = append(qbs, qb)
+	}
+
+	return qbs, s.queryRes(ctx, tx, "quarantinedbatches", fop, fi), err
+}
+
+func (s *SQLCommon) DeleteQuarantinedBatch(ctx context.Context, id *fftypes.UUID) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	qb, err := s.GetQuarantinedBatchByID(ctx, id)
+	if err == nil && qb != nil {
+		err = s.deleteTx(ctx, tx, sq.Delete("quarantinedbatches").Where(sq.Eq{"id": id}),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionQuarantinedBatches, fftypes.ChangeEventTypeDeleted, qb.Namespace, qb.ID)
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
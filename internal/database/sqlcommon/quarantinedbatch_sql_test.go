@@ -0,0 +1,179 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantinedBatchE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new quarantined batch entry
+	qb := &fftypes.QuarantinedBatch{
+		ID:         fftypes.NewUUID(),
+		Namespace:  "ns1",
+		Batch:      fftypes.NewUUID(),
+		TX:         fftypes.NewUUID(),
+		Hash:       fftypes.NewRandB32(),
+		PayloadRef: "Qm12345",
+		Key:        "0x12345",
+		Contexts:   fftypes.FFStringArray{fftypes.NewRandB32().String()},
+		Reason:     "Author could not be resolved",
+		Payload:    `{"not":"a batch"`,
+	}
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionQuarantinedBatches, fftypes.ChangeEventTypeCreated, "ns1", qb.ID).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionQuarantinedBatches, fftypes.ChangeEventTypeDeleted, "ns1", qb.ID).Return()
+
+	err := s.InsertQuarantinedBatch(ctx, qb)
+	assert.NoError(t, err)
+	assert.NotNil(t, qb.Created)
+	qbJson, _ := json.Marshal(&qb)
+
+	// Query back the quarantined batch (by query filter)
+	fb := database.QuarantinedBatchQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("batch", qb.Batch),
+		fb.Eq("namespace", "ns1"),
+	)
+	qbs, res, err := s.GetQuarantinedBatches(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(qbs))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	qbReadJson, _ := json.Marshal(qbs[0])
+	assert.Equal(t, string(qbJson), string(qbReadJson))
+
+	// Query back the quarantined batch (by ID)
+	qbRead, err := s.GetQuarantinedBatchByID(ctx, qb.ID)
+	assert.NoError(t, err)
+	qbReadJson, _ = json.Marshal(qbRead)
+	assert.Equal(t, string(qbJson), string(qbReadJson))
+
+	// Test delete, and refind no return
+	err = s.DeleteQuarantinedBatch(ctx, qb.ID)
+	assert.NoError(t, err)
+	qbs, _, err = s.GetQuarantinedBatches(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(qbs))
+}
+
+func TestInsertQuarantinedBatchFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertQuarantinedBatch(context.Background(), &fftypes.QuarantinedBatch{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertQuarantinedBatchFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertQuarantinedBatch(context.Background(), &fftypes.QuarantinedBatch{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertQuarantinedBatchFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertQuarantinedBatch(context.Background(), &fftypes.QuarantinedBatch{})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetQuarantinedBatchByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetQuarantinedBatchByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetQuarantinedBatchByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	qb, err := s.GetQuarantinedBatchByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, qb)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetQuarantinedBatchByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetQuarantinedBatchByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetQuarantinedBatchesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.QuarantinedBatchQueryFactory.NewFilter(context.Background()).Eq("reason", "")
+	_, _, err := s.GetQuarantinedBatches(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetQuarantinedBatchesBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.QuarantinedBatchQueryFactory.NewFilter(context.Background()).Eq("reason", map[bool]bool{true: false})
+	_, _, err := s.GetQuarantinedBatches(context.Background(), f)
+	assert.Regexp(t, "FF10149.*reason", err)
+}
+
+func TestGetQuarantinedBatchesScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.QuarantinedBatchQueryFactory.NewFilter(context.Background()).Eq("reason", "")
+	_, _, err := s.GetQuarantinedBatches(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQuarantinedBatchDeleteBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.DeleteQuarantinedBatch(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestQuarantinedBatchDeleteFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(quarantinedBatchColumns).AddRow(
+		fftypes.NewUUID(), "ns1", nil, nil, nil, "", "", "", "", "bad batch", "", fftypes.Now()),
+	)
+	mock.ExpectExec("DELETE .*").WillReturnError(fmt.Errorf("pop"))
+	err := s.DeleteQuarantinedBatch(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10118", err)
+}
@@ -20,12 +20,16 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/secrets"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/sirupsen/logrus"
@@ -40,6 +44,7 @@ type SQLCommon struct {
 	callbacks    database.Callbacks
 	provider     Provider
 	features     SQLFeatures
+	migrations   string
 }
 
 type txContextKey struct{}
@@ -63,16 +68,32 @@ func (s *SQLCommon) Init(ctx context.Context, provider Provider, prefix config.P
 		return i18n.NewError(ctx, i18n.MsgDBInitFailed)
 	}
 
-	if s.db, err = provider.Open(prefix.GetString(SQLConfDatasourceURL)); err != nil {
+	// The datasource URL can refer to secrets, such as the password of the database user
+	url := prefix.GetString(SQLConfDatasourceURL)
+	connector := &dsnConnector{}
+	dsn, err := secrets.Watch(ctx, url, connector.setDSN)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgDBInitFailed)
+	}
+	if s.db, err = provider.Open(dsn); err != nil {
 		return i18n.WrapError(ctx, err, i18n.MsgDBInitFailed)
 	}
+	if secrets.HasReference(url) {
+		// Open connections through a connector that uses rotated secrets. The pool opened by the provider
+		// has not made any connections yet, as they are opened lazily
+		connector.driver = s.db.Driver()
+		connector.setDSN(dsn)
+		_ = s.db.Close()
+		s.db = sql.OpenDB(connector)
+	}
 	connLimit := prefix.GetInt(SQLConfMaxConnections)
 	if connLimit > 0 {
 		s.db.SetMaxOpenConns(connLimit)
 	}
 
-	if prefix.GetBool(SQLConfMigrationsAuto) {
-		if err = s.applyDBMigrations(ctx, prefix, provider); err != nil {
+	s.migrations = "file://" + prefix.GetString(SQLConfMigrationsDirectory)
+	if prefix.GetBool(SQLConfMigrationsAuto) && !config.GetBool(config.DatabaseMigrationsDryRun) {
+		if err = s.applyDBMigrations(ctx, provider); err != nil {
 			return i18n.WrapError(ctx, err, i18n.MsgDBMigrationFailed)
 		}
 	}
@@ -82,12 +103,15 @@ func (s *SQLCommon) Init(ctx context.Context, provider Provider, prefix config.P
 
 func (s *SQLCommon) Capabilities() *database.Capabilities { return s.capabilities }
 
-func (s *SQLCommon) RunAsGroup(ctx context.Context, fn func(ctx context.Context) error) error {
+func (s *SQLCommon) RunAsGroup(ctx context.Context, fn func(ctx context.Context) error) (err error) {
 	if tx := getTXFromContext(ctx); tx != nil {
 		// transaction already exists - just continue using it
 		return fn(ctx)
 	}
 
+	ctx, span := tracing.StartSpan(ctx, "database.RunAsGroup")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	ctx, tx, _, err := s.beginOrUseTx(ctx)
 	if err != nil {
 		return err
@@ -101,23 +125,81 @@ func (s *SQLCommon) RunAsGroup(ctx context.Context, fn func(ctx context.Context)
 	return s.commitTx(ctx, tx, false /* we _are_ the auto-committer */)
 }
 
-func (s *SQLCommon) applyDBMigrations(ctx context.Context, prefix config.Prefix, provider Provider) error {
-	driver, err := provider.GetMigrationDriver(s.db)
-	if err == nil {
-		var m *migrate.Migrate
-		m, err = migrate.NewWithDatabaseInstance(
-			"file://"+prefix.GetString(SQLConfMigrationsDirectory),
-			provider.MigrationsDir(), driver)
-		if err == nil {
-			err = m.Up()
-		}
+func (s *SQLCommon) applyDBMigrations(ctx context.Context, provider Provider) error {
+	m, err := s.newMigrate(provider)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgDBMigrationFailed)
 	}
-	if err != nil && err != migrate.ErrNoChange {
+	// Check we are not about to run against a database written by a newer version, before touching the schema
+	if _, err = s.pendingMigrations(ctx, m); err != nil {
+		return err
+	}
+	if err = m.Up(); err != nil && err != migrate.ErrNoChange {
 		return i18n.WrapError(ctx, err, i18n.MsgDBMigrationFailed)
 	}
 	return nil
 }
 
+func (s *SQLCommon) newMigrate(provider Provider) (*migrate.Migrate, error) {
+	driver, err := provider.GetMigrationDriver(s.db)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithDatabaseInstance(s.migrations, provider.MigrationsDir(), driver)
+}
+
+// Ping checks a connection can be made to the database
+func (s *SQLCommon) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgDBQueryFailed)
+	}
+	return nil
+}
+
+// PendingMigrations returns the names of the migrations that have not yet been applied to the database,
+// in the order they would be applied
+func (s *SQLCommon) PendingMigrations(ctx context.Context) ([]string, error) {
+	m, err := s.newMigrate(s.provider)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBMigrationFailed)
+	}
+	return s.pendingMigrations(ctx, m)
+}
+
+// pendingMigrations compares the version of the database schema with the available migrations, and
+// fails if the database has been migrated beyond the migrations known to this version of FireFly
+func (s *SQLCommon) pendingMigrations(ctx context.Context, m *migrate.Migrate) ([]string, error) {
+	current, _, err := m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBMigrationFailed)
+	}
+
+	src, err := source.Open(s.migrations)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBMigrationFailed)
+	}
+	defer src.Close()
+
+	var pending []string
+	var latest uint
+	// The source reports the end of the ordered list of migrations with an error
+	for version, err := src.First(); err == nil; version, err = src.Next(version) {
+		latest = version
+		if version > current {
+			r, identifier, err := src.ReadUp(version)
+			if err != nil {
+				return nil, i18n.WrapError(ctx, err, i18n.MsgDBMigrationFailed)
+			}
+			_ = r.Close()
+			pending = append(pending, fmt.Sprintf("%06d_%s", version, identifier))
+		}
+	}
+	if current > latest {
+		return nil, i18n.NewError(ctx, i18n.MsgDBSchemaNewer, current, latest)
+	}
+	return pending, nil
+}
+
 func getTXFromContext(ctx context.Context) *txWrapper {
 	ctxKey := txContextKey{}
 	txi := ctx.Value(ctxKey)
@@ -168,11 +250,13 @@ func (s *SQLCommon) queryTx(ctx context.Context, tx *txWrapper, q sq.SelectBuild
 	l.Debugf(`SQL-> query: %s`, sqlQuery)
 	l.Tracef(`SQL-> query args: %+v`, args)
 	var rows *sql.Rows
+	start := time.Now()
 	if tx != nil {
 		rows, err = tx.sqlTX.QueryContext(ctx, sqlQuery, args...)
 	} else {
 		rows, err = s.db.QueryContext(ctx, sqlQuery, args...)
 	}
+	s.observe(ctx, start, err)
 	if err != nil {
 		l.Errorf(`SQL query failed: %s sql=[ %s ]`, err, sqlQuery)
 		return nil, tx, i18n.WrapError(ctx, err, i18n.MsgDBQueryFailed)
@@ -204,11 +288,13 @@ func (s *SQLCommon) countQuery(ctx context.Context, tx *txWrapper, tableName str
 	l.Debugf(`SQL-> count query: %s`, sqlQuery)
 	l.Tracef(`SQL-> count query args: %+v`, args)
 	var rows *sql.Rows
+	start := time.Now()
 	if tx != nil {
 		rows, err = tx.sqlTX.QueryContext(ctx, sqlQuery, args...)
 	} else {
 		rows, err = s.db.QueryContext(ctx, sqlQuery, args...)
 	}
+	s.observe(ctx, start, err)
 	if err != nil {
 		l.Errorf(`SQL count query failed: %s sql=[ %s ]`, err, sqlQuery)
 		return count, i18n.WrapError(ctx, err, i18n.MsgDBQueryFailed)
@@ -236,6 +322,15 @@ func (s *SQLCommon) queryRes(ctx context.Context, tx *txWrapper, tableName strin
 	return fr
 }
 
+// insertError returns the DuplicateKey sentinel for a violation of a unique index, so callers can report a conflict
+// with a record inserted concurrently, rather than a failure of the database
+func (s *SQLCommon) insertError(ctx context.Context, err error) error {
+	if s.features.IsUniqueViolation != nil && s.features.IsUniqueViolation(err) {
+		return database.DuplicateKey
+	}
+	return i18n.WrapError(ctx, err, i18n.MsgDBInsertFailed)
+}
+
 func (s *SQLCommon) insertTx(ctx context.Context, tx *txWrapper, q sq.InsertBuilder, postCommit func()) (int64, error) {
 	return s.insertTxExt(ctx, tx, q, postCommit, false)
 }
@@ -251,21 +346,32 @@ func (s *SQLCommon) insertTxExt(ctx context.Context, tx *txWrapper, q sq.InsertB
 	l.Debugf(`SQL-> insert: %s`, sqlQuery)
 	l.Tracef(`SQL-> insert args: %+v`, args)
 	var sequence int64
+	start := time.Now()
 	if useQuery {
 		err := tx.sqlTX.QueryRowContext(ctx, sqlQuery, args...).Scan(&sequence)
+		if err != nil && requestConflictEmptyResult {
+			// A conflict we asked for is an expected outcome, rather than a sign of database pressure
+			s.observe(ctx, start, nil)
+		} else {
+			s.observe(ctx, start, err)
+		}
 		if err != nil {
 			level := logrus.DebugLevel
 			if !requestConflictEmptyResult {
 				level = logrus.ErrorLevel
 			}
 			l.Logf(level, `SQL insert failed (conflictEmptyRequested=%t): %s sql=[ %s ]: %s`, requestConflictEmptyResult, err, sqlQuery, err)
-			return -1, i18n.WrapError(ctx, err, i18n.MsgDBInsertFailed)
+			if requestConflictEmptyResult && err == sql.ErrNoRows {
+				return -1, database.DuplicateKey
+			}
+			return -1, s.insertError(ctx, err)
 		}
 	} else {
 		res, err := tx.sqlTX.ExecContext(ctx, sqlQuery, args...)
+		s.observe(ctx, start, err)
 		if err != nil {
 			l.Errorf(`SQL insert failed: %s sql=[ %s ]: %s`, err, sqlQuery, err)
-			return -1, i18n.WrapError(ctx, err, i18n.MsgDBInsertFailed)
+			return -1, s.insertError(ctx, err)
 		}
 		sequence, _ = res.LastInsertId()
 	}
@@ -285,7 +391,9 @@ func (s *SQLCommon) deleteTx(ctx context.Context, tx *txWrapper, q sq.DeleteBuil
 	}
 	l.Debugf(`SQL-> delete: %s`, sqlQuery)
 	l.Tracef(`SQL-> delete args: %+v`, args)
+	start := time.Now()
 	res, err := tx.sqlTX.ExecContext(ctx, sqlQuery, args...)
+	s.observe(ctx, start, err)
 	if err != nil {
 		l.Errorf(`SQL delete failed: %s sql=[ %s ]: %s`, err, sqlQuery, err)
 		return i18n.WrapError(ctx, err, i18n.MsgDBDeleteFailed)
@@ -310,7 +418,9 @@ func (s *SQLCommon) updateTx(ctx context.Context, tx *txWrapper, q sq.UpdateBuil
 	}
 	l.Debugf(`SQL-> update: %s`, sqlQuery)
 	l.Tracef(`SQL-> update args: %+v`, args)
+	start := time.Now()
 	res, err := tx.sqlTX.ExecContext(ctx, sqlQuery, args...)
+	s.observe(ctx, start, err)
 	if err != nil {
 		l.Errorf(`SQL update failed: %s sql=[ %s ]`, err, sqlQuery)
 		return -1, i18n.WrapError(ctx, err, i18n.MsgDBUpdateFailed)
@@ -324,6 +434,11 @@ func (s *SQLCommon) updateTx(ctx context.Context, tx *txWrapper, q sq.UpdateBuil
 	return ra, nil
 }
 
+// observe reports the latency and outcome of a database call, so the node can react to database pressure
+func (s *SQLCommon) observe(ctx context.Context, start time.Time, err error) {
+	s.callbacks.ObserveLatency(ctx, time.Since(start), err)
+}
+
 func (s *SQLCommon) postCommitEvent(tx *txWrapper, fn func()) {
 	tx.postCommit = append(tx.postCommit, fn)
 }
@@ -393,7 +508,9 @@ func (s *SQLCommon) commitTx(ctx context.Context, tx *txWrapper, autoCommit bool
 	}
 
 	l.Debugf(`SQL-> commit`)
+	start := time.Now()
 	err := tx.sqlTX.Commit()
+	s.observe(ctx, start, err)
 	if err != nil {
 		l.Errorf(`SQL commit failed: %s`, err)
 		return i18n.WrapError(ctx, err, i18n.MsgDBCommitFailed)
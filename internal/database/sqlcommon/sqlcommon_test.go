@@ -20,13 +20,21 @@ import (
 	"context"
 	"database/sql/driver"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/secrets"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestInitSQLCommon(t *testing.T) {
@@ -36,6 +44,17 @@ func TestInitSQLCommon(t *testing.T) {
 	assert.NotNil(t, s.DB())
 }
 
+func TestPing(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	err := s.Ping(context.Background())
+	assert.NoError(t, err)
+
+	s.DB().Close()
+	err = s.Ping(context.Background())
+	assert.Regexp(t, "FF10115", err)
+}
+
 func TestInitSQLCommonMissingOptions(t *testing.T) {
 	s := &SQLCommon{}
 	err := s.Init(context.Background(), nil, nil, nil, nil)
@@ -49,6 +68,48 @@ func TestInitSQLCommonOpenFailed(t *testing.T) {
 	assert.Regexp(t, "FF10112.*pop", err)
 }
 
+func TestInitSQLCommonDatasourceSecret(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "dsn")
+	err := ioutil.WriteFile(secretFile, []byte("file:"+filepath.Join(dir, "one.db")), 0600)
+	assert.NoError(t, err)
+
+	tp := &sqliteGoTestProvider{
+		t:            t,
+		callbacks:    &databasemocks.Callbacks{},
+		capabilities: &database.Capabilities{},
+		prefix:       config.NewPluginConfig("unittest.db"),
+	}
+	config.Reset()
+	tp.SQLCommon.InitPrefix(tp, tp.prefix)
+	tp.prefix.Set(SQLConfDatasourceURL, fmt.Sprintf("${secret:file:%s}", secretFile))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = tp.Init(ctx, tp, tp.prefix, tp.callbacks, tp.capabilities)
+	assert.NoError(t, err)
+	defer tp.Close()
+	err = tp.DB().Ping()
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "one.db"))
+
+	// New connections use the rotated datasource URL
+	err = ioutil.WriteFile(secretFile, []byte("file:"+filepath.Join(dir, "two.db")), 0600)
+	assert.NoError(t, err)
+	secrets.Refresh(context.Background())
+	tp.DB().SetMaxIdleConns(0)
+	err = tp.DB().Ping()
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "two.db"))
+}
+
+func TestInitSQLCommonDatasourceSecretFail(t *testing.T) {
+	mp := newMockProvider()
+	mp.prefix.Set(SQLConfDatasourceURL, "${secret:vault:db#url}")
+	defer mp.prefix.Set(SQLConfDatasourceURL, "")
+	err := mp.SQLCommon.Init(context.Background(), mp, mp.prefix, mp.callbacks, mp.capabilities)
+	assert.Regexp(t, "FF10112.*FF10504", err)
+}
+
 func TestInitSQLCommonMigrationOpenFailed(t *testing.T) {
 	mp := newMockProvider()
 	mp.prefix.Set(SQLConfMigrationsAuto, true)
@@ -72,6 +133,127 @@ func TestMigrationUpDown(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func newTestMigrationsDir(t *testing.T, files ...string) string {
+	dir, err := ioutil.TempDir("", "migrations")
+	assert.NoError(t, err)
+	for _, f := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, f), []byte("SELECT 1;"), 0644)
+		assert.NoError(t, err)
+	}
+	return dir
+}
+
+func TestPendingMigrationsNone(t *testing.T) {
+	tp, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+
+	pending, err := tp.PendingMigrations(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestPendingMigrationsAfterRollback(t *testing.T) {
+	tp, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+
+	m, err := tp.newMigrate(tp)
+	assert.NoError(t, err)
+	err = m.Steps(-2)
+	assert.NoError(t, err)
+
+	pending, err := tp.PendingMigrations(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, "000084_add_node_privacy_key", pending[1])
+}
+
+func TestPendingMigrationsDryRun(t *testing.T) {
+	config.Reset()
+	config.Set(config.DatabaseMigrationsDryRun, true)
+	defer config.Reset()
+	tp, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+
+	pending, err := tp.PendingMigrations(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "000001_create_messages_table", pending[0])
+	assert.Equal(t, "000084_add_node_privacy_key", pending[len(pending)-1])
+}
+
+func TestPendingMigrationsDriverFail(t *testing.T) {
+	mp, _ := newMockProvider().init()
+	mp.getMigrationDriverError = fmt.Errorf("pop")
+	_, err := mp.PendingMigrations(context.Background())
+	assert.Regexp(t, "FF10163.*pop", err)
+}
+
+func TestPendingMigrationsBadDir(t *testing.T) {
+	tp, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+
+	m, err := tp.newMigrate(tp)
+	assert.NoError(t, err)
+
+	tp.migrations = "file://../../../db/migrations/missing"
+	_, err = tp.PendingMigrations(context.Background())
+	assert.Regexp(t, "FF10163", err)
+	_, err = tp.pendingMigrations(context.Background(), m)
+	assert.Regexp(t, "FF10163", err)
+}
+
+type versionFailDriver struct {
+	migratedb.Driver
+}
+
+func (d *versionFailDriver) Version() (int, bool, error) {
+	return 0, false, fmt.Errorf("pop")
+}
+
+func TestPendingMigrationsVersionFail(t *testing.T) {
+	tp, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+
+	driver, err := tp.GetMigrationDriver(tp.db)
+	assert.NoError(t, err)
+	m, err := migrate.NewWithDatabaseInstance(tp.migrations, tp.MigrationsDir(), &versionFailDriver{driver})
+	assert.NoError(t, err)
+	_, err = tp.pendingMigrations(context.Background(), m)
+	assert.Regexp(t, "FF10163.*pop", err)
+}
+
+func TestPendingMigrationsMissingUp(t *testing.T) {
+	tp, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+
+	dir := newTestMigrationsDir(t, "000085_new_table.down.sql")
+	defer os.RemoveAll(dir)
+	tp.migrations = "file://" + dir
+	_, err := tp.PendingMigrations(context.Background())
+	assert.Regexp(t, "FF10163", err)
+}
+
+func TestApplyMigrationsSchemaNewer(t *testing.T) {
+	tp, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+
+	dir := newTestMigrationsDir(t, "000001_create_messages_table.up.sql", "000001_create_messages_table.down.sql")
+	defer os.RemoveAll(dir)
+	tp.migrations = "file://" + dir
+	err := tp.applyDBMigrations(context.Background(), tp)
+	assert.Regexp(t, "FF10416.*84.*1", err)
+}
+
+func TestApplyMigrationsUpFail(t *testing.T) {
+	tp, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+
+	dir := newTestMigrationsDir(t, "000085_new_table.up.sql")
+	defer os.RemoveAll(dir)
+	tp.migrations = "file://" + dir
+	err := tp.applyDBMigrations(context.Background(), tp)
+	assert.Regexp(t, "FF10163", err)
+}
+
 func TestQueryTxBadSQL(t *testing.T) {
 	tp, cleanup := newSQLiteTestProvider(t)
 	defer cleanup()
@@ -104,6 +286,22 @@ func TestInsertTxPostgreSQLReturnedSyntaxFail(t *testing.T) {
 	assert.Regexp(t, "FF10116", err)
 }
 
+func TestInsertTxPostgreSQLConflictNotObservedAsError(t *testing.T) {
+	s, mdb := newMockProvider().init()
+	cb := &databasemocks.Callbacks{}
+	s.SQLCommon.callbacks = cb
+	cb.On("ObserveLatency", mock.Anything, mock.Anything, nil).Return()
+	mdb.ExpectBegin()
+	mdb.ExpectQuery("INSERT.*").WillReturnError(fmt.Errorf("conflict"))
+	ctx, tx, _, err := s.beginOrUseTx(context.Background())
+	assert.NoError(t, err)
+	s.fakePSQLInsert = true
+	sb := sq.Insert("table").Columns("col1").Values(("val1"))
+	_, err = s.insertTxExt(ctx, tx, sb, nil, true)
+	assert.Regexp(t, "FF10116", err)
+	cb.AssertExpectations(t)
+}
+
 func TestInsertTxBadSQL(t *testing.T) {
 	s, _ := newMockProvider().init()
 	_, err := s.insertTx(context.Background(), nil, sq.InsertBuilder{}, nil)
@@ -120,7 +120,10 @@ func TestSubscriptionsE2EWithDB(t *testing.T) {
 
 	// Update
 	updateTime := fftypes.Now()
-	up := database.SubscriptionQueryFactory.NewUpdate(ctx).Set("created", updateTime)
+	up := database.SubscriptionQueryFactory.NewUpdate(ctx).
+		Set("created", updateTime).
+		Set("updated", updateTime).
+		Set("filter.events", "message_confirmed")
 	err = s.UpdateSubscription(ctx, subscriptionUpdated.Namespace, subscriptionUpdated.Name, up)
 	assert.NoError(t, err)
 
@@ -128,6 +131,8 @@ func TestSubscriptionsE2EWithDB(t *testing.T) {
 	filter = fb.And(
 		fb.Eq("name", subscriptionUpdated.Name),
 		fb.Eq("created", updateTime.String()),
+		fb.Eq("updated", updateTime.String()),
+		fb.Eq("filter.events", "message_confirmed"),
 	)
 	subscriptions, _, err := s.GetSubscriptions(ctx, filter)
 	assert.NoError(t, err)
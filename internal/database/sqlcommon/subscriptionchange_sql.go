@@ -0,0 +1,119 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	subscriptionChangeColumns = []string{
+		"id",
+		"subscription_id",
+		"namespace",
+		"name",
+		"change_type",
+		"def_before",
+		"def_after",
+		"created",
+	}
+	subscriptionChangeFilterFieldMap = map[string]string{
+		"subscription": "subscription_id",
+		"type":         "change_type",
+	}
+)
+
+func (s *SQLCommon) InsertSubscriptionChange(ctx context.Context, change *fftypes.SubscriptionChange) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	change.Created = fftypes.Now()
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("subscriptionchanges").
+			Columns(subscriptionChangeColumns...).
+			Values(
+				change.ID,
+				change.Subscription,
+				change.Namespace,
+				change.Name,
+				change.Type,
+				change.Before,
+				change.After,
+				change.Created,
+			),
+		func() {
+			s.callbacks.SubscriptionChangeEvent(change)
+		},
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) subscriptionChangeResult(ctx context.Context, row *sql.Rows) (*fftypes.SubscriptionChange, error) {
+	var change fftypes.SubscriptionChange
+	err := row.Scan(
+		&change.ID,
+		&change.Subscription,
+		&change.Namespace,
+		&change.Name,
+		&change.Type,
+		&change.Before,
+		&change.After,
+		&change.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "subscriptionchanges")
+	}
+	return &change, nil
+}
+
+func (s *SQLCommon) GetSubscriptionChanges(ctx context.Context, filter database.Filter) ([]*fftypes.SubscriptionChange, *database.FilterResult, error) {
+	query, fop, fi, err := s.filterSelect(ctx, "",
+		sq.Select(subscriptionChangeColumns...).From("subscriptionchanges"),
+		filter, subscriptionChangeFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	changes := []*fftypes.SubscriptionChange{}
+	for rows.Next() {
+		change, err := s.subscriptionChangeResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, s.queryRes(ctx, tx, "subscriptionchanges", fop, fi), err
+}
@@ -0,0 +1,133 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionChangeE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	before := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Name:      "sub1",
+		},
+		Transport: "websockets",
+		Created:   fftypes.Now(),
+	}
+	after := *before
+	after.Filter.Topics = "topic1"
+	after.Updated = fftypes.Now()
+	change := fftypes.NewSubscriptionChange(fftypes.ChangeEventTypeUpdated, before, &after)
+
+	s.callbacks.On("SubscriptionChangeEvent", change).Return()
+
+	err := s.InsertSubscriptionChange(ctx, change)
+	assert.NoError(t, err)
+	assert.NotNil(t, change.Created)
+	changeJson, _ := json.Marshal(&change)
+
+	// Query back the change
+	fb := database.SubscriptionChangeQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("subscription", before.ID),
+		fb.Eq("type", fftypes.ChangeEventTypeUpdated),
+	)
+	changes, res, err := s.GetSubscriptionChanges(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	changeReadJson, _ := json.Marshal(changes[0])
+	assert.Equal(t, string(changeJson), string(changeReadJson))
+
+	// A delete has no after document
+	change = fftypes.NewSubscriptionChange(fftypes.ChangeEventTypeDeleted, &after, nil)
+	s.callbacks.On("SubscriptionChangeEvent", change).Return()
+	err = s.InsertSubscriptionChange(ctx, change)
+	assert.NoError(t, err)
+	changes, _, err = s.GetSubscriptionChanges(ctx, fb.And(fb.Eq("id", change.ID)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Nil(t, changes[0].After)
+	assert.Equal(t, "topic1", changes[0].Before.Filter.Topics)
+}
+
+func TestInsertSubscriptionChangeFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertSubscriptionChange(context.Background(), &fftypes.SubscriptionChange{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertSubscriptionChangeFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertSubscriptionChange(context.Background(), &fftypes.SubscriptionChange{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertSubscriptionChangeFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertSubscriptionChange(context.Background(), &fftypes.SubscriptionChange{})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSubscriptionChangesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.SubscriptionChangeQueryFactory.NewFilter(context.Background()).Eq("name", "")
+	_, _, err := s.GetSubscriptionChanges(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSubscriptionChangesBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.SubscriptionChangeQueryFactory.NewFilter(context.Background()).Eq("name", map[bool]bool{true: false})
+	_, _, err := s.GetSubscriptionChanges(context.Background(), f)
+	assert.Regexp(t, "FF10149.*name", err)
+}
+
+func TestGetSubscriptionChangesScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.SubscriptionChangeQueryFactory.NewFilter(context.Background()).Eq("name", "")
+	_, _, err := s.GetSubscriptionChanges(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
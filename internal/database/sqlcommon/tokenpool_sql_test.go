@@ -171,6 +171,7 @@ func TestUpsertTokenPoolFailCommit(t *testing.T) {
 func TestUpsertTokenPoolUpdateIDMismatch(t *testing.T) {
 	s, db := newMockProvider().init()
 	callbacks := &databasemocks.Callbacks{}
+	callbacks.On("ObserveLatency", mock.Anything, mock.Anything, mock.Anything).Maybe()
 	s.SQLCommon.callbacks = callbacks
 	poolID := fftypes.NewUUID()
 	pool := &fftypes.TokenPool{
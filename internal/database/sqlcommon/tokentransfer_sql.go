@@ -43,6 +43,7 @@ var (
 		"protocol_id",
 		"message_id",
 		"message_hash",
+		"metadata_id",
 		"tx_type",
 		"tx_id",
 		"blockchain_event",
@@ -57,6 +58,7 @@ var (
 		"protocolid":      "protocol_id",
 		"message":         "message_id",
 		"messagehash":     "message_hash",
+		"metadata":        "metadata_id",
 		"tx.type":         "tx_type",
 		"tx.id":           "tx_id",
 		"blockchainevent": "blockchain_event",
@@ -97,6 +99,7 @@ func (s *SQLCommon) UpsertTokenTransfer(ctx context.Context, transfer *fftypes.T
 				Set("amount", transfer.Amount).
 				Set("message_id", transfer.Message).
 				Set("message_hash", transfer.MessageHash).
+				Set("metadata_id", transfer.Metadata).
 				Set("tx_type", transfer.TX.Type).
 				Set("tx_id", transfer.TX.ID).
 				Set("blockchain_event", transfer.BlockchainEvent).
@@ -127,6 +130,7 @@ func (s *SQLCommon) UpsertTokenTransfer(ctx context.Context, transfer *fftypes.T
 					transfer.ProtocolID,
 					transfer.Message,
 					transfer.MessageHash,
+					transfer.Metadata,
 					transfer.TX.Type,
 					transfer.TX.ID,
 					transfer.BlockchainEvent,
@@ -160,6 +164,7 @@ func (s *SQLCommon) tokenTransferResult(ctx context.Context, row *sql.Rows) (*ff
 		&transfer.ProtocolID,
 		&transfer.Message,
 		&transfer.MessageHash,
+		&transfer.Metadata,
 		&transfer.TX.Type,
 		&transfer.TX.ID,
 		&transfer.BlockchainEvent,
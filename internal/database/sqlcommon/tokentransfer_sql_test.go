@@ -48,6 +48,7 @@ func TestTokenTransferE2EWithDB(t *testing.T) {
 		ProtocolID:  "12345",
 		Message:     fftypes.NewUUID(),
 		MessageHash: fftypes.NewRandB32(),
+		Metadata:    fftypes.NewUUID(),
 		TX: fftypes.TransactionRef{
 			Type: fftypes.TransactionTypeTokenTransfer,
 			ID:   fftypes.NewUUID(),
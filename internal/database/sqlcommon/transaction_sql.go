@@ -34,10 +34,12 @@ var (
 		"namespace",
 		"created",
 		"blockchain_ids",
+		"idempotency_key",
 	}
 	transactionFilterFieldMap = map[string]string{
-		"type":          "ttype",
-		"blockchainids": "blockchain_ids",
+		"type":           "ttype",
+		"blockchainids":  "blockchain_ids",
+		"idempotencykey": "idempotency_key",
 	}
 )
 
@@ -48,8 +50,10 @@ func (s *SQLCommon) InsertTransaction(ctx context.Context, transaction *fftypes.
 	}
 	defer s.rollbackTx(ctx, tx, autoCommit)
 
+	// A conflict on the idempotency key must not abort the transaction of the caller, who needs to look up the
+	// existing transaction to report it
 	transaction.Created = fftypes.Now()
-	if _, err = s.insertTx(ctx, tx,
+	if _, err = s.insertTxExt(ctx, tx,
 		sq.Insert("transactions").
 			Columns(transactionColumns...).
 			Values(
@@ -58,10 +62,12 @@ func (s *SQLCommon) InsertTransaction(ctx context.Context, transaction *fftypes.
 				transaction.Namespace,
 				transaction.Created,
 				transaction.BlockchainIDs,
+				transaction.IdempotencyKey,
 			),
 		func() {
 			s.callbacks.UUIDCollectionNSEvent(database.CollectionTransactions, fftypes.ChangeEventTypeCreated, transaction.Namespace, transaction.ID)
 		},
+		transaction.IdempotencyKey != "",
 	); err != nil {
 		return err
 	}
@@ -77,6 +83,7 @@ func (s *SQLCommon) transactionResult(ctx context.Context, row *sql.Rows) (*ffty
 		&transaction.Namespace,
 		&transaction.Created,
 		&transaction.BlockchainIDs,
+		&transaction.IdempotencyKey,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "transactions")
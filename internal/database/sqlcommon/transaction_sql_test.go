@@ -38,13 +38,14 @@ func TestTransactionE2EWithDB(t *testing.T) {
 	// Create a new transaction entry
 	transactionID := fftypes.NewUUID()
 	transaction := &fftypes.Transaction{
-		ID:            transactionID,
-		Type:          fftypes.TransactionTypeBatchPin,
-		Namespace:     "ns1",
-		BlockchainIDs: fftypes.FFStringArray{"tx1"},
+		ID:             transactionID,
+		Type:           fftypes.TransactionTypeBatchPin,
+		Namespace:      "ns1",
+		IdempotencyKey: "retry1",
+		BlockchainIDs:  fftypes.FFStringArray{"tx1"},
 	}
 
-	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionTransactions, fftypes.ChangeEventTypeCreated, "ns1", transactionID, mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionTransactions, fftypes.ChangeEventTypeCreated, "ns1", mock.Anything, mock.Anything).Return()
 	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionTransactions, fftypes.ChangeEventTypeUpdated, "ns1", transactionID, mock.Anything).Return()
 
 	err := s.InsertTransaction(ctx, transaction)
@@ -94,6 +95,23 @@ func TestTransactionE2EWithDB(t *testing.T) {
 	transactions, _, err = s.GetTransactions(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(transactions))
+
+	// Find by idempotency key
+	filter = fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("idempotencykey", "retry1"),
+	)
+	transactions, _, err = s.GetTransactions(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(transactions))
+
+	// Transactions without a key do not conflict, but a re-used key does
+	err = s.InsertTransaction(ctx, &fftypes.Transaction{ID: fftypes.NewUUID(), Namespace: "ns1", Type: fftypes.TransactionTypeBatchPin})
+	assert.NoError(t, err)
+	err = s.InsertTransaction(ctx, &fftypes.Transaction{ID: fftypes.NewUUID(), Namespace: "ns1", Type: fftypes.TransactionTypeBatchPin})
+	assert.NoError(t, err)
+	err = s.InsertTransaction(ctx, &fftypes.Transaction{ID: fftypes.NewUUID(), Namespace: "ns1", Type: fftypes.TransactionTypeBatchPin, IdempotencyKey: "retry1"})
+	assert.Equal(t, database.DuplicateKey, err)
 }
 
 func TestInsertTransactionFailBegin(t *testing.T) {
@@ -115,6 +133,17 @@ func TestInsertTransactionFailInsert(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestInsertTransactionConflictEmptyResult(t *testing.T) {
+	s, mock := newMockProvider().init()
+	s.fakePSQLInsert = true
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT .*").WillReturnRows(sqlmock.NewRows([]string{sequenceColumn}))
+	mock.ExpectRollback()
+	err := s.InsertTransaction(context.Background(), &fftypes.Transaction{ID: fftypes.NewUUID(), IdempotencyKey: "retry1"})
+	assert.Equal(t, database.DuplicateKey, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestInsertTransactionFailCommit(t *testing.T) {
 	s, mock := newMockProvider().init()
 	transactionID := fftypes.NewUUID()
@@ -70,6 +70,10 @@ func (sqlite *SQLite3) Features() sqlcommon.SQLFeatures {
 	features := sqlcommon.DefaultSQLProviderFeatures()
 	features.PlaceholderFormat = sq.Dollar
 	features.UseILIKE = false // Not supported
+	features.IsUniqueViolation = func(err error) bool {
+		sqliteErr, ok := err.(sqlite3.Error)
+		return ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
 	return features
 }
 
@@ -21,12 +21,14 @@ package sqlite3
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/database/sqlcommon"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,6 +51,9 @@ func TestSQLite3GoProvider(t *testing.T) {
 
 	assert.Equal(t, "sqlite3", sqlite.Name())
 	assert.Equal(t, sq.Dollar, sqlite.Features().PlaceholderFormat)
+	assert.True(t, sqlite.Features().IsUniqueViolation(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}))
+	assert.False(t, sqlite.Features().IsUniqueViolation(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey}))
+	assert.False(t, sqlite.Features().IsUniqueViolation(fmt.Errorf("pop")))
 
 	insert := sq.Insert("test").Columns("col1").Values("val1")
 	insert, query := sqlite.ApplyInsertQueryCustomizations(insert, false)
@@ -26,10 +26,16 @@ const (
 	DataExchangeManifestEnabled = "manifestEnabled"
 	// DataExchangeInitEnabled instructs FireFly to always post all current nodes to the /init API before connecting or reconnecting to the connector
 	DataExchangeInitEnabled = "initEnabled"
+	// DataExchangeChunkSize is the chunk size requested for blob transfers, each of which is hashed and acknowledged individually by the connector
+	DataExchangeChunkSize = "chunkSize"
+	// DataExchangeResumeLimit is the number of times a partially delivered blob transfer will be resumed from the last acknowledged offset, before it is failed
+	DataExchangeResumeLimit = "resumeLimit"
 )
 
 func (h *FFDX) InitPrefix(prefix config.Prefix) {
 	wsconfig.InitPrefix(prefix)
 	prefix.AddKnownKey(DataExchangeManifestEnabled, false)
 	prefix.AddKnownKey(DataExchangeInitEnabled, false)
+	prefix.AddKnownKey(DataExchangeChunkSize)
+	prefix.AddKnownKey(DataExchangeResumeLimit, 5)
 }
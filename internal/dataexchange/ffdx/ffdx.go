@@ -46,6 +46,19 @@ type FFDX struct {
 	initialized  bool
 	initMutex    sync.Mutex
 	nodes        []fftypes.DXInfo
+	chunkSize    int64
+	resumeLimit  int
+	transferMux  sync.Mutex
+	transfers    map[string]*blobTransfer
+}
+
+// blobTransfer tracks the chunks of a blob transfer the recipient has acknowledged, so a transfer is only
+// resumed from the end of a chunk the recipient holds, and a chunk that is sent again must match the original
+type blobTransfer struct {
+	resumes  int
+	acked    int64
+	chunks   map[int64]string
+	lastHash string
 }
 
 type wsEvent struct {
@@ -60,6 +73,8 @@ type wsEvent struct {
 	Error     string             `json:"error"`
 	Manifest  string             `json:"manifest"`
 	Info      fftypes.JSONObject `json:"info"`
+	Offset    int64              `json:"offset"`
+	ChunkHash string             `json:"chunkHash"`
 }
 
 const (
@@ -78,6 +93,7 @@ const (
 	blobDelivered       msgType = "blob-delivered"
 	blobAcknowledged    msgType = "blob-acknowledged"
 	blobFailed          msgType = "blob-failed"
+	blobProgress        msgType = "blob-progress"
 )
 
 type responseWithRequestID struct {
@@ -100,6 +116,8 @@ type transferBlob struct {
 	Path      string `json:"path"`
 	Recipient string `json:"recipient"`
 	RequestID string `json:"requestId"`
+	ChunkSize int64  `json:"chunkSize,omitempty"`
+	Offset    int64  `json:"offset,omitempty"`
 }
 
 type wsAck struct {
@@ -116,7 +134,7 @@ func (h *FFDX) Name() string {
 }
 
 func (h *FFDX) Init(ctx context.Context, prefix config.Prefix, nodes []fftypes.DXInfo, callbacks dataexchange.Callbacks) (err error) {
-	h.ctx = log.WithLogField(ctx, "dx", "https")
+	h.ctx = log.WithLogField(log.WithModule(ctx, "dataexchange"), "dx", "https")
 	h.callbacks = callbacks
 
 	h.needsInit = prefix.GetBool(DataExchangeInitEnabled)
@@ -126,6 +144,9 @@ func (h *FFDX) Init(ctx context.Context, prefix config.Prefix, nodes []fftypes.D
 	}
 
 	h.nodes = nodes
+	h.chunkSize = prefix.GetByteSize(DataExchangeChunkSize)
+	h.resumeLimit = prefix.GetInt(DataExchangeResumeLimit)
+	h.transfers = make(map[string]*blobTransfer)
 
 	h.client = restclient.New(h.ctx, prefix)
 	h.capabilities = &dataexchange.Capabilities{
@@ -282,13 +303,18 @@ func (h *FFDX) TransferBLOB(ctx context.Context, opID *fftypes.UUID, peerID, pay
 		return err
 	}
 
+	return h.postTransfer(ctx, &transferBlob{
+		Path:      fmt.Sprintf("/%s", payloadRef),
+		Recipient: peerID,
+		RequestID: opID.String(),
+	})
+}
+
+func (h *FFDX) postTransfer(ctx context.Context, transfer *transferBlob) error {
+	transfer.ChunkSize = h.chunkSize
 	var responseData responseWithRequestID
 	res, err := h.client.R().SetContext(ctx).
-		SetBody(&transferBlob{
-			Path:      fmt.Sprintf("/%s", payloadRef),
-			Recipient: peerID,
-			RequestID: opID.String(),
-		}).
+		SetBody(transfer).
 		SetResult(&responseData).
 		Post("/api/v1/transfers")
 	if err != nil || !res.IsSuccess() {
@@ -297,6 +323,96 @@ func (h *FFDX) TransferBLOB(ctx context.Context, opID *fftypes.UUID, peerID, pay
 	return nil
 }
 
+// transferState returns the tracking for a blob transfer, which the caller must hold transferMux to use
+func (h *FFDX) transferState(requestID string) *blobTransfer {
+	bt, ok := h.transfers[requestID]
+	if !ok {
+		bt = &blobTransfer{chunks: make(map[int64]string)}
+		h.transfers[requestID] = bt
+	}
+	return bt
+}
+
+// chunkAcknowledged records a chunk the recipient has acknowledged, which ends at the offset of the event.
+// A chunk that is sent again after a resume must have the same hash as when it was first acknowledged.
+func (h *FFDX) chunkAcknowledged(ctx context.Context, msg *wsEvent) (info fftypes.JSONObject, err error) {
+	h.transferMux.Lock()
+	defer h.transferMux.Unlock()
+	bt := h.transferState(msg.RequestID)
+	if msg.ChunkHash != "" {
+		if previous, ok := bt.chunks[msg.Offset]; ok && previous != msg.ChunkHash {
+			return nil, i18n.NewError(ctx, i18n.MsgDXChunkHashMismatch, msg.Offset, previous, msg.ChunkHash)
+		}
+		bt.chunks[msg.Offset] = msg.ChunkHash
+		bt.lastHash = msg.ChunkHash
+		if msg.Offset > bt.acked {
+			bt.acked = msg.Offset
+		}
+	}
+	return bt.progressInfo(msg), nil
+}
+
+// resumeTransfer restarts a blob transfer that failed part way through, from the end of the last
+// chunk the recipient acknowledged. Returns nil if the transfer cannot be resumed, in which case
+// the failure should be reported as normal. Otherwise returns the progress of the transfer.
+func (h *FFDX) resumeTransfer(ctx context.Context, msg *wsEvent) fftypes.JSONObject {
+	if msg.Path == "" || msg.Recipient == "" {
+		return nil
+	}
+	h.transferMux.Lock()
+	bt := h.transferState(msg.RequestID)
+	if bt.acked <= 0 || bt.resumes >= h.resumeLimit {
+		h.transferMux.Unlock()
+		return nil
+	}
+	bt.resumes++
+	attempt := bt.resumes
+	resume := *msg
+	if _, ok := bt.chunks[msg.Offset]; !ok {
+		// Only the chunks the recipient acknowledged (with their hashes) are known to have been received
+		resume.Offset = bt.acked
+	}
+	info := bt.progressInfo(&resume)
+	info["resumes"] = attempt
+	info["interruption"] = msg.Error
+	h.transferMux.Unlock()
+
+	log.L(ctx).Infof("Resuming blob transfer %s from offset %d (attempt=%d): %s", msg.RequestID, resume.Offset, attempt, msg.Error)
+	err := h.postTransfer(ctx, &transferBlob{
+		Path:      msg.Path,
+		Recipient: msg.Recipient,
+		RequestID: msg.RequestID,
+		Offset:    resume.Offset,
+	})
+	if err != nil {
+		log.L(ctx).Errorf("Failed to resume blob transfer %s: %s", msg.RequestID, err)
+		return nil
+	}
+	return info
+}
+
+func (h *FFDX) transferComplete(requestID string) {
+	h.transferMux.Lock()
+	defer h.transferMux.Unlock()
+	delete(h.transfers, requestID)
+}
+
+func (bt *blobTransfer) progressInfo(msg *wsEvent) fftypes.JSONObject {
+	info := fftypes.JSONObject{}
+	for k, v := range msg.Info {
+		info[k] = v
+	}
+	info["offset"] = msg.Offset
+	if msg.Size > 0 {
+		info["size"] = msg.Size
+	}
+	info["chunks"] = len(bt.chunks)
+	if bt.lastHash != "" {
+		info["chunkHash"] = bt.lastHash
+	}
+	return info
+}
+
 func (h *FFDX) CheckBLOBReceived(ctx context.Context, peerID, ns string, id fftypes.UUID) (hash *fftypes.Bytes32, size int64, err error) {
 	var responseData responseWithRequestID
 	res, err := h.client.R().SetContext(ctx).
@@ -367,11 +483,35 @@ func (h *FFDX) eventLoop() {
 			case messageReceived:
 				manifest, err = h.callbacks.MessageReceived(msg.Sender, []byte(msg.Message))
 			case blobFailed:
-				err = h.callbacks.TransferResult(msg.RequestID, fftypes.OpStatusFailed, fftypes.TransportStatusUpdate{
-					Error: msg.Error,
-					Info:  msg.Info,
-				})
+				if info := h.resumeTransfer(ctx, &msg); info != nil {
+					// The transfer remains in-flight, so the interruption is recorded with the progress,
+					// rather than as an error on the operation
+					err = h.callbacks.TransferResult(msg.RequestID, fftypes.OpStatusPending, fftypes.TransportStatusUpdate{
+						Info: info,
+					})
+				} else {
+					h.transferComplete(msg.RequestID)
+					err = h.callbacks.TransferResult(msg.RequestID, fftypes.OpStatusFailed, fftypes.TransportStatusUpdate{
+						Error: msg.Error,
+						Info:  msg.Info,
+					})
+				}
+			case blobProgress:
+				info, chunkErr := h.chunkAcknowledged(ctx, &msg)
+				if chunkErr != nil {
+					l.Errorf("Blob transfer %s failed: %s", msg.RequestID, chunkErr)
+					h.transferComplete(msg.RequestID)
+					err = h.callbacks.TransferResult(msg.RequestID, fftypes.OpStatusFailed, fftypes.TransportStatusUpdate{
+						Error: chunkErr.Error(),
+						Info:  msg.Info,
+					})
+				} else {
+					err = h.callbacks.TransferResult(msg.RequestID, fftypes.OpStatusPending, fftypes.TransportStatusUpdate{
+						Info: info,
+					})
+				}
 			case blobDelivered:
+				h.transferComplete(msg.RequestID)
 				status := fftypes.OpStatusSucceeded
 				if h.capabilities.Manifest {
 					status = fftypes.OpStatusPending
@@ -389,6 +529,7 @@ func (h *FFDX) eventLoop() {
 					err = h.callbacks.BLOBReceived(msg.Sender, *hash, msg.Size, msg.Path)
 				}
 			case blobAcknowledged:
+				h.transferComplete(msg.RequestID)
 				err = h.callbacks.TransferResult(msg.RequestID, fftypes.OpStatusSucceeded, fftypes.TransportStatusUpdate{
 					Hash: msg.Hash,
 					Info: msg.Info,
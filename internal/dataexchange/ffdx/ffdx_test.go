@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"regexp"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
@@ -395,6 +396,27 @@ func TestTransferBLOBError(t *testing.T) {
 	assert.Regexp(t, "FF10229", err)
 }
 
+func TestTransferBLOBChunked(t *testing.T) {
+
+	h, _, _, httpURL, done := newTestFFDX(t, false)
+	defer done()
+	h.chunkSize = 1024 * 1024
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/transfers", httpURL),
+		func(req *http.Request) (*http.Response, error) {
+			var body transferBlob
+			err := json.NewDecoder(req.Body).Decode(&body)
+			assert.NoError(t, err)
+			assert.Equal(t, "/ns1/id1", body.Path)
+			assert.Equal(t, int64(1024*1024), body.ChunkSize)
+			assert.Zero(t, body.Offset)
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{})(req)
+		})
+
+	err := h.TransferBLOB(context.Background(), fftypes.NewUUID(), "peer1", "ns1/id1")
+	assert.NoError(t, err)
+}
+
 func TestEvents(t *testing.T) {
 
 	h, toServer, fromServer, _, done := newTestFFDX(t, false)
@@ -501,6 +523,132 @@ func TestEventsWithManifest(t *testing.T) {
 	mcb.AssertExpectations(t)
 }
 
+func TestEventsBlobProgressAndResume(t *testing.T) {
+
+	h, toServer, fromServer, httpURL, done := newTestFFDX(t, false)
+	defer done()
+	h.resumeLimit = 1
+
+	resumed := make(chan transferBlob, 1)
+	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/transfers", httpURL),
+		func(req *http.Request) (*http.Response, error) {
+			var body transferBlob
+			err := json.NewDecoder(req.Body).Decode(&body)
+			assert.NoError(t, err)
+			resumed <- body
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{})(req)
+		})
+
+	err := h.Start()
+	assert.NoError(t, err)
+
+	mcb := h.callbacks.(*dataexchangemocks.Callbacks)
+
+	mcb.On("TransferResult", "tx12345", fftypes.OpStatusPending, mock.MatchedBy(func(ts fftypes.TransportStatusUpdate) bool {
+		return ts.Error == "" && ts.Info.String() == `{"chunkHash":"abcd","chunks":1,"offset":1024,"peer":"peer1","size":4096}`
+	})).Return(nil).Once()
+	fromServer <- `{"type":"blob-progress","requestID":"tx12345","offset":1024,"size":4096,"chunkHash":"abcd","info":{"peer":"peer1"}}`
+	msg := <-toServer
+	assert.Equal(t, `{"action":"commit"}`, string(msg))
+
+	// A failure part way through is resumed from the end of the last chunk that was acknowledged,
+	// and is recorded in the progress of the operation rather than as an error
+	mcb.On("TransferResult", "tx12345", fftypes.OpStatusPending, mock.MatchedBy(func(ts fftypes.TransportStatusUpdate) bool {
+		return ts.Error == "" && ts.Info.String() == `{"chunkHash":"abcd","chunks":1,"interruption":"blip","offset":1024,"resumes":1}`
+	})).Return(nil).Once()
+	fromServer <- `{"type":"blob-failed","requestID":"tx12345","path":"/ns1/id1","recipient":"peer1","offset":2048,"error":"blip"}`
+	msg = <-toServer
+	assert.Equal(t, `{"action":"commit"}`, string(msg))
+	body := <-resumed
+	assert.Equal(t, "/ns1/id1", body.Path)
+	assert.Equal(t, "peer1", body.Recipient)
+	assert.Equal(t, "tx12345", body.RequestID)
+	assert.Equal(t, int64(1024), body.Offset)
+
+	// A chunk that is acknowledged again must have the same hash
+	mcb.On("TransferResult", "tx12345", fftypes.OpStatusPending, mock.MatchedBy(func(ts fftypes.TransportStatusUpdate) bool {
+		return ts.Error == "" && ts.Info["offset"] == int64(1024)
+	})).Return(nil).Once()
+	fromServer <- `{"type":"blob-progress","requestID":"tx12345","offset":1024,"size":4096,"chunkHash":"abcd"}`
+	msg = <-toServer
+	assert.Equal(t, `{"action":"commit"}`, string(msg))
+	mcb.On("TransferResult", "tx12345", fftypes.OpStatusFailed, mock.MatchedBy(func(ts fftypes.TransportStatusUpdate) bool {
+		return regexp.MustCompile("FF10524.*abcd.*ef01").MatchString(ts.Error)
+	})).Return(nil).Once()
+	fromServer <- `{"type":"blob-progress","requestID":"tx12345","offset":1024,"size":4096,"chunkHash":"ef01"}`
+	msg = <-toServer
+	assert.Equal(t, `{"action":"commit"}`, string(msg))
+	assert.Empty(t, h.transfers)
+
+	mcb.AssertExpectations(t)
+}
+
+func TestEventsBlobResumeLimit(t *testing.T) {
+
+	h, toServer, fromServer, httpURL, done := newTestFFDX(t, false)
+	defer done()
+	h.resumeLimit = 1
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/transfers", httpURL),
+		func(req *http.Request) (*http.Response, error) {
+			var body transferBlob
+			err := json.NewDecoder(req.Body).Decode(&body)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2048), body.Offset)
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{})(req)
+		})
+
+	err := h.Start()
+	assert.NoError(t, err)
+
+	mcb := h.callbacks.(*dataexchangemocks.Callbacks)
+	mcb.On("TransferResult", "tx12345", fftypes.OpStatusPending, mock.Anything).Return(nil).Times(3)
+	fromServer <- `{"type":"blob-progress","requestID":"tx12345","offset":1024,"chunkHash":"abcd"}`
+	<-toServer
+	fromServer <- `{"type":"blob-progress","requestID":"tx12345","offset":2048,"chunkHash":"ef01"}`
+	<-toServer
+
+	// The failure is resumed from the acknowledged chunk it reports
+	fromServer <- `{"type":"blob-failed","requestID":"tx12345","path":"/ns1/id1","recipient":"peer1","offset":2048,"error":"blip"}`
+	msg := <-toServer
+	assert.Equal(t, `{"action":"commit"}`, string(msg))
+
+	// Second failure exceeds the resume limit
+	mcb.On("TransferResult", "tx12345", fftypes.OpStatusFailed, mock.MatchedBy(func(ts fftypes.TransportStatusUpdate) bool {
+		return ts.Error == "blip"
+	})).Return(nil).Once()
+	fromServer <- `{"type":"blob-failed","requestID":"tx12345","path":"/ns1/id1","recipient":"peer1","offset":3072,"error":"blip"}`
+	msg = <-toServer
+	assert.Equal(t, `{"action":"commit"}`, string(msg))
+	assert.Empty(t, h.transfers)
+
+	mcb.AssertExpectations(t)
+}
+
+func TestResumeTransferFail(t *testing.T) {
+
+	h, _, _, httpURL, done := newTestFFDX(t, false)
+	defer done()
+	h.resumeLimit = 1
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/transfers", httpURL),
+		httpmock.NewJsonResponderOrPanic(500, fftypes.JSONObject{}))
+
+	failed := &wsEvent{
+		RequestID: "tx12345",
+		Path:      "/ns1/id1",
+		Recipient: "peer1",
+		Offset:    1024,
+	}
+	// Nothing has been acknowledged, so there is nothing to resume from
+	assert.Nil(t, h.resumeTransfer(context.Background(), failed))
+	assert.Nil(t, h.resumeTransfer(context.Background(), &wsEvent{RequestID: "tx12345"}))
+
+	_, err := h.chunkAcknowledged(context.Background(), &wsEvent{RequestID: "tx12345", Offset: 1024, ChunkHash: "abcd"})
+	assert.NoError(t, err)
+	assert.Nil(t, h.resumeTransfer(context.Background(), failed))
+}
+
 func TestEventLoopReceiveClosed(t *testing.T) {
 	dxc := &dataexchangemocks.Callbacks{}
 	wsm := &wsmocks.WSClient{}
@@ -24,6 +24,7 @@ import (
 	"github.com/hyperledger/firefly/internal/broadcast"
 	"github.com/hyperledger/firefly/internal/contracts"
 	"github.com/hyperledger/firefly/internal/data"
+	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/privatemessaging"
 	"github.com/hyperledger/firefly/pkg/database"
@@ -70,17 +71,19 @@ type definitionHandlers struct {
 	database  database.Plugin
 	exchange  dataexchange.Plugin
 	data      data.Manager
+	identity  identity.Manager
 	broadcast broadcast.Manager
 	messaging privatemessaging.Manager
 	assets    assets.Manager
 	contracts contracts.Manager
 }
 
-func NewDefinitionHandlers(di database.Plugin, dx dataexchange.Plugin, dm data.Manager, bm broadcast.Manager, pm privatemessaging.Manager, am assets.Manager, cm contracts.Manager) DefinitionHandlers {
+func NewDefinitionHandlers(di database.Plugin, dx dataexchange.Plugin, dm data.Manager, im identity.Manager, bm broadcast.Manager, pm privatemessaging.Manager, am assets.Manager, cm contracts.Manager) DefinitionHandlers {
 	return &definitionHandlers{
 		database:  di,
 		exchange:  dx,
 		data:      dm,
+		identity:  im,
 		broadcast: bm,
 		messaging: pm,
 		assets:    am,
@@ -92,6 +95,10 @@ func (dh *definitionHandlers) GetGroupByID(ctx context.Context, id string) (*fft
 	return dh.messaging.GetGroupByID(ctx, id)
 }
 
+func (dh *definitionHandlers) GetGroupMembershipChanges(ctx context.Context, ns, groupHash string, filter database.AndFilter) ([]*fftypes.GroupMembershipChange, *database.FilterResult, error) {
+	return dh.messaging.GetGroupMembershipChanges(ctx, ns, groupHash, filter)
+}
+
 func (dh *definitionHandlers) GetGroupsNS(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error) {
 	return dh.messaging.GetGroupsNS(ctx, ns, filter)
 }
@@ -116,6 +123,8 @@ func (dh *definitionHandlers) HandleDefinitionBroadcast(ctx context.Context, msg
 		return dh.handleOrganizationBroadcast(ctx, msg, data)
 	case fftypes.SystemTagDefineNode:
 		return dh.handleNodeBroadcast(ctx, msg, data)
+	case fftypes.SystemTagRotateOrgKey:
+		return dh.handleKeyRotationBroadcast(ctx, msg, data, tx)
 	case fftypes.SystemTagDefinePool:
 		return dh.handleTokenPoolBroadcast(ctx, msg, data)
 	case fftypes.SystemTagDefineFFI:
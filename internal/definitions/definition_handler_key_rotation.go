@@ -0,0 +1,124 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (dh *definitionHandlers) handleKeyRotationBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data, tx *fftypes.UUID) (DefinitionMessageAction, *DefinitionBatchActions, error) {
+	l := log.L(ctx)
+
+	var rotation fftypes.KeyRotation
+	valid := dh.getSystemBroadcastPayload(ctx, msg, data, &rotation)
+	if !valid {
+		return ActionReject, nil, nil
+	}
+
+	if err := rotation.Validate(ctx, true); err != nil {
+		l.Warnf("Unable to process key rotation broadcast %s - validate failed: %s", msg.Header.ID, err)
+		return ActionReject, nil, nil
+	}
+
+	org, err := dh.database.GetOrganizationByID(ctx, rotation.Organization)
+	if err != nil {
+		return ActionRetry, nil, err // We only return database errors
+	}
+	if org == nil {
+		l.Warnf("Unable to process key rotation broadcast %s - organization not found: %s", msg.Header.ID, rotation.Organization)
+		return ActionReject, nil, nil
+	}
+	if org.Identity != rotation.PreviousKey {
+		l.Warnf("Unable to process key rotation broadcast %s - previous key mismatch. Expected=%s Received=%s", msg.Header.ID, org.Identity, rotation.PreviousKey)
+		return ActionReject, nil, nil
+	}
+
+	// Like the organization definition itself, the rotation is signed by the parent if there is one
+	signingKey := org.Identity
+	if org.Parent != "" {
+		signingKey = org.Parent
+	}
+	if msg.Header.Key != signingKey {
+		l.Warnf("Unable to process key rotation broadcast %s - incorrect signature. Expected=%s Received=%s", msg.Header.ID, signingKey, msg.Header.Key)
+		return ActionReject, nil, nil
+	}
+
+	existing, err := dh.database.GetOrganizationByIdentity(ctx, rotation.NewKey)
+	if err != nil {
+		return ActionRetry, nil, err // We only return database errors
+	}
+	if existing != nil {
+		l.Warnf("Unable to process key rotation broadcast %s - key %s already in use by %v", msg.Header.ID, rotation.NewKey, existing.ID)
+		return ActionReject, nil, nil
+	}
+
+	// The grace period for the previous key runs from the time the rotation was pinned on the blockchain,
+	// so every node makes the same decision about batches signed with the previous key
+	fb := database.BlockchainEventQueryFactory.NewFilter(ctx)
+	events, _, err := dh.database.GetBlockchainEvents(ctx, fb.And(fb.Eq("tx.id", tx)).Limit(1))
+	if err != nil {
+		return ActionRetry, nil, err // We only return database errors
+	}
+	if len(events) == 0 {
+		l.Warnf("Unable to process key rotation broadcast %s - no blockchain event for transaction %s", msg.Header.ID, tx)
+		return ActionReject, nil, nil
+	}
+
+	org.PreviousIdentity = org.Identity
+	org.Identity = rotation.NewKey
+	org.IdentityRotated = events[0].Timestamp
+	if err = dh.database.UpdateOrganization(ctx, org.ID, database.OrganizationQueryFactory.NewUpdate(ctx).
+		Set("identity", org.Identity).
+		Set("previousidentity", org.PreviousIdentity).
+		Set("identityrotated", org.IdentityRotated)); err != nil {
+		return ActionRetry, nil, err
+	}
+
+	// Move the nodes and child organizations that were registered against the previous key
+	nfb := database.NodeQueryFactory.NewFilter(ctx)
+	nodes, _, err := dh.database.GetNodes(ctx, nfb.And(nfb.Eq("owner", org.PreviousIdentity)))
+	if err != nil {
+		return ActionRetry, nil, err
+	}
+	for _, node := range nodes {
+		if err = dh.database.UpdateNode(ctx, node.ID, database.NodeQueryFactory.NewUpdate(ctx).Set("owner", org.Identity)); err != nil {
+			return ActionRetry, nil, err
+		}
+	}
+	ofb := database.OrganizationQueryFactory.NewFilter(ctx)
+	children, _, err := dh.database.GetOrganizations(ctx, ofb.And(ofb.Eq("parent", org.PreviousIdentity)))
+	if err != nil {
+		return ActionRetry, nil, err
+	}
+	for _, child := range children {
+		if err = dh.database.UpdateOrganization(ctx, child.ID, database.OrganizationQueryFactory.NewUpdate(ctx).Set("parent", org.Identity)); err != nil {
+			return ActionRetry, nil, err
+		}
+	}
+
+	l.Infof("Signing key of organization %s rotated from %s to %s", org.ID, org.PreviousIdentity, org.Identity)
+	return ActionConfirm, &DefinitionBatchActions{
+		Finalize: func(ctx context.Context) error {
+			dh.identity.OrgKeyRotated(org)
+			return nil
+		},
+	}, nil
+}
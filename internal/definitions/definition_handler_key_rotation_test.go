@@ -0,0 +1,311 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestKeyRotation(t *testing.T) (*fftypes.Organization, *fftypes.KeyRotation, *fftypes.Message, []*fftypes.Data) {
+	org := &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org1",
+		Identity: "0x12345",
+	}
+	rotation := &fftypes.KeyRotation{
+		ID:           fftypes.NewUUID(),
+		Organization: org.ID,
+		PreviousKey:  "0x12345",
+		NewKey:       "0x23456",
+	}
+	b, err := json.Marshal(&rotation)
+	assert.NoError(t, err)
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: fftypes.SystemNamespace,
+			Identity: fftypes.Identity{
+				Author: org.GetDID(),
+				Key:    "0x12345",
+			},
+			Tag: string(fftypes.SystemTagRotateOrgKey),
+		},
+	}
+	return org, rotation, msg, []*fftypes.Data{{Value: fftypes.JSONAnyPtrBytes(b)}}
+}
+
+func TestHandleKeyRotationBroadcastOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+	node := &fftypes.Node{ID: fftypes.NewUUID(), Owner: "0x12345"}
+	child := &fftypes.Organization{ID: fftypes.NewUUID(), Parent: "0x12345"}
+	pinned := fftypes.UnixTime(1000000)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{{Timestamp: pinned}}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, org.ID, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+	mdi.On("UpdateNode", mock.Anything, node.ID, mock.Anything).Return(nil)
+	mdi.On("GetOrganizations", mock.Anything, mock.Anything).Return([]*fftypes.Organization{child}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, child.ID, mock.Anything).Return(nil)
+	mim := dh.identity.(*identitymanagermocks.Manager)
+	mim.On("OrgKeyRotated", mock.MatchedBy(func(o *fftypes.Organization) bool {
+		return o.Identity == "0x23456" && o.PreviousIdentity == "0x12345" && o.IdentityRotated == pinned
+	})).Return()
+
+	action, ba, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+	err = ba.Finalize(context.Background())
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastBadPayload(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	_, _, msg, _ := newTestKeyRotation(t)
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, []*fftypes.Data{}, fftypes.NewUUID())
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleKeyRotationBroadcastValidateFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	_, _, msg, _ := newTestKeyRotation(t)
+	b, err := json.Marshal(&fftypes.KeyRotation{NewKey: "0x23456"})
+	assert.NoError(t, err)
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, []*fftypes.Data{{Value: fftypes.JSONAnyPtrBytes(b)}}, fftypes.NewUUID())
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleKeyRotationBroadcastGetOrgFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, fmt.Errorf("pop"))
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionRetry, action)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastOrgNotFound(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, nil)
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastPreviousKeyMismatch(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+	org.Identity = "0x34567"
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastChildOrgBadSignature(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+	org.Parent = "0x34567"
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastNewKeyLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, fmt.Errorf("pop"))
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionRetry, action)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastNewKeyInUse(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{ID: fftypes.NewUUID()}, nil)
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastGetEventsFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionRetry, action)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastNoEvent(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{}, nil, nil)
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastUpdateOrgFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{{Timestamp: fftypes.Now()}}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, org.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionRetry, action)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastGetNodesFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{{Timestamp: fftypes.Now()}}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, org.ID, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionRetry, action)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastUpdateNodeFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+	node := &fftypes.Node{ID: fftypes.NewUUID(), Owner: "0x12345"}
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{{Timestamp: fftypes.Now()}}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, org.ID, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+	mdi.On("UpdateNode", mock.Anything, node.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionRetry, action)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastGetChildOrgsFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{{Timestamp: fftypes.Now()}}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, org.ID, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	mdi.On("GetOrganizations", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionRetry, action)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleKeyRotationBroadcastUpdateChildOrgFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+	org, _, msg, data := newTestKeyRotation(t)
+	child := &fftypes.Organization{ID: fftypes.NewUUID(), Parent: "0x12345"}
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{{Timestamp: fftypes.Now()}}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, org.ID, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	mdi.On("GetOrganizations", mock.Anything, mock.Anything).Return([]*fftypes.Organization{child}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, child.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	action, _, err := dh.HandleDefinitionBroadcast(context.Background(), msg, data, fftypes.NewUUID())
+	assert.Equal(t, ActionRetry, action)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
@@ -37,6 +37,16 @@ func (dh *definitionHandlers) handleNodeBroadcast(ctx context.Context, msg *ffty
 		return ActionReject, nil, nil
 	}
 
+	if err := node.DX.VerifyCertPin(ctx); err != nil {
+		l.Warnf("Unable to process node broadcast %s - certificate pin failed: %s", msg.Header.ID, err)
+		return ActionReject, &DefinitionBatchActions{
+			Finalize: func(ctx context.Context) error {
+				event := fftypes.NewEvent(fftypes.EventTypeNodeCertPinMismatch, fftypes.SystemNamespace, node.ID, nil)
+				return dh.database.InsertEvent(ctx, event)
+			},
+		}, nil
+	}
+
 	owner, err := dh.database.GetOrganizationByIdentity(ctx, node.Owner)
 	if err != nil {
 		return ActionRetry, nil, err // We only return database errors
@@ -19,6 +19,7 @@ package definitions
 import (
 	"context"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"testing"
 
@@ -439,3 +440,50 @@ func TestHandleDefinitionBroadcastNodeUnmarshalFail(t *testing.T) {
 	assert.Equal(t, ActionReject, action)
 	assert.NoError(t, err)
 }
+
+func TestHandleDefinitionBroadcastNodeCertPinMismatch(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	node := &fftypes.Node{
+		ID:          fftypes.NewUUID(),
+		Name:        "node1",
+		Owner:       "0x23456",
+		Description: "my org",
+		DX: fftypes.DXInfo{
+			Peer: "peer1",
+			Endpoint: fftypes.JSONObject{
+				"cert": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("intercepted")})),
+			},
+			CertPin: "0123456789abcdef",
+		},
+	}
+	b, err := json.Marshal(&node)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.JSONAnyPtrBytes(b),
+	}
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeNodeCertPinMismatch &&
+			event.Namespace == fftypes.SystemNamespace &&
+			*event.Reference == *node.ID
+	})).Return(nil)
+	action, ba, err := dh.HandleDefinitionBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Identity: fftypes.Identity{
+				Author: "did:firefly:org/0x23456",
+				Key:    "0x23456",
+			},
+			Tag: string(fftypes.SystemTagDefineNode),
+		},
+	}, []*fftypes.Data{data}, fftypes.NewUUID())
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	err = ba.Finalize(context.Background())
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
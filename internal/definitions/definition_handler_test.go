@@ -26,6 +26,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -36,11 +37,12 @@ func newTestDefinitionHandlers(t *testing.T) *definitionHandlers {
 	mdi := &databasemocks.Plugin{}
 	mdx := &dataexchangemocks.Plugin{}
 	mdm := &datamocks.Manager{}
+	mim := &identitymanagermocks.Manager{}
 	mbm := &broadcastmocks.Manager{}
 	mpm := &privatemessagingmocks.Manager{}
 	mam := &assetmocks.Manager{}
 	mcm := &contractmocks.Manager{}
-	return NewDefinitionHandlers(mdi, mdx, mdm, mbm, mpm, mam, mcm).(*definitionHandlers)
+	return NewDefinitionHandlers(mdi, mdx, mdm, mim, mbm, mpm, mam, mcm).(*definitionHandlers)
 }
 
 func TestHandleDefinitionBroadcastUnknown(t *testing.T) {
@@ -81,11 +83,13 @@ func TestPrivateMessagingPassthroughs(t *testing.T) {
 	mpm := dh.messaging.(*privatemessagingmocks.Manager)
 	mpm.On("GetGroupByID", ctx, mock.Anything).Return(nil, nil)
 	mpm.On("GetGroupsNS", ctx, "ns1", mock.Anything).Return(nil, nil, nil)
+	mpm.On("GetGroupMembershipChanges", ctx, "ns1", "hash1", mock.Anything).Return(nil, nil, nil)
 	mpm.On("ResolveInitGroup", ctx, mock.Anything).Return(nil, nil)
 	mpm.On("EnsureLocalGroup", ctx, mock.Anything).Return(false, nil)
 
 	_, _ = dh.GetGroupByID(ctx, fftypes.NewUUID().String())
 	_, _, _ = dh.GetGroupsNS(ctx, "ns1", nil)
+	_, _, _ = dh.GetGroupMembershipChanges(ctx, "ns1", "hash1", nil)
 	_, _ = dh.ResolveInitGroup(ctx, nil)
 	_, _ = dh.EnsureLocalGroup(ctx, nil)
 
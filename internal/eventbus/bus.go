@@ -0,0 +1,104 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventbus
+
+import (
+	"sync"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Topic identifies a class of notification published on the bus
+type Topic string
+
+const (
+	// TopicMessageCreated is published with the int64 sequence of each new message
+	TopicMessageCreated Topic = "message_created"
+	// TopicEventCreated is published with the int64 sequence of each new event
+	TopicEventCreated Topic = "event_created"
+	// TopicPinCreated is published with the int64 sequence of each new pin
+	TopicPinCreated Topic = "pin_created"
+	// TopicSubscriptionCreated is published with the *fftypes.UUID of each new subscription
+	TopicSubscriptionCreated Topic = "subscription_created"
+	// TopicSubscriptionUpdated is published with the *fftypes.UUID of each updated subscription
+	TopicSubscriptionUpdated Topic = "subscription_updated"
+	// TopicSubscriptionDeleted is published with the *fftypes.UUID of each deleted subscription
+	TopicSubscriptionDeleted Topic = "subscription_deleted"
+	// TopicOffsetCommitted is published with a *fftypes.Offset each time a durable poller moves its offset
+	TopicOffsetCommitted Topic = "offset_committed"
+	// TopicOperationUpdated is published with an *OperationUpdate each time an operation is resolved
+	TopicOperationUpdated Topic = "operation_updated"
+	// TopicMessageStateChanged is published with a *MessageStateChange each time messages move state without an event
+	TopicMessageStateChanged Topic = "message_state_changed"
+	// TopicLoadSheddingChanged is published with the new loadshed.Level each time the level of load shedding changes
+	TopicLoadSheddingChanged Topic = "load_shedding_changed"
+)
+
+// OperationUpdate is the payload of TopicOperationUpdated
+type OperationUpdate struct {
+	ID        *fftypes.UUID
+	Namespace string
+	Type      fftypes.OpType
+	Status    fftypes.OpStatus
+}
+
+// MessageStateChange is the payload of TopicMessageStateChanged
+type MessageStateChange struct {
+	Namespace string
+	Messages  []*fftypes.UUID
+	State     fftypes.MessageState
+}
+
+// Listener is called with the payload of each notification published on a subscribed topic
+type Listener func(payload interface{})
+
+// Bus is a lightweight in-process publish/subscribe bus, used by the components of a single node
+// to notify each other of changes without needing direct references to each other.
+//
+// Listeners are called synchronously on the publishing goroutine, in the order they subscribed.
+// So a listener must not block for longer than the publisher is prepared to wait (handing off to
+// a buffered channel is the typical pattern), and must not publish to the bus itself.
+type Bus interface {
+	Subscribe(topic Topic, listener Listener)
+	Publish(topic Topic, payload interface{})
+}
+
+type bus struct {
+	mux       sync.RWMutex
+	listeners map[Topic][]Listener
+}
+
+func NewBus() Bus {
+	return &bus{
+		listeners: make(map[Topic][]Listener),
+	}
+}
+
+func (b *bus) Subscribe(topic Topic, listener Listener) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.listeners[topic] = append(b.listeners[topic], listener)
+}
+
+func (b *bus) Publish(topic Topic, payload interface{}) {
+	b.mux.RLock()
+	listeners := b.listeners[topic]
+	b.mux.RUnlock()
+	for _, listener := range listeners {
+		listener(payload)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishInSubscriptionOrder(t *testing.T) {
+	b := NewBus()
+	var received []string
+	b.Subscribe(TopicMessageCreated, func(payload interface{}) {
+		received = append(received, "first")
+		assert.Equal(t, int64(12345), payload)
+	})
+	b.Subscribe(TopicMessageCreated, func(payload interface{}) {
+		received = append(received, "second")
+	})
+	b.Subscribe(TopicEventCreated, func(payload interface{}) {
+		received = append(received, "other")
+	})
+	b.Publish(TopicMessageCreated, int64(12345))
+	assert.Equal(t, []string{"first", "second"}, received)
+}
+
+func TestPublishNoListeners(t *testing.T) {
+	b := NewBus()
+	b.Publish(TopicPinCreated, int64(12345))
+}
@@ -20,15 +20,21 @@ import (
 	"context"
 	"crypto/sha256"
 	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/definitions"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/retry"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -37,6 +43,7 @@ const (
 
 type aggregator struct {
 	ctx             context.Context
+	ledger          string
 	database        database.Plugin
 	definitions     definitions.DefinitionHandlers
 	data            data.Manager
@@ -46,12 +53,28 @@ type aggregator struct {
 	queuedRewinds   chan *fftypes.UUID
 	retry           *retry.Retry
 	metrics         metrics.Manager
+	rewindMux       sync.Mutex
+	pendingRewind   *int64
 }
 
-func newAggregator(ctx context.Context, di database.Plugin, sh definitions.DefinitionHandlers, dm data.Manager, en *eventNotifier, mm metrics.Manager) *aggregator {
+// ledgerOffsetName returns the name of the checkpoint for the aggregator of the pins from a ledger, with
+// the default ledger keeping its original checkpoint name
+func ledgerOffsetName(ledger string) string {
+	if ledger == "" {
+		return aggregatorOffsetName
+	}
+	return fmt.Sprintf("%s_%s", aggregatorOffsetName, ledger)
+}
+
+func newAggregator(ctx context.Context, di database.Plugin, sh definitions.DefinitionHandlers, dm data.Manager, en *eventNotifier, eb eventbus.Bus, mm metrics.Manager, ledger string) *aggregator {
 	batchSize := config.GetInt(config.EventAggregatorBatchSize)
+	role := "aggregator"
+	if ledger != "" {
+		role = fmt.Sprintf("aggregator[%s]", ledger)
+	}
 	ag := &aggregator{
-		ctx:             log.WithLogField(ctx, "role", "aggregator"),
+		ctx:             log.WithLogField(log.WithModule(ctx, "aggregator"), "role", role),
+		ledger:          ledger,
 		database:        di,
 		definitions:     sh,
 		data:            dm,
@@ -65,25 +88,32 @@ func newAggregator(ctx context.Context, di database.Plugin, sh definitions.Defin
 		eventBatchSize:             batchSize,
 		eventBatchTimeout:          config.GetDuration(config.EventAggregatorBatchTimeout),
 		eventPollTimeout:           config.GetDuration(config.EventAggregatorPollTimeout),
+		adaptivePoll:               newAdaptivePollConf(),
 		startupOffsetRetryAttempts: config.GetInt(config.OrchestratorStartupAttempts),
 		retry: retry.Retry{
-			InitialDelay: config.GetDuration(config.EventAggregatorRetryInitDelay),
-			MaximumDelay: config.GetDuration(config.EventAggregatorRetryMaxDelay),
-			Factor:       config.GetFloat64(config.EventAggregatorRetryFactor),
+			InitialDelay:      config.GetDuration(config.EventAggregatorRetryInitDelay),
+			MaximumDelay:      config.GetDuration(config.EventAggregatorRetryMaxDelay),
+			Factor:            config.GetFloat64(config.EventAggregatorRetryFactor),
+			Jitter:            config.GetFloat64(config.EventAggregatorRetryJitter),
+			BreakerThreshold:  config.GetInt(config.EventAggregatorRetryCircuitBreakerThreshold),
+			BreakerResetDelay: config.GetDuration(config.EventAggregatorRetryCircuitBreakerResetDelay),
 		},
 		firstEvent:       &firstEvent,
 		namespace:        fftypes.SystemNamespace,
+		eventBus:         eb,
 		offsetType:       fftypes.OffsetTypeAggregator,
-		offsetName:       aggregatorOffsetName,
+		offsetName:       ledgerOffsetName(ledger),
 		newEventsHandler: ag.processPinsEventsHandler,
 		getItems:         ag.getPins,
 		queryFactory:     database.PinQueryFactory,
 		addCriteria: func(af database.AndFilter) database.AndFilter {
-			return af.Condition(af.Builder().Eq("dispatched", false))
+			fb := af.Builder()
+			return af.Condition(fb.Eq("dispatched", false), fb.Eq("ledger", ag.ledger))
 		},
-		maybeRewind: ag.rewindOffchainBatches,
+		maybeRewind: ag.checkRewinds,
 	})
 	ag.retry = &ag.eventPoller.conf.retry
+	ag.retry.ReloadFromConfig(ag.ctx, config.EventAggregatorRetryInitDelay, config.EventAggregatorRetryMaxDelay, config.EventAggregatorRetryFactor)
 	return ag
 }
 
@@ -104,6 +134,61 @@ func (ag *aggregator) offchainListener() {
 	}
 }
 
+// queueCheckpointRewind requests the event poller to rewind the checkpoint, so that all pins from the
+// supplied sequence onwards are re-aggregated. The rewind itself is performed on the event poller routine.
+func (ag *aggregator) queueCheckpointRewind(sequence int64) {
+	ag.rewindMux.Lock()
+	offset := sequence - 1
+	if ag.pendingRewind == nil || offset < *ag.pendingRewind {
+		ag.pendingRewind = &offset
+	}
+	ag.rewindMux.Unlock()
+	ag.eventPoller.shoulderTap()
+}
+
+func (ag *aggregator) checkRewinds() (rewind bool, offset int64) {
+	rewind, offset = ag.rewindOffchainBatches()
+	if cpRewind, cpOffset := ag.rewindCheckpoint(); cpRewind && (!rewind || cpOffset < offset) {
+		return true, cpOffset
+	}
+	return rewind, offset
+}
+
+func (ag *aggregator) rewindCheckpoint() (rewind bool, offset int64) {
+	ag.rewindMux.Lock()
+	pendingRewind := ag.pendingRewind
+	ag.pendingRewind = nil
+	ag.rewindMux.Unlock()
+	if pendingRewind == nil {
+		return false, -1
+	}
+	offset = *pendingRewind
+
+	// Retry idefinitely for database errors (until the context closes)
+	err := ag.retry.Do(ag.ctx, "rewind checkpoint", func(attempt int) (retry bool, err error) {
+		err = ag.database.RunAsGroup(ag.ctx, func(ctx context.Context) error {
+			// Pins that were already dispatched must be re-aggregated
+			fb := database.PinQueryFactory.NewFilter(ctx)
+			err := ag.database.UpdatePins(ctx, fb.And(
+				fb.Gt("sequence", offset),
+				fb.Eq("dispatched", true),
+				fb.Eq("ledger", ag.ledger),
+			), database.PinQueryFactory.NewUpdate(ctx).Set("dispatched", false))
+			if err == nil {
+				// Persist the new checkpoint, so the rewind survives a restart
+				err = ag.eventPoller.commitOffset(ctx, offset)
+			}
+			return err
+		})
+		return err != nil, err
+	})
+	if err != nil {
+		return false, -1
+	}
+	log.L(ag.ctx).Infof("Rewound aggregator checkpoint to local pin sequence %d", offset)
+	return true, offset
+}
+
 func (ag *aggregator) rewindOffchainBatches() (rewind bool, offset int64) {
 	// Retry idefinitely for database errors (until the context closes)
 	_ = ag.retry.Do(ag.ctx, "check for off-chain batch deliveries", func(attempt int) (retry bool, err error) {
@@ -122,6 +207,7 @@ func (ag *aggregator) rewindOffchainBatches() (rewind bool, offset int64) {
 			filter := fb.And(
 				fb.In("batch", batchIDs),
 				fb.Eq("dispatched", false),
+				fb.Eq("ledger", ag.ledger),
 			).Sort("sequence").Limit(1) // only need the one oldest sequence
 			sequences, _, err := ag.database.GetPins(ag.ctx, filter)
 			if err != nil {
@@ -172,9 +258,16 @@ func (ag *aggregator) processPinsEventsHandler(items []fftypes.LocallySequenced)
 		pins[i] = item.(*fftypes.Pin)
 	}
 
-	return false, ag.processWithBatchState(func(ctx context.Context, state *batchState) error {
-		return ag.processPins(ctx, pins, state)
+	startTime := time.Now()
+	var nsPins map[string]int
+	err = ag.processWithBatchState(func(ctx context.Context, state *batchState) (err error) {
+		nsPins, err = ag.processPins(ctx, pins, state)
+		return err
 	})
+	if err == nil && ag.metrics.IsMetricsEnabled() {
+		ag.metrics.AggregatorBatchProcessed(ag.ledger, nsPins, time.Since(startTime))
+	}
+	return false, err
 }
 
 func (ag *aggregator) getPins(ctx context.Context, filter database.Filter) ([]fftypes.LocallySequenced, error) {
@@ -200,8 +293,11 @@ func (ag *aggregator) extractBatchMessagePin(batch *fftypes.Batch, requiredIndex
 	return totalBatchPins, msg, msgBaseIndex
 }
 
-func (ag *aggregator) processPins(ctx context.Context, pins []*fftypes.Pin, state *batchState) (err error) {
+// processPins returns the number of pins processed in each namespace. Pins that are parked waiting for
+// their batch are not counted, as they are processed again when the batch arrives.
+func (ag *aggregator) processPins(ctx context.Context, pins []*fftypes.Pin, state *batchState) (nsPins map[string]int, err error) {
 	l := log.L(ctx)
+	nsPins = make(map[string]int)
 
 	// Keep a batch cache for this list of pins
 	var batch *fftypes.Batch
@@ -213,13 +309,14 @@ func (ag *aggregator) processPins(ctx context.Context, pins []*fftypes.Pin, stat
 		if batch == nil || *batch.ID != *pin.Batch {
 			batch, err = ag.database.GetBatchByID(ctx, pin.Batch)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			if batch == nil {
 				l.Debugf("Batch %s not available - pin %s is parked", pin.Batch, pin.Hash)
 				continue
 			}
 		}
+		nsPins[batch.Namespace]++
 
 		// Extract the message from the batch - where the index is of a topic within a message
 		batchPinCount, msg, msgBaseIndex := ag.extractBatchMessagePin(batch, pin.Index)
@@ -241,12 +338,12 @@ func (ag *aggregator) processPins(ctx context.Context, pins []*fftypes.Pin, stat
 		// Attempt to process the message (only returns errors for database persistence issues)
 		err := ag.processMessage(ctx, batch, pin, msgBaseIndex, msg, state)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	err = ag.eventPoller.commitOffset(ctx, pins[len(pins)-1].Sequence)
-	return err
+	return nsPins, err
 }
 
 func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch, pin *fftypes.Pin, msgBaseIndex int64, msg *fftypes.Message, state *batchState) (err error) {
@@ -361,6 +458,16 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 
 	case msg.Header.Type == fftypes.MessageTypeGroupInit:
 		// Already handled as part of resolving the context - do nothing.
+		// Except a membership change is only valid if it was applied when initializing the context
+		// of the new version of the group, so it must be rejected if it arrived on an existing context.
+		if msg.Header.Tag == string(fftypes.SystemTagChangeGroupMembers) {
+			fb := database.GroupMembershipChangeQueryFactory.NewFilter(ctx)
+			changes, _, err := ag.database.GetGroupMembershipChanges(ctx, fb.And(fb.Eq("message", msg.Header.ID)))
+			if err != nil {
+				return false, err
+			}
+			valid = len(changes) > 0
+		}
 
 	case len(msg.Data) > 0:
 		valid, err = ag.data.ValidateAll(ctx, data)
@@ -376,7 +483,15 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 		eventType = fftypes.EventTypeMessageRejected
 	}
 
-	state.AddFinalize(func(ctx context.Context) error {
+	state.AddFinalize(func(ctx context.Context) (err error) {
+		// Join the trace the message was submitted under, if it was sent from this node.
+		// The database calls stay on the context of the batch, as they are already within its transaction.
+		_, span := tracing.StartSpan(tracing.MessageContext(ctx, msg.Header.ID), "aggregator.FinalizeMessage",
+			attribute.String("message.id", msg.Header.ID.String()),
+			attribute.String("message.state", string(status)),
+		)
+		defer func() { tracing.EndSpan(span, err) }()
+
 		// This message is now confirmed
 		setConfirmed := database.MessageQueryFactory.NewUpdate(ctx).
 			Set("confirmed", fftypes.Now()). // the timestamp of the aggregator provides ordering
@@ -21,6 +21,7 @@ import (
 	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/binary"
+	"hash"
 
 	"github.com/hyperledger/firefly/internal/definitions"
 	"github.com/hyperledger/firefly/internal/log"
@@ -47,6 +48,8 @@ func newBatchState(ag *aggregator) *batchState {
 // At the end we flush the changes to the database
 type nextPinGroupState struct {
 	groupID           *fftypes.Bytes32
+	groupVersion      int64
+	groupSalt         *fftypes.Bytes32
 	topic             string
 	nextPins          []*fftypes.NextPin
 	new               bool
@@ -178,8 +181,9 @@ func (bs *batchState) CheckMaskedContextReady(ctx context.Context, msg *fftypes.
 	h := sha256.New()
 	h.Write([]byte(topic))
 	h.Write((*msg.Header.Group)[:])
+	writeGroupVersion(h, msg.Header.GroupVersion)
 	contextUnmasked := fftypes.HashResult(h)
-	npg, err := bs.stateForMaskedContext(ctx, msg.Header.Group, topic, *contextUnmasked)
+	npg, err := bs.stateForMaskedContext(ctx, msg.Header.Group, msg.Header.GroupVersion, topic, *contextUnmasked)
 	if err != nil {
 		return nil, err
 	}
@@ -297,6 +301,10 @@ func (npg *nextPinGroupState) calcPinHash(identity string, nonce int64) *fftypes
 	h := sha256.New()
 	h.Write([]byte(npg.topic))
 	h.Write((*npg.groupID)[:])
+	writeGroupVersion(h, npg.groupVersion)
+	if npg.groupSalt != nil {
+		h.Write(npg.groupSalt[:])
+	}
 	h.Write([]byte(identity))
 	nonceBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(nonceBytes, uint64(nonce))
@@ -304,7 +312,34 @@ func (npg *nextPinGroupState) calcPinHash(identity string, nonce int64) *fftypes
 	return fftypes.HashResult(h)
 }
 
-func (bs *batchState) stateForMaskedContext(ctx context.Context, groupID *fftypes.Bytes32, topic string, contextUnmasked fftypes.Bytes32) (*nextPinGroupState, error) {
+// writeGroupVersion adds the version of the group into the context, once the membership of the group
+// has been changed. Each version of the group has a fresh set of nonces for its members.
+func writeGroupVersion(h hash.Hash, groupVersion int64) {
+	if groupVersion > 0 {
+		versionBytes := make([]byte, 8)
+		binary.BigEndian.PutUint64(versionBytes, uint64(groupVersion))
+		h.Write(versionBytes)
+	}
+}
+
+// groupVersionSalt returns the salt from the membership change that created a version of a group, which is
+// mixed into the pins of all messages sent to that version of the group
+func (bs *batchState) groupVersionSalt(ctx context.Context, groupID *fftypes.Bytes32, groupVersion int64) (*fftypes.Bytes32, error) {
+	if groupVersion == 0 {
+		return nil, nil
+	}
+	fb := database.GroupMembershipChangeQueryFactory.NewFilterLimit(ctx, 1)
+	changes, _, err := bs.database.GetGroupMembershipChanges(ctx, fb.And(
+		fb.Eq("group", groupID),
+		fb.Eq("effectivefrom", groupVersion),
+	))
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return changes[0].Salt, nil
+}
+
+func (bs *batchState) stateForMaskedContext(ctx context.Context, groupID *fftypes.Bytes32, groupVersion int64, topic string, contextUnmasked fftypes.Bytes32) (*nextPinGroupState, error) {
 
 	if npg, exists := bs.maskedContexts[contextUnmasked]; exists {
 		return npg, nil
@@ -320,8 +355,15 @@ func (bs *batchState) stateForMaskedContext(ctx context.Context, groupID *fftype
 		return nil, nil
 	}
 
+	groupSalt, err := bs.groupVersionSalt(ctx, groupID, groupVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	npg := &nextPinGroupState{
 		groupID:           groupID,
+		groupVersion:      groupVersion,
+		groupSalt:         groupSalt,
 		topic:             topic,
 		identitiesChanged: make(map[string]bool),
 		nextPins:          nextPins,
@@ -341,8 +383,16 @@ func (bs *batchState) attemptContextInit(ctx context.Context, msg *fftypes.Messa
 		return nil, err
 	}
 
+	// If this is a membership change, it will have been applied by the group init above
+	groupSalt, err := bs.groupVersionSalt(ctx, msg.Header.Group, msg.Header.GroupVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	npg := &nextPinGroupState{
 		groupID:           msg.Header.Group,
+		groupVersion:      msg.Header.GroupVersion,
+		groupSalt:         groupSalt,
 		topic:             topic,
 		new:               true,
 		identitiesChanged: make(map[string]bool),
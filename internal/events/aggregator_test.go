@@ -24,6 +24,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/definitions"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
@@ -42,7 +43,7 @@ func newTestAggregator() (*aggregator, func()) {
 	mmi := &metricsmocks.Manager{}
 	mmi.On("IsMetricsEnabled").Return(false)
 	ctx, cancel := context.WithCancel(context.Background())
-	ag := newAggregator(ctx, mdi, msh, mdm, newEventNotifier(ctx, "ut"), mmi)
+	ag := newAggregator(ctx, mdi, msh, mdm, newEventNotifier(ctx, "ut"), eventbus.NewBus(), mmi, "")
 	return ag, cancel
 }
 
@@ -54,7 +55,7 @@ func newTestAggregatorWithMetrics() (*aggregator, func()) {
 	mmi.On("MessageConfirmed", mock.Anything, fftypes.EventTypeMessageConfirmed).Return()
 	mmi.On("IsMetricsEnabled").Return(true)
 	ctx, cancel := context.WithCancel(context.Background())
-	ag := newAggregator(ctx, mdi, msh, mdm, newEventNotifier(ctx, "ut"), mmi)
+	ag := newAggregator(ctx, mdi, msh, mdm, newEventNotifier(ctx, "ut"), eventbus.NewBus(), mmi, "")
 	return ag, cancel
 }
 
@@ -162,7 +163,7 @@ func TestAggregationMaskedZeroNonceMatch(t *testing.T) {
 	// Confirm the offset
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := ag.processPins(ag.ctx, []*fftypes.Pin{
+	_, err := ag.processPins(ag.ctx, []*fftypes.Pin{
 		{
 			Sequence:   10001,
 			Masked:     true,
@@ -339,7 +340,7 @@ func TestAggregationBroadcast(t *testing.T) {
 	// Confirm the offset
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := ag.processPins(ag.ctx, []*fftypes.Pin{
+	_, err := ag.processPins(ag.ctx, []*fftypes.Pin{
 		{
 			Sequence:   10001,
 			Hash:       contextUnmasked,
@@ -395,6 +396,35 @@ func TestProcessPinsDBGroupFail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 }
 
+func TestProcessPinsEventsHandlerMetrics(t *testing.T) {
+	ag, cancel := newTestAggregatorWithMetrics()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", ag.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	batchID := fftypes.NewUUID()
+	parkedBatchID := fftypes.NewUUID()
+	mdi.On("GetBatchByID", ag.ctx, batchID).Return(&fftypes.Batch{ID: batchID, Namespace: "ns1"}, nil)
+	mdi.On("GetBatchByID", ag.ctx, parkedBatchID).Return(nil, nil)
+	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mmi := ag.metrics.(*metricsmocks.Manager)
+	mmi.On("AggregatorBatchProcessed", "", map[string]int{"ns1": 2}, mock.Anything).Return()
+
+	_, err := ag.processPinsEventsHandler([]fftypes.LocallySequenced{
+		&fftypes.Pin{Batch: batchID},
+		&fftypes.Pin{Batch: batchID},
+		&fftypes.Pin{Batch: parkedBatchID},
+	})
+	assert.NoError(t, err)
+
+	mmi.AssertCalled(t, "AggregatorBatchProcessed", "", map[string]int{"ns1": 2}, mock.Anything)
+}
+
 func TestGetPins(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
@@ -418,7 +448,7 @@ func TestProcessPinsMissingBatch(t *testing.T) {
 	mdi.On("GetBatchByID", ag.ctx, mock.Anything).Return(nil, nil)
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := ag.processPins(ag.ctx, []*fftypes.Pin{
+	_, err := ag.processPins(ag.ctx, []*fftypes.Pin{
 		{Sequence: 12345, Batch: fftypes.NewUUID()},
 	}, bs)
 	assert.NoError(t, err)
@@ -441,7 +471,7 @@ func TestProcessPinsMissingNoMsg(t *testing.T) {
 	}, nil)
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := ag.processPins(ag.ctx, []*fftypes.Pin{
+	_, err := ag.processPins(ag.ctx, []*fftypes.Pin{
 		{Sequence: 12345, Batch: fftypes.NewUUID(), Index: 25},
 	}, bs)
 	assert.NoError(t, err)
@@ -468,7 +498,7 @@ func TestProcessPinsBadMsgHeader(t *testing.T) {
 	}, nil)
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := ag.processPins(ag.ctx, []*fftypes.Pin{
+	_, err := ag.processPins(ag.ctx, []*fftypes.Pin{
 		{Sequence: 12345, Batch: fftypes.NewUUID(), Index: 0},
 	}, bs)
 	assert.NoError(t, err)
@@ -499,7 +529,7 @@ func TestProcessSkipDupMsg(t *testing.T) {
 	}, nil, nil)
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := ag.processPins(ag.ctx, []*fftypes.Pin{
+	_, err := ag.processPins(ag.ctx, []*fftypes.Pin{
 		{Sequence: 12345, Batch: batchID, Index: 0, Hash: fftypes.NewRandB32()},
 		{Sequence: 12345, Batch: batchID, Index: 1, Hash: fftypes.NewRandB32()},
 	}, bs)
@@ -528,7 +558,7 @@ func TestProcessMsgFailGetPins(t *testing.T) {
 	}, nil).Once()
 	mdi.On("GetPins", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 
-	err := ag.processPins(ag.ctx, []*fftypes.Pin{
+	_, err := ag.processPins(ag.ctx, []*fftypes.Pin{
 		{Sequence: 12345, Batch: batchID, Index: 0, Hash: fftypes.NewRandB32()},
 	}, bs)
 	assert.EqualError(t, err, "pop")
@@ -700,6 +730,39 @@ func TestAttemptContextInitGroupNotFound(t *testing.T) {
 
 }
 
+func TestAttemptContextInitGroupSaltFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	groupID := fftypes.NewRandB32()
+	msh := ag.definitions.(*definitionsmocks.DefinitionHandlers)
+	msh.On("ResolveInitGroup", ag.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: fftypes.Members{
+				{Identity: "author1"},
+			},
+		},
+		Hash: groupID,
+	}, nil)
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupMembershipChanges", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	bs := newBatchState(ag)
+	_, err := bs.attemptContextInit(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:           fftypes.NewUUID(),
+			Group:        groupID,
+			GroupVersion: 1,
+			Identity: fftypes.Identity{
+				Author: "author1",
+				Key:    "0x12345",
+			},
+		},
+	}, "topic1", 12345, fftypes.NewRandB32(), fftypes.NewRandB32())
+	assert.EqualError(t, err, "pop")
+
+}
+
 func TestAttemptContextInitAuthorMismatch(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
@@ -1413,6 +1476,76 @@ func TestRewindOffchainBatchesBatchesError(t *testing.T) {
 	assert.False(t, rewind)
 }
 
+func TestCheckRewindsCheckpointOlderThanBatch(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	ag.queuedRewinds <- fftypes.NewUUID()
+	ag.queueCheckpointRewind(100)
+	ag.queueCheckpointRewind(200) // ignored, as later than the first
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return([]*fftypes.Pin{
+		{Sequence: 12345},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", ag.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdatePins", ag.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop")).Once()
+	mdi.On("UpdatePins", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+
+	rewind, offset := ag.checkRewinds()
+	assert.True(t, rewind)
+	assert.Equal(t, int64(99), offset)
+	assert.Equal(t, int64(99), ag.eventPoller.getPollingOffset())
+
+	// Only applied once
+	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	rewind, _ = ag.checkRewinds()
+	assert.False(t, rewind)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckRewindsBatchOlderThanCheckpoint(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	ag.queuedRewinds <- fftypes.NewUUID()
+	ag.queueCheckpointRewind(20000)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return([]*fftypes.Pin{
+		{Sequence: 12345},
+	}, nil, nil)
+	mdi.On("RunAsGroup", ag.ctx, mock.Anything).Return(nil)
+
+	rewind, offset := ag.checkRewinds()
+	assert.True(t, rewind)
+	assert.Equal(t, int64(12344), offset)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRewindCheckpointFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	cancel()
+
+	ag.queueCheckpointRewind(100)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("RunAsGroup", ag.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	rewind, _ := ag.rewindCheckpoint()
+	assert.False(t, rewind)
+
+	mdi.AssertExpectations(t)
+}
+
 func TestResolveBlobsNoop(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
@@ -1618,3 +1751,175 @@ func TestProcessWithBatchActionsSuccess(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+func TestCheckMaskedContextReadyGroupVersion(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	bs := newBatchState(ag)
+
+	topic := "some-topic"
+	groupID := fftypes.NewRandB32()
+	h := sha256.New()
+	h.Write([]byte(topic))
+	h.Write((*groupID)[:])
+	h.Write([]byte{0, 0, 0, 0, 0, 0, 0, 2})
+	contextUnmasked := fftypes.HashResult(h)
+	salt := fftypes.NewRandB32()
+	npg := &nextPinGroupState{topic: topic, groupID: groupID, groupVersion: 2, groupSalt: salt}
+	pin := npg.calcPinHash("org1", 5)
+	assert.NotEqual(t, *pin, *(&nextPinGroupState{topic: topic, groupID: groupID}).calcPinHash("org1", 5))
+	assert.NotEqual(t, *pin, *(&nextPinGroupState{topic: topic, groupID: groupID, groupVersion: 2}).calcPinHash("org1", 5))
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupMembershipChanges", ag.ctx, mock.Anything).Return([]*fftypes.GroupMembershipChange{
+		{Group: groupID, EffectiveFrom: 2, Salt: salt},
+	}, nil, nil)
+	mdi.On("GetNextPins", ag.ctx, mock.MatchedBy(func(f database.Filter) bool {
+		fi, err := f.Finalize()
+		assert.NoError(t, err)
+		return fi.String() == fmt.Sprintf("context == '%s'", contextUnmasked)
+	})).Return([]*fftypes.NextPin{
+		{Context: contextUnmasked, Identity: "org1", Hash: pin, Nonce: 5},
+	}, nil, nil)
+
+	nps, err := bs.CheckMaskedContextReady(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:           fftypes.NewUUID(),
+			Group:        groupID,
+			GroupVersion: 2,
+			Topics:       fftypes.FFStringArray{topic},
+			Identity:     fftypes.Identity{Author: "org1"},
+		},
+	}, topic, 10001, pin)
+	assert.NoError(t, err)
+	assert.NotNil(t, nps)
+	assert.Equal(t, int64(2), nps.nextPinGroup.groupVersion)
+	assert.Equal(t, *salt, *nps.nextPinGroup.groupSalt)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckMaskedContextReadyGroupSaltFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	bs := newBatchState(ag)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetNextPins", ag.ctx, mock.Anything).Return([]*fftypes.NextPin{
+		{Identity: "org1", Hash: fftypes.NewRandB32(), Nonce: 5},
+	}, nil, nil)
+	mdi.On("GetGroupMembershipChanges", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := bs.CheckMaskedContextReady(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:           fftypes.NewUUID(),
+			Group:        fftypes.NewRandB32(),
+			GroupVersion: 2,
+			Topics:       fftypes.FFStringArray{"topic1"},
+			Identity:     fftypes.Identity{Author: "org1"},
+		},
+	}, "topic1", 10001, fftypes.NewRandB32())
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchMembershipChange(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	bs := newBatchState(ag)
+
+	msgID := fftypes.NewUUID()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdi.On("GetGroupMembershipChanges", ag.ctx, mock.Anything).Return([]*fftypes.GroupMembershipChange{
+		{Message: msgID},
+	}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return *e.Reference == *msgID && e.Type == fftypes.EventTypeMessageConfirmed
+	})).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   msgID,
+			Type: fftypes.MessageTypeGroupInit,
+			Tag:  string(fftypes.SystemTagChangeGroupMembers),
+		},
+	}, nil, bs)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	err = bs.RunFinalize(ag.ctx)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchMembershipChangeNotApplied(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	bs := newBatchState(ag)
+
+	msgID := fftypes.NewUUID()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdi.On("GetGroupMembershipChanges", ag.ctx, mock.Anything).Return([]*fftypes.GroupMembershipChange{}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return *e.Reference == *msgID && e.Type == fftypes.EventTypeMessageRejected
+	})).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   msgID,
+			Type: fftypes.MessageTypeGroupInit,
+			Tag:  string(fftypes.SystemTagChangeGroupMembers),
+		},
+	}, nil, bs)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	err = bs.RunFinalize(ag.ctx)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchMembershipChangeFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	bs := newBatchState(ag)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdi.On("GetGroupMembershipChanges", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.MessageTypeGroupInit,
+			Tag:  string(fftypes.SystemTagChangeGroupMembers),
+		},
+	}, nil, bs)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestLedgerAggregatorFiltersPins(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mmi := &metricsmocks.Manager{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ag := newAggregator(ctx, mdi, &definitionsmocks.DefinitionHandlers{}, &datamocks.Manager{}, newEventNotifier(ctx, "ut"), eventbus.NewBus(), mmi, "ledger2")
+	assert.Equal(t, "ff_aggregator_ledger2", ag.eventPoller.conf.offsetName)
+
+	fb := database.PinQueryFactory.NewFilter(ctx)
+	filter, err := ag.eventPoller.conf.addCriteria(fb.And()).Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "( dispatched == false ) && ( ledger == 'ledger2' )", filter.String())
+}
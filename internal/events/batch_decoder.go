@@ -0,0 +1,343 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// isStrictDecode returns true if the namespace is configured to reject unknown fields in inbound payloads
+func isStrictDecode(ns string) bool {
+	return config.GetPredefinedNamespace(ns).GetBool("strictDecode")
+}
+
+// gzipMagic are the leading bytes of a gzip stream, which can never be the start of a JSON payload
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompressPayload detects a compressed batch payload from its leading bytes, and returns a reader
+// for the uncompressed JSON. Payloads that are not compressed are returned as-is.
+func decompressPayload(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// limitedPayloadReader stops reading once more than the configured maximum number of bytes
+// has been read, and keeps track of whether any failure was from the underlying reader.
+type limitedPayloadReader struct {
+	ctx       context.Context
+	r         io.Reader
+	limit     int64
+	read      int64
+	exceeded  bool
+	readError error
+}
+
+func (lr *limitedPayloadReader) Read(p []byte) (n int, err error) {
+	if remaining := lr.limit + 1 - lr.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err = lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > lr.limit {
+		lr.exceeded = true
+		return n, i18n.NewError(lr.ctx, i18n.MsgBatchPayloadTooLarge, lr.limit)
+	}
+	if err != nil && err != io.EOF {
+		lr.readError = err
+	}
+	return n, err
+}
+
+// payloadHasher calculates the hash of a batch payload incrementally as each element is decoded, by
+// feeding the hash with the same serialization that fftypes.BatchPayload.Hash() uses. This is only
+// possible while the hashed fields arrive in the order they are serialized, so any other order falls
+// back to calculating the hash once the whole payload has been decoded.
+type payloadHasher struct {
+	h       hash.Hash
+	next    int
+	inOrder bool
+}
+
+// payloadHashFields are the hashed fields of fftypes.BatchPayload, in the order they are serialized
+var payloadHashFields = []string{"tx", "messages", "data"}
+
+func newPayloadHasher() *payloadHasher {
+	return &payloadHasher{h: sha256.New(), inOrder: true}
+}
+
+// field writes the key of the next hashed field, if it is the one expected
+func (ph *payloadHasher) field(key string) {
+	if !ph.inOrder || ph.next >= len(payloadHashFields) || payloadHashFields[ph.next] != key {
+		ph.inOrder = false
+		return
+	}
+	if ph.next == 0 {
+		ph.write("{")
+	} else {
+		ph.write(",")
+	}
+	b, _ := json.Marshal(key)
+	ph.h.Write(b)
+	ph.write(":")
+	ph.next++
+}
+
+func (ph *payloadHasher) write(s string) {
+	if ph.inOrder {
+		ph.h.Write([]byte(s))
+	}
+}
+
+func (ph *payloadHasher) value(v interface{}) {
+	if ph.inOrder {
+		b, _ := json.Marshal(v)
+		ph.h.Write(b)
+	}
+}
+
+// arrayEntry writes the separator before entry idx of an array, then the entry itself
+func (ph *payloadHasher) arrayEntry(idx int, v interface{}) {
+	if idx == 0 {
+		ph.write("[")
+	} else {
+		ph.write(",")
+	}
+	ph.value(v)
+}
+
+// arrayEnd writes the end of an array with the given number of entries, or a null
+func (ph *payloadHasher) arrayEnd(count int, isNil bool) {
+	switch {
+	case isNil:
+		ph.write("null")
+	case count == 0:
+		ph.write("[]")
+	default:
+		ph.write("]")
+	}
+}
+
+// sum returns the hash of the payload
+func (ph *payloadHasher) sum(payload *fftypes.BatchPayload) *fftypes.Bytes32 {
+	if !ph.inOrder || ph.next != len(payloadHashFields) {
+		return payload.Hash()
+	}
+	ph.write("}")
+	var b32 fftypes.Bytes32
+	copy(b32[:], ph.h.Sum(nil))
+	return &b32
+}
+
+// decodeBatch decodes a batch from a stream, one message and data element at a time, so that the
+// encoded payload is never buffered in full alongside the decoded batch. The decoded batch is still held
+// in memory in its entirety, so this does not bound memory use by itself - callers must limit the size
+// of the stream (see limitedPayloadReader) to bound the size of the batch.
+// If an expected hash is supplied, the hash of the payload is calculated as it is decoded, and decoding
+// stops with an error as soon as the payload has been read if it does not match.
+// In strict mode, any field that is not known is rejected with an error naming its path.
+func decodeBatch(ctx context.Context, r io.Reader, expectedHash *fftypes.Bytes32, strict bool) (*fftypes.Batch, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, i18n.NewError(ctx, i18n.MsgBatchPayloadUnexpectedToken, "{", dec.InputOffset())
+	}
+	batch := &fftypes.Batch{}
+	fields := make(map[string]json.RawMessage)
+	err = decodeObjectEntries(dec, func(key string) error {
+		if key == "payload" {
+			ph := newPayloadHasher()
+			if err := decodeBatchPayload(ctx, dec, &batch.Payload, ph, strict); err != nil {
+				return err
+			}
+			if expectedHash != nil {
+				if hash := ph.sum(&batch.Payload); !hash.Equals(expectedHash) {
+					return i18n.NewError(ctx, i18n.MsgBatchPayloadHashMismatch, hash, expectedHash)
+				}
+			}
+			return nil
+		}
+		return decodeField(dec, key, fields)
+	})
+	if err == nil {
+		err = decodeEnd(ctx, dec)
+	}
+	if err == nil {
+		err = unmarshalFields(ctx, fields, batch, strict, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func decodeBatchPayload(ctx context.Context, dec *json.Decoder, payload *fftypes.BatchPayload, ph *payloadHasher, strict bool) error {
+	fields := make(map[string]json.RawMessage)
+	err := decodeObject(ctx, dec, func(key string) error {
+		switch key {
+		case "tx":
+			ph.field(key)
+			err := decodeValue(ctx, dec, &payload.TX, strict, "payload.tx")
+			ph.value(&payload.TX)
+			return err
+		case "messages":
+			ph.field(key)
+			payload.Messages = nil
+			err := decodeArray(ctx, dec, func() error {
+				var msg *fftypes.Message
+				err := decodeValue(ctx, dec, &msg, strict, fmt.Sprintf("payload.messages[%d]", len(payload.Messages)))
+				ph.arrayEntry(len(payload.Messages), msg)
+				payload.Messages = append(payload.Messages, msg)
+				return err
+			}, func() {
+				payload.Messages = []*fftypes.Message{}
+			})
+			ph.arrayEnd(len(payload.Messages), payload.Messages == nil)
+			return err
+		case "data":
+			ph.field(key)
+			payload.Data = nil
+			err := decodeArray(ctx, dec, func() error {
+				var data *fftypes.Data
+				err := decodeValue(ctx, dec, &data, strict, fmt.Sprintf("payload.data[%d]", len(payload.Data)))
+				ph.arrayEntry(len(payload.Data), data)
+				payload.Data = append(payload.Data, data)
+				return err
+			}, func() {
+				payload.Data = []*fftypes.Data{}
+			})
+			ph.arrayEnd(len(payload.Data), payload.Data == nil)
+			return err
+		default:
+			return decodeField(dec, key, fields)
+		}
+	})
+	if err == nil {
+		err = unmarshalFields(ctx, fields, payload, strict, "payload")
+	}
+	return err
+}
+
+// decodeValue decodes the next value from the stream, rejecting unknown fields in strict mode
+func decodeValue(ctx context.Context, dec *json.Decoder, target interface{}, strict bool, path string) error {
+	if !strict {
+		return dec.Decode(target)
+	}
+	var raw json.RawMessage
+	err := dec.Decode(&raw)
+	if err == nil {
+		err = json.Unmarshal(raw, target)
+	}
+	if err == nil {
+		err = fftypes.CheckKnownFields(ctx, path, raw, target)
+	}
+	return err
+}
+
+func decodeField(dec *json.Decoder, key string, fields map[string]json.RawMessage) error {
+	var value json.RawMessage
+	err := dec.Decode(&value)
+	fields[key] = value
+	return err
+}
+
+// unmarshalFields applies the (small) fields that were not streamed, onto the target object,
+// rejecting unknown fields in strict mode
+func unmarshalFields(ctx context.Context, fields map[string]json.RawMessage, target interface{}, strict bool, path string) error {
+	b, _ := json.Marshal(fields)
+	err := json.Unmarshal(b, target)
+	if err == nil && strict {
+		err = fftypes.CheckKnownFields(ctx, path, b, target)
+	}
+	return err
+}
+
+// decodeObject calls fn for each key in a JSON object, with the decoder positioned to read the value.
+// A null is accepted in place of the object.
+func decodeObject(ctx context.Context, dec *json.Decoder, fn func(key string) error) error {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return i18n.NewError(ctx, i18n.MsgBatchPayloadUnexpectedToken, "{", dec.InputOffset())
+	}
+	return decodeObjectEntries(dec, fn)
+}
+
+// decodeObjectEntries calls fn for each key in a JSON object, after the opening delimiter has been read
+func decodeObjectEntries(dec *json.Decoder, fn func(key string) error) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err = fn(tok.(string)); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// decodeArray calls fn for each entry in a JSON array, with the decoder positioned to read the entry.
+// A null is accepted in place of the array, and start is called only if an array is found.
+func decodeArray(ctx context.Context, dec *json.Decoder, fn func() error, start func()) error {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return i18n.NewError(ctx, i18n.MsgBatchPayloadUnexpectedToken, "[", dec.InputOffset())
+	}
+	start()
+	for dec.More() {
+		if err = fn(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// decodeEnd checks there is nothing but whitespace after the top-level object
+func decodeEnd(ctx context.Context, dec *json.Decoder) error {
+	if _, err := dec.Token(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return i18n.NewError(ctx, i18n.MsgBatchPayloadUnexpectedToken, "EOF", dec.InputOffset())
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBatchMatchesUnmarshal(t *testing.T) {
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin, &fftypes.Data{
+		ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr(`"test"`),
+	})
+	b, err := json.Marshal(batch)
+	assert.NoError(t, err)
+
+	var expected *fftypes.Batch
+	err = json.Unmarshal(b, &expected)
+	assert.NoError(t, err)
+
+	decoded, err := decodeBatch(context.Background(), bytes.NewReader(b), nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, decoded)
+	assert.Equal(t, batch.Hash, decoded.Payload.Hash())
+}
+
+func TestDecodeBatchEmptyAndNullArrays(t *testing.T) {
+	decoded, err := decodeBatch(context.Background(), strings.NewReader(`{"payload":{"messages":[],"data":null,"tx":{"type":"batch_pin"}}}`), nil, false)
+	assert.NoError(t, err)
+	assert.NotNil(t, decoded.Payload.Messages)
+	assert.Empty(t, decoded.Payload.Messages)
+	assert.Nil(t, decoded.Payload.Data)
+	assert.Equal(t, fftypes.TransactionTypeBatchPin, decoded.Payload.TX.Type)
+
+	decoded, err = decodeBatch(context.Background(), strings.NewReader(`{"payload":null}`), nil, false)
+	assert.NoError(t, err)
+	assert.Nil(t, decoded.Payload.Messages)
+	assert.Nil(t, decoded.Payload.Data)
+}
+
+func TestDecodeBatchStreamingHash(t *testing.T) {
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin, &fftypes.Data{
+		ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr(`{"some":"<html>"}`),
+	}, &fftypes.Data{
+		ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr(`"test"`),
+	})
+	b, err := json.Marshal(batch)
+	assert.NoError(t, err)
+
+	decoded, err := decodeBatch(context.Background(), bytes.NewReader(b), batch.Hash, false)
+	assert.NoError(t, err)
+	assert.Equal(t, batch.Hash, decoded.Payload.Hash())
+
+	_, err = decodeBatch(context.Background(), bytes.NewReader(b), fftypes.NewRandB32(), false)
+	assert.Regexp(t, "FF10428", err)
+}
+
+func TestDecodeBatchStreamingHashNullAndEmpty(t *testing.T) {
+	payload := &fftypes.BatchPayload{
+		TX:   fftypes.TransactionRef{Type: fftypes.TransactionTypeBatchPin},
+		Data: []*fftypes.Data{},
+	}
+	_, err := decodeBatch(context.Background(), strings.NewReader(`{"payload":{"tx":{"type":"batch_pin"},"messages":null,"data":[],"nodeSignature":"sig"}}`), payload.Hash(), false)
+	assert.NoError(t, err)
+
+	_, err = decodeBatch(context.Background(), strings.NewReader(`{"payload":null}`), (&fftypes.BatchPayload{}).Hash(), false)
+	assert.NoError(t, err)
+}
+
+func TestDecodeBatchStreamingHashOutOfOrder(t *testing.T) {
+	payload := &fftypes.BatchPayload{
+		TX:       fftypes.TransactionRef{Type: fftypes.TransactionTypeBatchPin},
+		Messages: []*fftypes.Message{},
+	}
+	_, err := decodeBatch(context.Background(), strings.NewReader(`{"payload":{"messages":[],"tx":{"type":"batch_pin"}}}`), payload.Hash(), false)
+	assert.NoError(t, err)
+
+	_, err = decodeBatch(context.Background(), strings.NewReader(`{"payload":{"tx":{},"messages":[],"data":null,"tx":{"type":"batch_pin"},"messages":[]}}`), payload.Hash(), false)
+	assert.NoError(t, err)
+}
+
+func TestDecodeBatchStrict(t *testing.T) {
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin, &fftypes.Data{
+		ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr(`{"any":"fields"}`),
+	})
+	b, err := json.Marshal(batch)
+	assert.NoError(t, err)
+
+	decoded, err := decodeBatch(context.Background(), bytes.NewReader(b), batch.Hash, true)
+	assert.NoError(t, err)
+	assert.Equal(t, batch.Hash, decoded.Payload.Hash())
+
+	for _, tc := range []struct {
+		payload string
+		err     string
+	}{
+		{payload: `{"future":true}`, err: "FF10429.*'future'"},
+		{payload: `{"payload":{"future":true}}`, err: "FF10429.*'payload.future'"},
+		{payload: `{"payload":{"tx":{"future":true}}}`, err: "FF10429.*'payload.tx.future'"},
+		{payload: `{"payload":{"messages":[{},{"header":{"future":true}}]}}`, err: "FF10429.*'payload.messages\\[1\\].header.future'"},
+		{payload: `{"payload":{"data":[{"future":true}]}}`, err: "FF10429.*'payload.data\\[0\\].future'"},
+		{payload: `{"payload":{"data":[false]}}`, err: "cannot unmarshal"},
+		{payload: `{"payload":{"data":[!]}}`, err: "invalid character"},
+	} {
+		_, err := decodeBatch(context.Background(), strings.NewReader(tc.payload), nil, true)
+		assert.Regexp(t, tc.err, err, tc.payload)
+		_, err = decodeBatch(context.Background(), strings.NewReader(tc.payload), nil, false)
+		if strings.HasPrefix(tc.err, "FF10429") {
+			assert.NoError(t, err, tc.payload)
+		}
+	}
+}
+
+func TestDecodeBatchErrors(t *testing.T) {
+	for _, tc := range []struct {
+		payload string
+		err     string
+	}{
+		{payload: ``, err: "EOF|unexpected end"},
+		{payload: `null`, err: "FF10360"},
+		{payload: `[]`, err: "FF10360"},
+		{payload: `{"id":`, err: "EOF|unexpected end"},
+		{payload: `{"id":"!uuid"}`, err: "invalid UUID"},
+		{payload: `{"id":!`, err: "invalid character"},
+		{payload: `{}{}`, err: "FF10360"},
+		{payload: `{}!`, err: "invalid character"},
+		{payload: `{"payload":[]}`, err: "FF10360"},
+		{payload: `{"payload":{"tx":"wrong"}}`, err: "cannot unmarshal"},
+		{payload: `{"payload":{"messages":{}}}`, err: "FF10360"},
+		{payload: `{"payload":{"messages":[!]}}`, err: "invalid character"},
+		{payload: `{"payload":{"data":[false]}}`, err: "cannot unmarshal"},
+		{payload: `{"payload":{"data":[`, err: "EOF|unexpected end"},
+		{payload: `{"payload":`, err: "EOF|unexpected end"},
+		{payload: `{`, err: "EOF|unexpected end"},
+		{payload: `{!`, err: "invalid character"},
+	} {
+		_, err := decodeBatch(context.Background(), strings.NewReader(tc.payload), nil, false)
+		assert.Regexp(t, tc.err, err, tc.payload)
+	}
+}
+
+func TestLimitedPayloadReader(t *testing.T) {
+	lr := &limitedPayloadReader{ctx: context.Background(), r: strings.NewReader(`{"id":"too long"}`), limit: 5}
+	_, err := decodeBatch(context.Background(), lr, nil, false)
+	assert.Regexp(t, "FF10359", err)
+	assert.True(t, lr.exceeded)
+	assert.NoError(t, lr.readError)
+
+	lr = &limitedPayloadReader{ctx: context.Background(), r: strings.NewReader(`{}`), limit: 5}
+	_, err = decodeBatch(context.Background(), lr, nil, false)
+	assert.NoError(t, err)
+	assert.False(t, lr.exceeded)
+}
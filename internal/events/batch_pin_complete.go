@@ -17,13 +17,21 @@
 package events
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // BatchPinComplete is called in-line with a particular ledger's stream of events, so while we
@@ -33,6 +41,9 @@ import (
 // We must block here long enough to get the payload from the publicstorage, persist the messages in the correct
 // sequence, and also persist all the data.
 func (em *eventManager) BatchPinComplete(bi blockchain.Plugin, batchPin *blockchain.BatchPin, signingIdentity string) error {
+	if em.metrics.IsMetricsEnabled() {
+		em.metrics.BlockchainEventReceived(bi.Name(), batchPin.Namespace, "batchpin")
+	}
 	if batchPin.TransactionID == nil {
 		log.L(em.ctx).Errorf("Invalid BatchPin transaction - ID is nil")
 		return nil // move on
@@ -84,6 +95,7 @@ func (em *eventManager) persistContexts(ctx context.Context, batchPin *blockchai
 			Batch:   batchPin.BatchID,
 			Index:   int64(idx),
 			Created: fftypes.Now(),
+			Ledger:  batchPin.Ledger,
 		}); err != nil {
 			return err
 		}
@@ -92,28 +104,59 @@ func (em *eventManager) persistContexts(ctx context.Context, batchPin *blockchai
 }
 
 func (em *eventManager) handleBroadcastPinComplete(batchPin *blockchain.BatchPin, signingIdentity string) error {
-	var body io.ReadCloser
-	if err := em.retry.Do(em.ctx, "retrieve data", func(attempt int) (retry bool, err error) {
-		body, err = em.publicstorage.RetrieveData(em.ctx, batchPin.BatchPayloadRef)
+	// On restart, or re-delivery of blockchain events, we might see a batch we have already confirmed.
+	// In that case we acknowledge the event without re-fetching the batch from public storage.
+	var duplicate bool
+	if err := em.retry.Do(em.ctx, "check batch", func(attempt int) (retry bool, err error) {
+		duplicate, err = em.isConfirmedBatch(batchPin)
 		return err != nil, err // retry indefinitely (until context closes)
 	}); err != nil {
 		return err
 	}
-	defer body.Close()
+	if duplicate {
+		log.L(em.ctx).Infof("Batch '%s' with hash '%s' is already confirmed", batchPin.BatchID, batchPin.BatchHash)
+		return nil
+	}
 
 	var batch *fftypes.Batch
-	err := json.NewDecoder(body).Decode(&batch)
-	if err != nil {
-		log.L(em.ctx).Errorf("Failed to parse payload referred in batch ID '%s' from transaction '%s'", batchPin.BatchID, batchPin.Event.ProtocolID)
-		return nil // log and swallow unprocessable data
+	var parseErr error
+	strict := isStrictDecode(batchPin.Namespace)
+	if err := em.retry.Do(em.ctx, "retrieve data", func(attempt int) (retry bool, err error) {
+		batch, parseErr, err = em.retrieveBatch(batchPin.Namespace, batchPin.BatchPayloadRef, batchPin.BatchHash, strict)
+		return err != nil, err // retry indefinitely (until context closes)
+	}); err != nil {
+		return err
+	}
+
+	if parseErr != nil {
+		log.L(em.ctx).Errorf("Failed to parse payload referred in batch ID '%s' from transaction '%s': %s", batchPin.BatchID, batchPin.Event.ProtocolID, parseErr)
+		// We cannot process the data, but we retain it in quarantine so it can be inspected
+		return em.retry.Do(em.ctx, "quarantine batch", func(attempt int) (bool, error) {
+			payload, err := em.retrievePayload(batchPin.Namespace, batchPin.BatchPayloadRef)
+			if err == nil {
+				err = em.quarantineBatch(em.ctx, batchPin, signingIdentity, payload, fmt.Sprintf("Failed to parse batch payload: %v", parseErr))
+			}
+			return err != nil, err // retry indefinitely (until context closes)
+		})
+	}
+
+	// The identity registry is queried on the blockchain as of the block the batch was pinned in,
+	// so this happens before (and outside of) the database transaction
+	var registered bool
+	var unregisteredReason string
+	if err := em.retry.Do(em.ctx, "verify identity registered", func(attempt int) (retry bool, err error) {
+		registered, unregisteredReason, err = em.verifyIdentityRegistered(em.ctx, batch, batchPin.Event.Info)
+		return err != nil, err // retry indefinitely (until context closes)
+	}); err != nil {
+		return err
 	}
-	body.Close()
 
 	// At this point the batch is parsed, so any errors in processing need to be considered as:
 	// 1) Retryable - any transient error returned by processBatch is retried indefinitely
-	// 2) Swallowable - the data is invalid, and we have to move onto subsequent messages
+	// 2) Quarantined - the data is invalid, so we record it and move onto subsequent messages
 	// 3) Server shutting down - the context is cancelled (handled by retry)
-	return em.retry.Do(em.ctx, "persist batch", func(attempt int) (bool, error) {
+	var valid bool
+	err := em.retry.Do(em.ctx, "persist batch", func(attempt int) (bool, error) {
 		// We process the batch into the DB as a single transaction (if transactions are supported), both for
 		// efficiency and to minimize the chance of duplicates (although at-least-once delivery is the core model)
 		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
@@ -127,12 +170,121 @@ func (em *eventManager) handleBroadcastPinComplete(batchPin *blockchain.BatchPin
 
 			// Note that in the case of a bad batch broadcast, we don't store the pin. Because we know we
 			// are never going to be able to process it (we retrieved it successfully, it's just invalid).
-			valid, err := em.persistBatchFromBroadcast(ctx, batch, batchPin.BatchHash, signingIdentity)
-			if valid && err == nil {
-				err = em.persistContexts(ctx, batchPin, false)
+			// Instead we quarantine the batch, so it can be inspected and re-processed later if required.
+			reason := unregisteredReason
+			var err error
+			valid = registered
+			if valid {
+				valid, reason, err = em.persistBatchFromBroadcast(ctx, batch, batchPin.BatchHash, signingIdentity, batchPin.Event.Timestamp)
+			}
+			if err == nil {
+				if valid {
+					err = em.persistContexts(ctx, batchPin, false)
+				} else {
+					payload, _ := json.Marshal(batch)
+					err = em.quarantineBatch(ctx, batchPin, signingIdentity, payload, reason)
+				}
 			}
 			return err
 		})
 		return err != nil, err // retry indefinitely (until context closes)
 	})
+	if err == nil && valid {
+		em.batchCache.Set(batch.ID.String(), batch.Hash, em.batchCacheTTL)
+	}
+	return err
+}
+
+// isConfirmedBatch checks the cache of recently confirmed batches, and then the database, to determine
+// whether we have already persisted the batch referred to by a pin (with a matching hash)
+func (em *eventManager) isConfirmedBatch(batchPin *blockchain.BatchPin) (bool, error) {
+	if batchPin.BatchID == nil || batchPin.BatchHash == nil {
+		return false, nil
+	}
+	key := batchPin.BatchID.String()
+	if cached := em.batchCache.Get(key); cached != nil {
+		cached.Extend(em.batchCacheTTL)
+		return batchPin.BatchHash.Equals(cached.Value().(*fftypes.Bytes32)), nil
+	}
+	batch, err := em.database.GetBatchByID(em.ctx, batchPin.BatchID)
+	if err != nil || batch == nil || batch.Confirmed == nil {
+		return false, err
+	}
+	em.batchCache.Set(key, batch.Hash, em.batchCacheTTL)
+	return batchPin.BatchHash.Equals(batch.Hash), nil
+}
+
+// publicStorageFor returns the public storage the batches of a namespace are published to, which is the
+// default public storage of the node unless the namespace is configured with its own
+func (em *eventManager) publicStorageFor(ns string) publicstorage.Plugin {
+	if pi, ok := em.nsPublicStorage[ns]; ok {
+		return pi
+	}
+	return em.publicstorage
+}
+
+// retrieveBatch streams the batch from public storage, decoding it as it is read, and stopping if the
+// configured size limit is exceeded, or the payload does not match the hash pinned on-chain.
+// In strict mode, fields that are not known are also rejected.
+// Failures to read the data are returned as a retryable error, while problems with the data itself
+// are returned as a parse error.
+func (em *eventManager) retrieveBatch(ns, payloadRef string, expectedHash *fftypes.Bytes32, strict bool) (batch *fftypes.Batch, parseErr error, err error) {
+	ps := em.publicStorageFor(ns)
+	ctx, span := tracing.StartSpan(em.ctx, "publicstorage.RetrieveData",
+		attribute.String("ns", ns),
+		attribute.String("payloadRef", payloadRef),
+	)
+	defer func() {
+		if err != nil {
+			tracing.EndSpan(span, err)
+		} else {
+			tracing.EndSpan(span, parseErr)
+		}
+	}()
+
+	body, err := ps.RetrieveData(ctx, payloadRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+
+	// The limit applies both to the payload as stored, and once decompressed
+	raw := &limitedPayloadReader{ctx: em.ctx, r: body, limit: em.maxBatchPayloadSize}
+	r, parseErr := decompressPayload(raw)
+	lr := &limitedPayloadReader{ctx: em.ctx, r: r, limit: em.maxBatchPayloadSize}
+	if parseErr == nil {
+		batch, parseErr = decodeBatch(em.ctx, lr, expectedHash, strict)
+	}
+	switch {
+	case raw.readError != nil:
+		return nil, nil, raw.readError
+	case raw.exceeded || lr.exceeded:
+		return nil, i18n.NewError(em.ctx, i18n.MsgBatchPayloadTooLarge, em.maxBatchPayloadSize), nil
+	}
+	return batch, parseErr, nil
+}
+
+// retrievePayload retrieves the raw payload of a batch that could not be parsed, so it can be quarantined.
+// Payloads that exceed the size limit are not retained.
+func (em *eventManager) retrievePayload(ns, payloadRef string) ([]byte, error) {
+	body, err := em.publicStorageFor(ns).RetrieveData(em.ctx, payloadRef)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	payload, err := ioutil.ReadAll(io.LimitReader(body, em.maxBatchPayloadSize+1))
+	if err != nil || int64(len(payload)) > em.maxBatchPayloadSize {
+		return nil, err
+	}
+	// Compressed payloads are retained uncompressed if possible, so they can be inspected and re-processed
+	if bytes.HasPrefix(payload, gzipMagic) {
+		if zr, err := gzip.NewReader(bytes.NewReader(payload)); err == nil {
+			uncompressed, err := ioutil.ReadAll(io.LimitReader(zr, em.maxBatchPayloadSize+1))
+			if err == nil && int64(len(uncompressed)) <= em.maxBatchPayloadSize {
+				payload = uncompressed
+			}
+		}
+	}
+	return payload, nil
 }
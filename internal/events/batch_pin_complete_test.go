@@ -18,20 +18,26 @@ package events
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
 
+	"github.com/hyperledger/firefly/mocks/batchvalidatormocks"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/metricsmocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/batchvalidator"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -81,6 +87,7 @@ func TestBatchPinCompleteOkBroadcast(t *testing.T) {
 			Name:           "BatchPin",
 			BlockchainTXID: "0x12345",
 			ProtocolID:     "10/20/30",
+			Timestamp:      fftypes.Now(),
 		},
 	}
 	batchData := &fftypes.Batch{
@@ -118,6 +125,8 @@ func TestBatchPinCompleteOkBroadcast(t *testing.T) {
 		Return(true, nil)
 
 	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(nil, nil).Once()
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
 	rag.RunFn = func(a mock.Arguments) {
 		// Call through to persistBatch - the hash of our batch will be invalid,
@@ -141,11 +150,91 @@ func TestBatchPinCompleteOkBroadcast(t *testing.T) {
 	mbi := &blockchainmocks.Plugin{}
 
 	mim := em.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKeyIdentity", mock.Anything, "0x12345").Return("author1", nil)
+	mim.On("ResolveSigningKeyAuthor", mock.Anything, mock.Anything, "0x12345", batch.Event.Timestamp).Return("author1", nil)
 
 	err = em.BatchPinComplete(mbi, batch, "0x12345")
 	assert.NoError(t, err)
 
+	// A re-delivery of the same event is acknowledged from the cache, without retrieving the batch again
+	err = em.BatchPinComplete(mbi, batch, "0x12345")
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mpi.AssertNumberOfCalls(t, "RetrieveData", 1)
+}
+
+func TestBatchPinCompleteDuplicateConfirmed(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{
+		Namespace:       "ns1",
+		TransactionID:   fftypes.NewUUID(),
+		BatchID:         fftypes.NewUUID(),
+		BatchHash:       fftypes.NewRandB32(),
+		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(&fftypes.Batch{
+		ID:        batch.BatchID,
+		Hash:      batch.BatchHash,
+		Confirmed: fftypes.Now(),
+	}, nil).Once()
+
+	err := em.BatchPinComplete(&blockchainmocks.Plugin{}, batch, "0x12345")
+	assert.NoError(t, err)
+	err = em.BatchPinComplete(&blockchainmocks.Plugin{}, batch, "0x12345")
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestIsConfirmedBatchHashMismatch(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{
+		BatchID:   fftypes.NewUUID(),
+		BatchHash: fftypes.NewRandB32(),
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(&fftypes.Batch{
+		ID:        batch.BatchID,
+		Hash:      fftypes.NewRandB32(),
+		Confirmed: fftypes.Now(),
+	}, nil).Once()
+
+	duplicate, err := em.isConfirmedBatch(batch)
+	assert.NoError(t, err)
+	assert.False(t, duplicate)
+
+	duplicate, err = em.isConfirmedBatch(batch)
+	assert.NoError(t, err)
+	assert.False(t, duplicate)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteCheckBatchFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to avoid infinite retry
+
+	batch := &blockchain.BatchPin{
+		Namespace:       "ns1",
+		TransactionID:   fftypes.NewUUID(),
+		BatchID:         fftypes.NewUUID(),
+		BatchHash:       fftypes.NewRandB32(),
+		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(nil, fmt.Errorf("pop"))
+
+	err := em.BatchPinComplete(&blockchainmocks.Plugin{}, batch, "0x12345")
+	assert.Regexp(t, "FF10158", err)
+
 	mdi.AssertExpectations(t)
 }
 
@@ -158,6 +247,7 @@ func TestBatchPinCompleteOkPrivate(t *testing.T) {
 		TransactionID: fftypes.NewUUID(),
 		BatchID:       fftypes.NewUUID(),
 		Contexts:      []*fftypes.Bytes32{fftypes.NewRandB32()},
+		Ledger:        "ledger2",
 		Event: blockchain.Event{
 			BlockchainTXID: "0x12345",
 		},
@@ -189,7 +279,9 @@ func TestBatchPinCompleteOkPrivate(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
-	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertPin", mock.Anything, mock.MatchedBy(func(p *fftypes.Pin) bool {
+		return p.Ledger == "ledger2"
+	})).Return(nil)
 	mbi := &blockchainmocks.Plugin{}
 
 	err = em.BatchPinComplete(mbi, batch, "0xffffeeee")
@@ -239,14 +331,409 @@ func TestBatchPinCompleteBadData(t *testing.T) {
 		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
 		Contexts:        []*fftypes.Bytes32{fftypes.NewRandB32()},
 	}
-	batchReadCloser := ioutil.NopCloser(bytes.NewReader([]byte(`!json`)))
 
 	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
-	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(batchReadCloser, nil)
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader([]byte(`!json`))), nil).Once()
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop")).Once()
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader([]byte(`!json`))), nil).Once()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("InsertQuarantinedBatch", mock.Anything, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Batch.Equals(batch.BatchID) && qb.Payload == "!json" && qb.Key == "0xffffeeee" && len(qb.Contexts) == 1
+	})).Return(nil)
 	mbi := &blockchainmocks.Plugin{}
 
 	err := em.BatchPinComplete(mbi, batch, "0xffffeeee")
 	assert.NoError(t, err) // We do not return a blocking error in the case of bad data stored in IPFS
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteTooLarge(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.maxBatchPayloadSize = 5
+
+	batch := &blockchain.BatchPin{
+		Namespace:       "ns",
+		TransactionID:   fftypes.NewUUID(),
+		BatchID:         fftypes.NewUUID(),
+		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:        []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader([]byte(`{"id":"too long"}`))), nil).Once()
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader([]byte(`{"id":"too long"}`))), nil).Once()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("InsertQuarantinedBatch", mock.Anything, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Batch.Equals(batch.BatchID) && qb.Payload == "" && strings.Contains(qb.Reason, "FF10359")
+	})).Return(nil)
+	mbi := &blockchainmocks.Plugin{}
+
+	err := em.BatchPinComplete(mbi, batch, "0xffffeeee")
+	assert.NoError(t, err)
+
+	mpi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteHashMismatch(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batchData := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	b, _ := json.Marshal(batchData)
+
+	batch := &blockchain.BatchPin{
+		Namespace:       "ns",
+		TransactionID:   fftypes.NewUUID(),
+		BatchID:         batchData.ID,
+		BatchHash:       fftypes.NewRandB32(),
+		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:        []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader(b)), nil).Once()
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader(b)), nil).Once()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(nil, nil)
+	mdi.On("InsertQuarantinedBatch", mock.Anything, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Batch.Equals(batch.BatchID) && qb.Payload == string(b) && strings.Contains(qb.Reason, "FF10428")
+	})).Return(nil)
+	mbi := &blockchainmocks.Plugin{}
+
+	err := em.BatchPinComplete(mbi, batch, "0xffffeeee")
+	assert.NoError(t, err)
+
+	mpi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+type errorReader struct{}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("pop")
+}
+
+func TestRetrieveBatchReadError(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, "ref1").Return(ioutil.NopCloser(&errorReader{}), nil)
+
+	batch, parseErr, err := em.retrieveBatch("ns1", "ref1", nil, false)
+	assert.Nil(t, batch)
+	assert.NoError(t, parseErr)
+	assert.Regexp(t, "pop", err)
+
+	mpi.AssertExpectations(t)
+}
+
+func TestRetrievePayloadReadError(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, "ref1").Return(ioutil.NopCloser(&errorReader{}), nil)
+
+	_, err := em.retrievePayload("ns1", "ref1")
+	assert.Regexp(t, "pop", err)
+
+	mpi.AssertExpectations(t)
+}
+
+func TestRetrievePayloadNamespacePublicStorage(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	nsps := &publicstoragemocks.Plugin{}
+	em.nsPublicStorage = map[string]publicstorage.Plugin{"ns2": nsps}
+
+	nsps.On("RetrieveData", mock.Anything, "ref1").Return(ioutil.NopCloser(bytes.NewReader([]byte("payload"))), nil)
+
+	payload, err := em.retrievePayload("ns2", "ref1")
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", string(payload))
+
+	nsps.AssertExpectations(t)
+}
+
+func gzipBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(b)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func TestRetrieveBatchCompressed(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batchData := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	batchData.Encoding = fftypes.BatchEncodingGzip
+	b, _ := json.Marshal(batchData)
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, "ref1").Return(ioutil.NopCloser(bytes.NewReader(gzipBytes(b))), nil)
+
+	batch, parseErr, err := em.retrieveBatch("ns1", "ref1", batchData.Hash, true)
+	assert.NoError(t, err)
+	assert.NoError(t, parseErr)
+	assert.Equal(t, batchData.ID, batch.ID)
+	assert.Equal(t, fftypes.BatchEncodingGzip, batch.Encoding)
+
+	mpi.AssertExpectations(t)
+}
+
+func TestRetrieveBatchCompressedTooLarge(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.maxBatchPayloadSize = 1000
+
+	b := append(bytes.Repeat([]byte(" "), 10000), []byte(`{}`)...)
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, "ref1").Return(ioutil.NopCloser(bytes.NewReader(gzipBytes(b))), nil)
+
+	batch, parseErr, err := em.retrieveBatch("ns1", "ref1", nil, false)
+	assert.NoError(t, err)
+	assert.Nil(t, batch)
+	assert.Regexp(t, "FF10359", parseErr)
+
+	mpi.AssertExpectations(t)
+}
+
+func TestRetrieveBatchCompressedCorrupt(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, "ref1").Return(ioutil.NopCloser(bytes.NewReader([]byte{0x1f, 0x8b, 0x00})), nil)
+
+	batch, parseErr, err := em.retrieveBatch("ns1", "ref1", nil, false)
+	assert.NoError(t, err)
+	assert.Nil(t, batch)
+	assert.Error(t, parseErr)
+
+	mpi.AssertExpectations(t)
+}
+
+func TestRetrievePayloadCompressed(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, "ref1").Return(ioutil.NopCloser(bytes.NewReader(gzipBytes([]byte(`{"id":"bad"}`)))), nil)
+
+	payload, err := em.retrievePayload("ns1", "ref1")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":"bad"}`, string(payload))
+
+	mpi.AssertExpectations(t)
+}
+
+func TestRetrievePayloadCompressedCorrupt(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	corrupt := []byte{0x1f, 0x8b, 0x00}
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, "ref1").Return(ioutil.NopCloser(bytes.NewReader(corrupt)), nil)
+
+	payload, err := em.retrievePayload("ns1", "ref1")
+	assert.NoError(t, err)
+	assert.Equal(t, corrupt, payload)
+
+	mpi.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteQuarantineInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{
+		Namespace:       "ns1",
+		TransactionID:   fftypes.NewUUID(),
+		BatchID:         fftypes.NewUUID(),
+		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:        []*fftypes.Bytes32{fftypes.NewRandB32()},
+		Event: blockchain.Event{
+			Name:           "BatchPin",
+			BlockchainTXID: "0x12345",
+			ProtocolID:     "10/20/30",
+		},
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		PayloadRef: batch.BatchPayloadRef,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   batch.TransactionID,
+			},
+		},
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader(batchDataBytes))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPayloadRef).Return(batchReadCloser, nil)
+
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mth.On("PersistTransaction", mock.Anything, "ns1", batch.TransactionID, fftypes.TransactionTypeBatchPin, "0x12345").Return(true, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("InsertBlockchainEvent", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertQuarantinedBatch", mock.Anything, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Batch.Equals(batch.BatchID) &&
+			qb.Payload == string(batchDataBytes) &&
+			qb.Reason == "Author 'author1' could not be resolved: pop"
+	})).Return(nil)
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyAuthor", mock.Anything, mock.Anything, "0x12345", mock.Anything).Return("", fmt.Errorf("pop"))
+
+	err = em.BatchPinComplete(&blockchainmocks.Plugin{}, batch, "0x12345")
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteQuarantineNotRegistered(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	batch := &blockchain.BatchPin{
+		Namespace:       "ns1",
+		TransactionID:   fftypes.NewUUID(),
+		BatchID:         fftypes.NewUUID(),
+		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:        []*fftypes.Bytes32{fftypes.NewRandB32()},
+		Event: blockchain.Event{
+			Name:           "BatchPin",
+			BlockchainTXID: "0x12345",
+			ProtocolID:     "10/20/30",
+			Info:           fftypes.JSONObject{"blockNumber": "10"},
+		},
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		PayloadRef: batch.BatchPayloadRef,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   batch.TransactionID,
+			},
+		},
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader(batchDataBytes))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPayloadRef).Return(batchReadCloser, nil)
+
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", mock.Anything, "0x12345", fftypes.NewFFBigInt(10)).Return(false, nil)
+
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mth.On("PersistTransaction", mock.Anything, "ns1", batch.TransactionID, fftypes.TransactionTypeBatchPin, "0x12345").Return(true, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("InsertBlockchainEvent", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertQuarantinedBatch", mock.Anything, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Batch.Equals(batch.BatchID) &&
+			qb.Reason == "Signing key '0x12345' is not registered in the on-chain identity registry"
+	})).Return(nil)
+
+	err = em.BatchPinComplete(&blockchainmocks.Plugin{}, batch, "0x12345")
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteRegistryFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to avoid infinite retry
+	em.verifyIdentityReg = true
+
+	batch := &blockchain.BatchPin{
+		Namespace:       "ns1",
+		TransactionID:   fftypes.NewUUID(),
+		BatchID:         fftypes.NewUUID(),
+		BatchPayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		PayloadRef: batch.BatchPayloadRef,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   batch.TransactionID,
+			},
+		},
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader(batchDataBytes))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPayloadRef).Return(batchReadCloser, nil)
+
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", mock.Anything, "0x12345", (*fftypes.FFBigInt)(nil)).Return(false, fmt.Errorf("pop"))
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", mock.Anything, batch.BatchID).Return(nil, nil)
+
+	err = em.BatchPinComplete(&blockchainmocks.Plugin{}, batch, "0x12345")
+	assert.Regexp(t, "FF10158", err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
 }
 
 func TestBatchPinCompleteNoTX(t *testing.T) {
@@ -260,6 +747,22 @@ func TestBatchPinCompleteNoTX(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBatchPinCompleteMetrics(t *testing.T) {
+	em, cancel := newTestEventManagerWithMetrics(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{Namespace: "ns1"}
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ethereum")
+	mmi := em.metrics.(*metricsmocks.Manager)
+	mmi.On("BlockchainEventReceived", "ethereum", "ns1", "batchpin").Return()
+
+	err := em.BatchPinComplete(mbi, batch, "0x12345")
+	assert.NoError(t, err)
+
+	mmi.AssertCalled(t, "BlockchainEventReceived", "ethereum", "ns1", "batchpin")
+}
+
 func TestBatchPinCompleteBadNamespace(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -280,7 +783,7 @@ func TestBatchPinCompleteBadNamespace(t *testing.T) {
 func TestPersistBatchMissingID(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
-	valid, err := em.persistBatch(context.Background(), &fftypes.Batch{})
+	valid, _, err := em.persistBatch(context.Background(), &fftypes.Batch{})
 	assert.False(t, valid)
 	assert.NoError(t, err)
 }
@@ -304,9 +807,9 @@ func TestPersistBatchAuthorResolveFail(t *testing.T) {
 		Hash: batchHash,
 	}
 	mim := em.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKeyIdentity", mock.Anything, mock.Anything).Return("", fmt.Errorf("pop"))
+	mim.On("ResolveSigningKeyAuthor", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("", fmt.Errorf("pop"))
 	batch.Hash = batch.Payload.Hash()
-	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345")
+	valid, _, err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345", nil)
 	assert.NoError(t, err) // retryable
 	assert.False(t, valid)
 }
@@ -330,9 +833,9 @@ func TestPersistBatchBadAuthor(t *testing.T) {
 		Hash: batchHash,
 	}
 	mim := em.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKeyIdentity", mock.Anything, mock.Anything).Return("author2", nil)
+	mim.On("ResolveSigningKeyAuthor", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("author2", nil)
 	batch.Hash = batch.Payload.Hash()
-	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345")
+	valid, _, err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345", nil)
 	assert.NoError(t, err)
 	assert.False(t, valid)
 }
@@ -355,9 +858,9 @@ func TestPersistBatchMismatchChainHash(t *testing.T) {
 		Hash: fftypes.NewRandB32(),
 	}
 	mim := em.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKeyIdentity", mock.Anything, mock.Anything).Return("author1", nil)
+	mim.On("ResolveSigningKeyAuthor", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("author1", nil)
 	batch.Hash = batch.Payload.Hash()
-	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, fftypes.NewRandB32(), "0x12345")
+	valid, _, err := em.persistBatchFromBroadcast(context.Background(), batch, fftypes.NewRandB32(), "0x12345", nil)
 	assert.NoError(t, err)
 	assert.False(t, valid)
 }
@@ -383,7 +886,7 @@ func TestPersistBatchUpsertBatchMismatchHash(t *testing.T) {
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(database.HashMismatch)
 
-	valid, err := em.persistBatch(context.Background(), batch)
+	valid, _, err := em.persistBatch(context.Background(), batch)
 	assert.False(t, valid)
 	assert.NoError(t, err)
 	mdi.AssertExpectations(t)
@@ -407,9 +910,67 @@ func TestPersistBatchBadHash(t *testing.T) {
 	}
 	batch.Hash = fftypes.NewRandB32()
 
-	valid, err := em.persistBatch(context.Background(), batch)
+	valid, _, err := em.persistBatch(context.Background(), batch)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestPersistBatchValidatorsOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mbv1 := &batchvalidatormocks.Plugin{}
+	mbv1.On("ValidateBatch", mock.Anything, batch).Return(true, "", nil)
+	mbv2 := &batchvalidatormocks.Plugin{}
+	mbv2.On("ValidateBatch", mock.Anything, batch).Return(true, "", nil)
+	em.batchValidators = []batchvalidator.Plugin{mbv1, mbv2}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+
+	valid, _, err := em.persistBatch(context.Background(), batch)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+	mbv1.AssertExpectations(t)
+	mbv2.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestPersistBatchValidatorReject(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mbv1 := &batchvalidatormocks.Plugin{}
+	mbv1.On("Name").Return("rejecter")
+	mbv1.On("ValidateBatch", mock.Anything, batch).Return(false, "author not allowed", nil)
+	mbv2 := &batchvalidatormocks.Plugin{}
+	em.batchValidators = []batchvalidator.Plugin{mbv1, mbv2}
+
+	valid, reason, err := em.persistBatch(context.Background(), batch)
 	assert.False(t, valid)
+	assert.Equal(t, "Rejected by batch validator 'rejecter': author not allowed", reason)
 	assert.NoError(t, err)
+	mbv1.AssertExpectations(t)
+	mbv2.AssertNotCalled(t, "ValidateBatch", mock.Anything, mock.Anything)
+}
+
+func TestPersistBatchValidatorRetry(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mbv := &batchvalidatormocks.Plugin{}
+	mbv.On("Name").Return("unavailable")
+	mbv.On("ValidateBatch", mock.Anything, batch).Return(false, "", fmt.Errorf("pop"))
+	em.batchValidators = []batchvalidator.Plugin{mbv}
+
+	valid, _, err := em.persistBatch(context.Background(), batch)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+	mbv.AssertExpectations(t)
 }
 
 func TestPersistBatchUpsertBatchFail(t *testing.T) {
@@ -433,7 +994,7 @@ func TestPersistBatchUpsertBatchFail(t *testing.T) {
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
-	valid, err := em.persistBatch(context.Background(), batch)
+	valid, _, err := em.persistBatch(context.Background(), batch)
 	assert.False(t, valid)
 	assert.EqualError(t, err, "pop")
 }
@@ -462,7 +1023,7 @@ func TestPersistBatchSwallowBadData(t *testing.T) {
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
 
-	valid, err := em.persistBatch(context.Background(), batch)
+	valid, _, err := em.persistBatch(context.Background(), batch)
 	assert.False(t, valid)
 	assert.NoError(t, err)
 	mdi.AssertExpectations(t)
@@ -496,7 +1057,7 @@ func TestPersistBatchGoodDataUpsertOptimizeExistingFail(t *testing.T) {
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpsertData", mock.Anything, mock.Anything, database.UpsertOptimizationExisting).Return(fmt.Errorf("pop"))
 
-	valid, err := em.persistBatch(context.Background(), batch)
+	valid, _, err := em.persistBatch(context.Background(), batch)
 	assert.False(t, valid)
 	assert.EqualError(t, err, "pop")
 }
@@ -529,7 +1090,7 @@ func TestPersistBatchGoodDataUpsertOptimizeNewFail(t *testing.T) {
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpsertData", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
 
-	valid, err := em.persistBatch(context.Background(), batch)
+	valid, _, err := em.persistBatch(context.Background(), batch)
 	assert.False(t, valid)
 	assert.EqualError(t, err, "pop")
 }
@@ -547,7 +1108,7 @@ func TestPersistBatchGoodDataMessageFail(t *testing.T) {
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationSkip).Return(fmt.Errorf("pop"))
 
-	valid, err := em.persistBatch(context.Background(), batch)
+	valid, _, err := em.persistBatch(context.Background(), batch)
 	assert.False(t, valid)
 	assert.EqualError(t, err, "pop")
 }
@@ -564,7 +1125,7 @@ func TestPersistBatchGoodMessageAuthorMismatch(t *testing.T) {
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
 
-	valid, err := em.persistBatch(context.Background(), batch)
+	valid, _, err := em.persistBatch(context.Background(), batch)
 	assert.False(t, valid)
 	assert.NoError(t, err)
 }
@@ -54,7 +54,9 @@ func (em *eventManager) persistBlockchainEvent(ctx context.Context, chainEvent *
 }
 
 func (em *eventManager) BlockchainEvent(event *blockchain.EventWithSubscription) error {
-	return em.retry.Do(em.ctx, "persist contract event", func(attempt int) (bool, error) {
+	// The namespace is that of the subscription, so the event is counted once it has been looked up
+	var namespace string
+	err := em.retry.Do(em.ctx, "persist contract event", func(attempt int) (bool, error) {
 		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
 			// TODO: should cache this lookup for efficiency
 			sub, err := em.database.GetContractSubscriptionByProtocolID(ctx, event.Subscription)
@@ -65,6 +67,7 @@ func (em *eventManager) BlockchainEvent(event *blockchain.EventWithSubscription)
 				log.L(ctx).Warnf("Event received from unknown subscription %s", event.Subscription)
 				return nil // no retry
 			}
+			namespace = sub.Namespace
 
 			chainEvent := buildBlockchainEvent(sub.Namespace, sub.ID, &event.Event, nil)
 			if err := em.persistBlockchainEvent(ctx, chainEvent); err != nil {
@@ -74,4 +77,8 @@ func (em *eventManager) BlockchainEvent(event *blockchain.EventWithSubscription)
 		})
 		return err != nil, err
 	})
+	if err == nil && em.metrics.IsMetricsEnabled() {
+		em.metrics.BlockchainEventReceived(event.Source, namespace, "contract")
+	}
+	return err
 }
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/metricsmocks"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -95,3 +96,31 @@ func TestContractEventUnknownSubscription(t *testing.T) {
 
 	mdi.AssertExpectations(t)
 }
+
+func TestContractEventMetrics(t *testing.T) {
+	em, cancel := newTestEventManagerWithMetrics(t)
+	defer cancel()
+
+	ev := &blockchain.EventWithSubscription{
+		Subscription: "sb-1",
+		Event: blockchain.Event{
+			Source: "ethereum",
+			Name:   "Changed",
+		},
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetContractSubscriptionByProtocolID", mock.Anything, "sb-1").Return(&fftypes.ContractSubscription{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+	}, nil)
+	mdi.On("InsertBlockchainEvent", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	mmi := em.metrics.(*metricsmocks.Manager)
+	mmi.On("BlockchainEventReceived", "ethereum", "ns1", "contract").Return()
+
+	err := em.BlockchainEvent(ev)
+	assert.NoError(t, err)
+
+	mmi.AssertCalled(t, "BlockchainEventReceived", "ethereum", "ns1", "contract")
+}
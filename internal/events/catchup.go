@@ -0,0 +1,295 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/antireplay"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// StartCatchup backfills the history of broadcast batches, for a member that joined the network late.
+//
+// A peer catch-up requests pages of confirmed broadcast batches from an existing member over data exchange.
+// The pin of each batch is looked up on the blockchain, from the transaction the member reports pinned it, then the
+// batch is processed exactly as if its pin had arrived from the blockchain. The operation output records the progress
+// through the batches known to that member.
+//
+// A chain catch-up asks the blockchain connector to re-deliver all batch pins from the genesis block, which
+// applies to all namespaces. Batches that are already confirmed are skipped as duplicates.
+func (em *eventManager) StartCatchup(ctx context.Context, ns string, req *fftypes.CatchupRequest) (*fftypes.Operation, error) {
+	switch {
+	case req.Source.Equals(fftypes.CatchupSourcePeer):
+		return em.startPeerCatchup(ctx, ns, req)
+	case req.Source.Equals(fftypes.CatchupSourceChain):
+		return em.startChainCatchup(ctx, ns)
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownCatchupSource, req.Source)
+	}
+}
+
+func (em *eventManager) newCatchupOperation(ctx context.Context, plugin fftypes.Named, ns string, opType fftypes.OpType, input fftypes.JSONObject) (op *fftypes.Operation, err error) {
+	err = em.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		txid, err := em.txHelper.SubmitNewTransaction(ctx, ns, fftypes.TransactionTypeCatchup, "")
+		if err != nil {
+			return err
+		}
+		op = fftypes.NewOperation(plugin, ns, txid, opType)
+		op.Input = input
+		return em.database.InsertOperation(ctx, op)
+	})
+	return op, err
+}
+
+func (em *eventManager) startChainCatchup(ctx context.Context, ns string) (*fftypes.Operation, error) {
+	op, err := em.newCatchupOperation(ctx, em.blockchain, ns, fftypes.OpTypeBlockchainCatchup, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = em.blockchain.ResetBatchPinSubscription(ctx); err != nil {
+		em.txHelper.WriteOperationFailure(ctx, op, err)
+		return nil, err
+	}
+	em.txHelper.WriteOperationSuccess(ctx, op.ID, nil)
+	op.Status = fftypes.OpStatusSucceeded
+	return op, nil
+}
+
+func (em *eventManager) resolveCatchupNode(ctx context.Context, nameOrID string) (node *fftypes.Node, err error) {
+	if nameOrID == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "node")
+	}
+	if id, parseErr := fftypes.ParseUUID(ctx, nameOrID); parseErr == nil {
+		node, err = em.database.GetNodeByID(ctx, id)
+	} else {
+		var nodes []*fftypes.Node
+		nodes, _, err = em.database.GetNodes(ctx, database.NodeQueryFactory.NewFilter(ctx).Eq("name", nameOrID))
+		if len(nodes) > 0 {
+			node = nodes[0]
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if node == nil || node.DX.Peer == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgNodeNotFound, nameOrID)
+	}
+	if node.ID.Equals(em.ni.GetNodeUUID(ctx)) {
+		return nil, i18n.NewError(ctx, i18n.MsgCatchupFromLocalNode, node.Name)
+	}
+	return node, nil
+}
+
+func (em *eventManager) startPeerCatchup(ctx context.Context, ns string, req *fftypes.CatchupRequest) (*fftypes.Operation, error) {
+	node, err := em.resolveCatchupNode(ctx, req.Node)
+	if err != nil {
+		return nil, err
+	}
+	op, err := em.newCatchupOperation(ctx, em.dataexchange, ns, fftypes.OpTypeDataExchangeCatchup, fftypes.JSONObject{
+		"node": node.ID.String(),
+		"peer": node.DX.Peer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = em.sendCatchupRequest(ctx, op, node.DX.Peer, 0); err != nil {
+		em.txHelper.WriteOperationFailure(ctx, op, err)
+		return nil, err
+	}
+	return op, nil
+}
+
+func (em *eventManager) sendCatchupRequest(ctx context.Context, op *fftypes.Operation, peerID string, skip uint64) error {
+	payload, _ := json.Marshal(&fftypes.TransportWrapper{
+		CatchupRequest: &fftypes.CatchupPeerRequest{
+			ID:        op.ID,
+			Namespace: op.Namespace,
+			Skip:      skip,
+			Limit:     em.catchupPageSize,
+		},
+		Sequence: antireplay.NextSequence(),
+	})
+	return em.dataexchange.SendMessage(ctx, op.ID, peerID, payload)
+}
+
+// catchupRequestReceived returns a page of the confirmed broadcast batches in a namespace to a member of the network,
+// in the order they were confirmed, so the requester processes them in the same order as they were pinned
+func (em *eventManager) catchupRequestReceived(peerID string, req *fftypes.CatchupPeerRequest) error {
+	l := log.L(em.ctx)
+	if req.ID == nil || fftypes.ValidateFFNameField(em.ctx, req.Namespace, "namespace") != nil {
+		l.Errorf("Invalid catch-up request from '%s'", peerID)
+		return nil
+	}
+	limit := req.Limit
+	if limit == 0 || limit > em.catchupPageSize {
+		limit = em.catchupPageSize
+	}
+
+	var res *fftypes.CatchupPeerResponse
+	err := em.retry.Do(em.ctx, "catch-up request", func(attempt int) (retry bool, err error) {
+		// Only registered members of the network can request the history
+		nodes, _, err := em.database.GetNodes(em.ctx, database.NodeQueryFactory.NewFilter(em.ctx).Eq("dx.peer", peerID))
+		if err != nil || len(nodes) == 0 {
+			return err != nil, err
+		}
+		res, err = em.buildCatchupResponse(req, limit)
+		return err != nil, err
+	})
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		l.Errorf("Catch-up request '%s' received from unknown peer '%s'", req.ID, peerID)
+		return nil
+	}
+
+	l.Infof("Catch-up request '%s' from '%s' namespace=%s skip=%d: returning %d of %d batches", req.ID, peerID, req.Namespace, req.Skip, len(res.Pins), res.Total)
+	payload, _ := json.Marshal(&fftypes.TransportWrapper{CatchupResponse: res, Sequence: antireplay.NextSequence()})
+	if err := em.dataexchange.SendMessage(em.ctx, req.ID, peerID, payload); err != nil {
+		// The requester can start a new catch-up, so we do not block the receipt of further messages
+		l.Errorf("Failed to send catch-up response '%s' to '%s': %s", req.ID, peerID, err)
+	}
+	return nil
+}
+
+func (em *eventManager) buildCatchupResponse(req *fftypes.CatchupPeerRequest, limit uint64) (*fftypes.CatchupPeerResponse, error) {
+	fb := database.BatchQueryFactory.NewFilter(em.ctx)
+	filter := fb.And(
+		fb.Eq("namespace", req.Namespace),
+		fb.Neq("payloadref", ""),
+		fb.Gt("confirmed", 0),
+	).Sort("confirmed").Ascending().Skip(req.Skip).Limit(limit).Count(true)
+	batches, fr, err := em.database.GetBatches(em.ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &fftypes.CatchupPeerResponse{
+		ID:        req.ID,
+		Namespace: req.Namespace,
+		Skip:      req.Skip,
+		Pins:      make([]*fftypes.CatchupBatchPin, len(batches)),
+	}
+	if fr != nil && fr.TotalCount != nil {
+		res.Total = *fr.TotalCount
+	}
+	for i, batch := range batches {
+		tx, err := em.database.GetTransactionByID(em.ctx, batch.Payload.TX.ID)
+		if err != nil {
+			return nil, err
+		}
+		pin := &fftypes.CatchupBatchPin{
+			Batch: batch.ID,
+			Hash:  batch.Hash,
+			TX:    batch.Payload.TX,
+		}
+		if tx != nil {
+			pin.BlockchainIDs = tx.BlockchainIDs
+		}
+		res.Pins[i] = pin
+	}
+	return res, nil
+}
+
+// catchupResponseReceived processes a page of broadcast batches from the member we requested them from,
+// then requests the next page until all the batches have been processed
+func (em *eventManager) catchupResponseReceived(peerID string, res *fftypes.CatchupPeerResponse) error {
+	l := log.L(em.ctx)
+	if res.ID == nil {
+		l.Errorf("Invalid catch-up response from '%s'", peerID)
+		return nil
+	}
+
+	var op *fftypes.Operation
+	err := em.retry.Do(em.ctx, "get catch-up operation", func(attempt int) (retry bool, err error) {
+		op, err = em.database.GetOperationByID(em.ctx, res.ID)
+		return err != nil, err
+	})
+	if err != nil {
+		return err
+	}
+	if op == nil || op.Type != fftypes.OpTypeDataExchangeCatchup || op.Status != fftypes.OpStatusPending ||
+		op.Namespace != res.Namespace || op.Input.GetString("peer") != peerID {
+		l.Errorf("Unexpected catch-up response '%s' from '%s'", res.ID, peerID)
+		return nil
+	}
+
+	status := fftypes.OpStatusSucceeded
+	errorMessage := ""
+	for _, pin := range res.Pins {
+		if pin.Batch == nil || pin.Hash == nil || len(pin.BlockchainIDs) == 0 {
+			l.Errorf("Invalid batch in catch-up response '%s' from '%s'", res.ID, peerID)
+			continue
+		}
+		batchPin, signingKey, err := em.getCatchupBatchPin(op.Namespace, pin)
+		if err != nil {
+			// The blockchain connector could not be queried, so the catch-up cannot safely continue
+			status = fftypes.OpStatusFailed
+			errorMessage = err.Error()
+			break
+		}
+		if batchPin == nil {
+			l.Errorf("Batch '%s' in catch-up response '%s' from '%s' was not pinned on the blockchain", pin.Batch, res.ID, peerID)
+			continue
+		}
+		if err := em.handleBroadcastPinComplete(batchPin, signingKey); err != nil {
+			return err
+		}
+	}
+
+	processed := res.Skip + uint64(len(res.Pins))
+	output := fftypes.JSONObject{
+		"processed": processed,
+		"total":     res.Total,
+	}
+	if status != fftypes.OpStatusFailed && len(res.Pins) > 0 && int64(processed) < res.Total {
+		status = fftypes.OpStatusPending
+		if err := em.sendCatchupRequest(em.ctx, op, peerID, processed); err != nil {
+			status = fftypes.OpStatusFailed
+			errorMessage = err.Error()
+		}
+	}
+	l.Infof("Catch-up '%s' from '%s' processed %d of %d batches", op.ID, peerID, processed, res.Total)
+	return em.retry.Do(em.ctx, "update catch-up operation", func(attempt int) (retry bool, err error) {
+		err = em.database.ResolveOperation(em.ctx, op.ID, status, errorMessage, output)
+		return err != nil, err
+	})
+}
+
+// getCatchupBatchPin looks up the pin of a batch on the blockchain, from the transactions the peer reports pinned it.
+// Only a broadcast pin of the same batch in the namespace is accepted, and the signing key is taken from the blockchain
+func (em *eventManager) getCatchupBatchPin(ns string, pin *fftypes.CatchupBatchPin) (*blockchain.BatchPin, string, error) {
+	bi := em.blockchainFor(ns)
+	for _, blockchainID := range pin.BlockchainIDs {
+		batchPin, signingKey, err := bi.GetBatchPin(em.ctx, blockchainID)
+		if err != nil {
+			return nil, "", err
+		}
+		if batchPin != nil && batchPin.Namespace == ns && batchPin.BatchID.Equals(pin.Batch) &&
+			batchPin.BatchHash.Equals(pin.Hash) && batchPin.BatchPayloadRef != "" {
+			batchPin.Ledger = em.nsLedgers[ns]
+			return batchPin, signingKey, nil
+		}
+	}
+	return nil, "", nil
+}
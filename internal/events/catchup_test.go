@@ -0,0 +1,625 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func matchCatchupRequest(opID *fftypes.UUID, skip uint64) interface{} {
+	return mock.MatchedBy(func(b []byte) bool {
+		var tw fftypes.TransportWrapper
+		_ = json.Unmarshal(b, &tw)
+		return tw.CatchupRequest != nil &&
+			tw.CatchupRequest.ID.Equals(opID) &&
+			tw.CatchupRequest.Namespace == "ns1" &&
+			tw.CatchupRequest.Skip == skip &&
+			tw.CatchupRequest.Limit == 25
+	})
+}
+
+func newTestCatchupOp() *fftypes.Operation {
+	return &fftypes.Operation{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Type:      fftypes.OpTypeDataExchangeCatchup,
+		Status:    fftypes.OpStatusPending,
+		Input:     fftypes.JSONObject{"peer": "peer1"},
+	}
+}
+
+func catchupResponseTransfer(res *fftypes.CatchupPeerResponse) []byte {
+	b, _ := json.Marshal(&fftypes.TransportWrapper{CatchupResponse: res})
+	return b
+}
+
+func catchupRequestTransfer(req *fftypes.CatchupPeerRequest) []byte {
+	b, _ := json.Marshal(&fftypes.TransportWrapper{CatchupRequest: req})
+	return b
+}
+
+func TestStartCatchupUnknownSource(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: "wrong"})
+	assert.Regexp(t, "FF10389", err)
+}
+
+func TestStartChainCatchupOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	txid := fftypes.NewUUID()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mbi.On("Name").Return("utbc")
+	mth.On("SubmitNewTransaction", em.ctx, "ns1", fftypes.TransactionTypeCatchup, fftypes.IdempotencyKey("")).Return(txid, nil)
+	mdi.On("InsertOperation", em.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeBlockchainCatchup && op.Transaction.Equals(txid) && op.Plugin == "utbc"
+	})).Return(nil)
+	mbi.On("ResetBatchPinSubscription", em.ctx).Return(nil)
+	mth.On("WriteOperationSuccess", em.ctx, mock.Anything, fftypes.JSONObject(nil)).Return()
+
+	op, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourceChain})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusSucceeded, op.Status)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+	mth.AssertExpectations(t)
+}
+
+func TestStartChainCatchupTxFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mth.On("SubmitNewTransaction", em.ctx, "ns1", fftypes.TransactionTypeCatchup, fftypes.IdempotencyKey("")).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourceChain})
+	assert.EqualError(t, err, "pop")
+
+	mth.AssertExpectations(t)
+}
+
+func TestStartChainCatchupResetFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mbi.On("Name").Return("utbc")
+	mth.On("SubmitNewTransaction", em.ctx, "ns1", fftypes.TransactionTypeCatchup, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", em.ctx, mock.Anything).Return(nil)
+	mbi.On("ResetBatchPinSubscription", em.ctx).Return(fmt.Errorf("pop"))
+	mth.On("WriteOperationFailure", em.ctx, mock.Anything, fmt.Errorf("pop")).Return()
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourceChain})
+	assert.EqualError(t, err, "pop")
+
+	mbi.AssertExpectations(t)
+	mth.AssertExpectations(t)
+}
+
+func TestStartPeerCatchupByNameOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	node := &fftypes.Node{ID: fftypes.NewUUID(), Name: "node2", DX: fftypes.DXInfo{Peer: "peer2"}}
+	var opID *fftypes.UUID
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := em.dataexchange.(*dataexchangemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+	mdx.On("Name").Return("utdx")
+	mth.On("SubmitNewTransaction", em.ctx, "ns1", fftypes.TransactionTypeCatchup, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", em.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		opID = op.ID
+		return op.Type == fftypes.OpTypeDataExchangeCatchup &&
+			op.Input.GetString("peer") == "peer2" &&
+			op.Input.GetString("node") == node.ID.String()
+	})).Return(nil)
+	mdx.On("SendMessage", em.ctx, mock.Anything, "peer2", mock.MatchedBy(func(b []byte) bool {
+		var tw fftypes.TransportWrapper
+		_ = json.Unmarshal(b, &tw)
+		return tw.CatchupRequest.ID.Equals(opID) && tw.CatchupRequest.Skip == 0 && tw.CatchupRequest.Limit == 25
+	})).Return(nil)
+
+	op, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourcePeer, Node: "node2"})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusPending, op.Status)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+	mth.AssertExpectations(t)
+}
+
+func TestStartPeerCatchupByIDOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	node := &fftypes.Node{ID: fftypes.NewUUID(), Name: "node2", DX: fftypes.DXInfo{Peer: "peer2"}}
+	em.database = &databasemocks.Plugin{}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := em.dataexchange.(*dataexchangemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mdi.On("GetNodeByID", em.ctx, node.ID).Return(node, nil)
+	mdi.On("RunAsGroup", em.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		args[1].(func(context.Context) error)(em.ctx)
+	}).Return(nil)
+	mdx.On("Name").Return("utdx")
+	mth.On("SubmitNewTransaction", em.ctx, "ns1", fftypes.TransactionTypeCatchup, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", em.ctx, mock.Anything).Return(nil)
+	mdx.On("SendMessage", em.ctx, mock.Anything, "peer2", mock.Anything).Return(nil)
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourcePeer, Node: node.ID.String()})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestStartPeerCatchupMissingNode(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourcePeer})
+	assert.Regexp(t, "FF10140.*node", err)
+}
+
+func TestStartPeerCatchupNodeLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourcePeer, Node: "node2"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestStartPeerCatchupNodeNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourcePeer, Node: "node2"})
+	assert.Regexp(t, "FF10224", err)
+}
+
+func TestStartPeerCatchupLocalNode(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{ID: testNodeID, Name: "node1", DX: fftypes.DXInfo{Peer: "peer1"}},
+	}, nil, nil)
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourcePeer, Node: "node1"})
+	assert.Regexp(t, "FF10390", err)
+}
+
+func TestStartPeerCatchupOpFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{ID: fftypes.NewUUID(), Name: "node2", DX: fftypes.DXInfo{Peer: "peer2"}},
+	}, nil, nil)
+	mth.On("SubmitNewTransaction", em.ctx, "ns1", fftypes.TransactionTypeCatchup, fftypes.IdempotencyKey("")).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourcePeer, Node: "node2"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestStartPeerCatchupSendFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := em.dataexchange.(*dataexchangemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{ID: fftypes.NewUUID(), Name: "node2", DX: fftypes.DXInfo{Peer: "peer2"}},
+	}, nil, nil)
+	mdx.On("Name").Return("utdx")
+	mth.On("SubmitNewTransaction", em.ctx, "ns1", fftypes.TransactionTypeCatchup, fftypes.IdempotencyKey("")).Return(fftypes.NewUUID(), nil)
+	mdi.On("InsertOperation", em.ctx, mock.Anything).Return(nil)
+	mdx.On("SendMessage", em.ctx, mock.Anything, "peer2", mock.Anything).Return(fmt.Errorf("pop"))
+	mth.On("WriteOperationFailure", em.ctx, mock.Anything, fmt.Errorf("pop")).Return()
+
+	_, err := em.StartCatchup(em.ctx, "ns1", &fftypes.CatchupRequest{Source: fftypes.CatchupSourcePeer, Node: "node2"})
+	assert.EqualError(t, err, "pop")
+
+	mth.AssertExpectations(t)
+}
+
+func TestCatchupRequestReceivedOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	reqID := fftypes.NewUUID()
+	batch := &fftypes.Batch{
+		ID:         fftypes.NewUUID(),
+		Hash:       fftypes.NewRandB32(),
+		PayloadRef: "ref1",
+		Identity:   fftypes.Identity{Key: "0x12345"},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{Type: fftypes.TransactionTypeBatchPin, ID: fftypes.NewUUID()},
+		},
+	}
+	total := int64(3)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	em.dataexchange = mdx
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{{Name: "node2"}}, nil, nil)
+	mdi.On("GetBatches", em.ctx, mock.MatchedBy(func(f database.Filter) bool {
+		fi, _ := f.Finalize()
+		return fi.Skip == 2 && fi.Limit == 25 && fi.Count
+	})).Return([]*fftypes.Batch{batch}, &database.FilterResult{TotalCount: &total}, nil)
+	mdi.On("GetTransactionByID", em.ctx, batch.Payload.TX.ID).Return(&fftypes.Transaction{
+		BlockchainIDs: fftypes.FFStringArray{"0x111"},
+	}, nil)
+	mdx.On("SendMessage", em.ctx, reqID, "peer2", mock.MatchedBy(func(b []byte) bool {
+		var tw fftypes.TransportWrapper
+		_ = json.Unmarshal(b, &tw)
+		res := tw.CatchupResponse
+		return tw.Sequence > 0 && res.ID.Equals(reqID) && res.Namespace == "ns1" && res.Skip == 2 && res.Total == 3 &&
+			len(res.Pins) == 1 && res.Pins[0].Batch.Equals(batch.ID) && res.Pins[0].Hash.Equals(batch.Hash) &&
+			res.Pins[0].TX.ID.Equals(batch.Payload.TX.ID) && res.Pins[0].BlockchainIDs.String() == "0x111"
+	})).Return(nil)
+
+	m, err := em.MessageReceived(mdx, "peer2", catchupRequestTransfer(&fftypes.CatchupPeerRequest{
+		ID: reqID, Namespace: "ns1", Skip: 2, Limit: 1000,
+	}))
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestCatchupRequestReceivedInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdx := &dataexchangemocks.Plugin{}
+	_, err := em.MessageReceived(mdx, "peer2", catchupRequestTransfer(&fftypes.CatchupPeerRequest{
+		Namespace: "ns1",
+	}))
+	assert.NoError(t, err)
+}
+
+func TestCatchupRequestReceivedUnknownPeer(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+
+	_, err := em.MessageReceived(mdx, "peer2", catchupRequestTransfer(&fftypes.CatchupPeerRequest{
+		ID: fftypes.NewUUID(), Namespace: "ns1",
+	}))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestCatchupRequestReceivedGetBatchesFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{{Name: "node2"}}, nil, nil)
+	mdi.On("GetBatches", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := em.MessageReceived(mdx, "peer2", catchupRequestTransfer(&fftypes.CatchupPeerRequest{
+		ID: fftypes.NewUUID(), Namespace: "ns1",
+	}))
+	assert.Regexp(t, "FF10158", err)
+}
+
+func TestCatchupRequestReceivedGetTransactionFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{{Name: "node2"}}, nil, nil)
+	mdi.On("GetBatches", em.ctx, mock.Anything).Return([]*fftypes.Batch{{ID: fftypes.NewUUID()}}, nil, nil)
+	mdi.On("GetTransactionByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.MessageReceived(mdx, "peer2", catchupRequestTransfer(&fftypes.CatchupPeerRequest{
+		ID: fftypes.NewUUID(), Namespace: "ns1",
+	}))
+	assert.Regexp(t, "FF10158", err)
+}
+
+func TestCatchupRequestReceivedSendFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	em.dataexchange = mdx
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{{Name: "node2"}}, nil, nil)
+	mdi.On("GetBatches", em.ctx, mock.Anything).Return([]*fftypes.Batch{}, nil, nil)
+	mdx.On("SendMessage", em.ctx, mock.Anything, "peer2", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.MessageReceived(mdx, "peer2", catchupRequestTransfer(&fftypes.CatchupPeerRequest{
+		ID: fftypes.NewUUID(), Namespace: "ns1",
+	}))
+	assert.NoError(t, err)
+
+	mdx.AssertExpectations(t)
+}
+
+func TestCatchupResponseReceivedComplete(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	op := newTestCatchupOp()
+	pin := &fftypes.CatchupBatchPin{
+		Batch:         fftypes.NewUUID(),
+		Hash:          fftypes.NewRandB32(),
+		TX:            fftypes.TransactionRef{ID: fftypes.NewUUID()},
+		BlockchainIDs: fftypes.FFStringArray{"0x111", "0x222"},
+	}
+	forged := &fftypes.CatchupBatchPin{
+		Batch:         fftypes.NewUUID(),
+		Hash:          fftypes.NewRandB32(),
+		BlockchainIDs: fftypes.FFStringArray{"0x333"},
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mbi.On("GetBatchPin", em.ctx, "0x111").Return(nil, "", nil)
+	mbi.On("GetBatchPin", em.ctx, "0x222").Return(&blockchain.BatchPin{
+		Namespace:       "ns1",
+		TransactionID:   pin.TX.ID,
+		BatchID:         pin.Batch,
+		BatchHash:       pin.Hash,
+		BatchPayloadRef: "ref1",
+	}, "0x12345", nil)
+	mbi.On("GetBatchPin", em.ctx, "0x333").Return(&blockchain.BatchPin{
+		Namespace:       "ns1",
+		BatchID:         forged.Batch,
+		BatchHash:       fftypes.NewRandB32(),
+		BatchPayloadRef: "ref2",
+	}, "0x12345", nil)
+	mdi.On("GetBatchByID", em.ctx, pin.Batch).Return(&fftypes.Batch{
+		Hash:      pin.Hash,
+		Confirmed: fftypes.Now(),
+	}, nil)
+	mdi.On("ResolveOperation", em.ctx, op.ID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{
+		"processed": uint64(5),
+		"total":     int64(5),
+	}).Return(nil)
+
+	_, err := em.MessageReceived(mdx, "peer1", catchupResponseTransfer(&fftypes.CatchupPeerResponse{
+		ID:        op.ID,
+		Namespace: "ns1",
+		Skip:      3,
+		Total:     5,
+		Pins:      []*fftypes.CatchupBatchPin{pin, forged},
+	}))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestCatchupResponseReceivedGetBatchPinFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	op := newTestCatchupOp()
+	pin := &fftypes.CatchupBatchPin{
+		Batch:         fftypes.NewUUID(),
+		Hash:          fftypes.NewRandB32(),
+		BlockchainIDs: fftypes.FFStringArray{"0x111"},
+	}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mbi.On("GetBatchPin", em.ctx, "0x111").Return(nil, "", fmt.Errorf("pop"))
+	mdi.On("ResolveOperation", em.ctx, op.ID, fftypes.OpStatusFailed, "pop", fftypes.JSONObject{
+		"processed": uint64(1),
+		"total":     int64(5),
+	}).Return(nil)
+
+	_, err := em.MessageReceived(mdx, "peer1", catchupResponseTransfer(&fftypes.CatchupPeerResponse{
+		ID:        op.ID,
+		Namespace: "ns1",
+		Total:     5,
+		Pins:      []*fftypes.CatchupBatchPin{pin},
+	}))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestCatchupResponseReceivedNextPage(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	op := newTestCatchupOp()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	em.dataexchange = mdx
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdx.On("SendMessage", em.ctx, op.ID, "peer1", matchCatchupRequest(op.ID, 1)).Return(nil)
+	mdi.On("ResolveOperation", em.ctx, op.ID, fftypes.OpStatusPending, "", fftypes.JSONObject{
+		"processed": uint64(1),
+		"total":     int64(5),
+	}).Return(nil)
+
+	_, err := em.MessageReceived(mdx, "peer1", catchupResponseTransfer(&fftypes.CatchupPeerResponse{
+		ID:        op.ID,
+		Namespace: "ns1",
+		Total:     5,
+		Pins:      []*fftypes.CatchupBatchPin{{}},
+	}))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestCatchupResponseReceivedNextPageFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	op := newTestCatchupOp()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	em.dataexchange = mdx
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdx.On("SendMessage", em.ctx, op.ID, "peer1", mock.Anything).Return(fmt.Errorf("pop"))
+	mdi.On("ResolveOperation", em.ctx, op.ID, fftypes.OpStatusFailed, "pop", mock.Anything).Return(nil)
+
+	_, err := em.MessageReceived(mdx, "peer1", catchupResponseTransfer(&fftypes.CatchupPeerResponse{
+		ID:        op.ID,
+		Namespace: "ns1",
+		Total:     5,
+		Pins:      []*fftypes.CatchupBatchPin{{}},
+	}))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCatchupResponseReceivedInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdx := &dataexchangemocks.Plugin{}
+	_, err := em.MessageReceived(mdx, "peer1", catchupResponseTransfer(&fftypes.CatchupPeerResponse{
+		Namespace: "ns1",
+	}))
+	assert.NoError(t, err)
+}
+
+func TestCatchupResponseReceivedGetOpFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetOperationByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.MessageReceived(mdx, "peer1", catchupResponseTransfer(&fftypes.CatchupPeerResponse{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+	}))
+	assert.Regexp(t, "FF10158", err)
+}
+
+func TestCatchupResponseReceivedUnexpectedPeer(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	op := newTestCatchupOp()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+
+	_, err := em.MessageReceived(mdx, "peer2", catchupResponseTransfer(&fftypes.CatchupPeerResponse{
+		ID:        op.ID,
+		Namespace: "ns1",
+	}))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCatchupResponseReceivedBatchFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	op := newTestCatchupOp()
+	pin := &fftypes.CatchupBatchPin{
+		Batch:         fftypes.NewUUID(),
+		Hash:          fftypes.NewRandB32(),
+		TX:            fftypes.TransactionRef{ID: fftypes.NewUUID()},
+		BlockchainIDs: fftypes.FFStringArray{"0x111"},
+	}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mbi.On("GetBatchPin", em.ctx, "0x111").Return(&blockchain.BatchPin{
+		Namespace:       "ns1",
+		TransactionID:   pin.TX.ID,
+		BatchID:         pin.Batch,
+		BatchHash:       pin.Hash,
+		BatchPayloadRef: "ref1",
+	}, "0x12345", nil)
+	mdi.On("GetBatchByID", em.ctx, pin.Batch).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.MessageReceived(mdx, "peer1", catchupResponseTransfer(&fftypes.CatchupPeerResponse{
+		ID:        op.ID,
+		Namespace: "ns1",
+		Pins:      []*fftypes.CatchupBatchPin{pin},
+	}))
+	assert.Regexp(t, "FF10158", err)
+}
+
+func TestTransferResultCatchupDelivered(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	op := newTestCatchupOp()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{op}, nil, nil)
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdx.On("Name").Return("utdx")
+	err := em.TransferResult(mdx, op.ID.String(), fftypes.OpStatusSucceeded, fftypes.TransportStatusUpdate{})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
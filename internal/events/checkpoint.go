@@ -0,0 +1,65 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (em *eventManager) getLedgerAggregator(ctx context.Context, ledger string) (*aggregator, error) {
+	if ledger == "" {
+		return em.aggregator, nil
+	}
+	ag, ok := em.ledgerAggregators[ledger]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownLedger, ledger)
+	}
+	return ag, nil
+}
+
+// GetAggregatorCheckpoint returns the persisted checkpoint of the aggregator for a ledger (empty for the
+// default ledger), which is the local sequence of the last pin processed
+func (em *eventManager) GetAggregatorCheckpoint(ctx context.Context, ledger string) (*fftypes.Offset, error) {
+	ag, err := em.getLedgerAggregator(ctx, ledger)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := em.database.GetOffset(ctx, fftypes.OffsetTypeAggregator, ag.eventPoller.conf.offsetName)
+	if err == nil && offset == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return offset, err
+}
+
+// RewindAggregator forces re-aggregation of all pins from the supplied local sequence, such as after a
+// disaster recovery restore of the database. The rewind is applied asynchronously by the aggregator.
+func (em *eventManager) RewindAggregator(ctx context.Context, rewind *fftypes.AggregatorRewind) error {
+	if rewind.Sequence < 0 {
+		return i18n.NewError(ctx, i18n.MsgInvalidRewindSequence, rewind.Sequence)
+	}
+	ag, err := em.getLedgerAggregator(ctx, rewind.Ledger)
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Requesting aggregator rewind to local pin sequence %d ledger='%s'", rewind.Sequence, rewind.Ledger)
+	ag.queueCheckpointRewind(rewind.Sequence)
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAggregatorCheckpoint(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetOffset", em.ctx, fftypes.OffsetTypeAggregator, aggregatorOffsetName).Return(&fftypes.Offset{
+		Type:    fftypes.OffsetTypeAggregator,
+		Name:    aggregatorOffsetName,
+		Current: 12345,
+	}, nil)
+
+	offset, err := em.GetAggregatorCheckpoint(em.ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345), offset.Current)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetAggregatorCheckpointNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetOffset", em.ctx, fftypes.OffsetTypeAggregator, aggregatorOffsetName).Return(nil, nil)
+
+	_, err := em.GetAggregatorCheckpoint(em.ctx, "")
+	assert.Regexp(t, "FF10109", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetAggregatorCheckpointFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetOffset", em.ctx, fftypes.OffsetTypeAggregator, aggregatorOffsetName).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.GetAggregatorCheckpoint(em.ctx, "")
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRewindAggregator(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	err := em.RewindAggregator(em.ctx, &fftypes.AggregatorRewind{Sequence: 12345})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12344), *em.aggregator.pendingRewind)
+}
+
+func TestRewindAggregatorBadSequence(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	err := em.RewindAggregator(em.ctx, &fftypes.AggregatorRewind{Sequence: -1})
+	assert.Regexp(t, "FF10361", err)
+	assert.Nil(t, em.aggregator.pendingRewind)
+}
+
+func TestGetAggregatorCheckpointLedger(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.ledgerAggregators["ledger2"] = newAggregator(em.ctx, em.database, em.definitions, em.data, em.newPinNotifier, em.eventBus, em.metrics, "ledger2")
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetOffset", em.ctx, fftypes.OffsetTypeAggregator, "ff_aggregator_ledger2").Return(&fftypes.Offset{
+		Type:    fftypes.OffsetTypeAggregator,
+		Name:    "ff_aggregator_ledger2",
+		Current: 12345,
+	}, nil)
+
+	offset, err := em.GetAggregatorCheckpoint(em.ctx, "ledger2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345), offset.Current)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetAggregatorCheckpointUnknownLedger(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.GetAggregatorCheckpoint(em.ctx, "ledger2")
+	assert.Regexp(t, "FF10403", err)
+}
+
+func TestRewindAggregatorLedger(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	ag := newAggregator(em.ctx, em.database, em.definitions, em.data, em.newPinNotifier, em.eventBus, em.metrics, "ledger2")
+	em.ledgerAggregators["ledger2"] = ag
+
+	err := em.RewindAggregator(em.ctx, &fftypes.AggregatorRewind{Sequence: 12345, Ledger: "ledger2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12344), *ag.pendingRewind)
+	assert.Nil(t, em.aggregator.pendingRewind)
+}
+
+func TestRewindAggregatorUnknownLedger(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	err := em.RewindAggregator(em.ctx, &fftypes.AggregatorRewind{Sequence: 12345, Ledger: "ledger2"})
+	assert.Regexp(t, "FF10403", err)
+}
@@ -0,0 +1,94 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var deliveryClassConfig = map[fftypes.SubOptsDeliveryClass]struct {
+	workers      config.RootKey
+	bufferLength config.RootKey
+}{
+	fftypes.SubOptsDeliveryClassRealtime: {config.SubscriptionClassRealtimeWorkers, config.SubscriptionClassRealtimeBufferLength},
+	fftypes.SubOptsDeliveryClassStandard: {config.SubscriptionClassStandardWorkers, config.SubscriptionClassStandardBufferLength},
+	fftypes.SubOptsDeliveryClassBulk:     {config.SubscriptionClassBulkWorkers, config.SubscriptionClassBulkBufferLength},
+}
+
+// deliveryPool is shared by all the dispatchers of a delivery class, limiting how many of
+// them can be doing work against the database at once. This means a class of heavy consumers
+// (such as bulk analytics) can only ever consume its own share of the resources of the node.
+type deliveryPool struct {
+	class        fftypes.SubOptsDeliveryClass
+	slots        chan struct{}
+	bufferLength int
+	delay        int64 // atomic time.Duration, set while load shedding slows dispatch
+}
+
+func newDeliveryPools() map[fftypes.SubOptsDeliveryClass]*deliveryPool {
+	pools := make(map[fftypes.SubOptsDeliveryClass]*deliveryPool, len(deliveryClassConfig))
+	for class := range deliveryClassConfig {
+		pools[class] = newDeliveryPool(class)
+	}
+	return pools
+}
+
+func newDeliveryPool(class fftypes.SubOptsDeliveryClass) *deliveryPool {
+	conf := deliveryClassConfig[class]
+	dp := &deliveryPool{
+		class:        class,
+		bufferLength: config.GetInt(conf.bufferLength),
+	}
+	if dp.bufferLength <= 0 {
+		dp.bufferLength = config.GetInt(config.EventDispatcherBufferLength)
+	}
+	if workers := config.GetInt(conf.workers); workers > 0 {
+		dp.slots = make(chan struct{}, workers)
+	}
+	return dp
+}
+
+// setDelay sets a delay applied before each run, to slow the load dispatchers place on the database
+func (dp *deliveryPool) setDelay(delay time.Duration) {
+	atomic.StoreInt64(&dp.delay, int64(delay))
+}
+
+// run waits for any delay, and for a free worker in the pool (if the pool is limited), then runs the function
+func (dp *deliveryPool) run(ctx context.Context, fn func() error) error {
+	if delay := time.Duration(atomic.LoadInt64(&dp.delay)); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return i18n.NewError(ctx, i18n.MsgDispatcherClosing)
+		}
+	}
+	if dp.slots != nil {
+		select {
+		case dp.slots <- struct{}{}:
+			defer func() { <-dp.slots }()
+		case <-ctx.Done():
+			return i18n.NewError(ctx, i18n.MsgDispatcherClosing)
+		}
+	}
+	return fn()
+}
@@ -0,0 +1,115 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDeliveryPools(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventDispatcherBufferLength, 10)
+	config.Set(config.SubscriptionClassRealtimeBufferLength, 5)
+
+	pools := newDeliveryPools()
+	assert.Len(t, pools, 3)
+	assert.Nil(t, pools[fftypes.SubOptsDeliveryClassRealtime].slots)
+	assert.Equal(t, 5, pools[fftypes.SubOptsDeliveryClassRealtime].bufferLength)
+	assert.Equal(t, 20, cap(pools[fftypes.SubOptsDeliveryClassStandard].slots))
+	assert.Equal(t, 10, pools[fftypes.SubOptsDeliveryClassStandard].bufferLength)
+	assert.Equal(t, 2, cap(pools[fftypes.SubOptsDeliveryClassBulk].slots))
+	assert.Equal(t, 50, pools[fftypes.SubOptsDeliveryClassBulk].bufferLength)
+}
+
+func TestDeliveryPoolLimitsWorkers(t *testing.T) {
+	config.Reset()
+	config.Set(config.SubscriptionClassBulkWorkers, 1)
+	dp := newDeliveryPool(fftypes.SubOptsDeliveryClassBulk)
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- dp.run(context.Background(), func() error {
+			close(running)
+			<-release
+			return nil
+		})
+	}()
+	<-running
+
+	// The second worker cannot start until the first is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := dp.run(ctx, func() error {
+		panic("should not run")
+	})
+	assert.Regexp(t, "FF10182", err)
+
+	close(release)
+	assert.NoError(t, <-done)
+	ran := false
+	err = dp.run(context.Background(), func() error {
+		ran = true
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, ran)
+}
+
+func TestDeliveryPoolUnlimited(t *testing.T) {
+	config.Reset()
+	dp := newDeliveryPool(fftypes.SubOptsDeliveryClassRealtime)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ran := false
+	err := dp.run(ctx, func() error {
+		ran = true
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, ran)
+}
+
+func TestDeliveryPoolDelay(t *testing.T) {
+	config.Reset()
+	dp := newDeliveryPool(fftypes.SubOptsDeliveryClassRealtime)
+	dp.setDelay(1 * time.Millisecond)
+
+	ran := false
+	err := dp.run(context.Background(), func() error {
+		ran = true
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, ran)
+
+	dp.setDelay(1 * time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = dp.run(ctx, func() error {
+		panic("should not run")
+	})
+	assert.Regexp(t, "FF10182", err)
+}
@@ -20,7 +20,9 @@ import (
 	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/database"
@@ -35,17 +37,73 @@ func (em *eventManager) MessageReceived(dx dataexchange.Plugin, peerID string, d
 	// De-serializae the transport wrapper
 	var wrapper *fftypes.TransportWrapper
 	err = json.Unmarshal(data, &wrapper)
+	if err == nil && wrapper.Encrypted != nil {
+		// Transmissions encrypted end-to-end carry a full transport wrapper inside the envelope
+		if data, err = em.messaging.DecryptTransport(em.ctx, wrapper.Encrypted); err == nil {
+			wrapper = nil
+			err = json.Unmarshal(data, &wrapper)
+		}
+	}
+	if err == nil && wrapper.Batch != nil && isStrictDecode(wrapper.Batch.Namespace) {
+		err = fftypes.CheckKnownFields(em.ctx, "", data, wrapper)
+	}
 	if err != nil {
 		l.Errorf("Invalid transmission from '%s': %s", peerID, err)
 		return "", nil
 	}
+
+	// Reject transmissions we have already received from this peer, or that fall outside of the window
+	if err := em.replayWindow.Check(peerID, wrapper.Sequence); err != nil {
+		l.Errorf("Rejected transmission from '%s': %s", peerID, err)
+		return "", em.transmissionRejected(wrapper)
+	}
+	manifest, err = em.transmissionReceived(peerID, wrapper, len(data))
+	if err == nil {
+		em.replayWindow.Record(peerID, wrapper.Sequence)
+	}
+	return manifest, err
+}
+
+// transmissionRejected records an event for audit, for a transmission that was rejected as a replay
+func (em *eventManager) transmissionRejected(wrapper *fftypes.TransportWrapper) error {
+	namespace := fftypes.SystemNamespace
+	var ref *fftypes.UUID
+	switch {
+	case wrapper.Batch != nil:
+		namespace, ref = wrapper.Batch.Namespace, wrapper.Batch.ID
+	case wrapper.CatchupRequest != nil:
+		namespace, ref = wrapper.CatchupRequest.Namespace, wrapper.CatchupRequest.ID
+	case wrapper.CatchupResponse != nil:
+		namespace, ref = wrapper.CatchupResponse.Namespace, wrapper.CatchupResponse.ID
+	case wrapper.PrivateData != nil:
+		namespace, ref = wrapper.PrivateData.Namespace, wrapper.PrivateData.Batch
+	}
+	event := fftypes.NewEvent(fftypes.EventTypeTransmissionRejected, namespace, ref, nil)
+	return em.database.InsertEvent(em.ctx, event)
+}
+
+func (em *eventManager) transmissionReceived(peerID string, wrapper *fftypes.TransportWrapper, length int) (manifest string, err error) {
+
+	l := log.L(em.ctx)
+
+	switch {
+	case wrapper.CatchupRequest != nil:
+		return "", em.catchupRequestReceived(peerID, wrapper.CatchupRequest)
+	case wrapper.CatchupResponse != nil:
+		return "", em.catchupResponseReceived(peerID, wrapper.CatchupResponse)
+	case wrapper.PrivateData != nil:
+		l.Infof("Private data received from '%s' (len=%d)", peerID, length)
+		return em.privateDataReceived(peerID, wrapper.PrivateData)
+	}
 	if wrapper.Batch == nil {
 		l.Errorf("Invalid transmission: nil batch")
 		return "", nil
 	}
-	l.Infof("Private batch received from '%s' (len=%d)", peerID, len(data))
+	l.Infof("Private batch received from '%s' (len=%d)", peerID, length)
 
-	if wrapper.Batch.Payload.TX.Type == fftypes.TransactionTypeUnpinned {
+	// Unpinned batches always carry the group, as we cannot be sure it has been sent via the blockchain.
+	// Pinned batches carry it when the batch changes the group membership, for any members being added.
+	if wrapper.Batch.Payload.TX.Type == fftypes.TransactionTypeUnpinned || wrapper.Group != nil {
 		valid, err := em.definitions.EnsureLocalGroup(em.ctx, wrapper.Group)
 		if err != nil {
 			return "", err
@@ -56,6 +114,13 @@ func (em *eventManager) MessageReceived(dx dataexchange.Plugin, peerID string, d
 		}
 	}
 
+	// Where the pins are private transactions, they are only received once this node is subscribed to the privacy group
+	if wrapper.Batch.Payload.TX.Type == fftypes.TransactionTypeBatchPin {
+		if err := em.messaging.EnsurePrivacyGroup(em.ctx, wrapper.Batch.Namespace, wrapper.Batch.Group); err != nil {
+			return "", err
+		}
+	}
+
 	mf, err := em.privateBatchReceived(peerID, wrapper.Batch)
 	manifestBytes := []byte{}
 	if err == nil && mf != nil {
@@ -119,6 +184,13 @@ func (em *eventManager) privateBatchReceived(peerID string, batch *fftypes.Batch
 
 	// Retry for persistence errors (not validation errors)
 	err = em.retry.Do(em.ctx, "private batch received", func(attempt int) (bool, error) {
+		// The identity registry is queried on the blockchain outside of the database transaction.
+		// The pin of a private batch is not matched until it is persisted, so the latest block is checked
+		valid, reason, err := em.verifyIdentityRegistered(em.ctx, batch, nil)
+		if err != nil || !valid {
+			log.L(em.ctx).Errorf("Batch received from peer '%s' failed registry verification reason='%s': %s", peerID, reason, err)
+			return true, err // retry - verifyIdentityRegistered only returns retryable errors
+		}
 		return true, em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
 			l := log.L(ctx)
 
@@ -128,23 +200,17 @@ func (em *eventManager) privateBatchReceived(peerID string, batch *fftypes.Batch
 			}
 			if node == nil {
 				l.Errorf("Batch received from invalid author '%s' for peer ID '%s'", batch.Author, peerID)
-				return nil
+				return em.quarantinePrivateBatch(ctx, peerID, batch, fmt.Sprintf("Author '%s' is not valid for peer '%s'", batch.Author, peerID))
 			}
 
-			valid, err := em.persistBatch(ctx, batch)
+			valid, reason, err := em.persistBatch(ctx, batch)
 			if err != nil || !valid {
-				l.Errorf("Batch received from %s/%s processing failed valid=%t: %s", node.Owner, node.Name, valid, err)
+				l.Errorf("Batch received from %s/%s processing failed valid=%t reason='%s': %s", node.Owner, node.Name, valid, reason, err)
 				return err // retry - persistBatch only returns retryable errors
 			}
 
-			if batch.Payload.TX.Type == fftypes.TransactionTypeBatchPin {
-				// Poke the aggregator to do its stuff
-				em.aggregator.offchainBatches <- batch.ID
-			} else if batch.Payload.TX.Type == fftypes.TransactionTypeUnpinned {
-				// We need to confirm all these messages immediately.
-				if err := em.markUnpinnedMessagesConfirmed(ctx, batch); err != nil {
-					return err
-				}
+			if err := em.privateBatchPersisted(ctx, batch); err != nil {
+				return err
 			}
 			manifest = batch.Manifest()
 			return nil
@@ -154,6 +220,86 @@ func (em *eventManager) privateBatchReceived(peerID string, batch *fftypes.Batch
 
 }
 
+// privateBatchPersisted completes the processing of a private batch once it has been stored
+func (em *eventManager) privateBatchPersisted(ctx context.Context, batch *fftypes.Batch) error {
+	if batch.Payload.TX.Type == fftypes.TransactionTypeBatchPin {
+		// Poke the aggregator to do its stuff
+		em.notifyOffchainBatch(batch.Namespace, batch.ID)
+	} else if batch.Payload.TX.Type == fftypes.TransactionTypeUnpinned {
+		// We need to confirm all these messages immediately.
+		return em.markUnpinnedMessagesConfirmed(ctx, batch)
+	}
+	return nil
+}
+
+// privateDataReceived stores the values of broadcast data restricted to a private group, which are sent
+// directly to the members of that group. The sender must be a member of the group.
+func (em *eventManager) privateDataReceived(peerID string, pd *fftypes.PrivateDataTransfer) (manifest string, err error) {
+
+	// Retry for persistence errors (not validation errors)
+	err = em.retry.Do(em.ctx, "private data received", func(attempt int) (bool, error) {
+		return true, em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+			l := log.L(ctx)
+
+			group, err := em.database.GetGroupByHash(ctx, pd.Group)
+			if err != nil {
+				return err
+			}
+			if group == nil || group.Namespace != pd.Namespace {
+				l.Errorf("Private data received for unknown group '%s' in namespace '%s'", pd.Group, pd.Namespace)
+				return nil
+			}
+
+			filter := database.NodeQueryFactory.NewFilter(ctx).Eq("dx.peer", peerID)
+			nodes, _, err := em.database.GetNodes(ctx, filter)
+			if err != nil {
+				return err
+			}
+			isMember := false
+			for _, member := range group.Members {
+				if len(nodes) > 0 && member.Node.Equals(nodes[0].ID) {
+					isMember = true
+					break
+				}
+			}
+			if !isMember {
+				l.Errorf("Private data received for group '%s' from peer '%s', which is not a member", pd.Group, peerID)
+				return nil
+			}
+
+			for i, d := range pd.Data {
+				if d == nil || d.Namespace != pd.Namespace || !d.Group.Equals(pd.Group) {
+					l.Errorf("Invalid private data entry %d for batch '%s': mismatched namespace or group", i, pd.Batch)
+					return nil
+				}
+			}
+			valid, err := em.data.ValidateAll(ctx, pd.Data)
+			if err != nil || !valid {
+				l.Errorf("Private data for batch '%s' failed validation: %v", pd.Batch, err)
+				return err
+			}
+
+			for i, d := range pd.Data {
+				stored, err := em.persistReceivedData(ctx, i, d, "private data", pd.Batch, database.UpsertOptimizationSkip)
+				if err != nil {
+					return err
+				}
+				if stored {
+					event := fftypes.NewEvent(fftypes.EventTypePrivateDataReceived, pd.Namespace, d.ID, nil)
+					if err := em.database.InsertEvent(ctx, event); err != nil {
+						return err
+					}
+				}
+			}
+
+			manifestBytes, _ := json.Marshal(pd.Manifest())
+			manifest = string(manifestBytes)
+			return nil
+		})
+	})
+	return manifest, err
+}
+
 func (em *eventManager) markUnpinnedMessagesConfirmed(ctx context.Context, batch *fftypes.Batch) error {
 
 	// Update all the messages in the batch with the batch ID
@@ -200,7 +346,7 @@ func (em *eventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash
 	// we only confirm consumption of the event to the plugin once we've processed it.
 	return em.retry.Do(em.ctx, "blob reference insert", func(attempt int) (retry bool, err error) {
 
-		batchIDs := make(map[fftypes.UUID]bool)
+		batchIDs := make(map[fftypes.UUID]string)
 
 		err = em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
 			// Insert the blob into the detabase
@@ -239,7 +385,7 @@ func (em *eventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash
 			// Find the unique batch IDs for all the messages
 			for _, msg := range messages {
 				if msg.BatchID != nil {
-					batchIDs[*msg.BatchID] = true
+					batchIDs[*msg.BatchID] = msg.Header.Namespace
 				}
 			}
 			return nil
@@ -249,10 +395,10 @@ func (em *eventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash
 		}
 
 		// Initiate rewinds for all the batchIDs that are potentially completed by the arrival of this data
-		for bid := range batchIDs {
+		for bid, ns := range batchIDs {
 			var batchID = bid // cannot use the address of the loop var
 			l.Infof("Batch '%s' contains reference to received blob. Peer='%s' Hash='%v' PayloadRef='%s'", &bid, peerID, &hash, payloadRef)
-			em.aggregator.offchainBatches <- &batchID
+			em.notifyOffchainBatch(ns, &batchID)
 		}
 
 		return false, nil
@@ -264,7 +410,8 @@ func (em *eventManager) TransferResult(dx dataexchange.Plugin, trackingID string
 
 	// We process the event in a retry loop (which will break only if the context is closed), so that
 	// we only confirm consumption of the event to the plugin once we've processed it.
-	return em.retry.Do(em.ctx, "operation update", func(attempt int) (retry bool, err error) {
+	var resolved *fftypes.Operation
+	err := em.retry.Do(em.ctx, "operation update", func(attempt int) (retry bool, err error) {
 
 		// Find a matching operation, for this plugin, with the specified ID.
 		// We retry a few times, as there's an outside possibility of the event arriving before we're finished persisting the operation itself
@@ -291,6 +438,10 @@ func (em *eventManager) TransferResult(dx dataexchange.Plugin, trackingID string
 
 		// The maniest should exactly match that stored into the operation input, if supported
 		op := operations[0]
+		if op.Type == fftypes.OpTypeDataExchangeCatchup && status == fftypes.OpStatusSucceeded {
+			// Delivery of a request does not complete a catch-up, which is resolved when the final response is processed
+			return false, nil
+		}
 		if status == fftypes.OpStatusSucceeded && dx.Capabilities().Manifest {
 			switch op.Type {
 			case fftypes.OpTypeDataExchangeBatchSend:
@@ -319,7 +470,17 @@ func (em *eventManager) TransferResult(dx dataexchange.Plugin, trackingID string
 		if err := em.database.ResolveOperation(em.ctx, op.ID, status, update.Error, update.Info); err != nil {
 			return true, err // this is always retryable
 		}
+		resolved = op
 		return false, nil
 	})
-
+	if err == nil && resolved != nil {
+		// Acknowledgements from the receiving member are reported in the same way as blockchain operation updates
+		em.eventBus.Publish(eventbus.TopicOperationUpdated, &eventbus.OperationUpdate{
+			ID:        resolved.ID,
+			Namespace: resolved.Namespace,
+			Type:      resolved.Type,
+			Status:    status,
+		})
+	}
+	return err
 }
@@ -17,14 +17,21 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/antireplay"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
+	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -34,12 +41,15 @@ import (
 
 func sampleBatchTransfer(t *testing.T, txType fftypes.TransactionType, data ...*fftypes.Data) (*fftypes.Batch, []byte) {
 	batch := sampleBatch(t, txType, data...)
-	b, _ := json.Marshal(&fftypes.TransportWrapper{
+	tw := &fftypes.TransportWrapper{
 		Batch: batch,
-		Group: &fftypes.Group{
+	}
+	if txType == fftypes.TransactionTypeUnpinned {
+		tw.Group = &fftypes.Group{
 			Hash: fftypes.NewRandB32(),
-		},
-	})
+		}
+	}
+	b, _ := json.Marshal(tw)
 	return batch, b
 }
 
@@ -47,10 +57,12 @@ func TestPinnedReceiveOK(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
 
-	_, b := sampleBatchTransfer(t, fftypes.TransactionTypeBatchPin)
+	batch, b := sampleBatchTransfer(t, fftypes.TransactionTypeBatchPin)
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, batch.Namespace, batch.Group).Return(nil)
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
 	}, nil, nil)
@@ -68,6 +80,25 @@ func TestPinnedReceiveOK(t *testing.T) {
 
 	mdi.AssertExpectations(t)
 	mdx.AssertExpectations(t)
+	mpm.AssertExpectations(t)
+}
+
+func TestPinnedReceiveEnsurePrivacyGroupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, b := sampleBatchTransfer(t, fftypes.TransactionTypeBatchPin)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.Regexp(t, "pop", err)
+	assert.Empty(t, m)
+
+	mdi.AssertNotCalled(t, "UpsertBatch", mock.Anything, mock.Anything)
+	mpm.AssertExpectations(t)
 }
 
 func TestMessageReceiveOkBadBatchIgnored(t *testing.T) {
@@ -95,6 +126,46 @@ func TestMessageReceiveOkBadBatchIgnored(t *testing.T) {
 	mdx.AssertExpectations(t)
 }
 
+func TestMessageReceiveNotRegisteredIgnored(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	_, b := sampleBatchTransfer(t, fftypes.TransactionTypeBatchPin)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, mock.Anything, mock.Anything).Return(nil)
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", em.ctx, "0x12345", (*fftypes.FFBigInt)(nil)).Return(false, nil)
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertNotCalled(t, "UpsertBatch", mock.Anything, mock.Anything)
+	mbi.AssertExpectations(t)
+}
+
+func TestMessageReceiveRegistryError(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+	em.verifyIdentityReg = true
+
+	_, b := sampleBatchTransfer(t, fftypes.TransactionTypeBatchPin)
+
+	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, mock.Anything, mock.Anything).Return(nil)
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", em.ctx, "0x12345", (*fftypes.FFBigInt)(nil)).Return(false, fmt.Errorf("pop"))
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.Regexp(t, "FF10158", err)
+	assert.Empty(t, m)
+
+	mbi.AssertExpectations(t)
+}
+
 func TestMessageReceivePersistBatchError(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	cancel() // retryable error
@@ -103,6 +174,8 @@ func TestMessageReceivePersistBatchError(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
 	}, nil, nil)
@@ -132,6 +205,139 @@ func TestMessageReceivedBadData(t *testing.T) {
 
 }
 
+func TestMessageReceivedStrictUnknownField(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{{"name": "ns1", "strictDecode": true}})
+
+	mdx := &dataexchangemocks.Plugin{}
+	m, err := em.MessageReceived(mdx, "peer1", []byte(`{"batch":{"namespace":"ns1","payload":{"tx":{"type":"batch_pin","future":true}}}}`))
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+}
+
+func TestMessageReceivedRecordsSequence(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	seq := antireplay.NextSequence()
+	mdx := &dataexchangemocks.Plugin{}
+	b, _ := json.Marshal(&fftypes.TransportWrapper{Sequence: seq})
+	_, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+
+	assert.Regexp(t, "FF10400", em.replayWindow.Check("peer1", seq))
+}
+
+func TestMessageReceivedEncrypted(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	seq := antireplay.NextSequence()
+	et := &fftypes.EncryptedTransport{Algorithm: "aes-256-gcm"}
+	plaintext, _ := json.Marshal(&fftypes.TransportWrapper{Sequence: seq})
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("DecryptTransport", em.ctx, et).Return(plaintext, nil)
+
+	mdx := &dataexchangemocks.Plugin{}
+	b, _ := json.Marshal(&fftypes.TransportWrapper{Encrypted: et})
+	_, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+
+	assert.Regexp(t, "FF10400", em.replayWindow.Check("peer1", seq))
+	mpm.AssertExpectations(t)
+}
+
+func TestMessageReceivedDecryptFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	et := &fftypes.EncryptedTransport{Algorithm: "aes-256-gcm"}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("DecryptTransport", em.ctx, et).Return(nil, fmt.Errorf("pop"))
+
+	mdx := &dataexchangemocks.Plugin{}
+	b, _ := json.Marshal(&fftypes.TransportWrapper{Encrypted: et})
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mpm.AssertExpectations(t)
+}
+
+func TestMessageReceivedReplayRejected(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	seq := antireplay.NextSequence()
+	em.replayWindow.Record("peer1", seq)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{Batch: batch, Sequence: seq})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTransmissionRejected && e.Namespace == batch.Namespace && e.Reference.Equals(batch.ID)
+	})).Return(nil)
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceivedCatchupOutsideWindow(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	reqID := fftypes.NewUUID()
+	em.replayWindow.Record("peer1", antireplay.NextSequence())
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTransmissionRejected && e.Namespace == "ns1" && e.Reference.Equals(reqID)
+	})).Return(nil).Twice()
+
+	b, _ := json.Marshal(&fftypes.TransportWrapper{
+		CatchupRequest: &fftypes.CatchupPeerRequest{ID: reqID, Namespace: "ns1"},
+		Sequence:       1,
+	})
+	_, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+
+	b, _ = json.Marshal(&fftypes.TransportWrapper{
+		CatchupResponse: &fftypes.CatchupPeerResponse{ID: reqID, Namespace: "ns1"},
+		Sequence:        1,
+	})
+	_, err = em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceivedReplayRejectedInsertEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	seq := antireplay.NextSequence()
+	em.replayWindow.Record("peer1", seq)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{Sequence: seq})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Namespace == fftypes.SystemNamespace && e.Reference == nil
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := em.MessageReceived(mdx, "peer1", b)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
 func TestMessageReceivedUnknownType(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -213,6 +419,9 @@ func TestMessageReceiveNodeNotFound(t *testing.T) {
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return(nil, nil, nil)
+	mdi.On("InsertQuarantinedBatch", em.ctx, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Peer == "peer1"
+	})).Return(nil)
 	m, err := em.MessageReceived(mdx, "peer1", b)
 	assert.NoError(t, err)
 	assert.Empty(t, m)
@@ -253,6 +462,9 @@ func TestMessageReceiveAuthorNotFound(t *testing.T) {
 		{Name: "node1", Owner: "org1"},
 	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, mock.Anything).Return(nil, nil)
+	mdi.On("InsertQuarantinedBatch", em.ctx, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Peer == "peer1"
+	})).Return(nil)
 	m, err := em.MessageReceived(mdx, "peer1", b)
 	assert.NoError(t, err)
 	assert.Empty(t, m)
@@ -266,6 +478,8 @@ func TestMessageReceiveGetCandidateOrgFail(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
 	}, nil, nil)
@@ -289,6 +503,8 @@ func TestMessageReceiveGetCandidateOrgNotFound(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
 	}, nil, nil)
@@ -296,6 +512,9 @@ func TestMessageReceiveGetCandidateOrgNotFound(t *testing.T) {
 		Identity: "0x12345", Parent: "parentOrg",
 	}, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "parentOrg").Return(nil, nil)
+	mdi.On("InsertQuarantinedBatch", em.ctx, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Peer == "peer1"
+	})).Return(nil)
 	m, err := em.MessageReceived(mdx, "peer1", b)
 	assert.NoError(t, err)
 	assert.Empty(t, m)
@@ -312,6 +531,8 @@ func TestMessageReceiveGetCandidateOrgNotMatch(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "another"},
 	}, nil, nil)
@@ -321,6 +542,9 @@ func TestMessageReceiveGetCandidateOrgNotMatch(t *testing.T) {
 	mdi.On("GetOrganizationByIdentity", em.ctx, "parentOrg").Return(&fftypes.Organization{
 		Identity: "parentOrg",
 	}, nil)
+	mdi.On("InsertQuarantinedBatch", em.ctx, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		return qb.Peer == "peer1"
+	})).Return(nil)
 	m, err := em.MessageReceived(mdx, "peer1", b)
 	assert.NoError(t, err)
 	assert.Empty(t, m)
@@ -433,6 +657,11 @@ func TestTransferResultOk(t *testing.T) {
 		"extra": "info",
 	}).Return(nil)
 
+	var published *eventbus.OperationUpdate
+	em.eventBus.Subscribe(eventbus.TopicOperationUpdated, func(payload interface{}) {
+		published = payload.(*eventbus.OperationUpdate)
+	})
+
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
 	err := em.TransferResult(mdx, id.String(), fftypes.OpStatusFailed, fftypes.TransportStatusUpdate{
@@ -440,6 +669,8 @@ func TestTransferResultOk(t *testing.T) {
 		Info:  fftypes.JSONObject{"extra": "info"},
 	})
 	assert.NoError(t, err)
+	assert.Equal(t, id, published.ID)
+	assert.Equal(t, fftypes.OpStatusFailed, published.Status)
 }
 
 func TestTransferResultManifestMismatch(t *testing.T) {
@@ -594,6 +825,8 @@ func TestMessageReceiveMessageIdentityFail(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
+	mpm := em.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("EnsurePrivacyGroup", em.ctx, mock.Anything, mock.Anything).Return(nil)
 
 	msh := em.definitions.(*definitionsmocks.DefinitionHandlers)
 	msh.On("EnsureLocalGroup", em.ctx, mock.Anything).Return(true, nil)
@@ -612,7 +845,7 @@ func TestMessageReceiveMessageIdentityIncorrect(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	cancel() // to avoid infinite retry
 
-	_, b := sampleBatchTransfer(t, fftypes.TransactionTypeUnpinned)
+	batch, b := sampleBatchTransfer(t, fftypes.TransactionTypeUnpinned)
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
@@ -621,6 +854,17 @@ func TestMessageReceiveMessageIdentityIncorrect(t *testing.T) {
 	msh.On("EnsureLocalGroup", em.ctx, mock.Anything).Return(true, nil)
 
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	mdi.On("InsertQuarantinedBatch", em.ctx, mock.MatchedBy(func(qb *fftypes.QuarantinedBatch) bool {
+		var payload fftypes.Batch
+		err := json.Unmarshal([]byte(qb.Payload), &payload)
+		return err == nil &&
+			qb.Namespace == batch.Namespace &&
+			qb.Batch.Equals(batch.ID) &&
+			qb.Hash.Equals(batch.Hash) &&
+			qb.Key == "0x12345" &&
+			qb.Peer == "peer1" &&
+			payload.ID.Equals(batch.ID)
+	})).Return(nil)
 
 	m, err := em.MessageReceived(mdx, "peer1", b)
 	assert.NoError(t, err)
@@ -630,6 +874,29 @@ func TestMessageReceiveMessageIdentityIncorrect(t *testing.T) {
 	mdx.AssertExpectations(t)
 }
 
+func TestMessageReceiveMessageIdentityIncorrectQuarantineFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to avoid infinite retry
+
+	_, b := sampleBatchTransfer(t, fftypes.TransactionTypeUnpinned)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+
+	msh := em.definitions.(*definitionsmocks.DefinitionHandlers)
+	msh.On("EnsureLocalGroup", em.ctx, mock.Anything).Return(true, nil)
+
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	mdi.On("InsertQuarantinedBatch", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.Regexp(t, "FF10158", err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
 func TestMessageReceiveMessagePersistMessageFail(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	cancel() // to avoid infinite retry
@@ -845,3 +1112,260 @@ func TestMessageReceiveMessageEnsureLocalGroupReject(t *testing.T) {
 	mdi.AssertExpectations(t)
 	mdx.AssertExpectations(t)
 }
+
+func TestMessageReceivePinnedMembershipChangeEnsureLocalGroup(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to avoid infinite retry
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{
+		Batch: batch,
+		Group: &fftypes.Group{
+			Hash:    fftypes.NewRandB32(),
+			Version: 1,
+		},
+	})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+
+	msh := em.definitions.(*definitionsmocks.DefinitionHandlers)
+	msh.On("EnsureLocalGroup", em.ctx, mock.MatchedBy(func(g *fftypes.Group) bool {
+		return g.Version == 1
+	})).Return(false, nil)
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+	msh.AssertExpectations(t)
+}
+
+func samplePrivateDataTransfer(t *testing.T) (*fftypes.PrivateDataTransfer, *fftypes.Group, *fftypes.Node) {
+	node := &fftypes.Node{ID: fftypes.NewUUID(), Name: "node1"}
+	group := &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Namespace: "ns1",
+			Members: fftypes.Members{
+				{Identity: "0x12345", Node: node.ID},
+			},
+		},
+		Hash: fftypes.NewRandB32(),
+	}
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1", Group: group.Hash, Value: fftypes.JSONAnyPtr(`"secret"`)}
+	err := data.Seal(context.Background(), nil)
+	assert.NoError(t, err)
+	return &fftypes.PrivateDataTransfer{
+		Namespace: "ns1",
+		Batch:     fftypes.NewUUID(),
+		Group:     group.Hash,
+		Data:      []*fftypes.Data{data},
+	}, group, node
+}
+
+func TestMessageReceivedPrivateDataOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	pd, group, node := samplePrivateDataTransfer(t)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdm := em.data.(*datamocks.Manager)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(group, nil)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+	mdm.On("ValidateAll", em.ctx, pd.Data).Return(true, nil)
+	mdi.On("UpsertData", em.ctx, pd.Data[0], database.UpsertOptimizationSkip).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypePrivateDataReceived && e.Reference.Equals(pd.Data[0].ID)
+	})).Return(nil)
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	var mf fftypes.Manifest
+	err = json.Unmarshal([]byte(m), &mf)
+	assert.NoError(t, err)
+	assert.Equal(t, pd.Data[0].ID, mf.Data[0].ID)
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestMessageReceivedPrivateDataReplayRejected(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	pd, _, _ := samplePrivateDataTransfer(t)
+	seq := antireplay.NextSequence()
+	em.replayWindow.Record("peer1", seq)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd, Sequence: seq})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTransmissionRejected && e.Namespace == "ns1" && e.Reference.Equals(pd.Batch)
+	})).Return(nil)
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceivedPrivateDataGroupLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to stop retry
+
+	pd, _, _ := samplePrivateDataTransfer(t)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(nil, fmt.Errorf("pop"))
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.Regexp(t, "FF10158", err)
+	assert.Empty(t, m)
+}
+
+func TestMessageReceivedPrivateDataGroupWrongNamespace(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	pd, group, _ := samplePrivateDataTransfer(t)
+	group.Namespace = "ns2"
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(group, nil)
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceivedPrivateDataNodeLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to stop retry
+
+	pd, group, _ := samplePrivateDataTransfer(t)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(group, nil)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.Regexp(t, "FF10158", err)
+	assert.Empty(t, m)
+}
+
+func TestMessageReceivedPrivateDataNotMember(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	pd, group, _ := samplePrivateDataTransfer(t)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(group, nil)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{{ID: fftypes.NewUUID()}}, nil, nil)
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceivedPrivateDataMismatchedGroup(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	pd, group, node := samplePrivateDataTransfer(t)
+	pd.Data[0].Group = fftypes.NewRandB32()
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(group, nil)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceivedPrivateDataInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	pd, group, node := samplePrivateDataTransfer(t)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdm := em.data.(*datamocks.Manager)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(group, nil)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+	mdm.On("ValidateAll", em.ctx, mock.Anything).Return(false, nil)
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestMessageReceivedPrivateDataUpsertFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to stop retry
+
+	pd, group, node := samplePrivateDataTransfer(t)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdm := em.data.(*datamocks.Manager)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(group, nil)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+	mdm.On("ValidateAll", em.ctx, mock.Anything).Return(true, nil)
+	mdi.On("UpsertData", em.ctx, mock.Anything, database.UpsertOptimizationSkip).Return(fmt.Errorf("pop"))
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.Regexp(t, "FF10158", err)
+	assert.Empty(t, m)
+}
+
+func TestMessageReceivedPrivateDataInsertEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to stop retry
+
+	pd, group, node := samplePrivateDataTransfer(t)
+	b, _ := json.Marshal(&fftypes.TransportWrapper{PrivateData: pd})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdm := em.data.(*datamocks.Manager)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetGroupByHash", em.ctx, pd.Group).Return(group, nil)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+	mdm.On("ValidateAll", em.ctx, mock.Anything).Return(true, nil)
+	mdi.On("UpsertData", em.ctx, mock.Anything, database.UpsertOptimizationSkip).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	m, err := em.MessageReceived(mdx, "peer1", b)
+	assert.Regexp(t, "FF10158", err)
+	assert.Empty(t, m)
+}
@@ -25,12 +25,16 @@ import (
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/definitions"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/retry"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -63,9 +67,10 @@ type eventDispatcher struct {
 	subscription  *subscription
 	cel           *changeEventListener
 	changeEvents  chan *fftypes.ChangeEvent
+	metrics       metrics.Manager
 }
 
-func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugin, dm data.Manager, sh definitions.DefinitionHandlers, connID string, sub *subscription, en *eventNotifier, cel *changeEventListener) *eventDispatcher {
+func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugin, dm data.Manager, sh definitions.DefinitionHandlers, connID string, sub *subscription, en *eventNotifier, eb eventbus.Bus, cel *changeEventListener, mm metrics.Manager) *eventDispatcher {
 	ctx, cancelCtx := context.WithCancel(ctx)
 	readAhead := config.GetUint(config.SubscriptionDefaultsReadAhead)
 	if sub.definition.Options.ReadAhead != nil {
@@ -75,9 +80,10 @@ func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugi
 		readAhead = maxReadAhead
 	}
 	ed := &eventDispatcher{
-		ctx: log.WithLogField(log.WithLogField(ctx,
+		ctx: log.WithLogField(log.WithLogField(log.WithLogField(ctx,
 			"role", fmt.Sprintf("ed[%s]", connID)),
 			"sub", fmt.Sprintf("%s/%s:%s", sub.definition.ID, sub.definition.Namespace, sub.definition.Name)),
+			log.NamespaceField, sub.definition.Namespace),
 		database:      di,
 		transport:     ei,
 		definitions:   sh,
@@ -93,19 +99,25 @@ func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugi
 		acksNacks:     make(chan ackNack),
 		closed:        make(chan struct{}),
 		cel:           cel,
+		metrics:       mm,
 	}
 
 	pollerConf := &eventPollerConf{
-		eventBatchSize:             config.GetInt(config.EventDispatcherBufferLength),
+		eventBatchSize:             sub.pool.bufferLength,
 		eventBatchTimeout:          config.GetDuration(config.EventDispatcherBatchTimeout),
 		eventPollTimeout:           config.GetDuration(config.EventDispatcherPollTimeout),
+		adaptivePoll:               newAdaptivePollConf(),
 		startupOffsetRetryAttempts: 0, // We need to keep trying to start indefinitely
 		retry: retry.Retry{
-			InitialDelay: config.GetDuration(config.EventDispatcherRetryInitDelay),
-			MaximumDelay: config.GetDuration(config.EventDispatcherRetryMaxDelay),
-			Factor:       config.GetFloat64(config.EventDispatcherRetryFactor),
+			InitialDelay:      config.GetDuration(config.EventDispatcherRetryInitDelay),
+			MaximumDelay:      config.GetDuration(config.EventDispatcherRetryMaxDelay),
+			Factor:            config.GetFloat64(config.EventDispatcherRetryFactor),
+			Jitter:            config.GetFloat64(config.EventDispatcherRetryJitter),
+			BreakerThreshold:  config.GetInt(config.EventDispatcherRetryCircuitBreakerThreshold),
+			BreakerResetDelay: config.GetDuration(config.EventDispatcherRetryCircuitBreakerResetDelay),
 		},
 		namespace:  sub.definition.Namespace,
+		eventBus:   eb,
 		offsetType: fftypes.OffsetTypeSubscription,
 		offsetName: sub.definition.ID.String(),
 		addCriteria: func(af database.AndFilter) database.AndFilter {
@@ -118,6 +130,7 @@ func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugi
 		firstEvent:       sub.definition.Options.FirstEvent,
 	}
 
+	pollerConf.retry.ReloadFromConfig(ctx, config.EventDispatcherRetryInitDelay, config.EventDispatcherRetryMaxDelay, config.EventDispatcherRetryFactor)
 	ed.eventPoller = newEventPoller(ctx, di, en, pollerConf)
 	return ed
 }
@@ -150,7 +163,11 @@ func (ed *eventDispatcher) electAndStart() {
 }
 
 func (ed *eventDispatcher) getEvents(ctx context.Context, filter database.Filter) ([]fftypes.LocallySequenced, error) {
-	events, _, err := ed.database.GetEvents(ctx, filter)
+	var events []*fftypes.Event
+	err := ed.subscription.pool.run(ctx, func() (err error) {
+		events, _, err = ed.database.GetEvents(ctx, filter)
+		return err
+	})
 	ls := make([]fftypes.LocallySequenced, len(events))
 	for i, e := range events {
 		ls[i] = e
@@ -173,7 +190,11 @@ func (ed *eventDispatcher) enrichEvents(events []fftypes.LocallySequenced) ([]*f
 		mfb.In("id", refIDs),
 		mfb.Eq("namespace", ed.namespace),
 	)
-	msgs, _, err := ed.database.GetMessages(ed.ctx, msgFilter)
+	var msgs []*fftypes.Message
+	err := ed.subscription.pool.run(ed.ctx, func() (err error) {
+		msgs, _, err = ed.database.GetMessages(ed.ctx, msgFilter)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +209,7 @@ func (ed *eventDispatcher) enrichEvents(events []fftypes.LocallySequenced) ([]*f
 		for _, msg := range msgs {
 			if *e.Reference == *msg.Header.ID {
 				enriched[i].Message = msg
+				enriched[i].Headers = msg.Header.Headers
 				break
 			}
 		}
@@ -243,6 +265,42 @@ func (ed *eventDispatcher) filterEvents(candidates []*fftypes.EventDelivery) []*
 	return matchingEvents
 }
 
+// prioritizeEvents orders a set of events for dispatch, so that events for higher priority messages are
+// delivered first. An event never moves ahead of an earlier event for a message on the same topic, or
+// an event without a message.
+func prioritizeEvents(events []*fftypes.EventDelivery) []*fftypes.EventDelivery {
+	remaining := append([]*fftypes.EventDelivery{}, events...)
+	ordered := make([]*fftypes.EventDelivery, 0, len(events))
+	for len(remaining) > 0 {
+		next := -1
+		for i, event := range remaining {
+			if next >= 0 && eventPriorityWeight(event) <= eventPriorityWeight(remaining[next]) {
+				continue
+			}
+			blocked := false
+			for _, earlier := range remaining[0:i] {
+				if earlier.Message == nil || event.Message == nil || earlier.Message.Header.SharesTopic(&event.Message.Header) {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				next = i
+			}
+		}
+		ordered = append(ordered, remaining[next])
+		remaining = append(remaining[0:next], remaining[next+1:]...)
+	}
+	return ordered
+}
+
+func eventPriorityWeight(event *fftypes.EventDelivery) int {
+	if event.Message == nil {
+		return (&fftypes.MessageHeader{}).PriorityWeight()
+	}
+	return event.Message.Header.PriorityWeight()
+}
+
 func (ed *eventDispatcher) bufferedDelivery(events []fftypes.LocallySequenced) (bool, error) {
 	// At this point, the page of messages we've been given are loaded from the DB into memory,
 	// but we can only make them in-flight and push them to the client up to the maximum
@@ -284,7 +342,9 @@ func (ed *eventDispatcher) bufferedDelivery(events []fftypes.LocallySequenced) (
 		l.Debugf("Dispatcher event state: readahead=%d candidates=%d matched=%d inflight=%d queued=%d dispatched=%d dispatchable=%d lastAck=%d nacks=%d highest=%d",
 			ed.readAhead, len(candidates), matchCount, inflightCount, len(matching), dispatched, len(disapatchable), lastAck, nacks, highestOffset)
 
-		for _, event := range disapatchable {
+		// We only prioritize within the set we dispatch together, as all of these are in-flight before
+		// we process any acks - so the offset can never move past an event we've not yet delivered
+		for _, event := range prioritizeEvents(disapatchable) {
 			ed.mux.Lock()
 			ed.inflight[*event.ID] = &event.Event
 			inflightCount = len(ed.inflight)
@@ -379,16 +439,30 @@ func (ed *eventDispatcher) deliverEvents() {
 				return
 			}
 			log.L(ed.ctx).Debugf("Dispatching %s event: %.10d/%s [%s]: ref=%s/%s", ed.transport.Name(), event.Sequence, event.ID, event.Type, event.Namespace, event.Reference)
+			_, span := tracing.StartSpan(tracing.MessageContext(ed.ctx, event.Reference), "events.DeliverEvent",
+				attribute.String("event.type", string(event.Type)),
+				attribute.String("transport", ed.transport.Name()),
+				attribute.String("subscription", ed.subscription.definition.Name),
+			)
 			var data []*fftypes.Data
 			var err error
 			if withData && event.Message != nil {
-				data, _, err = ed.data.GetMessageData(ed.ctx, event.Message, true)
+				err = ed.subscription.pool.run(ed.ctx, func() (err error) {
+					data, _, err = ed.data.GetMessageData(ed.ctx, event.Message, true)
+					return err
+				})
 			}
 			if err == nil {
 				err = ed.transport.DeliveryRequest(ed.connID, ed.subscription.definition, event, data)
 			}
+			tracing.EndSpan(span, err)
+			if ed.metrics.IsMetricsEnabled() {
+				ed.metrics.EventDelivered(event.Namespace, ed.transport.Name(), err == nil)
+			}
 			if err != nil {
 				ed.deliveryResponse(&fftypes.EventDeliveryResponse{ID: event.ID, Rejected: true})
+			} else if ed.metrics.IsMetricsEnabled() {
+				ed.metrics.EventDispatched(event)
 			}
 		case changeEvent := <-ed.changeEvents:
 			ws, ok := ed.transport.(events.ChangeEventListener)
@@ -428,6 +502,9 @@ func (ed *eventDispatcher) deliveryResponse(response *fftypes.EventDeliveryRespo
 	}
 
 	l.Debugf("Response for %s event: %.10d/%s [%s]: ref=%s/%s rejected=%t info='%s'", ed.transport.Name(), event.Sequence, event.ID, event.Type, event.Namespace, event.Reference, response.Rejected, response.Info)
+	if ed.metrics.IsMetricsEnabled() {
+		ed.metrics.EventResponse(event.Namespace, ed.transport.Name(), response.Rejected)
+	}
 	// We don't do any meaningful work in this call, we just set things up so the right thing
 	// will happen when the poller wakes up. So we need to pass it over
 	select {
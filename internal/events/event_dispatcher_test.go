@@ -23,11 +23,13 @@ import (
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
 	"github.com/hyperledger/firefly/mocks/eventsmocks"
+	"github.com/hyperledger/firefly/mocks/metricsmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -42,8 +44,13 @@ func newTestEventDispatcher(sub *subscription) (*eventDispatcher, func()) {
 	mei.On("Name").Return("ut").Maybe()
 	mdm := &datamocks.Manager{}
 	msh := &definitionsmocks.DefinitionHandlers{}
+	mmi := &metricsmocks.Manager{}
+	mmi.On("IsMetricsEnabled").Return(false).Maybe()
 	ctx, cancel := context.WithCancel(context.Background())
-	return newEventDispatcher(ctx, mei, mdi, mdm, msh, fftypes.NewUUID().String(), sub, newEventNotifier(ctx, "ut"), newChangeEventListener(ctx)), func() {
+	if sub.pool == nil {
+		sub.pool = newDeliveryPool(fftypes.SubOptsDeliveryClassStandard)
+	}
+	return newEventDispatcher(ctx, mei, mdi, mdm, msh, fftypes.NewUUID().String(), sub, newEventNotifier(ctx, "ut"), eventbus.NewBus(), newChangeEventListener(ctx), mmi), func() {
 		cancel()
 		config.Reset()
 	}
@@ -74,6 +81,7 @@ func TestEventDispatcherStartStop(t *testing.T) {
 	}
 
 	assert.Equal(t, int(10), ed.readAhead)
+	assert.Equal(t, "ns1", log.GetField(ed.ctx, log.NamespaceField))
 	ed.start()
 	confirmedElected <- true
 	close(confirmedElected)
@@ -407,6 +415,29 @@ func TestEnrichEventsFailGetMessages(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestEnrichEventsMessageHeaders(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:      fftypes.NewUUID(),
+			Headers: fftypes.JSONObject{"x-route": "east"},
+		},
+	}
+	mdi := ed.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{msg}, nil, nil)
+
+	enriched, err := ed.enrichEvents([]fftypes.LocallySequenced{&fftypes.Event{ID: fftypes.NewUUID(), Reference: msg.Header.ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, msg, enriched[0].Message)
+	assert.Equal(t, "east", enriched[0].Headers.GetString("x-route"))
+}
+
 func TestFilterEventsMatch(t *testing.T) {
 
 	sub := &subscription{
@@ -777,6 +808,26 @@ func TestAckClosed(t *testing.T) {
 	ed.deliveryResponse(&fftypes.EventDeliveryResponse{ID: id1})
 }
 
+func TestAckMetrics(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	cancel()
+
+	mmi := &metricsmocks.Manager{}
+	ed.metrics = mmi
+	mmi.On("IsMetricsEnabled").Return(true)
+	mmi.On("EventResponse", "ns1", "ut", true).Return()
+
+	id1 := fftypes.NewUUID()
+	ed.inflight[*id1] = &fftypes.Event{ID: id1, Namespace: "ns1"}
+	ed.deliveryResponse(&fftypes.EventDeliveryResponse{ID: id1, Rejected: true})
+
+	mmi.AssertExpectations(t)
+}
+
 func TestGetEvents(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
@@ -840,6 +891,42 @@ func TestDeliverEventsWithDataFail(t *testing.T) {
 
 }
 
+func TestDeliverEventsMetrics(t *testing.T) {
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	mmi := &metricsmocks.Manager{}
+	ed.metrics = mmi
+	mmi.On("IsMetricsEnabled").Return(true)
+	mmi.On("EventDelivered", "ns1", "ut", true).Return()
+	dispatched := make(chan struct{})
+	mmi.On("EventDispatched", mock.MatchedBy(func(ed *fftypes.EventDelivery) bool {
+		return ed.Namespace == "ns1"
+	})).Run(func(args mock.Arguments) {
+		close(dispatched)
+	})
+
+	mei := ed.transport.(*eventsmocks.PluginAll)
+	mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything, []*fftypes.Data(nil)).Return(nil)
+
+	ed.eventDelivery <- &fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+		},
+	}
+
+	go ed.deliverEvents()
+	<-dispatched
+
+	mei.AssertExpectations(t)
+	mmi.AssertExpectations(t)
+}
+
 func TestEventDispatcherWithReply(t *testing.T) {
 	log.SetLevel("debug")
 	var two = uint16(5)
@@ -926,3 +1013,34 @@ func TestDispatchChangeEventBlockedClose(t *testing.T) {
 
 	ed.dispatchChangeEvent(&fftypes.ChangeEvent{})
 }
+
+func TestPrioritizeEvents(t *testing.T) {
+	newEvent := func(seq int64, priority fftypes.MessagePriority, topics ...string) *fftypes.EventDelivery {
+		return &fftypes.EventDelivery{
+			Event: fftypes.Event{Sequence: seq},
+			Message: &fftypes.Message{
+				Header: fftypes.MessageHeader{
+					Namespace: "ns1",
+					Topics:    topics,
+					Priority:  priority,
+				},
+			},
+		}
+	}
+	events := []*fftypes.EventDelivery{
+		newEvent(1, fftypes.MessagePriorityLow, "bulk"),
+		newEvent(2, fftypes.MessagePriorityLow, "control"),
+		newEvent(3, fftypes.MessagePriorityHigh, "control"),
+		newEvent(4, fftypes.MessagePriorityHigh, "other"),
+		{Event: fftypes.Event{Sequence: 5}},
+		newEvent(6, fftypes.MessagePriorityHigh, "another"),
+	}
+	ordered := prioritizeEvents(events)
+	sequences := make([]int64, len(ordered))
+	for i, event := range ordered {
+		sequences[i] = event.Sequence
+	}
+	// Event 3 cannot move ahead of event 2, as they share a topic, and
+	// nothing can move ahead of event 5 as it does not have a message
+	assert.Equal(t, []int64{4, 1, 2, 3, 5, 6}, sequences)
+}
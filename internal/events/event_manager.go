@@ -21,44 +21,50 @@ import (
 	"context"
 	"encoding/json"
 	"strconv"
+	"time"
 
+	"github.com/hyperledger/firefly/internal/antireplay"
 	"github.com/hyperledger/firefly/internal/assets"
 	"github.com/hyperledger/firefly/internal/broadcast"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/definitions"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/events/eifactory"
 	"github.com/hyperledger/firefly/internal/events/system"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
+	"github.com/hyperledger/firefly/internal/loadshed"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/privatemessaging"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
 	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/batchvalidator"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/hyperledger/firefly/pkg/tokens"
+	"github.com/karlseguin/ccache"
 )
 
 type EventManager interface {
-	NewPins() chan<- int64
-	NewEvents() chan<- int64
-	NewSubscriptions() chan<- *fftypes.UUID
-	SubscriptionUpdates() chan<- *fftypes.UUID
-	DeletedSubscriptions() chan<- *fftypes.UUID
 	ChangeEvents() chan<- *fftypes.ChangeEvent
 	DeleteDurableSubscription(ctx context.Context, subDef *fftypes.Subscription) (err error)
 	CreateUpdateDurableSubscription(ctx context.Context, subDef *fftypes.Subscription, mustNew bool) (err error)
+	UpdateDurableSubscription(ctx context.Context, existing, subDef *fftypes.Subscription) (err error)
+	ReprocessQuarantinedBatch(ctx context.Context, qb *fftypes.QuarantinedBatch) (*fftypes.Batch, error)
+	GetAggregatorCheckpoint(ctx context.Context, ledger string) (*fftypes.Offset, error)
+	RewindAggregator(ctx context.Context, rewind *fftypes.AggregatorRewind) error
+	StartCatchup(ctx context.Context, ns string, req *fftypes.CatchupRequest) (*fftypes.Operation, error)
 	Start() error
 	WaitStop()
 
 	// Bound blockchain callbacks
-	OperationUpdate(plugin fftypes.Named, operationID *fftypes.UUID, txState blockchain.TransactionStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject) error
+	OperationUpdate(plugin fftypes.Named, operationID *fftypes.UUID, txState blockchain.TransactionStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject, receipt *fftypes.TransactionReceipt) error
 	BatchPinComplete(bi blockchain.Plugin, batch *blockchain.BatchPin, signingIdentity string) error
 	BlockchainEvent(event *blockchain.EventWithSubscription) error
 
@@ -80,7 +86,11 @@ type eventManager struct {
 	ctx                  context.Context
 	ni                   sysmessaging.LocalNodeInfo
 	publicstorage        publicstorage.Plugin
+	nsPublicStorage      map[string]publicstorage.Plugin
 	database             database.Plugin
+	blockchain           blockchain.Plugin
+	nsBlockchains        map[string]blockchain.Plugin
+	dataexchange         dataexchange.Plugin
 	txHelper             txcommon.Helper
 	identity             identity.Manager
 	definitions          definitions.DefinitionHandlers
@@ -88,35 +98,50 @@ type eventManager struct {
 	subManager           *subscriptionManager
 	retry                retry.Retry
 	aggregator           *aggregator
+	ledgerAggregators    map[string]*aggregator
+	nsLedgers            map[string]string
 	broadcast            broadcast.Manager
 	messaging            privatemessaging.Manager
 	assets               assets.Manager
 	newEventNotifier     *eventNotifier
 	newPinNotifier       *eventNotifier
+	eventBus             eventbus.Bus
 	opCorrelationRetries int
 	defaultTransport     string
 	internalEvents       *system.Events
 	metrics              metrics.Manager
+	batchValidators      []batchvalidator.Plugin
+	requireNodeSignature bool
+	verifyIdentityReg    bool
+	maxBatchPayloadSize  int64
+	batchCacheTTL        time.Duration
+	batchCache           *ccache.Cache
+	catchupPageSize      uint64
+	replayWindow         antireplay.Window
 }
 
-func NewEventManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, pi publicstorage.Plugin, di database.Plugin, im identity.Manager, dh definitions.DefinitionHandlers, dm data.Manager, bm broadcast.Manager, pm privatemessaging.Manager, am assets.Manager, mm metrics.Manager) (EventManager, error) {
-	if ni == nil || pi == nil || di == nil || im == nil || dh == nil || dm == nil || bm == nil || pm == nil || am == nil {
+func NewEventManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, pi publicstorage.Plugin, nsPublicStorage map[string]publicstorage.Plugin, di database.Plugin, bi blockchain.Plugin, nsBlockchains map[string]blockchain.Plugin, dx dataexchange.Plugin, im identity.Manager, dh definitions.DefinitionHandlers, dm data.Manager, bm broadcast.Manager, pm privatemessaging.Manager, am assets.Manager, mm metrics.Manager, eb eventbus.Bus, bv []batchvalidator.Plugin, ledgers []string, nsLedgers map[string]string) (EventManager, error) {
+	if ni == nil || pi == nil || di == nil || bi == nil || dx == nil || im == nil || dh == nil || dm == nil || bm == nil || pm == nil || am == nil || eb == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 	newPinNotifier := newEventNotifier(ctx, "pins")
 	newEventNotifier := newEventNotifier(ctx, "events")
 	em := &eventManager{
-		ctx:           log.WithLogField(ctx, "role", "event-manager"),
-		ni:            ni,
-		publicstorage: pi,
-		database:      di,
-		txHelper:      txcommon.NewTransactionHelper(di),
-		identity:      im,
-		definitions:   dh,
-		data:          dm,
-		broadcast:     bm,
-		messaging:     pm,
-		assets:        am,
+		ctx:             log.WithLogField(log.WithModule(ctx, "events"), "role", "event-manager"),
+		ni:              ni,
+		publicstorage:   pi,
+		nsPublicStorage: nsPublicStorage,
+		database:        di,
+		blockchain:      bi,
+		nsBlockchains:   nsBlockchains,
+		dataexchange:    dx,
+		txHelper:        txcommon.NewTransactionHelper(di),
+		identity:        im,
+		definitions:     dh,
+		data:            dm,
+		broadcast:       bm,
+		messaging:       pm,
+		assets:          am,
 		retry: retry.Retry{
 			InitialDelay: config.GetDuration(config.EventAggregatorRetryInitDelay),
 			MaximumDelay: config.GetDuration(config.EventAggregatorRetryMaxDelay),
@@ -126,16 +151,48 @@ func NewEventManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, pi publ
 		opCorrelationRetries: config.GetInt(config.EventAggregatorOpCorrelationRetries),
 		newEventNotifier:     newEventNotifier,
 		newPinNotifier:       newPinNotifier,
-		aggregator:           newAggregator(ctx, di, dh, dm, newPinNotifier, mm),
+		eventBus:             eb,
+		aggregator:           newAggregator(ctx, di, dh, dm, newPinNotifier, eb, mm, ""),
+		ledgerAggregators:    make(map[string]*aggregator),
+		nsLedgers:            nsLedgers,
 		metrics:              mm,
+		batchValidators:      bv,
+		requireNodeSignature: config.GetBool(config.EventAggregatorRequireNodeSignature),
+		maxBatchPayloadSize:  config.GetByteSize(config.PublicStorageBatchPayloadLimit),
+		batchCacheTTL:        config.GetDuration(config.EventAggregatorBatchCacheTTL),
+		catchupPageSize:      uint64(config.GetUint(config.EventCatchupPageSize)),
+		replayWindow:         antireplay.NewWindow(ctx, config.GetDuration(config.EventDXAntiReplayWindow)),
+	}
+	em.retry.ReloadFromConfig(em.ctx, config.EventAggregatorRetryInitDelay, config.EventAggregatorRetryMaxDelay, config.EventAggregatorRetryFactor)
+	em.batchCache = ccache.New(
+		// We use a LRU cache of the hashes of recently confirmed batches, limited by item count
+		ccache.Configure().MaxSize(config.GetInt64(config.EventAggregatorBatchCacheLimit)),
+	)
+	for _, ledger := range ledgers {
+		// Each additional ledger has an independent stream of pins, so is aggregated with its own checkpoint
+		em.ledgerAggregators[ledger] = newAggregator(ctx, di, dh, dm, newPinNotifier, eb, mm, ledger)
 	}
 	ie, _ := eifactory.GetPlugin(ctx, system.SystemEventsTransport)
 	em.internalEvents = ie.(*system.Events)
 
+	if config.GetBool(config.EventAggregatorVerifyIdentityRegistry) {
+		// Batches are verified against the registry of the ledger their namespace is pinned to
+		if !bi.Capabilities().IdentityRegistry {
+			return nil, i18n.NewError(ctx, i18n.MsgNoIdentityRegistry, bi.Name())
+		}
+		for _, nsbi := range nsBlockchains {
+			if !nsbi.Capabilities().IdentityRegistry {
+				return nil, i18n.NewError(ctx, i18n.MsgNoIdentityRegistry, nsbi.Name())
+			}
+		}
+		em.verifyIdentityReg = true
+	}
+
 	var err error
-	if em.subManager, err = newSubscriptionManager(ctx, di, dm, newEventNotifier, dh); err != nil {
+	if em.subManager, err = newSubscriptionManager(ctx, di, dm, newEventNotifier, eb, dh, mm); err != nil {
 		return nil, err
 	}
+	em.subscribeEventBus()
 
 	return em, nil
 }
@@ -144,37 +201,66 @@ func (em *eventManager) Start() (err error) {
 	err = em.subManager.start()
 	if err == nil {
 		em.aggregator.start()
+		for _, ag := range em.ledgerAggregators {
+			ag.start()
+		}
 	}
 	return err
 }
 
-func (em *eventManager) NewEvents() chan<- int64 {
-	return em.newEventNotifier.newEvents
-}
-
-func (em *eventManager) NewPins() chan<- int64 {
-	return em.newPinNotifier.newEvents
+// subscribeEventBus hooks the notifications the event manager needs from other components onto the
+// channels of the pollers and subscription manager that process them
+func (em *eventManager) subscribeEventBus() {
+	em.eventBus.Subscribe(eventbus.TopicEventCreated, func(payload interface{}) {
+		em.newEventNotifier.newEvents <- payload.(int64)
+	})
+	em.eventBus.Subscribe(eventbus.TopicPinCreated, func(payload interface{}) {
+		em.newPinNotifier.newEvents <- payload.(int64)
+	})
+	newOrUpdated := func(payload interface{}) {
+		em.subManager.newOrUpdatedSubscriptions <- payload.(*fftypes.UUID)
+	}
+	em.eventBus.Subscribe(eventbus.TopicSubscriptionCreated, newOrUpdated)
+	em.eventBus.Subscribe(eventbus.TopicSubscriptionUpdated, newOrUpdated)
+	em.eventBus.Subscribe(eventbus.TopicSubscriptionDeleted, func(payload interface{}) {
+		em.subManager.deletedSubscriptions <- payload.(*fftypes.UUID)
+	})
+	em.eventBus.Subscribe(eventbus.TopicLoadSheddingChanged, func(payload interface{}) {
+		em.subManager.loadSheddingChanged(payload.(loadshed.Level))
+	})
 }
 
-func (em *eventManager) NewSubscriptions() chan<- *fftypes.UUID {
-	return em.subManager.newOrUpdatedSubscriptions
+func (em *eventManager) ChangeEvents() chan<- *fftypes.ChangeEvent {
+	return em.subManager.cel.changeEvents
 }
 
-func (em *eventManager) SubscriptionUpdates() chan<- *fftypes.UUID {
-	return em.subManager.newOrUpdatedSubscriptions
+func (em *eventManager) WaitStop() {
+	em.subManager.close()
+	<-em.aggregator.eventPoller.closed
+	for _, ag := range em.ledgerAggregators {
+		<-ag.eventPoller.closed
+	}
 }
 
-func (em *eventManager) DeletedSubscriptions() chan<- *fftypes.UUID {
-	return em.subManager.deletedSubscriptions
+// namespaceAggregator returns the aggregator of the ledger that a namespace pins its batches to
+func (em *eventManager) namespaceAggregator(ns string) *aggregator {
+	if ag, ok := em.ledgerAggregators[em.nsLedgers[ns]]; ok {
+		return ag
+	}
+	return em.aggregator
 }
 
-func (em *eventManager) ChangeEvents() chan<- *fftypes.ChangeEvent {
-	return em.subManager.cel.changeEvents
+// blockchainFor returns the blockchain plugin of the ledger that a namespace pins its batches to
+func (em *eventManager) blockchainFor(ns string) blockchain.Plugin {
+	if bi, ok := em.nsBlockchains[ns]; ok {
+		return bi
+	}
+	return em.blockchain
 }
 
-func (em *eventManager) WaitStop() {
-	em.subManager.close()
-	<-em.aggregator.eventPoller.closed
+// notifyOffchainBatch informs the aggregator that holds the pins for a batch that its off-chain parts have arrived
+func (em *eventManager) notifyOffchainBatch(ns string, batchID *fftypes.UUID) {
+	em.namespaceAggregator(ns).offchainBatches <- batchID
 }
 
 func (em *eventManager) CreateUpdateDurableSubscription(ctx context.Context, subDef *fftypes.Subscription, mustNew bool) (err error) {
@@ -220,13 +306,72 @@ func (em *eventManager) CreateUpdateDurableSubscription(ctx context.Context, sub
 		subDef.Options.FirstEvent = &lockedInFirstEvent
 	}
 
+	// Record the change to the definition for audit, alongside the change itself
+	change := fftypes.NewSubscriptionChange(fftypes.ChangeEventTypeCreated, nil, subDef)
+	if existing != nil {
+		change = fftypes.NewSubscriptionChange(fftypes.ChangeEventTypeUpdated, existing, subDef)
+	}
+
 	// The event in the database for the creation of the susbscription, will asynchronously update the submanager
-	return em.database.UpsertSubscription(ctx, subDef, !mustNew)
+	return em.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := em.database.UpsertSubscription(ctx, subDef, !mustNew); err != nil {
+			return err
+		}
+		return em.database.InsertSubscriptionChange(ctx, change)
+	})
+}
+
+// UpdateDurableSubscription persists only the fields of an existing subscription that differ in the new definition,
+// rather than replacing the whole subscription
+func (em *eventManager) UpdateDurableSubscription(ctx context.Context, existing, subDef *fftypes.Subscription) (err error) {
+	if subDef.Transport == "" {
+		subDef.Transport = em.defaultTransport
+	}
+	if _, err = em.subManager.parseSubscriptionDef(ctx, subDef); err != nil {
+		return err
+	}
+
+	subDef.Options.FirstEvent = existing.Options.FirstEvent // we do not reset the sub position
+	subDef.Updated = fftypes.Now()
+	update := database.SubscriptionQueryFactory.NewUpdate(ctx).Set("updated", subDef.Updated)
+	changed := false
+	setIfChanged := func(field, before, after string) {
+		if before != after {
+			update.Set(field, after)
+			changed = true
+		}
+	}
+	setIfChanged("transport", existing.Transport, subDef.Transport)
+	setIfChanged("filter.events", existing.Filter.Events, subDef.Filter.Events)
+	setIfChanged("filter.topics", existing.Filter.Topics, subDef.Filter.Topics)
+	setIfChanged("filter.tag", existing.Filter.Tag, subDef.Filter.Tag)
+	setIfChanged("filter.group", existing.Filter.Group, subDef.Filter.Group)
+	optsBefore, _ := existing.Options.MarshalJSON()
+	optsAfter, _ := subDef.Options.MarshalJSON()
+	setIfChanged("options", string(optsBefore), string(optsAfter))
+	if !changed {
+		log.L(ctx).Infof("Subscription update has no changes")
+		subDef.Updated = existing.Updated
+		return nil
+	}
+
+	// The event in the database for the update of the susbscription, will asynchronously update the submanager
+	return em.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := em.database.UpdateSubscription(ctx, subDef.Namespace, subDef.Name, update); err != nil {
+			return err
+		}
+		return em.database.InsertSubscriptionChange(ctx, fftypes.NewSubscriptionChange(fftypes.ChangeEventTypeUpdated, existing, subDef))
+	})
 }
 
 func (em *eventManager) DeleteDurableSubscription(ctx context.Context, subDef *fftypes.Subscription) (err error) {
 	// The event in the database for the deletion of the susbscription, will asynchronously update the submanager
-	return em.database.DeleteSubscriptionByID(ctx, subDef.ID)
+	return em.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := em.database.DeleteSubscriptionByID(ctx, subDef.ID); err != nil {
+			return err
+		}
+		return em.database.InsertSubscriptionChange(ctx, fftypes.NewSubscriptionChange(fftypes.ChangeEventTypeDeleted, subDef, nil))
+	})
 }
 
 func (em *eventManager) AddSystemEventListener(ns string, el system.EventListener) error {
@@ -20,12 +20,17 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/events/system"
+	"github.com/hyperledger/firefly/internal/loadshed"
 	"github.com/hyperledger/firefly/mocks/assetmocks"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/broadcastmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
 	"github.com/hyperledger/firefly/mocks/eventsmocks"
@@ -35,6 +40,8 @@ import (
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
 	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -47,7 +54,10 @@ func newTestEventManager(t *testing.T) (*eventManager, func()) {
 	ctx, cancel := context.WithCancel(context.Background())
 	mdi := &databasemocks.Plugin{}
 	mim := &identitymanagermocks.Manager{}
+	mim.On("VerifyCryptoPolicy", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe() // no namespace policies by default
 	mpi := &publicstoragemocks.Plugin{}
+	mbi := &blockchainmocks.Plugin{}
+	mdx := &dataexchangemocks.Plugin{}
 	met := &eventsmocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	msh := &definitionsmocks.DefinitionHandlers{}
@@ -59,10 +69,11 @@ func newTestEventManager(t *testing.T) (*eventManager, func()) {
 	mmi.On("IsMetricsEnabled").Return(false)
 	mni.On("GetNodeUUID", mock.Anything).Return(testNodeID).Maybe()
 	met.On("Name").Return("ut").Maybe()
-	emi, err := NewEventManager(ctx, mni, mpi, mdi, mim, msh, mdm, mbm, mpm, mam, mmi)
+	emi, err := NewEventManager(ctx, mni, mpi, nil, mdi, mbi, nil, mdx, mim, msh, mdm, mbm, mpm, mam, mmi, eventbus.NewBus(), nil, nil, nil)
 	em := emi.(*eventManager)
 	em.txHelper = &txcommonmocks.Helper{}
 	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything).Maybe()
+	mdi.On("GetNodeByID", mock.Anything, mock.Anything).Return(nil, nil).Maybe() // batches from unregistered nodes are accepted by default
 	rag.RunFn = func(a mock.Arguments) {
 		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
 	}
@@ -75,7 +86,10 @@ func newTestEventManagerWithMetrics(t *testing.T) (*eventManager, func()) {
 	ctx, cancel := context.WithCancel(context.Background())
 	mdi := &databasemocks.Plugin{}
 	mim := &identitymanagermocks.Manager{}
+	mim.On("VerifyCryptoPolicy", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe() // no namespace policies by default
 	mpi := &publicstoragemocks.Plugin{}
+	mbi := &blockchainmocks.Plugin{}
+	mdx := &dataexchangemocks.Plugin{}
 	met := &eventsmocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	msh := &definitionsmocks.DefinitionHandlers{}
@@ -88,10 +102,11 @@ func newTestEventManagerWithMetrics(t *testing.T) (*eventManager, func()) {
 	mmi.On("TransferConfirmed", mock.Anything)
 	mni.On("GetNodeUUID", mock.Anything).Return(testNodeID).Maybe()
 	met.On("Name").Return("ut").Maybe()
-	emi, err := NewEventManager(ctx, mni, mpi, mdi, mim, msh, mdm, mbm, mpm, mam, mmi)
+	emi, err := NewEventManager(ctx, mni, mpi, nil, mdi, mbi, nil, mdx, mim, msh, mdm, mbm, mpm, mam, mmi, eventbus.NewBus(), nil, nil, nil)
 	em := emi.(*eventManager)
 	em.txHelper = &txcommonmocks.Helper{}
 	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything).Maybe()
+	mdi.On("GetNodeByID", mock.Anything, mock.Anything).Return(nil, nil).Maybe() // batches from unregistered nodes are accepted by default
 	rag.RunFn = func(a mock.Arguments) {
 		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
 	}
@@ -111,15 +126,76 @@ func TestStartStop(t *testing.T) {
 	mdi.On("GetPins", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
 	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 	assert.NoError(t, em.Start())
-	em.NewEvents() <- 12345
-	em.NewPins() <- 12345
+	em.eventBus.Publish(eventbus.TopicEventCreated, int64(12345))
+	em.eventBus.Publish(eventbus.TopicPinCreated, int64(12345))
 	assert.Equal(t, chan<- *fftypes.ChangeEvent(em.subManager.cel.changeEvents), em.ChangeEvents())
 	cancel()
 	em.WaitStop()
 }
 
+func TestStartStopLedgers(t *testing.T) {
+	config.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	mdi := &databasemocks.Plugin{}
+	mim := &identitymanagermocks.Manager{}
+	mpi := &publicstoragemocks.Plugin{}
+	mbi := &blockchainmocks.Plugin{}
+	mdx := &dataexchangemocks.Plugin{}
+	mdm := &datamocks.Manager{}
+	msh := &definitionsmocks.DefinitionHandlers{}
+	mbm := &broadcastmocks.Manager{}
+	mpm := &privatemessagingmocks.Manager{}
+	mam := &assetmocks.Manager{}
+	mni := &sysmessagingmocks.LocalNodeInfo{}
+	mmi := &metricsmocks.Manager{}
+	emi, err := NewEventManager(ctx, mni, mpi, nil, mdi, mbi, nil, mdx, mim, msh, mdm, mbm, mpm, mam, mmi, eventbus.NewBus(), nil, []string{"ledger2"}, map[string]string{"ns2": "ledger2"})
+	assert.NoError(t, err)
+	em := emi.(*eventManager)
+	assert.Equal(t, "ff_aggregator_ledger2", em.ledgerAggregators["ledger2"].eventPoller.conf.offsetName)
+
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeAggregator, aggregatorOffsetName).Return(&fftypes.Offset{
+		Type: fftypes.OffsetTypeAggregator,
+		Name: aggregatorOffsetName,
+	}, nil)
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeAggregator, "ff_aggregator_ledger2").Return(&fftypes.Offset{
+		Type: fftypes.OffsetTypeAggregator,
+		Name: "ff_aggregator_ledger2",
+	}, nil)
+	mdi.On("GetPins", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
+	assert.NoError(t, em.Start())
+	cancel()
+	em.WaitStop()
+	mdi.AssertExpectations(t)
+}
+
+func TestNotifyOffchainBatchNamespaceLedger(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	ag := newAggregator(em.ctx, em.database, em.definitions, em.data, em.newPinNotifier, em.eventBus, em.metrics, "ledger2")
+	em.ledgerAggregators["ledger2"] = ag
+	em.nsLedgers = map[string]string{"ns2": "ledger2"}
+
+	batchID1 := fftypes.NewUUID()
+	batchID2 := fftypes.NewUUID()
+	em.notifyOffchainBatch("ns2", batchID1)
+	em.notifyOffchainBatch("ns1", batchID2)
+	assert.Equal(t, batchID1, <-ag.offchainBatches)
+	assert.Equal(t, batchID2, <-em.aggregator.offchainBatches)
+}
+
+func TestBlockchainForNamespaceLedger(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mbi2 := &blockchainmocks.Plugin{}
+	em.nsBlockchains = map[string]blockchain.Plugin{"ns2": mbi2}
+
+	assert.Equal(t, mbi2, em.blockchainFor("ns2"))
+	assert.Equal(t, em.blockchain, em.blockchainFor("ns1"))
+}
+
 func TestStartStopBadDependencies(t *testing.T) {
-	_, err := NewEventManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	_, err := NewEventManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 
 }
@@ -129,7 +205,10 @@ func TestStartStopBadTransports(t *testing.T) {
 	defer config.Reset()
 	mdi := &databasemocks.Plugin{}
 	mim := &identitymanagermocks.Manager{}
+	mim.On("VerifyCryptoPolicy", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe() // no namespace policies by default
 	mpi := &publicstoragemocks.Plugin{}
+	mbi := &blockchainmocks.Plugin{}
+	mdx := &dataexchangemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	msh := &definitionsmocks.DefinitionHandlers{}
 	mbm := &broadcastmocks.Manager{}
@@ -137,10 +216,47 @@ func TestStartStopBadTransports(t *testing.T) {
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mam := &assetmocks.Manager{}
 	mm := &metricsmocks.Manager{}
-	_, err := NewEventManager(context.Background(), mni, mpi, mdi, mim, msh, mdm, mbm, mpm, mam, mm)
+	_, err := NewEventManager(context.Background(), mni, mpi, nil, mdi, mbi, nil, mdx, mim, msh, mdm, mbm, mpm, mam, mm, eventbus.NewBus(), nil, nil, nil)
 	assert.Regexp(t, "FF10172", err)
 }
 
+func TestNewEventManagerIdentityRegistry(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventAggregatorVerifyIdentityRegistry, true)
+	defer config.Reset()
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Capabilities").Return(&blockchain.Capabilities{IdentityRegistry: true})
+	mmi := &metricsmocks.Manager{}
+	mmi.On("IsMetricsEnabled").Return(false)
+	emi, err := NewEventManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &publicstoragemocks.Plugin{}, nil, &databasemocks.Plugin{}, mbi, map[string]blockchain.Plugin{"ns1": mbi}, &dataexchangemocks.Plugin{}, &identitymanagermocks.Manager{}, &definitionsmocks.DefinitionHandlers{}, &datamocks.Manager{}, &broadcastmocks.Manager{}, &privatemessagingmocks.Manager{}, &assetmocks.Manager{}, mmi, eventbus.NewBus(), nil, nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, emi.(*eventManager).verifyIdentityReg)
+}
+
+func TestNewEventManagerIdentityRegistryNotConfigured(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventAggregatorVerifyIdentityRegistry, true)
+	defer config.Reset()
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Capabilities").Return(&blockchain.Capabilities{})
+	mbi.On("Name").Return("ut")
+	_, err := NewEventManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &publicstoragemocks.Plugin{}, nil, &databasemocks.Plugin{}, mbi, nil, &dataexchangemocks.Plugin{}, &identitymanagermocks.Manager{}, &definitionsmocks.DefinitionHandlers{}, &datamocks.Manager{}, &broadcastmocks.Manager{}, &privatemessagingmocks.Manager{}, &assetmocks.Manager{}, &metricsmocks.Manager{}, eventbus.NewBus(), nil, nil, nil)
+	assert.Regexp(t, "FF10457.*ut", err)
+}
+
+func TestNewEventManagerIdentityRegistryNotConfiguredNamespaceLedger(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventAggregatorVerifyIdentityRegistry, true)
+	defer config.Reset()
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Capabilities").Return(&blockchain.Capabilities{IdentityRegistry: true})
+	mbi2 := &blockchainmocks.Plugin{}
+	mbi2.On("Capabilities").Return(&blockchain.Capabilities{})
+	mbi2.On("Name").Return("ledger2")
+	_, err := NewEventManager(context.Background(), &sysmessagingmocks.LocalNodeInfo{}, &publicstoragemocks.Plugin{}, nil, &databasemocks.Plugin{}, mbi, map[string]blockchain.Plugin{"ns1": mbi2}, &dataexchangemocks.Plugin{}, &identitymanagermocks.Manager{}, &definitionsmocks.DefinitionHandlers{}, &datamocks.Manager{}, &broadcastmocks.Manager{}, &privatemessagingmocks.Manager{}, &assetmocks.Manager{}, &metricsmocks.Manager{}, eventbus.NewBus(), nil, nil, nil)
+	assert.Regexp(t, "FF10457.*ledger2", err)
+}
+
 func TestEmitSubscriptionEventsNoops(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	mdi := em.database.(*databasemocks.Plugin)
@@ -172,15 +288,31 @@ func TestEmitSubscriptionEventsNoops(t *testing.T) {
 
 	// Wait until the gets occur for these events, which will return nil
 	getSubCallReady <- true
-	em.NewSubscriptions() <- fftypes.NewUUID()
-	em.SubscriptionUpdates() <- fftypes.NewUUID()
+	em.eventBus.Publish(eventbus.TopicSubscriptionCreated, fftypes.NewUUID())
+	em.eventBus.Publish(eventbus.TopicSubscriptionUpdated, fftypes.NewUUID())
 	<-getSubCalled
 
-	em.DeletedSubscriptions() <- fftypes.NewUUID()
+	em.eventBus.Publish(eventbus.TopicSubscriptionDeleted, fftypes.NewUUID())
 	close(getSubCallReady)
 	<-delOffsetCalled
 }
 
+func TestLoadSheddingSlowsDispatch(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	config.Set(config.LoadSheddingDispatchDelay, "5s")
+
+	em.eventBus.Publish(eventbus.TopicLoadSheddingChanged, loadshed.LevelSlowDispatch)
+	for _, pool := range em.subManager.deliveryPools {
+		assert.Equal(t, int64(5*time.Second), pool.delay)
+	}
+
+	em.eventBus.Publish(eventbus.TopicLoadSheddingChanged, loadshed.LevelPauseBulkQueries)
+	for _, pool := range em.subManager.deliveryPools {
+		assert.Zero(t, pool.delay)
+	}
+}
+
 func TestCreateDurableSubscriptionBadSub(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -300,6 +432,9 @@ func TestCreateDurableSubscriptionOk(t *testing.T) {
 		{Sequence: 12345},
 	}, nil, nil)
 	mdi.On("UpsertSubscription", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("InsertSubscriptionChange", mock.Anything, mock.MatchedBy(func(change *fftypes.SubscriptionChange) bool {
+		return change.Type == fftypes.ChangeEventTypeCreated && change.Before == nil && change.After == sub
+	})).Return(nil)
 	err := em.CreateUpdateDurableSubscription(em.ctx, sub, true)
 	assert.NoError(t, err)
 	// Check genreated fields
@@ -331,6 +466,9 @@ func TestUpdateDurableSubscriptionOk(t *testing.T) {
 		},
 	}, nil) // return non-matching existing
 	mdi.On("UpsertSubscription", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertSubscriptionChange", mock.Anything, mock.MatchedBy(func(change *fftypes.SubscriptionChange) bool {
+		return change.Type == fftypes.ChangeEventTypeUpdated && change.Before != nil && change.After == sub
+	})).Return(nil)
 	err := em.CreateUpdateDurableSubscription(em.ctx, sub, false)
 	assert.NoError(t, err)
 	// Check genreated fields
@@ -366,6 +504,118 @@ func TestUpdateDurableSubscriptionNoOp(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUpdateDurableSubscriptionChangedFields(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	var firstEvent fftypes.SubOptsFirstEvent = "12345"
+	existing := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Name:      "sub1",
+		},
+		Transport: "websockets",
+		Filter: fftypes.SubscriptionFilter{
+			Topics: "topic1",
+		},
+		Options: fftypes.SubscriptionOptions{
+			SubscriptionCoreOptions: fftypes.SubscriptionCoreOptions{
+				FirstEvent: &firstEvent,
+			},
+		},
+	}
+	readAhead := uint16(50)
+	sub := &fftypes.Subscription{
+		SubscriptionRef: existing.SubscriptionRef,
+		Filter:          existing.Filter,
+		Options: fftypes.SubscriptionOptions{
+			SubscriptionCoreOptions: fftypes.SubscriptionCoreOptions{
+				ReadAhead: &readAhead,
+			},
+		},
+	}
+	mdi.On("UpdateSubscription", mock.Anything, "ns1", "sub1", mock.MatchedBy(func(u database.Update) bool {
+		info, _ := u.Finalize()
+		return len(info.SetOperations) == 2 &&
+			info.SetOperations[0].Field == "updated" &&
+			info.SetOperations[1].Field == "options"
+	})).Return(nil)
+	mdi.On("InsertSubscriptionChange", mock.Anything, mock.MatchedBy(func(change *fftypes.SubscriptionChange) bool {
+		return change.Type == fftypes.ChangeEventTypeUpdated && change.Before == existing && change.After == sub
+	})).Return(nil)
+	err := em.UpdateDurableSubscription(em.ctx, existing, sub)
+	assert.NoError(t, err)
+	assert.Equal(t, "websockets", sub.Transport)
+	assert.Equal(t, "12345", string(*sub.Options.FirstEvent))
+	assert.NotNil(t, sub.Updated)
+	mdi.AssertExpectations(t)
+}
+
+func TestUpdateDurableSubscriptionNoChanges(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	no := false
+	existing := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Name:      "sub1",
+		},
+		Transport: "websockets",
+		Options: fftypes.SubscriptionOptions{
+			SubscriptionCoreOptions: fftypes.SubscriptionCoreOptions{
+				WithData: &no,
+			},
+		},
+		Updated: fftypes.Now(),
+	}
+	sub := *existing
+	sub.Updated = nil
+	err := em.UpdateDurableSubscription(em.ctx, existing, &sub)
+	assert.NoError(t, err)
+	assert.Equal(t, existing.Updated, sub.Updated)
+	mdi.AssertNotCalled(t, "UpdateSubscription", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateDurableSubscriptionBadTransport(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	existing := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Name:      "sub1",
+		},
+		Transport: "websockets",
+	}
+	sub := *existing
+	sub.Transport = "wrong"
+	err := em.UpdateDurableSubscription(em.ctx, existing, &sub)
+	assert.Regexp(t, "FF10172", err)
+}
+
+func TestUpdateDurableSubscriptionFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	existing := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Name:      "sub1",
+		},
+		Transport: "websockets",
+	}
+	sub := *existing
+	sub.Filter.Tag = "tag1"
+	mdi.On("UpdateSubscription", mock.Anything, "ns1", "sub1", mock.Anything).Return(fmt.Errorf("pop"))
+	err := em.UpdateDurableSubscription(em.ctx, existing, &sub)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertNotCalled(t, "InsertSubscriptionChange", mock.Anything, mock.Anything)
+}
+
 func TestCreateDeleteDurableSubscriptionOk(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -374,10 +624,44 @@ func TestCreateDeleteDurableSubscriptionOk(t *testing.T) {
 	sub := &fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{ID: subId, Namespace: "ns1"}}
 	mdi.On("GetSubscriptionByID", mock.Anything, subId).Return(sub, nil)
 	mdi.On("DeleteSubscriptionByID", mock.Anything, subId).Return(nil)
+	mdi.On("InsertSubscriptionChange", mock.Anything, mock.MatchedBy(func(change *fftypes.SubscriptionChange) bool {
+		return change.Type == fftypes.ChangeEventTypeDeleted && change.Before == sub && change.After == nil
+	})).Return(nil)
 	err := em.DeleteDurableSubscription(em.ctx, sub)
 	assert.NoError(t, err)
 }
 
+func TestCreateDurableSubscriptionUpsertFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	sub := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Name:      "sub1",
+		},
+	}
+	mdi.On("GetSubscriptionByName", mock.Anything, "ns1", "sub1").Return(nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+	mdi.On("UpsertSubscription", mock.Anything, mock.Anything, false).Return(fmt.Errorf("pop"))
+	err := em.CreateUpdateDurableSubscription(em.ctx, sub, true)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertNotCalled(t, "InsertSubscriptionChange", mock.Anything, mock.Anything)
+}
+
+func TestDeleteDurableSubscriptionFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	subId := fftypes.NewUUID()
+	sub := &fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{ID: subId, Namespace: "ns1"}}
+	mdi.On("DeleteSubscriptionByID", mock.Anything, subId).Return(fmt.Errorf("pop"))
+	err := em.DeleteDurableSubscription(em.ctx, sub)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertNotCalled(t, "InsertSubscriptionChange", mock.Anything, mock.Anything)
+}
+
 func TestAddInternalListener(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	ie := &system.Events{}
@@ -22,6 +22,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/pkg/database"
@@ -36,10 +38,27 @@ type eventPoller struct {
 	closed        chan struct{}
 	offsetID      int64
 	pollingOffset int64
+	pollTimeout   time.Duration
 	mux           sync.Mutex
 	conf          *eventPollerConf
 }
 
+// adaptivePollConf configures lengthening of the poll timeout while no events are arriving, to reduce
+// the load on the database of idle pollers. Notifications of new events still wake the poller immediately.
+type adaptivePollConf struct {
+	enabled    bool
+	factor     float64
+	maxTimeout time.Duration
+}
+
+func newAdaptivePollConf() adaptivePollConf {
+	return adaptivePollConf{
+		enabled:    config.GetBool(config.EventPollerAdaptive),
+		factor:     config.GetFloat64(config.EventPollerAdaptiveFactor),
+		maxTimeout: config.GetDuration(config.EventPollerAdaptiveMaxTimeout),
+	}
+}
+
 type newEventsHandler func(events []fftypes.LocallySequenced) (bool, error)
 
 type eventPollerConf struct {
@@ -47,6 +66,7 @@ type eventPollerConf struct {
 	eventBatchSize             int
 	eventBatchTimeout          time.Duration
 	eventPollTimeout           time.Duration
+	adaptivePoll               adaptivePollConf
 	firstEvent                 *fftypes.SubOptsFirstEvent
 	queryFactory               database.QueryFactory
 	addCriteria                func(database.AndFilter) database.AndFilter
@@ -54,6 +74,7 @@ type eventPollerConf struct {
 	maybeRewind                func() (bool, int64)
 	newEventsHandler           newEventsHandler
 	namespace                  string
+	eventBus                   eventbus.Bus
 	offsetName                 string
 	offsetType                 fftypes.OffsetType
 	retry                      retry.Retry
@@ -67,6 +88,7 @@ func newEventPoller(ctx context.Context, di database.Plugin, en *eventNotifier,
 		shoulderTaps:  make(chan bool, 1),
 		eventNotifier: en,
 		closed:        make(chan struct{}),
+		pollTimeout:   conf.eventPollTimeout,
 		conf:          conf,
 	}
 	if ep.conf.maybeRewind == nil {
@@ -150,6 +172,12 @@ func (ep *eventPoller) commitOffset(ctx context.Context, offset int64) error {
 		if err := ep.database.UpdateOffset(ctx, ep.offsetID, u); err != nil {
 			return err
 		}
+		ep.conf.eventBus.Publish(eventbus.TopicOffsetCommitted, &fftypes.Offset{
+			Type:    ep.conf.offsetType,
+			Name:    ep.conf.offsetName,
+			Current: ep.pollingOffset,
+			RowID:   ep.offsetID,
+		})
 	}
 	l.Debugf("Event polling offset committed %d", ep.pollingOffset)
 	return nil
@@ -258,7 +286,10 @@ func (ep *eventPoller) shoulderTap() {
 
 func (ep *eventPoller) waitForShoulderTapOrPollTimeout(lastEventCount int) bool {
 	l := log.L(ep.ctx)
-	longTimeoutDuration := ep.conf.eventPollTimeout
+	if lastEventCount > 0 {
+		ep.pollTimeout = ep.conf.eventPollTimeout
+	}
+	longTimeoutDuration := ep.pollTimeout
 	// For throughput optimized environments, we can set an eventBatchingTimeout to allow messages to arrive
 	// between polling cycles (at the cost of some dispatch latency)
 	if ep.conf.eventBatchTimeout > 0 && lastEventCount > 0 && lastEventCount < ep.conf.eventBatchSize {
@@ -277,6 +308,7 @@ func (ep *eventPoller) waitForShoulderTapOrPollTimeout(lastEventCount int) bool
 	select {
 	case <-longTimeout.C:
 		l.Debugf("Woken after poll timeout")
+		ep.adaptPollTimeout(lastEventCount)
 	case <-ep.shoulderTaps:
 		l.Debug("Woken for trigger on event")
 	case <-ep.ctx.Done():
@@ -285,3 +317,16 @@ func (ep *eventPoller) waitForShoulderTapOrPollTimeout(lastEventCount int) bool
 	}
 	return true
 }
+
+// adaptPollTimeout lengthens the poll timeout in adaptive mode, when a poll timeout expired without any events
+func (ep *eventPoller) adaptPollTimeout(lastEventCount int) {
+	ap := &ep.conf.adaptivePoll
+	if !ap.enabled || lastEventCount > 0 {
+		return
+	}
+	ep.pollTimeout = time.Duration(float64(ep.pollTimeout) * ap.factor)
+	if ep.pollTimeout > ap.maxTimeout {
+		ep.pollTimeout = ap.maxTimeout
+	}
+	log.L(ep.ctx).Debugf("Poll timeout extended to %s", ep.pollTimeout)
+}
@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/pkg/database"
@@ -45,6 +46,7 @@ func newTestEventPoller(t *testing.T, mdi *databasemocks.Plugin, neh newEventsHa
 		newEventsHandler: neh,
 		offsetType:       fftypes.OffsetTypeSubscription,
 		namespace:        "unit",
+		eventBus:         eventbus.NewBus(),
 		offsetName:       "test",
 		queryFactory:     database.EventQueryFactory,
 		getItems: func(c context.Context, f database.Filter) ([]fftypes.LocallySequenced, error) {
@@ -311,3 +313,60 @@ func TestDoubleTap(t *testing.T) {
 	ep.shoulderTap()
 	ep.shoulderTap() // this should not block
 }
+
+func TestWaitForShoulderTapOrPollTimeoutAdaptive(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestEventPoller(t, mdi, nil, nil)
+	defer cancel()
+	ep.conf.eventPollTimeout = 1 * time.Microsecond
+	ep.conf.adaptivePoll = adaptivePollConf{
+		enabled:    true,
+		factor:     10.0,
+		maxTimeout: 50 * time.Microsecond,
+	}
+	ep.pollTimeout = ep.conf.eventPollTimeout
+
+	// Lengthens when no events arrive, up to the maximum
+	assert.True(t, ep.waitForShoulderTapOrPollTimeout(0))
+	assert.Equal(t, 10*time.Microsecond, ep.pollTimeout)
+	assert.True(t, ep.waitForShoulderTapOrPollTimeout(0))
+	assert.Equal(t, 50*time.Microsecond, ep.pollTimeout)
+
+	// Resets as soon as events arrive
+	assert.True(t, ep.waitForShoulderTapOrPollTimeout(1))
+	assert.Equal(t, 1*time.Microsecond, ep.pollTimeout)
+}
+
+func TestWaitForShoulderTapOrPollTimeoutNotAdaptive(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestEventPoller(t, mdi, nil, nil)
+	defer cancel()
+	ep.conf.eventPollTimeout = 1 * time.Microsecond
+	ep.pollTimeout = ep.conf.eventPollTimeout
+
+	assert.True(t, ep.waitForShoulderTapOrPollTimeout(0))
+	assert.Equal(t, 1*time.Microsecond, ep.pollTimeout)
+}
+
+func TestCommitOffsetPublishes(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestEventPoller(t, mdi, nil, nil)
+	defer cancel()
+	ep.offsetID = 11111
+	mdi.On("UpdateOffset", mock.Anything, int64(11111), mock.Anything).Return(nil)
+
+	var committed *fftypes.Offset
+	ep.conf.eventBus.Subscribe(eventbus.TopicOffsetCommitted, func(payload interface{}) {
+		committed = payload.(*fftypes.Offset)
+	})
+
+	err := ep.commitOffset(ep.ctx, 12345)
+	assert.NoError(t, err)
+	assert.Equal(t, &fftypes.Offset{
+		Type:    fftypes.OffsetTypeSubscription,
+		Name:    "test",
+		Current: 12345,
+		RowID:   11111,
+	}, committed)
+	mdi.AssertExpectations(t)
+}
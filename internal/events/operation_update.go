@@ -19,20 +19,31 @@ package events
 import (
 	"context"
 
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
-func (em *eventManager) operationUpdateCtx(ctx context.Context, operationID *fftypes.UUID, txState fftypes.OpStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject) error {
+func (em *eventManager) operationUpdateCtx(ctx context.Context, operationID *fftypes.UUID, txState fftypes.OpStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject, receipt *fftypes.TransactionReceipt) (*fftypes.Operation, error) {
 	op, err := em.database.GetOperationByID(ctx, operationID)
 	if err != nil || op == nil {
 		log.L(em.ctx).Warnf("Operation update '%s' ignored, as it was not submitted by this node", operationID)
-		return nil
+		return nil, nil
 	}
 
 	if err := em.database.ResolveOperation(ctx, op.ID, txState, errorMessage, opOutput); err != nil {
-		return err
+		return nil, err
+	}
+
+	// Blockchain plugins report the receipt of the transaction, so the outcome (including any revert reason)
+	// can be queried on the operation without needing to go to the connector
+	if receipt != nil {
+		update := database.OperationQueryFactory.NewUpdate(ctx).Set("receipt", receipt)
+		if err := em.database.UpdateOperation(ctx, op.ID, update); err != nil {
+			return nil, err
+		}
 	}
 
 	// Special handling for OpTypeTokenTransfer, which writes an event when it fails
@@ -47,7 +58,10 @@ func (em *eventManager) operationUpdateCtx(ctx context.Context, operationID *fft
 			em.metrics.TransferConfirmed(&tokenTransfer)
 		}
 		if err := em.database.InsertEvent(ctx, event); err != nil {
-			return err
+			return nil, err
+		}
+		if err := em.rejectTransferMessage(ctx, op); err != nil {
+			return nil, err
 		}
 	}
 
@@ -55,15 +69,63 @@ func (em *eventManager) operationUpdateCtx(ctx context.Context, operationID *fft
 	if op.Type == fftypes.OpTypeTokenApproval && txState == fftypes.OpStatusFailed {
 		event := fftypes.NewEvent(fftypes.EventTypeApprovalOpFailed, op.Namespace, op.ID, op.Transaction)
 		if err := em.database.InsertEvent(ctx, event); err != nil {
-			return err
+			return nil, err
+		}
+	}
+
+	// Special handling for OpTypeBlockchainInvoke, which writes an event when it succeeds or fails
+	if op.Type == fftypes.OpTypeBlockchainInvoke && txState != fftypes.OpStatusPending {
+		eventType := fftypes.EventTypeBlockchainInvokeOpSucceeded
+		if txState == fftypes.OpStatusFailed {
+			eventType = fftypes.EventTypeBlockchainInvokeOpFailed
+		}
+		event := fftypes.NewEvent(eventType, op.Namespace, op.ID, op.Transaction)
+		if err := em.database.InsertEvent(ctx, event); err != nil {
+			return nil, err
 		}
 	}
 
-	return em.txHelper.AddBlockchainTX(ctx, op.Transaction, blockchainTXID)
+	return op, em.txHelper.AddBlockchainTX(ctx, op.Transaction, blockchainTXID)
 }
 
-func (em *eventManager) OperationUpdate(plugin fftypes.Named, operationID *fftypes.UUID, txState fftypes.OpStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject) error {
-	return em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
-		return em.operationUpdateCtx(ctx, operationID, txState, blockchainTXID, errorMessage, opOutput)
+func (em *eventManager) OperationUpdate(plugin fftypes.Named, operationID *fftypes.UUID, txState fftypes.OpStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject, receipt *fftypes.TransactionReceipt) error {
+	var op *fftypes.Operation
+	err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) (err error) {
+		op, err = em.operationUpdateCtx(ctx, operationID, txState, blockchainTXID, errorMessage, opOutput, receipt)
+		return err
 	})
+	if err == nil && op != nil {
+		// Only notify once the update is committed
+		em.eventBus.Publish(eventbus.TopicOperationUpdated, &eventbus.OperationUpdate{
+			ID:        op.ID,
+			Namespace: op.Namespace,
+			Type:      op.Type,
+			Status:    txState,
+		})
+	}
+	return err
+}
+
+// rejectTransferMessage rejects any message attached to a failed transfer. The message is held back
+// in staged state until its transfer is confirmed, so it cannot be sent unless the transfer is retried.
+// A retry stages the message again (see txcommon PrepareOperationRetry).
+func (em *eventManager) rejectTransferMessage(ctx context.Context, op *fftypes.Operation) error {
+	msgID, err := fftypes.ParseUUID(ctx, op.Input.GetString("message"))
+	if err != nil {
+		return nil // no message attached to the transfer
+	}
+	msg, err := em.database.GetMessageByID(ctx, msgID)
+	if err != nil {
+		return err
+	}
+	if msg == nil || msg.State != fftypes.MessageStateStaged {
+		return nil
+	}
+	log.L(ctx).Infof("Rejecting message '%s' attached to failed token transfer operation '%s'", msgID, op.ID)
+	update := database.MessageQueryFactory.NewUpdate(ctx).Set("state", fftypes.MessageStateRejected)
+	if err := em.database.UpdateMessage(ctx, msgID, update); err != nil {
+		return err
+	}
+	event := fftypes.NewEvent(fftypes.EventTypeMessageRejected, msg.Header.Namespace, msgID, op.Transaction)
+	return em.database.InsertEvent(ctx, event)
 }
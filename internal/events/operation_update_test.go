@@ -21,9 +21,11 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -42,12 +44,26 @@ func TestOperationUpdateSuccess(t *testing.T) {
 	mdi.On("RunAsGroup", em.ctx, mock.Anything).Run(func(args mock.Arguments) {
 		args[1].(func(ctx context.Context) error)(em.ctx)
 	}).Return(nil)
-	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Transaction: txid}, nil)
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Type: fftypes.OpTypeBlockchainInvoke, Transaction: txid}, nil)
 	mdi.On("ResolveOperation", mock.Anything, opID, fftypes.OpStatusFailed, "some error", info).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeBlockchainInvokeOpFailed && e.Reference.Equals(opID) && e.Transaction.Equals(txid)
+	})).Return(nil)
 	mth.On("AddBlockchainTX", mock.Anything, txid, "0x12345").Return(nil)
 
-	err := em.OperationUpdate(mdi, opID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	var published *eventbus.OperationUpdate
+	em.eventBus.Subscribe(eventbus.TopicOperationUpdated, func(payload interface{}) {
+		published = payload.(*eventbus.OperationUpdate)
+	})
+
+	err := em.OperationUpdate(mdi, opID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.NoError(t, err)
+	assert.Equal(t, &eventbus.OperationUpdate{
+		ID:        opID,
+		Namespace: "ns1",
+		Type:      fftypes.OpTypeBlockchainInvoke,
+		Status:    fftypes.OpStatusFailed,
+	}, published)
 
 	mdi.AssertExpectations(t)
 	mbi.AssertExpectations(t)
@@ -63,7 +79,7 @@ func TestOperationUpdateNotFound(t *testing.T) {
 	mdi.On("GetOperationByID", em.ctx, opID).Return(nil, fmt.Errorf("pop"))
 
 	info := fftypes.JSONObject{"some": "info"}
-	err := em.operationUpdateCtx(em.ctx, opID, fftypes.OpStatusFailed, "", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, opID, fftypes.OpStatusFailed, "", "some error", info, nil)
 	assert.NoError(t, err) // swallowed after logging
 
 	mdi.AssertExpectations(t)
@@ -82,7 +98,7 @@ func TestOperationUpdateError(t *testing.T) {
 	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Transaction: txid}, nil)
 	mdi.On("ResolveOperation", mock.Anything, opID, fftypes.OpStatusFailed, "some error", info).Return(fmt.Errorf("pop"))
 
-	err := em.operationUpdateCtx(em.ctx, opID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, opID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.EqualError(t, err, "pop")
 
 	mdi.AssertExpectations(t)
@@ -103,7 +119,7 @@ func TestOperationTXUpdateError(t *testing.T) {
 	mdi.On("ResolveOperation", mock.Anything, opID, fftypes.OpStatusFailed, "some error", info).Return(nil)
 	mth.On("AddBlockchainTX", mock.Anything, txid, "0x12345").Return(fmt.Errorf("pop"))
 
-	err := em.operationUpdateCtx(em.ctx, opID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, opID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.EqualError(t, err, "pop")
 
 	mdi.AssertExpectations(t)
@@ -132,7 +148,7 @@ func TestOperationUpdateTransferFail(t *testing.T) {
 	})).Return(nil)
 	mth.On("AddBlockchainTX", mock.Anything, op.Transaction, "0x12345").Return(nil)
 
-	err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.NoError(t, err)
 
 	mdi.AssertExpectations(t)
@@ -159,7 +175,7 @@ func TestOperationUpdateTransferTransactionFail(t *testing.T) {
 	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil)
 	mth.On("AddBlockchainTX", mock.Anything, op.Transaction, "0x12345").Return(fmt.Errorf("pop"))
 
-	err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.EqualError(t, err, "pop")
 
 	mdi.AssertExpectations(t)
@@ -185,13 +201,138 @@ func TestOperationUpdateTransferEventFail(t *testing.T) {
 		return e.Type == fftypes.EventTypeTransferOpFailed && e.Namespace == "ns1"
 	})).Return(fmt.Errorf("pop"))
 
-	err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.EqualError(t, err, "pop")
 
 	mdi.AssertExpectations(t)
 	mbi.AssertExpectations(t)
 }
 
+func TestOperationUpdateTransferFailRejectMessage(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+
+	msgID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Type:        fftypes.OpTypeTokenTransfer,
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+		Input:       fftypes.JSONObject{"message": msgID.String()},
+	}
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID, Namespace: "ns1"},
+		State:  fftypes.MessageStateStaged,
+	}
+	info := fftypes.JSONObject{"some": "info"}
+
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("ResolveOperation", mock.Anything, op.ID, fftypes.OpStatusFailed, "some error", info).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTransferOpFailed
+	})).Return(nil)
+	mdi.On("GetMessageByID", em.ctx, msgID).Return(msg, nil)
+	mdi.On("UpdateMessage", em.ctx, msgID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeMessageRejected && e.Reference.Equals(msgID)
+	})).Return(nil)
+	mth.On("AddBlockchainTX", mock.Anything, op.Transaction, "0x12345").Return(nil)
+
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateTransferFailMessageAlreadyConfirmed(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+
+	msgID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Type:        fftypes.OpTypeTokenTransfer,
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+		Input:       fftypes.JSONObject{"message": msgID.String()},
+	}
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID, Namespace: "ns1"},
+		State:  fftypes.MessageStateConfirmed,
+	}
+	info := fftypes.JSONObject{"some": "info"}
+
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("ResolveOperation", mock.Anything, op.ID, fftypes.OpStatusFailed, "some error", info).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil).Once()
+	mdi.On("GetMessageByID", em.ctx, msgID).Return(msg, nil)
+	mth.On("AddBlockchainTX", mock.Anything, op.Transaction, "0x12345").Return(nil)
+
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateTransferFailGetMessageFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	msgID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:        fftypes.NewUUID(),
+		Type:      fftypes.OpTypeTokenTransfer,
+		Namespace: "ns1",
+		Input:     fftypes.JSONObject{"message": msgID.String()},
+	}
+	info := fftypes.JSONObject{"some": "info"}
+
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("ResolveOperation", mock.Anything, op.ID, fftypes.OpStatusFailed, "some error", info).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil)
+	mdi.On("GetMessageByID", em.ctx, msgID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateTransferFailUpdateMessageFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	msgID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:        fftypes.NewUUID(),
+		Type:      fftypes.OpTypeTokenTransfer,
+		Namespace: "ns1",
+		Input:     fftypes.JSONObject{"message": msgID.String()},
+	}
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID, Namespace: "ns1"},
+		State:  fftypes.MessageStateStaged,
+	}
+	info := fftypes.JSONObject{"some": "info"}
+
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("ResolveOperation", mock.Anything, op.ID, fftypes.OpStatusFailed, "some error", info).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil)
+	mdi.On("GetMessageByID", em.ctx, msgID).Return(msg, nil)
+	mdi.On("UpdateMessage", em.ctx, msgID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
 func TestOperationUpdateApprovalFail(t *testing.T) {
 	em, cancel := newTestEventManagerWithMetrics(t)
 	defer cancel()
@@ -214,7 +355,7 @@ func TestOperationUpdateApprovalFail(t *testing.T) {
 	})).Return(nil)
 	mth.On("AddBlockchainTX", mock.Anything, op.Transaction, "0x12345").Return(nil)
 
-	err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.NoError(t, err)
 
 	mdi.AssertExpectations(t)
@@ -241,7 +382,7 @@ func TestOperationUpdateApprovalTransactionFail(t *testing.T) {
 	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil)
 	mth.On("AddBlockchainTX", mock.Anything, op.Transaction, "0x12345").Return(fmt.Errorf("pop"))
 
-	err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.EqualError(t, err, "pop")
 
 	mdi.AssertExpectations(t)
@@ -267,9 +408,124 @@ func TestOperationUpdateApprovalEventFail(t *testing.T) {
 		return e.Type == fftypes.EventTypeApprovalOpFailed && e.Namespace == "ns1"
 	})).Return(fmt.Errorf("pop"))
 
-	err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info)
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusFailed, "0x12345", "some error", info, nil)
 	assert.EqualError(t, err, "pop")
 
 	mdi.AssertExpectations(t)
 	mbi.AssertExpectations(t)
 }
+
+func TestOperationUpdateWithReceipt(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+
+	opID := fftypes.NewUUID()
+	txid := fftypes.NewUUID()
+	info := fftypes.JSONObject{"some": "info"}
+	receipt := &fftypes.TransactionReceipt{BlockNumber: fftypes.NewFFBigInt(12345), RevertReason: "some error"}
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Transaction: txid}, nil)
+	mdi.On("ResolveOperation", mock.Anything, opID, fftypes.OpStatusFailed, "some error", info).Return(nil)
+	mdi.On("UpdateOperation", mock.Anything, opID, mock.MatchedBy(func(update database.Update) bool {
+		info, _ := update.Finalize()
+		return len(info.SetOperations) == 1 && info.SetOperations[0].Field == "receipt"
+	})).Return(nil)
+	mth.On("AddBlockchainTX", mock.Anything, txid, "0x12345").Return(nil)
+
+	_, err := em.operationUpdateCtx(em.ctx, opID, fftypes.OpStatusFailed, "0x12345", "some error", info, receipt)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mth.AssertExpectations(t)
+}
+
+func TestOperationUpdateWithReceiptFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	opID := fftypes.NewUUID()
+	info := fftypes.JSONObject{"some": "info"}
+	receipt := &fftypes.TransactionReceipt{BlockNumber: fftypes.NewFFBigInt(12345)}
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID}, nil)
+	mdi.On("ResolveOperation", mock.Anything, opID, fftypes.OpStatusSucceeded, "", info).Return(nil)
+	mdi.On("UpdateOperation", mock.Anything, opID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.operationUpdateCtx(em.ctx, opID, fftypes.OpStatusSucceeded, "0x12345", "", info, receipt)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateInvokeSucceeded(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+
+	op := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Type:        fftypes.OpTypeBlockchainInvoke,
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+	}
+
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("ResolveOperation", mock.Anything, op.ID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject(nil)).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeBlockchainInvokeOpSucceeded && e.Namespace == "ns1" && e.Reference.Equals(op.ID)
+	})).Return(nil)
+	mth.On("AddBlockchainTX", mock.Anything, op.Transaction, "0x12345").Return(nil)
+
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusSucceeded, "0x12345", "", nil, nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mth.AssertExpectations(t)
+}
+
+func TestOperationUpdateInvokePendingNoEvent(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mth := em.txHelper.(*txcommonmocks.Helper)
+
+	op := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Type:        fftypes.OpTypeBlockchainInvoke,
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+	}
+
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("ResolveOperation", mock.Anything, op.ID, fftypes.OpStatusPending, "", fftypes.JSONObject(nil)).Return(nil)
+	mth.On("AddBlockchainTX", mock.Anything, op.Transaction, "0x12345").Return(nil)
+
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusPending, "0x12345", "", nil, nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "InsertEvent", mock.Anything, mock.Anything)
+}
+
+func TestOperationUpdateInvokeEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	op := &fftypes.Operation{
+		ID:        fftypes.NewUUID(),
+		Type:      fftypes.OpTypeBlockchainInvoke,
+		Namespace: "ns1",
+	}
+
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("ResolveOperation", mock.Anything, op.ID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject(nil)).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.operationUpdateCtx(em.ctx, op.ID, fftypes.OpStatusSucceeded, "0x12345", "", nil, nil)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
@@ -19,21 +19,24 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/nodekey"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
-func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX context*/, batch *fftypes.Batch, onchainHash *fftypes.Bytes32, signingKey string) (valid bool, err error) {
+func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX context*/, batch *fftypes.Batch, onchainHash *fftypes.Bytes32, signingKey string, pinned *fftypes.FFTime) (valid bool, reason string, err error) {
 	l := log.L(ctx)
 
 	// Verify that we can resolve the signing key back to this identity.
 	// This is a specific rule for broadcasts, so we know the authenticity of the data.
-	resolvedAuthor, err := em.identity.ResolveSigningKeyIdentity(ctx, signingKey)
+	// For authors identified by an external DID, this checks the key is a verification method in the DID document.
+	// A rotated key is checked against the time the batch was pinned on the blockchain, so all nodes agree.
+	resolvedAuthor, err := em.identity.ResolveSigningKeyAuthor(ctx, batch.Author, signingKey, pinned)
 	if err != nil {
-		l.Errorf("Invalid batch '%s'. Author '%s' cound not be resolved: %s", batch.ID, batch.Author, err)
-		return false, nil // This is not retryable. skip this batch
+		return em.invalidBatch(ctx, batch, "Author '%s' could not be resolved: %s", batch.Author, err) // This is not retryable. skip this batch
 	}
 
 	// The special case of a root org broadcast is allowed to not have a resolved author, because it's not in the database yet
@@ -46,19 +49,45 @@ func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX c
 
 		} else {
 
-			l.Errorf("Invalid batch '%s'. Key/author in batch '%s' / '%s' does not match resolved key/author '%s' / '%s'", batch.ID, batch.Key, batch.Author, signingKey, resolvedAuthor)
-			return false, nil // This is not retryable. skip this batch
+			return em.invalidBatch(ctx, batch, "Key/author in batch '%s' / '%s' does not match resolved key/author '%s' / '%s'", batch.Key, batch.Author, signingKey, resolvedAuthor) // This is not retryable. skip this batch
 
 		}
 	}
 
 	if !onchainHash.Equals(batch.Hash) {
-		l.Errorf("Invalid batch '%s'. Hash in batch '%s' does not match transaction hash '%s'", batch.ID, batch.Hash, onchainHash)
-		return false, nil // This is not retryable. skip this batch
+		return em.invalidBatch(ctx, batch, "Hash in batch '%s' does not match transaction hash '%s'", batch.Hash, onchainHash) // This is not retryable. skip this batch
 	}
 
-	valid, err = em.persistBatch(ctx, batch)
-	return valid, err
+	return em.persistBatch(ctx, batch)
+}
+
+// verifyIdentityRegistered checks the key that signed the batch (and hence every message in it) is registered in the
+// on-chain identity registry of the namespace's blockchain, as of the block in the info of the blockchain event that
+// pinned the batch - or the latest block if the batch is not yet pinned.
+// This queries the blockchain, so must be called outside of any database transaction.
+func (em *eventManager) verifyIdentityRegistered(ctx context.Context, batch *fftypes.Batch, pinInfo fftypes.JSONObject) (valid bool, reason string, err error) {
+	if !em.verifyIdentityReg {
+		return true, "", nil
+	}
+	var blockNumber *fftypes.FFBigInt
+	if _, ok := pinInfo.GetStringOk("blockNumber"); ok {
+		blockNumber = (*fftypes.FFBigInt)(pinInfo.GetInteger("blockNumber"))
+	}
+	registered, err := em.blockchainFor(batch.Namespace).VerifyIdentityRegistered(ctx, batch.Key, blockNumber)
+	if err != nil {
+		return false, "", err // a failure to query the registry is considered retryable (so returned)
+	}
+	if !registered {
+		return em.invalidBatch(ctx, batch, "Signing key '%s' is not registered in the on-chain identity registry", batch.Key) // This is not retryable. skip this batch
+	}
+	return true, "", nil
+}
+
+// invalidBatch logs the reason a batch cannot be processed, and returns it for recording alongside the batch
+func (em *eventManager) invalidBatch(ctx context.Context, batch *fftypes.Batch, format string, args ...interface{}) (valid bool, reason string, err error) {
+	reason = fmt.Sprintf(format, args...)
+	log.L(ctx).Errorf("Invalid batch '%s'. %s", batch.ID, reason)
+	return false, reason, nil
 }
 
 func (em *eventManager) isRootOrgBroadcast(batch *fftypes.Batch) bool {
@@ -91,28 +120,52 @@ func (em *eventManager) isRootOrgBroadcast(batch *fftypes.Batch) bool {
 
 // persistBatch performs very simple validation on each message/data element (hashes) and either persists
 // or discards them. Errors are returned only in the case of database failures, which should be retried.
-func (em *eventManager) persistBatch(ctx context.Context /* db TX context*/, batch *fftypes.Batch) (valid bool, err error) {
+// When the batch is discarded, the reason is returned so it can be recorded.
+func (em *eventManager) persistBatch(ctx context.Context /* db TX context*/, batch *fftypes.Batch) (valid bool, reason string, err error) {
 	l := log.L(ctx)
 	now := fftypes.Now()
 
 	if batch.ID == nil || batch.Payload.TX.ID == nil {
-		l.Errorf("Invalid batch '%s'. Missing ID or transaction ID (%v)", batch.ID, batch.Payload.TX.ID)
-		return false, nil // This is not retryable. skip this batch
+		return em.invalidBatch(ctx, batch, "Missing ID or transaction ID (%v)", batch.Payload.TX.ID) // This is not retryable. skip this batch
 	}
 
 	switch batch.Payload.TX.Type {
 	case fftypes.TransactionTypeBatchPin:
 	case fftypes.TransactionTypeUnpinned:
 	default:
-		l.Errorf("Invalid batch '%s'. Invalid transaction type: %s", batch.ID, batch.Payload.TX.Type)
-		return false, nil // This is not retryable. skip this batch
+		return em.invalidBatch(ctx, batch, "Invalid transaction type: %s", batch.Payload.TX.Type) // This is not retryable. skip this batch
 	}
 
 	// Verify the hash calculation
 	hash := batch.Payload.Hash()
 	if batch.Hash == nil || *batch.Hash != *hash {
-		l.Errorf("Invalid batch '%s'. Hash does not match payload. Found=%s Expected=%s", batch.ID, hash, batch.Hash)
-		return false, nil // This is not retryable. skip this batch
+		return em.invalidBatch(ctx, batch, "Hash does not match payload. Found=%s Expected=%s", hash, batch.Hash) // This is not retryable. skip this batch
+	}
+
+	// Verify the batch was sealed by the node it claims
+	valid, reason, err = em.verifyNodeSignature(ctx, batch)
+	if err != nil {
+		return false, "", err // a database failure here is considered retryable (so returned)
+	}
+	if !valid {
+		return em.invalidBatch(ctx, batch, "%s", reason) // This is not retryable. skip this batch
+	}
+
+	// Verify the batch conforms to the cryptographic policy of its namespace
+	if err := em.identity.VerifyCryptoPolicy(ctx, batch.Namespace, &batch.Identity); err != nil {
+		return em.invalidBatch(ctx, batch, "%s", err) // This is not retryable. skip this batch
+	}
+
+	// Run any configured validators, before we persist anything from the batch
+	for _, bv := range em.batchValidators {
+		valid, reason, err = bv.ValidateBatch(ctx, batch)
+		if err != nil {
+			l.Errorf("Batch validator '%s' failed for batch '%s': %s", bv.Name(), batch.ID, err)
+			return false, "", err // the validator has asked us to retry
+		}
+		if !valid {
+			return em.invalidBatch(ctx, batch, "Rejected by batch validator '%s': %s", bv.Name(), reason) // This is not retryable. skip this batch
+		}
 	}
 
 	// Set confirmed on the batch (the messages should not be confirmed at this point - that's the aggregator's job)
@@ -122,11 +175,10 @@ func (em *eventManager) persistBatch(ctx context.Context /* db TX context*/, bat
 	err = em.database.UpsertBatch(ctx, batch)
 	if err != nil {
 		if err == database.HashMismatch {
-			l.Errorf("Invalid batch '%s'. Batch hash mismatch with existing record", batch.ID)
-			return false, nil // This is not retryable. skip this batch
+			return em.invalidBatch(ctx, batch, "Batch hash mismatch with existing record") // This is not retryable. skip this batch
 		}
 		l.Errorf("Failed to insert batch '%s': %s", batch.ID, err)
-		return false, err // a persistence failure here is considered retryable (so returned)
+		return false, "", err // a persistence failure here is considered retryable (so returned)
 	}
 
 	optimization := em.getOptimization(ctx, batch)
@@ -134,18 +186,67 @@ func (em *eventManager) persistBatch(ctx context.Context /* db TX context*/, bat
 	// Insert the data entries
 	for i, data := range batch.Payload.Data {
 		if err = em.persistBatchData(ctx, batch, i, data, optimization); err != nil {
-			return false, err
+			return false, "", err
 		}
 	}
 
 	// Insert the message entries
 	for i, msg := range batch.Payload.Messages {
-		if valid, err = em.persistBatchMessage(ctx, batch, i, msg, optimization); !valid || err != nil {
-			return valid, err
+		if valid, err = em.persistBatchMessage(ctx, batch, i, msg, optimization); err != nil {
+			return false, "", err
+		}
+		if !valid {
+			return em.invalidBatch(ctx, batch, "Invalid message entry %d", i)
 		}
 	}
 
-	return true, nil
+	return true, "", nil
+}
+
+// verifyNodeSignature checks the signature in the payload against the public key registered for the node that
+// sealed the batch. This detects a batch that claims to come from a registered node, but was sealed elsewhere -
+// for example using a compromised author key from a node that has not been registered.
+func (em *eventManager) verifyNodeSignature(ctx context.Context, batch *fftypes.Batch) (valid bool, reason string, err error) {
+	var node *fftypes.Node
+	if batch.Node != nil {
+		if node, err = em.database.GetNodeByID(ctx, batch.Node); err != nil {
+			return false, "", err
+		}
+	}
+	switch {
+	case node != nil && node.PublicKey != "":
+		if !nodekey.Verify(node.PublicKey, batch.Payload.NodeSignature, batch.Hash) {
+			return false, fmt.Sprintf("Node signature does not match the registered public key of node '%s'", batch.Node), nil
+		}
+	case !em.requireNodeSignature:
+		// Nodes that have not registered a public key are accepted, unless signatures are required
+	case isRegistrationBatch(batch):
+		// A node that has not completed registration must still be able to broadcast its own definitions
+	case node == nil:
+		return false, fmt.Sprintf("Node '%s' is not registered", batch.Node), nil
+	default:
+		return false, fmt.Sprintf("Node '%s' has no registered public key", batch.Node), nil
+	}
+	return true, "", nil
+}
+
+// isRegistrationBatch returns true for an unsigned batch that only contains the organization and node definitions
+// a node broadcasts to register itself, before it has a registered public key to sign with
+func isRegistrationBatch(batch *fftypes.Batch) bool {
+	if batch.Node != nil || batch.Namespace != fftypes.SystemNamespace || len(batch.Payload.Messages) == 0 {
+		return false
+	}
+	for _, msg := range batch.Payload.Messages {
+		if msg.Header.Type != fftypes.MessageTypeDefinition {
+			return false
+		}
+		switch fftypes.SystemTag(msg.Header.Tag) {
+		case fftypes.SystemTagDefineOrganization, fftypes.SystemTagDefineNode:
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 func (em *eventManager) getOptimization(ctx context.Context, batch *fftypes.Batch) database.UpsertOptimization {
@@ -176,14 +277,34 @@ func (em *eventManager) persistReceivedData(ctx context.Context /* db TX context
 		return false, nil // skip data entry
 	}
 
-	hash, err := data.CalcHash(ctx)
-	if err != nil {
-		log.L(ctx).Errorf("Invalid data entry %d in %s '%s': %s", i, mType, mID, err)
-		return false, nil //
-	}
-	if data.Hash == nil || *data.Hash != *hash {
-		log.L(ctx).Errorf("Invalid data entry %d in %s '%s': Hash=%v Expected=%v", i, mType, mID, data.Hash, hash)
-		return false, nil // skip data entry
+	if data.ValueWithheld() {
+		// The value of data restricted to a private group is not in the batch, so we cannot verify the hash here.
+		// That happens when the value is received from a member of the group, which might already have happened.
+		if data.Hash == nil {
+			log.L(ctx).Errorf("Invalid data entry %d in %s '%s': Missing hash for withheld value", i, mType, mID)
+			return false, nil // skip data entry
+		}
+		existing, err := em.database.GetDataByID(ctx, data.ID, false)
+		if err != nil {
+			return false, err
+		}
+		if existing != nil {
+			if existing.Hash == nil || *existing.Hash != *data.Hash {
+				log.L(ctx).Errorf("Invalid data entry %d in %s '%s'. Hash mismatch with existing record with same UUID '%s' Hash=%s", i, mType, mID, data.ID, data.Hash)
+				return false, nil // skip data entry
+			}
+			return true, nil // do not overwrite a value we already hold
+		}
+	} else {
+		hash, err := data.CalcHash(ctx)
+		if err != nil {
+			log.L(ctx).Errorf("Invalid data entry %d in %s '%s': %s", i, mType, mID, err)
+			return false, nil //
+		}
+		if data.Hash == nil || *data.Hash != *hash {
+			log.L(ctx).Errorf("Invalid data entry %d in %s '%s': Hash=%v Expected=%v", i, mType, mID, data.Hash, hash)
+			return false, nil // skip data entry
+		}
 	}
 
 	// Insert the data, ensuring the hash doesn't change
@@ -226,6 +347,7 @@ func (em *eventManager) persistReceivedMessage(ctx context.Context /* db TX cont
 	// Insert the message, ensuring the hash doesn't change.
 	// We do not mark it as confirmed at this point, that's the job of the aggregator.
 	msg.State = fftypes.MessageStatePending
+	msg.IdempotencyKey = "" // only meaningful to the submitting node, so never stored from a remote node
 	if err = em.database.UpsertMessage(ctx, msg, optimization); err != nil {
 		if err == database.HashMismatch {
 			l.Errorf("Invalid message entry %d in %s '%s'. Hash mismatch with existing record with same UUID '%s' Hash=%s", i, mType, mID, msg.Header.ID, msg.Hash)
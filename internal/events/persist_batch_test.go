@@ -17,12 +17,19 @@
 package events
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/nodekey"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -34,7 +41,7 @@ func TestPersistBatchFromBroadcastRootOrg(t *testing.T) {
 	defer cancel()
 
 	mim := em.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+	mim.On("ResolveSigningKeyAuthor", em.ctx, mock.Anything, mock.Anything, mock.Anything).Return("", nil)
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("UpsertBatch", em.ctx, mock.Anything).Return(fmt.Errorf(("pop")))
@@ -88,7 +95,7 @@ func TestPersistBatchFromBroadcastRootOrg(t *testing.T) {
 	}
 	batch.Hash = batch.Payload.Hash()
 
-	_, err = em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345")
+	_, _, err = em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", nil)
 	assert.EqualError(t, err, "pop") // Confirms we got to upserting the batch
 
 }
@@ -99,7 +106,7 @@ func TestPersistBatchFromBroadcastRootOrgBadData(t *testing.T) {
 	defer cancel()
 
 	mim := em.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+	mim.On("ResolveSigningKeyAuthor", em.ctx, mock.Anything, mock.Anything, mock.Anything).Return("", nil)
 
 	data := &fftypes.Data{
 		ID:        fftypes.NewUUID(),
@@ -141,7 +148,7 @@ func TestPersistBatchFromBroadcastRootOrgBadData(t *testing.T) {
 	}
 	batch.Hash = batch.Payload.Hash()
 
-	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345")
+	valid, _, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", nil)
 	assert.NoError(t, err)
 	assert.False(t, valid)
 
@@ -153,7 +160,7 @@ func TestPersistBatchFromBroadcastNoRootOrgBadIdentity(t *testing.T) {
 	defer cancel()
 
 	mim := em.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+	mim.On("ResolveSigningKeyAuthor", em.ctx, mock.Anything, mock.Anything, mock.Anything).Return("", nil)
 
 	batch := &fftypes.Batch{
 		ID: fftypes.NewUUID(),
@@ -163,8 +170,351 @@ func TestPersistBatchFromBroadcastNoRootOrgBadIdentity(t *testing.T) {
 	}
 	batch.Hash = batch.Payload.Hash()
 
-	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345")
+	valid, _, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", nil)
 	assert.NoError(t, err)
 	assert.False(t, valid)
 
 }
+
+func newTestNodeKey(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	return key
+}
+
+func TestPersistBatchNodeSignatureOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	key := newTestNodeKey(t)
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	batch.Payload.NodeSignature = nodekey.Sign(key, batch.Hash)
+
+	mdi := &databasemocks.Plugin{}
+	em.database = mdi
+	mdi.On("GetNodeByID", mock.Anything, batch.Node).Return(&fftypes.Node{ID: batch.Node, PublicKey: nodekey.PublicKey(key)}, nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+
+	valid, _, err := em.persistBatch(context.Background(), batch)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestPersistBatchNodeSignatureMismatch(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	batch.Payload.NodeSignature = nodekey.Sign(newTestNodeKey(t), batch.Hash)
+
+	mdi := &databasemocks.Plugin{}
+	em.database = mdi
+	mdi.On("GetNodeByID", mock.Anything, batch.Node).Return(&fftypes.Node{ID: batch.Node, PublicKey: nodekey.PublicKey(newTestNodeKey(t))}, nil)
+
+	valid, reason, err := em.persistBatch(context.Background(), batch)
+	assert.False(t, valid)
+	assert.Regexp(t, "Node signature does not match", reason)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestPersistBatchNodeSignatureMissing(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mdi := &databasemocks.Plugin{}
+	em.database = mdi
+	mdi.On("GetNodeByID", mock.Anything, batch.Node).Return(&fftypes.Node{ID: batch.Node, PublicKey: nodekey.PublicKey(newTestNodeKey(t))}, nil)
+
+	valid, reason, err := em.persistBatch(context.Background(), batch)
+	assert.False(t, valid)
+	assert.Regexp(t, "Node signature does not match", reason)
+	assert.NoError(t, err)
+}
+
+func TestPersistBatchNodeLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mdi := &databasemocks.Plugin{}
+	em.database = mdi
+	mdi.On("GetNodeByID", mock.Anything, batch.Node).Return(nil, fmt.Errorf("pop"))
+
+	valid, _, err := em.persistBatch(context.Background(), batch)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPersistBatchCryptoPolicyRejected(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mim := &identitymanagermocks.Manager{}
+	em.identity = mim
+	mim.On("VerifyCryptoPolicy", mock.Anything, batch.Namespace, &batch.Identity).Return(fmt.Errorf("pop"))
+
+	valid, reason, err := em.persistBatch(context.Background(), batch)
+	assert.False(t, valid)
+	assert.Equal(t, "pop", reason)
+	assert.NoError(t, err)
+	mim.AssertExpectations(t)
+}
+
+func TestVerifyIdentityRegisteredDisabled(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	valid, _, err := em.verifyIdentityRegistered(context.Background(), batch, nil)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestVerifyIdentityRegisteredAtPinBlock(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mbi := &blockchainmocks.Plugin{}
+	em.nsBlockchains = map[string]blockchain.Plugin{batch.Namespace: mbi}
+	mbi.On("VerifyIdentityRegistered", mock.Anything, "0x12345", fftypes.NewFFBigInt(12345)).Return(true, nil)
+
+	valid, _, err := em.verifyIdentityRegistered(context.Background(), batch, fftypes.JSONObject{"blockNumber": "12345"})
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	mbi.AssertExpectations(t)
+}
+
+func TestVerifyIdentityRegisteredLatestBlock(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", mock.Anything, "0x12345", (*fftypes.FFBigInt)(nil)).Return(true, nil)
+
+	valid, _, err := em.verifyIdentityRegistered(context.Background(), batch, nil)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	mbi.AssertExpectations(t)
+}
+
+func TestVerifyIdentityRegisteredNotRegistered(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", mock.Anything, "0x12345", mock.Anything).Return(false, nil)
+
+	valid, reason, err := em.verifyIdentityRegistered(context.Background(), batch, nil)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Regexp(t, "0x12345.*not registered", reason)
+	mbi.AssertExpectations(t)
+}
+
+func TestVerifyIdentityRegisteredFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", mock.Anything, "0x12345", mock.Anything).Return(false, fmt.Errorf("pop"))
+
+	valid, _, err := em.verifyIdentityRegistered(context.Background(), batch, nil)
+	assert.EqualError(t, err, "pop")
+	assert.False(t, valid)
+	mbi.AssertExpectations(t)
+}
+
+func TestVerifyNodeSignatureNoPublicKeyNotRequired(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mdi := &databasemocks.Plugin{}
+	em.database = mdi
+	mdi.On("GetNodeByID", mock.Anything, batch.Node).Return(&fftypes.Node{ID: batch.Node}, nil)
+
+	valid, _, err := em.verifyNodeSignature(context.Background(), batch)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestVerifyNodeSignatureRequiredNotRegistered(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.requireNodeSignature = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mdi := &databasemocks.Plugin{}
+	em.database = mdi
+	mdi.On("GetNodeByID", mock.Anything, batch.Node).Return(nil, nil)
+
+	valid, reason, err := em.verifyNodeSignature(context.Background(), batch)
+	assert.False(t, valid)
+	assert.Regexp(t, "is not registered", reason)
+	assert.NoError(t, err)
+}
+
+func TestVerifyNodeSignatureRequiredNoPublicKey(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.requireNodeSignature = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+
+	mdi := &databasemocks.Plugin{}
+	em.database = mdi
+	mdi.On("GetNodeByID", mock.Anything, batch.Node).Return(&fftypes.Node{ID: batch.Node}, nil)
+
+	valid, reason, err := em.verifyNodeSignature(context.Background(), batch)
+	assert.False(t, valid)
+	assert.Regexp(t, "has no registered public key", reason)
+	assert.NoError(t, err)
+}
+
+func TestVerifyNodeSignatureRequiredSystemBootstrap(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.requireNodeSignature = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	batch.Namespace = fftypes.SystemNamespace
+	batch.Node = nil
+	batch.Payload.Messages[0].Header.Type = fftypes.MessageTypeDefinition
+	batch.Payload.Messages[0].Header.Tag = string(fftypes.SystemTagDefineNode)
+
+	valid, _, err := em.verifyNodeSignature(context.Background(), batch)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestVerifyNodeSignatureRequiredSystemNotRegistration(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.requireNodeSignature = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	batch.Namespace = fftypes.SystemNamespace
+	batch.Node = nil
+	batch.Payload.Messages[0].Header.Type = fftypes.MessageTypeDefinition
+	batch.Payload.Messages[0].Header.Tag = string(fftypes.SystemTagRotateOrgKey)
+
+	valid, reason, err := em.verifyNodeSignature(context.Background(), batch)
+	assert.False(t, valid)
+	assert.Regexp(t, "is not registered", reason)
+	assert.NoError(t, err)
+}
+
+func TestVerifyNodeSignatureRequiredSystemNotDefinition(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.requireNodeSignature = true
+
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	batch.Namespace = fftypes.SystemNamespace
+	batch.Node = nil
+	batch.Payload.Messages[0].Header.Tag = string(fftypes.SystemTagDefineNode)
+
+	valid, reason, err := em.verifyNodeSignature(context.Background(), batch)
+	assert.False(t, valid)
+	assert.Regexp(t, "is not registered", reason)
+	assert.NoError(t, err)
+}
+
+func TestPersistReceivedDataWithheldValue(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Group: fftypes.NewRandB32()}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", em.ctx, data.ID, false).Return(nil, nil)
+	mdi.On("UpsertData", em.ctx, data, database.UpsertOptimizationNew).Return(nil)
+
+	valid, err := em.persistReceivedData(em.ctx, 0, data, "batch", fftypes.NewUUID(), database.UpsertOptimizationNew)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestPersistReceivedDataWithheldValueAlreadyReceived(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Group: fftypes.NewRandB32()}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", em.ctx, data.ID, false).Return(&fftypes.Data{ID: data.ID, Hash: data.Hash}, nil)
+
+	valid, err := em.persistReceivedData(em.ctx, 0, data, "batch", fftypes.NewUUID(), database.UpsertOptimizationNew)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestPersistReceivedDataWithheldValueHashMismatch(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Group: fftypes.NewRandB32()}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", em.ctx, data.ID, false).Return(&fftypes.Data{ID: data.ID, Hash: fftypes.NewRandB32()}, nil)
+
+	valid, err := em.persistReceivedData(em.ctx, 0, data, "batch", fftypes.NewUUID(), database.UpsertOptimizationNew)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestPersistReceivedDataWithheldValueLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Group: fftypes.NewRandB32()}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", em.ctx, data.ID, false).Return(nil, fmt.Errorf("pop"))
+
+	valid, err := em.persistReceivedData(em.ctx, 0, data, "batch", fftypes.NewUUID(), database.UpsertOptimizationNew)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestPersistReceivedDataWithheldValueNoHash(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Group: fftypes.NewRandB32()}
+
+	valid, err := em.persistReceivedData(em.ctx, 0, data, "batch", fftypes.NewUUID(), database.UpsertOptimizationNew)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
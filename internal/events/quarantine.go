@@ -0,0 +1,173 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (em *eventManager) quarantineBatch(ctx context.Context /* db TX context*/, batchPin *blockchain.BatchPin, signingKey string, payload []byte, reason string) error {
+	contexts := make(fftypes.FFStringArray, len(batchPin.Contexts))
+	for i, c := range batchPin.Contexts {
+		contexts[i] = c.String()
+	}
+	qb := &fftypes.QuarantinedBatch{
+		ID:         fftypes.NewUUID(),
+		Namespace:  batchPin.Namespace,
+		Batch:      batchPin.BatchID,
+		TX:         batchPin.TransactionID,
+		Hash:       batchPin.BatchHash,
+		PayloadRef: batchPin.BatchPayloadRef,
+		Key:        signingKey,
+		Contexts:   contexts,
+		Reason:     reason,
+		Payload:    string(payload),
+	}
+	log.L(ctx).Warnf("Quarantining batch '%s' from transaction '%s' as '%s': %s", batchPin.BatchID, batchPin.Event.ProtocolID, qb.ID, reason)
+	return em.database.InsertQuarantinedBatch(ctx, qb)
+}
+
+// quarantinePrivateBatch records a private batch received directly from a peer, which could not be processed.
+// The batch is stored as received, along with the peer, so the identity checks can be re-run later.
+func (em *eventManager) quarantinePrivateBatch(ctx context.Context /* db TX context*/, peerID string, batch *fftypes.Batch, reason string) error {
+	payload, _ := json.Marshal(batch)
+	qb := &fftypes.QuarantinedBatch{
+		ID:        fftypes.NewUUID(),
+		Namespace: batch.Namespace,
+		Batch:     batch.ID,
+		TX:        batch.Payload.TX.ID,
+		Hash:      batch.Hash,
+		Key:       batch.Key,
+		Peer:      peerID,
+		Reason:    reason,
+		Payload:   string(payload),
+	}
+	log.L(ctx).Warnf("Quarantining private batch '%s' from peer '%s' as '%s': %s", batch.ID, peerID, qb.ID, reason)
+	return em.database.InsertQuarantinedBatch(ctx, qb)
+}
+
+// ReprocessQuarantinedBatch re-runs the validation and persistence of a batch that was previously quarantined.
+// If the batch is now valid (for example because the author has since been registered), the pins are stored
+// for the aggregator to process, and the quarantine record is removed. Private batches are re-checked against
+// the peer they were received from, and processed as they would have been on receipt.
+func (em *eventManager) ReprocessQuarantinedBatch(ctx context.Context, qb *fftypes.QuarantinedBatch) (batch *fftypes.Batch, err error) {
+	err = json.Unmarshal([]byte(qb.Payload), &batch)
+	if err != nil || batch == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgQuarantinedBatchUnparsable, qb.ID, err)
+	}
+	if qb.Peer != "" {
+		return em.reprocessQuarantinedPrivateBatch(ctx, qb, batch)
+	}
+
+	batchPin := &blockchain.BatchPin{
+		Namespace:       qb.Namespace,
+		TransactionID:   qb.TX,
+		BatchID:         qb.Batch,
+		BatchHash:       qb.Hash,
+		BatchPayloadRef: qb.PayloadRef,
+		Contexts:        make([]*fftypes.Bytes32, len(qb.Contexts)),
+	}
+	for i, c := range qb.Contexts {
+		if batchPin.Contexts[i], err = fftypes.ParseBytes32(ctx, c); err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgQuarantinedBatchUnparsable, qb.ID, err)
+		}
+	}
+
+	// A rotated signing key is checked against the time the batch was pinned on the blockchain
+	fb := database.BlockchainEventQueryFactory.NewFilter(ctx)
+	events, _, err := em.database.GetBlockchainEvents(ctx, fb.And(fb.Eq("tx.id", qb.TX)).Limit(1))
+	if err != nil {
+		return nil, err
+	}
+	var pinned *fftypes.FFTime
+	var pinInfo fftypes.JSONObject
+	if len(events) > 0 {
+		pinned = events[0].Timestamp
+		pinInfo = events[0].Info
+	}
+
+	// The identity registry is queried on the blockchain outside of the database transaction
+	valid, reason, err := em.verifyIdentityRegistered(ctx, batch, pinInfo)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, i18n.NewError(ctx, i18n.MsgQuarantinedBatchInvalid, qb.ID, reason)
+	}
+
+	err = em.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		valid, reason, err = em.persistBatchFromBroadcast(ctx, batch, qb.Hash, qb.Key, pinned)
+		if err == nil && valid {
+			if err = em.persistContexts(ctx, batchPin, false); err == nil {
+				err = em.database.DeleteQuarantinedBatch(ctx, qb.ID)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, i18n.NewError(ctx, i18n.MsgQuarantinedBatchInvalid, qb.ID, reason)
+	}
+	return batch, nil
+}
+
+func (em *eventManager) reprocessQuarantinedPrivateBatch(ctx context.Context, qb *fftypes.QuarantinedBatch, batch *fftypes.Batch) (*fftypes.Batch, error) {
+	// The identity registry is queried on the blockchain outside of the database transaction.
+	// The pin of a private batch is not matched until it is persisted, so the latest block is checked
+	valid, reason, err := em.verifyIdentityRegistered(ctx, batch, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, i18n.NewError(ctx, i18n.MsgQuarantinedBatchInvalid, qb.ID, reason)
+	}
+
+	err = em.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		node, err := em.checkReceivedIdentity(ctx, qb.Peer, batch.Author, batch.Key)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			valid, reason = false, fmt.Sprintf("Author '%s' is not valid for peer '%s'", batch.Author, qb.Peer)
+			return nil
+		}
+		valid, reason, err = em.persistBatch(ctx, batch)
+		if err == nil && valid {
+			if err = em.privateBatchPersisted(ctx, batch); err == nil {
+				err = em.database.DeleteQuarantinedBatch(ctx, qb.ID)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, i18n.NewError(ctx, i18n.MsgQuarantinedBatchInvalid, qb.ID, reason)
+	}
+	return batch, nil
+}
@@ -0,0 +1,372 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func sampleQuarantinedBatch(t *testing.T) (*fftypes.QuarantinedBatch, *fftypes.Batch) {
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   fftypes.NewUUID(),
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	batchBytes, err := json.Marshal(&batch)
+	assert.NoError(t, err)
+	return &fftypes.QuarantinedBatch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Batch:     batch.ID,
+		TX:        batch.Payload.TX.ID,
+		Hash:      batch.Hash,
+		Key:       "0x12345",
+		Contexts:  fftypes.FFStringArray{fftypes.NewRandB32().String()},
+		Reason:    "Author 'author1' could not be resolved: pop",
+		Payload:   string(batchBytes),
+	}, batch
+}
+
+func TestReprocessQuarantinedBatchOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, batch := sampleQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertPin", mock.Anything, mock.MatchedBy(func(p *fftypes.Pin) bool {
+		return p.Batch.Equals(batch.ID) && p.Hash.String() == qb.Contexts[0] && !p.Masked
+	})).Return(nil)
+	mdi.On("DeleteQuarantinedBatch", mock.Anything, qb.ID).Return(nil)
+	pinned := fftypes.Now()
+	mdi.On("GetBlockchainEvents", em.ctx, mock.Anything).Return([]*fftypes.BlockchainEvent{{Timestamp: pinned}}, nil, nil)
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyAuthor", mock.Anything, mock.Anything, "0x12345", pinned).Return("author1", nil)
+
+	processed, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.NoError(t, err)
+	assert.Equal(t, batch.ID, processed.ID)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedBatchStillInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, _ := sampleQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+
+	mdi.On("GetBlockchainEvents", em.ctx, mock.Anything).Return([]*fftypes.BlockchainEvent{}, nil, nil)
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyAuthor", mock.Anything, mock.Anything, "0x12345", (*fftypes.FFTime)(nil)).Return("", fmt.Errorf("pop"))
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.Regexp(t, "FF10350.*could not be resolved", err)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedBatchPersistFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, _ := sampleQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mdi.On("GetBlockchainEvents", em.ctx, mock.Anything).Return([]*fftypes.BlockchainEvent{}, nil, nil)
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyAuthor", mock.Anything, mock.Anything, "0x12345", mock.Anything).Return("author1", nil)
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedBatchGetEventsFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, _ := sampleQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockchainEvents", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedBatchNotRegistered(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	qb, _ := sampleQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockchainEvents", em.ctx, mock.Anything).Return([]*fftypes.BlockchainEvent{
+		{Info: fftypes.JSONObject{"blockNumber": "12345"}},
+	}, nil, nil)
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", em.ctx, "0x12345", fftypes.NewFFBigInt(12345)).Return(false, nil)
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.Regexp(t, "FF10350.*not registered", err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedBatchRegistryFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	qb, _ := sampleQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockchainEvents", em.ctx, mock.Anything).Return([]*fftypes.BlockchainEvent{}, nil, nil)
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", em.ctx, "0x12345", (*fftypes.FFBigInt)(nil)).Return(false, fmt.Errorf("pop"))
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedBatchBadPayload(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, _ := sampleQuarantinedBatch(t)
+	qb.Payload = "!json"
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.Regexp(t, "FF10349", err)
+}
+
+func TestReprocessQuarantinedBatchBadContext(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, _ := sampleQuarantinedBatch(t)
+	qb.Contexts = fftypes.FFStringArray{"!hex"}
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.Regexp(t, "FF10349", err)
+}
+
+func samplePrivateQuarantinedBatch(t *testing.T) (*fftypes.QuarantinedBatch, *fftypes.Batch) {
+	batch := sampleBatch(t, fftypes.TransactionTypeBatchPin)
+	batchBytes, err := json.Marshal(&batch)
+	assert.NoError(t, err)
+	return &fftypes.QuarantinedBatch{
+		ID:      fftypes.NewUUID(),
+		Batch:   batch.ID,
+		TX:      batch.Payload.TX.ID,
+		Hash:    batch.Hash,
+		Key:     "0x12345",
+		Peer:    "peer1",
+		Reason:  "Author 'signingOrg' is not valid for peer 'peer1'",
+		Payload: string(batchBytes),
+	}, batch
+}
+
+func TestReprocessQuarantinedPrivateBatchOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, batch := samplePrivateQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{
+		{Name: "node1", Owner: "0x12345"},
+	}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{
+		Identity: "0x12345",
+	}, nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("DeleteQuarantinedBatch", mock.Anything, qb.ID).Return(nil)
+
+	processed, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.NoError(t, err)
+	assert.Equal(t, batch.ID, processed.ID)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedPrivateBatchStillInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, _ := samplePrivateQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.Regexp(t, "FF10350.*not valid for peer", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedPrivateBatchLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, _ := samplePrivateQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.Regexp(t, "pop", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedPrivateBatchPersistFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	qb, _ := samplePrivateQuarantinedBatch(t)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{
+		{Name: "node1", Owner: "0x12345"},
+	}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{
+		Identity: "0x12345",
+	}, nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.Regexp(t, "pop", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedPrivateBatchNotRegistered(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	qb, _ := samplePrivateQuarantinedBatch(t)
+
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", em.ctx, "0x12345", (*fftypes.FFBigInt)(nil)).Return(false, nil)
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.Regexp(t, "FF10350.*not registered", err)
+
+	mbi.AssertExpectations(t)
+}
+
+func TestReprocessQuarantinedPrivateBatchRegistryFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.verifyIdentityReg = true
+
+	qb, _ := samplePrivateQuarantinedBatch(t)
+
+	mbi := em.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("VerifyIdentityRegistered", em.ctx, "0x12345", (*fftypes.FFBigInt)(nil)).Return(false, fmt.Errorf("pop"))
+
+	_, err := em.ReprocessQuarantinedBatch(em.ctx, qb)
+	assert.EqualError(t, err, "pop")
+
+	mbi.AssertExpectations(t)
+}
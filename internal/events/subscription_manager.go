@@ -20,14 +20,18 @@ import (
 	"context"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/definitions"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/events/eifactory"
 	"github.com/hyperledger/firefly/internal/events/system"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/loadshed"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/events"
@@ -38,6 +42,7 @@ type subscription struct {
 	definition *fftypes.Subscription
 
 	dispatcherElection chan bool
+	pool               *deliveryPool
 	eventMatcher       *regexp.Regexp
 	groupFilter        *regexp.Regexp
 	tagFilter          *regexp.Regexp
@@ -58,6 +63,7 @@ type subscriptionManager struct {
 	database                  database.Plugin
 	data                      data.Manager
 	eventNotifier             *eventNotifier
+	eventBus                  eventbus.Bus
 	definitions               definitions.DefinitionHandlers
 	transports                map[string]events.Plugin
 	connections               map[string]*connection
@@ -69,9 +75,11 @@ type subscriptionManager struct {
 	deletedSubscriptions      chan *fftypes.UUID
 	cel                       *changeEventListener
 	retry                     retry.Retry
+	metrics                   metrics.Manager
+	deliveryPools             map[fftypes.SubOptsDeliveryClass]*deliveryPool
 }
 
-func newSubscriptionManager(ctx context.Context, di database.Plugin, dm data.Manager, en *eventNotifier, sh definitions.DefinitionHandlers) (*subscriptionManager, error) {
+func newSubscriptionManager(ctx context.Context, di database.Plugin, dm data.Manager, en *eventNotifier, eb eventbus.Bus, sh definitions.DefinitionHandlers, mm metrics.Manager) (*subscriptionManager, error) {
 	ctx, cancelCtx := context.WithCancel(ctx)
 	sm := &subscriptionManager{
 		ctx:                       ctx,
@@ -85,13 +93,17 @@ func newSubscriptionManager(ctx context.Context, di database.Plugin, dm data.Man
 		maxSubs:                   uint64(config.GetUint(config.SubscriptionMax)),
 		cancelCtx:                 cancelCtx,
 		eventNotifier:             en,
+		eventBus:                  eb,
 		definitions:               sh,
+		metrics:                   mm,
+		deliveryPools:             newDeliveryPools(),
 		retry: retry.Retry{
 			InitialDelay: config.GetDuration(config.SubscriptionsRetryInitialDelay),
 			MaximumDelay: config.GetDuration(config.SubscriptionsRetryMaxDelay),
 			Factor:       config.GetFloat64(config.SubscriptionsRetryFactor),
 		},
 	}
+	sm.retry.ReloadFromConfig(ctx, config.SubscriptionsRetryInitialDelay, config.SubscriptionsRetryMaxDelay, config.SubscriptionsRetryFactor)
 	sm.cel = newChangeEventListener(ctx)
 
 	err := sm.loadTransports()
@@ -267,6 +279,15 @@ func (sm *subscriptionManager) parseSubscriptionDef(ctx context.Context, subDef
 		return nil, err
 	}
 
+	deliveryClass := fftypes.SubOptsDeliveryClass(config.GetString(config.SubscriptionDefaultsDeliveryClass))
+	if subDef.Options.DeliveryClass != nil {
+		deliveryClass = *subDef.Options.DeliveryClass
+	}
+	pool, ok := sm.deliveryPools[deliveryClass]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownDeliveryClass, deliveryClass)
+	}
+
 	var eventFilter *regexp.Regexp
 	if filter.Events != "" {
 		eventFilter, err = regexp.Compile(filter.Events)
@@ -310,6 +331,7 @@ func (sm *subscriptionManager) parseSubscriptionDef(ctx context.Context, subDef
 	sub = &subscription{
 		dispatcherElection: make(chan bool, 1),
 		definition:         subDef,
+		pool:               pool,
 		eventMatcher:       eventFilter,
 		groupFilter:        groupFilter,
 		tagFilter:          tagFilter,
@@ -319,6 +341,17 @@ func (sm *subscriptionManager) parseSubscriptionDef(ctx context.Context, subDef
 	return sub, err
 }
 
+// loadSheddingChanged slows the database calls of all event dispatchers while the node is shedding load
+func (sm *subscriptionManager) loadSheddingChanged(level loadshed.Level) {
+	var delay time.Duration
+	if level >= loadshed.LevelSlowDispatch {
+		delay = config.GetDuration(config.LoadSheddingDispatchDelay)
+	}
+	for _, pool := range sm.deliveryPools {
+		pool.setDelay(delay)
+	}
+}
+
 func (sm *subscriptionManager) close() {
 	sm.mux.Lock()
 	conns := make([]*connection, 0, len(sm.connections))
@@ -381,7 +414,7 @@ func (sm *subscriptionManager) matchSubToConnLocked(conn *connection, sub *subsc
 	}
 	if conn.transport == sub.definition.Transport && conn.matcher(sub.definition.SubscriptionRef) {
 		if _, ok := conn.dispatchers[*sub.definition.ID]; !ok {
-			dispatcher := newEventDispatcher(sm.ctx, conn.ei, sm.database, sm.data, sm.definitions, conn.id, sub, sm.eventNotifier, sm.cel)
+			dispatcher := newEventDispatcher(sm.ctx, conn.ei, sm.database, sm.data, sm.definitions, conn.id, sub, sm.eventNotifier, sm.eventBus, sm.cel, sm.metrics)
 			conn.dispatchers[*sub.definition.ID] = dispatcher
 			dispatcher.start()
 		}
@@ -418,7 +451,7 @@ func (sm *subscriptionManager) ephemeralSubscription(ei events.Plugin, connID, n
 	}
 
 	// Create the dispatcher, and start immediately
-	dispatcher := newEventDispatcher(sm.ctx, ei, sm.database, sm.data, sm.definitions, connID, newSub, sm.eventNotifier, sm.cel)
+	dispatcher := newEventDispatcher(sm.ctx, ei, sm.database, sm.data, sm.definitions, connID, newSub, sm.eventNotifier, sm.eventBus, sm.cel, sm.metrics)
 	dispatcher.start()
 
 	conn.dispatchers[*subID] = dispatcher
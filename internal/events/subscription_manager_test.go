@@ -22,10 +22,12 @@ import (
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
 	"github.com/hyperledger/firefly/mocks/eventsmocks"
+	"github.com/hyperledger/firefly/mocks/metricsmocks"
 	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -47,7 +49,9 @@ func newTestSubManager(t *testing.T, mei *eventsmocks.PluginAll) (*subscriptionM
 	mei.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil).Maybe()
 	mdi.On("GetOffset", mock.Anything, mock.Anything, mock.Anything).Return(&fftypes.Offset{RowID: 3333333, Current: 0}, nil).Maybe()
-	sm, err := newSubscriptionManager(ctx, mdi, mdm, newEventNotifier(ctx, "ut"), msh)
+	mmi := &metricsmocks.Manager{}
+	mmi.On("IsMetricsEnabled").Return(false).Maybe()
+	sm, err := newSubscriptionManager(ctx, mdi, mdm, newEventNotifier(ctx, "ut"), eventbus.NewBus(), msh, mmi)
 	assert.NoError(t, err)
 	sm.transports = map[string]events.Plugin{
 		"ut": mei,
@@ -163,7 +167,7 @@ func TestSubManagerBadPlugin(t *testing.T) {
 	mdm := &datamocks.Manager{}
 	config.Reset()
 	config.Set(config.EventTransportsEnabled, []string{"!unknown!"})
-	_, err := newSubscriptionManager(context.Background(), mdi, mdm, newEventNotifier(context.Background(), "ut"), nil)
+	_, err := newSubscriptionManager(context.Background(), mdi, mdm, newEventNotifier(context.Background(), "ut"), eventbus.NewBus(), nil, nil)
 	assert.Regexp(t, "FF10172", err)
 }
 
@@ -325,6 +329,47 @@ func TestCreateSubscriptionBadAuthorFilter(t *testing.T) {
 	assert.Regexp(t, "FF10171.*author", err)
 }
 
+func TestCreateSubscriptionDeliveryClass(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	sub, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Transport: "ut",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.SubOptsDeliveryClassStandard, sub.pool.class)
+
+	bulk := fftypes.SubOptsDeliveryClassBulk
+	sub, err = sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Transport: "ut",
+		Options: fftypes.SubscriptionOptions{
+			SubscriptionCoreOptions: fftypes.SubscriptionCoreOptions{
+				DeliveryClass: &bulk,
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, sm.deliveryPools[fftypes.SubOptsDeliveryClassBulk], sub.pool)
+}
+
+func TestCreateSubscriptionBadDeliveryClass(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	wrong := fftypes.SubOptsDeliveryClass("wrong")
+	_, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Transport: "ut",
+		Options: fftypes.SubscriptionOptions{
+			SubscriptionCoreOptions: fftypes.SubscriptionCoreOptions{
+				DeliveryClass: &wrong,
+			},
+		},
+	})
+	assert.Regexp(t, "FF10392.*wrong", err)
+}
+
 func TestDispatchDeliveryResponseOK(t *testing.T) {
 	mei := &eventsmocks.PluginAll{}
 	sm, cancel := newTestSubManager(t, mei)
@@ -121,6 +121,7 @@ func (em *eventManager) shouldAnnounce(ctx context.Context, pool *tokens.TokenPo
 // When received in any other scenario, it should be ignored.
 func (em *eventManager) TokenPoolCreated(ti tokens.Plugin, pool *tokens.TokenPool) (err error) {
 	var batchID *fftypes.UUID
+	var batchNS string
 	var announcePool *fftypes.TokenPool
 
 	err = em.retry.Do(em.ctx, "persist token pool transaction", func(attempt int) (bool, error) {
@@ -131,13 +132,13 @@ func (em *eventManager) TokenPoolCreated(ti tokens.Plugin, pool *tokens.TokenPoo
 				return err
 			}
 			if existingPool != nil {
-				if existingPool.State == fftypes.TokenPoolStateConfirmed {
-					return nil // already confirmed
+				if existingPool.IsConfirmed() {
+					return nil // already confirmed (and possibly since paused or archived)
 				}
 				if msg, err := em.database.GetMessageByID(ctx, existingPool.Message); err != nil {
 					return err
 				} else if msg != nil {
-					batchID = msg.BatchID // trigger rewind after completion of database transaction
+					batchNS, batchID = msg.Header.Namespace, msg.BatchID // trigger rewind after completion of database transaction
 				}
 				return em.confirmPool(ctx, existingPool, &pool.Event, pool.Event.BlockchainTXID)
 			} else if pool.TransactionID == nil {
@@ -165,7 +166,7 @@ func (em *eventManager) TokenPoolCreated(ti tokens.Plugin, pool *tokens.TokenPoo
 		// Initiate a rewind if a batch was potentially completed by the arrival of this transaction
 		if batchID != nil {
 			log.L(em.ctx).Infof("Batch '%s' contains reference to received pool '%s'", batchID, pool.ProtocolID)
-			em.aggregator.offchainBatches <- batchID
+			em.notifyOffchainBatch(batchNS, batchID)
 		}
 
 		// Announce the details of the new token pool with the blockchain event details
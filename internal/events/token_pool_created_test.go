@@ -186,6 +186,43 @@ func TestTokenPoolCreatedAlreadyConfirmed(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestTokenPoolCreatedAlreadyConfirmedAndPaused(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	txID := fftypes.NewUUID()
+	info := fftypes.JSONObject{"some": "info"}
+	chainPool := &tokens.TokenPool{
+		Type:          fftypes.TokenTypeFungible,
+		ProtocolID:    "123",
+		Connector:     "erc1155",
+		TransactionID: txID,
+		Event: blockchain.Event{
+			BlockchainTXID: "0xffffeeee",
+			ProtocolID:     "tx1",
+			Info:           info,
+		},
+	}
+	storedPool := &fftypes.TokenPool{
+		Namespace: "ns1",
+		ID:        fftypes.NewUUID(),
+		State:     fftypes.TokenPoolStatePaused,
+		TX: fftypes.TransactionRef{
+			Type: fftypes.TransactionTypeTokenPool,
+			ID:   txID,
+		},
+	}
+
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "123").Return(storedPool, nil)
+
+	err := em.TokenPoolCreated(mti, chainPool)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
 func TestTokenPoolCreatedMigrate(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -119,6 +119,7 @@ func (em *eventManager) persistTokenTransfer(ctx context.Context, transfer *toke
 
 func (em *eventManager) TokensTransferred(ti tokens.Plugin, transfer *tokens.TokenTransfer) error {
 	var batchID *fftypes.UUID
+	var batchNS string
 
 	err := em.retry.Do(em.ctx, "persist token transfer", func(attempt int) (bool, error) {
 		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
@@ -138,7 +139,7 @@ func (em *eventManager) TokensTransferred(ti tokens.Plugin, transfer *tokens.Tok
 						}
 					} else {
 						// Message was already received - aggregator will need to be rewound
-						batchID = msg.BatchID
+						batchNS, batchID = msg.Header.Namespace, msg.BatchID
 					}
 				}
 			}
@@ -152,7 +153,7 @@ func (em *eventManager) TokensTransferred(ti tokens.Plugin, transfer *tokens.Tok
 	// Initiate a rewind if a batch was potentially completed by the arrival of this transfer
 	if err == nil && batchID != nil {
 		log.L(em.ctx).Infof("Batch '%s' contains reference to received transfer. Transfer='%s' Message='%s'", batchID, transfer.ProtocolID, transfer.Message)
-		em.aggregator.offchainBatches <- batchID
+		em.notifyOffchainBatch(batchNS, batchID)
 	}
 
 	return err
@@ -290,6 +290,12 @@ func (wh *WebHooks) attemptRequest(sub *fftypes.Subscription, event *fftypes.Eve
 	if err != nil {
 		return nil, nil, err
 	}
+	// Custom headers from the message cannot override the headers configured on the subscription
+	for h := range event.Headers {
+		if req.r.Header.Get(h) == "" {
+			_ = req.r.SetHeader(h, event.Headers.GetString(h))
+		}
+	}
 
 	if req.method == http.MethodPost || req.method == http.MethodPatch || req.method == http.MethodPut {
 		switch {
@@ -381,6 +381,52 @@ func TestRequestNoBodyNoReply(t *testing.T) {
 	assert.True(t, called)
 }
 
+func TestRequestMessageHeaders(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	called := false
+	r := mux.NewRouter()
+	r.HandleFunc("/myapi", func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "east", req.Header.Get("x-route"))
+		assert.Equal(t, "static", req.Header.Get("x-fixed"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		res.WriteHeader(200)
+		called = true
+	}).Methods(http.MethodPost)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	sub := &fftypes.Subscription{}
+	to := sub.Options.TransportOptions()
+	to["url"] = fmt.Sprintf("http://%s/myapi", server.Listener.Addr())
+	to["headers"] = map[string]interface{}{
+		"x-fixed": "static",
+	}
+	event := &fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID: fftypes.NewUUID(),
+		},
+		Subscription: fftypes.SubscriptionRef{
+			ID: sub.ID,
+		},
+		Message: &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID: fftypes.NewUUID(),
+			},
+		},
+		Headers: fftypes.JSONObject{
+			"x-route":      "east",
+			"x-fixed":      "overridden",
+			"Content-Type": "text/plain",
+		},
+	}
+
+	err := wh.DeliveryRequest(mock.Anything, sub, event, []*fftypes.Data{})
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
 func TestRequestReplyEmptyData(t *testing.T) {
 	wh, cancel := newTestWebHooks(t)
 	defer cancel()
@@ -21,12 +21,15 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
 	"sync"
 
 	"github.com/gorilla/websocket"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/rbac"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -38,6 +41,7 @@ type websocketStartedSub struct {
 
 type websocketConnection struct {
 	ctx                context.Context
+	authCtx            context.Context
 	ws                 *WebSockets
 	wsConn             *websocket.Conn
 	cancelCtx          func()
@@ -53,12 +57,15 @@ type websocketConnection struct {
 	changeEventMatcher *regexp.Regexp
 }
 
-func newConnection(pCtx context.Context, ws *WebSockets, wsConn *websocket.Conn) *websocketConnection {
+// newConnection creates a connection, retaining the context of the upgrade request as the authCtx
+// so the authenticated identity of the caller can be checked as each subscription is started
+func newConnection(pCtx context.Context, ws *WebSockets, wsConn *websocket.Conn, authCtx context.Context) *websocketConnection {
 	connID := fftypes.NewUUID().String()
 	ctx := log.WithLogField(pCtx, "websocket", connID)
 	ctx, cancelCtx := context.WithCancel(ctx)
 	wc := &websocketConnection{
 		ctx:          ctx,
+		authCtx:      authCtx,
 		ws:           ws,
 		wsConn:       wsConn,
 		cancelCtx:    cancelCtx,
@@ -72,32 +79,79 @@ func newConnection(pCtx context.Context, ws *WebSockets, wsConn *websocket.Conn)
 	return wc
 }
 
-// processAutoStart gives a helper to specify query parameters to auto-start your subscription
+// processAutoStart gives a helper to specify query parameters to auto-start your subscription, so simple clients
+// can subscribe without sending a start action. A full start action can also be supplied as a JSON document in the
+// "start" query parameter, with any other query parameters overriding the fields of that document.
 func (wc *websocketConnection) processAutoStart(req *http.Request) {
-	query := req.URL.Query()
-	ephemeral, hasEphemeral := req.URL.Query()["ephemeral"]
-	isEphemeral := hasEphemeral && (len(ephemeral) == 0 || ephemeral[0] != "false")
-	_, hasName := query["name"]
-	autoAck, hasAutoack := req.URL.Query()["autoack"]
-	isAutoack := hasAutoack && (len(autoAck) == 0 || autoAck[0] != "false")
-	if hasEphemeral || hasName {
-		err := wc.handleStart(&fftypes.WSClientActionStartPayload{
-			AutoAck:   &isAutoack,
-			Ephemeral: isEphemeral,
-			Namespace: query.Get("namespace"),
-			Name:      query.Get("name"),
-			Filter: fftypes.SubscriptionFilter{
-				Events: query.Get("filter.events"),
-				Topics: query.Get("filter.topics"),
-				Group:  query.Get("filter.group"),
-				Tag:    query.Get("filter.tag"),
-			},
-			ChangeEvents: query.Get("changeevents"),
-		})
+	start, autoStart, err := wc.parseAutoStart(req.URL.Query())
+	if err == nil && autoStart {
+		err = wc.handleStart(start)
+	}
+	if err != nil {
+		wc.protocolError(err)
+	}
+}
+
+func queryBool(query url.Values, name string) (value, present bool) {
+	values, present := query[name]
+	return present && (len(values) == 0 || values[0] != "false"), present
+}
+
+func (wc *websocketConnection) parseAutoStart(query url.Values) (start *fftypes.WSClientActionStartPayload, autoStart bool, err error) {
+	start = &fftypes.WSClientActionStartPayload{}
+	if startDoc := query.Get("start"); startDoc != "" {
+		if err := json.Unmarshal([]byte(startDoc), start); err != nil {
+			return nil, false, i18n.WrapError(wc.ctx, err, i18n.MsgWSInvalidAutoStartParam, startDoc, "start")
+		}
+		autoStart = true
+	}
+	if ephemeral, present := queryBool(query, "ephemeral"); present {
+		start.Ephemeral = ephemeral
+		autoStart = true
+	}
+	if _, present := query["name"]; present {
+		start.Name = query.Get("name")
+		autoStart = true
+	}
+	if autoAck, present := queryBool(query, "autoack"); present {
+		start.AutoAck = &autoAck
+	}
+
+	stringParams := map[string]*string{
+		"namespace":     &start.Namespace,
+		"changeevents":  &start.ChangeEvents,
+		"filter.events": &start.Filter.Events,
+		"filter.topics": &start.Filter.Topics,
+		"filter.tag":    &start.Filter.Tag,
+		"filter.group":  &start.Filter.Group,
+		"filter.author": &start.Filter.Author,
+	}
+	for name, field := range stringParams {
+		if _, present := query[name]; present {
+			*field = query.Get(name)
+		}
+	}
+
+	if _, present := query["options.firstEvent"]; present {
+		firstEvent := fftypes.SubOptsFirstEvent(query.Get("options.firstEvent"))
+		start.Options.FirstEvent = &firstEvent
+	}
+	if _, present := query["options.readAhead"]; present {
+		readAhead, err := strconv.ParseUint(query.Get("options.readAhead"), 10, 16)
 		if err != nil {
-			wc.protocolError(err)
+			return nil, false, i18n.WrapError(wc.ctx, err, i18n.MsgWSInvalidAutoStartParam, query.Get("options.readAhead"), "options.readAhead")
 		}
+		readAhead16 := uint16(readAhead)
+		start.Options.ReadAhead = &readAhead16
+	}
+	if withData, present := queryBool(query, "options.withData"); present {
+		start.Options.WithData = &withData
+	}
+	if _, present := query["options.deliveryClass"]; present {
+		deliveryClass := fftypes.SubOptsDeliveryClass(query.Get("options.deliveryClass"))
+		start.Options.DeliveryClass = &deliveryClass
 	}
+	return start, autoStart, nil
 }
 
 func (wc *websocketConnection) sendLoop() {
@@ -173,10 +227,15 @@ func (wc *websocketConnection) receiveLoop() {
 	}
 }
 
+// dispatchChangeEvent sends a change event to the client, if it matches the collections requested and the caller
+// is authorized to read the namespace of the change. Changes that are not in a namespace require access to all namespaces.
 func (wc *websocketConnection) dispatchChangeEvent(ce *fftypes.ChangeEvent) error {
 	if wc.changeEventMatcher == nil || !wc.changeEventMatcher.MatchString(ce.Collection) {
 		return nil
 	}
+	if err := rbac.Authorize(wc.authCtx, ce.Namespace, rbac.RoleReader); err != nil {
+		return nil
+	}
 	// Change events do *NOT* require an ack
 	return wc.send(&fftypes.WSChangeNotification{
 		WSClientActionBase: fftypes.WSClientActionBase{
@@ -186,7 +245,7 @@ func (wc *websocketConnection) dispatchChangeEvent(ce *fftypes.ChangeEvent) erro
 	})
 }
 
-func (wc *websocketConnection) dispatch(event *fftypes.EventDelivery) error {
+func (wc *websocketConnection) dispatch(event *fftypes.EventDelivery, data []*fftypes.Data) error {
 	inflight := &fftypes.EventDeliveryResponse{
 		ID:           event.ID,
 		Subscription: event.Subscription,
@@ -200,7 +259,10 @@ func (wc *websocketConnection) dispatch(event *fftypes.EventDelivery) error {
 	}
 	wc.mux.Unlock()
 
-	err := wc.send(event)
+	err := wc.send(&fftypes.WSEventDelivery{
+		EventDelivery: event,
+		Data:          data,
+	})
 	if err != nil {
 		return err
 	}
@@ -236,6 +298,10 @@ func (wc *websocketConnection) send(msg interface{}) error {
 }
 
 func (wc *websocketConnection) handleStart(start *fftypes.WSClientActionStartPayload) (err error) {
+	if err := rbac.Authorize(wc.authCtx, start.Namespace, rbac.RoleReader); err != nil {
+		return err
+	}
+
 	wc.mux.Lock()
 	if start.AutoAck != nil {
 		if *start.AutoAck != wc.autoAck && len(wc.started) > 0 {
@@ -69,12 +69,11 @@ func (ws *WebSockets) GetOptionsSchema(ctx context.Context) string {
 }
 
 func (ws *WebSockets) ValidateOptions(options *fftypes.SubscriptionOptions) error {
-	// We don't support streaming the full data over websockets
-	if options.WithData != nil && *options.WithData {
-		return i18n.NewError(ws.ctx, i18n.MsgWebsocketsNoData)
+	// The data is only streamed over websockets when explicitly requested
+	if options.WithData == nil {
+		defaultFalse := false
+		options.WithData = &defaultFalse
 	}
-	forceFalse := false
-	options.WithData = &forceFalse
 	return nil
 }
 
@@ -85,7 +84,7 @@ func (ws *WebSockets) DeliveryRequest(connID string, sub *fftypes.Subscription,
 	if !ok {
 		return i18n.NewError(ws.ctx, i18n.MsgWSConnectionNotActive, connID)
 	}
-	return conn.dispatch(event)
+	return conn.dispatch(event, data)
 }
 
 func (ws *WebSockets) ChangeEvent(connID string, ce *fftypes.ChangeEvent) {
@@ -108,7 +107,7 @@ func (ws *WebSockets) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	}
 
 	ws.connMux.Lock()
-	wc := newConnection(ws.ctx, ws, wsConn)
+	wc := newConnection(ws.ctx, ws, wsConn, req.Context())
 	ws.connections[wc.connID] = wc
 	ws.connMux.Unlock()
 
@@ -29,8 +29,10 @@ import (
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/config/wsconfig"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/rbac"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/mocks/eventsmocks"
+	"github.com/hyperledger/firefly/pkg/auth"
 	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/wsclient"
@@ -79,18 +81,20 @@ func newTestWebsockets(t *testing.T, cbs *eventsmocks.Callbacks, queryParams ...
 	}
 }
 
-func TestValidateOptionsFail(t *testing.T) {
+func TestValidateOptionsWithData(t *testing.T) {
 	cbs := &eventsmocks.Callbacks{}
 	ws, _, cancel := newTestWebsockets(t, cbs)
 	defer cancel()
 
 	yes := true
-	err := ws.ValidateOptions(&fftypes.SubscriptionOptions{
+	opts := &fftypes.SubscriptionOptions{
 		SubscriptionCoreOptions: fftypes.SubscriptionCoreOptions{
 			WithData: &yes,
 		},
-	})
-	assert.Regexp(t, "FF10244", err)
+	}
+	err := ws.ValidateOptions(opts)
+	assert.NoError(t, err)
+	assert.True(t, *opts.WithData)
 }
 
 func TestValidateOptionsOk(t *testing.T) {
@@ -328,6 +332,109 @@ func TestAutoStartBadOptions(t *testing.T) {
 	cbs.AssertExpectations(t)
 }
 
+func TestAutoStartEphemeralWithDataOptions(t *testing.T) {
+	var connID string
+	var filter *fftypes.SubscriptionFilter
+	var options *fftypes.SubscriptionOptions
+	cbs := &eventsmocks.Callbacks{}
+	sub := cbs.On("EphemeralSubscription",
+		mock.MatchedBy(func(s string) bool { connID = s; return true }),
+		"ns1", mock.Anything, mock.Anything).Return(nil)
+	waitSubscribed := make(chan struct{})
+	sub.RunFn = func(a mock.Arguments) {
+		filter = a[2].(*fftypes.SubscriptionFilter)
+		options = a[3].(*fftypes.SubscriptionOptions)
+		close(waitSubscribed)
+	}
+	cbs.On("DeliveryResponse", mock.Anything, mock.Anything).Return(nil)
+
+	ws, wsc, cancel := newTestWebsockets(t, cbs,
+		"ephemeral", "namespace=ns1", "autoack",
+		"filter.events=message_confirmed", "filter.topics=topic1", "filter.tag=tag1", "filter.group=group1", "filter.author=did:firefly:org/org1",
+		"options.firstEvent=newest", "options.readAhead=50", "options.withData", "options.deliveryClass=realtime",
+	)
+	defer cancel()
+
+	<-waitSubscribed
+	assert.Equal(t, fftypes.SubscriptionFilter{
+		Events: "message_confirmed",
+		Topics: "topic1",
+		Tag:    "tag1",
+		Group:  "group1",
+		Author: "did:firefly:org/org1",
+	}, *filter)
+	assert.Equal(t, fftypes.SubOptsFirstEventNewest, *options.FirstEvent)
+	assert.Equal(t, uint16(50), *options.ReadAhead)
+	assert.True(t, *options.WithData)
+	assert.Equal(t, fftypes.SubOptsDeliveryClassRealtime, *options.DeliveryClass)
+
+	dataID := fftypes.NewUUID()
+	ws.DeliveryRequest(connID, nil, &fftypes.EventDelivery{
+		Event:        fftypes.Event{ID: fftypes.NewUUID()},
+		Subscription: fftypes.SubscriptionRef{ID: fftypes.NewUUID()},
+	}, []*fftypes.Data{{ID: dataID, Value: fftypes.JSONAnyPtr(`"hello"`)}})
+
+	b := <-wsc.Receive()
+	var res fftypes.WSEventDelivery
+	err := json.Unmarshal(b, &res)
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 1)
+	assert.Equal(t, *dataID, *res.Data[0].ID)
+	assert.Equal(t, `"hello"`, res.Data[0].Value.String())
+}
+
+func TestAutoStartJSONDocument(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	waitRegistered := make(chan struct{})
+	reg := cbs.On("RegisterConnection", mock.Anything, mock.Anything).Return(nil)
+	reg.RunFn = func(a mock.Arguments) {
+		close(waitRegistered)
+	}
+
+	ws, _, cancel := newTestWebsockets(t, cbs,
+		"start="+url.QueryEscape(`{"namespace":"ns1","name":"sub1","autoack":true}`),
+		"namespace=ns2",
+	)
+	defer cancel()
+
+	<-waitRegistered
+	ws.connMux.Lock()
+	var wc *websocketConnection
+	for _, c := range ws.connections {
+		wc = c
+	}
+	ws.connMux.Unlock()
+	assert.True(t, wc.autoAck)
+	assert.True(t, wc.durableSubMatcher(fftypes.SubscriptionRef{Namespace: "ns2", Name: "sub1"}))
+	assert.False(t, wc.durableSubMatcher(fftypes.SubscriptionRef{Namespace: "ns1", Name: "sub1"}))
+}
+
+func TestAutoStartBadJSONDocument(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	_, wsc, cancel := newTestWebsockets(t, cbs, "start=!json")
+	defer cancel()
+
+	b := <-wsc.Receive()
+	var res fftypes.WSProtocolErrorPayload
+	err := json.Unmarshal(b, &res)
+	assert.NoError(t, err)
+	assert.Regexp(t, "FF10406.*start", res.Error)
+	cbs.AssertExpectations(t)
+}
+
+func TestAutoStartBadReadAhead(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	_, wsc, cancel := newTestWebsockets(t, cbs, "ephemeral", "namespace=ns1", "options.readAhead=-1")
+	defer cancel()
+
+	b := <-wsc.Receive()
+	var res fftypes.WSProtocolErrorPayload
+	err := json.Unmarshal(b, &res)
+	assert.NoError(t, err)
+	assert.Regexp(t, "FF10406.*options.readAhead", res.Error)
+	cbs.AssertExpectations(t)
+}
+
 func TestHandleAckWithAutoAck(t *testing.T) {
 	eventUUID := fftypes.NewUUID()
 	wsc := &websocketConnection{
@@ -349,6 +456,7 @@ func TestHandleStartFlippingAutoAck(t *testing.T) {
 	eventUUID := fftypes.NewUUID()
 	wsc := &websocketConnection{
 		ctx:          context.Background(),
+		authCtx:      context.Background(),
 		started:      []*websocketStartedSub{{ephemeral: false, name: "name1", namespace: "ns1"}},
 		sendMessages: make(chan interface{}, 1),
 		inflight: []*fftypes.EventDeliveryResponse{
@@ -367,6 +475,7 @@ func TestHandleStartWithChangeEvents(t *testing.T) {
 	mcb := &eventsmocks.Callbacks{}
 	wsc := &websocketConnection{
 		ctx:          context.Background(),
+		authCtx:      context.Background(),
 		connID:       "conn1",
 		started:      []*websocketStartedSub{{ephemeral: false, name: "name1", namespace: "ns1"}},
 		sendMessages: make(chan interface{}, 1),
@@ -403,6 +512,7 @@ func TestHandleChangeEventsDispatchFail(t *testing.T) {
 	mcb := &eventsmocks.Callbacks{}
 	wsc := &websocketConnection{
 		ctx:          ctx,
+		authCtx:      context.Background(),
 		connID:       "conn1",
 		started:      []*websocketStartedSub{{ephemeral: false, name: "name1", namespace: "ns1"}},
 		sendMessages: make(chan interface{}), // wil block
@@ -430,11 +540,88 @@ func TestHandleChangeEventsDispatchFail(t *testing.T) {
 	mcb.AssertExpectations(t)
 }
 
+func TestHandleStartForbidden(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthRBACEnabled, true)
+	config.Set(config.AuthRBACGrants, fftypes.JSONObjectArray{
+		{"identity": "app1", "namespace": "ns1", "role": "reader"},
+	})
+	authorizer, err := rbac.NewAuthorizer(context.Background())
+	assert.NoError(t, err)
+	mcb := &eventsmocks.Callbacks{}
+	wsc := &websocketConnection{
+		ctx:          context.Background(),
+		authCtx:      rbac.WithAuthorizer(auth.WithIdentity(context.Background(), "app1"), authorizer),
+		connID:       "conn1",
+		sendMessages: make(chan interface{}, 1),
+		ws: &WebSockets{
+			callbacks: mcb,
+		},
+	}
+	mcb.On("EphemeralSubscription", "conn1", "ns1", mock.Anything, mock.Anything).Return(nil)
+	err = wsc.handleStart(&fftypes.WSClientActionStartPayload{
+		Namespace: "ns1",
+		Ephemeral: true,
+	})
+	assert.NoError(t, err)
+	err = wsc.handleStart(&fftypes.WSClientActionStartPayload{
+		Namespace: "ns2",
+		Ephemeral: true,
+	})
+	assert.Regexp(t, "FF10492.*app1.*ns2", err)
+	assert.Len(t, wsc.started, 1)
+
+	mcb.AssertExpectations(t)
+}
+
+func TestChangeEventsFilteredByNamespace(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthRBACEnabled, true)
+	config.Set(config.AuthRBACGrants, fftypes.JSONObjectArray{
+		{"identity": "app1", "namespace": "ns1", "role": "reader"},
+	})
+	authorizer, err := rbac.NewAuthorizer(context.Background())
+	assert.NoError(t, err)
+	mcb := &eventsmocks.Callbacks{}
+	wsc := &websocketConnection{
+		ctx:          context.Background(),
+		authCtx:      rbac.WithAuthorizer(auth.WithIdentity(context.Background(), "app1"), authorizer),
+		connID:       "conn1",
+		sendMessages: make(chan interface{}, 3),
+		ws: &WebSockets{
+			callbacks: mcb,
+		},
+	}
+	mcb.On("EphemeralSubscription", "conn1", "ns1", mock.Anything, mock.Anything).Return(nil)
+	err = wsc.handleStart(&fftypes.WSClientActionStartPayload{
+		Namespace:    "ns1",
+		Ephemeral:    true,
+		ChangeEvents: ".*",
+	})
+	assert.NoError(t, err)
+
+	// Changes in another namespace, or outside of any namespace, are not delivered
+	err = wsc.dispatchChangeEvent(&fftypes.ChangeEvent{Collection: "messages", Namespace: "ns2", ID: fftypes.NewUUID()})
+	assert.NoError(t, err)
+	err = wsc.dispatchChangeEvent(&fftypes.ChangeEvent{Collection: "nodes", ID: fftypes.NewUUID()})
+	assert.NoError(t, err)
+	assert.Empty(t, wsc.sendMessages)
+
+	err = wsc.dispatchChangeEvent(&fftypes.ChangeEvent{Collection: "messages", Namespace: "ns1", ID: fftypes.NewUUID()})
+	assert.NoError(t, err)
+	wscn := (<-wsc.sendMessages).(*fftypes.WSChangeNotification)
+	assert.Equal(t, "ns1", wscn.ChangeEvent.Namespace)
+	assert.Empty(t, wsc.sendMessages)
+
+	mcb.AssertExpectations(t)
+}
+
 func TestHandleStartWithBadChangeEventsRegex(t *testing.T) {
 	eventUUID := fftypes.NewUUID()
 	mcb := &eventsmocks.Callbacks{}
 	wsc := &websocketConnection{
 		ctx:          context.Background(),
+		authCtx:      context.Background(),
 		connID:       "conn1",
 		started:      []*websocketStartedSub{{ephemeral: false, name: "name1", namespace: "ns1"}},
 		sendMessages: make(chan interface{}, 1),
@@ -570,7 +757,7 @@ func TestConnectionDispatchAfterClose(t *testing.T) {
 	wsc := &websocketConnection{
 		ctx: ctx,
 	}
-	err := wsc.dispatch(&fftypes.EventDelivery{})
+	err := wsc.dispatch(&fftypes.EventDelivery{}, nil)
 	assert.Regexp(t, "FF10160", err)
 }
 
@@ -161,7 +161,6 @@ var (
 	MsgDataDoesNotHaveBlob          = ffm("FF10241", "Data does not have a blob attachment", 404)
 	MsgWebhookURLEmpty              = ffm("FF10242", "Webhook subscription option 'url' cannot be empty", 400)
 	MsgWebhookInvalidStringMap      = ffm("FF10243", "Webhook subscription option '%s' must be map of string values. %s=%T", 400)
-	MsgWebsocketsNoData             = ffm("FF10244", "Websockets subscriptions do not support streaming the full data payload, just the references (withData must be false)", 400)
 	MsgWebhooksWithData             = ffm("FF10245", "Webhook subscriptions require the full data payload (withData must be true)", 400)
 	MsgWebhooksOptURL               = ffm("FF10246", "Webhook url to invoke. Can be relative if a base URL is set in the webhook plugin config")
 	MsgWebhooksOptMethod            = ffm("FF10247", "Webhook method to invoke. Default=POST")
@@ -266,4 +265,177 @@ var (
 	MsgFFIGenerationFailed          = ffm("FF10346", "Error generating smart contract interface: %s", 400)
 	MsgFFIGenerationUnsupported     = ffm("FF10347", "Smart contract interface generation is not supported by this blockchain plugin", 400)
 	MsgBlobHashMismatch             = ffm("FF10348", "Blob hash mismatch sent=%s received=%s", 400)
+	MsgQuarantinedBatchUnparsable   = ffm("FF10349", "Payload of quarantined batch '%s' could not be parsed: %s", 400)
+	MsgQuarantinedBatchInvalid      = ffm("FF10350", "Quarantined batch '%s' is still invalid: %s", 409)
+	MsgGroupMembershipChangeEmpty   = ffm("FF10351", "Membership change must add or remove at least one member", 400)
+	MsgGroupMemberExists            = ffm("FF10352", "Identity '%s' on node '%s' is already a member of the group", 400)
+	MsgGroupMemberNotFound          = ffm("FF10353", "Identity '%s' on node '%s' is not a member of the group", 400)
+	MsgGroupVersionMismatch         = ffm("FF10354", "Membership change effective from version %d cannot be applied to group at version %d", 409)
+	MsgGroupMembersMismatch         = ffm("FF10355", "Member list in membership change does not match the result of applying the change", 400)
+	MsgGroupChangeNotMember         = ffm("FF10356", "Identity '%s' is not a member of group '%s'", 403)
+	MsgUnknownBatchValidatorPlugin  = ffm("FF10357", "Unknown batch validator plugin '%s'")
+	MsgNodeReadOnly                 = ffm("FF10358", "This node is in read-only mode, and cannot send messages or submit blockchain transactions", 403)
+	MsgBatchPayloadTooLarge         = ffm("FF10359", "Batch payload exceeds the maximum size of %d bytes")
+	MsgBatchPayloadUnexpectedToken  = ffm("FF10360", "Invalid batch payload: expected '%s' at offset %d")
+	MsgInvalidRewindSequence        = ffm("FF10361", "Invalid rewind sequence %d - must be zero or greater", 400)
+	MsgDelegationNotAuthorized      = ffm("FF10362", "Identity '%s' is not authorized to act on behalf of '%s'", 403)
+	MsgWarnUnknownSortField         = ffm("FF10363", "Sort field '%s' is not a valid field for this collection, and was ignored")
+	MsgWarnUnknownQueryParam        = ffm("FF10364", "Query parameter '%s' is not a valid filter field for this collection, and was ignored")
+	MsgWarnDeprecatedRoute          = ffm("FF10365", "API '%s %s' is deprecated, and might be removed in a future release")
+	MsgWarnDeprecatedQueryParam     = ffm("FF10366", "Query parameter '%s' is deprecated, and might be removed in a future release")
+	MsgWarnInvalidRequestTimeout    = ffm("FF10367", "Invalid Request-Timeout header '%s' was ignored: %s")
+	MsgWarnFilterLimitNearMax       = ffm("FF10368", "Filter limit %d is close to the maximum of %d - consider paging with skip")
+	MsgWarnMessageNearBatchLimit    = ffm("FF10369", "Message size %.2fkb is close to the maximum batch payload size of %.2fkb")
+	MsgIPFSPinningNotConfigured     = ffm("FF10370", "IPFS pinning service is not configured")
+	MsgIPFSPinningRESTErr           = ffm("FF10371", "Error from IPFS pinning service: %s")
+	MsgIPFSPinFailed                = ffm("FF10372", "IPFS pinning service failed to pin '%s' (request '%s')")
+	MsgNamespaceUsageNotEnabled     = ffm("FF10373", "Namespace usage reporting requires metrics to be enabled", 409)
+	MsgInvalidWaitForState          = ffm("FF10374", "Invalid waitForState '%s' - must be one of: %v", 400)
+	MsgInvalidLongPollTimeout       = ffm("FF10375", "Invalid timeout '%s': %s", 400)
+	MsgWarnStateNotReached          = ffm("FF10376", "State '%s' was not reached before the timeout - current state is '%s'")
+	MsgWaitForStateDesc             = ffm("FF10377", "Hold the request until the resource reaches this state, or the timeout is reached")
+	MsgLongPollTimeoutDesc          = ffm("FF10378", "Maximum time to hold the request when waitForState is set. Default is seconds, or specify a unit such as 30s or 1m")
+	MsgS3RESTErr                    = ffm("FF10379", "Error from S3: %s")
+	MsgPinningNotSupported          = ffm("FF10380", "Public storage plugin '%s' does not support pinning")
+	MsgArweaveRESTErr               = ffm("FF10381", "Error from Arweave: %s")
+	MsgArweaveRewardTooHigh         = ffm("FF10382", "Arweave reward of %s winston to store %d bytes exceeds the configured maximum of %s winston")
+	MsgArweaveInvalidWallet         = ffm("FF10383", "Invalid Arweave wallet '%s': %s")
+	MsgArweaveNotConfirmed          = ffm("FF10384", "Arweave transaction '%s' did not reach %d confirmations within %s")
+	MsgArweaveInvalidMaxReward      = ffm("FF10385", "Invalid Arweave maxReward '%s' - must be an integer number of winston")
+	MsgInvalidNodeSigningKey        = ffm("FF10386", "Invalid node signing key '%s': %s")
+	MsgPublicStorageHashMismatch    = ffm("FF10387", "Data retrieved from %s for '%s' does not match the payload reference")
+	MsgIPFSUnsupportedCID           = ffm("FF10388", "Unable to verify IPFS data for '%s' - only CIDv0 references are supported")
+	MsgUnknownCatchupSource         = ffm("FF10389", "Unknown catch-up source '%s'", 400)
+	MsgCatchupFromLocalNode         = ffm("FF10390", "Cannot catch up from the local node '%s'", 400)
+	MsgPublicStorageCacheDirInvalid = ffm("FF10391", "Unable to use public storage cache directory '%s': %s")
+	MsgUnknownDeliveryClass         = ffm("FF10392", "Unknown subscription delivery class '%s'", 400)
+	MsgOperationNotFailed           = ffm("FF10393", "Operation '%s' has status '%s' - only failed operations can be retried", 409)
+	MsgOperationAlreadyRetried      = ffm("FF10394", "Operation '%s' has already been retried as operation '%s'", 409)
+	MsgOperationNotRetryable        = ffm("FF10395", "Operation '%s' of type '%s' cannot be retried", 400)
+	MsgInvalidCryptoPolicyValue     = ffm("FF10396", "Unknown value '%s' for %s in cryptographic policy")
+	MsgInvalidCryptoPolicy          = ffm("FF10397", "Invalid cryptographic policy for namespace '%s': %s")
+	MsgCryptoPolicyKeyType          = ffm("FF10398", "Signing key type '%s' is not permitted by the cryptographic policy of namespace '%s'", 403)
+	MsgCryptoPolicyHashAlgorithm    = ffm("FF10399", "Hash algorithm '%s' is not permitted by the cryptographic policy of namespace '%s'", 403)
+	MsgTransmissionReplayed         = ffm("FF10400", "Transmission with sequence %d from peer '%s' has already been received")
+	MsgTransmissionOutsideWindow    = ffm("FF10401", "Transmission with sequence %d from peer '%s' is outside of the anti-replay window (latest=%d window=%s)")
+	MsgDuplicateLedgerName          = ffm("FF10402", "Duplicate ledger name '%s'")
+	MsgUnknownLedger                = ffm("FF10403", "Unknown ledger '%s'", 400)
+	MsgUnknownNamespaceLedger       = ffm("FF10404", "Unknown ledger '%s' configured for namespace '%s'")
+	MsgLedgerNameDesc               = ffm("FF10405", "The name of a configured ledger, or empty for the default ledger")
+	MsgWSInvalidAutoStartParam      = ffm("FF10406", "Invalid value '%s' for websocket auto-start query parameter '%s'", 400)
+	MsgConnectorWSDisconnected      = ffm("FF10407", "Websocket connection to the connector is disconnected")
+	MsgArchiveStoreFailed           = ffm("FF10408", "Failed to access archive object '%s'")
+	MsgArchiveHashMismatch          = ffm("FF10409", "Hash mismatch for archive '%s' - expected=%s actual=%s")
+	MsgArchiveReadFailed            = ffm("FF10410", "Failed to read archive '%s'")
+	MsgInvalidFeePolicy             = ffm("FF10411", "Invalid fee policy '%s' configured at '%s'")
+	MsgInvalidFeeValue              = ffm("FF10412", "Invalid fee value '%s' configured at '%s' - must be an integer amount of wei")
+	MsgFeeOracleRESTErr             = ffm("FF10413", "Error from gas oracle: %s")
+	MsgFeeOracleNoEstimate          = ffm("FF10414", "Gas oracle response did not contain a gas price, or max fee and max priority fee per gas")
+	MsgNamespaceDeleteReserved      = ffm("FF10415", "Namespace '%s' is reserved, and cannot be deleted")
+	MsgDBSchemaNewer                = ffm("FF10416", "Database schema version %d is newer than the latest migration %d known to this version of FireFly. Refusing to start, as downgrading the database is not supported")
+	MsgContractInvokeFailed         = ffm("FF10417", "Contract invoke operation '%s' failed: %s")
+	MsgEventExportRecordInvalid     = ffm("FF10418", "Event export record %d is not valid JSON")
+	MsgEventExportChainBroken       = ffm("FF10419", "Event export record %d does not chain from the previous record: prevHash='%s' expected='%s'")
+	MsgEventExportHashMismatch      = ffm("FF10420", "Event export record %d has been modified: hash='%s' calculated='%s'")
+	MsgEventExportDescription       = ffm("FF10421", "Exports the matching events, oldest first, as hash-chained NDJSON records that can be verified independently of the database")
+	MsgEventExportPrevHashParam     = ffm("FF10422", "The hash of the last record of a previous export, to continue the hash chain from that export")
+	MsgPostDataBatchDescription     = ffm("FF10423", "Uploads a list of JSON data items in a single database transaction, so either all of the items are stored or none of them are")
+	MsgDXCertInvalid                = ffm("FF10424", "Data exchange endpoint for peer '%s' contains an invalid PEM encoded certificate")
+	MsgDXCertPinMismatch            = ffm("FF10425", "Data exchange certificate for peer '%s' does not match pinned fingerprint: pinned='%s' presented='%s'")
+	MsgTokenIndexRequired           = ffm("FF10426", "A token index must be specified to transfer or burn tokens in a non-fungible pool")
+	MsgNonFungibleAmount            = ffm("FF10427", "Non-fungible tokens must be transferred or burned with an amount of 1")
+	MsgBatchPayloadHashMismatch     = ffm("FF10428", "Hash of batch payload '%s' does not match the expected hash '%s'")
+	MsgUnknownField                 = ffm("FF10429", "Unknown field '%s'", 400)
+	MsgLoadShedding                 = ffm("FF10430", "The node is shedding load due to database pressure (level=%s) - retry later", 503)
+	MsgTokenPoolStateTransition     = ffm("FF10431", "Token pool cannot move from state '%s' to '%s'", 409)
+	MsgTokenPoolPaused              = ffm("FF10432", "Token pool is paused")
+	MsgTokenPoolArchived            = ffm("FF10433", "Token pool is archived")
+	MsgTooManyMessageHeaders        = ffm("FF10434", "Message has %d custom headers - the maximum is %d", 400)
+	MsgInvalidMessageHeader         = ffm("FF10435", "Invalid custom header '%s' - names must be alphanumeric with dashes, and values must be strings of at most %d characters", 400)
+	MsgTokenMetadataMintOnly        = ffm("FF10436", "Token metadata can only be supplied when minting tokens", 400)
+	MsgTokenMetadataWithURI         = ffm("FF10437", "Token metadata cannot be supplied together with a URI, as the URI is set from the published metadata", 400)
+	MsgTokenMetadataNotFound        = ffm("FF10438", "Token metadata '%s' not found", 404)
+	MsgSubscriptionHistoryDesc      = ffm("FF10439", "Lists the changes made to a subscription definition, with the full definition before and after each change. History is retained after the subscription is deleted")
+	MsgInvalidMessagePriority       = ffm("FF10440", "Invalid message priority '%s' - must be one of: low, normal, high", 400)
+	MsgExpired                      = ffm("FF10441", "Message with ID '%s' expired before it could be sent")
+	MsgUnsupportedBatchCompression  = ffm("FF10442", "Unsupported batch compression '%s' - must be one of: none, gzip")
+	MsgInvalidNodeEncryptionKey     = ffm("FF10443", "Invalid node encryption key '%s': %s")
+	MsgInvalidEncryptionPublicKey   = ffm("FF10444", "Invalid encryption public key '%s'")
+	MsgNodeNoEncryptionKey          = ffm("FF10445", "Node '%s' has not registered an encryption key, so cannot receive encrypted transmissions")
+	MsgEncryptionNotConfigured      = ffm("FF10446", "Received an encrypted transmission, but no encryption key is configured for this node")
+	MsgDecryptFailed                = ffm("FF10447", "Failed to decrypt transmission: %s")
+	MsgPrivateDataNotSupported      = ffm("FF10448", "Data cannot be restricted to a group. Only JSON values attached to broadcast messages can be restricted", 400)
+	MsgPrivateDataGroupNotFound     = ffm("FF10449", "Group '%s' for private data not found in namespace '%s'", 400)
+	MsgInvalidNamespaceBatchConfig  = ffm("FF10450", "Invalid batch configuration for namespace '%s': %s")
+	MsgDuplicateIdempotencyKeyMsg   = ffm("FF10451", "Idempotency key '%s' has already been used for message '%s'", 409)
+	MsgDuplicateIdempotencyKeyTX    = ffm("FF10452", "Idempotency key '%s' has already been used for transaction '%s'", 409)
+	MsgDIDResolverRESTErr           = ffm("FF10453", "Error from DID resolver: %s")
+	MsgDIDSigningKeyMismatch        = ffm("FF10454", "Signing key '%s' is not a verification method of '%s' on this blockchain", 400)
+	MsgKeyRotationUnchanged         = ffm("FF10455", "New signing key '%s' is the same as the current signing key", 400)
+	MsgKeyRotationKeyInUse          = ffm("FF10456", "Signing key '%s' is already the identity of organization '%s'", 409)
+	MsgNoIdentityRegistry           = ffm("FF10457", "Verification of the identity registry is enabled, but blockchain plugin '%s' does not have an identity registry configured")
+	MsgNamespaceExists              = ffm("FF10458", "Namespace '%s' already exists", 409)
+	MsgNamespaceNotLocal            = ffm("FF10459", "Namespace '%s' was not defined locally, and cannot be changed through the API", 409)
+	MsgNamespaceRetired             = ffm("FF10460", "Namespace '%s' has been retired", 409)
+	MsgNamespaceRetireReserved      = ffm("FF10461", "Namespace '%s' is reserved, and cannot be retired")
+	MsgDuplicatePublicStorageName   = ffm("FF10462", "Duplicate public storage name '%s'")
+	MsgUnknownNamespacePubStorage   = ffm("FF10463", "Unknown public storage '%s' configured for namespace '%s'")
+	MsgInvalidNamespaceMsgConfig    = ffm("FF10464", "Invalid message configuration for namespace '%s': %s")
+	MsgGraphQLSyntaxError           = ffm("FF10465", "GraphQL syntax error at position %d: %s", 400)
+	MsgGraphQLUnsupported           = ffm("FF10466", "GraphQL %s are not supported", 400)
+	MsgGraphQLOperationNotFound     = ffm("FF10467", "GraphQL operation '%s' not found. An operationName is required when the query contains more than one operation", 400)
+	MsgGraphQLUnknownField          = ffm("FF10468", "Unknown field '%s' on GraphQL type '%s'", 400)
+	MsgGraphQLSelectionRequired     = ffm("FF10469", "Field '%s' on GraphQL type '%s' must have a selection of sub-fields", 400)
+	MsgGraphQLUnknownArgument       = ffm("FF10470", "Unknown argument '%s' on field '%s'", 400)
+	MsgGraphQLUnknownVariable       = ffm("FF10471", "Variable '$%s' is not defined by the operation", 400)
+	MsgGraphQLInvalidArgument       = ffm("FF10472", "Invalid value for argument '%s' - must be a scalar, or a list of scalars", 400)
+	MsgBulkMessageType              = ffm("FF10473", "Message type '%s' cannot be submitted in bulk - only broadcast and private messages are supported", 400)
+	MsgBulkMessagesRejected         = ffm("FF10474", "%d of the %d messages in the bulk submission were rejected", 400)
+	MsgPostBulkMessagesDescription  = ffm("FF10475", "Submits a list of broadcast and private messages, with in-line data, in a single database transaction. Either all of the messages are sent, or none of them are, and the status of each message is returned")
+	MsgInvalidSubscriptionPatch     = ffm("FF10476", "Invalid patch for subscription: %s", 400)
+	MsgSubscriptionFieldImmutable   = ffm("FF10477", "Field '%s' of a subscription cannot be changed", 400)
+	MsgPatchSubscriptionDescription = ffm("FF10478", "Updates the fields of a subscription that are supplied, using a JSON merge patch (RFC 7396). Fields that are omitted are left unchanged, and fields set to null are removed")
+	MsgFilterFieldsDesc             = ffm("FF10479", "The fields to return for each item, as comma separated JSON paths such as header.id (all fields are returned if omitted)")
+	MsgWarnUnknownSparseField       = ffm("FF10480", "Field '%s' is not a known field of the items in this collection")
+	MsgErrorResponse                = ffm("FF10481", "Error")
+	MsgRateLimited                  = ffm("FF10482", "Rate limit exceeded for '%s' - retry later", 429)
+	MsgUnknownAuthPlugin            = ffm("FF10483", "Unknown auth plugin '%s'")
+	MsgAuthenticationFailed         = ffm("FF10484", "Authentication failed", 401)
+	MsgMissingCredentials           = ffm("FF10485", "No credentials were supplied with the request")
+	MsgInvalidAPIKey                = ffm("FF10486", "Invalid API key")
+	MsgInvalidAuthToken             = ffm("FF10487", "Invalid bearer token: %s")
+	MsgOIDCProviderRESTErr          = ffm("FF10488", "Error from OIDC provider: %s")
+	MsgUnmappedClientCert           = ffm("FF10489", "Client certificate '%s' is not mapped to an identity")
+	MsgInvalidAPIKeyConfig          = ffm("FF10490", "Invalid API key configuration at entry %d: 'key' and 'identity' are required")
+	MsgInvalidClientCertConfig      = ffm("FF10491", "Invalid client certificate configuration at entry %d: 'cn' and 'identity' are required")
+	MsgForbidden                    = ffm("FF10492", "Identity '%s' has not been granted the '%s' role for namespace '%s'", 403)
+	MsgInvalidRBACGrant             = ffm("FF10493", "Invalid role grant at entry %d: 'identity' and a 'role' of reader, sender or admin are required")
+	MsgRBACRequiresAuth             = ffm("FF10494", "Role based access control requires an auth plugin to be configured with auth.type")
+	MsgHijackUnsupported            = ffm("FF10495", "The response writer does not support hijacking the connection")
+	MsgInvalidLogLevel              = ffm("FF10496", "Invalid log level '%s': must be one of error, warn, info, debug or trace", 400)
+	MsgConfigReloadNoFile           = ffm("FF10497", "Config cannot be reloaded, as it was not read from a file", 409)
+	MsgAdminEventsNotAvailable      = ffm("FF10498", "Change events are not available until the node has been initialized", 503)
+	MsgAdminCLIRequestFailed        = ffm("FF10499", "Request to FireFly failed: %s")
+	MsgAdminCLIProtocolError        = ffm("FF10500", "FireFly reported an error on the websocket: %s")
+	MsgConfigIncludeCycle           = ffm("FF10501", "Config file '%s' includes itself")
+	MsgConfigFileParseFailed        = ffm("FF10502", "Failed to parse config file '%s'")
+	MsgConfigEnvVarNotSet           = ffm("FF10503", "Config refers to environment variable '%s', which is not set")
+	MsgSecretProviderNotConfigured  = ffm("FF10504", "Config refers to secrets provider '%s', which is not configured")
+	MsgSecretFetchFailed            = ffm("FF10505", "Failed to fetch secret '%s' from %s")
+	MsgSecretProviderRESTErr        = ffm("FF10506", "Error from %s secrets provider: %s")
+	MsgSecretFieldNotFound          = ffm("FF10507", "Secret '%s' does not have a string field '%s'")
+	MsgConfigValidationFailed       = ffm("FF10508", "Config validation failed for %d plugins")
+	MsgCircuitBreakerOpen           = ffm("FF10509", "Circuit breaker open after %d consecutive failures - next attempt in %s", 503)
+	MsgGraphQLMaxDepth              = ffm("FF10510", "GraphQL query exceeds the maximum depth of %d", 400)
+	MsgGraphQLMaxCost               = ffm("FF10511", "GraphQL query exceeds the maximum cost of %d objects", 400)
+	MsgUnknownArchiveType           = ffm("FF10512", "Unknown archive object store type '%s'")
+	MsgEncryptedBlobUnsupported     = ffm("FF10513", "Blob data cannot be sent privately while end-to-end encryption is enabled, as blobs are transferred outside of the encrypted batch", 400)
+	MsgUnknownEncryptionKeyType     = ffm("FF10514", "Unknown node encryption key type '%s'")
+	MsgBesuRPCErr                   = ffm("FF10515", "Error from Besu JSON-RPC: %s")
+	MsgNoPrivacyGroup               = ffm("FF10516", "No privacy group has been established for group '%s'")
+	MsgNodeNoPrivacyKey             = ffm("FF10517", "Node '%s' has not registered a privacy key, so cannot receive the private transactions of the group")
+	MsgBulkDuplicateIdempotencyKey  = ffm("FF10522", "Idempotency key '%s' is also used by message %d of the bulk submission", 409)
+	MsgDuplicateKey                 = ffm("FF10523", "Duplicate key", 409)
+	MsgDXChunkHashMismatch          = ffm("FF10524", "Chunk ending at offset %d was acknowledged with hash '%s', and then with hash '%s'")
+	MsgWarnArchivedRecords          = ffm("FF10525", "Records created up to %s might have been moved into archives, and are not included in these results - archived events and data can be fetched by ID")
 )
@@ -0,0 +1,107 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identity
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// loadCryptoPolicies reads the cryptographic policy declared on each of the predefined namespaces
+func loadCryptoPolicies(ctx context.Context) (map[string]*fftypes.CryptoPolicy, error) {
+	policies := make(map[string]*fftypes.CryptoPolicy)
+	for _, nsObject := range config.GetObjectArray(config.NamespacesPredefined) {
+		name := nsObject.GetString("name")
+		policyObject, ok := nsObject.GetObjectOk("cryptoPolicy")
+		if !ok || policies[name] != nil {
+			continue
+		}
+		var policy fftypes.CryptoPolicy
+		b, _ := json.Marshal(policyObject)
+		err := json.Unmarshal(b, &policy)
+		if err == nil {
+			err = policy.Validate(ctx)
+		}
+		if err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgInvalidCryptoPolicy, name, err)
+		}
+		log.L(ctx).Infof("Namespace '%s' cryptographic policy: keyTypes=%v hashAlgorithms=%v", name, policy.KeyTypes, policy.HashAlgorithms)
+		policies[name] = &policy
+	}
+	return policies, nil
+}
+
+// checkCryptoPolicies fails startup for a cryptographic policy that no message in the namespace could ever meet,
+// because it excludes the hash algorithm or the key type of the ledger the namespace is pinned to
+func (im *identityManager) checkCryptoPolicies(ctx context.Context) error {
+	for namespace, policy := range im.cryptoPolicies {
+		if err := im.checkCryptoPolicy(ctx, namespace, policy, im.blockchainFor(namespace).VerifierType()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *identityManager) checkCryptoPolicy(ctx context.Context, namespace string, policy *fftypes.CryptoPolicy, keyType fftypes.VerifierType) error {
+	if !policy.AllowsKeyType(keyType) {
+		return i18n.NewError(ctx, i18n.MsgCryptoPolicyKeyType, keyType, namespace)
+	}
+	// Messages, data and batches are all hashed with SHA-256
+	if !policy.AllowsHashAlgorithm(fftypes.HashAlgorithmSHA256) {
+		return i18n.NewError(ctx, i18n.MsgCryptoPolicyHashAlgorithm, fftypes.HashAlgorithmSHA256, namespace)
+	}
+	return nil
+}
+
+// VerifyCryptoPolicy checks that the key signing a message or batch, and the hash algorithm used for it,
+// are accepted by the cryptographic policy of the namespace
+func (im *identityManager) VerifyCryptoPolicy(ctx context.Context, namespace string, signer *fftypes.Identity) error {
+	policy := im.cryptoPolicies[namespace]
+	if policy == nil {
+		return nil
+	}
+	keyType, err := im.signingKeyType(ctx, namespace, signer)
+	if err != nil {
+		return err
+	}
+	return im.checkCryptoPolicy(ctx, namespace, policy, keyType)
+}
+
+// signingKeyType returns the type of the key that signs for an identity in a namespace. Keys are accounts on the
+// ledger the namespace is pinned to, so for an author identified by an external DID the key must be declared in
+// the DID document as a verification method on that ledger
+func (im *identityManager) signingKeyType(ctx context.Context, namespace string, signer *fftypes.Identity) (fftypes.VerifierType, error) {
+	bi := im.blockchainFor(namespace)
+	if !isResolvableDID(signer.Author) {
+		return bi.VerifierType(), nil
+	}
+	keys, err := im.cachedDIDSigningKeys(ctx, bi, signer.Author)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range keys {
+		if key == signer.Key {
+			return bi.VerifierType(), nil
+		}
+	}
+	return "", i18n.NewError(ctx, i18n.MsgDIDSigningKeyMismatch, signer.Key, signer.Author)
+}
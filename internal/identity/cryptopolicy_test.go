@@ -0,0 +1,203 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identity
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestCryptoPolicyManager(t *testing.T, predefined fftypes.JSONObjectArray, nsBlockchains map[string]blockchain.Plugin) (*identityManager, error) {
+	config.Reset()
+	config.Set(config.NamespacesPredefined, predefined)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("VerifierType").Return(fftypes.VerifierTypeEthAddress)
+	mbi.On("Name").Return("ethereum").Maybe()
+	im, err := NewIdentityManager(context.Background(), &databasemocks.Plugin{}, &identitymocks.Plugin{}, mbi, nsBlockchains)
+	if err != nil {
+		return nil, err
+	}
+	return im.(*identityManager), nil
+}
+
+func TestLoadCryptoPolicies(t *testing.T) {
+	im, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes":       []interface{}{"ethereum_address"},
+			"hashAlgorithms": []interface{}{"SHA256"},
+		}},
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": []interface{}{"fabric_msp_id"},
+		}},
+		{"name": "ns2"},
+	}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, im.cryptoPolicies, 1)
+	assert.Equal(t, []fftypes.VerifierType{fftypes.VerifierTypeEthAddress}, im.cryptoPolicies["ns1"].KeyTypes)
+	assert.Equal(t, []fftypes.HashAlgorithm{fftypes.HashAlgorithmSHA256}, im.cryptoPolicies["ns1"].HashAlgorithms)
+}
+
+func TestLoadCryptoPoliciesBadValue(t *testing.T) {
+	_, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"hashAlgorithms": []interface{}{"md5"},
+		}},
+	}, nil)
+	assert.Regexp(t, "FF10397.*ns1.*FF10396.*md5", err)
+}
+
+func TestLoadCryptoPoliciesBadType(t *testing.T) {
+	_, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": "ethereum_address",
+		}},
+	}, nil)
+	assert.Regexp(t, "FF10397.*ns1", err)
+}
+
+func TestCheckCryptoPoliciesKeyType(t *testing.T) {
+	_, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": []interface{}{"fabric_msp_id"},
+		}},
+	}, nil)
+	assert.Regexp(t, "FF10398.*ethereum_address.*ns1", err)
+}
+
+func TestCheckCryptoPoliciesNamespaceLedger(t *testing.T) {
+	mbi2 := &blockchainmocks.Plugin{}
+	mbi2.On("VerifierType").Return(fftypes.VerifierTypeMSPIdentity)
+	_, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": []interface{}{"fabric_msp_id"},
+		}},
+		{"name": "ns2", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": []interface{}{"ethereum_address"},
+		}},
+	}, map[string]blockchain.Plugin{"ns1": mbi2})
+	assert.NoError(t, err)
+	mbi2.AssertExpectations(t)
+}
+
+func TestCheckCryptoPoliciesHashAlgorithm(t *testing.T) {
+	im, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"hashAlgorithms": []interface{}{},
+		}},
+	}, nil)
+	assert.NoError(t, err)
+	im.cryptoPolicies["ns1"].HashAlgorithms = []fftypes.HashAlgorithm{"sha512"}
+
+	err = im.checkCryptoPolicies(context.Background())
+	assert.Regexp(t, "FF10399.*sha256.*ns1", err)
+}
+
+func TestVerifyCryptoPolicy(t *testing.T) {
+	im, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes":       []interface{}{"ethereum_address"},
+			"hashAlgorithms": []interface{}{"sha256"},
+		}},
+	}, nil)
+	assert.NoError(t, err)
+
+	signer := &fftypes.Identity{Author: "did:firefly:org/org1", Key: "0x12345"}
+	assert.NoError(t, im.VerifyCryptoPolicy(context.Background(), "ns1", signer))
+	assert.NoError(t, im.VerifyCryptoPolicy(context.Background(), "ns2", signer))
+}
+
+func TestVerifyCryptoPolicyKeyType(t *testing.T) {
+	im, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": []interface{}{"ethereum_address"},
+		}},
+	}, nil)
+	assert.NoError(t, err)
+	im.cryptoPolicies["ns1"].KeyTypes = []fftypes.VerifierType{fftypes.VerifierTypeMSPIdentity}
+
+	err = im.VerifyCryptoPolicy(context.Background(), "ns1", &fftypes.Identity{Author: "did:firefly:org/org1", Key: "0x12345"})
+	assert.Regexp(t, "FF10398.*ethereum_address.*ns1", err)
+}
+
+func TestVerifyCryptoPolicyDIDKey(t *testing.T) {
+	im, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": []interface{}{"ethereum_address"},
+		}},
+	}, nil)
+	assert.NoError(t, err)
+	ctx := context.Background()
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(testDIDDocument("did:ethr:0xabcde"), nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0xABCDE").Return("0xabcde", nil)
+
+	err = im.VerifyCryptoPolicy(ctx, "ns1", &fftypes.Identity{Author: "did:ethr:0xabcde", Key: "0xabcde"})
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+}
+
+func TestVerifyCryptoPolicyDIDKeyNotOnLedger(t *testing.T) {
+	mbi2 := &blockchainmocks.Plugin{}
+	mbi2.On("VerifierType").Return(fftypes.VerifierTypeMSPIdentity)
+	mbi2.On("Name").Return("fabric")
+	mbi2.On("ResolveSigningKey", mock.Anything, "user1").Return("user1", nil)
+	im, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": []interface{}{"fabric_msp_id"},
+		}},
+	}, map[string]blockchain.Plugin{"ns1": mbi2})
+	assert.NoError(t, err)
+	ctx := context.Background()
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(testDIDDocument("did:ethr:0xabcde"), nil)
+
+	// The ethereum key of the DID is not a key on the fabric ledger of the namespace
+	err = im.VerifyCryptoPolicy(ctx, "ns1", &fftypes.Identity{Author: "did:ethr:0xabcde", Key: "0xabcde"})
+	assert.Regexp(t, "FF10454.*0xabcde", err)
+
+	mii.AssertExpectations(t)
+	mbi2.AssertExpectations(t)
+}
+
+func TestVerifyCryptoPolicyDIDResolveFail(t *testing.T) {
+	im, err := newTestCryptoPolicyManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "cryptoPolicy": map[string]interface{}{
+			"keyTypes": []interface{}{"ethereum_address"},
+		}},
+	}, nil)
+	assert.NoError(t, err)
+	ctx := context.Background()
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(nil, fmt.Errorf("pop"))
+
+	err = im.VerifyCryptoPolicy(ctx, "ns1", &fftypes.Identity{Author: "did:ethr:0xabcde", Key: "0xabcde"})
+	assert.EqualError(t, err, "pop")
+
+	mii.AssertExpectations(t)
+}
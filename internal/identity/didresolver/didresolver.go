@@ -0,0 +1,80 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package didresolver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/identity"
+)
+
+// DIDResolver resolves DIDs of any method to their DID document, using a DIF Universal Resolver
+// compatible HTTP endpoint
+type DIDResolver struct {
+	ctx          context.Context
+	capabilities *identity.Capabilities
+	callbacks    identity.Callbacks
+	client       *resty.Client
+}
+
+func (r *DIDResolver) Name() string {
+	return "did"
+}
+
+func (r *DIDResolver) Init(ctx context.Context, prefix config.Prefix, callbacks identity.Callbacks) (err error) {
+	r.ctx = log.WithLogField(ctx, "identity", "did")
+	r.callbacks = callbacks
+	r.capabilities = &identity.Capabilities{}
+
+	if prefix.GetString(restclient.HTTPConfigURL) == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "identity.did")
+	}
+	r.client = restclient.New(r.ctx, prefix)
+	return nil
+}
+
+func (r *DIDResolver) Start() error {
+	return nil
+}
+
+func (r *DIDResolver) Capabilities() *identity.Capabilities {
+	return r.capabilities
+}
+
+func (r *DIDResolver) ResolveDID(ctx context.Context, did string) (*identity.DIDDocument, error) {
+	var doc identity.DIDDocument
+	res, err := r.client.R().SetContext(ctx).
+		SetHeader("Accept", "application/did+json").
+		SetResult(&doc).
+		Get(fmt.Sprintf("/1.0/identifiers/%s", url.PathEscape(did)))
+	if err == nil && res.StatusCode() == http.StatusNotFound {
+		log.L(ctx).Debugf("DID '%s' not found", did)
+		return nil, nil
+	}
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgDIDResolverRESTErr)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package didresolver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/mocks/identitymocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/identity"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("did_unit_tests")
+
+func newTestDIDResolver(t *testing.T) (*DIDResolver, func()) {
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+
+	config.Reset()
+	r := &DIDResolver{}
+	r.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://resolver.example.com")
+	utConfPrefix.Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := r.Init(context.Background(), utConfPrefix, &identitymocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Equal(t, "did", r.Name())
+	assert.NotNil(t, r.Capabilities())
+	assert.NoError(t, r.Start())
+	return r, httpmock.DeactivateAndReset
+}
+
+func TestInitMissingURL(t *testing.T) {
+	config.Reset()
+	r := &DIDResolver{}
+	r.InitPrefix(utConfPrefix)
+	err := r.Init(context.Background(), utConfPrefix, &identitymocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestResolveDIDOk(t *testing.T) {
+	r, done := newTestDIDResolver(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://resolver.example.com/1.0/identifiers/did:ethr:0x12345",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "application/did+json", req.Header.Get("Accept"))
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
+				"id": "did:ethr:0x12345",
+				"verificationMethod": []fftypes.JSONObject{
+					{
+						"id":                  "did:ethr:0x12345#controller",
+						"type":                "EcdsaSecp256k1RecoveryMethod2020",
+						"controller":          "did:ethr:0x12345",
+						"blockchainAccountId": "eip155:1:0x12345",
+					},
+				},
+			})(req)
+		})
+
+	doc, err := r.ResolveDID(context.Background(), "did:ethr:0x12345")
+	assert.NoError(t, err)
+	assert.Equal(t, &identity.DIDDocument{
+		ID: "did:ethr:0x12345",
+		VerificationMethods: []*identity.VerificationMethod{
+			{
+				ID:                  "did:ethr:0x12345#controller",
+				Type:                "EcdsaSecp256k1RecoveryMethod2020",
+				Controller:          "did:ethr:0x12345",
+				BlockchainAccountID: "eip155:1:0x12345",
+			},
+		},
+	}, doc)
+}
+
+func TestResolveDIDNotFound(t *testing.T) {
+	r, done := newTestDIDResolver(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://resolver.example.com/1.0/identifiers/did:ethr:0x12345",
+		httpmock.NewJsonResponderOrPanic(404, fftypes.JSONObject{}))
+
+	doc, err := r.ResolveDID(context.Background(), "did:ethr:0x12345")
+	assert.NoError(t, err)
+	assert.Nil(t, doc)
+}
+
+func TestResolveDIDError(t *testing.T) {
+	r, done := newTestDIDResolver(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://resolver.example.com/1.0/identifiers/did:ethr:0x12345",
+		httpmock.NewJsonResponderOrPanic(500, fftypes.JSONObject{}))
+
+	_, err := r.ResolveDID(context.Background(), "did:ethr:0x12345")
+	assert.Regexp(t, "FF10453", err)
+}
@@ -34,37 +34,54 @@ import (
 
 type Manager interface {
 	ResolveInputIdentity(ctx context.Context, identity *fftypes.Identity) (err error)
+	ResolveDelegatedIdentity(ctx context.Context, identity *fftypes.Identity, onBehalfOf string) (err error)
 	ResolveSigningKey(ctx context.Context, inputKey string) (outputKey string, err error)
 	ResolveSigningKeyIdentity(ctx context.Context, signingKey string) (author string, err error)
+	ResolveSigningKeyAuthor(ctx context.Context, author, signingKey string, pinned *fftypes.FFTime) (resolvedAuthor string, err error)
 	ResolveLocalOrgDID(ctx context.Context) (localOrgDID string, err error)
 	GetLocalOrgKey(ctx context.Context) (string, error)
 	OrgDID(org *fftypes.Organization) string
+	OrgKeyRotated(org *fftypes.Organization)
 	GetLocalOrganization(ctx context.Context) (*fftypes.Organization, error)
+	VerifyCryptoPolicy(ctx context.Context, namespace string, signer *fftypes.Identity) error
+}
+
+// caip2Namespaces maps the blockchain plugin in use, to the CAIP-2 namespace used in the blockchainAccountId of
+// DID verification methods. Other blockchains use the name of the plugin as the namespace.
+var caip2Namespaces = map[string]string{
+	"ethereum": "eip155",
 }
 
 type identityManager struct {
-	database   database.Plugin
-	plugin     identity.Plugin
-	blockchain blockchain.Plugin
-
-	localOrgSigningKey string
-	localOrgDID        string
-	identityCacheTTL   time.Duration
-	identityCache      *ccache.Cache
-	signingKeyCacheTTL time.Duration
-	signingKeyCache    *ccache.Cache
+	database      database.Plugin
+	plugin        identity.Plugin
+	blockchain    blockchain.Plugin
+	nsBlockchains map[string]blockchain.Plugin
+
+	localOrgSigningKey     string
+	localOrgDID            string
+	identityCacheTTL       time.Duration
+	identityCache          *ccache.Cache
+	signingKeyCacheTTL     time.Duration
+	signingKeyCache        *ccache.Cache
+	keyRotationGracePeriod time.Duration
+	cryptoPolicies         map[string]*fftypes.CryptoPolicy
 }
 
-func NewIdentityManager(ctx context.Context, di database.Plugin, ii identity.Plugin, bi blockchain.Plugin) (Manager, error) {
+// NewIdentityManager creates the identity manager. Namespaces that are pinned to a ledger other than the default
+// blockchain plugin are listed in nsBlockchains
+func NewIdentityManager(ctx context.Context, di database.Plugin, ii identity.Plugin, bi blockchain.Plugin, nsBlockchains map[string]blockchain.Plugin) (Manager, error) {
 	if di == nil || ii == nil || bi == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 	im := &identityManager{
-		database:           di,
-		plugin:             ii,
-		blockchain:         bi,
-		identityCacheTTL:   config.GetDuration(config.IdentityManagerCacheTTL),
-		signingKeyCacheTTL: config.GetDuration(config.IdentityManagerCacheTTL),
+		database:               di,
+		plugin:                 ii,
+		blockchain:             bi,
+		nsBlockchains:          nsBlockchains,
+		identityCacheTTL:       config.GetDuration(config.IdentityManagerCacheTTL),
+		signingKeyCacheTTL:     config.GetDuration(config.IdentityManagerCacheTTL),
+		keyRotationGracePeriod: config.GetDuration(config.IdentityManagerKeyRotationGracePeriod),
 	}
 	// For the identity and signingkey caches, we just treat them all equally sized and the max items
 	im.identityCache = ccache.New(
@@ -74,6 +91,14 @@ func NewIdentityManager(ctx context.Context, di database.Plugin, ii identity.Plu
 		ccache.Configure().MaxSize(config.GetInt64(config.IdentityManagerCacheLimit)),
 	)
 
+	var err error
+	if im.cryptoPolicies, err = loadCryptoPolicies(ctx); err != nil {
+		return nil, err
+	}
+	if err = im.checkCryptoPolicies(ctx); err != nil {
+		return nil, err
+	}
+
 	return im, nil
 }
 
@@ -108,7 +133,44 @@ func (im *identityManager) ResolveInputIdentity(ctx context.Context, identity *f
 	return
 }
 
+// ResolveDelegatedIdentity resolves the input identity of the submitter, then checks that it administers
+// the onBehalfOf identity (it is an ancestor in the org hierarchy). The identity is then updated to be
+// that of the onBehalfOf identity, including its signing key.
+func (im *identityManager) ResolveDelegatedIdentity(ctx context.Context, identity *fftypes.Identity, onBehalfOf string) (err error) {
+	if err = im.ResolveInputIdentity(ctx, identity); err != nil {
+		return err
+	}
+
+	delegate, err := im.cachedOrgLookupByAuthor(ctx, onBehalfOf)
+	if err != nil {
+		return err
+	}
+
+	// Walk up the parents of the delegate, until we find the submitter (or reach the root)
+	visited := map[string]bool{}
+	for org := delegate; org.Parent != "" && !visited[org.Parent]; {
+		visited[org.Parent] = true
+		if org.Parent == identity.Key {
+			log.L(ctx).Debugf("Identity '%s' acting on behalf of '%s'", identity.Author, onBehalfOf)
+			identity.Author = im.OrgDID(delegate)
+			identity.Key = delegate.Identity
+			return nil
+		}
+		if org, err = im.cachedOrgLookupBySigningKey(ctx, org.Parent, fftypes.Now()); err != nil {
+			return err
+		}
+		if org == nil {
+			break
+		}
+	}
+	return i18n.NewError(ctx, i18n.MsgDelegationNotAuthorized, identity.Author, onBehalfOf)
+}
+
 func (im *identityManager) ResolveSigningKeyIdentity(ctx context.Context, signingKey string) (author string, err error) {
+	return im.resolveSigningKeyIdentity(ctx, signingKey, fftypes.Now())
+}
+
+func (im *identityManager) resolveSigningKeyIdentity(ctx context.Context, signingKey string, at *fftypes.FFTime) (author string, err error) {
 
 	signingKey, err = im.ResolveSigningKey(ctx, signingKey)
 	if err != nil {
@@ -116,7 +178,7 @@ func (im *identityManager) ResolveSigningKeyIdentity(ctx context.Context, signin
 	}
 
 	// TODO: Consider other ways identity could be resolved
-	org, err := im.cachedOrgLookupBySigningKey(ctx, signingKey)
+	org, err := im.cachedOrgLookupBySigningKey(ctx, signingKey, at)
 	if err != nil {
 		return "", err
 	}
@@ -125,6 +187,28 @@ func (im *identityManager) ResolveSigningKeyIdentity(ctx context.Context, signin
 
 }
 
+// ResolveSigningKeyAuthor resolves the author that a signing key belongs to, when verifying data received from the network.
+// Authors identified by a DID of a method other than "firefly" are verified against the verification methods in the
+// DID document returned by the identity plugin. All other authors are resolved from the registered organizations.
+// The previous key of an organization is accepted if the data was pinned within the grace period of the key rotation,
+// based on the timestamp of the blockchain event that pinned it.
+func (im *identityManager) ResolveSigningKeyAuthor(ctx context.Context, author, signingKey string, pinned *fftypes.FFTime) (resolvedAuthor string, err error) {
+	if !isResolvableDID(author) {
+		return im.resolveSigningKeyIdentity(ctx, signingKey, pinned)
+	}
+
+	keys, err := im.cachedDIDSigningKeys(ctx, im.blockchain, author)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range keys {
+		if key == signingKey {
+			return author, nil
+		}
+	}
+	return "", nil
+}
+
 func (im *identityManager) getConfigOrgKey() string {
 	orgKey := config.GetString(config.OrgKey)
 	if orgKey == "" {
@@ -180,21 +264,108 @@ func (im *identityManager) ResolveSigningKey(ctx context.Context, inputKey strin
 	return
 }
 
-func (im *identityManager) cachedOrgLookupBySigningKey(ctx context.Context, signingKey string) (org *fftypes.Organization, err error) {
+// OrgKeyRotated must be called after the signing key of an organization has been rotated, to evict the
+// entries for the organization cached against its previous identity
+func (im *identityManager) OrgKeyRotated(org *fftypes.Organization) {
+	im.identityCache.Delete(fmt.Sprintf("key:%s", org.PreviousIdentity))
+	im.identityCache.Delete(fmt.Sprintf("key:%s", org.Identity))
+	im.identityCache.Delete(fmt.Sprintf("author:%s", im.OrgDID(org)))
+	im.identityCache.Delete(fmt.Sprintf("author:%s", org.Name))
+}
+
+// inKeyRotationGracePeriod checks if the previous signing key of an organization is accepted at a point in time.
+// For data received from the network this is the timestamp of the blockchain event that pinned it, so that every
+// node reaches the same decision, as the time of the rotation is also taken from the blockchain
+func (im *identityManager) inKeyRotationGracePeriod(org *fftypes.Organization, at *fftypes.FFTime) bool {
+	return org.IdentityRotated != nil && at != nil && at.Time().Sub(*org.IdentityRotated.Time()) < im.keyRotationGracePeriod
+}
+
+func (im *identityManager) lookupOrgByPreviousSigningKey(ctx context.Context, signingKey string) (*fftypes.Organization, error) {
+	fb := database.OrganizationQueryFactory.NewFilter(ctx)
+	orgs, _, err := im.database.GetOrganizations(ctx, fb.And(
+		fb.Eq("previousidentity", signingKey),
+	).Sort("identityrotated").Descending().Limit(1))
+	if err != nil || len(orgs) == 0 {
+		return nil, err
+	}
+	return orgs[0], nil
+}
+
+func (im *identityManager) cachedOrgLookupBySigningKey(ctx context.Context, signingKey string, at *fftypes.FFTime) (org *fftypes.Organization, err error) {
 	cacheKey := fmt.Sprintf("key:%s", signingKey)
 	if cached := im.identityCache.Get(cacheKey); cached != nil {
 		cached.Extend(im.identityCacheTTL)
 		org = cached.Value().(*fftypes.Organization)
 	} else {
-		if org, err = im.database.GetOrganizationByIdentity(ctx, signingKey); err != nil || org == nil {
-			return org, err
+		if org, err = im.database.GetOrganizationByIdentity(ctx, signingKey); err != nil {
+			return nil, err
+		}
+		if org == nil {
+			// The key might have been rotated, in which case it is accepted until the grace period ends
+			if org, err = im.lookupOrgByPreviousSigningKey(ctx, signingKey); err != nil || org == nil {
+				return nil, err
+			}
 		}
 		// Cache the result
 		im.identityCache.Set(cacheKey, org, im.identityCacheTTL)
 	}
+	if org.Identity != signingKey && org.PreviousIdentity == signingKey && !im.inKeyRotationGracePeriod(org, at) {
+		log.L(ctx).Warnf("Signing key '%s' of organization '%s' was rotated at %s, and is no longer accepted", signingKey, org.Name, org.IdentityRotated)
+		return nil, nil
+	}
 	return org, nil
 }
 
+func isResolvableDID(author string) bool {
+	return strings.HasPrefix(author, fftypes.DIDPrefix) && !strings.HasPrefix(author, fftypes.FireflyDIDPrefix)
+}
+
+// blockchainFor returns the plugin of the ledger the namespace is pinned to
+func (im *identityManager) blockchainFor(ns string) blockchain.Plugin {
+	if bi, ok := im.nsBlockchains[ns]; ok {
+		return bi
+	}
+	return im.blockchain
+}
+
+// cachedDIDSigningKeys resolves the DID document for a DID, and returns the signing keys it declares for the given blockchain
+func (im *identityManager) cachedDIDSigningKeys(ctx context.Context, bi blockchain.Plugin, did string) (keys []string, err error) {
+	cacheKey := fmt.Sprintf("did:%s:%s", bi.Name(), did)
+	if cached := im.identityCache.Get(cacheKey); cached != nil {
+		cached.Extend(im.identityCacheTTL)
+		return cached.Value().([]string), nil
+	}
+
+	doc, err := im.plugin.ResolveDID(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.ID != did {
+		log.L(ctx).Warnf("DID '%s' could not be resolved by identity plugin '%s'", did, im.plugin.Name())
+		return nil, nil
+	}
+
+	namespace, ok := caip2Namespaces[bi.Name()]
+	if !ok {
+		namespace = bi.Name()
+	}
+	for _, vm := range doc.VerificationMethods {
+		// CAIP-10 account IDs are of the form namespace:reference:address
+		parts := strings.SplitN(vm.BlockchainAccountID, ":", 3)
+		if len(parts) != 3 || parts[0] != namespace {
+			continue
+		}
+		key, err := bi.ResolveSigningKey(ctx, parts[2])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	im.identityCache.Set(cacheKey, keys, im.identityCacheTTL)
+	return keys, nil
+}
+
 func (im *identityManager) cachedOrgLookupByAuthor(ctx context.Context, author string) (org *fftypes.Organization, err error) {
 	// Use an LRU cache for the author identity, as it's likely for the same identity to be re-used over and over
 	cacheKey := fmt.Sprintf("author:%s", author)
@@ -231,8 +402,32 @@ func (im *identityManager) cachedOrgLookupByAuthor(ctx context.Context, author s
 	return org, nil
 }
 
+func (im *identityManager) resolveInputDIDAuthor(ctx context.Context, identity *fftypes.Identity) error {
+	keys, err := im.cachedDIDSigningKeys(ctx, im.blockchain, identity.Author)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return i18n.NewError(ctx, i18n.MsgAuthorNotFoundByDID, identity.Author)
+	}
+	if identity.Key == "" {
+		identity.Key = keys[0]
+		return nil
+	}
+	for _, key := range keys {
+		if key == identity.Key {
+			return nil
+		}
+	}
+	return i18n.NewError(ctx, i18n.MsgDIDSigningKeyMismatch, identity.Key, identity.Author)
+}
+
 func (im *identityManager) resolveInputAuthor(ctx context.Context, identity *fftypes.Identity) (err error) {
 
+	if isResolvableDID(identity.Author) {
+		return im.resolveInputDIDAuthor(ctx, identity)
+	}
+
 	var org *fftypes.Organization
 	if identity.Author == "" {
 		// We allow lookup of an org by signing key (this convenience mechanism is currently not cached)
@@ -257,7 +452,7 @@ func (im *identityManager) resolveInputAuthor(ctx context.Context, identity *fft
 	//       indirection is needed in front of orgs (likely it is).
 	if identity.Key == "" {
 		identity.Key = org.Identity
-	} else if org.Identity != identity.Key {
+	} else if org.Identity != identity.Key && (org.PreviousIdentity != identity.Key || !im.inKeyRotationGracePeriod(org, fftypes.Now())) {
 		return i18n.NewError(ctx, i18n.MsgAuthorOrgSigningKeyMismatch, org.ID, identity.Key)
 	}
 
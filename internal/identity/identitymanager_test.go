@@ -20,13 +20,16 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/identitymocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/identity"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func newTestIdentityManager(t *testing.T) (context.Context, *identityManager) {
@@ -38,13 +41,13 @@ func newTestIdentityManager(t *testing.T) (context.Context, *identityManager) {
 	config.Reset()
 
 	ctx := context.Background()
-	im, err := NewIdentityManager(ctx, mdi, mii, mbi)
+	im, err := NewIdentityManager(ctx, mdi, mii, mbi, nil)
 	assert.NoError(t, err)
 	return ctx, im.(*identityManager)
 }
 
 func TestNewIdentityManagerMissingDeps(t *testing.T) {
-	_, err := NewIdentityManager(context.Background(), nil, nil, nil)
+	_, err := NewIdentityManager(context.Background(), nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
 
@@ -213,6 +216,41 @@ func TestResolveInputIdentityOrgKeyMismatch(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestResolveInputIdentityOrgPreviousKey(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "org1key",
+		Author: "org1",
+	}
+	org := &fftypes.Organization{
+		ID:               fftypes.NewUUID(),
+		Name:             "org1",
+		Identity:         "0x222222",
+		PreviousIdentity: "0x111111",
+		IdentityRotated:  fftypes.Now(),
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "org1key").Return("0x111111", nil).Once()
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org, nil).Once()
+
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x111111", identity.Key)
+
+	// Rejected once the grace period has passed
+	im.keyRotationGracePeriod = 0
+	identity.Key = "org1key"
+	identity.Author = "org1"
+	err = im.ResolveInputIdentity(ctx, identity)
+	assert.Regexp(t, "FF10279", err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
 func TestResolveInputIdentityResolveKeyFail(t *testing.T) {
 
 	identity := &fftypes.Identity{
@@ -358,6 +396,7 @@ func TestResolveSigningKeyIdentityOrgLookupUnresolved(t *testing.T) {
 	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil)
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetOrganizations", ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
 
 	author, err := im.ResolveSigningKeyIdentity(ctx, "key1")
 	assert.NoError(t, err)
@@ -366,6 +405,113 @@ func TestResolveSigningKeyIdentityOrgLookupUnresolved(t *testing.T) {
 	mbi.AssertExpectations(t)
 }
 
+func TestResolveSigningKeyIdentityRotatedKeyInGracePeriod(t *testing.T) {
+
+	org := &fftypes.Organization{
+		ID:               fftypes.NewUUID(),
+		Identity:         "key2resolved",
+		PreviousIdentity: "key1resolved",
+		IdentityRotated:  fftypes.Now(),
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil).Once()
+	mdi.On("GetOrganizations", ctx, mock.Anything).Return([]*fftypes.Organization{org}, nil, nil).Once()
+
+	author, err := im.ResolveSigningKeyIdentity(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, im.OrgDID(org), author)
+
+	// Cached second time, but no longer accepted once the grace period has passed
+	im.keyRotationGracePeriod = 0
+	author, err = im.ResolveSigningKeyIdentity(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "", author)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyAuthorRotatedKeyPinned(t *testing.T) {
+
+	rotated := fftypes.Now()
+	org := &fftypes.Organization{
+		ID:               fftypes.NewUUID(),
+		Identity:         "key2resolved",
+		PreviousIdentity: "key1resolved",
+		IdentityRotated:  rotated,
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	im.keyRotationGracePeriod = 1 * time.Hour
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "key1resolved").Return("key1resolved", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil).Once()
+	mdi.On("GetOrganizations", ctx, mock.Anything).Return([]*fftypes.Organization{org}, nil, nil).Once()
+
+	// Pinned before the rotation, and within the grace period after it, the previous key is accepted
+	pinnedBefore := fftypes.FFTime(rotated.Time().Add(-2 * time.Hour))
+	author, err := im.ResolveSigningKeyAuthor(ctx, "org1", "key1resolved", &pinnedBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, im.OrgDID(org), author)
+	pinnedWithin := fftypes.FFTime(rotated.Time().Add(30 * time.Minute))
+	author, err = im.ResolveSigningKeyAuthor(ctx, "org1", "key1resolved", &pinnedWithin)
+	assert.NoError(t, err)
+	assert.Equal(t, im.OrgDID(org), author)
+
+	// Pinned after the grace period, or with no pin time, the previous key is rejected
+	pinnedAfter := fftypes.FFTime(rotated.Time().Add(2 * time.Hour))
+	author, err = im.ResolveSigningKeyAuthor(ctx, "org1", "key1resolved", &pinnedAfter)
+	assert.NoError(t, err)
+	assert.Equal(t, "", author)
+	author, err = im.ResolveSigningKeyAuthor(ctx, "org1", "key1resolved", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", author)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyIdentityRotatedKeyLookupFail(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetOrganizations", ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := im.ResolveSigningKeyIdentity(ctx, "key1")
+	assert.Regexp(t, "pop", err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestOrgKeyRotated(t *testing.T) {
+
+	org := &fftypes.Organization{
+		ID:               fftypes.NewUUID(),
+		Name:             "org1",
+		Identity:         "key2",
+		PreviousIdentity: "key1",
+	}
+
+	_, im := newTestIdentityManager(t)
+	im.identityCache.Set("key:key1", org, im.identityCacheTTL)
+	im.identityCache.Set("author:org1", org, im.identityCacheTTL)
+	im.identityCache.Set("author:"+im.OrgDID(org), org, im.identityCacheTTL)
+
+	im.OrgKeyRotated(org)
+	assert.Nil(t, im.identityCache.Get("key:key1"))
+	assert.Nil(t, im.identityCache.Get("author:org1"))
+	assert.Nil(t, im.identityCache.Get("author:"+im.OrgDID(org)))
+}
+
 func TestGetLocalOrgKey(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
@@ -461,6 +607,7 @@ func TestResolveLocalOrgDIDNotFound(t *testing.T) {
 	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil).Once()
+	mdi.On("GetOrganizations", ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil).Once()
 
 	config.Set(config.OrgIdentityDeprecated, "key1")
 
@@ -470,3 +617,377 @@ func TestResolveLocalOrgDIDNotFound(t *testing.T) {
 	mbi.AssertExpectations(t)
 
 }
+
+func TestResolveDelegatedIdentityGrandchild(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "0x111111",
+		Author: "org1",
+	}
+	org1 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x111111"}
+	org2 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org2", Identity: "0x222222", Parent: "0x111111"}
+	org3 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org3", Identity: "0x333333", Parent: "0x222222"}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0x111111").Return("0x111111", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org1, nil)
+	mdi.On("GetOrganizationByName", ctx, "org3").Return(org3, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "0x222222").Return(org2, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, identity, "org3")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x333333", identity.Key)
+	assert.Equal(t, fmt.Sprintf("did:firefly:org/%s", org3.ID), identity.Author)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityNotAuthorized(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "0x111111",
+		Author: "org1",
+	}
+	org1 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x111111"}
+	org2 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org2", Identity: "0x222222"}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0x111111").Return("0x111111", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org1, nil)
+	mdi.On("GetOrganizationByName", ctx, "org2").Return(org2, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, identity, "org2")
+	assert.Regexp(t, "FF10362", err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityParentLoop(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "0x111111",
+		Author: "org1",
+	}
+	org1 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x111111"}
+	org2 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org2", Identity: "0x222222", Parent: "0x333333"}
+	org3 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org3", Identity: "0x333333", Parent: "0x333333"}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0x111111").Return("0x111111", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org1, nil)
+	mdi.On("GetOrganizationByName", ctx, "org2").Return(org2, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "0x333333").Return(org3, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, identity, "org2")
+	assert.Regexp(t, "FF10362", err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityParentNotFound(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "0x111111",
+		Author: "org1",
+	}
+	org1 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x111111"}
+	org2 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org2", Identity: "0x222222", Parent: "0x333333"}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0x111111").Return("0x111111", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org1, nil)
+	mdi.On("GetOrganizationByName", ctx, "org2").Return(org2, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "0x333333").Return(nil, nil)
+	mdi.On("GetOrganizations", ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, identity, "org2")
+	assert.Regexp(t, "FF10362", err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityParentLookupFail(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "0x111111",
+		Author: "org1",
+	}
+	org1 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x111111"}
+	org2 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org2", Identity: "0x222222", Parent: "0x333333"}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0x111111").Return("0x111111", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org1, nil)
+	mdi.On("GetOrganizationByName", ctx, "org2").Return(org2, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "0x333333").Return(nil, fmt.Errorf("pop"))
+
+	err := im.ResolveDelegatedIdentity(ctx, identity, "org2")
+	assert.EqualError(t, err, "pop")
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityDelegateLookupFail(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "0x111111",
+		Author: "org1",
+	}
+	org1 := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x111111"}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0x111111").Return("0x111111", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org1, nil)
+	mdi.On("GetOrganizationByName", ctx, "org2").Return(nil, fmt.Errorf("pop"))
+
+	err := im.ResolveDelegatedIdentity(ctx, identity, "org2")
+	assert.EqualError(t, err, "pop")
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentitySubmitterFail(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0x111111").Return("", fmt.Errorf("pop"))
+
+	err := im.ResolveDelegatedIdentity(ctx, &fftypes.Identity{Key: "0x111111"}, "org2")
+	assert.EqualError(t, err, "pop")
+
+	mbi.AssertExpectations(t)
+}
+
+func testDIDDocument(did string) *identity.DIDDocument {
+	return &identity.DIDDocument{
+		ID: did,
+		VerificationMethods: []*identity.VerificationMethod{
+			{ID: did + "#key1", BlockchainAccountID: "eip155:1:0xABCDE"},
+			{ID: did + "#key2", BlockchainAccountID: "fabric:net1:user1"},
+			{ID: did + "#key3", BlockchainAccountID: "eip155:1"},
+			{ID: did + "#key4"},
+		},
+	}
+}
+
+func TestResolveSigningKeyAuthorFireflyDID(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "0x12345").Return("0x12345", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x12345"}
+	mdi.On("GetOrganizationByIdentity", ctx, "0x12345").Return(org, nil)
+
+	author, err := im.ResolveSigningKeyAuthor(ctx, org.GetDID(), "0x12345", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, org.GetDID(), author)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyAuthorDIDOk(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(testDIDDocument("did:ethr:0xabcde"), nil).Once()
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+	mbi.On("ResolveSigningKey", ctx, "0xABCDE").Return("0xabcde", nil)
+
+	author, err := im.ResolveSigningKeyAuthor(ctx, "did:ethr:0xabcde", "0xabcde", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "did:ethr:0xabcde", author)
+
+	// Cached result (note once above)
+	author, err = im.ResolveSigningKeyAuthor(ctx, "did:ethr:0xabcde", "0x12345", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, author)
+
+	mii.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyAuthorDIDOtherBlockchain(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:example:1234").Return(testDIDDocument("did:example:1234"), nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("fabric")
+	mbi.On("ResolveSigningKey", ctx, "user1").Return("user1", nil)
+
+	author, err := im.ResolveSigningKeyAuthor(ctx, "did:example:1234", "user1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "did:example:1234", author)
+
+	mii.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyAuthorDIDNotFound(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(nil, nil)
+	mii.On("Name").Return("did")
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+
+	author, err := im.ResolveSigningKeyAuthor(ctx, "did:ethr:0xabcde", "0xabcde", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, author)
+
+	mii.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyAuthorDIDDocumentMismatch(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(testDIDDocument("did:ethr:0x12345"), nil)
+	mii.On("Name").Return("did")
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+
+	author, err := im.ResolveSigningKeyAuthor(ctx, "did:ethr:0xabcde", "0xabcde", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, author)
+
+	mii.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyAuthorDIDResolveFail(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(nil, fmt.Errorf("pop"))
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+
+	_, err := im.ResolveSigningKeyAuthor(ctx, "did:ethr:0xabcde", "0xabcde", nil)
+	assert.EqualError(t, err, "pop")
+
+	mii.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyAuthorDIDBadKey(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(testDIDDocument("did:ethr:0xabcde"), nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+	mbi.On("ResolveSigningKey", ctx, "0xABCDE").Return("", fmt.Errorf("pop"))
+
+	_, err := im.ResolveSigningKeyAuthor(ctx, "did:ethr:0xabcde", "0xabcde", nil)
+	assert.EqualError(t, err, "pop")
+
+	mii.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityDIDDefaultKey(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(testDIDDocument("did:ethr:0xabcde"), nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+	mbi.On("ResolveSigningKey", ctx, "0xABCDE").Return("0xabcde", nil)
+
+	identity := &fftypes.Identity{Author: "did:ethr:0xabcde"}
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.NoError(t, err)
+	assert.Equal(t, "did:ethr:0xabcde", identity.Author)
+	assert.Equal(t, "0xabcde", identity.Key)
+
+	mii.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityDIDMatchingKey(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(testDIDDocument("did:ethr:0xabcde"), nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+	mbi.On("ResolveSigningKey", ctx, "0xABCDE").Return("0xabcde", nil)
+
+	identity := &fftypes.Identity{Author: "did:ethr:0xabcde", Key: "0xABCDE"}
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xabcde", identity.Key)
+
+	mii.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityDIDKeyMismatch(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(testDIDDocument("did:ethr:0xabcde"), nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+	mbi.On("ResolveSigningKey", ctx, "0xABCDE").Return("0xabcde", nil)
+	mbi.On("ResolveSigningKey", ctx, "0x12345").Return("0x12345", nil)
+
+	identity := &fftypes.Identity{Author: "did:ethr:0xabcde", Key: "0x12345"}
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.Regexp(t, "FF10454", err)
+
+	mii.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityDIDNotFound(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(nil, nil)
+	mii.On("Name").Return("did")
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+
+	identity := &fftypes.Identity{Author: "did:ethr:0xabcde"}
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.Regexp(t, "FF10277", err)
+
+	mii.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityDIDResolveFail(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mii := im.plugin.(*identitymocks.Plugin)
+	mii.On("ResolveDID", ctx, "did:ethr:0xabcde").Return(nil, fmt.Errorf("pop"))
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("Name").Return("ethereum")
+
+	identity := &fftypes.Identity{Author: "did:ethr:0xabcde"}
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.EqualError(t, err, "pop")
+
+	mii.AssertExpectations(t)
+}
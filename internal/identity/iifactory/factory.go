@@ -21,13 +21,15 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/identity/didresolver"
 	"github.com/hyperledger/firefly/internal/identity/tbd"
 	"github.com/hyperledger/firefly/pkg/identity"
 )
 
 var pluginsByName = map[string]func() identity.Plugin{
 	// Plugin interface is TBD at this point. Plugin with "onchain" naming, and TBD implementation provided to avoid config migration impact
-	(*tbd.TBD)(nil).Name(): func() identity.Plugin { return &tbd.TBD{} },
+	(*tbd.TBD)(nil).Name():                 func() identity.Plugin { return &tbd.TBD{} },
+	(*didresolver.DIDResolver)(nil).Name(): func() identity.Plugin { return &didresolver.DIDResolver{} },
 }
 
 func InitPrefix(prefix config.Prefix) {
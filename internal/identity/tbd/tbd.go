@@ -46,3 +46,7 @@ func (tbd *TBD) Start() error {
 func (tbd *TBD) Capabilities() *identity.Capabilities {
 	return tbd.capabilities
 }
+
+func (tbd *TBD) ResolveDID(ctx context.Context, did string) (*identity.DIDDocument, error) {
+	return nil, nil
+}
@@ -38,4 +38,7 @@ func TestInit(t *testing.T) {
 	assert.NoError(t, err)
 	capabilities := oc.Capabilities()
 	assert.NotNil(t, capabilities)
+	doc, err := oc.ResolveDID(context.Background(), "did:example:123")
+	assert.NoError(t, err)
+	assert.Nil(t, doc)
 }
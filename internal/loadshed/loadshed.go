@@ -0,0 +1,178 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadshed
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
+	"github.com/hyperledger/firefly/internal/log"
+)
+
+// Level is the degree to which load is being shed. Each level sheds the load of all the levels below it,
+// so under sustained database pressure the node degrades in a defined order rather than all at once.
+type Level int
+
+const (
+	// LevelNone is normal operation
+	LevelNone Level = iota
+	// LevelPauseBulkQueries rejects API queries that list collections
+	LevelPauseBulkQueries
+	// LevelSlowDispatch delays the database calls made delivering events to subscriptions
+	LevelSlowDispatch
+	// LevelRejectSends rejects all API requests that submit new work
+	LevelRejectSends
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelPauseBulkQueries:
+		return "pause_bulk_queries"
+	case LevelSlowDispatch:
+		return "slow_dispatch"
+	case LevelRejectSends:
+		return "reject_sends"
+	default:
+		return "none"
+	}
+}
+
+// Monitor observes the latency and errors of database calls, and decides the level of load shedding
+type Monitor interface {
+	Observe(elapsed time.Duration, err error)
+	Level() Level
+	RetryAfter() time.Duration
+}
+
+type monitor struct {
+	ctx                context.Context
+	eventBus           eventbus.Bus
+	enabled            bool
+	window             time.Duration
+	latencyThreshold   time.Duration
+	errorRateThreshold float64
+	retryAfter         time.Duration
+	now                func() time.Time
+
+	mux          sync.Mutex
+	level        Level
+	windowStart  time.Time
+	count        int64
+	errors       int64
+	totalLatency time.Duration
+}
+
+// NewMonitor creates a monitor configured from the loadShedding section of the config.
+// When load shedding is disabled, the level is always LevelNone.
+func NewMonitor(ctx context.Context, eb eventbus.Bus) Monitor {
+	m := &monitor{
+		ctx:                ctx,
+		eventBus:           eb,
+		enabled:            config.GetBool(config.LoadSheddingEnabled),
+		window:             config.GetDuration(config.LoadSheddingWindow),
+		latencyThreshold:   config.GetDuration(config.LoadSheddingLatencyThreshold),
+		errorRateThreshold: config.GetFloat64(config.LoadSheddingErrorRateThreshold),
+		retryAfter:         config.GetDuration(config.LoadSheddingRetryAfter),
+		now:                time.Now,
+	}
+	m.windowStart = m.now()
+	return m
+}
+
+func (m *monitor) Observe(elapsed time.Duration, err error) {
+	if !m.enabled {
+		return
+	}
+	m.mux.Lock()
+	// Close any elapsed window first, so the observation counts towards the window it was made in
+	changed, level := m.evaluateLocked()
+	m.count++
+	m.totalLatency += elapsed
+	if err != nil {
+		m.errors++
+	}
+	m.mux.Unlock()
+	if changed {
+		m.notify(level)
+	}
+}
+
+func (m *monitor) Level() Level {
+	if !m.enabled {
+		return LevelNone
+	}
+	m.mux.Lock()
+	changed, level := m.evaluateLocked()
+	m.mux.Unlock()
+	if changed {
+		m.notify(level)
+	}
+	return level
+}
+
+func (m *monitor) RetryAfter() time.Duration {
+	return m.retryAfter
+}
+
+// evaluateLocked closes the current window if it has elapsed, and moves the level towards the one
+// indicated by the pressure measured in that window. Escalation can jump straight to the indicated
+// level, but recovery steps down one level per window so a brief lull does not release all load at once.
+func (m *monitor) evaluateLocked() (changed bool, level Level) {
+	now := m.now()
+	if now.Sub(m.windowStart) < m.window {
+		return false, m.level
+	}
+
+	target := LevelNone
+	if m.count > 0 {
+		ratio := 0.0
+		if m.latencyThreshold > 0 {
+			ratio = float64(m.totalLatency/time.Duration(m.count)) / float64(m.latencyThreshold)
+		}
+		if m.errorRateThreshold > 0 {
+			if errRatio := float64(m.errors) / float64(m.count) / m.errorRateThreshold; errRatio > ratio {
+				ratio = errRatio
+			}
+		}
+		if ratio >= 1 {
+			target = LevelRejectSends
+			if ratio < float64(LevelRejectSends) {
+				target = Level(ratio)
+			}
+		}
+	}
+
+	m.windowStart = now
+	m.count = 0
+	m.errors = 0
+	m.totalLatency = 0
+
+	if target < m.level-1 {
+		target = m.level - 1
+	}
+	changed = target != m.level
+	m.level = target
+	return changed, target
+}
+
+func (m *monitor) notify(level Level) {
+	log.L(m.ctx).Warnf("Database load shedding level changed to '%s'", level)
+	m.eventBus.Publish(eventbus.TopicLoadSheddingChanged, level)
+}
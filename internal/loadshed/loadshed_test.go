@@ -0,0 +1,117 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadshed
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMonitor() (*monitor, *time.Time, *[]Level) {
+	config.Reset()
+	config.Set(config.LoadSheddingEnabled, true)
+	eb := eventbus.NewBus()
+	changes := []Level{}
+	eb.Subscribe(eventbus.TopicLoadSheddingChanged, func(payload interface{}) {
+		changes = append(changes, payload.(Level))
+	})
+	m := NewMonitor(context.Background(), eb).(*monitor)
+	now := m.windowStart
+	m.now = func() time.Time { return now }
+	return m, &now, &changes
+}
+
+func TestLevelString(t *testing.T) {
+	assert.Equal(t, "none", LevelNone.String())
+	assert.Equal(t, "pause_bulk_queries", LevelPauseBulkQueries.String())
+	assert.Equal(t, "slow_dispatch", LevelSlowDispatch.String())
+	assert.Equal(t, "reject_sends", LevelRejectSends.String())
+}
+
+func TestDisabled(t *testing.T) {
+	config.Reset()
+	m := NewMonitor(context.Background(), eventbus.NewBus())
+	m.Observe(1*time.Hour, fmt.Errorf("pop"))
+	assert.Equal(t, LevelNone, m.Level())
+	assert.Equal(t, 30*time.Second, m.RetryAfter())
+}
+
+func TestLatencyEscalatesAndRecovers(t *testing.T) {
+	m, now, changes := newTestMonitor()
+
+	// Pressure within the window has no effect until the window closes
+	m.Observe(2*time.Second, nil)
+	assert.Equal(t, LevelNone, m.Level())
+
+	// An average latency of 4x the threshold sheds everything
+	*now = now.Add(10 * time.Second)
+	m.Observe(2*time.Second, nil)
+	assert.Equal(t, LevelRejectSends, m.Level())
+
+	// Recovery steps down one level per window
+	*now = now.Add(10 * time.Second)
+	m.Observe(1*time.Millisecond, nil)
+	assert.Equal(t, LevelRejectSends, m.Level())
+	*now = now.Add(10 * time.Second)
+	assert.Equal(t, LevelSlowDispatch, m.Level())
+	*now = now.Add(10 * time.Second)
+	assert.Equal(t, LevelPauseBulkQueries, m.Level())
+	*now = now.Add(10 * time.Second)
+	assert.Equal(t, LevelNone, m.Level())
+
+	assert.Equal(t, []Level{LevelRejectSends, LevelSlowDispatch, LevelPauseBulkQueries, LevelNone}, *changes)
+}
+
+func TestErrorRateEscalates(t *testing.T) {
+	m, now, changes := newTestMonitor()
+
+	// 2 failures in 10 calls is twice the default threshold
+	for i := 0; i < 10; i++ {
+		var err error
+		if i < 2 {
+			err = fmt.Errorf("pop")
+		}
+		m.Observe(1*time.Millisecond, err)
+	}
+	*now = now.Add(10 * time.Second)
+	assert.Equal(t, LevelSlowDispatch, m.Level())
+	assert.Equal(t, []Level{LevelSlowDispatch}, *changes)
+}
+
+func TestLatencyBelowThreshold(t *testing.T) {
+	m, now, changes := newTestMonitor()
+	m.Observe(100*time.Millisecond, nil)
+	*now = now.Add(10 * time.Second)
+	assert.Equal(t, LevelNone, m.Level())
+	assert.Empty(t, *changes)
+}
+
+func TestThresholdsDisabled(t *testing.T) {
+	m, now, changes := newTestMonitor()
+	m.latencyThreshold = 0
+	m.errorRateThreshold = 0
+	m.Observe(1*time.Hour, fmt.Errorf("pop"))
+	*now = now.Add(10 * time.Second)
+	assert.Equal(t, LevelNone, m.Level())
+	assert.Empty(t, *changes)
+}
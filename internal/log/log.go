@@ -19,6 +19,7 @@ package log
 import (
 	"context"
 	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
@@ -35,6 +36,23 @@ type (
 	ctxLogKey struct{}
 )
 
+// RequestIDField is the log field that holds the ID of the API request an entry was written for,
+// which is forwarded to the other components of the stack so their logs can be correlated
+const RequestIDField = "httpreq"
+
+// NamespaceField is the log field that holds the namespace an entry was written for, which is also used
+// to break out the metrics of calls made on behalf of the namespace
+const NamespaceField = "ns"
+
+// ModuleField is the log field that identifies the subsystem that wrote an entry, for per-module log levels
+const ModuleField = "module"
+
+var (
+	levelsMux    sync.RWMutex
+	rootLevel    = logrus.InfoLevel
+	moduleLevels = map[string]logrus.Level{}
+)
+
 // WithLogger adds the specified logger to the context
 func WithLogger(ctx context.Context, logger *logrus.Entry) context.Context {
 	return context.WithValue(ctx, ctxLogKey{}, logger)
@@ -48,6 +66,18 @@ func WithLogField(ctx context.Context, key, value string) context.Context {
 	return WithLogger(ctx, loggerFromContext(ctx).WithField(key, value))
 }
 
+// WithModule tags the logger in the context with the module it belongs to, so the level of the
+// module can be set independently of the rest of the node
+func WithModule(ctx context.Context, module string) context.Context {
+	return WithLogField(ctx, ModuleField, module)
+}
+
+// GetField returns the value of a field of the logger in the context, or an empty string if it is not set
+func GetField(ctx context.Context, key string) string {
+	value, _ := loggerFromContext(ctx).Data[key].(string)
+	return value
+}
+
 // LoggerFromContext returns the logger for the current context, or no logger if there is no context
 func loggerFromContext(ctx context.Context) *logrus.Entry {
 	logger := ctx.Value(ctxLogKey{})
@@ -57,24 +87,106 @@ func loggerFromContext(ctx context.Context) *logrus.Entry {
 	return logger.(*logrus.Entry)
 }
 
-func SetLevel(level string) {
+// ParseLevel parses the name of a log level, returning false if it is not a known level
+func ParseLevel(level string) (logrus.Level, bool) {
 	switch strings.ToLower(level) {
 	case "error":
-		logrus.SetLevel(logrus.ErrorLevel)
+		return logrus.ErrorLevel, true
+	case "warn":
+		return logrus.WarnLevel, true
+	case "info":
+		return logrus.InfoLevel, true
 	case "debug":
-		logrus.SetLevel(logrus.DebugLevel)
+		return logrus.DebugLevel, true
 	case "trace":
-		logrus.SetLevel(logrus.TraceLevel)
+		return logrus.TraceLevel, true
 	default:
-		logrus.SetLevel(logrus.InfoLevel)
+		return logrus.InfoLevel, false
 	}
 }
 
+// SetLevel sets the level for all modules that do not have their own level
+func SetLevel(level string) {
+	levelsMux.Lock()
+	defer levelsMux.Unlock()
+	rootLevel, _ = ParseLevel(level)
+	applyLevelsLocked()
+}
+
+// SetModuleLevel sets the level for a single module, or removes the override for the module if the level is empty
+func SetModuleLevel(module, level string) {
+	levelsMux.Lock()
+	defer levelsMux.Unlock()
+	if level == "" {
+		delete(moduleLevels, module)
+	} else {
+		moduleLevels[module], _ = ParseLevel(level)
+	}
+	applyLevelsLocked()
+}
+
+// ResetModuleLevels removes all the module level overrides
+func ResetModuleLevels() {
+	levelsMux.Lock()
+	defer levelsMux.Unlock()
+	moduleLevels = map[string]logrus.Level{}
+	applyLevelsLocked()
+}
+
+// GetLevel returns the level for modules that do not have their own level
+func GetLevel() string {
+	levelsMux.RLock()
+	defer levelsMux.RUnlock()
+	return rootLevel.String()
+}
+
+// GetModuleLevels returns the level of each module that has been set independently
+func GetModuleLevels() map[string]string {
+	levelsMux.RLock()
+	defer levelsMux.RUnlock()
+	levels := make(map[string]string, len(moduleLevels))
+	for module, level := range moduleLevels {
+		levels[module] = level.String()
+	}
+	return levels
+}
+
+// applyLevelsLocked sets the logrus level to the most verbose of the configured levels, so that entries
+// for modules with a more verbose level are written. The module filter on the formatter then discards the
+// entries of all other modules that are more verbose than their level.
+func applyLevelsLocked() {
+	level := rootLevel
+	for _, moduleLevel := range moduleLevels {
+		if moduleLevel > level {
+			level = moduleLevel
+		}
+	}
+	logrus.SetLevel(level)
+}
+
+// filtered returns true if the entry is more verbose than the level of its module
+func filtered(e *logrus.Entry) bool {
+	levelsMux.RLock()
+	defer levelsMux.RUnlock()
+	if len(moduleLevels) == 0 {
+		// No overrides, so the logrus level is the root level
+		return false
+	}
+	level := rootLevel
+	if module, ok := e.Data[ModuleField].(string); ok {
+		if moduleLevel, ok := moduleLevels[module]; ok {
+			level = moduleLevel
+		}
+	}
+	return e.Level > level
+}
+
 type Formatting struct {
 	DisableColor    bool
 	ForceColor      bool
 	TimestampFormat string
 	UTC             bool
+	JSON            bool
 }
 
 type utcFormat struct {
@@ -86,17 +198,36 @@ func (utc *utcFormat) Format(e *logrus.Entry) ([]byte, error) {
 	return utc.f.Format(e)
 }
 
+// moduleFilter discards the entries that are more verbose than the level of their module
+type moduleFilter struct {
+	f logrus.Formatter
+}
+
+func (mf *moduleFilter) Format(e *logrus.Entry) ([]byte, error) {
+	if filtered(e) {
+		return nil, nil
+	}
+	return mf.f.Format(e)
+}
+
 func SetFormatting(format Formatting) {
-	var formatter logrus.Formatter = &prefixed.TextFormatter{
-		DisableColors:   format.DisableColor,
-		ForceColors:     format.ForceColor,
-		TimestampFormat: format.TimestampFormat,
-		DisableSorting:  false,
-		ForceFormatting: true,
-		FullTimestamp:   true,
+	var formatter logrus.Formatter
+	if format.JSON {
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: format.TimestampFormat,
+		}
+	} else {
+		formatter = &prefixed.TextFormatter{
+			DisableColors:   format.DisableColor,
+			ForceColors:     format.ForceColor,
+			TimestampFormat: format.TimestampFormat,
+			DisableSorting:  false,
+			ForceFormatting: true,
+			FullTimestamp:   true,
+		}
 	}
 	if format.UTC {
 		formatter = &utcFormat{f: formatter}
 	}
-	logrus.SetFormatter(formatter)
+	logrus.SetFormatter(&moduleFilter{f: formatter})
 }
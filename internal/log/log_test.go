@@ -17,7 +17,10 @@
 package log
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"os"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -29,6 +32,13 @@ func TestLogContext(t *testing.T) {
 	assert.Equal(t, "myvalue", L(ctx).Data["myfield"])
 }
 
+func TestGetField(t *testing.T) {
+	ctx := WithLogField(context.Background(), RequestIDField, "req1")
+	assert.Equal(t, "req1", GetField(ctx, RequestIDField))
+	assert.Equal(t, "", GetField(ctx, "other"))
+	assert.Equal(t, "", GetField(context.Background(), RequestIDField))
+}
+
 func TestLogContextLimited(t *testing.T) {
 	ctx := WithLogField(context.Background(), "myfield", "0123456789012345678901234567890123456789012345678901234567890123456789")
 	assert.Equal(t, "0123456789012345678901234567890123456789012345678901234567890...", L(ctx).Data["myfield"])
@@ -66,3 +76,74 @@ func TestSetFormatting(t *testing.T) {
 	})
 	L(context.Background()).Infof("time in UTC")
 }
+
+func TestSettingWarnLevel(t *testing.T) {
+	SetLevel("warn")
+	assert.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+	assert.Equal(t, "warning", GetLevel())
+}
+
+func TestParseLevel(t *testing.T) {
+	level, ok := ParseLevel("Debug")
+	assert.True(t, ok)
+	assert.Equal(t, logrus.DebugLevel, level)
+	_, ok = ParseLevel("verbose")
+	assert.False(t, ok)
+}
+
+func TestJSONFormatting(t *testing.T) {
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	defer logrus.SetOutput(os.Stderr)
+	SetLevel("info")
+	SetFormatting(Formatting{JSON: true})
+	defer SetFormatting(Formatting{})
+
+	L(WithLogField(context.Background(), "myfield", "myvalue")).Infof("hello")
+	var entry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", entry["msg"])
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "myvalue", entry["myfield"])
+}
+
+func TestModuleLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	defer logrus.SetOutput(os.Stderr)
+	SetFormatting(Formatting{JSON: true})
+	defer SetFormatting(Formatting{})
+	SetLevel("info")
+	defer ResetModuleLevels()
+
+	SetModuleLevel("aggregator", "trace")
+	SetModuleLevel("batch", "error")
+	assert.Equal(t, logrus.TraceLevel, logrus.GetLevel())
+	assert.Equal(t, "info", GetLevel())
+	assert.Equal(t, map[string]string{"aggregator": "trace", "batch": "error"}, GetModuleLevels())
+
+	aggCtx := WithModule(context.Background(), "aggregator")
+	batchCtx := WithModule(context.Background(), "batch")
+	L(aggCtx).Tracef("agg trace")
+	L(batchCtx).Infof("batch info")
+	L(batchCtx).Errorf("batch error")
+	L(context.Background()).Debugf("root debug")
+	L(context.Background()).Infof("root info")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	msgs := make([]string, len(lines))
+	for i, line := range lines {
+		var entry map[string]interface{}
+		err := json.Unmarshal(line, &entry)
+		assert.NoError(t, err)
+		msgs[i] = entry["msg"].(string)
+	}
+	assert.Equal(t, []string{"agg trace", "batch error", "root info"}, msgs)
+
+	SetModuleLevel("aggregator", "")
+	assert.Equal(t, map[string]string{"batch": "error"}, GetModuleLevels())
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	ResetModuleLevels()
+	assert.Empty(t, GetModuleLevels())
+}
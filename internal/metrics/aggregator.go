@@ -0,0 +1,51 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var AggregatorPinsCounter *prometheus.CounterVec
+var AggregatorBatchHistogram *prometheus.HistogramVec
+
+// AggregatorPinsCounterName is the prometheus metric for tracking the total number of pins processed by the aggregator
+var AggregatorPinsCounterName = "ff_aggregator_pins_processed_total"
+
+// AggregatorBatchHistogramName is the prometheus metric for tracking the time the aggregator takes to process each batch of pins
+var AggregatorBatchHistogramName = "ff_aggregator_batch_seconds"
+
+var aggregatorPinsLabels = []string{namespaceLabelName, "ledger"}
+
+// Each batch of pins processed by the aggregator can span namespaces, so is only broken out by ledger
+var aggregatorBatchLabels = []string{"ledger"}
+
+func InitAggregatorMetrics() {
+	AggregatorPinsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: AggregatorPinsCounterName,
+		Help: "Number of pins processed by the aggregator",
+	}, aggregatorPinsLabels)
+	AggregatorBatchHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: AggregatorBatchHistogramName,
+		Help: "Histogram of batches of pins processed by the aggregator, bucketed by time to process",
+	}, aggregatorBatchLabels)
+}
+
+func RegisterAggregatorMetrics() {
+	registry.MustRegister(AggregatorPinsCounter)
+	registry.MustRegister(AggregatorBatchHistogram)
+}
@@ -0,0 +1,49 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var BatchSizeHistogram *prometheus.HistogramVec
+var BatchTargetSizeGauge *prometheus.GaugeVec
+
+// BatchSizeHistogramName is the prometheus metric for tracking the number of messages in each batch flushed
+var BatchSizeHistogramName = "ff_batch_size_histogram"
+
+// BatchTargetSizeGaugeName is the prometheus metric for tracking the current target size of each batch processor
+var BatchTargetSizeGaugeName = "ff_batch_target_size"
+
+var batchLabels = []string{namespaceLabelName, "dispatcher"}
+
+func InitBatchMetrics() {
+	BatchSizeHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    BatchSizeHistogramName,
+		Help:    "Histogram of batches, bucketed by number of messages",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+	}, batchLabels)
+	BatchTargetSizeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: BatchTargetSizeGaugeName,
+		Help: "Number of messages that fills a batch, which varies over time when adaptive batch sizing is enabled",
+	}, batchLabels)
+}
+
+func RegisterBatchMetrics() {
+	registry.MustRegister(BatchSizeHistogram)
+	registry.MustRegister(BatchTargetSizeGauge)
+}
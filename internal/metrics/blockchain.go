@@ -0,0 +1,46 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var BlockchainEventsCounter *prometheus.CounterVec
+var BlockchainSubmitHistogram *prometheus.HistogramVec
+
+// BlockchainEventsCounterName is the prometheus metric for tracking the total number of events received from each blockchain plugin
+var BlockchainEventsCounterName = "ff_blockchain_events_total"
+
+// BlockchainSubmitHistogramName is the prometheus metric for tracking the time taken to submit transactions to each blockchain plugin
+var BlockchainSubmitHistogramName = "ff_blockchain_submit_seconds"
+
+func InitBlockchainMetrics() {
+	BlockchainEventsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: BlockchainEventsCounterName,
+		Help: "Number of events received from blockchain plugins, by type (batchpin or contract)",
+	}, []string{namespaceLabelName, "plugin", "type"})
+	BlockchainSubmitHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: BlockchainSubmitHistogramName,
+		Help: "Histogram of transactions submitted to blockchain plugins, bucketed by time to accept, by result (success or error)",
+	}, []string{namespaceLabelName, "plugin", "result"})
+}
+
+func RegisterBlockchainMetrics() {
+	registry.MustRegister(BlockchainEventsCounter)
+	registry.MustRegister(BlockchainSubmitHistogram)
+}
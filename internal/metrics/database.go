@@ -0,0 +1,40 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var DatabaseOperationHistogram *prometheus.HistogramVec
+
+// DatabaseOperationHistogramName is the prometheus metric for tracking the time taken by database operations
+var DatabaseOperationHistogramName = "ff_database_operation_seconds"
+
+var databaseLabels = []string{namespaceLabelName, "result"}
+
+func InitDatabaseMetrics() {
+	DatabaseOperationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    DatabaseOperationHistogramName,
+		Help:    "Histogram of database operations, bucketed by time taken, by result (success or error)",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, databaseLabels)
+}
+
+func RegisterDatabaseMetrics() {
+	registry.MustRegister(DatabaseOperationHistogram)
+}
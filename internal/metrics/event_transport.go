@@ -0,0 +1,48 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var EventDeliveriesCounter *prometheus.CounterVec
+var EventResponsesCounter *prometheus.CounterVec
+
+// EventDeliveriesCounterName is the prometheus metric for tracking the total number of events delivered to each event transport
+var EventDeliveriesCounterName = "ff_event_transport_deliveries_total"
+
+// EventResponsesCounterName is the prometheus metric for tracking the total number of acks and nacks received from each event transport
+var EventResponsesCounterName = "ff_event_transport_responses_total"
+
+var eventTransportLabels = []string{namespaceLabelName, "transport", "result"}
+
+func InitEventTransportMetrics() {
+	EventDeliveriesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: EventDeliveriesCounterName,
+		Help: "Number of events delivered to event transports, by result (delivered or failed)",
+	}, eventTransportLabels)
+	EventResponsesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: EventResponsesCounterName,
+		Help: "Number of responses to delivered events received from event transports, by result (ack or nack)",
+	}, eventTransportLabels)
+}
+
+func RegisterEventTransportMetrics() {
+	registry.MustRegister(EventDeliveriesCounter)
+	registry.MustRegister(EventResponsesCounter)
+}
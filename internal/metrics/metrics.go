@@ -27,12 +27,26 @@ import (
 
 var mutex = &sync.Mutex{}
 
+// namespaceLabelName is the label that breaks out each of our metrics by namespace
+const namespaceLabelName = "ns"
+
+var namespaceLabels = []string{namespaceLabelName}
+
 type Manager interface {
-	CountBatchPin()
+	CountBatchPin(ns string)
+	BatchFlushed(ns, dispatcher string, messages int, targetSize uint)
 	MessageSubmitted(msg *fftypes.Message)
 	MessageConfirmed(msg *fftypes.Message, eventType fftypes.FFEnum)
 	TransferSubmitted(transfer *fftypes.TokenTransfer)
 	TransferConfirmed(transfer *fftypes.TokenTransfer)
+	EventDispatched(event *fftypes.EventDelivery)
+	EventDelivered(ns, transport string, delivered bool)
+	EventResponse(ns, transport string, rejected bool)
+	AggregatorBatchProcessed(ledger string, nsPins map[string]int, elapsed time.Duration)
+	DatabaseOperation(ns string, elapsed time.Duration, err error)
+	BlockchainEventReceived(plugin, ns, eventType string)
+	BlockchainSubmitted(plugin, ns string, elapsed time.Duration, err error)
+	GetNamespaceUsage(ns string) *fftypes.NamespaceUsage
 	AddTime(id string)
 	GetTime(id string) time.Time
 	DeleteTime(id string)
@@ -44,6 +58,8 @@ type metricsManager struct {
 	ctx            context.Context
 	metricsEnabled bool
 	timeMap        map[string]time.Time
+	startTime      *fftypes.FFTime
+	usage          map[string]*fftypes.NamespaceUsage
 }
 
 func (mm *metricsManager) Start() error {
@@ -55,23 +71,37 @@ func NewMetricsManager(ctx context.Context) Manager {
 		ctx:            ctx,
 		metricsEnabled: config.GetBool(config.MetricsEnabled),
 		timeMap:        make(map[string]time.Time),
+		startTime:      fftypes.Now(),
+		usage:          make(map[string]*fftypes.NamespaceUsage),
 	}
 
 	return mm
 }
 
-func (mm *metricsManager) CountBatchPin() {
-	BatchPinCounter.Inc()
+func (mm *metricsManager) CountBatchPin(ns string) {
+	BatchPinCounter.WithLabelValues(ns).Inc()
+	mm.updateUsage(ns, func(u *fftypes.NamespaceUsage) { u.BatchPins++ })
+}
+
+func (mm *metricsManager) BatchFlushed(ns, dispatcher string, messages int, targetSize uint) {
+	BatchSizeHistogram.WithLabelValues(ns, dispatcher).Observe(float64(messages))
+	BatchTargetSizeGauge.WithLabelValues(ns, dispatcher).Set(float64(targetSize))
 }
 
 func (mm *metricsManager) MessageSubmitted(msg *fftypes.Message) {
 	if len(msg.Header.ID.String()) > 0 {
 		switch msg.Header.Type {
 		case fftypes.MessageTypeBroadcast:
-			BroadcastSubmittedCounter.Inc()
+			BroadcastSubmittedCounter.WithLabelValues(msg.Header.Namespace).Inc()
 		case fftypes.MessageTypePrivate:
-			PrivateMsgSubmittedCounter.Inc()
+			PrivateMsgSubmittedCounter.WithLabelValues(msg.Header.Namespace).Inc()
 		}
+		msgSize := msg.EstimateSize(true)
+		MessageBytesCounter.WithLabelValues(msg.Header.Namespace).Add(float64(msgSize))
+		mm.updateUsage(msg.Header.Namespace, func(u *fftypes.NamespaceUsage) {
+			u.MessagesSubmitted++
+			u.MessageBytes += msgSize
+		})
 		mm.AddTime(msg.Header.ID.String())
 	}
 }
@@ -80,20 +110,29 @@ func (mm *metricsManager) MessageConfirmed(msg *fftypes.Message, eventType fftyp
 	timeElapsed := time.Since(mm.GetTime(msg.Header.ID.String())).Seconds()
 	mm.DeleteTime(msg.Header.ID.String())
 
+	mm.updateUsage(msg.Header.Namespace, func(u *fftypes.NamespaceUsage) {
+		switch eventType {
+		case fftypes.EventTypeMessageConfirmed:
+			u.MessagesConfirmed++
+		case fftypes.EventTypeMessageRejected:
+			u.MessagesRejected++
+		}
+	})
+
 	switch msg.Header.Type {
 	case fftypes.MessageTypeBroadcast:
-		BroadcastHistogram.Observe(timeElapsed)
+		BroadcastHistogram.WithLabelValues(msg.Header.Namespace).Observe(timeElapsed)
 		if eventType == fftypes.EventTypeMessageConfirmed { // Broadcast Confirmed
-			BroadcastConfirmedCounter.Inc()
+			BroadcastConfirmedCounter.WithLabelValues(msg.Header.Namespace).Inc()
 		} else if eventType == fftypes.EventTypeMessageRejected { // Broadcast Rejected
-			BroadcastRejectedCounter.Inc()
+			BroadcastRejectedCounter.WithLabelValues(msg.Header.Namespace).Inc()
 		}
 	case fftypes.MessageTypePrivate:
-		PrivateMsgHistogram.Observe(timeElapsed)
+		PrivateMsgHistogram.WithLabelValues(msg.Header.Namespace).Observe(timeElapsed)
 		if eventType == fftypes.EventTypeMessageConfirmed { // Private Msg Confirmed
-			PrivateMsgConfirmedCounter.Inc()
+			PrivateMsgConfirmedCounter.WithLabelValues(msg.Header.Namespace).Inc()
 		} else if eventType == fftypes.EventTypeMessageRejected { // Private Msg Rejected
-			PrivateMsgRejectedCounter.Inc()
+			PrivateMsgRejectedCounter.WithLabelValues(msg.Header.Namespace).Inc()
 		}
 	}
 }
@@ -102,12 +141,13 @@ func (mm *metricsManager) TransferSubmitted(transfer *fftypes.TokenTransfer) {
 	if len(transfer.LocalID.String()) > 0 {
 		switch transfer.Type {
 		case fftypes.TokenTransferTypeMint: // Mint submitted
-			MintSubmittedCounter.Inc()
+			MintSubmittedCounter.WithLabelValues(transfer.Namespace).Inc()
 		case fftypes.TokenTransferTypeTransfer: // Transfer submitted
-			TransferSubmittedCounter.Inc()
+			TransferSubmittedCounter.WithLabelValues(transfer.Namespace).Inc()
 		case fftypes.TokenTransferTypeBurn: // Burn submitted
-			BurnSubmittedCounter.Inc()
+			BurnSubmittedCounter.WithLabelValues(transfer.Namespace).Inc()
 		}
+		mm.updateUsage(transfer.Namespace, func(u *fftypes.NamespaceUsage) { u.TransfersSubmitted++ })
 		mm.AddTime(transfer.LocalID.String())
 	}
 }
@@ -115,18 +155,91 @@ func (mm *metricsManager) TransferSubmitted(transfer *fftypes.TokenTransfer) {
 func (mm *metricsManager) TransferConfirmed(transfer *fftypes.TokenTransfer) {
 	timeElapsed := time.Since(mm.GetTime(transfer.LocalID.String())).Seconds()
 	mm.DeleteTime(transfer.LocalID.String())
+	mm.updateUsage(transfer.Namespace, func(u *fftypes.NamespaceUsage) { u.TransfersConfirmed++ })
 
 	switch transfer.Type {
 	case fftypes.TokenTransferTypeMint: // Mint confirmed
-		MintHistogram.Observe(timeElapsed)
-		MintConfirmedCounter.Inc()
+		MintHistogram.WithLabelValues(transfer.Namespace).Observe(timeElapsed)
+		MintConfirmedCounter.WithLabelValues(transfer.Namespace).Inc()
 	case fftypes.TokenTransferTypeTransfer: // Transfer confirmed
-		TransferHistogram.Observe(timeElapsed)
-		TransferConfirmedCounter.Inc()
+		TransferHistogram.WithLabelValues(transfer.Namespace).Observe(timeElapsed)
+		TransferConfirmedCounter.WithLabelValues(transfer.Namespace).Inc()
 	case fftypes.TokenTransferTypeBurn: // Burn confirmed
-		BurnHistogram.Observe(timeElapsed)
-		BurnConfirmedCounter.Inc()
+		BurnHistogram.WithLabelValues(transfer.Namespace).Observe(timeElapsed)
+		BurnConfirmedCounter.WithLabelValues(transfer.Namespace).Inc()
+	}
+}
+
+func (mm *metricsManager) EventDispatched(event *fftypes.EventDelivery) {
+	EventsDispatchedCounter.WithLabelValues(event.Namespace).Inc()
+	mm.updateUsage(event.Namespace, func(u *fftypes.NamespaceUsage) { u.EventsDispatched++ })
+}
+
+func (mm *metricsManager) EventDelivered(ns, transport string, delivered bool) {
+	result := "delivered"
+	if !delivered {
+		result = "failed"
+	}
+	EventDeliveriesCounter.WithLabelValues(ns, transport, result).Inc()
+}
+
+func (mm *metricsManager) EventResponse(ns, transport string, rejected bool) {
+	result := "ack"
+	if rejected {
+		result = "nack"
+	}
+	EventResponsesCounter.WithLabelValues(ns, transport, result).Inc()
+}
+
+func (mm *metricsManager) AggregatorBatchProcessed(ledger string, nsPins map[string]int, elapsed time.Duration) {
+	for ns, pins := range nsPins {
+		AggregatorPinsCounter.WithLabelValues(ns, ledger).Add(float64(pins))
+	}
+	AggregatorBatchHistogram.WithLabelValues(ledger).Observe(elapsed.Seconds())
+}
+
+// DatabaseOperation records a call to the database. The namespace is empty for calls not made on behalf of a namespace.
+func (mm *metricsManager) DatabaseOperation(ns string, elapsed time.Duration, err error) {
+	DatabaseOperationHistogram.WithLabelValues(ns, resultLabel(err)).Observe(elapsed.Seconds())
+}
+
+func (mm *metricsManager) BlockchainEventReceived(plugin, ns, eventType string) {
+	BlockchainEventsCounter.WithLabelValues(ns, plugin, eventType).Inc()
+}
+
+func (mm *metricsManager) BlockchainSubmitted(plugin, ns string, elapsed time.Duration, err error) {
+	BlockchainSubmitHistogram.WithLabelValues(ns, plugin, resultLabel(err)).Observe(elapsed.Seconds())
+}
+
+// resultLabel is the value of the result label for operations that either succeed or return an error
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+func (mm *metricsManager) updateUsage(ns string, update func(u *fftypes.NamespaceUsage)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	u, ok := mm.usage[ns]
+	if !ok {
+		u = &fftypes.NamespaceUsage{Namespace: ns}
+		mm.usage[ns] = u
+	}
+	update(u)
+}
+
+// GetNamespaceUsage returns a copy of the usage counters of a namespace, since this node started
+func (mm *metricsManager) GetNamespaceUsage(ns string) *fftypes.NamespaceUsage {
+	mutex.Lock()
+	defer mutex.Unlock()
+	usage := &fftypes.NamespaceUsage{Namespace: ns}
+	if u, ok := mm.usage[ns]; ok {
+		*usage = *u
 	}
+	usage.Since = mm.startTime
+	return usage
 }
 
 func (mm *metricsManager) AddTime(id string) {
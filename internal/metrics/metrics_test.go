@@ -18,6 +18,7 @@ package metrics
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -67,7 +68,16 @@ func TestStartDoesNothing(t *testing.T) {
 func TestCountBatchPin(t *testing.T) {
 	mm, cancel := newTestMetricsManager(t)
 	defer cancel()
-	mm.CountBatchPin()
+	mm.CountBatchPin("ns1")
+}
+
+func TestBatchFlushed(t *testing.T) {
+	mm, cancel := newTestMetricsManager(t)
+	defer cancel()
+	mm.BatchFlushed("ns1", "broadcast", 5, 8)
+	m, err := BatchTargetSizeGauge.GetMetricWithLabelValues("ns1", "broadcast")
+	assert.NoError(t, err)
+	assert.NotNil(t, m)
 }
 
 func TestMessageSubmittedBroadcast(t *testing.T) {
@@ -189,3 +199,108 @@ func TestIsMetricsEnabledFalse(t *testing.T) {
 	mm.metricsEnabled = false
 	assert.Equal(t, mm.IsMetricsEnabled(), false)
 }
+
+func TestNamespaceUsage(t *testing.T) {
+	mm, cancel := newTestMetricsManager(t)
+	defer cancel()
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Type:      fftypes.MessageTypeBroadcast,
+		},
+		Data: fftypes.DataRefs{
+			{ID: fftypes.NewUUID(), ValueSize: 1000},
+		},
+	}
+	mm.MessageSubmitted(msg)
+	mm.MessageConfirmed(msg, fftypes.EventTypeMessageConfirmed)
+	mm.MessageSubmitted(msg)
+	mm.MessageConfirmed(msg, fftypes.EventTypeMessageRejected)
+
+	transfer := &fftypes.TokenTransfer{
+		LocalID:   fftypes.NewUUID(),
+		Namespace: "ns1",
+		Type:      fftypes.TokenTransferTypeTransfer,
+	}
+	mm.TransferSubmitted(transfer)
+	mm.TransferConfirmed(transfer)
+
+	mm.CountBatchPin("ns1")
+	mm.EventDispatched(&fftypes.EventDelivery{Event: fftypes.Event{Namespace: "ns1"}})
+	mm.EventDispatched(&fftypes.EventDelivery{Event: fftypes.Event{Namespace: "ns2"}})
+
+	usage := mm.GetNamespaceUsage("ns1")
+	assert.Equal(t, "ns1", usage.Namespace)
+	assert.Equal(t, mm.startTime, usage.Since)
+	assert.Equal(t, int64(2), usage.MessagesSubmitted)
+	assert.Equal(t, int64(1), usage.MessagesConfirmed)
+	assert.Equal(t, int64(1), usage.MessagesRejected)
+	assert.Equal(t, 2*msg.EstimateSize(true), usage.MessageBytes)
+	assert.Equal(t, int64(1), usage.TransfersSubmitted)
+	assert.Equal(t, int64(1), usage.TransfersConfirmed)
+	assert.Equal(t, int64(1), usage.BatchPins)
+	assert.Equal(t, int64(1), usage.EventsDispatched)
+
+	// Returned usage is a copy
+	usage.EventsDispatched = 100
+	assert.Equal(t, int64(1), mm.GetNamespaceUsage("ns1").EventsDispatched)
+
+	usage = mm.GetNamespaceUsage("ns3")
+	assert.Equal(t, "ns3", usage.Namespace)
+	assert.Equal(t, int64(0), usage.MessagesSubmitted)
+}
+
+func TestEventTransportMetrics(t *testing.T) {
+	mm, cancel := newTestMetricsManager(t)
+	defer cancel()
+	mm.EventDelivered("ns1", "websockets", true)
+	mm.EventDelivered("ns1", "websockets", false)
+	mm.EventResponse("ns1", "websockets", false)
+	mm.EventResponse("ns1", "websockets", true)
+	for _, result := range []string{"delivered", "failed"} {
+		m, err := EventDeliveriesCounter.GetMetricWithLabelValues("ns1", "websockets", result)
+		assert.NoError(t, err)
+		assert.NotNil(t, m)
+	}
+	for _, result := range []string{"ack", "nack"} {
+		m, err := EventResponsesCounter.GetMetricWithLabelValues("ns1", "websockets", result)
+		assert.NoError(t, err)
+		assert.NotNil(t, m)
+	}
+}
+
+func TestAggregatorBatchProcessed(t *testing.T) {
+	mm, cancel := newTestMetricsManager(t)
+	defer cancel()
+	mm.AggregatorBatchProcessed("ledger1", map[string]int{"ns1": 10}, 50*time.Millisecond)
+	m, err := AggregatorPinsCounter.GetMetricWithLabelValues("ns1", "ledger1")
+	assert.NoError(t, err)
+	assert.NotNil(t, m)
+}
+
+func TestDatabaseOperation(t *testing.T) {
+	mm, cancel := newTestMetricsManager(t)
+	defer cancel()
+	mm.DatabaseOperation("ns1", 5*time.Millisecond, nil)
+	mm.DatabaseOperation("ns1", 5*time.Millisecond, fmt.Errorf("pop"))
+	for _, result := range []string{"success", "error"} {
+		m, err := DatabaseOperationHistogram.GetMetricWithLabelValues("ns1", result)
+		assert.NoError(t, err)
+		assert.NotNil(t, m)
+	}
+}
+
+func TestBlockchainMetrics(t *testing.T) {
+	mm, cancel := newTestMetricsManager(t)
+	defer cancel()
+	mm.BlockchainEventReceived("ethereum", "ns1", "batchpin")
+	mm.BlockchainSubmitted("ethereum", "ns1", 200*time.Millisecond, nil)
+	m, err := BlockchainEventsCounter.GetMetricWithLabelValues("ns1", "ethereum", "batchpin")
+	assert.NoError(t, err)
+	assert.NotNil(t, m)
+	m2, err := BlockchainSubmitHistogram.GetMetricWithLabelValues("ns1", "ethereum", "success")
+	assert.NoError(t, err)
+	assert.NotNil(t, m2)
+}
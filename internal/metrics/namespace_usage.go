@@ -0,0 +1,46 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var MessageBytesCounter *prometheus.CounterVec
+var EventsDispatchedCounter *prometheus.CounterVec
+
+// MessageBytesCounterName is the prometheus metric for tracking the estimated total size of messages submitted, including their data
+var MessageBytesCounterName = "ff_message_bytes_total"
+
+// EventsDispatchedCounterName is the prometheus metric for tracking the total number of events dispatched to subscriptions
+var EventsDispatchedCounterName = "ff_events_dispatched_total"
+
+func InitNamespaceUsageMetrics() {
+	MessageBytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MessageBytesCounterName,
+		Help: "Estimated size in bytes of submitted messages, including their data",
+	}, namespaceLabels)
+	EventsDispatchedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: EventsDispatchedCounterName,
+		Help: "Number of events dispatched to subscriptions",
+	}, namespaceLabels)
+}
+
+func RegisterNamespaceUsageMetrics() {
+	registry.MustRegister(MessageBytesCounter)
+	registry.MustRegister(EventsDispatchedCounter)
+}
@@ -80,6 +80,12 @@ func initMetricsCollectors() {
 	InitTokenTransferMetrics()
 	InitTokenBurnMetrics()
 	InitBatchPinMetrics()
+	InitBatchMetrics()
+	InitNamespaceUsageMetrics()
+	InitAggregatorMetrics()
+	InitEventTransportMetrics()
+	InitDatabaseMetrics()
+	InitBlockchainMetrics()
 }
 
 func registerMetricsCollectors() {
@@ -87,9 +93,15 @@ func registerMetricsCollectors() {
 	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
 	RegisterBatchPinMetrics()
+	RegisterBatchMetrics()
 	RegisterBroadcastMetrics()
 	RegisterPrivateMsgMetrics()
 	RegisterTokenMintMetrics()
 	RegisterTokenTransferMetrics()
 	RegisterTokenBurnMetrics()
+	RegisterNamespaceUsageMetrics()
+	RegisterAggregatorMetrics()
+	RegisterEventTransportMetrics()
+	RegisterDatabaseMetrics()
+	RegisterBlockchainMetrics()
 }
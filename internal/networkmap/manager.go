@@ -22,6 +22,7 @@ import (
 	"github.com/hyperledger/firefly/internal/broadcast"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
+	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -31,6 +32,7 @@ type Manager interface {
 	RegisterOrganization(ctx context.Context, org *fftypes.Organization, waitConfirm bool) (msg *fftypes.Message, err error)
 	RegisterNode(ctx context.Context, waitConfirm bool) (node *fftypes.Node, msg *fftypes.Message, err error)
 	RegisterNodeOrganization(ctx context.Context, waitConfirm bool) (org *fftypes.Organization, msg *fftypes.Message, err error)
+	RotateOrganizationKey(ctx context.Context, id string, rotation *fftypes.KeyRotation, waitConfirm bool) (msg *fftypes.Message, err error)
 
 	GetOrganizationByID(ctx context.Context, id string) (*fftypes.Organization, error)
 	GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error)
@@ -39,24 +41,26 @@ type Manager interface {
 }
 
 type networkMap struct {
-	ctx       context.Context
-	database  database.Plugin
-	broadcast broadcast.Manager
-	exchange  dataexchange.Plugin
-	identity  identity.Manager
+	ctx        context.Context
+	database   database.Plugin
+	broadcast  broadcast.Manager
+	exchange   dataexchange.Plugin
+	identity   identity.Manager
+	blockchain blockchain.Plugin
 }
 
-func NewNetworkMap(ctx context.Context, di database.Plugin, bm broadcast.Manager, dx dataexchange.Plugin, im identity.Manager) (Manager, error) {
-	if di == nil || bm == nil || dx == nil || im == nil {
+func NewNetworkMap(ctx context.Context, di database.Plugin, bm broadcast.Manager, dx dataexchange.Plugin, im identity.Manager, bi blockchain.Plugin) (Manager, error) {
+	if di == nil || bm == nil || dx == nil || im == nil || bi == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 
 	nm := &networkMap{
-		ctx:       ctx,
-		database:  di,
-		broadcast: bm,
-		exchange:  dx,
-		identity:  im,
+		ctx:        ctx,
+		database:   di,
+		broadcast:  bm,
+		exchange:   dx,
+		identity:   im,
+		blockchain: bi,
 	}
 	return nm, nil
 }
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/broadcastmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
@@ -35,13 +36,15 @@ func newTestNetworkmap(t *testing.T) (*networkMap, func()) {
 	mbm := &broadcastmocks.Manager{}
 	mdx := &dataexchangemocks.Plugin{}
 	mim := &identitymanagermocks.Manager{}
-	nm, err := NewNetworkMap(ctx, mdi, mbm, mdx, mim)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("PrivacyKey").Return("").Maybe()
+	nm, err := NewNetworkMap(ctx, mdi, mbm, mdx, mim, mbi)
 	assert.NoError(t, err)
 	return nm.(*networkMap), cancel
 
 }
 
 func TestNewNetworkMapMissingDep(t *testing.T) {
-	_, err := NewNetworkMap(context.Background(), nil, nil, nil, nil)
+	_, err := NewNetworkMap(context.Background(), nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
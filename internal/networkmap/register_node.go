@@ -22,6 +22,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/nodekey"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -49,11 +50,39 @@ func (nm *networkMap) RegisterNode(ctx context.Context, waitConfirm bool) (node
 		return nil, nil, i18n.NewError(ctx, i18n.MsgNodeAndOrgIDMustBeSet)
 	}
 
+	// Register the public key of the node, so other members can verify the batches we seal
+	if keyFile := config.GetString(config.NodeSigningKey); keyFile != "" {
+		key, err := nodekey.LoadPrivateKey(ctx, keyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		node.PublicKey = nodekey.PublicKey(key)
+	}
+
+	// Register the encryption key of the node, so other members can encrypt private transmissions to us
+	kw, err := nodekey.GetKeyWrapper(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kw != nil {
+		node.EncryptionKey = kw.PublicKey()
+	}
+
+	// Register the privacy key of the node, so other members can include us in the privacy groups of private transactions
+	node.PrivacyKey = nm.blockchain.PrivacyKey()
+
 	node.DX, err = nm.exchange.GetEndpointInfo(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// Pin the certificate of our data exchange, so other members can detect interception of the endpoint
+	if config.GetBool(config.NodeDXCertPin) {
+		if node.DX.CertPin, err = node.DX.CertFingerprint(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	err = node.Validate(ctx, false)
 	if err != nil {
 		return nil, nil, err
@@ -0,0 +1,65 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// RotateOrganizationKey broadcasts a change of the signing key of an organization, signed with the current key
+// (or the key of the parent, for a child organization). Other nodes continue to accept the previous key until the
+// end of the configured grace period, to allow batches already in flight to be confirmed.
+func (nm *networkMap) RotateOrganizationKey(ctx context.Context, id string, rotation *fftypes.KeyRotation, waitConfirm bool) (*fftypes.Message, error) {
+
+	org, err := nm.GetOrganizationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+
+	if rotation.NewKey, err = nm.identity.ResolveSigningKey(ctx, rotation.NewKey); err != nil {
+		return nil, err
+	}
+	rotation.ID = fftypes.NewUUID()
+	rotation.Organization = org.ID
+	rotation.PreviousKey = org.Identity
+	rotation.Created = fftypes.Now()
+	if err = rotation.Validate(ctx, true); err != nil {
+		return nil, err
+	}
+
+	existing, err := nm.database.GetOrganizationByIdentity(ctx, rotation.NewKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgKeyRotationKeyInUse, rotation.NewKey, existing.Name)
+	}
+
+	signingKey := org.Identity
+	if org.Parent != "" {
+		signingKey = org.Parent
+	}
+	return nm.broadcast.BroadcastDefinition(ctx, fftypes.SystemNamespace, rotation, &fftypes.Identity{
+		Key: signingKey,
+	}, fftypes.SystemTagRotateOrgKey, waitConfirm)
+}
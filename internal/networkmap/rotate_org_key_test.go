@@ -0,0 +1,167 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRotateOrganizationKeyOk(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(nil, nil)
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKey", nm.ctx, "newkey").Return("0x23456", nil)
+
+	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinition", nm.ctx, fftypes.SystemNamespace, mock.MatchedBy(func(kr *fftypes.KeyRotation) bool {
+		return kr.Organization.Equals(org.ID) && kr.PreviousKey == "0x12345" && kr.NewKey == "0x23456"
+	}), &fftypes.Identity{Key: "0x12345"}, fftypes.SystemTagRotateOrgKey, false).Return(mockMsg, nil)
+
+	msg, err := nm.RotateOrganizationKey(nm.ctx, org.ID.String(), &fftypes.KeyRotation{NewKey: "newkey"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, mockMsg, msg)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+	mbm.AssertExpectations(t)
+}
+
+func TestRotateOrganizationKeyChildOrgSignedByParent(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345", Parent: "0x34567"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(nil, nil)
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("0x23456", nil)
+
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinition", nm.ctx, fftypes.SystemNamespace, mock.Anything, &fftypes.Identity{Key: "0x34567"}, fftypes.SystemTagRotateOrgKey, true).Return(&fftypes.Message{}, nil)
+
+	_, err := nm.RotateOrganizationKey(nm.ctx, org.ID.String(), &fftypes.KeyRotation{NewKey: "0x23456"}, true)
+	assert.NoError(t, err)
+
+	mbm.AssertExpectations(t)
+}
+
+func TestRotateOrganizationKeyBadID(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	_, err := nm.RotateOrganizationKey(nm.ctx, "bad", &fftypes.KeyRotation{NewKey: "0x23456"}, false)
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestRotateOrganizationKeyNotFound(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	orgID := fftypes.NewUUID()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, orgID).Return(nil, nil)
+
+	_, err := nm.RotateOrganizationKey(nm.ctx, orgID.String(), &fftypes.KeyRotation{NewKey: "0x23456"}, false)
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRotateOrganizationKeyResolveFail(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKey", nm.ctx, "newkey").Return("", fmt.Errorf("pop"))
+
+	_, err := nm.RotateOrganizationKey(nm.ctx, org.ID.String(), &fftypes.KeyRotation{NewKey: "newkey"}, false)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRotateOrganizationKeyUnchanged(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKey", nm.ctx, "0x12345").Return("0x12345", nil)
+
+	_, err := nm.RotateOrganizationKey(nm.ctx, org.ID.String(), &fftypes.KeyRotation{NewKey: "0x12345"}, false)
+	assert.Regexp(t, "FF10455", err)
+}
+
+func TestRotateOrganizationKeyLookupFail(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(nil, fmt.Errorf("pop"))
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("0x23456", nil)
+
+	_, err := nm.RotateOrganizationKey(nm.ctx, org.ID.String(), &fftypes.KeyRotation{NewKey: "0x23456"}, false)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRotateOrganizationKeyInUse(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(&fftypes.Organization{Name: "org2"}, nil)
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("0x23456", nil)
+
+	_, err := nm.RotateOrganizationKey(nm.ctx, org.ID.String(), &fftypes.KeyRotation{NewKey: "0x23456"}, false)
+	assert.Regexp(t, "FF10456.*org2", err)
+}
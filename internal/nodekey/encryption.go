@@ -0,0 +1,116 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodekey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// KeyWrapper wraps the symmetric keys used to encrypt private transmissions end-to-end, to the encryption
+// key of each recipient node, and unwraps keys sent to this node. The local implementation holds an X25519
+// key loaded from a file, but the interface allows the private key to be managed elsewhere.
+type KeyWrapper interface {
+	// PublicKey returns the hex encoded public key of this node, as registered on the node
+	PublicKey() string
+	// WrapKey encrypts a symmetric key, so only the holder of the private key for the hex encoded public key can read it
+	WrapKey(ctx context.Context, publicKey string, key []byte) (string, error)
+	// UnwrapKey decrypts a symmetric key that was wrapped to the public key of this node
+	UnwrapKey(ctx context.Context, wrapped string) ([]byte, error)
+}
+
+var oidX25519 = asn1.ObjectIdentifier{1, 3, 101, 110}
+
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+type localKeyWrapper struct {
+	privateKey [32]byte
+	publicKey  [32]byte
+	rand       io.Reader
+}
+
+// LoadEncryptionKey reads a PEM encoded PKCS#8 X25519 private key, such as one generated with
+// "openssl genpkey -algorithm x25519", and returns a KeyWrapper that uses it
+func LoadEncryptionKey(ctx context.Context, filename string) (KeyWrapper, error) {
+	b, err := readKey(ctx, filename)
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeEncryptionKey, filename, err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeEncryptionKey, filename, "no PEM block found")
+	}
+	var key pkcs8
+	if _, err := asn1.Unmarshal(block.Bytes, &key); err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeEncryptionKey, filename, err)
+	}
+	var privateKey []byte
+	if !key.Algo.Algorithm.Equal(oidX25519) {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeEncryptionKey, filename, "not an X25519 key")
+	}
+	if _, err := asn1.Unmarshal(key.PrivateKey, &privateKey); err != nil || len(privateKey) != curve25519.ScalarSize {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeEncryptionKey, filename, "invalid X25519 private key")
+	}
+	kw := &localKeyWrapper{rand: rand.Reader}
+	copy(kw.privateKey[:], privateKey)
+	curve25519.ScalarBaseMult(&kw.publicKey, &kw.privateKey)
+	return kw, nil
+}
+
+func (kw *localKeyWrapper) PublicKey() string {
+	return hex.EncodeToString(kw.publicKey[:])
+}
+
+func (kw *localKeyWrapper) WrapKey(ctx context.Context, publicKey string, key []byte) (string, error) {
+	pubBytes, err := hex.DecodeString(publicKey)
+	if err != nil || len(pubBytes) != curve25519.PointSize {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidEncryptionPublicKey, publicKey)
+	}
+	var recipient [32]byte
+	copy(recipient[:], pubBytes)
+	wrapped, err := box.SealAnonymous(nil, key, &recipient, kw.rand)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+func (kw *localKeyWrapper) UnwrapKey(ctx context.Context, wrapped string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgDecryptFailed, err)
+	}
+	key, ok := box.OpenAnonymous(nil, b, &kw.publicKey, &kw.privateKey)
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgDecryptFailed, "unable to unwrap key")
+	}
+	return key, nil
+}
@@ -0,0 +1,50 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodekey
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// KeyWrapperTypeLocal holds the X25519 private key in this process, read from the node.encryptionKey file or secret
+const KeyWrapperTypeLocal = "local"
+
+var keyWrappersByType = map[string]func(ctx context.Context) (KeyWrapper, error){
+	KeyWrapperTypeLocal: newLocalKeyWrapper,
+}
+
+// GetKeyWrapper returns the KeyWrapper of the type selected by node.encryptionKeyType, or nil if
+// end-to-end encryption is not configured on this node
+func GetKeyWrapper(ctx context.Context) (KeyWrapper, error) {
+	keyType := config.GetString(config.NodeEncryptionKeyType)
+	factory, ok := keyWrappersByType[keyType]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownEncryptionKeyType, keyType)
+	}
+	return factory(ctx)
+}
+
+func newLocalKeyWrapper(ctx context.Context) (KeyWrapper, error) {
+	keyFile := config.GetString(config.NodeEncryptionKey)
+	if keyFile == "" {
+		return nil, nil
+	}
+	return LoadEncryptionKey(ctx, keyFile)
+}
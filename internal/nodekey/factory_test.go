@@ -0,0 +1,47 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodekey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKeyWrapperLocal(t *testing.T) {
+	config.Reset()
+	config.Set(config.NodeEncryptionKey, writeKeyFile(t, testX25519Key1))
+	kw, err := GetKeyWrapper(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, kw.PublicKey(), 64)
+}
+
+func TestGetKeyWrapperNotConfigured(t *testing.T) {
+	config.Reset()
+	kw, err := GetKeyWrapper(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, kw)
+}
+
+func TestGetKeyWrapperUnknownType(t *testing.T) {
+	config.Reset()
+	config.Set(config.NodeEncryptionKeyType, "wrong")
+	_, err := GetKeyWrapper(context.Background())
+	assert.Regexp(t, "FF10514", err)
+}
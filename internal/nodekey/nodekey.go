@@ -0,0 +1,85 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodekey
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/secrets"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// readKey reads a PEM encoded key from a file, or from a secret when the config refers to one. Keys are not
+// watched for rotation, as a new key must be registered with the network before the node can use it.
+func readKey(ctx context.Context, filename string) ([]byte, error) {
+	if secrets.HasReference(filename) {
+		key, err := secrets.Resolve(ctx, filename)
+		return []byte(key), err
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// LoadPrivateKey reads a PEM encoded PKCS#8 Ed25519 private key, such as one generated with
+// "openssl genpkey -algorithm ed25519"
+func LoadPrivateKey(ctx context.Context, filename string) (ed25519.PrivateKey, error) {
+	b, err := readKey(ctx, filename)
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeSigningKey, filename, err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeSigningKey, filename, "no PEM block found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeSigningKey, filename, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNodeSigningKey, filename, "not an Ed25519 key")
+	}
+	return edKey, nil
+}
+
+// PublicKey returns the hex encoded public key, as registered on the node
+func PublicKey(key ed25519.PrivateKey) string {
+	return hex.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+// Sign returns the hex encoded signature of a batch hash
+func Sign(key ed25519.PrivateKey, hash *fftypes.Bytes32) string {
+	return hex.EncodeToString(ed25519.Sign(key, hash[:]))
+}
+
+// Verify checks a hex encoded signature of a batch hash, against a hex encoded public key
+func Verify(publicKey, signature string, hash *fftypes.Bytes32) bool {
+	pubBytes, err := hex.DecodeString(publicKey)
+	if err != nil || len(pubBytes) != ed25519.PublicKeySize {
+		return false
+	}
+	sigBytes, err := hex.DecodeString(signature)
+	if err != nil || hash == nil {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(pubBytes), hash[:], sigBytes)
+}
@@ -21,9 +21,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -49,6 +54,9 @@ func (og *ffiSwaggerGen) Generate(ctx context.Context, baseURL string, api *ffty
 	for _, event := range ffi.Events {
 		routes = og.addEvent(routes, event, hasLocation)
 	}
+	if len(ffi.Events) > 0 {
+		routes = og.addSubscriptionManagement(routes)
+	}
 
 	return oapispec.SwaggerGen(ctx, routes, &oapispec.SwaggerGenConfig{
 		Title:       ffi.Name,
@@ -96,6 +104,28 @@ func (og *ffiSwaggerGen) addEvent(routes []*oapispec.Route, event *fftypes.FFIEv
 	})
 }
 
+// addSubscriptionManagement adds the routes to list and delete the subscriptions created against the events of the API
+func (og *ffiSwaggerGen) addSubscriptionManagement(routes []*oapispec.Route) []*oapispec.Route {
+	routes = append(routes, &oapispec.Route{
+		Name:            "getSubscriptions",
+		Path:            "subscriptions", // must match a route defined in apiserver routes!
+		Method:          http.MethodGet,
+		FilterFactory:   database.ContractSubscriptionQueryFactory,
+		JSONOutputValue: func() interface{} { return []*fftypes.ContractSubscription{} },
+		JSONOutputCodes: []int{http.StatusOK},
+	})
+	routes = append(routes, &oapispec.Route{
+		Name:   "deleteSubscription",
+		Path:   "subscriptions/{nameOrId}", // must match a route defined in apiserver routes!
+		Method: http.MethodDelete,
+		PathParams: []*oapispec.PathParam{
+			{Name: "nameOrId"},
+		},
+		JSONOutputCodes: []int{http.StatusNoContent},
+	})
+	return routes
+}
+
 /**
  * Parse the FFI and build a corresponding JSON Schema to describe the request body for "invoke".
  * Returns the JSON Schema as an `fftypes.JSONObject`.
@@ -125,9 +155,128 @@ func ffiParamsJSONSchema(params *fftypes.FFIParams) *fftypes.JSONObject {
 }
 
 func ffiParamJSONSchema(param *fftypes.FFIParam) *fftypes.JSONObject {
-	out := fftypes.JSONObject{}
-	if err := json.Unmarshal(param.Schema.Bytes(), &out); err == nil {
+	in := fftypes.JSONObject{}
+	if err := json.Unmarshal(param.Schema.Bytes(), &in); err == nil {
+		out := openAPIParamSchema(in)
 		return &out
 	}
 	return nil
 }
+
+var intTypeRegex = regexp.MustCompile(`^(u?)int([1-9][0-9]*)?$`)
+var bytesTypeRegex = regexp.MustCompile(`^bytes([0-9]*)$`)
+
+/**
+ * Convert the JSON Schema of an FFI param into a precise OpenAPI schema, descending into the properties
+ * of tuples/objects and the items of arrays. The blockchain specific "details" of each schema are used
+ * to describe the blockchain type, constrain integers to the width of that type, mark all fields of a
+ * tuple as required, and generate examples. The details themselves are not valid OpenAPI, so are removed.
+ */
+func openAPIParamSchema(in fftypes.JSONObject) fftypes.JSONObject {
+	out := make(fftypes.JSONObject, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	delete(out, "details")
+	details := in.GetObject("details")
+	blockchainType := details.GetString("type")
+	if _, ok := out["description"]; !ok {
+		if internalType := details.GetString("internalType"); internalType != "" {
+			out["description"] = internalType
+		} else if blockchainType != "" {
+			out["description"] = blockchainType
+		}
+	}
+
+	switch in.GetString("type") {
+	case "object":
+		properties := in.GetObject("properties")
+		outProps := make(fftypes.JSONObject, len(properties))
+		required := make([]string, 0, len(properties))
+		for name := range properties {
+			outProps[name] = openAPIParamSchema(properties.GetObject(name))
+			required = append(required, name)
+		}
+		out["properties"] = outProps
+		if blockchainType == "tuple" {
+			// Every component of a tuple must be supplied, in the order of the tuple
+			sort.Slice(required, func(i, j int) bool {
+				return properties.GetObject(required[i]).GetObject("details").GetInt64("index") <
+					properties.GetObject(required[j]).GetObject("details").GetInt64("index")
+			})
+			out["required"] = required
+		}
+	case "array":
+		if items, ok := in.GetObjectOk("items"); ok {
+			out["items"] = openAPIParamSchema(items)
+		}
+	case "integer":
+		addIntegerConstraints(out, blockchainType)
+	case "string":
+		addStringConstraints(out, blockchainType)
+	case "boolean":
+		setExample(out, true)
+	}
+	return out
+}
+
+func setExample(schema fftypes.JSONObject, example interface{}) {
+	if _, ok := schema["example"]; !ok {
+		schema["example"] = example
+	}
+}
+
+func addIntegerConstraints(schema fftypes.JSONObject, blockchainType string) {
+	setExample(schema, 0)
+	match := intTypeRegex.FindStringSubmatch(blockchainType)
+	if match == nil {
+		return
+	}
+	unsigned := match[1] == "u"
+	width := 256 // "int" and "uint" are aliases for the 256 bit types
+	if match[2] != "" {
+		width, _ = strconv.Atoi(match[2])
+	}
+	if unsigned {
+		schema["minimum"] = 0
+	}
+	bits := width
+	if unsigned {
+		bits++ // needs a sign bit to be represented as a signed integer
+	}
+	switch {
+	case bits <= 32:
+		schema["format"] = "int32"
+	case bits <= 64:
+		schema["format"] = "int64"
+	}
+	// Only set exact bounds when they can be represented precisely as a JSON number
+	if width <= 32 {
+		if unsigned {
+			schema["maximum"] = uint64(1)<<width - 1
+		} else {
+			schema["minimum"] = -(int64(1) << (width - 1))
+			schema["maximum"] = int64(1)<<(width-1) - 1
+		}
+	}
+}
+
+func addStringConstraints(schema fftypes.JSONObject, blockchainType string) {
+	switch {
+	case blockchainType == "address":
+		schema["pattern"] = "^(0x)?[0-9a-fA-F]{40}$"
+		setExample(schema, "0x"+strings.Repeat("0", 40))
+	case bytesTypeRegex.MatchString(blockchainType):
+		size, _ := strconv.Atoi(bytesTypeRegex.FindStringSubmatch(blockchainType)[1])
+		if size > 0 {
+			schema["pattern"] = fmt.Sprintf("^(0x)?[0-9a-fA-F]{%d}$", size*2)
+		} else {
+			size = 1
+		}
+		setExample(schema, "0x"+strings.Repeat("00", size))
+	case intTypeRegex.MatchString(blockchainType):
+		// Large integers can be passed as strings, to avoid loss of precision
+		schema["pattern"] = "^-?[0-9]+$"
+		setExample(schema, "0")
+	}
+}
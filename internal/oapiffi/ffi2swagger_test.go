@@ -91,6 +91,23 @@ func TestGenerate(t *testing.T) {
 	b, err := yaml.Marshal(doc)
 	assert.NoError(t, err)
 	fmt.Print(string(b))
+
+	assert.NotNil(t, doc.Paths["/invoke/method1"].Post)
+	assert.NotNil(t, doc.Paths["/query/method1"].Post)
+	assert.NotNil(t, doc.Paths["/subscribe/event1"].Post)
+	assert.NotNil(t, doc.Paths["/subscriptions"].Get)
+	assert.NotNil(t, doc.Paths["/subscriptions/{nameOrId}"].Delete)
+}
+
+func TestGenerateNoEvents(t *testing.T) {
+	g := NewFFISwaggerGen()
+	api := &fftypes.ContractAPI{}
+	ffi := testFFI()
+	ffi.Events = nil
+	doc := g.Generate(context.Background(), "http://localhost:12345", api, ffi)
+
+	assert.NotNil(t, doc.Paths["/invoke/method1"])
+	assert.Nil(t, doc.Paths["/subscriptions"])
 }
 
 func TestGenerateWithLocation(t *testing.T) {
@@ -111,3 +128,92 @@ func TestFFIParamBadSchema(t *testing.T) {
 	r := ffiParamJSONSchema(param)
 	assert.Nil(t, r)
 }
+
+func TestFFIParamNestedSchema(t *testing.T) {
+	param := &fftypes.FFIParam{
+		Name: "order",
+		Schema: fftypes.JSONAnyPtr(`{
+			"type": "object",
+			"details": {"type": "tuple", "internalType": "struct Exchange.Order"},
+			"properties": {
+				"maker": {"type": "string", "details": {"type": "address", "index": 1}},
+				"id": {"type": "integer", "details": {"type": "uint64", "index": 0}},
+				"lines": {
+					"type": "array",
+					"details": {"type": "tuple[]", "index": 2},
+					"items": {
+						"type": "object",
+						"details": {"type": "tuple"},
+						"properties": {
+							"qty": {"type": "integer", "details": {"type": "uint8", "index": 0}},
+							"delta": {"type": "integer", "details": {"type": "int16", "index": 1}},
+							"total": {"type": "string", "details": {"type": "uint256", "index": 2}},
+							"sku": {"type": "string", "details": {"type": "bytes4", "index": 3}},
+							"memo": {"type": "string", "details": {"type": "bytes", "index": 4}},
+							"ok": {"type": "boolean", "details": {"type": "bool", "index": 5}},
+							"big": {"type": "integer", "details": {"type": "int", "index": 6}}
+						}
+					}
+				}
+			}
+		}`),
+	}
+	r := ffiParamJSONSchema(param)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"description": "struct Exchange.Order",
+		"required": ["id", "maker", "lines"],
+		"properties": {
+			"maker": {"type": "string", "description": "address", "pattern": "^(0x)?[0-9a-fA-F]{40}$", "example": "0x0000000000000000000000000000000000000000"},
+			"id": {"type": "integer", "description": "uint64", "minimum": 0, "example": 0},
+			"lines": {
+				"type": "array",
+				"description": "tuple[]",
+				"items": {
+					"type": "object",
+					"description": "tuple",
+					"required": ["qty", "delta", "total", "sku", "memo", "ok", "big"],
+					"properties": {
+						"qty": {"type": "integer", "description": "uint8", "format": "int32", "minimum": 0, "maximum": 255, "example": 0},
+						"delta": {"type": "integer", "description": "int16", "format": "int32", "minimum": -32768, "maximum": 32767, "example": 0},
+						"total": {"type": "string", "description": "uint256", "pattern": "^-?[0-9]+$", "example": "0"},
+						"sku": {"type": "string", "description": "bytes4", "pattern": "^(0x)?[0-9a-fA-F]{8}$", "example": "0x00000000"},
+						"memo": {"type": "string", "description": "bytes", "example": "0x00"},
+						"ok": {"type": "boolean", "description": "bool", "example": true},
+						"big": {"type": "integer", "description": "int", "example": 0}
+					}
+				}
+			}
+		}
+	}`, r.String())
+}
+
+func TestFFIParamIntegerWidths(t *testing.T) {
+	r := openAPIParamSchema(fftypes.JSONObject{"type": "integer", "details": fftypes.JSONObject{"type": "int64"}})
+	assert.Equal(t, "int64", r["format"])
+	assert.Nil(t, r["minimum"])
+	r = openAPIParamSchema(fftypes.JSONObject{"type": "integer", "details": fftypes.JSONObject{"type": "uint32"}})
+	assert.Equal(t, "int64", r["format"])
+	assert.Equal(t, uint64(4294967295), r["maximum"])
+	r = openAPIParamSchema(fftypes.JSONObject{"type": "integer", "description": "custom", "example": 42})
+	assert.Equal(t, "custom", r["description"])
+	assert.Equal(t, 42, r["example"])
+	assert.Nil(t, r["format"])
+}
+
+func TestGenerateNestedParams(t *testing.T) {
+	g := NewFFISwaggerGen()
+	api := &fftypes.ContractAPI{}
+	ffi := testFFI()
+	ffi.Methods[0].Params = append(ffi.Methods[0].Params, &fftypes.FFIParam{
+		Name:   "values",
+		Schema: fftypes.JSONAnyPtr(`{"type": "array", "details": {"type": "uint8[]"}, "items": {"type": "integer", "details": {"type": "uint8"}}}`),
+	})
+	doc := g.Generate(context.Background(), "http://localhost:12345", api, ffi)
+
+	input := doc.Paths["/invoke/method1"].Post.RequestBody.Value.Content["application/json"].Schema.Value.Properties["input"].Value
+	items := input.Properties["values"].Value.Items.Value
+	assert.Equal(t, "integer", items.Type)
+	assert.Equal(t, "int32", items.Format)
+	assert.Equal(t, float64(255), *items.Max)
+}
@@ -34,6 +34,9 @@ import (
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
+// errorSchemaName is the name of the component schema for the error object, returned by every route on failure
+const errorSchemaName = "Error"
+
 type SwaggerGenConfig struct {
 	BaseURL     string
 	Title       string
@@ -57,6 +60,7 @@ func SwaggerGen(ctx context.Context, routes []*Route, conf *SwaggerGenConfig) *o
 			Schemas: make(openapi3.Schemas),
 		},
 	}
+	doc.Components.Schemas[errorSchemaName], _ = openapi3gen.NewSchemaRefForValue(&fftypes.RESTError{}, doc.Components.Schemas)
 	opIds := make(map[string]bool)
 	for _, route := range routes {
 		if route.Name == "" || opIds[route.Name] {
@@ -113,12 +117,20 @@ func genSchemaRef(ctx context.Context, doc *openapi3.T, obj interface{}, mask []
 	return schemaRef
 }
 
-func addInput(ctx context.Context, doc *openapi3.T, input interface{}, mask []string, schemaDef func(context.Context) string, op *openapi3.Operation) {
+func addInput(ctx context.Context, doc *openapi3.T, input interface{}, mask []string, schemaDef func(context.Context) string, example func() interface{}, op *openapi3.Operation) {
 	op.RequestBody.Value.Content["application/json"] = &openapi3.MediaType{
-		Schema: genSchemaRef(ctx, doc, input, mask, schemaDef),
+		Schema:  genSchemaRef(ctx, doc, input, mask, schemaDef),
+		Example: genExample(example),
 	}
 }
 
+func genExample(example func() interface{}) interface{} {
+	if example == nil {
+		return nil
+	}
+	return example()
+}
+
 func addFormInput(ctx context.Context, op *openapi3.Operation, formParams []*FormParam) {
 	props := openapi3.Schemas{
 		"filename.ext": &openapi3.SchemaRef{
@@ -147,6 +159,17 @@ func addFormInput(ctx context.Context, op *openapi3.Operation, formParams []*For
 	}
 }
 
+func addStreamInput(op *openapi3.Operation) {
+	op.RequestBody.Value.Content["application/octet-stream"] = &openapi3.MediaType{
+		Schema: &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type:   "string",
+				Format: "binary",
+			},
+		},
+	}
+}
+
 func addOutput(ctx context.Context, doc *openapi3.T, route *Route, output interface{}, schemaDef func(context.Context) string, op *openapi3.Operation) {
 	s := i18n.Expand(ctx, i18n.MsgSuccessResponse)
 	for _, code := range route.JSONOutputCodes {
@@ -155,7 +178,8 @@ func addOutput(ctx context.Context, doc *openapi3.T, route *Route, output interf
 				Description: &s,
 				Content: openapi3.Content{
 					"application/json": &openapi3.MediaType{
-						Schema: genSchemaRef(ctx, doc, output, nil, schemaDef),
+						Schema:  genSchemaRef(ctx, doc, output, nil, schemaDef),
+						Example: genExample(route.JSONOutputExample),
 					},
 				},
 			},
@@ -163,6 +187,20 @@ func addOutput(ctx context.Context, doc *openapi3.T, route *Route, output interf
 	}
 }
 
+// errorResponse is the default response of every operation, for all the status codes that are not a success
+func errorResponse(ctx context.Context, doc *openapi3.T) *openapi3.ResponseRef {
+	s := i18n.Expand(ctx, i18n.MsgErrorResponse)
+	return &openapi3.ResponseRef{
+		Value: &openapi3.Response{
+			Description: &s,
+			Content: openapi3.NewContentWithJSONSchemaRef(&openapi3.SchemaRef{
+				Ref:   "#/components/schemas/" + errorSchemaName,
+				Value: doc.Components.Schemas[errorSchemaName].Value,
+			}),
+		},
+	}
+}
+
 func addParam(ctx context.Context, op *openapi3.Operation, in, name, def, example string, description i18n.MessageKey, deprecated bool, msgArgs ...interface{}) {
 	required := false
 	if in == "path" {
@@ -199,7 +237,7 @@ func addRoute(ctx context.Context, doc *openapi3.T, route *Route) {
 	op := &openapi3.Operation{
 		Description: i18n.Expand(ctx, route.Description),
 		OperationID: route.Name,
-		Responses:   openapi3.NewResponses(),
+		Responses:   openapi3.Responses{"default": errorResponse(ctx, doc)},
 		Deprecated:  route.Deprecated,
 	}
 	if route.Method != http.MethodGet && route.Method != http.MethodDelete {
@@ -209,11 +247,14 @@ func addRoute(ctx context.Context, doc *openapi3.T, route *Route) {
 		}
 		initInput(op)
 		if input != nil || route.JSONInputSchema != nil {
-			addInput(ctx, doc, input, route.JSONInputMask, route.JSONInputSchema, op)
+			addInput(ctx, doc, input, route.JSONInputMask, route.JSONInputSchema, route.JSONInputExample, op)
 		}
 		if route.FormUploadHandler != nil {
 			addFormInput(ctx, op, route.FormParams)
 		}
+		if route.StreamUploadHandler != nil {
+			addStreamInput(op)
+		}
 	}
 	var output interface{}
 	if route.JSONOutputValue != nil {
@@ -249,12 +290,15 @@ func addRoute(ctx context.Context, doc *openapi3.T, route *Route) {
 		addParam(ctx, op, "query", "skip", "", "", i18n.MsgFilterSkipDesc, false, config.GetUint(config.APIMaxFilterSkip))
 		addParam(ctx, op, "query", "limit", "", config.GetString(config.APIDefaultFilterLimit), i18n.MsgFilterLimitDesc, false, config.GetUint(config.APIMaxFilterLimit))
 		addParam(ctx, op, "query", "count", "", "", i18n.MsgFilterCountDesc, false)
+		addParam(ctx, op, "query", "fields", "", "", i18n.MsgFilterFieldsDesc, false)
 	}
 	switch route.Method {
 	case http.MethodGet:
 		pi.Get = op
 	case http.MethodPut:
 		pi.Put = op
+	case http.MethodPatch:
+		pi.Patch = op
 	case http.MethodPost:
 		pi.Post = op
 	case http.MethodDelete:
@@ -263,7 +307,7 @@ func addRoute(ctx context.Context, doc *openapi3.T, route *Route) {
 }
 
 func maskFieldsOnStruct(t reflect.Type, mask []string) reflect.Type {
-	if mask == nil {
+	if mask == nil && !hasShadowedFields(t) {
 		return t
 	}
 	fieldCount := t.NumField()
@@ -275,8 +319,72 @@ func maskFieldsOnStruct(t reflect.Type, mask []string) reflect.Type {
 				field.Tag = "`json:-`"
 			}
 		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			field.Type = hideShadowedFields(field.Type, jsonFieldNames(t))
+		}
+		newFields[i] = field
+	}
+	return reflect.StructOf(newFields)
+}
+
+// jsonFieldName returns the name a field is serialized with, or "" for embedded and excluded fields
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	switch {
+	case name == "-" || (field.Anonymous && name == ""):
+		return ""
+	case name == "":
+		return field.Name
+	default:
+		return name
+	}
+}
+
+// jsonFieldNames returns the serialized names of the fields declared directly on a struct
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonFieldName(t.Field(i)); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// hasShadowedFields returns true if a field declared on the struct has the same serialized name as a
+// field of an embedded struct. The schema generator does not resolve these the way encoding/json does,
+// and picks one of the two arbitrarily.
+func hasShadowedFields(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	names := jsonFieldNames(t)
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if hideShadowedFields(field.Type, names) != field.Type {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hideShadowedFields returns a copy of an embedded struct, excluding the fields that are shadowed by
+// fields of the outer struct
+func hideShadowedFields(t reflect.Type, outer map[string]bool) reflect.Type {
+	shadowed := false
+	newFields := make([]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if outer[jsonFieldName(field)] {
+			field.Tag = `json:"-"`
+			shadowed = true
+		}
 		newFields[i] = field
 	}
+	if !shadowed {
+		return t
+	}
 	return reflect.StructOf(newFields)
 }
 
@@ -113,15 +113,30 @@ var testRoutes = []*Route{
 		JSONOutputCodes: []int{http.StatusNoContent},
 	},
 	{
-		Name:            "op5",
-		Path:            "example2",
-		Method:          http.MethodPost,
-		PathParams:      nil,
+		Name:                "op5",
+		Path:                "example2",
+		Method:              http.MethodPost,
+		PathParams:          nil,
+		QueryParams:         nil,
+		FilterFactory:       nil,
+		Description:         i18n.MsgTBD,
+		JSONInputValue:      func() interface{} { return &fftypes.Data{} },
+		JSONInputMask:       []string{"id"},
+		JSONOutputValue:     func() interface{} { return &fftypes.Data{} },
+		JSONOutputCodes:     []int{http.StatusOK},
+		StreamUploadHandler: func(r *APIRequest) (output interface{}, err error) { return nil, nil },
+	},
+	{
+		Name:   "op6",
+		Path:   "example2/{id}",
+		Method: http.MethodPatch,
+		PathParams: []*PathParam{
+			{Name: "id", Description: i18n.MsgTBD},
+		},
 		QueryParams:     nil,
 		FilterFactory:   nil,
 		Description:     i18n.MsgTBD,
-		JSONInputValue:  func() interface{} { return &fftypes.Data{} },
-		JSONInputMask:   []string{"id"},
+		JSONInputValue:  func() interface{} { return &fftypes.JSONObject{} },
 		JSONOutputValue: func() interface{} { return &fftypes.Data{} },
 		JSONOutputCodes: []int{http.StatusOK},
 	},
@@ -178,3 +193,75 @@ func TestBadCustomSchema(t *testing.T) {
 		})
 	})
 }
+
+func TestExamplesEnumsAndErrors(t *testing.T) {
+	config.Reset()
+	routes := []*Route{
+		{
+			Name:              "op1",
+			Path:              "example1",
+			Method:            http.MethodPost,
+			Description:       i18n.MsgTBD,
+			JSONInputValue:    func() interface{} { return &fftypes.MessageInOut{} },
+			JSONInputExample:  func() interface{} { return &fftypes.MessageInOut{OnBehalfOf: "org1"} },
+			JSONOutputValue:   func() interface{} { return &fftypes.Message{} },
+			JSONOutputExample: func() interface{} { return &fftypes.Message{State: fftypes.MessageStateReady} },
+			JSONOutputCodes:   []int{http.StatusOK},
+		},
+	}
+	doc := SwaggerGen(context.Background(), routes, &SwaggerGenConfig{
+		Title:   "UnitTest",
+		Version: "1.0",
+		BaseURL: "http://localhost:12345/api/v1",
+	})
+	err := doc.Validate(context.Background())
+	assert.NoError(t, err)
+
+	op := doc.Paths["/example1"].Post
+	assert.Equal(t, "org1", op.RequestBody.Value.Content["application/json"].Example.(*fftypes.MessageInOut).OnBehalfOf)
+	assert.Equal(t, fftypes.MessageStateReady, op.Responses["200"].Value.Content["application/json"].Example.(*fftypes.Message).State)
+
+	errSchema := op.Responses["default"].Value.Content["application/json"].Schema
+	assert.Equal(t, "#/components/schemas/Error", errSchema.Ref)
+	assert.Contains(t, doc.Components.Schemas["Error"].Value.Properties, "error")
+
+	headerType := op.Responses["200"].Value.Content["application/json"].Schema.Value.Properties["header"].Value.Properties["type"]
+	assert.Contains(t, headerType.Value.Enum, "broadcast")
+}
+
+func TestShadowedEmbeddedFields(t *testing.T) {
+	config.Reset()
+	routes := []*Route{
+		{
+			Name:            "op1",
+			Path:            "example1",
+			Method:          http.MethodPost,
+			Description:     i18n.MsgTBD,
+			JSONInputValue:  func() interface{} { return &fftypes.TokenTransferInput{} },
+			JSONInputMask:   []string{"Metadata"},
+			JSONOutputCodes: []int{http.StatusOK},
+		},
+		{
+			Name:            "op2",
+			Path:            "example2",
+			Method:          http.MethodPost,
+			Description:     i18n.MsgTBD,
+			JSONInputValue:  func() interface{} { return &fftypes.TokenTransferInput{} },
+			JSONOutputCodes: []int{http.StatusOK},
+		},
+	}
+	doc := SwaggerGen(context.Background(), routes, &SwaggerGenConfig{
+		Title:   "UnitTest",
+		Version: "1.0",
+		BaseURL: "http://localhost:12345/api/v1",
+	})
+	err := doc.Validate(context.Background())
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/example1", "/example2"} {
+		props := doc.Paths[path].Post.RequestBody.Value.Content["application/json"].Schema.Value.Properties
+		assert.Contains(t, props["message"].Value.Properties, "header")
+		assert.Equal(t, "string", props["pool"].Value.Type)
+		assert.Contains(t, props, "idempotencyKey")
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/rbac"
 	"github.com/hyperledger/firefly/pkg/database"
 )
 
@@ -55,12 +56,24 @@ type Route struct {
 	JSONOutputSchema func(ctx context.Context) string
 	// JSONOutputValue is a function that returns a pointer to a structure to take JSON output
 	JSONOutputValue func() interface{}
+	// JSONInputExample is a function that returns an example of the JSON input, to include in the Swagger definition
+	JSONInputExample func() interface{}
+	// JSONOutputExample is a function that returns an example of the JSON output, to include in the Swagger definition
+	JSONOutputExample func() interface{}
 	// JSONOutputCodes is the success response code
 	JSONOutputCodes []int
 	// JSONHandler is a function for handling JSON content type input. Input/Ouptut objects are returned by JSONInputValue/JSONOutputValue funcs
 	JSONHandler func(r *APIRequest) (output interface{}, err error)
 	// FormUploadHandler takes a single file upload, and returns a JSON object
 	FormUploadHandler func(r *APIRequest) (output interface{}, err error)
+	// StreamUploadHandler takes the raw request body as a single binary stream, with any parameters passed on the query string
+	StreamUploadHandler func(r *APIRequest) (output interface{}, err error)
+	// Transactional runs the handler inside a single database transaction, so all objects it writes are committed together or not at all.
+	// Must not be set on routes that block waiting for confirmation, as the objects they wait on are not visible until the handler returns
+	Transactional bool
+	// RequiredRole is the role the caller must hold for the namespace of the request, when role based access control is enabled.
+	// Defaults to reader for GET routes, and sender for all others
+	RequiredRole rbac.Role
 	// Deprecated whether this route is deprecated
 	Deprecated bool
 }
@@ -0,0 +1,45 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecordAudit(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("InsertAuditRecord", mock.Anything, mock.MatchedBy(func(record *fftypes.AuditRecord) bool {
+		return record.ID != nil && record.Operation == "postNewMessageBroadcast"
+	})).Return(nil)
+	err := or.RecordAudit(context.Background(), &fftypes.AuditRecord{Operation: "postNewMessageBroadcast"})
+	assert.NoError(t, err)
+}
+
+func TestGetAuditRecords(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetAuditRecords", mock.Anything, mock.Anything).Return([]*fftypes.AuditRecord{}, nil, nil)
+	fb := database.AuditRecordQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("identity", "app1"))
+	_, _, err := or.GetAuditRecords(context.Background(), f)
+	assert.NoError(t, err)
+}
@@ -21,24 +21,31 @@ import (
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/hyperledger/firefly/pkg/tokens"
 )
 
 type boundCallbacks struct {
-	bi blockchain.Plugin
-	dx dataexchange.Plugin
-	ei events.EventManager
+	bi     blockchain.Plugin
+	dx     dataexchange.Plugin
+	ei     events.EventManager
+	ledger string
 }
 
-func (bc *boundCallbacks) BlockchainOpUpdate(operationID *fftypes.UUID, txState blockchain.TransactionStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject) error {
-	return bc.ei.OperationUpdate(bc.bi, operationID, txState, blockchainTXID, errorMessage, opOutput)
+func (bc *boundCallbacks) BlockchainOpUpdate(operationID *fftypes.UUID, txState blockchain.TransactionStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject, receipt *fftypes.TransactionReceipt) error {
+	return bc.ei.OperationUpdate(bc.bi, operationID, txState, blockchainTXID, errorMessage, opOutput, receipt)
 }
 
 func (bc *boundCallbacks) TokenOpUpdate(plugin tokens.Plugin, operationID *fftypes.UUID, txState fftypes.OpStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject) error {
-	return bc.ei.OperationUpdate(plugin, operationID, txState, blockchainTXID, errorMessage, opOutput)
+	return bc.ei.OperationUpdate(plugin, operationID, txState, blockchainTXID, errorMessage, opOutput, nil)
+}
+
+func (bc *boundCallbacks) PublicStorageOpUpdate(plugin publicstorage.Plugin, operationID *fftypes.UUID, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error {
+	return bc.ei.OperationUpdate(plugin, operationID, txState, "", errorMessage, opOutput, nil)
 }
 
 func (bc *boundCallbacks) BatchPinComplete(batch *blockchain.BatchPin, signingIdentity string) error {
+	batch.Ledger = bc.ledger
 	return bc.ei.BatchPinComplete(bc.bi, batch, signingIdentity)
 }
 
@@ -23,6 +23,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/eventmocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -36,7 +37,7 @@ func TestBoundCallbacks(t *testing.T) {
 	mbi := &blockchainmocks.Plugin{}
 	mdx := &dataexchangemocks.Plugin{}
 	mti := &tokenmocks.Plugin{}
-	bc := boundCallbacks{bi: mbi, dx: mdx, ei: mei}
+	bc := boundCallbacks{bi: mbi, dx: mdx, ei: mei, ledger: "ledger2"}
 
 	info := fftypes.JSONObject{"hello": "world"}
 	batch := &blockchain.BatchPin{TransactionID: fftypes.NewUUID()}
@@ -49,15 +50,22 @@ func TestBoundCallbacks(t *testing.T) {
 	mei.On("BatchPinComplete", mbi, batch, "0x12345").Return(fmt.Errorf("pop"))
 	err := bc.BatchPinComplete(batch, "0x12345")
 	assert.EqualError(t, err, "pop")
+	assert.Equal(t, "ledger2", batch.Ledger)
 
-	mei.On("OperationUpdate", mbi, opID, fftypes.OpStatusFailed, "0xffffeeee", "error info", info).Return(fmt.Errorf("pop"))
-	err = bc.BlockchainOpUpdate(opID, fftypes.OpStatusFailed, "0xffffeeee", "error info", info)
+	receipt := &fftypes.TransactionReceipt{BlockNumber: fftypes.NewFFBigInt(12345)}
+	mei.On("OperationUpdate", mbi, opID, fftypes.OpStatusFailed, "0xffffeeee", "error info", info, receipt).Return(fmt.Errorf("pop"))
+	err = bc.BlockchainOpUpdate(opID, fftypes.OpStatusFailed, "0xffffeeee", "error info", info, receipt)
 	assert.EqualError(t, err, "pop")
 
-	mei.On("OperationUpdate", mti, opID, fftypes.OpStatusFailed, "0xffffeeee", "error info", info).Return(fmt.Errorf("pop"))
+	mei.On("OperationUpdate", mti, opID, fftypes.OpStatusFailed, "0xffffeeee", "error info", info, (*fftypes.TransactionReceipt)(nil)).Return(fmt.Errorf("pop"))
 	err = bc.TokenOpUpdate(mti, opID, fftypes.OpStatusFailed, "0xffffeeee", "error info", info)
 	assert.EqualError(t, err, "pop")
 
+	mps := &publicstoragemocks.Plugin{}
+	mei.On("OperationUpdate", mps, opID, fftypes.OpStatusSucceeded, "", "", info, (*fftypes.TransactionReceipt)(nil)).Return(fmt.Errorf("pop"))
+	err = bc.PublicStorageOpUpdate(mps, opID, fftypes.OpStatusSucceeded, "", info)
+	assert.EqualError(t, err, "pop")
+
 	mei.On("TransferResult", mdx, "tracking12345", fftypes.OpStatusFailed, mock.Anything).Return(fmt.Errorf("pop"))
 	err = bc.TransferResult("tracking12345", fftypes.OpStatusFailed, fftypes.TransportStatusUpdate{
 		Error: "error info", Info: info,
@@ -0,0 +1,38 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (or *orchestrator) GetAggregatorCheckpoint(ctx context.Context, ledger string) (*fftypes.Offset, error) {
+	return or.events.GetAggregatorCheckpoint(ctx, ledger)
+}
+
+func (or *orchestrator) RewindAggregator(ctx context.Context, rewind *fftypes.AggregatorRewind) error {
+	return or.events.RewindAggregator(ctx, rewind)
+}
+
+func (or *orchestrator) StartCatchup(ctx context.Context, ns string, req *fftypes.CatchupRequest) (*fftypes.Operation, error) {
+	if err := or.verifyNamespaceSyntax(ctx, ns); err != nil {
+		return nil, err
+	}
+	return or.events.StartCatchup(ctx, ns, req)
+}
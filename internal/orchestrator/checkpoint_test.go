@@ -0,0 +1,58 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAggregatorCheckpoint(t *testing.T) {
+	or := newTestOrchestrator()
+	offset := &fftypes.Offset{Current: 12345}
+	or.mem.On("GetAggregatorCheckpoint", context.Background(), "ledger2").Return(offset, nil)
+	res, err := or.GetAggregatorCheckpoint(context.Background(), "ledger2")
+	assert.NoError(t, err)
+	assert.Equal(t, offset, res)
+}
+
+func TestRewindAggregator(t *testing.T) {
+	or := newTestOrchestrator()
+	rewind := &fftypes.AggregatorRewind{Sequence: 12345}
+	or.mem.On("RewindAggregator", context.Background(), rewind).Return(nil)
+	err := or.RewindAggregator(context.Background(), rewind)
+	assert.NoError(t, err)
+}
+
+func TestStartCatchup(t *testing.T) {
+	or := newTestOrchestrator()
+	req := &fftypes.CatchupRequest{Source: fftypes.CatchupSourceChain}
+	op := &fftypes.Operation{}
+	or.mem.On("StartCatchup", context.Background(), "ns1", req).Return(op, nil)
+	res, err := or.StartCatchup(context.Background(), "ns1", req)
+	assert.NoError(t, err)
+	assert.Equal(t, op, res)
+}
+
+func TestStartCatchupBadNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.StartCatchup(context.Background(), "!wrong", &fftypes.CatchupRequest{})
+	assert.Regexp(t, "FF10131", err)
+}
@@ -20,6 +20,8 @@ import (
 	"context"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -58,3 +60,45 @@ func (or *orchestrator) ResetConfig(ctx context.Context) {
 func (or *orchestrator) DeleteConfigRecord(ctx context.Context, key string) (err error) {
 	return or.database.DeleteConfigRecord(ctx, key)
 }
+
+func (or *orchestrator) GetLogLevels(ctx context.Context) *fftypes.LogLevels {
+	return &fftypes.LogLevels{
+		Level:   log.GetLevel(),
+		Modules: log.GetModuleLevels(),
+	}
+}
+
+// SetLogLevels changes the log levels of the running node, without a restart. The node level is left unchanged
+// if it is not supplied, and a module with an empty level goes back to logging at the node level.
+// Changes are not persisted, so the levels revert to the configuration when the node is restarted.
+func (or *orchestrator) SetLogLevels(ctx context.Context, levels *fftypes.LogLevels) (*fftypes.LogLevels, error) {
+	if _, ok := log.ParseLevel(levels.Level); levels.Level != "" && !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidLogLevel, levels.Level)
+	}
+	for _, level := range levels.Modules {
+		if _, ok := log.ParseLevel(level); level != "" && !ok {
+			return nil, i18n.NewError(ctx, i18n.MsgInvalidLogLevel, level)
+		}
+	}
+	if levels.Level != "" {
+		log.SetLevel(levels.Level)
+	}
+	for module, level := range levels.Modules {
+		log.SetModuleLevel(module, level)
+	}
+	log.L(ctx).Infof("Log levels changed to %s modules: %v", log.GetLevel(), log.GetModuleLevels())
+	return or.GetLogLevels(ctx), nil
+}
+
+// ReloadConfig reads the config file again, and applies the changes to settings that can be changed while the
+// node is running. Config records stored in the database are not re-applied, as they only apply on a reset.
+func (or *orchestrator) ReloadConfig(ctx context.Context) (*fftypes.ConfigReloadResult, error) {
+	applied, restartRequired, err := config.ReloadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &fftypes.ConfigReloadResult{
+		Applied:         applied,
+		RestartRequired: restartRequired,
+	}, nil
+}
@@ -19,9 +19,13 @@ package orchestrator
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -98,3 +102,62 @@ func TestResetConfig(t *testing.T) {
 	cancelFunc()
 	<-or.ctx.Done()
 }
+
+func TestGetSetLogLevels(t *testing.T) {
+	or := newTestOrchestrator()
+	defer log.ResetModuleLevels()
+	log.SetLevel("info")
+
+	levels, err := or.SetLogLevels(or.ctx, &fftypes.LogLevels{
+		Modules: map[string]string{"aggregator": "trace"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &fftypes.LogLevels{Level: "info", Modules: map[string]string{"aggregator": "trace"}}, levels)
+
+	levels, err = or.SetLogLevels(or.ctx, &fftypes.LogLevels{
+		Level:   "debug",
+		Modules: map[string]string{"aggregator": ""},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &fftypes.LogLevels{Level: "debug", Modules: map[string]string{}}, levels)
+	assert.Equal(t, levels, or.GetLogLevels(or.ctx))
+	log.SetLevel("info")
+}
+
+func TestSetLogLevelsInvalid(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.SetLogLevels(or.ctx, &fftypes.LogLevels{Level: "verbose"})
+	assert.Regexp(t, "FF10496.*verbose", err)
+	_, err = or.SetLogLevels(or.ctx, &fftypes.LogLevels{Modules: map[string]string{"batch": "loud"}})
+	assert.Regexp(t, "FF10496.*loud", err)
+	assert.Empty(t, log.GetModuleLevels())
+}
+
+func TestReloadConfig(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "reloadtest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	or := newTestOrchestrator()
+	config.Reset()
+	defer config.Reset()
+	err = ioutil.WriteFile(fileName, []byte("node:\n  name: node1\n"), 0600)
+	assert.NoError(t, err)
+	err = config.ReadConfig(fileName)
+	assert.NoError(t, err)
+
+	err = ioutil.WriteFile(fileName, []byte("node:\n  name: node2\n"), 0600)
+	assert.NoError(t, err)
+	result, err := or.ReloadConfig(or.ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Equal(t, []string{"node.name"}, result.RestartRequired)
+}
+
+func TestReloadConfigNoFile(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Reset()
+	_, err := or.ReloadConfig(or.ctx)
+	assert.Regexp(t, "FF10497", err)
+}
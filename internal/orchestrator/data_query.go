@@ -17,9 +17,14 @@
 package orchestrator
 
 import (
+	"bytes"
 	"context"
 	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"io/ioutil"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -113,7 +118,11 @@ func (or *orchestrator) GetDataByID(ctx context.Context, ns, id string) (*fftype
 	if err != nil {
 		return nil, err
 	}
-	return or.database.GetDataByID(ctx, u, true)
+	data, err := or.database.GetDataByID(ctx, u, true)
+	if err != nil || data != nil || or.archive == nil {
+		return data, err
+	}
+	return or.archive.GetDataByID(ctx, u)
 }
 
 func (or *orchestrator) GetDatatypeByID(ctx context.Context, ns, id string) (*fftypes.Datatype, error) {
@@ -147,7 +156,11 @@ func (or *orchestrator) GetEventByID(ctx context.Context, ns, id string) (*fftyp
 	if err != nil {
 		return nil, err
 	}
-	return or.database.GetEventByID(ctx, u)
+	event, err := or.database.GetEventByID(ctx, u)
+	if err != nil || event != nil || or.archive == nil {
+		return event, err
+	}
+	return or.archive.GetEventByID(ctx, u)
 }
 
 func (or *orchestrator) GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, *database.FilterResult, error) {
@@ -249,6 +262,9 @@ func (or *orchestrator) GetMessageEvents(ctx context.Context, ns, id string, fil
 		referencedIDs[i+1] = dataRef.ID
 	}
 	filter = filter.Condition(filter.Builder().In("reference", referencedIDs))
+	if err := or.warnArchived(ctx); err != nil {
+		return nil, nil, err
+	}
 	// Execute the filter
 	return or.database.GetEvents(ctx, filter)
 }
@@ -258,8 +274,26 @@ func (or *orchestrator) GetBatches(ctx context.Context, ns string, filter databa
 	return or.database.GetBatches(ctx, filter)
 }
 
+// warnArchived warns the caller of a list query that older records might have been moved into the archives
+func (or *orchestrator) warnArchived(ctx context.Context) error {
+	if or.archive == nil {
+		return nil
+	}
+	newest, err := or.archive.ArchivedBefore(ctx)
+	if err != nil {
+		return err
+	}
+	if newest != nil {
+		apiwarnings.Add(ctx, i18n.MsgWarnArchivedRecords, newest)
+	}
+	return nil
+}
+
 func (or *orchestrator) GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
+	if err := or.warnArchived(ctx); err != nil {
+		return nil, nil, err
+	}
 	return or.database.GetData(ctx, filter)
 }
 
@@ -284,9 +318,40 @@ func (or *orchestrator) GetOperations(ctx context.Context, ns string, filter dat
 
 func (or *orchestrator) GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
+	if err := or.warnArchived(ctx); err != nil {
+		return nil, nil, err
+	}
 	return or.database.GetEvents(ctx, filter)
 }
 
+func (or *orchestrator) ExportEvents(ctx context.Context, ns, prevHash string, filter database.AndFilter) (io.ReadCloser, error) {
+	var lastHash *fftypes.Bytes32
+	if prevHash != "" {
+		var err error
+		if lastHash, err = fftypes.ParseBytes32(ctx, prevHash); err != nil {
+			return nil, err
+		}
+	}
+	filter = or.scopeNS(ns, filter)
+	filter.Sort("sequence").Ascending()
+	if err := or.warnArchived(ctx); err != nil {
+		return nil, err
+	}
+	events, _, err := or.database.GetEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, event := range events {
+		record := fftypes.NewEventExportRecord(lastHash, event)
+		b, _ := json.Marshal(record)
+		buf.Write(b)
+		buf.WriteByte('\n')
+		lastHash = record.Hash
+	}
+	return ioutil.NopCloser(&buf), nil
+}
+
 func (or *orchestrator) GetBlockchainEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BlockchainEvent, error) {
 	return or.database.GetBlockchainEventByID(ctx, id)
 }
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/mocks/archivemocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -432,6 +434,17 @@ func TestGetMessageEventsBadMsgID(t *testing.T) {
 	assert.Nil(t, ev)
 }
 
+func TestGetMessageEventsArchivedFail(t *testing.T) {
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	fb := database.EventQueryFactory.NewFilter(context.Background())
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(&fftypes.Message{}, nil)
+	mar.On("ArchivedBefore", mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, _, err := or.GetMessageEvents(context.Background(), "ns1", fftypes.NewUUID().String(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
 func TestGetBatchByID(t *testing.T) {
 	or := newTestOrchestrator()
 	u := fftypes.NewUUID()
@@ -464,6 +477,19 @@ func TestGetDataByID(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetDataByIDArchived(t *testing.T) {
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	u := fftypes.NewUUID()
+	or.mdi.On("GetDataByID", mock.Anything, u, true).Return(nil, nil)
+	mar.On("GetDataByID", mock.Anything, u).Return(&fftypes.Data{ID: u}, nil)
+	data, err := or.GetDataByID(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, u, data.ID)
+	mar.AssertExpectations(t)
+}
+
 func TestGetDataByIDBadID(t *testing.T) {
 	or := newTestOrchestrator()
 	_, err := or.GetDataByID(context.Background(), "", "")
@@ -480,6 +506,43 @@ func TestGetData(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetDataArchived(t *testing.T) {
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	ctx := apiwarnings.WithWarnings(context.Background())
+	or.mdi.On("GetData", mock.Anything, mock.Anything).Return([]*fftypes.Data{}, nil, nil)
+	mar.On("ArchivedBefore", mock.Anything).Return(fftypes.Now(), nil)
+	fb := database.DataQueryFactory.NewFilter(ctx)
+	_, _, err := or.GetData(ctx, "ns1", fb.And())
+	assert.NoError(t, err)
+	assert.Len(t, apiwarnings.Get(ctx), 1)
+	assert.Regexp(t, "FF10525", apiwarnings.Get(ctx)[0])
+}
+
+func TestGetDataNotArchived(t *testing.T) {
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	ctx := apiwarnings.WithWarnings(context.Background())
+	or.mdi.On("GetData", mock.Anything, mock.Anything).Return([]*fftypes.Data{}, nil, nil)
+	mar.On("ArchivedBefore", mock.Anything).Return(nil, nil)
+	fb := database.DataQueryFactory.NewFilter(ctx)
+	_, _, err := or.GetData(ctx, "ns1", fb.And())
+	assert.NoError(t, err)
+	assert.Empty(t, apiwarnings.Get(ctx))
+}
+
+func TestGetDataArchivedFail(t *testing.T) {
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	mar.On("ArchivedBefore", mock.Anything).Return(nil, fmt.Errorf("pop"))
+	fb := database.DataQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetData(context.Background(), "ns1", fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
 func TestGetDatatypeByID(t *testing.T) {
 	or := newTestOrchestrator()
 	u := fftypes.NewUUID()
@@ -535,6 +598,19 @@ func TestGetEventByID(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetEventByIDArchived(t *testing.T) {
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	u := fftypes.NewUUID()
+	or.mdi.On("GetEventByID", mock.Anything, u).Return(nil, nil)
+	mar.On("GetEventByID", mock.Anything, u).Return(&fftypes.Event{ID: u}, nil)
+	event, err := or.GetEventByID(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, u, event.ID)
+	mar.AssertExpectations(t)
+}
+
 func TestGetEventIDBadID(t *testing.T) {
 	or := newTestOrchestrator()
 	_, err := or.GetEventByID(context.Background(), "", "")
@@ -571,6 +647,64 @@ func TestGetEvents(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetEventsArchivedFail(t *testing.T) {
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	mar.On("ArchivedBefore", mock.Anything).Return(nil, fmt.Errorf("pop"))
+	fb := database.EventQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetEvents(context.Background(), "ns1", fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestExportEvents(t *testing.T) {
+	or := newTestOrchestrator()
+	prevHash := fftypes.NewRandB32()
+	events := []*fftypes.Event{
+		fftypes.NewEvent(fftypes.EventTypeMessageConfirmed, "ns1", fftypes.NewUUID(), nil),
+		fftypes.NewEvent(fftypes.EventTypeMessageConfirmed, "ns1", fftypes.NewUUID(), nil),
+	}
+	or.mdi.On("GetEvents", mock.Anything, mock.Anything).Return(events, nil, nil)
+	fb := database.EventQueryFactory.NewFilter(context.Background())
+	f := fb.And()
+	r, err := or.ExportEvents(context.Background(), "ns1", prevHash.String(), f)
+	assert.NoError(t, err)
+
+	lastHash, count, err := fftypes.VerifyEventExport(context.Background(), r, prevHash)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NotNil(t, lastHash)
+
+	fi, err := f.Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "( namespace == 'ns1' ) sort=sequence", fi.String())
+}
+
+func TestExportEventsBadPrevHash(t *testing.T) {
+	or := newTestOrchestrator()
+	fb := database.EventQueryFactory.NewFilter(context.Background())
+	_, err := or.ExportEvents(context.Background(), "ns1", "!hash", fb.And())
+	assert.Regexp(t, "FF10232", err)
+}
+
+func TestExportEventsArchivedFail(t *testing.T) {
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	mar.On("ArchivedBefore", mock.Anything).Return(nil, fmt.Errorf("pop"))
+	fb := database.EventQueryFactory.NewFilter(context.Background())
+	_, err := or.ExportEvents(context.Background(), "ns1", "", fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestExportEventsFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetEvents", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	fb := database.EventQueryFactory.NewFilter(context.Background())
+	_, err := or.ExportEvents(context.Background(), "ns1", "", fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
 func TestGetBlockchainEventByID(t *testing.T) {
 	or := newTestOrchestrator()
 
@@ -0,0 +1,155 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+)
+
+// pluginHealthCheck checks the connectivity of a plugin, returning any details of the runtime to report
+type pluginHealthCheck struct {
+	pluginType string
+	name       string
+	plugin     string
+	check      func(ctx context.Context) (fftypes.JSONObject, error)
+}
+
+// GetPluginHealth checks the connectivity of each plugin that uses an external runtime. The checks run
+// in parallel, and the result of each is recorded so the times of the last success and error can be reported.
+func (or *orchestrator) GetPluginHealth(ctx context.Context) []*fftypes.PluginHealth {
+	checks := or.pluginHealthChecks()
+	results := make([]*fftypes.PluginHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, hc := range checks {
+		wg.Add(1)
+		go func(i int, hc *pluginHealthCheck) {
+			defer wg.Done()
+			details, err := hc.check(ctx)
+			results[i] = or.recordPluginHealth(hc, details, err)
+		}(i, hc)
+	}
+	wg.Wait()
+	return results
+}
+
+func (or *orchestrator) pluginHealthChecks() []*pluginHealthCheck {
+	checks := []*pluginHealthCheck{
+		{
+			pluginType: "database",
+			name:       or.database.Name(),
+			plugin:     or.database.Name(),
+			check: func(ctx context.Context) (fftypes.JSONObject, error) {
+				return nil, or.database.Ping(ctx)
+			},
+		},
+	}
+	addBlockchain := func(name string, bi blockchain.Plugin) {
+		checks = append(checks, &pluginHealthCheck{
+			pluginType: "blockchain",
+			name:       name,
+			plugin:     bi.Name(),
+			check:      func(ctx context.Context) (fftypes.JSONObject, error) { return connectorHealthCheck(bi) },
+		})
+	}
+	addBlockchain(or.blockchain.Name(), or.blockchain)
+	for _, name := range or.ledgerNames() {
+		addBlockchain(name, or.ledgers[name].bi)
+	}
+	addPublicStorage := func(name string, pi publicstorage.Plugin) {
+		checks = append(checks, &pluginHealthCheck{
+			pluginType: "publicstorage",
+			name:       name,
+			plugin:     pi.Name(),
+			check:      func(ctx context.Context) (fftypes.JSONObject, error) { return nil, pi.Ping(ctx) },
+		})
+	}
+	addPublicStorage(or.publicstorage.Name(), or.publicstorage)
+	for _, name := range or.publicStorageNames() {
+		addPublicStorage(name, or.publicstorages[name])
+	}
+	checks = append(checks, &pluginHealthCheck{
+		pluginType: "dataexchange",
+		name:       or.dataexchange.Name(),
+		plugin:     or.dataexchange.Name(),
+		check: func(ctx context.Context) (fftypes.JSONObject, error) {
+			peer, err := or.dataexchange.GetEndpointInfo(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return fftypes.JSONObject{"peer": peer.Peer}, nil
+		},
+	})
+	return checks
+}
+
+// connectorHealthCheck reports the result of the latest liveness probe of a blockchain connector, including the
+// state of the websocket the plugin uses to receive events. Connectors that are not probed are reported as healthy.
+func connectorHealthCheck(bi blockchain.Plugin) (fftypes.JSONObject, error) {
+	health := bi.ConnectorHealth()
+	if health == nil {
+		return nil, nil
+	}
+	details := fftypes.JSONObject{
+		"connected":   health.Connected,
+		"lastHealthy": health.LastHealthy,
+		"recoveries":  health.Recoveries,
+	}
+	if !health.Healthy || !health.Connected {
+		errMsg := health.Error
+		if errMsg == "" {
+			errMsg = "disconnected"
+		}
+		return details, errors.New(errMsg)
+	}
+	return details, nil
+}
+
+func (or *orchestrator) recordPluginHealth(hc *pluginHealthCheck, details fftypes.JSONObject, err error) *fftypes.PluginHealth {
+	or.healthMux.Lock()
+	defer or.healthMux.Unlock()
+	if or.pluginHealth == nil {
+		or.pluginHealth = make(map[string]*fftypes.PluginHealth)
+	}
+	key := hc.pluginType + "/" + hc.name
+	health, ok := or.pluginHealth[key]
+	if !ok {
+		health = &fftypes.PluginHealth{
+			Type:   hc.pluginType,
+			Name:   hc.name,
+			Plugin: hc.plugin,
+		}
+		or.pluginHealth[key] = health
+	}
+	now := fftypes.Now()
+	health.LastChecked = now
+	health.Details = details
+	health.Healthy = err == nil
+	if err == nil {
+		health.LastSuccess = now
+	} else {
+		health.LastError = err.Error()
+		health.LastErrorTime = now
+	}
+	healthCopy := *health
+	return &healthCopy
+}
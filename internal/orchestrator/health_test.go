@@ -0,0 +1,97 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetPluginHealth(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("Ping", mock.Anything).Return(nil)
+	or.mbi.On("ConnectorHealth").Return(&fftypes.ConnectorHealth{Healthy: true, Connected: true, Recoveries: 1})
+	mbi2 := &blockchainmocks.Plugin{}
+	mbi2.On("Name").Return("ethereum")
+	mbi2.On("ConnectorHealth").Return(nil)
+	or.ledgers = map[string]*boundCallbacks{"ledger1": {bi: mbi2}}
+	or.mps.On("Ping", mock.Anything).Return(fmt.Errorf("pop")).Once()
+	or.mps.On("Ping", mock.Anything).Return(nil)
+	mps2 := &publicstoragemocks.Plugin{}
+	mps2.On("Name").Return("ipfs")
+	mps2.On("Ping", mock.Anything).Return(nil)
+	or.publicstorages = map[string]publicstorage.Plugin{"ipfs2": mps2}
+	or.mdx.On("GetEndpointInfo", mock.Anything).Return(fftypes.DXInfo{Peer: "peer1"}, nil)
+
+	health := or.GetPluginHealth(or.ctx)
+	assert.Len(t, health, 6)
+	assert.Equal(t, "database", health[0].Type)
+	assert.True(t, health[0].Healthy)
+	assert.NotNil(t, health[0].LastSuccess)
+	assert.Equal(t, "blockchain", health[1].Type)
+	assert.True(t, health[1].Healthy)
+	assert.Equal(t, int64(1), health[1].Details["recoveries"])
+	assert.Equal(t, "ledger1", health[2].Name)
+	assert.Equal(t, "ethereum", health[2].Plugin)
+	assert.True(t, health[2].Healthy)
+	assert.Equal(t, "publicstorage", health[3].Type)
+	assert.False(t, health[3].Healthy)
+	assert.Equal(t, "pop", health[3].LastError)
+	assert.NotNil(t, health[3].LastErrorTime)
+	assert.Nil(t, health[3].LastSuccess)
+	assert.Equal(t, "ipfs2", health[4].Name)
+	assert.Equal(t, "dataexchange", health[5].Type)
+	assert.Equal(t, "peer1", health[5].Details["peer"])
+
+	// The last error is kept once the plugin recovers
+	health = or.GetPluginHealth(or.ctx)
+	assert.True(t, health[3].Healthy)
+	assert.Equal(t, "pop", health[3].LastError)
+	assert.NotNil(t, health[3].LastSuccess)
+}
+
+func TestGetPluginHealthErrors(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("Ping", mock.Anything).Return(fmt.Errorf("pop"))
+	or.mbi.On("ConnectorHealth").Return(&fftypes.ConnectorHealth{Healthy: true, Connected: false})
+	or.mps.On("Ping", mock.Anything).Return(nil)
+	or.mdx.On("GetEndpointInfo", mock.Anything).Return(fftypes.DXInfo{}, fmt.Errorf("pop"))
+
+	health := or.GetPluginHealth(or.ctx)
+	assert.Len(t, health, 4)
+	assert.False(t, health[0].Healthy)
+	assert.False(t, health[1].Healthy)
+	assert.Equal(t, "disconnected", health[1].LastError)
+	assert.Equal(t, false, health[1].Details["connected"])
+	assert.True(t, health[2].Healthy)
+	assert.False(t, health[3].Healthy)
+	assert.Nil(t, health[3].Details)
+}
+
+func TestConnectorHealthCheckError(t *testing.T) {
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("ConnectorHealth").Return(&fftypes.ConnectorHealth{Healthy: false, Connected: true, Error: "FF10999: pop"})
+	_, err := connectorHealthCheck(mbi)
+	assert.EqualError(t, err, "FF10999: pop")
+}
@@ -0,0 +1,96 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hyperledger/firefly/internal/blockchain/bifactory"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// initLedgers loads a blockchain plugin instance for each of the additional named ledgers, alongside the
+// default ledger of the node, and resolves the ledger each predefined namespace pins its batches to
+func (or *orchestrator) initLedgers(ctx context.Context) (err error) {
+	if or.ledgers == nil {
+		or.ledgers = make(map[string]*boundCallbacks)
+		ledgersConfigArraySize := ledgersConfig.ArraySize()
+		for i := 0; i < ledgersConfigArraySize; i++ {
+			prefix := ledgersConfig.ArrayEntry(i)
+			name := prefix.GetString(blockchain.LedgerConfigName)
+			pluginType := prefix.GetString(blockchain.LedgerConfigType)
+			if name == "" {
+				return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, blockchain.LedgerConfigName, "ledgers")
+			}
+			if pluginType == "" {
+				return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, blockchain.LedgerConfigType, "ledgers")
+			}
+			if err = fftypes.ValidateFFNameField(ctx, name, "name"); err != nil {
+				return err
+			}
+			if _, exists := or.ledgers[name]; exists {
+				return i18n.NewError(ctx, i18n.MsgDuplicateLedgerName, name)
+			}
+
+			log.L(ctx).Infof("Loading ledger name=%s type=%s", name, pluginType)
+			plugin, err := bifactory.GetPlugin(ctx, pluginType)
+			if err != nil {
+				return err
+			}
+			lbc := &boundCallbacks{bi: plugin, ledger: name}
+			pluginPrefix := prefix.SubPrefix(pluginType)
+			plugin.InitPrefix(pluginPrefix)
+			if err = plugin.Init(ctx, pluginPrefix, lbc); err != nil {
+				return err
+			}
+			or.ledgers[name] = lbc
+		}
+	}
+
+	or.nsLedgers = make(map[string]blockchain.Plugin)
+	or.nsLedgerNames = make(map[string]string)
+	for _, nsObj := range config.GetObjectArray(config.NamespacesPredefined) {
+		name := nsObj.GetString("name")
+		ledger := nsObj.GetString("ledger")
+		if ledger == "" {
+			continue // pins to the default ledger
+		}
+		lbc, ok := or.ledgers[ledger]
+		if !ok {
+			return i18n.NewError(ctx, i18n.MsgUnknownNamespaceLedger, ledger, name)
+		}
+		log.L(ctx).Infof("Namespace '%s' pins to ledger '%s'", name, ledger)
+		or.nsLedgers[name] = lbc.bi
+		or.nsLedgerNames[name] = ledger
+	}
+	return nil
+}
+
+// ledgerNames returns the sorted names of the additional ledgers
+func (or *orchestrator) ledgerNames() []string {
+	names := make([]string, 0, len(or.ledgers))
+	for name := range or.ledgers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
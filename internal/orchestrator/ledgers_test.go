@@ -0,0 +1,183 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/blockchain/bifactory"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestEthconnect(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/eventstreams":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "es12345"})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "sub12345"})
+		}
+	}))
+}
+
+func setTestLedgers(ledgers ...fftypes.JSONObject) {
+	// Viper only navigates into arrays that are loaded as config (rather than set)
+	b, _ := json.Marshal(fftypes.JSONObject{"ledgers": ledgers})
+	viper.SetConfigType("json")
+	_ = viper.ReadConfig(bytes.NewReader(b))
+	bifactory.InitLedgersPrefix(ledgersConfig)
+}
+
+func TestInitLedgersOK(t *testing.T) {
+	or := newTestOrchestrator()
+	defer or.cancelCtx()
+	server := newTestEthconnect(t)
+	defer server.Close()
+
+	setTestLedgers(fftypes.JSONObject{
+		"name": "ledger2",
+		"type": "ethereum",
+		"ethereum": fftypes.JSONObject{
+			"ethconnect": fftypes.JSONObject{
+				"url":      server.URL,
+				"instance": "0x12345",
+				"topic":    "topic1",
+			},
+		},
+	})
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "default"},
+		{"name": "ns2", "ledger": "ledger2"},
+	})
+	or.ledgers = nil
+
+	err := or.initLedgers(or.ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"ledger2"}, or.ledgerNames())
+	lbc := or.ledgers["ledger2"]
+	assert.Equal(t, "ledger2", lbc.ledger)
+	assert.Equal(t, "ethereum", lbc.bi.Name())
+	assert.Len(t, or.nsLedgers, 1)
+	assert.Equal(t, lbc.bi, or.nsLedgers["ns2"])
+	assert.Equal(t, map[string]string{"ns2": "ledger2"}, or.nsLedgerNames)
+}
+
+func TestInitLedgersMissingName(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestLedgers(fftypes.JSONObject{"type": "ethereum"})
+	or.ledgers = nil
+
+	err := or.initLedgers(or.ctx)
+	assert.Regexp(t, "FF10138.*name", err)
+}
+
+func TestInitLedgersMissingType(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestLedgers(fftypes.JSONObject{"name": "ledger2"})
+	or.ledgers = nil
+
+	err := or.initLedgers(or.ctx)
+	assert.Regexp(t, "FF10138.*type", err)
+}
+
+func TestInitLedgersBadName(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestLedgers(fftypes.JSONObject{"name": "!wrong", "type": "ethereum"})
+	or.ledgers = nil
+
+	err := or.initLedgers(or.ctx)
+	assert.Regexp(t, "FF10131.*'name'", err)
+}
+
+func TestInitLedgersUnknownType(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestLedgers(fftypes.JSONObject{"name": "ledger2", "type": "wrong"})
+	or.ledgers = nil
+
+	err := or.initLedgers(or.ctx)
+	assert.Regexp(t, "FF10110.*wrong", err)
+}
+
+func TestInitLedgersPluginInitFail(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestLedgers(fftypes.JSONObject{"name": "ledger2", "type": "ethereum"})
+	or.ledgers = nil
+
+	err := or.initLedgers(or.ctx)
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestInitLedgersDuplicateName(t *testing.T) {
+	or := newTestOrchestrator()
+	defer or.cancelCtx()
+	server := newTestEthconnect(t)
+	defer server.Close()
+
+	ledger := fftypes.JSONObject{
+		"name": "ledger2",
+		"type": "ethereum",
+		"ethereum": fftypes.JSONObject{
+			"ethconnect": fftypes.JSONObject{
+				"url":      server.URL,
+				"instance": "0x12345",
+				"topic":    "topic1",
+			},
+		},
+	}
+	setTestLedgers(ledger, ledger)
+	or.ledgers = nil
+
+	err := or.initLedgers(or.ctx)
+	assert.Regexp(t, "FF10402.*ledger2", err)
+}
+
+func TestInitLedgersUnknownNamespaceLedger(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "ns2", "ledger": "ledger2"},
+	})
+	or.ledgers = map[string]*boundCallbacks{}
+
+	err := or.initLedgers(context.Background())
+	assert.Regexp(t, "FF10404.*ledger2.*ns2", err)
+}
+
+func TestInitPluginsLedgersFail(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestLedgers(fftypes.JSONObject{"name": "ledger2"})
+	or.ledgers = nil
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := or.initPlugins(or.ctx)
+	assert.Regexp(t, "FF10138.*type", err)
+}
@@ -19,6 +19,7 @@ package orchestrator
 import (
 	"context"
 
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -29,3 +30,97 @@ func (or *orchestrator) RequestReply(ctx context.Context, ns string, msg *fftype
 	}
 	return or.PrivateMessaging().RequestReply(ctx, ns, msg)
 }
+
+// SubmitMessages validates and stores a list of broadcast and private messages in a single database transaction,
+// so either all of the messages are sent or none of them are.
+// Every message is validated before anything is written, even after one is rejected, so the caller can correct all
+// of the problems in the submission before retrying. The messages are then stored in order, stopping at the first
+// that fails, as a failed statement aborts the whole transaction on some databases (such as PostgreSQL).
+func (or *orchestrator) SubmitMessages(ctx context.Context, ns string, msgs []*fftypes.MessageInOut) ([]*fftypes.BulkMessageResult, error) {
+	maxMessages := config.GetInt(config.APIMaxBulkMessages)
+	if len(msgs) > maxMessages {
+		return nil, i18n.NewError(ctx, i18n.MsgTooManyItems, "messages", maxMessages, len(msgs))
+	}
+
+	results := make([]*fftypes.BulkMessageResult, len(msgs))
+	rejected := 0
+	reject := func(i int, err error) {
+		results[i].Status = fftypes.BulkMessageStatusRejected
+		results[i].Error = err.Error()
+		rejected++
+	}
+	idempotencyKeys := make(map[fftypes.IdempotencyKey]int)
+	for i, msg := range msgs {
+		results[i] = &fftypes.BulkMessageResult{Index: i, Status: fftypes.BulkMessageStatusAccepted}
+		if err := or.validateBulkMessage(ctx, msg, i, idempotencyKeys); err != nil {
+			reject(i, err)
+		}
+	}
+
+	var err error
+	if rejected == 0 {
+		err = or.database.RunAsGroup(ctx, func(ctx context.Context) error {
+			for i, msg := range msgs {
+				if err := or.submitMessage(ctx, ns, msg); err != nil {
+					// Roll back everything stored for the messages before this one
+					reject(i, err)
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err != nil && rejected == 0 {
+		return nil, err
+	}
+	for i, result := range results {
+		switch {
+		case rejected == 0:
+			result.ID = msgs[i].Header.ID
+		case result.Status == fftypes.BulkMessageStatusAccepted:
+			result.Status = fftypes.BulkMessageStatusAborted
+		}
+	}
+	return results, nil
+}
+
+// validateBulkMessage checks everything about a message that can be checked without writing to the database.
+// The data of the message is validated against its datatype as the message is stored.
+func (or *orchestrator) validateBulkMessage(ctx context.Context, msg *fftypes.MessageInOut, i int, idempotencyKeys map[fftypes.IdempotencyKey]int) error {
+	switch msg.Header.Type {
+	case fftypes.MessageTypePrivate:
+		if msg.Header.Group == nil && (msg.Group == nil || len(msg.Group.Members) == 0) {
+			return i18n.NewError(ctx, i18n.MsgGroupMustHaveMembers)
+		}
+	case fftypes.MessageTypeBroadcast, "":
+	default:
+		return i18n.NewError(ctx, i18n.MsgBulkMessageType, msg.Header.Type)
+	}
+	if err := msg.Header.Validate(ctx); err != nil {
+		return err
+	}
+	for j, d := range msg.InlineData {
+		if d == nil || (d.ID == nil && d.Value == nil && d.Blob == nil) {
+			return i18n.NewError(ctx, i18n.MsgDataMissing, j)
+		}
+	}
+	if msg.IdempotencyKey != "" {
+		// Two messages with the same key would fail the unique constraint part way through the transaction
+		if other, ok := idempotencyKeys[msg.IdempotencyKey]; ok {
+			return i18n.NewError(ctx, i18n.MsgBulkDuplicateIdempotencyKey, msg.IdempotencyKey, other)
+		}
+		idempotencyKeys[msg.IdempotencyKey] = i
+	}
+	return nil
+}
+
+func (or *orchestrator) submitMessage(ctx context.Context, ns string, msg *fftypes.MessageInOut) (err error) {
+	// Consistent with request/reply, a message with a group is private
+	if msg.Header.Type == fftypes.MessageTypePrivate ||
+		(msg.Header.Type == "" && (msg.Header.Group != nil || (msg.Group != nil && len(msg.Group.Members) > 0))) {
+		_, err = or.PrivateMessaging().SendMessage(ctx, ns, msg, false)
+	} else {
+		_, err = or.Broadcast().BroadcastMessage(ctx, ns, msg, false)
+	}
+	return err
+}
@@ -18,10 +18,13 @@ package orchestrator
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestRequestReplyMissingGroup(t *testing.T) {
@@ -44,3 +47,115 @@ func TestRequestReply(t *testing.T) {
 	_, err := or.RequestReply(context.Background(), "ns1", input)
 	assert.NoError(t, err)
 }
+
+func mockRunAsGroupPassthrough(or *testOrchestrator) {
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+}
+
+func TestSubmitMessages(t *testing.T) {
+	or := newTestOrchestrator()
+	mockRunAsGroupPassthrough(or)
+	msgs := []*fftypes.MessageInOut{
+		{},
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Type: fftypes.MessageTypeBroadcast}}},
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Type: fftypes.MessageTypePrivate, Group: fftypes.NewRandB32()}}},
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Group: fftypes.NewRandB32()}}},
+		{Group: &fftypes.InputGroup{Members: []fftypes.MemberInput{{Identity: "org1"}}}},
+	}
+	setID := func(args mock.Arguments) {
+		args[2].(*fftypes.MessageInOut).Header.ID = fftypes.NewUUID()
+	}
+	or.mbm.On("BroadcastMessage", mock.Anything, "ns1", msgs[0], false).Run(setID).Return(nil, nil)
+	or.mbm.On("BroadcastMessage", mock.Anything, "ns1", msgs[1], false).Run(setID).Return(nil, nil)
+	or.mpm.On("SendMessage", mock.Anything, "ns1", msgs[2], false).Run(setID).Return(nil, nil)
+	or.mpm.On("SendMessage", mock.Anything, "ns1", msgs[3], false).Run(setID).Return(nil, nil)
+	or.mpm.On("SendMessage", mock.Anything, "ns1", msgs[4], false).Run(setID).Return(nil, nil)
+
+	results, err := or.SubmitMessages(context.Background(), "ns1", msgs)
+	assert.NoError(t, err)
+	assert.Len(t, results, 5)
+	for i, result := range results {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, fftypes.BulkMessageStatusAccepted, result.Status)
+		assert.Equal(t, msgs[i].Header.ID, result.ID)
+		assert.NotNil(t, result.ID)
+	}
+	or.mbm.AssertExpectations(t)
+	or.mpm.AssertExpectations(t)
+}
+
+func TestSubmitMessagesRejected(t *testing.T) {
+	or := newTestOrchestrator()
+	msgs := []*fftypes.MessageInOut{
+		{},
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Type: fftypes.MessageTypeDefinition}}},
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Tag: "!bad"}}},
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Type: fftypes.MessageTypePrivate}}},
+		{InlineData: fftypes.InlineData{{}}},
+		{Message: fftypes.Message{IdempotencyKey: "key1"}},
+		{Message: fftypes.Message{IdempotencyKey: "key1"}},
+	}
+
+	results, err := or.SubmitMessages(context.Background(), "ns1", msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.BulkMessageStatusAborted, results[0].Status)
+	assert.Nil(t, results[0].ID)
+	assert.Equal(t, fftypes.BulkMessageStatusRejected, results[1].Status)
+	assert.Regexp(t, "FF10473", results[1].Error)
+	assert.Equal(t, fftypes.BulkMessageStatusRejected, results[2].Status)
+	assert.Regexp(t, "FF10131.*header.tag", results[2].Error)
+	assert.Equal(t, fftypes.BulkMessageStatusRejected, results[3].Status)
+	assert.Regexp(t, "FF10219", results[3].Error)
+	assert.Equal(t, fftypes.BulkMessageStatusRejected, results[4].Status)
+	assert.Regexp(t, "FF10205", results[4].Error)
+	assert.Equal(t, fftypes.BulkMessageStatusAborted, results[5].Status)
+	assert.Equal(t, fftypes.BulkMessageStatusRejected, results[6].Status)
+	assert.Regexp(t, "FF10522.*key1.*5", results[6].Error)
+
+	// Nothing is written when any message is invalid
+	or.mdi.AssertNotCalled(t, "RunAsGroup", mock.Anything, mock.Anything)
+}
+
+func TestSubmitMessagesStopsAtFirstFailure(t *testing.T) {
+	or := newTestOrchestrator()
+	mockRunAsGroupPassthrough(or)
+	msgs := []*fftypes.MessageInOut{
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Tag: "first"}}},
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Tag: "second"}}},
+		{Message: fftypes.Message{Header: fftypes.MessageHeader{Tag: "third"}}},
+	}
+	or.mbm.On("BroadcastMessage", mock.Anything, "ns1", msgs[0], false).Return(nil, nil).Once()
+	or.mbm.On("BroadcastMessage", mock.Anything, "ns1", msgs[1], false).Return(nil, fmt.Errorf("pop")).Once()
+
+	results, err := or.SubmitMessages(context.Background(), "ns1", msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.BulkMessageStatusAborted, results[0].Status)
+	assert.Equal(t, fftypes.BulkMessageStatusRejected, results[1].Status)
+	assert.Equal(t, "pop", results[1].Error)
+	assert.Equal(t, fftypes.BulkMessageStatusAborted, results[2].Status)
+	or.mbm.AssertExpectations(t)
+}
+
+func TestSubmitMessagesCommitFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return fmt.Errorf("pop")
+	})
+	msgs := []*fftypes.MessageInOut{{}}
+	or.mbm.On("BroadcastMessage", mock.Anything, "ns1", msgs[0], false).Return(nil, nil)
+
+	_, err := or.SubmitMessages(context.Background(), "ns1", msgs)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestSubmitMessagesTooMany(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.APIMaxBulkMessages, 1)
+	_, err := or.SubmitMessages(context.Background(), "ns1", []*fftypes.MessageInOut{{}, {}})
+	assert.Regexp(t, "FF10227", err)
+}
@@ -0,0 +1,174 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// CreateUpdateNamespace creates a local namespace on this node at runtime, or updates the description of an
+// existing local namespace. Batch processors and event dispatchers are started on demand for the messages and
+// subscriptions of the namespace, so it is ready for use as soon as it is persisted.
+func (or *orchestrator) CreateUpdateNamespace(ctx context.Context, ns *fftypes.Namespace) (*fftypes.Namespace, error) {
+	if err := ns.Validate(ctx, false); err != nil {
+		return nil, err
+	}
+	existing, err := or.database.GetNamespace(ctx, ns.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		// Broadcast namespaces are owned by the network, and the system namespace by FireFly itself
+		if existing.Type != fftypes.NamespaceTypeLocal {
+			return nil, i18n.NewError(ctx, i18n.MsgNamespaceNotLocal, ns.Name)
+		}
+		if existing.Retired != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgNamespaceRetired, ns.Name)
+		}
+		existing.Description = ns.Description
+		if err := or.database.UpsertNamespace(ctx, existing, true); err != nil {
+			return nil, err
+		}
+		log.L(ctx).Infof("Updated namespace '%s' [%s]", existing.Name, existing.ID)
+		return existing, nil
+	}
+
+	ns.ID = fftypes.NewUUID()
+	ns.Message = nil
+	ns.Type = fftypes.NamespaceTypeLocal
+	ns.Created = fftypes.Now()
+	ns.Retired = nil
+	err = or.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := or.database.UpsertNamespace(ctx, ns, false); err != nil {
+			return err
+		}
+		event := fftypes.NewEvent(fftypes.EventTypeNamespaceConfirmed, ns.Name, ns.ID, nil)
+		return or.database.InsertEvent(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Created namespace '%s' [%s]", ns.Name, ns.ID)
+	return ns, nil
+}
+
+// RetireNamespace stops a namespace accepting new definitions and subscriptions, while keeping all of its data.
+// Any open batches for the namespace are dispatched immediately, rather than waiting for their timeout.
+func (or *orchestrator) RetireNamespace(ctx context.Context, ns string) (*fftypes.Namespace, error) {
+	if err := or.verifyNamespaceSyntax(ctx, ns); err != nil {
+		return nil, err
+	}
+	if ns == fftypes.SystemNamespace || ns == config.GetString(config.NamespacesDefault) {
+		return nil, i18n.NewError(ctx, i18n.MsgNamespaceRetireReserved, ns)
+	}
+	namespace, err := or.database.GetNamespace(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if namespace.Retired != nil {
+		return namespace, nil
+	}
+
+	namespace.Retired = fftypes.Now()
+	err = or.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := or.database.UpsertNamespace(ctx, namespace, true); err != nil {
+			return err
+		}
+		event := fftypes.NewEvent(fftypes.EventTypeNamespaceRetired, fftypes.SystemNamespace, namespace.ID, nil)
+		return or.database.InsertEvent(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := or.batch.Flush(ctx, ns, ""); err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Retired namespace '%s' [%s]", ns, namespace.ID)
+	return namespace, nil
+}
+
+func (or *orchestrator) DeleteNamespace(ctx context.Context, ns string) error {
+	if err := or.verifyNamespaceSyntax(ctx, ns); err != nil {
+		return err
+	}
+	if ns == fftypes.SystemNamespace || ns == config.GetString(config.NamespacesDefault) {
+		return i18n.NewError(ctx, i18n.MsgNamespaceDeleteReserved, ns)
+	}
+	namespace, err := or.database.GetNamespace(ctx, ns)
+	if err != nil {
+		return err
+	}
+	if namespace == nil {
+		return i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+
+	// Subscriptions are removed individually first, so the dispatchers and connector listeners are
+	// halted before the data they deliver is removed underneath them
+	if err := or.deleteNamespaceSubscriptions(ctx, ns); err != nil {
+		return err
+	}
+
+	err = or.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := or.database.DeleteNamespaceData(ctx, ns); err != nil {
+			return err
+		}
+		if err := or.database.DeleteNamespace(ctx, namespace.ID); err != nil {
+			return err
+		}
+		event := fftypes.NewEvent(fftypes.EventTypeNamespaceDeleted, fftypes.SystemNamespace, namespace.ID, nil)
+		return or.database.InsertEvent(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Deleted namespace '%s' [%s]", ns, namespace.ID)
+	return nil
+}
+
+func (or *orchestrator) deleteNamespaceSubscriptions(ctx context.Context, ns string) error {
+	subs, _, err := or.database.GetSubscriptions(ctx, database.SubscriptionQueryFactory.NewFilter(ctx).Eq("namespace", ns))
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if err := or.events.DeleteDurableSubscription(ctx, sub); err != nil {
+			return err
+		}
+	}
+
+	contractSubs, _, err := or.contracts.GetContractSubscriptions(ctx, ns, database.ContractSubscriptionQueryFactory.NewFilter(ctx).And())
+	if err != nil {
+		return err
+	}
+	for _, sub := range contractSubs {
+		if err := or.contracts.DeleteContractSubscriptionByNameOrID(ctx, ns, sub.ID.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/batch"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestNamespaceDelete(or *testOrchestrator) *fftypes.Namespace {
+	ns := &fftypes.Namespace{ID: fftypes.NewUUID(), Name: "ns1"}
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(ns, nil)
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	return ns
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	ns := newTestNamespaceDelete(or)
+	sub := &fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1"}}
+	contractSub := &fftypes.ContractSubscription{ID: fftypes.NewUUID(), Namespace: "ns1"}
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{sub}, nil, nil)
+	or.mem.On("DeleteDurableSubscription", mock.Anything, sub).Return(nil)
+	or.mcm.On("GetContractSubscriptions", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.ContractSubscription{contractSub}, nil, nil)
+	or.mcm.On("DeleteContractSubscriptionByNameOrID", mock.Anything, "ns1", contractSub.ID.String()).Return(nil)
+	or.mdi.On("DeleteNamespaceData", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("DeleteNamespace", mock.Anything, ns.ID).Return(nil)
+	or.mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeNamespaceDeleted && e.Namespace == fftypes.SystemNamespace && *e.Reference == *ns.ID
+	})).Return(nil)
+
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.NoError(t, err)
+
+	or.mdi.AssertExpectations(t)
+	or.mem.AssertExpectations(t)
+	or.mcm.AssertExpectations(t)
+}
+
+func TestDeleteNamespaceBadName(t *testing.T) {
+	or := newTestOrchestrator()
+	err := or.DeleteNamespace(context.Background(), "!wrong")
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestDeleteNamespaceReserved(t *testing.T) {
+	or := newTestOrchestrator()
+	err := or.DeleteNamespace(context.Background(), fftypes.SystemNamespace)
+	assert.Regexp(t, "FF10415", err)
+	err = or.DeleteNamespace(context.Background(), "default")
+	assert.Regexp(t, "FF10415", err)
+}
+
+func TestDeleteNamespaceGetFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, fmt.Errorf("pop"))
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDeleteNamespaceNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, nil)
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestDeleteNamespaceGetSubscriptionsFail(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestNamespaceDelete(or)
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDeleteNamespaceDeleteSubscriptionFail(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestNamespaceDelete(or)
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{{}}, nil, nil)
+	or.mem.On("DeleteDurableSubscription", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDeleteNamespaceGetContractSubscriptionsFail(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestNamespaceDelete(or)
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
+	or.mcm.On("GetContractSubscriptions", mock.Anything, "ns1", mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDeleteNamespaceDeleteContractSubscriptionFail(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestNamespaceDelete(or)
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
+	or.mcm.On("GetContractSubscriptions", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.ContractSubscription{{ID: fftypes.NewUUID()}}, nil, nil)
+	or.mcm.On("DeleteContractSubscriptionByNameOrID", mock.Anything, "ns1", mock.Anything).Return(fmt.Errorf("pop"))
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func newTestNamespaceDeleteNoSubs(or *testOrchestrator) *fftypes.Namespace {
+	ns := &fftypes.Namespace{ID: fftypes.NewUUID(), Name: "ns1"}
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(ns, nil)
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
+	or.mcm.On("GetContractSubscriptions", mock.Anything, "ns1", mock.Anything).Return([]*fftypes.ContractSubscription{}, nil, nil)
+	return ns
+}
+
+func TestDeleteNamespaceDataFail(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestNamespaceDeleteNoSubs(or)
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	or.mdi.On("DeleteNamespaceData", mock.Anything, "ns1").Return(fmt.Errorf("pop"))
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDeleteNamespaceRecordFail(t *testing.T) {
+	or := newTestOrchestrator()
+	ns := newTestNamespaceDeleteNoSubs(or)
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	or.mdi.On("DeleteNamespaceData", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("DeleteNamespace", mock.Anything, ns.ID).Return(fmt.Errorf("pop"))
+	err := or.DeleteNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCreateUpdateNamespaceCreate(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, nil)
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.MatchedBy(func(ns *fftypes.Namespace) bool {
+		return ns.Type == fftypes.NamespaceTypeLocal && ns.ID != nil && ns.Created != nil
+	}), false).Return(nil)
+	or.mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeNamespaceConfirmed && e.Namespace == "ns1"
+	})).Return(nil)
+
+	ns, err := or.CreateUpdateNamespace(context.Background(), &fftypes.Namespace{
+		Name:        "ns1",
+		Description: "new namespace",
+		Type:        fftypes.NamespaceTypeBroadcast,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.NamespaceTypeLocal, ns.Type)
+	assert.Equal(t, "new namespace", ns.Description)
+
+	or.mdi.AssertExpectations(t)
+}
+
+func TestCreateUpdateNamespaceUpdate(t *testing.T) {
+	or := newTestOrchestrator()
+	existing := &fftypes.Namespace{ID: fftypes.NewUUID(), Name: "ns1", Type: fftypes.NamespaceTypeLocal, Description: "old"}
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(existing, nil)
+	or.mdi.On("UpsertNamespace", mock.Anything, existing, true).Return(nil)
+
+	ns, err := or.CreateUpdateNamespace(context.Background(), &fftypes.Namespace{Name: "ns1", Description: "new"})
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, ns.ID)
+	assert.Equal(t, "new", ns.Description)
+
+	or.mdi.AssertExpectations(t)
+}
+
+func TestCreateUpdateNamespaceBadName(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.CreateUpdateNamespace(context.Background(), &fftypes.Namespace{Name: "!wrong"})
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestCreateUpdateNamespaceGetFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, fmt.Errorf("pop"))
+	_, err := or.CreateUpdateNamespace(context.Background(), &fftypes.Namespace{Name: "ns1"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCreateUpdateNamespaceNotLocal(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{Name: "ns1", Type: fftypes.NamespaceTypeBroadcast}, nil)
+	_, err := or.CreateUpdateNamespace(context.Background(), &fftypes.Namespace{Name: "ns1"})
+	assert.Regexp(t, "FF10459", err)
+}
+
+func TestCreateUpdateNamespaceRetired(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{Name: "ns1", Type: fftypes.NamespaceTypeLocal, Retired: fftypes.Now()}, nil)
+	_, err := or.CreateUpdateNamespace(context.Background(), &fftypes.Namespace{Name: "ns1"})
+	assert.Regexp(t, "FF10460", err)
+}
+
+func TestCreateUpdateNamespaceUpdateFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{Name: "ns1", Type: fftypes.NamespaceTypeLocal}, nil)
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.Anything, true).Return(fmt.Errorf("pop"))
+	_, err := or.CreateUpdateNamespace(context.Background(), &fftypes.Namespace{Name: "ns1"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCreateUpdateNamespaceCreateFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, nil)
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.Anything, false).Return(fmt.Errorf("pop"))
+	_, err := or.CreateUpdateNamespace(context.Background(), &fftypes.Namespace{Name: "ns1"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetireNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	existing := &fftypes.Namespace{ID: fftypes.NewUUID(), Name: "ns1", Type: fftypes.NamespaceTypeLocal}
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(existing, nil)
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.MatchedBy(func(ns *fftypes.Namespace) bool {
+		return ns.Retired != nil
+	}), true).Return(nil)
+	or.mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeNamespaceRetired && e.Namespace == fftypes.SystemNamespace && *e.Reference == *existing.ID
+	})).Return(nil)
+	or.mba.On("Flush", mock.Anything, "ns1", "").Return(&batch.FlushResult{}, nil)
+
+	ns, err := or.RetireNamespace(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.NotNil(t, ns.Retired)
+
+	or.mdi.AssertExpectations(t)
+	or.mba.AssertExpectations(t)
+}
+
+func TestRetireNamespaceAlreadyRetired(t *testing.T) {
+	or := newTestOrchestrator()
+	existing := &fftypes.Namespace{ID: fftypes.NewUUID(), Name: "ns1", Retired: fftypes.Now()}
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(existing, nil)
+	ns, err := or.RetireNamespace(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, existing, ns)
+}
+
+func TestRetireNamespaceBadName(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.RetireNamespace(context.Background(), "!wrong")
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestRetireNamespaceReserved(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.RetireNamespace(context.Background(), fftypes.SystemNamespace)
+	assert.Regexp(t, "FF10461", err)
+	_, err = or.RetireNamespace(context.Background(), "default")
+	assert.Regexp(t, "FF10461", err)
+}
+
+func TestRetireNamespaceGetFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, fmt.Errorf("pop"))
+	_, err := or.RetireNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetireNamespaceNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, nil)
+	_, err := or.RetireNamespace(context.Background(), "ns1")
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetireNamespaceUpsertFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{ID: fftypes.NewUUID(), Name: "ns1"}, nil)
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.Anything, true).Return(fmt.Errorf("pop"))
+	_, err := or.RetireNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetireNamespaceFlushFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{ID: fftypes.NewUUID(), Name: "ns1"}, nil)
+	or.mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.Anything, true).Return(nil)
+	or.mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	or.mba.On("Flush", mock.Anything, "ns1", "").Return(nil, fmt.Errorf("pop"))
+	_, err := or.RetireNamespace(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
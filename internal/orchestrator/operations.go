@@ -0,0 +1,48 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (or *orchestrator) RetryOperation(ctx context.Context, ns, id string) (*fftypes.Operation, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	op, err := or.database.GetOperationByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if op == nil || op.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+
+	// Retries go through the managers returned to the API layer, as they resubmit blockchain transactions
+	switch op.Type {
+	case fftypes.OpTypeBlockchainRawTransaction:
+		return or.Contracts().RetryOperation(ctx, op)
+	case fftypes.OpTypeTokenTransfer, fftypes.OpTypeTokenApproval:
+		return or.Assets().RetryOperation(ctx, op)
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgOperationNotRetryable, op.ID, op.Type)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRetryOperationRawTransaction(t *testing.T) {
+	or := newTestOrchestrator()
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Namespace: "ns1", Type: fftypes.OpTypeBlockchainRawTransaction}
+	retry := &fftypes.Operation{ID: fftypes.NewUUID()}
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	or.mcm.On("RetryOperation", mock.Anything, op).Return(retry, nil)
+	res, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, retry, res)
+}
+
+func TestRetryOperationTokenTransfer(t *testing.T) {
+	or := newTestOrchestrator()
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Namespace: "ns1", Type: fftypes.OpTypeTokenTransfer}
+	retry := &fftypes.Operation{ID: fftypes.NewUUID()}
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	or.mam.On("RetryOperation", mock.Anything, op).Return(retry, nil)
+	res, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, retry, res)
+}
+
+func TestRetryOperationReadOnly(t *testing.T) {
+	or := newTestOrchestrator()
+	or.readOnly = true
+	for _, opType := range []fftypes.OpType{fftypes.OpTypeBlockchainRawTransaction, fftypes.OpTypeTokenTransfer, fftypes.OpTypeTokenApproval} {
+		op := &fftypes.Operation{ID: fftypes.NewUUID(), Namespace: "ns1", Type: opType}
+		or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+		_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+		assert.Regexp(t, "FF10358", err)
+	}
+	or.mcm.AssertNotCalled(t, "RetryOperation", mock.Anything, mock.Anything)
+	or.mam.AssertNotCalled(t, "RetryOperation", mock.Anything, mock.Anything)
+}
+
+func TestRetryOperationNotRetryable(t *testing.T) {
+	or := newTestOrchestrator()
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Namespace: "ns1", Type: fftypes.OpTypeBlockchainBatchPin}
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10395", err)
+}
+
+func TestRetryOperationWrongNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Namespace: "ns2", Type: fftypes.OpTypeTokenTransfer}
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationFail(t *testing.T) {
+	or := newTestOrchestrator()
+	id := fftypes.NewUUID()
+	or.mdi.On("GetOperationByID", mock.Anything, id).Return(nil, fmt.Errorf("pop"))
+	_, err := or.RetryOperation(context.Background(), "ns1", id.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.RetryOperation(context.Background(), "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
@@ -18,11 +18,18 @@ package orchestrator
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
+	"io"
+	"sync"
+	"time"
 
+	"github.com/hyperledger/firefly/internal/adminevents"
+	"github.com/hyperledger/firefly/internal/archive"
 	"github.com/hyperledger/firefly/internal/assets"
 	"github.com/hyperledger/firefly/internal/batch"
 	"github.com/hyperledger/firefly/internal/batchpin"
+	"github.com/hyperledger/firefly/internal/batchvalidator/bvfactory"
 	"github.com/hyperledger/firefly/internal/blockchain/bifactory"
 	"github.com/hyperledger/firefly/internal/broadcast"
 	"github.com/hyperledger/firefly/internal/config"
@@ -31,17 +38,23 @@ import (
 	"github.com/hyperledger/firefly/internal/database/difactory"
 	"github.com/hyperledger/firefly/internal/dataexchange/dxfactory"
 	"github.com/hyperledger/firefly/internal/definitions"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/events"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/identity/iifactory"
+	"github.com/hyperledger/firefly/internal/loadshed"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/networkmap"
+	"github.com/hyperledger/firefly/internal/nodekey"
 	"github.com/hyperledger/firefly/internal/privatemessaging"
+	"github.com/hyperledger/firefly/internal/publicstorage/pscache"
 	"github.com/hyperledger/firefly/internal/publicstorage/psfactory"
+	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/internal/tokens/tifactory"
+	"github.com/hyperledger/firefly/pkg/batchvalidator"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
@@ -52,12 +65,15 @@ import (
 )
 
 var (
-	blockchainConfig    = config.NewPluginConfig("blockchain")
-	databaseConfig      = config.NewPluginConfig("database")
-	identityConfig      = config.NewPluginConfig("identity")
-	publicstorageConfig = config.NewPluginConfig("publicstorage")
-	dataexchangeConfig  = config.NewPluginConfig("dataexchange")
-	tokensConfig        = config.NewPluginConfig("tokens").Array()
+	blockchainConfig     = config.NewPluginConfig("blockchain")
+	databaseConfig       = config.NewPluginConfig("database")
+	identityConfig       = config.NewPluginConfig("identity")
+	publicstorageConfig  = config.NewPluginConfig("publicstorage")
+	dataexchangeConfig   = config.NewPluginConfig("dataexchange")
+	tokensConfig         = config.NewPluginConfig("tokens").Array()
+	ledgersConfig        = config.NewPluginConfig("ledgers").Array()
+	publicstoragesConfig = config.NewPluginConfig("publicstorages").Array()
+	batchValidatorConfig = config.NewPluginConfig("batchvalidator")
 )
 
 // Orchestrator is the main interface behind the API, implementing the actions
@@ -73,22 +89,33 @@ type Orchestrator interface {
 	Assets() assets.Manager
 	Contracts() contracts.Manager
 	Metrics() metrics.Manager
+	LoadShedding() loadshed.Monitor
 	BatchManager() batch.Manager
+	AdminEvents() adminevents.Manager
 	IsPreInit() bool
+	PendingDatabaseMigrations(ctx context.Context) ([]string, error)
+	ValidateConfig(ctx context.Context) *fftypes.ConfigValidationReport
 
 	// Status
 	GetStatus(ctx context.Context) (*fftypes.NodeStatus, error)
+	GetStatusPlugins(ctx context.Context) []*fftypes.NodeStatusPlugin
+	GetPluginHealth(ctx context.Context) []*fftypes.PluginHealth
 
 	// Subscription management
 	GetSubscriptions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Subscription, *database.FilterResult, error)
 	GetSubscriptionByID(ctx context.Context, ns, id string) (*fftypes.Subscription, error)
+	GetSubscriptionHistory(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.SubscriptionChange, *database.FilterResult, error)
 	CreateSubscription(ctx context.Context, ns string, subDef *fftypes.Subscription) (*fftypes.Subscription, error)
 	CreateUpdateSubscription(ctx context.Context, ns string, subDef *fftypes.Subscription) (*fftypes.Subscription, error)
+	PatchSubscription(ctx context.Context, ns, id string, patch fftypes.JSONObject) (*fftypes.Subscription, error)
 	DeleteSubscription(ctx context.Context, ns, id string) error
 
 	// Data Query
 	GetNamespace(ctx context.Context, ns string) (*fftypes.Namespace, error)
 	GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, *database.FilterResult, error)
+	CreateUpdateNamespace(ctx context.Context, ns *fftypes.Namespace) (*fftypes.Namespace, error)
+	RetireNamespace(ctx context.Context, ns string) (*fftypes.Namespace, error)
+	DeleteNamespace(ctx context.Context, ns string) error
 	GetTransactionByID(ctx context.Context, ns, id string) (*fftypes.Transaction, error)
 	GetTransactionOperations(ctx context.Context, ns, id string) ([]*fftypes.Operation, *database.FilterResult, error)
 	GetTransactionBlockchainEvents(ctx context.Context, ns, id string) ([]*fftypes.BlockchainEvent, *database.FilterResult, error)
@@ -114,12 +141,40 @@ type Orchestrator interface {
 	GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, *database.FilterResult, error)
 	GetEventByID(ctx context.Context, ns, id string) (*fftypes.Event, error)
 	GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error)
+	ExportEvents(ctx context.Context, ns, prevHash string, filter database.AndFilter) (io.ReadCloser, error)
+
+	// Database transactions
+	RunAsGroup(ctx context.Context, fn func(ctx context.Context) error) error
 	GetBlockchainEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BlockchainEvent, error)
 	GetBlockchainEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.BlockchainEvent, *database.FilterResult, error)
 
+	// Operation retry
+	RetryOperation(ctx context.Context, ns, id string) (*fftypes.Operation, error)
+
+	// Long-poll
+	WaitForMessageState(ctx context.Context, ns, id string, state fftypes.MessageState, timeout time.Duration) (*fftypes.Message, error)
+	WaitForOperationState(ctx context.Context, ns, id string, status fftypes.OpStatus, timeout time.Duration) (*fftypes.Operation, error)
+
+	// Quarantined batches
+	GetQuarantinedBatchByID(ctx context.Context, ns, id string) (*fftypes.QuarantinedBatch, error)
+	GetQuarantinedBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.QuarantinedBatch, *database.FilterResult, error)
+	ReprocessQuarantinedBatch(ctx context.Context, ns, id string) (*fftypes.Batch, error)
+
+	// Audit log
+	RecordAudit(ctx context.Context, record *fftypes.AuditRecord) error
+	GetAuditRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.AuditRecord, *database.FilterResult, error)
+
+	// Aggregator checkpoint
+	GetAggregatorCheckpoint(ctx context.Context, ledger string) (*fftypes.Offset, error)
+	RewindAggregator(ctx context.Context, rewind *fftypes.AggregatorRewind) error
+	StartCatchup(ctx context.Context, ns string, req *fftypes.CatchupRequest) (*fftypes.Operation, error)
+
 	// Charts
 	GetChartHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, tableName database.CollectionName) ([]*fftypes.ChartHistogram, error)
 
+	// Usage
+	GetNamespaceUsage(ctx context.Context, ns string) (*fftypes.NamespaceUsage, error)
+
 	// Config Management
 	GetConfig(ctx context.Context) fftypes.JSONObject
 	GetConfigRecord(ctx context.Context, key string) (*fftypes.ConfigRecord, error)
@@ -127,41 +182,67 @@ type Orchestrator interface {
 	PutConfigRecord(ctx context.Context, key string, configRecord *fftypes.JSONAny) (outputValue *fftypes.JSONAny, err error)
 	DeleteConfigRecord(ctx context.Context, key string) (err error)
 	ResetConfig(ctx context.Context)
+	GetLogLevels(ctx context.Context) *fftypes.LogLevels
+	SetLogLevels(ctx context.Context, levels *fftypes.LogLevels) (*fftypes.LogLevels, error)
+	ReloadConfig(ctx context.Context) (*fftypes.ConfigReloadResult, error)
 
 	// Message Routing
 	RequestReply(ctx context.Context, ns string, msg *fftypes.MessageInOut) (reply *fftypes.MessageInOut, err error)
+	SubmitMessages(ctx context.Context, ns string, msgs []*fftypes.MessageInOut) ([]*fftypes.BulkMessageResult, error)
 }
 
 type orchestrator struct {
-	ctx            context.Context
-	cancelCtx      context.CancelFunc
-	started        bool
-	database       database.Plugin
-	blockchain     blockchain.Plugin
-	identity       identity.Manager
-	identityPlugin idplugin.Plugin
-	publicstorage  publicstorage.Plugin
-	dataexchange   dataexchange.Plugin
-	events         events.EventManager
-	networkmap     networkmap.Manager
-	batch          batch.Manager
-	broadcast      broadcast.Manager
-	messaging      privatemessaging.Manager
-	definitions    definitions.DefinitionHandlers
-	data           data.Manager
-	syncasync      syncasync.Bridge
-	batchpin       batchpin.Submitter
-	assets         assets.Manager
-	tokens         map[string]tokens.Plugin
-	bc             boundCallbacks
-	preInitMode    bool
-	contracts      contracts.Manager
-	node           *fftypes.UUID
-	metrics        metrics.Manager
+	ctx             context.Context
+	cancelCtx       context.CancelFunc
+	started         bool
+	database        database.Plugin
+	blockchain      blockchain.Plugin
+	identity        identity.Manager
+	identityPlugin  idplugin.Plugin
+	publicstorage   publicstorage.Plugin
+	dataexchange    dataexchange.Plugin
+	events          events.EventManager
+	eventBus        eventbus.Bus
+	loadShed        loadshed.Monitor
+	networkmap      networkmap.Manager
+	batch           batch.Manager
+	broadcast       broadcast.Manager
+	messaging       privatemessaging.Manager
+	definitions     definitions.DefinitionHandlers
+	data            data.Manager
+	syncasync       syncasync.Bridge
+	batchpin        batchpin.Submitter
+	assets          assets.Manager
+	tokens          map[string]tokens.Plugin
+	bc              boundCallbacks
+	ledgers         map[string]*boundCallbacks
+	nsLedgers       map[string]blockchain.Plugin
+	nsLedgerNames   map[string]string
+	publicstorages  map[string]publicstorage.Plugin
+	nsPublicStorage map[string]publicstorage.Plugin
+	preInitMode     bool
+	contracts       contracts.Manager
+	node            *fftypes.UUID
+	nodeKey         ed25519.PrivateKey
+	metrics         metrics.Manager
+	batchValidators []batchvalidator.Plugin
+	archive         archive.Manager
+	adminEvents     adminevents.Manager
+	readOnly        bool
+
+	startupMux        sync.Mutex
+	startupBackground bool
+	startupRetry      retry.Retry
+	startupPlugins    []*fftypes.NodeStatusPluginStartup
+
+	healthMux    sync.Mutex
+	pluginHealth map[string]*fftypes.PluginHealth
 }
 
 func NewOrchestrator() Orchestrator {
-	or := &orchestrator{}
+	or := &orchestrator{
+		eventBus: eventbus.NewBus(),
+	}
 
 	// Initialize the config on all the factories
 	bifactory.InitPrefix(blockchainConfig)
@@ -169,6 +250,10 @@ func NewOrchestrator() Orchestrator {
 	psfactory.InitPrefix(publicstorageConfig)
 	dxfactory.InitPrefix(dataexchangeConfig)
 	tifactory.InitPrefix(tokensConfig)
+	bifactory.InitLedgersPrefix(ledgersConfig)
+	psfactory.InitInstancesPrefix(publicstoragesConfig)
+	bvfactory.InitPrefix(batchValidatorConfig)
+	archive.InitConfig()
 
 	return or
 }
@@ -190,6 +275,10 @@ func (or *orchestrator) Init(ctx context.Context, cancelCtx context.CancelFunc)
 	or.bc.bi = or.blockchain
 	or.bc.ei = or.events
 	or.bc.dx = or.dataexchange
+	for _, lbc := range or.ledgers {
+		lbc.ei = or.events
+		lbc.dx = or.dataexchange
+	}
 	return err
 }
 
@@ -198,10 +287,7 @@ func (or *orchestrator) Start() error {
 		log.L(or.ctx).Infof("Orchestrator in pre-init mode, waiting for initialization")
 		return nil
 	}
-	err := or.blockchain.Start()
-	if err == nil {
-		err = or.batch.Start()
-	}
+	err := or.batch.Start()
 	if err == nil {
 		err = or.events.Start()
 	}
@@ -209,17 +295,13 @@ func (or *orchestrator) Start() error {
 		err = or.broadcast.Start()
 	}
 	if err == nil {
-		err = or.messaging.Start()
+		err = or.metrics.Start()
 	}
-	if err == nil {
-		for _, el := range or.tokens {
-			if err = el.Start(); err != nil {
-				break
-			}
-		}
+	if err == nil && or.archive != nil {
+		err = or.archive.Start()
 	}
 	if err == nil {
-		err = or.metrics.Start()
+		err = or.startPlugins()
 	}
 	or.started = true
 	return err
@@ -237,6 +319,13 @@ func (or *orchestrator) WaitStop() {
 		or.broadcast.WaitStop()
 		or.broadcast = nil
 	}
+	if or.archive != nil {
+		or.archive.WaitStop()
+		or.archive = nil
+	}
+	if or.adminEvents != nil {
+		or.adminEvents.WaitStop()
+	}
 	or.started = false
 }
 
@@ -245,13 +334,23 @@ func (or *orchestrator) IsPreInit() bool {
 }
 
 func (or *orchestrator) Broadcast() broadcast.Manager {
+	if or.readOnly {
+		return &readOnlyBroadcast{or.broadcast}
+	}
 	return or.broadcast
 }
 
 func (or *orchestrator) PrivateMessaging() privatemessaging.Manager {
+	if or.readOnly {
+		return &readOnlyPrivateMessaging{or.messaging}
+	}
 	return or.messaging
 }
 
+func (or *orchestrator) RunAsGroup(ctx context.Context, fn func(ctx context.Context) error) error {
+	return or.database.RunAsGroup(ctx, fn)
+}
+
 func (or *orchestrator) Events() events.EventManager {
 	return or.events
 }
@@ -261,6 +360,9 @@ func (or *orchestrator) BatchManager() batch.Manager {
 }
 
 func (or *orchestrator) NetworkMap() networkmap.Manager {
+	if or.readOnly {
+		return &readOnlyNetworkMap{or.networkmap}
+	}
 	return or.networkmap
 }
 
@@ -269,10 +371,16 @@ func (or *orchestrator) Data() data.Manager {
 }
 
 func (or *orchestrator) Assets() assets.Manager {
+	if or.readOnly {
+		return &readOnlyAssets{or.assets}
+	}
 	return or.assets
 }
 
 func (or *orchestrator) Contracts() contracts.Manager {
+	if or.readOnly {
+		return &readOnlyContracts{or.contracts}
+	}
 	return or.contracts
 }
 
@@ -280,14 +388,36 @@ func (or *orchestrator) Metrics() metrics.Manager {
 	return or.metrics
 }
 
-func (or *orchestrator) initDatabaseCheckPreinit(ctx context.Context) (err error) {
+func (or *orchestrator) LoadShedding() loadshed.Monitor {
+	return or.loadShed
+}
+
+func (or *orchestrator) AdminEvents() adminevents.Manager {
+	return or.adminEvents
+}
+
+func (or *orchestrator) initDatabase(ctx context.Context) (err error) {
 	if or.database == nil {
 		diType := config.GetString(config.DatabaseType)
 		if or.database, err = difactory.GetPlugin(ctx, diType); err != nil {
 			return err
 		}
 	}
-	if err = or.database.Init(ctx, databaseConfig.SubPrefix(or.database.Name()), or); err != nil {
+	return or.database.Init(ctx, databaseConfig.SubPrefix(or.database.Name()), or)
+}
+
+// PendingDatabaseMigrations initializes only the database plugin, without applying any migrations,
+// and returns the migrations that would be applied on a normal startup
+func (or *orchestrator) PendingDatabaseMigrations(ctx context.Context) ([]string, error) {
+	config.Set(config.DatabaseMigrationsDryRun, true)
+	if err := or.initDatabase(ctx); err != nil {
+		return nil, err
+	}
+	return or.database.PendingMigrations(ctx)
+}
+
+func (or *orchestrator) initDatabaseCheckPreinit(ctx context.Context) (err error) {
+	if err = or.initDatabase(ctx); err != nil {
 		return err
 	}
 
@@ -304,8 +434,9 @@ func (or *orchestrator) initDatabaseCheckPreinit(ctx context.Context) (err error
 	return config.MergeConfig(configRecords)
 }
 
-func (or *orchestrator) initDataExchange(ctx context.Context) (err error) {
-	dxPlugin := config.GetString(config.DataexchangeType)
+// getDataExchangePlugin loads the data exchange plugin, returning the name of its config section
+func (or *orchestrator) getDataExchangePlugin(ctx context.Context) (dxPlugin string, err error) {
+	dxPlugin = config.GetString(config.DataexchangeType)
 	if or.dataexchange == nil {
 		pluginName := dxPlugin
 		if pluginName == "https" {
@@ -314,9 +445,15 @@ func (or *orchestrator) initDataExchange(ctx context.Context) (err error) {
 			log.L(ctx).Warnf("Your data exchange config uses the old plugin name 'https' - this plugin has been renamed to 'ffdx'")
 			pluginName = "ffdx"
 		}
-		if or.dataexchange, err = dxfactory.GetPlugin(ctx, pluginName); err != nil {
-			return err
-		}
+		or.dataexchange, err = dxfactory.GetPlugin(ctx, pluginName)
+	}
+	return dxPlugin, err
+}
+
+func (or *orchestrator) initDataExchange(ctx context.Context) (err error) {
+	dxPlugin, err := or.getDataExchangePlugin(ctx)
+	if err != nil {
+		return err
 	}
 
 	nodes, _, err := or.database.GetNodes(ctx, database.NodeQueryFactory.NewFilter(ctx).And())
@@ -331,120 +468,199 @@ func (or *orchestrator) initDataExchange(ctx context.Context) (err error) {
 	return or.dataexchange.Init(ctx, dataexchangeConfig.SubPrefix(dxPlugin), nodeInfo, &or.bc)
 }
 
+func (or *orchestrator) initBatchValidators(ctx context.Context) (err error) {
+	if or.batchValidators == nil {
+		or.batchValidators = []batchvalidator.Plugin{}
+		for _, bvType := range config.GetStringSlice(config.EventAggregatorBatchValidators) {
+			log.L(ctx).Infof("Loading batch validator plugin %s", bvType)
+			bv, err := bvfactory.GetPlugin(ctx, bvType)
+			if err != nil {
+				return err
+			}
+			if err = bv.Init(ctx, batchValidatorConfig.SubPrefix(bv.Name())); err != nil {
+				return err
+			}
+			or.batchValidators = append(or.batchValidators, bv)
+		}
+	}
+	return nil
+}
+
 func (or *orchestrator) initPlugins(ctx context.Context) (err error) {
 
+	// Created before the database, so the monitor observes every call the database plugin makes
+	or.loadShed = loadshed.NewMonitor(ctx, or.eventBus)
+
 	if err = or.initDatabaseCheckPreinit(ctx); err != nil {
 		return err
 	} else if or.preInitMode {
 		return nil
 	}
 
+	if err = or.initIdentity(ctx); err != nil {
+		return err
+	}
+
+	if err = or.initBlockchain(ctx); err != nil {
+		return err
+	}
+
+	if err = or.initLedgers(ctx); err != nil {
+		return err
+	}
+
+	if err = or.initPublicStorage(ctx); err != nil {
+		return err
+	}
+
+	if err = or.initPublicStorages(ctx); err != nil {
+		return err
+	}
+
+	if err = or.initDataExchange(ctx); err != nil {
+		return err
+	}
+
+	if err = or.initBatchValidators(ctx); err != nil {
+		return err
+	}
+
+	return or.initTokens(ctx)
+}
+
+func (or *orchestrator) initIdentity(ctx context.Context) (err error) {
 	if or.identityPlugin == nil {
 		iiType := config.GetString(config.IdentityType)
 		if or.identityPlugin, err = iifactory.GetPlugin(ctx, iiType); err != nil {
 			return err
 		}
 	}
-	if err = or.identityPlugin.Init(ctx, identityConfig.SubPrefix(or.identityPlugin.Name()), or); err != nil {
-		return err
-	}
+	return or.identityPlugin.Init(ctx, identityConfig.SubPrefix(or.identityPlugin.Name()), or)
+}
 
+func (or *orchestrator) initBlockchain(ctx context.Context) (err error) {
 	if or.blockchain == nil {
 		biType := config.GetString(config.BlockchainType)
 		if or.blockchain, err = bifactory.GetPlugin(ctx, biType); err != nil {
 			return err
 		}
 	}
-	if err = or.blockchain.Init(ctx, blockchainConfig.SubPrefix(or.blockchain.Name()), &or.bc); err != nil {
-		return err
-	}
+	return or.blockchain.Init(ctx, blockchainConfig.SubPrefix(or.blockchain.Name()), &or.bc)
+}
 
+func (or *orchestrator) initPublicStorage(ctx context.Context) (err error) {
 	if or.publicstorage == nil {
 		psType := config.GetString(config.PublicStorageType)
 		if or.publicstorage, err = psfactory.GetPlugin(ctx, psType); err != nil {
 			return err
 		}
 	}
-	if err = or.publicstorage.Init(ctx, publicstorageConfig.SubPrefix(or.publicstorage.Name()), or); err != nil {
-		return err
-	}
-
-	if err = or.initDataExchange(ctx); err != nil {
+	if err = or.publicstorage.Init(ctx, publicstorageConfig.SubPrefix(or.publicstorage.Name()), &or.bc); err != nil {
 		return err
 	}
+	or.publicstorage, err = pscache.Wrap(ctx, or.publicstorage)
+	return err
+}
 
+func (or *orchestrator) initTokens(ctx context.Context) error {
 	if or.tokens == nil {
 		or.tokens = make(map[string]tokens.Plugin)
 		tokensConfigArraySize := tokensConfig.ArraySize()
 		for i := 0; i < tokensConfigArraySize; i++ {
-			prefix := tokensConfig.ArrayEntry(i)
-			name := prefix.GetString(tokens.TokensConfigName)
-			pluginName := prefix.GetString(tokens.TokensConfigPlugin)
-			if name == "" {
-				return i18n.NewError(ctx, i18n.MsgMissingTokensPluginConfig)
-			}
-			if err = fftypes.ValidateFFNameField(ctx, name, "name"); err != nil {
-				return err
-			}
-			if pluginName == "" {
-				// Migration path for old config key
-				// TODO: eventually make this fatal
-				pluginName = prefix.GetString(tokens.TokensConfigConnector)
-				if pluginName == "" {
-					return i18n.NewError(ctx, i18n.MsgMissingTokensPluginConfig)
-				}
-				log.L(ctx).Warnf("Your tokens config uses the deprecated 'connector' key - please change to 'plugin' instead")
-			}
-			if pluginName == "https" {
-				// Migration path for old plugin name
-				// TODO: eventually make this fatal
-				log.L(ctx).Warnf("Your tokens config uses the old plugin name 'https' - this plugin has been renamed to 'fftokens'")
-				pluginName = "fftokens"
-			}
-
-			log.L(ctx).Infof("Loading tokens plugin name=%s plugin=%s", name, pluginName)
-			plugin, err := tifactory.GetPlugin(ctx, pluginName)
-			if plugin != nil {
-				err = plugin.Init(ctx, name, prefix, &or.bc)
-			}
+			name, plugin, err := or.initTokensPlugin(ctx, tokensConfig.ArrayEntry(i))
 			if err != nil {
 				return err
 			}
 			or.tokens[name] = plugin
 		}
 	}
-
 	return nil
 }
 
+func (or *orchestrator) initTokensPlugin(ctx context.Context, prefix config.Prefix) (string, tokens.Plugin, error) {
+	name := prefix.GetString(tokens.TokensConfigName)
+	pluginName := prefix.GetString(tokens.TokensConfigPlugin)
+	if name == "" {
+		return "", nil, i18n.NewError(ctx, i18n.MsgMissingTokensPluginConfig)
+	}
+	if err := fftypes.ValidateFFNameField(ctx, name, "name"); err != nil {
+		return "", nil, err
+	}
+	if pluginName == "" {
+		// Migration path for old config key
+		// TODO: eventually make this fatal
+		pluginName = prefix.GetString(tokens.TokensConfigConnector)
+		if pluginName == "" {
+			return "", nil, i18n.NewError(ctx, i18n.MsgMissingTokensPluginConfig)
+		}
+		log.L(ctx).Warnf("Your tokens config uses the deprecated 'connector' key - please change to 'plugin' instead")
+	}
+	if pluginName == "https" {
+		// Migration path for old plugin name
+		// TODO: eventually make this fatal
+		log.L(ctx).Warnf("Your tokens config uses the old plugin name 'https' - this plugin has been renamed to 'fftokens'")
+		pluginName = "fftokens"
+	}
+
+	log.L(ctx).Infof("Loading tokens plugin name=%s plugin=%s", name, pluginName)
+	plugin, err := tifactory.GetPlugin(ctx, pluginName)
+	if plugin != nil {
+		err = plugin.Init(ctx, name, prefix, &or.bc)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return name, plugin, nil
+}
+
 func (or *orchestrator) initComponents(ctx context.Context) (err error) {
+	if or.readOnly = config.GetBool(config.NodeReadOnly); or.readOnly {
+		log.L(ctx).Infof("Node is in read-only mode. Messages and blockchain transactions will not be submitted")
+	}
+
+	if keyFile := config.GetString(config.NodeSigningKey); keyFile != "" && or.nodeKey == nil {
+		if or.nodeKey, err = nodekey.LoadPrivateKey(ctx, keyFile); err != nil {
+			return err
+		}
+	}
+
 	if or.metrics == nil {
 		or.metrics = metrics.NewMetricsManager(ctx)
 	}
 
+	if or.adminEvents == nil {
+		or.adminEvents = adminevents.NewAdminEventManager(ctx)
+	}
+
 	if or.identity == nil {
-		or.identity, err = identity.NewIdentityManager(ctx, or.database, or.identityPlugin, or.blockchain)
+		or.identity, err = identity.NewIdentityManager(ctx, or.database, or.identityPlugin, or.blockchain, or.nsLedgers)
 		if err != nil {
 			return err
 		}
 	}
 
+	if or.archive == nil && config.GetBool(config.ArchiveEnabled) {
+		if or.archive, err = archive.NewArchiveManager(ctx, or.database); err != nil {
+			return err
+		}
+	}
+
 	if or.data == nil {
-		or.data, err = data.NewDataManager(ctx, or.database, or.publicstorage, or.dataexchange)
+		or.data, err = data.NewDataManager(ctx, or.database, or.publicstorage, or.nsPublicStorage, or.dataexchange, or.archive)
 		if err != nil {
 			return err
 		}
 	}
 
 	if or.batch == nil {
-		or.batch, err = batch.NewBatchManager(ctx, or, or.database, or.data)
+		or.batch, err = batch.NewBatchManager(ctx, or, or.database, or.data, or.eventBus, or.metrics)
 		if err != nil {
 			return err
 		}
 	}
 
-	or.syncasync = syncasync.NewSyncAsyncBridge(ctx, or.database, or.data)
-	or.batchpin = batchpin.NewBatchPinSubmitter(or.database, or.identity, or.blockchain, or.metrics)
+	or.syncasync = syncasync.NewSyncAsyncBridge(ctx, or.database, or.data, or.eventBus)
+	or.batchpin = batchpin.NewBatchPinSubmitter(or.database, or.identity, or.blockchain, or.nsLedgers, or.metrics)
 
 	if or.messaging == nil {
 		if or.messaging, err = privatemessaging.NewPrivateMessaging(ctx, or.database, or.identity, or.dataexchange, or.blockchain, or.batch, or.data, or.syncasync, or.batchpin, or.metrics); err != nil {
@@ -453,36 +669,36 @@ func (or *orchestrator) initComponents(ctx context.Context) (err error) {
 	}
 
 	if or.broadcast == nil {
-		if or.broadcast, err = broadcast.NewBroadcastManager(ctx, or.database, or.identity, or.data, or.blockchain, or.dataexchange, or.publicstorage, or.batch, or.syncasync, or.batchpin, or.metrics); err != nil {
+		if or.broadcast, err = broadcast.NewBroadcastManager(ctx, or.database, or.identity, or.data, or.blockchain, or.dataexchange, or.publicstorage, or.nsPublicStorage, or.batch, or.syncasync, or.batchpin, or.messaging, or.metrics); err != nil {
 			return err
 		}
 	}
 
 	if or.assets == nil {
-		or.assets, err = assets.NewAssetManager(ctx, or.database, or.identity, or.data, or.syncasync, or.broadcast, or.messaging, or.tokens, or.metrics)
+		or.assets, err = assets.NewAssetManager(ctx, or.database, or.identity, or.data, or.syncasync, or.broadcast, or.messaging, or.publicstorage, or.tokens, or.metrics)
 		if err != nil {
 			return err
 		}
 	}
 
 	if or.contracts == nil {
-		or.contracts, err = contracts.NewContractManager(ctx, or.database, or.publicstorage, or.broadcast, or.identity, or.blockchain)
+		or.contracts, err = contracts.NewContractManager(ctx, or.database, or.publicstorage, or.broadcast, or.identity, or.blockchain, or.syncasync)
 		if err != nil {
 			return err
 		}
 	}
 
-	or.definitions = definitions.NewDefinitionHandlers(or.database, or.dataexchange, or.data, or.broadcast, or.messaging, or.assets, or.contracts)
+	or.definitions = definitions.NewDefinitionHandlers(or.database, or.dataexchange, or.data, or.identity, or.broadcast, or.messaging, or.assets, or.contracts)
 
 	if or.events == nil {
-		or.events, err = events.NewEventManager(ctx, or, or.publicstorage, or.database, or.identity, or.definitions, or.data, or.broadcast, or.messaging, or.assets, or.metrics)
+		or.events, err = events.NewEventManager(ctx, or, or.publicstorage, or.nsPublicStorage, or.database, or.blockchain, or.nsLedgers, or.dataexchange, or.identity, or.definitions, or.data, or.broadcast, or.messaging, or.assets, or.metrics, or.eventBus, or.batchValidators, or.ledgerNames(), or.nsLedgerNames)
 		if err != nil {
 			return err
 		}
 	}
 
 	if or.networkmap == nil {
-		or.networkmap, err = networkmap.NewNetworkMap(ctx, or.database, or.broadcast, or.dataexchange, or.identity)
+		or.networkmap, err = networkmap.NewNetworkMap(ctx, or.database, or.broadcast, or.dataexchange, or.identity, or.blockchain)
 		if err != nil {
 			return err
 		}
@@ -551,6 +767,7 @@ func (or *orchestrator) initNamespaces(ctx context.Context) error {
 		} else {
 			// Only update if the description has changed, and the one in our DB is locally defined
 			updated = ns.Description != newNS.Description && ns.Type == fftypes.NamespaceTypeLocal
+			newNS.Retired = ns.Retired
 		}
 		if updated {
 			if err := or.database.UpsertNamespace(ctx, newNS, true); err != nil {
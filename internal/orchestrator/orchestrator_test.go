@@ -18,13 +18,23 @@ package orchestrator
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/batchvalidator/bvfactory"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/dataexchange/dxfactory"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/internal/tokens/tifactory"
+	"github.com/hyperledger/firefly/mocks/admineventsmocks"
+	"github.com/hyperledger/firefly/mocks/archivemocks"
 	"github.com/hyperledger/firefly/mocks/assetmocks"
 	"github.com/hyperledger/firefly/mocks/batchmocks"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
@@ -40,6 +50,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/networkmapmocks"
 	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/tokens"
@@ -68,6 +79,7 @@ type testOrchestrator struct {
 	mti *tokenmocks.Plugin
 	mcm *contractmocks.Manager
 	mmi *metricsmocks.Manager
+	msa *syncasyncmocks.Bridge
 }
 
 func newTestOrchestrator() *testOrchestrator {
@@ -77,6 +89,7 @@ func newTestOrchestrator() *testOrchestrator {
 		orchestrator: orchestrator{
 			ctx:       ctx,
 			cancelCtx: cancel,
+			eventBus:  eventbus.NewBus(),
 		},
 		mdi: &databasemocks.Plugin{},
 		mdm: &datamocks.Manager{},
@@ -94,6 +107,7 @@ func newTestOrchestrator() *testOrchestrator {
 		mti: &tokenmocks.Plugin{},
 		mcm: &contractmocks.Manager{},
 		mmi: &metricsmocks.Manager{},
+		msa: &syncasyncmocks.Bridge{},
 	}
 	tor.orchestrator.database = tor.mdi
 	tor.orchestrator.data = tor.mdm
@@ -111,6 +125,7 @@ func newTestOrchestrator() *testOrchestrator {
 	tor.orchestrator.contracts = tor.mcm
 	tor.orchestrator.tokens = map[string]tokens.Plugin{"token": tor.mti}
 	tor.orchestrator.metrics = tor.mmi
+	tor.orchestrator.syncasync = tor.msa
 	tor.mdi.On("Name").Return("mock-di").Maybe()
 	tor.mem.On("Name").Return("mock-ei").Maybe()
 	tor.mps.On("Name").Return("mock-ps").Maybe()
@@ -159,6 +174,23 @@ func TestBadDatabasePreInitMode(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPendingDatabaseMigrations(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("PendingMigrations", mock.Anything).Return([]string{"000001_create_messages_table"}, nil)
+	pending, err := or.PendingDatabaseMigrations(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"000001_create_messages_table"}, pending)
+	assert.True(t, config.GetBool(config.DatabaseMigrationsDryRun))
+}
+
+func TestPendingDatabaseMigrationsInitFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	_, err := or.PendingDatabaseMigrations(context.Background())
+	assert.EqualError(t, err, "pop")
+}
+
 func TestBadIdentityPlugin(t *testing.T) {
 	or := newTestOrchestrator()
 	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
@@ -256,6 +288,22 @@ func TestBadPublicStorageInitFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestBadPublicStorageCacheDir(t *testing.T) {
+	or := newTestOrchestrator()
+	notADir := filepath.Join(t.TempDir(), "file")
+	err := ioutil.WriteFile(notADir, []byte{}, 0600)
+	assert.NoError(t, err)
+	config.Set(config.PublicStorageCacheDirectory, filepath.Join(notADir, "cache"))
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	err = or.Init(ctx, cancelCtx)
+	assert.Regexp(t, "FF10391", err)
+}
+
 func TestBadDataExchangePlugin(t *testing.T) {
 	or := newTestOrchestrator()
 	config.Set(config.DataexchangeType, "wrong")
@@ -457,6 +505,23 @@ func TestInitNetworkMapComponentFail(t *testing.T) {
 	assert.Regexp(t, "FF10128", err)
 }
 
+func TestInitArchiveComponentFail(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.ArchiveEnabled, true)
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10138", err)
+}
+
+func TestInitArchiveComponentOk(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.ArchiveEnabled, true)
+	config.Set(config.ArchiveDirectory, t.TempDir())
+	or.msa.On("Init", or.mem).Return()
+	err := or.initComponents(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, or.archive)
+}
+
 func TestInitBatchComponentFail(t *testing.T) {
 	or := newTestOrchestrator()
 	or.database = nil
@@ -511,6 +576,26 @@ func TestInitContractsComponentFail(t *testing.T) {
 	assert.Regexp(t, "FF10128", err)
 }
 
+func TestInitNodeSigningKey(t *testing.T) {
+	or := newTestOrchestrator()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	keyBytes, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyFile := filepath.Join(t.TempDir(), "node.pem")
+	err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0600)
+	assert.NoError(t, err)
+	config.Set(config.NodeSigningKey, keyFile)
+	err = or.initComponents(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, key, or.nodeKey)
+}
+
+func TestInitNodeSigningKeyFail(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.NodeSigningKey, filepath.Join(t.TempDir(), "missing.pem"))
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10386", err)
+}
+
 func TestStartBatchFail(t *testing.T) {
 	config.Reset()
 	or := newTestOrchestrator()
@@ -529,9 +614,13 @@ func TestStartTokensFail(t *testing.T) {
 	or.mbm.On("Start").Return(nil)
 	or.mpm.On("Start").Return(nil)
 	or.mam.On("Start").Return(nil)
+	or.mmi.On("Start").Return(nil)
 	or.mti.On("Start").Return(fmt.Errorf("pop"))
 	err := or.Start()
 	assert.EqualError(t, err, "pop")
+	status := or.getStartupStatus()
+	assert.False(t, status.Ready)
+	assert.Equal(t, "pop", status.Plugins[2].Error)
 }
 
 func TestStartStopOk(t *testing.T) {
@@ -551,10 +640,38 @@ func TestStartStopOk(t *testing.T) {
 	or.mbm.On("WaitStop").Return(nil)
 	or.mam.On("WaitStop").Return(nil)
 	or.mti.On("WaitStop").Return(nil)
+	mae := &admineventsmocks.Manager{}
+	or.adminEvents = mae
+	mae.On("WaitStop").Return().Once()
 	err := or.Start()
 	assert.NoError(t, err)
 	or.WaitStop()
 	or.WaitStop() // swallows dups
+	mae.AssertExpectations(t)
+}
+
+func TestStartStopArchiveOk(t *testing.T) {
+	config.Reset()
+	or := newTestOrchestrator()
+	mar := &archivemocks.Manager{}
+	or.archive = mar
+	or.mbi.On("Start").Return(nil)
+	or.mba.On("Start").Return(nil)
+	or.mem.On("Start").Return(nil)
+	or.mbm.On("Start").Return(nil)
+	or.mpm.On("Start").Return(nil)
+	or.mam.On("Start").Return(nil)
+	or.mti.On("Start").Return(nil)
+	or.mmi.On("Start").Return(nil)
+	mar.On("Start").Return(nil)
+	or.mba.On("WaitStop").Return(nil)
+	or.mbm.On("WaitStop").Return(nil)
+	mar.On("WaitStop").Return(nil)
+	err := or.Start()
+	assert.NoError(t, err)
+	or.WaitStop()
+	assert.Nil(t, or.archive)
+	mar.AssertExpectations(t)
 }
 
 func TestInitNamespacesBadName(t *testing.T) {
@@ -591,6 +708,22 @@ func TestInitNamespacesUpsertNotNeeded(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestInitNamespacesUpdateKeepsRetired(t *testing.T) {
+	or := newTestOrchestrator()
+	retired := fftypes.Now()
+	or.mdi.On("GetNamespace", mock.Anything, mock.Anything).Return(&fftypes.Namespace{
+		Type:        fftypes.NamespaceTypeLocal,
+		Description: "old description",
+		Retired:     retired,
+	}, nil)
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.MatchedBy(func(ns *fftypes.Namespace) bool {
+		return ns.Retired == retired
+	}), true).Return(nil)
+	err := or.initNamespaces(context.Background())
+	assert.NoError(t, err)
+	or.mdi.AssertExpectations(t)
+}
+
 func TestInitNamespacesDefaultMissing(t *testing.T) {
 	or := newTestOrchestrator()
 	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{})
@@ -627,11 +760,15 @@ func TestInitOK(t *testing.T) {
 	or.mdi.On("UpsertNamespace", mock.Anything, mock.Anything, true).Return(nil)
 	or.mti.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mmi.On("Init").Return(nil)
+	lbc := &boundCallbacks{bi: &blockchainmocks.Plugin{}, ledger: "ledger2"}
+	or.ledgers = map[string]*boundCallbacks{"ledger2": lbc}
 	err := config.ReadConfig(configDir + "/firefly.core.yaml")
 	assert.NoError(t, err)
 	ctx, cancelCtx := context.WithCancel(context.Background())
 	err = or.Init(ctx, cancelCtx)
 	assert.NoError(t, err)
+	assert.Equal(t, or.mem, lbc.ei)
+	assert.Equal(t, or.mdx, lbc.dx)
 
 	assert.False(t, or.IsPreInit())
 	assert.Equal(t, or.mbm, or.Broadcast())
@@ -643,6 +780,8 @@ func TestInitOK(t *testing.T) {
 	assert.Equal(t, or.mam, or.Assets())
 	assert.Equal(t, or.mcm, or.Contracts())
 	assert.Equal(t, or.mmi, or.Metrics())
+	assert.NotNil(t, or.LoadShedding())
+	assert.NotNil(t, or.AdminEvents())
 }
 
 func TestInitDataExchangeGetNodesFail(t *testing.T) {
@@ -663,3 +802,46 @@ func TestInitDataExchangeWithNodes(t *testing.T) {
 	err := or.initDataExchange(or.ctx)
 	assert.NoError(t, err)
 }
+
+func TestInitBatchValidators(t *testing.T) {
+	or := newTestOrchestrator()
+	bvfactory.InitPrefix(batchValidatorConfig)
+	config.Set(config.EventAggregatorBatchValidators, []string{"authorallowlist"})
+
+	err := or.initBatchValidators(or.ctx)
+	assert.NoError(t, err)
+	assert.Len(t, or.batchValidators, 1)
+	assert.Equal(t, "authorallowlist", or.batchValidators[0].Name())
+}
+
+func TestInitBatchValidatorsInitFail(t *testing.T) {
+	or := newTestOrchestrator()
+	bvfactory.InitPrefix(batchValidatorConfig)
+	config.Set(config.EventAggregatorBatchValidators, []string{"authorallowlist"})
+	batchValidatorConfig.SubPrefix("authorallowlist").Set("namespaces", fftypes.JSONObjectArray{{}})
+
+	err := or.initBatchValidators(or.ctx)
+	assert.Regexp(t, "FF10138", err)
+}
+
+func TestBadBatchValidatorPlugin(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.EventAggregatorBatchValidators, []string{"wrong"})
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	or.mdx.On("Init", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	err := or.Init(ctx, cancelCtx)
+	assert.Regexp(t, "FF10357.*wrong", err)
+}
+
+func TestRunAsGroup(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("RunAsGroup", or.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+	err := or.RunAsGroup(or.ctx, func(ctx context.Context) error { return nil })
+	assert.EqualError(t, err, "pop")
+}
@@ -17,12 +17,20 @@
 package orchestrator
 
 import (
+	"context"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
 func (or *orchestrator) attemptChangeEventDispatch(ev *fftypes.ChangeEvent) {
+	if or.adminEvents != nil {
+		// Listeners on the admin websocket receive all change events, and are never allowed to block us
+		or.adminEvents.Dispatch(ev)
+	}
 	// For change events we're not processing as a system, we don't block our processing to dispatch
 	// them remotely. So if the queue is full, we discard the event rather than blocking.
 	select {
@@ -35,9 +43,9 @@ func (or *orchestrator) attemptChangeEventDispatch(ev *fftypes.ChangeEvent) {
 func (or *orchestrator) OrderedUUIDCollectionNSEvent(resType database.OrderedUUIDCollectionNS, eventType fftypes.ChangeEventType, ns string, id *fftypes.UUID, sequence int64) {
 	switch {
 	case eventType == fftypes.ChangeEventTypeCreated && resType == database.CollectionMessages:
-		or.batch.NewMessages() <- sequence
+		or.eventBus.Publish(eventbus.TopicMessageCreated, sequence)
 	case eventType == fftypes.ChangeEventTypeCreated && resType == database.CollectionEvents:
-		or.events.NewEvents() <- sequence
+		or.eventBus.Publish(eventbus.TopicEventCreated, sequence)
 	}
 	var ces *int64
 	if eventType == fftypes.ChangeEventTypeCreated {
@@ -55,7 +63,7 @@ func (or *orchestrator) OrderedUUIDCollectionNSEvent(resType database.OrderedUUI
 
 func (or *orchestrator) OrderedCollectionEvent(resType database.OrderedCollection, eventType fftypes.ChangeEventType, sequence int64) {
 	if eventType == fftypes.ChangeEventTypeCreated && resType == database.CollectionPins {
-		or.events.NewPins() <- sequence
+		or.eventBus.Publish(eventbus.TopicPinCreated, sequence)
 	}
 	or.attemptChangeEventDispatch(&fftypes.ChangeEvent{
 		Collection: string(resType),
@@ -67,11 +75,15 @@ func (or *orchestrator) OrderedCollectionEvent(resType database.OrderedCollectio
 func (or *orchestrator) UUIDCollectionNSEvent(resType database.UUIDCollectionNS, eventType fftypes.ChangeEventType, ns string, id *fftypes.UUID) {
 	switch {
 	case eventType == fftypes.ChangeEventTypeCreated && resType == database.CollectionSubscriptions:
-		or.events.NewSubscriptions() <- id
+		or.eventBus.Publish(eventbus.TopicSubscriptionCreated, id)
 	case eventType == fftypes.ChangeEventTypeDeleted && resType == database.CollectionSubscriptions:
-		or.events.DeletedSubscriptions() <- id
+		or.eventBus.Publish(eventbus.TopicSubscriptionDeleted, id)
 	case eventType == fftypes.ChangeEventTypeUpdated && resType == database.CollectionSubscriptions:
-		or.events.SubscriptionUpdates() <- id
+		or.eventBus.Publish(eventbus.TopicSubscriptionUpdated, id)
+	}
+	if resType == database.CollectionSubscriptions {
+		// Change events for subscriptions are dispatched from SubscriptionChangeEvent, with the full definitions
+		return
 	}
 	or.attemptChangeEventDispatch(&fftypes.ChangeEvent{
 		Collection: string(resType),
@@ -98,3 +110,28 @@ func (or *orchestrator) HashCollectionNSEvent(resType database.HashCollectionNS,
 	})
 
 }
+
+func (or *orchestrator) SubscriptionChangeEvent(change *fftypes.SubscriptionChange) {
+	ev := &fftypes.ChangeEvent{
+		Collection: string(database.CollectionSubscriptions),
+		Type:       change.Type,
+		Namespace:  change.Namespace,
+		ID:         change.Subscription,
+	}
+	if change.Before != nil {
+		ev.Before = change.Before
+	}
+	if change.After != nil {
+		ev.After = change.After
+	}
+	or.attemptChangeEventDispatch(ev)
+}
+
+func (or *orchestrator) ObserveLatency(ctx context.Context, elapsed time.Duration, err error) {
+	if or.loadShed != nil {
+		or.loadShed.Observe(elapsed, err)
+	}
+	if or.metrics != nil && or.metrics.IsMetricsEnabled() {
+		or.metrics.DatabaseOperation(log.GetField(ctx, log.NamespaceField), elapsed, err)
+	}
+}
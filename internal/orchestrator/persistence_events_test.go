@@ -19,78 +19,98 @@ package orchestrator
 import (
 	"context"
 	"testing"
+	"time"
 
-	"github.com/hyperledger/firefly/mocks/batchmocks"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/eventbus"
+	"github.com/hyperledger/firefly/internal/loadshed"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/mocks/admineventsmocks"
 	"github.com/hyperledger/firefly/mocks/eventmocks"
+	"github.com/hyperledger/firefly/mocks/metricsmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
-func TestMessageCreated(t *testing.T) {
-	mb := &batchmocks.Manager{}
+func newTestPersistenceEvents(topic eventbus.Topic) (*orchestrator, *eventmocks.EventManager, *[]interface{}) {
 	mem := &eventmocks.EventManager{}
 	o := &orchestrator{
-		batch:  mb,
-		events: mem,
+		events:   mem,
+		eventBus: eventbus.NewBus(),
 	}
-	mb.On("NewMessages").Return((chan<- int64)(make(chan int64, 1)))
+	var published []interface{}
+	o.eventBus.Subscribe(topic, func(payload interface{}) {
+		published = append(published, payload)
+	})
 	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 1)))
+	return o, mem, &published
+}
+
+func TestMessageCreated(t *testing.T) {
+	o, mem, published := newTestPersistenceEvents(eventbus.TopicMessageCreated)
 	o.OrderedUUIDCollectionNSEvent(database.CollectionMessages, fftypes.ChangeEventTypeCreated, "ns1", fftypes.NewUUID(), 12345)
-	mb.AssertExpectations(t)
+	assert.Equal(t, []interface{}{int64(12345)}, *published)
+	mem.AssertExpectations(t)
 }
 
 func TestPinCreated(t *testing.T) {
-	mem := &eventmocks.EventManager{}
-	o := &orchestrator{
-		events: mem,
-	}
-	mem.On("NewPins").Return((chan<- int64)(make(chan int64, 1)))
-	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 1)))
+	o, mem, published := newTestPersistenceEvents(eventbus.TopicPinCreated)
 	o.OrderedCollectionEvent(database.CollectionPins, fftypes.ChangeEventTypeCreated, 12345)
+	assert.Equal(t, []interface{}{int64(12345)}, *published)
 	mem.AssertExpectations(t)
 }
 
 func TestEventCreated(t *testing.T) {
-	mem := &eventmocks.EventManager{}
-	o := &orchestrator{
-		events: mem,
-	}
-	mem.On("NewEvents").Return((chan<- int64)(make(chan int64, 1)))
-	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 1)))
+	o, mem, published := newTestPersistenceEvents(eventbus.TopicEventCreated)
 	o.OrderedUUIDCollectionNSEvent(database.CollectionEvents, fftypes.ChangeEventTypeCreated, "ns1", fftypes.NewUUID(), 12345)
+	assert.Equal(t, []interface{}{int64(12345)}, *published)
 	mem.AssertExpectations(t)
 }
 
 func TestSubscriptionCreated(t *testing.T) {
-	mem := &eventmocks.EventManager{}
-	o := &orchestrator{
-		events: mem,
-	}
-	mem.On("NewSubscriptions").Return((chan<- *fftypes.UUID)(make(chan *fftypes.UUID, 1)))
-	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 1)))
-	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeCreated, "ns1", fftypes.NewUUID())
-	mem.AssertExpectations(t)
+	o, mem, published := newTestPersistenceEvents(eventbus.TopicSubscriptionCreated)
+	id := fftypes.NewUUID()
+	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeCreated, "ns1", id)
+	assert.Equal(t, []interface{}{id}, *published)
+	mem.AssertNotCalled(t, "ChangeEvents")
 }
 
 func TestSubscriptionUpdated(t *testing.T) {
-	mem := &eventmocks.EventManager{}
-	o := &orchestrator{
-		events: mem,
-	}
-	mem.On("SubscriptionUpdates").Return((chan<- *fftypes.UUID)(make(chan *fftypes.UUID, 1)))
-	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 1)))
-	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeUpdated, "ns1", fftypes.NewUUID())
-	mem.AssertExpectations(t)
+	o, mem, published := newTestPersistenceEvents(eventbus.TopicSubscriptionUpdated)
+	id := fftypes.NewUUID()
+	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeUpdated, "ns1", id)
+	assert.Equal(t, []interface{}{id}, *published)
+	mem.AssertNotCalled(t, "ChangeEvents")
 }
 
 func TestSubscriptionDeleted(t *testing.T) {
+	o, mem, published := newTestPersistenceEvents(eventbus.TopicSubscriptionDeleted)
+	id := fftypes.NewUUID()
+	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeDeleted, "ns1", id)
+	assert.Equal(t, []interface{}{id}, *published)
+	mem.AssertNotCalled(t, "ChangeEvents")
+}
+
+func TestSubscriptionChangeEvent(t *testing.T) {
 	mem := &eventmocks.EventManager{}
 	o := &orchestrator{
+		ctx:    context.Background(),
 		events: mem,
 	}
-	mem.On("DeletedSubscriptions").Return((chan<- *fftypes.UUID)(make(chan *fftypes.UUID, 1)))
-	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 1)))
-	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeDeleted, "ns1", fftypes.NewUUID())
+	changeEvents := make(chan *fftypes.ChangeEvent, 1)
+	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(changeEvents))
+	sub := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1", Name: "sub1"},
+	}
+	o.SubscriptionChangeEvent(fftypes.NewSubscriptionChange(fftypes.ChangeEventTypeCreated, nil, sub))
+	ev := <-changeEvents
+	assert.Equal(t, "subscriptions", ev.Collection)
+	assert.Equal(t, fftypes.ChangeEventTypeCreated, ev.Type)
+	assert.Equal(t, sub.ID, ev.ID)
+	assert.Nil(t, ev.Before)
+	assert.Equal(t, sub, ev.After)
 	mem.AssertExpectations(t)
 }
 
@@ -115,3 +135,42 @@ func TestHashCollectionNSEventOk(t *testing.T) {
 	o.HashCollectionNSEvent(database.CollectionGroups, fftypes.ChangeEventTypeDeleted, "ns1", fftypes.NewRandB32())
 	mem.AssertExpectations(t)
 }
+
+func TestChangeEventDispatchedToAdminEvents(t *testing.T) {
+	mem := &eventmocks.EventManager{}
+	mae := &admineventsmocks.Manager{}
+	o := &orchestrator{
+		ctx:         context.Background(),
+		events:      mem,
+		adminEvents: mae,
+	}
+	id := fftypes.NewUUID()
+	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 1)))
+	mae.On("Dispatch", mock.MatchedBy(func(ce *fftypes.ChangeEvent) bool {
+		return ce.Collection == "operations" && ce.Type == fftypes.ChangeEventTypeUpdated && *ce.ID == *id
+	})).Return()
+	o.UUIDCollectionNSEvent(database.CollectionOperations, fftypes.ChangeEventTypeUpdated, "ns1", id)
+	mem.AssertExpectations(t)
+	mae.AssertExpectations(t)
+}
+
+func TestObserveLatency(t *testing.T) {
+	o := &orchestrator{
+		ctx: context.Background(),
+	}
+	o.ObserveLatency(context.Background(), 1*time.Second, nil)
+
+	config.Reset()
+	config.Set(config.LoadSheddingEnabled, true)
+	config.Set(config.LoadSheddingWindow, "0s")
+	o.loadShed = loadshed.NewMonitor(o.ctx, eventbus.NewBus())
+	o.ObserveLatency(context.Background(), 1*time.Second, nil)
+	assert.Equal(t, loadshed.LevelSlowDispatch, o.LoadShedding().Level())
+
+	mmi := &metricsmocks.Manager{}
+	mmi.On("IsMetricsEnabled").Return(true)
+	mmi.On("DatabaseOperation", "ns1", 1*time.Second, nil).Return()
+	o.metrics = mmi
+	o.ObserveLatency(log.WithLogField(context.Background(), log.NamespaceField, "ns1"), 1*time.Second, nil)
+	mmi.AssertExpectations(t)
+}
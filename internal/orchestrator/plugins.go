@@ -0,0 +1,182 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"runtime/debug"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const redactedConfigValue = "***"
+
+// sensitiveConfigKeys are the (lower case) fragments of config key names, whose values are redacted
+var sensitiveConfigKeys = []string{"password", "secret", "apikey", "privatekey", "passphrase", "authorization"}
+
+var readBuildInfo = debug.ReadBuildInfo
+
+// GetStatusPlugins returns information about each plugin loaded by the node, so the wiring of the node
+// can be verified against the intended deployment without access to its configuration files
+func (or *orchestrator) GetStatusPlugins(ctx context.Context) []*fftypes.NodeStatusPlugin {
+	conf := config.GetConfig()
+	version := buildVersion()
+	startup := or.getStartupStatus()
+
+	plugins := []*fftypes.NodeStatusPlugin{}
+	add := func(pluginType, name, plugin string, capabilities interface{}, pluginConf fftypes.JSONObject) {
+		ps := &fftypes.NodeStatusPlugin{
+			Type:         pluginType,
+			Name:         name,
+			Plugin:       plugin,
+			Version:      version,
+			Capabilities: capabilities,
+			Config:       sanitizePluginConfig(pluginConf),
+			// Plugins that do not connect to an external runtime are ready as soon as they are initialized
+			State: fftypes.PluginStartupStateStarted,
+		}
+		for _, s := range startup.Plugins {
+			if s.Type == pluginType && s.Name == name {
+				ps.State = s.State
+				ps.Error = s.Error
+			}
+		}
+		plugins = append(plugins, ps)
+	}
+
+	add("database", or.database.Name(), or.database.Name(), or.database.Capabilities(),
+		conf.GetObject("database").GetObject(or.database.Name()))
+	add("blockchain", or.blockchain.Name(), or.blockchain.Name(), or.blockchain.Capabilities(),
+		conf.GetObject("blockchain").GetObject(or.blockchain.Name()))
+	for _, name := range or.ledgerNames() {
+		bi := or.ledgers[name].bi
+		add("blockchain", name, bi.Name(), bi.Capabilities(), namedArrayEntry(conf, "ledgers", name))
+	}
+	add("identity", or.identityPlugin.Name(), or.identityPlugin.Name(), or.identityPlugin.Capabilities(),
+		conf.GetObject("identity").GetObject(or.identityPlugin.Name()))
+	add("publicstorage", or.publicstorage.Name(), or.publicstorage.Name(), or.publicstorage.Capabilities(),
+		conf.GetObject("publicstorage").GetObject(or.publicstorage.Name()))
+	for _, name := range or.publicStorageNames() {
+		pi := or.publicstorages[name]
+		add("publicstorage", name, pi.Name(), pi.Capabilities(), namedArrayEntry(conf, "publicstorages", name))
+	}
+	add("dataexchange", or.dataexchange.Name(), or.dataexchange.Name(), or.dataexchange.Capabilities(),
+		conf.GetObject("dataexchange").GetObject(or.dataexchange.Name()))
+	for _, bv := range or.batchValidators {
+		add("batchvalidator", bv.Name(), bv.Name(), nil,
+			conf.GetObject("batchvalidator").GetObject(bv.Name()))
+	}
+	for _, name := range or.tokenNames() {
+		ti := or.tokens[name]
+		add("tokens", name, ti.Name(), ti.Capabilities(), namedArrayEntry(conf, "tokens", name))
+	}
+	return plugins
+}
+
+// buildVersion returns the version of the node build - all plugins are compiled into the node
+func buildVersion() string {
+	if info, ok := readBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+func namedArrayEntry(conf fftypes.JSONObject, key, name string) fftypes.JSONObject {
+	entries, _ := conf[key].([]interface{})
+	for _, e := range entries {
+		entry := fftypes.JSONObject(toConfigMap(e))
+		if entry.GetString("name") == name {
+			return entry
+		}
+	}
+	return fftypes.JSONObject{}
+}
+
+func isSensitiveConfigKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, s := range sensitiveConfigKeys {
+		if strings.Contains(lowerKey, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizePluginConfig returns a copy of the config of a plugin, with credentials redacted. That includes
+// the values of sensitive keys, all custom HTTP headers, and the passwords in any URLs.
+func sanitizePluginConfig(conf fftypes.JSONObject) fftypes.JSONObject {
+	sanitized := fftypes.JSONObject{}
+	for k, v := range conf {
+		switch {
+		case isSensitiveConfigKey(k):
+			sanitized[k] = redactedConfigValue
+		case strings.ToLower(k) == "headers":
+			headers := fftypes.JSONObject{}
+			for h := range toConfigMap(v) {
+				headers[h] = redactedConfigValue
+			}
+			sanitized[k] = headers
+		default:
+			sanitized[k] = sanitizeConfigValue(v)
+		}
+	}
+	return sanitized
+}
+
+// toConfigMap returns the map for an object in the config, including those within arrays that
+// are parsed from YAML with non-string keys
+func toConfigMap(v interface{}) map[string]interface{} {
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		return vt
+	case fftypes.JSONObject:
+		return vt
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vt))
+		for k, v := range vt {
+			m[fmt.Sprintf("%v", k)] = v
+		}
+		return m
+	default:
+		return nil
+	}
+}
+
+func sanitizeConfigValue(v interface{}) interface{} {
+	if m := toConfigMap(v); m != nil {
+		return map[string]interface{}(sanitizePluginConfig(m))
+	}
+	switch vt := v.(type) {
+	case []interface{}:
+		sanitized := make([]interface{}, len(vt))
+		for i, e := range vt {
+			sanitized[i] = sanitizeConfigValue(e)
+		}
+		return sanitized
+	case string:
+		if u, err := url.Parse(vt); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+		return vt
+	default:
+		return vt
+	}
+}
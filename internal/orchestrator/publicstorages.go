@@ -0,0 +1,98 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/publicstorage/pscache"
+	"github.com/hyperledger/firefly/internal/publicstorage/psfactory"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+)
+
+// initPublicStorages loads a public storage plugin instance for each of the additional named public storage
+// instances, alongside the default public storage of the node, and resolves the instance each predefined
+// namespace publishes its batches and blobs to
+func (or *orchestrator) initPublicStorages(ctx context.Context) (err error) {
+	if or.publicstorages == nil {
+		or.publicstorages = make(map[string]publicstorage.Plugin)
+		publicstoragesConfigArraySize := publicstoragesConfig.ArraySize()
+		for i := 0; i < publicstoragesConfigArraySize; i++ {
+			prefix := publicstoragesConfig.ArrayEntry(i)
+			name := prefix.GetString(publicstorage.InstanceConfigName)
+			pluginType := prefix.GetString(publicstorage.InstanceConfigType)
+			if name == "" {
+				return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, publicstorage.InstanceConfigName, "publicstorages")
+			}
+			if pluginType == "" {
+				return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, publicstorage.InstanceConfigType, "publicstorages")
+			}
+			if err = fftypes.ValidateFFNameField(ctx, name, "name"); err != nil {
+				return err
+			}
+			if _, exists := or.publicstorages[name]; exists {
+				return i18n.NewError(ctx, i18n.MsgDuplicatePublicStorageName, name)
+			}
+
+			log.L(ctx).Infof("Loading public storage name=%s type=%s", name, pluginType)
+			plugin, err := psfactory.GetPlugin(ctx, pluginType)
+			if err != nil {
+				return err
+			}
+			pluginPrefix := prefix.SubPrefix(pluginType)
+			plugin.InitPrefix(pluginPrefix)
+			if err = plugin.Init(ctx, pluginPrefix, &or.bc); err != nil {
+				return err
+			}
+			if plugin, err = pscache.Wrap(ctx, plugin); err != nil {
+				return err
+			}
+			or.publicstorages[name] = plugin
+		}
+	}
+
+	or.nsPublicStorage = make(map[string]publicstorage.Plugin)
+	for _, nsObj := range config.GetObjectArray(config.NamespacesPredefined) {
+		name := nsObj.GetString("name")
+		storage := nsObj.GetString("publicstorage")
+		if storage == "" {
+			continue // uses the default public storage
+		}
+		plugin, ok := or.publicstorages[storage]
+		if !ok {
+			return i18n.NewError(ctx, i18n.MsgUnknownNamespacePubStorage, storage, name)
+		}
+		log.L(ctx).Infof("Namespace '%s' uses public storage '%s'", name, storage)
+		or.nsPublicStorage[name] = plugin
+	}
+	return nil
+}
+
+// publicStorageNames returns the sorted names of the additional public storage instances
+func (or *orchestrator) publicStorageNames() []string {
+	names := make([]string, 0, len(or.publicstorages))
+	for name := range or.publicstorages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
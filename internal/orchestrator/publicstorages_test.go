@@ -0,0 +1,155 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/publicstorage/psfactory"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setTestPublicStorages(publicstorages ...fftypes.JSONObject) {
+	// Viper only navigates into arrays that are loaded as config (rather than set)
+	b, _ := json.Marshal(fftypes.JSONObject{"publicstorages": publicstorages})
+	viper.SetConfigType("json")
+	_ = viper.ReadConfig(bytes.NewReader(b))
+	psfactory.InitInstancesPrefix(publicstoragesConfig)
+}
+
+func testIPFSInstance(name string) fftypes.JSONObject {
+	return fftypes.JSONObject{
+		"name": name,
+		"type": "ipfs",
+		"ipfs": fftypes.JSONObject{
+			"api":     fftypes.JSONObject{"url": "http://localhost:5001"},
+			"gateway": fftypes.JSONObject{"url": "http://localhost:8080"},
+		},
+	}
+}
+
+func TestInitPublicStoragesOK(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(testIPFSInstance("ipfs2"))
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "default"},
+		{"name": "ns2", "publicstorage": "ipfs2"},
+	})
+
+	err := or.initPublicStorages(or.ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"ipfs2"}, or.publicStorageNames())
+	pi := or.publicstorages["ipfs2"]
+	assert.Equal(t, "ipfs", pi.Name())
+	assert.Len(t, or.nsPublicStorage, 1)
+	assert.Equal(t, pi, or.nsPublicStorage["ns2"])
+}
+
+func TestInitPublicStoragesMissingName(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(fftypes.JSONObject{"type": "ipfs"})
+
+	err := or.initPublicStorages(or.ctx)
+	assert.Regexp(t, "FF10138.*name", err)
+}
+
+func TestInitPublicStoragesMissingType(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(fftypes.JSONObject{"name": "ipfs2"})
+
+	err := or.initPublicStorages(or.ctx)
+	assert.Regexp(t, "FF10138.*type", err)
+}
+
+func TestInitPublicStoragesBadName(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(fftypes.JSONObject{"name": "!wrong", "type": "ipfs"})
+
+	err := or.initPublicStorages(or.ctx)
+	assert.Regexp(t, "FF10131.*'name'", err)
+}
+
+func TestInitPublicStoragesUnknownType(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(fftypes.JSONObject{"name": "ipfs2", "type": "wrong"})
+
+	err := or.initPublicStorages(or.ctx)
+	assert.Regexp(t, "FF10134.*wrong", err)
+}
+
+func TestInitPublicStoragesPluginInitFail(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(fftypes.JSONObject{"name": "ipfs2", "type": "ipfs"})
+
+	err := or.initPublicStorages(or.ctx)
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestInitPublicStoragesCacheDirFail(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(testIPFSInstance("ipfs2"))
+	notADir := filepath.Join(t.TempDir(), "file")
+	err := ioutil.WriteFile(notADir, []byte{}, 0600)
+	assert.NoError(t, err)
+	config.Set(config.PublicStorageCacheDirectory, filepath.Join(notADir, "cache"))
+
+	err = or.initPublicStorages(or.ctx)
+	assert.Regexp(t, "FF10391", err)
+}
+
+func TestInitPublicStoragesDuplicateName(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(testIPFSInstance("ipfs2"), testIPFSInstance("ipfs2"))
+
+	err := or.initPublicStorages(or.ctx)
+	assert.Regexp(t, "FF10462.*ipfs2", err)
+}
+
+func TestInitPublicStoragesUnknownNamespaceStorage(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "ns2", "publicstorage": "ipfs2"},
+	})
+	or.publicstorages = map[string]publicstorage.Plugin{}
+
+	err := or.initPublicStorages(context.Background())
+	assert.Regexp(t, "FF10463.*ipfs2.*ns2", err)
+}
+
+func TestInitPluginsPublicStoragesFail(t *testing.T) {
+	or := newTestOrchestrator()
+	setTestPublicStorages(fftypes.JSONObject{"name": "ipfs2"})
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := or.initPlugins(or.ctx)
+	assert.Regexp(t, "FF10138.*type", err)
+}
@@ -0,0 +1,49 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (or *orchestrator) GetQuarantinedBatchByID(ctx context.Context, ns, id string) (*fftypes.QuarantinedBatch, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	qb, err := or.database.GetQuarantinedBatchByID(ctx, u)
+	if err == nil && (qb == nil || qb.Namespace != ns) {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return qb, err
+}
+
+func (or *orchestrator) GetQuarantinedBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.QuarantinedBatch, *database.FilterResult, error) {
+	return or.database.GetQuarantinedBatches(ctx, or.scopeNS(ns, filter))
+}
+
+func (or *orchestrator) ReprocessQuarantinedBatch(ctx context.Context, ns, id string) (*fftypes.Batch, error) {
+	qb, err := or.GetQuarantinedBatchByID(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	return or.events.ReprocessQuarantinedBatch(ctx, qb)
+}
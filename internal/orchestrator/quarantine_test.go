@@ -0,0 +1,85 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetQuarantinedBatchByID(t *testing.T) {
+	or := newTestOrchestrator()
+	id := fftypes.NewUUID()
+	or.mdi.On("GetQuarantinedBatchByID", mock.Anything, id).Return(&fftypes.QuarantinedBatch{ID: id, Namespace: "ns1"}, nil)
+	qb, err := or.GetQuarantinedBatchByID(context.Background(), "ns1", id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, id, qb.ID)
+}
+
+func TestGetQuarantinedBatchByIDBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetQuarantinedBatchByID(context.Background(), "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetQuarantinedBatchByIDWrongNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	id := fftypes.NewUUID()
+	or.mdi.On("GetQuarantinedBatchByID", mock.Anything, id).Return(&fftypes.QuarantinedBatch{ID: id, Namespace: "ns2"}, nil)
+	_, err := or.GetQuarantinedBatchByID(context.Background(), "ns1", id.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetQuarantinedBatchByIDFail(t *testing.T) {
+	or := newTestOrchestrator()
+	id := fftypes.NewUUID()
+	or.mdi.On("GetQuarantinedBatchByID", mock.Anything, id).Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetQuarantinedBatchByID(context.Background(), "ns1", id.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetQuarantinedBatches(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetQuarantinedBatches", mock.Anything, mock.Anything).Return([]*fftypes.QuarantinedBatch{}, nil, nil)
+	fb := database.QuarantinedBatchQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("reason", "test"))
+	_, _, err := or.GetQuarantinedBatches(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+}
+
+func TestReprocessQuarantinedBatch(t *testing.T) {
+	or := newTestOrchestrator()
+	qb := &fftypes.QuarantinedBatch{ID: fftypes.NewUUID(), Namespace: "ns1"}
+	or.mdi.On("GetQuarantinedBatchByID", mock.Anything, qb.ID).Return(qb, nil)
+	or.mem.On("ReprocessQuarantinedBatch", mock.Anything, qb).Return(&fftypes.Batch{}, nil)
+	_, err := or.ReprocessQuarantinedBatch(context.Background(), "ns1", qb.ID.String())
+	assert.NoError(t, err)
+}
+
+func TestReprocessQuarantinedBatchNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	id := fftypes.NewUUID()
+	or.mdi.On("GetQuarantinedBatchByID", mock.Anything, id).Return(nil, nil)
+	_, err := or.ReprocessQuarantinedBatch(context.Background(), "ns1", id.String())
+	assert.Regexp(t, "FF10109", err)
+}
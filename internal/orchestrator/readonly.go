@@ -0,0 +1,211 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/assets"
+	"github.com/hyperledger/firefly/internal/broadcast"
+	"github.com/hyperledger/firefly/internal/contracts"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/networkmap"
+	"github.com/hyperledger/firefly/internal/privatemessaging"
+	"github.com/hyperledger/firefly/internal/sysmessaging"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// When the node is in read-only mode, the managers returned to the API layer are wrapped
+// so that every action that would send a message, or submit a blockchain transaction, is refused
+// with a 403 before any work is done.
+// These wrappers are only an early exit for the API. Read-only mode is enforced where batches are pinned,
+// contracts are invoked, token connectors are called and private data is sent, so a path that does not
+// pass through a wrapper is refused all the same. Aggregation of data arriving from the network is unaffected.
+
+type readOnlySender struct{}
+
+func (s *readOnlySender) Prepare(ctx context.Context) error {
+	return i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (s *readOnlySender) Send(ctx context.Context) error {
+	return i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (s *readOnlySender) SendAndWait(ctx context.Context) error {
+	return i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+type readOnlyBroadcast struct {
+	broadcast.Manager
+}
+
+func (ro *readOnlyBroadcast) NewBroadcast(ns string, in *fftypes.MessageInOut) sysmessaging.MessageSender {
+	return &readOnlySender{}
+}
+
+func (ro *readOnlyBroadcast) BroadcastDatatype(ctx context.Context, ns string, datatype *fftypes.Datatype, waitConfirm bool) (msg *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyBroadcast) BroadcastNamespace(ctx context.Context, ns *fftypes.Namespace, waitConfirm bool) (msg *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyBroadcast) BroadcastMessage(ctx context.Context, ns string, in *fftypes.MessageInOut, waitConfirm bool) (out *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyBroadcast) BroadcastDefinitionAsNode(ctx context.Context, ns string, def fftypes.Definition, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyBroadcast) BroadcastDefinition(ctx context.Context, ns string, def fftypes.Definition, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyBroadcast) BroadcastRootOrgDefinition(ctx context.Context, def *fftypes.Organization, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyBroadcast) BroadcastTokenPool(ctx context.Context, ns string, pool *fftypes.TokenPoolAnnouncement, waitConfirm bool) (msg *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+type readOnlyPrivateMessaging struct {
+	privatemessaging.Manager
+}
+
+func (ro *readOnlyPrivateMessaging) NewMessage(ns string, msg *fftypes.MessageInOut) sysmessaging.MessageSender {
+	return &readOnlySender{}
+}
+
+func (ro *readOnlyPrivateMessaging) SendMessage(ctx context.Context, ns string, in *fftypes.MessageInOut, waitConfirm bool) (out *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyPrivateMessaging) RequestReply(ctx context.Context, ns string, request *fftypes.MessageInOut) (reply *fftypes.MessageInOut, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyPrivateMessaging) CreateGroup(ctx context.Context, ns string, in *fftypes.InputGroup) (*fftypes.Group, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyPrivateMessaging) ChangeGroupMembers(ctx context.Context, ns, groupHash string, input *fftypes.GroupMembershipChangeInput) (*fftypes.GroupMembershipChange, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+type readOnlyNetworkMap struct {
+	networkmap.Manager
+}
+
+func (ro *readOnlyNetworkMap) RegisterOrganization(ctx context.Context, org *fftypes.Organization, waitConfirm bool) (msg *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyNetworkMap) RegisterNode(ctx context.Context, waitConfirm bool) (node *fftypes.Node, msg *fftypes.Message, err error) {
+	return nil, nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyNetworkMap) RegisterNodeOrganization(ctx context.Context, waitConfirm bool) (org *fftypes.Organization, msg *fftypes.Message, err error) {
+	return nil, nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyNetworkMap) RotateOrganizationKey(ctx context.Context, id string, rotation *fftypes.KeyRotation, waitConfirm bool) (msg *fftypes.Message, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+type readOnlyAssets struct {
+	assets.Manager
+}
+
+func (ro *readOnlyAssets) CreateTokenPool(ctx context.Context, ns string, pool *fftypes.TokenPool, waitConfirm bool) (*fftypes.TokenPool, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyAssets) UpdateTokenPoolState(ctx context.Context, ns, poolNameOrID string, state fftypes.TokenPoolState) (*fftypes.TokenPool, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyAssets) NewTransfer(ns string, transfer *fftypes.TokenTransferInput) sysmessaging.MessageSender {
+	return &readOnlySender{}
+}
+
+func (ro *readOnlyAssets) MintTokens(ctx context.Context, ns string, transfer *fftypes.TokenTransferInput, waitConfirm bool) (*fftypes.TokenTransfer, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyAssets) BurnTokens(ctx context.Context, ns string, transfer *fftypes.TokenTransferInput, waitConfirm bool) (*fftypes.TokenTransfer, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyAssets) TransferTokens(ctx context.Context, ns string, transfer *fftypes.TokenTransferInput, waitConfirm bool) (*fftypes.TokenTransfer, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyAssets) NewApproval(ns string, approve *fftypes.TokenApprovalInput) sysmessaging.MessageSender {
+	return &readOnlySender{}
+}
+
+func (ro *readOnlyAssets) TokenApproval(ctx context.Context, ns string, approval *fftypes.TokenApprovalInput, waitConfirm bool) (*fftypes.TokenApproval, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyAssets) RetryOperation(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+type readOnlyContracts struct {
+	contracts.Manager
+}
+
+func (ro *readOnlyContracts) BroadcastFFI(ctx context.Context, ns string, ffi *fftypes.FFI, waitConfirm bool) (output *fftypes.FFI, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyContracts) ImportFFI(ctx context.Context, ns string, generationRequest *fftypes.FFIGenerationRequest, waitConfirm bool) (*fftypes.FFI, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyContracts) InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest, waitConfirm bool) (interface{}, error) {
+	if req.Type == fftypes.CallTypeQuery {
+		// Queries do not submit a transaction, so are permitted
+		return ro.Manager.InvokeContract(ctx, ns, req, waitConfirm)
+	}
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyContracts) InvokeContractAPI(ctx context.Context, ns, apiName, methodPath string, req *fftypes.ContractCallRequest, waitConfirm bool) (interface{}, error) {
+	if req.Type == fftypes.CallTypeQuery {
+		// Queries do not submit a transaction, so are permitted
+		return ro.Manager.InvokeContractAPI(ctx, ns, apiName, methodPath, req, waitConfirm)
+	}
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyContracts) BroadcastContractAPI(ctx context.Context, httpServerURL, ns string, api *fftypes.ContractAPI, waitConfirm bool) (output *fftypes.ContractAPI, err error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyContracts) SubmitRawTransaction(ctx context.Context, ns string, req *fftypes.RawTransactionRequest) (*fftypes.Operation, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
+
+func (ro *readOnlyContracts) RetryOperation(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error) {
+	return nil, i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+}
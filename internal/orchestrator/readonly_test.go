@@ -0,0 +1,193 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInitReadOnly(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.NodeReadOnly, true)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	or.mdx.On("Init", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("GetNamespace", mock.Anything, mock.Anything).Return(nil, nil)
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.Anything, true).Return(nil)
+	or.mti.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mmi.On("Init").Return(nil)
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	err := or.Init(ctx, cancelCtx)
+	assert.NoError(t, err)
+
+	assert.True(t, or.readOnly)
+	assert.Equal(t, &readOnlyBroadcast{or.mbm}, or.Broadcast())
+	assert.Equal(t, &readOnlyPrivateMessaging{or.mpm}, or.PrivateMessaging())
+	assert.Equal(t, &readOnlyNetworkMap{or.mnm}, or.NetworkMap())
+	assert.Equal(t, &readOnlyAssets{or.mam}, or.Assets())
+	assert.Equal(t, &readOnlyContracts{or.mcm}, or.Contracts())
+	assert.Equal(t, or.mem, or.Events())
+	assert.Equal(t, or.mdm, or.Data())
+}
+
+func TestReadOnlySender(t *testing.T) {
+	or := newTestOrchestrator()
+	or.readOnly = true
+	ctx := context.Background()
+
+	for _, sender := range []interface {
+		Prepare(ctx context.Context) error
+		Send(ctx context.Context) error
+		SendAndWait(ctx context.Context) error
+	}{
+		or.Broadcast().NewBroadcast("ns1", &fftypes.MessageInOut{}),
+		or.PrivateMessaging().NewMessage("ns1", &fftypes.MessageInOut{}),
+		or.Assets().NewTransfer("ns1", &fftypes.TokenTransferInput{}),
+		or.Assets().NewApproval("ns1", &fftypes.TokenApprovalInput{}),
+	} {
+		assert.Regexp(t, "FF10358", sender.Prepare(ctx))
+		assert.Regexp(t, "FF10358", sender.Send(ctx))
+		assert.Regexp(t, "FF10358", sender.SendAndWait(ctx))
+	}
+}
+
+func TestReadOnlyBroadcast(t *testing.T) {
+	or := newTestOrchestrator()
+	or.readOnly = true
+	ctx := context.Background()
+	bm := or.Broadcast()
+
+	_, err := bm.BroadcastDatatype(ctx, "ns1", &fftypes.Datatype{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = bm.BroadcastNamespace(ctx, &fftypes.Namespace{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = bm.BroadcastDefinitionAsNode(ctx, "ns1", &fftypes.Datatype{}, fftypes.SystemTagDefineDatatype, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = bm.BroadcastDefinition(ctx, "ns1", &fftypes.Datatype{}, &fftypes.Identity{}, fftypes.SystemTagDefineDatatype, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = bm.BroadcastRootOrgDefinition(ctx, &fftypes.Organization{}, &fftypes.Identity{}, fftypes.SystemTagDefineOrganization, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = bm.BroadcastTokenPool(ctx, "ns1", &fftypes.TokenPoolAnnouncement{}, false)
+	assert.Regexp(t, "FF10358", err)
+}
+
+func TestReadOnlyPrivateMessaging(t *testing.T) {
+	or := newTestOrchestrator()
+	or.readOnly = true
+	ctx := context.Background()
+	pm := or.PrivateMessaging()
+
+	_, err := pm.SendMessage(ctx, "ns1", &fftypes.MessageInOut{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = pm.RequestReply(ctx, "ns1", &fftypes.MessageInOut{})
+	assert.Regexp(t, "FF10358", err)
+	_, err = pm.CreateGroup(ctx, "ns1", &fftypes.InputGroup{})
+	assert.Regexp(t, "FF10358", err)
+	_, err = pm.ChangeGroupMembers(ctx, "ns1", "abcd", &fftypes.GroupMembershipChangeInput{})
+	assert.Regexp(t, "FF10358", err)
+
+	or.mpm.On("GetGroupByID", ctx, "abcd").Return(&fftypes.Group{}, nil)
+	_, err = pm.GetGroupByID(ctx, "abcd")
+	assert.NoError(t, err)
+}
+
+func TestReadOnlyRequestReply(t *testing.T) {
+	or := newTestOrchestrator()
+	or.readOnly = true
+	_, err := or.RequestReply(context.Background(), "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{Header: fftypes.MessageHeader{Group: fftypes.NewRandB32()}},
+	})
+	assert.Regexp(t, "FF10358", err)
+}
+
+func TestReadOnlyNetworkMap(t *testing.T) {
+	or := newTestOrchestrator()
+	or.readOnly = true
+	ctx := context.Background()
+	nm := or.NetworkMap()
+
+	_, err := nm.RegisterOrganization(ctx, &fftypes.Organization{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, _, err = nm.RegisterNode(ctx, false)
+	assert.Regexp(t, "FF10358", err)
+	_, _, err = nm.RegisterNodeOrganization(ctx, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = nm.RotateOrganizationKey(ctx, "org1", &fftypes.KeyRotation{}, false)
+	assert.Regexp(t, "FF10358", err)
+}
+
+func TestReadOnlyAssets(t *testing.T) {
+	or := newTestOrchestrator()
+	or.readOnly = true
+	ctx := context.Background()
+	am := or.Assets()
+
+	_, err := am.CreateTokenPool(ctx, "ns1", &fftypes.TokenPool{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = am.UpdateTokenPoolState(ctx, "ns1", "pool1", fftypes.TokenPoolStatePaused)
+	assert.Regexp(t, "FF10358", err)
+	_, err = am.MintTokens(ctx, "ns1", &fftypes.TokenTransferInput{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = am.BurnTokens(ctx, "ns1", &fftypes.TokenTransferInput{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = am.TransferTokens(ctx, "ns1", &fftypes.TokenTransferInput{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = am.TokenApproval(ctx, "ns1", &fftypes.TokenApprovalInput{}, false)
+	assert.Regexp(t, "FF10358", err)
+}
+
+func TestReadOnlyContracts(t *testing.T) {
+	or := newTestOrchestrator()
+	or.readOnly = true
+	ctx := context.Background()
+	cm := or.Contracts()
+
+	_, err := cm.BroadcastFFI(ctx, "ns1", &fftypes.FFI{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = cm.ImportFFI(ctx, "ns1", &fftypes.FFIGenerationRequest{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = cm.BroadcastContractAPI(ctx, "http://localhost", "ns1", &fftypes.ContractAPI{}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = cm.InvokeContract(ctx, "ns1", &fftypes.ContractCallRequest{Type: fftypes.CallTypeInvoke}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = cm.InvokeContractAPI(ctx, "ns1", "api", "method", &fftypes.ContractCallRequest{Type: fftypes.CallTypeInvoke}, false)
+	assert.Regexp(t, "FF10358", err)
+	_, err = cm.SubmitRawTransaction(ctx, "ns1", &fftypes.RawTransactionRequest{})
+	assert.Regexp(t, "FF10358", err)
+
+	query := &fftypes.ContractCallRequest{Type: fftypes.CallTypeQuery}
+	or.mcm.On("InvokeContract", ctx, "ns1", query, false).Return("result", nil)
+	or.mcm.On("InvokeContractAPI", ctx, "ns1", "api", "method", query, false).Return("result", nil)
+	res, err := cm.InvokeContract(ctx, "ns1", query, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "result", res)
+	res, err = cm.InvokeContractAPI(ctx, "ns1", "api", "method", query, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "result", res)
+}
@@ -0,0 +1,130 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"sort"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/retry"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// startPlugins starts each of the plugins that connect to an external runtime. In background mode
+// these are started with indefinite retry in the background, so that the node can serve queries
+// from the database while a slow plugin (such as a blockchain connector that is still syncing) starts.
+func (or *orchestrator) startPlugins() error {
+	or.startupBackground = config.GetBool(config.NodeStartupBackground)
+	or.startupRetry = retry.Retry{
+		InitialDelay: config.GetDuration(config.NodeStartupRetryInitDelay),
+		MaximumDelay: config.GetDuration(config.NodeStartupRetryMaxDelay),
+		Factor:       config.GetFloat64(config.NodeStartupRetryFactor),
+	}
+	or.startupPlugins = nil
+
+	tokenNames := or.tokenNames()
+	ledgerNames := or.ledgerNames()
+
+	err := or.startPlugin("blockchain", or.blockchain.Name(), or.blockchain.Start)
+	for _, name := range ledgerNames {
+		if err == nil {
+			err = or.startPlugin("blockchain", name, or.ledgers[name].bi.Start)
+		}
+	}
+	if err == nil {
+		err = or.startPlugin("dataexchange", or.dataexchange.Name(), or.messaging.Start)
+	}
+	for _, name := range tokenNames {
+		if err == nil {
+			err = or.startPlugin("tokens", name, or.tokens[name].Start)
+		}
+	}
+	return err
+}
+
+func (or *orchestrator) startPlugin(pluginType, name string, start func() error) error {
+	ps := &fftypes.NodeStatusPluginStartup{
+		Type:  pluginType,
+		Name:  name,
+		State: fftypes.PluginStartupStateStarting,
+	}
+	or.startupMux.Lock()
+	or.startupPlugins = append(or.startupPlugins, ps)
+	or.startupMux.Unlock()
+
+	if !or.startupBackground {
+		err := start()
+		or.setPluginStartupState(ps, err)
+		return err
+	}
+
+	go func() {
+		_ = or.startupRetry.Do(or.ctx, "start "+pluginType+" plugin "+name, func(attempt int) (retry bool, err error) {
+			err = start()
+			or.setPluginStartupState(ps, err)
+			return err != nil, err // retry indefinitely (until context closes)
+		})
+	}()
+	return nil
+}
+
+func (or *orchestrator) setPluginStartupState(ps *fftypes.NodeStatusPluginStartup, err error) {
+	or.startupMux.Lock()
+	defer or.startupMux.Unlock()
+	if err != nil {
+		ps.Error = err.Error()
+		return
+	}
+	ps.State = fftypes.PluginStartupStateStarted
+	ps.Error = ""
+	log.L(or.ctx).Infof("Started %s plugin '%s'", ps.Type, ps.Name)
+	if ready, _ := or.startupStatusLocked(); ready {
+		log.L(or.ctx).Infof("All plugins started - node is ready")
+	}
+}
+
+func (or *orchestrator) getStartupStatus() fftypes.NodeStatusStartup {
+	or.startupMux.Lock()
+	defer or.startupMux.Unlock()
+	ready, plugins := or.startupStatusLocked()
+	return fftypes.NodeStatusStartup{
+		Ready:   ready,
+		Plugins: plugins,
+	}
+}
+
+func (or *orchestrator) startupStatusLocked() (ready bool, plugins []*fftypes.NodeStatusPluginStartup) {
+	ready = len(or.startupPlugins) > 0
+	plugins = make([]*fftypes.NodeStatusPluginStartup, len(or.startupPlugins))
+	for i, ps := range or.startupPlugins {
+		psCopy := *ps
+		plugins[i] = &psCopy
+		ready = ready && ps.State == fftypes.PluginStartupStateStarted
+	}
+	return ready, plugins
+}
+
+// tokenNames returns the sorted names of the tokens plugins
+func (or *orchestrator) tokenNames() []string {
+	names := make([]string, 0, len(or.tokens))
+	for name := range or.tokens {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,112 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStartPluginsSync(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mbi.On("Start").Return(nil)
+	or.mpm.On("Start").Return(nil)
+	or.mti.On("Start").Return(nil)
+
+	status := or.getStartupStatus()
+	assert.False(t, status.Ready)
+	assert.Empty(t, status.Plugins)
+
+	err := or.startPlugins()
+	assert.NoError(t, err)
+
+	status = or.getStartupStatus()
+	assert.True(t, status.Ready)
+	assert.Equal(t, []*fftypes.NodeStatusPluginStartup{
+		{Type: "blockchain", Name: "mock-bi", State: fftypes.PluginStartupStateStarted},
+		{Type: "dataexchange", Name: "mock-dx", State: fftypes.PluginStartupStateStarted},
+		{Type: "tokens", Name: "token", State: fftypes.PluginStartupStateStarted},
+	}, status.Plugins)
+}
+
+func TestStartPluginsLedgers(t *testing.T) {
+	or := newTestOrchestrator()
+	mbi2 := &blockchainmocks.Plugin{}
+	or.ledgers = map[string]*boundCallbacks{"ledger2": {bi: mbi2, ledger: "ledger2"}}
+	or.mbi.On("Start").Return(nil)
+	mbi2.On("Start").Return(nil)
+	or.mpm.On("Start").Return(nil)
+	or.mti.On("Start").Return(nil)
+
+	err := or.startPlugins()
+	assert.NoError(t, err)
+
+	status := or.getStartupStatus()
+	assert.True(t, status.Ready)
+	assert.Equal(t, []*fftypes.NodeStatusPluginStartup{
+		{Type: "blockchain", Name: "mock-bi", State: fftypes.PluginStartupStateStarted},
+		{Type: "blockchain", Name: "ledger2", State: fftypes.PluginStartupStateStarted},
+		{Type: "dataexchange", Name: "mock-dx", State: fftypes.PluginStartupStateStarted},
+		{Type: "tokens", Name: "token", State: fftypes.PluginStartupStateStarted},
+	}, status.Plugins)
+}
+
+func TestStartPluginsSyncFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mbi.On("Start").Return(fmt.Errorf("pop"))
+
+	err := or.startPlugins()
+	assert.EqualError(t, err, "pop")
+
+	status := or.getStartupStatus()
+	assert.False(t, status.Ready)
+	assert.Len(t, status.Plugins, 1)
+	assert.Equal(t, fftypes.PluginStartupStateStarting, status.Plugins[0].State)
+	assert.Equal(t, "pop", status.Plugins[0].Error)
+}
+
+func TestStartPluginsBackground(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.NodeStartupBackground, true)
+	config.Set(config.NodeStartupRetryInitDelay, "1ms")
+	started := make(chan struct{})
+	or.mbi.On("Start").Return(fmt.Errorf("pop")).Once()
+	or.mbi.On("Start").Return(nil).Run(func(_ mock.Arguments) {
+		close(started)
+	})
+	or.mpm.On("Start").Return(nil)
+	or.mti.On("Start").Return(nil)
+
+	err := or.startPlugins()
+	assert.NoError(t, err)
+
+	<-started
+	for !or.getStartupStatus().Ready {
+		time.Sleep(1 * time.Millisecond)
+	}
+	status := or.getStartupStatus()
+	assert.Len(t, status.Plugins, 3)
+	assert.Empty(t, status.Plugins[0].Error)
+	or.mbi.AssertExpectations(t)
+}
@@ -20,7 +20,10 @@ import (
 	"context"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/loadshed"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/nodekey"
+	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -41,12 +44,20 @@ func (or *orchestrator) GetNodeUUID(ctx context.Context) (node *fftypes.UUID) {
 	return or.node
 }
 
+func (or *orchestrator) SignBatchHash(ctx context.Context, hash *fftypes.Bytes32) string {
+	if or.nodeKey == nil {
+		return ""
+	}
+	return nodekey.Sign(or.nodeKey, hash)
+}
+
 func (or *orchestrator) GetStatus(ctx context.Context) (status *fftypes.NodeStatus, err error) {
 
 	orgKey, _ := or.identity.GetLocalOrgKey(ctx)
 	status = &fftypes.NodeStatus{
 		Node: fftypes.NodeStatusNode{
-			Name: config.GetString(config.NodeName),
+			Name:     config.GetString(config.NodeName),
+			ReadOnly: or.readOnly,
 		},
 		Org: fftypes.NodeStatusOrg{
 			Name:     config.GetString(config.OrgName),
@@ -55,6 +66,14 @@ func (or *orchestrator) GetStatus(ctx context.Context) (status *fftypes.NodeStat
 		Defaults: fftypes.NodeStatusDefaults{
 			Namespace: config.GetString(config.NamespacesDefault),
 		},
+		Startup:    or.getStartupStatus(),
+		Connectors: or.getConnectorStatus(),
+		LoadShedding: fftypes.NodeStatusLoadShedding{
+			Level: loadshed.LevelNone.String(),
+		},
+	}
+	if or.loadShed != nil {
+		status.LoadShedding.Level = or.loadShed.Level().String()
 	}
 
 	org, err := or.database.GetOrganizationByName(ctx, status.Org.Name)
@@ -79,3 +98,22 @@ func (or *orchestrator) GetStatus(ctx context.Context) (status *fftypes.NodeStat
 
 	return status, nil
 }
+
+// getConnectorStatus returns the health of the connectors of the default ledger, and any additional ledgers
+func (or *orchestrator) getConnectorStatus() []*fftypes.NodeStatusConnector {
+	connectors := []*fftypes.NodeStatusConnector{}
+	addConnector := func(name string, bi blockchain.Plugin) {
+		if health := bi.ConnectorHealth(); health != nil {
+			connectors = append(connectors, &fftypes.NodeStatusConnector{
+				Type:            "blockchain",
+				Name:            name,
+				ConnectorHealth: *health,
+			})
+		}
+	}
+	addConnector(or.blockchain.Name(), or.blockchain)
+	for _, name := range or.ledgerNames() {
+		addConnector(name, or.ledgers[name].bi)
+	}
+	return connectors
+}
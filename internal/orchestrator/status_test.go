@@ -17,12 +17,18 @@
 package orchestrator
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"fmt"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/loadshed"
+	"github.com/hyperledger/firefly/internal/nodekey"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/loadshedmocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -53,6 +59,11 @@ func TestGetStatusRegistered(t *testing.T) {
 	mim := or.identity.(*identitymanagermocks.Manager)
 	mim.On("GetLocalOrgKey", mock.Anything).Return("0x1111111", nil)
 
+	or.mbi.On("ConnectorHealth").Return(&fftypes.ConnectorHealth{Healthy: true})
+	mbi2 := &blockchainmocks.Plugin{}
+	mbi2.On("ConnectorHealth").Return(nil)
+	or.ledgers = map[string]*boundCallbacks{"ledger1": {bi: mbi2}}
+
 	status, err := or.GetStatus(or.ctx)
 	assert.NoError(t, err)
 
@@ -66,14 +77,45 @@ func TestGetStatusRegistered(t *testing.T) {
 	assert.Equal(t, "node1", status.Node.Name)
 	assert.True(t, status.Node.Registered)
 	assert.Equal(t, *nodeID, *status.Node.ID)
+	assert.False(t, status.Node.ReadOnly)
+
+	assert.Len(t, status.Connectors, 1)
+	assert.Equal(t, "mock-bi", status.Connectors[0].Name)
+	assert.True(t, status.Connectors[0].Healthy)
+
+	assert.Equal(t, "none", status.LoadShedding.Level)
 
 	assert.True(t, or.GetNodeUUID(or.ctx).Equals(nodeID))
 	assert.True(t, or.GetNodeUUID(or.ctx).Equals(nodeID)) // cached
 
 }
 
+func TestGetStatusLoadShedding(t *testing.T) {
+	or := newTestOrchestrator()
+
+	config.Reset()
+	config.Set(config.OrgName, "org1")
+
+	mdi := or.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", or.ctx, "org1").Return(nil, nil)
+	mim := or.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrgKey", mock.Anything).Return("", nil)
+	or.mbi.On("ConnectorHealth").Return(nil)
+
+	mls := &loadshedmocks.Monitor{}
+	mls.On("Level").Return(loadshed.LevelRejectSends)
+	or.loadShed = mls
+
+	status, err := or.GetStatus(or.ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "reject_sends", status.LoadShedding.Level)
+
+	mls.AssertExpectations(t)
+}
+
 func TestGetStatusUnregistered(t *testing.T) {
 	or := newTestOrchestrator()
+	or.readOnly = true
 
 	config.Reset()
 	config.Set(config.NamespacesDefault, "default")
@@ -85,6 +127,7 @@ func TestGetStatusUnregistered(t *testing.T) {
 	mim := or.identity.(*identitymanagermocks.Manager)
 	mim.On("GetLocalOrgKey", mock.Anything).Return("0x1111111", nil)
 
+	or.mbi.On("ConnectorHealth").Return(nil)
 	status, err := or.GetStatus(or.ctx)
 	assert.NoError(t, err)
 
@@ -95,6 +138,7 @@ func TestGetStatusUnregistered(t *testing.T) {
 
 	assert.Equal(t, "node1", status.Node.Name)
 	assert.False(t, status.Node.Registered)
+	assert.True(t, status.Node.ReadOnly)
 
 	assert.Nil(t, or.GetNodeUUID(or.ctx))
 
@@ -120,6 +164,7 @@ func TestGetStatusOrgOnlyRegistered(t *testing.T) {
 	mim := or.identity.(*identitymanagermocks.Manager)
 	mim.On("GetLocalOrgKey", mock.Anything).Return("0x1111111", nil)
 
+	or.mbi.On("ConnectorHealth").Return(nil)
 	status, err := or.GetStatus(or.ctx)
 	assert.NoError(t, err)
 
@@ -149,6 +194,7 @@ func TestGetStatuOrgError(t *testing.T) {
 	mim := or.identity.(*identitymanagermocks.Manager)
 	mim.On("GetLocalOrgKey", mock.Anything).Return("0x1111111", nil)
 
+	or.mbi.On("ConnectorHealth").Return(nil)
 	_, err := or.GetStatus(or.ctx)
 	assert.EqualError(t, err, "pop")
 }
@@ -173,9 +219,21 @@ func TestGetStatusNodeError(t *testing.T) {
 	mim := or.identity.(*identitymanagermocks.Manager)
 	mim.On("GetLocalOrgKey", mock.Anything).Return("0x1111111", nil)
 
+	or.mbi.On("ConnectorHealth").Return(nil)
 	_, err := or.GetStatus(or.ctx)
 	assert.EqualError(t, err, "pop")
 
 	assert.Nil(t, or.GetNodeUUID(or.ctx))
 
 }
+
+func TestSignBatchHash(t *testing.T) {
+	or := newTestOrchestrator()
+
+	hash := fftypes.NewRandB32()
+	assert.Equal(t, "", or.SignBatchHash(or.ctx, hash))
+
+	_, or.nodeKey, _ = ed25519.GenerateKey(rand.Reader)
+	sig := or.SignBatchHash(or.ctx, hash)
+	assert.True(t, nodekey.Verify(nodekey.PublicKey(or.nodeKey), sig, hash))
+}
@@ -18,6 +18,8 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/hyperledger/firefly/internal/events/system"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -51,6 +53,43 @@ func (or *orchestrator) createUpdateSubscription(ctx context.Context, ns string,
 	return subDef, or.events.CreateUpdateDurableSubscription(ctx, subDef, mustNew)
 }
 
+// subscriptionImmutableFields cannot be changed by a patch, as they identify the subscription or are generated
+var subscriptionImmutableFields = []string{"id", "namespace", "name", "ephemeral", "created", "updated"}
+
+func (or *orchestrator) PatchSubscription(ctx context.Context, ns, id string, patch fftypes.JSONObject) (*fftypes.Subscription, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := or.database.GetSubscriptionByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil || existing.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+
+	// Apply the patch to the JSON representation of the existing subscription
+	b, _ := json.Marshal(existing)
+	var before fftypes.JSONObject
+	_ = json.Unmarshal(b, &before)
+	after := before.MergePatch(patch)
+	for _, field := range subscriptionImmutableFields {
+		if fmt.Sprintf("%v", before[field]) != fmt.Sprintf("%v", after[field]) {
+			return nil, i18n.NewError(ctx, i18n.MsgSubscriptionFieldImmutable, field)
+		}
+	}
+	var subDef fftypes.Subscription
+	if err := json.Unmarshal([]byte(after.String()), &subDef); err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgInvalidSubscriptionPatch, err)
+	}
+	if subDef.Transport == system.SystemEventsTransport {
+		return nil, i18n.NewError(ctx, i18n.MsgSystemTransportInternal)
+	}
+
+	return &subDef, or.events.UpdateDurableSubscription(ctx, existing, &subDef)
+}
+
 func (or *orchestrator) DeleteSubscription(ctx context.Context, ns, id string) error {
 	u, err := fftypes.ParseUUID(ctx, id)
 	if err != nil {
@@ -78,3 +117,12 @@ func (or *orchestrator) GetSubscriptionByID(ctx context.Context, ns, id string)
 	}
 	return or.database.GetSubscriptionByID(ctx, u)
 }
+
+func (or *orchestrator) GetSubscriptionHistory(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.SubscriptionChange, *database.FilterResult, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter = or.scopeNS(ns, filter)
+	return or.database.GetSubscriptionChanges(ctx, filter.Condition(filter.Builder().Eq("subscription", u)))
+}
@@ -157,3 +157,110 @@ func TestGetSubscriptionDefsByIDBadID(t *testing.T) {
 	_, err := or.GetSubscriptionByID(context.Background(), "", "")
 	assert.Regexp(t, "FF10142", err)
 }
+
+func TestGetSubscriptionHistory(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetSubscriptionChanges", mock.Anything, mock.Anything).Return([]*fftypes.SubscriptionChange{}, nil, nil)
+	fb := database.SubscriptionChangeQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("type", fftypes.ChangeEventTypeUpdated))
+	_, _, err := or.GetSubscriptionHistory(context.Background(), "ns1", u.String(), f)
+	assert.NoError(t, err)
+}
+
+func TestGetSubscriptionHistoryBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	fb := database.SubscriptionChangeQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetSubscriptionHistory(context.Background(), "ns1", "", fb.And())
+	assert.Regexp(t, "FF10142", err)
+}
+
+func newTestPatchSubscription() *fftypes.Subscription {
+	return &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Name:      "sub1",
+			Namespace: "ns1",
+		},
+		Transport: "websockets",
+		Filter: fftypes.SubscriptionFilter{
+			Topics: "topic1",
+		},
+		Created: fftypes.Now(),
+	}
+}
+
+func TestPatchSubscription(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := newTestPatchSubscription()
+	or.mdi.On("GetSubscriptionByID", mock.Anything, sub.ID).Return(sub, nil)
+	or.mem.On("UpdateDurableSubscription", mock.Anything, sub, mock.MatchedBy(func(subDef *fftypes.Subscription) bool {
+		return *subDef.Options.ReadAhead == 50 &&
+			subDef.Filter.Topics == "topic1" &&
+			subDef.Filter.Tag == "" &&
+			subDef.ID.Equals(sub.ID)
+	})).Return(nil)
+	patched, err := or.PatchSubscription(or.ctx, "ns1", sub.ID.String(), fftypes.JSONObject{
+		"name": "sub1",
+		"filter": fftypes.JSONObject{
+			"tag": nil,
+		},
+		"options": fftypes.JSONObject{
+			"readAhead": float64(50),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(50), *patched.Options.ReadAhead)
+	or.mem.AssertExpectations(t)
+}
+
+func TestPatchSubscriptionBadUUID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.PatchSubscription(or.ctx, "ns1", "! a UUID", fftypes.JSONObject{})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestPatchSubscriptionLookupError(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetSubscriptionByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := or.PatchSubscription(or.ctx, "ns1", fftypes.NewUUID().String(), fftypes.JSONObject{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPatchSubscriptionNSMismatch(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := newTestPatchSubscription()
+	or.mdi.On("GetSubscriptionByID", mock.Anything, sub.ID).Return(sub, nil)
+	_, err := or.PatchSubscription(or.ctx, "ns2", sub.ID.String(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestPatchSubscriptionImmutableField(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := newTestPatchSubscription()
+	or.mdi.On("GetSubscriptionByID", mock.Anything, sub.ID).Return(sub, nil)
+	_, err := or.PatchSubscription(or.ctx, "ns1", sub.ID.String(), fftypes.JSONObject{
+		"name": "sub2",
+	})
+	assert.Regexp(t, "FF10477.*name", err)
+}
+
+func TestPatchSubscriptionBadPatch(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := newTestPatchSubscription()
+	or.mdi.On("GetSubscriptionByID", mock.Anything, sub.ID).Return(sub, nil)
+	_, err := or.PatchSubscription(or.ctx, "ns1", sub.ID.String(), fftypes.JSONObject{
+		"transport": float64(12345),
+	})
+	assert.Regexp(t, "FF10476", err)
+}
+
+func TestPatchSubscriptionSystemTransport(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := newTestPatchSubscription()
+	or.mdi.On("GetSubscriptionByID", mock.Anything, sub.ID).Return(sub, nil)
+	_, err := or.PatchSubscription(or.ctx, "ns1", sub.ID.String(), fftypes.JSONObject{
+		"transport": system.SystemEventsTransport,
+	})
+	assert.Regexp(t, "FF10266", err)
+}
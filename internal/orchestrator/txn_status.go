@@ -64,6 +64,7 @@ func (or *orchestrator) GetTransactionStatus(ctx context.Context, ns, id string)
 			ID:        op.ID,
 			Error:     op.Error,
 			Info:      op.Output,
+			Receipt:   op.Receipt,
 		})
 		updateStatus(result, op.Status)
 	}
@@ -118,7 +119,7 @@ func (or *orchestrator) GetTransactionStatus(ctx context.Context, ns, id string)
 		case len(pools) == 0:
 			result.Details = append(result.Details, pendingPlaceholder(fftypes.TransactionStatusTypeTokenPool))
 			updateStatus(result, fftypes.OpStatusPending)
-		case pools[0].State != fftypes.TokenPoolStateConfirmed:
+		case !pools[0].IsConfirmed():
 			result.Details = append(result.Details, &fftypes.TransactionStatusDetails{
 				Status:  fftypes.OpStatusPending,
 				Type:    fftypes.TransactionStatusTypeTokenPool,
@@ -178,7 +179,7 @@ func (or *orchestrator) GetTransactionStatus(ctx context.Context, ns, id string)
 			})
 		}
 
-	case fftypes.TransactionTypeContractInvoke:
+	case fftypes.TransactionTypeContractInvoke, fftypes.TransactionTypeRawTransaction:
 		// no blockchain events or other objects
 
 	default:
@@ -123,6 +123,11 @@ func TestGetTransactionStatusBatchPinFail(t *testing.T) {
 			ID:     fftypes.NewUUID(),
 			Type:   fftypes.OpTypeBlockchainBatchPin,
 			Error:  "complete failure",
+			Receipt: &fftypes.TransactionReceipt{
+				BlockNumber:  fftypes.NewFFBigInt(12345),
+				GasUsed:      fftypes.NewFFBigInt(21000),
+				RevertReason: "complete failure",
+			},
 		},
 	}
 	events := []*fftypes.BlockchainEvent{}
@@ -144,7 +149,8 @@ func TestGetTransactionStatusBatchPinFail(t *testing.T) {
 				"subtype": "blockchain_batch_pin",
 				"status": "Failed",
 				"id": "` + ops[0].ID.String() + `",
-				"error": "complete failure"
+				"error": "complete failure",
+				"receipt": {"blockNumber": "12345", "gasUsed": "21000", "revertReason": "complete failure"}
 			},
 			{
 				"type": "BlockchainEvent",
@@ -694,6 +700,48 @@ func TestGetTransactionStatusContractInvokeSuccess(t *testing.T) {
 	or.mdi.AssertExpectations(t)
 }
 
+func TestGetTransactionStatusRawTransactionPending(t *testing.T) {
+	or := newTestOrchestrator()
+
+	txID := fftypes.NewUUID()
+	tx := &fftypes.Transaction{
+		Type: fftypes.TransactionTypeRawTransaction,
+	}
+	ops := []*fftypes.Operation{
+		{
+			Status:  fftypes.OpStatusPending,
+			ID:      fftypes.NewUUID(),
+			Type:    fftypes.OpTypeBlockchainRawTransaction,
+			Updated: fftypes.UnixTime(0),
+		},
+	}
+	events := []*fftypes.BlockchainEvent{}
+
+	or.mdi.On("GetTransactionByID", mock.Anything, txID).Return(tx, nil)
+	or.mdi.On("GetOperations", mock.Anything, mock.Anything).Return(ops, nil, nil)
+	or.mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return(events, nil, nil)
+
+	status, err := or.GetTransactionStatus(context.Background(), "ns1", txID.String())
+	assert.NoError(t, err)
+
+	expectedStatus := compactJSON(`{
+		"status": "Pending",
+		"details": [
+			{
+				"type": "Operation",
+				"subtype": "blockchain_raw_transaction",
+				"status": "Pending",
+				"timestamp": "1970-01-01T00:00:00Z",
+				"id": "` + ops[0].ID.String() + `"
+			}
+		]
+	}`)
+	statusJSON, _ := json.Marshal(status)
+	assert.Equal(t, expectedStatus, string(statusJSON))
+
+	or.mdi.AssertExpectations(t)
+}
+
 func TestGetTransactionStatusTXError(t *testing.T) {
 	or := newTestOrchestrator()
 
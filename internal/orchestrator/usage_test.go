@@ -0,0 +1,48 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNamespaceUsage(t *testing.T) {
+	or := newTestOrchestrator()
+	usage := &fftypes.NamespaceUsage{Namespace: "ns1", MessagesSubmitted: 10}
+	or.mmi.On("IsMetricsEnabled").Return(true)
+	or.mmi.On("GetNamespaceUsage", "ns1").Return(usage)
+	res, err := or.GetNamespaceUsage(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, usage, res)
+}
+
+func TestGetNamespaceUsageBadNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetNamespaceUsage(context.Background(), "!wrong")
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestGetNamespaceUsageMetricsDisabled(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mmi.On("IsMetricsEnabled").Return(false)
+	_, err := or.GetNamespaceUsage(context.Background(), "ns1")
+	assert.Regexp(t, "FF10373", err)
+}
@@ -0,0 +1,157 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/events/eifactory"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/tokens"
+)
+
+// ValidateConfig initializes each plugin from the config, without applying database migrations or starting
+// the node, and probes the connectivity of the runtimes that can be checked without starting the plugin.
+// Every plugin is checked, rather than stopping at the first failure, so all problems are reported at once.
+// Config records stored in the database are not merged, as the database might not yet be migrated.
+func (or *orchestrator) ValidateConfig(ctx context.Context) *fftypes.ConfigValidationReport {
+	config.Set(config.DatabaseMigrationsDryRun, true)
+	report := &fftypes.ConfigValidationReport{
+		Valid:      true,
+		ConfigFile: config.ConfigFileUsed(),
+	}
+
+	dbErr := or.initDatabase(ctx)
+	dbPlugin := config.GetString(config.DatabaseType)
+	or.addPluginValidation(ctx, report, &pluginHealthCheck{
+		pluginType: "database",
+		name:       dbPlugin,
+		plugin:     dbPlugin,
+		check: func(ctx context.Context) (fftypes.JSONObject, error) {
+			if err := or.database.Ping(ctx); err != nil {
+				return nil, err
+			}
+			pending, err := or.database.PendingMigrations(ctx)
+			return fftypes.JSONObject{"pendingMigrations": pending}, err
+		},
+	}, dbErr)
+
+	iiPlugin := config.GetString(config.IdentityType)
+	or.addPluginValidation(ctx, report, &pluginHealthCheck{pluginType: "identity", name: iiPlugin, plugin: iiPlugin}, or.initIdentity(ctx))
+
+	biPlugin := config.GetString(config.BlockchainType)
+	or.addPluginValidation(ctx, report, &pluginHealthCheck{pluginType: "blockchain", name: biPlugin, plugin: biPlugin}, or.initBlockchain(ctx))
+	if err := or.initLedgers(ctx); err != nil {
+		or.addPluginValidation(ctx, report, &pluginHealthCheck{pluginType: "blockchain", name: "ledgers"}, err)
+	} else {
+		for _, name := range or.ledgerNames() {
+			or.addPluginValidation(ctx, report, &pluginHealthCheck{pluginType: "blockchain", name: name, plugin: or.ledgers[name].bi.Name()}, nil)
+		}
+	}
+
+	psPlugin := config.GetString(config.PublicStorageType)
+	or.addPluginValidation(ctx, report, &pluginHealthCheck{
+		pluginType: "publicstorage",
+		name:       psPlugin,
+		plugin:     psPlugin,
+		check:      func(ctx context.Context) (fftypes.JSONObject, error) { return nil, or.publicstorage.Ping(ctx) },
+	}, or.initPublicStorage(ctx))
+	if err := or.initPublicStorages(ctx); err != nil {
+		or.addPluginValidation(ctx, report, &pluginHealthCheck{pluginType: "publicstorage", name: "publicstorages"}, err)
+	} else {
+		for _, name := range or.publicStorageNames() {
+			pi := or.publicstorages[name]
+			or.addPluginValidation(ctx, report, &pluginHealthCheck{
+				pluginType: "publicstorage",
+				name:       name,
+				plugin:     pi.Name(),
+				check:      func(ctx context.Context) (fftypes.JSONObject, error) { return nil, pi.Ping(ctx) },
+			}, nil)
+		}
+	}
+
+	// The data exchange is initialized without the list of nodes, as that is read from the database
+	dxPlugin, dxErr := or.getDataExchangePlugin(ctx)
+	if dxErr == nil {
+		dxErr = or.dataexchange.Init(ctx, dataexchangeConfig.SubPrefix(dxPlugin), nil, &or.bc)
+	}
+	or.addPluginValidation(ctx, report, &pluginHealthCheck{
+		pluginType: "dataexchange",
+		name:       dxPlugin,
+		plugin:     dxPlugin,
+		check: func(ctx context.Context) (fftypes.JSONObject, error) {
+			peer, err := or.dataexchange.GetEndpointInfo(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return fftypes.JSONObject{"peer": peer.Peer}, nil
+		},
+	}, dxErr)
+
+	or.addPluginValidation(ctx, report, &pluginHealthCheck{pluginType: "batchvalidator", name: "batchValidators"}, or.initBatchValidators(ctx))
+
+	for i := 0; i < tokensConfig.ArraySize(); i++ {
+		prefix := tokensConfig.ArrayEntry(i)
+		pluginName := prefix.GetString(tokens.TokensConfigPlugin)
+		if pluginName == "" {
+			pluginName = prefix.GetString(tokens.TokensConfigConnector)
+		}
+		_, _, err := or.initTokensPlugin(ctx, prefix)
+		or.addPluginValidation(ctx, report, &pluginHealthCheck{
+			pluginType: "tokens",
+			name:       prefix.GetString(tokens.TokensConfigName),
+			plugin:     pluginName,
+		}, err)
+	}
+
+	for _, transport := range config.GetStringSlice(config.EventTransportsEnabled) {
+		_, err := eifactory.GetPlugin(ctx, transport)
+		or.addPluginValidation(ctx, report, &pluginHealthCheck{pluginType: "events", name: transport, plugin: transport}, err)
+	}
+
+	// Every plugin has now registered its config keys, so any remaining keys in the file are not used
+	eifactory.InitPrefix(config.NewPluginConfig("events"))
+	report.UnknownKeys = config.UnknownKeys()
+	return report
+}
+
+// addPluginValidation records the result of initializing a plugin, then runs the connectivity check
+// of the plugin if it has one, and the plugin was initialized
+func (or *orchestrator) addPluginValidation(ctx context.Context, report *fftypes.ConfigValidationReport, hc *pluginHealthCheck, initErr error) {
+	pv := &fftypes.PluginValidation{
+		Type:        hc.pluginType,
+		Name:        hc.name,
+		Plugin:      hc.plugin,
+		Initialized: initErr == nil,
+	}
+	if initErr != nil {
+		pv.Error = initErr.Error()
+	} else if hc.check != nil {
+		details, err := hc.check(ctx)
+		reachable := err == nil
+		pv.Reachable = &reachable
+		pv.Details = details
+		if err != nil {
+			pv.Error = err.Error()
+		}
+	}
+	if pv.Error != "" {
+		report.Valid = false
+	}
+	report.Plugins = append(report.Plugins, pv)
+}
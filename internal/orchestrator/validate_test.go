@@ -0,0 +1,119 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+	"github.com/hyperledger/firefly/pkg/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidateConfig(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.DatabaseType, "postgres")
+	config.Set(config.DataexchangeType, "ffdx")
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("Ping", mock.Anything).Return(nil)
+	or.mdi.On("PendingMigrations", mock.Anything).Return([]string{"000001_create_messages_table"}, nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mbi2 := &blockchainmocks.Plugin{}
+	mbi2.On("Name").Return("ethereum")
+	or.ledgers = map[string]*boundCallbacks{"ledger1": {bi: mbi2}}
+	or.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mps.On("Ping", mock.Anything).Return(nil)
+	mps2 := &publicstoragemocks.Plugin{}
+	mps2.On("Name").Return("ipfs")
+	mps2.On("Ping", mock.Anything).Return(nil)
+	or.publicstorages = map[string]publicstorage.Plugin{"ipfs2": mps2}
+	or.mdx.On("Init", mock.Anything, mock.Anything, []fftypes.DXInfo(nil), mock.Anything).Return(nil)
+	or.mdx.On("GetEndpointInfo", mock.Anything).Return(fftypes.DXInfo{Peer: "peer1"}, nil)
+
+	report := or.ValidateConfig(or.ctx)
+	assert.True(t, report.Valid)
+	assert.True(t, config.GetBool(config.DatabaseMigrationsDryRun))
+	assert.Empty(t, report.UnknownKeys)
+	assert.Equal(t, "database", report.Plugins[0].Type)
+	assert.Equal(t, "postgres", report.Plugins[0].Plugin)
+	assert.True(t, *report.Plugins[0].Reachable)
+	assert.Equal(t, []string{"000001_create_messages_table"}, report.Plugins[0].Details["pendingMigrations"])
+	assert.Equal(t, "identity", report.Plugins[1].Type)
+	assert.Nil(t, report.Plugins[1].Reachable)
+	assert.Equal(t, "ledger1", report.Plugins[3].Name)
+	assert.Equal(t, "ethereum", report.Plugins[3].Plugin)
+	assert.Equal(t, "ipfs2", report.Plugins[5].Name)
+	assert.True(t, *report.Plugins[5].Reachable)
+	assert.Equal(t, "dataexchange", report.Plugins[6].Type)
+	assert.Equal(t, "peer1", report.Plugins[6].Details["peer"])
+	assert.Equal(t, "batchvalidator", report.Plugins[7].Type)
+	for _, pv := range report.Plugins {
+		assert.True(t, pv.Initialized)
+		assert.Empty(t, pv.Error)
+	}
+}
+
+func TestValidateConfigErrors(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.BlockchainType, "wrong")
+	config.Set(config.EventTransportsEnabled, []string{"websockets", "wrong"})
+	config.Set(config.EventAggregatorBatchValidators, []string{"wrong"})
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "ns1", "ledger": "missing", "publicstorage": "missing"},
+	})
+	tokensConfig.AddKnownKey(tokens.TokensConfigName)
+	tokensConfig.AddKnownKey(tokens.TokensConfigConnector)
+	tokensConfig.AddKnownKey(tokens.TokensConfigPlugin)
+	config.Set("tokens", []fftypes.JSONObject{{}})
+	or.blockchain = nil
+	or.ledgers = map[string]*boundCallbacks{}
+	or.publicstorages = map[string]publicstorage.Plugin{}
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("Ping", mock.Anything).Return(fmt.Errorf("pop"))
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	or.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mps.On("Ping", mock.Anything).Return(nil)
+	or.mdx.On("Init", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdx.On("GetEndpointInfo", mock.Anything).Return(fftypes.DXInfo{}, fmt.Errorf("pop"))
+
+	report := or.ValidateConfig(or.ctx)
+	assert.False(t, report.Valid)
+	failed := make(map[string]string)
+	for _, pv := range report.Plugins {
+		if pv.Error != "" {
+			failed[pv.Type+"/"+pv.Name] = pv.Error
+		}
+	}
+	assert.Equal(t, "pop", failed["database/"+config.GetString(config.DatabaseType)])
+	assert.False(t, *report.Plugins[0].Reachable)
+	assert.Equal(t, "pop", failed["identity/"+config.GetString(config.IdentityType)])
+	assert.Regexp(t, "FF10110.*wrong", failed["blockchain/wrong"])
+	assert.Regexp(t, "FF10404", failed["blockchain/ledgers"])
+	assert.Regexp(t, "FF10463", failed["publicstorage/publicstorages"])
+	assert.Equal(t, "pop", failed["dataexchange/"+config.GetString(config.DataexchangeType)])
+	assert.Regexp(t, "FF10357.*wrong", failed["batchvalidator/batchValidators"])
+	assert.Regexp(t, "FF10273", failed["tokens/"])
+	assert.Regexp(t, "FF10172.*wrong", failed["events/wrong"])
+	assert.NotContains(t, failed, "events/websockets")
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var opStatusValues = []fftypes.OpStatus{
+	fftypes.OpStatusPending,
+	fftypes.OpStatusSucceeded,
+	fftypes.OpStatusFailed,
+}
+
+func (or *orchestrator) resolveMessageState(ctx context.Context, state fftypes.MessageState) (fftypes.MessageState, error) {
+	for _, v := range fftypes.FFEnumValues("messagestate") {
+		if state.Equals(fftypes.MessageState(v.(string))) {
+			return state.Lower(), nil
+		}
+	}
+	return "", i18n.NewError(ctx, i18n.MsgInvalidWaitForState, state, fftypes.FFEnumValues("messagestate"))
+}
+
+func (or *orchestrator) resolveOpStatus(ctx context.Context, status fftypes.OpStatus) (fftypes.OpStatus, error) {
+	for _, v := range opStatusValues {
+		if strings.EqualFold(string(status), string(v)) {
+			return v, nil
+		}
+	}
+	return "", i18n.NewError(ctx, i18n.MsgInvalidWaitForState, status, opStatusValues)
+}
+
+// WaitForMessageState holds the caller until the message reaches the supplied state, or the timeout expires.
+// In the case of a timeout the current copy of the message is returned, along with a warning.
+func (or *orchestrator) WaitForMessageState(ctx context.Context, ns, id string, state fftypes.MessageState, timeout time.Duration) (*fftypes.Message, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if state, err = or.resolveMessageState(ctx, state); err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	msg, err := or.syncasync.WaitForMessageState(waitCtx, ns, u, state)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if msg.State != state {
+		apiwarnings.Add(ctx, i18n.MsgWarnStateNotReached, state, msg.State)
+	}
+	return msg, nil
+}
+
+// WaitForOperationState holds the caller until the operation reaches the supplied status, or the timeout expires.
+// In the case of a timeout the current copy of the operation is returned, along with a warning.
+func (or *orchestrator) WaitForOperationState(ctx context.Context, ns, id string, status fftypes.OpStatus, timeout time.Duration) (*fftypes.Operation, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if status, err = or.resolveOpStatus(ctx, status); err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	op, err := or.syncasync.WaitForOperationState(waitCtx, ns, u, status)
+	if err != nil {
+		return nil, err
+	}
+	if op == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if op.Status != status {
+		apiwarnings.Add(ctx, i18n.MsgWarnStateNotReached, status, op.Status)
+	}
+	return op, nil
+}
@@ -0,0 +1,155 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWaitForMessageStateReached(t *testing.T) {
+	or := newTestOrchestrator()
+	msgID := fftypes.NewUUID()
+	ctx := apiwarnings.WithWarnings(context.Background())
+	or.msa.On("WaitForMessageState", mock.Anything, "ns1", msgID, fftypes.MessageStateConfirmed).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateConfirmed,
+	}, nil)
+	msg, err := or.WaitForMessageState(ctx, "ns1", msgID.String(), "Confirmed", 1*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateConfirmed, msg.State)
+	assert.Empty(t, apiwarnings.Get(ctx))
+}
+
+func TestWaitForMessageStateNotReached(t *testing.T) {
+	or := newTestOrchestrator()
+	msgID := fftypes.NewUUID()
+	ctx := apiwarnings.WithWarnings(context.Background())
+	or.msa.On("WaitForMessageState", mock.Anything, "ns1", msgID, fftypes.MessageStateConfirmed).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateSent,
+	}, nil)
+	msg, err := or.WaitForMessageState(ctx, "ns1", msgID.String(), fftypes.MessageStateConfirmed, 1*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateSent, msg.State)
+	assert.Regexp(t, "FF10376", apiwarnings.Get(ctx)[0])
+}
+
+func TestWaitForMessageStateNonFinalReached(t *testing.T) {
+	or := newTestOrchestrator()
+	msgID := fftypes.NewUUID()
+	ctx := apiwarnings.WithWarnings(context.Background())
+	or.msa.On("WaitForMessageState", mock.Anything, "ns1", msgID, fftypes.MessageStateSent).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateSent,
+	}, nil)
+	msg, err := or.WaitForMessageState(ctx, "ns1", msgID.String(), "sent", 1*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateSent, msg.State)
+	assert.Empty(t, apiwarnings.Get(ctx))
+}
+
+func TestWaitForMessageStateNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	msgID := fftypes.NewUUID()
+	or.msa.On("WaitForMessageState", mock.Anything, "ns1", msgID, fftypes.MessageStateConfirmed).Return(nil, nil)
+	_, err := or.WaitForMessageState(context.Background(), "ns1", msgID.String(), fftypes.MessageStateConfirmed, 1*time.Second)
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestWaitForMessageStateFail(t *testing.T) {
+	or := newTestOrchestrator()
+	msgID := fftypes.NewUUID()
+	or.msa.On("WaitForMessageState", mock.Anything, "ns1", msgID, fftypes.MessageStateConfirmed).Return(nil, fmt.Errorf("pop"))
+	_, err := or.WaitForMessageState(context.Background(), "ns1", msgID.String(), fftypes.MessageStateConfirmed, 1*time.Second)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestWaitForMessageStateBadState(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.WaitForMessageState(context.Background(), "ns1", fftypes.NewUUID().String(), "wrong", 1*time.Second)
+	assert.Regexp(t, "FF10374", err)
+}
+
+func TestWaitForMessageStateBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.WaitForMessageState(context.Background(), "ns1", "!uuid", fftypes.MessageStateConfirmed, 1*time.Second)
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestWaitForOperationStateReached(t *testing.T) {
+	or := newTestOrchestrator()
+	opID := fftypes.NewUUID()
+	ctx := apiwarnings.WithWarnings(context.Background())
+	or.msa.On("WaitForOperationState", mock.Anything, "ns1", opID, fftypes.OpStatusSucceeded).Return(&fftypes.Operation{
+		ID:     opID,
+		Status: fftypes.OpStatusSucceeded,
+	}, nil)
+	op, err := or.WaitForOperationState(ctx, "ns1", opID.String(), "succeeded", 1*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusSucceeded, op.Status)
+	assert.Empty(t, apiwarnings.Get(ctx))
+}
+
+func TestWaitForOperationStateNotReached(t *testing.T) {
+	or := newTestOrchestrator()
+	opID := fftypes.NewUUID()
+	ctx := apiwarnings.WithWarnings(context.Background())
+	or.msa.On("WaitForOperationState", mock.Anything, "ns1", opID, fftypes.OpStatusSucceeded).Return(&fftypes.Operation{
+		ID:     opID,
+		Status: fftypes.OpStatusFailed,
+	}, nil)
+	op, err := or.WaitForOperationState(ctx, "ns1", opID.String(), fftypes.OpStatusSucceeded, 1*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusFailed, op.Status)
+	assert.Regexp(t, "FF10376", apiwarnings.Get(ctx)[0])
+}
+
+func TestWaitForOperationStateNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	opID := fftypes.NewUUID()
+	or.msa.On("WaitForOperationState", mock.Anything, "ns1", opID, fftypes.OpStatusSucceeded).Return(nil, nil)
+	_, err := or.WaitForOperationState(context.Background(), "ns1", opID.String(), fftypes.OpStatusSucceeded, 1*time.Second)
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestWaitForOperationStateFail(t *testing.T) {
+	or := newTestOrchestrator()
+	opID := fftypes.NewUUID()
+	or.msa.On("WaitForOperationState", mock.Anything, "ns1", opID, fftypes.OpStatusSucceeded).Return(nil, fmt.Errorf("pop"))
+	_, err := or.WaitForOperationState(context.Background(), "ns1", opID.String(), fftypes.OpStatusSucceeded, 1*time.Second)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestWaitForOperationStateBadState(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.WaitForOperationState(context.Background(), "ns1", fftypes.NewUUID().String(), "wrong", 1*time.Second)
+	assert.Regexp(t, "FF10374", err)
+}
+
+func TestWaitForOperationStateBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.WaitForOperationState(context.Background(), "ns1", "!uuid", fftypes.OpStatusSucceeded, 1*time.Second)
+	assert.Regexp(t, "FF10142", err)
+}
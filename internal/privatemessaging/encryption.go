@@ -0,0 +1,142 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const encryptionAlgorithmAES256GCM = "aes-256-gcm"
+
+// groupKey is the symmetric key used to encrypt transmissions to a group, along with the copies of it
+// wrapped to the encryption key of each remote member node
+type groupKey struct {
+	recipients string
+	key        *fftypes.Bytes32
+	wrapped    map[string]string
+}
+
+func newGCM(key []byte) cipher.AEAD {
+	// Neither call can fail for a 32 byte key, and the standard nonce size
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
+
+// getGroupKey returns the key for the group, generating a new one whenever the set of remote nodes changes.
+// This means nodes removed from the group cannot decrypt any transmission sent after their removal.
+func (pm *privateMessaging) getGroupKey(ctx context.Context, groupHash *fftypes.Bytes32, nodes []*fftypes.Node) (*groupKey, error) {
+	keys := make([]string, len(nodes))
+	for i, node := range nodes {
+		if node.EncryptionKey == "" {
+			return nil, i18n.NewError(ctx, i18n.MsgNodeNoEncryptionKey, node.Name)
+		}
+		keys[i] = node.EncryptionKey
+	}
+	sort.Strings(keys)
+	recipients := strings.Join(keys, ",")
+
+	pm.groupKeysMux.Lock()
+	defer pm.groupKeysMux.Unlock()
+	if gk, ok := pm.groupKeys[*groupHash]; ok && gk.recipients == recipients {
+		return gk, nil
+	}
+
+	log.L(ctx).Infof("Generating new encryption key for group=%s recipients=%d", groupHash, len(keys))
+	gk := &groupKey{
+		recipients: recipients,
+		key:        fftypes.NewRandB32(),
+		wrapped:    make(map[string]string, len(keys)),
+	}
+	for _, publicKey := range keys {
+		wrapped, err := pm.keyWrapper.WrapKey(ctx, publicKey, gk.key[:])
+		if err != nil {
+			return nil, err
+		}
+		gk.wrapped[publicKey] = wrapped
+	}
+	pm.groupKeys[*groupHash] = gk
+	return gk, nil
+}
+
+// checkNoPrivateBlobs rejects data with a blob that would be transferred directly over data exchange. Blobs
+// are sent outside of the batch, so would not be covered by the end-to-end encryption of the transport.
+func (pm *privateMessaging) checkNoPrivateBlobs(ctx context.Context, data []*fftypes.Data) error {
+	for _, d := range data {
+		if d.Blob != nil && d.Blob.Hash != nil && d.Blob.Public == "" {
+			return i18n.NewError(ctx, i18n.MsgEncryptedBlobUnsupported)
+		}
+	}
+	return nil
+}
+
+// encryptTransport seals a serialized transport wrapper with the group key, so that only the remote member
+// nodes can read it. Data exchange, and any storage it uses, only ever sees the encrypted envelope.
+func (pm *privateMessaging) encryptTransport(ctx context.Context, groupHash *fftypes.Bytes32, payload []byte, nodes []*fftypes.Node) ([]byte, error) {
+	gk, err := pm.getGroupKey(ctx, groupHash, nodes)
+	if err != nil {
+		return nil, err
+	}
+	aead := newGCM(gk.key[:])
+	nonce := fftypes.NewRandB32()[0:aead.NonceSize()]
+	return json.Marshal(&fftypes.TransportWrapper{
+		Encrypted: &fftypes.EncryptedTransport{
+			Algorithm: encryptionAlgorithmAES256GCM,
+			Keys:      gk.wrapped,
+			Nonce:     nonce,
+			Payload:   aead.Seal(nil, nonce, payload, nil),
+		},
+	})
+}
+
+func (pm *privateMessaging) DecryptTransport(ctx context.Context, et *fftypes.EncryptedTransport) ([]byte, error) {
+	if pm.keyWrapper == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgEncryptionNotConfigured)
+	}
+	if et.Algorithm != encryptionAlgorithmAES256GCM {
+		return nil, i18n.NewError(ctx, i18n.MsgDecryptFailed, "unsupported algorithm")
+	}
+	wrapped, ok := et.Keys[pm.keyWrapper.PublicKey()]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgDecryptFailed, "no key for this node")
+	}
+	key, err := pm.keyWrapper.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, i18n.NewError(ctx, i18n.MsgDecryptFailed, "invalid key")
+	}
+	aead := newGCM(key)
+	if len(et.Nonce) != aead.NonceSize() {
+		return nil, i18n.NewError(ctx, i18n.MsgDecryptFailed, "invalid nonce")
+	}
+	payload, err := aead.Open(nil, et.Nonce, et.Payload, nil)
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgDecryptFailed, err)
+	}
+	return payload, nil
+}
@@ -32,6 +32,7 @@ import (
 type GroupManager interface {
 	GetGroupByID(ctx context.Context, id string) (*fftypes.Group, error)
 	GetGroupsNS(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error)
+	GetGroupMembershipChanges(ctx context.Context, ns, groupHash string, filter database.AndFilter) ([]*fftypes.GroupMembershipChange, *database.FilterResult, error)
 	ResolveInitGroup(ctx context.Context, msg *fftypes.Message) (*fftypes.Group, error)
 	EnsureLocalGroup(ctx context.Context, group *fftypes.Group) (ok bool, err error)
 }
@@ -157,6 +158,15 @@ func (gm *groupManager) GetGroups(ctx context.Context, filter database.AndFilter
 	return gm.database.GetGroups(ctx, filter)
 }
 
+func (gm *groupManager) GetGroupMembershipChanges(ctx context.Context, ns, groupHash string, filter database.AndFilter) ([]*fftypes.GroupMembershipChange, *database.FilterResult, error) {
+	h, err := fftypes.ParseBytes32(ctx, groupHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	fb := filter.Builder()
+	return gm.database.GetGroupMembershipChanges(ctx, filter.Condition(fb.And(fb.Eq("namespace", ns), fb.Eq("group", h))))
+}
+
 func (gm *groupManager) getGroupNodes(ctx context.Context, groupHash *fftypes.Bytes32) (*fftypes.Group, []*fftypes.Node, error) {
 
 	if cached := gm.groupCache.Get(groupHash.String()); cached != nil {
@@ -204,6 +214,10 @@ func (gm *groupManager) getGroupNodes(ctx context.Context, groupHash *fftypes.By
 //
 // Errors are only returned for database issues. For validation issues, a nil group is returned without an error.
 func (gm *groupManager) ResolveInitGroup(ctx context.Context, msg *fftypes.Message) (*fftypes.Group, error) {
+	if msg.Header.Tag == string(fftypes.SystemTagChangeGroupMembers) {
+		// A membership change is always the first message in the context of the new version of the group
+		return gm.applyMembershipChange(ctx, msg)
+	}
 	if msg.Header.Tag == string(fftypes.SystemTagDefineGroup) {
 		// Store the new group
 		data, foundAll, err := gm.data.GetMessageData(ctx, msg, true)
@@ -243,5 +257,8 @@ func (gm *groupManager) ResolveInitGroup(ctx context.Context, msg *fftypes.Messa
 		log.L(ctx).Warnf("Group %s not found for first message in context. type=%s namespace=%s", msg.Header.Group, msg.Header.Type, msg.Header.Namespace)
 		return nil, nil
 	}
+	if msg.Header.GroupVersion != group.Version {
+		return gm.resolveGroupVersion(ctx, group, msg.Header.GroupVersion)
+	}
 	return group, nil
 }
@@ -0,0 +1,343 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// CreateGroup resolves the supplied members into a group, and sends the definition of the group to all members
+// if it does not already exist. The group hash is calculated from the sorted member list, name and ledger, so
+// requesting an existing group returns that group rather than creating a duplicate.
+func (pm *privateMessaging) CreateGroup(ctx context.Context, ns string, in *fftypes.InputGroup) (group *fftypes.Group, err error) {
+	if len(in.Members) == 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgGroupMustHaveMembers)
+	}
+
+	signer := &fftypes.Identity{}
+	if err := pm.identity.ResolveInputIdentity(ctx, signer); err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+	}
+
+	err = pm.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		var isNew bool
+		group, isNew, err = pm.findOrGenerateGroup(ctx, ns, in)
+		if err != nil || !isNew {
+			return err
+		}
+		return pm.groupManager.groupInit(ctx, signer, group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// ChangeGroupMembers sends a membership change for an existing group, signed by the local org (which must be a
+// current member). The change is applied by all parties, including this node, when it is confirmed as the
+// first message on the next version of the group.
+func (pm *privateMessaging) ChangeGroupMembers(ctx context.Context, ns, groupHash string, input *fftypes.GroupMembershipChangeInput) (*fftypes.GroupMembershipChange, error) {
+	group, err := pm.GetGroupByID(ctx, groupHash)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil || group.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.MsgGroupNotFound, groupHash)
+	}
+
+	signer := &fftypes.Identity{}
+	if err := pm.identity.ResolveInputIdentity(ctx, signer); err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+	}
+	if !group.Members.IsMember(signer.Author) {
+		return nil, i18n.NewError(ctx, i18n.MsgGroupChangeNotMember, signer.Author, group.Hash)
+	}
+
+	change := &fftypes.GroupMembershipChange{
+		ID:            fftypes.NewUUID(),
+		Namespace:     ns,
+		Group:         group.Hash,
+		EffectiveFrom: group.Version + 1,
+		Author:        signer.Author,
+		Salt:          fftypes.NewRandB32(),
+		Message:       fftypes.NewUUID(),
+	}
+	if change.Add, err = pm.resolveAddedMembers(ctx, input.Add); err != nil {
+		return nil, err
+	}
+	if change.Remove, err = pm.resolveRemovedMembers(ctx, group, input.Remove); err != nil {
+		return nil, err
+	}
+	if change.Members, err = change.Apply(ctx, group.Members); err != nil {
+		return nil, err
+	}
+	if err = change.Validate(ctx, group); err != nil {
+		return nil, err
+	}
+
+	err = pm.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		return pm.sendMembershipChange(ctx, signer, change)
+	})
+	return change, err
+}
+
+func (pm *privateMessaging) resolveAddedMembers(ctx context.Context, inputs []fftypes.MemberInput) (fftypes.Members, error) {
+	members := make(fftypes.Members, len(inputs))
+	for i, rInput := range inputs {
+		org, err := pm.resolveOrg(ctx, rInput.Identity)
+		if err != nil {
+			return nil, err
+		}
+		node, err := pm.resolveNode(ctx, org, rInput.Node)
+		if err != nil {
+			return nil, err
+		}
+		members[i] = &fftypes.Member{
+			Identity: org.GetDID(),
+			Node:     node.ID,
+		}
+	}
+	return members, nil
+}
+
+func (pm *privateMessaging) resolveRemovedMembers(ctx context.Context, group *fftypes.Group, inputs []fftypes.MemberInput) (fftypes.Members, error) {
+	members := fftypes.Members{}
+	for _, rInput := range inputs {
+		org, err := pm.resolveOrg(ctx, rInput.Identity)
+		if err != nil {
+			return nil, err
+		}
+		// If no node is specified, the org is removed from all nodes
+		var nodeID *fftypes.UUID
+		if rInput.Node != "" {
+			node, err := pm.resolveNode(ctx, org, rInput.Node)
+			if err != nil {
+				return nil, err
+			}
+			nodeID = node.ID
+		}
+		found := false
+		for _, m := range group.Members {
+			if m.Identity == org.GetDID() && (nodeID == nil || m.Node.Equals(nodeID)) {
+				members = append(members, m)
+				found = true
+			}
+		}
+		if !found {
+			return nil, i18n.NewError(ctx, i18n.MsgGroupMemberNotFound, org.GetDID(), rInput.Node)
+		}
+	}
+	return members, nil
+}
+
+func (pm *privateMessaging) sendMembershipChange(ctx context.Context, signer *fftypes.Identity, change *fftypes.GroupMembershipChange) (err error) {
+
+	// Serialize it into a data object, as a piece of data we can write to a message
+	data := &fftypes.Data{
+		Validator: fftypes.ValidatorTypeSystemDefinition,
+		ID:        fftypes.NewUUID(),
+		Namespace: change.Namespace,
+		Created:   fftypes.Now(),
+	}
+	b, _ := json.Marshal(change.Definition())
+	data.Value = fftypes.JSONAnyPtrBytes(b)
+	err = data.Seal(ctx, nil)
+	if err == nil {
+		err = pm.database.UpsertData(ctx, data, database.UpsertOptimizationNew)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The change is sent as the first message on the new version of the group, so it is the first
+	// message that all members of the resulting group (including any new members) can process
+	msg := &fftypes.Message{
+		State: fftypes.MessageStateReady,
+		Header: fftypes.MessageHeader{
+			ID:           change.Message,
+			Group:        change.Group,
+			GroupVersion: change.EffectiveFrom,
+			Namespace:    change.Namespace,
+			Type:         fftypes.MessageTypeGroupInit,
+			Identity:     *signer,
+			Tag:          string(fftypes.SystemTagChangeGroupMembers),
+			Topics:       fftypes.FFStringArray{change.Group.String()},
+			TxType:       fftypes.TransactionTypeBatchPin,
+		},
+		Data: fftypes.DataRefs{
+			{ID: data.ID, Hash: data.Hash},
+		},
+	}
+
+	// Seal the message
+	err = msg.Seal(ctx)
+	if err == nil {
+		// Store the message - this asynchronously triggers the next step in process
+		err = pm.database.UpsertMessage(ctx, msg, database.UpsertOptimizationNew)
+	}
+	if err == nil {
+		log.L(ctx).Infof("Sent membership change for group %s effective from version %d", change.Group, change.EffectiveFrom)
+	}
+	return err
+}
+
+// getMembershipChangeNodes returns the nodes of any members being added to the group by a membership
+// change in the batch, in addition to the nodes of the current members, as they must receive the batch in order to join.
+//
+// Nodes that only host members being removed are excluded, as the change contains the salt for the pins of the new
+// version of the group. The batch processor always sends a membership change in a batch of its own, so the removed
+// members do not miss any other messages as a result.
+func (pm *privateMessaging) getMembershipChangeNodes(ctx context.Context, batch *fftypes.Batch, nodes []*fftypes.Node) (allNodes []*fftypes.Node, changed bool, err error) {
+	allNodes = append([]*fftypes.Node{}, nodes...)
+	knownIDs := make(map[fftypes.UUID]bool)
+	for _, node := range nodes {
+		knownIDs[*node.ID] = true
+	}
+	departingNodes := make(map[fftypes.UUID]bool)
+	for _, msg := range batch.Payload.Messages {
+		if msg.Header.Tag != string(fftypes.SystemTagChangeGroupMembers) || len(msg.Data) == 0 {
+			continue
+		}
+		changed = true
+		for _, d := range batch.Payload.Data {
+			if !d.ID.Equals(msg.Data[0].ID) {
+				continue
+			}
+			var def fftypes.GroupMembershipChangeDefinition
+			if err := json.Unmarshal(d.Value.Bytes(), &def); err != nil {
+				return nil, false, i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
+			}
+			change := def.Change()
+			for _, m := range change.Add {
+				if knownIDs[*m.Node] {
+					continue
+				}
+				node, err := pm.database.GetNodeByID(ctx, m.Node)
+				if err != nil {
+					return nil, false, err
+				}
+				if node == nil {
+					return nil, false, i18n.NewError(ctx, i18n.MsgNodeNotFound, m.Node)
+				}
+				knownIDs[*node.ID] = true
+				allNodes = append(allNodes, node)
+			}
+			for _, m := range change.Remove {
+				if !change.Members.HasNode(m.Node) {
+					departingNodes[*m.Node] = true
+				}
+			}
+		}
+	}
+	if len(departingNodes) > 0 {
+		remainingNodes := make([]*fftypes.Node, 0, len(allNodes))
+		for _, node := range allNodes {
+			if !departingNodes[*node.ID] {
+				remainingNodes = append(remainingNodes, node)
+			}
+		}
+		allNodes = remainingNodes
+	}
+	return allNodes, changed, nil
+}
+
+// applyMembershipChange is called when a membership change is the first message on the context of the new version
+// of a group. The change is validated against the current version of the group, and applied.
+//
+// Errors are only returned for database issues. For validation issues, a nil group is returned without an error.
+func (gm *groupManager) applyMembershipChange(ctx context.Context, msg *fftypes.Message) (*fftypes.Group, error) {
+	l := log.L(ctx)
+	data, foundAll, err := gm.data.GetMessageData(ctx, msg, true)
+	if err != nil || !foundAll || len(data) == 0 {
+		l.Warnf("Group %s membership change in message %s invalid: missing data", msg.Header.Group, msg.Header.ID)
+		return nil, err
+	}
+	var def fftypes.GroupMembershipChangeDefinition
+	err = json.Unmarshal(data[0].Value.Bytes(), &def)
+	if err != nil {
+		l.Warnf("Group %s membership change in message %s invalid: %s", msg.Header.Group, msg.Header.ID, err)
+		return nil, nil
+	}
+	change := def.Change()
+	if !change.Group.Equals(msg.Header.Group) || change.Namespace != msg.Header.Namespace ||
+		change.EffectiveFrom != msg.Header.GroupVersion || change.Author != msg.Header.Author {
+		l.Warnf("Group %s membership change in message %s invalid: mismatched with message header", msg.Header.Group, msg.Header.ID)
+		return nil, nil
+	}
+
+	group, err := gm.database.GetGroupByHash(ctx, msg.Header.Group)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		l.Warnf("Group %s membership change in message %s invalid: group not found", msg.Header.Group, msg.Header.ID)
+		return nil, nil
+	}
+	if !group.Members.IsMember(change.Author) {
+		l.Warnf("Group %s membership change in message %s invalid: author '%s' is not a member", msg.Header.Group, msg.Header.ID, change.Author)
+		return nil, nil
+	}
+	if err = change.Validate(ctx, group); err != nil {
+		l.Warnf("Group %s membership change in message %s invalid: %s", msg.Header.Group, msg.Header.ID, err)
+		return nil, nil
+	}
+
+	change.Message = msg.Header.ID
+	if err = gm.database.InsertGroupMembershipChange(ctx, change); err != nil {
+		return nil, err
+	}
+	gm.groupCache.Delete(group.Hash.String())
+	l.Infof("Group %s membership changed to version %d (added=%d removed=%d)", group.Hash, change.EffectiveFrom, len(change.Add), len(change.Remove))
+
+	group.Members = change.Members
+	group.Version = change.EffectiveFrom
+	return group, nil
+}
+
+// resolveGroupVersion returns the group with the members at the requested version, when that is not the current version.
+//
+// Errors are only returned for database issues. For validation issues, a nil group is returned without an error.
+func (gm *groupManager) resolveGroupVersion(ctx context.Context, group *fftypes.Group, version int64) (*fftypes.Group, error) {
+	if version > group.Version {
+		log.L(ctx).Warnf("Group %s version %d not found for first message in context. current=%d", group.Hash, version, group.Version)
+		return nil, nil
+	}
+
+	// The members at an earlier version are those from before the change that replaced it
+	fb := database.GroupMembershipChangeQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("group", group.Hash),
+		fb.Eq("effectivefrom", version+1),
+	)
+	changes, _, err := gm.database.GetGroupMembershipChanges(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		log.L(ctx).Warnf("Group %s membership change to version %d not found", group.Hash, version+1)
+		return nil, nil
+	}
+	group.Members = changes[0].Previous()
+	group.Version = version
+	return group, nil
+}
@@ -0,0 +1,897 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/batchpinmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testMembershipOrgs struct {
+	org1, org2, org3    *fftypes.Organization
+	node1, node2, node3 *fftypes.Node
+	group               *fftypes.Group
+}
+
+func newTestMembershipOrgs() *testMembershipOrgs {
+	tmo := &testMembershipOrgs{
+		org1:  &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x11111"},
+		org2:  &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org2", Identity: "0x22222"},
+		org3:  &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org3", Identity: "0x33333"},
+		node1: &fftypes.Node{ID: fftypes.NewUUID(), Name: "node1", Owner: "0x11111", DX: fftypes.DXInfo{Peer: "peer1"}},
+		node2: &fftypes.Node{ID: fftypes.NewUUID(), Name: "node2", Owner: "0x22222", DX: fftypes.DXInfo{Peer: "peer2"}},
+		node3: &fftypes.Node{ID: fftypes.NewUUID(), Name: "node3", Owner: "0x33333", DX: fftypes.DXInfo{Peer: "peer3"}},
+	}
+	tmo.group = &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Namespace: "ns1",
+			Name:      "group1",
+			Members: fftypes.Members{
+				{Identity: tmo.org1.GetDID(), Node: tmo.node1.ID},
+				{Identity: tmo.org2.GetDID(), Node: tmo.node2.ID},
+			},
+		},
+	}
+	tmo.group.Seal()
+	return tmo
+}
+
+func (tmo *testMembershipOrgs) mockLocalMember(pm *privateMessaging) {
+	mdi := pm.database.(*databasemocks.Plugin)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		identity := args[1].(*fftypes.Identity)
+		identity.Author = tmo.org1.GetDID()
+		identity.Key = "0x11111"
+	}).Return(nil)
+}
+
+func TestChangeGroupMembersOk(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org3").Return(tmo.org3, nil)
+	mdi.On("GetNode", pm.ctx, "0x33333", "node3").Return(tmo.node3, nil)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(tmo.org2, nil)
+	mdi.On("GetNode", pm.ctx, "0x22222", "node2").Return(tmo.node2, nil)
+	var data *fftypes.Data
+	mdi.On("UpsertData", pm.ctx, mock.MatchedBy(func(d *fftypes.Data) bool {
+		data = d
+		return d.Validator == fftypes.ValidatorTypeSystemDefinition
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.Header.Type == fftypes.MessageTypeGroupInit &&
+			msg.Header.Tag == string(fftypes.SystemTagChangeGroupMembers) &&
+			msg.Header.GroupVersion == 1 &&
+			msg.Header.Group.Equals(tmo.group.Hash) &&
+			msg.Data[0].ID.Equals(data.ID)
+	}), database.UpsertOptimizationNew).Return(nil)
+
+	change, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Add:    []fftypes.MemberInput{{Identity: "org3", Node: "node3"}},
+		Remove: []fftypes.MemberInput{{Identity: "org2", Node: "node2"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), change.EffectiveFrom)
+	assert.Equal(t, tmo.org1.GetDID(), change.Author)
+	assert.Len(t, change.Members, 2)
+	assert.True(t, change.Members.IsMember(tmo.org3.GetDID()))
+	assert.False(t, change.Members.IsMember(tmo.org2.GetDID()))
+
+	var sent fftypes.GroupMembershipChangeDefinition
+	err = json.Unmarshal(data.Value.Bytes(), &sent)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Message, sent.Message)
+	assert.NotNil(t, sent.Salt)
+	assert.Equal(t, change.Salt, sent.Salt)
+
+	// The salt is not returned on the API
+	b, _ := json.Marshal(change)
+	assert.NotContains(t, string(b), "salt")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestChangeGroupMembersBadHash(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", "!bad", &fftypes.GroupMembershipChangeInput{})
+	assert.Regexp(t, "FF10232", err)
+}
+
+func TestChangeGroupMembersGroupNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, nil)
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", fftypes.NewRandB32().String(), &fftypes.GroupMembershipChangeInput{})
+	assert.Regexp(t, "FF10226", err)
+}
+
+func TestChangeGroupMembersWrongNamespace(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns2", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{})
+	assert.Regexp(t, "FF10226", err)
+}
+
+func TestChangeGroupMembersGetGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", fftypes.NewRandB32().String(), &fftypes.GroupMembershipChangeInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestChangeGroupMembersResolveIdentityFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{})
+	assert.Regexp(t, "FF10206", err)
+}
+
+func TestChangeGroupMembersNotMember(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		args[1].(*fftypes.Identity).Author = tmo.org3.GetDID()
+	}).Return(nil)
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{})
+	assert.Regexp(t, "FF10356", err)
+}
+
+func TestChangeGroupMembersAddOrgFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org3").Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Add: []fftypes.MemberInput{{Identity: "org3"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestChangeGroupMembersAddNodeFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org3").Return(tmo.org3, nil)
+	mdi.On("GetNode", pm.ctx, "0x33333", "node3").Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Add: []fftypes.MemberInput{{Identity: "org3", Node: "node3"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestChangeGroupMembersAddExisting(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(tmo.org2, nil)
+	mdi.On("GetNode", pm.ctx, "0x22222", "node2").Return(tmo.node2, nil)
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Add: []fftypes.MemberInput{{Identity: "org2", Node: "node2"}},
+	})
+	assert.Regexp(t, "FF10352", err)
+}
+
+func TestChangeGroupMembersRemoveOrgFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Remove: []fftypes.MemberInput{{Identity: "org2"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestChangeGroupMembersRemoveNodeFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(tmo.org2, nil)
+	mdi.On("GetNode", pm.ctx, "0x22222", "node2").Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Remove: []fftypes.MemberInput{{Identity: "org2", Node: "node2"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestChangeGroupMembersRemoveNotMember(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org3").Return(tmo.org3, nil)
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Remove: []fftypes.MemberInput{{Identity: "org3"}},
+	})
+	assert.Regexp(t, "FF10353", err)
+}
+
+func TestChangeGroupMembersRemoveAll(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org1").Return(tmo.org1, nil)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(tmo.org2, nil)
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Remove: []fftypes.MemberInput{{Identity: "org1"}, {Identity: "org2"}},
+	})
+	assert.Regexp(t, "FF10219", err)
+}
+
+func TestChangeGroupMembersUpsertDataFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(tmo.org2, nil)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Remove: []fftypes.MemberInput{{Identity: "org2"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestChangeGroupMembersUpsertMessageFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockLocalMember(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(tmo.org2, nil)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := pm.ChangeGroupMembers(pm.ctx, "ns1", tmo.group.Hash.String(), &fftypes.GroupMembershipChangeInput{
+		Remove: []fftypes.MemberInput{{Identity: "org2"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func newTestMembershipChangeBatch(tmo *testMembershipOrgs, change *fftypes.GroupMembershipChange) *fftypes.Batch {
+	b, _ := json.Marshal(change.Definition())
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtrBytes(b)}
+	return &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Group:     tmo.group.Hash,
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID:   fftypes.NewUUID(),
+				Type: fftypes.TransactionTypeBatchPin,
+			},
+			Messages: []*fftypes.Message{
+				{Header: fftypes.MessageHeader{Tag: "mytag"}},
+				{
+					Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagChangeGroupMembers)},
+					Data:   fftypes.DataRefs{{ID: data.ID}},
+				},
+			},
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID()},
+				data,
+			},
+		},
+	}
+}
+
+func TestDispatchMembershipChangeToAddedMembers(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mbp := pm.batchpin.(*batchpinmocks.Submitter)
+	mbp.On("EnsurePrivacyGroup", pm.ctx, "ns1", tmo.group.Hash).Return(false, nil)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node1.ID).Return(tmo.node1, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node2.ID).Return(tmo.node2, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node3.ID).Return(tmo.node3, nil)
+	mim.On("GetLocalOrgKey", pm.ctx).Return("0x11111", nil)
+	mdi.On("InsertOperation", pm.ctx, mock.Anything).Return(nil)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, "peer2", mock.Anything).Return(nil)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, "peer3", mock.MatchedBy(func(b []byte) bool {
+		var tw fftypes.TransportWrapper
+		err := json.Unmarshal(b, &tw)
+		assert.NoError(t, err)
+		return tw.Group != nil && tw.Group.Hash.Equals(tmo.group.Hash)
+	})).Return(nil)
+
+	batch := newTestMembershipChangeBatch(tmo, &fftypes.GroupMembershipChange{
+		Add: fftypes.Members{
+			{Identity: tmo.org3.GetDID(), Node: tmo.node3.ID},
+			{Identity: tmo.org3.GetDID(), Node: tmo.node2.ID},
+		},
+	})
+	err := pm.dispatchBatchCommon(pm.ctx, batch)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestDispatchMembershipChangeExcludesDepartingMembers(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mbp := pm.batchpin.(*batchpinmocks.Submitter)
+	mbp.On("EnsurePrivacyGroup", pm.ctx, "ns1", tmo.group.Hash).Return(false, nil)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node1.ID).Return(tmo.node1, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node2.ID).Return(tmo.node2, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node3.ID).Return(tmo.node3, nil)
+	mim.On("GetLocalOrgKey", pm.ctx).Return("0x11111", nil)
+	mdi.On("InsertOperation", pm.ctx, mock.Anything).Return(nil)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, "peer3", mock.Anything).Return(nil)
+
+	batch := newTestMembershipChangeBatch(tmo, &fftypes.GroupMembershipChange{
+		Add:    fftypes.Members{{Identity: tmo.org3.GetDID(), Node: tmo.node3.ID}},
+		Remove: fftypes.Members{{Identity: tmo.org2.GetDID(), Node: tmo.node2.ID}},
+		Members: fftypes.Members{
+			{Identity: tmo.org1.GetDID(), Node: tmo.node1.ID},
+			{Identity: tmo.org3.GetDID(), Node: tmo.node3.ID},
+		},
+		Salt: fftypes.NewRandB32(),
+	})
+	batch.Payload.Messages = batch.Payload.Messages[1:]
+	err := pm.dispatchBatchCommon(pm.ctx, batch)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+	mdx.AssertNotCalled(t, "SendMessage", pm.ctx, mock.Anything, "peer2", mock.Anything)
+}
+
+func TestDispatchMembershipChangeWithOtherMessagesExcludesDepartingMembers(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mbp := pm.batchpin.(*batchpinmocks.Submitter)
+	mbp.On("EnsurePrivacyGroup", pm.ctx, "ns1", tmo.group.Hash).Return(false, nil)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node1.ID).Return(tmo.node1, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node2.ID).Return(tmo.node2, nil)
+
+	mim.On("GetLocalOrgKey", pm.ctx).Return("0x11111", nil)
+
+	batch := newTestMembershipChangeBatch(tmo, &fftypes.GroupMembershipChange{
+		Remove:  fftypes.Members{{Identity: tmo.org2.GetDID(), Node: tmo.node2.ID}},
+		Members: fftypes.Members{{Identity: tmo.org1.GetDID(), Node: tmo.node1.ID}},
+		Salt:    fftypes.NewRandB32(),
+	})
+	err := pm.dispatchBatchCommon(pm.ctx, batch)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+	mdx.AssertNotCalled(t, "SendMessage", pm.ctx, mock.Anything, "peer2", mock.Anything)
+}
+
+func TestDispatchMembershipChangeBadData(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node1.ID).Return(tmo.node1, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node2.ID).Return(tmo.node2, nil)
+
+	batch := newTestMembershipChangeBatch(tmo, &fftypes.GroupMembershipChange{})
+	batch.Payload.Data[1].Value = fftypes.JSONAnyPtr(`!json`)
+	err := pm.dispatchBatchCommon(pm.ctx, batch)
+	assert.Regexp(t, "FF10137", err)
+}
+
+func TestDispatchMembershipChangeNodeLookupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node1.ID).Return(tmo.node1, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node2.ID).Return(tmo.node2, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node3.ID).Return(nil, fmt.Errorf("pop"))
+
+	batch := newTestMembershipChangeBatch(tmo, &fftypes.GroupMembershipChange{
+		Add: fftypes.Members{{Identity: tmo.org3.GetDID(), Node: tmo.node3.ID}},
+	})
+	err := pm.dispatchBatchCommon(pm.ctx, batch)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDispatchMembershipChangeNodeNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node1.ID).Return(tmo.node1, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node2.ID).Return(tmo.node2, nil)
+	mdi.On("GetNodeByID", pm.ctx, tmo.node3.ID).Return(nil, nil)
+
+	batch := newTestMembershipChangeBatch(tmo, &fftypes.GroupMembershipChange{
+		Add: fftypes.Members{{Identity: tmo.org3.GetDID(), Node: tmo.node3.ID}},
+	})
+	err := pm.dispatchBatchCommon(pm.ctx, batch)
+	assert.Regexp(t, "FF10224", err)
+}
+
+func newTestMembershipChangeMessage(tmo *testMembershipOrgs) (*fftypes.Message, *fftypes.GroupMembershipChange) {
+	change := &fftypes.GroupMembershipChange{
+		ID:            fftypes.NewUUID(),
+		Namespace:     "ns1",
+		Group:         tmo.group.Hash,
+		EffectiveFrom: 1,
+		Author:        tmo.org1.GetDID(),
+		Add:           fftypes.Members{{Identity: tmo.org3.GetDID(), Node: tmo.node3.ID}},
+	}
+	change.Members, _ = change.Apply(context.Background(), tmo.group.Members)
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:           fftypes.NewUUID(),
+			Namespace:    "ns1",
+			Type:         fftypes.MessageTypeGroupInit,
+			Tag:          string(fftypes.SystemTagChangeGroupMembers),
+			Group:        tmo.group.Hash,
+			GroupVersion: 1,
+			Identity:     fftypes.Identity{Author: tmo.org1.GetDID()},
+		},
+	}
+	return msg, change
+}
+
+func mockMembershipChangeData(pm *privateMessaging, change interface{}) {
+	b, _ := json.Marshal(change)
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", pm.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtrBytes(b)},
+	}, true, nil)
+}
+
+func TestResolveInitGroupMembershipChangeOk(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, change := newTestMembershipChangeMessage(tmo)
+	mockMembershipChangeData(pm, change)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("InsertGroupMembershipChange", pm.ctx, mock.MatchedBy(func(c *fftypes.GroupMembershipChange) bool {
+		return c.Message.Equals(msg.Header.ID) && c.EffectiveFrom == 1
+	})).Return(nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), group.Version)
+	assert.Len(t, group.Members, 3)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveInitGroupMembershipChangeMissingData(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, _ := newTestMembershipChangeMessage(tmo)
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", pm.ctx, mock.Anything, true).Return(nil, false, nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestResolveInitGroupMembershipChangeBadData(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, _ := newTestMembershipChangeMessage(tmo)
+	mockMembershipChangeData(pm, "not a change")
+
+	group, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestResolveInitGroupMembershipChangeMismatchedHeader(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, change := newTestMembershipChangeMessage(tmo)
+	change.EffectiveFrom = 2
+	mockMembershipChangeData(pm, change)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestResolveInitGroupMembershipChangeGetGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, change := newTestMembershipChangeMessage(tmo)
+	mockMembershipChangeData(pm, change)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestResolveInitGroupMembershipChangeGroupNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, change := newTestMembershipChangeMessage(tmo)
+	mockMembershipChangeData(pm, change)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(nil, nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestResolveInitGroupMembershipChangeAuthorNotMember(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, change := newTestMembershipChangeMessage(tmo)
+	msg.Header.Author = tmo.org3.GetDID()
+	change.Author = tmo.org3.GetDID()
+	mockMembershipChangeData(pm, change)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestResolveInitGroupMembershipChangeInvalid(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, change := newTestMembershipChangeMessage(tmo)
+	change.Members = tmo.group.Members
+	mockMembershipChangeData(pm, change)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestResolveInitGroupMembershipChangeInsertFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	msg, change := newTestMembershipChangeMessage(tmo)
+	mockMembershipChangeData(pm, change)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("InsertGroupMembershipChange", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.ResolveInitGroup(pm.ctx, msg)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestResolveInitGroupEarlierVersion(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	_, change := newTestMembershipChangeMessage(tmo)
+	originalMembers := tmo.group.Members
+	tmo.group.Members = change.Members
+	tmo.group.Version = 1
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetGroupMembershipChanges", pm.ctx, mock.Anything).Return([]*fftypes.GroupMembershipChange{change}, nil, nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:    fftypes.NewUUID(),
+			Group: tmo.group.Hash,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), group.Version)
+	assert.Equal(t, originalMembers, group.Members)
+}
+
+func TestResolveInitGroupEarlierVersionChangeNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.group.Version = 1
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetGroupMembershipChanges", pm.ctx, mock.Anything).Return([]*fftypes.GroupMembershipChange{}, nil, nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:    fftypes.NewUUID(),
+			Group: tmo.group.Hash,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestResolveInitGroupEarlierVersionFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.group.Version = 1
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+	mdi.On("GetGroupMembershipChanges", pm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := pm.ResolveInitGroup(pm.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:    fftypes.NewUUID(),
+			Group: tmo.group.Hash,
+		},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestResolveInitGroupLaterVersion(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, tmo.group.Hash).Return(tmo.group, nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:           fftypes.NewUUID(),
+			Group:        tmo.group.Hash,
+			GroupVersion: 1,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func (tmo *testMembershipOrgs) mockCreateGroupMembers(pm *privateMessaging) {
+	mdi := pm.database.(*databasemocks.Plugin)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+	mim.On("ResolveLocalOrgDID", pm.ctx).Return(tmo.org1.GetDID(), nil)
+	mim.On("GetLocalOrganization", pm.ctx).Return(tmo.org1, nil)
+	mdi.On("GetOrganizationByName", pm.ctx, "org1").Return(tmo.org1, nil)
+	mdi.On("GetNode", pm.ctx, "0x11111", "node1").Return(tmo.node1, nil)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(tmo.org2, nil)
+	mdi.On("GetNode", pm.ctx, "0x22222", "node2").Return(tmo.node2, nil)
+}
+
+func TestCreateGroupNew(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockCreateGroupMembers(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{}, nil, nil)
+	mdi.On("UpsertGroup", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.Header.Type == fftypes.MessageTypeGroupInit &&
+			msg.Header.Tag == string(fftypes.SystemTagDefineGroup) &&
+			msg.Header.Group.Equals(tmo.group.Hash)
+	}), database.UpsertOptimizationNew).Return(nil)
+
+	group, err := pm.CreateGroup(pm.ctx, "ns1", &fftypes.InputGroup{
+		Name: "group1",
+		Members: []fftypes.MemberInput{
+			{Identity: "org2", Node: "node2"},
+			{Identity: "org1", Node: "node1"},
+		},
+	})
+	assert.NoError(t, err)
+	// The hash is calculated from the sorted member list, so is independent of input order
+	assert.Equal(t, tmo.group.Hash, group.Hash)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCreateGroupExisting(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockCreateGroupMembers(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{tmo.group}, nil, nil)
+
+	group, err := pm.CreateGroup(pm.ctx, "ns1", &fftypes.InputGroup{
+		Name: "group1",
+		Members: []fftypes.MemberInput{
+			{Identity: "org1", Node: "node1"},
+			{Identity: "org2", Node: "node2"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, tmo.group, group)
+
+	mdi.AssertNotCalled(t, "UpsertGroup", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateGroupInitFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	tmo.mockCreateGroupMembers(pm)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{}, nil, nil)
+	mdi.On("UpsertGroup", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := pm.CreateGroup(pm.ctx, "ns1", &fftypes.InputGroup{
+		Members: []fftypes.MemberInput{
+			{Identity: "org1", Node: "node1"},
+			{Identity: "org2", Node: "node2"},
+		},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCreateGroupNoMembers(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.CreateGroup(pm.ctx, "ns1", &fftypes.InputGroup{})
+	assert.Regexp(t, "FF10219", err)
+}
+
+func TestCreateGroupBadIdentity(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.CreateGroup(pm.ctx, "ns1", &fftypes.InputGroup{
+		Members: []fftypes.MemberInput{{Identity: "org2"}},
+	})
+	assert.Regexp(t, "FF10206.*pop", err)
+}
+
+func TestGetGroupMembershipChanges(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	tmo := newTestMembershipOrgs()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupMembershipChanges", pm.ctx, mock.Anything).Return([]*fftypes.GroupMembershipChange{}, nil, nil)
+
+	fb := database.GroupMembershipChangeQueryFactory.NewFilter(pm.ctx)
+	_, _, err := pm.GetGroupMembershipChanges(pm.ctx, "ns1", tmo.group.Hash.String(), fb.And(fb.Eq("author", "org1")))
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestGetGroupMembershipChangesBadHash(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	fb := database.GroupMembershipChangeQueryFactory.NewFilter(pm.ctx)
+	_, _, err := pm.GetGroupMembershipChanges(pm.ctx, "ns1", "!hash", fb.And())
+	assert.Regexp(t, "FF10232", err)
+}
@@ -19,9 +19,12 @@ package privatemessaging
 import (
 	"context"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/internal/batch"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -99,6 +102,7 @@ func (s *messageSender) setDefaults() {
 	if s.msg.Header.Type == "" {
 		s.msg.Header.Type = fftypes.MessageTypePrivate
 	}
+	batch.NamespaceMessageDefaults(s.namespace, &s.msg.Message)
 	switch s.msg.Header.TxType {
 	case fftypes.TransactionTypeUnpinned, fftypes.TransactionTypeNone:
 		// "unpinned" used to be called "none" (before we introduced batching + a TX on unppinned sends)
@@ -119,8 +123,13 @@ func (s *messageSender) resolveAndSend(ctx context.Context, method sendMethod) e
 				return err
 			}
 			msgSizeEstimate := s.msg.EstimateSize(true)
-			if msgSizeEstimate > s.mgr.maxBatchPayloadLength {
-				return i18n.NewError(ctx, i18n.MsgTooLargePrivate, float64(msgSizeEstimate)/1024, float64(s.mgr.maxBatchPayloadLength)/1024)
+			maxBatchPayloadLength := batch.NamespacePayloadLimit(s.msg.Header.Namespace, s.mgr.maxBatchPayloadLength)
+			if msgSizeEstimate > maxBatchPayloadLength {
+				return i18n.NewError(ctx, i18n.MsgTooLargePrivate, float64(msgSizeEstimate)/1024, float64(maxBatchPayloadLength)/1024)
+			}
+			if msgSizeEstimate*100 >= maxBatchPayloadLength*batchSizeWarnPercent {
+				// The message only just fits in a batch, so warn the caller while there is still headroom
+				apiwarnings.Add(ctx, i18n.MsgWarnMessageNearBatchLimit, float64(msgSizeEstimate)/1024, float64(maxBatchPayloadLength)/1024)
 			}
 			s.resolved = true
 		}
@@ -134,16 +143,23 @@ func (s *messageSender) resolveAndSend(ctx context.Context, method sendMethod) e
 	})
 
 	if err != nil || sent {
-		return err
+		return s.checkDuplicateKey(ctx, err)
 	}
 
 	return s.sendInternal(ctx, method)
 }
 
 func (s *messageSender) resolve(ctx context.Context) error {
+	if err := s.checkIdempotencyKey(ctx); err != nil {
+		return err
+	}
+
 	// Resolve the sending identity
-	if err := s.mgr.identity.ResolveInputIdentity(ctx, &s.msg.Header.Identity); err != nil {
-		return i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+	if err := s.resolveIdentity(ctx); err != nil {
+		return err
+	}
+	if err := s.mgr.identity.VerifyCryptoPolicy(ctx, s.namespace, &s.msg.Header.Identity); err != nil {
+		return err
 	}
 
 	// Resolve the member list into a group
@@ -154,7 +170,58 @@ func (s *messageSender) resolve(ctx context.Context) error {
 	// The data manager is responsible for the heavy lifting of storing/validating all our in-line data elements
 	dataRefs, err := s.mgr.data.ResolveInlineDataPrivate(ctx, s.namespace, s.msg.InlineData)
 	s.msg.Message.Data = dataRefs
-	return err
+	if err != nil || s.mgr.keyWrapper == nil {
+		return err
+	}
+
+	// Fail fast on blobs when encryption is enabled, rather than when the batch is dispatched
+	data, _, err := s.mgr.data.GetMessageData(ctx, &s.msg.Message, false)
+	if err != nil {
+		return err
+	}
+	return s.mgr.checkNoPrivateBlobs(ctx, data)
+}
+
+// checkIdempotencyKey rejects a retry of a submission that has already been accepted, before any data is
+// written. The unique constraint on the messages table protects against two concurrent submissions.
+func (s *messageSender) checkIdempotencyKey(ctx context.Context) error {
+	if s.msg.IdempotencyKey == "" {
+		return nil
+	}
+	existing, err := s.existingMessage(ctx)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return i18n.NewError(ctx, i18n.MsgDuplicateIdempotencyKeyMsg, s.msg.IdempotencyKey, existing.Header.ID)
+	}
+	return nil
+}
+
+// checkDuplicateKey reports a message with the same idempotency key that was accepted concurrently, which the
+// unique constraint rejected after checkIdempotencyKey had passed. This is called once the database transaction
+// of the insert has been rolled back.
+func (s *messageSender) checkDuplicateKey(ctx context.Context, err error) error {
+	if err != database.DuplicateKey || s.msg.IdempotencyKey == "" {
+		return err
+	}
+	existing, lookupErr := s.existingMessage(ctx)
+	if lookupErr != nil || existing == nil {
+		return err
+	}
+	return i18n.NewError(ctx, i18n.MsgDuplicateIdempotencyKeyMsg, s.msg.IdempotencyKey, existing.Header.ID)
+}
+
+func (s *messageSender) existingMessage(ctx context.Context) (*fftypes.Message, error) {
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	existing, _, err := s.mgr.database.GetMessages(ctx, fb.And(
+		fb.Eq("namespace", s.namespace),
+		fb.Eq("idempotencykey", string(s.msg.IdempotencyKey)),
+	).Limit(1))
+	if err != nil || len(existing) == 0 {
+		return nil, err
+	}
+	return existing[0], nil
 }
 
 func (s *messageSender) sendInternal(ctx context.Context, method sendMethod) error {
@@ -180,7 +247,20 @@ func (s *messageSender) sendInternal(ctx context.Context, method sendMethod) err
 	if err := s.mgr.database.UpsertMessage(ctx, &s.msg.Message, database.UpsertOptimizationNew); err != nil {
 		return err
 	}
+	tracing.RecordMessage(ctx, s.msg.Header.ID)
 	log.L(ctx).Infof("Sent private message %s:%s sequence=%d", s.msg.Header.Namespace, s.msg.Header.ID, s.msg.Sequence)
 
 	return nil
 }
+
+// resolveIdentity resolves the sending identity, which might be a child identity that the submitter
+// is sending on behalf of
+func (s *messageSender) resolveIdentity(ctx context.Context) error {
+	if s.msg.OnBehalfOf != "" {
+		return s.mgr.identity.ResolveDelegatedIdentity(ctx, &s.msg.Header.Identity, s.msg.OnBehalfOf)
+	}
+	if err := s.mgr.identity.ResolveInputIdentity(ctx, &s.msg.Header.Identity); err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+	}
+	return nil
+}
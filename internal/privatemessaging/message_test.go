@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/apiwarnings"
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
@@ -143,6 +145,45 @@ func TestSendUnpinnedMessageE2EOk(t *testing.T) {
 
 }
 
+func TestSendMessageNamespaceDefaultUnpinned(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessagingWithMetrics(t)
+	defer cancel()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "ns1", "message": map[string]interface{}{"txType": "unpinned"}},
+	})
+	defer config.Reset()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+
+	groupID := fftypes.NewRandB32()
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("ResolveInlineDataPrivate", pm.ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, nil)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertMessage", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil).Once()
+	mdi.On("GetGroupByHash", pm.ctx, groupID).Return(&fftypes.Group{Hash: groupID}, nil)
+
+	msg, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Group: groupID,
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TransactionTypeUnpinned, msg.Header.TxType)
+
+	mdi.AssertExpectations(t)
+
+}
+
 func TestSendMessageBadGroup(t *testing.T) {
 
 	pm, cancel := newTestPrivateMessaging(t)
@@ -163,6 +204,155 @@ func TestSendMessageBadGroup(t *testing.T) {
 
 }
 
+func TestSendMessageIdempotencyKeyNotUsed(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", pm.ctx, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+
+	_, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+		Group: &fftypes.InputGroup{},
+	}, false)
+	assert.Regexp(t, "FF10219", err)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+
+}
+
+func TestSendMessageIdempotencyKeyDuplicate(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	existing := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", pm.ctx, mock.Anything).Return([]*fftypes.Message{existing}, nil, nil)
+
+	_, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+		Group: &fftypes.InputGroup{},
+	}, false)
+	assert.Regexp(t, "FF10451.*"+existing.Header.ID.String(), err)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestSendMessageIdempotencyKeyQueryFail(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", pm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+		Group: &fftypes.InputGroup{},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestSendMessageIdempotencyKeyConcurrent(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+
+	groupID := fftypes.NewRandB32()
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("ResolveInlineDataPrivate", pm.ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, nil)
+
+	// The other submission is accepted between the check, and the insert
+	existing := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", pm.ctx, mock.Anything).Return([]*fftypes.Message{}, nil, nil).Once()
+	mdi.On("GetGroupByHash", pm.ctx, groupID).Return(&fftypes.Group{Hash: groupID}, nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(database.DuplicateKey)
+	mdi.On("GetMessages", pm.ctx, mock.Anything).Return([]*fftypes.Message{existing}, nil, nil).Once()
+
+	_, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Group: groupID,
+			},
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+	}, false)
+	assert.Regexp(t, "FF10451.*"+existing.Header.ID.String(), err)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestSendMessageIdempotencyKeyConcurrentQueryFail(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+
+	groupID := fftypes.NewRandB32()
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("ResolveInlineDataPrivate", pm.ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, nil)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", pm.ctx, mock.Anything).Return([]*fftypes.Message{}, nil, nil).Once()
+	mdi.On("GetGroupByHash", pm.ctx, groupID).Return(&fftypes.Group{Hash: groupID}, nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(database.DuplicateKey)
+	mdi.On("GetMessages", pm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop")).Once()
+
+	_, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Group: groupID,
+			},
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+	}, false)
+	assert.Equal(t, database.DuplicateKey, err)
+
+	mdi.AssertExpectations(t)
+
+}
+
 func TestSendMessageBadIdentity(t *testing.T) {
 
 	pm, cancel := newTestPrivateMessaging(t)
@@ -187,6 +377,57 @@ func TestSendMessageBadIdentity(t *testing.T) {
 
 }
 
+func TestSendMessageCryptoPolicyRejected(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := &identitymanagermocks.Manager{}
+	pm.identity = mim
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+	mim.On("VerifyCryptoPolicy", pm.ctx, "ns1", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+		Group: &fftypes.InputGroup{
+			Members: []fftypes.MemberInput{
+				{Identity: "org1"},
+			},
+		},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mim.AssertExpectations(t)
+
+}
+
+func TestSendMessageOnBehalfOfFail(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveDelegatedIdentity", pm.ctx, mock.Anything, "org2").Return(fmt.Errorf("pop"))
+
+	_, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+		Group: &fftypes.InputGroup{
+			Members: []fftypes.MemberInput{
+				{Identity: "org1"},
+			},
+		},
+		OnBehalfOf: "org2",
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mim.AssertExpectations(t)
+
+}
+
 func TestSendMessageFail(t *testing.T) {
 
 	pm, cancel := newTestPrivateMessaging(t)
@@ -334,6 +575,52 @@ func TestSendUnpinnedMessageTooLarge(t *testing.T) {
 
 }
 
+func TestSendMessageNearLimitWarning(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	pm.maxBatchPayloadLength = 100000
+	defer cancel()
+	ctx := apiwarnings.WithWarnings(pm.ctx)
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		identity := args[1].(*fftypes.Identity)
+		identity.Author = "localorg"
+		identity.Key = "localkey"
+	}).Return(nil)
+
+	groupID := fftypes.NewRandB32()
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("ResolveInlineDataPrivate", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), ValueSize: 95000},
+	}, nil)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ctx, groupID).Return(&fftypes.Group{Hash: groupID}, nil)
+	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := pm.SendMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				TxType: fftypes.TransactionTypeUnpinned,
+				Group:  groupID,
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.JSONAnyPtr(`{"some": "data"}`)},
+		},
+	}, false)
+	assert.EqualError(t, err, "pop")
+	warnings := apiwarnings.Get(ctx)
+	assert.Len(t, warnings, 1)
+	assert.Regexp(t, "FF10369", warnings[0])
+
+	mdm.AssertExpectations(t)
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+
+}
+
 func TestSealFail(t *testing.T) {
 
 	pm, cancel := newTestPrivateMessaging(t)
@@ -793,30 +1080,29 @@ func TestSendDataTransferBlobsFail(t *testing.T) {
 		ID: nodeID2, Name: "node2", Owner: "org1", DX: fftypes.DXInfo{Peer: "peer2-remote"},
 	}}
 
-	err := pm.sendData(pm.ctx, &fftypes.TransportWrapper{
-		Batch: &fftypes.Batch{
-			ID:    fftypes.NewUUID(),
-			Group: groupID,
-			Payload: fftypes.BatchPayload{
-				Messages: []*fftypes.Message{
-					{
-						Header: fftypes.MessageHeader{
-							Tag:   "mytag",
-							Group: groupID,
-							Identity: fftypes.Identity{
-								Author: "org1",
-							},
+	batch := &fftypes.Batch{
+		ID:    fftypes.NewUUID(),
+		Group: groupID,
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{
+					Header: fftypes.MessageHeader{
+						Tag:   "mytag",
+						Group: groupID,
+						Identity: fftypes.Identity{
+							Author: "org1",
 						},
 					},
 				},
-				Data: []*fftypes.Data{
-					{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr("{}"), Blob: &fftypes.BlobRef{
-						Hash: fftypes.NewRandB32(),
-					}},
-				},
+			},
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Value: fftypes.JSONAnyPtr("{}"), Blob: &fftypes.BlobRef{
+					Hash: fftypes.NewRandB32(),
+				}},
 			},
 		},
-	}, nodes)
+	}
+	err := pm.sendData(pm.ctx, batch, &fftypes.TransportWrapper{Batch: batch}, nodes)
 	assert.Regexp(t, "pop", err)
 
 	mdi.AssertExpectations(t)
@@ -848,25 +1134,24 @@ func TestSendDataTransferFail(t *testing.T) {
 		ID: nodeID2, Name: "node2", Owner: "org1", DX: fftypes.DXInfo{Peer: "peer2-remote"},
 	}}
 
-	err := pm.sendData(pm.ctx, &fftypes.TransportWrapper{
-		Batch: &fftypes.Batch{
-			ID:    fftypes.NewUUID(),
-			Group: groupID,
-			Payload: fftypes.BatchPayload{
-				Messages: []*fftypes.Message{
-					{
-						Header: fftypes.MessageHeader{
-							Tag:   "mytag",
-							Group: groupID,
-							Identity: fftypes.Identity{
-								Author: "org1",
-							},
+	batch := &fftypes.Batch{
+		ID:    fftypes.NewUUID(),
+		Group: groupID,
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{
+					Header: fftypes.MessageHeader{
+						Tag:   "mytag",
+						Group: groupID,
+						Identity: fftypes.Identity{
+							Author: "org1",
 						},
 					},
 				},
 			},
 		},
-	}, nodes)
+	}
+	err := pm.sendData(pm.ctx, batch, &fftypes.TransportWrapper{Batch: batch}, nodes)
 	assert.Regexp(t, "pop", err)
 
 	mdx.AssertExpectations(t)
@@ -895,25 +1180,24 @@ func TestSendDataTransferInsertOperationFail(t *testing.T) {
 		ID: nodeID2, Name: "node2", Owner: "org1", DX: fftypes.DXInfo{Peer: "peer2-remote"},
 	}}
 
-	err := pm.sendData(pm.ctx, &fftypes.TransportWrapper{
-		Batch: &fftypes.Batch{
-			ID:    fftypes.NewUUID(),
-			Group: groupID,
-			Payload: fftypes.BatchPayload{
-				Messages: []*fftypes.Message{
-					{
-						Header: fftypes.MessageHeader{
-							Tag:   "mytag",
-							Group: groupID,
-							Identity: fftypes.Identity{
-								Author: "org1",
-							},
+	batch := &fftypes.Batch{
+		ID:    fftypes.NewUUID(),
+		Group: groupID,
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{
+					Header: fftypes.MessageHeader{
+						Tag:   "mytag",
+						Group: groupID,
+						Identity: fftypes.Identity{
+							Author: "org1",
 						},
 					},
 				},
 			},
 		},
-	}, nodes)
+	}
+	err := pm.sendData(pm.ctx, batch, &fftypes.TransportWrapper{Batch: batch}, nodes)
 	assert.Regexp(t, "pop", err)
 
 }
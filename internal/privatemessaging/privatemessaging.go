@@ -19,7 +19,9 @@ package privatemessaging
 import (
 	"context"
 	"encoding/json"
+	"sync"
 
+	"github.com/hyperledger/firefly/internal/antireplay"
 	"github.com/hyperledger/firefly/internal/batch"
 	"github.com/hyperledger/firefly/internal/batchpin"
 	"github.com/hyperledger/firefly/internal/config"
@@ -28,6 +30,7 @@ import (
 	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/nodekey"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
@@ -41,6 +44,9 @@ import (
 const pinnedPrivateDispatcherName = "pinned_private"
 const unpinnedPrivateDispatcherName = "unpinned_private"
 
+// batchSizeWarnPercent is the percentage of the batch payload limit, above which a message sender receives a warning
+const batchSizeWarnPercent = 90
+
 type Manager interface {
 	GroupManager
 
@@ -48,6 +54,11 @@ type Manager interface {
 	NewMessage(ns string, msg *fftypes.MessageInOut) sysmessaging.MessageSender
 	SendMessage(ctx context.Context, ns string, in *fftypes.MessageInOut, waitConfirm bool) (out *fftypes.Message, err error)
 	RequestReply(ctx context.Context, ns string, request *fftypes.MessageInOut) (reply *fftypes.MessageInOut, err error)
+	CreateGroup(ctx context.Context, ns string, in *fftypes.InputGroup) (*fftypes.Group, error)
+	ChangeGroupMembers(ctx context.Context, ns, groupHash string, input *fftypes.GroupMembershipChangeInput) (*fftypes.GroupMembershipChange, error)
+	DecryptTransport(ctx context.Context, et *fftypes.EncryptedTransport) ([]byte, error)
+	SendPrivateData(ctx context.Context, batch *fftypes.Batch) error
+	EnsurePrivacyGroup(ctx context.Context, ns string, group *fftypes.Bytes32) error
 }
 
 type privateMessaging struct {
@@ -68,6 +79,10 @@ type privateMessaging struct {
 	opCorrelationRetries  int
 	maxBatchPayloadLength int64
 	metrics               metrics.Manager
+	keyWrapper            nodekey.KeyWrapper // nil unless end-to-end encryption is configured
+	groupKeys             map[fftypes.Bytes32]*groupKey
+	groupKeysMux          sync.Mutex
+	readOnly              bool
 }
 
 func NewPrivateMessaging(ctx context.Context, di database.Plugin, im identity.Manager, dx dataexchange.Plugin, bi blockchain.Plugin, ba batch.Manager, dm data.Manager, sa syncasync.Bridge, bp batchpin.Submitter, mm metrics.Manager) (Manager, error) {
@@ -99,7 +114,15 @@ func NewPrivateMessaging(ctx context.Context, di database.Plugin, im identity.Ma
 		opCorrelationRetries:  config.GetInt(config.PrivateMessagingOpCorrelationRetries),
 		maxBatchPayloadLength: config.GetByteSize(config.PrivateMessagingBatchPayloadLimit),
 		metrics:               mm,
+		groupKeys:             make(map[fftypes.Bytes32]*groupKey),
+		readOnly:              config.GetBool(config.NodeReadOnly),
+	}
+	pm.retry.ReloadFromConfig(ctx, config.PrivateMessagingRetryInitDelay, config.PrivateMessagingRetryMaxDelay, config.PrivateMessagingRetryFactor)
+	kw, err := nodekey.GetKeyWrapper(ctx)
+	if err != nil {
+		return nil, err
 	}
+	pm.keyWrapper = kw
 	pm.groupManager.groupCache = ccache.New(
 		// We use a LRU cache with a size-aware max
 		ccache.Configure().
@@ -129,6 +152,12 @@ func NewPrivateMessaging(ctx context.Context, di database.Plugin, im identity.Ma
 		},
 		pm.dispatchUnpinnedBatch, bo)
 
+	config.OnReload(ctx, func(ctx context.Context) {
+		timeout := config.GetDuration(config.PrivateMessagingBatchTimeout)
+		ba.SetBatchTimeout(pinnedPrivateDispatcherName, timeout)
+		ba.SetBatchTimeout(unpinnedPrivateDispatcherName, timeout)
+	}, config.PrivateMessagingBatchTimeout)
+
 	return pm, nil
 }
 
@@ -160,13 +189,84 @@ func (pm *privateMessaging) dispatchBatchCommon(ctx context.Context, batch *ffty
 		return err
 	}
 
-	if batch.Payload.TX.Type == fftypes.TransactionTypeUnpinned {
+	// Any members being added to the group must receive the membership change, and the group itself
+	nodes, membershipChange, err := pm.getMembershipChangeNodes(ctx, batch, nodes)
+	if err != nil {
+		return err
+	}
+
+	// If the pins are private transactions, the privacy group of the members must exist before the pin is submitted
+	privateTX := false
+	if batch.Payload.TX.Type == fftypes.TransactionTypeBatchPin {
+		if privateTX, err = pm.batchpin.EnsurePrivacyGroup(ctx, batch.Namespace, batch.Group); err != nil {
+			return err
+		}
+	}
+
+	if batch.Payload.TX.Type == fftypes.TransactionTypeUnpinned || membershipChange || privateTX {
 		// In the case of an un-pinned message we cannot be sure the group has been broadcast via the blockchain.
 		// So we have to take the hit of sending it along with every message.
+		// Private pins are only visible to members that have joined the privacy group, which they do from the group.
 		tw.Group = group
 	}
 
-	return pm.sendData(ctx, tw, nodes)
+	return pm.sendData(ctx, batch, tw, nodes)
+}
+
+// SendPrivateData distributes the values of any data in a broadcast batch that is restricted to a private group,
+// to the member nodes of that group. The values are withheld from the batch itself, which is published publicly.
+func (pm *privateMessaging) SendPrivateData(ctx context.Context, batch *fftypes.Batch) error {
+	var groups []*fftypes.Bytes32
+	groupData := make(map[fftypes.Bytes32][]*fftypes.Data)
+	for _, d := range batch.Payload.Data {
+		if d.Group == nil {
+			continue
+		}
+		data, err := pm.database.GetDataByID(ctx, d.ID, true)
+		if err != nil {
+			return err
+		}
+		if data == nil || data.ValueWithheld() {
+			return i18n.NewError(ctx, i18n.MsgDataNotFound, d.ID)
+		}
+		if _, ok := groupData[*d.Group]; !ok {
+			groups = append(groups, d.Group)
+		}
+		groupData[*d.Group] = append(groupData[*d.Group], data)
+	}
+
+	for _, group := range groups {
+		_, nodes, err := pm.groupManager.getGroupNodes(ctx, group)
+		if err != nil {
+			return err
+		}
+		pd := &fftypes.PrivateDataTransfer{
+			Namespace: batch.Namespace,
+			Batch:     batch.ID,
+			Group:     group,
+			Data:      groupData[*group],
+		}
+		// The transmission is sent in the context of the broadcast batch, but only contains the private data
+		groupBatch := &fftypes.Batch{
+			ID:        batch.ID,
+			Namespace: batch.Namespace,
+			Group:     group,
+			Payload: fftypes.BatchPayload{
+				TX:   batch.Payload.TX,
+				Data: pd.Data,
+			},
+		}
+		if err := pm.sendData(ctx, groupBatch, &fftypes.TransportWrapper{PrivateData: pd}, nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsurePrivacyGroup joins the privacy group of the members of a group, so the private pins of its batches are received
+func (pm *privateMessaging) EnsurePrivacyGroup(ctx context.Context, ns string, group *fftypes.Bytes32) error {
+	_, err := pm.batchpin.EnsurePrivacyGroup(ctx, ns, group)
+	return err
 }
 
 func (pm *privateMessaging) transferBlobs(ctx context.Context, data []*fftypes.Data, txid *fftypes.UUID, node *fftypes.Node) error {
@@ -202,10 +302,15 @@ func (pm *privateMessaging) transferBlobs(ctx context.Context, data []*fftypes.D
 	return nil
 }
 
-func (pm *privateMessaging) sendData(ctx context.Context, tw *fftypes.TransportWrapper, nodes []*fftypes.Node) (err error) {
+func (pm *privateMessaging) sendData(ctx context.Context, batch *fftypes.Batch, tw *fftypes.TransportWrapper, nodes []*fftypes.Node) (err error) {
 	l := log.L(ctx)
-	batch := tw.Batch
 
+	// Every private batch, and the private data of every broadcast, is sent here - so this is where read-only mode is enforced
+	if pm.readOnly {
+		return i18n.NewError(ctx, i18n.MsgNodeReadOnly)
+	}
+
+	tw.Sequence = antireplay.NextSequence()
 	payload, err := json.Marshal(tw)
 	if err != nil {
 		return i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
@@ -217,15 +322,29 @@ func (pm *privateMessaging) sendData(ctx context.Context, tw *fftypes.TransportW
 		return err
 	}
 
-	// Write it to the dataexchange for each member
+	remoteNodes := make([]*fftypes.Node, 0, len(nodes))
 	for i, node := range nodes {
-
 		if node.Owner == localOrgSigingKey {
 			l.Debugf("Skipping send of batch for local node %s:%s for group=%s node=%s (%d/%d)", batch.Namespace, batch.ID, batch.Group, node.ID, i+1, len(nodes))
 			continue
 		}
+		remoteNodes = append(remoteNodes, node)
+	}
+
+	// Blobs are transferred separately, so are refused rather than sent outside of the encryption
+	if pm.keyWrapper != nil {
+		if err = pm.checkNoPrivateBlobs(ctx, batch.Payload.Data); err != nil {
+			return err
+		}
+		if payload, err = pm.encryptTransport(ctx, batch.Group, payload, remoteNodes); err != nil {
+			return err
+		}
+	}
+
+	// Write it to the dataexchange for each member
+	for i, node := range remoteNodes {
 
-		l.Debugf("Sending batch %s:%s to group=%s node=%s (%d/%d)", batch.Namespace, batch.ID, batch.Group, node.ID, i+1, len(nodes))
+		l.Debugf("Sending batch %s:%s to group=%s node=%s (%d/%d)", batch.Namespace, batch.ID, batch.Group, node.ID, i+1, len(remoteNodes))
 
 		// Initiate transfer of any blobs first
 		if err = pm.transferBlobs(ctx, batch.Payload.Data, batch.Payload.TX.ID, node); err != nil {
@@ -238,7 +357,7 @@ func (pm *privateMessaging) sendData(ctx context.Context, tw *fftypes.TransportW
 			batch.Payload.TX.ID,
 			fftypes.OpTypeDataExchangeBatchSend)
 		op.Input = fftypes.JSONObject{
-			"manifest": tw.Batch.Manifest().String(),
+			"manifest": batch.Manifest().String(),
 		}
 		if err = pm.database.InsertOperation(ctx, op); err != nil {
 			return err
@@ -18,6 +18,7 @@ package privatemessaging
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -44,6 +45,7 @@ func newTestPrivateMessagingCommon(t *testing.T, metricsEnabled bool) (*privateM
 
 	mdi := &databasemocks.Plugin{}
 	mim := &identitymanagermocks.Manager{}
+	mim.On("VerifyCryptoPolicy", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe() // no namespace policies by default
 	mdx := &dataexchangemocks.Plugin{}
 	mbi := &blockchainmocks.Plugin{}
 	mba := &batchmocks.Manager{}
@@ -162,7 +164,11 @@ func TestDispatchBatchWithBlobs(t *testing.T) {
 		return op.Type == fftypes.OpTypeDataExchangeBlobSend
 	})).Return(nil, nil)
 
-	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything, mock.MatchedBy(func(payload []byte) bool {
+		var tw fftypes.TransportWrapper
+		_ = json.Unmarshal(payload, &tw)
+		return tw.Sequence > 0
+	})).Return(nil).Once()
 	mdi.On("InsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
 		return op.Type == fftypes.OpTypeDataExchangeBatchSend
 	})).Return(nil, nil)
@@ -196,6 +202,96 @@ func TestDispatchBatchWithBlobs(t *testing.T) {
 	mdx.AssertExpectations(t)
 }
 
+func TestDispatchPinnedBatchPrivateTXSendsGroup(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	groupID := fftypes.NewRandB32()
+	node1 := fftypes.NewUUID()
+	node2 := fftypes.NewUUID()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mbp := pm.batchpin.(*batchpinmocks.Submitter)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+
+	mim.On("GetLocalOrgKey", pm.ctx).Return("localorg", nil)
+	mdi.On("GetGroupByHash", pm.ctx, groupID).Return(&fftypes.Group{
+		Hash: groupID,
+		GroupIdentity: fftypes.GroupIdentity{
+			Name: "group1",
+			Members: fftypes.Members{
+				{Identity: "org1", Node: node1},
+				{Identity: "org2", Node: node2},
+			},
+		},
+	}, nil)
+	mdi.On("GetNodeByID", pm.ctx, node1).Return(&fftypes.Node{ID: node1, Owner: "localorg"}, nil)
+	mdi.On("GetNodeByID", pm.ctx, node2).Return(&fftypes.Node{ID: node2, DX: fftypes.DXInfo{Peer: "node2"}}, nil)
+	mbp.On("EnsurePrivacyGroup", pm.ctx, "ns1", groupID).Return(true, nil)
+	mdi.On("InsertOperation", pm.ctx, mock.Anything).Return(nil)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, "node2", mock.MatchedBy(func(payload []byte) bool {
+		var tw fftypes.TransportWrapper
+		_ = json.Unmarshal(payload, &tw)
+		return tw.Group != nil && tw.Group.Hash.Equals(groupID)
+	})).Return(nil)
+	mbp.On("SubmitPinnedBatch", pm.ctx, mock.Anything, mock.Anything).Return(nil)
+
+	err := pm.dispatchPinnedBatch(pm.ctx, &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Group:     groupID,
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID:   fftypes.NewUUID(),
+				Type: fftypes.TransactionTypeBatchPin,
+			},
+		},
+	}, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbp.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestDispatchPinnedBatchEnsurePrivacyGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	groupID := fftypes.NewRandB32()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mbp := pm.batchpin.(*batchpinmocks.Submitter)
+	mdi.On("GetGroupByHash", pm.ctx, groupID).Return(&fftypes.Group{Hash: groupID}, nil)
+	mbp.On("EnsurePrivacyGroup", pm.ctx, "ns1", groupID).Return(false, fmt.Errorf("pop"))
+
+	err := pm.dispatchPinnedBatch(pm.ctx, &fftypes.Batch{
+		Group:     groupID,
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+			},
+		},
+	}, []*fftypes.Bytes32{})
+	assert.Regexp(t, "pop", err)
+
+	mbp.AssertNotCalled(t, "SubmitPinnedBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEnsurePrivacyGroup(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	groupID := fftypes.NewRandB32()
+	mbp := pm.batchpin.(*batchpinmocks.Submitter)
+	mbp.On("EnsurePrivacyGroup", pm.ctx, "ns1", groupID).Return(true, fmt.Errorf("pop"))
+
+	err := pm.EnsurePrivacyGroup(pm.ctx, "ns1", groupID)
+	assert.Regexp(t, "pop", err)
+}
+
 func TestNewPrivateMessagingMissingDeps(t *testing.T) {
 	_, err := NewPrivateMessaging(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
@@ -295,6 +391,19 @@ func TestSendImmediateFail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 }
 
+func TestSendDataReadOnly(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+	pm.readOnly = true
+
+	batch := &fftypes.Batch{ID: fftypes.NewUUID()}
+	err := pm.sendData(pm.ctx, batch, &fftypes.TransportWrapper{Batch: batch}, []*fftypes.Node{{Owner: "org2", Name: "node2"}})
+	assert.Regexp(t, "FF10358", err)
+
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdx.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestSendSubmitInsertOperationFail(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
@@ -421,3 +530,136 @@ func TestStart(t *testing.T) {
 	err := pm.Start()
 	assert.NoError(t, err)
 }
+
+func TestSendPrivateData(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group1 := fftypes.NewRandB32()
+	group2 := fftypes.NewRandB32()
+	node1 := fftypes.NewUUID()
+	node2 := fftypes.NewUUID()
+	data1 := &fftypes.Data{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Value: fftypes.JSONAnyPtr(`"one"`), Group: group1}
+	data2 := &fftypes.Data{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Value: fftypes.JSONAnyPtr(`"two"`), Group: group2}
+	data3 := &fftypes.Data{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Value: fftypes.JSONAnyPtr(`"three"`), Group: group1}
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrgKey", pm.ctx).Return("localorg", nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", pm.ctx, data1.ID, true).Return(data1, nil)
+	mdi.On("GetDataByID", pm.ctx, data2.ID, true).Return(data2, nil)
+	mdi.On("GetDataByID", pm.ctx, data3.ID, true).Return(data3, nil)
+	mdi.On("GetGroupByHash", pm.ctx, group1).Return(&fftypes.Group{
+		Hash:          group1,
+		GroupIdentity: fftypes.GroupIdentity{Members: fftypes.Members{{Identity: "org1", Node: node1}}},
+	}, nil)
+	mdi.On("GetGroupByHash", pm.ctx, group2).Return(&fftypes.Group{
+		Hash:          group2,
+		GroupIdentity: fftypes.GroupIdentity{Members: fftypes.Members{{Identity: "org2", Node: node2}}},
+	}, nil)
+	mdi.On("GetNodeByID", pm.ctx, node1).Return(&fftypes.Node{ID: node1, Owner: "org1", DX: fftypes.DXInfo{Peer: "peer1"}}, nil)
+	mdi.On("GetNodeByID", pm.ctx, node2).Return(&fftypes.Node{ID: node2, Owner: "org2", DX: fftypes.DXInfo{Peer: "peer2"}}, nil)
+	mdi.On("InsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeDataExchangeBatchSend
+	})).Return(nil)
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{ID: fftypes.NewUUID()},
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID()},
+				{ID: data1.ID, Group: group1},
+				{ID: data2.ID, Group: group2},
+				{ID: data3.ID, Group: group1},
+			},
+		},
+	}
+
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, "peer1", mock.MatchedBy(func(payload []byte) bool {
+		var tw fftypes.TransportWrapper
+		_ = json.Unmarshal(payload, &tw)
+		return tw.Batch == nil && tw.PrivateData.Batch.Equals(batch.ID) && tw.PrivateData.Group.Equals(group1) &&
+			len(tw.PrivateData.Data) == 2 && tw.PrivateData.Data[1].Value.String() == `"three"`
+	})).Return(nil).Once()
+	mdx.On("SendMessage", pm.ctx, mock.Anything, "peer2", mock.MatchedBy(func(payload []byte) bool {
+		var tw fftypes.TransportWrapper
+		_ = json.Unmarshal(payload, &tw)
+		return tw.PrivateData.Group.Equals(group2) && len(tw.PrivateData.Data) == 1
+	})).Return(nil).Once()
+
+	err := pm.SendPrivateData(pm.ctx, batch)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestSendPrivateDataLookupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", pm.ctx, mock.Anything, true).Return(nil, fmt.Errorf("pop"))
+
+	err := pm.SendPrivateData(pm.ctx, &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{{ID: fftypes.NewUUID(), Group: fftypes.NewRandB32()}},
+		},
+	})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestSendPrivateDataValueNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	groupHash := fftypes.NewRandB32()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", pm.ctx, mock.Anything, true).Return(&fftypes.Data{Group: groupHash}, nil)
+
+	err := pm.SendPrivateData(pm.ctx, &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{{ID: fftypes.NewUUID(), Group: groupHash}},
+		},
+	})
+	assert.Regexp(t, "FF10133", err)
+}
+
+func TestSendPrivateDataGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	groupHash := fftypes.NewRandB32()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", pm.ctx, mock.Anything, true).Return(&fftypes.Data{Group: groupHash, Value: fftypes.JSONAnyPtr(`{}`)}, nil)
+	mdi.On("GetGroupByHash", pm.ctx, groupHash).Return(nil, fmt.Errorf("pop"))
+
+	err := pm.SendPrivateData(pm.ctx, &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{{ID: fftypes.NewUUID(), Group: groupHash}},
+		},
+	})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestSendPrivateDataSendFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	groupHash := fftypes.NewRandB32()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", pm.ctx, mock.Anything, true).Return(&fftypes.Data{Group: groupHash, Value: fftypes.JSONAnyPtr(`{}`)}, nil)
+	mdi.On("GetGroupByHash", pm.ctx, groupHash).Return(&fftypes.Group{Hash: groupHash}, nil)
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrgKey", pm.ctx).Return("", fmt.Errorf("pop"))
+
+	err := pm.SendPrivateData(pm.ctx, &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{{ID: fftypes.NewUUID(), Group: groupHash}},
+		},
+	})
+	assert.Regexp(t, "pop", err)
+}
@@ -37,18 +37,20 @@ func (pm *privateMessaging) resolveRecipientList(ctx context.Context, in *fftype
 		if group == nil {
 			return i18n.NewError(ctx, i18n.MsgGroupNotFound, in.Header.Group)
 		}
-		// We have a group already resolved
+		// We have a group already resolved - the message is sent to the current version of the group
+		in.Message.Header.GroupVersion = group.Version
 		return nil
 	}
 	if in.Group == nil || len(in.Group.Members) == 0 {
 		return i18n.NewError(ctx, i18n.MsgGroupMustHaveMembers)
 	}
-	group, isNew, err := pm.findOrGenerateGroup(ctx, in)
+	group, isNew, err := pm.findOrGenerateGroup(ctx, in.Header.Namespace, in.Group)
 	if err != nil {
 		return err
 	}
 	log.L(ctx).Debugf("Resolved group '%s' for message. New=%t", group.Hash, isNew)
 	in.Message.Header.Group = group.Hash
+	in.Message.Header.GroupVersion = group.Version
 
 	// If the group is new, we need to do a group initialization, before we send the message itself.
 	if isNew {
@@ -114,7 +116,7 @@ func (pm *privateMessaging) resolveNode(ctx context.Context, org *fftypes.Organi
 	return node, nil
 }
 
-func (pm *privateMessaging) getRecipients(ctx context.Context, in *fftypes.MessageInOut) (gi *fftypes.GroupIdentity, err error) {
+func (pm *privateMessaging) getRecipients(ctx context.Context, ns string, in *fftypes.InputGroup) (gi *fftypes.GroupIdentity, err error) {
 
 	localOrgDID, err := pm.identity.ResolveLocalOrgDID(ctx)
 	if err != nil {
@@ -128,12 +130,12 @@ func (pm *privateMessaging) getRecipients(ctx context.Context, in *fftypes.Messa
 
 	foundLocal := false
 	gi = &fftypes.GroupIdentity{
-		Namespace: in.Message.Header.Namespace,
-		Name:      in.Group.Name,
-		Ledger:    in.Group.Ledger,
-		Members:   make(fftypes.Members, len(in.Group.Members)),
+		Namespace: ns,
+		Name:      in.Name,
+		Ledger:    in.Ledger,
+		Members:   make(fftypes.Members, len(in.Members)),
 	}
-	for i, rInput := range in.Group.Members {
+	for i, rInput := range in.Members {
 		// Resolve the org
 		org, err := pm.resolveOrg(ctx, rInput.Identity)
 		if err != nil {
@@ -184,8 +186,8 @@ func (pm *privateMessaging) resolveLocalNode(ctx context.Context, localOrgSignin
 	return pm.localNodeID, nil
 }
 
-func (pm *privateMessaging) findOrGenerateGroup(ctx context.Context, in *fftypes.MessageInOut) (group *fftypes.Group, isNew bool, err error) {
-	gi, err := pm.getRecipients(ctx, in)
+func (pm *privateMessaging) findOrGenerateGroup(ctx context.Context, ns string, in *fftypes.InputGroup) (group *fftypes.Group, isNew bool, err error) {
+	gi, err := pm.getRecipients(ctx, ns, in)
 	if err != nil {
 		return nil, false, err
 	}
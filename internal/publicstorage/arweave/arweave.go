@@ -0,0 +1,388 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arweave
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+)
+
+// Arweave stores each payload permanently in its own Arweave transaction, signed and paid for by the configured wallet.
+// The transaction ID is returned as the payload reference. PinData tracks the transaction until it is confirmed.
+type Arweave struct {
+	ctx            context.Context
+	capabilities   *publicstorage.Capabilities
+	callbacks      publicstorage.Callbacks
+	client         *resty.Client
+	wallet         *rsa.PrivateKey
+	maxReward      *big.Int
+	confirmations  int
+	pollInterval   time.Duration
+	confirmTimeout time.Duration
+	rewardsMux     sync.Mutex
+	rewards        map[string]string
+}
+
+// All Arweave wallets use the standard RSA public exponent, so only the modulus is included in the transaction
+const arweavePublicExponent = 65537
+
+type arweaveTag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type arweaveTx struct {
+	Format    int           `json:"format"`
+	ID        string        `json:"id"`
+	LastTx    string        `json:"last_tx"`
+	Owner     string        `json:"owner"`
+	Tags      []*arweaveTag `json:"tags"`
+	Target    string        `json:"target"`
+	Quantity  string        `json:"quantity"`
+	Data      string        `json:"data"`
+	DataSize  string        `json:"data_size"`
+	DataRoot  string        `json:"data_root"`
+	Reward    string        `json:"reward"`
+	Signature string        `json:"signature"`
+}
+
+type arweaveTxStatus struct {
+	BlockHeight           int64  `json:"block_height"`
+	BlockIndepHash        string `json:"block_indep_hash"`
+	NumberOfConfirmations int    `json:"number_of_confirmations"`
+}
+
+func (a *Arweave) Name() string {
+	return "arweave"
+}
+
+func (a *Arweave) Init(ctx context.Context, prefix config.Prefix, callbacks publicstorage.Callbacks) (err error) {
+
+	a.ctx = log.WithLogField(log.WithModule(ctx, "publicstorage"), "publicstorage", "arweave")
+	a.callbacks = callbacks
+
+	if prefix.GetString(restclient.HTTPConfigURL) == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(restclient.HTTPConfigURL), "arweave")
+	}
+	walletFile := prefix.GetString(ArweaveConfWallet)
+	if walletFile == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(ArweaveConfWallet), "arweave")
+	}
+	if a.wallet, err = loadWallet(ctx, walletFile); err != nil {
+		return err
+	}
+	if maxReward := prefix.GetString(ArweaveConfMaxReward); maxReward != "" {
+		var ok bool
+		if a.maxReward, ok = new(big.Int).SetString(maxReward, 10); !ok {
+			return i18n.NewError(ctx, i18n.MsgArweaveInvalidMaxReward, maxReward)
+		}
+	}
+	a.client = restclient.New(a.ctx, prefix)
+	a.confirmations = prefix.GetInt(ArweaveConfConfirmations)
+	a.pollInterval = prefix.GetDuration(ArweaveConfPollInterval)
+	a.confirmTimeout = prefix.GetDuration(ArweaveConfConfirmationTimeout)
+	a.rewards = make(map[string]string)
+	a.capabilities = &publicstorage.Capabilities{
+		Pinning: true,
+	}
+	return nil
+}
+
+func (a *Arweave) Capabilities() *publicstorage.Capabilities {
+	return a.capabilities
+}
+
+func (a *Arweave) getText(ctx context.Context, path string) (string, error) {
+	res, err := a.client.R().
+		SetContext(ctx).
+		Get(path)
+	if err != nil || !res.IsSuccess() {
+		return "", restclient.WrapRestErr(a.ctx, res, err, i18n.MsgArweaveRESTErr)
+	}
+	return strings.TrimSpace(res.String()), nil
+}
+
+// estimateReward returns the reward in winston required to store the supplied number of bytes
+func (a *Arweave) estimateReward(ctx context.Context, size int) (string, error) {
+	reward, err := a.getText(ctx, fmt.Sprintf("/price/%d", size))
+	if err != nil {
+		return "", err
+	}
+	rewardInt, ok := new(big.Int).SetString(reward, 10)
+	if !ok {
+		return "", i18n.NewError(ctx, i18n.MsgArweaveRESTErr, fmt.Sprintf("invalid price '%s'", reward))
+	}
+	if a.maxReward != nil && rewardInt.Cmp(a.maxReward) > 0 {
+		return "", i18n.NewError(ctx, i18n.MsgArweaveRewardTooHigh, reward, size, a.maxReward.String())
+	}
+	return reward, nil
+}
+
+// signatureData returns the deep hash of the fields of a format 2 transaction, which is the data that is signed
+func (tx *arweaveTx) signatureData() ([]byte, error) {
+	fields := make([][]byte, 4)
+	for i, s := range []string{tx.Owner, tx.Target, tx.LastTx, tx.DataRoot} {
+		b, err := b64url.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = b
+	}
+	tags := make([]interface{}, len(tx.Tags))
+	for i, tag := range tx.Tags {
+		name, _ := b64url.DecodeString(tag.Name)
+		value, _ := b64url.DecodeString(tag.Value)
+		tags[i] = []interface{}{name, value}
+	}
+	return deepHash([]interface{}{
+		[]byte(strconv.Itoa(tx.Format)),
+		fields[0], // owner
+		fields[1], // target
+		[]byte(tx.Quantity),
+		[]byte(tx.Reward),
+		fields[2], // last_tx
+		tags,
+		[]byte(tx.DataSize),
+		fields[3], // data_root
+	}), nil
+}
+
+// signTransaction completes a format 2 transaction, by calculating the signature and the ID
+func (a *Arweave) signTransaction(tx *arweaveTx, data []byte) error {
+	tx.DataRoot = b64url.EncodeToString(dataRoot(data))
+	signatureData, err := tx.signatureData()
+	if err != nil {
+		return err
+	}
+	digest := sha256Of(signatureData)
+	signature, err := rsa.SignPSS(rand.Reader, a.wallet, crypto.SHA256, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	if err != nil {
+		return err
+	}
+	tx.Signature = b64url.EncodeToString(signature)
+	tx.ID = b64url.EncodeToString(sha256Of(signature))
+	return nil
+}
+
+// verifyTransaction checks retrieved data against the signed headers of the transaction that stored it.
+// The transaction ID is the hash of the signature, and the signature covers the data root, so a gateway
+// cannot substitute different data for a transaction ID without also forging the owner's signature.
+func (a *Arweave) verifyTransaction(ctx context.Context, payloadRef string, payload []byte) error {
+	var tx arweaveTx
+	res, err := a.client.R().
+		SetContext(ctx).
+		SetResult(&tx).
+		Get(fmt.Sprintf("/tx/%s", payloadRef))
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(a.ctx, res, err, i18n.MsgArweaveRESTErr)
+	}
+	signature, err := b64url.DecodeString(tx.Signature)
+	if err != nil || b64url.EncodeToString(sha256Of(signature)) != payloadRef {
+		return i18n.NewError(ctx, i18n.MsgPublicStorageHashMismatch, a.Name(), payloadRef)
+	}
+	signatureData, err := tx.signatureData()
+	if err != nil {
+		return i18n.NewError(ctx, i18n.MsgPublicStorageHashMismatch, a.Name(), payloadRef)
+	}
+	owner, _ := decodeBigInt(tx.Owner)
+	pubKey := &rsa.PublicKey{N: owner, E: arweavePublicExponent}
+	if err := rsa.VerifyPSS(pubKey, crypto.SHA256, sha256Of(signatureData), signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}); err != nil {
+		return i18n.NewError(ctx, i18n.MsgPublicStorageHashMismatch, a.Name(), payloadRef)
+	}
+	if tx.DataRoot != b64url.EncodeToString(dataRoot(payload)) || tx.DataSize != strconv.Itoa(len(payload)) {
+		return i18n.NewError(ctx, i18n.MsgPublicStorageHashMismatch, a.Name(), payloadRef)
+	}
+	return nil
+}
+
+func (a *Arweave) PublishData(ctx context.Context, data io.Reader) (string, error) {
+	payload, err := ioutil.ReadAll(data)
+	if err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgArweaveRESTErr, err)
+	}
+	reward, err := a.estimateReward(ctx, len(payload))
+	if err != nil {
+		return "", err
+	}
+	anchor, err := a.getText(ctx, "/tx_anchor")
+	if err != nil {
+		return "", err
+	}
+
+	tx := &arweaveTx{
+		Format: 2,
+		LastTx: anchor,
+		Owner:  b64url.EncodeToString(a.wallet.N.Bytes()),
+		Tags: []*arweaveTag{
+			{Name: b64url.EncodeToString([]byte("Content-Type")), Value: b64url.EncodeToString([]byte("application/json"))},
+		},
+		Quantity: "0",
+		Data:     b64url.EncodeToString(payload),
+		DataSize: strconv.Itoa(len(payload)),
+		Reward:   reward,
+	}
+	if err := a.signTransaction(tx, payload); err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgArweaveRESTErr, err)
+	}
+
+	res, err := a.client.R().
+		SetContext(ctx).
+		SetBody(tx).
+		Post("/tx")
+	if err != nil || !res.IsSuccess() {
+		return "", restclient.WrapRestErr(a.ctx, res, err, i18n.MsgArweaveRESTErr)
+	}
+	log.L(ctx).Infof("Arweave published %s Size=%d Reward=%s", tx.ID, len(payload), reward)
+
+	// Keep the reward, so it can be recorded against the operation that tracks the transaction
+	a.rewardsMux.Lock()
+	a.rewards[tx.ID] = reward
+	a.rewardsMux.Unlock()
+	return tx.ID, nil
+}
+
+func (a *Arweave) RetrieveData(ctx context.Context, payloadRef string) (data io.ReadCloser, err error) {
+	res, err := a.client.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		Get(fmt.Sprintf("/%s", payloadRef))
+	restclient.OnAfterResponse(a.client, res) // required using SetDoNotParseResponse
+	if err != nil || !res.IsSuccess() {
+		if res != nil && res.RawBody() != nil {
+			_ = res.RawBody().Close()
+		}
+		return nil, restclient.WrapRestErr(a.ctx, res, err, i18n.MsgArweaveRESTErr)
+	}
+	defer res.RawBody().Close()
+	payload, err := ioutil.ReadAll(res.RawBody())
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgArweaveRESTErr, err)
+	}
+	if err := a.verifyTransaction(ctx, payloadRef, payload); err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Arweave retrieved %s", payloadRef)
+	return ioutil.NopCloser(bytes.NewReader(payload)), nil
+}
+
+// PinData tracks the transaction that stored the data, until it has the configured number of confirmations
+func (a *Arweave) PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) error {
+	a.rewardsMux.Lock()
+	reward := a.rewards[payloadRef]
+	delete(a.rewards, payloadRef)
+	a.rewardsMux.Unlock()
+
+	go a.trackTransaction(operationID, payloadRef, reward)
+	return nil
+}
+
+// Ping checks the gateway is available, by requesting the information about the network it serves
+func (a *Arweave) Ping(ctx context.Context) error {
+	res, err := a.client.R().
+		SetContext(ctx).
+		Get("/info")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(a.ctx, res, err, i18n.MsgArweaveRESTErr)
+	}
+	return nil
+}
+
+// getTxStatus returns nil if the transaction is pending, or not yet known to the node
+func (a *Arweave) getTxStatus(txID string) (*arweaveTxStatus, error) {
+	var status arweaveTxStatus
+	res, err := a.client.R().
+		SetContext(a.ctx).
+		SetResult(&status).
+		Get(fmt.Sprintf("/tx/%s/status", txID))
+	switch {
+	case err == nil && res.StatusCode() == http.StatusOK:
+		return &status, nil
+	case err == nil && (res.StatusCode() == http.StatusAccepted || res.StatusCode() == http.StatusNotFound):
+		return nil, nil
+	default:
+		return nil, restclient.WrapRestErr(a.ctx, res, err, i18n.MsgArweaveRESTErr)
+	}
+}
+
+func (a *Arweave) trackTransaction(operationID *fftypes.UUID, txID, reward string) {
+	l := log.L(a.ctx)
+	timeout := time.After(a.confirmTimeout)
+	for {
+		status, err := a.getTxStatus(txID)
+		switch {
+		case err != nil:
+			// Transient errors querying the status should not fail the operation, so we just try again next time
+			l.Warnf("Failed to query Arweave transaction status for %s: %s", txID, err)
+		case status != nil && status.NumberOfConfirmations >= a.confirmations:
+			l.Infof("Arweave transaction %s confirmed in block %d", txID, status.BlockHeight)
+			a.trackComplete(operationID, txID, reward, status, fftypes.OpStatusSucceeded, "")
+			return
+		case status != nil:
+			l.Debugf("Arweave transaction %s has %d/%d confirmations", txID, status.NumberOfConfirmations, a.confirmations)
+		}
+		select {
+		case <-a.ctx.Done():
+			l.Debugf("Stopped tracking Arweave transaction %s", txID)
+			return
+		case <-timeout:
+			errorMessage := i18n.NewError(a.ctx, i18n.MsgArweaveNotConfirmed, txID, a.confirmations, a.confirmTimeout).Error()
+			l.Errorf("%s", errorMessage)
+			a.trackComplete(operationID, txID, reward, status, fftypes.OpStatusFailed, errorMessage)
+			return
+		case <-time.After(a.pollInterval):
+		}
+	}
+}
+
+func (a *Arweave) trackComplete(operationID *fftypes.UUID, txID, reward string, status *arweaveTxStatus, txState fftypes.OpStatus, errorMessage string) {
+	opOutput := fftypes.JSONObject{
+		"txid": txID,
+	}
+	if reward != "" {
+		opOutput["reward"] = reward
+	}
+	if status != nil {
+		var statusJSON fftypes.JSONObject
+		b, _ := json.Marshal(status)
+		_ = json.Unmarshal(b, &statusJSON)
+		opOutput["status"] = statusJSON
+	}
+	if err := a.callbacks.PublicStorageOpUpdate(a, operationID, txState, errorMessage, opOutput); err != nil {
+		log.L(a.ctx).Errorf("Failed to update transaction operation %s: %s", operationID, err)
+	}
+}
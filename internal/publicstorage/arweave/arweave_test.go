@@ -0,0 +1,571 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arweave
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var utConfPrefix = config.NewPluginConfig("arweave_unit_tests")
+
+var testKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+func b64Int(i *big.Int) string {
+	return b64url.EncodeToString(i.Bytes())
+}
+
+func writeTestWallet(t *testing.T, key *rsa.PrivateKey) string {
+	b, _ := json.Marshal(&jwk{
+		N: b64Int(key.N),
+		E: b64Int(big.NewInt(int64(key.E))),
+		D: b64Int(key.D),
+		P: b64Int(key.Primes[0]),
+		Q: b64Int(key.Primes[1]),
+	})
+	walletFile := filepath.Join(t.TempDir(), "wallet.json")
+	err := ioutil.WriteFile(walletFile, b, 0600)
+	assert.NoError(t, err)
+	return walletFile
+}
+
+func resetConf() {
+	config.Reset()
+	a := &Arweave{}
+	a.InitPrefix(utConfPrefix)
+}
+
+func newTestArweave(t *testing.T) (*Arweave, *publicstoragemocks.Callbacks, func()) {
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+
+	resetConf()
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(restclient.HTTPCustomClient, mockedClient)
+	utConfPrefix.Set(ArweaveConfWallet, writeTestWallet(t, testKey))
+	utConfPrefix.Set(ArweaveConfMaxReward, "1000000")
+	utConfPrefix.Set(ArweaveConfConfirmations, 2)
+	utConfPrefix.Set(ArweaveConfPollInterval, "1ms")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mcb := &publicstoragemocks.Callbacks{}
+	a := &Arweave{}
+	err := a.Init(ctx, utConfPrefix, mcb)
+	assert.NoError(t, err)
+	return a, mcb, func() {
+		cancel()
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestInitMissingURL(t *testing.T) {
+	a := &Arweave{}
+	resetConf()
+	err := a.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestInitMissingWallet(t *testing.T) {
+	a := &Arweave{}
+	resetConf()
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	err := a.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*wallet", err)
+}
+
+func TestInitWalletNotFound(t *testing.T) {
+	a := &Arweave{}
+	resetConf()
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(ArweaveConfWallet, filepath.Join(t.TempDir(), "missing.json"))
+	err := a.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10383", err)
+}
+
+func TestInitWalletBadJSON(t *testing.T) {
+	a := &Arweave{}
+	resetConf()
+	walletFile := filepath.Join(t.TempDir(), "wallet.json")
+	ioutil.WriteFile(walletFile, []byte("!json"), 0600)
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(ArweaveConfWallet, walletFile)
+	err := a.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10383", err)
+}
+
+func TestInitWalletBadBase64(t *testing.T) {
+	a := &Arweave{}
+	resetConf()
+	walletFile := filepath.Join(t.TempDir(), "wallet.json")
+	ioutil.WriteFile(walletFile, []byte(`{"n":"!!!"}`), 0600)
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(ArweaveConfWallet, walletFile)
+	err := a.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10383", err)
+}
+
+func TestInitWalletInvalidKey(t *testing.T) {
+	a := &Arweave{}
+	resetConf()
+	walletFile := filepath.Join(t.TempDir(), "wallet.json")
+	ioutil.WriteFile(walletFile, []byte(`{"n":"AQ","e":"AQAB","d":"AQ","p":"Aw","q":"BQ"}`), 0600)
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(ArweaveConfWallet, walletFile)
+	err := a.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10383", err)
+}
+
+func TestInitBadMaxReward(t *testing.T) {
+	a := &Arweave{}
+	resetConf()
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(ArweaveConfWallet, writeTestWallet(t, testKey))
+	utConfPrefix.Set(ArweaveConfMaxReward, "lots")
+	err := a.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10385", err)
+}
+
+func TestInit(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+	assert.Equal(t, "arweave", a.Name())
+	assert.True(t, a.Capabilities().Pinning)
+	assert.Equal(t, testKey.N, a.wallet.N)
+	assert.Equal(t, int64(1000000), a.maxReward.Int64())
+}
+
+func TestPublishDataSuccess(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	anchor := b64url.EncodeToString([]byte("anchor"))
+	data := []byte(`{"hello":"world"}`)
+	httpmock.RegisterResponder("GET", fmt.Sprintf("http://localhost:12345/price/%d", len(data)),
+		httpmock.NewStringResponder(200, "12345"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx_anchor",
+		httpmock.NewStringResponder(200, anchor))
+	var tx arweaveTx
+	httpmock.RegisterResponder("POST", "http://localhost:12345/tx",
+		func(req *http.Request) (*http.Response, error) {
+			err := json.NewDecoder(req.Body).Decode(&tx)
+			assert.NoError(t, err)
+			return httpmock.NewStringResponse(200, "OK"), nil
+		})
+
+	payloadRef, err := a.PublishData(context.Background(), bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, tx.ID, payloadRef)
+	assert.Equal(t, 2, tx.Format)
+	assert.Equal(t, anchor, tx.LastTx)
+	assert.Equal(t, "12345", tx.Reward)
+	assert.Equal(t, "0", tx.Quantity)
+	assert.Equal(t, strconv.Itoa(len(data)), tx.DataSize)
+	assert.Equal(t, b64url.EncodeToString(data), tx.Data)
+	assert.Equal(t, b64url.EncodeToString(dataRoot(data)), tx.DataRoot)
+	assert.Equal(t, "12345", a.rewards[tx.ID])
+
+	// Verify the signature over the deep hash, and that the ID is the hash of the signature
+	signature, _ := b64url.DecodeString(tx.Signature)
+	assert.Equal(t, b64url.EncodeToString(sha256Of(signature)), tx.ID)
+	signatureData := deepHash([]interface{}{
+		[]byte("2"),
+		testKey.N.Bytes(),
+		[]byte{},
+		[]byte("0"),
+		[]byte("12345"),
+		[]byte("anchor"),
+		[]interface{}{[]interface{}{[]byte("Content-Type"), []byte("application/json")}},
+		[]byte(strconv.Itoa(len(data))),
+		dataRoot(data),
+	})
+	err = rsa.VerifyPSS(&testKey.PublicKey, crypto.SHA256, sha256Of(signatureData), signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	assert.NoError(t, err)
+}
+
+func TestPublishDataReadFail(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	_, err := a.PublishData(context.Background(), iotest.ErrReader(fmt.Errorf("pop")))
+	assert.Regexp(t, "FF10381.*pop", err)
+}
+
+func TestPublishDataPriceFail(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/price/5",
+		httpmock.NewStringResponder(500, "pop"))
+
+	_, err := a.PublishData(context.Background(), bytes.NewReader([]byte("hello")))
+	assert.Regexp(t, "FF10381", err)
+}
+
+func TestPublishDataPriceInvalid(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/price/5",
+		httpmock.NewStringResponder(200, "lots"))
+
+	_, err := a.PublishData(context.Background(), bytes.NewReader([]byte("hello")))
+	assert.Regexp(t, "FF10381.*lots", err)
+}
+
+func TestPublishDataPriceTooHigh(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/price/5",
+		httpmock.NewStringResponder(200, "1000001"))
+
+	_, err := a.PublishData(context.Background(), bytes.NewReader([]byte("hello")))
+	assert.Regexp(t, "FF10382", err)
+}
+
+func TestPublishDataAnchorFail(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/price/5",
+		httpmock.NewStringResponder(200, "100"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx_anchor",
+		httpmock.NewErrorResponder(fmt.Errorf("pop")))
+
+	_, err := a.PublishData(context.Background(), bytes.NewReader([]byte("hello")))
+	assert.Regexp(t, "FF10381", err)
+}
+
+func TestPublishDataAnchorInvalid(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/price/5",
+		httpmock.NewStringResponder(200, "100"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx_anchor",
+		httpmock.NewStringResponder(200, "!!!"))
+
+	_, err := a.PublishData(context.Background(), bytes.NewReader([]byte("hello")))
+	assert.Regexp(t, "FF10381", err)
+}
+
+func TestPublishDataSignFail(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+	a.wallet = &rsa.PrivateKey{PublicKey: rsa.PublicKey{N: big.NewInt(1), E: 3}, D: big.NewInt(1)}
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/price/5",
+		httpmock.NewStringResponder(200, "100"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx_anchor",
+		httpmock.NewStringResponder(200, "YW5jaG9y"))
+
+	_, err := a.PublishData(context.Background(), bytes.NewReader([]byte("hello")))
+	assert.Regexp(t, "FF10381", err)
+}
+
+func TestPublishDataPostFail(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/price/5",
+		httpmock.NewStringResponder(200, "100"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx_anchor",
+		httpmock.NewStringResponder(200, "YW5jaG9y"))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/tx",
+		httpmock.NewStringResponder(400, "Transaction verification failed."))
+
+	_, err := a.PublishData(context.Background(), bytes.NewReader([]byte("hello")))
+	assert.Regexp(t, "FF10381", err)
+}
+
+func newTestSignedTx(t *testing.T, a *Arweave, data []byte) *arweaveTx {
+	tx := &arweaveTx{
+		Format:   2,
+		LastTx:   b64url.EncodeToString([]byte("anchor")),
+		Owner:    b64url.EncodeToString(a.wallet.N.Bytes()),
+		Quantity: "0",
+		DataSize: strconv.Itoa(len(data)),
+		Reward:   "12345",
+	}
+	err := a.signTransaction(tx, data)
+	assert.NoError(t, err)
+	return tx
+}
+
+func TestRetrieveDataSuccess(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	tx := newTestSignedTx(t, a, []byte("hello"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/"+tx.ID,
+		httpmock.NewStringResponder(200, "hello"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/"+tx.ID,
+		httpmock.NewJsonResponderOrPanic(200, tx))
+
+	r, err := a.RetrieveData(context.Background(), tx.ID)
+	assert.NoError(t, err)
+	defer r.Close()
+	b, _ := ioutil.ReadAll(r)
+	assert.Equal(t, "hello", string(b))
+}
+
+func TestRetrieveDataReadError(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx1",
+		httpmock.ResponderFromResponse(&http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(iotest.ErrReader(fmt.Errorf("pop"))),
+		}))
+
+	_, err := a.RetrieveData(context.Background(), "tx1")
+	assert.Regexp(t, "FF10381.*pop", err)
+}
+
+func TestRetrieveDataTxFail(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx1",
+		httpmock.NewStringResponder(200, "hello"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/tx1",
+		httpmock.NewStringResponder(404, "Not Found"))
+
+	_, err := a.RetrieveData(context.Background(), "tx1")
+	assert.Regexp(t, "FF10381", err)
+}
+
+func TestRetrieveDataWrongTx(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	tx := newTestSignedTx(t, a, []byte("hello"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx1",
+		httpmock.NewStringResponder(200, "hello"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/tx1",
+		httpmock.NewJsonResponderOrPanic(200, tx))
+
+	_, err := a.RetrieveData(context.Background(), "tx1")
+	assert.Regexp(t, "FF10387", err)
+}
+
+func TestRetrieveDataBadSignature(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	tx := newTestSignedTx(t, a, []byte("hello"))
+	tx.Signature = "!!!"
+	httpmock.RegisterResponder("GET", "http://localhost:12345/"+tx.ID,
+		httpmock.NewStringResponder(200, "hello"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/"+tx.ID,
+		httpmock.NewJsonResponderOrPanic(200, tx))
+
+	_, err := a.RetrieveData(context.Background(), tx.ID)
+	assert.Regexp(t, "FF10387", err)
+}
+
+func TestRetrieveDataBadFields(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	tx := newTestSignedTx(t, a, []byte("hello"))
+	tx.LastTx = "!!!"
+	httpmock.RegisterResponder("GET", "http://localhost:12345/"+tx.ID,
+		httpmock.NewStringResponder(200, "hello"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/"+tx.ID,
+		httpmock.NewJsonResponderOrPanic(200, tx))
+
+	_, err := a.RetrieveData(context.Background(), tx.ID)
+	assert.Regexp(t, "FF10387", err)
+}
+
+func TestRetrieveDataTamperedTx(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	// Re-point the signed headers at different data
+	tx := newTestSignedTx(t, a, []byte("hello"))
+	tx.DataRoot = b64url.EncodeToString(dataRoot([]byte("tampered")))
+	tx.DataSize = "8"
+	httpmock.RegisterResponder("GET", "http://localhost:12345/"+tx.ID,
+		httpmock.NewStringResponder(200, "tampered"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/"+tx.ID,
+		httpmock.NewJsonResponderOrPanic(200, tx))
+
+	_, err := a.RetrieveData(context.Background(), tx.ID)
+	assert.Regexp(t, "FF10387", err)
+}
+
+func TestRetrieveDataTamperedData(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	tx := newTestSignedTx(t, a, []byte("hello"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/"+tx.ID,
+		httpmock.NewStringResponder(200, "tampered"))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/"+tx.ID,
+		httpmock.NewJsonResponderOrPanic(200, tx))
+
+	_, err := a.RetrieveData(context.Background(), tx.ID)
+	assert.Regexp(t, "FF10387", err)
+}
+
+func TestRetrieveDataFail(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx1",
+		httpmock.NewStringResponder(404, "Not Found"))
+
+	_, err := a.RetrieveData(context.Background(), "tx1")
+	assert.Regexp(t, "FF10381", err)
+}
+
+func TestRetrieveDataError(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx1",
+		httpmock.NewErrorResponder(fmt.Errorf("pop")))
+
+	_, err := a.RetrieveData(context.Background(), "tx1")
+	assert.Regexp(t, "FF10381", err)
+}
+
+func TestPinDataConfirmed(t *testing.T) {
+	a, mcb, done := newTestArweave(t)
+	defer done()
+	a.rewards["tx1"] = "12345"
+
+	polls := 0
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/tx1/status",
+		func(req *http.Request) (*http.Response, error) {
+			polls++
+			switch polls {
+			case 1:
+				return httpmock.NewStringResponse(404, "Not Found"), nil
+			case 2:
+				return httpmock.NewStringResponse(202, "Pending"), nil
+			case 3:
+				return nil, fmt.Errorf("pop")
+			case 4:
+				return httpmock.NewJsonResponse(200, &arweaveTxStatus{BlockHeight: 100, NumberOfConfirmations: 1})
+			default:
+				return httpmock.NewJsonResponse(200, &arweaveTxStatus{BlockHeight: 100, BlockIndepHash: "block1", NumberOfConfirmations: 2})
+			}
+		})
+
+	opID := fftypes.NewUUID()
+	updated := make(chan struct{})
+	mcb.On("PublicStorageOpUpdate", a, opID, fftypes.OpStatusSucceeded, "", mock.MatchedBy(func(output fftypes.JSONObject) bool {
+		return output.GetString("txid") == "tx1" &&
+			output.GetString("reward") == "12345" &&
+			output.GetObject("status").GetString("block_indep_hash") == "block1"
+	})).Return(fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		close(updated)
+	})
+
+	err := a.PinData(context.Background(), opID, "tx1")
+	assert.NoError(t, err)
+	<-updated
+	assert.Empty(t, a.rewards)
+	mcb.AssertExpectations(t)
+}
+
+func TestPinDataTimeout(t *testing.T) {
+	a, mcb, done := newTestArweave(t)
+	defer done()
+	a.confirmTimeout = 10 * time.Millisecond
+	a.pollInterval = 1 * time.Second
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/tx1/status",
+		httpmock.NewJsonResponderOrPanic(200, &arweaveTxStatus{BlockHeight: 100, NumberOfConfirmations: 1}))
+
+	opID := fftypes.NewUUID()
+	updated := make(chan struct{})
+	mcb.On("PublicStorageOpUpdate", a, opID, fftypes.OpStatusFailed, mock.MatchedBy(func(errorMessage string) bool {
+		return assert.Regexp(t, "FF10384", errorMessage)
+	}), mock.MatchedBy(func(output fftypes.JSONObject) bool {
+		return output.GetString("reward") == "" && output.GetObject("status").GetInt64("number_of_confirmations") == 1
+	})).Return(nil).Run(func(args mock.Arguments) {
+		close(updated)
+	})
+
+	err := a.PinData(context.Background(), opID, "tx1")
+	assert.NoError(t, err)
+	<-updated
+	mcb.AssertExpectations(t)
+}
+
+func TestPinDataStopped(t *testing.T) {
+	a, mcb, done := newTestArweave(t)
+	a.pollInterval = 1 * time.Second
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/tx/tx1/status",
+		httpmock.NewStringResponder(202, "Pending"))
+
+	cctx, cancel := context.WithCancel(context.Background())
+	a.ctx = cctx
+	cancel()
+	a.trackTransaction(fftypes.NewUUID(), "tx1", "")
+	done()
+	mcb.AssertExpectations(t)
+}
+
+func TestPing(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/info",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"network": "arweave.N.1"}))
+
+	err := a.Ping(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestPingFail(t *testing.T) {
+	a, _, done := newTestArweave(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/info",
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := a.Ping(context.Background())
+	assert.Regexp(t, "FF10381", err)
+}
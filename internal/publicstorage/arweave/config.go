@@ -0,0 +1,44 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arweave
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+)
+
+const (
+	// ArweaveConfWallet is the path to the JWK file of the wallet that signs, and pays the reward for, each storage transaction
+	ArweaveConfWallet = "wallet"
+	// ArweaveConfMaxReward is an optional limit in winston on the reward paid for a single transaction. Uploads that would cost more fail
+	ArweaveConfMaxReward = "maxReward"
+	// ArweaveConfConfirmations is the number of confirmations after which stored data is considered permanent
+	ArweaveConfConfirmations = "confirmations"
+	// ArweaveConfPollInterval is how often to check the status of a transaction that is not yet confirmed
+	ArweaveConfPollInterval = "pollInterval"
+	// ArweaveConfConfirmationTimeout is how long to wait for a transaction to be confirmed, before marking the operation failed
+	ArweaveConfConfirmationTimeout = "confirmationTimeout"
+)
+
+func (a *Arweave) InitPrefix(prefix config.Prefix) {
+	restclient.InitPrefix(prefix)
+	prefix.AddKnownKey(ArweaveConfWallet)
+	prefix.AddKnownKey(ArweaveConfMaxReward)
+	prefix.AddKnownKey(ArweaveConfConfirmations, 10)
+	prefix.AddKnownKey(ArweaveConfPollInterval, "30s")
+	prefix.AddKnownKey(ArweaveConfConfirmationTimeout, "2h")
+}
@@ -0,0 +1,125 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arweave
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"math/big"
+	"strconv"
+)
+
+const (
+	maxChunkSize = 256 * 1024
+	minChunkSize = 32 * 1024
+	noteSize     = 32
+)
+
+// deepHash implements the Arweave deep hash algorithm, over a tree of byte slices (blobs) and lists.
+// Items must be []byte or []interface{} containing further items.
+func deepHash(item interface{}) []byte {
+	switch v := item.(type) {
+	case []interface{}:
+		tag := sha512.Sum384(append([]byte("list"), []byte(strconv.Itoa(len(v)))...))
+		acc := tag[:]
+		for _, child := range v {
+			pair := sha512.Sum384(append(append([]byte{}, acc...), deepHash(child)...))
+			acc = pair[:]
+		}
+		return acc
+	default:
+		blob := v.([]byte)
+		tag := sha512.Sum384(append([]byte("blob"), []byte(strconv.Itoa(len(blob)))...))
+		data := sha512.Sum384(blob)
+		tagged := sha512.Sum384(append(tag[:], data[:]...))
+		return tagged[:]
+	}
+}
+
+type merkleNode struct {
+	id           []byte
+	maxByteRange int
+}
+
+func sha256Concat(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+func sha256Of(b []byte) []byte {
+	h := sha256.Sum256(b)
+	return h[:]
+}
+
+// intToNote encodes an offset as a fixed size big-endian buffer, as used in the Arweave merkle tree
+func intToNote(i int) []byte {
+	note := make([]byte, noteSize)
+	big.NewInt(int64(i)).FillBytes(note)
+	return note
+}
+
+// chunkLeaves splits data into chunks in the same way as the Arweave reference client, and returns the leaf nodes.
+// Note the final chunk is always added, even if it is empty.
+func chunkLeaves(data []byte) []*merkleNode {
+	leaves := []*merkleNode{}
+	addLeaf := func(chunk []byte, maxByteRange int) {
+		leaves = append(leaves, &merkleNode{
+			id:           sha256Concat(sha256Of(sha256Of(chunk)), sha256Of(intToNote(maxByteRange))),
+			maxByteRange: maxByteRange,
+		})
+	}
+	rest := data
+	cursor := 0
+	for len(rest) >= maxChunkSize {
+		chunkSize := maxChunkSize
+		nextChunkSize := len(rest) - maxChunkSize
+		if nextChunkSize > 0 && nextChunkSize < minChunkSize {
+			// Avoid a final chunk smaller than the minimum, by splitting the remainder in two
+			chunkSize = (len(rest) + 1) / 2
+		}
+		cursor += chunkSize
+		addLeaf(rest[0:chunkSize], cursor)
+		rest = rest[chunkSize:]
+	}
+	addLeaf(rest, cursor+len(rest))
+	return leaves
+}
+
+// dataRoot calculates the root of the Arweave merkle tree over the chunks of the data
+func dataRoot(data []byte) []byte {
+	nodes := chunkLeaves(data)
+	for len(nodes) > 1 {
+		nextLayer := make([]*merkleNode, 0, (len(nodes)+1)/2)
+		for i := 0; i < len(nodes); i += 2 {
+			left := nodes[i]
+			if i+1 == len(nodes) {
+				nextLayer = append(nextLayer, left)
+				continue
+			}
+			right := nodes[i+1]
+			nextLayer = append(nextLayer, &merkleNode{
+				id:           sha256Concat(sha256Of(left.id), sha256Of(right.id), sha256Of(intToNote(left.maxByteRange))),
+				maxByteRange: right.maxByteRange,
+			})
+		}
+		nodes = nextLayer
+	}
+	return nodes[0].id
+}
@@ -0,0 +1,78 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arweave
+
+import (
+	"crypto/sha512"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeepHashBlob(t *testing.T) {
+	tag := sha512.Sum384([]byte("blob5"))
+	data := sha512.Sum384([]byte("hello"))
+	expected := sha512.Sum384(append(tag[:], data[:]...))
+	assert.Equal(t, expected[:], deepHash([]byte("hello")))
+}
+
+func TestDeepHashList(t *testing.T) {
+	tag := sha512.Sum384([]byte("list2"))
+	acc := sha512.Sum384(append(tag[:], deepHash([]byte("a"))...))
+	acc = sha512.Sum384(append(acc[:], deepHash([]interface{}{})...))
+	assert.Equal(t, acc[:], deepHash([]interface{}{[]byte("a"), []interface{}{}}))
+}
+
+func TestIntToNote(t *testing.T) {
+	note := intToNote(256*1024 + 1)
+	assert.Len(t, note, 32)
+	assert.Equal(t, []byte{0x04, 0x00, 0x01}, note[29:])
+}
+
+func TestChunkLeavesSmall(t *testing.T) {
+	leaves := chunkLeaves([]byte("hello"))
+	assert.Len(t, leaves, 1)
+	assert.Equal(t, 5, leaves[0].maxByteRange)
+	assert.Equal(t, sha256Concat(sha256Of(sha256Of([]byte("hello"))), sha256Of(intToNote(5))), leaves[0].id)
+	assert.Equal(t, leaves[0].id, dataRoot([]byte("hello")))
+}
+
+func TestChunkLeavesExactMultiple(t *testing.T) {
+	// The reference client always adds a final chunk, even if it is empty
+	leaves := chunkLeaves(make([]byte, maxChunkSize))
+	assert.Len(t, leaves, 2)
+	assert.Equal(t, maxChunkSize, leaves[0].maxByteRange)
+	assert.Equal(t, maxChunkSize, leaves[1].maxByteRange)
+}
+
+func TestChunkLeavesAvoidSmallFinalChunk(t *testing.T) {
+	size := maxChunkSize + 1001
+	leaves := chunkLeaves(make([]byte, size))
+	assert.Len(t, leaves, 2)
+	assert.Equal(t, (size+1)/2, leaves[0].maxByteRange)
+	assert.Equal(t, size, leaves[1].maxByteRange)
+}
+
+func TestDataRootOddLeaves(t *testing.T) {
+	size := 2*maxChunkSize + 100
+	data := make([]byte, size)
+	leaves := chunkLeaves(data)
+	assert.Len(t, leaves, 3)
+	branch := sha256Concat(sha256Of(leaves[0].id), sha256Of(leaves[1].id), sha256Of(intToNote(leaves[0].maxByteRange)))
+	root := sha256Concat(sha256Of(branch), sha256Of(leaves[2].id), sha256Of(intToNote(leaves[1].maxByteRange)))
+	assert.Equal(t, root, dataRoot(data))
+}
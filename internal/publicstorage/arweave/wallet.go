@@ -0,0 +1,89 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arweave
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+var b64url = base64.RawURLEncoding
+
+// jwk is the subset of fields of an RSA JSON Web Key, as used for Arweave wallet files
+type jwk struct {
+	N  string `json:"n"`
+	E  string `json:"e"`
+	D  string `json:"d"`
+	P  string `json:"p"`
+	Q  string `json:"q"`
+	DP string `json:"dp"`
+	DQ string `json:"dq"`
+	QI string `json:"qi"`
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := b64url.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func parseWallet(data []byte) (*rsa.PrivateKey, error) {
+	var key jwk
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	values := make([]*big.Int, 5)
+	for i, s := range []string{key.N, key.E, key.D, key.P, key.Q} {
+		v, err := decodeBigInt(s)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	pk := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{
+			N: values[0],
+			E: int(values[1].Int64()),
+		},
+		D:      values[2],
+		Primes: []*big.Int{values[3], values[4]},
+	}
+	if err := pk.Validate(); err != nil {
+		return nil, err
+	}
+	pk.Precompute()
+	return pk, nil
+}
+
+func loadWallet(ctx context.Context, path string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		var pk *rsa.PrivateKey
+		if pk, err = parseWallet(data); err == nil {
+			return pk, nil
+		}
+	}
+	return nil, i18n.NewError(ctx, i18n.MsgArweaveInvalidWallet, path, err)
+}
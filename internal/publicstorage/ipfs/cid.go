@@ -0,0 +1,160 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipfs
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+// The CID of data added to IPFS is the hash of the root of a UnixFS DAG built over the data. These are
+// the defaults used by IPFS when adding a file, which are the settings we use in PublishData.
+const (
+	unixfsChunkSize    = 262144
+	unixfsMaxLinks     = 174
+	unixfsTypeFile     = 2
+	multihashSHA256    = 0x12
+	base58Alphabet     = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	cidV0Prefix        = "Qm"
+	protobufTypeVarint = 0
+	protobufTypeBytes  = 2
+)
+
+type dagNode struct {
+	multihash []byte
+	fileSize  uint64 // the number of bytes of file data in this node and its children
+	dagSize   uint64 // the encoded size of this node and its children
+}
+
+type dagBuilder struct {
+	data      []byte
+	done      bool
+	chunkSize int
+	maxLinks  int
+}
+
+// cidV0 calculates the CIDv0 of data added to IPFS, using the balanced DAG layout with dag-pb leaves
+func cidV0(data []byte) string {
+	db := &dagBuilder{
+		data:      data,
+		chunkSize: unixfsChunkSize,
+		maxLinks:  unixfsMaxLinks,
+	}
+	return db.build()
+}
+
+func (db *dagBuilder) build() string {
+	root := db.newLeaf()
+	for depth := 1; !db.done; depth++ {
+		root = db.fillNode([]*dagNode{root}, depth)
+	}
+	return base58Encode(root.multihash)
+}
+
+func (db *dagBuilder) newLeaf() *dagNode {
+	chunk := db.data
+	if len(chunk) > db.chunkSize {
+		chunk = chunk[0:db.chunkSize]
+	}
+	db.data = db.data[len(chunk):]
+	db.done = len(db.data) == 0
+
+	unixfs := appendVarintField(nil, 1, unixfsTypeFile)
+	if len(chunk) > 0 {
+		unixfs = appendBytesField(unixfs, 2, chunk)
+	}
+	unixfs = appendVarintField(unixfs, 3, uint64(len(chunk)))
+	return newDAGNode(appendBytesField(nil, 1, unixfs), uint64(len(chunk)), 0)
+}
+
+func (db *dagBuilder) fillNode(children []*dagNode, depth int) *dagNode {
+	for len(children) < db.maxLinks && !db.done {
+		if depth == 1 {
+			children = append(children, db.newLeaf())
+		} else {
+			children = append(children, db.fillNode(nil, depth-1))
+		}
+	}
+
+	// Links are encoded before the data in dag-pb, and each link has an empty name
+	var pbNode []byte
+	var fileSize, childDAGSize uint64
+	for _, child := range children {
+		link := appendBytesField(nil, 1, child.multihash)
+		link = appendBytesField(link, 2, []byte{})
+		link = appendVarintField(link, 3, child.dagSize)
+		pbNode = appendBytesField(pbNode, 2, link)
+		fileSize += child.fileSize
+		childDAGSize += child.dagSize
+	}
+	unixfs := appendVarintField(nil, 1, unixfsTypeFile)
+	unixfs = appendVarintField(unixfs, 3, fileSize)
+	for _, child := range children {
+		unixfs = appendVarintField(unixfs, 4, child.fileSize)
+	}
+	pbNode = appendBytesField(pbNode, 1, unixfs)
+	return newDAGNode(pbNode, fileSize, childDAGSize)
+}
+
+func newDAGNode(pbNode []byte, fileSize, childDAGSize uint64) *dagNode {
+	hash := sha256.Sum256(pbNode)
+	return &dagNode{
+		multihash: append([]byte{multihashSHA256, sha256.Size}, hash[:]...),
+		fileSize:  fileSize,
+		dagSize:   uint64(len(pbNode)) + childDAGSize,
+	}
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	b = appendVarint(b, uint64(field<<3|protobufTypeVarint))
+	return appendVarint(b, v)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendVarint(b, uint64(field<<3|protobufTypeBytes))
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var encoded []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for _, v := range b {
+		if v != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
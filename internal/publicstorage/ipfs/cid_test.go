@@ -0,0 +1,59 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipfs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIDv0KnownValues(t *testing.T) {
+	assert.Equal(t, "QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH", cidV0([]byte{}))
+	assert.Equal(t, "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o", cidV0([]byte("hello world\n")))
+}
+
+func TestCIDv0MultipleChunks(t *testing.T) {
+	data := bytes.Repeat([]byte{0x01}, unixfsChunkSize+1)
+	db := &dagBuilder{data: data, chunkSize: unixfsChunkSize, maxLinks: unixfsMaxLinks}
+	root := db.newLeaf()
+	assert.False(t, db.done)
+	root = db.fillNode([]*dagNode{root}, 1)
+	assert.True(t, db.done)
+	assert.Equal(t, uint64(len(data)), root.fileSize)
+	assert.Greater(t, root.dagSize, uint64(len(data)))
+	assert.Equal(t, base58Encode(root.multihash), cidV0(data))
+	assert.NotEqual(t, cidV0(data[1:]), cidV0(data))
+}
+
+func TestCIDv0MultipleLevels(t *testing.T) {
+	// Use a tiny layout to exercise the DAG growing in depth
+	build := func(data []byte) *dagBuilder {
+		return &dagBuilder{data: data, chunkSize: 2, maxLinks: 2}
+	}
+	data := []byte("0123456789")
+	cid := build(data).build()
+	assert.Regexp(t, "^Qm", cid)
+	assert.Equal(t, cid, build(data).build())
+	assert.NotEqual(t, cid, build([]byte("0123456788")).build())
+}
+
+func TestBase58EncodeLeadingZeros(t *testing.T) {
+	assert.Equal(t, "11", base58Encode([]byte{0x00, 0x00}))
+	assert.Equal(t, "12", base58Encode([]byte{0x00, 0x01}))
+}
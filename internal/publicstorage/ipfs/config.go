@@ -26,9 +26,34 @@ const (
 	IPFSConfAPISubconf = "api"
 	// IPFSConfGatewaySubconf is the http configuration to connect to the Gateway endpoint of IPFS
 	IPFSConfGatewaySubconf = "gateway"
+	// IPFSConfGatewaysSubconf is an optional ordered list of http configurations for additional Gateway endpoints, to fail over to on retrieval
+	IPFSConfGatewaysSubconf = "gateways"
+	// IPFSConfGatewayFailoverSubconf configures how long a Gateway that failed a retrieval is deprioritized for
+	IPFSConfGatewayFailoverSubconf = "gatewayFailover"
+	// IPFSConfGatewayFailoverInitialDelay is how long a Gateway is deprioritized after its first consecutive failure
+	IPFSConfGatewayFailoverInitialDelay = "initialDelay"
+	// IPFSConfGatewayFailoverMaxDelay is the maximum time a Gateway is deprioritized, as the delay doubles on each consecutive failure
+	IPFSConfGatewayFailoverMaxDelay = "maxDelay"
+	// IPFSConfPinningSubconf is the optional http configuration to connect to a remote pinning service, implementing the IPFS Pinning Service API
+	IPFSConfPinningSubconf = "pinning"
+	// IPFSConfPinningPollInterval is how often to check the status of a pin request that is queued or in progress
+	IPFSConfPinningPollInterval = "pollInterval"
 )
 
 func (i *IPFS) InitPrefix(prefix config.Prefix) {
 	restclient.InitPrefix(prefix.SubPrefix(IPFSConfAPISubconf))
 	restclient.InitPrefix(prefix.SubPrefix(IPFSConfGatewaySubconf))
+	gatewaysConfig(prefix)
+	failoverPrefix := prefix.SubPrefix(IPFSConfGatewayFailoverSubconf)
+	failoverPrefix.AddKnownKey(IPFSConfGatewayFailoverInitialDelay, "5s")
+	failoverPrefix.AddKnownKey(IPFSConfGatewayFailoverMaxDelay, "2m")
+	pinningPrefix := prefix.SubPrefix(IPFSConfPinningSubconf)
+	restclient.InitPrefix(pinningPrefix)
+	pinningPrefix.AddKnownKey(IPFSConfPinningPollInterval, "5s")
+}
+
+func gatewaysConfig(prefix config.Prefix) config.PrefixArray {
+	gwArray := prefix.SubPrefix(IPFSConfGatewaysSubconf).Array()
+	restclient.InitPrefix(gwArray)
+	return gwArray
 }
@@ -17,26 +17,51 @@
 package ipfs
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-
 	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/publicstorage"
 )
 
+const (
+	ipfsPinStatusPinned = "pinned"
+	ipfsPinStatusFailed = "failed"
+)
+
 type IPFS struct {
 	ctx          context.Context
 	capabilities *publicstorage.Capabilities
 	callbacks    publicstorage.Callbacks
 	apiClient    *resty.Client
-	gwClient     *resty.Client
+	gateways     []*ipfsGateway
+	gwMux        sync.Mutex
+	gwInitDelay  time.Duration
+	gwMaxDelay   time.Duration
+	pinClient    *resty.Client
+	pinPollTime  time.Duration
+}
+
+// ipfsGateway tracks the health of each configured gateway, so that one that is failing
+// is moved to the back of the list until its delay expires
+type ipfsGateway struct {
+	url            string
+	client         *resty.Client
+	failures       int
+	unhealthyUntil time.Time
 }
 
 type ipfsUploadResponse struct {
@@ -45,13 +70,29 @@ type ipfsUploadResponse struct {
 	Size json.Number `json:"Size"`
 }
 
+type ipfsPinRequest struct {
+	CID  string `json:"cid"`
+	Name string `json:"name,omitempty"`
+}
+
+type ipfsPinStatus struct {
+	RequestID string                 `json:"requestid"`
+	Status    string                 `json:"status"`
+	Info      map[string]interface{} `json:"info,omitempty"`
+}
+
+type ipfsPinResults struct {
+	Count   int              `json:"count"`
+	Results []*ipfsPinStatus `json:"results"`
+}
+
 func (i *IPFS) Name() string {
 	return "ipfs"
 }
 
 func (i *IPFS) Init(ctx context.Context, prefix config.Prefix, callbacks publicstorage.Callbacks) error {
 
-	i.ctx = log.WithLogField(ctx, "publicstorage", "ipfs")
+	i.ctx = log.WithLogField(log.WithModule(ctx, "publicstorage"), "publicstorage", "ipfs")
 	i.callbacks = callbacks
 
 	apiPrefix := prefix.SubPrefix(IPFSConfAPISubconf)
@@ -59,12 +100,56 @@ func (i *IPFS) Init(ctx context.Context, prefix config.Prefix, callbacks publics
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, apiPrefix.Resolve(restclient.HTTPConfigURL), "ipfs")
 	}
 	i.apiClient = restclient.New(i.ctx, apiPrefix)
+	if err := i.initGateways(ctx, prefix); err != nil {
+		return err
+	}
+	config.OnPrefixReload(i.ctx, func(ctx context.Context) {
+		// The current gateways are kept if the new configuration is not valid
+		if err := i.initGateways(ctx, prefix); err != nil {
+			log.L(ctx).Errorf("Failed to reload IPFS gateways: %s", err)
+		}
+	}, prefix.SubPrefix(IPFSConfGatewaySubconf), prefix.SubPrefix(IPFSConfGatewaysSubconf), prefix.SubPrefix(IPFSConfGatewayFailoverSubconf))
+	i.capabilities = &publicstorage.Capabilities{}
+	pinningPrefix := prefix.SubPrefix(IPFSConfPinningSubconf)
+	if pinningPrefix.GetString(restclient.HTTPConfigURL) != "" {
+		i.pinClient = restclient.New(i.ctx, pinningPrefix)
+		i.pinPollTime = pinningPrefix.GetDuration(IPFSConfPinningPollInterval)
+		i.capabilities.Pinning = true
+	}
+	return nil
+}
+
+func (i *IPFS) initGateways(ctx context.Context, prefix config.Prefix) error {
+	gwPrefixes := []config.Prefix{}
 	gwPrefix := prefix.SubPrefix(IPFSConfGatewaySubconf)
-	if gwPrefix.GetString(restclient.HTTPConfigURL) == "" {
+	if gwPrefix.GetString(restclient.HTTPConfigURL) != "" {
+		gwPrefixes = append(gwPrefixes, gwPrefix)
+	}
+	gwArray := gatewaysConfig(prefix)
+	for n := 0; n < gwArray.ArraySize(); n++ {
+		entry := gwArray.ArrayEntry(n)
+		if entry.GetString(restclient.HTTPConfigURL) == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, entry.Resolve(restclient.HTTPConfigURL), "ipfs")
+		}
+		gwPrefixes = append(gwPrefixes, entry)
+	}
+	if len(gwPrefixes) == 0 {
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, gwPrefix.Resolve(restclient.HTTPConfigURL), "ipfs")
 	}
-	i.gwClient = restclient.New(i.ctx, gwPrefix)
-	i.capabilities = &publicstorage.Capabilities{}
+	gateways := make([]*ipfsGateway, len(gwPrefixes))
+	for n, p := range gwPrefixes {
+		gateways[n] = &ipfsGateway{
+			url:    p.GetString(restclient.HTTPConfigURL),
+			client: restclient.New(i.ctx, p),
+		}
+	}
+	failoverPrefix := prefix.SubPrefix(IPFSConfGatewayFailoverSubconf)
+
+	i.gwMux.Lock()
+	defer i.gwMux.Unlock()
+	i.gateways = gateways
+	i.gwInitDelay = failoverPrefix.GetDuration(IPFSConfGatewayFailoverInitialDelay)
+	i.gwMaxDelay = failoverPrefix.GetDuration(IPFSConfGatewayFailoverMaxDelay)
 	return nil
 }
 
@@ -86,18 +171,213 @@ func (i *IPFS) PublishData(ctx context.Context, data io.Reader) (string, error)
 	return ipfsResponse.Hash, err
 }
 
+// orderedGateways returns the healthy gateways in configured order, followed by the
+// unhealthy ones in the order they are due to become healthy again
+func (i *IPFS) orderedGateways() []*ipfsGateway {
+	i.gwMux.Lock()
+	defer i.gwMux.Unlock()
+	now := time.Now()
+	healthy := make([]*ipfsGateway, 0, len(i.gateways))
+	unhealthy := make([]*ipfsGateway, 0)
+	for _, gw := range i.gateways {
+		if gw.unhealthyUntil.After(now) {
+			unhealthy = append(unhealthy, gw)
+		} else {
+			healthy = append(healthy, gw)
+		}
+	}
+	sort.SliceStable(unhealthy, func(a, b int) bool {
+		return unhealthy[a].unhealthyUntil.Before(unhealthy[b].unhealthyUntil)
+	})
+	return append(healthy, unhealthy...)
+}
+
+func (i *IPFS) gatewaySucceeded(gw *ipfsGateway) {
+	i.gwMux.Lock()
+	defer i.gwMux.Unlock()
+	gw.failures = 0
+	gw.unhealthyUntil = time.Time{}
+}
+
+func (i *IPFS) gatewayFailed(ctx context.Context, gw *ipfsGateway, payloadRef string, err error) {
+	i.gwMux.Lock()
+	defer i.gwMux.Unlock()
+	gw.failures++
+	delay := i.gwInitDelay
+	for n := 1; n < gw.failures && delay < i.gwMaxDelay; n++ {
+		delay *= 2
+	}
+	if delay > i.gwMaxDelay {
+		delay = i.gwMaxDelay
+	}
+	gw.unhealthyUntil = time.Now().Add(delay)
+	log.L(ctx).Warnf("IPFS gateway %s failed to retrieve %s (failures=%d delay=%s): %s", gw.url, payloadRef, gw.failures, delay, err)
+}
+
 func (i *IPFS) RetrieveData(ctx context.Context, payloadRef string) (data io.ReadCloser, err error) {
-	res, err := i.gwClient.R().
+	if !strings.HasPrefix(payloadRef, cidV0Prefix) {
+		// We can only verify the data returned by the gateway for V0 CIDs
+		return nil, i18n.NewError(ctx, i18n.MsgIPFSUnsupportedCID, payloadRef)
+	}
+	for _, gw := range i.orderedGateways() {
+		var payload []byte
+		payload, err = i.retrieveFromGateway(ctx, gw, payloadRef)
+		if err == nil {
+			i.gatewaySucceeded(gw)
+			log.L(ctx).Infof("IPFS retrieved %s from %s Size=%d", payloadRef, gw.url, len(payload))
+			return ioutil.NopCloser(bytes.NewReader(payload)), nil
+		}
+		if ctx.Err() != nil {
+			// The gateway is not at fault if we were cancelled
+			return nil, err
+		}
+		i.gatewayFailed(ctx, gw, payloadRef, err)
+	}
+	return nil, err
+}
+
+func (i *IPFS) retrieveFromGateway(ctx context.Context, gw *ipfsGateway, payloadRef string) ([]byte, error) {
+	res, err := gw.client.R().
 		SetContext(ctx).
 		SetDoNotParseResponse(true).
 		Get(fmt.Sprintf("/ipfs/%s", payloadRef))
-	restclient.OnAfterResponse(i.gwClient, res) // required using SetDoNotParseResponse
+	restclient.OnAfterResponse(gw.client, res) // required using SetDoNotParseResponse
 	if err != nil || !res.IsSuccess() {
 		if res != nil && res.RawBody() != nil {
 			_ = res.RawBody().Close()
 		}
 		return nil, restclient.WrapRestErr(i.ctx, res, err, i18n.MsgIPFSRESTErr)
 	}
-	log.L(ctx).Infof("IPFS retrieved %s", payloadRef)
-	return res.RawBody(), nil
+	defer res.RawBody().Close()
+
+	// We do not trust the gateway, so we check the data matches the CID before returning it
+	payload, err := ioutil.ReadAll(res.RawBody())
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgIPFSRESTErr, err)
+	}
+	if cid := cidV0(payload); cid != payloadRef {
+		log.L(ctx).Errorf("IPFS data for %s from %s has CID %s", payloadRef, gw.url, cid)
+		return nil, i18n.NewError(ctx, i18n.MsgPublicStorageHashMismatch, i.Name(), payloadRef)
+	}
+	return payload, nil
+}
+
+// Ping checks the IPFS API is available, by requesting its version
+func (i *IPFS) Ping(ctx context.Context) error {
+	res, err := i.apiClient.R().
+		SetContext(ctx).
+		Post("/api/v0/version")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(i.ctx, res, err, i18n.MsgIPFSRESTErr)
+	}
+	return nil
+}
+
+// PinData requests the pinning service pins the data, naming the request after the operation. If the operation
+// already has a request, such as when resuming a pending operation after a restart, we track that request instead.
+func (i *IPFS) PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) error {
+	if i.pinClient == nil {
+		return i18n.NewError(ctx, i18n.MsgIPFSPinningNotConfigured)
+	}
+	existing, err := i.findPin(ctx, operationID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		log.L(ctx).Infof("IPFS pin already requested for %s RequestID=%s Status=%s", payloadRef, existing.RequestID, existing.Status)
+		go i.trackPin(operationID, payloadRef, existing)
+		return nil
+	}
+
+	var pinStatus ipfsPinStatus
+	res, err := i.pinClient.R().
+		SetContext(ctx).
+		SetBody(&ipfsPinRequest{
+			CID:  payloadRef,
+			Name: operationID.String(),
+		}).
+		SetResult(&pinStatus).
+		Post("/pins")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(i.ctx, res, err, i18n.MsgIPFSPinningRESTErr)
+	}
+	log.L(ctx).Infof("IPFS pin requested for %s RequestID=%s Status=%s", payloadRef, pinStatus.RequestID, pinStatus.Status)
+
+	// The pinning service processes the request asynchronously, so we poll for completion in the background
+	go i.trackPin(operationID, payloadRef, &pinStatus)
+	return nil
+}
+
+// findPin returns the pin request named after the operation in any state, or nil if there is none
+func (i *IPFS) findPin(ctx context.Context, operationID *fftypes.UUID) (*ipfsPinStatus, error) {
+	var pinResults ipfsPinResults
+	res, err := i.pinClient.R().
+		SetContext(ctx).
+		SetQueryParam("name", operationID.String()).
+		SetQueryParam("status", "queued,pinning,pinned,failed"). // only pinned requests are returned by default
+		SetResult(&pinResults).
+		Get("/pins")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(i.ctx, res, err, i18n.MsgIPFSPinningRESTErr)
+	}
+	if len(pinResults.Results) == 0 {
+		return nil, nil
+	}
+	return pinResults.Results[0], nil
+}
+
+func (i *IPFS) getPinStatus(requestID string) (*ipfsPinStatus, error) {
+	var pinStatus ipfsPinStatus
+	res, err := i.pinClient.R().
+		SetContext(i.ctx).
+		SetResult(&pinStatus).
+		Get(fmt.Sprintf("/pins/%s", requestID))
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(i.ctx, res, err, i18n.MsgIPFSPinningRESTErr)
+	}
+	return &pinStatus, nil
+}
+
+func (i *IPFS) trackPin(operationID *fftypes.UUID, payloadRef string, pinStatus *ipfsPinStatus) {
+	l := log.L(i.ctx)
+	for {
+		switch pinStatus.Status {
+		case ipfsPinStatusPinned:
+			l.Infof("IPFS pinned %s RequestID=%s", payloadRef, pinStatus.RequestID)
+			i.pinComplete(operationID, payloadRef, pinStatus, fftypes.OpStatusSucceeded, "")
+			return
+		case ipfsPinStatusFailed:
+			errorMessage := i18n.NewError(i.ctx, i18n.MsgIPFSPinFailed, payloadRef, pinStatus.RequestID).Error()
+			l.Errorf("%s", errorMessage)
+			i.pinComplete(operationID, payloadRef, pinStatus, fftypes.OpStatusFailed, errorMessage)
+			return
+		}
+		select {
+		case <-i.ctx.Done():
+			l.Debugf("Stopped tracking IPFS pin of %s RequestID=%s", payloadRef, pinStatus.RequestID)
+			return
+		case <-time.After(i.pinPollTime):
+		}
+		latest, err := i.getPinStatus(pinStatus.RequestID)
+		if err != nil {
+			// Transient errors querying the status should not fail the pin, so we just try again next time
+			l.Warnf("Failed to query IPFS pin status for %s RequestID=%s: %s", payloadRef, pinStatus.RequestID, err)
+			continue
+		}
+		pinStatus = latest
+	}
+}
+
+func (i *IPFS) pinComplete(operationID *fftypes.UUID, payloadRef string, pinStatus *ipfsPinStatus, txState fftypes.OpStatus, errorMessage string) {
+	opOutput := fftypes.JSONObject{
+		"cid":       payloadRef,
+		"requestid": pinStatus.RequestID,
+		"status":    pinStatus.Status,
+	}
+	if pinStatus.Info != nil {
+		opOutput["info"] = pinStatus.Info
+	}
+	if err := i.callbacks.PublicStorageOpUpdate(i, operationID, txState, errorMessage, opOutput); err != nil {
+		log.L(i.ctx).Errorf("Failed to update pin operation %s: %s", operationID, err)
+	}
 }
@@ -21,8 +21,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/restclient"
@@ -30,6 +36,7 @@ import (
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var utConfPrefix = config.NewPluginConfig("ipfs_unit_tests")
@@ -38,6 +45,7 @@ func resetConf() {
 	config.Reset()
 	i := &IPFS{}
 	i.InitPrefix(utConfPrefix)
+	utConfPrefix.AddKnownKey(IPFSConfGatewaysSubconf)
 }
 
 func TestInitMissingAPIURL(t *testing.T) {
@@ -97,6 +105,32 @@ func TestIPFSUploadSuccess(t *testing.T) {
 
 }
 
+func TestIPFSPing(t *testing.T) {
+	i := &IPFS{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.NoError(t, err)
+
+	httpmock.RegisterResponder("POST", "http://localhost:12345/api/v0/version",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"Version": "0.12.0"}))
+	err = i.Ping(context.Background())
+	assert.NoError(t, err)
+
+	httpmock.RegisterResponder("POST", "http://localhost:12345/api/v0/version",
+		httpmock.NewJsonResponderOrPanic(500, map[string]interface{}{"error": "pop"}))
+	err = i.Ping(context.Background())
+	assert.Regexp(t, "FF10136", err)
+}
+
 func TestIPFSUploadFail(t *testing.T) {
 	i := &IPFS{}
 
@@ -137,10 +171,10 @@ func TestIPFSDownloadSuccess(t *testing.T) {
 	assert.NoError(t, err)
 
 	data := []byte(`{"hello": "world"}`)
-	httpmock.RegisterResponder("GET", "http://localhost:12345/ipfs/QmRAQfHNnknnz8S936M2yJGhhVNA6wXJ4jTRP3VXtptmmL",
+	httpmock.RegisterResponder("GET", "http://localhost:12345/ipfs/QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb",
 		httpmock.NewBytesResponder(200, data))
 
-	r, err := i.RetrieveData(context.Background(), "QmRAQfHNnknnz8S936M2yJGhhVNA6wXJ4jTRP3VXtptmmL")
+	r, err := i.RetrieveData(context.Background(), "QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb")
 	assert.NoError(t, err)
 	defer r.Close()
 
@@ -195,3 +229,424 @@ func TestIPFSDownloadError(t *testing.T) {
 	assert.Regexp(t, "FF10136", err)
 
 }
+
+func newTestDownloadIPFS(t *testing.T) (*IPFS, func()) {
+	i := &IPFS{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.NoError(t, err)
+	return i, httpmock.DeactivateAndReset
+}
+
+func TestIPFSDownloadHashMismatch(t *testing.T) {
+	i, done := newTestDownloadIPFS(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/ipfs/QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb",
+		httpmock.NewBytesResponder(200, []byte(`{"hello": "tampered"}`)))
+
+	_, err := i.RetrieveData(context.Background(), "QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb")
+	assert.Regexp(t, "FF10387", err)
+}
+
+func TestIPFSDownloadUnsupportedCID(t *testing.T) {
+	i, done := newTestDownloadIPFS(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/ipfs/bafkreibm6jg3ux5qumhcn2b3flc3tyu6dmlb4xa7u5bf44yegnrjhc4yeq",
+		httpmock.NewBytesResponder(200, []byte(`{"hello": "world"}`)))
+
+	_, err := i.RetrieveData(context.Background(), "bafkreibm6jg3ux5qumhcn2b3flc3tyu6dmlb4xa7u5bf44yegnrjhc4yeq")
+	assert.Regexp(t, "FF10388", err)
+}
+
+func TestIPFSDownloadReadError(t *testing.T) {
+	i, done := newTestDownloadIPFS(t)
+	defer done()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/ipfs/QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb",
+		httpmock.ResponderFromResponse(&http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(iotest.ErrReader(fmt.Errorf("pop"))),
+		}))
+
+	_, err := i.RetrieveData(context.Background(), "QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb")
+	assert.Regexp(t, "FF10136.*pop", err)
+}
+
+func newTestPinningIPFS(t *testing.T) (*IPFS, *publicstoragemocks.Callbacks, func()) {
+	i := &IPFS{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfPinningSubconf).Set(restclient.HTTPConfigURL, "http://localhost:23456")
+	utConfPrefix.SubPrefix(IPFSConfPinningSubconf).Set(restclient.HTTPCustomClient, mockedClient)
+	utConfPrefix.SubPrefix(IPFSConfPinningSubconf).Set(IPFSConfPinningPollInterval, "1ms")
+
+	mcb := &publicstoragemocks.Callbacks{}
+	ctx, cancel := context.WithCancel(context.Background())
+	err := i.Init(ctx, utConfPrefix, mcb)
+	assert.NoError(t, err)
+	assert.True(t, i.Capabilities().Pinning)
+	return i, mcb, func() {
+		cancel()
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestIPFSPinNotConfigured(t *testing.T) {
+	i := &IPFS{}
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.False(t, i.Capabilities().Pinning)
+
+	err = i.PinData(context.Background(), fftypes.NewUUID(), "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	assert.Regexp(t, "FF10370", err)
+}
+
+func TestIPFSPinSuccess(t *testing.T) {
+	i, mcb, cancel := newTestPinningIPFS(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+	httpmock.RegisterResponder("GET", "http://localhost:23456/pins",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, opID.String(), req.URL.Query().Get("name"))
+			return httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+				"count":   0,
+				"results": []interface{}{},
+			})(req)
+		})
+	httpmock.RegisterResponder("POST", "http://localhost:23456/pins",
+		func(req *http.Request) (*http.Response, error) {
+			var pinReq ipfsPinRequest
+			json.NewDecoder(req.Body).Decode(&pinReq)
+			assert.Equal(t, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", pinReq.CID)
+			assert.Equal(t, opID.String(), pinReq.Name)
+			return httpmock.NewJsonResponderOrPanic(202, map[string]interface{}{
+				"requestid": "req1",
+				"status":    "pinned",
+				"info":      map[string]interface{}{"some": "info"},
+			})(req)
+		})
+
+	done := make(chan struct{})
+	mcb.On("PublicStorageOpUpdate", i, opID, fftypes.OpStatusSucceeded, "", mock.MatchedBy(func(output fftypes.JSONObject) bool {
+		return output.GetString("requestid") == "req1" &&
+			output.GetString("status") == "pinned" &&
+			output.GetString("cid") == "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD" &&
+			output.GetObject("info").GetString("some") == "info"
+	})).Return(fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		close(done)
+	})
+
+	err := i.PinData(context.Background(), opID, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	assert.NoError(t, err)
+	<-done
+
+	mcb.AssertExpectations(t)
+}
+
+func TestIPFSPinResumeExisting(t *testing.T) {
+	i, mcb, cancel := newTestPinningIPFS(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+	httpmock.RegisterResponder("GET", "http://localhost:23456/pins",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, opID.String(), req.URL.Query().Get("name"))
+			assert.Equal(t, "queued,pinning,pinned,failed", req.URL.Query().Get("status"))
+			return httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+				"count": 1,
+				"results": []interface{}{
+					map[string]interface{}{"requestid": "req1", "status": "pinning"},
+				},
+			})(req)
+		})
+	httpmock.RegisterResponder("GET", "http://localhost:23456/pins/req1",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"requestid": "req1", "status": "pinned"}))
+
+	done := make(chan struct{})
+	mcb.On("PublicStorageOpUpdate", i, opID, fftypes.OpStatusSucceeded, "", mock.MatchedBy(func(output fftypes.JSONObject) bool {
+		return output.GetString("requestid") == "req1" && output.GetString("status") == "pinned"
+	})).Return(nil).Run(func(args mock.Arguments) {
+		close(done)
+	})
+
+	err := i.PinData(context.Background(), opID, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	assert.NoError(t, err)
+	<-done
+
+	// The existing request is tracked, rather than a new one being made
+	assert.Zero(t, httpmock.GetCallCountInfo()["POST http://localhost:23456/pins"])
+	mcb.AssertExpectations(t)
+}
+
+func TestIPFSPinLookupFail(t *testing.T) {
+	i, _, cancel := newTestPinningIPFS(t)
+	defer cancel()
+
+	httpmock.RegisterResponder("GET", "http://localhost:23456/pins",
+		httpmock.NewJsonResponderOrPanic(500, map[string]interface{}{"error": "pop"}))
+
+	err := i.PinData(context.Background(), fftypes.NewUUID(), "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	assert.Regexp(t, "FF10371", err)
+}
+
+func TestIPFSPinFail(t *testing.T) {
+	i, _, cancel := newTestPinningIPFS(t)
+	defer cancel()
+
+	httpmock.RegisterResponder("GET", "http://localhost:23456/pins",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"count": 0}))
+	httpmock.RegisterResponder("POST", "http://localhost:23456/pins",
+		httpmock.NewJsonResponderOrPanic(401, map[string]interface{}{"error": "pop"}))
+
+	err := i.PinData(context.Background(), fftypes.NewUUID(), "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	assert.Regexp(t, "FF10371", err)
+}
+
+func TestIPFSTrackPinPollUntilFailed(t *testing.T) {
+	i, mcb, cancel := newTestPinningIPFS(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+	polls := 0
+	httpmock.RegisterResponder("GET", "http://localhost:23456/pins/req1",
+		func(req *http.Request) (*http.Response, error) {
+			polls++
+			switch polls {
+			case 1:
+				return httpmock.NewJsonResponderOrPanic(500, map[string]interface{}{"error": "pop"})(req)
+			case 2:
+				return httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"requestid": "req1", "status": "pinning"})(req)
+			default:
+				return httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"requestid": "req1", "status": "failed"})(req)
+			}
+		})
+
+	mcb.On("PublicStorageOpUpdate", i, opID, fftypes.OpStatusFailed, mock.MatchedBy(func(errorMessage string) bool {
+		return strings.Contains(errorMessage, "FF10372")
+	}), mock.MatchedBy(func(output fftypes.JSONObject) bool {
+		return output.GetString("status") == "failed" && output["info"] == nil
+	})).Return(nil)
+
+	i.trackPin(opID, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", &ipfsPinStatus{
+		RequestID: "req1",
+		Status:    "queued",
+	})
+	assert.Equal(t, 3, polls)
+
+	mcb.AssertExpectations(t)
+}
+
+func TestIPFSTrackPinContextCancelled(t *testing.T) {
+	i, mcb, cancel := newTestPinningIPFS(t)
+	cancel()
+
+	i.trackPin(fftypes.NewUUID(), "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", &ipfsPinStatus{
+		RequestID: "req1",
+		Status:    "queued",
+	})
+
+	mcb.AssertExpectations(t)
+}
+
+func newTestFailoverIPFS(t *testing.T) (*IPFS, func()) {
+	i := &IPFS{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPConfigURL, "http://gw1")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPCustomClient, mockedClient)
+	utConfPrefix.Set(IPFSConfGatewaysSubconf, map[string]interface{}{
+		"0": map[string]interface{}{restclient.HTTPConfigURL: "http://gw2", restclient.HTTPCustomClient: mockedClient},
+		"1": map[string]interface{}{restclient.HTTPConfigURL: "http://gw3", restclient.HTTPCustomClient: mockedClient},
+	})
+	utConfPrefix.SubPrefix(IPFSConfGatewayFailoverSubconf).Set(IPFSConfGatewayFailoverInitialDelay, "1m")
+	utConfPrefix.SubPrefix(IPFSConfGatewayFailoverSubconf).Set(IPFSConfGatewayFailoverMaxDelay, "3m")
+
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Len(t, i.gateways, 3)
+	return i, httpmock.DeactivateAndReset
+}
+
+func TestInitGatewaysOnly(t *testing.T) {
+	i := &IPFS{}
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(IPFSConfGatewaysSubconf, map[string]interface{}{
+		"0": map[string]interface{}{restclient.HTTPConfigURL: "http://gw2"},
+	})
+
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Len(t, i.gateways, 1)
+	assert.Equal(t, "http://gw2", i.gateways[0].url)
+}
+
+func TestInitGatewaysMissingURL(t *testing.T) {
+	i := &IPFS{}
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(IPFSConfGatewaysSubconf, map[string]interface{}{
+		"0": map[string]interface{}{},
+	})
+
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*gateways.0.url", err)
+}
+
+func TestReloadGateways(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "ipfstest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	i := &IPFS{}
+	resetConf()
+	defer config.Reset()
+	conf := `
+ipfs_unit_tests:
+  api:
+    url: http://localhost:12345
+  gateway:
+    url: http://gw1
+`
+	err = ioutil.WriteFile(fileName, []byte(conf), 0600)
+	assert.NoError(t, err)
+	err = config.ReadConfig(fileName)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = i.Init(ctx, utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Len(t, i.gateways, 1)
+
+	err = ioutil.WriteFile(fileName, []byte(conf+`
+  gateways:
+  - url: http://gw2
+  - url: http://gw3
+  gatewayFailover:
+    maxDelay: 10m
+`), 0600)
+	assert.NoError(t, err)
+	applied, restartRequired, err := config.ReloadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ipfs_unit_tests.gatewayfailover.maxdelay", "ipfs_unit_tests.gateways"}, applied)
+	assert.Empty(t, restartRequired)
+	gateways := i.orderedGateways()
+	assert.Len(t, gateways, 3)
+	assert.Equal(t, "http://gw3", gateways[2].url)
+	assert.Equal(t, 10*time.Minute, i.gwMaxDelay)
+
+	// A gateway with no URL is rejected, and the current gateways kept
+	err = ioutil.WriteFile(fileName, []byte(conf+`
+  gateways:
+  - requestTimeout: 10s
+`), 0600)
+	assert.NoError(t, err)
+	_, _, err = config.ReloadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, i.orderedGateways(), 3)
+}
+
+func TestIPFSDownloadFailover(t *testing.T) {
+	i, done := newTestFailoverIPFS(t)
+	defer done()
+
+	cid := "QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb"
+	httpmock.RegisterResponder("GET", "http://gw1/ipfs/"+cid,
+		httpmock.NewErrorResponder(fmt.Errorf("pop")))
+	httpmock.RegisterResponder("GET", "http://gw2/ipfs/"+cid,
+		httpmock.NewBytesResponder(200, []byte(`{"hello": "tampered"}`)))
+	httpmock.RegisterResponder("GET", "http://gw3/ipfs/"+cid,
+		httpmock.NewBytesResponder(200, []byte(`{"hello": "world"}`)))
+
+	r, err := i.RetrieveData(context.Background(), cid)
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(r)
+	assert.Equal(t, `{"hello": "world"}`, string(b))
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET http://gw1/ipfs/"+cid])
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET http://gw2/ipfs/"+cid])
+
+	// The healthy gateway is now tried first, and the failing ones are tried in the order they recover
+	ordered := i.orderedGateways()
+	assert.Equal(t, "http://gw3", ordered[0].url)
+	assert.Equal(t, "http://gw1", ordered[1].url)
+	assert.Equal(t, "http://gw2", ordered[2].url)
+
+	_, err = i.RetrieveData(context.Background(), cid)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET http://gw1/ipfs/"+cid])
+	assert.Equal(t, 2, httpmock.GetCallCountInfo()["GET http://gw3/ipfs/"+cid])
+
+	// Once recovered, the gateway goes back to its configured position
+	httpmock.RegisterResponder("GET", "http://gw3/ipfs/"+cid,
+		httpmock.NewErrorResponder(fmt.Errorf("pop")))
+	httpmock.RegisterResponder("GET", "http://gw1/ipfs/"+cid,
+		httpmock.NewBytesResponder(200, []byte(`{"hello": "world"}`)))
+	_, err = i.RetrieveData(context.Background(), cid)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, i.gateways[0].failures)
+	assert.True(t, i.gateways[0].unhealthyUntil.IsZero())
+}
+
+func TestIPFSDownloadAllGatewaysFail(t *testing.T) {
+	i, done := newTestFailoverIPFS(t)
+	defer done()
+
+	cid := "QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb"
+	httpmock.RegisterResponder("GET", "http://gw1/ipfs/"+cid,
+		httpmock.NewErrorResponder(fmt.Errorf("pop")))
+	httpmock.RegisterResponder("GET", "http://gw2/ipfs/"+cid,
+		httpmock.NewErrorResponder(fmt.Errorf("pop")))
+	httpmock.RegisterResponder("GET", "http://gw3/ipfs/"+cid,
+		httpmock.NewJsonResponderOrPanic(500, map[string]interface{}{"error": "pop"}))
+
+	for n := 0; n < 3; n++ {
+		_, err := i.RetrieveData(context.Background(), cid)
+		assert.Regexp(t, "FF10136", err)
+	}
+	assert.Equal(t, 3, httpmock.GetCallCountInfo()["GET http://gw1/ipfs/"+cid])
+	assert.Equal(t, 3, i.gateways[0].failures)
+	assert.WithinDuration(t, time.Now().Add(3*time.Minute), i.gateways[0].unhealthyUntil, time.Second)
+}
+
+func TestIPFSDownloadCancelled(t *testing.T) {
+	i, done := newTestFailoverIPFS(t)
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cid := "QmRjVUAuS7V2c8bKbXKN9eXzp2dMXW8jwYLCAFo9nHBSeb"
+	httpmock.RegisterResponder("GET", "http://gw1/ipfs/"+cid,
+		func(req *http.Request) (*http.Response, error) {
+			cancel()
+			return nil, fmt.Errorf("pop")
+		})
+
+	_, err := i.RetrieveData(ctx, cid)
+	assert.Regexp(t, "FF10136", err)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET http://gw2/ipfs/"+cid])
+	assert.Equal(t, 0, i.gateways[0].failures)
+}
@@ -0,0 +1,198 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pscache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+	"github.com/karlseguin/ccache"
+)
+
+const tmpSuffix = ".tmp"
+
+// cachedPayload is sized by its length, so the memory cache is limited by the total size of the payloads it holds
+type cachedPayload []byte
+
+func (p cachedPayload) Size() int64 {
+	return int64(len(p))
+}
+
+// cachingPlugin caches the data retrieved by a public storage plugin in a memory LRU cache, and optionally on disk.
+// Payload references are content addressed, so a cached payload is never stale. Retrieved data is only cached
+// once it has been read to the end, which is after any verification of the data by the plugin has completed.
+type cachingPlugin struct {
+	publicstorage.Plugin
+	memory    *ccache.Cache
+	maxMemory int64
+	ttl       time.Duration
+	directory string
+}
+
+// Wrap returns a plugin that caches the data retrieved by the supplied plugin, according to the configuration.
+// The supplied plugin is returned unchanged if caching is disabled.
+func Wrap(ctx context.Context, plugin publicstorage.Plugin) (publicstorage.Plugin, error) {
+	c := &cachingPlugin{
+		Plugin:    plugin,
+		maxMemory: config.GetByteSize(config.PublicStorageCacheSize),
+		ttl:       config.GetDuration(config.PublicStorageCacheTTL),
+		directory: config.GetString(config.PublicStorageCacheDirectory),
+	}
+	if c.maxMemory <= 0 && c.directory == "" {
+		return plugin, nil
+	}
+	if c.maxMemory > 0 {
+		c.memory = ccache.New(ccache.Configure().MaxSize(c.maxMemory))
+	}
+	if c.directory != "" {
+		if err := os.MkdirAll(c.directory, 0700); err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgPublicStorageCacheDirInvalid, c.directory, err)
+		}
+		// Remove any partial downloads left over from a previous run
+		tmpFiles, _ := filepath.Glob(filepath.Join(c.directory, "*"+tmpSuffix))
+		for _, tmpFile := range tmpFiles {
+			_ = os.Remove(tmpFile)
+		}
+	}
+	log.L(ctx).Infof("Public storage cache enabled: memory=%d directory='%s'", c.maxMemory, c.directory)
+	return c, nil
+}
+
+func (c *cachingPlugin) diskPath(payloadRef string) string {
+	hash := sha256.Sum256([]byte(payloadRef))
+	return filepath.Join(c.directory, hex.EncodeToString(hash[:]))
+}
+
+func (c *cachingPlugin) RetrieveData(ctx context.Context, payloadRef string) (io.ReadCloser, error) {
+	if c.memory != nil {
+		if cached := c.memory.Get(payloadRef); cached != nil {
+			cached.Extend(c.ttl)
+			log.L(ctx).Debugf("Retrieved %s from memory cache", payloadRef)
+			return ioutil.NopCloser(bytes.NewReader(cached.Value().(cachedPayload))), nil
+		}
+	}
+	if c.directory != "" {
+		if f, err := os.Open(c.diskPath(payloadRef)); err == nil {
+			log.L(ctx).Debugf("Retrieved %s from disk cache", payloadRef)
+			return c.newCachingReader(ctx, payloadRef, f, false), nil
+		}
+	}
+	data, err := c.Plugin.RetrieveData(ctx, payloadRef)
+	if err != nil {
+		return nil, err
+	}
+	return c.newCachingReader(ctx, payloadRef, data, c.directory != ""), nil
+}
+
+// cachingReader passes data through as it is read, and stores it in the cache once the end is reached.
+// The copy in memory is abandoned if the data exceeds the size of the memory cache.
+type cachingReader struct {
+	ctx        context.Context
+	c          *cachingPlugin
+	payloadRef string
+	reader     io.ReadCloser
+	buffer     *bytes.Buffer
+	file       *os.File
+}
+
+func (c *cachingPlugin) newCachingReader(ctx context.Context, payloadRef string, reader io.ReadCloser, toDisk bool) *cachingReader {
+	r := &cachingReader{
+		ctx:        ctx,
+		c:          c,
+		payloadRef: payloadRef,
+		reader:     reader,
+	}
+	if c.memory != nil {
+		r.buffer = &bytes.Buffer{}
+	}
+	if toDisk {
+		f, err := ioutil.TempFile(c.directory, "*"+tmpSuffix)
+		if err != nil {
+			log.L(ctx).Warnf("Unable to cache %s on disk: %s", payloadRef, err)
+		}
+		r.file = f
+	}
+	return r
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if r.buffer != nil {
+			if int64(r.buffer.Len()+n) > r.c.maxMemory {
+				r.buffer = nil
+			} else {
+				r.buffer.Write(p[:n])
+			}
+		}
+		if r.file != nil {
+			if _, writeErr := r.file.Write(p[:n]); writeErr != nil {
+				log.L(r.ctx).Warnf("Unable to cache %s on disk: %s", r.payloadRef, writeErr)
+				r.discardFile()
+			}
+		}
+	}
+	if err == io.EOF {
+		r.complete()
+	}
+	return n, err
+}
+
+func (r *cachingReader) complete() {
+	if r.buffer != nil {
+		r.c.memory.Set(r.payloadRef, cachedPayload(r.buffer.Bytes()), r.c.ttl)
+		r.buffer = nil
+	}
+	if r.file != nil {
+		tmpName := r.file.Name()
+		err := r.file.Close()
+		if err == nil {
+			err = os.Rename(tmpName, r.c.diskPath(r.payloadRef))
+		}
+		if err != nil {
+			log.L(r.ctx).Warnf("Unable to cache %s on disk: %s", r.payloadRef, err)
+			_ = os.Remove(tmpName)
+		}
+		r.file = nil
+	}
+}
+
+func (r *cachingReader) discardFile() {
+	if r.file != nil {
+		_ = r.file.Close()
+		_ = os.Remove(r.file.Name())
+		r.file = nil
+	}
+}
+
+// Close discards anything that was not read to the end, so it is not cached
+func (r *cachingReader) Close() error {
+	r.buffer = nil
+	r.discardFile()
+	return r.reader.Close()
+}
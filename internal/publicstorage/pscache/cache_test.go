@@ -0,0 +1,199 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pscache
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(t *testing.T, size, directory string) (*cachingPlugin, *publicstoragemocks.Plugin) {
+	config.Reset()
+	config.Set(config.PublicStorageCacheSize, size)
+	config.Set(config.PublicStorageCacheDirectory, directory)
+	mps := &publicstoragemocks.Plugin{}
+	p, err := Wrap(context.Background(), mps)
+	assert.NoError(t, err)
+	return p.(*cachingPlugin), mps
+}
+
+func readAll(t *testing.T, c *cachingPlugin, payloadRef string) string {
+	r, err := c.RetrieveData(context.Background(), payloadRef)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	return string(b)
+}
+
+func TestWrapDisabled(t *testing.T) {
+	config.Reset()
+	config.Set(config.PublicStorageCacheSize, "0")
+	mps := &publicstoragemocks.Plugin{}
+	p, err := Wrap(context.Background(), mps)
+	assert.NoError(t, err)
+	assert.Equal(t, mps, p)
+}
+
+func TestWrapBadDirectory(t *testing.T) {
+	notADir := filepath.Join(t.TempDir(), "file")
+	err := ioutil.WriteFile(notADir, []byte{}, 0600)
+	assert.NoError(t, err)
+	config.Reset()
+	config.Set(config.PublicStorageCacheDirectory, filepath.Join(notADir, "cache"))
+	_, err = Wrap(context.Background(), &publicstoragemocks.Plugin{})
+	assert.Regexp(t, "FF10391", err)
+}
+
+func TestWrapRemovesPartialDownloads(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "partial.tmp"), []byte("hel"), 0600)
+	assert.NoError(t, err)
+	newTestCache(t, "0", dir)
+	_, err = os.Stat(filepath.Join(dir, "partial.tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRetrieveMemoryCache(t *testing.T) {
+	c, mps := newTestCache(t, "1Kb", "")
+	mps.On("Name").Return("utps")
+	mps.On("RetrieveData", context.Background(), "ref1").Return(ioutil.NopCloser(strings.NewReader("hello")), nil).Once()
+
+	assert.Equal(t, "utps", c.Name())
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+
+	mps.AssertExpectations(t)
+}
+
+func TestRetrieveDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	c, mps := newTestCache(t, "0", dir)
+	mps.On("RetrieveData", context.Background(), "ref1").Return(ioutil.NopCloser(strings.NewReader("hello")), nil).Once()
+
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1)
+
+	mps.AssertExpectations(t)
+}
+
+func TestRetrieveDiskCachePromotedToMemory(t *testing.T) {
+	dir := t.TempDir()
+	c, mps := newTestCache(t, "1Kb", dir)
+	mps.On("RetrieveData", context.Background(), "ref1").Return(ioutil.NopCloser(strings.NewReader("hello")), nil).Once()
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+
+	// A new cache (such as after a restart) finds the payload on disk, then holds it in memory
+	c, _ = newTestCache(t, "1Kb", dir)
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+	err := os.Remove(c.diskPath("ref1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+
+	mps.AssertExpectations(t)
+}
+
+func TestRetrieveFail(t *testing.T) {
+	c, mps := newTestCache(t, "1Kb", "")
+	mps.On("RetrieveData", context.Background(), "ref1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := c.RetrieveData(context.Background(), "ref1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetrieveNotReadToEnd(t *testing.T) {
+	dir := t.TempDir()
+	c, mps := newTestCache(t, "1Kb", dir)
+	mps.On("RetrieveData", context.Background(), "ref1").Return(ioutil.NopCloser(strings.NewReader("hello")), nil).Twice()
+
+	r, err := c.RetrieveData(context.Background(), "ref1")
+	assert.NoError(t, err)
+	b := make([]byte, 3)
+	_, err = r.Read(b)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	files, _ := ioutil.ReadDir(dir)
+	assert.Empty(t, files)
+
+	_, err = c.RetrieveData(context.Background(), "ref1")
+	assert.NoError(t, err)
+
+	mps.AssertExpectations(t)
+}
+
+func TestRetrieveTooLargeForMemory(t *testing.T) {
+	c, mps := newTestCache(t, "4", "")
+	mps.On("RetrieveData", context.Background(), "ref1").Return(ioutil.NopCloser(strings.NewReader("hello")), nil).Once()
+	mps.On("RetrieveData", context.Background(), "ref1").Return(ioutil.NopCloser(strings.NewReader("hello")), nil).Once()
+
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+
+	mps.AssertExpectations(t)
+}
+
+func TestRetrieveDiskCacheUnavailable(t *testing.T) {
+	dir := t.TempDir()
+	c, mps := newTestCache(t, "1Kb", dir)
+	err := os.Remove(dir)
+	assert.NoError(t, err)
+	mps.On("RetrieveData", context.Background(), "ref1").Return(ioutil.NopCloser(strings.NewReader("hello")), nil).Once()
+
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+	assert.Equal(t, "hello", readAll(t, c, "ref1"))
+
+	mps.AssertExpectations(t)
+}
+
+func TestRetrieveDiskWriteFail(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := newTestCache(t, "0", dir)
+	r := c.newCachingReader(context.Background(), "ref1", ioutil.NopCloser(strings.NewReader("hello")), true)
+	r.file.Close()
+
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	assert.Nil(t, r.file)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func TestRetrieveDiskRenameFail(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := newTestCache(t, "0", dir)
+	err := os.MkdirAll(filepath.Join(c.diskPath("ref1"), "blocker"), 0700)
+	assert.NoError(t, err)
+	r := c.newCachingReader(context.Background(), "ref1", ioutil.NopCloser(strings.NewReader("hello")), true)
+
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	tmpFiles, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	assert.Empty(t, tmpFiles)
+}
@@ -21,12 +21,16 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/publicstorage/arweave"
 	"github.com/hyperledger/firefly/internal/publicstorage/ipfs"
+	"github.com/hyperledger/firefly/internal/publicstorage/s3"
 	"github.com/hyperledger/firefly/pkg/publicstorage"
 )
 
 var pluginsByName = map[string]func() publicstorage.Plugin{
-	(*ipfs.IPFS)(nil).Name(): func() publicstorage.Plugin { return &ipfs.IPFS{} },
+	(*ipfs.IPFS)(nil).Name():       func() publicstorage.Plugin { return &ipfs.IPFS{} },
+	(*arweave.Arweave)(nil).Name(): func() publicstorage.Plugin { return &arweave.Arweave{} },
+	(*s3.S3)(nil).Name():           func() publicstorage.Plugin { return &s3.S3{} },
 }
 
 func InitPrefix(prefix config.Prefix) {
@@ -35,6 +39,13 @@ func InitPrefix(prefix config.Prefix) {
 	}
 }
 
+// InitInstancesPrefix registers the keys common to all entries in the list of additional public storage instances.
+// The config of the plugin for each instance is registered as it is loaded, as it depends on the type of the entry
+func InitInstancesPrefix(prefix config.PrefixArray) {
+	prefix.AddKnownKey(publicstorage.InstanceConfigName)
+	prefix.AddKnownKey(publicstorage.InstanceConfigType)
+}
+
 func GetPlugin(ctx context.Context, pluginType string) (publicstorage.Plugin, error) {
 	plugin, ok := pluginsByName[pluginType]
 	if !ok {
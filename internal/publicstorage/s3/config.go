@@ -0,0 +1,44 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+)
+
+const (
+	// S3ConfBucket is the name of the bucket in which to store payloads, which must already exist
+	S3ConfBucket = "bucket"
+	// S3ConfRegion is the region used to sign requests. MinIO and most other S3-compatible stores accept the default
+	S3ConfRegion = "region"
+	// S3ConfAccessKeyID is the access key used to sign requests. Requests are not signed if this is not set
+	S3ConfAccessKeyID = "accessKeyId"
+	// S3ConfSecretAccessKey is the secret key used to sign requests
+	S3ConfSecretAccessKey = "secretAccessKey"
+	// S3ConfKeyPrefix is an optional prefix added to the key of every object, allowing a bucket to be shared
+	S3ConfKeyPrefix = "keyPrefix"
+)
+
+func (s *S3) InitPrefix(prefix config.Prefix) {
+	restclient.InitPrefix(prefix)
+	prefix.AddKnownKey(S3ConfBucket)
+	prefix.AddKnownKey(S3ConfRegion, "us-east-1")
+	prefix.AddKnownKey(S3ConfAccessKeyID)
+	prefix.AddKnownKey(S3ConfSecretAccessKey)
+	prefix.AddKnownKey(S3ConfKeyPrefix)
+}
@@ -0,0 +1,204 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/internal/secrets"
+	"github.com/hyperledger/firefly/internal/sigv4"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+)
+
+// S3 stores payloads as objects in an S3-compatible bucket (AWS S3, MinIO etc.), using path-style requests.
+// Each payload is keyed by the hex SHA256 hash of its content, which is returned as the payload reference.
+type S3 struct {
+	ctx             context.Context
+	capabilities    *publicstorage.Capabilities
+	callbacks       publicstorage.Callbacks
+	client          *resty.Client
+	host            string
+	basePath        string
+	bucket          string
+	region          string
+	credentialsMux  sync.Mutex
+	accessKeyID     string
+	secretAccessKey string
+	keyPrefix       string
+}
+
+func (s *S3) Name() string {
+	return "s3"
+}
+
+func (s *S3) Init(ctx context.Context, prefix config.Prefix, callbacks publicstorage.Callbacks) error {
+
+	s.ctx = log.WithLogField(log.WithModule(ctx, "publicstorage"), "publicstorage", "s3")
+	s.callbacks = callbacks
+
+	baseURL := prefix.GetString(restclient.HTTPConfigURL)
+	if baseURL == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(restclient.HTTPConfigURL), "s3")
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgInvalidURL, baseURL)
+	}
+	s.host = u.Host
+	s.basePath = u.Path
+	s.bucket = prefix.GetString(S3ConfBucket)
+	if s.bucket == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(S3ConfBucket), "s3")
+	}
+	s.region = prefix.GetString(S3ConfRegion)
+	// The credentials can refer to secrets, and are applied to the next request when they are rotated
+	if s.accessKeyID, err = secrets.Watch(s.ctx, prefix.GetString(S3ConfAccessKeyID), func(accessKeyID string) {
+		s.credentialsMux.Lock()
+		defer s.credentialsMux.Unlock()
+		s.accessKeyID = accessKeyID
+	}); err != nil {
+		return err
+	}
+	if s.secretAccessKey, err = secrets.Watch(s.ctx, prefix.GetString(S3ConfSecretAccessKey), func(secretAccessKey string) {
+		s.credentialsMux.Lock()
+		defer s.credentialsMux.Unlock()
+		s.secretAccessKey = secretAccessKey
+	}); err != nil {
+		return err
+	}
+	s.keyPrefix = prefix.GetString(S3ConfKeyPrefix)
+	s.client = restclient.New(s.ctx, prefix)
+	s.capabilities = &publicstorage.Capabilities{}
+	return nil
+}
+
+func (s *S3) Capabilities() *publicstorage.Capabilities {
+	return s.capabilities
+}
+
+func (s *S3) objectPath(key string) string {
+	return path.Join("/", s.basePath, s.bucket, s.keyPrefix+key)
+}
+
+// newRequest builds a request for an object, signed if credentials are configured
+func (s *S3) newRequest(ctx context.Context, method, objectPath, payloadHash string) *resty.Request {
+	req := s.client.R().SetContext(ctx)
+	s.credentialsMux.Lock()
+	defer s.credentialsMux.Unlock()
+	if s.accessKeyID != "" {
+		now := time.Now()
+		headers := map[string]string{
+			"host":                 s.host,
+			"x-amz-content-sha256": payloadHash,
+			"x-amz-date":           now.UTC().Format(sigv4.DateFormat),
+		}
+		req.SetHeader("x-amz-content-sha256", headers["x-amz-content-sha256"])
+		req.SetHeader("x-amz-date", headers["x-amz-date"])
+		req.SetHeader("Authorization", sigv4.Sign(s.accessKeyID, s.secretAccessKey, s.region, "s3", method, objectPath, headers, payloadHash, now))
+	}
+	return req
+}
+
+func (s *S3) PublishData(ctx context.Context, data io.Reader) (string, error) {
+	// We need the hash of the content before we upload, as it is the key of the object
+	payload, err := ioutil.ReadAll(data)
+	if err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgS3RESTErr, err)
+	}
+	payloadRef := sigv4.SHA256Hex(payload)
+	if err := s.PutObject(ctx, payloadRef, payload); err != nil {
+		return "", err
+	}
+	log.L(ctx).Infof("S3 published %s Size=%d", payloadRef, len(payload))
+	return payloadRef, nil
+}
+
+func (s *S3) RetrieveData(ctx context.Context, payloadRef string) (data io.ReadCloser, err error) {
+	payload, err := s.GetObject(ctx, payloadRef)
+	if err != nil {
+		return nil, err
+	}
+	// The object key is the hash of the content, so we check the object has not been modified in the bucket
+	if hash := sigv4.SHA256Hex(payload); hash != payloadRef {
+		log.L(ctx).Errorf("S3 object %s has hash %s", payloadRef, hash)
+		return nil, i18n.NewError(ctx, i18n.MsgPublicStorageHashMismatch, s.Name(), payloadRef)
+	}
+	log.L(ctx).Infof("S3 retrieved %s Size=%d", payloadRef, len(payload))
+	return ioutil.NopCloser(bytes.NewReader(payload)), nil
+}
+
+// PutObject writes an object with the supplied key (after the configured key prefix) to the bucket
+func (s *S3) PutObject(ctx context.Context, key string, data []byte) error {
+	objectPath := s.objectPath(key)
+	res, err := s.newRequest(ctx, "PUT", objectPath, sigv4.SHA256Hex(data)).
+		SetHeader("Content-Type", "application/octet-stream").
+		SetBody(data).
+		Put(objectPath)
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(s.ctx, res, err, i18n.MsgS3RESTErr)
+	}
+	return nil
+}
+
+// GetObject reads an object with the supplied key (after the configured key prefix) from the bucket
+func (s *S3) GetObject(ctx context.Context, key string) ([]byte, error) {
+	objectPath := s.objectPath(key)
+	res, err := s.newRequest(ctx, "GET", objectPath, sigv4.EmptyPayloadHash).
+		SetDoNotParseResponse(true).
+		Get(objectPath)
+	restclient.OnAfterResponse(s.client, res) // required using SetDoNotParseResponse
+	if err != nil || !res.IsSuccess() {
+		if res != nil && res.RawBody() != nil {
+			_ = res.RawBody().Close()
+		}
+		return nil, restclient.WrapRestErr(s.ctx, res, err, i18n.MsgS3RESTErr)
+	}
+	defer res.RawBody().Close()
+	data, err := ioutil.ReadAll(res.RawBody())
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgS3RESTErr, err)
+	}
+	return data, nil
+}
+
+func (s *S3) PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) error {
+	return i18n.NewError(ctx, i18n.MsgPinningNotSupported, s.Name())
+}
+
+// Ping checks the bucket exists, and can be accessed with the configured credentials
+func (s *S3) Ping(ctx context.Context) error {
+	bucketPath := path.Join("/", s.basePath, s.bucket)
+	res, err := s.newRequest(ctx, "HEAD", bucketPath, sigv4.EmptyPayloadHash).
+		Head(bucketPath)
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(s.ctx, res, err, i18n.MsgS3RESTErr)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+)
+
+// sweepInterval is how often buckets for identities that have gone idle are discarded
+const sweepInterval = 1 * time.Minute
+
+// Limiter decides whether an API request can proceed, using a token bucket shared by all
+// callers, and a token bucket for each calling identity.
+type Limiter interface {
+	// Allow consumes a token for the identity, or returns how long the caller should wait before retrying
+	Allow(identity string) (ok bool, retryAfter time.Duration)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type limiter struct {
+	globalRate    float64
+	globalBurst   float64
+	identityRate  float64
+	identityBurst float64
+	now           func() time.Time
+
+	mux        sync.Mutex
+	global     *bucket
+	identities map[string]*bucket
+	lastSweep  time.Time
+}
+
+// NewLimiter creates a limiter configured from the api.rateLimit section of the config,
+// or returns nil if rate limiting is disabled. A rate of zero means that rate is not limited.
+func NewLimiter() Limiter {
+	if !config.GetBool(config.APIRateLimitEnabled) {
+		return nil
+	}
+	l := &limiter{
+		globalRate:    config.GetFloat64(config.APIRateLimitRequestsPerSecond),
+		globalBurst:   float64(config.GetInt(config.APIRateLimitBurst)),
+		identityRate:  config.GetFloat64(config.APIRateLimitIdentityRequestsPerSecond),
+		identityBurst: float64(config.GetInt(config.APIRateLimitIdentityBurst)),
+		now:           time.Now,
+		identities:    make(map[string]*bucket),
+	}
+	l.lastSweep = l.now()
+	l.global = &bucket{tokens: l.globalBurst, last: l.lastSweep}
+	return l
+}
+
+// take refills the bucket for the time elapsed since it was last used, then consumes a token if one is available
+func (b *bucket) take(now time.Time, rate, burst float64) (bool, time.Duration) {
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+func (l *limiter) Allow(identity string) (bool, time.Duration) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	now := l.now()
+	l.sweepLocked(now)
+
+	// Check the identity first, so a caller that is over its own limit does not use up the shared allowance
+	var ib *bucket
+	if l.identityRate > 0 {
+		ib = l.identities[identity]
+		if ib == nil {
+			ib = &bucket{tokens: l.identityBurst, last: now}
+			l.identities[identity] = ib
+		}
+		if ok, retryAfter := ib.take(now, l.identityRate, l.identityBurst); !ok {
+			return false, retryAfter
+		}
+	}
+	if l.globalRate > 0 {
+		if ok, retryAfter := l.global.take(now, l.globalRate, l.globalBurst); !ok {
+			if ib != nil {
+				// Return the token to the identity, as the request is not going to be processed
+				ib.tokens++
+			}
+			return false, retryAfter
+		}
+	}
+	return true, 0
+}
+
+// sweepLocked discards the buckets of identities that have been idle long enough to refill,
+// as a new full bucket would be created for them on their next request anyway
+func (l *limiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for identity, b := range l.identities {
+		if b.tokens+now.Sub(b.last).Seconds()*l.identityRate >= l.identityBurst {
+			delete(l.identities, identity)
+		}
+	}
+}
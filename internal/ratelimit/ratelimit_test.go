@@ -0,0 +1,101 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter(globalRate, globalBurst, identityRate, identityBurst int) (*limiter, *time.Time) {
+	config.Reset()
+	config.Set(config.APIRateLimitEnabled, true)
+	config.Set(config.APIRateLimitRequestsPerSecond, globalRate)
+	config.Set(config.APIRateLimitBurst, globalBurst)
+	config.Set(config.APIRateLimitIdentityRequestsPerSecond, identityRate)
+	config.Set(config.APIRateLimitIdentityBurst, identityBurst)
+	l := NewLimiter().(*limiter)
+	now := l.lastSweep
+	l.now = func() time.Time { return now }
+	l.global.last = now
+	return l, &now
+}
+
+func TestDisabled(t *testing.T) {
+	config.Reset()
+	assert.Nil(t, NewLimiter())
+}
+
+func TestIdentityLimit(t *testing.T) {
+	l, now := newTestLimiter(0, 0, 2, 2)
+
+	ok, _ := l.Allow("id1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("id1")
+	assert.True(t, ok)
+	ok, retryAfter := l.Allow("id1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// Other identities have their own allowance
+	ok, _ = l.Allow("id2")
+	assert.True(t, ok)
+
+	*now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("id1")
+	assert.True(t, ok)
+}
+
+func TestGlobalLimit(t *testing.T) {
+	l, now := newTestLimiter(1, 2, 10, 1)
+
+	ok, _ := l.Allow("id1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("id2")
+	assert.True(t, ok)
+	ok, retryAfter := l.Allow("id3")
+	assert.False(t, ok)
+	assert.Equal(t, 1*time.Second, retryAfter)
+
+	// The identity token is returned when the global limit rejects the request
+	assert.Equal(t, float64(1), l.identities["id3"].tokens)
+
+	*now = now.Add(1 * time.Second)
+	ok, _ = l.Allow("id3")
+	assert.True(t, ok)
+}
+
+func TestSweepIdleIdentities(t *testing.T) {
+	l, now := newTestLimiter(0, 0, 1, 100)
+
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("id1")
+		assert.True(t, ok)
+	}
+	ok, _ := l.Allow("id2")
+	assert.True(t, ok)
+
+	// id2 has refilled by the time of the sweep, but id1 has not
+	*now = now.Add(sweepInterval)
+	_, _ = l.Allow("id3")
+	assert.Contains(t, l.identities, "id1")
+	assert.NotContains(t, l.identities, "id2")
+	assert.Contains(t, l.identities, "id3")
+}
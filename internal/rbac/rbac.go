@@ -0,0 +1,137 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/auth"
+)
+
+// AllNamespaces is the namespace of a grant that applies to every namespace. Resources that do not belong
+// to a namespace, such as the network map and node status, can only be accessed with a grant for all namespaces.
+const AllNamespaces = "*"
+
+// Role is a level of access to a namespace. Each role includes the access of all the roles below it.
+type Role int
+
+const (
+	// RoleNone is no access
+	RoleNone Role = iota
+	// RoleReader can query resources, and listen for events
+	RoleReader
+	// RoleSender can also submit messages, data, transactions, and manage subscriptions
+	RoleSender
+	// RoleAdmin can also manage namespaces and network registration, and use the admin API
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleNone:   "none",
+	RoleReader: "reader",
+	RoleSender: "sender",
+	RoleAdmin:  "admin",
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// ParseRole returns the role with the given name
+func ParseRole(name string) (Role, bool) {
+	for role, roleName := range roleNames {
+		if role != RoleNone && roleName == name {
+			return role, true
+		}
+	}
+	return RoleNone, false
+}
+
+// Authorizer checks that the caller has been granted a role for a namespace
+type Authorizer interface {
+	// Authorize checks that the identity authenticated on the context holds at least the given role for the namespace
+	Authorize(ctx context.Context, namespace string, role Role) error
+}
+
+type grantKey struct {
+	identity  string
+	namespace string
+}
+
+type authorizer struct {
+	grants map[grantKey]Role
+}
+
+// NewAuthorizer creates an authorizer for the grants in the auth.rbac section of the config,
+// or returns nil if role based access control is disabled
+func NewAuthorizer(ctx context.Context) (Authorizer, error) {
+	if !config.GetBool(config.AuthRBACEnabled) {
+		return nil, nil
+	}
+	a := &authorizer{
+		grants: make(map[grantKey]Role),
+	}
+	for i, entry := range config.GetObjectArray(config.AuthRBACGrants) {
+		identity := entry.GetString("identity")
+		role, ok := ParseRole(entry.GetString("role"))
+		if identity == "" || !ok {
+			return nil, i18n.NewError(ctx, i18n.MsgInvalidRBACGrant, i)
+		}
+		namespace := entry.GetString("namespace")
+		if namespace == "" {
+			namespace = AllNamespaces
+		}
+		key := grantKey{identity: identity, namespace: namespace}
+		if role > a.grants[key] {
+			a.grants[key] = role
+		}
+	}
+	return a, nil
+}
+
+func (a *authorizer) Authorize(ctx context.Context, namespace string, role Role) error {
+	identity := auth.GetIdentity(ctx)
+	if namespace == "" {
+		namespace = AllNamespaces
+	}
+	granted := a.grants[grantKey{identity: identity, namespace: AllNamespaces}]
+	if nsRole := a.grants[grantKey{identity: identity, namespace: namespace}]; nsRole > granted {
+		granted = nsRole
+	}
+	if granted < role {
+		return i18n.NewError(ctx, i18n.MsgForbidden, identity, role, namespace)
+	}
+	return nil
+}
+
+type authorizerContextKey struct{}
+
+// WithAuthorizer returns a context carrying the authorizer, for checks that are made after the request
+// has been dispatched, such as starting subscriptions on a websocket
+func WithAuthorizer(ctx context.Context, a Authorizer) context.Context {
+	return context.WithValue(ctx, authorizerContextKey{}, a)
+}
+
+// Authorize checks the role using the authorizer on the context. All access is allowed when there is no authorizer.
+func Authorize(ctx context.Context, namespace string, role Role) error {
+	if a, ok := ctx.Value(authorizerContextKey{}).(Authorizer); ok && a != nil {
+		return a.Authorize(ctx, namespace, role)
+	}
+	return nil
+}
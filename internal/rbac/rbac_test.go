@@ -0,0 +1,102 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/auth"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAuthorizer(t *testing.T, grants fftypes.JSONObjectArray) Authorizer {
+	config.Reset()
+	config.Set(config.AuthRBACEnabled, true)
+	config.Set(config.AuthRBACGrants, grants)
+	a, err := NewAuthorizer(context.Background())
+	assert.NoError(t, err)
+	return a
+}
+
+func TestRoles(t *testing.T) {
+	role, ok := ParseRole("sender")
+	assert.True(t, ok)
+	assert.Equal(t, RoleSender, role)
+	assert.Equal(t, "sender", role.String())
+	_, ok = ParseRole("none")
+	assert.False(t, ok)
+	_, ok = ParseRole("superuser")
+	assert.False(t, ok)
+}
+
+func TestDisabled(t *testing.T) {
+	config.Reset()
+	a, err := NewAuthorizer(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+}
+
+func TestInvalidGrant(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthRBACEnabled, true)
+	config.Set(config.AuthRBACGrants, fftypes.JSONObjectArray{
+		{"identity": "app1", "role": "reader"},
+		{"identity": "app1", "role": "superuser"},
+	})
+	_, err := NewAuthorizer(context.Background())
+	assert.Regexp(t, "FF10493.*1", err)
+}
+
+func TestAuthorize(t *testing.T) {
+	a := newTestAuthorizer(t, fftypes.JSONObjectArray{
+		{"identity": "app1", "namespace": "ns1", "role": "sender"},
+		{"identity": "app1", "namespace": "ns1", "role": "reader"},
+		{"identity": "app1", "namespace": "*", "role": "reader"},
+		{"identity": "ops", "role": "admin"},
+	})
+	app1 := auth.WithIdentity(context.Background(), "app1")
+	ops := auth.WithIdentity(context.Background(), "ops")
+
+	// The highest role granted for a namespace applies
+	assert.NoError(t, a.Authorize(app1, "ns1", RoleSender))
+	assert.Regexp(t, "FF10492.*app1.*admin.*ns1", a.Authorize(app1, "ns1", RoleAdmin))
+
+	// Grants for all namespaces apply to every namespace, and to resources outside of namespaces
+	assert.NoError(t, a.Authorize(app1, "ns2", RoleReader))
+	assert.Regexp(t, "FF10492.*app1.*sender.*ns2", a.Authorize(app1, "ns2", RoleSender))
+	assert.NoError(t, a.Authorize(app1, "", RoleReader))
+	assert.NoError(t, a.Authorize(ops, "", RoleAdmin))
+	assert.NoError(t, a.Authorize(ops, "ns2", RoleAdmin))
+
+	// Identities with no grants have no access
+	assert.Regexp(t, "FF10492", a.Authorize(context.Background(), "ns1", RoleReader))
+}
+
+func TestAuthorizeFromContext(t *testing.T) {
+	a := newTestAuthorizer(t, fftypes.JSONObjectArray{
+		{"identity": "app1", "namespace": "ns1", "role": "reader"},
+	})
+	ctx := auth.WithIdentity(context.Background(), "app1")
+	assert.NoError(t, Authorize(ctx, "ns2", RoleAdmin))
+
+	ctx = WithAuthorizer(ctx, a)
+	assert.NoError(t, Authorize(ctx, "ns1", RoleReader))
+	assert.Regexp(t, "FF10492", Authorize(ctx, "ns2", RoleReader))
+}
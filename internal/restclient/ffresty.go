@@ -30,7 +30,11 @@ import (
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type retryCtxKey struct{}
@@ -39,6 +43,7 @@ type retryCtx struct {
 	id       string
 	start    time.Time
 	attempts uint
+	span     trace.Span
 }
 
 // OnAfterResponse when using SetDoNotParseResponse(true) for streming binary replies,
@@ -53,6 +58,29 @@ func OnAfterResponse(c *resty.Client, resp *resty.Response) {
 	rc := rctx.Value(retryCtxKey{}).(*retryCtx)
 	elapsed := float64(time.Since(rc.start)) / float64(time.Millisecond)
 	log.L(rctx).Infof("<== %s %s [%d] (%.2fms)", resp.Request.Method, resp.Request.URL, resp.StatusCode(), elapsed)
+	if rc.span != nil {
+		rc.span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode()))
+		if resp.IsError() {
+			rc.span.SetStatus(codes.Error, resp.Status())
+		}
+		rc.span.End()
+	}
+}
+
+// startClientSpan starts a span for each attempt of the request, and passes its trace context to the server
+// in the request headers. The span is not set on the request context, so each retry is a sibling of the first attempt.
+func startClientSpan(rctx context.Context, req *resty.Request) {
+	rc := rctx.Value(retryCtxKey{}).(*retryCtx)
+	if rc.span != nil {
+		// The previous attempt failed without a response
+		rc.span.End()
+	}
+	var spanCtx context.Context
+	spanCtx, rc.span = tracing.StartSpan(rctx, "HTTP "+req.Method,
+		attribute.String("http.method", req.Method),
+		attribute.String("http.url", req.URL),
+	)
+	tracing.Inject(spanCtx, req.Header)
 }
 
 // New creates a new Resty client, using static configuration (from the config file)
@@ -111,30 +139,53 @@ func New(ctx context.Context, staticConfig config.Prefix) *resty.Client {
 				id:    fftypes.ShortID(),
 				start: time.Now(),
 			}
-			rctx = context.WithValue(rctx, retryCtxKey{}, r)
 			// Create a request logger from the root logger passed into the client
 			l := log.L(ctx).WithField("breq", r.id)
+			// Forward the ID of the API request this call is made for, if there is one
+			if httpReqID := log.GetField(rctx, log.RequestIDField); httpReqID != "" {
+				req.SetHeader(fftypes.HTTPHeadersRequestID, httpReqID)
+				l = l.WithField(log.RequestIDField, httpReqID)
+			}
+			rctx = context.WithValue(rctx, retryCtxKey{}, r)
 			rctx = log.WithLogger(rctx, l)
 			req.SetContext(rctx)
 		}
 		log.L(rctx).Infof("==> %s %s%s", req.Method, url, req.URL)
+		startClientSpan(rctx, req)
 		return nil
 	})
 
+	client.OnError(func(req *resty.Request, err error) {
+		if rc, ok := req.Context().Value(retryCtxKey{}).(*retryCtx); ok && rc.span != nil {
+			tracing.EndSpan(rc.span, err)
+		}
+	})
+
 	// Note that callers using SetNotParseResponse will need to invoke this themselves
 
 	client.OnAfterResponse(func(c *resty.Client, r *resty.Response) error { OnAfterResponse(c, r); return nil })
 
+	// Headers that refer to secrets are set on each request, so that rotated secrets are used without a restart
+	sh := &secretHeaders{headers: make(map[string]string)}
 	headers := staticConfig.GetObject(HTTPConfigHeaders)
 	for k, v := range headers {
 		if vs, ok := v.(string); ok {
-			client.SetHeader(k, vs)
+			sh.setHeader(ctx, client, k, vs, func(value string) string { return value })
 		}
 	}
 	authUsername := staticConfig.GetString((HTTPConfigAuthUsername))
 	authPassword := staticConfig.GetString((HTTPConfigAuthPassword))
 	if authUsername != "" && authPassword != "" {
-		client.SetHeader("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", authUsername, authPassword)))))
+		// The username and password are resolved together, so a rotated pair of credentials is applied at once
+		sh.setHeader(ctx, client, "Authorization", fmt.Sprintf("%s:%s", authUsername, authPassword), func(credentials string) string {
+			return fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(credentials)))
+		})
+	}
+	if sh.watched {
+		client.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
+			sh.apply(req)
+			return nil
+		})
 	}
 
 	if staticConfig.GetBool(HTTPConfigRetryEnabled) {
@@ -25,8 +25,16 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/tracing"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var utConfPrefix = config.NewPluginConfig("http_unit_tests")
@@ -95,6 +103,90 @@ func TestRequestRetry(t *testing.T) {
 
 }
 
+func TestRequestIDForwarded(t *testing.T) {
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
+
+	c := New(context.Background(), utConfPrefix)
+	httpmock.ActivateNonDefault(c.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	var reqIDs []string
+	httpmock.RegisterResponder("GET", "http://localhost:12345/test",
+		func(req *http.Request) (*http.Response, error) {
+			reqIDs = append(reqIDs, req.Header.Get(fftypes.HTTPHeadersRequestID))
+			return httpmock.NewStringResponder(200, `{}`)(req)
+		})
+
+	ctx := log.WithLogField(context.Background(), log.RequestIDField, "req12345")
+	_, err := c.R().SetContext(ctx).Get("/test")
+	assert.NoError(t, err)
+	_, err = c.R().Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"req12345", ""}, reqIDs)
+}
+
+func TestRequestTracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(HTTPConfigRetryEnabled, true)
+	utConfPrefix.Set(HTTPConfigRetryInitDelay, 1)
+	utConfPrefix.Set(HTTPConfigRetryCount, 1)
+
+	c := New(context.Background(), utConfPrefix)
+	httpmock.ActivateNonDefault(c.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	traceparents := map[string]bool{}
+	httpmock.RegisterResponder("GET", "http://localhost:12345/test",
+		func(req *http.Request) (*http.Response, error) {
+			traceparents[req.Header.Get("traceparent")] = true
+			return httpmock.NewStringResponder(500, `{"message": "pop"}`)(req)
+		})
+
+	ctx, parent := tracing.StartSpan(context.Background(), "parent")
+	resp, err := c.R().SetContext(ctx).Get("/test")
+	parent.End()
+	assert.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode())
+
+	// One span per attempt, each a child of the caller's span
+	spans := sr.Ended()
+	assert.Len(t, spans, 3)
+	assert.Len(t, traceparents, 2)
+	for _, span := range spans[0:2] {
+		assert.Equal(t, "HTTP GET", span.Name())
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+}
+
+func TestRequestTracingError(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
+
+	c := New(context.Background(), utConfPrefix)
+	httpmock.ActivateNonDefault(c.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/test", httpmock.NewErrorResponder(fmt.Errorf("pop")))
+
+	_, err := c.R().Get("/test")
+	assert.Regexp(t, "pop", err)
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
 func TestConfWithProxy(t *testing.T) {
 
 	ctx := context.Background()
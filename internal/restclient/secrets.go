@@ -0,0 +1,65 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/secrets"
+)
+
+// secretHeaders holds the headers of a client that are resolved from secrets, which change when a secret is rotated
+type secretHeaders struct {
+	mux     sync.Mutex
+	headers map[string]string
+	watched bool
+}
+
+// setHeader sets a header on the client, or watches the secrets it refers to. The format function builds the
+// header from the resolved value.
+func (sh *secretHeaders) setHeader(ctx context.Context, client *resty.Client, name, value string, format func(resolved string) string) {
+	if !secrets.HasReference(value) {
+		client.SetHeader(name, format(value))
+		return
+	}
+	resolved, err := secrets.Watch(ctx, value, func(resolved string) {
+		log.L(ctx).Infof("Applying rotated secret to header %s", name)
+		sh.mux.Lock()
+		defer sh.mux.Unlock()
+		sh.headers[name] = format(resolved)
+	})
+	if err != nil {
+		// The secrets in the config are fetched at startup, so this only happens if the secrets store fails afterwards
+		log.L(ctx).Errorf("Failed to resolve secret for header %s: %s", name, err)
+		return
+	}
+	sh.mux.Lock()
+	defer sh.mux.Unlock()
+	sh.headers[name] = format(resolved)
+	sh.watched = true
+}
+
+func (sh *secretHeaders) apply(req *resty.Request) {
+	sh.mux.Lock()
+	defer sh.mux.Unlock()
+	for name, value := range sh.headers {
+		req.SetHeader(name, value)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restclient
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/secrets"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestSecretHeadersRotated(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	apiKeyFile := filepath.Join(dir, "apikey")
+	err := ioutil.WriteFile(passwordFile, []byte("pass"), 0600)
+	assert.NoError(t, err)
+	err = ioutil.WriteFile(apiKeyFile, []byte("key1"), 0600)
+	assert.NoError(t, err)
+
+	customClient := &http.Client{}
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(HTTPConfigHeaders, map[string]interface{}{
+		"x-api-key":  fmt.Sprintf("${secret:file:%s}", apiKeyFile),
+		"someheader": "headervalue",
+	})
+	utConfPrefix.Set(HTTPConfigAuthUsername, "user")
+	utConfPrefix.Set(HTTPConfigAuthPassword, fmt.Sprintf("${secret:file:%s}", passwordFile))
+	utConfPrefix.Set(HTTPCustomClient, customClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := New(ctx, utConfPrefix)
+	httpmock.ActivateNonDefault(customClient)
+	defer httpmock.DeactivateAndReset()
+
+	var apiKey, auth string
+	httpmock.RegisterResponder("GET", "http://localhost:12345/test",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "headervalue", req.Header.Get("someheader"))
+			apiKey = req.Header.Get("x-api-key")
+			auth = req.Header.Get("Authorization")
+			return httpmock.NewStringResponder(200, `{}`)(req)
+		})
+
+	_, err = c.R().Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", apiKey)
+	assert.Equal(t, "Basic dXNlcjpwYXNz", auth)
+
+	err = ioutil.WriteFile(passwordFile, []byte("pass2"), 0600)
+	assert.NoError(t, err)
+	err = ioutil.WriteFile(apiKeyFile, []byte("key2"), 0600)
+	assert.NoError(t, err)
+	secrets.Refresh(context.Background())
+
+	_, err = c.R().Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, "key2", apiKey)
+	assert.Equal(t, "Basic dXNlcjpwYXNzMg==", auth)
+}
+
+func TestRequestSecretHeaderFail(t *testing.T) {
+	customClient := &http.Client{}
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(HTTPConfigHeaders, map[string]interface{}{
+		"x-api-key": fmt.Sprintf("${secret:file:%s}", filepath.Join(t.TempDir(), "missing")),
+	})
+	utConfPrefix.Set(HTTPCustomClient, customClient)
+
+	c := New(context.Background(), utConfPrefix)
+	httpmock.ActivateNonDefault(customClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/test",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Empty(t, req.Header.Get("x-api-key"))
+			return httpmock.NewStringResponder(200, `{}`)(req)
+		})
+	_, err := c.R().Get("/test")
+	assert.NoError(t, err)
+}
@@ -18,8 +18,11 @@ package retry
 
 import (
 	"context"
+	"math/rand"
+	"sync"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 )
@@ -28,12 +31,49 @@ const (
 	defaultFactor = 2.0
 )
 
-// Retry is a concurrency safe retry structure that configures a simple backoff retry mechanism
+// Retry is a concurrency safe retry structure that configures a simple backoff retry mechanism.
+//
+// Jitter is the fraction of each delay that is randomized, from 0 to 1, so that callers that fail at the same
+// time against the same backend do not all retry at the same time. The delay is never more than the maximum.
+//
+// An optional circuit breaker trips after BreakerThreshold consecutive failed attempts, across all the retry
+// loops using the structure. While it is open no attempts are made, until BreakerResetDelay has passed and a
+// single trial attempt is allowed through - which closes the breaker if it succeeds, and opens it again if not.
 type Retry struct {
-	InitialDelay time.Duration
-	MaximumDelay time.Duration
-	Factor       float64
-	ErrCallback  func(err error)
+	InitialDelay      time.Duration
+	MaximumDelay      time.Duration
+	Factor            float64
+	Jitter            float64
+	BreakerThreshold  int
+	BreakerResetDelay time.Duration
+	ErrCallback       func(err error)
+
+	mux       sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// Delays returns the current delay configuration
+func (r *Retry) Delays() (initialDelay, maximumDelay time.Duration, factor float64) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.InitialDelay, r.MaximumDelay, r.Factor
+}
+
+// SetDelays changes the delay configuration, for retry loops that start after the change
+func (r *Retry) SetDelays(initialDelay, maximumDelay time.Duration, factor float64) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.InitialDelay = initialDelay
+	r.MaximumDelay = maximumDelay
+	r.Factor = factor
+}
+
+// ReloadFromConfig updates the delay configuration when any of the keys are changed by reloading the config file
+func (r *Retry) ReloadFromConfig(ctx context.Context, initialDelayKey, maximumDelayKey, factorKey config.RootKey) {
+	config.OnReload(ctx, func(ctx context.Context) {
+		r.SetDelays(config.GetDuration(initialDelayKey), config.GetDuration(maximumDelayKey), config.GetFloat64(factorKey))
+	}, initialDelayKey, maximumDelayKey, factorKey)
 }
 
 // DoCustomLog disables the automatic attempt logging, so the caller should do logging for each attempt
@@ -46,14 +86,19 @@ func (r *Retry) DoCustomLog(ctx context.Context, f func(attempt int) (retry bool
 // you'll be using a closure for that.
 func (r *Retry) Do(ctx context.Context, logDescription string, f func(attempt int) (retry bool, err error)) error {
 	attempt := 0
-	delay := r.InitialDelay
-	factor := r.Factor
+	delay, maximumDelay, factor := r.Delays()
 	if factor < 1 { // Can't reduce
 		factor = defaultFactor
 	}
 	for {
-		attempt++
-		retry, err := f(attempt)
+		// While the circuit breaker is open the attempt is not made, and we wait for it to allow a trial attempt
+		retry := true
+		breakerWait, err := r.checkBreaker(ctx)
+		if err == nil {
+			attempt++
+			retry, err = f(attempt)
+			r.recordResult(ctx, err)
+		}
 		if err != nil && logDescription != "" {
 			log.L(ctx).Errorf("%s attempt %d: %s", logDescription, attempt, err)
 			if r.ErrCallback != nil {
@@ -74,18 +119,82 @@ func (r *Retry) Do(ctx context.Context, logDescription string, f func(attempt in
 		// Limit the delay based on the context deadline and maximum delay
 		deadline, dok := ctx.Deadline()
 		now := time.Now()
-		if delay > r.MaximumDelay {
-			delay = r.MaximumDelay
+		if delay > maximumDelay {
+			delay = maximumDelay
+		}
+		sleep := r.jitter(delay)
+		if breakerWait > 0 {
+			if dok && deadline.Before(now.Add(breakerWait)) {
+				// The breaker will still be open when the deadline passes
+				return err
+			}
+			sleep = breakerWait
 		}
 		if dok {
 			timeleft := deadline.Sub(now)
-			if timeleft < delay {
-				delay = timeleft
+			if timeleft < sleep {
+				sleep = timeleft
 			}
 		}
 
 		// Sleep and set the delay for next time
-		time.Sleep(delay)
-		delay = time.Duration(float64(delay) * factor)
+		time.Sleep(sleep)
+		if breakerWait == 0 {
+			delay = time.Duration(float64(delay) * factor)
+		}
+	}
+}
+
+// jitter randomly shortens the delay by up to the configured fraction
+func (r *Retry) jitter(delay time.Duration) time.Duration {
+	r.mux.Lock()
+	jitter := r.Jitter
+	r.mux.Unlock()
+	if jitter <= 0 {
+		return delay
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return delay - time.Duration(rand.Float64()*jitter*float64(delay)) // #nosec G404 -- jitter does not need a secure random source
+}
+
+// checkBreaker returns an error, and how long until a trial attempt will be allowed, if the circuit breaker is open.
+// Once the reset delay has passed, the first caller is allowed to make the trial attempt while the others keep waiting.
+func (r *Retry) checkBreaker(ctx context.Context) (time.Duration, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.BreakerThreshold <= 0 || r.failures < r.BreakerThreshold {
+		return 0, nil
+	}
+	now := time.Now()
+	if now.Before(r.openUntil) {
+		wait := r.openUntil.Sub(now)
+		return wait, i18n.NewError(ctx, i18n.MsgCircuitBreakerOpen, r.failures, wait)
+	}
+	r.openUntil = now.Add(r.BreakerResetDelay)
+	return 0, nil
+}
+
+// recordResult counts consecutive failed attempts, tripping the circuit breaker when they reach the threshold
+func (r *Retry) recordResult(ctx context.Context, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.BreakerThreshold <= 0 {
+		return
+	}
+	if err == nil {
+		if r.failures >= r.BreakerThreshold {
+			log.L(ctx).Infof("Circuit breaker closed after trial attempt succeeded")
+		}
+		r.failures = 0
+		return
+	}
+	r.failures++
+	if r.failures >= r.BreakerThreshold {
+		if r.failures == r.BreakerThreshold {
+			log.L(ctx).Warnf("Circuit breaker tripped after %d consecutive failures", r.failures)
+		}
+		r.openUntil = time.Now().Add(r.BreakerResetDelay)
 	}
 }
@@ -19,9 +19,13 @@ package retry
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,3 +69,99 @@ func TestRetryContextCancellled(t *testing.T) {
 	})
 	assert.Regexp(t, "FF10158", err)
 }
+
+func TestRetryReloadFromConfig(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "retrytest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	fileName := path.Join(tmpDir, "firefly.core.yaml")
+
+	config.Reset()
+	defer config.Reset()
+	err = ioutil.WriteFile(fileName, []byte("batch:\n  retry:\n    initDelay: 1s\n"), 0600)
+	assert.NoError(t, err)
+	err = config.ReadConfig(fileName)
+	assert.NoError(t, err)
+
+	r := &Retry{
+		InitialDelay: config.GetDuration(config.BatchRetryInitDelay),
+		MaximumDelay: config.GetDuration(config.BatchRetryMaxDelay),
+		Factor:       config.GetFloat64(config.BatchRetryFactor),
+	}
+	r.ReloadFromConfig(context.Background(), config.BatchRetryInitDelay, config.BatchRetryMaxDelay, config.BatchRetryFactor)
+
+	err = ioutil.WriteFile(fileName, []byte("batch:\n  retry:\n    initDelay: 5ms\n    maxDelay: 10ms\n    factor: 3\n"), 0600)
+	assert.NoError(t, err)
+	_, _, err = config.ReloadConfig(context.Background())
+	assert.NoError(t, err)
+
+	initialDelay, maximumDelay, factor := r.Delays()
+	assert.Equal(t, 5*time.Millisecond, initialDelay)
+	assert.Equal(t, 10*time.Millisecond, maximumDelay)
+	assert.Equal(t, 3.0, factor)
+}
+
+func TestRetryJitter(t *testing.T) {
+	r := &Retry{}
+	assert.Equal(t, 100*time.Millisecond, r.jitter(100*time.Millisecond))
+
+	r.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := r.jitter(100 * time.Millisecond)
+		assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+		assert.LessOrEqual(t, delay, 100*time.Millisecond)
+	}
+
+	r.Jitter = 2
+	for i := 0; i < 100; i++ {
+		delay := r.jitter(100 * time.Millisecond)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, 100*time.Millisecond)
+	}
+}
+
+func TestRetryCircuitBreakerOpenPastDeadline(t *testing.T) {
+	r := &Retry{
+		InitialDelay:      1 * time.Microsecond,
+		MaximumDelay:      1 * time.Microsecond,
+		Jitter:            1,
+		BreakerThreshold:  2,
+		BreakerResetDelay: 1 * time.Minute,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	calls := 0
+	err := r.Do(ctx, "unit test", func(i int) (retry bool, err error) {
+		calls++
+		return true, fmt.Errorf("pop")
+	})
+	assert.Regexp(t, "FF10509.*2", err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetryCircuitBreakerTrialAttempts(t *testing.T) {
+	var errs []error
+	r := &Retry{
+		BreakerThreshold:  1,
+		BreakerResetDelay: 10 * time.Millisecond,
+		ErrCallback: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+	err := r.Do(context.Background(), "unit test", func(i int) (retry bool, err error) {
+		if i < 3 {
+			return true, fmt.Errorf("pop")
+		}
+		return false, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.failures)
+	assert.EqualError(t, errs[0], "pop")
+	breakerErrs := 0
+	for _, err := range errs {
+		if assert.Regexp(t, "pop|FF10509", err) && err.Error() != "pop" {
+			breakerErrs++
+		}
+	}
+	assert.Equal(t, 2, breakerErrs)
+}
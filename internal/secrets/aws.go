@@ -0,0 +1,112 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/sigv4"
+)
+
+// awsProvider reads secrets from AWS Secrets Manager. The reference is the name or ARN of the secret,
+// and the value is the string of the current version of the secret.
+type awsProvider struct {
+	client          *resty.Client
+	host            string
+	path            string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	now             func() time.Time
+}
+
+type awsSecretValue struct {
+	SecretString string `json:"SecretString"`
+}
+
+func newAWSProvider(ctx context.Context, prefix config.Prefix, timeout time.Duration) (*awsProvider, error) {
+	region := prefix.GetString(AWSConfRegion)
+	endpoint := prefix.GetString(AWSConfURL)
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgInvalidURL, endpoint)
+	}
+	a := &awsProvider{
+		client:          resty.New().SetBaseURL(fmt.Sprintf("%s://%s", u.Scheme, u.Host)).SetTimeout(timeout),
+		host:            u.Host,
+		path:            u.Path,
+		region:          region,
+		accessKeyID:     prefix.GetString(AWSConfAccessKeyID),
+		secretAccessKey: prefix.GetString(AWSConfSecretAccessKey),
+		sessionToken:    prefix.GetString(AWSConfSessionToken),
+		now:             time.Now,
+	}
+	if a.path == "" {
+		a.path = "/"
+	}
+	return a, nil
+}
+
+func (a *awsProvider) name() string {
+	return "aws"
+}
+
+func (a *awsProvider) fetch(ctx context.Context, reference string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"SecretId": reference})
+	payloadHash := sigv4.SHA256Hex(body)
+	now := a.now()
+	headers := map[string]string{
+		"host":         a.host,
+		"content-type": "application/x-amz-json-1.1",
+		"x-amz-date":   now.UTC().Format(sigv4.DateFormat),
+		"x-amz-target": "secretsmanager.GetSecretValue",
+	}
+	if a.sessionToken != "" {
+		headers["x-amz-security-token"] = a.sessionToken
+	}
+	req := a.client.R().SetContext(ctx).SetBody(body)
+	for k, v := range headers {
+		if k != "host" {
+			req.SetHeader(k, v)
+		}
+	}
+	req.SetHeader("Authorization", sigv4.Sign(a.accessKeyID, a.secretAccessKey, a.region, "secretsmanager", "POST", a.path, headers, payloadHash, now))
+	res, err := req.Post(a.path)
+	if err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgSecretFetchFailed, reference, a.name())
+	}
+	if !res.IsSuccess() {
+		return "", providerRESTErr(ctx, a.name(), res)
+	}
+	// The response is not parsed by resty, as the content type is application/x-amz-json-1.1
+	var value awsSecretValue
+	if err := json.Unmarshal(res.Body(), &value); err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgSecretFetchFailed, reference, a.name())
+	}
+	return value.SecretString, nil
+}
@@ -0,0 +1,65 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+)
+
+var secretsConfig = config.NewPluginConfig("secrets")
+
+const (
+	// SecretsConfRefreshInterval how often every secret in use is fetched again, so rotated credentials are picked up. Zero disables refreshing
+	SecretsConfRefreshInterval = "refreshInterval"
+	// SecretsConfRequestTimeout the timeout for requests to the Vault and AWS Secrets Manager providers
+	SecretsConfRequestTimeout = "requestTimeout"
+
+	// VaultConfURL the address of the HashiCorp Vault server. The vault provider is only available when this is set
+	VaultConfURL = "vault.url"
+	// VaultConfToken the token used to authenticate with Vault
+	VaultConfToken = "vault.token"
+	// VaultConfNamespace the Vault Enterprise namespace to read secrets from
+	VaultConfNamespace = "vault.namespace"
+	// VaultConfMount the path the KV version 2 secrets engine is mounted at
+	VaultConfMount = "vault.mount"
+
+	// AWSConfRegion the region of AWS Secrets Manager. The aws provider is only available when this is set
+	AWSConfRegion = "aws.region"
+	// AWSConfURL overrides the endpoint of AWS Secrets Manager, which is otherwise derived from the region
+	AWSConfURL = "aws.url"
+	// AWSConfAccessKeyID the access key used to sign requests to AWS Secrets Manager
+	AWSConfAccessKeyID = "aws.accessKeyId"
+	// AWSConfSecretAccessKey the secret key used to sign requests to AWS Secrets Manager
+	AWSConfSecretAccessKey = "aws.secretAccessKey"
+	// AWSConfSessionToken the session token to send with temporary credentials
+	AWSConfSessionToken = "aws.sessionToken"
+)
+
+// InitConfig registers the config of the secrets providers
+func InitConfig() {
+	secretsConfig.AddKnownKey(SecretsConfRefreshInterval, "5m")
+	secretsConfig.AddKnownKey(SecretsConfRequestTimeout, "30s")
+	secretsConfig.AddKnownKey(VaultConfURL)
+	secretsConfig.AddKnownKey(VaultConfToken)
+	secretsConfig.AddKnownKey(VaultConfNamespace)
+	secretsConfig.AddKnownKey(VaultConfMount, "secret")
+	secretsConfig.AddKnownKey(AWSConfRegion)
+	secretsConfig.AddKnownKey(AWSConfURL)
+	secretsConfig.AddKnownKey(AWSConfAccessKeyID)
+	secretsConfig.AddKnownKey(AWSConfSecretAccessKey)
+	secretsConfig.AddKnownKey(AWSConfSessionToken)
+}
@@ -0,0 +1,41 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// fileProvider reads secrets from files, such as those mounted into a container by Kubernetes or Docker.
+// The reference is the path of the file, and trailing newlines are removed from the content.
+type fileProvider struct{}
+
+func (f *fileProvider) name() string {
+	return "file"
+}
+
+func (f *fileProvider) fetch(ctx context.Context, reference string) (string, error) {
+	b, err := ioutil.ReadFile(reference)
+	if err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgSecretFetchFailed, reference, f.name())
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
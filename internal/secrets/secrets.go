@@ -0,0 +1,270 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// referencePattern matches a reference to a secret within a config value, such as ${secret:vault:firefly/db#password}.
+// The reference is interpreted by the named provider, and the optional field selects a string field from a secret
+// that holds a JSON object. References can be embedded in a longer value, such as the password of a database URL.
+var referencePattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_-]+):([^}#]+)(#([^}]+))?\}`)
+
+// provider fetches the current value of a secret from a secrets store
+type provider interface {
+	name() string
+	fetch(ctx context.Context, reference string) (string, error)
+}
+
+// watcher is a config value that refers to secrets, used by a component that applies rotated secrets without a restart
+type watcher struct {
+	ctx      context.Context
+	value    string
+	resolved string
+	onRotate func(resolved string)
+}
+
+var (
+	mux       sync.Mutex
+	providers = map[string]provider{"file": &fileProvider{}}
+	cache     = map[string]string{}
+	watchers  []*watcher
+)
+
+// Init configures the secrets providers, and fetches every secret the config refers to so that a reference that
+// cannot be resolved fails startup. The secrets are then fetched again on the refresh interval until the context
+// is cancelled, and the components watching a secret that has been rotated are notified.
+func Init(ctx context.Context) error {
+	timeout := secretsConfig.GetDuration(SecretsConfRequestTimeout)
+	p := map[string]provider{"file": &fileProvider{}}
+	if secretsConfig.GetString(VaultConfURL) != "" {
+		p["vault"] = newVaultProvider(secretsConfig, timeout)
+	}
+	if secretsConfig.GetString(AWSConfRegion) != "" {
+		aws, err := newAWSProvider(ctx, secretsConfig, timeout)
+		if err != nil {
+			return err
+		}
+		p["aws"] = aws
+	}
+	mux.Lock()
+	providers = p
+	cache = make(map[string]string)
+	watchers = nil
+	mux.Unlock()
+
+	if err := resolveAll(ctx, config.GetConfig()); err != nil {
+		return err
+	}
+	if interval := secretsConfig.GetDuration(SecretsConfRefreshInterval); interval > 0 {
+		go refreshLoop(ctx, interval)
+	}
+	return nil
+}
+
+// resolveAll resolves every string in the config tree that refers to a secret
+func resolveAll(ctx context.Context, v interface{}) error {
+	var children []interface{}
+	switch vt := v.(type) {
+	case fftypes.JSONObject:
+		return resolveAll(ctx, map[string]interface{}(vt))
+	case map[string]interface{}:
+		for _, child := range vt {
+			children = append(children, child)
+		}
+	case fftypes.JSONObjectArray:
+		for _, child := range vt {
+			children = append(children, child)
+		}
+	case []interface{}:
+		children = vt
+	case []string:
+		for _, child := range vt {
+			children = append(children, child)
+		}
+	case string:
+		_, err := Resolve(ctx, vt)
+		return err
+	}
+	for _, child := range children {
+		if err := resolveAll(ctx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasReference returns true if the value refers to one or more secrets
+func HasReference(value string) bool {
+	return referencePattern.MatchString(value)
+}
+
+// Resolve returns the value with every reference to a secret replaced with the value of the secret.
+// Values that do not refer to secrets are returned unchanged.
+func Resolve(ctx context.Context, value string) (string, error) {
+	if !HasReference(value) {
+		return value, nil
+	}
+	var err error
+	resolved := referencePattern.ReplaceAllStringFunc(value, func(ref string) string {
+		var secret string
+		if err == nil {
+			secret, err = get(ctx, ref)
+		}
+		return secret
+	})
+	if err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// Watch resolves the value, then calls onRotate with the value resolved again each time one of the
+// secrets it refers to is rotated, until the context is cancelled
+func Watch(ctx context.Context, value string, onRotate func(resolved string)) (string, error) {
+	resolved, err := Resolve(ctx, value)
+	if err != nil || resolved == value {
+		return resolved, err
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	watchers = append(watchers, &watcher{
+		ctx:      ctx,
+		value:    value,
+		resolved: resolved,
+		onRotate: onRotate,
+	})
+	return resolved, nil
+}
+
+// get returns the value of a secret, which is only fetched from the provider the first time it is used
+func get(ctx context.Context, ref string) (string, error) {
+	mux.Lock()
+	secret, ok := cache[ref]
+	p := providers
+	mux.Unlock()
+	if ok {
+		return secret, nil
+	}
+	secret, err := fetch(ctx, p, ref)
+	if err != nil {
+		return "", err
+	}
+	log.L(ctx).Debugf("Fetched secret %s", ref)
+	mux.Lock()
+	cache[ref] = secret
+	mux.Unlock()
+	return secret, nil
+}
+
+func fetch(ctx context.Context, p map[string]provider, ref string) (string, error) {
+	parts := referencePattern.FindStringSubmatch(ref)
+	name, reference, field := parts[1], parts[2], parts[4]
+	prov, ok := p[name]
+	if !ok {
+		return "", i18n.NewError(ctx, i18n.MsgSecretProviderNotConfigured, name)
+	}
+	secret, err := prov.fetch(ctx, reference)
+	if err != nil || field == "" {
+		return secret, err
+	}
+	var fields map[string]interface{}
+	_ = json.Unmarshal([]byte(secret), &fields)
+	value, ok := fields[field].(string)
+	if !ok {
+		return "", i18n.NewError(ctx, i18n.MsgSecretFieldNotFound, reference, field)
+	}
+	return value, nil
+}
+
+func refreshLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.L(ctx).Debugf("Secrets refresh loop exiting")
+			return
+		case <-ticker.C:
+			Refresh(ctx)
+		}
+	}
+}
+
+// Refresh fetches every secret in use again, and notifies the watchers of the values that have changed.
+// A secret that cannot be fetched keeps its previous value, so an outage of a secrets store does not
+// break connections that are working with the current credentials.
+func Refresh(ctx context.Context) {
+	mux.Lock()
+	refs := make([]string, 0, len(cache))
+	for ref := range cache {
+		refs = append(refs, ref)
+	}
+	p := providers
+	mux.Unlock()
+
+	fetched := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		secret, err := fetch(ctx, p, ref)
+		if err != nil {
+			log.L(ctx).Errorf("Failed to refresh secret %s: %s", ref, err)
+			continue
+		}
+		fetched[ref] = secret
+	}
+
+	type rotation struct {
+		onRotate func(resolved string)
+		resolved string
+	}
+	var rotations []rotation
+	mux.Lock()
+	for ref, secret := range fetched {
+		if cache[ref] != secret {
+			log.L(ctx).Infof("Secret %s has been rotated", ref)
+			cache[ref] = secret
+		}
+	}
+	live := make([]*watcher, 0, len(watchers))
+	for _, w := range watchers {
+		if w.ctx.Err() != nil {
+			continue
+		}
+		live = append(live, w)
+		resolved := referencePattern.ReplaceAllStringFunc(w.value, func(ref string) string { return cache[ref] })
+		if resolved != w.resolved {
+			w.resolved = resolved
+			rotations = append(rotations, rotation{onRotate: w.onRotate, resolved: resolved})
+		}
+	}
+	watchers = live
+	mux.Unlock()
+
+	for _, r := range rotations {
+		r.onRotate(r.resolved)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// vaultProvider reads secrets from the KV version 2 secrets engine of HashiCorp Vault. The reference is the
+// path of the secret within the engine, and the secret is a JSON object of its fields - so a field is
+// normally selected with a reference such as ${secret:vault:firefly/db#password}.
+type vaultProvider struct {
+	client *resty.Client
+	mount  string
+}
+
+type vaultSecret struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+func newVaultProvider(prefix config.Prefix, timeout time.Duration) *vaultProvider {
+	client := resty.New().
+		SetBaseURL(strings.TrimSuffix(prefix.GetString(VaultConfURL), "/")).
+		SetTimeout(timeout).
+		SetHeader("X-Vault-Token", prefix.GetString(VaultConfToken))
+	if namespace := prefix.GetString(VaultConfNamespace); namespace != "" {
+		client.SetHeader("X-Vault-Namespace", namespace)
+	}
+	return &vaultProvider{
+		client: client,
+		mount:  strings.Trim(prefix.GetString(VaultConfMount), "/"),
+	}
+}
+
+func (v *vaultProvider) name() string {
+	return "vault"
+}
+
+func (v *vaultProvider) fetch(ctx context.Context, reference string) (string, error) {
+	var secret vaultSecret
+	res, err := v.client.R().
+		SetContext(ctx).
+		SetResult(&secret).
+		Get(fmt.Sprintf("/v1/%s/data/%s", v.mount, strings.TrimPrefix(reference, "/")))
+	if err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgSecretFetchFailed, reference, v.name())
+	}
+	if !res.IsSuccess() {
+		return "", providerRESTErr(ctx, v.name(), res)
+	}
+	b, _ := json.Marshal(secret.Data.Data)
+	return string(b), nil
+}
+
+// providerRESTErr reports an error response from a secrets store, which never contains the value of a secret
+func providerRESTErr(ctx context.Context, name string, res *resty.Response) error {
+	body := res.String()
+	if len(body) > 256 {
+		body = body[0:256] + "..."
+	}
+	return i18n.NewError(ctx, i18n.MsgSecretProviderRESTErr, name, fmt.Sprintf("[%d] %s", res.StatusCode(), body))
+}
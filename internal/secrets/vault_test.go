@@ -0,0 +1,100 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestVault(t *testing.T) *vaultProvider {
+	config.Reset()
+	InitConfig()
+	secretsConfig.Set(VaultConfURL, "https://vault.example.com/")
+	secretsConfig.Set(VaultConfToken, "vault-token")
+	secretsConfig.Set(VaultConfNamespace, "ns1")
+	v := newVaultProvider(secretsConfig, 1*time.Second)
+	httpmock.ActivateNonDefault(v.client.GetClient())
+	t.Cleanup(httpmock.DeactivateAndReset)
+	return v
+}
+
+func TestVaultFetch(t *testing.T) {
+	v := newTestVault(t)
+	httpmock.RegisterResponder("GET", "https://vault.example.com/v1/secret/data/firefly/db",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "vault-token", req.Header.Get("X-Vault-Token"))
+			assert.Equal(t, "ns1", req.Header.Get("X-Vault-Namespace"))
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
+				"data": fftypes.JSONObject{
+					"data":     fftypes.JSONObject{"password": "s3cr3t"},
+					"metadata": fftypes.JSONObject{"version": 2},
+				},
+			})(req)
+		})
+	secret, err := v.fetch(context.Background(), "/firefly/db")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"password":"s3cr3t"}`, secret)
+}
+
+func TestVaultFetchField(t *testing.T) {
+	v := newTestVault(t)
+	httpmock.RegisterResponder("GET", "https://vault.example.com/v1/secret/data/firefly/db",
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
+			"data": fftypes.JSONObject{
+				"data": fftypes.JSONObject{"password": "s3cr3t"},
+			},
+		}))
+	secret, err := fetch(context.Background(), map[string]provider{"vault": v}, "${secret:vault:firefly/db#password}")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", secret)
+}
+
+func TestVaultFetchNotFound(t *testing.T) {
+	v := newTestVault(t)
+	httpmock.RegisterResponder("GET", "https://vault.example.com/v1/secret/data/firefly/db",
+		httpmock.NewStringResponder(404, `{"errors":[]}`))
+	_, err := v.fetch(context.Background(), "firefly/db")
+	assert.Regexp(t, "FF10506.*vault.*404", err)
+}
+
+func TestVaultFetchFail(t *testing.T) {
+	v := newTestVault(t)
+	httpmock.RegisterResponder("GET", "https://vault.example.com/v1/secret/data/firefly/db",
+		httpmock.NewErrorResponder(context.Canceled))
+	_, err := v.fetch(context.Background(), "firefly/db")
+	assert.Regexp(t, "FF10505.*firefly/db", err)
+}
+
+func TestProviderRESTErrTruncated(t *testing.T) {
+	v := newTestVault(t)
+	body := make([]byte, 300)
+	for i := range body {
+		body[i] = 'x'
+	}
+	httpmock.RegisterResponder("GET", "https://vault.example.com/v1/secret/data/firefly/db",
+		httpmock.NewBytesResponder(500, body))
+	_, err := v.fetch(context.Background(), "firefly/db")
+	assert.Regexp(t, "FF10506.*x\\.\\.\\.$", err)
+}
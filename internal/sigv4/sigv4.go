@@ -0,0 +1,112 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4Terminator = "aws4_request"
+	amzDayFormat    = "20060102"
+
+	// DateFormat is the format of the x-amz-date header
+	DateFormat = "20060102T150405Z"
+)
+
+// EmptyPayloadHash is the SHA256 of an empty body, used when signing requests without a payload
+var EmptyPayloadHash = SHA256Hex(nil)
+
+// SHA256Hex returns the hex encoded SHA256 hash of a payload
+func SHA256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapePath URI encodes every character of a path other than the unreserved characters and '/',
+// as required for the canonical request (which is stricter than url.PathEscape)
+func escapePath(path string) string {
+	escaped := strings.Builder{}
+	for _, b := range []byte(path) {
+		switch {
+		case (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9'),
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			escaped.WriteByte(b)
+		default:
+			escaped.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return escaped.String()
+}
+
+// Sign calculates the AWS Signature Version 4 Authorization header for a request to an AWS service with no query string.
+// The supplied headers must include every header to be signed, including host and x-amz-date.
+func Sign(accessKeyID, secretAccessKey, region, service, method, path string, headers map[string]string, payloadHash string, now time.Time) string {
+	names := make([]string, 0, len(headers))
+	lowerHeaders := make(map[string]string, len(headers))
+	for k, v := range headers {
+		lk := strings.ToLower(k)
+		names = append(names, lk)
+		lowerHeaders[lk] = strings.TrimSpace(v)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := strings.Builder{}
+	for _, k := range names {
+		canonicalHeaders.WriteString(fmt.Sprintf("%s:%s\n", k, lowerHeaders[k]))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		escapePath(path),
+		"", // no query string
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	day := now.UTC().Format(amzDayFormat)
+	scope := strings.Join([]string{day, region, service, sigV4Terminator}, "/")
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.UTC().Format(DateFormat),
+		scope,
+		SHA256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretAccessKey), day)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, sigV4Terminator)
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", sigV4Algorithm, accessKeyID, scope, signedHeaders, signature)
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/firefly/internal/data"
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
@@ -50,6 +51,17 @@ type Bridge interface {
 	WaitForTokenTransfer(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.TokenTransfer, error)
 	// WaitForTokenTransfer waits for a token approval with the supplied ID
 	WaitForTokenApproval(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.TokenApproval, error)
+	// WaitForInvokeOpResult waits for the contract invoke operation with the supplied ID to succeed or fail
+	WaitForInvokeOpResult(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.Operation, error)
+
+	// The following "WaitFor*State" methods do not send anything, but block until an existing object reaches the
+	// supplied state (or a final state from which it cannot move), or the context is done.
+	// The latest copy of the object is returned in all cases - including when the context is done.
+
+	// WaitForMessageState waits for the message with the supplied ID to reach the supplied state
+	WaitForMessageState(ctx context.Context, ns string, id *fftypes.UUID, state fftypes.MessageState) (*fftypes.Message, error)
+	// WaitForOperationState waits for the operation with the supplied ID to reach the supplied status
+	WaitForOperationState(ctx context.Context, ns string, id *fftypes.UUID, status fftypes.OpStatus) (*fftypes.Operation, error)
 }
 
 type RequestSender func(ctx context.Context) error
@@ -62,6 +74,7 @@ const (
 	tokenPoolConfirm
 	tokenTransferConfirm
 	tokenApproveConfirm
+	contractInvokeConfirm
 )
 
 type inflightRequest struct {
@@ -83,18 +96,32 @@ type syncAsyncBridge struct {
 	ctx         context.Context
 	database    database.Plugin
 	data        data.Manager
+	eventBus    eventbus.Bus
 	sysevents   sysmessaging.SystemEvents
 	inflightMux sync.Mutex
 	inflight    inflightRequestMap
+	watchers    map[string][]*stateWatcher
 }
 
-func NewSyncAsyncBridge(ctx context.Context, di database.Plugin, dm data.Manager) Bridge {
+func NewSyncAsyncBridge(ctx context.Context, di database.Plugin, dm data.Manager, eb eventbus.Bus) Bridge {
 	sa := &syncAsyncBridge{
-		ctx:      log.WithLogField(ctx, "role", "sync-async-bridge"),
+		ctx:      log.WithLogField(log.WithModule(ctx, "syncasync"), "role", "sync-async-bridge"),
 		database: di,
 		data:     dm,
+		eventBus: eb,
 		inflight: make(inflightRequestMap),
-	}
+		watchers: make(map[string][]*stateWatcher),
+	}
+	// State changes that do not emit an event of their own, such as operation updates and messages
+	// being sent, are notified on the event bus instead
+	eb.Subscribe(eventbus.TopicOperationUpdated, func(payload interface{}) {
+		update := payload.(*eventbus.OperationUpdate)
+		sa.notifyWatchersByID(update.Namespace, update.ID)
+	})
+	eb.Subscribe(eventbus.TopicMessageStateChanged, func(payload interface{}) {
+		change := payload.(*eventbus.MessageStateChange)
+		sa.notifyWatchersByID(change.Namespace, change.Messages...)
+	})
 	return sa
 }
 
@@ -114,6 +141,16 @@ func (sa *syncAsyncBridge) addInFlight(ns string, id *fftypes.UUID, reqType requ
 		sa.inflightMux.Unlock()
 	}()
 
+	inflightNS, err := sa.getInFlightNS(ns)
+	if err != nil {
+		return nil, err
+	}
+	inflightNS[*inflight.id] = inflight
+	return inflight, nil
+}
+
+// getInFlightNS must be called with the inflightMux held, and registers a system event listener on first use of a namespace
+func (sa *syncAsyncBridge) getInFlightNS(ns string) (map[fftypes.UUID]*inflightRequest, error) {
 	inflightNS := sa.inflight[ns]
 	if inflightNS == nil {
 		err := sa.sysevents.AddSystemEventListener(ns, sa.eventCallback)
@@ -123,8 +160,7 @@ func (sa *syncAsyncBridge) addInFlight(ns string, id *fftypes.UUID, reqType requ
 		inflightNS = make(map[fftypes.UUID]*inflightRequest)
 		sa.inflight[ns] = inflightNS
 	}
-	inflightNS[*inflight.id] = inflight
-	return inflight, nil
+	return inflightNS, nil
 }
 
 func (sa *syncAsyncBridge) getInFlight(ns string, reqType requestType, id *fftypes.UUID) *inflightRequest {
@@ -269,6 +305,15 @@ func (sa *syncAsyncBridge) handleMessageRejectedEvent(event *fftypes.EventDelive
 	return nil
 }
 
+func (sa *syncAsyncBridge) handleMessageExpiredEvent(event *fftypes.EventDelivery) error {
+	// See if this is the expiry of an inflight message
+	inflight := sa.getInFlight(event.Namespace, messageConfirm, event.Reference)
+	if inflight != nil {
+		go sa.resolveExpired(inflight, event.Reference)
+	}
+	return nil
+}
+
 func (sa *syncAsyncBridge) handlePoolConfirmedEvent(event *fftypes.EventDelivery) error {
 	pool, err := sa.getPoolFromEvent(event)
 	if err != nil || pool == nil {
@@ -350,10 +395,30 @@ func (sa *syncAsyncBridge) handleApprovalOpFailedEvent(event *fftypes.EventDeliv
 	return nil
 }
 
+func (sa *syncAsyncBridge) handleInvokeOpEvent(event *fftypes.EventDelivery) error {
+	// See if this is the completion of an inflight contract invoke operation
+	inflight := sa.getInFlight(event.Namespace, contractInvokeConfirm, event.Reference)
+	if inflight == nil {
+		return nil
+	}
+	op, err := sa.getOperationFromEvent(event)
+	if err != nil || op == nil {
+		return err
+	}
+	if event.Type == fftypes.EventTypeBlockchainInvokeOpFailed {
+		go sa.resolveFailedInvokeOp(inflight, op)
+	} else {
+		go sa.resolveSucceededInvokeOp(inflight, op)
+	}
+	return nil
+}
+
 func (sa *syncAsyncBridge) eventCallback(event *fftypes.EventDelivery) error {
 	sa.inflightMux.Lock()
 	defer sa.inflightMux.Unlock()
 
+	sa.notifyWatchers(event)
+
 	inflightNS := sa.inflight[event.Namespace]
 	if len(inflightNS) == 0 {
 		// No need to do any expensive lookups/matching - this could not be a match
@@ -367,6 +432,9 @@ func (sa *syncAsyncBridge) eventCallback(event *fftypes.EventDelivery) error {
 	case fftypes.EventTypeMessageRejected:
 		return sa.handleMessageRejectedEvent(event)
 
+	case fftypes.EventTypeMessageExpired:
+		return sa.handleMessageExpiredEvent(event)
+
 	case fftypes.EventTypePoolConfirmed:
 		return sa.handlePoolConfirmedEvent(event)
 
@@ -381,6 +449,9 @@ func (sa *syncAsyncBridge) eventCallback(event *fftypes.EventDelivery) error {
 
 	case fftypes.EventTypeApprovalOpFailed:
 		return sa.handleApprovalOpFailedEvent(event)
+
+	case fftypes.EventTypeBlockchainInvokeOpSucceeded, fftypes.EventTypeBlockchainInvokeOpFailed:
+		return sa.handleInvokeOpEvent(event)
 	}
 
 	return nil
@@ -410,6 +481,12 @@ func (sa *syncAsyncBridge) resolveRejected(inflight *inflightRequest, msgID *fft
 	inflight.response <- inflightResponse{err: err}
 }
 
+func (sa *syncAsyncBridge) resolveExpired(inflight *inflightRequest, msgID *fftypes.UUID) {
+	err := i18n.NewError(sa.ctx, i18n.MsgExpired, msgID)
+	log.L(sa.ctx).Errorf("Resolving message confirmation request '%s' with error: %s", inflight.id, err)
+	inflight.response <- inflightResponse{err: err}
+}
+
 func (sa *syncAsyncBridge) resolveConfirmedTokenPool(inflight *inflightRequest, pool *fftypes.TokenPool) {
 	log.L(sa.ctx).Debugf("Resolving token pool confirmation request '%s' with ID '%s'", inflight.id, pool.ID)
 	inflight.response <- inflightResponse{id: pool.ID, data: pool}
@@ -437,6 +514,17 @@ func (sa *syncAsyncBridge) resolveFailedTokenTransfer(inflight *inflightRequest,
 	inflight.response <- inflightResponse{err: err}
 }
 
+func (sa *syncAsyncBridge) resolveSucceededInvokeOp(inflight *inflightRequest, op *fftypes.Operation) {
+	log.L(sa.ctx).Debugf("Resolving contract invoke request '%s' with operation '%s'", inflight.id, op.ID)
+	inflight.response <- inflightResponse{id: op.ID, data: op}
+}
+
+func (sa *syncAsyncBridge) resolveFailedInvokeOp(inflight *inflightRequest, op *fftypes.Operation) {
+	err := i18n.NewError(sa.ctx, i18n.MsgContractInvokeFailed, op.ID, op.Error)
+	log.L(sa.ctx).Debugf("Resolving contract invoke request '%s' with error '%s'", inflight.id, err)
+	inflight.response <- inflightResponse{err: err}
+}
+
 func (sa *syncAsyncBridge) sendAndWait(ctx context.Context, ns string, id *fftypes.UUID, reqType requestType, send RequestSender) (interface{}, error) {
 	inflight, err := sa.addInFlight(ns, id, reqType)
 	if err != nil {
@@ -506,3 +594,11 @@ func (sa *syncAsyncBridge) WaitForTokenApproval(ctx context.Context, ns string,
 	}
 	return reply.(*fftypes.TokenApproval), err
 }
+
+func (sa *syncAsyncBridge) WaitForInvokeOpResult(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.Operation, error) {
+	reply, err := sa.sendAndWait(ctx, ns, id, contractInvokeConfirm, send)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*fftypes.Operation), err
+}
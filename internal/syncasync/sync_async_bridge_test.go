@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/eventbus"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
@@ -35,7 +36,7 @@ func newTestSyncAsyncBridge(t *testing.T) (*syncAsyncBridge, func()) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	mse := &sysmessagingmocks.SystemEvents{}
-	sa := NewSyncAsyncBridge(ctx, mdi, mdm)
+	sa := NewSyncAsyncBridge(ctx, mdi, mdm, eventbus.NewBus())
 	sa.Init(mse)
 	return sa.(*syncAsyncBridge), cancel
 }
@@ -182,6 +183,54 @@ func TestAwaitConfirmationRejected(t *testing.T) {
 	assert.Regexp(t, "FF10269", err)
 }
 
+func TestAwaitConfirmationExpired(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	requestID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	_, err := sa.WaitForMessage(sa.ctx, "ns1", requestID, func(ctx context.Context) error {
+		go func() {
+			sa.eventCallback(&fftypes.EventDelivery{
+				Event: fftypes.Event{
+					ID:        fftypes.NewUUID(),
+					Type:      fftypes.EventTypeMessageExpired,
+					Reference: requestID,
+					Namespace: "ns1",
+				},
+			})
+		}()
+		return nil
+	})
+	assert.Regexp(t, "FF10441", err)
+}
+
+func TestEventCallbackMessageExpiredNotInflight(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	sa.inflight = map[string]map[fftypes.UUID]*inflightRequest{
+		"ns1": {
+			*fftypes.NewUUID(): &inflightRequest{},
+		},
+	}
+
+	err := sa.eventCallback(&fftypes.EventDelivery{
+		Event: fftypes.Event{
+			Namespace: "ns1",
+			ID:        fftypes.NewUUID(),
+			Reference: fftypes.NewUUID(),
+			Type:      fftypes.EventTypeMessageExpired,
+		},
+	})
+	assert.NoError(t, err)
+}
+
 func TestRequestReplyTimeout(t *testing.T) {
 
 	sa, cancel := newTestSyncAsyncBridge(t)
@@ -1081,3 +1130,133 @@ func TestFailedTokenTransferIDLookupFail(t *testing.T) {
 
 	mdi.AssertExpectations(t)
 }
+
+func TestAwaitInvokeOpSucceeded(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	op := &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Status: fftypes.OpStatusSucceeded,
+	}
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", sa.ctx, op.ID).Return(op, nil)
+
+	retOp, err := sa.WaitForInvokeOpResult(sa.ctx, "ns1", op.ID, func(ctx context.Context) error {
+		go func() {
+			sa.eventCallback(&fftypes.EventDelivery{
+				Event: fftypes.Event{
+					ID:        fftypes.NewUUID(),
+					Type:      fftypes.EventTypeBlockchainInvokeOpSucceeded,
+					Reference: op.ID,
+					Namespace: "ns1",
+				},
+			})
+		}()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, op, retOp)
+}
+
+func TestAwaitInvokeOpFailed(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	op := &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Status: fftypes.OpStatusFailed,
+		Error:  "reverted",
+	}
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", sa.ctx, op.ID).Return(op, nil)
+
+	_, err := sa.WaitForInvokeOpResult(sa.ctx, "ns1", op.ID, func(ctx context.Context) error {
+		go func() {
+			sa.eventCallback(&fftypes.EventDelivery{
+				Event: fftypes.Event{
+					ID:        fftypes.NewUUID(),
+					Type:      fftypes.EventTypeBlockchainInvokeOpFailed,
+					Reference: op.ID,
+					Namespace: "ns1",
+				},
+			})
+		}()
+		return nil
+	})
+	assert.Regexp(t, "FF10417.*reverted", err)
+}
+
+func TestAwaitInvokeOpSendFail(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	_, err := sa.WaitForInvokeOpResult(sa.ctx, "ns1", fftypes.NewUUID(), func(ctx context.Context) error {
+		return fmt.Errorf("pop")
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestInvokeOpEventNotInflight(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	sa.inflight = map[string]map[fftypes.UUID]*inflightRequest{
+		"ns1": {
+			*fftypes.NewUUID(): &inflightRequest{reqType: contractInvokeConfirm},
+		},
+	}
+
+	err := sa.eventCallback(&fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID:        fftypes.NewUUID(),
+			Type:      fftypes.EventTypeBlockchainInvokeOpSucceeded,
+			Reference: fftypes.NewUUID(),
+			Namespace: "ns1",
+		},
+	})
+	assert.NoError(t, err)
+}
+
+func TestInvokeOpEventOpLookupFail(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+	sa.inflight = map[string]map[fftypes.UUID]*inflightRequest{
+		"ns1": {
+			*opID: &inflightRequest{reqType: contractInvokeConfirm},
+		},
+	}
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", sa.ctx, opID).Return(nil, fmt.Errorf("pop"))
+
+	err := sa.eventCallback(&fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID:        fftypes.NewUUID(),
+			Type:      fftypes.EventTypeBlockchainInvokeOpFailed,
+			Reference: opID,
+			Namespace: "ns1",
+		},
+	})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
@@ -0,0 +1,172 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncasync
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// stateWatcher is woken each time an event is confirmed that references one of the objects it is watching,
+// or a change to one of those objects is notified on the event bus.
+// Any number of watchers can exist for the same object, unlike inflight requests.
+type stateWatcher struct {
+	refs map[fftypes.UUID]bool
+	wake chan bool
+}
+
+// stateCheck reads the latest copy of the object being watched, and reports whether the wait is complete.
+// It can also return the ID of a related object (such as a transaction), events for which should also wake the watcher.
+type stateCheck func(ctx context.Context) (done bool, related *fftypes.UUID, err error)
+
+func (sa *syncAsyncBridge) addWatcher(ns string, id *fftypes.UUID) (*stateWatcher, error) {
+	w := &stateWatcher{
+		refs: map[fftypes.UUID]bool{*id: true},
+		wake: make(chan bool, 1),
+	}
+	sa.inflightMux.Lock()
+	defer sa.inflightMux.Unlock()
+	if _, err := sa.getInFlightNS(ns); err != nil {
+		return nil, err
+	}
+	sa.watchers[ns] = append(sa.watchers[ns], w)
+	return w, nil
+}
+
+func (sa *syncAsyncBridge) removeWatcher(ns string, w *stateWatcher) {
+	sa.inflightMux.Lock()
+	defer sa.inflightMux.Unlock()
+	watchers := sa.watchers[ns]
+	for i, existing := range watchers {
+		if existing == w {
+			sa.watchers[ns] = append(watchers[0:i], watchers[i+1:]...)
+			break
+		}
+	}
+}
+
+// watchRelated adds a reference to the watcher, and returns true if it was not already being watched
+func (sa *syncAsyncBridge) watchRelated(w *stateWatcher, related *fftypes.UUID) bool {
+	if related == nil {
+		return false
+	}
+	sa.inflightMux.Lock()
+	defer sa.inflightMux.Unlock()
+	if w.refs[*related] {
+		return false
+	}
+	w.refs[*related] = true
+	return true
+}
+
+// notifyWatchers must be called with the inflightMux held
+func (sa *syncAsyncBridge) notifyWatchers(event *fftypes.EventDelivery) {
+	for _, w := range sa.watchers[event.Namespace] {
+		if (event.Reference != nil && w.refs[*event.Reference]) ||
+			(event.Transaction != nil && w.refs[*event.Transaction]) {
+			w.notify()
+		}
+	}
+}
+
+// notifyWatchersByID wakes the watchers of any of the supplied objects, when notified from the event bus
+func (sa *syncAsyncBridge) notifyWatchersByID(ns string, ids ...*fftypes.UUID) {
+	sa.inflightMux.Lock()
+	defer sa.inflightMux.Unlock()
+	for _, w := range sa.watchers[ns] {
+		for _, id := range ids {
+			if id != nil && w.refs[*id] {
+				w.notify()
+				break
+			}
+		}
+	}
+}
+
+func (w *stateWatcher) notify() {
+	select {
+	case w.wake <- true:
+	default: // already due to wake
+	}
+}
+
+func (sa *syncAsyncBridge) waitForState(ctx context.Context, ns string, id *fftypes.UUID, check stateCheck) error {
+	w, err := sa.addWatcher(ns, id)
+	if err != nil {
+		return err
+	}
+	defer sa.removeWatcher(ns, w)
+
+	for {
+		done, related, err := check(ctx)
+		if err != nil || done {
+			return err
+		}
+		if sa.watchRelated(w, related) {
+			// We might have missed an event for the related object before we started watching it
+			continue
+		}
+		select {
+		case <-w.wake:
+			log.L(sa.ctx).Debugf("Checking state of '%s' after notification", id)
+		case <-ctx.Done():
+			// Not an error - the caller receives the latest copy we have, and can compare the state
+			log.L(sa.ctx).Debugf("Wait for state of '%s' ended: %s", id, ctx.Err())
+			return nil
+		}
+	}
+}
+
+func (sa *syncAsyncBridge) WaitForMessageState(ctx context.Context, ns string, id *fftypes.UUID, state fftypes.MessageState) (msg *fftypes.Message, err error) {
+	err = sa.waitForState(ctx, ns, id, func(ctx context.Context) (bool, *fftypes.UUID, error) {
+		latest, err := sa.database.GetMessageByID(ctx, id)
+		if err != nil {
+			return false, nil, err
+		}
+		msg = latest
+		if msg == nil {
+			return true, nil, nil
+		}
+		final := msg.State == fftypes.MessageStateConfirmed || msg.State == fftypes.MessageStateRejected
+		return final || msg.State == state, nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (sa *syncAsyncBridge) WaitForOperationState(ctx context.Context, ns string, id *fftypes.UUID, status fftypes.OpStatus) (op *fftypes.Operation, err error) {
+	err = sa.waitForState(ctx, ns, id, func(ctx context.Context) (bool, *fftypes.UUID, error) {
+		latest, err := sa.database.GetOperationByID(ctx, id)
+		if err != nil {
+			return false, nil, err
+		}
+		op = latest
+		if op == nil {
+			return true, nil, nil
+		}
+		// Operation updates are notified on the event bus, and we are also woken by events for their transaction
+		return op.Status != fftypes.OpStatusPending || op.Status == status, op.Transaction, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return op, nil
+}
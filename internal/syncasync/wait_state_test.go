@@ -0,0 +1,410 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncasync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/eventbus"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWaitForMessageStateConfirmed(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	msgID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateSent,
+	}, nil).Once()
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateConfirmed,
+	}, nil).Once()
+
+	go func() {
+		for {
+			sa.inflightMux.Lock()
+			watching := len(sa.watchers["ns1"]) > 0
+			sa.inflightMux.Unlock()
+			if watching {
+				break
+			}
+			time.Sleep(1 * time.Millisecond)
+		}
+		// Unrelated event
+		sa.eventCallback(&fftypes.EventDelivery{
+			Event: fftypes.Event{
+				Namespace: "ns1",
+				Type:      fftypes.EventTypeMessageConfirmed,
+				Reference: fftypes.NewUUID(),
+			},
+		})
+		// Event for our message
+		sa.eventCallback(&fftypes.EventDelivery{
+			Event: fftypes.Event{
+				Namespace: "ns1",
+				Type:      fftypes.EventTypeMessageConfirmed,
+				Reference: msgID,
+			},
+		})
+	}()
+
+	msg, err := sa.WaitForMessageState(sa.ctx, "ns1", msgID, fftypes.MessageStateConfirmed)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateConfirmed, msg.State)
+	assert.Empty(t, sa.watchers["ns1"])
+
+	mdi.AssertExpectations(t)
+}
+
+func TestWaitForMessageStateFinal(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	msgID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateRejected,
+	}, nil)
+
+	msg, err := sa.WaitForMessageState(sa.ctx, "ns1", msgID, fftypes.MessageStateConfirmed)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateRejected, msg.State)
+}
+
+func TestWaitForMessageStateNotFound(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	msgID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(nil, nil)
+
+	msg, err := sa.WaitForMessageState(sa.ctx, "ns1", msgID, fftypes.MessageStateConfirmed)
+	assert.NoError(t, err)
+	assert.Nil(t, msg)
+}
+
+func TestWaitForMessageStateLookupFail(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	msgID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := sa.WaitForMessageState(sa.ctx, "ns1", msgID, fftypes.MessageStateConfirmed)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestWaitForMessageStateTimeout(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	msgID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateSent,
+	}, nil)
+
+	ctx, cancelWait := context.WithCancel(sa.ctx)
+	cancelWait()
+	msg, err := sa.WaitForMessageState(ctx, "ns1", msgID, fftypes.MessageStateConfirmed)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateSent, msg.State)
+}
+
+func TestWaitForMessageStateListenerFail(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := sa.WaitForMessageState(sa.ctx, "ns1", fftypes.NewUUID(), fftypes.MessageStateConfirmed)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestWaitForOperationStateTransactionEvent(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	woken := make(chan bool)
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", mock.Anything, opID).Return(&fftypes.Operation{
+		ID:          opID,
+		Transaction: txID,
+		Status:      fftypes.OpStatusPending,
+	}, nil).Twice()
+	mdi.On("GetOperationByID", mock.Anything, opID).Run(func(args mock.Arguments) {
+		close(woken)
+	}).Return(&fftypes.Operation{
+		ID:          opID,
+		Transaction: txID,
+		Status:      fftypes.OpStatusSucceeded,
+	}, nil).Once()
+
+	go func() {
+		for {
+			sa.inflightMux.Lock()
+			watching := len(sa.watchers["ns1"]) > 0 && sa.watchers["ns1"][0].refs[*txID]
+			sa.inflightMux.Unlock()
+			if watching {
+				break
+			}
+			time.Sleep(1 * time.Millisecond)
+		}
+		// Event for a second watcher should not wake this one
+		other, err := sa.addWatcher("ns1", fftypes.NewUUID())
+		assert.NoError(t, err)
+		sa.eventCallback(&fftypes.EventDelivery{
+			Event: fftypes.Event{
+				Namespace:   "ns1",
+				Type:        fftypes.EventTypeTransferConfirmed,
+				Reference:   fftypes.NewUUID(),
+				Transaction: txID,
+			},
+		})
+		// A second event before we have checked does not block
+		sa.eventCallback(&fftypes.EventDelivery{
+			Event: fftypes.Event{
+				Namespace:   "ns1",
+				Type:        fftypes.EventTypeTransferConfirmed,
+				Reference:   fftypes.NewUUID(),
+				Transaction: txID,
+			},
+		})
+		<-woken
+		sa.removeWatcher("ns1", other)
+	}()
+
+	op, err := sa.WaitForOperationState(sa.ctx, "ns1", opID, fftypes.OpStatusSucceeded)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusSucceeded, op.Status)
+
+	mdi.AssertExpectations(t)
+}
+
+func waitForWatcher(sa *syncAsyncBridge, ns string) {
+	for {
+		sa.inflightMux.Lock()
+		watching := len(sa.watchers[ns]) > 0
+		sa.inflightMux.Unlock()
+		if watching {
+			return
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+}
+
+func TestWaitForMessageStateSentEventBus(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	msgID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateReady,
+	}, nil).Once()
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateSent,
+	}, nil).Once()
+
+	go func() {
+		waitForWatcher(sa, "ns1")
+		// Change in another namespace
+		sa.eventBus.Publish(eventbus.TopicMessageStateChanged, &eventbus.MessageStateChange{
+			Namespace: "ns2",
+			Messages:  []*fftypes.UUID{msgID},
+			State:     fftypes.MessageStateSent,
+		})
+		// Sending our message does not emit an event - only a notification on the bus
+		sa.eventBus.Publish(eventbus.TopicMessageStateChanged, &eventbus.MessageStateChange{
+			Namespace: "ns1",
+			Messages:  []*fftypes.UUID{fftypes.NewUUID(), msgID},
+			State:     fftypes.MessageStateSent,
+		})
+	}()
+
+	msg, err := sa.WaitForMessageState(sa.ctx, "ns1", msgID, fftypes.MessageStateSent)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateSent, msg.State)
+	assert.Empty(t, sa.watchers["ns1"])
+
+	mdi.AssertExpectations(t)
+}
+
+func TestWaitForOperationStateEventBus(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", mock.Anything, opID).Return(&fftypes.Operation{
+		ID:     opID,
+		Status: fftypes.OpStatusPending,
+	}, nil).Once()
+	mdi.On("GetOperationByID", mock.Anything, opID).Return(&fftypes.Operation{
+		ID:     opID,
+		Status: fftypes.OpStatusSucceeded,
+	}, nil).Once()
+
+	go func() {
+		waitForWatcher(sa, "ns1")
+		// Update for another operation
+		sa.eventBus.Publish(eventbus.TopicOperationUpdated, &eventbus.OperationUpdate{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Status:    fftypes.OpStatusSucceeded,
+		})
+		// The operation has no transaction, so no event is ever emitted that would wake us
+		sa.eventBus.Publish(eventbus.TopicOperationUpdated, &eventbus.OperationUpdate{
+			ID:        opID,
+			Namespace: "ns1",
+			Status:    fftypes.OpStatusSucceeded,
+		})
+	}()
+
+	op, err := sa.WaitForOperationState(sa.ctx, "ns1", opID, fftypes.OpStatusSucceeded)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusSucceeded, op.Status)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestWaitForOperationStateTimeout(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", mock.Anything, opID).Return(&fftypes.Operation{
+		ID:     opID,
+		Status: fftypes.OpStatusPending,
+	}, nil)
+
+	// No event or notification arrives before the timeout, so we get the pending operation back
+	ctx, cancelWait := context.WithTimeout(sa.ctx, 10*time.Millisecond)
+	defer cancelWait()
+	op, err := sa.WaitForOperationState(ctx, "ns1", opID, fftypes.OpStatusSucceeded)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusPending, op.Status)
+	assert.Empty(t, sa.watchers["ns1"])
+}
+
+func TestWaitForOperationStateNotFound(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", mock.Anything, opID).Return(nil, nil)
+
+	op, err := sa.WaitForOperationState(sa.ctx, "ns1", opID, fftypes.OpStatusSucceeded)
+	assert.NoError(t, err)
+	assert.Nil(t, op)
+}
+
+func TestWaitForOperationStateLookupFail(t *testing.T) {
+
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+
+	mse := sa.sysevents.(*sysmessagingmocks.SystemEvents)
+	mse.On("AddSystemEventListener", "ns1", mock.Anything).Return(nil)
+
+	mdi := sa.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", mock.Anything, opID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := sa.WaitForOperationState(sa.ctx, "ns1", opID, fftypes.OpStatusSucceeded)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestWatchRelatedNil(t *testing.T) {
+	sa, cancel := newTestSyncAsyncBridge(t)
+	defer cancel()
+	assert.False(t, sa.watchRelated(&stateWatcher{}, nil))
+}
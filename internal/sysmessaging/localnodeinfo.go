@@ -26,4 +26,7 @@ import (
 type LocalNodeInfo interface {
 	// GetNodeUUID returns the local node UUID, or nil if the node is not yet registered. It is cached for fast access
 	GetNodeUUID(ctx context.Context) *fftypes.UUID
+
+	// SignBatchHash signs the hash of a sealed batch with the node signing key, or returns an empty string if no node signing key is configured
+	SignBatchHash(ctx context.Context, hash *fftypes.Bytes32) string
 }
@@ -93,6 +93,7 @@ type activatePool struct {
 type mintTokens struct {
 	PoolID     string `json:"poolId"`
 	TokenIndex string `json:"tokenIndex,omitempty"`
+	URI        string `json:"uri,omitempty"`
 	To         string `json:"to"`
 	Amount     string `json:"amount"`
 	RequestID  string `json:"requestId,omitempty"`
@@ -126,7 +127,7 @@ func (ft *FFTokens) Name() string {
 }
 
 func (ft *FFTokens) Init(ctx context.Context, name string, prefix config.Prefix, callbacks tokens.Callbacks) (err error) {
-	ft.ctx = log.WithLogField(ctx, "proto", "fftokens")
+	ft.ctx = log.WithLogField(log.WithModule(ctx, "tokens"), "proto", "fftokens")
 	ft.callbacks = callbacks
 	ft.configuredName = name
 
@@ -505,6 +506,7 @@ func (ft *FFTokens) MintTokens(ctx context.Context, opID *fftypes.UUID, poolProt
 		SetBody(&mintTokens{
 			PoolID:     poolProtocolID,
 			TokenIndex: mint.TokenIndex,
+			URI:        mint.URI,
 			To:         mint.To,
 			Amount:     mint.Amount.Int().String(),
 			RequestID:  opID.String(),
@@ -427,6 +427,7 @@ func TestMintTokens(t *testing.T) {
 
 	mint := &fftypes.TokenTransfer{
 		LocalID: fftypes.NewUUID(),
+		URI:     "https://example.com/token/1",
 		To:      "user1",
 		Key:     "0x123",
 		Amount:  *fftypes.NewFFBigInt(10),
@@ -444,6 +445,7 @@ func TestMintTokens(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, fftypes.JSONObject{
 				"poolId":    "123",
+				"uri":       "https://example.com/token/1",
 				"to":        "user1",
 				"amount":    "10",
 				"signer":    "0x123",
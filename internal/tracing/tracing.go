@@ -0,0 +1,157 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/karlseguin/ccache"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/hyperledger/firefly"
+
+var (
+	provider        *sdktrace.TracerProvider
+	messageContexts *ccache.Cache
+	propagator      propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+)
+
+// Init starts exporting spans to the OTLP collector in the tracing section of the config.
+// When tracing is disabled the global no-op tracer is left in place, so every span is discarded at little cost.
+func Init(ctx context.Context) error {
+	if !config.GetBool(config.TracingEnabled) {
+		return nil
+	}
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(config.GetString(config.TracingOTLPEndpoint)),
+	}
+	if config.GetBool(config.TracingOTLPInsecure) {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	setProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", config.GetString(config.TracingServiceName)))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.GetFloat64(config.TracingSampleRatio)))),
+	))
+	log.L(ctx).Infof("Exporting traces to %s", config.GetString(config.TracingOTLPEndpoint))
+	return nil
+}
+
+func setProvider(tp *sdktrace.TracerProvider) {
+	provider = tp
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+	messageContexts = ccache.New(ccache.Configure().MaxSize(config.GetInt64(config.TracingMessageCacheSize)))
+}
+
+// Shutdown flushes any spans that have not yet been exported
+func Shutdown(ctx context.Context) {
+	if provider == nil {
+		return
+	}
+	if err := provider.Shutdown(ctx); err != nil {
+		log.L(ctx).Errorf("Failed to flush traces: %s", err)
+	}
+}
+
+// StartSpan starts a span as a child of the span on the context, if there is one
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartLinkedSpan starts a span as a child of the span on the context, linked to the traces of other
+// work that it completes - such as a batch pin that confirms messages submitted by different API calls
+func StartLinkedSpan(ctx context.Context, name string, links []trace.Link, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithLinks(links...))
+}
+
+// EndSpan ends the span, marking it as failed if there was an error
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract returns a context carrying the trace context from the headers of an incoming request
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject sets the trace context of the span on the context into the headers of an outgoing request
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// RecordMessage remembers the trace context that a message was submitted under, so that the
+// processing of the message when it is confirmed can be joined to the same trace
+func RecordMessage(ctx context.Context, msgID *fftypes.UUID) {
+	if messageContexts == nil || msgID == nil {
+		return
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		messageContexts.Set(msgID.String(), sc, config.GetDuration(config.TracingMessageCacheTTL))
+	}
+}
+
+func messageSpanContext(msgID *fftypes.UUID) (trace.SpanContext, bool) {
+	if messageContexts == nil || msgID == nil {
+		return trace.SpanContext{}, false
+	}
+	item := messageContexts.Get(msgID.String())
+	if item == nil || item.Expired() {
+		return trace.SpanContext{}, false
+	}
+	return item.Value().(trace.SpanContext), true
+}
+
+// MessageContext returns a context that continues the trace the message was submitted under,
+// or the original context if the message was not submitted by this node, or has been forgotten
+func MessageContext(ctx context.Context, msgID *fftypes.UUID) context.Context {
+	if sc, ok := messageSpanContext(msgID); ok {
+		return trace.ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// MessageLinks returns links to the traces the messages were submitted under
+func MessageLinks(msgIDs ...*fftypes.UUID) []trace.Link {
+	links := make([]trace.Link, 0, len(msgIDs))
+	for _, msgID := range msgIDs {
+		if sc, ok := messageSpanContext(msgID); ok {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+	return links
+}
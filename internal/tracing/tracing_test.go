@@ -0,0 +1,166 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestRecorder(t *testing.T) *tracetest.SpanRecorder {
+	config.Reset()
+	sr := tracetest.NewSpanRecorder()
+	setProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() {
+		provider = nil
+		messageContexts = nil
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+	return sr
+}
+
+func TestInitDisabled(t *testing.T) {
+	config.Reset()
+	err := Init(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+	Shutdown(context.Background())
+}
+
+func TestInitEnabled(t *testing.T) {
+	config.Reset()
+	config.Set(config.TracingEnabled, true)
+	config.Set(config.TracingOTLPInsecure, true)
+	defer func() {
+		provider = nil
+		messageContexts = nil
+	}()
+	err := Init(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, provider)
+	assert.NotNil(t, messageContexts)
+	Shutdown(context.Background())
+}
+
+func TestInitFail(t *testing.T) {
+	config.Reset()
+	config.Set(config.TracingEnabled, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Init(ctx)
+	assert.Regexp(t, "context canceled", err)
+	assert.Nil(t, provider)
+}
+
+func TestShutdownFail(t *testing.T) {
+	newTestRecorder(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Shutdown(ctx)
+}
+
+func TestStartEndSpan(t *testing.T) {
+	sr := newTestRecorder(t)
+
+	ctx, parent := StartSpan(context.Background(), "parent")
+	_, child := StartSpan(ctx, "child")
+	EndSpan(child, fmt.Errorf("pop"))
+	EndSpan(parent, nil)
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "pop", spans[0].Status().Description)
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestStartLinkedSpan(t *testing.T) {
+	sr := newTestRecorder(t)
+
+	_, submit := StartSpan(context.Background(), "submit")
+	submit.End()
+	_, pin := StartLinkedSpan(context.Background(), "pin", []trace.Link{{SpanContext: submit.SpanContext()}})
+	EndSpan(pin, nil)
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "pin", spans[1].Name())
+	assert.Len(t, spans[1].Links(), 1)
+	assert.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Links()[0].SpanContext.SpanID())
+}
+
+func TestInjectExtract(t *testing.T) {
+	newTestRecorder(t)
+
+	ctx, span := StartSpan(context.Background(), "client")
+	defer span.End()
+	header := http.Header{}
+	Inject(ctx, header)
+	assert.NotEmpty(t, header.Get("traceparent"))
+
+	sc := trace.SpanContextFromContext(Extract(context.Background(), header))
+	assert.True(t, sc.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), sc.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), sc.SpanID())
+}
+
+func TestMessageContext(t *testing.T) {
+	sr := newTestRecorder(t)
+
+	msgID := fftypes.NewUUID()
+	ctx, submit := StartSpan(context.Background(), "submit")
+	RecordMessage(ctx, msgID)
+	RecordMessage(context.Background(), fftypes.NewUUID()) // no span to record
+	submit.End()
+
+	_, confirm := StartSpan(MessageContext(context.Background(), msgID), "confirm")
+	confirm.End()
+	_, unknown := StartSpan(MessageContext(context.Background(), fftypes.NewUUID()), "unknown")
+	unknown.End()
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.NotEqual(t, spans[0].SpanContext().TraceID(), spans[2].SpanContext().TraceID())
+
+	links := MessageLinks(msgID, fftypes.NewUUID(), nil)
+	assert.Len(t, links, 1)
+	assert.Equal(t, spans[0].SpanContext().SpanID(), links[0].SpanContext.SpanID())
+}
+
+func TestMessageContextDisabled(t *testing.T) {
+	config.Reset()
+	msgID := fftypes.NewUUID()
+	ctx := context.Background()
+	RecordMessage(ctx, msgID)
+	assert.Equal(t, ctx, MessageContext(ctx, msgID))
+	assert.Empty(t, MessageLinks(msgID))
+}
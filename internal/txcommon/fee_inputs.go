@@ -0,0 +1,35 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// AddTransactionFeeInput records the fee chosen by the blockchain plugin on the inputs of the operation.
+// The inputs are copied rather than updated in place, as they can be shared with the request.
+func AddTransactionFeeInput(op *fftypes.Operation, fee *fftypes.TransactionFee) {
+	if fee == nil {
+		return
+	}
+	input := fftypes.JSONObject{}
+	for k, v := range op.Input {
+		input[k] = v
+	}
+	input["fee"] = fee
+	op.Input = input
+}
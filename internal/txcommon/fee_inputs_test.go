@@ -0,0 +1,43 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTransactionFeeInput(t *testing.T) {
+	input := fftypes.JSONObject{"x": 1}
+	op := &fftypes.Operation{Input: input}
+	fee := &fftypes.TransactionFee{
+		Policy:   fftypes.FeePolicyTypeFixed,
+		GasPrice: fftypes.NewFFBigInt(100),
+	}
+	AddTransactionFeeInput(op, fee)
+	assert.Equal(t, 1, op.Input["x"])
+	assert.Equal(t, fee, op.Input["fee"])
+	assert.Nil(t, input["fee"])
+}
+
+func TestAddTransactionFeeInputNoFee(t *testing.T) {
+	op := &fftypes.Operation{}
+	AddTransactionFeeInput(op, nil)
+	assert.Nil(t, op.Input)
+}
@@ -20,17 +20,19 @@ import (
 	"context"
 	"strings"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
 type Helper interface {
-	SubmitNewTransaction(ctx context.Context, ns string, txType fftypes.TransactionType) (*fftypes.UUID, error)
+	SubmitNewTransaction(ctx context.Context, ns string, txType fftypes.TransactionType, idempotencyKey fftypes.IdempotencyKey) (*fftypes.UUID, error)
 	PersistTransaction(ctx context.Context, ns string, id *fftypes.UUID, txType fftypes.TransactionType, blockchainTXID string) (valid bool, err error)
 	AddBlockchainTX(ctx context.Context, id *fftypes.UUID, blockchainTXID string) error
 	WriteOperationSuccess(ctx context.Context, opID *fftypes.UUID, output fftypes.JSONObject)
-	WriteOperationFailure(ctx context.Context, opID *fftypes.UUID, err error)
+	WriteOperationFailure(ctx context.Context, op *fftypes.Operation, err error)
+	PrepareOperationRetry(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error)
 }
 
 type transactionHelper struct {
@@ -43,16 +45,23 @@ func NewTransactionHelper(di database.Plugin) Helper {
 	}
 }
 
-// SubmitNewTransaction is called when there is a new transaction being submitted by the local node
-func (t *transactionHelper) SubmitNewTransaction(ctx context.Context, ns string, txType fftypes.TransactionType) (*fftypes.UUID, error) {
+// SubmitNewTransaction is called when there is a new transaction being submitted by the local node.
+// If an idempotency key is supplied, and a transaction already exists with that key, a conflict error is returned.
+func (t *transactionHelper) SubmitNewTransaction(ctx context.Context, ns string, txType fftypes.TransactionType, idempotencyKey fftypes.IdempotencyKey) (*fftypes.UUID, error) {
 
 	tx := &fftypes.Transaction{
-		ID:        fftypes.NewUUID(),
-		Namespace: ns,
-		Type:      txType,
+		ID:             fftypes.NewUUID(),
+		Namespace:      ns,
+		Type:           txType,
+		IdempotencyKey: idempotencyKey,
 	}
 
+	// The unique index on the idempotency key rejects a retry of a submission that has already been accepted,
+	// including one that is being accepted concurrently
 	if err := t.database.InsertTransaction(ctx, tx); err != nil {
+		if err == database.DuplicateKey && idempotencyKey != "" {
+			return nil, t.duplicateIdempotencyKey(ctx, ns, idempotencyKey, err)
+		}
 		return nil, err
 	}
 
@@ -63,6 +72,19 @@ func (t *transactionHelper) SubmitNewTransaction(ctx context.Context, ns string,
 	return tx.ID, nil
 }
 
+// duplicateIdempotencyKey reports the transaction that was accepted with an idempotency key
+func (t *transactionHelper) duplicateIdempotencyKey(ctx context.Context, ns string, idempotencyKey fftypes.IdempotencyKey, err error) error {
+	fb := database.TransactionQueryFactory.NewFilter(ctx)
+	existing, _, lookupErr := t.database.GetTransactions(ctx, fb.And(
+		fb.Eq("namespace", ns),
+		fb.Eq("idempotencykey", string(idempotencyKey)),
+	).Limit(1))
+	if lookupErr != nil || len(existing) == 0 {
+		return err
+	}
+	return i18n.NewError(ctx, i18n.MsgDuplicateIdempotencyKeyTX, idempotencyKey, existing[0].ID)
+}
+
 // PersistTransaction is called when we need to ensure a transaction exists in the DB, and optionally associate a new BlockchainTXID to it
 func (t *transactionHelper) PersistTransaction(ctx context.Context, ns string, id *fftypes.UUID, txType fftypes.TransactionType, blockchainTXID string) (valid bool, err error) {
 
@@ -135,8 +157,75 @@ func (t *transactionHelper) WriteOperationSuccess(ctx context.Context, opID *fft
 	}
 }
 
-func (t *transactionHelper) WriteOperationFailure(ctx context.Context, opID *fftypes.UUID, err error) {
-	if err2 := t.database.ResolveOperation(ctx, opID, fftypes.OpStatusFailed, err.Error(), nil); err2 != nil {
-		log.L(ctx).Errorf("Failed to update operation %s: %s", opID, err2)
+// WriteOperationFailure is called when submission of an operation has failed, after any retries within the plugin,
+// and records the final error against the operation along with an event so that applications can choose to retry it
+func (t *transactionHelper) WriteOperationFailure(ctx context.Context, op *fftypes.Operation, err error) {
+	if err2 := t.database.ResolveOperation(ctx, op.ID, fftypes.OpStatusFailed, err.Error(), nil); err2 != nil {
+		log.L(ctx).Errorf("Failed to update operation %s: %s", op.ID, err2)
+		return
+	}
+	if err2 := t.database.InsertEvent(ctx, fftypes.NewEvent(fftypes.EventTypeTransactionSubmitFailed, op.Namespace, op.ID, op.Transaction)); err2 != nil {
+		log.L(ctx).Errorf("Failed to write submit failure event for operation %s: %s", op.ID, err2)
+	}
+}
+
+// PrepareOperationRetry checks a failed operation can be retried, then creates a new pending operation in the same
+// transaction with the same inputs, linked from the original. The caller is then responsible for submitting it.
+// A message attached to a retried token transfer is staged again, so it is sent if the retried transfer is confirmed.
+func (t *transactionHelper) PrepareOperationRetry(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error) {
+	if op.Status != fftypes.OpStatusFailed {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationNotFailed, op.ID, op.Status)
+	}
+	if op.Retry != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationAlreadyRetried, op.ID, op.Retry)
+	}
+
+	now := fftypes.Now()
+	retry := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Namespace:   op.Namespace,
+		Transaction: op.Transaction,
+		Type:        op.Type,
+		Status:      fftypes.OpStatusPending,
+		Plugin:      op.Plugin,
+		Input:       op.Input,
+		Created:     now,
+		Updated:     now,
+	}
+	err := t.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := t.database.InsertOperation(ctx, retry); err != nil {
+			return err
+		}
+		if err := t.database.UpdateOperation(ctx, op.ID, database.OperationQueryFactory.NewUpdate(ctx).Set("retry", retry.ID)); err != nil {
+			return err
+		}
+		if op.Type == fftypes.OpTypeTokenTransfer {
+			return t.restageTransferMessage(ctx, op)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	op.Retry = retry.ID
+	log.L(ctx).Infof("Retrying operation %s as %s", op.ID, retry.ID)
+	return retry, nil
+}
+
+// restageTransferMessage returns a message that was rejected when its transfer failed back to staged state.
+// Messages that were sent are left alone, as they were not rejected by the transfer failure.
+func (t *transactionHelper) restageTransferMessage(ctx context.Context, op *fftypes.Operation) error {
+	msgID, err := fftypes.ParseUUID(ctx, op.Input.GetString("message"))
+	if err != nil {
+		return nil // no message attached to the transfer
+	}
+	msg, err := t.database.GetMessageByID(ctx, msgID)
+	if err != nil {
+		return err
+	}
+	if msg == nil || msg.State != fftypes.MessageStateRejected || msg.BatchID != nil {
+		return nil
 	}
+	log.L(ctx).Infof("Staging message '%s' attached to retried token transfer operation '%s'", msgID, op.ID)
+	return t.database.UpdateMessage(ctx, msgID, database.MessageQueryFactory.NewUpdate(ctx).Set("state", fftypes.MessageStateStaged))
 }
@@ -47,7 +47,7 @@ func TestSubmitNewTransactionOK(t *testing.T) {
 		return e.Type == fftypes.EventTypeTransactionSubmitted && e.Reference.Equals(txidInserted)
 	})).Return(nil)
 
-	txidReturned, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeBatchPin)
+	txidReturned, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeBatchPin, "")
 	assert.NoError(t, err)
 	assert.Equal(t, *txidInserted, *txidReturned)
 
@@ -63,7 +63,7 @@ func TestSubmitNewTransactionFail(t *testing.T) {
 
 	mdi.On("InsertTransaction", ctx, mock.Anything).Return(fmt.Errorf("pop"))
 
-	_, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeBatchPin)
+	_, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeBatchPin, "")
 	assert.Regexp(t, "pop", err)
 
 	mdi.AssertExpectations(t)
@@ -79,13 +79,64 @@ func TestSubmitNewTransactionEventFail(t *testing.T) {
 	mdi.On("InsertTransaction", ctx, mock.Anything).Return(nil)
 	mdi.On("InsertEvent", ctx, mock.Anything).Return(fmt.Errorf("pop"))
 
-	_, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeBatchPin)
+	_, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeBatchPin, "")
 	assert.Regexp(t, "pop", err)
 
 	mdi.AssertExpectations(t)
 
 }
 
+func TestSubmitNewTransactionIdempotencyKey(t *testing.T) {
+
+	mdi := &databasemocks.Plugin{}
+	txHelper := NewTransactionHelper(mdi)
+	ctx := context.Background()
+
+	mdi.On("InsertTransaction", ctx, mock.MatchedBy(func(transaction *fftypes.Transaction) bool {
+		return transaction.IdempotencyKey == "retry1"
+	})).Return(nil)
+	mdi.On("InsertEvent", ctx, mock.Anything).Return(nil)
+
+	_, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeTokenTransfer, "retry1")
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestSubmitNewTransactionIdempotencyKeyDuplicate(t *testing.T) {
+
+	mdi := &databasemocks.Plugin{}
+	txHelper := NewTransactionHelper(mdi)
+	ctx := context.Background()
+
+	existing := &fftypes.Transaction{ID: fftypes.NewUUID()}
+	mdi.On("InsertTransaction", ctx, mock.Anything).Return(database.DuplicateKey)
+	mdi.On("GetTransactions", ctx, mock.Anything).Return([]*fftypes.Transaction{existing}, nil, nil)
+
+	_, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeTokenTransfer, "retry1")
+	assert.Regexp(t, "FF10452.*"+existing.ID.String(), err)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestSubmitNewTransactionIdempotencyKeyDuplicateQueryFail(t *testing.T) {
+
+	mdi := &databasemocks.Plugin{}
+	txHelper := NewTransactionHelper(mdi)
+	ctx := context.Background()
+
+	mdi.On("InsertTransaction", ctx, mock.Anything).Return(database.DuplicateKey)
+	mdi.On("GetTransactions", ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := txHelper.SubmitNewTransaction(ctx, "ns1", fftypes.TransactionTypeTokenTransfer, "retry1")
+	assert.Equal(t, database.DuplicateKey, err)
+
+	mdi.AssertExpectations(t)
+
+}
+
 func TestPersistTransactionNew(t *testing.T) {
 
 	mdi := &databasemocks.Plugin{}
@@ -398,10 +449,247 @@ func TestWriteOperationFailure(t *testing.T) {
 	txHelper := NewTransactionHelper(mdi)
 	ctx := context.Background()
 
-	opID := fftypes.NewUUID()
-	mdi.On("ResolveOperation", ctx, opID, fftypes.OpStatusFailed, "pop", mock.Anything).Return(fmt.Errorf("pop"))
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Namespace: "ns1", Transaction: fftypes.NewUUID()}
+	mdi.On("ResolveOperation", ctx, op.ID, fftypes.OpStatusFailed, "pop", mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTransactionSubmitFailed && e.Namespace == "ns1" &&
+			*e.Reference == *op.ID && *e.Transaction == *op.Transaction
+	})).Return(nil)
+
+	txHelper.WriteOperationFailure(ctx, op, fmt.Errorf("pop"))
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestWriteOperationFailureResolveFail(t *testing.T) {
+
+	mdi := &databasemocks.Plugin{}
+	txHelper := NewTransactionHelper(mdi)
+	ctx := context.Background()
+
+	op := &fftypes.Operation{ID: fftypes.NewUUID()}
+	mdi.On("ResolveOperation", ctx, op.ID, fftypes.OpStatusFailed, "pop", mock.Anything).Return(fmt.Errorf("pop"))
+
+	txHelper.WriteOperationFailure(ctx, op, fmt.Errorf("pop"))
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestWriteOperationFailureEventFail(t *testing.T) {
+
+	mdi := &databasemocks.Plugin{}
+	txHelper := NewTransactionHelper(mdi)
+	ctx := context.Background()
+
+	op := &fftypes.Operation{ID: fftypes.NewUUID()}
+	mdi.On("ResolveOperation", ctx, op.ID, fftypes.OpStatusFailed, "pop", mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	txHelper.WriteOperationFailure(ctx, op, fmt.Errorf("pop"))
+
+	mdi.AssertExpectations(t)
+
+}
+
+func newRetryTestHelper() (*databasemocks.Plugin, Helper) {
+	mdi := &databasemocks.Plugin{}
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	return mdi, NewTransactionHelper(mdi)
+}
+
+func TestPrepareOperationRetry(t *testing.T) {
+
+	mdi, txHelper := newRetryTestHelper()
+	ctx := context.Background()
+
+	op := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+		Type:        fftypes.OpTypeTokenTransfer,
+		Status:      fftypes.OpStatusFailed,
+		Plugin:      "fftokens",
+		Input:       fftypes.JSONObject{"some": "input"},
+	}
+	var retryID *fftypes.UUID
+	mdi.On("InsertOperation", ctx, mock.MatchedBy(func(retry *fftypes.Operation) bool {
+		retryID = retry.ID
+		return retry.Status == fftypes.OpStatusPending && retry.Namespace == "ns1" &&
+			*retry.Transaction == *op.Transaction && retry.Type == op.Type &&
+			retry.Plugin == "fftokens" && retry.Input.GetString("some") == "input"
+	})).Return(nil)
+	mdi.On("UpdateOperation", ctx, op.ID, mock.MatchedBy(func(u database.Update) bool {
+		info, _ := u.Finalize()
+		return len(info.SetOperations) == 1 && info.SetOperations[0].Field == "retry"
+	})).Return(nil)
+
+	retry, err := txHelper.PrepareOperationRetry(ctx, op)
+	assert.NoError(t, err)
+	assert.Equal(t, retryID, retry.ID)
+	assert.Equal(t, retryID, op.Retry)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestPrepareOperationRetryRestagesMessage(t *testing.T) {
+
+	mdi, txHelper := newRetryTestHelper()
+	ctx := context.Background()
+
+	msgID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Type:   fftypes.OpTypeTokenTransfer,
+		Status: fftypes.OpStatusFailed,
+		Input:  fftypes.JSONObject{"message": msgID.String()},
+	}
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(nil)
+	mdi.On("UpdateOperation", ctx, op.ID, mock.Anything).Return(nil)
+	mdi.On("GetMessageByID", ctx, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+		State:  fftypes.MessageStateRejected,
+	}, nil)
+	mdi.On("UpdateMessage", ctx, msgID, mock.MatchedBy(func(u database.Update) bool {
+		info, _ := u.Finalize()
+		val, _ := info.SetOperations[0].Value.Value()
+		return len(info.SetOperations) == 1 && info.SetOperations[0].Field == "state" &&
+			val == string(fftypes.MessageStateStaged)
+	})).Return(nil)
+
+	_, err := txHelper.PrepareOperationRetry(ctx, op)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestPrepareOperationRetryApproval(t *testing.T) {
+
+	mdi, txHelper := newRetryTestHelper()
+	ctx := context.Background()
+
+	op := &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Type:   fftypes.OpTypeTokenApproval,
+		Status: fftypes.OpStatusFailed,
+		Input:  fftypes.JSONObject{"message": fftypes.NewUUID().String()},
+	}
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(nil)
+	mdi.On("UpdateOperation", ctx, op.ID, mock.Anything).Return(nil)
+
+	_, err := txHelper.PrepareOperationRetry(ctx, op)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestPrepareOperationRetryMessageSent(t *testing.T) {
+
+	mdi, txHelper := newRetryTestHelper()
+	ctx := context.Background()
+
+	msgID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Type:   fftypes.OpTypeTokenTransfer,
+		Status: fftypes.OpStatusFailed,
+		Input:  fftypes.JSONObject{"message": msgID.String()},
+	}
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(nil)
+	mdi.On("UpdateOperation", ctx, op.ID, mock.Anything).Return(nil)
+	mdi.On("GetMessageByID", ctx, msgID).Return(&fftypes.Message{
+		Header:  fftypes.MessageHeader{ID: msgID},
+		State:   fftypes.MessageStateRejected,
+		BatchID: fftypes.NewUUID(),
+	}, nil)
+
+	_, err := txHelper.PrepareOperationRetry(ctx, op)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestPrepareOperationRetryGetMessageFail(t *testing.T) {
+
+	mdi, txHelper := newRetryTestHelper()
+	ctx := context.Background()
 
-	txHelper.WriteOperationFailure(ctx, opID, fmt.Errorf("pop"))
+	msgID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Type:   fftypes.OpTypeTokenTransfer,
+		Status: fftypes.OpStatusFailed,
+		Input:  fftypes.JSONObject{"message": msgID.String()},
+	}
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(nil)
+	mdi.On("UpdateOperation", ctx, op.ID, mock.Anything).Return(nil)
+	mdi.On("GetMessageByID", ctx, msgID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := txHelper.PrepareOperationRetry(ctx, op)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestPrepareOperationRetryNotFailed(t *testing.T) {
+
+	_, txHelper := newRetryTestHelper()
+	_, err := txHelper.PrepareOperationRetry(context.Background(), &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Status: fftypes.OpStatusPending,
+	})
+	assert.Regexp(t, "FF10393", err)
+
+}
+
+func TestPrepareOperationRetryAlreadyRetried(t *testing.T) {
+
+	_, txHelper := newRetryTestHelper()
+	_, err := txHelper.PrepareOperationRetry(context.Background(), &fftypes.Operation{
+		ID:     fftypes.NewUUID(),
+		Status: fftypes.OpStatusFailed,
+		Retry:  fftypes.NewUUID(),
+	})
+	assert.Regexp(t, "FF10394", err)
+
+}
+
+func TestPrepareOperationRetryInsertFail(t *testing.T) {
+
+	mdi, txHelper := newRetryTestHelper()
+	ctx := context.Background()
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Status: fftypes.OpStatusFailed}
+	_, err := txHelper.PrepareOperationRetry(ctx, op)
+	assert.EqualError(t, err, "pop")
+	assert.Nil(t, op.Retry)
+
+	mdi.AssertExpectations(t)
+
+}
+
+func TestPrepareOperationRetryUpdateFail(t *testing.T) {
+
+	mdi, txHelper := newRetryTestHelper()
+	ctx := context.Background()
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(nil)
+	mdi.On("UpdateOperation", ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Status: fftypes.OpStatusFailed}
+	_, err := txHelper.PrepareOperationRetry(ctx, op)
+	assert.EqualError(t, err, "pop")
 
 	mdi.AssertExpectations(t)
 
@@ -142,6 +142,29 @@ func (_m *Manager) GetTokenAccounts(ctx context.Context, ns string, filter datab
 	return r0, r1, r2
 }
 
+// GetTokenApprovalByID provides a mock function with given fields: ctx, ns, id
+func (_m *Manager) GetTokenApprovalByID(ctx context.Context, ns string, id string) (*fftypes.TokenApproval, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.TokenApproval
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.TokenApproval); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TokenApproval)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTokenApprovals provides a mock function with given fields: ctx, ns, filter
 func (_m *Manager) GetTokenApprovals(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenApproval, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
@@ -417,6 +440,29 @@ func (_m *Manager) NewTransfer(ns string, transfer *fftypes.TokenTransferInput)
 	return r0
 }
 
+// RetryOperation provides a mock function with given fields: ctx, op
+func (_m *Manager) RetryOperation(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, op)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) *fftypes.Operation); ok {
+		r0 = rf(ctx, op)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Operation) error); ok {
+		r1 = rf(ctx, op)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields:
 func (_m *Manager) Start() error {
 	ret := _m.Called()
@@ -477,6 +523,29 @@ func (_m *Manager) TransferTokens(ctx context.Context, ns string, transfer *ffty
 	return r0, r1
 }
 
+// UpdateTokenPoolState provides a mock function with given fields: ctx, ns, poolNameOrID, state
+func (_m *Manager) UpdateTokenPoolState(ctx context.Context, ns string, poolNameOrID string, state fftypes.FFEnum) (*fftypes.TokenPool, error) {
+	ret := _m.Called(ctx, ns, poolNameOrID, state)
+
+	var r0 *fftypes.TokenPool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, fftypes.FFEnum) *fftypes.TokenPool); ok {
+		r0 = rf(ctx, ns, poolNameOrID, state)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TokenPool)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, fftypes.FFEnum) error); ok {
+		r1 = rf(ctx, ns, poolNameOrID, state)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WaitStop provides a mock function with given fields:
 func (_m *Manager) WaitStop() {
 	_m.Called()
@@ -3,10 +3,14 @@
 package batchmocks
 
 import (
+	context "context"
+
 	batch "github.com/hyperledger/firefly/internal/batch"
 	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Manager is an autogenerated mock type for the Manager type
@@ -19,20 +23,27 @@ func (_m *Manager) Close() {
 	_m.Called()
 }
 
-// NewMessages provides a mock function with given fields:
-func (_m *Manager) NewMessages() chan<- int64 {
-	ret := _m.Called()
+// Flush provides a mock function with given fields: ctx, ns, author
+func (_m *Manager) Flush(ctx context.Context, ns string, author string) (*batch.FlushResult, error) {
+	ret := _m.Called(ctx, ns, author)
 
-	var r0 chan<- int64
-	if rf, ok := ret.Get(0).(func() chan<- int64); ok {
-		r0 = rf()
+	var r0 *batch.FlushResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *batch.FlushResult); ok {
+		r0 = rf(ctx, ns, author)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan<- int64)
+			r0 = ret.Get(0).(*batch.FlushResult)
 		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, author)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // RegisterDispatcher provides a mock function with given fields: name, txType, msgTypes, handler, batchOptions
@@ -40,6 +51,11 @@ func (_m *Manager) RegisterDispatcher(name string, txType fftypes.FFEnum, msgTyp
 	_m.Called(name, txType, msgTypes, handler, batchOptions)
 }
 
+// SetBatchTimeout provides a mock function with given fields: dispatcherName, timeout
+func (_m *Manager) SetBatchTimeout(dispatcherName string, timeout time.Duration) {
+	_m.Called(dispatcherName, timeout)
+}
+
 // Start provides a mock function with given fields:
 func (_m *Manager) Start() error {
 	ret := _m.Called()
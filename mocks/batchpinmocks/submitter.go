@@ -14,6 +14,27 @@ type Submitter struct {
 	mock.Mock
 }
 
+// EnsurePrivacyGroup provides a mock function with given fields: ctx, ns, group
+func (_m *Submitter) EnsurePrivacyGroup(ctx context.Context, ns string, group *fftypes.Bytes32) (bool, error) {
+	ret := _m.Called(ctx, ns, group)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Bytes32) bool); ok {
+		r0 = rf(ctx, ns, group)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.Bytes32) error); ok {
+		r1 = rf(ctx, ns, group)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SubmitPinnedBatch provides a mock function with given fields: ctx, batch, contexts
 func (_m *Submitter) SubmitPinnedBatch(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
 	ret := _m.Called(ctx, batch, contexts)
@@ -48,6 +48,22 @@ func (_m *Plugin) Capabilities() *blockchain.Capabilities {
 	return r0
 }
 
+// ConnectorHealth provides a mock function with given fields:
+func (_m *Plugin) ConnectorHealth() *fftypes.ConnectorHealth {
+	ret := _m.Called()
+
+	var r0 *fftypes.ConnectorHealth
+	if rf, ok := ret.Get(0).(func() *fftypes.ConnectorHealth); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ConnectorHealth)
+		}
+	}
+
+	return r0
+}
+
 // DeleteSubscription provides a mock function with given fields: ctx, subscription
 func (_m *Plugin) DeleteSubscription(ctx context.Context, subscription *fftypes.ContractSubscription) error {
 	ret := _m.Called(ctx, subscription)
@@ -62,6 +78,20 @@ func (_m *Plugin) DeleteSubscription(ctx context.Context, subscription *fftypes.
 	return r0
 }
 
+// EnsurePrivacyGroup provides a mock function with given fields: ctx, group, privacyKeys
+func (_m *Plugin) EnsurePrivacyGroup(ctx context.Context, group *fftypes.Bytes32, privacyKeys []string) error {
+	ret := _m.Called(ctx, group, privacyKeys)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32, []string) error); ok {
+		r0 = rf(ctx, group, privacyKeys)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GenerateFFI provides a mock function with given fields: ctx, generationRequest
 func (_m *Plugin) GenerateFFI(ctx context.Context, generationRequest *fftypes.FFIGenerationRequest) (*fftypes.FFI, error) {
 	ret := _m.Called(ctx, generationRequest)
@@ -85,6 +115,36 @@ func (_m *Plugin) GenerateFFI(ctx context.Context, generationRequest *fftypes.FF
 	return r0, r1
 }
 
+// GetBatchPin provides a mock function with given fields: ctx, blockchainTXID
+func (_m *Plugin) GetBatchPin(ctx context.Context, blockchainTXID string) (*blockchain.BatchPin, string, error) {
+	ret := _m.Called(ctx, blockchainTXID)
+
+	var r0 *blockchain.BatchPin
+	if rf, ok := ret.Get(0).(func(context.Context, string) *blockchain.BatchPin); ok {
+		r0 = rf(ctx, blockchainTXID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*blockchain.BatchPin)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, string) string); ok {
+		r1 = rf(ctx, blockchainTXID)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, blockchainTXID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetFFIParamValidator provides a mock function with given fields: ctx
 func (_m *Plugin) GetFFIParamValidator(ctx context.Context) (fftypes.FFIParamValidator, error) {
 	ret := _m.Called(ctx)
@@ -127,13 +187,13 @@ func (_m *Plugin) InitPrefix(prefix config.Prefix) {
 	_m.Called(prefix)
 }
 
-// InvokeContract provides a mock function with given fields: ctx, operationID, signingKey, location, method, input
-func (_m *Plugin) InvokeContract(ctx context.Context, operationID *fftypes.UUID, signingKey string, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}) error {
-	ret := _m.Called(ctx, operationID, signingKey, location, method, input)
+// InvokeContract provides a mock function with given fields: ctx, operationID, signingKey, location, method, input, fee
+func (_m *Plugin) InvokeContract(ctx context.Context, operationID *fftypes.UUID, signingKey string, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}, fee *fftypes.TransactionFee) error {
+	ret := _m.Called(ctx, operationID, signingKey, location, method, input, fee)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, string, *fftypes.JSONAny, *fftypes.FFIMethod, map[string]interface{}) error); ok {
-		r0 = rf(ctx, operationID, signingKey, location, method, input)
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, string, *fftypes.JSONAny, *fftypes.FFIMethod, map[string]interface{}, *fftypes.TransactionFee) error); ok {
+		r0 = rf(ctx, operationID, signingKey, location, method, input, fee)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -155,6 +215,20 @@ func (_m *Plugin) Name() string {
 	return r0
 }
 
+// PrivacyKey provides a mock function with given fields:
+func (_m *Plugin) PrivacyKey() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // QueryContract provides a mock function with given fields: ctx, location, method, input
 func (_m *Plugin) QueryContract(ctx context.Context, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}) (interface{}, error) {
 	ret := _m.Called(ctx, location, method, input)
@@ -178,6 +252,20 @@ func (_m *Plugin) QueryContract(ctx context.Context, location *fftypes.JSONAny,
 	return r0, r1
 }
 
+// ResetBatchPinSubscription provides a mock function with given fields: ctx
+func (_m *Plugin) ResetBatchPinSubscription(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResolveSigningKey provides a mock function with given fields: ctx, signingKey
 func (_m *Plugin) ResolveSigningKey(ctx context.Context, signingKey string) (string, error) {
 	ret := _m.Called(ctx, signingKey)
@@ -199,6 +287,29 @@ func (_m *Plugin) ResolveSigningKey(ctx context.Context, signingKey string) (str
 	return r0, r1
 }
 
+// ResolveTransactionFee provides a mock function with given fields: ctx, ns
+func (_m *Plugin) ResolveTransactionFee(ctx context.Context, ns string) (*fftypes.TransactionFee, error) {
+	ret := _m.Called(ctx, ns)
+
+	var r0 *fftypes.TransactionFee
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.TransactionFee); ok {
+		r0 = rf(ctx, ns)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TransactionFee)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ns)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields:
 func (_m *Plugin) Start() error {
 	ret := _m.Called()
@@ -226,3 +337,54 @@ func (_m *Plugin) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID,
 
 	return r0
 }
+
+// SubmitRawTransaction provides a mock function with given fields: ctx, operationID, signingKey, tx
+func (_m *Plugin) SubmitRawTransaction(ctx context.Context, operationID *fftypes.UUID, signingKey string, tx fftypes.JSONObject) error {
+	ret := _m.Called(ctx, operationID, signingKey, tx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, string, fftypes.JSONObject) error); ok {
+		r0 = rf(ctx, operationID, signingKey, tx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifierType provides a mock function with given fields:
+func (_m *Plugin) VerifierType() fftypes.VerifierType {
+	ret := _m.Called()
+
+	var r0 fftypes.VerifierType
+	if rf, ok := ret.Get(0).(func() fftypes.VerifierType); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(fftypes.VerifierType)
+		}
+	}
+
+	return r0
+}
+
+// VerifyIdentityRegistered provides a mock function with given fields: ctx, signingKey, blockNumber
+func (_m *Plugin) VerifyIdentityRegistered(ctx context.Context, signingKey string, blockNumber *fftypes.FFBigInt) (bool, error) {
+	ret := _m.Called(ctx, signingKey, blockNumber)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFBigInt) bool); ok {
+		r0 = rf(ctx, signingKey, blockNumber)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFBigInt) error); ok {
+		r1 = rf(ctx, signingKey, blockNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
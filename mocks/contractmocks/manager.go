@@ -86,6 +86,20 @@ func (_m *Manager) BroadcastFFI(ctx context.Context, ns string, ffi *fftypes.FFI
 	return r0, r1
 }
 
+// DeleteContractAPISubscription provides a mock function with given fields: ctx, ns, apiName, nameOrID
+func (_m *Manager) DeleteContractAPISubscription(ctx context.Context, ns string, apiName string, nameOrID string) error {
+	ret := _m.Called(ctx, ns, apiName, nameOrID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, ns, apiName, nameOrID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteContractSubscriptionByNameOrID provides a mock function with given fields: ctx, ns, nameOrID
 func (_m *Manager) DeleteContractSubscriptionByNameOrID(ctx context.Context, ns string, nameOrID string) error {
 	ret := _m.Called(ctx, ns, nameOrID)
@@ -146,6 +160,38 @@ func (_m *Manager) GetContractAPI(ctx context.Context, httpServerURL string, ns
 	return r0, r1
 }
 
+// GetContractAPISubscriptions provides a mock function with given fields: ctx, ns, apiName, filter
+func (_m *Manager) GetContractAPISubscriptions(ctx context.Context, ns string, apiName string, filter database.AndFilter) ([]*fftypes.ContractSubscription, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, apiName, filter)
+
+	var r0 []*fftypes.ContractSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) []*fftypes.ContractSubscription); ok {
+		r0 = rf(ctx, ns, apiName, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ContractSubscription)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, apiName, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, apiName, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetContractAPIs provides a mock function with given fields: ctx, httpServerURL, ns, filter
 func (_m *Manager) GetContractAPIs(ctx context.Context, httpServerURL string, ns string, filter database.AndFilter) ([]*fftypes.ContractAPI, *database.FilterResult, error) {
 	ret := _m.Called(ctx, httpServerURL, ns, filter)
@@ -334,13 +380,36 @@ func (_m *Manager) GetFFIs(ctx context.Context, ns string, filter database.AndFi
 	return r0, r1, r2
 }
 
-// InvokeContract provides a mock function with given fields: ctx, ns, req
-func (_m *Manager) InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest) (interface{}, error) {
-	ret := _m.Called(ctx, ns, req)
+// ImportFFI provides a mock function with given fields: ctx, ns, generationRequest, waitConfirm
+func (_m *Manager) ImportFFI(ctx context.Context, ns string, generationRequest *fftypes.FFIGenerationRequest, waitConfirm bool) (*fftypes.FFI, error) {
+	ret := _m.Called(ctx, ns, generationRequest, waitConfirm)
+
+	var r0 *fftypes.FFI
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFIGenerationRequest, bool) *fftypes.FFI); ok {
+		r0 = rf(ctx, ns, generationRequest, waitConfirm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.FFI)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFIGenerationRequest, bool) error); ok {
+		r1 = rf(ctx, ns, generationRequest, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvokeContract provides a mock function with given fields: ctx, ns, req, waitConfirm
+func (_m *Manager) InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest, waitConfirm bool) (interface{}, error) {
+	ret := _m.Called(ctx, ns, req, waitConfirm)
 
 	var r0 interface{}
-	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.ContractCallRequest) interface{}); ok {
-		r0 = rf(ctx, ns, req)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.ContractCallRequest, bool) interface{}); ok {
+		r0 = rf(ctx, ns, req, waitConfirm)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(interface{})
@@ -348,8 +417,8 @@ func (_m *Manager) InvokeContract(ctx context.Context, ns string, req *fftypes.C
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.ContractCallRequest) error); ok {
-		r1 = rf(ctx, ns, req)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.ContractCallRequest, bool) error); ok {
+		r1 = rf(ctx, ns, req, waitConfirm)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -357,13 +426,13 @@ func (_m *Manager) InvokeContract(ctx context.Context, ns string, req *fftypes.C
 	return r0, r1
 }
 
-// InvokeContractAPI provides a mock function with given fields: ctx, ns, apiName, methodPath, req
-func (_m *Manager) InvokeContractAPI(ctx context.Context, ns string, apiName string, methodPath string, req *fftypes.ContractCallRequest) (interface{}, error) {
-	ret := _m.Called(ctx, ns, apiName, methodPath, req)
+// InvokeContractAPI provides a mock function with given fields: ctx, ns, apiName, methodPath, req, waitConfirm
+func (_m *Manager) InvokeContractAPI(ctx context.Context, ns string, apiName string, methodPath string, req *fftypes.ContractCallRequest, waitConfirm bool) (interface{}, error) {
+	ret := _m.Called(ctx, ns, apiName, methodPath, req, waitConfirm)
 
 	var r0 interface{}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, *fftypes.ContractCallRequest) interface{}); ok {
-		r0 = rf(ctx, ns, apiName, methodPath, req)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, *fftypes.ContractCallRequest, bool) interface{}); ok {
+		r0 = rf(ctx, ns, apiName, methodPath, req, waitConfirm)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(interface{})
@@ -371,8 +440,54 @@ func (_m *Manager) InvokeContractAPI(ctx context.Context, ns string, apiName str
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, *fftypes.ContractCallRequest) error); ok {
-		r1 = rf(ctx, ns, apiName, methodPath, req)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, *fftypes.ContractCallRequest, bool) error); ok {
+		r1 = rf(ctx, ns, apiName, methodPath, req, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetryOperation provides a mock function with given fields: ctx, op
+func (_m *Manager) RetryOperation(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, op)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) *fftypes.Operation); ok {
+		r0 = rf(ctx, op)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Operation) error); ok {
+		r1 = rf(ctx, op)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubmitRawTransaction provides a mock function with given fields: ctx, ns, req
+func (_m *Manager) SubmitRawTransaction(ctx context.Context, ns string, req *fftypes.RawTransactionRequest) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, req)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.RawTransactionRequest) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.RawTransactionRequest) error); ok {
+		r1 = rf(ctx, ns, req)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -3,10 +3,14 @@
 package databasemocks
 
 import (
+	context "context"
+
 	database "github.com/hyperledger/firefly/pkg/database"
 	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Callbacks is an autogenerated mock type for the Callbacks type
@@ -19,6 +23,11 @@ func (_m *Callbacks) HashCollectionNSEvent(resType database.HashCollectionNS, ev
 	_m.Called(resType, eventType, ns, hash)
 }
 
+// ObserveLatency provides a mock function with given fields: ctx, elapsed, err
+func (_m *Callbacks) ObserveLatency(ctx context.Context, elapsed time.Duration, err error) {
+	_m.Called(ctx, elapsed, err)
+}
+
 // OrderedCollectionEvent provides a mock function with given fields: resType, eventType, sequence
 func (_m *Callbacks) OrderedCollectionEvent(resType database.OrderedCollection, eventType fftypes.ChangeEventType, sequence int64) {
 	_m.Called(resType, eventType, sequence)
@@ -29,6 +38,11 @@ func (_m *Callbacks) OrderedUUIDCollectionNSEvent(resType database.OrderedUUIDCo
 	_m.Called(resType, eventType, ns, id, sequence)
 }
 
+// SubscriptionChangeEvent provides a mock function with given fields: change
+func (_m *Callbacks) SubscriptionChangeEvent(change *fftypes.SubscriptionChange) {
+	_m.Called(change)
+}
+
 // UUIDCollectionEvent provides a mock function with given fields: resType, eventType, id
 func (_m *Callbacks) UUIDCollectionEvent(resType database.UUIDCollection, eventType fftypes.ChangeEventType, id *fftypes.UUID) {
 	_m.Called(resType, eventType, id)
@@ -77,6 +77,34 @@ func (_m *Plugin) DeleteContractSubscriptionByID(ctx context.Context, id *fftype
 	return r0
 }
 
+// DeleteData provides a mock function with given fields: ctx, ids
+func (_m *Plugin) DeleteData(ctx context.Context, ids []*fftypes.UUID) error {
+	ret := _m.Called(ctx, ids)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*fftypes.UUID) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteEvents provides a mock function with given fields: ctx, ids
+func (_m *Plugin) DeleteEvents(ctx context.Context, ids []*fftypes.UUID) error {
+	ret := _m.Called(ctx, ids)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*fftypes.UUID) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteNamespace provides a mock function with given fields: ctx, id
 func (_m *Plugin) DeleteNamespace(ctx context.Context, id *fftypes.UUID) error {
 	ret := _m.Called(ctx, id)
@@ -91,6 +119,20 @@ func (_m *Plugin) DeleteNamespace(ctx context.Context, id *fftypes.UUID) error {
 	return r0
 }
 
+// DeleteNamespaceData provides a mock function with given fields: ctx, ns
+func (_m *Plugin) DeleteNamespaceData(ctx context.Context, ns string) error {
+	ret := _m.Called(ctx, ns)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, ns)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteNextPin provides a mock function with given fields: ctx, sequence
 func (_m *Plugin) DeleteNextPin(ctx context.Context, sequence int64) error {
 	ret := _m.Called(ctx, sequence)
@@ -147,6 +189,20 @@ func (_m *Plugin) DeletePin(ctx context.Context, sequence int64) error {
 	return r0
 }
 
+// DeleteQuarantinedBatch provides a mock function with given fields: ctx, id
+func (_m *Plugin) DeleteQuarantinedBatch(ctx context.Context, id *fftypes.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteSubscriptionByID provides a mock function with given fields: ctx, id
 func (_m *Plugin) DeleteSubscriptionByID(ctx context.Context, id *fftypes.UUID) error {
 	ret := _m.Called(ctx, id)
@@ -161,6 +217,116 @@ func (_m *Plugin) DeleteSubscriptionByID(ctx context.Context, id *fftypes.UUID)
 	return r0
 }
 
+// GetArchiveByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetArchiveByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Archive, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.Archive
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Archive); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Archive)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetArchiveForEntry provides a mock function with given fields: ctx, entryType, id
+func (_m *Plugin) GetArchiveForEntry(ctx context.Context, entryType fftypes.FFEnum, id *fftypes.UUID) (*fftypes.Archive, error) {
+	ret := _m.Called(ctx, entryType, id)
+
+	var r0 *fftypes.Archive
+	if rf, ok := ret.Get(0).(func(context.Context, fftypes.FFEnum, *fftypes.UUID) *fftypes.Archive); ok {
+		r0 = rf(ctx, entryType, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Archive)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, fftypes.FFEnum, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, entryType, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetArchives provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetArchives(ctx context.Context, filter database.Filter) ([]*fftypes.Archive, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Archive
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Archive); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Archive)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetAuditRecords provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetAuditRecords(ctx context.Context, filter database.Filter) ([]*fftypes.AuditRecord, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.AuditRecord
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.AuditRecord); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.AuditRecord)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetBatchByID provides a mock function with given fields: ctx, id
 func (_m *Plugin) GetBatchByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Batch, error) {
 	ret := _m.Called(ctx, id)
@@ -1037,6 +1203,38 @@ func (_m *Plugin) GetGroupByHash(ctx context.Context, hash *fftypes.Bytes32) (*f
 	return r0, r1
 }
 
+// GetGroupMembershipChanges provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetGroupMembershipChanges(ctx context.Context, filter database.Filter) ([]*fftypes.GroupMembershipChange, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.GroupMembershipChange
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.GroupMembershipChange); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.GroupMembershipChange)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetGroups provides a mock function with given fields: ctx, filter
 func (_m *Plugin) GetGroups(ctx context.Context, filter database.Filter) ([]*fftypes.Group, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -1665,6 +1863,61 @@ func (_m *Plugin) GetPins(ctx context.Context, filter database.Filter) ([]*fftyp
 	return r0, r1, r2
 }
 
+// GetQuarantinedBatchByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetQuarantinedBatchByID(ctx context.Context, id *fftypes.UUID) (*fftypes.QuarantinedBatch, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.QuarantinedBatch
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.QuarantinedBatch); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.QuarantinedBatch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetQuarantinedBatches provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetQuarantinedBatches(ctx context.Context, filter database.Filter) ([]*fftypes.QuarantinedBatch, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.QuarantinedBatch
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.QuarantinedBatch); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.QuarantinedBatch)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetSubscriptionByID provides a mock function with given fields: ctx, id
 func (_m *Plugin) GetSubscriptionByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Subscription, error) {
 	ret := _m.Called(ctx, id)
@@ -1711,6 +1964,38 @@ func (_m *Plugin) GetSubscriptionByName(ctx context.Context, ns string, name str
 	return r0, r1
 }
 
+// GetSubscriptionChanges provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetSubscriptionChanges(ctx context.Context, filter database.Filter) ([]*fftypes.SubscriptionChange, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.SubscriptionChange
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.SubscriptionChange); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.SubscriptionChange)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetSubscriptions provides a mock function with given fields: ctx, filter
 func (_m *Plugin) GetSubscriptions(ctx context.Context, filter database.Filter) ([]*fftypes.Subscription, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -2193,6 +2478,34 @@ func (_m *Plugin) InitPrefix(prefix config.Prefix) {
 	_m.Called(prefix)
 }
 
+// InsertArchive provides a mock function with given fields: ctx, archive
+func (_m *Plugin) InsertArchive(ctx context.Context, archive *fftypes.Archive) error {
+	ret := _m.Called(ctx, archive)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Archive) error); ok {
+		r0 = rf(ctx, archive)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertAuditRecord provides a mock function with given fields: ctx, record
+func (_m *Plugin) InsertAuditRecord(ctx context.Context, record *fftypes.AuditRecord) error {
+	ret := _m.Called(ctx, record)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.AuditRecord) error); ok {
+		r0 = rf(ctx, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // InsertBlob provides a mock function with given fields: ctx, blob
 func (_m *Plugin) InsertBlob(ctx context.Context, blob *fftypes.Blob) error {
 	ret := _m.Called(ctx, blob)
@@ -2235,6 +2548,20 @@ func (_m *Plugin) InsertEvent(ctx context.Context, data *fftypes.Event) error {
 	return r0
 }
 
+// InsertGroupMembershipChange provides a mock function with given fields: ctx, change
+func (_m *Plugin) InsertGroupMembershipChange(ctx context.Context, change *fftypes.GroupMembershipChange) error {
+	ret := _m.Called(ctx, change)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.GroupMembershipChange) error); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // InsertNextPin provides a mock function with given fields: ctx, nextpin
 func (_m *Plugin) InsertNextPin(ctx context.Context, nextpin *fftypes.NextPin) error {
 	ret := _m.Called(ctx, nextpin)
@@ -2263,6 +2590,34 @@ func (_m *Plugin) InsertOperation(ctx context.Context, operation *fftypes.Operat
 	return r0
 }
 
+// InsertQuarantinedBatch provides a mock function with given fields: ctx, qb
+func (_m *Plugin) InsertQuarantinedBatch(ctx context.Context, qb *fftypes.QuarantinedBatch) error {
+	ret := _m.Called(ctx, qb)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.QuarantinedBatch) error); ok {
+		r0 = rf(ctx, qb)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertSubscriptionChange provides a mock function with given fields: ctx, change
+func (_m *Plugin) InsertSubscriptionChange(ctx context.Context, change *fftypes.SubscriptionChange) error {
+	ret := _m.Called(ctx, change)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.SubscriptionChange) error); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // InsertTransaction provides a mock function with given fields: ctx, data
 func (_m *Plugin) InsertTransaction(ctx context.Context, data *fftypes.Transaction) error {
 	ret := _m.Called(ctx, data)
@@ -2291,6 +2646,43 @@ func (_m *Plugin) Name() string {
 	return r0
 }
 
+// PendingMigrations provides a mock function with given fields: ctx
+func (_m *Plugin) PendingMigrations(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *Plugin) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ReplaceMessage provides a mock function with given fields: ctx, message
 func (_m *Plugin) ReplaceMessage(ctx context.Context, message *fftypes.Message) error {
 	ret := _m.Called(ctx, message)
@@ -2473,6 +2865,20 @@ func (_m *Plugin) UpdateOffset(ctx context.Context, rowID int64, update database
 	return r0
 }
 
+// UpdateOperation provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdateOperation(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateOrganization provides a mock function with given fields: ctx, id, update
 func (_m *Plugin) UpdateOrganization(ctx context.Context, id *fftypes.UUID, update database.Update) error {
 	ret := _m.Called(ctx, id, update)
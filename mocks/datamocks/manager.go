@@ -116,6 +116,20 @@ func (_m *Manager) GetMessageData(ctx context.Context, msg *fftypes.Message, wit
 	return r0, r1, r2
 }
 
+// PublishBLOB provides a mock function with given fields: ctx, _a1
+func (_m *Manager) PublishBLOB(ctx context.Context, _a1 *fftypes.Data) error {
+	ret := _m.Called(ctx, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Data) error); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResolveInlineDataBroadcast provides a mock function with given fields: ctx, ns, inData
 func (_m *Manager) ResolveInlineDataBroadcast(ctx context.Context, ns string, inData fftypes.InlineData) (fftypes.DataRefs, []*fftypes.DataAndBlob, error) {
 	ret := _m.Called(ctx, ns, inData)
@@ -62,6 +62,38 @@ func (_m *DefinitionHandlers) GetGroupByID(ctx context.Context, id string) (*fft
 	return r0, r1
 }
 
+// GetGroupMembershipChanges provides a mock function with given fields: ctx, ns, groupHash, filter
+func (_m *DefinitionHandlers) GetGroupMembershipChanges(ctx context.Context, ns string, groupHash string, filter database.AndFilter) ([]*fftypes.GroupMembershipChange, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, groupHash, filter)
+
+	var r0 []*fftypes.GroupMembershipChange
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) []*fftypes.GroupMembershipChange); ok {
+		r0 = rf(ctx, ns, groupHash, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.GroupMembershipChange)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, groupHash, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, groupHash, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetGroupsNS provides a mock function with given fields: ctx, ns, filter
 func (_m *DefinitionHandlers) GetGroupsNS(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
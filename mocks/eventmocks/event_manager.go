@@ -123,20 +123,27 @@ func (_m *EventManager) DeleteDurableSubscription(ctx context.Context, subDef *f
 	return r0
 }
 
-// DeletedSubscriptions provides a mock function with given fields:
-func (_m *EventManager) DeletedSubscriptions() chan<- *fftypes.UUID {
-	ret := _m.Called()
+// GetAggregatorCheckpoint provides a mock function with given fields: ctx, ledger
+func (_m *EventManager) GetAggregatorCheckpoint(ctx context.Context, ledger string) (*fftypes.Offset, error) {
+	ret := _m.Called(ctx, ledger)
 
-	var r0 chan<- *fftypes.UUID
-	if rf, ok := ret.Get(0).(func() chan<- *fftypes.UUID); ok {
-		r0 = rf()
+	var r0 *fftypes.Offset
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Offset); ok {
+		r0 = rf(ctx, ledger)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan<- *fftypes.UUID)
+			r0 = ret.Get(0).(*fftypes.Offset)
 		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ledger)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // MessageReceived provides a mock function with given fields: dx, peerID, data
@@ -160,61 +167,50 @@ func (_m *EventManager) MessageReceived(dx dataexchange.Plugin, peerID string, d
 	return r0, r1
 }
 
-// NewEvents provides a mock function with given fields:
-func (_m *EventManager) NewEvents() chan<- int64 {
-	ret := _m.Called()
+// OperationUpdate provides a mock function with given fields: plugin, operationID, txState, blockchainTXID, errorMessage, opOutput, receipt
+func (_m *EventManager) OperationUpdate(plugin fftypes.Named, operationID *fftypes.UUID, txState fftypes.OpStatus, blockchainTXID string, errorMessage string, opOutput fftypes.JSONObject, receipt *fftypes.TransactionReceipt) error {
+	ret := _m.Called(plugin, operationID, txState, blockchainTXID, errorMessage, opOutput, receipt)
 
-	var r0 chan<- int64
-	if rf, ok := ret.Get(0).(func() chan<- int64); ok {
-		r0 = rf()
+	var r0 error
+	if rf, ok := ret.Get(0).(func(fftypes.Named, *fftypes.UUID, fftypes.OpStatus, string, string, fftypes.JSONObject, *fftypes.TransactionReceipt) error); ok {
+		r0 = rf(plugin, operationID, txState, blockchainTXID, errorMessage, opOutput, receipt)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan<- int64)
-		}
+		r0 = ret.Error(0)
 	}
 
 	return r0
 }
 
-// NewPins provides a mock function with given fields:
-func (_m *EventManager) NewPins() chan<- int64 {
-	ret := _m.Called()
+// ReprocessQuarantinedBatch provides a mock function with given fields: ctx, qb
+func (_m *EventManager) ReprocessQuarantinedBatch(ctx context.Context, qb *fftypes.QuarantinedBatch) (*fftypes.Batch, error) {
+	ret := _m.Called(ctx, qb)
 
-	var r0 chan<- int64
-	if rf, ok := ret.Get(0).(func() chan<- int64); ok {
-		r0 = rf()
+	var r0 *fftypes.Batch
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.QuarantinedBatch) *fftypes.Batch); ok {
+		r0 = rf(ctx, qb)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan<- int64)
+			r0 = ret.Get(0).(*fftypes.Batch)
 		}
 	}
 
-	return r0
-}
-
-// NewSubscriptions provides a mock function with given fields:
-func (_m *EventManager) NewSubscriptions() chan<- *fftypes.UUID {
-	ret := _m.Called()
-
-	var r0 chan<- *fftypes.UUID
-	if rf, ok := ret.Get(0).(func() chan<- *fftypes.UUID); ok {
-		r0 = rf()
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.QuarantinedBatch) error); ok {
+		r1 = rf(ctx, qb)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan<- *fftypes.UUID)
-		}
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
-// OperationUpdate provides a mock function with given fields: plugin, operationID, txState, blockchainTXID, errorMessage, opOutput
-func (_m *EventManager) OperationUpdate(plugin fftypes.Named, operationID *fftypes.UUID, txState fftypes.OpStatus, blockchainTXID string, errorMessage string, opOutput fftypes.JSONObject) error {
-	ret := _m.Called(plugin, operationID, txState, blockchainTXID, errorMessage, opOutput)
+// RewindAggregator provides a mock function with given fields: ctx, rewind
+func (_m *EventManager) RewindAggregator(ctx context.Context, rewind *fftypes.AggregatorRewind) error {
+	ret := _m.Called(ctx, rewind)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(fftypes.Named, *fftypes.UUID, fftypes.OpStatus, string, string, fftypes.JSONObject) error); ok {
-		r0 = rf(plugin, operationID, txState, blockchainTXID, errorMessage, opOutput)
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.AggregatorRewind) error); ok {
+		r0 = rf(ctx, rewind)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -236,20 +232,27 @@ func (_m *EventManager) Start() error {
 	return r0
 }
 
-// SubscriptionUpdates provides a mock function with given fields:
-func (_m *EventManager) SubscriptionUpdates() chan<- *fftypes.UUID {
-	ret := _m.Called()
+// StartCatchup provides a mock function with given fields: ctx, ns, req
+func (_m *EventManager) StartCatchup(ctx context.Context, ns string, req *fftypes.CatchupRequest) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, req)
 
-	var r0 chan<- *fftypes.UUID
-	if rf, ok := ret.Get(0).(func() chan<- *fftypes.UUID); ok {
-		r0 = rf()
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.CatchupRequest) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, req)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan<- *fftypes.UUID)
+			r0 = ret.Get(0).(*fftypes.Operation)
 		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.CatchupRequest) error); ok {
+		r1 = rf(ctx, ns, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // TokenPoolCreated provides a mock function with given fields: ti, pool
@@ -308,6 +311,20 @@ func (_m *EventManager) TransferResult(dx dataexchange.Plugin, trackingID string
 	return r0
 }
 
+// UpdateDurableSubscription provides a mock function with given fields: ctx, existing, subDef
+func (_m *EventManager) UpdateDurableSubscription(ctx context.Context, existing *fftypes.Subscription, subDef *fftypes.Subscription) error {
+	ret := _m.Called(ctx, existing, subDef)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Subscription, *fftypes.Subscription) error); ok {
+		r0 = rf(ctx, existing, subDef)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WaitStop provides a mock function with given fields:
 func (_m *EventManager) WaitStop() {
 	_m.Called()
@@ -73,6 +73,25 @@ func (_m *Manager) OrgDID(org *fftypes.Organization) string {
 	return r0
 }
 
+// OrgKeyRotated provides a mock function with given fields: org
+func (_m *Manager) OrgKeyRotated(org *fftypes.Organization) {
+	_m.Called(org)
+}
+
+// ResolveDelegatedIdentity provides a mock function with given fields: ctx, identity, onBehalfOf
+func (_m *Manager) ResolveDelegatedIdentity(ctx context.Context, identity *fftypes.Identity, onBehalfOf string) error {
+	ret := _m.Called(ctx, identity, onBehalfOf)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Identity, string) error); ok {
+		r0 = rf(ctx, identity, onBehalfOf)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResolveInputIdentity provides a mock function with given fields: ctx, _a1
 func (_m *Manager) ResolveInputIdentity(ctx context.Context, _a1 *fftypes.Identity) error {
 	ret := _m.Called(ctx, _a1)
@@ -129,6 +148,27 @@ func (_m *Manager) ResolveSigningKey(ctx context.Context, inputKey string) (stri
 	return r0, r1
 }
 
+// ResolveSigningKeyAuthor provides a mock function with given fields: ctx, author, signingKey, pinned
+func (_m *Manager) ResolveSigningKeyAuthor(ctx context.Context, author string, signingKey string, pinned *fftypes.FFTime) (string, error) {
+	ret := _m.Called(ctx, author, signingKey, pinned)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *fftypes.FFTime) string); ok {
+		r0 = rf(ctx, author, signingKey, pinned)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *fftypes.FFTime) error); ok {
+		r1 = rf(ctx, author, signingKey, pinned)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ResolveSigningKeyIdentity provides a mock function with given fields: ctx, signingKey
 func (_m *Manager) ResolveSigningKeyIdentity(ctx context.Context, signingKey string) (string, error) {
 	ret := _m.Called(ctx, signingKey)
@@ -149,3 +189,17 @@ func (_m *Manager) ResolveSigningKeyIdentity(ctx context.Context, signingKey str
 
 	return r0, r1
 }
+
+// VerifyCryptoPolicy provides a mock function with given fields: ctx, namespace, signer
+func (_m *Manager) VerifyCryptoPolicy(ctx context.Context, namespace string, signer *fftypes.Identity) error {
+	ret := _m.Called(ctx, namespace, signer)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Identity) error); ok {
+		r0 = rf(ctx, namespace, signer)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
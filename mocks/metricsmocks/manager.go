@@ -20,9 +20,34 @@ func (_m *Manager) AddTime(id string) {
 	_m.Called(id)
 }
 
-// CountBatchPin provides a mock function with given fields:
-func (_m *Manager) CountBatchPin() {
-	_m.Called()
+// AggregatorBatchProcessed provides a mock function with given fields: ledger, nsPins, elapsed
+func (_m *Manager) AggregatorBatchProcessed(ledger string, nsPins map[string]int, elapsed time.Duration) {
+	_m.Called(ledger, nsPins, elapsed)
+}
+
+// BatchFlushed provides a mock function with given fields: ns, dispatcher, messages, targetSize
+func (_m *Manager) BatchFlushed(ns string, dispatcher string, messages int, targetSize uint) {
+	_m.Called(ns, dispatcher, messages, targetSize)
+}
+
+// BlockchainEventReceived provides a mock function with given fields: plugin, ns, eventType
+func (_m *Manager) BlockchainEventReceived(plugin string, ns string, eventType string) {
+	_m.Called(plugin, ns, eventType)
+}
+
+// BlockchainSubmitted provides a mock function with given fields: plugin, ns, elapsed, err
+func (_m *Manager) BlockchainSubmitted(plugin string, ns string, elapsed time.Duration, err error) {
+	_m.Called(plugin, ns, elapsed, err)
+}
+
+// CountBatchPin provides a mock function with given fields: ns
+func (_m *Manager) CountBatchPin(ns string) {
+	_m.Called(ns)
+}
+
+// DatabaseOperation provides a mock function with given fields: ns, elapsed, err
+func (_m *Manager) DatabaseOperation(ns string, elapsed time.Duration, err error) {
+	_m.Called(ns, elapsed, err)
 }
 
 // DeleteTime provides a mock function with given fields: id
@@ -30,6 +55,37 @@ func (_m *Manager) DeleteTime(id string) {
 	_m.Called(id)
 }
 
+// EventDelivered provides a mock function with given fields: ns, transport, delivered
+func (_m *Manager) EventDelivered(ns string, transport string, delivered bool) {
+	_m.Called(ns, transport, delivered)
+}
+
+// EventDispatched provides a mock function with given fields: event
+func (_m *Manager) EventDispatched(event *fftypes.EventDelivery) {
+	_m.Called(event)
+}
+
+// EventResponse provides a mock function with given fields: ns, transport, rejected
+func (_m *Manager) EventResponse(ns string, transport string, rejected bool) {
+	_m.Called(ns, transport, rejected)
+}
+
+// GetNamespaceUsage provides a mock function with given fields: ns
+func (_m *Manager) GetNamespaceUsage(ns string) *fftypes.NamespaceUsage {
+	ret := _m.Called(ns)
+
+	var r0 *fftypes.NamespaceUsage
+	if rf, ok := ret.Get(0).(func(string) *fftypes.NamespaceUsage); ok {
+		r0 = rf(ns)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.NamespaceUsage)
+		}
+	}
+
+	return r0
+}
+
 // GetTime provides a mock function with given fields: id
 func (_m *Manager) GetTime(id string) time.Time {
 	ret := _m.Called(id)
@@ -212,3 +212,26 @@ func (_m *Manager) RegisterOrganization(ctx context.Context, org *fftypes.Organi
 
 	return r0, r1
 }
+
+// RotateOrganizationKey provides a mock function with given fields: ctx, id, rotation, waitConfirm
+func (_m *Manager) RotateOrganizationKey(ctx context.Context, id string, rotation *fftypes.KeyRotation, waitConfirm bool) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, id, rotation, waitConfirm)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.KeyRotation, bool) *fftypes.Message); ok {
+		r0 = rf(ctx, id, rotation, waitConfirm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.KeyRotation, bool) error); ok {
+		r1 = rf(ctx, id, rotation, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
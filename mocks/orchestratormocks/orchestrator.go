@@ -3,6 +3,7 @@
 package orchestratormocks
 
 import (
+	adminevents "github.com/hyperledger/firefly/internal/adminevents"
 	assets "github.com/hyperledger/firefly/internal/assets"
 	batch "github.com/hyperledger/firefly/internal/batch"
 
@@ -10,6 +11,10 @@ import (
 
 	context "context"
 
+	io "io"
+
+	loadshed "github.com/hyperledger/firefly/internal/loadshed"
+
 	contracts "github.com/hyperledger/firefly/internal/contracts"
 
 	data "github.com/hyperledger/firefly/internal/data"
@@ -27,6 +32,8 @@ import (
 	networkmap "github.com/hyperledger/firefly/internal/networkmap"
 
 	privatemessaging "github.com/hyperledger/firefly/internal/privatemessaging"
+
+	time "time"
 )
 
 // Orchestrator is an autogenerated mock type for the Orchestrator type
@@ -34,6 +41,22 @@ type Orchestrator struct {
 	mock.Mock
 }
 
+// AdminEvents provides a mock function with given fields:
+func (_m *Orchestrator) AdminEvents() adminevents.Manager {
+	ret := _m.Called()
+
+	var r0 adminevents.Manager
+	if rf, ok := ret.Get(0).(func() adminevents.Manager); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(adminevents.Manager)
+		}
+	}
+
+	return r0
+}
+
 // Assets provides a mock function with given fields:
 func (_m *Orchestrator) Assets() assets.Manager {
 	ret := _m.Called()
@@ -121,6 +144,29 @@ func (_m *Orchestrator) CreateSubscription(ctx context.Context, ns string, subDe
 	return r0, r1
 }
 
+// CreateUpdateNamespace provides a mock function with given fields: ctx, ns
+func (_m *Orchestrator) CreateUpdateNamespace(ctx context.Context, ns *fftypes.Namespace) (*fftypes.Namespace, error) {
+	ret := _m.Called(ctx, ns)
+
+	var r0 *fftypes.Namespace
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Namespace) *fftypes.Namespace); ok {
+		r0 = rf(ctx, ns)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Namespace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Namespace) error); ok {
+		r1 = rf(ctx, ns)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateUpdateSubscription provides a mock function with given fields: ctx, ns, subDef
 func (_m *Orchestrator) CreateUpdateSubscription(ctx context.Context, ns string, subDef *fftypes.Subscription) (*fftypes.Subscription, error) {
 	ret := _m.Called(ctx, ns, subDef)
@@ -174,6 +220,20 @@ func (_m *Orchestrator) DeleteConfigRecord(ctx context.Context, key string) erro
 	return r0
 }
 
+// DeleteNamespace provides a mock function with given fields: ctx, ns
+func (_m *Orchestrator) DeleteNamespace(ctx context.Context, ns string) error {
+	ret := _m.Called(ctx, ns)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, ns)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteSubscription provides a mock function with given fields: ctx, ns, id
 func (_m *Orchestrator) DeleteSubscription(ctx context.Context, ns string, id string) error {
 	ret := _m.Called(ctx, ns, id)
@@ -204,6 +264,84 @@ func (_m *Orchestrator) Events() events.EventManager {
 	return r0
 }
 
+// ExportEvents provides a mock function with given fields: ctx, ns, prevHash, filter
+func (_m *Orchestrator) ExportEvents(ctx context.Context, ns string, prevHash string, filter database.AndFilter) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, ns, prevHash, filter)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) io.ReadCloser); ok {
+		r0 = rf(ctx, ns, prevHash, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r1 = rf(ctx, ns, prevHash, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAggregatorCheckpoint provides a mock function with given fields: ctx, ledger
+func (_m *Orchestrator) GetAggregatorCheckpoint(ctx context.Context, ledger string) (*fftypes.Offset, error) {
+	ret := _m.Called(ctx, ledger)
+
+	var r0 *fftypes.Offset
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Offset); ok {
+		r0 = rf(ctx, ledger)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Offset)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ledger)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuditRecords provides a mock function with given fields: ctx, filter
+func (_m *Orchestrator) GetAuditRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.AuditRecord, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.AuditRecord
+	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.AuditRecord); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.AuditRecord)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetBatchByID provides a mock function with given fields: ctx, ns, id
 func (_m *Orchestrator) GetBatchByID(ctx context.Context, ns string, id string) (*fftypes.Batch, error) {
 	ret := _m.Called(ctx, ns, id)
@@ -596,6 +734,22 @@ func (_m *Orchestrator) GetEvents(ctx context.Context, ns string, filter databas
 	return r0, r1, r2
 }
 
+// GetLogLevels provides a mock function with given fields: ctx
+func (_m *Orchestrator) GetLogLevels(ctx context.Context) *fftypes.LogLevels {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.LogLevels
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.LogLevels); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.LogLevels)
+		}
+	}
+
+	return r0
+}
+
 // GetMessageByID provides a mock function with given fields: ctx, ns, id
 func (_m *Orchestrator) GetMessageByID(ctx context.Context, ns string, id string) (*fftypes.Message, error) {
 	ret := _m.Called(ctx, ns, id)
@@ -871,6 +1025,29 @@ func (_m *Orchestrator) GetNamespace(ctx context.Context, ns string) (*fftypes.N
 	return r0, r1
 }
 
+// GetNamespaceUsage provides a mock function with given fields: ctx, ns
+func (_m *Orchestrator) GetNamespaceUsage(ctx context.Context, ns string) (*fftypes.NamespaceUsage, error) {
+	ret := _m.Called(ctx, ns)
+
+	var r0 *fftypes.NamespaceUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.NamespaceUsage); ok {
+		r0 = rf(ctx, ns)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.NamespaceUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ns)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNamespaces provides a mock function with given fields: ctx, filter
 func (_m *Orchestrator) GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -958,6 +1135,77 @@ func (_m *Orchestrator) GetOperations(ctx context.Context, ns string, filter dat
 	return r0, r1, r2
 }
 
+// GetPluginHealth provides a mock function with given fields: ctx
+func (_m *Orchestrator) GetPluginHealth(ctx context.Context) []*fftypes.PluginHealth {
+	ret := _m.Called(ctx)
+
+	var r0 []*fftypes.PluginHealth
+	if rf, ok := ret.Get(0).(func(context.Context) []*fftypes.PluginHealth); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.PluginHealth)
+		}
+	}
+
+	return r0
+}
+
+// GetQuarantinedBatchByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetQuarantinedBatchByID(ctx context.Context, ns string, id string) (*fftypes.QuarantinedBatch, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.QuarantinedBatch
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.QuarantinedBatch); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.QuarantinedBatch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetQuarantinedBatches provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetQuarantinedBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.QuarantinedBatch, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.QuarantinedBatch
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.QuarantinedBatch); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.QuarantinedBatch)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetStatus provides a mock function with given fields: ctx
 func (_m *Orchestrator) GetStatus(ctx context.Context) (*fftypes.NodeStatus, error) {
 	ret := _m.Called(ctx)
@@ -981,6 +1229,22 @@ func (_m *Orchestrator) GetStatus(ctx context.Context) (*fftypes.NodeStatus, err
 	return r0, r1
 }
 
+// GetStatusPlugins provides a mock function with given fields: ctx
+func (_m *Orchestrator) GetStatusPlugins(ctx context.Context) []*fftypes.NodeStatusPlugin {
+	ret := _m.Called(ctx)
+
+	var r0 []*fftypes.NodeStatusPlugin
+	if rf, ok := ret.Get(0).(func(context.Context) []*fftypes.NodeStatusPlugin); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.NodeStatusPlugin)
+		}
+	}
+
+	return r0
+}
+
 // GetSubscriptionByID provides a mock function with given fields: ctx, ns, id
 func (_m *Orchestrator) GetSubscriptionByID(ctx context.Context, ns string, id string) (*fftypes.Subscription, error) {
 	ret := _m.Called(ctx, ns, id)
@@ -1004,6 +1268,38 @@ func (_m *Orchestrator) GetSubscriptionByID(ctx context.Context, ns string, id s
 	return r0, r1
 }
 
+// GetSubscriptionHistory provides a mock function with given fields: ctx, ns, id, filter
+func (_m *Orchestrator) GetSubscriptionHistory(ctx context.Context, ns string, id string, filter database.AndFilter) ([]*fftypes.SubscriptionChange, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, id, filter)
+
+	var r0 []*fftypes.SubscriptionChange
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) []*fftypes.SubscriptionChange); ok {
+		r0 = rf(ctx, ns, id, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.SubscriptionChange)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, id, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, id, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetSubscriptions provides a mock function with given fields: ctx, ns, filter
 func (_m *Orchestrator) GetSubscriptions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Subscription, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
@@ -1206,6 +1502,22 @@ func (_m *Orchestrator) IsPreInit() bool {
 	return r0
 }
 
+// LoadShedding provides a mock function with given fields:
+func (_m *Orchestrator) LoadShedding() loadshed.Monitor {
+	ret := _m.Called()
+
+	var r0 loadshed.Monitor
+	if rf, ok := ret.Get(0).(func() loadshed.Monitor); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(loadshed.Monitor)
+		}
+	}
+
+	return r0
+}
+
 // Metrics provides a mock function with given fields:
 func (_m *Orchestrator) Metrics() metrics.Manager {
 	ret := _m.Called()
@@ -1238,6 +1550,52 @@ func (_m *Orchestrator) NetworkMap() networkmap.Manager {
 	return r0
 }
 
+// PendingDatabaseMigrations provides a mock function with given fields: ctx
+func (_m *Orchestrator) PendingDatabaseMigrations(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchSubscription provides a mock function with given fields: ctx, ns, id, patch
+func (_m *Orchestrator) PatchSubscription(ctx context.Context, ns string, id string, patch fftypes.JSONObject) (*fftypes.Subscription, error) {
+	ret := _m.Called(ctx, ns, id, patch)
+
+	var r0 *fftypes.Subscription
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, fftypes.JSONObject) *fftypes.Subscription); ok {
+		r0 = rf(ctx, ns, id, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, fftypes.JSONObject) error); ok {
+		r1 = rf(ctx, ns, id, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PrivateMessaging provides a mock function with given fields:
 func (_m *Orchestrator) PrivateMessaging() privatemessaging.Manager {
 	ret := _m.Called()
@@ -1277,6 +1635,66 @@ func (_m *Orchestrator) PutConfigRecord(ctx context.Context, key string, configR
 	return r0, r1
 }
 
+// RecordAudit provides a mock function with given fields: ctx, record
+func (_m *Orchestrator) RecordAudit(ctx context.Context, record *fftypes.AuditRecord) error {
+	ret := _m.Called(ctx, record)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.AuditRecord) error); ok {
+		r0 = rf(ctx, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReloadConfig provides a mock function with given fields: ctx
+func (_m *Orchestrator) ReloadConfig(ctx context.Context) (*fftypes.ConfigReloadResult, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.ConfigReloadResult
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.ConfigReloadResult); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ConfigReloadResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReprocessQuarantinedBatch provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) ReprocessQuarantinedBatch(ctx context.Context, ns string, id string) (*fftypes.Batch, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Batch
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Batch); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Batch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RequestReply provides a mock function with given fields: ctx, ns, msg
 func (_m *Orchestrator) RequestReply(ctx context.Context, ns string, msg *fftypes.MessageInOut) (*fftypes.MessageInOut, error) {
 	ret := _m.Called(ctx, ns, msg)
@@ -1305,6 +1723,103 @@ func (_m *Orchestrator) ResetConfig(ctx context.Context) {
 	_m.Called(ctx)
 }
 
+// RetireNamespace provides a mock function with given fields: ctx, ns
+func (_m *Orchestrator) RetireNamespace(ctx context.Context, ns string) (*fftypes.Namespace, error) {
+	ret := _m.Called(ctx, ns)
+
+	var r0 *fftypes.Namespace
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Namespace); ok {
+		r0 = rf(ctx, ns)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Namespace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ns)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetryOperation provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) RetryOperation(ctx context.Context, ns string, id string) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RewindAggregator provides a mock function with given fields: ctx, rewind
+func (_m *Orchestrator) RewindAggregator(ctx context.Context, rewind *fftypes.AggregatorRewind) error {
+	ret := _m.Called(ctx, rewind)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.AggregatorRewind) error); ok {
+		r0 = rf(ctx, rewind)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RunAsGroup provides a mock function with given fields: ctx, fn
+func (_m *Orchestrator) RunAsGroup(ctx context.Context, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetLogLevels provides a mock function with given fields: ctx, levels
+func (_m *Orchestrator) SetLogLevels(ctx context.Context, levels *fftypes.LogLevels) (*fftypes.LogLevels, error) {
+	ret := _m.Called(ctx, levels)
+
+	var r0 *fftypes.LogLevels
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.LogLevels) *fftypes.LogLevels); ok {
+		r0 = rf(ctx, levels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.LogLevels)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.LogLevels) error); ok {
+		r1 = rf(ctx, levels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields:
 func (_m *Orchestrator) Start() error {
 	ret := _m.Called()
@@ -1319,6 +1834,114 @@ func (_m *Orchestrator) Start() error {
 	return r0
 }
 
+// StartCatchup provides a mock function with given fields: ctx, ns, req
+func (_m *Orchestrator) StartCatchup(ctx context.Context, ns string, req *fftypes.CatchupRequest) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, req)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.CatchupRequest) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.CatchupRequest) error); ok {
+		r1 = rf(ctx, ns, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubmitMessages provides a mock function with given fields: ctx, ns, msgs
+func (_m *Orchestrator) SubmitMessages(ctx context.Context, ns string, msgs []*fftypes.MessageInOut) ([]*fftypes.BulkMessageResult, error) {
+	ret := _m.Called(ctx, ns, msgs)
+
+	var r0 []*fftypes.BulkMessageResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, []*fftypes.MessageInOut) []*fftypes.BulkMessageResult); ok {
+		r0 = rf(ctx, ns, msgs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.BulkMessageResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []*fftypes.MessageInOut) error); ok {
+		r1 = rf(ctx, ns, msgs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ValidateConfig provides a mock function with given fields: ctx
+func (_m *Orchestrator) ValidateConfig(ctx context.Context) *fftypes.ConfigValidationReport {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.ConfigValidationReport
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.ConfigValidationReport); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ConfigValidationReport)
+		}
+	}
+
+	return r0
+}
+
+// WaitForMessageState provides a mock function with given fields: ctx, ns, id, state, timeout
+func (_m *Orchestrator) WaitForMessageState(ctx context.Context, ns string, id string, state fftypes.MessageState, timeout time.Duration) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, ns, id, state, timeout)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, fftypes.MessageState, time.Duration) *fftypes.Message); ok {
+		r0 = rf(ctx, ns, id, state, timeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, fftypes.MessageState, time.Duration) error); ok {
+		r1 = rf(ctx, ns, id, state, timeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WaitForOperationState provides a mock function with given fields: ctx, ns, id, status, timeout
+func (_m *Orchestrator) WaitForOperationState(ctx context.Context, ns string, id string, status fftypes.OpStatus, timeout time.Duration) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, id, status, timeout)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, fftypes.OpStatus, time.Duration) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, id, status, timeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, fftypes.OpStatus, time.Duration) error); ok {
+		r1 = rf(ctx, ns, id, status, timeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WaitStop provides a mock function with given fields:
 func (_m *Orchestrator) WaitStop() {
 	_m.Called()
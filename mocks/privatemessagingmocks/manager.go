@@ -18,6 +18,75 @@ type Manager struct {
 	mock.Mock
 }
 
+// ChangeGroupMembers provides a mock function with given fields: ctx, ns, groupHash, input
+func (_m *Manager) ChangeGroupMembers(ctx context.Context, ns string, groupHash string, input *fftypes.GroupMembershipChangeInput) (*fftypes.GroupMembershipChange, error) {
+	ret := _m.Called(ctx, ns, groupHash, input)
+
+	var r0 *fftypes.GroupMembershipChange
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *fftypes.GroupMembershipChangeInput) *fftypes.GroupMembershipChange); ok {
+		r0 = rf(ctx, ns, groupHash, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.GroupMembershipChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *fftypes.GroupMembershipChangeInput) error); ok {
+		r1 = rf(ctx, ns, groupHash, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateGroup provides a mock function with given fields: ctx, ns, in
+func (_m *Manager) CreateGroup(ctx context.Context, ns string, in *fftypes.InputGroup) (*fftypes.Group, error) {
+	ret := _m.Called(ctx, ns, in)
+
+	var r0 *fftypes.Group
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.InputGroup) *fftypes.Group); ok {
+		r0 = rf(ctx, ns, in)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Group)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.InputGroup) error); ok {
+		r1 = rf(ctx, ns, in)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecryptTransport provides a mock function with given fields: ctx, et
+func (_m *Manager) DecryptTransport(ctx context.Context, et *fftypes.EncryptedTransport) ([]byte, error) {
+	ret := _m.Called(ctx, et)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.EncryptedTransport) []byte); ok {
+		r0 = rf(ctx, et)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.EncryptedTransport) error); ok {
+		r1 = rf(ctx, et)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // EnsureLocalGroup provides a mock function with given fields: ctx, group
 func (_m *Manager) EnsureLocalGroup(ctx context.Context, group *fftypes.Group) (bool, error) {
 	ret := _m.Called(ctx, group)
@@ -39,6 +108,20 @@ func (_m *Manager) EnsureLocalGroup(ctx context.Context, group *fftypes.Group) (
 	return r0, r1
 }
 
+// EnsurePrivacyGroup provides a mock function with given fields: ctx, ns, group
+func (_m *Manager) EnsurePrivacyGroup(ctx context.Context, ns string, group *fftypes.Bytes32) error {
+	ret := _m.Called(ctx, ns, group)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Bytes32) error); ok {
+		r0 = rf(ctx, ns, group)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetGroupByID provides a mock function with given fields: ctx, id
 func (_m *Manager) GetGroupByID(ctx context.Context, id string) (*fftypes.Group, error) {
 	ret := _m.Called(ctx, id)
@@ -62,6 +145,38 @@ func (_m *Manager) GetGroupByID(ctx context.Context, id string) (*fftypes.Group,
 	return r0, r1
 }
 
+// GetGroupMembershipChanges provides a mock function with given fields: ctx, ns, groupHash, filter
+func (_m *Manager) GetGroupMembershipChanges(ctx context.Context, ns string, groupHash string, filter database.AndFilter) ([]*fftypes.GroupMembershipChange, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, groupHash, filter)
+
+	var r0 []*fftypes.GroupMembershipChange
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) []*fftypes.GroupMembershipChange); ok {
+		r0 = rf(ctx, ns, groupHash, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.GroupMembershipChange)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, groupHash, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, groupHash, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetGroupsNS provides a mock function with given fields: ctx, ns, filter
 func (_m *Manager) GetGroupsNS(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
@@ -179,6 +294,20 @@ func (_m *Manager) SendMessage(ctx context.Context, ns string, in *fftypes.Messa
 	return r0, r1
 }
 
+// SendPrivateData provides a mock function with given fields: ctx, batch
+func (_m *Manager) SendPrivateData(ctx context.Context, batch *fftypes.Batch) error {
+	ret := _m.Called(ctx, batch)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Batch) error); ok {
+		r0 = rf(ctx, batch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Start provides a mock function with given fields:
 func (_m *Manager) Start() error {
 	ret := _m.Called()
@@ -7,6 +7,8 @@ import (
 
 	config "github.com/hyperledger/firefly/internal/config"
 
+	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
+
 	io "io"
 
 	mock "github.com/stretchr/testify/mock"
@@ -68,6 +70,34 @@ func (_m *Plugin) Name() string {
 	return r0
 }
 
+// PinData provides a mock function with given fields: ctx, operationID, payloadRef
+func (_m *Plugin) PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) error {
+	ret := _m.Called(ctx, operationID, payloadRef)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, string) error); ok {
+		r0 = rf(ctx, operationID, payloadRef)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *Plugin) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PublishData provides a mock function with given fields: ctx, data
 func (_m *Plugin) PublishData(ctx context.Context, data io.Reader) (string, error) {
 	ret := _m.Called(ctx, data)
@@ -23,6 +23,29 @@ func (_m *Bridge) Init(sysevents sysmessaging.SystemEvents) {
 	_m.Called(sysevents)
 }
 
+// WaitForInvokeOpResult provides a mock function with given fields: ctx, ns, id, send
+func (_m *Bridge) WaitForInvokeOpResult(ctx context.Context, ns string, id *fftypes.UUID, send syncasync.RequestSender) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, id, send)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.UUID, syncasync.RequestSender) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, id, send)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.UUID, syncasync.RequestSender) error); ok {
+		r1 = rf(ctx, ns, id, send)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WaitForMessage provides a mock function with given fields: ctx, ns, id, send
 func (_m *Bridge) WaitForMessage(ctx context.Context, ns string, id *fftypes.UUID, send syncasync.RequestSender) (*fftypes.Message, error) {
 	ret := _m.Called(ctx, ns, id, send)
@@ -46,6 +69,52 @@ func (_m *Bridge) WaitForMessage(ctx context.Context, ns string, id *fftypes.UUI
 	return r0, r1
 }
 
+// WaitForMessageState provides a mock function with given fields: ctx, ns, id, state
+func (_m *Bridge) WaitForMessageState(ctx context.Context, ns string, id *fftypes.UUID, state fftypes.MessageState) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, ns, id, state)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.UUID, fftypes.MessageState) *fftypes.Message); ok {
+		r0 = rf(ctx, ns, id, state)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.UUID, fftypes.MessageState) error); ok {
+		r1 = rf(ctx, ns, id, state)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WaitForOperationState provides a mock function with given fields: ctx, ns, id, status
+func (_m *Bridge) WaitForOperationState(ctx context.Context, ns string, id *fftypes.UUID, status fftypes.OpStatus) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, id, status)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.UUID, fftypes.OpStatus) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, id, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.UUID, fftypes.OpStatus) error); ok {
+		r1 = rf(ctx, ns, id, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WaitForReply provides a mock function with given fields: ctx, ns, id, send
 func (_m *Bridge) WaitForReply(ctx context.Context, ns string, id *fftypes.UUID, send syncasync.RequestSender) (*fftypes.MessageInOut, error) {
 	ret := _m.Called(ctx, ns, id, send)
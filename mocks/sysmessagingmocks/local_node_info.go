@@ -29,3 +29,17 @@ func (_m *LocalNodeInfo) GetNodeUUID(ctx context.Context) *fftypes.UUID {
 
 	return r0
 }
+
+// SignBatchHash provides a mock function with given fields: ctx, hash
+func (_m *LocalNodeInfo) SignBatchHash(ctx context.Context, hash *fftypes.Bytes32) string {
+	ret := _m.Called(ctx, hash)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) string); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
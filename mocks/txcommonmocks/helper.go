@@ -49,13 +49,36 @@ func (_m *Helper) PersistTransaction(ctx context.Context, ns string, id *fftypes
 	return r0, r1
 }
 
-// SubmitNewTransaction provides a mock function with given fields: ctx, ns, txType
-func (_m *Helper) SubmitNewTransaction(ctx context.Context, ns string, txType fftypes.FFEnum) (*fftypes.UUID, error) {
-	ret := _m.Called(ctx, ns, txType)
+// PrepareOperationRetry provides a mock function with given fields: ctx, op
+func (_m *Helper) PrepareOperationRetry(ctx context.Context, op *fftypes.Operation) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, op)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) *fftypes.Operation); ok {
+		r0 = rf(ctx, op)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Operation) error); ok {
+		r1 = rf(ctx, op)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubmitNewTransaction provides a mock function with given fields: ctx, ns, txType, idempotencyKey
+func (_m *Helper) SubmitNewTransaction(ctx context.Context, ns string, txType fftypes.FFEnum, idempotencyKey fftypes.IdempotencyKey) (*fftypes.UUID, error) {
+	ret := _m.Called(ctx, ns, txType, idempotencyKey)
 
 	var r0 *fftypes.UUID
-	if rf, ok := ret.Get(0).(func(context.Context, string, fftypes.FFEnum) *fftypes.UUID); ok {
-		r0 = rf(ctx, ns, txType)
+	if rf, ok := ret.Get(0).(func(context.Context, string, fftypes.FFEnum, fftypes.IdempotencyKey) *fftypes.UUID); ok {
+		r0 = rf(ctx, ns, txType, idempotencyKey)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*fftypes.UUID)
@@ -63,8 +86,8 @@ func (_m *Helper) SubmitNewTransaction(ctx context.Context, ns string, txType ff
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, fftypes.FFEnum) error); ok {
-		r1 = rf(ctx, ns, txType)
+	if rf, ok := ret.Get(1).(func(context.Context, string, fftypes.FFEnum, fftypes.IdempotencyKey) error); ok {
+		r1 = rf(ctx, ns, txType, idempotencyKey)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,9 +95,9 @@ func (_m *Helper) SubmitNewTransaction(ctx context.Context, ns string, txType ff
 	return r0, r1
 }
 
-// WriteOperationFailure provides a mock function with given fields: ctx, opID, err
-func (_m *Helper) WriteOperationFailure(ctx context.Context, opID *fftypes.UUID, err error) {
-	_m.Called(ctx, opID, err)
+// WriteOperationFailure provides a mock function with given fields: ctx, op, err
+func (_m *Helper) WriteOperationFailure(ctx context.Context, op *fftypes.Operation, err error) {
+	_m.Called(ctx, op, err)
 }
 
 // WriteOperationSuccess provides a mock function with given fields: ctx, opID, output
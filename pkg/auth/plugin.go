@@ -0,0 +1,54 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Plugin is the interface implemented by each API authentication plugin
+type Plugin interface {
+	fftypes.Named
+
+	// InitPrefix initializes the set of configuration options that are valid, with defaults. Called on all plugins.
+	InitPrefix(prefix config.Prefix)
+
+	// Init initializes the plugin, with configuration
+	Init(ctx context.Context, prefix config.Prefix) error
+
+	// Authenticate resolves the identity of the caller from the credentials presented on an API request.
+	// Returns an error if the request does not carry valid credentials for this plugin.
+	Authenticate(req *http.Request) (identity string, err error)
+}
+
+type identityContextKey struct{}
+
+// WithIdentity returns a context carrying the authenticated identity of the API caller
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// GetIdentity returns the authenticated identity of the API caller, or an empty string if the
+// request was not authenticated
+func GetIdentity(ctx context.Context) string {
+	identity, _ := ctx.Value(identityContextKey{}).(string)
+	return identity
+}
@@ -0,0 +1,46 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batchvalidator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Plugin is the interface implemented by each batch validator.
+// Batch validators are called for every batch received from the network, after the built-in
+// checks on the batch have passed, but before any of the messages or data in the batch are persisted.
+type Plugin interface {
+	fftypes.Named
+
+	// InitPrefix initializes the set of configuration options that are valid, with defaults. Called on all plugins.
+	InitPrefix(prefix config.Prefix)
+
+	// Init initializes the plugin, with configuration
+	Init(ctx context.Context, prefix config.Prefix) error
+
+	// ValidateBatch checks a batch before it is persisted. The contract is:
+	// - Return valid=true to allow the batch to proceed (to the next validator, then to be persisted)
+	// - Return valid=false with a reason to reject the batch. This is final - the batch will not be retried,
+	//   and the reason is recorded against the batch.
+	// - Return an error for transient failures, such as a failure to reach an external system. The batch
+	//   will be retried, so the validator must return a consistent answer for the same batch once available.
+	// The context passed is within the database transaction of the event processor, so calls must not block indefinitely.
+	ValidateBatch(ctx context.Context, batch *fftypes.Batch) (valid bool, reason string, err error)
+}
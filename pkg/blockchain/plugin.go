@@ -40,15 +40,38 @@ type Plugin interface {
 	// Capabilities returns capabilities - not called until after Init
 	Capabilities() *Capabilities
 
+	// ConnectorHealth returns the result of the latest liveness probe of the connector, or nil if not probed
+	ConnectorHealth() *fftypes.ConnectorHealth
+
+	// VerifierType returns the type of the signing keys used by this blockchain
+	VerifierType() fftypes.VerifierType
+
 	// ResolveSigningKey verifies that the supplied identity string is valid syntax according to the protocol.
 	// Can apply transformations to the supplied signing identity (only), such as lower case
 	ResolveSigningKey(ctx context.Context, signingKey string) (string, error)
 
+	// ResolveTransactionFee returns the fee the fee policy of the namespace chooses for the next transaction,
+	// or nil if the plugin does not set fees for the namespace
+	ResolveTransactionFee(ctx context.Context, ns string) (*fftypes.TransactionFee, error)
+
+	// PrivacyKey returns the key this node uses for private transactions, such as the Tessera public key of a Besu node,
+	// or an empty string if the pins of private batches are submitted publicly
+	PrivacyKey() string
+
+	// EnsurePrivacyGroup looks up, or creates, the privacy group containing the nodes with the supplied privacy keys, and
+	// subscribes to the pins submitted in it. Only called when PrivacyKey is set, before the pins of the group are submitted
+	// or expected, so the pins of private batches sent to the group are delivered on every member node
+	EnsurePrivacyGroup(ctx context.Context, group *fftypes.Bytes32, privacyKeys []string) error
+
 	// SubmitBatchPin sequences a batch of message globally to all viewers of a given ledger
 	SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batch *BatchPin) error
 
 	// InvokeContract submits a new transaction to be executed by custom on-chain logic
-	InvokeContract(ctx context.Context, operationID *fftypes.UUID, signingKey string, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}) error
+	InvokeContract(ctx context.Context, operationID *fftypes.UUID, signingKey string, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}, fee *fftypes.TransactionFee) error
+
+	// SubmitRawTransaction passes a transaction in the connector's native format through to the connector,
+	// with the operation ID and signing key applied so the receipt can be correlated
+	SubmitRawTransaction(ctx context.Context, operationID *fftypes.UUID, signingKey string, tx fftypes.JSONObject) error
 
 	// QueryContract executes a method via custom on-chain logic and returns the result
 	QueryContract(ctx context.Context, location *fftypes.JSONAny, method *fftypes.FFIMethod, input map[string]interface{}) (interface{}, error)
@@ -59,9 +82,21 @@ type Plugin interface {
 	// DeleteSubscription deletes a previously-created subscription
 	DeleteSubscription(ctx context.Context, subscription *fftypes.ContractSubscription) error
 
+	// ResetBatchPinSubscription replaces the subscription to batch pin events with one that starts from the genesis block,
+	// so that all historical batch pins are re-delivered
+	ResetBatchPinSubscription(ctx context.Context) error
+
+	// GetBatchPin looks up the batch pin emitted by a blockchain transaction, along with the key that signed the transaction,
+	// so that batch pins learned of from outside of the blockchain can be verified. Returns nil if the transaction did not pin a batch
+	GetBatchPin(ctx context.Context, blockchainTXID string) (batch *BatchPin, signingKey string, err error)
+
 	// GetFFIParamValidator returns a blockchain-plugin-specific validator for FFIParams and their JSON Schema
 	GetFFIParamValidator(ctx context.Context) (fftypes.FFIParamValidator, error)
 
+	// VerifyIdentityRegistered checks the signing key is registered in the on-chain identity registry configured for the plugin,
+	// as of the given block (or the latest block if nil). Only called when the plugin reports the IdentityRegistry capability
+	VerifyIdentityRegistered(ctx context.Context, signingKey string, blockNumber *fftypes.FFBigInt) (registered bool, err error)
+
 	// GenerateFFI returns an FFI from a blockchain specific interface format e.g. an Ethereum ABI
 	GenerateFFI(ctx context.Context, generationRequest *fftypes.FFIGenerationRequest) (*fftypes.FFI, error)
 }
@@ -75,11 +110,12 @@ type Callbacks interface {
 	// BlockchainOpUpdate notifies firefly of an update to this plugin's operation within a transaction.
 	// Only success/failure and errorMessage (for errors) are modeled.
 	// opOutput can be used to add opaque protocol specific JSON from the plugin (protocol transaction ID etc.)
+	// receipt is the structured outcome of the transaction (block number, gas used, revert reason), where available.
 	// Note this is an optional hook information, and stored separately to the confirmation of the actual event that was being submitted/sequenced.
 	// Only the party submitting the transaction will see this data.
 	//
 	// Error should will only be returned in shutdown scenarios
-	BlockchainOpUpdate(operationID *fftypes.UUID, txState TransactionStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject) error
+	BlockchainOpUpdate(operationID *fftypes.UUID, txState TransactionStatus, blockchainTXID, errorMessage string, opOutput fftypes.JSONObject, receipt *fftypes.TransactionReceipt) error
 
 	// BatchPinComplete notifies on the arrival of a sequenced batch of messages, which might have been
 	// submitted by us, or by any other authorized party in the network.
@@ -97,6 +133,9 @@ type Capabilities struct {
 	// GlobalSequencer means submitting an ordered piece of data visible to all
 	// participants of the network (requires an all-participant chain)
 	GlobalSequencer bool
+
+	// IdentityRegistry means an on-chain registry of the identities that are allowed to submit batches is configured
+	IdentityRegistry bool
 }
 
 // TransactionStatus is the only architecturally significant thing that Firefly tracks on blockchain transactions.
@@ -121,6 +160,17 @@ type BatchPin struct {
 	// BatchPayloadRef is a string that can be passed to to the storage interface to retrieve the payload. Nil for private messages
 	BatchPayloadRef string
 
+	// Group is the hash of the group a private batch was sent to, which is not written to the chain, but can be used
+	// by the plugin to restrict the visibility of the pin. Nil for broadcast messages
+	Group *fftypes.Bytes32
+
+	// Ledger is the name of the configured ledger the pin was received from, which is set by the FireFly core
+	// rather than the plugin. Empty for the default ledger
+	Ledger string
+
+	// Fee is the fee resolved by ResolveTransactionFee for the namespace, to apply when submitting the pin. Nil if not set
+	Fee *fftypes.TransactionFee
+
 	// Contexts is an array of hashes that allow the FireFly runtimes to identify whether one of the messgages in
 	// that batch is the next message for a sequence that involves that node. If so that means the FireFly runtime must
 	//
@@ -47,6 +47,10 @@ type Filter interface {
 	// Request a count to be returned on the total number that match the query
 	Count(c bool) Filter
 
+	// RequiredFields limits the fields of each result the caller needs, as dot separated JSON paths.
+	// Plugins can use this to avoid reading unneeded columns, but can also return every field.
+	RequiredFields(fields ...string) Filter
+
 	// Finalize completes the filter, and for the plugin to validated output structure to convert
 	Finalize() (*FilterInfo, error)
 
@@ -216,16 +220,17 @@ type SortField struct {
 // FilterInfo is the structure returned by Finalize to the plugin, to serialize this filter
 // into the underlying database mechanism's filter language
 type FilterInfo struct {
-	Sort      []*SortField
-	Skip      uint64
-	Limit     uint64
-	Count     bool
-	CountExpr string
-	Field     string
-	Op        FilterOp
-	Values    []FieldSerialization
-	Value     FieldSerialization
-	Children  []*FilterInfo
+	Sort           []*SortField
+	Skip           uint64
+	Limit          uint64
+	Count          bool
+	CountExpr      string
+	RequiredFields []string
+	Field          string
+	Op             FilterOp
+	Values         []FieldSerialization
+	Value          FieldSerialization
+	Children       []*FilterInfo
 }
 
 // FilterResult is has additional info if requested on the query - currently only the total count
@@ -314,6 +319,7 @@ type filterBuilder struct {
 	skip            uint64
 	limit           uint64
 	count           bool
+	requiredFields  []string
 	forceAscending  bool
 	forceDescending bool
 }
@@ -401,15 +407,16 @@ func (f *baseFilter) Finalize() (fi *FilterInfo, err error) {
 	}
 
 	return &FilterInfo{
-		Children: children,
-		Op:       f.op,
-		Field:    f.field,
-		Values:   values,
-		Value:    value,
-		Sort:     f.fb.sort,
-		Skip:     f.fb.skip,
-		Limit:    f.fb.limit,
-		Count:    f.fb.count,
+		Children:       children,
+		Op:             f.op,
+		Field:          f.field,
+		Values:         values,
+		Value:          value,
+		Sort:           f.fb.sort,
+		Skip:           f.fb.skip,
+		Limit:          f.fb.limit,
+		Count:          f.fb.count,
+		RequiredFields: f.fb.requiredFields,
 	}, nil
 }
 
@@ -445,6 +452,11 @@ func (f *baseFilter) Count(c bool) Filter {
 	return f
 }
 
+func (f *baseFilter) RequiredFields(fields ...string) Filter {
+	f.fb.requiredFields = append(f.fb.requiredFields, fields...)
+	return f
+}
+
 func (f *baseFilter) Ascending() Filter {
 	f.fb.forceAscending = true
 	return f
@@ -342,3 +342,10 @@ func TestStringsForTypes(t *testing.T) {
 	assert.Equal(t, "t1,t2", (&ffNameArrayField{na: fftypes.FFStringArray{"t1", "t2"}}).String())
 	assert.Equal(t, "true", (&boolField{b: true}).String())
 }
+
+func TestQueryFactoryRequiredFields(t *testing.T) {
+	fb := MessageQueryFactory.NewFilter(context.Background())
+	fi, err := fb.And().RequiredFields("header.id").RequiredFields("state").Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"header.id", "state"}, fi.RequiredFields)
+}
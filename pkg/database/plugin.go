@@ -18,6 +18,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -29,6 +30,8 @@ var (
 	HashMismatch = i18n.NewError(context.Background(), i18n.MsgHashMismatch)
 	// IDMismatch sentinel error
 	IDMismatch = i18n.NewError(context.Background(), i18n.MsgIDMismatch)
+	// DuplicateKey sentinel error, when an insert conflicts with an existing record on a unique index
+	DuplicateKey = i18n.NewError(context.Background(), i18n.MsgDuplicateKey)
 	// DeleteRecordNotFound sentinel error
 	DeleteRecordNotFound = i18n.NewError(context.Background(), i18n.Msg404NotFound)
 )
@@ -54,6 +57,12 @@ type Plugin interface {
 
 	// Capabilities returns capabilities - not called until after Init
 	Capabilities() *Capabilities
+
+	// PendingMigrations returns the names of the schema migrations not yet applied to the database, in the order they would be applied
+	PendingMigrations(ctx context.Context) ([]string, error)
+
+	// Ping checks the database can be reached
+	Ping(ctx context.Context) error
 }
 
 type iNamespaceCollection interface {
@@ -64,6 +73,9 @@ type iNamespaceCollection interface {
 	// DeleteNamespace - Delete namespace
 	DeleteNamespace(ctx context.Context, id *fftypes.UUID) (err error)
 
+	// DeleteNamespaceData - Delete all data scoped to a namespace, across every collection
+	DeleteNamespaceData(ctx context.Context, ns string) (err error)
+
 	// GetNamespace - Get an namespace by name
 	GetNamespace(ctx context.Context, name string) (offset *fftypes.Namespace, err error)
 
@@ -114,6 +126,9 @@ type iDataCollection interface {
 
 	// GetDataRefs - Get data references only (no data)
 	GetDataRefs(ctx context.Context, filter Filter) (message fftypes.DataRefs, res *FilterResult, err error)
+
+	// DeleteData - Delete data records that have been moved into an archive
+	DeleteData(ctx context.Context, ids []*fftypes.UUID) (err error)
 }
 
 type iBatchCollection interface {
@@ -199,6 +214,9 @@ type iOperationCollection interface {
 	// ResolveOperation - Resolve operation upon completion
 	ResolveOperation(ctx context.Context, id *fftypes.UUID, status fftypes.OpStatus, errorMsg string, output fftypes.JSONObject) (err error)
 
+	// UpdateOperation - Update an operation
+	UpdateOperation(ctx context.Context, id *fftypes.UUID, update Update) (err error)
+
 	// GetOperationByID - Get an operation by ID
 	GetOperationByID(ctx context.Context, id *fftypes.UUID) (operation *fftypes.Operation, err error)
 
@@ -227,6 +245,14 @@ type iSubscriptionCollection interface {
 	DeleteSubscriptionByID(ctx context.Context, id *fftypes.UUID) (err error)
 }
 
+type iSubscriptionChangeCollection interface {
+	// InsertSubscriptionChange - Record a change to a subscription definition
+	InsertSubscriptionChange(ctx context.Context, change *fftypes.SubscriptionChange) (err error)
+
+	// GetSubscriptionChanges - Get the history of changes to subscription definitions
+	GetSubscriptionChanges(ctx context.Context, filter Filter) ([]*fftypes.SubscriptionChange, *FilterResult, error)
+}
+
 type iEventCollection interface {
 	// InsertEvent - Insert an event. The order of the sequences added to the database, must match the order that
 	//               the rows/objects appear available to the event dispatcher. For a concurrency enabled database
@@ -242,6 +268,9 @@ type iEventCollection interface {
 
 	// GetEvents - Get events
 	GetEvents(ctx context.Context, filter Filter) (message []*fftypes.Event, res *FilterResult, err error)
+
+	// DeleteEvents - Delete events that have been moved into an archive
+	DeleteEvents(ctx context.Context, ids []*fftypes.UUID) (err error)
 }
 
 type iOrganizationsCollection interface {
@@ -295,6 +324,14 @@ type iGroupCollection interface {
 	GetGroups(ctx context.Context, filter Filter) (node []*fftypes.Group, res *FilterResult, err error)
 }
 
+type iGroupMembershipChangeCollection interface {
+	// InsertGroupMembershipChange - Record a membership change, and update the version and member list of the group
+	InsertGroupMembershipChange(ctx context.Context, change *fftypes.GroupMembershipChange) (err error)
+
+	// GetGroupMembershipChanges - Get the history of membership changes
+	GetGroupMembershipChanges(ctx context.Context, filter Filter) ([]*fftypes.GroupMembershipChange, *FilterResult, error)
+}
+
 type iNonceCollection interface {
 	// UpsertNonceNext - Upsert a context, assigning zero if not found, or the next nonce if it is
 	UpsertNonceNext(ctx context.Context, context *fftypes.Nonce) (err error)
@@ -478,6 +515,42 @@ type iBlockchainEventCollection interface {
 	GetBlockchainEvents(ctx context.Context, filter Filter) ([]*fftypes.BlockchainEvent, *FilterResult, error)
 }
 
+type iQuarantinedBatchCollection interface {
+	// InsertQuarantinedBatch - insert a record of a batch that failed validation
+	InsertQuarantinedBatch(ctx context.Context, qb *fftypes.QuarantinedBatch) (err error)
+
+	// GetQuarantinedBatchByID - get a quarantined batch by ID
+	GetQuarantinedBatchByID(ctx context.Context, id *fftypes.UUID) (*fftypes.QuarantinedBatch, error)
+
+	// GetQuarantinedBatches - get quarantined batches
+	GetQuarantinedBatches(ctx context.Context, filter Filter) ([]*fftypes.QuarantinedBatch, *FilterResult, error)
+
+	// DeleteQuarantinedBatch - delete a quarantined batch
+	DeleteQuarantinedBatch(ctx context.Context, id *fftypes.UUID) (err error)
+}
+
+type iArchiveCollection interface {
+	// InsertArchive - insert the manifest of an archive, and index its entries
+	InsertArchive(ctx context.Context, archive *fftypes.Archive) (err error)
+
+	// GetArchiveByID - get the manifest of an archive by ID
+	GetArchiveByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Archive, error)
+
+	// GetArchives - get archive manifests
+	GetArchives(ctx context.Context, filter Filter) ([]*fftypes.Archive, *FilterResult, error)
+
+	// GetArchiveForEntry - get the manifest of the archive that contains an entry
+	GetArchiveForEntry(ctx context.Context, entryType fftypes.ArchiveEntryType, id *fftypes.UUID) (*fftypes.Archive, error)
+}
+
+type iAuditRecordCollection interface {
+	// InsertAuditRecord - insert a record of a call to the API
+	InsertAuditRecord(ctx context.Context, record *fftypes.AuditRecord) (err error)
+
+	// GetAuditRecords - get audit records
+	GetAuditRecords(ctx context.Context, filter Filter) ([]*fftypes.AuditRecord, *FilterResult, error)
+}
+
 // PersistenceInterface are the operations that must be implemented by a database interface plugin.
 type iChartCollection interface {
 	// GetChartHistogram - Get charting data for a histogram
@@ -507,7 +580,6 @@ type iChartCollection interface {
 // interface.
 // For SQL databases the process of adding a new database is simplified via the common SQL layer.
 // For NoSQL databases, the code should be straight forward to map the collections, indexes, and operations.
-//
 type PersistenceInterface interface {
 	fftypes.Named
 
@@ -529,10 +601,12 @@ type PersistenceInterface interface {
 	iPinCollection
 	iOperationCollection
 	iSubscriptionCollection
+	iSubscriptionChangeCollection
 	iEventCollection
 	iOrganizationsCollection
 	iNodeCollection
 	iGroupCollection
+	iGroupMembershipChangeCollection
 	iNonceCollection
 	iNextPinCollection
 	iBlobCollection
@@ -547,6 +621,9 @@ type PersistenceInterface interface {
 	iContractAPICollection
 	iContractSubscriptionCollection
 	iBlockchainEventCollection
+	iQuarantinedBatchCollection
+	iArchiveCollection
+	iAuditRecordCollection
 	iChartCollection
 }
 
@@ -578,18 +655,20 @@ const (
 type UUIDCollectionNS CollectionName
 
 const (
-	CollectionBatches               UUIDCollectionNS = "batches"
-	CollectionData                  UUIDCollectionNS = "data"
-	CollectionDataTypes             UUIDCollectionNS = "datatypes"
-	CollectionOperations            UUIDCollectionNS = "operations"
-	CollectionSubscriptions         UUIDCollectionNS = "subscriptions"
-	CollectionTransactions          UUIDCollectionNS = "transactions"
-	CollectionTokenPools            UUIDCollectionNS = "tokenpools"
-	CollectionFFIs                  UUIDCollectionNS = "ffi"
-	CollectionFFIMethods            UUIDCollectionNS = "ffimethods"
-	CollectionFFIEvents             UUIDCollectionNS = "ffievents"
-	CollectionContractAPIs          UUIDCollectionNS = "contractapis"
-	CollectionContractSubscriptions UUIDCollectionNS = "contractsubscriptions"
+	CollectionBatches                UUIDCollectionNS = "batches"
+	CollectionData                   UUIDCollectionNS = "data"
+	CollectionDataTypes              UUIDCollectionNS = "datatypes"
+	CollectionOperations             UUIDCollectionNS = "operations"
+	CollectionSubscriptions          UUIDCollectionNS = "subscriptions"
+	CollectionTransactions           UUIDCollectionNS = "transactions"
+	CollectionTokenPools             UUIDCollectionNS = "tokenpools"
+	CollectionFFIs                   UUIDCollectionNS = "ffi"
+	CollectionFFIMethods             UUIDCollectionNS = "ffimethods"
+	CollectionFFIEvents              UUIDCollectionNS = "ffievents"
+	CollectionContractAPIs           UUIDCollectionNS = "contractapis"
+	CollectionContractSubscriptions  UUIDCollectionNS = "contractsubscriptions"
+	CollectionQuarantinedBatches     UUIDCollectionNS = "quarantinedbatches"
+	CollectionGroupMembershipChanges UUIDCollectionNS = "groupmembershipchanges"
 )
 
 // HashCollectionNS is a collection where the primary key is a hash, such that it can
@@ -625,6 +704,7 @@ const (
 	CollectionNonces        OtherCollection = "nonces"
 	CollectionOffsets       OtherCollection = "offsets"
 	CollectionTokenBalances OtherCollection = "tokenbalances"
+	CollectionAuditRecords  OtherCollection = "auditrecords"
 )
 
 // Callbacks are the methods for passing data from plugin to core
@@ -644,7 +724,6 @@ const (
 // Events are emitted locally to the individual FireFly core process. However, a WebSocket interface is
 // available for remote listening to these events. That allows the UI to listen to the events, as well as
 // providing a building block for a cluster of FireFly servers to directly propgate events to each other.
-//
 type Callbacks interface {
 	// OrderedUUIDCollectionNSEvent emits the sequence on insert, but it will be -1 on update
 	OrderedUUIDCollectionNSEvent(resType OrderedUUIDCollectionNS, eventType fftypes.ChangeEventType, ns string, id *fftypes.UUID, sequence int64)
@@ -652,6 +731,14 @@ type Callbacks interface {
 	UUIDCollectionNSEvent(resType UUIDCollectionNS, eventType fftypes.ChangeEventType, ns string, id *fftypes.UUID)
 	UUIDCollectionEvent(resType UUIDCollection, eventType fftypes.ChangeEventType, id *fftypes.UUID)
 	HashCollectionNSEvent(resType HashCollectionNS, eventType fftypes.ChangeEventType, ns string, hash *fftypes.Bytes32)
+
+	// SubscriptionChangeEvent is emitted when a change to a subscription definition is recorded, with the
+	// definition before and after the change
+	SubscriptionChangeEvent(change *fftypes.SubscriptionChange)
+
+	// ObserveLatency is called with the elapsed time and outcome of each call made to the database, so the
+	// node can detect and respond to database pressure. The context is that of the call.
+	ObserveLatency(ctx context.Context, elapsed time.Duration, err error)
 }
 
 // Capabilities defines the capabilities a plugin can report as implementing or not
@@ -668,27 +755,32 @@ var NamespaceQueryFactory = &queryFields{
 	"description": &StringField{},
 	"created":     &TimeField{},
 	"confirmed":   &TimeField{},
+	"retired":     &TimeField{},
 }
 
 // MessageQueryFactory filter fields for messages
 var MessageQueryFactory = &queryFields{
-	"id":        &UUIDField{},
-	"cid":       &UUIDField{},
-	"namespace": &StringField{},
-	"type":      &StringField{},
-	"author":    &StringField{},
-	"key":       &StringField{},
-	"topics":    &FFStringArrayField{},
-	"tag":       &StringField{},
-	"group":     &Bytes32Field{},
-	"created":   &TimeField{},
-	"hash":      &Bytes32Field{},
-	"pins":      &FFStringArrayField{},
-	"state":     &StringField{},
-	"confirmed": &TimeField{},
-	"sequence":  &Int64Field{},
-	"txtype":    &StringField{},
-	"batch":     &UUIDField{},
+	"id":             &UUIDField{},
+	"cid":            &UUIDField{},
+	"namespace":      &StringField{},
+	"type":           &StringField{},
+	"author":         &StringField{},
+	"key":            &StringField{},
+	"topics":         &FFStringArrayField{},
+	"tag":            &StringField{},
+	"group":          &Bytes32Field{},
+	"groupversion":   &Int64Field{},
+	"created":        &TimeField{},
+	"hash":           &Bytes32Field{},
+	"pins":           &FFStringArrayField{},
+	"state":          &StringField{},
+	"confirmed":      &TimeField{},
+	"sequence":       &Int64Field{},
+	"txtype":         &StringField{},
+	"batch":          &UUIDField{},
+	"priority":       &StringField{},
+	"expiry":         &TimeField{},
+	"idempotencykey": &StringField{},
 }
 
 // BatchQueryFactory filter fields for batches
@@ -706,15 +798,17 @@ var BatchQueryFactory = &queryFields{
 	"tx.type":    &StringField{},
 	"tx.id":      &UUIDField{},
 	"node":       &UUIDField{},
+	"encoding":   &StringField{},
 }
 
 // TransactionQueryFactory filter fields for transactions
 var TransactionQueryFactory = &queryFields{
-	"id":            &UUIDField{},
-	"type":          &StringField{},
-	"created":       &TimeField{},
-	"namespace":     &StringField{},
-	"blockchainids": &FFStringArrayField{},
+	"id":             &UUIDField{},
+	"type":           &StringField{},
+	"created":        &TimeField{},
+	"namespace":      &StringField{},
+	"blockchainids":  &FFStringArrayField{},
+	"idempotencykey": &StringField{},
 }
 
 // DataQueryFactory filter fields for data
@@ -731,6 +825,7 @@ var DataQueryFactory = &queryFields{
 	"blob.size":        &Int64Field{},
 	"created":          &TimeField{},
 	"value":            &JSONField{},
+	"group":            &Bytes32Field{},
 }
 
 // DatatypeQueryFactory filter fields for data definitions
@@ -764,6 +859,8 @@ var OperationQueryFactory = &queryFields{
 	"output":    &JSONField{},
 	"created":   &TimeField{},
 	"updated":   &TimeField{},
+	"retry":     &UUIDField{},
+	"receipt":   &JSONField{},
 }
 
 // SubscriptionQueryFactory filter fields for data subscriptions
@@ -773,11 +870,23 @@ var SubscriptionQueryFactory = &queryFields{
 	"name":          &StringField{},
 	"transport":     &StringField{},
 	"events":        &StringField{},
+	"filter.events": &StringField{},
 	"filter.topics": &StringField{},
 	"filter.tag":    &StringField{},
 	"filter.group":  &StringField{},
 	"options":       &StringField{},
 	"created":       &TimeField{},
+	"updated":       &TimeField{},
+}
+
+// SubscriptionChangeQueryFactory filter fields for subscription changes
+var SubscriptionChangeQueryFactory = &queryFields{
+	"id":           &UUIDField{},
+	"subscription": &UUIDField{},
+	"namespace":    &StringField{},
+	"name":         &StringField{},
+	"type":         &StringField{},
+	"created":      &TimeField{},
 }
 
 // EventQueryFactory filter fields for data events
@@ -800,6 +909,7 @@ var PinQueryFactory = &queryFields{
 	"index":      &Int64Field{},
 	"dispatched": &BoolField{},
 	"created":    &TimeField{},
+	"ledger":     &StringField{},
 }
 
 // OrganizationQueryFactory filter fields for organizations
@@ -811,18 +921,24 @@ var OrganizationQueryFactory = &queryFields{
 	"description": &StringField{},
 	"profile":     &JSONField{},
 	"created":     &TimeField{},
+
+	"previousidentity": &StringField{},
+	"identityrotated":  &TimeField{},
 }
 
 // NodeQueryFactory filter fields for nodes
 var NodeQueryFactory = &queryFields{
-	"id":          &UUIDField{},
-	"message":     &UUIDField{},
-	"owner":       &StringField{},
-	"name":        &StringField{},
-	"description": &StringField{},
-	"dx.peer":     &StringField{},
-	"dx.endpoint": &JSONField{},
-	"created":     &TimeField{},
+	"id":            &UUIDField{},
+	"message":       &UUIDField{},
+	"owner":         &StringField{},
+	"name":          &StringField{},
+	"description":   &StringField{},
+	"dx.peer":       &StringField{},
+	"dx.endpoint":   &JSONField{},
+	"created":       &TimeField{},
+	"publickey":     &StringField{},
+	"encryptionkey": &StringField{},
+	"privacykey":    &StringField{},
 }
 
 // GroupQueryFactory filter fields for nodes
@@ -832,9 +948,21 @@ var GroupQueryFactory = &queryFields{
 	"namespace":   &StringField{},
 	"description": &StringField{},
 	"ledger":      &UUIDField{},
+	"version":     &Int64Field{},
 	"created":     &TimeField{},
 }
 
+// GroupMembershipChangeQueryFactory filter fields for group membership changes
+var GroupMembershipChangeQueryFactory = &queryFields{
+	"id":            &UUIDField{},
+	"namespace":     &StringField{},
+	"group":         &Bytes32Field{},
+	"effectivefrom": &Int64Field{},
+	"author":        &StringField{},
+	"message":       &UUIDField{},
+	"created":       &TimeField{},
+}
+
 // NonceQueryFactory filter fields for nodes
 var NonceQueryFactory = &queryFields{
 	"context": &StringField{},
@@ -923,6 +1051,7 @@ var TokenTransferQueryFactory = &queryFields{
 	"protocolid":      &StringField{},
 	"message":         &UUIDField{},
 	"messagehash":     &Bytes32Field{},
+	"metadata":        &UUIDField{},
 	"created":         &TimeField{},
 	"tx.type":         &StringField{},
 	"tx.id":           &UUIDField{},
@@ -979,6 +1108,7 @@ var ContractSubscriptionQueryFactory = &queryFields{
 	"interface":  &UUIDField{},
 	"namespace":  &StringField{},
 	"protocolid": &StringField{},
+	"location":   &JSONField{},
 	"created":    &TimeField{},
 }
 
@@ -995,6 +1125,49 @@ var BlockchainEventQueryFactory = &queryFields{
 	"timestamp":    &TimeField{},
 }
 
+// QuarantinedBatchQueryFactory filter fields for quarantined batches
+var QuarantinedBatchQueryFactory = &queryFields{
+	"id":         &UUIDField{},
+	"namespace":  &StringField{},
+	"batch":      &UUIDField{},
+	"tx":         &UUIDField{},
+	"hash":       &Bytes32Field{},
+	"payloadref": &StringField{},
+	"key":        &StringField{},
+	"peer":       &StringField{},
+	"reason":     &StringField{},
+	"created":    &TimeField{},
+}
+
+// ArchiveQueryFactory filter fields for archives
+var ArchiveQueryFactory = &queryFields{
+	"id":            &UUIDField{},
+	"location":      &StringField{},
+	"hash":          &Bytes32Field{},
+	"eventcount":    &Int64Field{},
+	"datacount":     &Int64Field{},
+	"firstsequence": &Int64Field{},
+	"lastsequence":  &Int64Field{},
+	"oldest":        &TimeField{},
+	"newest":        &TimeField{},
+	"created":       &TimeField{},
+}
+
+// AuditRecordQueryFactory filter fields for audit records
+var AuditRecordQueryFactory = &queryFields{
+	"id":          &UUIDField{},
+	"namespace":   &StringField{},
+	"identity":    &StringField{},
+	"remoteaddr":  &StringField{},
+	"method":      &StringField{},
+	"path":        &StringField{},
+	"operation":   &StringField{},
+	"requesthash": &Bytes32Field{},
+	"status":      &Int64Field{},
+	"error":       &StringField{},
+	"created":     &TimeField{},
+}
+
 // ContractAPIQueryFactory filter fields for Contract APIs
 var ContractAPIQueryFactory = &queryFields{
 	"id":        &UUIDField{},
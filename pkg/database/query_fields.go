@@ -284,6 +284,8 @@ func (f *jsonField) Scan(src interface{}) (err error) {
 		f.b = tv
 	case fftypes.JSONObject:
 		f.b, err = json.Marshal(tv)
+	case *fftypes.TransactionReceipt:
+		f.b, err = json.Marshal(tv)
 	case nil:
 		f.b = nil
 	default:
@@ -167,6 +167,12 @@ func TestJSONField(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, v, []byte("{}"))
 
+	err = f.Scan(&fftypes.TransactionReceipt{RevertReason: "pop"})
+	assert.NoError(t, err)
+	v, err = f.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, v, []byte(`{"revertReason":"pop"}`))
+
 	err = f.Scan(nil)
 	assert.NoError(t, err)
 	v, err = f.Value()
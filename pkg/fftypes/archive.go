@@ -0,0 +1,51 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// ArchiveEntryType is the type of a record moved into an archive
+type ArchiveEntryType = FFEnum
+
+var (
+	// ArchiveEntryTypeEvent is an event
+	ArchiveEntryTypeEvent ArchiveEntryType = ffEnum("archiveentrytype", "event")
+	// ArchiveEntryTypeData is the payload data of a message that has been delivered
+	ArchiveEntryTypeData ArchiveEntryType = ffEnum("archiveentrytype", "data")
+)
+
+// ArchiveEntry is the reference to a record in an archive
+type ArchiveEntry struct {
+	Type ArchiveEntryType `json:"type" ffenum:"archiveentrytype"`
+	ID   *UUID            `json:"id"`
+}
+
+// Archive is the manifest of a compressed archive of events and data, that has been moved out
+// of the database into object storage. The entries are only populated in the manifest stored
+// alongside the archive, and are indexed in the database so individual records can be fetched on demand.
+type Archive struct {
+	ID            *UUID           `json:"id"`
+	Location      string          `json:"location"`
+	Hash          *Bytes32        `json:"hash"`
+	Size          int64           `json:"size"`
+	EventCount    int64           `json:"eventCount"`
+	DataCount     int64           `json:"dataCount"`
+	FirstSequence int64           `json:"firstSequence"`
+	LastSequence  int64           `json:"lastSequence"`
+	Oldest        *FFTime         `json:"oldest"`
+	Newest        *FFTime         `json:"newest"`
+	Created       *FFTime         `json:"created"`
+	Entries       []*ArchiveEntry `json:"entries,omitempty"`
+}
@@ -0,0 +1,34 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// AuditRecord is a record of a call to the API that made, or attempted to make, a change.
+// Records are written for both successful and failed calls, so they show who attempted
+// to do what, when, and with what result.
+type AuditRecord struct {
+	ID          *UUID    `json:"id"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Identity    string   `json:"identity,omitempty"`
+	RemoteAddr  string   `json:"remoteAddr,omitempty"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Operation   string   `json:"operation"`
+	RequestHash *Bytes32 `json:"requestHash,omitempty"`
+	Status      int      `json:"status"`
+	Error       string   `json:"error,omitempty"`
+	Created     *FFTime  `json:"created"`
+}
@@ -25,25 +25,35 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 )
 
+// BatchEncoding is the content encoding applied to a batch payload when it is published to public storage
+type BatchEncoding = FFEnum
+
+var (
+	// BatchEncodingGzip is a batch payload compressed with gzip. Batches with no encoding are uncompressed JSON
+	BatchEncodingGzip BatchEncoding = ffEnum("batchencoding", "gzip")
+)
+
 type Batch struct {
 	ID        *UUID       `json:"id"`
 	Namespace string      `json:"namespace"`
-	Type      MessageType `json:"type"`
+	Type      MessageType `json:"type" ffenum:"messagetype"`
 	Node      *UUID       `json:"node,omitempty"`
 	Identity
-	Group      *Bytes32     `jdon:"group,omitempty"`
-	Hash       *Bytes32     `json:"hash"`
-	Created    *FFTime      `json:"created"`
-	Confirmed  *FFTime      `json:"confirmed"`
-	Payload    BatchPayload `json:"payload"`
-	PayloadRef string       `json:"payloadRef,omitempty"`
-	Blobs      []*Bytes32   `json:"blobs,omitempty"` // only used in-flight
+	Group      *Bytes32      `jdon:"group,omitempty"`
+	Hash       *Bytes32      `json:"hash"`
+	Created    *FFTime       `json:"created"`
+	Confirmed  *FFTime       `json:"confirmed"`
+	Payload    BatchPayload  `json:"payload"`
+	PayloadRef string        `json:"payloadRef,omitempty"`
+	Encoding   BatchEncoding `json:"encoding,omitempty" ffenum:"batchencoding"`
+	Blobs      []*Bytes32    `json:"blobs,omitempty"` // only used in-flight
 }
 
 type BatchPayload struct {
-	TX       TransactionRef `json:"tx"`
-	Messages []*Message     `json:"messages"`
-	Data     []*Data        `json:"data"`
+	TX            TransactionRef `json:"tx"`
+	Messages      []*Message     `json:"messages"`
+	Data          []*Data        `json:"data"`
+	NodeSignature string         `json:"nodeSignature,omitempty"`
 }
 
 // Value implements sql.Valuer
@@ -51,8 +61,12 @@ func (ma BatchPayload) Value() (driver.Value, error) {
 	return json.Marshal(&ma)
 }
 
+// Hash calculates the hash of the payload. The node signature is excluded, as it
+// is a signature over this hash by the node that sealed the batch.
 func (ma *BatchPayload) Hash() *Bytes32 {
-	b, _ := json.Marshal(&ma)
+	unsigned := *ma
+	unsigned.NodeSignature = ""
+	b, _ := json.Marshal(&unsigned)
 	var b32 Bytes32 = sha256.Sum256(b)
 	return &b32
 }
@@ -64,4 +64,8 @@ func TestSQLSerializedMessageArray(t *testing.T) {
 	hash := batchPayload.Hash()
 	assert.NotNil(t, hash)
 
+	batchPayload.NodeSignature = "sig1"
+	assert.Equal(t, hash, batchPayload.Hash())
+	assert.Equal(t, "sig1", batchPayload.NodeSignature)
+
 }
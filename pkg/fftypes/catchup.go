@@ -0,0 +1,58 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+type CatchupSource = FFEnum
+
+var (
+	// CatchupSourcePeer requests the history of broadcast batches from an existing member of the network
+	CatchupSourcePeer CatchupSource = ffEnum("catchupsource", "peer")
+	// CatchupSourceChain re-scans the blockchain from the genesis block, re-fetching broadcast batches from public storage
+	CatchupSourceChain CatchupSource = ffEnum("catchupsource", "chain")
+)
+
+// CatchupRequest is a request to backfill the history of broadcast batches, for a member that joined the network late
+type CatchupRequest struct {
+	Source CatchupSource `json:"source" ffenum:"catchupsource"`
+	Node   string        `json:"node,omitempty"`
+}
+
+// CatchupPeerRequest is sent over data exchange to an existing member, to request a page of broadcast batch pins
+type CatchupPeerRequest struct {
+	ID        *UUID  `json:"id"`
+	Namespace string `json:"namespace"`
+	Skip      uint64 `json:"skip"`
+	Limit     uint64 `json:"limit"`
+}
+
+// CatchupPeerResponse is returned over data exchange with a page of broadcast batch pins, in the order they were confirmed
+type CatchupPeerResponse struct {
+	ID        *UUID              `json:"id"`
+	Namespace string             `json:"namespace"`
+	Skip      uint64             `json:"skip"`
+	Total     int64              `json:"total"`
+	Pins      []*CatchupBatchPin `json:"pins"`
+}
+
+// CatchupBatchPin is the record of a confirmed broadcast batch, with the blockchain transactions that pinned it.
+// The requester looks up the batch pin on the blockchain, rather than trusting the details supplied by the peer
+type CatchupBatchPin struct {
+	Batch         *UUID          `json:"batch"`
+	Hash          *Bytes32       `json:"hash"`
+	TX            TransactionRef `json:"tx"`
+	BlockchainIDs FFStringArray  `json:"blockchainIds"`
+}
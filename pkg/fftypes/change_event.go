@@ -39,4 +39,7 @@ type ChangeEvent struct {
 	Hash *Bytes32 `json:"hash,omitempty"`
 	// Sequence is set if there is a local ordered sequence associated with the changed resource
 	Sequence *int64 `json:"sequence,omitempty"`
+	// Before and After are the full resource before and after the change, for collections that record a history of changes (currently subscriptions)
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
 }
@@ -20,3 +20,16 @@ type ConfigRecord struct {
 	Key   string   `json:"key,omitempty"`
 	Value *JSONAny `json:"value,omitempty"`
 }
+
+// LogLevels are the log level of the node, and the levels of any modules that log at a different level
+type LogLevels struct {
+	Level   string            `json:"level,omitempty"`
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+// ConfigReloadResult lists the keys that changed when the config file was reloaded, split into those that have
+// been applied to the running node, and those that only take effect when the node is restarted
+type ConfigReloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restartRequired"`
+}
@@ -0,0 +1,38 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// ConfigValidationReport is the result of validating the config of a node without starting it
+type ConfigValidationReport struct {
+	Valid       bool                `json:"valid"`
+	ConfigFile  string              `json:"configFile,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	UnknownKeys []string            `json:"unknownKeys,omitempty"`
+	Plugins     []*PluginValidation `json:"plugins,omitempty"`
+}
+
+// PluginValidation is the result of initializing a plugin from the config, and of probing the connectivity of its
+// runtime. Reachable is only set for plugins that initialized, and that can be probed without being started.
+type PluginValidation struct {
+	Type        string     `json:"type"`
+	Name        string     `json:"name"`
+	Plugin      string     `json:"plugin"`
+	Initialized bool       `json:"initialized"`
+	Reachable   *bool      `json:"reachable,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Details     JSONObject `json:"details,omitempty"`
+}
@@ -41,6 +41,9 @@ const (
 	// SystemTagDefineGroup is the topic for messages that send the definition of a group, to all parties in that group
 	SystemTagDefineGroup SystemTag = "ff_define_group"
 
+	// SystemTagChangeGroupMembers is the topic for messages that send a change to the membership of a group, to all parties in the resulting group
+	SystemTagChangeGroupMembers SystemTag = "ff_change_group_members"
+
 	// SystemTagDefinePool is the topic for messages that broadcast data definitions
 	SystemTagDefinePool SystemTag = "ff_define_pool"
 
@@ -49,4 +52,7 @@ const (
 
 	// SystemTagDefineContractAPI is the topic for messages that broadcast contract APIs
 	SystemTagDefineContractAPI SystemTag = "ff_define_contract_api"
+
+	// SystemTagRotateOrgKey is the topic for messages that broadcast a change to the signing key of an organization
+	SystemTagRotateOrgKey SystemTag = "ff_rotate_org_key"
 )
@@ -41,6 +41,13 @@ type ContractCallResponse struct {
 	ID *UUID `json:"id"`
 }
 
+// RawTransactionRequest is a transaction that is already encoded in the native format of the
+// blockchain connector, for administrative actions that are not modeled by an FFI
+type RawTransactionRequest struct {
+	Key         string     `json:"key,omitempty"`
+	Transaction JSONObject `json:"transaction"`
+}
+
 type ContractSubscribeRequest struct {
 	Interface *UUID     `json:"interface,omitempty"`
 	Location  *JSONAny  `json:"location,omitempty"`
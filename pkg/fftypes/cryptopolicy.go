@@ -0,0 +1,85 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// VerifierType is the type of signing key used by a blockchain plugin
+type VerifierType = FFEnum
+
+var (
+	// VerifierTypeEthAddress is an Ethereum (secp256k1) address string
+	VerifierTypeEthAddress VerifierType = ffEnum("verifiertype", "ethereum_address")
+	// VerifierTypeMSPIdentity is the MSP id (X.509 distinguished name) of a Fabric identity
+	VerifierTypeMSPIdentity VerifierType = ffEnum("verifiertype", "fabric_msp_id")
+)
+
+// HashAlgorithm is an algorithm used to hash messages, data and batches
+type HashAlgorithm = FFEnum
+
+var (
+	// HashAlgorithmSHA256 is the SHA-256 algorithm
+	HashAlgorithmSHA256 HashAlgorithm = ffEnum("hashalgorithm", "sha256")
+)
+
+// CryptoPolicy restricts the signing key types and hash algorithms that are accepted in a namespace.
+// An empty list places no restriction.
+type CryptoPolicy struct {
+	KeyTypes       []VerifierType  `json:"keyTypes,omitempty"`
+	HashAlgorithms []HashAlgorithm `json:"hashAlgorithms,omitempty"`
+}
+
+func enumContains(values []FFEnum, v FFEnum) bool {
+	for _, value := range values {
+		if value.Equals(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateEnumValues(ctx context.Context, enumType string, values []FFEnum, fieldName string) error {
+	for _, v := range values {
+		found := false
+		for _, known := range FFEnumValues(enumType) {
+			found = found || v.Equals(FFEnum(known.(string)))
+		}
+		if !found {
+			return i18n.NewError(ctx, i18n.MsgInvalidCryptoPolicyValue, v, fieldName)
+		}
+	}
+	return nil
+}
+
+func (cp *CryptoPolicy) Validate(ctx context.Context) error {
+	if err := validateEnumValues(ctx, "verifiertype", cp.KeyTypes, "keyTypes"); err != nil {
+		return err
+	}
+	return validateEnumValues(ctx, "hashalgorithm", cp.HashAlgorithms, "hashAlgorithms")
+}
+
+func (cp *CryptoPolicy) AllowsKeyType(keyType VerifierType) bool {
+	return len(cp.KeyTypes) == 0 || enumContains(cp.KeyTypes, keyType)
+}
+
+func (cp *CryptoPolicy) AllowsHashAlgorithm(hashAlgorithm HashAlgorithm) bool {
+	return len(cp.HashAlgorithms) == 0 || enumContains(cp.HashAlgorithms, hashAlgorithm)
+}
@@ -0,0 +1,51 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCryptoPolicyValidate(t *testing.T) {
+	cp := &CryptoPolicy{
+		KeyTypes:       []VerifierType{"Ethereum_Address", VerifierTypeMSPIdentity},
+		HashAlgorithms: []HashAlgorithm{HashAlgorithmSHA256},
+	}
+	assert.NoError(t, cp.Validate(context.Background()))
+
+	cp.KeyTypes = []VerifierType{"rsa"}
+	assert.Regexp(t, "FF10396.*rsa.*keyTypes", cp.Validate(context.Background()))
+
+	cp.KeyTypes = nil
+	cp.HashAlgorithms = []HashAlgorithm{"md5"}
+	assert.Regexp(t, "FF10396.*md5.*hashAlgorithms", cp.Validate(context.Background()))
+}
+
+func TestCryptoPolicyAllows(t *testing.T) {
+	cp := &CryptoPolicy{}
+	assert.True(t, cp.AllowsKeyType(VerifierTypeEthAddress))
+	assert.True(t, cp.AllowsHashAlgorithm(HashAlgorithmSHA256))
+
+	cp.KeyTypes = []VerifierType{VerifierTypeMSPIdentity}
+	cp.HashAlgorithms = []HashAlgorithm{"SHA256"}
+	assert.False(t, cp.AllowsKeyType(VerifierTypeEthAddress))
+	assert.True(t, cp.AllowsKeyType(VerifierTypeMSPIdentity))
+	assert.True(t, cp.AllowsHashAlgorithm(HashAlgorithmSHA256))
+}
@@ -41,13 +41,14 @@ type BlobRef struct {
 
 type Data struct {
 	ID        *UUID         `json:"id,omitempty"`
-	Validator ValidatorType `json:"validator"`
+	Validator ValidatorType `json:"validator" ffenum:"validatortype"`
 	Namespace string        `json:"namespace,omitempty"`
 	Hash      *Bytes32      `json:"hash,omitempty"`
 	Created   *FFTime       `json:"created,omitempty"`
 	Datatype  *DatatypeRef  `json:"datatype,omitempty"`
 	Value     *JSONAny      `json:"value"`
 	Blob      *BlobRef      `json:"blob,omitempty"`
+	Group     *Bytes32      `json:"group,omitempty"` // for data attached to a broadcast, restricts the value to the members of a private group
 
 	ValueSize int64 `json:"-"` // Used internally for message size calcuation, without full payload retrieval
 }
@@ -86,6 +87,12 @@ func CheckValidatorType(ctx context.Context, validator ValidatorType) error {
 	}
 }
 
+// ValueWithheld returns true for data that is restricted to a private group, where the value is not available
+// locally. Either because this node is not a member of the group, or the value has not yet been received.
+func (d *Data) ValueWithheld() bool {
+	return d.Group != nil && (d.Value == nil || d.Value.String() == NullString)
+}
+
 const dataSizeEstimateBase = int64(256)
 
 func (d *Data) EstimateSize() int64 {
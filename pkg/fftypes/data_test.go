@@ -35,6 +35,13 @@ func TestEstimateDataSize(t *testing.T) {
 	assert.Equal(t, dataSizeEstimateBase+int64(4), d.EstimateSize())
 }
 
+func TestValueWithheld(t *testing.T) {
+	assert.False(t, (&Data{}).ValueWithheld())
+	assert.True(t, (&Data{Group: NewRandB32()}).ValueWithheld())
+	assert.True(t, (&Data{Group: NewRandB32(), Value: JSONAnyPtr(NullString)}).ValueWithheld())
+	assert.False(t, (&Data{Group: NewRandB32(), Value: JSONAnyPtr(`{}`)}).ValueWithheld())
+}
+
 func TestDatatypeReference(t *testing.T) {
 
 	var dr *DatatypeRef
@@ -22,19 +22,31 @@ type EventType = FFEnum
 var (
 	// EventTypeTransactionSubmitted occurs only on the node that initiates a tranaction, when the transaction is submitted
 	EventTypeTransactionSubmitted EventType = ffEnum("eventtype", "transaction_submitted")
+	// EventTypeTransactionSubmitFailed occurs only on the node that initiates a transaction, when submission of one of its operations has failed (referring to the operation)
+	EventTypeTransactionSubmitFailed EventType = ffEnum("eventtype", "transaction_submit_failed")
+	// EventTypeTransmissionRejected occurs when a transmission received over data exchange is rejected as a replay, or as outside of the anti-replay window (referring to the batch or catch-up request/response)
+	EventTypeTransmissionRejected EventType = ffEnum("eventtype", "transmission_rejected")
 	// EventTypeMessageConfirmed is the most important event type in the system. This means a message and all of its data
 	// is available for processing by an application. Most applications only need to listen to this event type
 	EventTypeMessageConfirmed EventType = ffEnum("eventtype", "message_confirmed")
 	// EventTypeMessageRejected occurs if a message is received and confirmed from a sequencing perspective, but is rejected as invalid (mismatch to schema, or duplicate system broadcast)
 	EventTypeMessageRejected EventType = ffEnum("eventtype", "message_rejected")
+	// EventTypeMessageExpired occurs on the sending node if a message reaches its expiry time before it could be sent in a batch
+	EventTypeMessageExpired EventType = ffEnum("eventtype", "message_expired")
 	// EventTypeNamespaceConfirmed occurs when a new namespace is ready for use (on the namespace itself)
 	EventTypeNamespaceConfirmed EventType = ffEnum("eventtype", "namespace_confirmed")
+	// EventTypeNamespaceDeleted occurs when a namespace, and all the data within it, has been deleted (on the system namespace)
+	EventTypeNamespaceDeleted EventType = ffEnum("eventtype", "namespace_deleted")
+	// EventTypeNamespaceRetired occurs when a namespace is retired, and no longer accepts new definitions or subscriptions (on the system namespace)
+	EventTypeNamespaceRetired EventType = ffEnum("eventtype", "namespace_retired")
 	// EventTypeDatatypeConfirmed occurs when a new datatype is ready for use (on the namespace of the datatype)
 	EventTypeDatatypeConfirmed EventType = ffEnum("eventtype", "datatype_confirmed")
 	// EventTypeGroupConfirmed occurs when a new group is ready to use (on the namespace of the group, on all group participants)
 	EventTypeGroupConfirmed EventType = ffEnum("eventtype", "group_confirmed")
 	// EventTypePoolConfirmed occurs when a new token pool is ready for use
 	EventTypePoolConfirmed EventType = ffEnum("eventtype", "token_pool_confirmed")
+	// EventTypePoolStateChanged occurs when a token pool is paused, resumed or archived on this node
+	EventTypePoolStateChanged EventType = ffEnum("eventtype", "token_pool_state_changed")
 	// EventTypeTransferConfirmed occurs when a token transfer has been confirmed
 	EventTypeTransferConfirmed EventType = ffEnum("eventtype", "token_transfer_confirmed")
 	// EventTypeTransferOpFailed occurs when a token transfer submitted by this node has failed (based on feedback from connector)
@@ -49,6 +61,14 @@ var (
 	EventTypeContractAPIConfirmed EventType = ffEnum("eventtype", "contract_api_confirmed")
 	// EventTypeBlockchainEvent occurs when a new event has been recorded from the blockchain
 	EventTypeBlockchainEvent EventType = ffEnum("eventtype", "blockchain_event")
+	// EventTypeBlockchainInvokeOpSucceeded occurs when a contract invocation submitted by this node has been confirmed by the blockchain
+	EventTypeBlockchainInvokeOpSucceeded EventType = ffEnum("eventtype", "blockchain_invoke_op_succeeded")
+	// EventTypeBlockchainInvokeOpFailed occurs when a contract invocation submitted by this node has failed or been reverted (based on feedback from connector)
+	EventTypeBlockchainInvokeOpFailed EventType = ffEnum("eventtype", "blockchain_invoke_op_failed")
+	// EventTypeNodeCertPinMismatch occurs when a node broadcast is rejected, because the TLS certificate of its data exchange endpoint does not match the pinned fingerprint (referring to the node)
+	EventTypeNodeCertPinMismatch EventType = ffEnum("eventtype", "node_cert_pin_mismatch")
+	// EventTypePrivateDataReceived occurs when the value of data attached to a broadcast, but restricted to a private group, is received from a member of the group (referring to the data)
+	EventTypePrivateDataReceived EventType = ffEnum("eventtype", "private_data_received")
 )
 
 // Event is an activity in the system, delivered reliably to applications, that indicates something has happened in the network
@@ -68,6 +88,7 @@ type EventDelivery struct {
 	Event
 	Subscription SubscriptionRef `json:"subscription"`
 	Message      *Message        `json:"message,omitempty"`
+	Headers      JSONObject      `json:"headers,omitempty"` // custom headers of the message, propagated as transport metadata
 }
 
 // EventDeliveryResponse is the payload an application sends back, to confirm it has accepted (or rejected) the event and as such
@@ -0,0 +1,112 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// EventExportRecord is a single line of a hash-chained NDJSON event export.
+//
+// The hash of each record is the SHA256 of the hex prevHash (empty for the first record in the chain),
+// followed by the canonical JSON of the event - with object keys sorted, no insignificant whitespace,
+// and no HTML escaping. So an auditor can verify the export independently of the database, and any
+// edit, insertion or removal of a record in the file breaks the chain from that point onwards.
+type EventExportRecord struct {
+	PrevHash *Bytes32 `json:"prevHash,omitempty"`
+	Hash     *Bytes32 `json:"hash"`
+	Event    *Event   `json:"event"`
+}
+
+type eventExportRecordRaw struct {
+	PrevHash *Bytes32        `json:"prevHash,omitempty"`
+	Hash     *Bytes32        `json:"hash"`
+	Event    json.RawMessage `json:"event"`
+}
+
+// NewEventExportRecord creates a record for the event, chained to the record with the supplied hash
+func NewEventExportRecord(prevHash *Bytes32, event *Event) *EventExportRecord {
+	// An event always serializes to a valid JSON object, so hashing cannot fail
+	eventJSON, _ := json.Marshal(event)
+	hash, _ := eventExportHash(context.Background(), prevHash, eventJSON)
+	return &EventExportRecord{
+		PrevHash: prevHash,
+		Hash:     hash,
+		Event:    event,
+	}
+}
+
+// VerifyEventExport reads an NDJSON event export, checking each record chains from the previous one,
+// and that the hash of each record matches its content. The hash of the last record is returned,
+// so that consecutive exports can be verified as a single chain.
+func VerifyEventExport(ctx context.Context, r io.Reader, prevHash *Bytes32) (lastHash *Bytes32, count int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lastHash = prevHash
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		count++
+		var record eventExportRecordRaw
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, count, i18n.WrapError(ctx, err, i18n.MsgEventExportRecordInvalid, count)
+		}
+		if !record.PrevHash.Equals(lastHash) {
+			return nil, count, i18n.NewError(ctx, i18n.MsgEventExportChainBroken, count, record.PrevHash, lastHash)
+		}
+		hash, err := eventExportHash(ctx, record.PrevHash, record.Event)
+		if err != nil {
+			return nil, count, err
+		}
+		if !hash.Equals(record.Hash) {
+			return nil, count, i18n.NewError(ctx, i18n.MsgEventExportHashMismatch, count, record.Hash, hash)
+		}
+		lastHash = record.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, count, i18n.WrapError(ctx, err, i18n.MsgEventExportRecordInvalid, count+1)
+	}
+	return lastHash, count, nil
+}
+
+func eventExportHash(ctx context.Context, prevHash *Bytes32, eventJSON []byte) (*Bytes32, error) {
+	var event interface{}
+	decoder := json.NewDecoder(bytes.NewReader(eventJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&event); err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgJSONDecodeFailed)
+	}
+	// Re-encoding the generic form sorts the keys of every object, and preserves numbers exactly
+	var canonical bytes.Buffer
+	encoder := json.NewEncoder(&canonical)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(event)
+
+	hash := sha256.New()
+	hash.Write([]byte(prevHash.String()))
+	hash.Write(bytes.TrimSuffix(canonical.Bytes(), []byte("\n")))
+	return HashResult(hash), nil
+}
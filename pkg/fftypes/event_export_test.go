@@ -0,0 +1,133 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestEventExport(t *testing.T, prevHash *Bytes32, events ...*Event) ([]*EventExportRecord, string) {
+	var buf bytes.Buffer
+	records := make([]*EventExportRecord, len(events))
+	for i, event := range events {
+		record := NewEventExportRecord(prevHash, event)
+		b, _ := json.Marshal(record)
+		buf.Write(b)
+		buf.WriteByte('\n')
+		records[i] = record
+		prevHash = record.Hash
+	}
+	return records, buf.String()
+}
+
+func TestEventExportRoundTrip(t *testing.T) {
+	e1 := NewEvent(EventTypeMessageConfirmed, "ns1", NewUUID(), nil)
+	e1.Sequence = 1
+	e2 := NewEvent(EventTypeMessageConfirmed, "ns1", NewUUID(), NewUUID())
+	e2.Sequence = 9007199254740993
+
+	records, export := writeTestEventExport(t, nil, e1, e2)
+	assert.Nil(t, records[0].PrevHash)
+	assert.Equal(t, records[0].Hash, records[1].PrevHash)
+
+	lastHash, count, err := VerifyEventExport(context.Background(), strings.NewReader(export+"\n"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, records[1].Hash, lastHash)
+
+	// A second export continues the chain from the first
+	e3 := NewEvent(EventTypeMessageConfirmed, "ns1", NewUUID(), nil)
+	_, export2 := writeTestEventExport(t, lastHash, e3)
+	_, count, err = VerifyEventExport(context.Background(), strings.NewReader(export2), lastHash)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestEventExportHashIsCanonical(t *testing.T) {
+	prevHash := NewRandB32()
+	record := NewEventExportRecord(prevHash, &Event{
+		ID:        MustParseUUID("7e3bd6de-9bb4-4cb4-8d5a-53e5fca2b3c1"),
+		Type:      EventTypeMessageConfirmed,
+		Namespace: "<ns1>",
+		Sequence:  12345,
+	})
+
+	canonical := `{"created":null,"id":"7e3bd6de-9bb4-4cb4-8d5a-53e5fca2b3c1","namespace":"<ns1>","reference":null,"sequence":12345,"type":"message_confirmed"}`
+	hash := sha256.New()
+	hash.Write([]byte(prevHash.String()))
+	hash.Write([]byte(canonical))
+	assert.Equal(t, HashResult(hash), record.Hash)
+}
+
+func TestVerifyEventExportModified(t *testing.T) {
+	e1 := NewEvent(EventTypeMessageConfirmed, "ns1", NewUUID(), nil)
+	e2 := NewEvent(EventTypeMessageConfirmed, "ns1", NewUUID(), nil)
+	_, export := writeTestEventExport(t, nil, e1, e2)
+
+	tampered := strings.Replace(export, e2.Reference.String(), NewUUID().String(), 1)
+	_, count, err := VerifyEventExport(context.Background(), strings.NewReader(tampered), nil)
+	assert.Regexp(t, "FF10420", err)
+	assert.Equal(t, 2, count)
+}
+
+func TestVerifyEventExportRemoved(t *testing.T) {
+	e1 := NewEvent(EventTypeMessageConfirmed, "ns1", NewUUID(), nil)
+	e2 := NewEvent(EventTypeMessageConfirmed, "ns1", NewUUID(), nil)
+	e3 := NewEvent(EventTypeMessageConfirmed, "ns1", NewUUID(), nil)
+	_, export := writeTestEventExport(t, nil, e1, e2, e3)
+
+	lines := strings.Split(export, "\n")
+	_, count, err := VerifyEventExport(context.Background(), strings.NewReader(lines[0]+"\n"+lines[2]), nil)
+	assert.Regexp(t, "FF10419", err)
+	assert.Equal(t, 2, count)
+}
+
+func TestVerifyEventExportBadRecord(t *testing.T) {
+	_, _, err := VerifyEventExport(context.Background(), strings.NewReader("!json"), nil)
+	assert.Regexp(t, "FF10418", err)
+}
+
+func TestVerifyEventExportBadEvent(t *testing.T) {
+	_, _, err := VerifyEventExport(context.Background(), strings.NewReader(`{"hash":"`+NewRandB32().String()+`"}`), nil)
+	assert.Regexp(t, "FF10103", err)
+}
+
+func TestVerifyEventExportLineTooLong(t *testing.T) {
+	_, _, err := VerifyEventExport(context.Background(), strings.NewReader(strings.Repeat("a", 17*1024*1024)), nil)
+	assert.Regexp(t, "FF10418", err)
+}
+
+func TestVerifyEventExportReadFail(t *testing.T) {
+	_, _, err := VerifyEventExport(context.Background(), &errReader{err: fmt.Errorf("pop")}, nil)
+	assert.Regexp(t, "FF10418.*pop", err)
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -86,6 +86,7 @@ type FFIGenerationRequest struct {
 	Description string   `json:"description"`
 	Version     string   `json:"version"`
 	Input       *JSONAny `json:"input"`
+	DevDocs     *JSONAny `json:"devdocs,omitempty"`
 }
 
 func (f *FFI) Validate(ctx context.Context, existing bool) (err error) {
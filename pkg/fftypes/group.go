@@ -19,6 +19,7 @@ package fftypes
 import (
 	"context"
 	"crypto/sha256"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -37,6 +38,7 @@ type Group struct {
 	GroupIdentity
 	Message *UUID    `json:"message,omitempty"`
 	Hash    *Bytes32 `json:"hash,omitempty"`
+	Version int64    `json:"version,omitempty"`
 	Created *FFTime  `json:"created,omitempty"`
 }
 
@@ -46,6 +48,49 @@ func (m Members) Len() int           { return len(m) }
 func (m Members) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
 func (m Members) Less(i, j int) bool { return m[i].Identity < m[j].Identity } // Note there's a dupcheck in validate
 
+// IsMember checks if the supplied identity is one of the members
+func (m Members) IsMember(identity string) bool {
+	for _, member := range m {
+		if member.Identity == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// HasNode checks if any of the members are hosted on the supplied node
+func (m Members) HasNode(node *UUID) bool {
+	for _, member := range m {
+		if member.Node.Equals(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan implements sql.Scanner
+func (m *Members) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(src), &m)
+	case []byte:
+		return json.Unmarshal(src, &m)
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, m)
+	}
+}
+
+// Value implements sql.Valuer
+func (m Members) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, _ := json.Marshal(m)
+	return string(b), nil
+}
+
 type Member struct {
 	Identity string `json:"identity,omitempty"`
 	Node     *UUID  `json:"node,omitempty"`
@@ -92,7 +137,8 @@ func (group *Group) Validate(ctx context.Context, existing bool) (err error) {
 		}
 		dupCheck[key] = true
 	}
-	if existing {
+	// The hash is of the founding member list, so can only be verified before any membership changes
+	if existing && group.Version == 0 {
 		hash := group.GroupIdentity.Hash()
 		if !group.Hash.Equals(hash) {
 			return i18n.NewError(ctx, i18n.MsgGroupInvalidHash, group.Hash, hash)
@@ -154,3 +154,67 @@ func TestGroupSealSorting(t *testing.T) {
 	assert.Equal(t, *group1.Hash, *group2.Hash)
 
 }
+
+func TestGroupValidateChangedVersion(t *testing.T) {
+
+	group := &Group{
+		GroupIdentity: GroupIdentity{
+			Name:      "name1",
+			Namespace: "ns1",
+			Members:   Members{{Node: NewUUID(), Identity: "0x11111"}},
+		},
+	}
+	group.Seal()
+	assert.NoError(t, group.Validate(context.Background(), true))
+
+	group.Members = append(group.Members, &Member{Node: NewUUID(), Identity: "0x22222"})
+	assert.Regexp(t, "FF10230", group.Validate(context.Background(), true))
+
+	group.Version = 1
+	assert.NoError(t, group.Validate(context.Background(), true))
+
+}
+
+func TestMembersIsMember(t *testing.T) {
+	members := Members{{Node: NewUUID(), Identity: "0x11111"}}
+	assert.True(t, members.IsMember("0x11111"))
+	assert.False(t, members.IsMember("0x22222"))
+}
+
+func TestMembersHasNode(t *testing.T) {
+	members := Members{{Node: NewUUID(), Identity: "0x11111"}}
+	assert.True(t, members.HasNode(members[0].Node))
+	assert.False(t, members.HasNode(NewUUID()))
+}
+
+func TestMembersScanValue(t *testing.T) {
+
+	nodeID := MustParseUUID("8b5c0d39-925f-4579-9c60-54f3e846ab99")
+	members := Members{{Node: nodeID, Identity: "0x12345"}}
+	v, err := members.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"identity":"0x12345","node":"8b5c0d39-925f-4579-9c60-54f3e846ab99"}]`, v)
+
+	var m2 Members
+	err = m2.Scan(v)
+	assert.NoError(t, err)
+	assert.Equal(t, members, m2)
+
+	var m3 Members
+	err = m3.Scan([]byte(v.(string)))
+	assert.NoError(t, err)
+	assert.Equal(t, members, m3)
+
+	var m4 Members
+	err = m4.Scan(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, m4)
+
+	v, err = m4.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	err = m4.Scan(12345)
+	assert.Regexp(t, "FF10125", err)
+
+}
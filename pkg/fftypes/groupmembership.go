@@ -0,0 +1,168 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// GroupMembershipChangeInput is the request to add and/or remove members from an existing group
+type GroupMembershipChangeInput struct {
+	Add    []MemberInput `json:"add,omitempty"`
+	Remove []MemberInput `json:"remove,omitempty"`
+}
+
+// GroupMembershipChange is a change to the member list of an existing group, sent privately to all
+// members of the resulting group, and signed by an existing member.
+//
+// Each change moves the group to a new version - the EffectiveFrom version. Private messages are pinned
+// against the version of the group they were sent to, so the ordering of messages either side of a change
+// remains verifiable by all members without needing to recreate the group.
+//
+// The Salt is a random secret generated for each change, and mixed into the pins of every message sent
+// to the new version of the group. It is only sent to the members of the resulting group, so members
+// that have been removed cannot calculate (and hence correlate) the pins of future messages. It is never
+// returned on the API, and is only serialized as part of a GroupMembershipChangeDefinition.
+type GroupMembershipChange struct {
+	ID            *UUID    `json:"id,omitempty"`
+	Namespace     string   `json:"namespace,omitempty"`
+	Group         *Bytes32 `json:"group,omitempty"`
+	EffectiveFrom int64    `json:"effectiveFrom"`
+	Author        string   `json:"author,omitempty"`
+	Add           Members  `json:"add,omitempty"`
+	Remove        Members  `json:"remove,omitempty"`
+	Members       Members  `json:"members"`
+	Salt          *Bytes32 `json:"-"`
+	Message       *UUID    `json:"message,omitempty"`
+	Created       *FFTime  `json:"created,omitempty"`
+}
+
+// GroupMembershipChangeDefinition is the form of a GroupMembershipChange that is sent privately to the
+// members of the resulting group, including the Salt
+type GroupMembershipChangeDefinition struct {
+	*GroupMembershipChange
+	Salt *Bytes32 `json:"salt,omitempty"`
+}
+
+// Definition returns the form of the change that is sent to the members of the resulting group
+func (change *GroupMembershipChange) Definition() *GroupMembershipChangeDefinition {
+	return &GroupMembershipChangeDefinition{
+		GroupMembershipChange: change,
+		Salt:                  change.Salt,
+	}
+}
+
+// Change returns the change from a received definition, with the Salt set
+func (def *GroupMembershipChangeDefinition) Change() *GroupMembershipChange {
+	change := &GroupMembershipChange{}
+	if def.GroupMembershipChange != nil {
+		*change = *def.GroupMembershipChange
+	}
+	change.Salt = def.Salt
+	return change
+}
+
+func memberKey(m *Member) string {
+	return fmt.Sprintf("%s:%s", m.Node, m.Identity)
+}
+
+// Apply calculates the member list that results from applying this change to the supplied member list
+func (change *GroupMembershipChange) Apply(ctx context.Context, current Members) (Members, error) {
+	existing := make(map[string]bool, len(current))
+	for _, m := range current {
+		existing[memberKey(m)] = true
+	}
+	removed := make(map[string]bool, len(change.Remove))
+	for _, m := range change.Remove {
+		key := memberKey(m)
+		if !existing[key] || removed[key] {
+			return nil, i18n.NewError(ctx, i18n.MsgGroupMemberNotFound, m.Identity, m.Node)
+		}
+		removed[key] = true
+	}
+
+	result := make(Members, 0, len(current)+len(change.Add))
+	for _, m := range current {
+		if !removed[memberKey(m)] {
+			result = append(result, m)
+		}
+	}
+	for i, m := range change.Add {
+		if m.Identity == "" {
+			return nil, i18n.NewError(ctx, i18n.MsgEmptyMemberIdentity, i)
+		}
+		if m.Node == nil {
+			return nil, i18n.NewError(ctx, i18n.MsgEmptyMemberNode, i)
+		}
+		key := memberKey(m)
+		if existing[key] && !removed[key] {
+			return nil, i18n.NewError(ctx, i18n.MsgGroupMemberExists, m.Identity, m.Node)
+		}
+		existing[key] = true
+		result = append(result, m)
+	}
+	sort.Stable(result)
+	return result, nil
+}
+
+// Previous calculates the member list of the group before this change was applied
+func (change *GroupMembershipChange) Previous() Members {
+	added := make(map[string]bool, len(change.Add))
+	for _, m := range change.Add {
+		added[memberKey(m)] = true
+	}
+	result := make(Members, 0, len(change.Members)+len(change.Remove))
+	for _, m := range change.Members {
+		if !added[memberKey(m)] {
+			result = append(result, m)
+		}
+	}
+	result = append(result, change.Remove...)
+	sort.Stable(result)
+	return result
+}
+
+// Validate checks the change can be applied as the next version of the supplied group,
+// and that the member list in the change is the result of applying it
+func (change *GroupMembershipChange) Validate(ctx context.Context, group *Group) error {
+	if len(change.Add) == 0 && len(change.Remove) == 0 {
+		return i18n.NewError(ctx, i18n.MsgGroupMembershipChangeEmpty)
+	}
+	if change.EffectiveFrom != group.Version+1 {
+		return i18n.NewError(ctx, i18n.MsgGroupVersionMismatch, change.EffectiveFrom, group.Version)
+	}
+	members, err := change.Apply(ctx, group.Members)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return i18n.NewError(ctx, i18n.MsgGroupMustHaveMembers)
+	}
+	if len(members) != len(change.Members) {
+		return i18n.NewError(ctx, i18n.MsgGroupMembersMismatch)
+	}
+	for i, m := range members {
+		if change.Members[i] == nil || memberKey(m) != memberKey(change.Members[i]) {
+			return i18n.NewError(ctx, i18n.MsgGroupMembersMismatch)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testMembershipGroup() (*Group, *Member, *Member) {
+	m1 := &Member{Node: NewUUID(), Identity: "0x11111"}
+	m2 := &Member{Node: NewUUID(), Identity: "0x22222"}
+	group := &Group{
+		GroupIdentity: GroupIdentity{
+			Name:      "name1",
+			Namespace: "ns1",
+			Members:   Members{m1, m2},
+		},
+	}
+	group.Seal()
+	return group, m1, m2
+}
+
+func TestGroupMembershipChangeApplyAddRemove(t *testing.T) {
+
+	group, m1, m2 := testMembershipGroup()
+	m3 := &Member{Node: NewUUID(), Identity: "0x00000"}
+	change := &GroupMembershipChange{
+		EffectiveFrom: 1,
+		Add:           Members{m3},
+		Remove:        Members{m2},
+	}
+	members, err := change.Apply(context.Background(), group.Members)
+	assert.NoError(t, err)
+	assert.Equal(t, Members{m3, m1}, members)
+
+	change.Members = members
+	assert.NoError(t, change.Validate(context.Background(), group))
+	assert.Equal(t, Members{m1, m2}, change.Previous())
+
+}
+
+func TestGroupMembershipChangeApplyErrors(t *testing.T) {
+
+	group, m1, m2 := testMembershipGroup()
+	ctx := context.Background()
+
+	_, err := (&GroupMembershipChange{Remove: Members{{Node: NewUUID(), Identity: "0x11111"}}}).Apply(ctx, group.Members)
+	assert.Regexp(t, "FF10353", err)
+
+	_, err = (&GroupMembershipChange{Remove: Members{m1, m1}}).Apply(ctx, group.Members)
+	assert.Regexp(t, "FF10353", err)
+
+	_, err = (&GroupMembershipChange{Add: Members{{Node: NewUUID()}}}).Apply(ctx, group.Members)
+	assert.Regexp(t, "FF10220", err)
+
+	_, err = (&GroupMembershipChange{Add: Members{{Identity: "0x33333"}}}).Apply(ctx, group.Members)
+	assert.Regexp(t, "FF10221", err)
+
+	_, err = (&GroupMembershipChange{Add: Members{m2}}).Apply(ctx, group.Members)
+	assert.Regexp(t, "FF10352", err)
+
+	// Removing and re-adding in the same change is allowed
+	members, err := (&GroupMembershipChange{Add: Members{m2}, Remove: Members{m2}}).Apply(ctx, group.Members)
+	assert.NoError(t, err)
+	assert.Equal(t, Members{m1, m2}, members)
+
+}
+
+func TestGroupMembershipChangeValidate(t *testing.T) {
+
+	group, m1, m2 := testMembershipGroup()
+	ctx := context.Background()
+	m3 := &Member{Node: NewUUID(), Identity: "0x33333"}
+
+	change := &GroupMembershipChange{EffectiveFrom: 1}
+	assert.Regexp(t, "FF10351", change.Validate(ctx, group))
+
+	change = &GroupMembershipChange{EffectiveFrom: 2, Add: Members{m3}}
+	assert.Regexp(t, "FF10354", change.Validate(ctx, group))
+
+	change = &GroupMembershipChange{EffectiveFrom: 1, Add: Members{m2}}
+	assert.Regexp(t, "FF10352", change.Validate(ctx, group))
+
+	change = &GroupMembershipChange{EffectiveFrom: 1, Remove: Members{m1, m2}}
+	assert.Regexp(t, "FF10219", change.Validate(ctx, group))
+
+	change = &GroupMembershipChange{EffectiveFrom: 1, Add: Members{m3}, Members: Members{m1, m2}}
+	assert.Regexp(t, "FF10355", change.Validate(ctx, group))
+
+	change = &GroupMembershipChange{EffectiveFrom: 1, Add: Members{m3}, Members: Members{m1, m2, nil}}
+	assert.Regexp(t, "FF10355", change.Validate(ctx, group))
+
+	change = &GroupMembershipChange{EffectiveFrom: 1, Add: Members{m3}, Members: Members{m1, m2, m3}}
+	assert.NoError(t, change.Validate(ctx, group))
+
+}
+
+func TestGroupMembershipChangeDefinitionSalt(t *testing.T) {
+	change := &GroupMembershipChange{
+		ID:            NewUUID(),
+		EffectiveFrom: 2,
+		Salt:          NewRandB32(),
+	}
+
+	b, err := json.Marshal(change)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "salt")
+
+	b, err = json.Marshal(change.Definition())
+	assert.NoError(t, err)
+	var def GroupMembershipChangeDefinition
+	err = json.Unmarshal(b, &def)
+	assert.NoError(t, err)
+	received := def.Change()
+	assert.Equal(t, change.ID, received.ID)
+	assert.Equal(t, int64(2), received.EffectiveFrom)
+	assert.Equal(t, change.Salt, received.Salt)
+
+	def = GroupMembershipChangeDefinition{}
+	assert.Nil(t, def.Change().Salt)
+}
@@ -19,4 +19,6 @@ package fftypes
 const (
 	HTTPHeadersBlobHashSHA256 = "x-ff-blob-hash-sha256"
 	HTTPHeadersBlobSize       = "x-ff-blob-size"
+	HTTPHeadersWarning        = "x-ff-warning"
+	HTTPHeadersRequestID      = "X-Request-ID"
 )
@@ -0,0 +1,53 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// IdempotencyKey is an optional key supplied by the submitter of a message or transaction, which must be
+// unique within the namespace. This allows a client to safely retry a submission after a network error,
+// without the risk of it being processed twice.
+type IdempotencyKey string
+
+// Value stores an empty key as NULL, so it does not conflict with other submissions that have no key
+func (ik IdempotencyKey) Value() (driver.Value, error) {
+	if ik == "" {
+		return nil, nil
+	}
+	return string(ik), nil
+}
+
+func (ik *IdempotencyKey) Scan(src interface{}) error {
+	switch st := src.(type) {
+	case string:
+		*ik = IdempotencyKey(st)
+		return nil
+	case []byte:
+		*ik = IdempotencyKey(st)
+		return nil
+	case nil:
+		*ik = ""
+		return nil
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, ik)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKeyValue(t *testing.T) {
+
+	v, err := IdempotencyKey("").Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	v, err = IdempotencyKey("retry1").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "retry1", v)
+
+}
+
+func TestIdempotencyKeyScan(t *testing.T) {
+
+	var ik IdempotencyKey
+	assert.NoError(t, ik.Scan("retry1"))
+	assert.Equal(t, IdempotencyKey("retry1"), ik)
+
+	assert.NoError(t, ik.Scan([]byte("retry2")))
+	assert.Equal(t, IdempotencyKey("retry2"), ik)
+
+	assert.NoError(t, ik.Scan(nil))
+	assert.Equal(t, IdempotencyKey(""), ik)
+
+	assert.Regexp(t, "FF10125", ik.Scan(12345))
+
+}
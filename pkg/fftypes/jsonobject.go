@@ -183,6 +183,39 @@ func (jd JSONObject) GetStringArrayOk(key string) ([]string, bool) {
 	return []string{}, false // Ensures a non-nil return
 }
 
+// MergePatch returns a new object, with the supplied JSON merge patch (RFC 7396) applied.
+// A null in the patch removes the key, objects are merged recursively, and any other value replaces the existing one.
+func (jd JSONObject) MergePatch(patch JSONObject) JSONObject {
+	merged := make(JSONObject, len(jd))
+	for k, v := range jd {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		patchObj, isObj := toJSONObject(v)
+		switch {
+		case v == nil:
+			delete(merged, k)
+		case isObj:
+			existing, _ := toJSONObject(merged[k])
+			merged[k] = existing.MergePatch(patchObj)
+		default:
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func toJSONObject(v interface{}) (JSONObject, bool) {
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		return JSONObject(vt), true
+	case JSONObject:
+		return vt, true
+	default:
+		return nil, false
+	}
+}
+
 // Value implements sql.Valuer
 func (jd JSONObject) Value() (driver.Value, error) {
 	b, err := json.Marshal(&jd)
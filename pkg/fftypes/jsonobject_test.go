@@ -217,3 +217,41 @@ func TestJSONNestedSafeGet(t *testing.T) {
 	)
 
 }
+
+func TestJSONObjectMergePatch(t *testing.T) {
+	original := JSONObject{
+		"keep":    "a",
+		"replace": "b",
+		"remove":  "c",
+		"nested": map[string]interface{}{
+			"keep":    float64(1),
+			"replace": float64(2),
+		},
+		"overwrite": "not an object",
+	}
+	patched := original.MergePatch(JSONObject{
+		"replace": "B",
+		"remove":  nil,
+		"nested": JSONObject{
+			"replace": float64(20),
+			"add":     float64(3),
+		},
+		"overwrite": map[string]interface{}{"now": "object"},
+		"array":     []interface{}{"x"},
+	})
+	assert.Equal(t, JSONObject{
+		"keep":    "a",
+		"replace": "B",
+		"nested": JSONObject{
+			"keep":    float64(1),
+			"replace": float64(20),
+			"add":     float64(3),
+		},
+		"overwrite": JSONObject{"now": "object"},
+		"array":     []interface{}{"x"},
+	}, patched)
+
+	// The original is unchanged
+	assert.Equal(t, "c", original.GetString("remove"))
+	assert.Equal(t, float64(2), original.GetObject("nested")["replace"])
+}
@@ -0,0 +1,60 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// KeyRotation is a definition broadcast by an organization to move to a new blockchain signing key.
+// It is signed with the current key, and nodes continue to accept the previous key for a grace period.
+type KeyRotation struct {
+	ID           *UUID   `json:"id"`
+	Message      *UUID   `json:"message,omitempty"`
+	Organization *UUID   `json:"organization,omitempty"`
+	PreviousKey  string  `json:"previousKey,omitempty"`
+	NewKey       string  `json:"newKey,omitempty"`
+	Created      *FFTime `json:"created,omitempty"`
+}
+
+func (kr *KeyRotation) Validate(ctx context.Context, existing bool) (err error) {
+	if kr.NewKey == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "newKey")
+	}
+	if existing {
+		if kr.ID == nil || kr.Organization == nil {
+			return i18n.NewError(ctx, i18n.MsgNilID)
+		}
+		if kr.PreviousKey == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "previousKey")
+		}
+	}
+	if kr.NewKey == kr.PreviousKey {
+		return i18n.NewError(ctx, i18n.MsgKeyRotationUnchanged, kr.NewKey)
+	}
+	return nil
+}
+
+func (kr *KeyRotation) Topic() string {
+	return OrgTopic
+}
+
+func (kr *KeyRotation) SetBroadcastMessage(msgID *UUID) {
+	kr.Message = msgID
+}
@@ -0,0 +1,51 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotationValidation(t *testing.T) {
+
+	kr := &KeyRotation{}
+	assert.Regexp(t, "FF10140.*newKey", kr.Validate(context.Background(), false))
+
+	kr = &KeyRotation{
+		NewKey: "0x2222",
+	}
+	assert.NoError(t, kr.Validate(context.Background(), false))
+	assert.Regexp(t, "FF10203", kr.Validate(context.Background(), true))
+
+	kr.ID = NewUUID()
+	kr.Organization = NewUUID()
+	assert.Regexp(t, "FF10140.*previousKey", kr.Validate(context.Background(), true))
+
+	kr.PreviousKey = "0x2222"
+	assert.Regexp(t, "FF10455", kr.Validate(context.Background(), true))
+
+	kr.PreviousKey = "0x1111"
+	assert.NoError(t, kr.Validate(context.Background(), true))
+
+	var def Definition = kr
+	assert.Equal(t, "ff_organizations", def.Topic())
+	def.SetBroadcastMessage(NewUUID())
+	assert.NotNil(t, kr.Message)
+}
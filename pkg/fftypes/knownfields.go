@@ -0,0 +1,158 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// CheckKnownFields checks that every field in a JSON document maps to a field of the target type,
+// returning an error naming the path of the first unknown field. It is used for strict decoding,
+// where fields that would otherwise be silently dropped indicate a mismatch in schema between members.
+// Types with custom unmarshaling are not inspected. The path is prefixed with basePath if not empty.
+func CheckKnownFields(ctx context.Context, basePath string, b []byte, target interface{}) error {
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	if path := unknownFieldPath(basePath, doc, reflect.TypeOf(target)); path != "" {
+		return i18n.NewError(ctx, i18n.MsgUnknownField, path)
+	}
+	return nil
+}
+
+func unknownFieldPath(path string, doc interface{}, t reflect.Type) string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || reflect.PtrTo(t).Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if obj, ok := doc.(map[string]interface{}); ok {
+			fields := jsonFields(t)
+			for _, key := range sortedKeys(obj) {
+				ft, ok := lookupJSONField(fields, key)
+				if !ok {
+					return joinFieldPath(path, key)
+				}
+				if unknown := unknownFieldPath(joinFieldPath(path, key), obj[key], ft); unknown != "" {
+					return unknown
+				}
+			}
+		}
+	case reflect.Map:
+		if obj, ok := doc.(map[string]interface{}); ok {
+			for _, key := range sortedKeys(obj) {
+				if unknown := unknownFieldPath(joinFieldPath(path, key), obj[key], t.Elem()); unknown != "" {
+					return unknown
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if arr, ok := doc.([]interface{}); ok {
+			for i, entry := range arr {
+				if unknown := unknownFieldPath(fmt.Sprintf("%s[%d]", path, i), entry, t.Elem()); unknown != "" {
+					return unknown
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// jsonFields returns the types of the fields of a struct by JSON name, including those promoted from
+// embedded structs. Fields declared directly on the struct take precedence over promoted fields.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct {
+			if name == "" {
+				embedded = append(embedded, ft)
+				continue
+			}
+		} else if f.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	for _, et := range embedded {
+		for name, ft := range jsonFields(et) {
+			if _, exists := fields[name]; !exists {
+				fields[name] = ft
+			}
+		}
+	}
+	return fields
+}
+
+// lookupJSONField finds a field by name, matching case-insensitively if there is no exact match (as encoding/json does)
+func lookupJSONField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if ft, ok := fields[key]; ok {
+		return ft, true
+	}
+	for name, ft := range fields {
+		if strings.EqualFold(name, key) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,112 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testKnownFieldsInner struct {
+	Value string `json:"value"`
+}
+
+type testKnownFieldsEmbedded struct {
+	Promoted string `json:"promoted"`
+	Shadowed int    `json:"shadowed"`
+}
+
+type testKnownFields struct {
+	testKnownFieldsEmbedded
+	*testKnownFieldsInner `json:"inner"`
+	Shadowed              string                          `json:"shadowed"`
+	Untagged              string                          // matched by field name
+	Ignored               string                          `json:"-"`
+	Array                 []*testKnownFieldsInner         `json:"array"`
+	Map                   map[string]testKnownFieldsInner `json:"map"`
+	Fixed                 [1]testKnownFieldsInner         `json:"fixed"`
+	Custom                *JSONAny                        `json:"custom"`
+	ID                    *UUID                           `json:"id"`
+	Any                   interface{}                     `json:"any"`
+	Object                JSONObject                      `json:"object"`
+	Nested                *testKnownFieldsInnerWithEmbed  `json:"nested,omitempty"`
+	unexported            string
+}
+
+type testKnownFieldsInnerWithEmbed struct {
+	testKnownFieldsInner
+}
+
+func TestCheckKnownFieldsOk(t *testing.T) {
+	err := CheckKnownFields(context.Background(), "", []byte(`{
+		"promoted": "a",
+		"shadowed": "b",
+		"inner": {"value": "c"},
+		"untagged": "d",
+		"array": [{"value": "e"}, null],
+		"map": {"key1": {"value": "f"}},
+		"fixed": [{"value": "g"}],
+		"custom": {"anything": "goes"},
+		"id": "2b0c8b6c-6a4f-4b4f-9a8c-3c9c0c7e4a5e",
+		"any": {"anything": "goes"},
+		"object": {"anything": {"goes": true}},
+		"nested": {"value": "h"},
+		"Array": "mismatched types are left to the decoder"
+	}`), &testKnownFields{})
+	assert.NoError(t, err)
+}
+
+func TestCheckKnownFieldsUnknown(t *testing.T) {
+	for _, tc := range []struct {
+		doc  string
+		path string
+	}{
+		{doc: `{"unknown": true}`, path: "base.unknown"},
+		{doc: `{"-": "ignored"}`, path: "base.-"},
+		{doc: `{"ignored": "ignored"}`, path: "base.ignored"},
+		{doc: `{"unexported": "a"}`, path: "base.unexported"},
+		{doc: `{"inner": {"value": "a", "other": "b"}}`, path: "base.inner.other"},
+		{doc: `{"array": [{"value": "a"}, {"other": "b"}]}`, path: "base.array[1].other"},
+		{doc: `{"map": {"key1": {"other": "b"}}}`, path: "base.map.key1.other"},
+		{doc: `{"fixed": [{"other": "b"}]}`, path: "base.fixed[0].other"},
+		{doc: `{"nested": {"value": "a", "other": "b"}}`, path: "base.nested.other"},
+	} {
+		err := CheckKnownFields(context.Background(), "base", []byte(tc.doc), &testKnownFields{})
+		assert.Regexp(t, "FF10429", err, tc.doc)
+		assert.Contains(t, err.Error(), "'"+tc.path+"'", tc.doc)
+	}
+}
+
+func TestCheckKnownFieldsNoBasePath(t *testing.T) {
+	err := CheckKnownFields(context.Background(), "", []byte(`{"header":{"unknown":true}}`), &Message{})
+	assert.Regexp(t, "FF10429.*'header.unknown'", err)
+
+	err = CheckKnownFields(context.Background(), "", []byte(`{"header":{"author":"did:firefly:org/org1","key":"0x12345"}}`), &Message{})
+	assert.NoError(t, err)
+}
+
+func TestCheckKnownFieldsBadJSON(t *testing.T) {
+	err := CheckKnownFields(context.Background(), "", []byte(`!json`), &testKnownFields{})
+	assert.Regexp(t, "invalid character", err)
+}
+
+func TestCheckKnownFieldsNilTarget(t *testing.T) {
+	err := CheckKnownFields(context.Background(), "", []byte(`{"any":"thing"}`), nil)
+	assert.NoError(t, err)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"regexp"
 
 	"github.com/hyperledger/firefly/internal/i18n"
 )
@@ -27,8 +28,14 @@ import (
 const (
 	// DefaultTopic will be set as the topic of any messages set without a topic
 	DefaultTopic = "default"
+	// MessageHeadersMax is the maximum number of custom headers on a message
+	MessageHeadersMax = 16
+	// MessageHeaderValueMaxLen is the maximum length of the value of a custom header on a message
+	MessageHeaderValueMaxLen = 256
 )
 
+var messageHeaderNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,63}$`)
+
 // MessageType is the fundamental type of a message
 type MessageType = FFEnum
 
@@ -63,6 +70,32 @@ var (
 	MessageStateConfirmed MessageState = ffEnum("messagestate", "confirmed")
 	// MessageStateRejected is a message that has completed confirmation, but has been rejected by FireFly
 	MessageStateRejected MessageState = ffEnum("messagestate", "rejected")
+	// MessageStateExpired is a message created locally that reached its expiry time before it was sent in a batch, so will never be sent
+	MessageStateExpired MessageState = ffEnum("messagestate", "expired")
+)
+
+// MessagePriority controls how urgently a message is batched and delivered, relative to other messages
+type MessagePriority = FFEnum
+
+var (
+	// MessagePriorityLow is for bulk data, which is dispatched after other messages that are ready at the same time
+	MessagePriorityLow MessagePriority = ffEnum("messagepriority", "low")
+	// MessagePriorityNormal is the default priority, if none is set on the message
+	MessagePriorityNormal MessagePriority = ffEnum("messagepriority", "normal")
+	// MessagePriorityHigh is for operational control messages, which are dispatched ahead of other messages and flush batches immediately
+	MessagePriorityHigh MessagePriority = ffEnum("messagepriority", "high")
+)
+
+// BulkMessageStatus is the outcome of each message in a bulk submission
+type BulkMessageStatus = FFEnum
+
+var (
+	// BulkMessageStatusAccepted is a message that was validated and stored, ready to be sent
+	BulkMessageStatusAccepted BulkMessageStatus = ffEnum("bulkmessagestatus", "accepted")
+	// BulkMessageStatusRejected is a message that failed validation, which means none of the messages in the submission were stored
+	BulkMessageStatusRejected BulkMessageStatus = ffEnum("bulkmessagestatus", "rejected")
+	// BulkMessageStatusAborted is a valid message that was not stored, because another message in the submission was rejected
+	BulkMessageStatusAborted BulkMessageStatus = ffEnum("bulkmessagestatus", "aborted")
 )
 
 // MessageHeader contains all fields that contribute to the hash
@@ -71,28 +104,33 @@ type MessageHeader struct {
 	ID     *UUID           `json:"id,omitempty"`
 	CID    *UUID           `json:"cid,omitempty"`
 	Type   MessageType     `json:"type" ffenum:"messagetype"`
-	TxType TransactionType `json:"txtype,omitempty"`
+	TxType TransactionType `json:"txtype,omitempty" ffenum:"txtype"`
 	Identity
-	Created   *FFTime       `json:"created,omitempty"`
-	Namespace string        `json:"namespace,omitempty"`
-	Group     *Bytes32      `json:"group,omitempty"`
-	Topics    FFStringArray `json:"topics,omitempty"`
-	Tag       string        `json:"tag,omitempty"`
-	DataHash  *Bytes32      `json:"datahash,omitempty"`
+	Created      *FFTime         `json:"created,omitempty"`
+	Namespace    string          `json:"namespace,omitempty"`
+	Group        *Bytes32        `json:"group,omitempty"`
+	Topics       FFStringArray   `json:"topics,omitempty"`
+	Tag          string          `json:"tag,omitempty"`
+	DataHash     *Bytes32        `json:"datahash,omitempty"`
+	GroupVersion int64           `json:"groupVersion,omitempty"`
+	Headers      JSONObject      `json:"headers,omitempty"`
+	Priority     MessagePriority `json:"priority,omitempty" ffenum:"messagepriority"`
 }
 
 // Message is the envelope by which coordinated data exchange can happen between parties in the network
 // Data is passed by reference in these messages, and a chain of hashes covering the data and the
 // details of the message, provides a verification against tampering.
 type Message struct {
-	Header    MessageHeader `json:"header"`
-	Hash      *Bytes32      `json:"hash,omitempty"`
-	BatchID   *UUID         `json:"batch,omitempty"`
-	State     MessageState  `json:"state,omitempty" ffenum:"messagestate"`
-	Confirmed *FFTime       `json:"confirmed,omitempty"`
-	Data      DataRefs      `json:"data"`
-	Pins      FFStringArray `json:"pins,omitempty"`
-	Sequence  int64         `json:"-"` // Local database sequence used internally for batch assembly
+	Header         MessageHeader  `json:"header"`
+	Hash           *Bytes32       `json:"hash,omitempty"`
+	BatchID        *UUID          `json:"batch,omitempty"`
+	State          MessageState   `json:"state,omitempty" ffenum:"messagestate"`
+	Confirmed      *FFTime        `json:"confirmed,omitempty"`
+	Expiry         *FFTime        `json:"expiry,omitempty"`         // Local only - if the message has not been sent by this time, it moves to expired
+	IdempotencyKey IdempotencyKey `json:"idempotencyKey,omitempty"` // Local only - set by the submitter to detect retries of the same submission
+	Data           DataRefs       `json:"data"`
+	Pins           FFStringArray  `json:"pins,omitempty"`
+	Sequence       int64          `json:"-"` // Local database sequence used internally for batch assembly
 }
 
 // MessageInOut allows API users to submit values in-line in the payload submitted, which
@@ -101,6 +139,15 @@ type MessageInOut struct {
 	Message
 	InlineData InlineData  `json:"data"`
 	Group      *InputGroup `json:"group,omitempty"`
+	OnBehalfOf string      `json:"onBehalfOf,omitempty"`
+}
+
+// BulkMessageResult is the outcome of a message in a bulk submission, in the same order the messages were submitted
+type BulkMessageResult struct {
+	Index  int               `json:"index"`
+	ID     *UUID             `json:"id,omitempty"`
+	Status BulkMessageStatus `json:"status" ffenum:"bulkmessagestatus"`
+	Error  string            `json:"error,omitempty"`
 }
 
 // InputGroup declares a group in-line for auotmatic resolution, without having to define a group up-front
@@ -118,10 +165,11 @@ type InlineData []*DataRefOrValue
 type DataRefOrValue struct {
 	DataRef
 
-	Validator ValidatorType `json:"validator,omitempty"`
+	Validator ValidatorType `json:"validator,omitempty" ffenum:"validatortype"`
 	Datatype  *DatatypeRef  `json:"datatype,omitempty"`
 	Value     *JSONAny      `json:"value,omitempty"`
 	Blob      *BlobRef      `json:"blob,omitempty"`
+	Group     *Bytes32      `json:"group,omitempty"`
 }
 
 // MessageRef is a lightweight data structure that can be used to refer to a message
@@ -130,6 +178,81 @@ type MessageRef struct {
 	Hash *Bytes32 `json:"hash,omitempty"`
 }
 
+// validateHeaders checks the custom headers are a small set of string values, with names that can be
+// propagated as-is by every event transport (including as HTTP headers)
+func (h *MessageHeader) validateHeaders(ctx context.Context) error {
+	if len(h.Headers) > MessageHeadersMax {
+		return i18n.NewError(ctx, i18n.MsgTooManyMessageHeaders, len(h.Headers), MessageHeadersMax)
+	}
+	for name, v := range h.Headers {
+		value, ok := v.(string)
+		if !ok || !messageHeaderNameRegex.MatchString(name) || len(value) > MessageHeaderValueMaxLen {
+			return i18n.NewError(ctx, i18n.MsgInvalidMessageHeader, name, MessageHeaderValueMaxLen)
+		}
+	}
+	return nil
+}
+
+// Validate checks the fields of the header that are supplied by the caller, before the message is sealed.
+// Topics are optional, as the default topic is assigned when the message is sealed.
+func (h *MessageHeader) Validate(ctx context.Context) error {
+	if len(h.Topics) > 0 {
+		if err := h.Topics.Validate(ctx, "header.topics", true); err != nil {
+			return err
+		}
+	}
+	if err := h.validateHeaders(ctx); err != nil {
+		return err
+	}
+	if err := h.validatePriority(ctx); err != nil {
+		return err
+	}
+	if h.Tag != "" {
+		if err := ValidateFFNameField(ctx, h.Tag, "header.tag"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *MessageHeader) validatePriority(ctx context.Context) error {
+	switch h.Priority.Lower() {
+	case "", MessagePriorityLow, MessagePriorityNormal, MessagePriorityHigh:
+		return nil
+	default:
+		return i18n.NewError(ctx, i18n.MsgInvalidMessagePriority, h.Priority)
+	}
+}
+
+// PriorityWeight returns a relative weight for the priority of the message, where higher
+// weights are dispatched first. Messages with no (or an unknown) priority are normal.
+func (h *MessageHeader) PriorityWeight() int {
+	switch h.Priority.Lower() {
+	case MessagePriorityLow:
+		return 0
+	case MessagePriorityHigh:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// SharesTopic returns true if the two messages are in the same namespace, and have a topic in common,
+// meaning the order between them must be preserved
+func (h *MessageHeader) SharesTopic(h2 *MessageHeader) bool {
+	if h.Namespace != h2.Namespace {
+		return false
+	}
+	for _, t1 := range h.Topics {
+		for _, t2 := range h2.Topics {
+			if t1 == t2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (h *MessageHeader) Hash() *Bytes32 {
 	b, _ := json.Marshal(&h)
 	var b32 Bytes32 = sha256.Sum256(b)
@@ -170,14 +293,9 @@ func (m *Message) Seal(ctx context.Context) (err error) {
 	if len(m.Header.Topics) == 0 {
 		m.Header.Topics = []string{DefaultTopic}
 	}
-	if err := m.Header.Topics.Validate(ctx, "header.topics", true); err != nil {
+	if err := m.Header.Validate(ctx); err != nil {
 		return err
 	}
-	if m.Header.Tag != "" {
-		if err := ValidateFFNameField(ctx, m.Header.Tag, "header.tag"); err != nil {
-			return err
-		}
-	}
 	if m.Header.ID == nil {
 		m.Header.ID = NewUUID()
 	}
@@ -221,6 +339,9 @@ func (m *Message) Verify(ctx context.Context) error {
 	if err := m.Header.Topics.Validate(ctx, "header.topics", true); err != nil {
 		return err
 	}
+	if err := m.Header.validateHeaders(ctx); err != nil {
+		return err
+	}
 	if m.Header.Tag != "" {
 		if err := ValidateFFNameField(ctx, m.Header.Tag, "header.tag"); err != nil {
 			return err
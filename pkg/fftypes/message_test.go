@@ -20,6 +20,8 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,6 +67,18 @@ func TestSealBadTagString(t *testing.T) {
 	assert.Regexp(t, `FF10131.*header.tag`, err)
 }
 
+func TestValidateHeader(t *testing.T) {
+	header := MessageHeader{}
+	assert.NoError(t, header.Validate(context.Background()))
+
+	header.Topics = []string{"topic1"}
+	header.Tag = "tag1"
+	assert.NoError(t, header.Validate(context.Background()))
+
+	header.Topics = []string{"!wrong"}
+	assert.Regexp(t, `FF10131.*header.topics\[0\]`, header.Validate(context.Background()))
+}
+
 func TestVerifyTXType(t *testing.T) {
 	msg := Message{
 		Header: MessageHeader{
@@ -112,6 +126,86 @@ func TestVerifyBadTagString(t *testing.T) {
 	assert.Regexp(t, `FF10131.*header.tag`, err)
 }
 
+func TestSealHeaders(t *testing.T) {
+	msg := Message{
+		Header: MessageHeader{
+			Headers: JSONObject{"x-route": "east", "Priority": "1"},
+		},
+	}
+	err := msg.Seal(context.Background())
+	assert.NoError(t, err)
+	hash := msg.Hash
+
+	// The headers are covered by the hash of the message
+	msg.Header.Headers["x-route"] = "west"
+	assert.NotEqual(t, hash, msg.Header.Hash())
+}
+
+func TestSealBadHeaders(t *testing.T) {
+	msg := Message{
+		Header: MessageHeader{
+			Headers: JSONObject{"bad header": "value"},
+		},
+	}
+	err := msg.Seal(context.Background())
+	assert.Regexp(t, `FF10435.*bad header`, err)
+
+	msg.Header.Headers = JSONObject{"x-count": 1}
+	err = msg.Seal(context.Background())
+	assert.Regexp(t, `FF10435.*x-count`, err)
+
+	msg.Header.Headers = JSONObject{"x-long": strings.Repeat("a", MessageHeaderValueMaxLen+1)}
+	err = msg.Seal(context.Background())
+	assert.Regexp(t, `FF10435.*x-long`, err)
+
+	msg.Header.Headers = JSONObject{}
+	for i := 0; i <= MessageHeadersMax; i++ {
+		msg.Header.Headers[fmt.Sprintf("x-%d", i)] = "value"
+	}
+	err = msg.Seal(context.Background())
+	assert.Regexp(t, `FF10434`, err)
+}
+
+func TestSealPriority(t *testing.T) {
+	msg := Message{
+		Header: MessageHeader{
+			Priority: "HIGH",
+		},
+	}
+	err := msg.Seal(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, msg.Header.PriorityWeight())
+
+	msg.Header.Priority = "urgent"
+	err = msg.Seal(context.Background())
+	assert.Regexp(t, `FF10440.*urgent`, err)
+}
+
+func TestPriorityWeight(t *testing.T) {
+	assert.Equal(t, 0, (&MessageHeader{Priority: MessagePriorityLow}).PriorityWeight())
+	assert.Equal(t, 1, (&MessageHeader{}).PriorityWeight())
+	assert.Equal(t, 1, (&MessageHeader{Priority: MessagePriorityNormal}).PriorityWeight())
+	assert.Equal(t, 2, (&MessageHeader{Priority: MessagePriorityHigh}).PriorityWeight())
+}
+
+func TestSharesTopic(t *testing.T) {
+	h1 := &MessageHeader{Namespace: "ns1", Topics: FFStringArray{"t1", "t2"}}
+	assert.True(t, h1.SharesTopic(&MessageHeader{Namespace: "ns1", Topics: FFStringArray{"t3", "t2"}}))
+	assert.False(t, h1.SharesTopic(&MessageHeader{Namespace: "ns1", Topics: FFStringArray{"t3"}}))
+	assert.False(t, h1.SharesTopic(&MessageHeader{Namespace: "ns2", Topics: FFStringArray{"t1"}}))
+}
+
+func TestVerifyBadHeaders(t *testing.T) {
+	msg := Message{
+		Header: MessageHeader{
+			TxType:  TransactionTypeBatchPin,
+			Headers: JSONObject{"-leading": "value"},
+		},
+	}
+	err := msg.Verify(context.Background())
+	assert.Regexp(t, `FF10435`, err)
+}
+
 func TestSealNilDataID(t *testing.T) {
 	msg := Message{
 		Header: MessageHeader{
@@ -44,6 +44,23 @@ type Namespace struct {
 	Description string        `json:"description"`
 	Type        NamespaceType `json:"type" ffenum:"namespacetype"`
 	Created     *FFTime       `json:"created"`
+	Retired     *FFTime       `json:"retired,omitempty"`
+}
+
+// NamespaceUsage is the activity of a namespace on this node, since the node started.
+// MessageBytes is the estimated size of submitted messages including their inline data,
+// which approximates the storage consumed by the namespace.
+type NamespaceUsage struct {
+	Namespace          string  `json:"namespace"`
+	Since              *FFTime `json:"since"`
+	MessagesSubmitted  int64   `json:"messagesSubmitted"`
+	MessagesConfirmed  int64   `json:"messagesConfirmed"`
+	MessagesRejected   int64   `json:"messagesRejected"`
+	MessageBytes       int64   `json:"messageBytes"`
+	TransfersSubmitted int64   `json:"transfersSubmitted"`
+	TransfersConfirmed int64   `json:"transfersConfirmed"`
+	BatchPins          int64   `json:"batchPins"`
+	EventsDispatched   int64   `json:"eventsDispatched"`
 }
 
 func (ns *Namespace) Validate(ctx context.Context, existing bool) (err error) {
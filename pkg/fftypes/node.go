@@ -18,25 +18,64 @@ package fftypes
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
 
 	"github.com/hyperledger/firefly/internal/i18n"
 )
 
 // Node is a FireFly node within the network
 type Node struct {
-	ID          *UUID   `json:"id"`
-	Message     *UUID   `json:"message,omitempty"`
-	Owner       string  `json:"owner,omitempty"`
-	Name        string  `json:"name,omitempty"`
-	Description string  `json:"description,omitempty"`
-	DX          DXInfo  `json:"dx"`
-	Created     *FFTime `json:"created,omitempty"`
+	ID            *UUID   `json:"id"`
+	Message       *UUID   `json:"message,omitempty"`
+	Owner         string  `json:"owner,omitempty"`
+	Name          string  `json:"name,omitempty"`
+	Description   string  `json:"description,omitempty"`
+	DX            DXInfo  `json:"dx"`
+	Created       *FFTime `json:"created,omitempty"`
+	PublicKey     string  `json:"publicKey,omitempty"`
+	EncryptionKey string  `json:"encryptionKey,omitempty"`
+	PrivacyKey    string  `json:"privacyKey,omitempty"`
 }
 
 // DXInfo is the data exchange information
 type DXInfo struct {
 	Peer     string     `json:"peer,omitempty"`
 	Endpoint JSONObject `json:"endpoint,omitempty"`
+	CertPin  string     `json:"certPin,omitempty"`
+}
+
+// CertFingerprint returns the hex encoded SHA-256 fingerprint of the PEM encoded TLS certificate
+// published in the endpoint, or an empty string if the endpoint does not publish a certificate
+func (dx *DXInfo) CertFingerprint(ctx context.Context) (string, error) {
+	certPEM := dx.Endpoint.GetString("cert")
+	if certPEM == "" {
+		return "", nil
+	}
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", i18n.NewError(ctx, i18n.MsgDXCertInvalid, dx.Peer)
+	}
+	hash := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// VerifyCertPin checks the certificate published in the endpoint matches the pinned fingerprint.
+// Endpoints with no pin are not checked
+func (dx *DXInfo) VerifyCertPin(ctx context.Context) error {
+	if dx.CertPin == "" {
+		return nil
+	}
+	fingerprint, err := dx.CertFingerprint(ctx)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(fingerprint, dx.CertPin) {
+		return i18n.NewError(ctx, i18n.MsgDXCertPinMismatch, dx.Peer, dx.CertPin, fingerprint)
+	}
+	return nil
 }
 
 func (n *Node) Validate(ctx context.Context, existing bool) (err error) {
@@ -18,9 +18,12 @@ package fftypes
 
 // NodeStatus is a set of information that represents the health, and identity of a node
 type NodeStatus struct {
-	Node     NodeStatusNode     `json:"node"`
-	Org      NodeStatusOrg      `json:"org"`
-	Defaults NodeStatusDefaults `json:"defaults"`
+	Node         NodeStatusNode         `json:"node"`
+	Org          NodeStatusOrg          `json:"org"`
+	Defaults     NodeStatusDefaults     `json:"defaults"`
+	Startup      NodeStatusStartup      `json:"startup"`
+	Connectors   []*NodeStatusConnector `json:"connectors"`
+	LoadShedding NodeStatusLoadShedding `json:"loadShedding"`
 }
 
 // NodeStatusNode is the information about the local node, returned in the node status
@@ -28,6 +31,7 @@ type NodeStatusNode struct {
 	Name       string `json:"name"`
 	Registered bool   `json:"registered"`
 	ID         *UUID  `json:"id,omitempty"`
+	ReadOnly   bool   `json:"readOnly"`
 }
 
 // NodeStatusOrg is the information about the node owning org, returned in the node status
@@ -42,3 +46,78 @@ type NodeStatusOrg struct {
 type NodeStatusDefaults struct {
 	Namespace string `json:"namespace"`
 }
+
+// NodeStatusStartup is the startup state of the plugins that connect to external runtimes. The node is
+// ready once all plugins are started, which might happen in the background after the API is available.
+type NodeStatusStartup struct {
+	Ready   bool                       `json:"ready"`
+	Plugins []*NodeStatusPluginStartup `json:"plugins"`
+}
+
+// PluginStartupState is the startup state of an individual plugin
+type PluginStartupState = FFEnum
+
+var (
+	// PluginStartupStateStarting is a plugin that has not yet started successfully
+	PluginStartupStateStarting PluginStartupState = ffEnum("pluginstartupstate", "starting")
+	// PluginStartupStateStarted is a plugin that has started, and is ready for use
+	PluginStartupStateStarted PluginStartupState = ffEnum("pluginstartupstate", "started")
+)
+
+// NodeStatusPluginStartup is the startup state of an individual plugin, including the last error if it is still starting
+type NodeStatusPluginStartup struct {
+	Type  string             `json:"type"`
+	Name  string             `json:"name"`
+	State PluginStartupState `json:"state" ffenum:"pluginstartupstate"`
+	Error string             `json:"error,omitempty"`
+}
+
+// ConnectorHealth is the result of the liveness probing of the connector runtime used by a plugin, such as ethconnect
+type ConnectorHealth struct {
+	Healthy     bool    `json:"healthy"`
+	Connected   bool    `json:"connected"`
+	LastChecked *FFTime `json:"lastChecked,omitempty"`
+	LastHealthy *FFTime `json:"lastHealthy,omitempty"`
+	Recoveries  int64   `json:"recoveries"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// NodeStatusConnector is the health of a blockchain connector, returned in the node status
+type NodeStatusConnector struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	ConnectorHealth
+}
+
+// NodeStatusLoadShedding is the degree to which the node is currently shedding load, due to pressure on its database.
+// The level is "none" in normal operation, and when load shedding is disabled.
+type NodeStatusLoadShedding struct {
+	Level string `json:"level"`
+}
+
+// NodeStatusPlugin is the introspection information about a plugin loaded by the node, including its
+// capabilities and its configuration (with any credentials redacted), so the wiring of a node can be verified
+type NodeStatusPlugin struct {
+	Type         string             `json:"type"`
+	Name         string             `json:"name"`
+	Plugin       string             `json:"plugin"`
+	Version      string             `json:"version"`
+	Capabilities interface{}        `json:"capabilities,omitempty"`
+	Config       JSONObject         `json:"config"`
+	State        PluginStartupState `json:"state" ffenum:"pluginstartupstate"`
+	Error        string             `json:"error,omitempty"`
+}
+
+// PluginHealth is the result of checking the connectivity of a plugin to the runtime it uses, along with the
+// times of the last successful and failed checks made since the node started
+type PluginHealth struct {
+	Type          string     `json:"type"`
+	Name          string     `json:"name"`
+	Plugin        string     `json:"plugin"`
+	Healthy       bool       `json:"healthy"`
+	LastChecked   *FFTime    `json:"lastChecked,omitempty"`
+	LastSuccess   *FFTime    `json:"lastSuccess,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorTime *FFTime    `json:"lastErrorTime,omitempty"`
+	Details       JSONObject `json:"details,omitempty"`
+}
@@ -18,6 +18,9 @@ package fftypes
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -52,3 +55,38 @@ func TestNodeValidation(t *testing.T) {
 	def.SetBroadcastMessage(NewUUID())
 	assert.NotNil(t, n.Message)
 }
+
+func TestDXInfoCertPin(t *testing.T) {
+
+	certDER := []byte("some certificate")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	hash := sha256.Sum256(certDER)
+	fingerprint := hex.EncodeToString(hash[:])
+
+	dx := &DXInfo{
+		Peer:     "peer1",
+		Endpoint: JSONObject{"cert": string(certPEM)},
+	}
+	fp, err := dx.CertFingerprint(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, fingerprint, fp)
+
+	// No pin is not checked
+	assert.NoError(t, dx.VerifyCertPin(context.Background()))
+
+	dx.CertPin = fingerprint
+	assert.NoError(t, dx.VerifyCertPin(context.Background()))
+
+	dx.CertPin = hex.EncodeToString(make([]byte, 32))
+	assert.Regexp(t, "FF10425.*peer1", dx.VerifyCertPin(context.Background()))
+
+	dx.Endpoint = JSONObject{}
+	fp, err = dx.CertFingerprint(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, fp)
+	assert.Regexp(t, "FF10425", dx.VerifyCertPin(context.Background()))
+
+	dx.Endpoint = JSONObject{"cert": "not a cert"}
+	assert.Regexp(t, "FF10424.*peer1", dx.VerifyCertPin(context.Background()))
+
+}
@@ -35,3 +35,10 @@ type Offset struct {
 
 	RowID int64 `json:"-"`
 }
+
+// AggregatorRewind is a request to rewind the aggregator checkpoint, so that all pins from the
+// specified local sequence onwards are re-aggregated. Ledger is empty for the default ledger
+type AggregatorRewind struct {
+	Sequence int64  `json:"sequence"`
+	Ledger   string `json:"ledger,omitempty"`
+}
@@ -26,12 +26,20 @@ var (
 	OpTypeBlockchainBatchPin OpType = ffEnum("optype", "blockchain_batch_pin")
 	// OpTypeBlockchainInvoke is a smart contract invoke
 	OpTypeBlockchainInvoke OpType = ffEnum("optype", "blockchain_invoke")
+	// OpTypeBlockchainRawTransaction is a pre-encoded transaction passed through to the blockchain connector
+	OpTypeBlockchainRawTransaction OpType = ffEnum("optype", "blockchain_raw_transaction")
+	// OpTypeBlockchainCatchup is a re-scan of batch pins from the genesis block
+	OpTypeBlockchainCatchup OpType = ffEnum("optype", "blockchain_catchup")
 	// OpTypePublicStorageBatchBroadcast is a public storage operation to store broadcast data
 	OpTypePublicStorageBatchBroadcast OpType = ffEnum("optype", "publicstorage_batch_broadcast")
+	// OpTypePublicStorageBatchPin is a request to a remote pinning service to retain broadcast data
+	OpTypePublicStorageBatchPin OpType = ffEnum("optype", "publicstorage_batch_pin")
 	// OpTypeDataExchangeBatchSend is a private send
 	OpTypeDataExchangeBatchSend OpType = ffEnum("optype", "dataexchange_batch_send")
 	// OpTypeDataExchangeBlobSend is a private send
 	OpTypeDataExchangeBlobSend OpType = ffEnum("optype", "dataexchange_blob_send")
+	// OpTypeDataExchangeCatchup is a request to another member for the history of broadcast batches
+	OpTypeDataExchangeCatchup OpType = ffEnum("optype", "dataexchange_catchup")
 	// OpTypeTokenCreatePool is a token pool creation
 	OpTypeTokenCreatePool OpType = ffEnum("optype", "token_create_pool")
 	// OpTypeTokenActivatePool is a token pool activation
@@ -75,15 +83,17 @@ func NewOperation(plugin Named, namespace string, tx *UUID, opType OpType) *Oper
 
 // Operation is a description of an action performed as part of a transaction submitted by this node
 type Operation struct {
-	ID          *UUID      `json:"id"`
-	Namespace   string     `json:"namespace"`
-	Transaction *UUID      `json:"tx"`
-	Type        OpType     `json:"type" ffenum:"optype"`
-	Status      OpStatus   `json:"status"`
-	Error       string     `json:"error,omitempty"`
-	Plugin      string     `json:"plugin"`
-	Input       JSONObject `json:"input,omitempty"`
-	Output      JSONObject `json:"output,omitempty"`
-	Created     *FFTime    `json:"created,omitempty"`
-	Updated     *FFTime    `json:"updated,omitempty"`
+	ID          *UUID               `json:"id"`
+	Namespace   string              `json:"namespace"`
+	Transaction *UUID               `json:"tx"`
+	Type        OpType              `json:"type" ffenum:"optype"`
+	Status      OpStatus            `json:"status"`
+	Error       string              `json:"error,omitempty"`
+	Plugin      string              `json:"plugin"`
+	Input       JSONObject          `json:"input,omitempty"`
+	Output      JSONObject          `json:"output,omitempty"`
+	Created     *FFTime             `json:"created,omitempty"`
+	Updated     *FFTime             `json:"updated,omitempty"`
+	Retry       *UUID               `json:"retry,omitempty"`
+	Receipt     *TransactionReceipt `json:"receipt,omitempty"`
 }
@@ -24,6 +24,8 @@ import (
 )
 
 const (
+	DIDPrefix           = "did:"
+	FireflyDIDPrefix    = "did:firefly:"
 	FireflyOrgDIDPrefix = "did:firefly:org/"
 	OrgTopic            = "ff_organizations"
 )
@@ -38,6 +40,9 @@ type Organization struct {
 	Description string     `json:"description,omitempty"`
 	Profile     JSONObject `json:"profile,omitempty"`
 	Created     *FFTime    `json:"created,omitempty"`
+
+	PreviousIdentity string  `json:"previousIdentity,omitempty"`
+	IdentityRotated  *FFTime `json:"identityRotated,omitempty"`
 }
 
 func (org *Organization) Validate(ctx context.Context, existing bool) (err error) {
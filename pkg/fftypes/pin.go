@@ -44,6 +44,7 @@ type Pin struct {
 	Index      int64    `json:"index,omitempty"`
 	Dispatched bool     `json:"dispatched,omitempty"`
 	Created    *FFTime  `json:"created,omitempty"`
+	Ledger     string   `json:"ledger,omitempty"`
 }
 
 func (p *Pin) LocalSequence() int64 {
@@ -0,0 +1,36 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// QuarantinedBatch is a record of a batch that was retrieved from the network, but could not be
+// processed because it failed validation. The raw payload is retained exactly as received,
+// along with the information from the pinning transaction required to re-process it later.
+// Private batches received directly from a peer record the peer ID in place of the pinning transaction.
+type QuarantinedBatch struct {
+	ID         *UUID         `json:"id"`
+	Namespace  string        `json:"namespace"`
+	Batch      *UUID         `json:"batch,omitempty"`
+	TX         *UUID         `json:"tx,omitempty"`
+	Hash       *Bytes32      `json:"hash,omitempty"`
+	PayloadRef string        `json:"payloadRef,omitempty"`
+	Key        string        `json:"key,omitempty"`
+	Peer       string        `json:"peer,omitempty"`
+	Contexts   FFStringArray `json:"contexts,omitempty"`
+	Reason     string        `json:"reason"`
+	Payload    string        `json:"payload,omitempty"`
+	Created    *FFTime       `json:"created"`
+}
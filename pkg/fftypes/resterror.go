@@ -17,5 +17,6 @@
 package fftypes
 
 type RESTError struct {
-	Error string `json:"error"`
+	Error    string   `json:"error"`
+	Warnings []string `json:"warnings,omitempty"`
 }
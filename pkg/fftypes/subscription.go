@@ -43,11 +43,24 @@ const (
 	SubOptsFirstEventNewest SubOptsFirstEvent = "newest"
 )
 
+// SubOptsDeliveryClass assigns the subscription to a class of dispatchers, each with its own pool of resources
+type SubOptsDeliveryClass string
+
+const (
+	// SubOptsDeliveryClassRealtime is for latency sensitive consumers
+	SubOptsDeliveryClassRealtime SubOptsDeliveryClass = "realtime"
+	// SubOptsDeliveryClassStandard is the default class for subscriptions
+	SubOptsDeliveryClassStandard SubOptsDeliveryClass = "standard"
+	// SubOptsDeliveryClassBulk is for throughput oriented consumers, such as analytics, which must not impact the other classes
+	SubOptsDeliveryClassBulk SubOptsDeliveryClass = "bulk"
+)
+
 // SubscriptionCoreOptions are the core options that apply across all transports
 type SubscriptionCoreOptions struct {
-	FirstEvent *SubOptsFirstEvent `json:"firstEvent,omitempty"`
-	ReadAhead  *uint16            `json:"readAhead,omitempty"`
-	WithData   *bool              `json:"withData,omitempty"`
+	FirstEvent    *SubOptsFirstEvent    `json:"firstEvent,omitempty"`
+	ReadAhead     *uint16               `json:"readAhead,omitempty"`
+	WithData      *bool                 `json:"withData,omitempty"`
+	DeliveryClass *SubOptsDeliveryClass `json:"deliveryClass,omitempty"`
 }
 
 // SubscriptionOptions cutomize the behavior of subscriptions
@@ -92,6 +105,7 @@ func (so *SubscriptionOptions) UnmarshalJSON(b []byte) error {
 	delete(so.additionalOptions, "firstEvent")
 	delete(so.additionalOptions, "readAhead")
 	delete(so.additionalOptions, "withData")
+	delete(so.additionalOptions, "deliveryClass")
 	return nil
 }
 
@@ -108,6 +122,9 @@ func (so SubscriptionOptions) MarshalJSON() ([]byte, error) {
 	if so.ReadAhead != nil {
 		so.additionalOptions["readAhead"] = float64(*so.ReadAhead)
 	}
+	if so.DeliveryClass != nil {
+		so.additionalOptions["deliveryClass"] = *so.DeliveryClass
+	}
 	return json.Marshal(&so.additionalOptions)
 }
 
@@ -0,0 +1,76 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// SubscriptionChange is an audit record of a durable subscription definition being created, updated or deleted,
+// with the full definition before and after the change
+type SubscriptionChange struct {
+	ID           *UUID           `json:"id"`
+	Subscription *UUID           `json:"subscription"`
+	Namespace    string          `json:"namespace"`
+	Name         string          `json:"name"`
+	Type         ChangeEventType `json:"type"`
+	Before       *Subscription   `json:"before,omitempty"`
+	After        *Subscription   `json:"after,omitempty"`
+	Created      *FFTime         `json:"created"`
+}
+
+// NewSubscriptionChange builds the change record for a subscription, where before is nil on create and after is nil on delete
+func NewSubscriptionChange(changeType ChangeEventType, before, after *Subscription) *SubscriptionChange {
+	change := &SubscriptionChange{
+		ID:     NewUUID(),
+		Type:   changeType,
+		Before: before,
+		After:  after,
+	}
+	sub := after
+	if sub == nil {
+		sub = before
+	}
+	change.Subscription = sub.ID
+	change.Namespace = sub.Namespace
+	change.Name = sub.Name
+	return change
+}
+
+// Scan implements sql.Scanner
+func (s *Subscription) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(src), &s)
+	case []byte:
+		return json.Unmarshal(src, &s)
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, s)
+	}
+}
+
+// Value implements sql.Valuer
+func (s Subscription) Value() (driver.Value, error) {
+	bytes, _ := json.Marshal(&s)
+	return bytes, nil
+}
@@ -0,0 +1,64 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSubscriptionChange(t *testing.T) {
+	sub := &Subscription{
+		SubscriptionRef: SubscriptionRef{ID: NewUUID(), Namespace: "ns1", Name: "sub1"},
+	}
+	change := NewSubscriptionChange(ChangeEventTypeCreated, nil, sub)
+	assert.NotNil(t, change.ID)
+	assert.Equal(t, sub.ID, change.Subscription)
+	assert.Equal(t, "ns1", change.Namespace)
+	assert.Equal(t, "sub1", change.Name)
+
+	change = NewSubscriptionChange(ChangeEventTypeDeleted, sub, nil)
+	assert.Equal(t, sub.ID, change.Subscription)
+	assert.Nil(t, change.After)
+}
+
+func TestSubscriptionScanValue(t *testing.T) {
+	sub := &Subscription{
+		SubscriptionRef: SubscriptionRef{ID: NewUUID(), Namespace: "ns1", Name: "sub1"},
+		Transport:       "websockets",
+	}
+	val, err := sub.Value()
+	assert.NoError(t, err)
+
+	var sub1 Subscription
+	err = sub1.Scan(val)
+	assert.NoError(t, err)
+	assert.Equal(t, sub.ID, sub1.ID)
+	assert.Equal(t, "websockets", sub1.Transport)
+
+	var sub2 Subscription
+	err = sub2.Scan(string(val.([]byte)))
+	assert.NoError(t, err)
+	assert.Equal(t, "sub1", sub2.Name)
+
+	err = sub2.Scan(nil)
+	assert.NoError(t, err)
+
+	err = sub2.Scan(12345)
+	assert.Regexp(t, "FF10125", err)
+}
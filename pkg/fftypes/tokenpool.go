@@ -18,6 +18,8 @@ package fftypes
 
 import (
 	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
 )
 
 type TokenType = FFEnum
@@ -38,8 +40,19 @@ var (
 	TokenPoolStatePending TokenPoolState = ffEnum("tokenpoolstate", "pending")
 	// TokenPoolStateConfirmed is a token pool that has been confirmed on chain
 	TokenPoolStateConfirmed TokenPoolState = ffEnum("tokenpoolstate", "confirmed")
+	// TokenPoolStatePaused is a confirmed token pool, against which this node will not submit new transfers until it is resumed
+	TokenPoolStatePaused TokenPoolState = ffEnum("tokenpoolstate", "paused")
+	// TokenPoolStateArchived is a confirmed token pool that has been retired, and against which this node will submit no further transfers or approvals
+	TokenPoolStateArchived TokenPoolState = ffEnum("tokenpoolstate", "archived")
 )
 
+// tokenPoolStateTransitions lists the states a pool can be moved to through the API, from each state.
+// Pending pools only move to confirmed, when activation is confirmed by the token connector.
+var tokenPoolStateTransitions = map[TokenPoolState][]TokenPoolState{
+	TokenPoolStateConfirmed: {TokenPoolStatePaused, TokenPoolStateArchived},
+	TokenPoolStatePaused:    {TokenPoolStateConfirmed, TokenPoolStateArchived},
+}
+
 type TokenPool struct {
 	ID         *UUID          `json:"id,omitempty"`
 	Type       TokenType      `json:"type" ffenum:"tokentype"`
@@ -72,6 +85,21 @@ func (t *TokenPool) Validate(ctx context.Context) (err error) {
 	return nil
 }
 
+// IsConfirmed returns true if the pool has been confirmed on chain, regardless of whether it has since been paused or archived
+func (t *TokenPool) IsConfirmed() bool {
+	return t.State == TokenPoolStateConfirmed || t.State == TokenPoolStatePaused || t.State == TokenPoolStateArchived
+}
+
+// ValidateStateTransition checks the pool is allowed to move from its current state to the new state
+func (t *TokenPool) ValidateStateTransition(ctx context.Context, state TokenPoolState) error {
+	for _, allowed := range tokenPoolStateTransitions[t.State] {
+		if allowed == state {
+			return nil
+		}
+	}
+	return i18n.NewError(ctx, i18n.MsgTokenPoolStateTransition, t.State, state)
+}
+
 func (t *TokenPoolAnnouncement) Topic() string {
 	return namespaceTopic(t.Pool.Namespace)
 }
@@ -58,3 +58,25 @@ func TestTokenPoolDefinition(t *testing.T) {
 	def.SetBroadcastMessage(id)
 	assert.Equal(t, id, pool.Message)
 }
+
+func TestTokenPoolStateTransitions(t *testing.T) {
+	ctx := context.Background()
+	pool := &TokenPool{State: TokenPoolStatePending}
+	assert.False(t, pool.IsConfirmed())
+	assert.Regexp(t, "FF10431", pool.ValidateStateTransition(ctx, TokenPoolStatePaused))
+
+	pool.State = TokenPoolStateConfirmed
+	assert.True(t, pool.IsConfirmed())
+	assert.NoError(t, pool.ValidateStateTransition(ctx, TokenPoolStatePaused))
+	assert.NoError(t, pool.ValidateStateTransition(ctx, TokenPoolStateArchived))
+	assert.Regexp(t, "FF10431", pool.ValidateStateTransition(ctx, TokenPoolStateConfirmed))
+
+	pool.State = TokenPoolStatePaused
+	assert.True(t, pool.IsConfirmed())
+	assert.NoError(t, pool.ValidateStateTransition(ctx, TokenPoolStateConfirmed))
+	assert.NoError(t, pool.ValidateStateTransition(ctx, TokenPoolStateArchived))
+
+	pool.State = TokenPoolStateArchived
+	assert.True(t, pool.IsConfirmed())
+	assert.Regexp(t, "FF10431", pool.ValidateStateTransition(ctx, TokenPoolStateConfirmed))
+}
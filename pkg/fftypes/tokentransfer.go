@@ -39,6 +39,7 @@ type TokenTransfer struct {
 	ProtocolID      string            `json:"protocolId,omitempty"`
 	Message         *UUID             `json:"message,omitempty"`
 	MessageHash     *Bytes32          `json:"messageHash,omitempty"`
+	Metadata        *UUID             `json:"metadata,omitempty"`
 	Created         *FFTime           `json:"created,omitempty"`
 	TX              TransactionRef    `json:"tx"`
 	BlockchainEvent *UUID             `json:"blockchainEvent,omitempty"`
@@ -46,6 +47,8 @@ type TokenTransfer struct {
 
 type TokenTransferInput struct {
 	TokenTransfer
-	Message *MessageInOut `json:"message,omitempty"`
-	Pool    string        `json:"pool,omitempty"`
+	Message        *MessageInOut   `json:"message,omitempty"`
+	Pool           string          `json:"pool,omitempty"`
+	Metadata       *DataRefOrValue `json:"metadata,omitempty"`
+	IdempotencyKey IdempotencyKey  `json:"idempotencyKey,omitempty"`
 }
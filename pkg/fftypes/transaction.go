@@ -33,22 +33,27 @@ var (
 	TransactionTypeContractInvoke OpType = ffEnum("txtype", "contract_invoke")
 	// TransactionTypeTokenTransfer represents a token approval
 	TransactionTypeTokenApproval TransactionType = ffEnum("txtype", "token_approval")
+	// TransactionTypeRawTransaction is an administrative transaction submitted in the connector's native format
+	TransactionTypeRawTransaction TransactionType = ffEnum("txtype", "raw_transaction")
+	// TransactionTypeCatchup tracks the backfill of historical broadcast batches
+	TransactionTypeCatchup TransactionType = ffEnum("txtype", "catchup")
 )
 
 // TransactionRef refers to a transaction, in other types
 type TransactionRef struct {
-	Type TransactionType `json:"type"`
+	Type TransactionType `json:"type" ffenum:"txtype"`
 	ID   *UUID           `json:"id,omitempty"`
 }
 
 // Transaction is a unit of work sent or received by this node
 // It serves as a container for one or more Operations, BlockchainEvents, and other related objects
 type Transaction struct {
-	ID            *UUID           `json:"id,omitempty"`
-	Namespace     string          `json:"namespace,omitempty"`
-	Type          TransactionType `json:"type" ffenum:"txtype"`
-	Created       *FFTime         `json:"created"`
-	BlockchainIDs FFStringArray   `json:"blockchainIds,omitempty"`
+	ID             *UUID           `json:"id,omitempty"`
+	Namespace      string          `json:"namespace,omitempty"`
+	Type           TransactionType `json:"type" ffenum:"txtype"`
+	Created        *FFTime         `json:"created"`
+	IdempotencyKey IdempotencyKey  `json:"idempotencyKey,omitempty"`
+	BlockchainIDs  FFStringArray   `json:"blockchainIds,omitempty"`
 }
 
 type TransactionStatusType string
@@ -70,6 +75,7 @@ type TransactionStatusDetails struct {
 	ID        *UUID                 `json:"id,omitempty"`
 	Error     string                `json:"error,omitempty"`
 	Info      JSONObject            `json:"info,omitempty"`
+	Receipt   *TransactionReceipt   `json:"receipt,omitempty"`
 }
 
 type TransactionStatus struct {
@@ -0,0 +1,37 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// FeePolicyType is the policy a blockchain plugin uses to set the fee of the transactions it submits
+type FeePolicyType = FFEnum
+
+var (
+	// FeePolicyTypeFixed uses a fixed gas price
+	FeePolicyTypeFixed FeePolicyType = ffEnum("feepolicy", "fixed")
+	// FeePolicyTypeEIP1559 uses a fixed max fee and max priority fee per gas, for EIP-1559 transactions
+	FeePolicyTypeEIP1559 FeePolicyType = ffEnum("feepolicy", "eip1559")
+	// FeePolicyTypeEstimate queries a gas oracle for the fee to use for each transaction
+	FeePolicyTypeEstimate FeePolicyType = ffEnum("feepolicy", "estimate")
+)
+
+// TransactionFee is the fee chosen by the policy of the blockchain plugin when it submitted a transaction
+type TransactionFee struct {
+	Policy               FeePolicyType `json:"policy" ffenum:"feepolicy"`
+	GasPrice             *FFBigInt     `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *FFBigInt     `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *FFBigInt     `json:"maxPriorityFeePerGas,omitempty"`
+}
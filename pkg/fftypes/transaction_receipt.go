@@ -0,0 +1,51 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// TransactionReceipt is the outcome of a blockchain transaction submitted by this node, as reported by the connector
+type TransactionReceipt struct {
+	BlockNumber  *FFBigInt `json:"blockNumber,omitempty"`
+	GasUsed      *FFBigInt `json:"gasUsed,omitempty"`
+	RevertReason string    `json:"revertReason,omitempty"`
+}
+
+// Scan implements sql.Scanner
+func (r *TransactionReceipt) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(src), &r)
+	case []byte:
+		return json.Unmarshal(src, &r)
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, r)
+	}
+}
+
+func (r TransactionReceipt) Value() (driver.Value, error) {
+	bytes, _ := json.Marshal(&r)
+	return bytes, nil
+}
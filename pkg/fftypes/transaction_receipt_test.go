@@ -0,0 +1,62 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionReceiptScan(t *testing.T) {
+	receipt := &TransactionReceipt{}
+	err := receipt.Scan([]byte(`{"blockNumber":"12345","gasUsed":"21000","revertReason":"out of stock"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345), receipt.BlockNumber.Int().Int64())
+	assert.Equal(t, int64(21000), receipt.GasUsed.Int().Int64())
+	assert.Equal(t, "out of stock", receipt.RevertReason)
+}
+
+func TestTransactionReceiptScanNil(t *testing.T) {
+	receipt := &TransactionReceipt{}
+	err := receipt.Scan(nil)
+	assert.NoError(t, err)
+}
+
+func TestTransactionReceiptScanString(t *testing.T) {
+	receipt := &TransactionReceipt{}
+	err := receipt.Scan(`{"blockNumber":"12345"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345), receipt.BlockNumber.Int().Int64())
+}
+
+func TestTransactionReceiptScanError(t *testing.T) {
+	receipt := &TransactionReceipt{}
+	err := receipt.Scan(map[string]interface{}{"this is": "not a supported serialization of a TransactionReceipt"})
+	assert.Regexp(t, "FF10125", err)
+}
+
+func TestTransactionReceiptValue(t *testing.T) {
+	receipt := &TransactionReceipt{
+		BlockNumber:  NewFFBigInt(12345),
+		GasUsed:      NewFFBigInt(21000),
+		RevertReason: "out of stock",
+	}
+	val, err := receipt.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"blockNumber":"12345","gasUsed":"21000","revertReason":"out of stock"}`, string(val.([]byte)))
+}
@@ -25,8 +25,38 @@ var (
 
 // TransportWrapper wraps paylaods over data exchange transfers, for easy deserialization at target
 type TransportWrapper struct {
-	Group *Group `json:"group,omitempty"`
-	Batch *Batch `json:"batch,omitempty"`
+	Group           *Group               `json:"group,omitempty"`
+	Batch           *Batch               `json:"batch,omitempty"`
+	CatchupRequest  *CatchupPeerRequest  `json:"catchupRequest,omitempty"`
+	CatchupResponse *CatchupPeerResponse `json:"catchupResponse,omitempty"`
+	PrivateData     *PrivateDataTransfer `json:"privateData,omitempty"`
+	Encrypted       *EncryptedTransport  `json:"encrypted,omitempty"`
+	Sequence        int64                `json:"sequence,omitempty"`
+}
+
+// EncryptedTransport is an envelope containing a transport wrapper that is encrypted end-to-end, so that the
+// data exchange and any intermediate storage never see the plaintext. The symmetric key used to encrypt the
+// payload is wrapped to the encryption key of each recipient node.
+type EncryptedTransport struct {
+	Algorithm string            `json:"algorithm"`
+	Keys      map[string]string `json:"keys"` // wrapped symmetric keys, by the encryption key of the recipient
+	Nonce     []byte            `json:"nonce"`
+	Payload   []byte            `json:"payload"`
+}
+
+// PrivateDataTransfer carries the values of data attached to a broadcast batch that are restricted to a
+// private group. The values are omitted from the batch published to public storage, and sent only to the
+// member nodes of the group.
+type PrivateDataTransfer struct {
+	Namespace string   `json:"namespace"`
+	Batch     *UUID    `json:"batch"`
+	Group     *Bytes32 `json:"group"`
+	Data      []*Data  `json:"data"`
+}
+
+// Manifest returns the manifest of the data in the transfer, in the same form as the manifest of a batch
+func (pd *PrivateDataTransfer) Manifest() *Manifest {
+	return (&Batch{Payload: BatchPayload{Data: pd.Data}}).Manifest()
 }
 
 type TransportStatusUpdate struct {
@@ -58,3 +58,18 @@ func TestNillBatchManifest(t *testing.T) {
 	assert.Nil(t, tw.Batch.Manifest())
 
 }
+
+func TestPrivateDataManifest(t *testing.T) {
+
+	pd := &PrivateDataTransfer{
+		Data: []*Data{
+			{ID: NewUUID(), Hash: NewRandB32()},
+		},
+	}
+	tm := pd.Manifest()
+	assert.Empty(t, tm.Messages)
+	assert.Equal(t, 1, len(tm.Data))
+	assert.Equal(t, pd.Data[0].ID, tm.Data[0].ID)
+	assert.Equal(t, pd.Data[0].Hash, tm.Data[0].Hash)
+
+}
@@ -34,7 +34,7 @@ var (
 
 // WSClientActionBase is the base fields of all client actions sent on the websocket
 type WSClientActionBase struct {
-	Type WSClientPayloadType `json:"type,omitempty"`
+	Type WSClientPayloadType `json:"type,omitempty" ffenum:"wstype"`
 }
 
 // WSClientActionStartPayload starts a subscription on this socket - either an existing one, or creating an ephemeral one
@@ -70,3 +70,10 @@ type WSChangeNotification struct {
 
 	ChangeEvent *ChangeEvent `json:"change"`
 }
+
+// WSEventDelivery is an event delivered to a websocket client, which includes the data of the message
+// when the subscription has withData set
+type WSEventDelivery struct {
+	*EventDelivery
+	Data []*Data `json:"data,omitempty"`
+}
@@ -40,14 +40,9 @@ type Plugin interface {
 	// Capabilities returns capabilities - not called until after Init
 	Capabilities() *Capabilities
 
-	// INTERFACE IS TBD SINCE INTRODUCTION OF THE IDENTITY MANAGER [Im] COMPONENT
-	//
-	// There is a strong thought that a pluggable infrastructure for mapping external DID based identity
-	// solutions into FireFly is required. However, the immediate shift in Sep 2021 moved to defining
-	// a strong enough identity construct within FireFly to map from/to.
-	//
-	// See issue https://github.com/hyperledger/firefly/issues/187 to contribute to the discussion
-
+	// ResolveDID resolves a DID to its DID document, so that the verification methods it contains can be used
+	// to authenticate data signed by that identity. Returns nil if the DID cannot be resolved by this plugin.
+	ResolveDID(ctx context.Context, did string) (*DIDDocument, error)
 }
 
 // Callbacks is the interface provided to the identity plugin, to allow it to request information from firefly, or pass events.
@@ -58,3 +53,18 @@ type Callbacks interface {
 // interface implemented by the plugin, with the specified config
 type Capabilities struct {
 }
+
+// DIDDocument is the subset of a W3C DID document used by FireFly to authenticate an identity
+type DIDDocument struct {
+	ID                  string                `json:"id"`
+	VerificationMethods []*VerificationMethod `json:"verificationMethod,omitempty"`
+}
+
+// VerificationMethod is a key in a DID document. FireFly uses verification methods that declare a CAIP-10
+// blockchainAccountId (such as "eip155:1:0x...") to find the signing keys of the identity on a given blockchain
+type VerificationMethod struct {
+	ID                  string `json:"id"`
+	Type                string `json:"type"`
+	Controller          string `json:"controller"`
+	BlockchainAccountID string `json:"blockchainAccountId,omitempty"`
+}
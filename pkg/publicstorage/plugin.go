@@ -43,10 +43,27 @@ type Plugin interface {
 
 	// RetrieveData reads data back from IPFS using the payload reference format returned from PublishData
 	RetrieveData(ctx context.Context, payloadRef string) (data io.ReadCloser, err error)
+
+	// PinData requests the data is retained after publishing - such as by a remote pinning service, or by
+	// confirming the storage transaction on a permanent storage network.
+	// Only called if the Pinning capability is reported. Completion is reported asynchronously via PublicStorageOpUpdate.
+	// Also called on startup for each operation that is still pending, so must resume tracking an existing request.
+	PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) error
+
+	// Ping checks the storage can be reached, with a lightweight request that does not store or retrieve data
+	Ping(ctx context.Context) error
 }
 
 type Callbacks interface {
+	// PublicStorageOpUpdate notifies firefly of an update to this plugin's operation.
+	// Only success/failure and errorMessage (for errors) are modeled.
+	// opOutput can be used to add opaque protocol specific JSON from the plugin (pinning service request ID etc.)
+	//
+	// Error should only be returned in shutdown scenarios
+	PublicStorageOpUpdate(plugin Plugin, operationID *fftypes.UUID, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error
 }
 
 type Capabilities struct {
+	// Pinning indicates the plugin supports PinData, to confirm published data is retained
+	Pinning bool
 }
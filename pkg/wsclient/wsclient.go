@@ -53,6 +53,7 @@ type WSClient interface {
 	URL() string
 	SetURL(url string)
 	Send(ctx context.Context, message []byte) error
+	Connected() bool
 	Close()
 }
 
@@ -75,6 +76,8 @@ type wsClient struct {
 	heartbeatMux         sync.Mutex
 	activePingSent       *time.Time
 	lastPingCompleted    time.Time
+	connectedMux         sync.Mutex
+	connected            bool
 }
 
 // WSPreConnectHandler will be called before every connect/reconnect. Any error returned will prevent the websocket from connecting.
@@ -159,6 +162,19 @@ func (w *wsClient) SetURL(url string) {
 	w.url = url
 }
 
+// Connected returns true if the websocket is currently connected, or false if it is reconnecting
+func (w *wsClient) Connected() bool {
+	w.connectedMux.Lock()
+	defer w.connectedMux.Unlock()
+	return w.connected
+}
+
+func (w *wsClient) setConnected(connected bool) {
+	w.connectedMux.Lock()
+	defer w.connectedMux.Unlock()
+	w.connected = connected
+}
+
 func (w *wsClient) Send(ctx context.Context, message []byte) error {
 	// Send
 	select {
@@ -234,6 +250,7 @@ func (w *wsClient) connect(initial bool) error {
 
 		w.pongReceivedOrReset(false)
 		w.wsconn.SetPongHandler(w.pongHandler)
+		w.setConnected(true)
 		l.Infof("WS %s connected", w.url)
 		return false, nil
 	})
@@ -338,6 +355,7 @@ func (w *wsClient) receiveReconnectLoop() {
 		if err == nil {
 			// Synchronously invoke the reader, as it's important we react immediately to any error there.
 			w.readLoop()
+			w.setConnected(false)
 			close(receiverDone)
 			<-w.sendDone
 
@@ -66,6 +66,7 @@ func TestWSClientE2E(t *testing.T) {
 	wsc.SetURL(wsc.URL() + "/updated")
 	err = wsc.Connect()
 	assert.NoError(t, err)
+	assert.True(t, wsc.Connected())
 
 	// Receive the message automatically sent in afterConnect
 	message1 := <-toServer
@@ -253,6 +254,7 @@ func TestWSReconnectFail(t *testing.T) {
 	close(w.send) // will mean sender exits immediately
 
 	w.receiveReconnectLoop()
+	assert.False(t, w.Connected())
 }
 
 func TestWSSendFail(t *testing.T) {